@@ -121,4 +121,51 @@ type AuthorizationFilter struct {
 
 	OrgID *ID
 	Org   *string
+
+	// ResourceType limits authorizations to those with a permission on
+	// resources of the type. ResourceID requires it.
+	ResourceType *ResourceType
+	// ResourceID limits authorizations to those with a permission granting
+	// access to the resource: a permission on it, on every resource of its
+	// type in its organization, or on every resource of its type.
+	ResourceID *ID
+	// ResourceOrgID is the organization of ResourceID. Without it,
+	// permissions on every resource of the type in any organization match.
+	ResourceOrgID *ID
+	// Action limits authorizations to those with a permission for it.
+	Action *Action
+}
+
+// HasPermissionFilter reports whether the filter restricts authorizations by
+// their permissions.
+func (f AuthorizationFilter) HasPermissionFilter() bool {
+	return f.ResourceType != nil || f.Action != nil
+}
+
+// MatchedPermissions returns the permissions of the authorization matching
+// the permission filters of f.
+func (f AuthorizationFilter) MatchedPermissions(a *Authorization) []Permission {
+	var ps []Permission
+	for _, p := range a.Permissions {
+		if f.Action != nil && p.Action != *f.Action {
+			continue
+		}
+		if f.ResourceType != nil && p.Resource.Type != *f.ResourceType {
+			continue
+		}
+		if f.ResourceID != nil {
+			switch {
+			case p.Resource.ID != nil:
+				if *p.Resource.ID != *f.ResourceID {
+					continue
+				}
+			case p.Resource.OrgID != nil:
+				if f.ResourceOrgID != nil && *p.Resource.OrgID != *f.ResourceOrgID {
+					continue
+				}
+			}
+		}
+		ps = append(ps, p)
+	}
+	return ps
 }
@@ -15,8 +15,9 @@ var (
 
 	// ErrAuthNotFound is used when the specified auth cannot be found
 	ErrAuthNotFound = &influxdb.Error{
-		Code: influxdb.ENotFound,
-		Msg:  "authorization not found",
+		Code:  influxdb.ENotFound,
+		Msg:   "authorization not found",
+		ErrID: influxdb.ErrIDAuthorizationNotFound,
 	}
 
 	// NotUniqueIDError occurs when attempting to create an Authorization with an ID that already belongs to another one
@@ -35,8 +36,9 @@ var (
 	// ErrTokenAlreadyExistsError is used when attempting to create an authorization
 	// with a token that already exists
 	ErrTokenAlreadyExistsError = &influxdb.Error{
-		Code: influxdb.EConflict,
-		Msg:  "token already exists",
+		Code:  influxdb.EConflict,
+		Msg:   "token already exists",
+		ErrID: influxdb.ErrIDAuthorizationCreateTokenConflict,
 	}
 )
 
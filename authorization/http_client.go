@@ -47,6 +47,15 @@ func (s *AuthorizationClientService) FindAuthorizations(ctx context.Context, fil
 	if filter.Org != nil {
 		params = append(params, [2]string{"org", *filter.Org})
 	}
+	if filter.ResourceType != nil {
+		params = append(params, [2]string{"resource", string(*filter.ResourceType)})
+	}
+	if filter.ResourceID != nil {
+		params = append(params, [2]string{"resourceID", filter.ResourceID.String()})
+	}
+	if filter.Action != nil {
+		params = append(params, [2]string{"action", string(*filter.Action)})
+	}
 
 	var as authsResponse
 	err := s.Client.
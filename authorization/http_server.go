@@ -145,6 +145,10 @@ type authResponse struct {
 	Links       map[string]string    `json:"links"`
 	CreatedAt   time.Time            `json:"createdAt"`
 	UpdatedAt   time.Time            `json:"updatedAt"`
+
+	// MatchedPermissions are the permissions matching the permission
+	// filters of a list request.
+	MatchedPermissions []permissionResponse `json:"matchedPermissions,omitempty"`
 }
 
 // In the future, we would like only the service layer to look up the user and org to see if they are valid
@@ -388,6 +392,11 @@ func (h *AuthHandler) handleGetAuthorizations(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if err := h.resolveResourceOrg(ctx, &req.filter); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
 	opts := influxdb.FindOptions{}
 	as, _, err := h.authSvc.FindAuthorizations(ctx, req.filter, opts)
 
@@ -429,6 +438,12 @@ func (h *AuthHandler) handleGetAuthorizations(w http.ResponseWriter, r *http.Req
 			h.log.Info("Failed to create auth response", zap.String("handler", "getAuthorizations"))
 			continue
 		}
+		if req.filter.HasPermissionFilter() {
+			if resp.MatchedPermissions, err = h.newPermissionsResponse(ctx, req.filter.MatchedPermissions(a)); err != nil {
+				h.api.Err(w, r, err)
+				return
+			}
+		}
 		auths = append(auths, resp)
 	}
 
@@ -437,6 +452,31 @@ func (h *AuthHandler) handleGetAuthorizations(w http.ResponseWriter, r *http.Req
 	h.api.Respond(w, r, http.StatusOK, newAuthsResponse(auths))
 }
 
+// resolveResourceOrg sets the organization of the resource the filter
+// matches permissions on, for the resource types it can be looked up for, so
+// that permissions on all the resources of the type in other organizations
+// do not match.
+func (h *AuthHandler) resolveResourceOrg(ctx context.Context, f *influxdb.AuthorizationFilter) error {
+	if f.ResourceID == nil || f.ResourceOrgID != nil {
+		return nil
+	}
+
+	switch *f.ResourceType {
+	case influxdb.OrgsResourceType:
+		f.ResourceOrgID = f.ResourceID
+	case influxdb.BucketsResourceType:
+		b, err := h.tenantService.FindBucketByID(ctx, *f.ResourceID)
+		if influxdb.ErrorCode(err) == influxdb.ENotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		f.ResourceOrgID = &b.OrgID
+	}
+	return nil
+}
+
 type getAuthorizationsRequest struct {
 	filter influxdb.AuthorizationFilter
 }
@@ -483,6 +523,36 @@ func decodeGetAuthorizationsRequest(ctx context.Context, r *http.Request) (*getA
 		req.filter.ID = id
 	}
 
+	if resource := qp.Get("resource"); resource != "" {
+		rt := influxdb.ResourceType(resource)
+		if err := rt.Valid(); err != nil {
+			return nil, err
+		}
+		req.filter.ResourceType = &rt
+	}
+
+	if resourceID := qp.Get("resourceID"); resourceID != "" {
+		if req.filter.ResourceType == nil {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "resourceID requires resource",
+			}
+		}
+		id, err := influxdb.IDFromString(resourceID)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.ResourceID = id
+	}
+
+	if action := qp.Get("action"); action != "" {
+		a := influxdb.Action(action)
+		if err := a.Valid(); err != nil {
+			return nil, err
+		}
+		req.filter.Action = &a
+	}
+
 	return req, nil
 }
 
@@ -70,6 +70,7 @@ func (s *Service) CreateAuthorization(ctx context.Context, a *influxdb.Authoriza
 }
 
 func (s *Service) FindAuthorizationByID(ctx context.Context, id influxdb.ID) (*influxdb.Authorization, error) {
+	ctx = kv.WithCaller(ctx, "authorization.FindAuthorizationByID")
 	var a *influxdb.Authorization
 	err := s.store.View(ctx, func(tx kv.Tx) error {
 		auth, err := s.store.GetAuthorizationByID(ctx, tx, id)
@@ -90,6 +91,7 @@ func (s *Service) FindAuthorizationByID(ctx context.Context, id influxdb.ID) (*i
 
 // FindAuthorizationByToken returns a authorization by token for a particular authorization.
 func (s *Service) FindAuthorizationByToken(ctx context.Context, n string) (*influxdb.Authorization, error) {
+	ctx = kv.WithCaller(ctx, "authorization.FindAuthorizationByToken")
 	var a *influxdb.Authorization
 	err := s.store.View(ctx, func(tx kv.Tx) error {
 		auth, err := s.store.GetAuthorizationByToken(ctx, tx, n)
@@ -13,8 +13,9 @@ const MaxIDGenerationN = 100
 const ReservedIDs = 1000
 
 var (
-	authBucket = []byte("authorizationsv1")
-	authIndex  = []byte("authorizationindexv1")
+	authBucket        = []byte("authorizationsv1")
+	authIndex         = []byte("authorizationindexv1")
+	authResourceIndex = []byte("authorizationresourceindexv1")
 )
 
 type Store struct {
@@ -3,6 +3,8 @@ package authorization
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sort"
 
 	"github.com/buger/jsonparser"
 	"github.com/influxdata/influxdb/v2"
@@ -23,6 +25,127 @@ func authIndexBucket(tx kv.Tx) (kv.Bucket, error) {
 	return b, nil
 }
 
+// authResourceIndexBucket returns the index of authorizations by the
+// resources of their permissions, or nil if the store has not been migrated
+// to have one yet.
+func authResourceIndexBucket(tx kv.Tx) (kv.Bucket, error) {
+	b, err := tx.Bucket(authResourceIndex)
+	if errors.Is(err, kv.ErrBucketNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, UnexpectedAuthIndexError(err)
+	}
+
+	return b, nil
+}
+
+// authResourceIndexPrefix returns the prefix of the keys indexing the
+// authorizations with a permission on r: the resource type followed by "i"
+// and the resource ID, "o" and the organization ID for every resource of the
+// type in an organization, or nothing for every resource of the type.
+func authResourceIndexPrefix(r influxdb.Resource) ([]byte, error) {
+	key := []byte(string(r.Type) + "/")
+	var id *influxdb.ID
+	switch {
+	case r.ID != nil:
+		key, id = append(key, 'i'), r.ID
+	case r.OrgID != nil:
+		key, id = append(key, 'o'), r.OrgID
+	}
+	if id != nil {
+		encodedID, err := id.Encode()
+		if err != nil {
+			return nil, err
+		}
+		key = append(key, encodedID...)
+	}
+	return append(key, '/'), nil
+}
+
+// putAuthResourceIndex indexes the authorization under the resources of its
+// permissions.
+func putAuthResourceIndex(tx kv.Tx, a *influxdb.Authorization, encodedID []byte) error {
+	idx, err := authResourceIndexBucket(tx)
+	if err != nil || idx == nil {
+		return err
+	}
+	for _, p := range a.Permissions {
+		prefix, err := authResourceIndexPrefix(p.Resource)
+		if err != nil {
+			return ErrInvalidAuthIDError(err)
+		}
+		if err := idx.Put(append(prefix, encodedID...), encodedID); err != nil {
+			return ErrInternalServiceError(err)
+		}
+	}
+	return nil
+}
+
+// deleteAuthResourceIndex removes the authorization from the resource index.
+func deleteAuthResourceIndex(tx kv.Tx, a *influxdb.Authorization, encodedID []byte) error {
+	idx, err := authResourceIndexBucket(tx)
+	if err != nil || idx == nil {
+		return err
+	}
+	for _, p := range a.Permissions {
+		prefix, err := authResourceIndexPrefix(p.Resource)
+		if err != nil {
+			return ErrInvalidAuthIDError(err)
+		}
+		if err := idx.Delete(append(prefix, encodedID...)); err != nil {
+			return ErrInternalServiceError(err)
+		}
+	}
+	return nil
+}
+
+// authIDsByResource returns, in ID order, the IDs of the authorizations
+// indexed under the resources the permission filters of f may match.
+func authIDsByResource(ctx context.Context, idx kv.Bucket, f influxdb.AuthorizationFilter) ([]influxdb.ID, error) {
+	typ := string(*f.ResourceType)
+	prefixes := [][]byte{[]byte(typ + "/")}
+	if f.ResourceID != nil {
+		byID, err := authResourceIndexPrefix(influxdb.Resource{Type: *f.ResourceType, ID: f.ResourceID})
+		if err != nil {
+			return nil, err
+		}
+		byOrg := []byte(typ + "/o")
+		if f.ResourceOrgID != nil {
+			if byOrg, err = authResourceIndexPrefix(influxdb.Resource{Type: *f.ResourceType, OrgID: f.ResourceOrgID}); err != nil {
+				return nil, err
+			}
+		}
+		prefixes = [][]byte{byID, byOrg, []byte(typ + "//")}
+	}
+
+	seen := map[influxdb.ID]bool{}
+	var ids []influxdb.ID
+	for _, prefix := range prefixes {
+		cur, err := idx.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+		if err != nil {
+			return nil, err
+		}
+		err = kv.WalkCursor(ctx, cur, func(_, v []byte) (bool, error) {
+			var id influxdb.ID
+			if err := id.Decode(v); err != nil {
+				return false, err
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
 func encodeAuthorization(a *influxdb.Authorization) ([]byte, error) {
 	switch a.Status {
 	case influxdb.Active, influxdb.Inactive:
@@ -95,6 +218,10 @@ func (s *Store) CreateAuthorization(ctx context.Context, tx kv.Tx, a *influxdb.A
 		}
 	}
 
+	if err := putAuthResourceIndex(tx, a, encodedID); err != nil {
+		return err
+	}
+
 	b, err := tx.Bucket(authBucket)
 	if err != nil {
 		return err
@@ -173,7 +300,39 @@ func (s *Store) ListAuthorizations(ctx context.Context, tx kv.Tx, f influxdb.Aut
 	var as []*influxdb.Authorization
 	pred := authorizationsPredicateFn(f)
 	filterFn := filterAuthorizationsFn(f)
-	err := s.forEachAuthorization(ctx, tx, pred, func(a *influxdb.Authorization) bool {
+	if f.HasPermissionFilter() {
+		byOwner := filterFn
+		filterFn = func(a *influxdb.Authorization) bool {
+			return byOwner(a) && len(f.MatchedPermissions(a)) > 0
+		}
+	}
+
+	// Look up the authorizations with permissions on a resource type in the
+	// resource index rather than scanning them all.
+	idx, err := authResourceIndexBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+	if idx != nil && f.ResourceType != nil && f.ID == nil && f.Token == nil {
+		ids, err := authIDsByResource(ctx, idx, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			a, err := s.GetAuthorizationByID(ctx, tx, id)
+			if err == ErrAuthNotFound {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			if filterFn(a) {
+				as = append(as, a)
+			}
+		}
+		return as, nil
+	}
+
+	err = s.forEachAuthorization(ctx, tx, pred, func(a *influxdb.Authorization) bool {
 		if filterFn(a) {
 			as = append(as, a)
 		}
@@ -291,6 +450,10 @@ func (s *Store) DeleteAuthorization(ctx context.Context, tx kv.Tx, id influxdb.I
 		return ErrInternalServiceError(err)
 	}
 
+	if err := deleteAuthResourceIndex(tx, a, encodedID); err != nil {
+		return err
+	}
+
 	if err := b.Delete(encodedID); err != nil {
 		return ErrInternalServiceError(err)
 	}
@@ -222,3 +222,114 @@ func TestAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestAuth_ListByResource(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.NewKVStore()
+	if err := all.Up(ctx, zaptest.NewLogger(t), store); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := authorization.NewStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		orgID      = influxdb.ID(100)
+		otherOrgID = influxdb.ID(200)
+		bucketID   = influxdb.ID(300)
+		otherID    = influxdb.ID(400)
+	)
+	perm := func(action influxdb.Action, orgID, id *influxdb.ID) influxdb.Permission {
+		return influxdb.Permission{Action: action, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: orgID, ID: id}}
+	}
+	auths := []*influxdb.Authorization{
+		// write to the bucket
+		{ID: 1, Permissions: []influxdb.Permission{perm(influxdb.ReadAction, &orgID, &bucketID), perm(influxdb.WriteAction, &orgID, &bucketID)}},
+		// write to every bucket of its org
+		{ID: 2, Permissions: []influxdb.Permission{perm(influxdb.WriteAction, &orgID, nil)}},
+		// write to every bucket of another org
+		{ID: 3, Permissions: []influxdb.Permission{perm(influxdb.WriteAction, &otherOrgID, nil)}},
+		// write to every bucket
+		{ID: 4, Permissions: []influxdb.Permission{perm(influxdb.WriteAction, nil, nil)}},
+		// read the bucket
+		{ID: 5, Permissions: []influxdb.Permission{perm(influxdb.ReadAction, &orgID, &bucketID)}},
+		// write to another bucket
+		{ID: 6, Permissions: []influxdb.Permission{perm(influxdb.WriteAction, &orgID, &otherID)}},
+		// write to the bucket, deleted below
+		{ID: 7, Permissions: []influxdb.Permission{perm(influxdb.WriteAction, &orgID, &bucketID)}},
+		// no bucket permissions
+		{ID: 8, Permissions: []influxdb.Permission{{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &orgID}}}},
+	}
+	err = ts.Update(ctx, func(tx kv.Tx) error {
+		for i, a := range auths {
+			a.OrgID, a.UserID, a.Token = orgID, 1, fmt.Sprintf("token%d", i)
+			if err := ts.CreateAuthorization(ctx, tx, a); err != nil {
+				return err
+			}
+		}
+		return ts.DeleteAuthorization(ctx, tx, 7)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buckets, write := influxdb.BucketsResourceType, influxdb.WriteAction
+	tests := []struct {
+		name   string
+		filter influxdb.AuthorizationFilter
+		want   []influxdb.ID
+	}{
+		{
+			name:   "resource type",
+			filter: influxdb.AuthorizationFilter{ResourceType: &buckets},
+			want:   []influxdb.ID{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:   "write to resource in known org",
+			filter: influxdb.AuthorizationFilter{ResourceType: &buckets, ResourceID: &bucketID, ResourceOrgID: &orgID, Action: &write},
+			want:   []influxdb.ID{1, 2, 4},
+		},
+		{
+			name:   "write to resource in unknown org",
+			filter: influxdb.AuthorizationFilter{ResourceType: &buckets, ResourceID: &bucketID, Action: &write},
+			want:   []influxdb.ID{1, 2, 3, 4},
+		},
+		{
+			name:   "any access to resource",
+			filter: influxdb.AuthorizationFilter{ResourceType: &buckets, ResourceID: &bucketID, ResourceOrgID: &orgID},
+			want:   []influxdb.ID{1, 2, 4, 5},
+		},
+		{
+			name:   "action only",
+			filter: influxdb.AuthorizationFilter{Action: &write},
+			want:   []influxdb.ID{1, 2, 3, 4, 6, 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ts.View(ctx, func(tx kv.Tx) error {
+				got, err := ts.ListAuthorizations(ctx, tx, tt.filter)
+				if err != nil {
+					return err
+				}
+				var ids []influxdb.ID
+				for _, a := range got {
+					ids = append(ids, a.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.want) {
+					t.Errorf("expected authorizations %v, got %v", tt.want, ids)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	matched := influxdb.AuthorizationFilter{ResourceType: &buckets, ResourceID: &bucketID, Action: &write}.MatchedPermissions(auths[0])
+	if !reflect.DeepEqual(matched, auths[0].Permissions[1:]) {
+		t.Errorf("expected the write permission to match, got %v", matched)
+	}
+}
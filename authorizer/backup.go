@@ -43,3 +43,23 @@ func (b BackupService) BackupShard(ctx context.Context, w io.Writer, shardID uin
 	}
 	return b.s.BackupShard(ctx, w, shardID, since)
 }
+
+func (b BackupService) BackupShardMeasurements(ctx context.Context, w io.Writer, shardID uint64, since time.Time, measurements []string) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return b.s.BackupShardMeasurements(ctx, w, shardID, since, measurements)
+}
+
+func (b BackupService) ShardSizes(ctx context.Context, shardIDs []uint64) ([]influxdb.ShardSize, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return b.s.ShardSizes(ctx, shardIDs)
+}
@@ -101,6 +101,12 @@ func (s *BucketService) UpdateBucket(ctx context.Context, id influxdb.ID, upd in
 	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, id, b.OrgID); err != nil {
 		return nil, err
 	}
+	// Only org owners may change the delete protection of a bucket.
+	if upd.ChangesDeleteProtection() {
+		if _, _, err := AuthorizeWriteOrg(ctx, b.OrgID); err != nil {
+			return nil, err
+		}
+	}
 	return s.s.UpdateBucket(ctx, id, upd)
 }
 
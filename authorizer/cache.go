@@ -0,0 +1,47 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.CacheService = (*CacheService)(nil)
+
+// CacheService wraps a influxdb.CacheService and authorizes actions
+// against it appropriately.
+type CacheService struct {
+	s influxdb.CacheService
+}
+
+// NewCacheService constructs an instance of an authorizing cache service.
+func NewCacheService(s influxdb.CacheService) *CacheService {
+	return &CacheService{
+		s: s,
+	}
+}
+
+// ShardCaches returns the state of the shard caches if the authorizer on
+// context has operator permissions.
+func (s *CacheService) ShardCaches(ctx context.Context) ([]influxdb.ShardCache, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.ShardCaches(ctx)
+}
+
+// SnapshotBucketCaches snapshots the caches of a bucket if the authorizer on
+// context has operator permissions.
+func (s *CacheService) SnapshotBucketCaches(ctx context.Context, bucketID influxdb.ID) ([]influxdb.ShardCache, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.SnapshotBucketCaches(ctx, bucketID)
+}
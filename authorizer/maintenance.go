@@ -0,0 +1,35 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.MaintenanceService = (*MaintenanceService)(nil)
+
+// MaintenanceService wraps a influxdb.MaintenanceService and authorizes actions
+// against it appropriately.
+type MaintenanceService struct {
+	s influxdb.MaintenanceService
+}
+
+// NewMaintenanceService constructs an instance of an authorizing maintenance service.
+func NewMaintenanceService(s influxdb.MaintenanceService) *MaintenanceService {
+	return &MaintenanceService{
+		s: s,
+	}
+}
+
+// MaintenanceServices returns the status of the background maintenance
+// services if the authorizer on context has operator permissions.
+func (s *MaintenanceService) MaintenanceServices(ctx context.Context) ([]influxdb.MaintenanceServiceStatus, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.MaintenanceServices(ctx)
+}
@@ -30,6 +30,16 @@ func (s *OrgService) FindOrganizationByID(ctx context.Context, id influxdb.ID) (
 	return s.s.FindOrganizationByID(ctx, id)
 }
 
+// FindOrganizationsByIDs checks to see if the authorizer on context has read access to each of the ids provided.
+func (s *OrgService) FindOrganizationsByIDs(ctx context.Context, ids []influxdb.ID) ([]*influxdb.Organization, error) {
+	for _, id := range ids {
+		if _, _, err := AuthorizeReadOrg(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.s.FindOrganizationsByIDs(ctx, ids)
+}
+
 // FindOrganization retrieves the organization and checks to see if the authorizer on context has read access to the org.
 func (s *OrgService) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
 	o, err := s.s.FindOrganization(ctx, filter)
@@ -81,6 +91,14 @@ func (s *OrgService) UpdateOrganization(ctx context.Context, id influxdb.ID, upd
 	return s.s.UpdateOrganization(ctx, id, upd)
 }
 
+// RenameOrganizationSafely checks to see if the authorizer on context has write access to the organization provided.
+func (s *OrgService) RenameOrganizationSafely(ctx context.Context, id influxdb.ID, name string) (*influxdb.Organization, []influxdb.OrganizationReference, error) {
+	if _, _, err := AuthorizeWriteOrg(ctx, id); err != nil {
+		return nil, nil, err
+	}
+	return s.s.RenameOrganizationSafely(ctx, id, name)
+}
+
 // DeleteOrganization checks to see if the authorizer on context has write access to the organization provided.
 func (s *OrgService) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
 	if _, _, err := AuthorizeWriteOrg(ctx, id); err != nil {
@@ -0,0 +1,35 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.OrphanReportService = (*OrphanReportService)(nil)
+
+// OrphanReportService wraps a influxdb.OrphanReportService and authorizes actions
+// against it appropriately.
+type OrphanReportService struct {
+	s influxdb.OrphanReportService
+}
+
+// NewOrphanReportService constructs an instance of an authorizing orphan report service.
+func NewOrphanReportService(s influxdb.OrphanReportService) *OrphanReportService {
+	return &OrphanReportService{
+		s: s,
+	}
+}
+
+// OrphanReport returns the orphaned directories of the storage engine if the
+// authorizer on context has operator permissions.
+func (s *OrphanReportService) OrphanReport(ctx context.Context) (*influxdb.OrphanReport, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.OrphanReport(ctx)
+}
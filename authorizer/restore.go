@@ -43,6 +43,16 @@ func (b RestoreService) RestoreBucket(ctx context.Context, id influxdb.ID, dbi [
 	return b.s.RestoreBucket(ctx, id, dbi)
 }
 
+func (b RestoreService) ReplaceBucket(ctx context.Context, id influxdb.ID, dbi []byte) (shardIDMap map[uint64]uint64, err error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return b.s.ReplaceBucket(ctx, id, dbi)
+}
+
 func (b RestoreService) RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
@@ -52,3 +62,13 @@ func (b RestoreService) RestoreShard(ctx context.Context, shardID uint64, r io.R
 	}
 	return b.s.RestoreShard(ctx, shardID, r)
 }
+
+func (b RestoreService) BucketShardDigests(ctx context.Context, id influxdb.ID) ([]influxdb.ShardDigest, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return b.s.BucketShardDigests(ctx, id)
+}
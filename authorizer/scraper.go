@@ -2,6 +2,7 @@ package authorizer
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/influxdata/influxdb/v2"
 )
@@ -13,20 +14,43 @@ var _ influxdb.ScraperTargetStoreService = (*ScraperTargetStoreService)(nil)
 type ScraperTargetStoreService struct {
 	influxdb.UserResourceMappingService
 	influxdb.OrganizationService
-	s influxdb.ScraperTargetStoreService
+	s     influxdb.ScraperTargetStoreService
+	users influxdb.UserService
 }
 
 // NewScraperTargetStoreService constructs an instance of an authorizing scraper target store service.
 func NewScraperTargetStoreService(s influxdb.ScraperTargetStoreService,
 	urm influxdb.UserResourceMappingService,
 	org influxdb.OrganizationService,
+	users influxdb.UserService,
 ) *ScraperTargetStoreService {
 	return &ScraperTargetStoreService{
 		UserResourceMappingService: urm,
 		s:                          s,
+		users:                      users,
 	}
 }
 
+// authorizeOwner checks that the user who will own the target has write access
+// to its bucket, as the scraped metrics are written with their permission.
+func (s *ScraperTargetStoreService) authorizeOwner(ctx context.Context, st influxdb.ScraperTarget, userID influxdb.ID) error {
+	p, err := st.WritePermission()
+	if err != nil {
+		return err
+	}
+	ps, err := s.users.FindPermissionForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ps.Allowed(*p) {
+		return &influxdb.Error{
+			Code: influxdb.EUnauthorized,
+			Msg:  fmt.Sprintf("user %s is not authorized to %s", userID, p),
+		}
+	}
+	return nil
+}
+
 // GetTargetByID checks to see if the authorizer on context has read access to the id provided.
 func (s *ScraperTargetStoreService) GetTargetByID(ctx context.Context, id influxdb.ID) (*influxdb.ScraperTarget, error) {
 	st, err := s.s.GetTargetByID(ctx, id)
@@ -59,6 +83,9 @@ func (s *ScraperTargetStoreService) AddTarget(ctx context.Context, st *influxdb.
 	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, st.BucketID, st.OrgID); err != nil {
 		return err
 	}
+	if err := s.authorizeOwner(ctx, *st, userID); err != nil {
+		return err
+	}
 	return s.s.AddTarget(ctx, st, userID)
 }
 
@@ -71,12 +98,43 @@ func (s *ScraperTargetStoreService) UpdateTarget(ctx context.Context, upd *influ
 	if _, _, err := AuthorizeWrite(ctx, influxdb.ScraperResourceType, upd.ID, st.OrgID); err != nil {
 		return nil, err
 	}
-	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, st.BucketID, st.OrgID); err != nil {
+	// Check the bucket the target will write to after the update.
+	dest := *st
+	if upd.BucketID.Valid() {
+		dest.BucketID = upd.BucketID
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, dest.BucketID, dest.OrgID); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOwner(ctx, dest, userID); err != nil {
 		return nil, err
 	}
 	return s.s.UpdateTarget(ctx, upd, userID)
 }
 
+// PatchTarget checks to see if the authorizer on context has write access to the scraper target provided.
+func (s *ScraperTargetStoreService) PatchTarget(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+	st, err := s.s.GetTargetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.ScraperResourceType, id, st.OrgID); err != nil {
+		return nil, err
+	}
+	// Check the bucket the target will write to after the update.
+	dest := *st
+	if upd.BucketID != nil {
+		dest.BucketID = *upd.BucketID
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, dest.BucketID, dest.OrgID); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOwner(ctx, dest, userID); err != nil {
+		return nil, err
+	}
+	return s.s.PatchTarget(ctx, id, upd, userID)
+}
+
 // RemoveTarget checks to see if the authorizer on context has write access to the scraper target provided.
 func (s *ScraperTargetStoreService) RemoveTarget(ctx context.Context, id influxdb.ID) error {
 	st, err := s.s.GetTargetByID(ctx, id)
@@ -104,7 +104,7 @@ func TestScraperTargetStoreService_GetTargetByID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := authorizer.NewScraperTargetStoreService(tt.fields.ScraperTargetStoreService, mock.NewUserResourceMappingService(), mock.NewOrganizationService())
+			s := authorizer.NewScraperTargetStoreService(tt.fields.ScraperTargetStoreService, mock.NewUserResourceMappingService(), mock.NewOrganizationService(), mock.NewUserService())
 
 			ctx := context.Background()
 			ctx = influxdbcontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, []influxdb.Permission{tt.args.permission}))
@@ -229,7 +229,7 @@ func TestScraperTargetStoreService_ListTargets(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := authorizer.NewScraperTargetStoreService(tt.fields.ScraperTargetStoreService, mock.NewUserResourceMappingService(),
-				mock.NewOrganizationService())
+				mock.NewOrganizationService(), mock.NewUserService())
 
 			ctx := context.Background()
 			ctx = influxdbcontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, []influxdb.Permission{tt.args.permission}))
@@ -398,8 +398,12 @@ func TestScraperTargetStoreService_UpdateTarget(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			users := mock.NewUserService()
+			users.FindPermissionForUserFn = func(ctx context.Context, id influxdb.ID) (influxdb.PermissionSet, error) {
+				return tt.args.permissions, nil
+			}
 			s := authorizer.NewScraperTargetStoreService(tt.fields.ScraperTargetStoreService, mock.NewUserResourceMappingService(),
-				mock.NewOrganizationService())
+				mock.NewOrganizationService(), users)
 
 			ctx := context.Background()
 			ctx = influxdbcontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, tt.args.permissions))
@@ -410,6 +414,104 @@ func TestScraperTargetStoreService_UpdateTarget(t *testing.T) {
 	}
 }
 
+func TestScraperTargetStoreService_PatchTarget(t *testing.T) {
+	otherBucketID := influxdb.ID(200)
+
+	type args struct {
+		upd         influxdb.ScraperTargetUpdate
+		permissions []influxdb.Permission
+	}
+	type wants struct {
+		err error
+	}
+
+	writeScraper := influxdb.Permission{
+		Action: influxdb.WriteAction,
+		Resource: influxdb.Resource{
+			Type: influxdb.ScraperResourceType,
+			ID:   influxdbtesting.IDPtr(1),
+		},
+	}
+	writeBucket := influxdb.Permission{
+		Action: influxdb.WriteAction,
+		Resource: influxdb.Resource{
+			Type: influxdb.BucketsResourceType,
+			ID:   influxdbtesting.IDPtr(100),
+		},
+	}
+
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "authorized to patch scraper",
+			args: args{
+				permissions: []influxdb.Permission{writeScraper, writeBucket},
+			},
+		},
+		{
+			name: "unauthorized to patch scraper",
+			args: args{
+				permissions: []influxdb.Permission{writeBucket},
+			},
+			wants: wants{
+				err: &influxdb.Error{
+					Msg:  "write:orgs/000000000000000a/scrapers/0000000000000001 is unauthorized",
+					Code: influxdb.EUnauthorized,
+				},
+			},
+		},
+		{
+			name: "unauthorized to write to new bucket",
+			args: args{
+				upd:         influxdb.ScraperTargetUpdate{BucketID: &otherBucketID},
+				permissions: []influxdb.Permission{writeScraper, writeBucket},
+			},
+			wants: wants{
+				err: &influxdb.Error{
+					Msg:  "write:orgs/000000000000000a/buckets/00000000000000c8 is unauthorized",
+					Code: influxdb.EUnauthorized,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mock.ScraperTargetStoreService{
+				GetTargetByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.ScraperTarget, error) {
+					return &influxdb.ScraperTarget{
+						ID:       1,
+						OrgID:    10,
+						BucketID: 100,
+					}, nil
+				},
+				PatchTargetF: func(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+					return &influxdb.ScraperTarget{
+						ID:       1,
+						OrgID:    10,
+						BucketID: 100,
+					}, nil
+				},
+			}
+			users := mock.NewUserService()
+			users.FindPermissionForUserFn = func(ctx context.Context, id influxdb.ID) (influxdb.PermissionSet, error) {
+				return tt.args.permissions, nil
+			}
+			s := authorizer.NewScraperTargetStoreService(svc, mock.NewUserResourceMappingService(),
+				mock.NewOrganizationService(), users)
+
+			ctx := context.Background()
+			ctx = influxdbcontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, tt.args.permissions))
+
+			_, err := s.PatchTarget(ctx, 1, tt.args.upd, influxdb.ID(1))
+			influxdbtesting.ErrorsEqual(t, err, tt.wants.err)
+		})
+	}
+}
+
 func TestScraperTargetStoreService_RemoveTarget(t *testing.T) {
 	type fields struct {
 		ScraperTargetStoreService influxdb.ScraperTargetStoreService
@@ -505,7 +607,7 @@ func TestScraperTargetStoreService_RemoveTarget(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := authorizer.NewScraperTargetStoreService(tt.fields.ScraperTargetStoreService, mock.NewUserResourceMappingService(),
-				mock.NewOrganizationService())
+				mock.NewOrganizationService(), mock.NewUserService())
 
 			ctx := context.Background()
 			ctx = influxdbcontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, tt.args.permissions))
@@ -521,9 +623,10 @@ func TestScraperTargetStoreService_AddTarget(t *testing.T) {
 		ScraperTargetStoreService influxdb.ScraperTargetStoreService
 	}
 	type args struct {
-		permissions []influxdb.Permission
-		orgID       influxdb.ID
-		bucketID    influxdb.ID
+		permissions      []influxdb.Permission
+		ownerPermissions []influxdb.Permission
+		orgID            influxdb.ID
+		bucketID         influxdb.ID
 	}
 	type wants struct {
 		err error
@@ -640,12 +743,64 @@ func TestScraperTargetStoreService_AddTarget(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "owner unauthorized to write to bucket",
+			fields: fields{
+				ScraperTargetStoreService: &mock.ScraperTargetStoreService{
+					AddTargetF: func(ctx context.Context, st *influxdb.ScraperTarget, userID influxdb.ID) error {
+						return nil
+					},
+				},
+			},
+			args: args{
+				orgID:    10,
+				bucketID: 100,
+				permissions: []influxdb.Permission{
+					{
+						Action: influxdb.WriteAction,
+						Resource: influxdb.Resource{
+							Type:  influxdb.ScraperResourceType,
+							OrgID: influxdbtesting.IDPtr(10),
+						},
+					},
+					{
+						Action: influxdb.WriteAction,
+						Resource: influxdb.Resource{
+							Type: influxdb.BucketsResourceType,
+							ID:   influxdbtesting.IDPtr(100),
+						},
+					},
+				},
+				ownerPermissions: []influxdb.Permission{
+					{
+						Action: influxdb.ReadAction,
+						Resource: influxdb.Resource{
+							Type: influxdb.BucketsResourceType,
+							ID:   influxdbtesting.IDPtr(100),
+						},
+					},
+				},
+			},
+			wants: wants{
+				err: &influxdb.Error{
+					Msg:  "user 0000000000000001 is not authorized to write:orgs/000000000000000a/buckets/0000000000000064",
+					Code: influxdb.EUnauthorized,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			users := mock.NewUserService()
+			users.FindPermissionForUserFn = func(ctx context.Context, id influxdb.ID) (influxdb.PermissionSet, error) {
+				if tt.args.ownerPermissions != nil {
+					return tt.args.ownerPermissions, nil
+				}
+				return tt.args.permissions, nil
+			}
 			s := authorizer.NewScraperTargetStoreService(tt.fields.ScraperTargetStoreService, mock.NewUserResourceMappingService(),
-				mock.NewOrganizationService())
+				mock.NewOrganizationService(), users)
 
 			ctx := context.Background()
 			ctx = influxdbcontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, tt.args.permissions))
@@ -0,0 +1,47 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.SlowQueryService = (*SlowQueryService)(nil)
+
+// SlowQueryService wraps a influxdb.SlowQueryService and authorizes actions
+// against it appropriately.
+type SlowQueryService struct {
+	s influxdb.SlowQueryService
+}
+
+// NewSlowQueryService constructs an instance of an authorizing slow query service.
+func NewSlowQueryService(s influxdb.SlowQueryService) *SlowQueryService {
+	return &SlowQueryService{
+		s: s,
+	}
+}
+
+// SlowQueries returns the slowest recent queries if the authorizer on
+// context has operator permissions.
+func (s *SlowQueryService) SlowQueries(ctx context.Context) ([]influxdb.SlowQuery, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.SlowQueries(ctx)
+}
+
+// ClearSlowQueries clears the slowest recent queries if the authorizer on
+// context has operator permissions.
+func (s *SlowQueryService) ClearSlowQueries(ctx context.Context) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return s.s.ClearSlowQueries(ctx)
+}
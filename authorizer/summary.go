@@ -0,0 +1,35 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.SummaryService = (*SummaryService)(nil)
+
+// SummaryService wraps a influxdb.SummaryService and authorizes actions
+// against it appropriately.
+type SummaryService struct {
+	s influxdb.SummaryService
+}
+
+// NewSummaryService constructs an instance of an authorizing summary service.
+func NewSummaryService(s influxdb.SummaryService) *SummaryService {
+	return &SummaryService{
+		s: s,
+	}
+}
+
+// ServerSummary returns the summary of the server if the authorizer on
+// context has operator permissions.
+func (s *SummaryService) ServerSummary(ctx context.Context) (*influxdb.ServerSummary, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.s.ServerSummary(ctx)
+}
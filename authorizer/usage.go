@@ -0,0 +1,60 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.UsageReportService = (*UsageReportService)(nil)
+
+// UsageReportService wraps a influxdb.UsageReportService and authorizes actions
+// against it appropriately.
+type UsageReportService struct {
+	s influxdb.UsageReportService
+}
+
+// NewUsageReportService constructs an instance of an authorizing usage report service.
+func NewUsageReportService(s influxdb.UsageReportService) *UsageReportService {
+	return &UsageReportService{
+		s: s,
+	}
+}
+
+// UsageReport returns the usage of the buckets the authorizer on context can
+// read. The totals of each organization only include those buckets.
+func (s *UsageReportService) UsageReport(ctx context.Context, filter influxdb.UsageFilter) (*influxdb.UsageReport, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if filter.OrgID != nil {
+		if _, _, err := AuthorizeReadOrg(ctx, *filter.OrgID); err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := s.s.UsageReport(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &influxdb.UsageReport{Range: r.Range, Orgs: []influxdb.OrgUsage{}}
+	for _, o := range r.Orgs {
+		org := influxdb.OrgUsage{OrgID: o.OrgID, Org: o.Org, Buckets: []influxdb.BucketUsage{}}
+		for _, b := range o.Buckets {
+			_, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, b.BucketID, b.OrgID)
+			if err != nil && influxdb.ErrorCode(err) != influxdb.EUnauthorized {
+				return nil, err
+			}
+			if influxdb.ErrorCode(err) == influxdb.EUnauthorized {
+				continue
+			}
+			org.Add(b)
+		}
+		if len(org.Buckets) > 0 {
+			report.Orgs = append(report.Orgs, org)
+		}
+	}
+	return report, nil
+}
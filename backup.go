@@ -2,6 +2,7 @@ package influxdb
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"time"
 )
@@ -17,6 +18,20 @@ type BackupService interface {
 
 	// BackupShard downloads a backup file for a single shard.
 	BackupShard(ctx context.Context, w io.Writer, shardID uint64, since time.Time) error
+
+	// BackupShardMeasurements downloads a backup file containing only the
+	// given measurements of a single shard.
+	BackupShardMeasurements(ctx context.Context, w io.Writer, shardID uint64, since time.Time, measurements []string) error
+
+	// ShardSizes returns the size on disk of the given shards, used to
+	// estimate the size of a backup. Shards not found are omitted.
+	ShardSizes(ctx context.Context, shardIDs []uint64) ([]ShardSize, error)
+}
+
+// ShardSize is the size on disk of the TSM and WAL files of a shard.
+type ShardSize struct {
+	ShardID uint64 `json:"shardID"`
+	Size    int64  `json:"size"`
 }
 
 // RestoreService represents the data restore functions of InfluxDB.
@@ -27,8 +42,27 @@ type RestoreService interface {
 	// RestoreKVStore restores the metadata database.
 	RestoreBucket(ctx context.Context, id ID, rpiData []byte) (shardIDMap map[uint64]uint64, err error)
 
+	// ReplaceBucket replaces the shards of an existing bucket, and their data,
+	// with the shards of a backup, keeping the bucket ID.
+	ReplaceBucket(ctx context.Context, id ID, rpiData []byte) (shardIDMap map[uint64]uint64, err error)
+
 	// RestoreShard uploads a backup file for a single shard.
 	RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error
+
+	// BucketShardDigests returns digests of the shards of a bucket, used to
+	// verify a restore.
+	BucketShardDigests(ctx context.Context, id ID) ([]ShardDigest, error)
+}
+
+// ShardDigest summarizes the data of a shard from its TSM index metadata.
+// Shards are identified across servers by their shard group time range.
+type ShardDigest struct {
+	ShardID   uint64    `json:"shardID"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	SeriesN   int       `json:"seriesN"`
+	BlockN    int       `json:"blockN"`
+	Checksum  string    `json:"checksum"`
 }
 
 // Manifest lists the KV and shard file information contained in the backup.
@@ -37,8 +71,21 @@ type Manifest struct {
 	Files []ManifestEntry `json:"files"`
 
 	// These fields are only set if filtering options are set on the CLI.
-	OrganizationID string `json:"organizationID,omitempty"`
-	BucketID       string `json:"bucketID,omitempty"`
+	OrganizationID string   `json:"organizationID,omitempty"`
+	BucketID       string   `json:"bucketID,omitempty"`
+	Measurements   []string `json:"measurements,omitempty"`
+
+	// Secrets lists the keys of the secrets of each backed up organization,
+	// so that a restore can recreate those missing on the server. Secret
+	// values are never backed up.
+	Secrets []ManifestSecretEntry `json:"secrets,omitempty"`
+}
+
+// ManifestSecretEntry lists the secret keys of a backed up organization.
+type ManifestSecretEntry struct {
+	OrganizationID   string   `json:"organizationID"`
+	OrganizationName string   `json:"organizationName"`
+	Keys             []string `json:"keys"`
 }
 
 // ManifestEntry contains the data information for a backed up shard.
@@ -51,12 +98,35 @@ type ManifestEntry struct {
 	FileName         string    `json:"fileName"`
 	Size             int64     `json:"size"`
 	LastModified     time.Time `json:"lastModified"`
+	// Checksum is the hex encoded SHA-256 of the file.
+	Checksum string `json:"checksum,omitempty"`
+	// StartTime and EndTime bound the time range of the shard group of the
+	// shard, the end excluded. They are zero in manifests written by older
+	// versions.
+	StartTime time.Time `json:"startTime,omitempty"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+
+	// Measurements is set if the shard backup only contains these measurements.
+	Measurements []string `json:"measurements,omitempty"`
+}
+
+// HasTimeRange returns true if the manifest records the time range of the
+// shard.
+func (e *ManifestEntry) HasTimeRange() bool {
+	return !e.StartTime.IsZero() && !e.EndTime.IsZero()
+}
+
+// Partial returns true if the shard backup only contains some measurements.
+func (e *ManifestEntry) Partial() bool {
+	return len(e.Measurements) > 0
 }
 
 // ManifestKVEntry contains the KV store information for a backup.
 type ManifestKVEntry struct {
 	FileName string `json:"fileName"`
 	Size     int64  `json:"size"`
+	// Checksum is the hex encoded SHA-256 of the file.
+	Checksum string `json:"checksum,omitempty"`
 }
 
 // Size returns the size of the manifest.
@@ -67,3 +137,10 @@ func (m *Manifest) Size() int64 {
 	}
 	return n
 }
+
+// MarshalCanonical returns the serialization of the manifest that is signed.
+// It is compact JSON with fields in declaration order, so it only depends on
+// the content of the manifest and not on how its file was formatted.
+func (m *Manifest) MarshalCanonical() ([]byte, error) {
+	return json.Marshal(m)
+}
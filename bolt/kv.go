@@ -29,6 +29,9 @@ type KVStore struct {
 	log  *zap.Logger
 
 	noSync bool
+
+	metrics         *kvMetrics
+	slowTxThreshold time.Duration
 }
 
 type KVOption func(*KVStore)
@@ -151,6 +154,10 @@ func (s *KVStore) View(ctx context.Context, fn func(tx kv.Tx) error) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	if s.instrumented() {
+		return s.observeTx(ctx, txRead, s.DB().View, fn)
+	}
+
 	return s.DB().View(func(tx *bolt.Tx) error {
 		return fn(&Tx{
 			tx:  tx,
@@ -164,6 +171,10 @@ func (s *KVStore) Update(ctx context.Context, fn func(tx kv.Tx) error) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	if s.instrumented() {
+		return s.observeTx(ctx, txWrite, s.DB().Update, fn)
+	}
+
 	return s.DB().Update(func(tx *bolt.Tx) error {
 		return fn(&Tx{
 			tx:  tx,
@@ -245,8 +256,9 @@ func (s *KVStore) Restore(ctx context.Context, r io.Reader) error {
 
 // Tx is a light wrapper around a boltdb transaction. It implements kv.Tx.
 type Tx struct {
-	tx  *bolt.Tx
-	ctx context.Context
+	tx      *bolt.Tx
+	ctx     context.Context
+	metrics *kvMetrics
 }
 
 // Context returns the context for the transaction.
@@ -266,17 +278,30 @@ func (tx *Tx) Bucket(b []byte) (kv.Bucket, error) {
 		return nil, fmt.Errorf("bucket %q: %w", string(b), kv.ErrBucketNotFound)
 	}
 	return &Bucket{
-		bucket: bkt,
+		bucket:  bkt,
+		name:    string(b),
+		metrics: tx.metrics,
 	}, nil
 }
 
 // Bucket implements kv.Bucket.
 type Bucket struct {
 	bucket *bolt.Bucket
+
+	name    string
+	metrics *kvMetrics
+}
+
+// count records n operations of kind op on the bucket when metrics are enabled.
+func (b *Bucket) count(op string, n int) {
+	if b.metrics != nil {
+		b.metrics.bucketOp(b.name, op, n)
+	}
 }
 
 // Get retrieves the value at the provided key.
 func (b *Bucket) Get(key []byte) ([]byte, error) {
+	b.count("get", 1)
 	val := b.bucket.Get(key)
 	if len(val) == 0 {
 		return nil, kv.ErrKeyNotFound
@@ -287,6 +312,7 @@ func (b *Bucket) Get(key []byte) ([]byte, error) {
 
 // GetBatch retrieves the values for the provided keys.
 func (b *Bucket) GetBatch(keys ...[]byte) ([][]byte, error) {
+	b.count("get", len(keys))
 	values := make([][]byte, len(keys))
 	for idx, key := range keys {
 		val := b.bucket.Get(key)
@@ -302,6 +328,7 @@ func (b *Bucket) GetBatch(keys ...[]byte) ([][]byte, error) {
 
 // Put sets the value at the provided key.
 func (b *Bucket) Put(key []byte, value []byte) error {
+	b.count("put", 1)
 	err := b.bucket.Put(key, value)
 	if err == bolt.ErrTxNotWritable {
 		return kv.ErrTxNotWritable
@@ -311,6 +338,7 @@ func (b *Bucket) Put(key []byte, value []byte) error {
 
 // Delete removes the provided key.
 func (b *Bucket) Delete(key []byte) error {
+	b.count("delete", 1)
 	err := b.bucket.Delete(key)
 	if err == bolt.ErrTxNotWritable {
 		return kv.ErrTxNotWritable
@@ -321,6 +349,7 @@ func (b *Bucket) Delete(key []byte) error {
 // ForwardCursor retrieves a cursor for iterating through the entries
 // in the key value store in a given direction (ascending / descending).
 func (b *Bucket) ForwardCursor(seek []byte, opts ...kv.CursorOption) (kv.ForwardCursor, error) {
+	b.count("cursor", 1)
 	var (
 		cursor     = b.bucket.Cursor()
 		config     = kv.NewCursorConfig(opts...)
@@ -354,6 +383,7 @@ func (b *Bucket) ForwardCursor(seek []byte, opts ...kv.CursorOption) (kv.Forward
 // Cursor retrieves a cursor for iterating through the entries
 // in the key value store.
 func (b *Bucket) Cursor(opts ...kv.CursorHint) (kv.Cursor, error) {
+	b.count("cursor", 1)
 	return &Cursor{
 		cursor: b.bucket.Cursor(),
 	}, nil
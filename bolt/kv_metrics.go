@@ -0,0 +1,149 @@
+package bolt
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/prometheus/client_golang/prometheus"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+const (
+	txRead  = "read"
+	txWrite = "write"
+
+	// otherBucket labels the operations on buckets not in hotBuckets.
+	otherBucket = "other"
+)
+
+// hotBuckets are the KV buckets whose operations are counted individually.
+// Operations on any other bucket are counted together to bound the number of
+// series.
+var hotBuckets = map[string]bool{
+	"authorizationsv1":       true,
+	"bucketsv1":              true,
+	"dashboardsv2":           true,
+	"organizationsv1":        true,
+	"tasksv1":                true,
+	"userresourcemappingsv1": true,
+	"usersv1":                true,
+}
+
+// kvMetrics instruments the transactions of a KVStore.
+type kvMetrics struct {
+	txDuration *prometheus.HistogramVec
+	openTxs    *prometheus.GaugeVec
+	slowTxs    *prometheus.CounterVec
+	bucketOps  *prometheus.CounterVec
+}
+
+func newKVMetrics() *kvMetrics {
+	const namespace = "boltdb"
+	const subsystem = "kv"
+
+	return &kvMetrics{
+		txDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "tx_duration_seconds",
+			Help:      "Duration of transactions, including the wait to begin them.",
+			Buckets:   []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+		}, []string{"kind"}),
+		openTxs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "open_txs",
+			Help:      "Number of transactions begun or waiting to begin.",
+		}, []string{"kind"}),
+		slowTxs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "slow_txs_total",
+			Help:      "Number of transactions slower than the slow transaction threshold.",
+		}, []string{"kind"}),
+		bucketOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "bucket_ops_total",
+			Help:      "Number of operations on KV buckets.",
+		}, []string{"bucket", "op"}),
+	}
+}
+
+// bucketOp counts an operation on the named bucket.
+func (m *kvMetrics) bucketOp(bucket, op string, n int) {
+	if !hotBuckets[bucket] {
+		bucket = otherBucket
+	}
+	m.bucketOps.WithLabelValues(bucket, op).Add(float64(n))
+}
+
+// WithMetrics enables the Prometheus metrics of a KVStore, which are
+// returned by PrometheusCollectors.
+func WithMetrics(s *KVStore) {
+	s.metrics = newKVMetrics()
+}
+
+// WithSlowTxThreshold logs the transactions taking at least d, along with the
+// caller their context is tagged with by kv.WithCaller. Zero disables logging.
+func WithSlowTxThreshold(d time.Duration) KVOption {
+	return func(s *KVStore) {
+		s.slowTxThreshold = d
+	}
+}
+
+// PrometheusCollectors returns the collectors of the store's metrics, or
+// none if the store was not created WithMetrics.
+func (s *KVStore) PrometheusCollectors() []prometheus.Collector {
+	if s.metrics == nil {
+		return nil
+	}
+	return []prometheus.Collector{
+		s.metrics.txDuration,
+		s.metrics.openTxs,
+		s.metrics.slowTxs,
+		s.metrics.bucketOps,
+	}
+}
+
+// instrumented reports whether transactions need to be timed.
+func (s *KVStore) instrumented() bool {
+	return s.metrics != nil || s.slowTxThreshold > 0
+}
+
+// observeTx runs fn in a transaction begun by begin, recording its duration and
+// logging it when slow.
+func (s *KVStore) observeTx(ctx context.Context, kind string, begin func(func(*bolt.Tx) error) error, fn func(kv.Tx) error) error {
+	if s.metrics != nil {
+		open := s.metrics.openTxs.WithLabelValues(kind)
+		open.Inc()
+		defer open.Dec()
+	}
+
+	start := time.Now()
+	err := begin(func(tx *bolt.Tx) error {
+		return fn(&Tx{
+			tx:      tx,
+			ctx:     ctx,
+			metrics: s.metrics,
+		})
+	})
+	d := time.Since(start)
+
+	if s.metrics != nil {
+		s.metrics.txDuration.WithLabelValues(kind).Observe(d.Seconds())
+	}
+	if s.slowTxThreshold > 0 && d >= s.slowTxThreshold {
+		if s.metrics != nil {
+			s.metrics.slowTxs.WithLabelValues(kind).Inc()
+		}
+		s.log.Warn("Slow bolt transaction",
+			zap.String("kind", kind),
+			zap.String("caller", kv.CallerFromContext(ctx)),
+			zap.Duration("duration", d),
+			zap.Error(err))
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package bolt_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestKVStore_Metrics(t *testing.T) {
+	f, err := ioutil.TempFile("", "influxdata-platform-bolt-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	core, logs := observer.New(zap.WarnLevel)
+	s := bolt.NewKVStore(zap.New(core), f.Name(), bolt.WithNoSync, bolt.WithMetrics, bolt.WithSlowTxThreshold(time.Nanosecond))
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	mustCreateBucket(t, s, []byte("usersv1"))
+	mustCreateBucket(t, s, []byte("somebucket"))
+
+	ctx := kv.WithCaller(context.Background(), "test.Caller")
+	if err := s.Update(ctx, func(tx kv.Tx) error {
+		for _, name := range []string{"usersv1", "somebucket"} {
+			b, err := tx.Bucket([]byte(name))
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte("k"), []byte("v")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket([]byte("usersv1"))
+		if err != nil {
+			return err
+		}
+		_, err = b.GetBatch([]byte("k"), []byte("j"))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+	reg.MustRegister(s.PrometheusCollectors()...)
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		labels map[string]string
+		want   float64
+	}{
+		{labels: map[string]string{"bucket": "usersv1", "op": "put"}, want: 1},
+		{labels: map[string]string{"bucket": "other", "op": "put"}, want: 1},
+		{labels: map[string]string{"bucket": "usersv1", "op": "get"}, want: 2},
+	} {
+		m := promtest.MustFindMetric(t, mfs, "boltdb_kv_bucket_ops_total", tt.labels)
+		if got := m.GetCounter().GetValue(); got != tt.want {
+			t.Errorf("expected %v operations for %v, got %v", tt.want, tt.labels, got)
+		}
+	}
+
+	for _, kind := range []string{"read", "write"} {
+		m := promtest.MustFindMetric(t, mfs, "boltdb_kv_tx_duration_seconds", map[string]string{"kind": kind})
+		if m.GetHistogram().GetSampleCount() == 0 {
+			t.Errorf("expected %s transactions to be observed", kind)
+		}
+		m = promtest.MustFindMetric(t, mfs, "boltdb_kv_open_txs", map[string]string{"kind": kind})
+		if got := m.GetGauge().GetValue(); got != 0 {
+			t.Errorf("expected no open %s transactions, got %v", kind, got)
+		}
+	}
+
+	slow := logs.FilterMessage("Slow bolt transaction").FilterField(zap.String("caller", "test.Caller"))
+	if got := slow.Len(); got != 2 {
+		t.Errorf("expected 2 slow transactions logged for the caller, got %d", got)
+	}
+}
+
+func TestKVStore_MetricsDisabled(t *testing.T) {
+	s, closeFn, err := NewTestKVStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFn()
+
+	if got := s.PrometheusCollectors(); len(got) != 0 {
+		t.Errorf("expected no collectors, got %d", len(got))
+	}
+}
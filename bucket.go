@@ -36,6 +36,24 @@ type Bucket struct {
 	Description         string        `json:"description"`
 	RetentionPolicyName string        `json:"rp,omitempty"` // This to support v1 sources
 	RetentionPeriod     time.Duration `json:"retentionPeriod"`
+	// ShardGroupDuration is the time range of the shard groups of the
+	// bucket. Zero derives it from the retention period.
+	ShardGroupDuration time.Duration `json:"shardGroupDuration,omitempty"`
+	// DeleteProtected prevents the bucket, and any data within it, from
+	// being deleted until the flag is explicitly cleared.
+	DeleteProtected bool `json:"deleteProtected,omitempty"`
+	// RetentionBypassesDeleteProtection allows the retention period to
+	// expire data from a delete protected bucket.
+	RetentionBypassesDeleteProtection bool `json:"retentionBypassesDeleteProtection,omitempty"`
+	// Archived rejects writes and deletes to the bucket, and changes to its
+	// retention period, while keeping its data queryable.
+	Archived bool `json:"archived,omitempty"`
+	// NaNHandling is how writes treat float field values that are NaN or
+	// infinite.
+	NaNHandling NaNHandling `json:"nanHandling,omitempty"`
+	// LastValueMeasurements are the measurements whose most recent values
+	// are cached as they are written, to be read without a query.
+	LastValueMeasurements []string `json:"lastValueMeasurements,omitempty"`
 	CRUDLog
 }
 
@@ -45,6 +63,60 @@ func (b *Bucket) Clone() *Bucket {
 	return &other
 }
 
+// NaNHandling is the policy of a bucket for float field values that are NaN
+// or infinite. Line protocol cannot represent them, so they are written as
+// the literals NaN and Inf, or as floats too large to hold.
+type NaNHandling string
+
+const (
+	// NaNHandlingDefault fails the whole write, as for any line that cannot
+	// be parsed.
+	NaNHandlingDefault NaNHandling = ""
+	// NaNHandlingReject rejects the lines holding such values, and writes
+	// the others.
+	NaNHandlingReject NaNHandling = "reject"
+	// NaNHandlingDrop skips the fields holding such values, and counts them.
+	NaNHandlingDrop NaNHandling = "drop"
+	// NaNHandlingNull writes the points without the fields holding such
+	// values, so that queries read them as null. Storage has no null, so
+	// this stores the same as NaNHandlingDrop.
+	NaNHandlingNull NaNHandling = "null"
+)
+
+// Valid returns an error if h is not a known policy.
+func (h NaNHandling) Valid() error {
+	switch h {
+	case NaNHandlingDefault, NaNHandlingReject, NaNHandlingDrop, NaNHandlingNull:
+		return nil
+	}
+	return &Error{
+		Code: EInvalid,
+		Msg:  fmt.Sprintf("invalid nanHandling %q: must be reject, drop or null", string(h)),
+	}
+}
+
+// ValidLastValueMeasurements returns an error if the last value
+// measurements of a bucket hold an empty or duplicate name.
+func ValidLastValueMeasurements(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" {
+			return &Error{
+				Code: EInvalid,
+				Msg:  "lastValueMeasurements cannot hold an empty measurement",
+			}
+		}
+		if seen[name] {
+			return &Error{
+				Code: EInvalid,
+				Msg:  fmt.Sprintf("lastValueMeasurements holds %q more than once", name),
+			}
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
 // BucketType differentiates system buckets from user buckets.
 type BucketType int
 
@@ -105,6 +177,88 @@ type BucketUpdate struct {
 	Name            *string        `json:"name,omitempty"`
 	Description     *string        `json:"description,omitempty"`
 	RetentionPeriod *time.Duration `json:"retentionPeriod,omitempty"`
+
+	DeleteProtected                   *bool `json:"deleteProtected,omitempty"`
+	RetentionBypassesDeleteProtection *bool `json:"retentionBypassesDeleteProtection,omitempty"`
+
+	Archived *bool `json:"archived,omitempty"`
+
+	NaNHandling *NaNHandling `json:"nanHandling,omitempty"`
+
+	LastValueMeasurements *[]string `json:"lastValueMeasurements,omitempty"`
+}
+
+// ChangesDeleteProtection reports whether the update modifies the delete
+// protection settings of a bucket.
+func (u BucketUpdate) ChangesDeleteProtection() bool {
+	return u.DeleteProtected != nil || u.RetentionBypassesDeleteProtection != nil
+}
+
+// Valid returns an error if the update clears delete protection along with
+// any other change. Clearing the flag must be a separate update so that it is
+// recorded as its own operation.
+func (u BucketUpdate) Valid() error {
+	if u.NaNHandling != nil {
+		if err := u.NaNHandling.Valid(); err != nil {
+			return err
+		}
+	}
+	if u.LastValueMeasurements != nil {
+		if err := ValidLastValueMeasurements(*u.LastValueMeasurements); err != nil {
+			return err
+		}
+	}
+	if u.DeleteProtected == nil || *u.DeleteProtected {
+		return nil
+	}
+	if u.Name != nil || u.Description != nil || u.RetentionPeriod != nil || u.RetentionBypassesDeleteProtection != nil {
+		return &Error{
+			Code: EInvalid,
+			Msg:  "deleteProtected must be cleared in an update of its own",
+		}
+	}
+	return nil
+}
+
+// ValidFor returns an error if the update cannot be applied to b: the
+// retention period of a bucket cannot be changed while it is archived,
+// including by the update archiving it.
+func (u BucketUpdate) ValidFor(b *Bucket) error {
+	archived := b.Archived
+	if u.Archived != nil {
+		archived = *u.Archived
+	}
+	if archived && u.RetentionPeriod != nil {
+		return ErrBucketArchivedRetention(b)
+	}
+	return nil
+}
+
+// ErrBucketDeleteProtected is returned when attempting to delete a bucket,
+// or data within a bucket, that is delete protected.
+func ErrBucketDeleteProtected(b *Bucket) *Error {
+	return &Error{
+		Code: EForbidden,
+		Msg:  fmt.Sprintf("bucket %q is delete protected; deleteProtected must be cleared before deleting", b.Name),
+	}
+}
+
+// ErrBucketArchived is returned when attempting to write to, or delete data
+// from, an archived bucket.
+func ErrBucketArchived(b *Bucket) *Error {
+	return &Error{
+		Code: EForbidden,
+		Msg:  fmt.Sprintf("bucket %q is archived; it must be unarchived before writing or deleting data", b.Name),
+	}
+}
+
+// ErrBucketArchivedRetention is returned when attempting to change the
+// retention period of an archived bucket.
+func ErrBucketArchivedRetention(b *Bucket) *Error {
+	return &Error{
+		Code: EConflict,
+		Msg:  fmt.Sprintf("bucket %q is archived; its retention period cannot be changed until it is unarchived", b.Name),
+	}
 }
 
 // BucketFilter represents a set of filter that restrict the returned results.
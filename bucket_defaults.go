@@ -0,0 +1,87 @@
+package influxdb
+
+import (
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultRetention is the retention period of a bucket to be created with the
+// default retention period of its organization. Unlike InfiniteRetention it
+// is never stored; creating the bucket replaces it.
+const DefaultRetention time.Duration = -1
+
+// BucketDefaults are the settings an organization applies to the buckets
+// created in it when the creation request leaves them unset.
+type BucketDefaults struct {
+	// RetentionPeriod is the retention period of buckets created without
+	// one. Zero is infinite retention.
+	RetentionPeriod Duration `json:"retentionPeriod"`
+	// ShardGroupDuration is the shard group duration of buckets created
+	// without one. Zero derives it from the retention period.
+	ShardGroupDuration Duration `json:"shardGroupDuration"`
+	// DescriptionTemplate is the text/template of the description of buckets
+	// created without one. It is executed with the bucket as {{.Name}} and
+	// the organization as {{.Org}}.
+	DescriptionTemplate string `json:"descriptionTemplate,omitempty"`
+}
+
+// minDefaultRetention is the smallest finite retention period the storage
+// engine accepts.
+const minDefaultRetention = time.Hour
+
+// Valid returns an error if the defaults cannot be applied to a bucket.
+func (d BucketDefaults) Valid() error {
+	rp, sgd := d.RetentionPeriod.Duration, d.ShardGroupDuration.Duration
+	switch {
+	case rp < 0:
+		return &Error{Code: EInvalid, Msg: "default retention period must not be negative"}
+	case rp > 0 && rp < minDefaultRetention:
+		return &Error{Code: EInvalid, Msg: "default retention period must be at least an hour"}
+	case sgd < 0:
+		return &Error{Code: EInvalid, Msg: "default shard group duration must not be negative"}
+	case rp > 0 && sgd > rp:
+		return &Error{Code: EInvalid, Msg: "default shard group duration must not exceed the default retention period"}
+	}
+	if _, err := d.description("bucket", "org"); err != nil {
+		return &Error{Code: EInvalid, Msg: "invalid default description template", Err: err}
+	}
+	return nil
+}
+
+// Apply sets the settings of a user bucket that are unset to the defaults of
+// the organization named org. A retention period of DefaultRetention is
+// unset; a description is unset if empty and a shard group duration if zero.
+// The default shard group duration is not applied to a bucket whose retention
+// period is shorter.
+func (d BucketDefaults) Apply(b *Bucket, org string) error {
+	if b.RetentionPeriod == DefaultRetention {
+		b.RetentionPeriod = d.RetentionPeriod.Duration
+	}
+	if b.ShardGroupDuration == 0 && (b.RetentionPeriod == 0 || d.ShardGroupDuration.Duration <= b.RetentionPeriod) {
+		b.ShardGroupDuration = d.ShardGroupDuration.Duration
+	}
+	if b.Description != "" || d.DescriptionTemplate == "" {
+		return nil
+	}
+
+	desc, err := d.description(b.Name, org)
+	if err != nil {
+		return &Error{Code: EInvalid, Msg: "invalid default description template", Err: err}
+	}
+	b.Description = desc
+	return nil
+}
+
+// description executes the description template for a bucket.
+func (d BucketDefaults) description(bucket, org string) (string, error) {
+	tmpl, err := template.New("description").Parse(d.DescriptionTemplate)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, struct{ Name, Org string }{Name: bucket, Org: org}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
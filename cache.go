@@ -0,0 +1,32 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// ShardCache is the state of the in-memory write cache of a shard.
+type ShardCache struct {
+	BucketID ID     `json:"bucketID"`
+	ShardID  uint64 `json:"shardID"`
+	// Size is the number of bytes held by the cache, including data being
+	// snapshotted.
+	Size    uint64 `json:"size"`
+	MaxSize uint64 `json:"maxSize"`
+	// OldestEntryAge is how long ago the oldest data in the cache was
+	// written. It is zero when the cache is empty.
+	OldestEntryAge    Duration  `json:"oldestEntryAge"`
+	LastSnapshot      time.Time `json:"lastSnapshot"`
+	SinceLastSnapshot Duration  `json:"sinceLastSnapshot"`
+}
+
+// CacheService reports on the in-memory write caches of the storage engine
+// and snapshots them to disk.
+type CacheService interface {
+	// ShardCaches returns the state of the cache of every shard.
+	ShardCaches(ctx context.Context) ([]ShardCache, error)
+
+	// SnapshotBucketCaches writes the caches of the shards of a bucket to
+	// disk, returning their state afterwards.
+	SnapshotBucketCaches(ctx context.Context, bucketID ID) ([]ShardCache, error)
+}
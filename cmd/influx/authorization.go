@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 
 	platform "github.com/influxdata/influxdb/v2"
@@ -18,6 +20,10 @@ type token struct {
 	UserName    string      `json:"userName"`
 	UserID      platform.ID `json:"userID"`
 	Permissions []string    `json:"permissions"`
+
+	// MatchedPermissions are the permissions matching the resource and
+	// action a list was filtered by.
+	MatchedPermissions []string `json:"matchedPermissions,omitempty"`
 }
 
 func cmdAuth(f *globalFlags, opt genericCLIOpts) *cobra.Command {
@@ -303,9 +309,12 @@ func authorizationCreateF(cmd *cobra.Command, args []string) error {
 }
 
 var authorizationFindFlags struct {
-	org    organization
-	user   string
-	userID string
+	org        organization
+	user       string
+	userID     string
+	resource   string
+	resourceID string
+	action     string
 }
 
 func authFindCmd(f *globalFlags, opt genericCLIOpts) *cobra.Command {
@@ -323,6 +332,9 @@ func authFindCmd(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 	cmd.Flags().StringVarP(&authorizationFindFlags.userID, "user-id", "", "", "The user ID")
 
 	cmd.Flags().StringVarP(&authCRUDFlags.id, "id", "i", "", "The authorization ID")
+	cmd.Flags().StringVar(&authorizationFindFlags.resource, "resource", "", "Only list authorizations with a permission on this resource type (e.g. buckets)")
+	cmd.Flags().StringVar(&authorizationFindFlags.resourceID, "resource-id", "", "Only list authorizations with a permission granting access to this resource. Requires --resource")
+	cmd.Flags().StringVar(&authorizationFindFlags.action, "action", "", "Only list authorizations with a permission for this action (read or write)")
 
 	return cmd
 }
@@ -366,6 +378,30 @@ func authorizationFindF(cmd *cobra.Command, args []string) error {
 		}
 		filter.OrgID = oID
 	}
+	if authorizationFindFlags.resource != "" {
+		rt := platform.ResourceType(authorizationFindFlags.resource)
+		if err := rt.Valid(); err != nil {
+			return fmt.Errorf("invalid resource %q: %v", authorizationFindFlags.resource, err)
+		}
+		filter.ResourceType = &rt
+	}
+	if authorizationFindFlags.resourceID != "" {
+		if filter.ResourceType == nil {
+			return errors.New("--resource-id requires --resource")
+		}
+		rID, err := platform.IDFromString(authorizationFindFlags.resourceID)
+		if err != nil {
+			return err
+		}
+		filter.ResourceID = rID
+	}
+	if authorizationFindFlags.action != "" {
+		a := platform.Action(authorizationFindFlags.action)
+		if err := a.Valid(); err != nil {
+			return fmt.Errorf("invalid action %q: %v", authorizationFindFlags.action, err)
+		}
+		filter.Action = &a
+	}
 
 	authorizations, _, err := s.FindAuthorizations(context.Background(), filter)
 	if err != nil {
@@ -384,20 +420,29 @@ func authorizationFindF(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		// The client does not return the permissions the server matched, so
+		// they are matched again here.
+		var matched []string
+		for _, p := range filter.MatchedPermissions(a) {
+			matched = append(matched, p.String())
+		}
+
 		tokens = append(tokens, token{
-			ID:          a.ID,
-			Description: a.Description,
-			Token:       a.Token,
-			Status:      string(a.Status),
-			UserName:    user.Name,
-			UserID:      a.UserID,
-			Permissions: permissions,
+			ID:                 a.ID,
+			Description:        a.Description,
+			Token:              a.Token,
+			Status:             string(a.Status),
+			UserName:           user.Name,
+			UserID:             a.UserID,
+			Permissions:        permissions,
+			MatchedPermissions: matched,
 		})
 	}
 
 	return writeTokens(cmd.OutOrStdout(), tokenPrintOpt{
 		jsonOut:     authCRUDFlags.json,
 		hideHeaders: authCRUDFlags.hideHeaders,
+		matched:     filter.HasPermissionFilter(),
 		tokens:      tokens,
 	})
 }
@@ -609,6 +654,7 @@ type tokenPrintOpt struct {
 	jsonOut     bool
 	deleted     bool
 	hideHeaders bool
+	matched     bool
 	token       token
 	tokens      []token
 }
@@ -635,6 +681,9 @@ func writeTokens(w io.Writer, printOpts tokenPrintOpt) error {
 		"User ID",
 		"Permissions",
 	}
+	if printOpts.matched {
+		headers = append(headers, "Matched Permissions")
+	}
 	if printOpts.deleted {
 		headers = append(headers, "Deleted")
 	}
@@ -653,6 +702,9 @@ func writeTokens(w io.Writer, printOpts tokenPrintOpt) error {
 			"User ID":     t.UserID.String(),
 			"Permissions": t.Permissions,
 		}
+		if printOpts.matched {
+			m["Matched Permissions"] = t.MatchedPermissions
+		}
 		if printOpts.deleted {
 			m["Deleted"] = true
 		}
@@ -3,18 +3,27 @@ package main
 import (
 	"compress/gzip"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/bolt"
 	"github.com/influxdata/influxdb/v2/http"
 	"github.com/influxdata/influxdb/v2/kv"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/pkg/fs"
+	isecret "github.com/influxdata/influxdb/v2/secret"
 	"github.com/influxdata/influxdb/v2/tenant"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"github.com/spf13/cobra"
@@ -29,15 +38,28 @@ type cmdBackupBuilder struct {
 	genericCLIOpts
 	*globalFlags
 
-	bucketID   string
-	bucketName string
-	org        organization
-	path       string
+	bucketID     string
+	bucketName   string
+	measurements []string
+	org          organization
+	path         string
+	wait         time.Duration
 
-	manifest influxdb.Manifest
-	baseName string
+	// progressInterval is the interval of the progress frames the server is
+	// asked for during transfers, if not 0.
+	progressInterval time.Duration
 
-	backupService *http.BackupService
+	ignoreSpaceCheck bool
+	signingKeyFile   string
+
+	signingKey    ed25519.PrivateKey
+	manifest      influxdb.Manifest
+	baseName      string
+	estimatedSize int64
+
+	backupService influxdb.BackupService
+	secretService influxdb.SecretService
+	diskUsage     func(path string) (*fs.DiskStatus, error)
 	kvStore       *bolt.KVStore
 	kvService     *kv.Service
 	tenantService *tenant.Service
@@ -50,6 +72,7 @@ func newCmdBackupBuilder(f *globalFlags, opts genericCLIOpts) *cmdBackupBuilder
 	return &cmdBackupBuilder{
 		genericCLIOpts: opts,
 		globalFlags:    f,
+		diskUsage:      fs.DiskUsage,
 	}
 }
 
@@ -58,6 +81,11 @@ func (b *cmdBackupBuilder) cmdBackup() *cobra.Command {
 	b.org.register(b.viper, cmd, true)
 	cmd.Flags().StringVar(&b.bucketID, "bucket-id", "", "The ID of the bucket to backup")
 	cmd.Flags().StringVarP(&b.bucketName, "bucket", "b", "", "The name of the bucket to backup")
+	cmd.Flags().StringArrayVar(&b.measurements, "measurement", nil, "The name of a measurement to backup; may be repeated. Requires a bucket")
+	cmd.Flags().BoolVar(&b.ignoreSpaceCheck, "ignore-space-check", false, "Start the backup even if the output path looks short of free space")
+	cmd.Flags().StringVar(&b.signingKeyFile, "signing-key-file", "", "Path to a PEM encoded Ed25519 private key to sign the backup manifest with")
+	registerWaitForServer(cmd, &b.wait)
+	registerProgressInterval(cmd, &b.progressInterval)
 	cmd.Use = "backup [flags] path"
 	cmd.Args = func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
@@ -72,10 +100,40 @@ func (b *cmdBackupBuilder) cmdBackup() *cobra.Command {
 	cmd.Long = `
 Backs up InfluxDB to a directory.
 
+Before transferring any shard, the size of the backup is estimated from the
+size on disk of the selected shards and the backup is refused if the output
+path has less free space; use --ignore-space-check to start it anyway. The
+estimate and the bytes actually written are logged when the backup completes.
+
+The manifest of a backup lists the SHA-256 checksum of each file. With
+--signing-key-file, the manifest is also signed with an Ed25519 key and the
+signature written alongside it, so that "influx restore --verify-key-file"
+can detect a backup modified after it was taken. Keys are PEM files, as
+written by:
+
+	openssl genpkey -algorithm ed25519 -out backup-key.pem
+	openssl pkey -in backup-key.pem -pubout -out backup-key.pub.pem
+
+Proxies, like load balancers, may close connections idle for too long, as a
+connection can be while the server prepares a large shard. With
+--progress-interval, the server sends a progress frame at that interval
+during each transfer, keeping its connection busy, and the progress is
+logged. Servers of older versions ignore it.
+
 Examples:
 	# backup all data
 	influx backup /path/to/backup
+
+	# backup the "cpu" and "mem" measurements of the "telegraf" bucket
+	influx backup --bucket telegraf --measurement cpu --measurement mem /path/to/backup
+
+	# backup all data and sign the manifest
+	influx backup --signing-key-file backup-key.pem /path/to/backup
+
+	# backup all data through a load balancer closing idle connections
+	influx backup --progress-interval 10s /path/to/backup
 `
+	cmd.AddCommand(newCmdVerifyCycleBuilder(b.globalFlags, b.genericCLIOpts).cmd())
 	return cmd
 }
 
@@ -100,6 +158,24 @@ func (b *cmdBackupBuilder) backupRunE(cmd *cobra.Command, args []string) (err er
 		return err
 	}
 
+	return b.backup(ctx)
+}
+
+// backup streams the KV store & matching shards from the server into b.path.
+func (b *cmdBackupBuilder) backup(ctx context.Context) (err error) {
+	// A measurement filter only makes sense within a single bucket.
+	if len(b.measurements) > 0 && b.bucketID == "" && b.bucketName == "" {
+		return fmt.Errorf("must specify a bucket id or name when backing up measurements")
+	}
+	b.manifest.Measurements = b.measurements
+
+	// Read the signing key before taking the backup, so a bad key fails fast.
+	if b.signingKeyFile != "" {
+		if b.signingKey, err = readSigningKey(b.signingKeyFile); err != nil {
+			return fmt.Errorf("cannot read signing key: %w", err)
+		}
+	}
+
 	// Determine a base
 	b.baseName = time.Now().UTC().Format(influxdb.BackupFilenamePattern)
 
@@ -109,17 +185,36 @@ func (b *cmdBackupBuilder) backupRunE(cmd *cobra.Command, args []string) (err er
 	}
 
 	ac := flags.config()
+	if err := waitForServer(ctx, b.logger, ac.Host, &tls.Config{InsecureSkipVerify: flags.skipVerify}, b.wait); err != nil {
+		return err
+	}
+
 	b.backupService = &http.BackupService{
 		Addr:               ac.Host,
 		Token:              ac.Token,
 		InsecureSkipVerify: flags.skipVerify,
 	}
 
+	client, err := newHTTPClient()
+	if err != nil {
+		return err
+	}
+	b.secretService = &isecret.Client{Client: client}
+
 	// Back up Bolt database to file.
 	if err := b.backupKVStore(ctx); err != nil {
 		return err
 	}
 
+	// Remove the KV store again if the backup is refused for lack of space,
+	// leaving no partial backup behind. Deferred before the store is opened,
+	// this runs after it is closed.
+	defer func() {
+		if _, ok := err.(*insufficientSpaceError); ok {
+			os.Remove(filepath.Join(b.path, b.kvPath()))
+		}
+	}()
+
 	// Open bolt DB.
 	boltClient := bolt.NewClient(b.logger)
 	boltClient.Path = filepath.Join(b.path, b.kvPath())
@@ -142,20 +237,104 @@ func (b *cmdBackupBuilder) backupRunE(cmd *cobra.Command, args []string) (err er
 		return err
 	}
 
-	// Filter through organizations & buckets to backup appropriate shards.
-	if err := b.backupOrganizations(ctx); err != nil {
+	// Filter through organizations & buckets to find the appropriate shards.
+	shards, err := b.findOrganizationShards(ctx)
+	if err != nil {
 		return err
 	}
 
+	if err := b.checkSpace(ctx, shards); err != nil {
+		return err
+	}
+
+	for _, sh := range shards {
+		if err := b.backupShard(ctx, sh); influxdb.ErrorCode(err) == influxdb.ENotFound {
+			b.logger.Warn("Shard removed during backup", zap.Uint64("shard_id", sh.id))
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
 	if err := b.writeManifest(ctx); err != nil {
 		return err
 	}
 
-	b.logger.Info("Backup complete")
+	b.logger.Info("Backup complete",
+		zap.String("estimated_size", humanize.Bytes(uint64(b.estimatedSize))),
+		zap.Int64("estimated_bytes", b.estimatedSize),
+		zap.String("written_size", humanize.Bytes(uint64(b.manifest.Size()))),
+		zap.Int64("written_bytes", b.manifest.Size()))
 
 	return nil
 }
 
+// backupShardRef is a shard selected for backup.
+type backupShardRef struct {
+	org    *influxdb.Organization
+	bkt    *influxdb.Bucket
+	policy string
+	id     uint64
+	// start and end bound the time range of the shard group of the shard.
+	start, end time.Time
+}
+
+// insufficientSpaceError is returned when the output path of a backup has
+// less free space than the backup is estimated to need.
+type insufficientSpaceError struct {
+	path              string
+	needed, available uint64
+}
+
+func (e *insufficientSpaceError) Error() string {
+	return fmt.Sprintf("not enough space at %s: backup needs an estimated %s, %s available; use --ignore-space-check to back up anyway",
+		e.path, humanize.Bytes(e.needed), humanize.Bytes(e.available))
+}
+
+// checkSpace estimates the size of the shards to back up from their size on
+// the server and returns an error if it exceeds the free space at the output
+// path. The estimate is recorded for the final summary. Failing to estimate
+// the size or to find the free space only skips the check.
+func (b *cmdBackupBuilder) checkSpace(ctx context.Context, shards []backupShardRef) error {
+	ids := make([]uint64, len(shards))
+	for i, sh := range shards {
+		ids[i] = sh.id
+	}
+	sizes, err := b.backupService.ShardSizes(ctx, ids)
+	if err != nil {
+		b.logger.Warn("Unable to estimate backup size, skipping space check", zap.Error(err))
+		return nil
+	}
+
+	var needed uint64
+	for _, s := range sizes {
+		needed += uint64(s.Size)
+	}
+	b.estimatedSize = b.manifest.KV.Size + int64(needed)
+	b.logger.Info("Estimated backup size", zap.String("size", humanize.Bytes(uint64(b.estimatedSize))), zap.Int("shards", len(sizes)))
+
+	disk, err := b.diskUsage(b.path)
+	if err != nil {
+		b.logger.Warn("Unable to find free space at output path, skipping space check", zap.String("path", b.path), zap.Error(err))
+		return nil
+	}
+	if disk.All == 0 {
+		// Network and object store mounts often report no size at all.
+		b.logger.Warn("Output path does not report its size, skipping space check; it may be a network or object store mount", zap.String("path", b.path))
+		return nil
+	}
+
+	if needed <= disk.Avail {
+		return nil
+	}
+	err = &insufficientSpaceError{path: b.path, needed: needed, available: disk.Avail}
+	if b.ignoreSpaceCheck {
+		b.logger.Warn("Ignoring space check", zap.Error(err))
+		return nil
+	}
+	return err
+}
+
 // backupKVStore streams the bolt KV file to a file at path.
 func (b *cmdBackupBuilder) backupKVStore(ctx context.Context) error {
 	path := filepath.Join(b.path, b.kvPath())
@@ -169,7 +348,9 @@ func (b *cmdBackupBuilder) backupKVStore(ctx context.Context) error {
 	defer f.Close()
 
 	// Stream bolt file from server, sync, and ensure file closes correctly.
-	if err := b.backupService.BackupKVStore(ctx, f); err != nil {
+	h := sha256.New()
+	ctx = withTransferProgress(ctx, b.logger, b.progressInterval, zap.String("path", b.kvPath()))
+	if err := b.backupService.BackupKVStore(ctx, io.MultiWriter(f, h)); err != nil {
 		return err
 	} else if err := f.Sync(); err != nil {
 		return err
@@ -185,17 +366,20 @@ func (b *cmdBackupBuilder) backupKVStore(ctx context.Context) error {
 	b.manifest.KV = influxdb.ManifestKVEntry{
 		FileName: b.kvPath(),
 		Size:     fi.Size(),
+		Checksum: hex.EncodeToString(h.Sum(nil)),
 	}
 
 	return nil
 }
 
-func (b *cmdBackupBuilder) backupOrganizations(ctx context.Context) (err error) {
+// findOrganizationShards returns the shards of the matching buckets of the
+// matching organizations.
+func (b *cmdBackupBuilder) findOrganizationShards(ctx context.Context) (shards []backupShardRef, err error) {
 	// Build a filter if org ID or org name were specified.
 	var filter influxdb.OrganizationFilter
 	if b.org.id != "" {
 		if filter.ID, err = influxdb.IDFromString(b.org.id); err != nil {
-			return err
+			return nil, err
 		}
 	} else if b.org.name != "" {
 		filter.Name = &b.org.name
@@ -204,26 +388,59 @@ func (b *cmdBackupBuilder) backupOrganizations(ctx context.Context) (err error)
 	// Retrieve a list of all matching organizations.
 	orgs, _, err := b.tenantService.FindOrganizations(ctx, filter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Back up buckets in each matching organization.
+	// Find buckets in each matching organization.
 	for _, org := range orgs {
 		b.logger.Info("Backing up organization", zap.String("id", org.ID.String()), zap.String("name", org.Name))
-		if err := b.backupBuckets(ctx, org); err != nil {
-			return err
+		if err := b.backupSecretKeys(ctx, org); err != nil {
+			return nil, err
 		}
+		orgShards, err := b.findBucketShards(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, orgShards...)
+	}
+	return shards, nil
+}
+
+// backupSecretKeys records the keys of the secrets of an organization in the
+// manifest, so a restore can recreate them as placeholders. Their values are
+// never read. A token not allowed to read the secrets only skips them.
+func (b *cmdBackupBuilder) backupSecretKeys(ctx context.Context, org *influxdb.Organization) error {
+	keys, err := b.secretService.GetSecretKeys(ctx, org.ID)
+	switch influxdb.ErrorCode(err) {
+	case "":
+	case influxdb.ENotFound:
+		return nil
+	case influxdb.EUnauthorized, influxdb.EForbidden:
+		b.logger.Warn("Not allowed to read secret keys, they will not be restored", zap.String("org_id", org.ID.String()), zap.String("org", org.Name), zap.Error(err))
+		return nil
+	default:
+		return fmt.Errorf("cannot find secret keys of organization %q: %w", org.Name, err)
+	}
+	if len(keys) == 0 {
+		return nil
 	}
+
+	sort.Strings(keys)
+	b.manifest.Secrets = append(b.manifest.Secrets, influxdb.ManifestSecretEntry{
+		OrganizationID:   org.ID.String(),
+		OrganizationName: org.Name,
+		Keys:             keys,
+	})
 	return nil
 }
 
-func (b *cmdBackupBuilder) backupBuckets(ctx context.Context, org *influxdb.Organization) (err error) {
+func (b *cmdBackupBuilder) findBucketShards(ctx context.Context, org *influxdb.Organization) (shards []backupShardRef, err error) {
 	// Build a filter if bucket ID or bucket name were specified.
 	var filter influxdb.BucketFilter
 	filter.OrganizationID = &org.ID
 	if b.bucketID != "" {
 		if filter.ID, err = influxdb.IDFromString(b.bucketID); err != nil {
-			return err
+			return nil, err
 		}
 	} else if b.bucketName != "" {
 		filter.Name = &b.bucketName
@@ -232,28 +449,31 @@ func (b *cmdBackupBuilder) backupBuckets(ctx context.Context, org *influxdb.Orga
 	// Retrieve a list of all matching organizations.
 	buckets, _, err := b.tenantService.FindBuckets(ctx, filter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Back up shards in each matching bucket.
+	// Find shards in each matching bucket.
 	for _, bkt := range buckets {
-		if err := b.backupBucket(ctx, org, bkt); err != nil {
-			return err
+		bktShards, err := b.findShards(org, bkt)
+		if err != nil {
+			return nil, err
 		}
+		shards = append(shards, bktShards...)
 	}
-	return nil
+	return shards, nil
 }
 
-func (b *cmdBackupBuilder) backupBucket(ctx context.Context, org *influxdb.Organization, bkt *influxdb.Bucket) (err error) {
+func (b *cmdBackupBuilder) findShards(org *influxdb.Organization, bkt *influxdb.Bucket) ([]backupShardRef, error) {
 	b.logger.Info("Backing up bucket", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name))
 
 	// Lookup matching database from the meta store.
 	dbi := b.metaClient.Database(bkt.ID.String())
 	if dbi == nil {
-		return fmt.Errorf("bucket database not found: %s", bkt.ID.String())
+		return nil, fmt.Errorf("bucket database not found: %s", bkt.ID.String())
 	}
 
-	// Iterate over and backup each shard.
+	// Iterate over each shard.
+	var shards []backupShardRef
 	for _, rpi := range dbi.RetentionPolicies {
 		for _, sg := range rpi.ShardGroups {
 			if sg.Deleted() {
@@ -261,20 +481,16 @@ func (b *cmdBackupBuilder) backupBucket(ctx context.Context, org *influxdb.Organ
 			}
 
 			for _, sh := range sg.Shards {
-				if err := b.backupShard(ctx, org, bkt, rpi.Name, sh.ID); influxdb.ErrorCode(err) == influxdb.ENotFound {
-					b.logger.Warn("Shard removed during backup", zap.Uint64("shard_id", sh.ID))
-					continue
-				} else if err != nil {
-					return err
-				}
+				shards = append(shards, backupShardRef{org: org, bkt: bkt, policy: rpi.Name, id: sh.ID, start: sg.StartTime, end: sg.EndTime})
 			}
 		}
 	}
-	return nil
+	return shards, nil
 }
 
 // backupShard streams a tar of TSM data for shard.
-func (b *cmdBackupBuilder) backupShard(ctx context.Context, org *influxdb.Organization, bkt *influxdb.Bucket, policy string, shardID uint64) error {
+func (b *cmdBackupBuilder) backupShard(ctx context.Context, sh backupShardRef) error {
+	shardID := sh.id
 	path := filepath.Join(b.path, b.shardPath(shardID))
 	b.logger.Info("Backing up shard", zap.Uint64("id", shardID), zap.String("path", b.shardPath(shardID)))
 
@@ -285,12 +501,14 @@ func (b *cmdBackupBuilder) backupShard(ctx context.Context, org *influxdb.Organi
 	}
 	defer f.Close()
 
-	// Wrap file writer with a gzip writer.
-	gw := gzip.NewWriter(f)
+	// Wrap file writer with a gzip writer, checksumming the compressed file.
+	h := sha256.New()
+	gw := gzip.NewWriter(io.MultiWriter(f, h))
 	defer gw.Close()
 
 	// Stream file from server, sync, and ensure file closes correctly.
-	if err := b.backupService.BackupShard(ctx, gw, shardID, time.Time{}); err != nil {
+	ctx = withTransferProgress(ctx, b.logger, b.progressInterval, zap.Uint64("id", shardID))
+	if err := b.streamShard(ctx, gw, shardID); err != nil {
 		return err
 	} else if err := gw.Close(); err != nil {
 		return err
@@ -308,19 +526,32 @@ func (b *cmdBackupBuilder) backupShard(ctx context.Context, org *influxdb.Organi
 
 	// Update manifest.
 	b.manifest.Files = append(b.manifest.Files, influxdb.ManifestEntry{
-		OrganizationID:   org.ID.String(),
-		OrganizationName: org.Name,
-		BucketID:         bkt.ID.String(),
-		BucketName:       bkt.Name,
+		OrganizationID:   sh.org.ID.String(),
+		OrganizationName: sh.org.Name,
+		BucketID:         sh.bkt.ID.String(),
+		BucketName:       sh.bkt.Name,
 		ShardID:          shardID,
 		FileName:         b.shardPath(shardID),
 		Size:             fi.Size(),
 		LastModified:     fi.ModTime().UTC(),
+		Checksum:         hex.EncodeToString(h.Sum(nil)),
+		StartTime:        sh.start.UTC(),
+		EndTime:          sh.end.UTC(),
+		Measurements:     b.measurements,
 	})
 
 	return nil
 }
 
+// streamShard streams the shard backup from the server, filtered to the
+// requested measurements if any.
+func (b *cmdBackupBuilder) streamShard(ctx context.Context, w io.Writer, shardID uint64) error {
+	if len(b.measurements) > 0 {
+		return b.backupService.BackupShardMeasurements(ctx, w, shardID, time.Time{}, b.measurements)
+	}
+	return b.backupService.BackupShard(ctx, w, shardID, time.Time{})
+}
+
 // writeManifest writes the manifest file out.
 func (b *cmdBackupBuilder) writeManifest(ctx context.Context) error {
 	path := filepath.Join(b.path, b.manifestPath())
@@ -331,7 +562,15 @@ func (b *cmdBackupBuilder) writeManifest(ctx context.Context) error {
 		return fmt.Errorf("create manifest: %w", err)
 	}
 	buf = append(buf, '\n')
-	return ioutil.WriteFile(path, buf, 0600)
+	if err := ioutil.WriteFile(path, buf, 0600); err != nil {
+		return err
+	}
+
+	if b.signingKey == nil {
+		return nil
+	}
+	b.logger.Info("Signing manifest", zap.String("path", manifestSignaturePath(b.manifestPath())), zap.String("key_id", keyID(b.signingKey.Public().(ed25519.PublicKey))))
+	return signManifest(path, &b.manifest, b.signingKey)
 }
 
 func (b *cmdBackupBuilder) newCmd(use string, runE func(*cobra.Command, []string) error) *cobra.Command {
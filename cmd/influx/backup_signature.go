@@ -0,0 +1,262 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// Backup manifests are signed with Ed25519 keys stored as PEM files, the
+// private key in PKCS #8 and the public key in PKIX form, as written by:
+//
+//	openssl genpkey -algorithm ed25519 -out backup-key.pem
+//	openssl pkey -in backup-key.pem -pubout -out backup-key.pub.pem
+
+const (
+	manifestSignatureAlgorithm = "ed25519"
+	manifestSignatureExt       = ".sig"
+)
+
+var (
+	// errUnsignedBackup is returned when a backup verified with a key has
+	// no signature.
+	errUnsignedBackup = errors.New("backup is unsigned")
+	// errBadSignature is returned when a backup has been modified since it
+	// was signed, or was signed with another key.
+	errBadSignature = errors.New("bad backup signature")
+)
+
+// manifestSignature is the content of the signature file written alongside
+// a manifest.
+type manifestSignature struct {
+	Algorithm string `json:"algorithm"`
+	// KeyID identifies the public key the signature verifies with.
+	KeyID     string `json:"keyID"`
+	Signature []byte `json:"signature"`
+}
+
+// manifestSignaturePath returns the path of the signature of a manifest.
+func manifestSignaturePath(manifestPath string) string {
+	return manifestPath + manifestSignatureExt
+}
+
+// keyID returns a short fingerprint of a public key.
+func keyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// readPEMBlock reads the single PEM block of a key file.
+func readPEMBlock(path string) (*pem.Block, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded key", path)
+	}
+	return block, nil
+}
+
+// readSigningKey reads an Ed25519 private key from a PEM file.
+func readSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a PKCS #8 private key: %v", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 private key", path)
+	}
+	return priv, nil
+}
+
+// readVerifyKey reads an Ed25519 public key from a PEM file.
+func readVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a PKIX public key: %v", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 public key", path)
+	}
+	return pub, nil
+}
+
+// signManifest writes the signature of a manifest alongside it.
+func signManifest(manifestPath string, m *influxdb.Manifest, key ed25519.PrivateKey) error {
+	payload, err := m.MarshalCanonical()
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(manifestSignature{
+		Algorithm: manifestSignatureAlgorithm,
+		KeyID:     keyID(key.Public().(ed25519.PublicKey)),
+		Signature: ed25519.Sign(key, payload),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+	return ioutil.WriteFile(manifestSignaturePath(manifestPath), buf, 0600)
+}
+
+// readManifest reads a manifest file.
+func readManifest(path string) (*influxdb.Manifest, error) {
+	var m influxdb.Manifest
+	if buf, err := ioutil.ReadFile(path); err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("read manifest: %v", err)
+	}
+	return &m, nil
+}
+
+// manifestSigned reports whether a manifest has a signature file.
+func manifestSigned(manifestPath string) (bool, error) {
+	_, err := os.Stat(manifestSignaturePath(manifestPath))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// verifyManifest verifies the signature of a manifest with key. The error
+// wraps errUnsignedBackup if the manifest has no signature and
+// errBadSignature if the signature does not verify.
+func verifyManifest(manifestPath string, m *influxdb.Manifest, key ed25519.PublicKey) error {
+	name := filepath.Base(manifestPath)
+	buf, err := ioutil.ReadFile(manifestSignaturePath(manifestPath))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: manifest %s has no signature file %s", errUnsignedBackup, name, filepath.Base(manifestSignaturePath(manifestPath)))
+	} else if err != nil {
+		return err
+	}
+
+	var sig manifestSignature
+	if err := json.Unmarshal(buf, &sig); err != nil {
+		return fmt.Errorf("%w: cannot read signature of manifest %s: %v", errBadSignature, name, err)
+	}
+	if sig.Algorithm != manifestSignatureAlgorithm {
+		return fmt.Errorf("%w: manifest %s is signed with unsupported algorithm %q", errBadSignature, name, sig.Algorithm)
+	}
+	if id := keyID(key); sig.KeyID != id {
+		return fmt.Errorf("%w: manifest %s is signed with key %s, not with the verify key %s", errBadSignature, name, sig.KeyID, id)
+	}
+
+	payload, err := m.MarshalCanonical()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, payload, sig.Signature) {
+		return fmt.Errorf("%w: manifest %s has been modified since it was signed", errBadSignature, name)
+	}
+	return nil
+}
+
+// verifyManifests verifies the signatures of all manifests in a backup
+// directory.
+func verifyManifests(path string, key ed25519.PublicKey) error {
+	manifests, err := filepath.Glob(filepath.Join(path, "*.manifest"))
+	if err != nil {
+		return err
+	}
+	for _, filename := range manifests {
+		if fi, err := os.Stat(filename); err != nil {
+			return err
+		} else if fi.IsDir() {
+			continue
+		}
+
+		m, err := readManifest(filename)
+		if err != nil {
+			return err
+		}
+		if err := verifyManifest(filename, m, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileChecksum returns the hex encoded SHA-256 checksum of the file at path.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyFileChecksum verifies the file of a backup against the checksum
+// recorded for it in its signed manifest, reading the checksum of the file
+// with checksumOf.
+func verifyFileChecksum(fileName, checksum string, checksumOf func(fileName string) (string, error)) error {
+	if checksum == "" {
+		return fmt.Errorf("%w: manifest has no checksum for %s", errBadSignature, fileName)
+	}
+	sum, err := checksumOf(fileName)
+	if err != nil {
+		return err
+	}
+	if sum != checksum {
+		return fmt.Errorf("%w: checksum of %s does not match its manifest", errBadSignature, fileName)
+	}
+	return nil
+}
+
+// verifySignedBackup verifies the signatures of the manifests of a backup
+// and the checksums of the files loaded from them.
+func verifySignedBackup(path string, key ed25519.PublicKey, kvEntry *influxdb.ManifestKVEntry, shardEntries map[uint64]*influxdb.ManifestEntry) error {
+	return verifySignedBackupWith(path, key, kvEntry, shardEntries, func(fileName string) (string, error) {
+		return fileChecksum(filepath.Join(path, fileName))
+	})
+}
+
+// verifySignedBackupWith is verifySignedBackup with the checksums of the
+// shard files read with shardChecksum, for backups whose shard files are not
+// in path.
+func verifySignedBackupWith(path string, key ed25519.PublicKey, kvEntry *influxdb.ManifestKVEntry, shardEntries map[uint64]*influxdb.ManifestEntry, shardChecksum func(fileName string) (string, error)) error {
+	if err := verifyManifests(path, key); err != nil {
+		return err
+	}
+	kvChecksum := func(fileName string) (string, error) {
+		return fileChecksum(filepath.Join(path, fileName))
+	}
+	if err := verifyFileChecksum(kvEntry.FileName, kvEntry.Checksum, kvChecksum); err != nil {
+		return err
+	}
+	for _, file := range shardEntries {
+		if err := verifyFileChecksum(file.FileName, file.Checksum, shardChecksum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
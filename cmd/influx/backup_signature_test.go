@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestKeys writes a new Ed25519 key pair to dir as PEM files and returns
+// their paths.
+func writeTestKeys(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	privPath := filepath.Join(dir, name+".pem")
+	pubPath := filepath.Join(dir, name+".pub.pem")
+	require.NoError(t, ioutil.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+	require.NoError(t, ioutil.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0600))
+	return privPath, pubPath
+}
+
+// writeTestBackup writes a backup of a KV file and one shard to dir and
+// returns the path of its manifest.
+func writeTestBackup(t *testing.T, dir string) (string, *influxdb.Manifest) {
+	t.Helper()
+
+	checksum := func(name, content string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	m := &influxdb.Manifest{
+		KV: influxdb.ManifestKVEntry{FileName: "20200101T000000Z.bolt", Size: 2, Checksum: checksum("20200101T000000Z.bolt", "kv")},
+		Files: []influxdb.ManifestEntry{
+			{ShardID: 1, FileName: "20200101T000000Z.s1.tar.gz", Size: 5, Checksum: checksum("20200101T000000Z.s1.tar.gz", "shard")},
+		},
+	}
+	buf, err := json.MarshalIndent(m, "", "  ")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "20200101T000000Z.manifest")
+	require.NoError(t, ioutil.WriteFile(path, buf, 0600))
+	return path, m
+}
+
+func TestBackupSignature(t *testing.T) {
+	keyDir, err := ioutil.TempDir("", "influx-backup-keys-")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyDir)
+
+	privPath, pubPath := writeTestKeys(t, keyDir, "key")
+	_, otherPubPath := writeTestKeys(t, keyDir, "other")
+	priv, err := readSigningKey(privPath)
+	require.NoError(t, err)
+	pub, err := readVerifyKey(pubPath)
+	require.NoError(t, err)
+	otherPub, err := readVerifyKey(otherPubPath)
+	require.NoError(t, err)
+
+	_, err = readVerifyKey(privPath)
+	assert.Error(t, err)
+
+	tests := []struct {
+		name    string
+		sign    bool
+		key     ed25519.PublicKey
+		modify  func(t *testing.T, dir, manifestPath string)
+		wantErr error
+	}{
+		{
+			name: "verified",
+			sign: true,
+			key:  pub,
+		},
+		{
+			name:    "unsigned",
+			key:     pub,
+			wantErr: errUnsignedBackup,
+		},
+		{
+			name:    "other key",
+			sign:    true,
+			key:     otherPub,
+			wantErr: errBadSignature,
+		},
+		{
+			name: "modified manifest",
+			sign: true,
+			key:  pub,
+			modify: func(t *testing.T, dir, manifestPath string) {
+				m, err := readManifest(manifestPath)
+				require.NoError(t, err)
+				m.Files[0].ShardID = 2
+				buf, err := json.Marshal(m)
+				require.NoError(t, err)
+				require.NoError(t, ioutil.WriteFile(manifestPath, buf, 0600))
+			},
+			wantErr: errBadSignature,
+		},
+		{
+			name: "reformatted manifest",
+			sign: true,
+			key:  pub,
+			modify: func(t *testing.T, dir, manifestPath string) {
+				m, err := readManifest(manifestPath)
+				require.NoError(t, err)
+				buf, err := json.MarshalIndent(m, "", "\t")
+				require.NoError(t, err)
+				require.NoError(t, ioutil.WriteFile(manifestPath, buf, 0600))
+			},
+		},
+		{
+			name: "modified shard file",
+			sign: true,
+			key:  pub,
+			modify: func(t *testing.T, dir, manifestPath string) {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "20200101T000000Z.s1.tar.gz"), []byte("SHARD"), 0600))
+			},
+			wantErr: errBadSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "influx-backup-")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			manifestPath, m := writeTestBackup(t, dir)
+			if tt.sign {
+				require.NoError(t, signManifest(manifestPath, m, priv))
+			}
+			signed, err := manifestSigned(manifestPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.sign, signed)
+
+			if tt.modify != nil {
+				tt.modify(t, dir, manifestPath)
+			}
+
+			kvEntry, shardEntries, err := loadIncremental(dir)
+			require.NoError(t, err)
+			err = verifySignedBackup(dir, tt.key, kvEntry, shardEntries)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/pkg/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeBackupService struct {
+	influxdb.BackupService
+
+	shardIDs []uint64
+	sizes    []influxdb.ShardSize
+	err      error
+}
+
+func (s *fakeBackupService) ShardSizes(ctx context.Context, shardIDs []uint64) ([]influxdb.ShardSize, error) {
+	s.shardIDs = shardIDs
+	return s.sizes, s.err
+}
+
+func TestCmdBackup_CheckSpace(t *testing.T) {
+	shards := []backupShardRef{{id: 1}, {id: 2}, {id: 3}}
+	sizes := []influxdb.ShardSize{{ShardID: 1, Size: 600}, {ShardID: 3, Size: 400}}
+
+	tests := []struct {
+		name      string
+		svc       *fakeBackupService
+		disk      *fs.DiskStatus
+		diskErr   error
+		ignore    bool
+		wantErr   bool
+		estimated int64
+	}{
+		{
+			name:      "enough space",
+			svc:       &fakeBackupService{sizes: sizes},
+			disk:      &fs.DiskStatus{All: 10000, Avail: 1000},
+			estimated: 1100,
+		},
+		{
+			name:      "not enough space",
+			svc:       &fakeBackupService{sizes: sizes},
+			disk:      &fs.DiskStatus{All: 10000, Avail: 999},
+			wantErr:   true,
+			estimated: 1100,
+		},
+		{
+			name:      "not enough space ignored",
+			svc:       &fakeBackupService{sizes: sizes},
+			disk:      &fs.DiskStatus{All: 10000, Avail: 999},
+			ignore:    true,
+			estimated: 1100,
+		},
+		{
+			name:      "size not reported by output path",
+			svc:       &fakeBackupService{sizes: sizes},
+			disk:      &fs.DiskStatus{},
+			estimated: 1100,
+		},
+		{
+			name:      "free space unknown",
+			svc:       &fakeBackupService{sizes: sizes},
+			diskErr:   errors.New("not supported"),
+			estimated: 1100,
+		},
+		{
+			name: "size not estimated",
+			svc:  &fakeBackupService{err: errors.New("not found")},
+			disk: &fs.DiskStatus{All: 10000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCmdBackupBuilder(&globalFlags{}, genericCLIOpts{})
+			b.logger = zaptest.NewLogger(t)
+			b.path = "/backups"
+			b.manifest.KV.Size = 100
+			b.ignoreSpaceCheck = tt.ignore
+			b.backupService = tt.svc
+			b.diskUsage = func(path string) (*fs.DiskStatus, error) {
+				assert.Equal(t, "/backups", path)
+				return tt.disk, tt.diskErr
+			}
+
+			err := b.checkSpace(context.Background(), shards)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "--ignore-space-check")
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, []uint64{1, 2, 3}, tt.svc.shardIDs)
+			assert.Equal(t, tt.estimated, b.estimatedSize)
+		})
+	}
+}
+
+func TestCmdBackup_SecretKeys(t *testing.T) {
+	org := &influxdb.Organization{ID: influxdb.ID(9000), Name: "org"}
+
+	tests := []struct {
+		name     string
+		keys     []string
+		err      error
+		expected []influxdb.ManifestSecretEntry
+		wantErr  bool
+	}{
+		{
+			name: "records sorted keys",
+			keys: []string{"token", "password"},
+			expected: []influxdb.ManifestSecretEntry{
+				{OrganizationID: org.ID.String(), OrganizationName: "org", Keys: []string{"password", "token"}},
+			},
+		},
+		{
+			name: "no secrets",
+			err:  &influxdb.Error{Code: influxdb.ENotFound},
+		},
+		{
+			name: "not allowed to read secrets",
+			err:  &influxdb.Error{Code: influxdb.EUnauthorized},
+		},
+		{
+			name:    "error",
+			err:     errors.New("unavailable"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCmdBackupBuilder(&globalFlags{}, genericCLIOpts{})
+			b.logger = zaptest.NewLogger(t)
+			svc := mock.NewSecretService()
+			svc.GetSecretKeysFn = func(ctx context.Context, orgID influxdb.ID) ([]string, error) {
+				assert.Equal(t, org.ID, orgID)
+				return tt.keys, tt.err
+			}
+			svc.LoadSecretFn = func(ctx context.Context, orgID influxdb.ID, k string) (string, error) {
+				t.Fatal("secret values must not be read")
+				return "", nil
+			}
+			b.secretService = svc
+
+			err := b.backupSecretKeys(context.Background(), org)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, b.manifest.Secrets)
+		})
+	}
+}
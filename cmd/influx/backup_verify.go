@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// verifyCycleMeasurement is the measurement written by the verify-cycle dataset.
+const verifyCycleMeasurement = "verify_cycle"
+
+type cmdVerifyCycleBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	scratchOrg string
+	confirmed  bool
+	points     int
+
+	orgService    influxdb.OrganizationService
+	bucketService influxdb.BucketService
+	writeService  influxdb.WriteService
+	queryService  query.QueryService
+
+	logger *zap.Logger
+}
+
+func newCmdVerifyCycleBuilder(f *globalFlags, opts genericCLIOpts) *cmdVerifyCycleBuilder {
+	return &cmdVerifyCycleBuilder{
+		genericCLIOpts: opts,
+		globalFlags:    f,
+	}
+}
+
+func (b *cmdVerifyCycleBuilder) cmd() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("verify-cycle", b.verifyCycleRunE, true)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	cmd.Flags().StringVar(&b.scratchOrg, "scratch-org", "", "The name of the organization to write test data to (required)")
+	cmd.Flags().BoolVar(&b.confirmed, "i-know-this-writes-data", false, "Confirm that test buckets may be created in the scratch organization")
+	cmd.Flags().IntVar(&b.points, "points", 1000, "The number of points to write to the test dataset")
+	cmd.Short = "Verify a full backup and restore cycle"
+	cmd.Long = `
+Writes a known dataset to a new bucket in a scratch organization, backs it up,
+restores it into a fresh bucket, and compares the point counts and checksums of
+both buckets. Both buckets are deleted afterwards. A JSON report is written to
+stdout and the command exits with an error if any discrepancy was found.
+
+Examples:
+	# verify the backup pipeline against the "scratch" organization
+	influx backup verify-cycle --scratch-org scratch --i-know-this-writes-data
+`
+	return cmd
+}
+
+// verifyCycleReport is the JSON report written by the verify-cycle command.
+type verifyCycleReport struct {
+	Org            string             `json:"org"`
+	SourceBucket   string             `json:"sourceBucket"`
+	RestoredBucket string             `json:"restoredBucket"`
+	ValuesWritten  int                `json:"valuesWritten"`
+	Source         verifyCycleSummary `json:"source"`
+	Restored       verifyCycleSummary `json:"restored"`
+	Discrepancies  []string           `json:"discrepancies"`
+	Passed         bool               `json:"passed"`
+}
+
+// verifyCycleSummary describes the data queried from one side of the cycle.
+type verifyCycleSummary struct {
+	Count    int    `json:"count"`
+	Checksum string `json:"checksum"`
+}
+
+func (b *cmdVerifyCycleBuilder) verifyCycleRunE(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+
+	if b.scratchOrg == "" {
+		return fmt.Errorf("must specify --scratch-org")
+	} else if !b.confirmed {
+		return fmt.Errorf("refusing to write test data to organization %q without --i-know-this-writes-data", b.scratchOrg)
+	} else if b.points <= 0 {
+		return fmt.Errorf("must write at least one point")
+	}
+
+	// Log progress to stderr so stdout only contains the JSON report.
+	logconf := influxlogger.NewConfig()
+	if b.logger, err = logconf.New(os.Stderr); err != nil {
+		return err
+	}
+
+	client, err := newHTTPClient()
+	if err != nil {
+		return err
+	}
+	ac := b.config()
+	b.orgService = &tenant.OrgClientService{Client: client}
+	b.bucketService = &tenant.BucketClientService{Client: client}
+	b.writeService = &http.WriteService{
+		Addr:               ac.Host,
+		Token:              ac.Token,
+		InsecureSkipVerify: b.skipVerify,
+	}
+	b.queryService = &http.FluxQueryService{
+		Addr:               ac.Host,
+		Token:              ac.Token,
+		InsecureSkipVerify: b.skipVerify,
+	}
+
+	report, err := b.verifyCycle(ctx)
+	if err != nil {
+		return err
+	}
+	if err := b.writeJSON(report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		return fmt.Errorf("backup verification failed with %d discrepancies", len(report.Discrepancies))
+	}
+	return nil
+}
+
+// verifyCycle runs a write, backup, restore & query cycle against the scratch
+// organization and cleans up all buckets & files it created.
+func (b *cmdVerifyCycleBuilder) verifyCycle(ctx context.Context) (_ *verifyCycleReport, err error) {
+	org, err := b.orgService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &b.scratchOrg})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find scratch organization: %w", err)
+	}
+
+	baseName := "verify-cycle-" + time.Now().UTC().Format(influxdb.BackupFilenamePattern)
+	report := &verifyCycleReport{
+		Org:            org.Name,
+		SourceBucket:   baseName,
+		RestoredBucket: baseName + "-restored",
+		Discrepancies:  []string{},
+	}
+
+	// Create the source bucket and remove both buckets once done.
+	src := &influxdb.Bucket{OrgID: org.ID, Name: report.SourceBucket}
+	if err := b.bucketService.CreateBucket(ctx, src); err != nil {
+		return nil, fmt.Errorf("cannot create source bucket: %w", err)
+	}
+	defer b.deleteBucket(ctx, org.ID, report.SourceBucket)
+	defer b.deleteBucket(ctx, org.ID, report.RestoredBucket)
+
+	// Write the known dataset.
+	b.logger.Info("Writing test dataset", zap.String("bucket", src.Name), zap.Int("points", b.points))
+	lp := verifyCycleDataset(b.points, time.Now().UTC().Truncate(time.Second))
+	if err := b.writeService.Write(ctx, org.ID, src.ID, strings.NewReader(lp)); err != nil {
+		return nil, fmt.Errorf("cannot write test dataset: %w", err)
+	}
+	report.ValuesWritten = 2 * b.points
+
+	dir, err := ioutil.TempDir("", "influx-verify-cycle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// Back up the source bucket.
+	backup := newCmdBackupBuilder(b.globalFlags, b.genericCLIOpts)
+	backup.logger = b.logger
+	backup.path = dir
+	backup.org.id = org.ID.String()
+	backup.bucketID = src.ID.String()
+	if err := backup.backup(ctx); err != nil {
+		return nil, fmt.Errorf("backup failed: %w", err)
+	}
+
+	// Restore it into a fresh bucket, remapping shard IDs on the server.
+	restore := newCmdRestoreBuilder(b.globalFlags, b.genericCLIOpts)
+	restore.logger = b.logger
+	restore.path = dir
+	restore.org.id = org.ID.String()
+	restore.bucketID = src.ID.String()
+	restore.newBucketName = report.RestoredBucket
+	restore.strict = true
+	if err := restore.restore(ctx); err != nil {
+		return nil, fmt.Errorf("restore failed: %w", err)
+	}
+
+	// Query both sides and compare.
+	if report.Source, err = b.summarizeBucket(ctx, org.ID, report.SourceBucket); err != nil {
+		return nil, err
+	}
+	if report.Restored, err = b.summarizeBucket(ctx, org.ID, report.RestoredBucket); err != nil {
+		return nil, err
+	}
+
+	if report.Source.Count != report.ValuesWritten {
+		report.Discrepancies = append(report.Discrepancies, fmt.Sprintf("source bucket has %d values, expected %d", report.Source.Count, report.ValuesWritten))
+	}
+	if report.Restored.Count != report.Source.Count {
+		report.Discrepancies = append(report.Discrepancies, fmt.Sprintf("restored bucket has %d values, source has %d", report.Restored.Count, report.Source.Count))
+	}
+	if report.Restored.Checksum != report.Source.Checksum {
+		report.Discrepancies = append(report.Discrepancies, fmt.Sprintf("restored bucket checksum %s does not match source checksum %s", report.Restored.Checksum, report.Source.Checksum))
+	}
+	report.Passed = len(report.Discrepancies) == 0
+
+	return report, nil
+}
+
+// deleteBucket removes a bucket created by the cycle, if it exists.
+func (b *cmdVerifyCycleBuilder) deleteBucket(ctx context.Context, orgID influxdb.ID, name string) {
+	bkt, err := b.bucketService.FindBucket(ctx, influxdb.BucketFilter{OrganizationID: &orgID, Name: &name})
+	if influxdb.ErrorCode(err) == influxdb.ENotFound {
+		return
+	} else if err != nil {
+		b.logger.Warn("Cannot find bucket for cleanup", zap.String("bucket", name), zap.Error(err))
+		return
+	}
+
+	if err := b.bucketService.DeleteBucket(ctx, bkt.ID); err != nil {
+		b.logger.Warn("Cannot delete bucket", zap.String("bucket", name), zap.Error(err))
+	}
+}
+
+// summarizeBucket queries every value in the bucket and computes an
+// order-independent checksum over them.
+func (b *cmdVerifyCycleBuilder) summarizeBucket(ctx context.Context, orgID influxdb.ID, name string) (verifyCycleSummary, error) {
+	q := fmt.Sprintf(`from(bucket: %q) |> range(start: 0) |> filter(fn: (r) => r._measurement == %q)`, name, verifyCycleMeasurement)
+	itr, err := b.queryService.Query(ctx, &query.Request{
+		OrganizationID: orgID,
+		Compiler:       lang.FluxCompiler{Query: q},
+	})
+	if err != nil {
+		return verifyCycleSummary{}, fmt.Errorf("cannot query bucket %q: %w", name, err)
+	}
+	defer itr.Release()
+
+	var summary verifyCycleSummary
+	var sum uint64
+	for itr.More() {
+		if err := itr.Next().Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				for i := 0; i < cr.Len(); i++ {
+					sum += hashRow(cr, i)
+					summary.Count++
+				}
+				return nil
+			})
+		}); err != nil {
+			return verifyCycleSummary{}, err
+		}
+	}
+	if err := itr.Err(); err != nil {
+		return verifyCycleSummary{}, fmt.Errorf("cannot query bucket %q: %w", name, err)
+	}
+
+	summary.Checksum = fmt.Sprintf("%016x", sum)
+	return summary, nil
+}
+
+// hashRow returns a hash of all columns in the row which are independent of
+// the query itself, sorted by column name.
+func hashRow(cr flux.ColReader, i int) uint64 {
+	var cols []string
+	for j, col := range cr.Cols() {
+		switch col.Label {
+		case "result", "table", execute.DefaultStartColLabel, execute.DefaultStopColLabel:
+			continue
+		}
+		cols = append(cols, fmt.Sprintf("%s=%v", col.Label, execute.ValueForRow(cr, i, j)))
+	}
+	sort.Strings(cols)
+
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(cols, ",")))
+	return h.Sum64()
+}
+
+// verifyCycleDataset returns n deterministic points in line protocol, one
+// second apart and ending at now.
+func verifyCycleDataset(n int, now time.Time) string {
+	var sb strings.Builder
+	start := now.Add(-time.Duration(n) * time.Second)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%s,host=host%d value=%g,count=%di %d\n",
+			verifyCycleMeasurement, i%4, float64(i)*1.5, i, start.Add(time.Duration(i)*time.Second).UnixNano())
+	}
+	return sb.String()
+}
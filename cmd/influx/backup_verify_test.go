@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdVerifyCycle_RequiresConfirmation(t *testing.T) {
+	b := newCmdVerifyCycleBuilder(&globalFlags{}, genericCLIOpts{})
+	b.scratchOrg = "scratch"
+	b.points = 10
+
+	err := b.verifyCycleRunE(nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--i-know-this-writes-data")
+}
+
+func TestVerifyCycleDataset(t *testing.T) {
+	now := time.Unix(1000, 0).UTC()
+	lines := strings.Split(strings.TrimSpace(verifyCycleDataset(3, now)), "\n")
+	assert.Equal(t, []string{
+		"verify_cycle,host=host0 value=0,count=0i 997000000000",
+		"verify_cycle,host=host1 value=1.5,count=1i 998000000000",
+		"verify_cycle,host=host2 value=3,count=2i 999000000000",
+	}, lines)
+}
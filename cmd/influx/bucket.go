@@ -12,6 +12,8 @@ import (
 
 type bucketSVCsFn func() (influxdb.BucketService, influxdb.OrganizationService, error)
 
+type bucketLogSVCFn func() (influxdb.BucketOperationLogService, error)
+
 func cmdBucket(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 	builder := newCmdBucketBuilder(newBucketSVCs, f, opt)
 	return builder.cmd()
@@ -21,7 +23,8 @@ type cmdBucketBuilder struct {
 	genericCLIOpts
 	*globalFlags
 
-	svcFn bucketSVCsFn
+	svcFn    bucketSVCsFn
+	logSVCFn bucketLogSVCFn
 
 	id          string
 	hideHeaders bool
@@ -30,6 +33,11 @@ type cmdBucketBuilder struct {
 	description string
 	org         organization
 	retention   string
+	limit       int
+	offset      int
+
+	deleteProtected           bool
+	retentionBypassProtection bool
 }
 
 func newCmdBucketBuilder(svcsFn bucketSVCsFn, f *globalFlags, opts genericCLIOpts) *cmdBucketBuilder {
@@ -37,6 +45,7 @@ func newCmdBucketBuilder(svcsFn bucketSVCsFn, f *globalFlags, opts genericCLIOpt
 		globalFlags:    f,
 		genericCLIOpts: opts,
 		svcFn:          svcsFn,
+		logSVCFn:       newBucketLogSVC,
 	}
 }
 
@@ -46,15 +55,99 @@ func (b *cmdBucketBuilder) cmd() *cobra.Command {
 	cmd.TraverseChildren = true
 	cmd.Run = seeHelp
 	cmd.AddCommand(
+		b.cmdArchive(),
 		b.cmdCreate(),
 		b.cmdDelete(),
+		b.cmdHistory(),
 		b.cmdList(),
+		b.cmdUnarchive(),
 		b.cmdUpdate(),
 	)
 
 	return cmd
 }
 
+func (b *cmdBucketBuilder) cmdArchive() *cobra.Command {
+	cmd := b.newCmd("archive", b.archiveRunEFn(true))
+	cmd.Short = "Archive bucket"
+	cmd.Long = `Archive a bucket, rejecting writes and deletes to it, and changes to its
+retention period, while keeping its data queryable. Archiving compacts the
+data of the bucket to its minimal size on disk.`
+
+	b.registerBucketLookupFlags(cmd)
+	return cmd
+}
+
+func (b *cmdBucketBuilder) cmdUnarchive() *cobra.Command {
+	cmd := b.newCmd("unarchive", b.archiveRunEFn(false))
+	cmd.Short = "Unarchive bucket, accepting writes to it again"
+
+	b.registerBucketLookupFlags(cmd)
+	return cmd
+}
+
+func (b *cmdBucketBuilder) registerBucketLookupFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&b.id, "id", "i", "", "The bucket ID, required if name isn't provided")
+	cmd.Flags().StringVarP(&b.name, "name", "n", "", "The bucket name, org or org-id will be required by choosing this")
+	b.org.register(b.viper, cmd, false)
+	b.registerPrintFlags(cmd)
+}
+
+func (b *cmdBucketBuilder) archiveRunEFn(archived bool) func(*cobra.Command, []string) error {
+	return func(*cobra.Command, []string) error {
+		bktSVC, _, err := b.svcFn()
+		if err != nil {
+			return err
+		}
+
+		filter, err := b.bucketFilter()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		bkt, err := bktSVC.FindBucket(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to find bucket: %v", err)
+		}
+
+		bkt, err = bktSVC.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{Archived: &archived})
+		if err != nil {
+			return fmt.Errorf("failed to update bucket: %v", err)
+		}
+		return b.printBuckets(bucketPrintOpt{bucket: bkt})
+	}
+}
+
+// bucketFilter returns the filter finding the bucket named by the id, or the
+// name and org, flags.
+func (b *cmdBucketBuilder) bucketFilter() (influxdb.BucketFilter, error) {
+	var filter influxdb.BucketFilter
+	if b.id == "" && b.name != "" {
+		if err := b.org.validOrgFlags(&flags); err != nil {
+			return filter, err
+		}
+		filter.Name = &b.name
+		if b.org.id != "" {
+			orgID, err := influxdb.IDFromString(b.org.id)
+			if err != nil {
+				return filter, err
+			}
+			filter.OrganizationID = orgID
+		} else if b.org.name != "" {
+			filter.Org = &b.org.name
+		}
+		return filter, nil
+	}
+
+	id, err := influxdb.IDFromString(b.id)
+	if err != nil {
+		return filter, fmt.Errorf("failed to decode bucket id %q: %v", b.id, err)
+	}
+	filter.ID = id
+	return filter, nil
+}
+
 func (b *cmdBucketBuilder) cmdCreate() *cobra.Command {
 	cmd := b.newCmd("create", b.cmdCreateRunEFn)
 	cmd.Short = "Create bucket"
@@ -72,7 +165,7 @@ func (b *cmdBucketBuilder) cmdCreate() *cobra.Command {
 	opts.mustRegister(b.viper, cmd)
 
 	cmd.Flags().StringVarP(&b.description, "description", "d", "", "Description of bucket that will be created")
-	cmd.Flags().StringVarP(&b.retention, "retention", "r", "", "Duration bucket will retain data. 0 is infinite. Default is 0.")
+	cmd.Flags().StringVarP(&b.retention, "retention", "r", "", "Duration bucket will retain data. 0 is infinite. Defaults to the default retention of the organization, infinite unless set.")
 	b.org.register(b.viper, cmd, false)
 	b.registerPrintFlags(cmd)
 
@@ -89,9 +182,12 @@ func (b *cmdBucketBuilder) cmdCreateRunEFn(*cobra.Command, []string) error {
 		return err
 	}
 
-	dur, err := internal.RawDurationToTimeDuration(b.retention)
-	if err != nil {
-		return err
+	// Without a retention the bucket gets the default of its organization.
+	dur := influxdb.DefaultRetention
+	if b.retention != "" {
+		if dur, err = internal.RawDurationToTimeDuration(b.retention); err != nil {
+			return err
+		}
 	}
 
 	bkt := &influxdb.Bucket{
@@ -166,6 +262,53 @@ func (b *cmdBucketBuilder) cmdDeleteRunEFn(cmd *cobra.Command, args []string) er
 	})
 }
 
+func (b *cmdBucketBuilder) cmdHistory() *cobra.Command {
+	cmd := b.newCmd("history", b.cmdHistoryRunEFn)
+	cmd.Short = "List the operation log of a bucket"
+
+	cmd.Flags().StringVarP(&b.id, "id", "i", "", "The bucket ID, required if name isn't provided")
+	cmd.Flags().StringVarP(&b.name, "name", "n", "", "The bucket name, org or org-id will be required by choosing this")
+	cmd.Flags().IntVar(&b.limit, "limit", influxdb.DefaultPageSize, "The maximum number of entries to list")
+	cmd.Flags().IntVar(&b.offset, "offset", 0, "The number of most recent entries to skip")
+	b.org.register(b.viper, cmd, false)
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdBucketBuilder) cmdHistoryRunEFn(cmd *cobra.Command, args []string) error {
+	bktSVC, _, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+	logSVC, err := b.logSVCFn()
+	if err != nil {
+		return err
+	}
+
+	filter, err := b.bucketFilter()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bkt, err := bktSVC.FindBucket(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to find bucket: %v", err)
+	}
+
+	entries, _, err := logSVC.GetBucketOperationLog(ctx, bkt.ID, influxdb.FindOptions{
+		Limit:      b.limit,
+		Offset:     b.offset,
+		Descending: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve bucket history: %v", err)
+	}
+
+	return printOperationLog(b.genericCLIOpts, b.json, b.hideHeaders, entries)
+}
+
 func (b *cmdBucketBuilder) cmdList() *cobra.Command {
 	cmd := b.newCmd("list", b.cmdListRunEFn)
 	cmd.Short = "List buckets"
@@ -251,6 +394,8 @@ func (b *cmdBucketBuilder) cmdUpdate() *cobra.Command {
 	cmd.Flags().StringVarP(&b.description, "description", "d", "", "Description of bucket that will be created")
 	cmd.MarkFlagRequired("id")
 	cmd.Flags().StringVarP(&b.retention, "retention", "r", "", "Duration bucket will retain data. 0 is infinite. Default is 0.")
+	cmd.Flags().BoolVar(&b.deleteProtected, "delete-protected", false, "Protect the bucket and its data from deletion; pass --delete-protected=false on its own to clear. Requires org owner")
+	cmd.Flags().BoolVar(&b.retentionBypassProtection, "retention-bypasses-delete-protection", false, "Allow retention to expire data from a delete protected bucket. Requires org owner")
 
 	return cmd
 }
@@ -281,6 +426,12 @@ func (b *cmdBucketBuilder) cmdUpdateRunEFn(cmd *cobra.Command, args []string) er
 	if dur != 0 {
 		update.RetentionPeriod = &dur
 	}
+	if cmd.Flags().Changed("delete-protected") {
+		update.DeleteProtected = &b.deleteProtected
+	}
+	if cmd.Flags().Changed("retention-bypasses-delete-protection") {
+		update.RetentionBypassesDeleteProtection = &b.retentionBypassProtection
+	}
 
 	bkt, err := bktSVC.UpdateBucket(context.Background(), id, update)
 	if err != nil {
@@ -320,7 +471,7 @@ func (b *cmdBucketBuilder) printBuckets(printOpt bucketPrintOpt) error {
 
 	w.HideHeaders(b.hideHeaders)
 
-	headers := []string{"ID", "Name", "Retention", "Organization ID"}
+	headers := []string{"ID", "Name", "Retention", "Organization ID", "Archived"}
 	if printOpt.deleted {
 		headers = append(headers, "Deleted")
 	}
@@ -336,6 +487,7 @@ func (b *cmdBucketBuilder) printBuckets(printOpt bucketPrintOpt) error {
 			"Name":            bkt.Name,
 			"Retention":       bkt.RetentionPeriod,
 			"Organization ID": bkt.OrgID.String(),
+			"Archived":        bkt.Archived,
 		}
 		if printOpt.deleted {
 			m["Deleted"] = true
@@ -356,3 +508,12 @@ func newBucketSVCs() (influxdb.BucketService, influxdb.OrganizationService, erro
 
 	return &tenant.BucketClientService{Client: httpClient}, orgSvc, nil
 }
+
+func newBucketLogSVC() (influxdb.BucketOperationLogService, error) {
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant.BucketClientService{Client: httpClient}, nil
+}
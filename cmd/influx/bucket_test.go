@@ -41,8 +41,18 @@ func TestCmdBucket(t *testing.T) {
 				name:  "basic just name",
 				flags: []string{"--name=new name", "--org=org name"},
 				expectedBucket: influxdb.Bucket{
-					Name:  "new name",
-					OrgID: orgID,
+					Name:            "new name",
+					RetentionPeriod: influxdb.DefaultRetention,
+					OrgID:           orgID,
+				},
+			},
+			{
+				name:  "explicit infinite retention",
+				flags: []string{"--name=new name", "--retention=0", "--org=org name"},
+				expectedBucket: influxdb.Bucket{
+					Name:            "new name",
+					RetentionPeriod: influxdb.InfiniteRetention,
+					OrgID:           orgID,
 				},
 			},
 			{
@@ -95,7 +105,7 @@ func TestCmdBucket(t *testing.T) {
 		cmdFn := func(expectedBkt influxdb.Bucket) func(*globalFlags, genericCLIOpts) *cobra.Command {
 			svc := mock.NewBucketService()
 			svc.CreateBucketFn = func(ctx context.Context, bucket *influxdb.Bucket) error {
-				if expectedBkt != *bucket {
+				if !reflect.DeepEqual(expectedBkt, *bucket) {
 					return fmt.Errorf("unexpected bucket;\n\twant= %+v\n\tgot=  %+v", expectedBkt, *bucket)
 				}
 				return nil
@@ -349,6 +359,67 @@ func TestCmdBucket(t *testing.T) {
 		}
 	})
 
+	t.Run("archive", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			cmd      string
+			flags    []string
+			archived bool
+		}{
+			{
+				name:     "by id",
+				cmd:      "archive",
+				flags:    []string{"--id=" + influxdb.ID(3).String()},
+				archived: true,
+			},
+			{
+				name:     "by name",
+				cmd:      "archive",
+				flags:    []string{"--name=history", "--org=influxdata"},
+				archived: true,
+			},
+			{
+				name:  "unarchive",
+				cmd:   "unarchive",
+				flags: []string{"--id=" + influxdb.ID(3).String()},
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				svc := mock.NewBucketService()
+				svc.FindBucketFn = func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+					if filter.ID == nil && (filter.Name == nil || *filter.Name != "history") {
+						return nil, fmt.Errorf("unexpected filter: %s", filter)
+					}
+					return &influxdb.Bucket{ID: 3, Name: "history"}, nil
+				}
+				var updated bool
+				svc.UpdateBucketFn = func(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+					updated = true
+					expected := influxdb.BucketUpdate{Archived: boolPtr(tt.archived)}
+					if id != 3 || !reflect.DeepEqual(expected, upd) {
+						return nil, fmt.Errorf("unexpected bucket update of %s: %+v", id, upd)
+					}
+					return &influxdb.Bucket{ID: id, Name: "history", Archived: tt.archived}, nil
+				}
+
+				builder := newInfluxCmdBuilder(
+					in(new(bytes.Buffer)),
+					out(ioutil.Discard),
+				)
+				cmd := builder.cmd(func(g *globalFlags, opt genericCLIOpts) *cobra.Command {
+					return newCmdBucketBuilder(fakeSVCFn(svc), g, opt).cmd()
+				})
+				cmd.SetArgs(append([]string{"bucket", tt.cmd}, tt.flags...))
+				require.NoError(t, cmd.Execute())
+				assert.True(t, updated)
+			}
+
+			t.Run(tt.name, fn)
+		}
+	})
+
 	t.Run("update", func(t *testing.T) {
 		tests := []struct {
 			name     string
@@ -408,6 +479,28 @@ func TestCmdBucket(t *testing.T) {
 					RetentionPeriod: durPtr(time.Minute),
 				},
 			},
+			{
+				name: "set delete protection",
+				flags: []string{
+					"--id=" + influxdb.ID(3).String(),
+					"--delete-protected",
+					"--retention-bypasses-delete-protection",
+				},
+				expected: influxdb.BucketUpdate{
+					DeleteProtected:                   boolPtr(true),
+					RetentionBypassesDeleteProtection: boolPtr(true),
+				},
+			},
+			{
+				name: "clear delete protection",
+				flags: []string{
+					"--id=" + influxdb.ID(3).String(),
+					"--delete-protected=false",
+				},
+				expected: influxdb.BucketUpdate{
+					DeleteProtected: boolPtr(false),
+				},
+			},
 		}
 
 		cmdFn := func(expectedUpdate influxdb.BucketUpdate) func(*globalFlags, genericCLIOpts) *cobra.Command {
@@ -455,6 +548,10 @@ func durPtr(d time.Duration) *time.Duration {
 	return &d
 }
 
+func boolPtr(b bool) *bool {
+	return &b
+}
+
 func addEnvVars(t *testing.T, envVars map[string]string) func() {
 	t.Helper()
 
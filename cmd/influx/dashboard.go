@@ -20,10 +20,12 @@ type cmdDashboardBuilder struct {
 	genericCLIOpts
 	*globalFlags
 
-	svcFn dashboardSVCsFn
+	svcFn      dashboardSVCsFn
+	copySVCsFn dashboardCopySVCsFn
 
-	ids []string
-	org organization
+	ids  []string
+	org  organization
+	copy dashboardCopyFlags
 }
 
 func newCmdDashboardBuilder(svcFn dashboardSVCsFn, f *globalFlags, opts genericCLIOpts) *cmdDashboardBuilder {
@@ -31,6 +33,7 @@ func newCmdDashboardBuilder(svcFn dashboardSVCsFn, f *globalFlags, opts genericC
 		genericCLIOpts: opts,
 		globalFlags:    f,
 		svcFn:          svcFn,
+		copySVCsFn:     newDashboardCopySVCs,
 	}
 }
 
@@ -54,6 +57,8 @@ func (b *cmdDashboardBuilder) cmdDashboards() *cobra.Command {
 	b.org.register(b.viper, cmd, false)
 	cmd.Flags().StringArrayVarP(&b.ids, "id", "i", nil, "Dashboard ID to retrieve.")
 
+	cmd.AddCommand(b.cmdCopy())
+
 	return cmd
 }
 
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/influx/internal"
+	internal2 "github.com/influxdata/influxdb/v2/cmd/internal"
+	"github.com/influxdata/influxdb/v2/dashboards/transport"
+	ihttp "github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"github.com/influxdata/influxdb/v2/pkger"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/spf13/cobra"
+	"github.com/tcnksm/go-input"
+	"gopkg.in/yaml.v3"
+)
+
+// How a dashboard copy handles dashboards and variables whose name is
+// already taken in the destination organization.
+const (
+	dashboardConflictRename  = "rename"
+	dashboardConflictSkip    = "skip"
+	dashboardConflictReplace = "replace"
+)
+
+// Statuses of the resources of a dashboard copy.
+const (
+	dashboardCopyCreated  = "created"
+	dashboardCopyRenamed  = "renamed"
+	dashboardCopyReplaced = "replaced"
+	dashboardCopySkipped  = "skipped"
+)
+
+// dashboardCopySVCs are the services of one end of a dashboard copy.
+type dashboardCopySVCs struct {
+	dashboards influxdb.DashboardService
+	variables  influxdb.VariableService
+	orgs       influxdb.OrganizationService
+	templates  pkger.SVC
+}
+
+// dashboardCopySVCsFn returns the services of the source and destination
+// instances. An empty destination host or token is the source's.
+type dashboardCopySVCsFn func(destHost, destToken string) (src, dest dashboardCopySVCs, err error)
+
+type dashboardCopyFlags struct {
+	id            string
+	destOrg       organization
+	destHost      string
+	destToken     string
+	bucketMapping string
+	onConflict    string
+}
+
+// dashboardCopyResult is a resource of the destination organization touched
+// by a dashboard copy.
+type dashboardCopyResult struct {
+	Kind   pkger.Kind  `json:"kind"`
+	Name   string      `json:"name"`
+	ID     influxdb.ID `json:"id"`
+	Status string      `json:"status"`
+}
+
+func (b *cmdDashboardBuilder) cmdCopy() *cobra.Command {
+	cmd := b.newCmd("copy", b.copyRunE)
+	cmd.Short = "Copy a dashboard and its variables to another organization"
+	cmd.Long = `
+	Copy a dashboard, and the variables its queries use, to another organization.
+	The destination may be on another instance when --dest-host or --dest-token
+	are given. The dashboard and its variables are created all together, or not
+	at all.
+
+	The buckets queried by the dashboard are mapped to buckets of the destination
+	organization. The mapping is read from --bucket-mapping, a JSON or YAML object
+	of source to destination bucket names, or asked for each bucket otherwise.
+	Buckets missing from the mapping file keep their name.
+
+	Dashboards and variables whose name is taken in the destination organization
+	are handled by --on-conflict:
+		rename   copy them under a new name (default)
+		skip     keep the destination's and do not copy them
+		replace  overwrite the destination's with the copies
+
+	Examples:
+		# copy a dashboard to another organization of the same instance
+		influx dashboards copy --id $ID --dest-org other-org
+
+		# copy a dashboard to another instance, mapping buckets from a file
+		influx dashboards copy --id $ID --dest-org other-org \
+			--dest-host https://other.example.com --dest-token $TOKEN \
+			--bucket-mapping buckets.yml
+
+		# replace the dashboards and variables of the same name
+		influx dashboards copy --id $ID --dest-org other-org --on-conflict replace
+`
+
+	cmd.Flags().StringVarP(&b.copy.id, "id", "i", "", "ID of the dashboard to copy")
+	cmd.MarkFlagRequired("id")
+	cmd.Flags().StringVar(&b.copy.destOrg.name, "dest-org", "", "Name of the destination organization")
+	cmd.Flags().StringVar(&b.copy.destOrg.id, "dest-org-id", "", "ID of the destination organization")
+	cmd.Flags().StringVar(&b.copy.destHost, "dest-host", "", "HTTP address of the destination instance; defaults to the source's")
+	cmd.Flags().StringVar(&b.copy.destToken, "dest-token", "", "Token for the destination instance; defaults to the source's")
+	cmd.Flags().StringVar(&b.copy.bucketMapping, "bucket-mapping", "", "Path to a JSON or YAML file mapping source bucket names to destination bucket names")
+	cmd.Flags().StringVar(&b.copy.onConflict, "on-conflict", dashboardConflictRename, "How to handle names taken in the destination organization; one of rename, skip or replace")
+
+	return cmd
+}
+
+func (b *cmdDashboardBuilder) copyRunE(cmd *cobra.Command, args []string) error {
+	switch b.copy.onConflict {
+	case dashboardConflictRename, dashboardConflictSkip, dashboardConflictReplace:
+	default:
+		return fmt.Errorf("invalid --on-conflict %q; must be one of rename, skip or replace", b.copy.onConflict)
+	}
+	if b.copy.destOrg.id == "" && b.copy.destOrg.name == "" {
+		return errors.New("must specify dest-org-id, or dest-org name")
+	}
+	id, err := influxdb.IDFromString(b.copy.id)
+	if err != nil {
+		return err
+	}
+
+	src, dest, err := b.copySVCsFn(b.copy.destHost, b.copy.destToken)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	dash, err := src.dashboards.FindDashboardByID(ctx, *id)
+	if err != nil {
+		return err
+	}
+	destOrgID, err := b.copy.destOrg.getID(dest.orgs)
+	if err != nil {
+		return err
+	}
+
+	template, err := exportDashboard(ctx, src, dash)
+	if err != nil {
+		return err
+	}
+	mapping, err := b.bucketMapping(templateBuckets(template))
+	if err != nil {
+		return err
+	}
+	remapTemplateBuckets(template, mapping)
+
+	existing, err := destResourceNames(ctx, dest, destOrgID)
+	if err != nil {
+		return err
+	}
+	plan := resolveDashboardCopyConflicts(template, existing, b.copy.onConflict)
+
+	results := plan.skipped
+	if hasDashboard(template) {
+		impact, err := dest.templates.Apply(ctx, destOrgID, 0, pkger.ApplyWithTemplate(template))
+		if err != nil {
+			return err
+		}
+		for _, d := range impact.Summary.Dashboards {
+			results = append(results, plan.result(pkger.KindDashboard, d.Name, influxdb.ID(d.ID)))
+		}
+		for _, v := range impact.Summary.Variables {
+			results = append(results, plan.result(pkger.KindVariable, v.Name, influxdb.ID(v.ID)))
+		}
+
+		// Dashboards can't be updated by a template, so the ones replaced are
+		// only removed once their copy exists.
+		for _, replacedID := range plan.replaced {
+			if err := dest.dashboards.DeleteDashboard(ctx, replacedID); err != nil {
+				return fmt.Errorf("dashboard copied, but failed to remove the dashboard %s it replaces: %w", replacedID, err)
+			}
+		}
+	}
+
+	return b.writeDashboardCopyResults(results)
+}
+
+// bucketMapping returns the destination bucket of each of the source
+// buckets, read from the mapping file or asked for.
+func (b *cmdDashboardBuilder) bucketMapping(buckets []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(buckets))
+	if b.copy.bucketMapping != "" {
+		raw, err := ioutil.ReadFile(b.copy.bucketMapping)
+		if err != nil {
+			return nil, err
+		}
+		// JSON is valid YAML, so either is decoded as YAML.
+		if err := yaml.Unmarshal(raw, &mapping); err != nil {
+			return nil, fmt.Errorf("failed to decode bucket mapping %q: %w", b.copy.bucketMapping, err)
+		}
+		return mapping, nil
+	}
+
+	ui := &input.UI{Writer: b.w, Reader: b.in}
+	for _, bkt := range buckets {
+		mapping[bkt] = internal2.GetInput(ui, fmt.Sprintf("Destination bucket for %q", bkt), bkt)
+	}
+	return mapping, nil
+}
+
+func (b *cmdDashboardBuilder) writeDashboardCopyResults(results []dashboardCopyResult) error {
+	if b.json {
+		return b.writeJSON(results)
+	}
+
+	tabW := b.newTabWriter()
+	defer tabW.Flush()
+
+	writeDashboardCopyRows(tabW, results...)
+	return nil
+}
+
+func writeDashboardCopyRows(tabW *internal.TabWriter, results ...dashboardCopyResult) {
+	tabW.WriteHeaders("Kind", "ID", "Name", "Status")
+	for _, r := range results {
+		tabW.Write(map[string]interface{}{
+			"Kind":   r.Kind,
+			"ID":     r.ID,
+			"Name":   r.Name,
+			"Status": r.Status,
+		})
+	}
+}
+
+// exportDashboard exports the dashboard along with the variables of its
+// organization it depends on.
+func exportDashboard(ctx context.Context, src dashboardCopySVCs, dash *influxdb.Dashboard) (*pkger.Template, error) {
+	resources := []pkger.ResourceToClone{{Kind: pkger.KindDashboard, ID: dash.ID}}
+	template, err := src.templates.Export(ctx, pkger.ExportWithExistingResources(resources...))
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := src.variables.FindVariables(ctx, influxdb.VariableFilter{OrganizationID: &dash.OrganizationID})
+	if err != nil {
+		return nil, err
+	}
+	deps := variableDependencies(templateQueries(template), vars)
+	if len(deps) == 0 {
+		return template, nil
+	}
+	for _, v := range deps {
+		resources = append(resources, pkger.ResourceToClone{Kind: pkger.KindVariable, ID: v.ID})
+	}
+	return src.templates.Export(ctx, pkger.ExportWithExistingResources(resources...))
+}
+
+// destResourceNames returns the IDs of the dashboards and variables of the
+// destination organization by kind and name.
+func destResourceNames(ctx context.Context, dest dashboardCopySVCs, orgID influxdb.ID) (map[pkger.Kind]map[string][]influxdb.ID, error) {
+	names := map[pkger.Kind]map[string][]influxdb.ID{
+		pkger.KindDashboard: {},
+		pkger.KindVariable:  {},
+	}
+
+	const limit = 100
+	for offset := 0; ; {
+		dashboards, _, err := dest.dashboards.FindDashboards(ctx, influxdb.DashboardFilter{
+			OrganizationID: &orgID,
+		}, influxdb.FindOptions{
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil && influxdb.ErrorCode(err) != influxdb.ENotFound {
+			return nil, err
+		}
+		for _, d := range dashboards {
+			names[pkger.KindDashboard][d.Name] = append(names[pkger.KindDashboard][d.Name], d.ID)
+		}
+		if len(dashboards) < limit {
+			break
+		}
+		offset += len(dashboards)
+	}
+
+	vars, err := dest.variables.FindVariables(ctx, influxdb.VariableFilter{OrganizationID: &orgID})
+	if err != nil && influxdb.ErrorCode(err) != influxdb.ENotFound {
+		return nil, err
+	}
+	for _, v := range vars {
+		names[pkger.KindVariable][v.Name] = append(names[pkger.KindVariable][v.Name], v.ID)
+	}
+	return names, nil
+}
+
+// dashboardCopyPlan is how the conflicts of a dashboard copy are resolved.
+type dashboardCopyPlan struct {
+	// statuses of the copied resources by kind and destination name.
+	statuses map[pkger.Kind]map[string]string
+	// skipped are the resources of the destination kept in place of a copy.
+	skipped []dashboardCopyResult
+	// replaced are the dashboards of the destination removed once copied.
+	replaced []influxdb.ID
+}
+
+func (p dashboardCopyPlan) result(kind pkger.Kind, name string, id influxdb.ID) dashboardCopyResult {
+	status := p.statuses[kind][name]
+	if status == "" {
+		status = dashboardCopyCreated
+	}
+	return dashboardCopyResult{Kind: kind, Name: name, ID: id, Status: status}
+}
+
+// hasDashboard returns whether the template still holds a dashboard to copy.
+func hasDashboard(t *pkger.Template) bool {
+	for _, o := range t.Objects {
+		if o.Kind == pkger.KindDashboard {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDashboardCopyConflicts resolves the names of the template taken by
+// the existing resources according to policy, updating the template.
+func resolveDashboardCopyConflicts(t *pkger.Template, existing map[pkger.Kind]map[string][]influxdb.ID, policy string) dashboardCopyPlan {
+	plan := dashboardCopyPlan{
+		statuses: map[pkger.Kind]map[string]string{
+			pkger.KindDashboard: {},
+			pkger.KindVariable:  {},
+		},
+	}
+
+	renamedVars := make(map[string]string)
+	objects := t.Objects[:0]
+	for _, o := range t.Objects {
+		kind := copyResourceKind(o.Kind)
+		name := objectDisplayName(o)
+		ids, taken := existing[kind][name]
+		if kind == "" || !taken {
+			objects = append(objects, o)
+			continue
+		}
+
+		switch policy {
+		case dashboardConflictSkip:
+			for _, id := range ids {
+				plan.skipped = append(plan.skipped, dashboardCopyResult{Kind: kind, Name: name, ID: id, Status: dashboardCopySkipped})
+			}
+			continue
+		case dashboardConflictReplace:
+			// Variables of the same name are updated by the template.
+			plan.statuses[kind][name] = dashboardCopyReplaced
+			if kind == pkger.KindDashboard {
+				plan.replaced = append(plan.replaced, ids...)
+			}
+		default:
+			newName := uniqueCopyName(kind, name, existing[kind])
+			existing[kind][newName] = nil
+			o.Spec["name"] = newName
+			plan.statuses[kind][newName] = dashboardCopyRenamed
+			if kind == pkger.KindVariable {
+				renamedVars[name] = newName
+			}
+		}
+		objects = append(objects, o)
+	}
+	t.Objects = objects
+
+	for oldName, newName := range renamedVars {
+		ref := variableRefPattern(oldName)
+		rewriteTemplateQueries(t, func(q string) string {
+			return ref.ReplaceAllString(q, "${1}v."+newName)
+		})
+	}
+	return plan
+}
+
+// copyResourceKind returns the kind of the resources of a dashboard copy
+// whose names may conflict, or an empty kind for others.
+func copyResourceKind(k pkger.Kind) pkger.Kind {
+	switch k {
+	case pkger.KindDashboard, pkger.KindVariable:
+		return k
+	default:
+		return ""
+	}
+}
+
+// objectDisplayName returns the name the resource of o is created with.
+func objectDisplayName(o pkger.Object) string {
+	if name, ok := o.Spec["name"].(string); ok && name != "" {
+		return name
+	}
+	return o.Name()
+}
+
+// uniqueCopyName returns name suffixed with the first number not taken.
+// Variable names are referenced from queries, so they stay identifiers.
+func uniqueCopyName(kind pkger.Kind, name string, taken map[string][]influxdb.ID) string {
+	format := "%s (%d)"
+	if kind == pkger.KindVariable {
+		format = "%s_%d"
+	}
+	for i := 2; ; i++ {
+		newName := fmt.Sprintf(format, name, i)
+		if _, ok := taken[newName]; !ok {
+			return newName
+		}
+	}
+}
+
+// variableRefPattern matches the references to the variable name from a
+// query, capturing what precedes each.
+func variableRefPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^\w.])v\.` + regexp.QuoteMeta(name) + `\b`)
+}
+
+// variableDependencies returns the variables referenced by the queries,
+// including those referenced by the queries of the variables themselves.
+func variableDependencies(queries []string, vars []*influxdb.Variable) []*influxdb.Variable {
+	var deps []*influxdb.Variable
+	found := make(map[influxdb.ID]bool)
+	for len(queries) > 0 {
+		var next []string
+		for _, v := range vars {
+			if found[v.ID] || !anyMatch(variableRefPattern(v.Name), queries) {
+				continue
+			}
+			found[v.ID] = true
+			deps = append(deps, v)
+			if v.Arguments == nil {
+				continue
+			}
+			if q, ok := v.Arguments.Values.(influxdb.VariableQueryValues); ok {
+				next = append(next, q.Query)
+			}
+		}
+		queries = next
+	}
+	return deps
+}
+
+func anyMatch(re *regexp.Regexp, ss []string) bool {
+	for _, s := range ss {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// bucketRefPattern matches the buckets named in a query, capturing what
+// precedes the name and the name itself.
+var bucketRefPattern = regexp.MustCompile(`(\bbucket\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+
+// templateBuckets returns the sorted names of the buckets queried by the
+// template.
+func templateBuckets(t *pkger.Template) []string {
+	seen := make(map[string]bool)
+	var buckets []string
+	for _, q := range templateQueries(t) {
+		for _, m := range bucketRefPattern.FindAllStringSubmatch(q, -1) {
+			if !seen[m[2]] {
+				seen[m[2]] = true
+				buckets = append(buckets, m[2])
+			}
+		}
+	}
+	sort.Strings(buckets)
+	return buckets
+}
+
+// remapTemplateBuckets renames the buckets queried by the template
+// according to mapping. Buckets missing from mapping are left as is.
+func remapTemplateBuckets(t *pkger.Template, mapping map[string]string) {
+	rewriteTemplateQueries(t, func(q string) string {
+		return bucketRefPattern.ReplaceAllStringFunc(q, func(ref string) string {
+			m := bucketRefPattern.FindStringSubmatch(ref)
+			to, ok := mapping[m[2]]
+			if !ok {
+				return ref
+			}
+			return m[1] + `"` + strings.ReplaceAll(to, `"`, `\"`) + `"`
+		})
+	})
+}
+
+// templateQueries returns the queries of the dashboards and variables of
+// the template.
+func templateQueries(t *pkger.Template) []string {
+	var queries []string
+	rewriteTemplateQueries(t, func(q string) string {
+		queries = append(queries, q)
+		return q
+	})
+	return queries
+}
+
+// rewriteTemplateQueries replaces each query of the dashboards and variables
+// of the template with the one returned by fn.
+func rewriteTemplateQueries(t *pkger.Template, fn func(query string) string) {
+	for _, o := range t.Objects {
+		if copyResourceKind(o.Kind) != "" {
+			rewriteQueries(o.Spec, fn)
+		}
+	}
+}
+
+func rewriteQueries(v interface{}, fn func(query string) string) {
+	switch v := v.(type) {
+	case pkger.Resource:
+		rewriteQueries(map[string]interface{}(v), fn)
+	case map[string]interface{}:
+		for k, val := range v {
+			if q, ok := val.(string); ok && k == "query" {
+				v[k] = fn(q)
+				continue
+			}
+			rewriteQueries(val, fn)
+		}
+	case []pkger.Resource:
+		for _, r := range v {
+			rewriteQueries(r, fn)
+		}
+	case []interface{}:
+		for _, e := range v {
+			rewriteQueries(e, fn)
+		}
+	}
+}
+
+func newDashboardCopySVCs(destHost, destToken string) (dashboardCopySVCs, dashboardCopySVCs, error) {
+	srcClient, err := newHTTPClient()
+	if err != nil {
+		return dashboardCopySVCs{}, dashboardCopySVCs{}, err
+	}
+
+	destClient := srcClient
+	if destHost != "" || destToken != "" {
+		ac := flags.config()
+		if destHost == "" {
+			destHost = ac.Host
+		}
+		if destToken == "" {
+			destToken = ac.Token
+		}
+		if destClient, err = newHTTPClientFor(destHost, destToken); err != nil {
+			return dashboardCopySVCs{}, dashboardCopySVCs{}, err
+		}
+	}
+
+	newSVCs := func(client *httpc.Client) dashboardCopySVCs {
+		return dashboardCopySVCs{
+			dashboards: &transport.DashboardService{Client: client},
+			variables:  &ihttp.VariableService{Client: client},
+			orgs:       &tenant.OrgClientService{Client: client},
+			templates:  &pkger.HTTPRemoteService{Client: client},
+		}
+	}
+	return newSVCs(srcClient), newSVCs(destClient), nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/pkger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dashboardCopyTemplate = `[
+	{
+		"apiVersion": "influxdata.com/v2alpha1",
+		"kind": "Dashboard",
+		"metadata": {"name": "dash-1"},
+		"spec": {
+			"name": "System",
+			"charts": [
+				{
+					"kind": "Single_Stat",
+					"name": "CPU",
+					"width": 4,
+					"height": 3,
+					"queries": [
+						{"query": "from(bucket: \"telegraf\") |> range(start: v.timeRangeStart) |> filter(fn: (r) => r.host == v.host)"}
+					]
+				}
+			]
+		}
+	},
+	{
+		"apiVersion": "influxdata.com/v2alpha1",
+		"kind": "Variable",
+		"metadata": {"name": "var-1"},
+		"spec": {
+			"name": "host",
+			"type": "query",
+			"language": "flux",
+			"query": "import \"influxdata/influxdb/v1\" v1.tagValues(bucket: \"telegraf\", tag: \"host\")"
+		}
+	}
+]`
+
+func newDashboardCopyTemplate(t *testing.T) *pkger.Template {
+	t.Helper()
+	var template pkger.Template
+	require.NoError(t, json.Unmarshal([]byte(dashboardCopyTemplate), &template.Objects))
+	return &template
+}
+
+func TestVariableDependencies(t *testing.T) {
+	queryVar := func(id influxdb.ID, name, query string) *influxdb.Variable {
+		return &influxdb.Variable{
+			ID:   id,
+			Name: name,
+			Arguments: &influxdb.VariableArguments{
+				Type:   "query",
+				Values: influxdb.VariableQueryValues{Query: query, Language: "flux"},
+			},
+		}
+	}
+	vars := []*influxdb.Variable{
+		queryVar(1, "host", `from(bucket: "b") |> filter(fn: (r) => r.region == v.region)`),
+		queryVar(2, "region", `buckets()`),
+		queryVar(3, "hostname", `buckets()`),
+		queryVar(4, "unused", `buckets()`),
+	}
+
+	deps := variableDependencies([]string{`from(bucket: "b") |> filter(fn: (r) => r.host == v.host)`}, vars)
+
+	var names []string
+	for _, v := range deps {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"host", "region"}, names)
+}
+
+func TestRemapTemplateBuckets(t *testing.T) {
+	template := newDashboardCopyTemplate(t)
+	assert.Equal(t, []string{"telegraf"}, templateBuckets(template))
+
+	remapTemplateBuckets(template, map[string]string{"telegraf": "metrics"})
+
+	queries := templateQueries(template)
+	require.Len(t, queries, 2)
+	for _, q := range queries {
+		assert.Contains(t, q, `bucket: "metrics"`)
+		assert.NotContains(t, q, "telegraf")
+	}
+}
+
+func TestResolveDashboardCopyConflicts(t *testing.T) {
+	existing := func() map[pkger.Kind]map[string][]influxdb.ID {
+		return map[pkger.Kind]map[string][]influxdb.ID{
+			pkger.KindDashboard: {"System": {10}, "System (2)": {11}},
+			pkger.KindVariable:  {"host": {20}},
+		}
+	}
+
+	t.Run("rename", func(t *testing.T) {
+		template := newDashboardCopyTemplate(t)
+		plan := resolveDashboardCopyConflicts(template, existing(), dashboardConflictRename)
+
+		require.Len(t, template.Objects, 2)
+		assert.Equal(t, "System (3)", objectDisplayName(template.Objects[0]))
+		assert.Equal(t, "host_2", objectDisplayName(template.Objects[1]))
+		assert.Contains(t, templateQueries(template)[0], "r.host == v.host_2")
+		assert.Equal(t, dashboardCopyRenamed, plan.result(pkger.KindDashboard, "System (3)", 1).Status)
+		assert.Empty(t, plan.skipped)
+		assert.Empty(t, plan.replaced)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		template := newDashboardCopyTemplate(t)
+		plan := resolveDashboardCopyConflicts(template, existing(), dashboardConflictSkip)
+
+		assert.Empty(t, template.Objects)
+		assert.False(t, hasDashboard(template))
+		assert.Equal(t, []dashboardCopyResult{
+			{Kind: pkger.KindDashboard, Name: "System", ID: 10, Status: dashboardCopySkipped},
+			{Kind: pkger.KindVariable, Name: "host", ID: 20, Status: dashboardCopySkipped},
+		}, plan.skipped)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		template := newDashboardCopyTemplate(t)
+		plan := resolveDashboardCopyConflicts(template, existing(), dashboardConflictReplace)
+
+		require.Len(t, template.Objects, 2)
+		assert.Equal(t, "System", objectDisplayName(template.Objects[0]))
+		assert.Equal(t, []influxdb.ID{10}, plan.replaced)
+		assert.Equal(t, dashboardCopyReplaced, plan.result(pkger.KindVariable, "host", 20).Status)
+	})
+
+	t.Run("no conflict", func(t *testing.T) {
+		template := newDashboardCopyTemplate(t)
+		plan := resolveDashboardCopyConflicts(template, map[pkger.Kind]map[string][]influxdb.ID{}, dashboardConflictSkip)
+
+		require.Len(t, template.Objects, 2)
+		assert.Equal(t, dashboardCopyCreated, plan.result(pkger.KindDashboard, "System", 1).Status)
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -41,13 +42,27 @@ func main() {
 
 	influxCmd := influxCmd()
 	if err := influxCmd.Execute(); err != nil {
+		var exitErr exitCoder
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
 		seeHelp(influxCmd, nil)
 		os.Exit(1)
 	}
 }
 
+// exitCoder is implemented by errors that exit the CLI with a code other
+// than 1.
+type exitCoder interface {
+	ExitCode() int
+}
+
 var (
 	httpClient *httpc.Client
+
+	// responseStats records the responses of the command when --verbose
+	// is set.
+	responseStats *responseStatsRecorder
 )
 
 func newHTTPClient() (*httpc.Client, error) {
@@ -55,6 +70,20 @@ func newHTTPClient() (*httpc.Client, error) {
 		return httpClient, nil
 	}
 
+	ac := flags.config()
+	c, err := newHTTPClientFor(ac.Host, ac.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient = c
+	return httpClient, nil
+}
+
+// newHTTPClientFor returns a client for the instance at host, authenticated
+// with token, sharing the options of the default client. Options in extra are
+// applied last.
+func newHTTPClientFor(host, token string, extra ...httpc.ClientOptFn) (*httpc.Client, error) {
 	userAgent := fmt.Sprintf(
 		"influx/%s (%s) Sha/%s Date/%s",
 		version, runtime.GOOS, commit, date,
@@ -68,14 +97,8 @@ func newHTTPClient() (*httpc.Client, error) {
 		opts = append(opts, httpc.WithHeader("jaeger-debug-id", flags.traceDebugID))
 	}
 
-	ac := flags.config()
-	c, err := http.NewHTTPClient(ac.Host, ac.Token, flags.skipVerify, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	httpClient = c
-	return httpClient, nil
+	opts = append(opts, extra...)
+	return http.NewHTTPClient(host, token, flags.skipVerify, opts...)
 }
 
 type (
@@ -151,6 +174,7 @@ type globalFlags struct {
 	traceDebugID string
 	filepath     string
 	activeConfig string
+	verbose      bool
 	configs      config.Configs
 }
 
@@ -212,6 +236,11 @@ func (g *globalFlags) registerFlags(v *viper.Viper, cmd *cobra.Command, skipFlag
 			Desc:  "Config name to use for command",
 			Short: 'c',
 		},
+		{
+			DestP: &g.verbose,
+			Flag:  "verbose",
+			Desc:  "Print the statistics InfluxDB reports about the requests of the command",
+		},
 	}
 
 	var filtered flagOpts
@@ -292,6 +321,16 @@ func (b *cmdInfluxBuilder) cmd(childCmdFns ...func(f *globalFlags, opt genericCL
 			cfg.Host = flags.host
 		}
 		flags.configs[cfg.Name] = cfg
+
+		if flags.verbose && responseStats == nil {
+			responseStats = recordResponseStats()
+		}
+	}
+
+	cmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		if responseStats != nil {
+			responseStats.print(b.errW)
+		}
 	}
 
 	// Update help description for all commands in command tree
@@ -328,11 +367,14 @@ func influxCmd(opts ...genericCLIOptFn) *cobra.Command {
 		cmdDashboard,
 		cmdDelete,
 		cmdExport,
+		cmdNotification,
 		cmdOrganization,
 		cmdPing,
 		cmdQuery,
 		cmdRestore,
+		cmdScraper,
 		cmdSecret,
+		cmdServer,
 		cmdSetup,
 		cmdStack,
 		cmdTask,
@@ -340,6 +382,7 @@ func influxCmd(opts ...genericCLIOptFn) *cobra.Command {
 		cmdTemplate,
 		cmdApply,
 		cmdTranspile,
+		cmdUsage,
 		cmdUser,
 		cmdWrite,
 		cmdV1SubCommands,
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/spf13/cobra"
+)
+
+func cmdNotification(f *globalFlags, opts genericCLIOpts) *cobra.Command {
+	return newCmdNotificationBuilder(f, opts).cmd()
+}
+
+type notificationDeliveryFinder interface {
+	FindNotificationDeliveries(ctx context.Context, endpointID influxdb.ID) ([]*influxdb.NotificationDelivery, error)
+}
+
+type cmdNotificationBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	endpointID string
+
+	deliveryFinder notificationDeliveryFinder
+}
+
+func newCmdNotificationBuilder(f *globalFlags, opts genericCLIOpts) *cmdNotificationBuilder {
+	return &cmdNotificationBuilder{
+		genericCLIOpts: opts,
+		globalFlags:    f,
+	}
+}
+
+func (b *cmdNotificationBuilder) cmd() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("notification", nil, false)
+	cmd.Short = "Notification management commands"
+	cmd.Run = seeHelp
+	cmd.AddCommand(b.cmdEndpoint())
+	return cmd
+}
+
+func (b *cmdNotificationBuilder) cmdEndpoint() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("endpoint", nil, false)
+	cmd.Short = "Notification endpoint management commands"
+	cmd.Run = seeHelp
+	cmd.AddCommand(b.cmdDeliveries())
+	return cmd
+}
+
+func (b *cmdNotificationBuilder) cmdDeliveries() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("deliveries", b.deliveriesRunE, true)
+	b.genericCLIOpts.registerPrintOptions(cmd)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	cmd.Flags().StringVarP(&b.endpointID, "id", "i", "", "The ID of the notification endpoint (required)")
+	cmd.MarkFlagRequired("id")
+	cmd.Short = "List the recent delivery attempts of a notification endpoint"
+	cmd.Long = `
+Lists the most recent attempts of notification rules to send notifications
+through an endpoint, newest first, with the HTTP status or error returned by
+the 3rd party. Attempts are recorded even when the task run of the rule
+succeeded. Request and response bodies, shown with --json, are truncated and
+have the secrets of the endpoint redacted.
+
+Examples:
+	# list the delivery attempts of an endpoint
+	influx notification endpoint deliveries --id $ENDPOINT_ID
+`
+	return cmd
+}
+
+func (b *cmdNotificationBuilder) deliveriesRunE(cmd *cobra.Command, args []string) error {
+	id, err := influxdb.IDFromString(b.endpointID)
+	if err != nil {
+		return fmt.Errorf("failed to decode endpoint id %q: %v", b.endpointID, err)
+	}
+	if b.deliveryFinder == nil {
+		client, err := newHTTPClient()
+		if err != nil {
+			return err
+		}
+		b.deliveryFinder = http.NewNotificationEndpointService(client)
+	}
+
+	deliveries, err := b.deliveryFinder.FindNotificationDeliveries(context.Background(), *id)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve notification deliveries: %v", err)
+	}
+	return b.printDeliveries(deliveries)
+}
+
+func (b *cmdNotificationBuilder) printDeliveries(deliveries []*influxdb.NotificationDelivery) error {
+	if b.json {
+		return b.writeJSON(deliveries)
+	}
+
+	tabW := b.newTabWriter()
+	defer tabW.Flush()
+
+	tabW.WriteHeaders("Time", "Rule ID", "Host", "Status", "Latency", "Error")
+	for _, d := range deliveries {
+		status := "failed"
+		if d.Succeeded() {
+			status = "ok"
+		}
+		if d.StatusCode != 0 {
+			status = fmt.Sprintf("%s (%d)", status, d.StatusCode)
+		}
+		errMsg := d.Error
+		if errMsg == "" && !d.Succeeded() {
+			errMsg = d.ResponseBody
+		}
+		tabW.Write(map[string]interface{}{
+			"Time":    d.Time.Format(time.RFC3339),
+			"Rule ID": d.RuleID,
+			"Host":    d.Host,
+			"Status":  status,
+			"Latency": d.Latency.Duration,
+			"Error":   strings.Join(strings.Fields(errMsg), " "),
+		})
+	}
+	return nil
+}
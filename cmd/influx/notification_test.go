@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDeliveryFinder struct {
+	endpointID influxdb.ID
+	deliveries []*influxdb.NotificationDelivery
+}
+
+func (f *fakeDeliveryFinder) FindNotificationDeliveries(ctx context.Context, endpointID influxdb.ID) ([]*influxdb.NotificationDelivery, error) {
+	f.endpointID = endpointID
+	return f.deliveries, nil
+}
+
+func TestCmdNotificationEndpointDeliveries(t *testing.T) {
+	now := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)
+	finder := &fakeDeliveryFinder{deliveries: []*influxdb.NotificationDelivery{
+		{EndpointID: 3, RuleID: 2, Time: now, Host: "https://hooks.slack.com", StatusCode: 404, Latency: influxdb.Duration{Duration: 120 * time.Millisecond}, ResponseBody: "no_service"},
+		{EndpointID: 3, RuleID: 2, Time: now.Add(-time.Minute), Host: "https://hooks.slack.com", StatusCode: 200, Latency: influxdb.Duration{Duration: 80 * time.Millisecond}},
+		{EndpointID: 3, RuleID: 2, Time: now.Add(-2 * time.Minute), Host: "https://hooks.slack.com", Error: "connection\nrefused"},
+	}}
+
+	out := new(bytes.Buffer)
+	b := newCmdNotificationBuilder(&globalFlags{}, genericCLIOpts{w: out})
+	b.deliveryFinder = finder
+	b.endpointID = influxdb.ID(3).String()
+	require.NoError(t, b.deliveriesRunE(nil, nil))
+	assert.Equal(t, influxdb.ID(3), finder.endpointID)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"2020-01-01T01:00:00Z", "0000000000000002", "https://hooks.slack.com", "failed", "(404)", "120ms", "no_service"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"2020-01-01T00:59:00Z", "0000000000000002", "https://hooks.slack.com", "ok", "(200)", "80ms"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"2020-01-01T00:58:00Z", "0000000000000002", "https://hooks.slack.com", "failed", "0s", "connection", "refused"}, strings.Fields(lines[3]))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// printOperationLog writes operation log entries as JSON or a table.
+func printOperationLog(opts genericCLIOpts, json, hideHeaders bool, entries []*influxdb.OperationLogEntry) error {
+	if json {
+		return opts.writeJSON(entries)
+	}
+
+	w := opts.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(hideHeaders)
+	w.WriteHeaders("Time", "Operation", "Fields", "User ID", "Description")
+
+	for _, e := range entries {
+		userID := ""
+		if e.UserID.Valid() {
+			userID = e.UserID.String()
+		}
+		w.Write(map[string]interface{}{
+			"Time":        e.Time.UTC().Format(time.RFC3339Nano),
+			"Operation":   e.Operation,
+			"Fields":      strings.Join(e.Fields, ","),
+			"User ID":     userID,
+			"Description": e.Description,
+		})
+	}
+
+	return nil
+}
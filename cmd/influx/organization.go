@@ -6,12 +6,15 @@ import (
 	"io"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/internal"
 	"github.com/influxdata/influxdb/v2/tenant"
 	"github.com/spf13/cobra"
 )
 
 type orgSVCFn func() (influxdb.OrganizationService, influxdb.UserResourceMappingService, influxdb.UserService, error)
 
+type orgLogSVCFn func() (influxdb.OrganizationOperationLogService, error)
+
 func cmdOrganization(f *globalFlags, opts genericCLIOpts) *cobra.Command {
 	builder := newCmdOrgBuilder(newOrgServices, f, opts)
 	return builder.cmd()
@@ -21,7 +24,9 @@ type cmdOrgBuilder struct {
 	genericCLIOpts
 	*globalFlags
 
-	svcFn orgSVCFn
+	svcFn       orgSVCFn
+	logSVCFn    orgLogSVCFn
+	inviteSVCFn inviteSVCFn
 
 	json        bool
 	hideHeaders bool
@@ -29,6 +34,14 @@ type cmdOrgBuilder struct {
 	id          string
 	memberID    string
 	name        string
+	limit       int
+	offset      int
+
+	defaultRetention           string
+	defaultShardGroupDuration  string
+	defaultDescriptionTemplate string
+
+	invite orgInviteFlags
 }
 
 func newCmdOrgBuilder(svcFn orgSVCFn, f *globalFlags, opts genericCLIOpts) *cmdOrgBuilder {
@@ -36,6 +49,8 @@ func newCmdOrgBuilder(svcFn orgSVCFn, f *globalFlags, opts genericCLIOpts) *cmdO
 		genericCLIOpts: opts,
 		globalFlags:    f,
 		svcFn:          svcFn,
+		logSVCFn:       newOrgLogService,
+		inviteSVCFn:    newInviteService,
 	}
 }
 
@@ -49,6 +64,8 @@ func (b *cmdOrgBuilder) cmd() *cobra.Command {
 		b.cmdCreate(),
 		b.cmdDelete(),
 		b.cmdFind(),
+		b.cmdHistory(),
+		b.cmdInvite(),
 		b.cmdMember(),
 		b.cmdUpdate(),
 	)
@@ -216,6 +233,9 @@ func (b *cmdOrgBuilder) cmdUpdate() *cobra.Command {
 	}
 	opts.mustRegister(b.viper, cmd)
 	b.registerPrintFlags(cmd)
+	cmd.Flags().StringVar(&b.defaultRetention, "default-retention", "", "Retention of buckets created without one. 0 is infinite")
+	cmd.Flags().StringVar(&b.defaultShardGroupDuration, "default-shard-group-duration", "", "Shard group duration of buckets created without one. 0 derives it from the retention")
+	cmd.Flags().StringVar(&b.defaultDescriptionTemplate, "default-description-template", "", "Template of the description of buckets created without one, e.g. '{{.Name}} of {{.Org}}'")
 
 	return cmd
 }
@@ -238,6 +258,9 @@ func (b *cmdOrgBuilder) updateRunEFn(cmd *cobra.Command, args []string) error {
 	if b.description != "" {
 		update.Description = &b.description
 	}
+	if update.BucketDefaults, err = b.bucketDefaultsUpdate(cmd, orgSvc, id); err != nil {
+		return err
+	}
 
 	o, err := orgSvc.UpdateOrganization(context.Background(), id, update)
 	if err != nil {
@@ -247,6 +270,107 @@ func (b *cmdOrgBuilder) updateRunEFn(cmd *cobra.Command, args []string) error {
 	return b.printOrg(orgPrintOpt{org: o})
 }
 
+// bucketDefaultsUpdate returns the bucket defaults of the organization with
+// the defaults set by flags changed, or nil if no default is set.
+func (b *cmdOrgBuilder) bucketDefaultsUpdate(cmd *cobra.Command, orgSvc influxdb.OrganizationService, id influxdb.ID) (*influxdb.BucketDefaults, error) {
+	flags := cmd.Flags()
+	if !flags.Changed("default-retention") && !flags.Changed("default-shard-group-duration") && !flags.Changed("default-description-template") {
+		return nil, nil
+	}
+
+	o, err := orgSvc.FindOrganizationByID(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find org: %v", err)
+	}
+	defaults := o.BucketDefaults
+
+	if flags.Changed("default-retention") {
+		if defaults.RetentionPeriod.Duration, err = internal.RawDurationToTimeDuration(b.defaultRetention); err != nil {
+			return nil, fmt.Errorf("invalid default retention %q: %v", b.defaultRetention, err)
+		}
+	}
+	if flags.Changed("default-shard-group-duration") {
+		if defaults.ShardGroupDuration.Duration, err = internal.RawDurationToTimeDuration(b.defaultShardGroupDuration); err != nil {
+			return nil, fmt.Errorf("invalid default shard group duration %q: %v", b.defaultShardGroupDuration, err)
+		}
+	}
+	if flags.Changed("default-description-template") {
+		defaults.DescriptionTemplate = b.defaultDescriptionTemplate
+	}
+	return &defaults, nil
+}
+
+func (b *cmdOrgBuilder) cmdHistory() *cobra.Command {
+	cmd := b.newCmd("history", b.historyRunEFn)
+	cmd.Short = "List the operation log of an organization"
+
+	opts := flagOpts{
+		{
+			DestP:  &b.name,
+			Flag:   "name",
+			Short:  'n',
+			EnvVar: "ORG",
+			Desc:   "The organization name",
+		},
+		{
+			DestP:  &b.id,
+			Flag:   "id",
+			Short:  'i',
+			EnvVar: "ORG_ID",
+			Desc:   "The organization ID",
+		},
+	}
+	opts.mustRegister(b.viper, cmd)
+	cmd.Flags().IntVar(&b.limit, "limit", influxdb.DefaultPageSize, "The maximum number of entries to list")
+	cmd.Flags().IntVar(&b.offset, "offset", 0, "The number of most recent entries to skip")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdOrgBuilder) historyRunEFn(cmd *cobra.Command, args []string) error {
+	if b.id == "" && b.name == "" {
+		return fmt.Errorf("must specify org ID or org name")
+	}
+
+	orgSvc, _, _, err := b.svcFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize org service client: %v", err)
+	}
+	logSvc, err := b.logSVCFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize org log service client: %v", err)
+	}
+
+	filter := influxdb.OrganizationFilter{}
+	if b.id != "" {
+		id, err := influxdb.IDFromString(b.id)
+		if err != nil {
+			return fmt.Errorf("failed to decode org id %s: %v", b.id, err)
+		}
+		filter.ID = id
+	} else {
+		filter.Name = &b.name
+	}
+
+	ctx := context.Background()
+	o, err := orgSvc.FindOrganization(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to find org: %v", err)
+	}
+
+	entries, _, err := logSvc.GetOrganizationOperationLog(ctx, o.ID, influxdb.FindOptions{
+		Limit:      b.limit,
+		Offset:     b.offset,
+		Descending: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve org history: %v", err)
+	}
+
+	return printOperationLog(b.genericCLIOpts, b.json, b.hideHeaders, entries)
+}
+
 func (b *cmdOrgBuilder) printOrg(opts orgPrintOpt) error {
 	if b.json {
 		var v interface{} = opts.orgs
@@ -532,6 +656,15 @@ func newOrgServices() (influxdb.OrganizationService, influxdb.UserResourceMappin
 	return orgSVC, urmSVC, userSVC, nil
 }
 
+func newOrgLogService() (influxdb.OrganizationOperationLogService, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant.OrgClientService{Client: client}, nil
+}
+
 func newOrganizationService() (influxdb.OrganizationService, error) {
 	client, err := newHTTPClient()
 	if err != nil {
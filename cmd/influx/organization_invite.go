@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/internal"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/spf13/cobra"
+	"github.com/tcnksm/go-input"
+)
+
+type inviteSVCFn func() (influxdb.InviteService, error)
+
+func newInviteService() (influxdb.InviteService, error) {
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return &tenant.InviteClientService{Client: client}, nil
+}
+
+type orgInviteFlags struct {
+	inviteID  string
+	user      string
+	role      string
+	expiresIn time.Duration
+	token     string
+	password  string
+}
+
+func (b *cmdOrgBuilder) cmdInvite() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("invite", nil, false)
+	cmd.Short = "Invite users to an organization"
+	cmd.Long = `
+Invites create an inactive user in an organization along with a one-time
+setup token. The invited user accepts the invite by choosing a password
+with the token, which activates them as a member or owner of the
+organization. Setup tokens expire, and can be replaced by resending the
+invite.
+
+Examples:
+	# invite a member and print their setup token
+	influx org invite create -n my-org --user jane
+
+	# accept the invite as the invited user
+	influx org invite accept --setup-token $SETUP_TOKEN
+`
+	cmd.Run = seeHelp
+
+	cmd.AddCommand(
+		b.cmdInviteAccept(),
+		b.cmdInviteCreate(),
+		b.cmdInviteList(),
+		b.cmdInviteResend(),
+		b.cmdInviteRevoke(),
+	)
+
+	return cmd
+}
+
+func (b *cmdOrgBuilder) registerInviteOrgFlags(cmd *cobra.Command) {
+	opts := flagOpts{
+		{
+			DestP:  &b.name,
+			Flag:   "name",
+			Short:  'n',
+			EnvVar: "ORG",
+			Desc:   "The organization name",
+		},
+		{
+			DestP:  &b.id,
+			Flag:   "id",
+			Short:  'i',
+			EnvVar: "ORG_ID",
+			Desc:   "The organization ID",
+		},
+	}
+	opts.mustRegister(b.viper, cmd)
+}
+
+func (b *cmdOrgBuilder) cmdInviteCreate() *cobra.Command {
+	cmd := b.newCmd("create", b.inviteCreateRunEFn)
+	cmd.Short = "Invite a user to an organization"
+
+	b.registerInviteOrgFlags(cmd)
+	cmd.Flags().StringVarP(&b.invite.user, "user", "u", "", "The name of the user to invite")
+	cmd.MarkFlagRequired("user")
+	cmd.Flags().StringVar(&b.invite.role, "role", string(influxdb.Member), "The role of the user in the organization, member or owner")
+	cmd.Flags().DurationVar(&b.invite.expiresIn, "expires-in", influxdb.DefaultInviteTTL, "How long the setup token is valid for")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdOrgBuilder) inviteCreateRunEFn(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	orgID, err := b.inviteOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
+	inviteSvc, err := b.inviteSVCFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize invite service client: %v", err)
+	}
+
+	invite, err := inviteSvc.CreateInvite(ctx, influxdb.InviteCreate{
+		OrgID: orgID,
+		Name:  b.invite.user,
+		Role:  influxdb.UserType(b.invite.role),
+		TTL:   b.invite.expiresIn,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create invite: %v", err)
+	}
+
+	return b.printInviteTokens(invite)
+}
+
+func (b *cmdOrgBuilder) cmdInviteList() *cobra.Command {
+	cmd := b.newCmd("list", b.inviteListRunEFn)
+	cmd.Short = "List the invites of an organization"
+	cmd.Aliases = []string{"find", "ls"}
+
+	b.registerInviteOrgFlags(cmd)
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdOrgBuilder) inviteListRunEFn(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	orgID, err := b.inviteOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
+	inviteSvc, err := b.inviteSVCFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize invite service client: %v", err)
+	}
+
+	invites, err := inviteSvc.FindInvites(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to list invites: %v", err)
+	}
+
+	return b.printInvites(invites)
+}
+
+func (b *cmdOrgBuilder) cmdInviteResend() *cobra.Command {
+	cmd := b.newCmd("resend", b.inviteResendRunEFn)
+	cmd.Short = "Replace the setup token of a pending invite"
+
+	cmd.Flags().StringVar(&b.invite.inviteID, "invite-id", "", "The invite ID")
+	cmd.MarkFlagRequired("invite-id")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdOrgBuilder) inviteResendRunEFn(cmd *cobra.Command, args []string) error {
+	id, err := influxdb.IDFromString(b.invite.inviteID)
+	if err != nil {
+		return fmt.Errorf("failed to decode invite id %s: %v", b.invite.inviteID, err)
+	}
+
+	inviteSvc, err := b.inviteSVCFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize invite service client: %v", err)
+	}
+
+	invite, err := inviteSvc.ResendInvite(context.Background(), *id)
+	if err != nil {
+		return fmt.Errorf("failed to resend invite: %v", err)
+	}
+
+	return b.printInviteTokens(invite)
+}
+
+func (b *cmdOrgBuilder) cmdInviteRevoke() *cobra.Command {
+	cmd := b.newCmd("revoke", b.inviteRevokeRunEFn)
+	cmd.Short = "Revoke a pending invite and remove its user"
+
+	cmd.Flags().StringVar(&b.invite.inviteID, "invite-id", "", "The invite ID")
+	cmd.MarkFlagRequired("invite-id")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdOrgBuilder) inviteRevokeRunEFn(cmd *cobra.Command, args []string) error {
+	id, err := influxdb.IDFromString(b.invite.inviteID)
+	if err != nil {
+		return fmt.Errorf("failed to decode invite id %s: %v", b.invite.inviteID, err)
+	}
+
+	inviteSvc, err := b.inviteSVCFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize invite service client: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := inviteSvc.RevokeInvite(ctx, *id); err != nil {
+		return fmt.Errorf("failed to revoke invite: %v", err)
+	}
+
+	invite, err := inviteSvc.FindInviteByID(ctx, *id)
+	if err != nil {
+		return fmt.Errorf("failed to find revoked invite: %v", err)
+	}
+	return b.printInvites([]*influxdb.Invite{invite})
+}
+
+func (b *cmdOrgBuilder) cmdInviteAccept() *cobra.Command {
+	cmd := b.newCmd("accept", b.inviteAcceptRunEFn)
+	cmd.Short = "Accept an invite by choosing a password"
+	cmd.Long = `
+Accepts an invite with its setup token. The password of the invited user
+is prompted for unless given with --password. No API token is required.
+`
+
+	cmd.Flags().StringVar(&b.invite.token, "setup-token", "", "The setup token of the invite")
+	cmd.MarkFlagRequired("setup-token")
+	cmd.Flags().StringVarP(&b.invite.password, "password", "p", "", "The password of the invited user")
+	b.registerPrintFlags(cmd)
+
+	return cmd
+}
+
+func (b *cmdOrgBuilder) inviteAcceptRunEFn(cmd *cobra.Command, args []string) error {
+	password := b.invite.password
+	if password == "" {
+		ui := &input.UI{
+			Writer: b.genericCLIOpts.w,
+			Reader: b.genericCLIOpts.in,
+		}
+		password = internal.GetPassword(ui, true)
+	}
+
+	inviteSvc, err := b.inviteSVCFn()
+	if err != nil {
+		return fmt.Errorf("failed to initialize invite service client: %v", err)
+	}
+
+	invite, err := inviteSvc.AcceptInvite(context.Background(), influxdb.InviteAccept{
+		Token:    b.invite.token,
+		Password: password,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to accept invite: %v", err)
+	}
+
+	return b.printInvites([]*influxdb.Invite{invite})
+}
+
+// inviteOrgID returns the ID of the organization given by ID or name.
+func (b *cmdOrgBuilder) inviteOrgID(ctx context.Context) (influxdb.ID, error) {
+	if (b.id == "") == (b.name == "") {
+		return 0, fmt.Errorf("must specify exactly one of id and name")
+	}
+	if b.id != "" {
+		id, err := influxdb.IDFromString(b.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode org id %s: %v", b.id, err)
+		}
+		return *id, nil
+	}
+
+	orgSvc, _, _, err := b.svcFn()
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize org service client: %v", err)
+	}
+	o, err := orgSvc.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &b.name})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find org: %v", err)
+	}
+	return o.ID, nil
+}
+
+func (b *cmdOrgBuilder) printInvites(invites []*influxdb.Invite) error {
+	if b.json {
+		return b.writeJSON(invites)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+	w.WriteHeaders("ID", "User", "Role", "Status", "Expires At")
+	for _, i := range invites {
+		w.Write(inviteRow(i))
+	}
+	return nil
+}
+
+func (b *cmdOrgBuilder) printInviteTokens(invites ...*influxdb.InviteToken) error {
+	if b.json {
+		return b.writeJSON(invites)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+	w.WriteHeaders("ID", "User", "Role", "Status", "Expires At", "Setup Token")
+	for _, i := range invites {
+		row := inviteRow(i.Invite)
+		row["Setup Token"] = i.Token
+		w.Write(row)
+	}
+	return nil
+}
+
+func inviteRow(i *influxdb.Invite) map[string]interface{} {
+	status := i.Status
+	if i.Expired(time.Now()) {
+		status = "expired"
+	}
+	return map[string]interface{}{
+		"ID":         i.ID.String(),
+		"User":       i.Name,
+		"Role":       i.Role,
+		"Status":     status,
+		"Expires At": i.ExpiresAt.Format(time.RFC3339),
+	}
+}
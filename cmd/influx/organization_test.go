@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -282,6 +283,33 @@ func TestCmdOrg(t *testing.T) {
 					Description: strPtr("desc"),
 				},
 			},
+			{
+				name: "bucket defaults",
+				flags: []string{
+					"--id=" + influxdb.ID(3).String(),
+					"--default-retention=30d",
+					"--default-shard-group-duration=1d",
+				},
+				expected: influxdb.OrganizationUpdate{
+					BucketDefaults: &influxdb.BucketDefaults{
+						RetentionPeriod:     influxdb.Duration{Duration: 30 * 24 * time.Hour},
+						ShardGroupDuration:  influxdb.Duration{Duration: 24 * time.Hour},
+						DescriptionTemplate: "{{.Name}}",
+					},
+				},
+			},
+			{
+				name: "clear description template",
+				flags: []string{
+					"--id=" + influxdb.ID(3).String(),
+					"--default-description-template=",
+				},
+				expected: influxdb.OrganizationUpdate{
+					BucketDefaults: &influxdb.BucketDefaults{
+						RetentionPeriod: influxdb.Duration{Duration: time.Hour},
+					},
+				},
+			},
 			{
 				name: "env var",
 				envVars: map[string]string{
@@ -307,6 +335,12 @@ func TestCmdOrg(t *testing.T) {
 				}
 				return &influxdb.Organization{}, nil
 			}
+			svc.FindOrganizationByIDF = func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: id, BucketDefaults: influxdb.BucketDefaults{
+					RetentionPeriod:     influxdb.Duration{Duration: time.Hour},
+					DescriptionTemplate: "{{.Name}}",
+				}}, nil
+			}
 
 			return func(g *globalFlags, opt genericCLIOpts) *cobra.Command {
 				builder := newCmdOrgBuilder(fakeOrgSVCFn(svc), g, opt)
@@ -588,3 +622,58 @@ var envVarsZeroMap = map[string]string{
 	"INFLUX_ORG_ID": "",
 	"INFLUX_ORG":    "",
 }
+
+// fakeInviteService records the invites it is asked to create and accept.
+type fakeInviteService struct {
+	influxdb.InviteService
+	created  influxdb.InviteCreate
+	accepted influxdb.InviteAccept
+}
+
+func (s *fakeInviteService) CreateInvite(ctx context.Context, c influxdb.InviteCreate) (*influxdb.InviteToken, error) {
+	s.created = c
+	return &influxdb.InviteToken{
+		Invite: &influxdb.Invite{ID: 3, OrgID: c.OrgID, Name: c.Name, Role: c.Role, Status: influxdb.InvitePending, ExpiresAt: time.Now().Add(c.TTL)},
+		Token:  "setup-token",
+	}, nil
+}
+
+func (s *fakeInviteService) AcceptInvite(ctx context.Context, a influxdb.InviteAccept) (*influxdb.Invite, error) {
+	s.accepted = a
+	return &influxdb.Invite{ID: 3, Name: "jane", Role: influxdb.Member, Status: influxdb.InviteAccepted}, nil
+}
+
+func TestCmdOrgInvite(t *testing.T) {
+	newCmd := func(svc *fakeInviteService, w *bytes.Buffer) *cobra.Command {
+		builder := newInfluxCmdBuilder(in(new(bytes.Buffer)), out(w))
+		return builder.cmd(func(f *globalFlags, opt genericCLIOpts) *cobra.Command {
+			b := newCmdOrgBuilder(func() (influxdb.OrganizationService, influxdb.UserResourceMappingService, influxdb.UserService, error) {
+				return mock.NewOrganizationService(), mock.NewUserResourceMappingService(), mock.NewUserService(), nil
+			}, f, opt)
+			b.inviteSVCFn = func() (influxdb.InviteService, error) { return svc, nil }
+			return b.cmd()
+		})
+	}
+
+	t.Run("create", func(t *testing.T) {
+		svc := new(fakeInviteService)
+		w := new(bytes.Buffer)
+		cmd := newCmd(svc, w)
+		cmd.SetArgs([]string{"org", "invite", "create", "--id=" + influxdb.ID(1).String(), "--user=jane", "--role=owner", "--expires-in=72h"})
+
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, influxdb.InviteCreate{OrgID: 1, Name: "jane", Role: influxdb.Owner, TTL: 72 * time.Hour}, svc.created)
+		assert.Contains(t, w.String(), "setup-token")
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		svc := new(fakeInviteService)
+		w := new(bytes.Buffer)
+		cmd := newCmd(svc, w)
+		cmd.SetArgs([]string{"org", "invite", "accept", "--setup-token=setup-token", "--password=password"})
+
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, influxdb.InviteAccept{Token: "setup-token", Password: "password"}, svc.accepted)
+		assert.Contains(t, w.String(), influxdb.InviteAccepted)
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -9,44 +10,132 @@ import (
 
 	"github.com/influxdata/influxdb/v2/kit/check"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
 func cmdPing(f *globalFlags, opts genericCLIOpts) *cobra.Command {
+	var verbose bool
 	runE := func(cmd *cobra.Command, args []string) error {
-		c := http.Client{
-			Timeout: 5 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: flags.skipVerify},
-			},
-		}
-		url := flags.config().Host + "/health"
-		resp, err := c.Get(url)
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode/100 != 2 {
-			return fmt.Errorf("got %d from '%s'", resp.StatusCode, url)
+		cfg := f.config()
+		if verbose {
+			report := pingDiagnostics(context.Background(), cfg.Host, cfg.Token, f.skipVerify)
+			if err := printPingReport(opts, report); err != nil {
+				return err
+			}
+			if failure := report.failure(); failure != pingFailureNone {
+				return &pingFailedError{failure: failure}
+			}
+			return nil
 		}
 
-		var healthResponse check.Response
-		if err = json.NewDecoder(resp.Body).Decode(&healthResponse); err != nil {
+		if err := checkHealth(context.Background(), cfg.Host, &tls.Config{InsecureSkipVerify: f.skipVerify}); err != nil {
 			return err
 		}
-
-		if healthResponse.Status == check.StatusPass {
-			fmt.Println("OK")
-		} else {
-			return fmt.Errorf("health check failed: '%s'", healthResponse.Message)
-		}
-
+		fmt.Println("OK")
 		return nil
 	}
 
 	cmd := opts.newCmd("ping", runE, true)
 	cmd.Short = "Check the InfluxDB /health endpoint"
-	cmd.Long = `Checks the health of a running InfluxDB instance by querying /health. Does not require valid token.`
-	f.registerFlags(opts.viper, cmd, "token")
+	cmd.Long = `Checks the health of a running InfluxDB instance by querying /health. Does not require valid token.
+
+With --verbose, diagnoses the connection to the instance step by step: the TCP
+connection, the TLS handshake and certificate, /health, /ready, whether the
+token is valid, the latency of each request and the build of the server.
+The command exits with the code of the most severe failure:
+
+	2	the token is invalid
+	3	the server is not healthy or not ready
+	4	the TLS handshake failed
+	5	the host is unreachable
+`
+	f.registerFlags(opts.viper, cmd, "verbose")
+	opts.registerPrintOptions(cmd)
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Diagnose the connection to the server: TLS, health, readiness, token and latency")
 
 	return cmd
 }
+
+// checkHealth returns nil if the /health endpoint of host reports it passing.
+func checkHealth(ctx context.Context, host string, tlsConfig *tls.Config) error {
+	c := http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	url := host + "/health"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("got %d from '%s'", resp.StatusCode, url)
+	}
+
+	var healthResponse check.Response
+	if err = json.NewDecoder(resp.Body).Decode(&healthResponse); err != nil {
+		return err
+	}
+
+	if healthResponse.Status != check.StatusPass {
+		return fmt.Errorf("health check failed: '%s'", healthResponse.Message)
+	}
+	return nil
+}
+
+// waitForServerInterval is the time between health checks while waiting for
+// a server to become ready.
+var waitForServerInterval = time.Second
+
+// serverNotReadyError is returned when a server does not become ready before
+// the time allowed by --wait-for-server elapses.
+type serverNotReadyError struct {
+	host string
+	wait time.Duration
+	err  error
+}
+
+func (e *serverNotReadyError) Error() string {
+	return fmt.Sprintf("server %s did not become ready within %s: %v", e.host, e.wait, e.err)
+}
+
+func (e *serverNotReadyError) Unwrap() error {
+	return e.err
+}
+
+// registerWaitForServer registers the --wait-for-server flag on cmd.
+func registerWaitForServer(cmd *cobra.Command, wait *time.Duration) {
+	cmd.Flags().DurationVar(wait, "wait-for-server", 0, "Wait up to this long for the server to pass its health check before starting")
+}
+
+// waitForServer polls the /health endpoint of host until it passes or wait
+// elapses, logging each attempt. It returns immediately if wait is zero.
+func waitForServer(ctx context.Context, log *zap.Logger, host string, tlsConfig *tls.Config, wait time.Duration) error {
+	if wait <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		err := checkHealth(ctx, host, tlsConfig)
+		if err == nil {
+			log.Info("Server is ready", zap.String("host", host), zap.Int("attempt", attempt))
+			return nil
+		}
+		log.Info("Server is not ready", zap.String("host", host), zap.Int("attempt", attempt), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return &serverNotReadyError{host: host, wait: wait, err: err}
+		case <-time.After(waitForServerInterval):
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/check"
+)
+
+// pingFailure is a class of failure found by the ping diagnostics. Classes
+// are ordered by severity, and the command exits with the code of the most
+// severe one.
+type pingFailure int
+
+const (
+	pingFailureNone pingFailure = iota
+	pingFailureToken
+	pingFailureNotReady
+	pingFailureTLS
+	pingFailureUnreachable
+)
+
+func (f pingFailure) String() string {
+	switch f {
+	case pingFailureToken:
+		return "token invalid"
+	case pingFailureNotReady:
+		return "server not ready"
+	case pingFailureTLS:
+		return "TLS handshake failed"
+	case pingFailureUnreachable:
+		return "host unreachable"
+	default:
+		return "ok"
+	}
+}
+
+// pingFailedError is returned when any of the ping diagnostics fails.
+type pingFailedError struct {
+	failure pingFailure
+}
+
+func (e *pingFailedError) Error() string {
+	return "ping failed: " + e.failure.String()
+}
+
+// ExitCode is 2 for an invalid token, 3 for a server that is not ready, 4 for
+// a failed TLS handshake and 5 for an unreachable host.
+func (e *pingFailedError) ExitCode() int {
+	return int(e.failure) + 1
+}
+
+const (
+	pingCheckOK      = "ok"
+	pingCheckWarn    = "warn"
+	pingCheckFail    = "fail"
+	pingCheckSkipped = "skipped"
+)
+
+// pingCertExpiryWarning is how close to expiring the certificate of the
+// server has to be to be warned about.
+const pingCertExpiryWarning = 30 * 24 * time.Hour
+
+type pingCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Latency string `json:"latency,omitempty"`
+	failure pingFailure
+}
+
+type pingTLS struct {
+	Version     string    `json:"version"`
+	CipherSuite string    `json:"cipherSuite"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	NotAfter    time.Time `json:"notAfter"`
+}
+
+type pingBuild struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+type pingReport struct {
+	Host    string      `json:"host"`
+	Checks  []pingCheck `json:"checks"`
+	TLS     *pingTLS    `json:"tls,omitempty"`
+	Build   *pingBuild  `json:"build,omitempty"`
+	Failure string      `json:"failure,omitempty"`
+}
+
+// failure returns the most severe failure of the checks.
+func (r *pingReport) failure() pingFailure {
+	worst := pingFailureNone
+	for _, c := range r.Checks {
+		if c.failure > worst {
+			worst = c.failure
+		}
+	}
+	return worst
+}
+
+func (r *pingReport) add(c pingCheck) {
+	r.Checks = append(r.Checks, c)
+}
+
+// pingDiagnostics checks the connection to host step by step: the TCP
+// connection, the TLS handshake, the health and readiness of the server and
+// the validity of token. The HTTP checks are not run if the host cannot be
+// connected to or the TLS handshake fails.
+func pingDiagnostics(ctx context.Context, host, token string, skipVerify bool) *pingReport {
+	report := &pingReport{Host: host}
+	defer func() {
+		if f := report.failure(); f != pingFailureNone {
+			report.Failure = f.String()
+		}
+	}()
+
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		report.add(pingCheck{Name: "tcp", Status: pingCheckFail, Message: fmt.Sprintf("invalid host %q", host), failure: pingFailureUnreachable})
+		return report
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		report.add(pingCheck{Name: "tcp", Status: pingCheckFail, Message: err.Error(), failure: pingFailureUnreachable})
+		return report
+	}
+	report.add(pingCheck{
+		Name:    "tcp",
+		Status:  pingCheckOK,
+		Message: "connected to " + conn.RemoteAddr().String(),
+		Latency: time.Since(start).String(),
+	})
+
+	if u.Scheme == "https" {
+		c := pingTLSHandshake(conn, u.Hostname(), skipVerify, report)
+		report.add(c)
+		if c.Status == pingCheckFail {
+			return report
+		}
+	} else {
+		conn.Close()
+		report.add(pingCheck{Name: "tls", Status: pingCheckSkipped, Message: "host does not use https"})
+	}
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify},
+		},
+	}
+	report.add(pingHealth(ctx, client, host, report))
+	report.add(pingReady(ctx, client, host))
+	report.add(pingToken(ctx, client, host, token))
+	return report
+}
+
+func pingTLSHandshake(conn net.Conn, serverName string, skipVerify bool, report *pingReport) pingCheck {
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: serverName, InsecureSkipVerify: skipVerify})
+	defer tlsConn.Close()
+
+	tlsConn.SetDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	if err := tlsConn.Handshake(); err != nil {
+		return pingCheck{Name: "tls", Status: pingCheckFail, Message: err.Error(), failure: pingFailureTLS}
+	}
+	latency := time.Since(start).String()
+
+	state := tlsConn.ConnectionState()
+	info := &pingTLS{
+		Version:     tlsVersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+	}
+	report.TLS = info
+	if len(state.PeerCertificates) == 0 {
+		return pingCheck{Name: "tls", Status: pingCheckWarn, Message: info.Version + ", no certificate presented", Latency: latency}
+	}
+
+	cert := state.PeerCertificates[0]
+	info.Subject = cert.Subject.String()
+	info.Issuer = cert.Issuer.String()
+	info.NotAfter = cert.NotAfter.UTC()
+
+	c := pingCheck{
+		Name:    "tls",
+		Status:  pingCheckOK,
+		Message: fmt.Sprintf("%s, certificate %q expires %s", info.Version, info.Subject, info.NotAfter.Format(time.RFC3339)),
+		Latency: latency,
+	}
+	if left := time.Until(cert.NotAfter); left < 0 {
+		c.Status = pingCheckWarn
+		c.Message += " (expired)"
+	} else if left < pingCertExpiryWarning {
+		c.Status = pingCheckWarn
+		c.Message += fmt.Sprintf(" (in %d days)", int(left.Hours()/24))
+	}
+	return c
+}
+
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04x", v)
+	}
+}
+
+// pingGet sends a GET request for path to host, returning the response and
+// how long it took.
+func pingGet(ctx context.Context, client *http.Client, host, path, token string) (*http.Response, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	return resp, time.Since(start), err
+}
+
+func pingHealth(ctx context.Context, client *http.Client, host string, report *pingReport) pingCheck {
+	resp, latency, err := pingGet(ctx, client, host, "/health", "")
+	if err != nil {
+		return pingCheck{Name: "health", Status: pingCheckFail, Message: err.Error(), failure: pingFailureUnreachable}
+	}
+	defer resp.Body.Close()
+
+	c := pingCheck{Name: "health", Latency: latency.String()}
+	var health struct {
+		check.Response
+		Version string `json:"version"`
+		Commit  string `json:"commit"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		c.Status, c.Message, c.failure = pingCheckFail, fmt.Sprintf("got %d with an invalid body: %v", resp.StatusCode, err), pingFailureNotReady
+		return c
+	}
+	if health.Version != "" {
+		report.Build = &pingBuild{Version: health.Version, Commit: health.Commit}
+	}
+	if resp.StatusCode/100 != 2 || health.Status != check.StatusPass {
+		c.Status, c.Message, c.failure = pingCheckFail, fmt.Sprintf("got %d: %s", resp.StatusCode, health.Message), pingFailureNotReady
+		return c
+	}
+	c.Status, c.Message = pingCheckOK, health.Message
+	return c
+}
+
+func pingReady(ctx context.Context, client *http.Client, host string) pingCheck {
+	resp, latency, err := pingGet(ctx, client, host, "/ready", "")
+	if err != nil {
+		return pingCheck{Name: "ready", Status: pingCheckFail, Message: err.Error(), failure: pingFailureUnreachable}
+	}
+	defer resp.Body.Close()
+
+	c := pingCheck{Name: "ready", Latency: latency.String()}
+	var ready struct {
+		Status string `json:"status"`
+		Up     string `json:"up"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ready); err != nil || resp.StatusCode/100 != 2 || ready.Status != "ready" {
+		c.Status, c.Message, c.failure = pingCheckFail, fmt.Sprintf("got %d, status %q", resp.StatusCode, ready.Status), pingFailureNotReady
+		return c
+	}
+	c.Status, c.Message = pingCheckOK, "up "+ready.Up
+	return c
+}
+
+func pingToken(ctx context.Context, client *http.Client, host, token string) pingCheck {
+	if token == "" {
+		return pingCheck{Name: "token", Status: pingCheckSkipped, Message: "no token configured"}
+	}
+
+	resp, latency, err := pingGet(ctx, client, host, "/api/v2/me", token)
+	if err != nil {
+		return pingCheck{Name: "token", Status: pingCheckFail, Message: err.Error(), failure: pingFailureUnreachable}
+	}
+	defer resp.Body.Close()
+
+	c := pingCheck{Name: "token", Latency: latency.String()}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		c.Status, c.Message, c.failure = pingCheckFail, fmt.Sprintf("token invalid: got %d", resp.StatusCode), pingFailureToken
+	case resp.StatusCode/100 != 2:
+		c.Status, c.Message, c.failure = pingCheckFail, fmt.Sprintf("got %d", resp.StatusCode), pingFailureNotReady
+	default:
+		var me influxdb.User
+		if err := json.NewDecoder(resp.Body).Decode(&me); err != nil {
+			c.Status, c.Message, c.failure = pingCheckFail, fmt.Sprintf("invalid response: %v", err), pingFailureNotReady
+			return c
+		}
+		c.Status, c.Message = pingCheckOK, "authenticated as "+me.Name
+	}
+	return c
+}
+
+func printPingReport(opts genericCLIOpts, report *pingReport) error {
+	if opts.json {
+		return opts.writeJSON(report)
+	}
+
+	w := opts.newTabWriter()
+	w.WriteHeaders("Check", "Status", "Latency", "Details")
+	for _, c := range report.Checks {
+		w.Write(map[string]interface{}{
+			"Check":   c.Name,
+			"Status":  c.Status,
+			"Latency": c.Latency,
+			"Details": c.Message,
+		})
+	}
+	if b := report.Build; b != nil {
+		w.Write(map[string]interface{}{
+			"Check":   "build",
+			"Status":  pingCheckOK,
+			"Details": fmt.Sprintf("version %s, commit %s", b.Version, b.Commit),
+		})
+	}
+	w.Flush()
+	return nil
+}
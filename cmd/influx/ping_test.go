@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWaitForServer(t *testing.T) {
+	defer func(d time.Duration) { waitForServerInterval = d }(waitForServerInterval)
+	waitForServerInterval = 10 * time.Millisecond
+
+	// newServer returns a server that fails its health check the given number
+	// of times before passing.
+	newServer := func(failures int32) (*httptest.Server, *int32) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		}))
+		return srv, &calls
+	}
+
+	t.Run("becomes ready", func(t *testing.T) {
+		srv, calls := newServer(2)
+		defer srv.Close()
+
+		require.NoError(t, waitForServer(context.Background(), zaptest.NewLogger(t), srv.URL, nil, time.Minute))
+		assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	})
+
+	t.Run("never ready", func(t *testing.T) {
+		srv, _ := newServer(1 << 30)
+		defer srv.Close()
+
+		err := waitForServer(context.Background(), zaptest.NewLogger(t), srv.URL, nil, 50*time.Millisecond)
+		var notReady *serverNotReadyError
+		require.True(t, errors.As(err, &notReady), "unexpected error: %v", err)
+		assert.Contains(t, err.Error(), "did not become ready")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		srv, calls := newServer(0)
+		defer srv.Close()
+
+		require.NoError(t, waitForServer(context.Background(), zaptest.NewLogger(t), srv.URL, nil, 0))
+		assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+	})
+}
+
+func TestPingDiagnostics(t *testing.T) {
+	newServer := func(healthy bool) *httptest.Server {
+		return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/health":
+				if !healthy {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					w.Write([]byte(`{"name":"influxdb","status":"fail","message":"starting"}`))
+					return
+				}
+				w.Write([]byte(`{"name":"influxdb","status":"pass","message":"ready for queries and writes","version":"2.0.0","commit":"abc"}`))
+			case "/ready":
+				w.Write([]byte(`{"status":"ready","up":"1h0m0s"}`))
+			case "/api/v2/me":
+				if r.Header.Get("Authorization") != "Token good" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{"id":"0000000000000001","name":"admin"}`))
+			}
+		}))
+	}
+
+	statuses := func(report *pingReport) map[string]string {
+		m := make(map[string]string)
+		for _, c := range report.Checks {
+			m[c.Name] = c.Status
+		}
+		return m
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		srv := newServer(true)
+		defer srv.Close()
+
+		report := pingDiagnostics(context.Background(), srv.URL, "good", true)
+		assert.Equal(t, pingFailureNone, report.failure())
+		assert.Equal(t, map[string]string{"tcp": "ok", "tls": "ok", "health": "ok", "ready": "ok", "token": "ok"}, statuses(report))
+		require.NotNil(t, report.TLS)
+		assert.NotEmpty(t, report.TLS.Version)
+		assert.Equal(t, &pingBuild{Version: "2.0.0", Commit: "abc"}, report.Build)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		srv := newServer(true)
+		defer srv.Close()
+
+		report := pingDiagnostics(context.Background(), srv.URL, "bad", true)
+		assert.Equal(t, pingFailureToken, report.failure())
+		assert.Equal(t, "token invalid", report.Failure)
+		assert.Equal(t, 2, (&pingFailedError{failure: report.failure()}).ExitCode())
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		srv := newServer(true)
+		defer srv.Close()
+
+		report := pingDiagnostics(context.Background(), srv.URL, "", true)
+		assert.Equal(t, pingFailureNone, report.failure())
+		assert.Equal(t, "skipped", statuses(report)["token"])
+	})
+
+	t.Run("not healthy", func(t *testing.T) {
+		srv := newServer(false)
+		defer srv.Close()
+
+		report := pingDiagnostics(context.Background(), srv.URL, "bad", true)
+		assert.Equal(t, pingFailureNotReady, report.failure())
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		srv := newServer(true)
+		defer srv.Close()
+
+		report := pingDiagnostics(context.Background(), srv.URL, "good", false)
+		assert.Equal(t, pingFailureTLS, report.failure())
+		assert.NotContains(t, statuses(report), "health")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv := newServer(true)
+		srv.Close()
+
+		report := pingDiagnostics(context.Background(), srv.URL, "good", true)
+		assert.Equal(t, pingFailureUnreachable, report.failure())
+		assert.Equal(t, 5, (&pingFailedError{failure: report.failure()}).ExitCode())
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// defaultProgressInterval is the interval of the progress frames a restore
+// asks for once a proxy closed one of its idle connections.
+const defaultProgressInterval = 10 * time.Second
+
+// registerProgressInterval registers the --progress-interval flag on cmd.
+func registerProgressInterval(cmd *cobra.Command, interval *time.Duration) {
+	cmd.Flags().DurationVar(interval, "progress-interval", 0, "Ask the server for progress frames at this interval during transfers, to keep connections through proxies closing idle connections open; 0 disables them")
+}
+
+// withTransferProgress returns the context of a backup or restore request,
+// asking the server for progress frames at interval, which are logged with
+// fields. It returns ctx as is if interval is 0.
+func withTransferProgress(ctx context.Context, log *zap.Logger, interval time.Duration, fields ...zap.Field) context.Context {
+	if interval <= 0 {
+		return ctx
+	}
+	return http.WithProgress(ctx, interval, func(p http.Progress) {
+		log.Info("Transfer in progress", append(fields[:len(fields):len(fields)], zap.Int64("bytes", p.Bytes))...)
+	})
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// readingRestoreService reads the shards it restores, then restores them
+// as its fakeRestoreService does.
+type readingRestoreService struct {
+	*fakeRestoreService
+	read map[uint64][]byte
+}
+
+func (s *readingRestoreService) RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.read[shardID] = buf
+	s.mu.Unlock()
+	return s.fakeRestoreService.RestoreShard(ctx, shardID, r)
+}
+
+// slowBackupService writes its chunks to the backups of shards, after a
+// delay before each.
+type slowBackupService struct {
+	influxdb.BackupService
+
+	chunks []string
+	delay  time.Duration
+	err    error
+}
+
+func (s *slowBackupService) BackupShard(ctx context.Context, w io.Writer, shardID uint64, since time.Time) error {
+	for _, chunk := range s.chunks {
+		time.Sleep(s.delay)
+		if _, err := io.WriteString(w, chunk); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func newProgressTestRestoreBuilder(t *testing.T, h nethttp.Handler) (*cmdRestoreBuilder, *observer.ObservedLogs) {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+	b.logger = zap.New(core)
+	b.restoreService = &http.RestoreService{Addr: srv.URL}
+	return b, logs
+}
+
+func newRestoreTestHandler(svc influxdb.RestoreService) nethttp.Handler {
+	return http.NewRestoreHandler(&http.RestoreBackend{
+		Logger:           zap.NewNop(),
+		HTTPErrorHandler: kithttp.ErrorHandler(0),
+		RestoreService:   svc,
+	})
+}
+
+func TestCmdRestore_ProgressFrames(t *testing.T) {
+	ctx := context.Background()
+	const shard = "shard data"
+
+	t.Run("logs progress while the server restores a shard", func(t *testing.T) {
+		svc := &readingRestoreService{
+			fakeRestoreService: &fakeRestoreService{delay: 500 * time.Millisecond},
+			read:               make(map[uint64][]byte),
+		}
+		b, logs := newProgressTestRestoreBuilder(t, newRestoreTestHandler(svc))
+		b.progressInterval = 100 * time.Millisecond
+
+		require.NoError(t, b.streamShard(b.progressContext(ctx, zap.Uint64("shard", 5)), 5, strings.NewReader(gzipped(t, shard))))
+		assert.Equal(t, shard, string(svc.read[5]))
+		assert.Equal(t, []uint64{5}, svc.restoredShards)
+
+		progress := logs.FilterMessage("Transfer in progress").All()
+		require.NotEmpty(t, progress)
+		assert.Equal(t, int64(len(shard)), progress[0].ContextMap()["bytes"])
+		assert.Equal(t, uint64(5), progress[0].ContextMap()["shard"])
+	})
+
+	t.Run("returns the error of a streamed restore", func(t *testing.T) {
+		svc := &readingRestoreService{
+			fakeRestoreService: &fakeRestoreService{
+				delay:     300 * time.Millisecond,
+				shardErrs: map[uint64]error{5: &influxdb.Error{Code: influxdb.EConflict, Msg: "shard is busy"}},
+			},
+			read: make(map[uint64][]byte),
+		}
+		b, logs := newProgressTestRestoreBuilder(t, newRestoreTestHandler(svc))
+		b.progressInterval = 100 * time.Millisecond
+
+		err := b.streamShard(b.progressContext(ctx), 5, strings.NewReader(gzipped(t, shard)))
+		require.Error(t, err)
+		assert.Equal(t, influxdb.EConflict, influxdb.ErrorCode(err))
+		assert.Contains(t, err.Error(), "shard is busy")
+		assert.NotEmpty(t, logs.FilterMessage("Transfer in progress").All())
+	})
+
+	t.Run("does not ask for progress by default", func(t *testing.T) {
+		var headers []string
+		svc := &readingRestoreService{fakeRestoreService: &fakeRestoreService{}, read: make(map[uint64][]byte)}
+		h := newRestoreTestHandler(svc)
+		b, _ := newProgressTestRestoreBuilder(t, nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			headers = append(headers, r.Header.Get(http.ProgressIntervalHeader))
+			h.ServeHTTP(w, r)
+		}))
+
+		require.NoError(t, b.streamShard(b.progressContext(ctx), 5, strings.NewReader(gzipped(t, shard))))
+		assert.Equal(t, []string{""}, headers)
+	})
+
+	t.Run("retries with progress after a proxy timeout", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "influx-restore-progress-")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shard.tar.gz"), []byte(gzipped(t, shard)), 0666))
+
+		var (
+			mu      sync.Mutex
+			headers []string
+		)
+		svc := &readingRestoreService{fakeRestoreService: &fakeRestoreService{}, read: make(map[uint64][]byte)}
+		h := newRestoreTestHandler(svc)
+		b, logs := newProgressTestRestoreBuilder(t, nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			mu.Lock()
+			headers = append(headers, r.Header.Get(http.ProgressIntervalHeader))
+			mu.Unlock()
+			if r.Header.Get(http.ProgressIntervalHeader) == "" {
+				ioutil.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(nethttp.StatusGatewayTimeout)
+				io.WriteString(w, "<html><body>504 Gateway Time-out</body></html>\n")
+				return
+			}
+			h.ServeHTTP(w, r)
+		}))
+		b.path = dir
+		b.skipVerifyFiles = true
+
+		require.NoError(t, b.restoreShard(ctx, 5, &influxdb.ManifestEntry{ShardID: 1, FileName: "shard.tar.gz"}))
+		assert.Equal(t, []string{"", defaultProgressInterval.String()}, headers)
+		assert.Equal(t, shard, string(svc.read[5]))
+		assert.Len(t, logs.FilterMessage("Connection closed as if by a proxy timing out, retrying with progress frames").All(), 1)
+
+		// Progress frames are asked for from then on.
+		assert.Equal(t, defaultProgressInterval, b.transferProgressInterval())
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		svc := &readingRestoreService{
+			fakeRestoreService: &fakeRestoreService{shardErrs: map[uint64]error{5: &influxdb.Error{Code: influxdb.EInternal, Msg: "disk full"}}},
+			read:               make(map[uint64][]byte),
+		}
+		b, _ := newProgressTestRestoreBuilder(t, newRestoreTestHandler(svc))
+
+		err := b.transfer(ctx, func(ctx context.Context) error {
+			return b.streamShard(ctx, 5, strings.NewReader(gzipped(t, shard)))
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "disk full")
+		assert.Zero(t, b.transferProgressInterval())
+	})
+}
+
+func TestCmdBackup_ProgressFrames(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(t *testing.T, svc influxdb.BackupService) *http.BackupService {
+		srv := httptest.NewServer(http.NewBackupHandler(&http.BackupBackend{
+			Logger:           zap.NewNop(),
+			HTTPErrorHandler: kithttp.ErrorHandler(0),
+			BackupService:    svc,
+		}))
+		t.Cleanup(srv.Close)
+		return &http.BackupService{Addr: srv.URL}
+	}
+
+	t.Run("streams the backup with progress", func(t *testing.T) {
+		svc := newService(t, &slowBackupService{chunks: []string{"", "tsm", "data"}, delay: 200 * time.Millisecond})
+
+		var (
+			mu       sync.Mutex
+			progress []int64
+		)
+		ctx := http.WithProgress(ctx, 100*time.Millisecond, func(p http.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, p.Bytes)
+		})
+
+		var buf bytes.Buffer
+		require.NoError(t, svc.BackupShard(ctx, &buf, 1, time.Time{}))
+		assert.Equal(t, "tsmdata", buf.String())
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotEmpty(t, progress)
+		assert.Equal(t, int64(0), progress[0])
+	})
+
+	t.Run("returns the error of a streamed backup", func(t *testing.T) {
+		svc := newService(t, &slowBackupService{
+			chunks: []string{"tsm"},
+			err:    &influxdb.Error{Code: influxdb.ENotFound, Msg: "shard not found"},
+		})
+
+		var buf bytes.Buffer
+		err := svc.BackupShard(http.WithProgress(ctx, time.Second, nil), &buf, 1, time.Time{})
+		require.Error(t, err)
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+		assert.Equal(t, "tsm", buf.String())
+	})
+
+	t.Run("streams the backup as before without progress", func(t *testing.T) {
+		svc := newService(t, &slowBackupService{chunks: []string{"tsm", "data"}})
+
+		var buf bytes.Buffer
+		require.NoError(t, svc.BackupShard(ctx, &buf, 1, time.Time{}))
+		assert.Equal(t, "tsmdata", buf.String())
+	})
+
+	t.Run("reports a stream cut short as a proxy timeout", func(t *testing.T) {
+		srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			w.Header().Set("Content-Type", "application/vnd.influxdata.progress-stream")
+			io.WriteString(w, "d\x00\x00\x00\x03tsm")
+		}))
+		defer srv.Close()
+
+		svc := &http.BackupService{Addr: srv.URL}
+		err := svc.BackupShard(http.WithProgress(ctx, time.Second, nil), ioutil.Discard, 1, time.Time{})
+		require.Error(t, err)
+		assert.True(t, http.IsProxyTimeout(err))
+		assert.True(t, strings.Contains(err.Error(), "ended before its result"))
+	})
+}
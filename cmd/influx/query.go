@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/values"
 	ihttp "github.com/influxdata/influxdb/v2/http"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/spf13/cobra"
 )
 
@@ -127,6 +128,7 @@ func fluxQueryF(cmd *cobra.Command, args []string) error {
 
 	if queryFlags.raw {
 		io.Copy(os.Stdout, resp.Body)
+		warnPartialResult(resp)
 		return nil
 	}
 
@@ -151,7 +153,25 @@ func fluxQueryF(cmd *cobra.Command, args []string) error {
 	// It is safe and appropriate to call Release multiple times and must be
 	// called before checking the error on the next line.
 	results.Release()
-	return results.Err()
+	if err := results.Err(); err != nil {
+		return err
+	}
+	warnPartialResult(resp)
+	return nil
+}
+
+// warnPartialResult warns on stderr if the results of a query were truncated
+// by a limit of the server. The rest of the body is read first, since the
+// warning is sent as a trailer when the results were streamed.
+func warnPartialResult(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	msg := resp.Header.Get(kithttp.PartialResultHeader)
+	if msg == "" {
+		msg = resp.Trailer.Get(kithttp.PartialResultHeader)
+	}
+	if msg != "" {
+		fmt.Fprintf(os.Stderr, "WARNING: %s; the results above are incomplete\n", msg)
+	}
 }
 
 // Below is a copy and trimmed version of the execute/format.go file from flux.
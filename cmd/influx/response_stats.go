@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	ihttp "github.com/influxdata/influxdb/v2/http"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+)
+
+// responseStatsHeaders are the headers InfluxDB reports the cost of requests
+// in when it is started with --http-response-stats.
+var responseStatsHeaders = []string{
+	kithttp.PointsWrittenHeader,
+	kithttp.BytesReadHeader,
+	kithttp.RowsReturnedHeader,
+	kithttp.QueryDurationHeader,
+}
+
+// responseStatsRecorder records the responses to the requests of a command
+// to print the statistics InfluxDB reported in them once it is done.
+type responseStatsRecorder struct {
+	mu        sync.Mutex
+	responses []*http.Response
+}
+
+// recordResponseStats makes the HTTP clients of the CLI record their
+// responses with a new recorder.
+func recordResponseStats() *responseStatsRecorder {
+	r := new(responseStatsRecorder)
+	ihttp.DefaultTransport = r.wrap(ihttp.DefaultTransport)
+	ihttp.DefaultTransportInsecure = r.wrap(ihttp.DefaultTransportInsecure)
+	http.DefaultClient.Transport = r.wrap(http.DefaultClient.Transport)
+	return r
+}
+
+// wrap returns a round tripper recording the responses of base.
+func (r *responseStatsRecorder) wrap(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &responseStatsTransport{base: base, recorder: r}
+}
+
+func (r *responseStatsRecorder) record(resp *http.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, resp)
+}
+
+// print writes the statistics of each response reporting any to w. The
+// statistics of streamed responses are trailers, which are only set once
+// their body has been read.
+func (r *responseStatsRecorder) print(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, resp := range r.responses {
+		var stats []string
+		for _, h := range responseStatsHeaders {
+			v := resp.Header.Get(h)
+			if v == "" {
+				v = resp.Trailer.Get(h)
+			}
+			if v != "" {
+				stats = append(stats, h+": "+v)
+			}
+		}
+		if len(stats) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", resp.Request.Method, resp.Request.URL.Path, strings.Join(stats, ", "))
+	}
+}
+
+type responseStatsTransport struct {
+	base     http.RoundTripper
+	recorder *responseStatsRecorder
+}
+
+func (t *responseStatsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.recorder.record(resp)
+	}
+	return resp, err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseStatsRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/write":
+			w.Header().Set(kithttp.PointsWrittenHeader, "3")
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v2/query":
+			w.Header().Set("Trailer", kithttp.RowsReturnedHeader)
+			_, _ = io.WriteString(w, "_value\n1\n2\n")
+			w.(http.Flusher).Flush()
+			w.Header().Set(kithttp.RowsReturnedHeader, "2")
+		}
+	}))
+	defer srv.Close()
+
+	r := new(responseStatsRecorder)
+	client := &http.Client{Transport: r.wrap(nil)}
+	for _, path := range []string{"/api/v2/write", "/api/v2/query", "/health"} {
+		resp, err := client.Post(srv.URL+path, "text/plain", nil)
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	out := new(bytes.Buffer)
+	r.print(out)
+	assert.Equal(t, "POST /api/v2/write: X-Influxdb-Points-Written: 3\nPOST /api/v2/query: X-Influxdb-Rows-Returned: 2\n", out.String())
+}
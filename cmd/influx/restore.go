@@ -3,22 +3,36 @@ package main
 import (
 	"compress/gzip"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
+	nethttp "net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/bolt"
+	internal2 "github.com/influxdata/influxdb/v2/cmd/internal"
 	"github.com/influxdata/influxdb/v2/http"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	isecret "github.com/influxdata/influxdb/v2/secret"
+	"github.com/influxdata/influxdb/v2/snowflake"
 	"github.com/influxdata/influxdb/v2/tenant"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"github.com/spf13/cobra"
+	"github.com/tcnksm/go-input"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 func cmdRestore(f *globalFlags, opts genericCLIOpts) *cobra.Command {
@@ -36,13 +50,87 @@ type cmdRestoreBuilder struct {
 	newOrgName    string
 	org           organization
 	path          string
-
-	kvEntry      *influxdb.ManifestKVEntry
-	shardEntries map[uint64]*influxdb.ManifestEntry
-
-	orgService     *tenant.OrgClientService
-	bucketService  *tenant.BucketClientService
-	restoreService *http.RestoreService
+	wait          time.Duration
+
+	skipMissingMeta bool
+	strict          bool
+	// overwriteBucket restores into the buckets of the target organization
+	// named as the restored buckets, replacing their data.
+	overwriteBucket bool
+	prune           bool
+	force           bool
+	dryRun          bool
+	// skipVerifyFiles restores shard files that do not match the size and
+	// checksum recorded in their manifest.
+	skipVerifyFiles bool
+
+	excludeInternalOrgs bool
+	includeInternalOrgs bool
+
+	verifyKeyFile string
+	caCert        string
+
+	// parallelism is the number of shards a full restore restores
+	// concurrently.
+	parallelism int
+
+	// start and end, RFC3339 times, bound the time range of the shards to
+	// restore.
+	start, end string
+
+	// progressInterval is the interval of the progress frames the server is
+	// asked for during transfers, if not 0. It is set once a proxy closes an
+	// idle connection, guarded by progressMu.
+	progressInterval time.Duration
+	progressMu       sync.Mutex
+
+	// metadataOnly restores the KV store, or the organizations and buckets,
+	// of the backup without the data of its shards, which are listed in
+	// metadataOnlyShards.
+	metadataOnly       bool
+	metadataOnlyShards []*influxdb.ManifestEntry
+
+	// retries and retryMaxDuration bound the retries of uploads failing
+	// with a transient error.
+	retries          int
+	retryMaxDuration time.Duration
+
+	// resume records the restored buckets and shards in the state file at
+	// statePath, and skips those a previous run recorded there.
+	resume    bool
+	statePath string
+	state     *restoreState
+	stateMu   sync.Mutex
+
+	restoreID string
+	host      string
+
+	// result records what the restore did, written with --json.
+	result   *restoreResult
+	resultMu sync.Mutex
+
+	// archive is the archive the shard files of the backup are read from,
+	// if they are not read from b.path.
+	archive       *backupArchive
+	kvEntry       *influxdb.ManifestKVEntry
+	shardEntries  map[uint64]*influxdb.ManifestEntry
+	secretEntries []influxdb.ManifestSecretEntry
+	skippedShards []skippedShard
+	// placeholders holds the secrets created without values, which must be
+	// set once the restore completes.
+	placeholders []restoredPlaceholder
+	pruneBuckets []*influxdb.Bucket
+	// plan holds the buckets a dry run would restore.
+	plan []restorePlanRow
+	// serverIDs holds the IDs of the buckets and storage directories already
+	// on the server, mapped to what holds them.
+	serverIDs map[influxdb.ID]string
+
+	orgService     influxdb.OrganizationService
+	bucketService  influxdb.BucketService
+	restoreService influxdb.RestoreService
+	orphanService  influxdb.OrphanReportService
+	secretService  influxdb.SecretService
 	tenantService  *tenant.Service
 	metaClient     *meta.Client
 
@@ -54,7 +142,9 @@ func newCmdRestoreBuilder(f *globalFlags, opts genericCLIOpts) *cmdRestoreBuilde
 		genericCLIOpts: opts,
 		globalFlags:    f,
 
+		parallelism:  1,
 		shardEntries: make(map[uint64]*influxdb.ManifestEntry),
+		result:       newRestoreResult(),
 	}
 }
 
@@ -66,11 +156,31 @@ func (b *cmdRestoreBuilder) cmdRestore() *cobra.Command {
 	cmd.Flags().StringVarP(&b.bucketName, "bucket", "b", "", "The name of the bucket to restore")
 	cmd.Flags().StringVar(&b.newBucketName, "new-bucket", "", "The name of the bucket to restore to")
 	cmd.Flags().StringVar(&b.newOrgName, "new-org", "", "The name of the organization to restore to")
-	cmd.Flags().StringVar(&b.path, "input", "", "Local backup data path (required)")
+	cmd.Flags().StringVar(&b.path, "input", "", "Local backup data path, a directory or a tar archive of one, which may be gzipped, or - to read an archive from stdin (required)")
+	cmd.Flags().BoolVar(&b.skipMissingMeta, "skip-missing-meta", false, "Skip shards whose bucket metadata is missing from the backup instead of failing")
+	cmd.Flags().BoolVar(&b.strict, "strict", false, "Fail the restore if any shard in the backup cannot be restored")
+	cmd.Flags().BoolVar(&b.overwriteBucket, "overwrite-bucket", false, "Restore into a bucket of the same name already in the target organization, replacing its data but keeping its ID")
+	cmd.Flags().BoolVar(&b.prune, "prune", false, "After restoring, delete buckets in the target organization that are not in the backup")
+	cmd.Flags().BoolVar(&b.force, "force", false, "Prune buckets without a confirmation prompt")
+	cmd.Flags().BoolVar(&b.skipVerifyFiles, "skip-verify-files", false, "Restore shard files that do not match the size and checksum recorded in their manifest, for backups edited on purpose")
+	cmd.Flags().BoolVar(&b.dryRun, "dry-run", false, "Print the organizations, buckets and shards that would be restored and check the shard files of the backup, without contacting the server")
+	cmd.Flags().BoolVar(&b.excludeInternalOrgs, "exclude-internal-orgs", true, "Skip internal organizations (names starting with _) and refuse a full restore that would overwrite them")
+	cmd.Flags().BoolVar(&b.includeInternalOrgs, "include-internal-orgs", false, "Restore internal organizations too; the same as --exclude-internal-orgs=false")
+	cmd.Flags().IntVar(&b.parallelism, "parallelism", 1, "The number of shards to restore concurrently with --full")
+	cmd.Flags().StringVar(&b.verifyKeyFile, "verify-key-file", "", "Path to a PEM encoded Ed25519 public key; refuse to restore unless the backup is signed with it and unmodified")
+	cmd.Flags().StringVar(&b.start, "start", "", "Only restore the shards holding data at or after this RFC3339 time")
+	cmd.Flags().StringVar(&b.end, "end", "", "Only restore the shards holding data before this RFC3339 time")
+	cmd.Flags().StringVar(&b.caCert, "ca-cert", "", "Path to a PEM encoded CA bundle to verify the server certificate with; takes precedence over --skip-verify")
+	cmd.Flags().BoolVar(&b.metadataOnly, "metadata-only", false, "Restore the organizations, buckets, tokens, dashboards, tasks and other metadata of the backup, but not the data of its shards")
+	cmd.Flags().BoolVar(&b.resume, "resume", false, "Record the restored shards in a state file, and skip the shards a previous run with --resume restored")
+	registerWaitForServer(cmd, &b.wait)
+	registerProgressInterval(cmd, &b.progressInterval)
+	cmd.Flags().IntVar(&b.retries, "retries", 0, "The number of times to retry an upload failing with a server error, a connection reset or a timeout, with exponential backoff; 0 disables retries")
+	cmd.Flags().DurationVar(&b.retryMaxDuration, "retry-max-duration", 0, "The longest time to spend retrying an upload from its first failure; 0 for no limit")
 	cmd.Use = "restore [flags] path"
 	cmd.Args = func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
-			return fmt.Errorf("must specify path to backup directory")
+			return fmt.Errorf("must specify path to backup directory or archive, or - for stdin")
 		} else if len(args) > 1 {
 			return fmt.Errorf("too many args specified")
 		}
@@ -84,18 +194,192 @@ Restore influxdb.
 Examples:
 	# restore all data
 	influx restore /path/to/restore
+
+	# fully restore all data, restoring four shards at a time
+	influx restore --full --parallelism 4 /path/to/restore
+
+	# check what restoring a bucket under a new name would do
+	influx restore --bucket example-bucket --new-bucket example-copy --dry-run /path/to/restore
+
+	# check a backup copied from another host before restoring all of it
+	influx restore --full --dry-run /path/to/restore
+
+	# restore yesterday's data into the bucket it came from, replacing its
+	# data but keeping the bucket ID that tasks and dashboards refer to
+	influx restore --bucket example-bucket --overwrite-bucket /path/to/restore
+
+	# make the "staging" organization match the backup exactly
+	influx restore --org staging --prune /path/to/restore
+
+	# restore all data from a gzipped tar archive of a backup directory
+	influx restore /path/to/backup.tar.gz
+
+	# restore all data from an archive read from stdin
+	ssh backup-host cat backup.tar.gz | influx restore -
+
+	# wait up to a minute for a newly provisioned server before restoring
+	influx restore --wait-for-server 1m /path/to/restore
+
+	# also restore internal organizations, whose names start with an underscore
+	influx restore --include-internal-orgs /path/to/restore
+
+	# only restore a backup signed with "influx backup --signing-key-file"
+	influx restore --verify-key-file backup-key.pub.pem /path/to/restore
+
+	# only restore the data of the first week of March
+	influx restore --start 2020-03-01T00:00:00Z --end 2020-03-08T00:00:00Z /path/to/restore
+
+	# restore all data through a load balancer closing idle connections
+	influx restore --progress-interval 10s /path/to/restore
+
+	# retry uploads failing with a server error up to 5 times, for up to 10 minutes
+	influx restore --retries 5 --retry-max-duration 10m /path/to/restore
+
+	# restore all metadata onto a fresh server, without any shard data
+	influx restore --full --metadata-only /path/to/restore
+
+	# restore all data, and run the same command again to resume after a failure
+	influx restore --full --resume /path/to/restore
+
+	# restore all data, printing what was restored as JSON instead of logging it
+	influx restore --json /path/to/restore
+
+A backup may be given as a tar archive of its directory, which may be
+gzipped, or as "-" to read such an archive from stdin. The shard files of an
+archive are read from it directly: it is read once to verify them, and again
+to restore them. Only its manifests and metadata are written to a temporary
+directory, in $TMPDIR, which is removed once the restore completes. As stdin
+cannot be read twice, and its manifests may follow its shard files, the shard
+files of an archive read from stdin are written to the temporary directory
+too.
+
+Internal organizations, like internal buckets, have names starting with an
+underscore and hold data used to operate the server. They are skipped by
+default, and a full restore is refused if the server has any, as it would
+overwrite them.
+
+Restored buckets are created with new IDs. A bucket is not restored if the ID
+it is given already holds data on the server, for example the storage
+directory of a deleted bucket, so that the data of two buckets is never mixed.
+
+A bucket already in the target organization under the name a bucket is
+restored to is not restored into, unless --overwrite-bucket is given. Its
+shards are then deleted and replaced with the shards of the backup, keeping
+its ID, so the tasks and dashboards referring to it keep working. Its data
+not in the backup is lost.
+
+Shard files are verified against the size and checksum recorded in their
+manifest before they are restored, so a truncated or corrupted copy of a
+backup is not partially restored. Manifests written by older versions have no
+checksums; their files are restored with a warning. Use --skip-verify-files to
+restore a backup whose files were edited on purpose.
+
+With --dry-run, the backup is loaded and filtered as for a restore, and the
+buckets that would be restored are printed with their number of shards, their
+size and the names they would be restored to, without contacting the server.
+Every shard file listed in the manifests is checked to exist, to be readable
+and to match its manifest; the command fails if any does not, so an
+incomplete copy of a backup is caught before restoring it. As the server is
+not contacted, buckets that already exist there and the buckets --prune would
+delete are not reported.
+
+Secrets are backed up by key only, never by value. Secrets missing from the
+server are restored as placeholders without a value, which "influx secret list"
+flags, and are listed once the restore completes so their values can be set
+with "influx secret update".
+
+With --start or --end, only the shards whose shard group overlaps the time
+range are restored. A shard overlapping the range in part is restored whole,
+with its data outside the range. The metadata of the other shards is restored,
+but not their data. Manifests written by older versions do not record the
+time ranges of shards, so their backups cannot be restored with these flags.
+
+Proxies, like load balancers, may close connections idle for too long, as a
+connection is while the server ingests a large shard. With
+--progress-interval, the server sends a progress frame at that interval once
+it has read a shard, until it is restored, keeping its connection busy, and
+the progress is logged. Without it, a shard or metadata upload failing as if
+a proxy closed its connection is retried once with progress frames every
+10s, which are then used for the rest of the restore. Shards read from an
+archive cannot be retried, as it is read once. Servers of older versions
+ignore progress frames.
+
+With --retries, an upload of metadata, a bucket or a shard failing with a
+server error (5xx), a connection reset or a timeout is retried up to that
+many times, waiting 1s before the first retry and twice as long before each
+retry after it, up to 30s. Each retry is logged with its number. With
+--retry-max-duration, no retry is made later than that after the first
+failure of an upload. Uploads refused by the server (4xx) are never
+retried, nor are the shards read from an archive.
+
+With --metadata-only, no shard data is restored. A full restore replaces the
+KV store of the server, holding its organizations, buckets, tokens,
+dashboards and tasks, and stops there; the backup must have one. A partial
+restore creates the organizations and buckets of the backup, with the
+metadata of their shards, but their shards are empty. The number of shards
+whose data was intentionally not restored is logged once the restore
+completes, and they are listed with the reason "metadata only" with --json.
+
+With --resume, each restored shard is recorded in a state file, with the
+server restored to and the buckets created, and the shards recorded by a
+previous run with --resume are skipped. The state file is .restore-state.json
+in a backup directory, or named after an archive, next to it, so backups
+read from stdin cannot be resumed. A state file of a restore to another
+server, of a restore of another kind, or that restored a bucket under
+another name than --new-bucket gives, is refused; remove it to restore from
+scratch. It is removed once the restore completes. A full restore replacing
+the KV store of the server invalidates any previous state file.
+
+With --json, nothing is logged. Once the restore ends, a JSON document is
+printed with its mode, the organizations and buckets restored to, with their
+IDs and names in the backup and on the server, the shards restored, with the
+IDs of the shards created for them and the sizes of their files, the shards
+skipped and why, the secrets restored as placeholders and the buckets pruned.
+A failed restore prints it too, with its error and what was restored before
+it failed. It cannot be used with --dry-run, nor with --prune without
+--force.
+
+With --verify-key-file, nothing is restored unless every manifest in the
+backup has a signature made with the matching private key and every file to
+be restored matches the checksum listed in its manifest.
 `
+	cmd.AddCommand(newCmdRestoreVerifyBuilder(b.globalFlags, b.genericCLIOpts).cmd())
 	return cmd
 }
 
 func (b *cmdRestoreBuilder) restoreRunE(cmd *cobra.Command, args []string) (err error) {
 	ctx := context.Background()
 
-	// Create top level logger
-	logconf := influxlogger.NewConfig()
-	if b.logger, err = logconf.New(os.Stdout); err != nil {
-		return err
+	if b.includeInternalOrgs && b.excludeInternalOrgs && cmd.Flags().Changed("exclude-internal-orgs") {
+		return fmt.Errorf("cannot use --include-internal-orgs with --exclude-internal-orgs")
+	}
+	b.includeInternalOrgs = b.includeInternalOrgs || !b.excludeInternalOrgs
+
+	// Create top level logger. With --json, what was restored is printed
+	// once the restore ends instead.
+	if b.json {
+		b.logger = zap.NewNop()
+	} else {
+		logconf := influxlogger.NewConfig()
+		if b.logger, err = logconf.New(os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	err = b.restore(ctx)
+	if b.json {
+		if werr := b.writeRestoreResult(err); err == nil {
+			return werr
+		}
 	}
+	return err
+}
+
+// restore loads the backup from b.path and restores it to the server.
+func (b *cmdRestoreBuilder) restore(ctx context.Context) error {
+	// Generate an ID so server logs for this restore can be correlated. It is
+	// generated first so that the result of any failed restore has it.
+	b.restoreID = snowflake.NewDefaultIDGenerator().ID().String()
 
 	// Ensure org/bucket filters are set if a new org/bucket name is specified.
 	if b.newOrgName != "" && b.org.id == "" && b.org.name == "" {
@@ -104,294 +388,1712 @@ func (b *cmdRestoreBuilder) restoreRunE(cmd *cobra.Command, args []string) (err
 		return fmt.Errorf("must specify source bucket id or name when renaming restored bucket")
 	}
 
-	// Read in set of KV data & shard data to restore.
-	if err := b.loadIncremental(); err != nil {
-		return fmt.Errorf("restore failed while processing manifest files: %s", err.Error())
-	} else if b.kvEntry == nil {
-		return fmt.Errorf("no manifest files found in: %s", b.path)
+	if b.parallelism < 1 {
+		return fmt.Errorf("--parallelism must be at least 1")
+	}
+	if b.retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	} else if b.retryMaxDuration < 0 {
+		return fmt.Errorf("--retry-max-duration must not be negative")
 	}
 
-	ac := flags.config()
-	b.restoreService = &http.RestoreService{
-		Addr:               ac.Host,
-		Token:              ac.Token,
-		InsecureSkipVerify: flags.skipVerify,
+	if b.metadataOnly && b.dryRun {
+		return fmt.Errorf("cannot use --metadata-only with --dry-run")
 	}
 
-	client, err := newHTTPClient()
-	if err != nil {
-		return err
+	// The tables of a dry run and the confirmation of pruning are printed,
+	// which would mix with the JSON document.
+	if b.json && b.dryRun {
+		return fmt.Errorf("cannot use --json with --dry-run")
+	} else if b.json && b.prune && !b.force {
+		return fmt.Errorf("cannot use --json with --prune without --force")
 	}
 
-	b.orgService = &tenant.OrgClientService{Client: client}
-	b.bucketService = &tenant.BucketClientService{Client: client}
+	b.result.Mode = restoreModePartial
+	if b.full {
+		b.result.Mode = restoreModeFull
+	}
 
-	if !b.full {
-		return b.restorePartial(ctx)
+	if b.overwriteBucket && b.full {
+		return fmt.Errorf("cannot use --overwrite-bucket with --full; a full restore already replaces all buckets")
 	}
-	return b.restoreFull(ctx)
-}
 
-// restoreFull completely replaces the bolt metadata file and restores all shard data.
-func (b *cmdRestoreBuilder) restoreFull(ctx context.Context) (err error) {
-	if err := b.restoreKVStore(ctx); err != nil {
-		return err
+	// Skipping shards with missing metadata contradicts strict mode.
+	if b.skipMissingMeta && b.strict {
+		return fmt.Errorf("cannot use --skip-missing-meta with --strict")
 	}
 
-	// Restore each shard for the bucket.
-	for _, file := range b.shardEntries {
-		if err := b.restoreShard(ctx, file.ShardID, file); err != nil {
-			return err
-		}
+	if err := b.validatePrune(); err != nil {
+		return err
 	}
 
-	return nil
-}
+	if b.newOrgName != "" && isInternalOrgName(b.newOrgName) && !b.includeInternalOrgs {
+		return fmt.Errorf("cannot restore to internal organization %q without --include-internal-orgs", b.newOrgName)
+	}
 
-func (b *cmdRestoreBuilder) restoreKVStore(ctx context.Context) (err error) {
-	f, err := os.Open(filepath.Join(b.path, b.kvEntry.FileName))
+	start, end, err := b.timeRange()
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	if err := b.restoreService.RestoreKVStore(ctx, f); err != nil {
-		return err
+	// The state file is found before an archive is read into a temporary
+	// directory.
+	if b.resume {
+		if b.dryRun {
+			return fmt.Errorf("cannot use --resume with --dry-run")
+		}
+		if b.statePath, err = restoreStatePath(b.path); err != nil {
+			return err
+		}
+	} else if b.full && !b.dryRun {
+		// A full restore invalidates the state file of any previous restore.
+		b.statePath, _ = restoreStatePath(b.path)
 	}
-	b.logger.Info("Full metadata restored.")
-
-	return nil
-}
 
-// restorePartial restores shard data to a server without deleting existing data.
-// Organizations & buckets are created as needed. Cannot overwrite an existing bucket.
-func (b *cmdRestoreBuilder) restorePartial(ctx context.Context) (err error) {
-	// Open bolt DB.
-	boltClient := bolt.NewClient(b.logger)
-	boltClient.Path = filepath.Join(b.path, b.kvEntry.FileName)
-	if err := boltClient.Open(ctx); err != nil {
+	cleanup, err := b.loadBackup()
+	if err != nil {
 		return err
 	}
-	defer boltClient.Close()
-
-	// Open meta store so we can iterate over meta data.
-	kvStore := bolt.NewKVStore(b.logger, boltClient.Path)
-	kvStore.WithDB(boltClient.DB())
+	defer cleanup()
 
-	tenantStore := tenant.NewStore(kvStore)
-	b.tenantService = tenant.NewService(tenantStore)
-
-	b.metaClient = meta.NewClient(meta.NewConfig(), kvStore)
-	if err := b.metaClient.Open(); err != nil {
+	if err := b.filterTimeRange(start, end); err != nil {
 		return err
 	}
 
-	// Filter through organizations & buckets to restore appropriate shards.
-	if err := b.restoreOrganizations(ctx); err != nil {
-		return err
+	if b.metadataOnly && b.full {
+		if b.kvEntry.FileName == "" {
+			return fmt.Errorf("cannot use --metadata-only with --full: the backup has no KV store")
+		} else if _, err := os.Stat(filepath.Join(b.path, b.kvEntry.FileName)); err != nil {
+			return fmt.Errorf("cannot use --metadata-only with --full: cannot find the KV store of the backup: %w", err)
+		}
 	}
 
-	b.logger.Info("Restore complete")
+	if b.verifyKeyFile != "" {
+		if err := b.verifySignature(); err != nil {
+			return err
+		}
+	}
 
-	return nil
-}
+	b.logger = b.logger.With(zap.String("restore_id", b.restoreID))
+	b.logger.Info("Starting restore")
+	b.warnUnverifiedFiles()
 
-func (b *cmdRestoreBuilder) restoreOrganizations(ctx context.Context) (err error) {
-	// Build a filter if org ID or org name were specified.
-	var filter influxdb.OrganizationFilter
-	if b.org.id != "" {
-		if filter.ID, err = influxdb.IDFromString(b.org.id); err != nil {
+	// A dry run only reads the backup.
+	if !b.dryRun {
+		if err := b.connect(ctx); err != nil {
 			return err
 		}
-	} else if b.org.name != "" {
-		filter.Name = &b.org.name
 	}
 
-	// Retrieve a list of all matching organizations.
-	orgs, _, err := b.tenantService.FindOrganizations(ctx, filter)
+	if b.resume {
+		if err := b.loadRestoreState(); err != nil {
+			return err
+		}
+	}
+
+	if !b.full {
+		err = b.restorePartial(ctx)
+	} else {
+		err = b.restoreFull(ctx)
+	}
 	if err != nil {
 		return err
 	}
+	return b.removeRestoreState()
+}
 
-	// Restore matching organizations.
-	for _, org := range orgs {
-		if err := b.restoreOrganization(ctx, org); err != nil {
-			return err
+// loadBackup loads the manifests of the backup at b.path, a backup directory
+// or an archive of one. It returns a func removing what was written to read
+// an archive.
+func (b *cmdRestoreBuilder) loadBackup() (func(), error) {
+	cleanup := func() {}
+	if archive, err := isBackupArchive(b.path); err != nil {
+		return nil, err
+	} else if archive {
+		a, err := openBackupArchive(b.path, b.in)
+		if err != nil {
+			return nil, err
+		}
+		cleanup = a.Close
+		b.logger.Info("Read backup archive", zap.String("archive", a.name()), zap.String("path", a.backupDir))
+		b.path = a.backupDir
+		if a.streamed() {
+			b.archive = a
 		}
 	}
-	return nil
+
+	// Read in set of KV data & shard data to restore.
+	var err error
+	if b.kvEntry, b.shardEntries, err = loadIncrementalWith(b.path, b.shardFileExists); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("restore failed while processing manifest files: %s", err.Error())
+	} else if b.kvEntry == nil {
+		cleanup()
+		return nil, fmt.Errorf("no manifest files found in: %s", b.path)
+	}
+	if b.secretEntries, err = loadSecretEntries(b.path); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("restore failed while processing manifest files: %s", err.Error())
+	}
+	return cleanup, nil
 }
 
-func (b *cmdRestoreBuilder) restoreOrganization(ctx context.Context, org *influxdb.Organization) (err error) {
-	b.logger.Info("Restoring organization", zap.String("id", org.ID.String()), zap.String("name", org.Name))
+// timeRange returns the times of --start and --end, which are zero if not
+// set.
+func (b *cmdRestoreBuilder) timeRange() (start, end time.Time, err error) {
+	if b.start != "" {
+		if start, err = time.Parse(time.RFC3339Nano, b.start); err != nil {
+			return start, end, fmt.Errorf("invalid --start time %q: must be RFC3339", b.start)
+		}
+	}
+	if b.end != "" {
+		if end, err = time.Parse(time.RFC3339Nano, b.end); err != nil {
+			return start, end, fmt.Errorf("invalid --end time %q: must be RFC3339", b.end)
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return start, end, fmt.Errorf("--start must be before --end")
+	}
+	return start, end, nil
+}
 
-	newOrg := *org
-	if b.newOrgName != "" {
-		newOrg.Name = b.newOrgName
+// filterTimeRange removes the shards entirely outside the time range from
+// start to end, either of which may be zero, from the shards to restore.
+// Shards overlapping the time range in part are restored whole.
+func (b *cmdRestoreBuilder) filterTimeRange(start, end time.Time) error {
+	if start.IsZero() && end.IsZero() {
+		return nil
 	}
 
-	// Create organization on server, if it doesn't already exist.
-	if o, err := b.orgService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &newOrg.Name}); influxdb.ErrorCode(err) == influxdb.ENotFound {
-		if err := b.orgService.CreateOrganization(ctx, &newOrg); err != nil {
-			return fmt.Errorf("cannot create organization: %w", err)
+	for _, file := range b.shardEntries {
+		if !file.HasTimeRange() {
+			return fmt.Errorf("cannot use --start or --end: the manifest of shard %d does not record its time range, as it was written by an older version of influx backup", file.ShardID)
 		}
-	} else if err != nil {
-		return fmt.Errorf("cannot find existing organization: %#v", err)
-	} else {
-		newOrg.ID = o.ID
 	}
 
-	// Build a filter if bucket ID or bucket name were specified.
-	var filter influxdb.BucketFilter
-	filter.OrganizationID = &org.ID // match on backup's org ID
-	if b.bucketID != "" {
-		if filter.ID, err = influxdb.IDFromString(b.bucketID); err != nil {
-			return err
+	var excluded int
+	for id, file := range b.shardEntries {
+		if (!start.IsZero() && !file.EndTime.After(start)) || (!end.IsZero() && !file.StartTime.Before(end)) {
+			delete(b.shardEntries, id)
+			excluded++
+			continue
+		}
+		if (!start.IsZero() && file.StartTime.Before(start)) || (!end.IsZero() && file.EndTime.After(end)) {
+			b.logger.Info("Shard overlaps the time range in part, restoring it whole",
+				zap.Uint64("shard", file.ShardID),
+				zap.String("bucket", file.BucketName),
+				zap.Time("shard_start", file.StartTime),
+				zap.Time("shard_end", file.EndTime))
 		}
-	} else if b.bucketName != "" {
-		filter.Name = &b.bucketName
 	}
+	b.logger.Info("Filtered shards by time range", zap.Int("shards", len(b.shardEntries)), zap.Int("excluded", excluded))
+	return nil
+}
 
-	// Retrieve a list of all buckets for the organization in the local backup.
-	buckets, _, err := b.tenantService.FindBuckets(ctx, filter)
+// connect waits for the server and creates the clients of its services.
+func (b *cmdRestoreBuilder) connect(ctx context.Context) error {
+	tlsConfig, err := b.tlsConfig()
 	if err != nil {
 		return err
 	}
 
-	// Restore each matching bucket.
-	for _, bkt := range buckets {
-		// Skip internal buckets.
-		if strings.HasPrefix(bkt.Name, "_") {
-			continue
-		}
+	ac := flags.config()
+	if err := waitForServer(ctx, b.logger, ac.Host, tlsConfig, b.wait); err != nil {
+		return err
+	}
 
-		bkt = bkt.Clone()
-		bkt.OrgID = newOrg.ID
+	b.host = ac.Host
+	b.restoreService = &http.RestoreService{
+		Addr:               ac.Host,
+		Token:              ac.Token,
+		InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+		TLSConfig:          tlsConfig,
+		RestoreID:          b.restoreID,
+		Retry:              b.retryPolicy(),
+	}
 
-		if err := b.restoreBucket(ctx, bkt); err != nil {
-			return err
-		}
+	client, err := newHTTPClientFor(ac.Host, ac.Token,
+		httpc.WithHTTPClient(&nethttp.Client{Transport: http.NewTLSTransport(tlsConfig)}))
+	if err != nil {
+		return err
 	}
+
+	b.orgService = &tenant.OrgClientService{Client: client}
+	b.bucketService = &tenant.BucketClientService{Client: client}
+	b.orphanService = &http.OrphanService{Client: client}
+	b.secretService = &isecret.Client{Client: client}
 	return nil
 }
 
-func (b *cmdRestoreBuilder) restoreBucket(ctx context.Context, bkt *influxdb.Bucket) (err error) {
-	b.logger.Info("Restoring bucket", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name))
-
-	// Create bucket on server.
-	newBucket := *bkt
-	if b.newBucketName != "" {
-		newBucket.Name = b.newBucketName
+// tlsConfig returns the TLS configuration of connections to the server. The
+// CA bundle of --ca-cert is preferred over --skip-verify if both are given.
+func (b *cmdRestoreBuilder) tlsConfig() (*tls.Config, error) {
+	if b.caCert == "" {
+		return &tls.Config{InsecureSkipVerify: b.skipVerify}, nil
 	}
-	if err := b.bucketService.CreateBucket(ctx, &newBucket); err != nil {
-		return fmt.Errorf("cannot create bucket: %w", err)
+	if b.skipVerify {
+		b.logger.Warn("Ignoring --skip-verify, verifying the server certificate with the CA bundle of --ca-cert")
 	}
 
-	// Lookup matching database from the meta store.
-	// Search using bucket ID from backup.
-	dbi := b.metaClient.Database(bkt.ID.String())
-	if dbi == nil {
-		return fmt.Errorf("bucket database not found: %s", bkt.ID.String())
+	buf, err := ioutil.ReadFile(b.caCert)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA bundle: %w", err)
 	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in CA bundle %q", b.caCert)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
 
-	// Serialize to protobufs.
-	buf, err := dbi.MarshalBinary()
+// verifySignature refuses to restore a backup that is not signed with the
+// verify key or has been modified since it was signed.
+func (b *cmdRestoreBuilder) verifySignature() error {
+	key, err := readVerifyKey(b.verifyKeyFile)
 	if err != nil {
-		return fmt.Errorf("cannot marshal database info: %w", err)
+		return fmt.Errorf("cannot read verify key: %w", err)
+	}
+	shardChecksum := func(fileName string) (string, error) {
+		return fileChecksum(filepath.Join(b.path, fileName))
 	}
+	if b.archive != nil {
+		shardChecksum = func(fileName string) (string, error) {
+			if file := b.archive.file(fileName); file != nil {
+				return file.checksum, nil
+			}
+			return "", fmt.Errorf("%s: %w", fileName, os.ErrNotExist)
+		}
+	}
+	if err := verifySignedBackupWith(b.path, key, b.kvEntry, b.shardEntries, shardChecksum); err != nil {
+		return fmt.Errorf("refusing to restore: %w", err)
+	}
+	b.logger.Info("Backup signature verified", zap.String("key_id", keyID(key)))
+	return nil
+}
 
-	shardIDMap, err := b.restoreService.RestoreBucket(ctx, newBucket.ID, buf)
-	if err != nil {
-		return fmt.Errorf("cannot restore bucket: %w", err)
+// validatePrune ensures --prune is only used where mirroring an organization
+// is well defined.
+func (b *cmdRestoreBuilder) validatePrune() error {
+	if !b.prune {
+		if b.force {
+			return fmt.Errorf("--force can only be used with --prune")
+		}
+		return nil
 	}
 
-	// Restore each shard for the bucket.
+	switch {
+	case b.full:
+		return fmt.Errorf("cannot use --prune with --full; a full restore already replaces all buckets")
+	case b.org.id == "" && b.org.name == "":
+		return fmt.Errorf("must specify the org to mirror when using --prune")
+	case b.bucketID != "" || b.bucketName != "" || b.newBucketName != "":
+		return fmt.Errorf("cannot use --prune when restoring a single bucket")
+	case b.skipMissingMeta:
+		return fmt.Errorf("cannot use --prune with --skip-missing-meta")
+	}
+	return nil
+}
+
+// restoreFull completely replaces the bolt metadata file and restores all shard data.
+func (b *cmdRestoreBuilder) restoreFull(ctx context.Context) (err error) {
+	// Replacing all data with a measurement-scoped backup would lose every
+	// other measurement.
 	for _, file := range b.shardEntries {
-		if bkt.ID.String() != file.BucketID {
-			continue
+		if file.Partial() {
+			return fmt.Errorf("cannot use --full with a backup of only some measurements; shard %d contains only %s", file.ShardID, strings.Join(file.Measurements, ", "))
 		}
+	}
 
-		// Skip if shard metadata was not imported.
-		newID, ok := shardIDMap[file.ShardID]
-		if !ok {
-			b.logger.Warn("Meta info not found, skipping file", zap.Uint64("shard", file.ShardID), zap.String("bucket_id", file.BucketID), zap.String("filename", file.FileName))
-			return nil
+	if b.dryRun {
+		b.logger.Info("Dry run: would replace all metadata", zap.String("filename", b.kvEntry.FileName))
+		if !b.includeInternalOrgs {
+			b.logger.Warn("Dry run: internal organizations on the server, which a full restore refuses to overwrite, are not checked")
 		}
+		b.planFull()
+		return b.finishDryRun()
+	}
 
-		if err := b.restoreShard(ctx, newID, file); err != nil {
+	// The KV store restored by a previous run holds the organizations of the
+	// backup, internal ones included.
+	if b.state != nil && b.state.KVRestored {
+		b.logger.Info("Skipping KV store restored by a previous run")
+	} else {
+		if err := b.protectInternalOrgs(ctx); err != nil {
+			return err
+		}
+		if err := b.restoreKVStore(ctx); err != nil {
+			return err
+		}
+		if err := b.invalidateRestoreState(); err != nil {
+			return err
+		}
+	}
+
+	files := make([]*influxdb.ManifestEntry, 0, len(b.shardEntries))
+	for _, file := range b.shardEntries {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ShardID < files[j].ShardID })
+
+	var restored int64
+	if b.metadataOnly {
+		b.metadataOnlyShards = files
+	} else {
+		restored, err = b.restoreShards(ctx, files)
+		if err == nil && b.archive != nil {
+			var n int
+			n, err = b.restoreArchiveShards(ctx)
+			restored = int64(n)
+		}
+		if err != nil {
+			b.logger.Error("Full restore failed", zap.Int64("shards_restored", restored), zap.Int("shards", len(files)), zap.Error(err))
+			return err
+		}
+	}
+	// The organizations keep their IDs in a full restore.
+	for i := range b.secretEntries {
+		entry := &b.secretEntries[i]
+		orgID, err := influxdb.IDFromString(entry.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("invalid organization id %q in manifest: %w", entry.OrganizationID, err)
+		}
+		if err := b.restoreSecrets(ctx, entry, *orgID, entry.OrganizationName); err != nil {
 			return err
 		}
 	}
 
+	b.logger.Info("Full restore complete", zap.Int64("shards_restored", restored), zap.Int("shards", len(files)))
+	b.logMetadataOnly()
+	b.printPlaceholders()
 	return nil
 }
 
-func (b *cmdRestoreBuilder) restoreShard(ctx context.Context, newShardID uint64, file *influxdb.ManifestEntry) error {
-	b.logger.Info("Restoring shard live from backup", zap.Uint64("shard", newShardID), zap.String("filename", file.FileName))
+// restoreShards restores the files to the shards they were backed up from,
+// up to b.parallelism at a time. The first error cancels the shards not yet
+// restored. It returns the number of shards restored.
+func (b *cmdRestoreBuilder) restoreShards(ctx context.Context, files []*influxdb.ManifestEntry) (int64, error) {
+	var restored int64
+	g, ctx := errgroup.WithContext(ctx)
+	work := make(chan *influxdb.ManifestEntry)
+
+	g.Go(func() error {
+		defer close(work)
+		for _, file := range files {
+			select {
+			case work <- file:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	for i := 0; i < b.parallelism; i++ {
+		g.Go(func() error {
+			for file := range work {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := b.restoreShard(ctx, file.ShardID, file); err != nil {
+					return err
+				}
+				atomic.AddInt64(&restored, 1)
+			}
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	return atomic.LoadInt64(&restored), err
+}
 
-	f, err := os.Open(filepath.Join(b.path, file.FileName))
+// protectInternalOrgs refuses a full restore that would overwrite the internal
+// organizations on the server, unless they are included explicitly.
+func (b *cmdRestoreBuilder) protectInternalOrgs(ctx context.Context) error {
+	if b.includeInternalOrgs {
+		return nil
+	}
+
+	orgs, _, err := b.orgService.FindOrganizations(ctx, influxdb.OrganizationFilter{})
 	if err != nil {
-		return err
+		return fmt.Errorf("cannot list organizations: %w", err)
 	}
-	defer f.Close()
+	var names []string
+	for _, org := range orgs {
+		if isInternalOrg(org) {
+			names = append(names, org.Name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return fmt.Errorf("a full restore would overwrite internal organizations %s; use --include-internal-orgs to overwrite them", strings.Join(names, ", "))
+	}
+	return nil
+}
 
-	gr, err := gzip.NewReader(f)
+func (b *cmdRestoreBuilder) restoreKVStore(ctx context.Context) (err error) {
+	err = b.transfer(ctx, func(ctx context.Context) error {
+		f, err := os.Open(filepath.Join(b.path, b.kvEntry.FileName))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return b.restoreService.RestoreKVStore(ctx, f)
+	}, zap.String("filename", b.kvEntry.FileName))
 	if err != nil {
 		return err
 	}
-	defer gr.Close()
+	b.logger.Info("Full metadata restored.")
 
-	return b.restoreService.RestoreShard(ctx, newShardID, gr)
+	return nil
 }
 
-// loadIncremental loads multiple manifest files from a given directory.
-func (b *cmdRestoreBuilder) loadIncremental() error {
-	// Read all manifest files from path, sort in descending time.
-	manifests, err := filepath.Glob(filepath.Join(b.path, "*.manifest"))
+// restorePartial restores shard data to a server without deleting existing data.
+// Organizations & buckets are created as needed. Cannot overwrite an existing bucket.
+func (b *cmdRestoreBuilder) restorePartial(ctx context.Context) (err error) {
+	bm, err := openBackupMeta(ctx, b.logger, filepath.Join(b.path, b.kvEntry.FileName))
 	if err != nil {
 		return err
-	} else if len(manifests) == 0 {
-		return nil
 	}
-	sort.Sort(sort.Reverse(sort.StringSlice(manifests)))
+	defer bm.Close()
+	b.tenantService, b.metaClient = bm.tenantService, bm.metaClient
 
-	b.shardEntries = make(map[uint64]*influxdb.ManifestEntry)
-	for _, filename := range manifests {
-		// Skip file if it is a directory.
-		if fi, err := os.Stat(filename); err != nil {
-			return err
-		} else if fi.IsDir() {
-			continue
+	if b.dryRun {
+		if b.prune {
+			b.logger.Warn("Dry run: the buckets to prune are found on the server, and are not listed")
 		}
-
-		// Read manifest file for backup.
-		var manifest influxdb.Manifest
-		if buf, err := ioutil.ReadFile(filename); err != nil {
-			return err
-		} else if err := json.Unmarshal(buf, &manifest); err != nil {
-			return fmt.Errorf("read manifest: %v", err)
+	} else {
+		// Find and confirm buckets to prune before anything is modified.
+		if b.prune {
+			if err := b.planPrune(ctx); err != nil {
+				return err
+			}
+			if err := b.confirmPrune(); err != nil {
+				return err
+			}
+		}
+
+		// Find the IDs already in use so restored buckets never share storage.
+		if err := b.loadServerIDs(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Filter through organizations & buckets to restore appropriate shards.
+	if err := b.restoreOrganizations(ctx); err != nil {
+		return err
+	}
+
+	if b.dryRun {
+		return b.finishDryRun()
+	}
+
+	if b.archive != nil && !b.metadataOnly {
+		if _, err := b.restoreArchiveShards(ctx); err != nil {
+			return err
+		}
+	}
+	b.logMetadataOnly()
+
+	if len(b.skippedShards) > 0 {
+		b.logSkippedShards()
+		b.printPlaceholders()
+		// Pruning would leave the server with neither the data of the
+		// skipped shards nor that of the pruned buckets.
+		if b.prune && len(b.pruneBuckets) > 0 {
+			return b.skipPrune()
+		}
+		return nil
+	}
+
+	if b.prune {
+		if err := b.pruneServerBuckets(ctx); err != nil {
+			return err
+		}
+	}
+
+	b.logger.Info("Restore complete")
+	b.printPlaceholders()
+
+	return nil
+}
+
+// loadServerIDs finds the IDs of the buckets on the server and of the
+// storage directories left behind by deleted buckets. Orphaned directories
+// can only be listed with an operator token; without one only bucket IDs are
+// checked.
+func (b *cmdRestoreBuilder) loadServerIDs(ctx context.Context) error {
+	b.serverIDs = make(map[influxdb.ID]string)
+
+	const limit = influxdb.MaxPageSize
+	for offset := 0; ; offset += limit {
+		buckets, _, err := b.bucketService.FindBuckets(ctx, influxdb.BucketFilter{}, influxdb.FindOptions{Limit: limit, Offset: offset})
+		if err != nil {
+			return fmt.Errorf("cannot list buckets on server: %w", err)
+		}
+		for _, bkt := range buckets {
+			b.serverIDs[bkt.ID] = fmt.Sprintf("bucket %q", bkt.Name)
+		}
+		if len(buckets) < limit {
+			break
+		}
+	}
+
+	if b.orphanService == nil {
+		return nil
+	}
+	report, err := b.orphanService.OrphanReport(ctx)
+	if code := influxdb.ErrorCode(err); code == influxdb.EUnauthorized || code == influxdb.EForbidden || code == influxdb.ENotFound {
+		b.logger.Warn("Cannot list orphaned storage directories on server; checking restored bucket IDs against existing buckets only", zap.Error(err))
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot list orphaned storage directories on server: %w", err)
+	}
+	for _, o := range report.Orphans {
+		id, err := influxdb.IDFromString(o.BucketID)
+		if err != nil {
+			continue
+		}
+		if _, ok := b.serverIDs[*id]; !ok {
+			b.serverIDs[*id] = fmt.Sprintf("orphaned storage directory %s", o.Path)
+		}
+	}
+	return nil
+}
+
+// backupMeta provides read access to the metadata stored in a backup.
+type backupMeta struct {
+	boltClient    *bolt.Client
+	tenantService *tenant.Service
+	metaClient    *meta.Client
+}
+
+// openBackupMeta opens the backed up bolt file at path.
+func openBackupMeta(ctx context.Context, log *zap.Logger, path string) (*backupMeta, error) {
+	// Open bolt DB.
+	boltClient := bolt.NewClient(log)
+	boltClient.Path = path
+	if err := boltClient.Open(ctx); err != nil {
+		return nil, err
+	}
+
+	// Open meta store so we can iterate over meta data.
+	kvStore := bolt.NewKVStore(log, boltClient.Path)
+	kvStore.WithDB(boltClient.DB())
+
+	metaClient := meta.NewClient(meta.NewConfig(), kvStore)
+	if err := metaClient.Open(); err != nil {
+		boltClient.Close()
+		return nil, err
+	}
+
+	return &backupMeta{
+		boltClient:    boltClient,
+		tenantService: tenant.NewService(tenant.NewStore(kvStore)),
+		metaClient:    metaClient,
+	}, nil
+}
+
+// Close closes the backed up bolt file.
+func (m *backupMeta) Close() error {
+	return m.boltClient.Close()
+}
+
+// planPrune finds the buckets in each target organization on the server that
+// do not exist in the backup.
+func (b *cmdRestoreBuilder) planPrune(ctx context.Context) error {
+	orgs, err := b.backupOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, org := range orgs {
+		if skip, err := b.skipInternalOrg(org); err != nil {
+			return err
+		} else if skip {
+			continue
+		}
+
+		targetName := org.Name
+		if b.newOrgName != "" {
+			targetName = b.newOrgName
+		}
+
+		// Nothing to prune if the organization doesn't exist on the server yet.
+		target, err := b.orgService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &targetName})
+		if influxdb.ErrorCode(err) == influxdb.ENotFound {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("cannot find existing organization: %w", err)
+		}
+
+		backupBuckets, _, err := b.tenantService.FindBuckets(ctx, influxdb.BucketFilter{OrganizationID: &org.ID})
+		if err != nil {
+			return err
+		}
+		keep := make(map[string]bool)
+		for _, bkt := range backupBuckets {
+			if !isInternalBucket(bkt) {
+				keep[bkt.Name] = true
+			}
+		}
+
+		// Guard against wiping an organization with an empty or mismatched backup.
+		if len(keep) == 0 {
+			return fmt.Errorf("refusing to prune organization %q: backup contains no buckets for it", targetName)
+		}
+
+		serverBuckets, _, err := b.bucketService.FindBuckets(ctx, influxdb.BucketFilter{OrganizationID: &target.ID})
+		if err != nil {
+			return fmt.Errorf("cannot list buckets in organization %q: %w", targetName, err)
+		}
+		for _, bkt := range serverBuckets {
+			if isInternalBucket(bkt) || keep[bkt.Name] {
+				continue
+			}
+			if bkt.DeleteProtected {
+				b.logger.Warn("Bucket is delete protected and will not be pruned", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name), zap.String("org", targetName))
+				continue
+			}
+			b.pruneBuckets = append(b.pruneBuckets, bkt)
+		}
+	}
+
+	sort.Slice(b.pruneBuckets, func(i, j int) bool { return b.pruneBuckets[i].Name < b.pruneBuckets[j].Name })
+	return nil
+}
+
+// confirmPrune lists the buckets to be pruned and asks for confirmation
+// unless --force is set.
+func (b *cmdRestoreBuilder) confirmPrune() error {
+	if len(b.pruneBuckets) == 0 {
+		b.logger.Info("No buckets to prune")
+		return nil
+	}
+
+	if b.json {
+		// The pruned buckets are listed in the JSON document.
+		return nil
+	}
+
+	w := b.newTabWriter()
+	w.WriteHeaders("ID", "Name", "Organization ID")
+	for _, bkt := range b.pruneBuckets {
+		w.Write(map[string]interface{}{
+			"ID":              bkt.ID.String(),
+			"Name":            bkt.Name,
+			"Organization ID": bkt.OrgID.String(),
+		})
+	}
+	w.Flush()
+
+	if b.force {
+		return nil
+	}
+
+	ui := &input.UI{Writer: b.w, Reader: b.in}
+	msg := fmt.Sprintf("Confirm deletion of the %d buckets listed above and all of their data (y/n)", len(b.pruneBuckets))
+	if internal2.GetInput(ui, msg, "n") != "y" {
+		return fmt.Errorf("restore aborted: pruning was not confirmed")
+	}
+	return nil
+}
+
+// pruneServerBuckets deletes the buckets found by planPrune.
+func (b *cmdRestoreBuilder) pruneServerBuckets(ctx context.Context) error {
+	var failed int
+	for _, bkt := range b.pruneBuckets {
+		if err := b.bucketService.DeleteBucket(ctx, bkt.ID); err != nil {
+			b.logger.Error("Failed to prune bucket", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name), zap.Error(err))
+			failed++
+			continue
+		}
+		b.logger.Info("Pruned bucket", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name))
+		b.result.PrunedBuckets = append(b.result.PrunedBuckets, prunedBucketResult{ID: bkt.ID.String(), Name: bkt.Name})
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to prune %d of %d buckets", failed, len(b.pruneBuckets))
+	}
+	return nil
+}
+
+// skipPrune lists the buckets found by planPrune, which are not pruned
+// because shards were skipped, and returns an error saying so.
+func (b *cmdRestoreBuilder) skipPrune() error {
+	names := make([]string, 0, len(b.pruneBuckets))
+	for _, bkt := range b.pruneBuckets {
+		b.logger.Warn("Bucket not pruned because shards were skipped", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name))
+		names = append(names, fmt.Sprintf("%q", bkt.Name))
+	}
+	return fmt.Errorf("prune not performed because %d shards were skipped; it would have deleted the buckets %s",
+		len(b.skippedShards), strings.Join(names, ", "))
+}
+
+// isInternalBucket returns true for system buckets, which are never restored or pruned.
+func isInternalBucket(bkt *influxdb.Bucket) bool {
+	return bkt.Type == influxdb.BucketTypeSystem || strings.HasPrefix(bkt.Name, "_")
+}
+
+// isInternalOrg returns true for internal organizations, which hold data used
+// to operate the server and are only restored when explicitly included.
+func isInternalOrg(org *influxdb.Organization) bool {
+	return isInternalOrgName(org.Name)
+}
+
+// isInternalOrgName returns true if name is the name of an internal
+// organization. Like internal buckets, their names start with an underscore.
+func isInternalOrgName(name string) bool {
+	return strings.HasPrefix(name, "_")
+}
+
+// skipInternalOrg reports whether an organization from the backup is skipped
+// because it is internal. Selecting an internal organization with --org
+// without including internal organizations is an error.
+func (b *cmdRestoreBuilder) skipInternalOrg(org *influxdb.Organization) (bool, error) {
+	if b.includeInternalOrgs || !isInternalOrg(org) {
+		return false, nil
+	}
+	if b.org.id != "" || b.org.name != "" {
+		return false, fmt.Errorf("cannot restore internal organization %q without --include-internal-orgs", org.Name)
+	}
+	b.logger.Info("Skipping internal organization", zap.String("id", org.ID.String()), zap.String("name", org.Name))
+	return true, nil
+}
+
+// backupOrganizations returns the organizations in the backup matching the org filter.
+func (b *cmdRestoreBuilder) backupOrganizations(ctx context.Context) (_ []*influxdb.Organization, err error) {
+	// Build a filter if org ID or org name were specified.
+	var filter influxdb.OrganizationFilter
+	if b.org.id != "" {
+		if filter.ID, err = influxdb.IDFromString(b.org.id); err != nil {
+			return nil, err
+		}
+	} else if b.org.name != "" {
+		filter.Name = &b.org.name
+	}
+
+	// Retrieve a list of all matching organizations.
+	orgs, _, err := b.tenantService.FindOrganizations(ctx, filter)
+	return orgs, err
+}
+
+func (b *cmdRestoreBuilder) restoreOrganizations(ctx context.Context) (err error) {
+	orgs, err := b.backupOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Restore matching organizations.
+	for _, org := range orgs {
+		if skip, err := b.skipInternalOrg(org); err != nil {
+			return err
+		} else if skip {
+			continue
+		}
+		if err := b.restoreOrganization(ctx, org); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *cmdRestoreBuilder) restoreOrganization(ctx context.Context, org *influxdb.Organization) (err error) {
+	b.logger.Info("Restoring organization", zap.String("id", org.ID.String()), zap.String("name", org.Name))
+
+	newOrg := *org
+	if b.newOrgName != "" {
+		newOrg.Name = b.newOrgName
+	}
+
+	// Create organization on server, if it doesn't already exist. A dry run
+	// does not know the ID of the organization on the server.
+	if b.dryRun {
+		newOrg.ID = 0
+	} else if o, err := b.orgService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &newOrg.Name}); influxdb.ErrorCode(err) == influxdb.ENotFound {
+		if err := b.orgService.CreateOrganization(ctx, &newOrg); err != nil {
+			return fmt.Errorf("cannot create organization: %w", err)
+		}
+		b.recordOrgResult(org, &newOrg, true)
+	} else if err != nil {
+		return fmt.Errorf("cannot find existing organization: %#v", err)
+	} else {
+		newOrg.ID = o.ID
+		b.recordOrgResult(org, &newOrg, false)
+	}
+
+	// Build a filter if bucket ID or bucket name were specified.
+	var filter influxdb.BucketFilter
+	filter.OrganizationID = &org.ID // match on backup's org ID
+	if b.bucketID != "" {
+		if filter.ID, err = influxdb.IDFromString(b.bucketID); err != nil {
+			return err
+		}
+	} else if b.bucketName != "" {
+		filter.Name = &b.bucketName
+	}
+
+	// Retrieve a list of all buckets for the organization in the local backup.
+	buckets, _, err := b.tenantService.FindBuckets(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	// Restore each matching bucket.
+	for _, bkt := range buckets {
+		// Skip internal buckets.
+		if isInternalBucket(bkt) {
+			continue
+		}
+
+		bkt = bkt.Clone()
+		bkt.OrgID = newOrg.ID
+
+		if err := b.restoreBucket(ctx, org.Name, newOrg.Name, bkt); err != nil {
+			return err
+		}
+	}
+
+	if entry := b.secretEntry(org.ID); entry != nil {
+		return b.restoreSecrets(ctx, entry, newOrg.ID, newOrg.Name)
+	}
+	return nil
+}
+
+// restoredPlaceholder is a secret created without a value by a restore.
+type restoredPlaceholder struct {
+	orgID   influxdb.ID
+	orgName string
+	key     string
+}
+
+// secretEntry returns the secret keys backed up for an organization in the
+// backup, or nil if none were.
+func (b *cmdRestoreBuilder) secretEntry(orgID influxdb.ID) *influxdb.ManifestSecretEntry {
+	for i := range b.secretEntries {
+		if b.secretEntries[i].OrganizationID == orgID.String() {
+			return &b.secretEntries[i]
+		}
+	}
+	return nil
+}
+
+// restoreSecrets creates the backed up secrets of entry that are missing from
+// the organization on the server as placeholders, with empty values. Secrets
+// on the server are left as they are.
+func (b *cmdRestoreBuilder) restoreSecrets(ctx context.Context, entry *influxdb.ManifestSecretEntry, orgID influxdb.ID, orgName string) error {
+	if b.dryRun {
+		if len(entry.Keys) > 0 {
+			b.logger.Info("Dry run: would create the secrets missing from the organization as placeholders", zap.String("org", orgName), zap.Strings("keys", entry.Keys))
+		}
+		return nil
+	}
+
+	keys, err := b.secretService.GetSecretKeys(ctx, orgID)
+	if err != nil && influxdb.ErrorCode(err) != influxdb.ENotFound {
+		return fmt.Errorf("cannot find secrets of organization %q: %w", orgName, err)
+	}
+	existing := make(map[string]bool)
+	for _, k := range keys {
+		existing[k] = true
+	}
+
+	placeholders := make(map[string]string)
+	var missing []string
+	for _, k := range entry.Keys {
+		if !existing[k] {
+			placeholders[k] = ""
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := b.secretService.PatchSecrets(ctx, orgID, placeholders); err != nil {
+		return fmt.Errorf("cannot create placeholder secrets in organization %q: %w", orgName, err)
+	}
+	b.logger.Info("Created placeholder secrets", zap.String("org", orgName), zap.Strings("keys", missing))
+	for _, k := range missing {
+		b.placeholders = append(b.placeholders, restoredPlaceholder{orgID: orgID, orgName: orgName, key: k})
+	}
+	return nil
+}
+
+// printPlaceholders prints the checklist of the secrets restored as
+// placeholders, whose values must be set before they can be used.
+func (b *cmdRestoreBuilder) printPlaceholders() {
+	if len(b.placeholders) == 0 || b.json {
+		return
+	}
+
+	fmt.Fprintf(b.w, "\nThe following secrets were restored without values. Set them with \"influx secret update\":\n\n")
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.WriteHeaders("Organization ID", "Organization", "Secret Key")
+	for _, p := range b.placeholders {
+		w.Write(map[string]interface{}{
+			"Organization ID": p.orgID.String(),
+			"Organization":    p.orgName,
+			"Secret Key":      p.key,
+		})
+	}
+}
+
+// restoreBucket restores a bucket of the organization orgName in the backup
+// to the organization newOrgName.
+func (b *cmdRestoreBuilder) restoreBucket(ctx context.Context, orgName, newOrgName string, bkt *influxdb.Bucket) (err error) {
+	b.logger.Info("Restoring bucket", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name))
+
+	// Lookup matching database from the meta store.
+	// Search using bucket ID from backup.
+	dbi := b.metaClient.Database(bkt.ID.String())
+	if dbi == nil {
+		if !b.skipMissingMeta {
+			return fmt.Errorf("bucket database not found: %s", bkt.ID.String())
+		}
+
+		// Skip every shard belonging to the bucket but keep restoring the rest.
+		b.logger.Warn("Bucket database not found, skipping bucket", zap.String("id", bkt.ID.String()), zap.String("name", bkt.Name))
+		for _, file := range b.bucketShardEntries(bkt.ID) {
+			if err := b.skipShard(file, "bucket database meta not found"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Serialize to protobufs.
+	buf, err := dbi.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("cannot marshal database info: %w", err)
+	}
+
+	// Create bucket on server.
+	newBucket := *bkt
+	if b.newBucketName != "" {
+		newBucket.Name = b.newBucketName
+	}
+	if used, ok := b.serverIDs[bkt.ID]; ok {
+		b.logger.Info("Bucket ID is in use on server, bucket will be restored with a new ID", zap.String("id", bkt.ID.String()), zap.String("used_by", used))
+	}
+	if b.dryRun {
+		return b.planBucket(orgName, newOrgName, bkt, newBucket.Name, dbi)
+	}
+
+	resumed, err := b.resumedBucket(bkt, newBucket.Name)
+	if err != nil {
+		return err
+	}
+	var shardIDMap map[uint64]uint64
+	if resumed != nil {
+		id, err := influxdb.IDFromString(resumed.ID)
+		if err != nil {
+			return fmt.Errorf("invalid bucket id %q in restore state file: %w", resumed.ID, err)
+		}
+		if _, err := b.bucketService.FindBucketByID(ctx, *id); err != nil {
+			return fmt.Errorf("cannot resume the restore of state file %s: cannot find bucket %q restored by a previous run: %w; remove it to restore from scratch", b.statePath, resumed.Name, err)
+		}
+		b.logger.Info("Skipping bucket restored by a previous run", zap.String("id", bkt.ID.String()), zap.String("new_id", resumed.ID))
+		shardIDMap = resumed.ShardIDs
+		newBucket.ID = *id
+		b.recordBucketResult(bkt, &newBucket, newOrgName, true, false)
+	} else {
+		replace, err := b.createBucket(ctx, &newBucket)
+		if err != nil {
+			return err
+		}
+
+		restore := b.restoreService.RestoreBucket
+		if replace {
+			restore = b.restoreService.ReplaceBucket
+		} else {
+			// Refuse to restore shards into storage that already holds data.
+			if used, ok := b.serverIDs[newBucket.ID]; ok {
+				return fmt.Errorf("cannot restore bucket %q: new bucket ID %s is already used by %s on the server; the empty bucket %q was left behind, remove orphaned data with \"influxd inspect report-orphans --remove\" and retry", bkt.Name, newBucket.ID, used, newBucket.Name)
+			}
+			b.serverIDs[newBucket.ID] = fmt.Sprintf("bucket %q", newBucket.Name)
+		}
+
+		// The bucket is not restored again if a proxy closes the connection, as
+		// its shards may have been created.
+		shardIDMap, err = restore(b.progressContext(ctx, zap.Stringer("bucket_id", newBucket.ID)), newBucket.ID, buf)
+		if err != nil {
+			return fmt.Errorf("cannot restore bucket: %w", err)
+		}
+		if err := b.recordBucket(bkt, &newBucket, shardIDMap); err != nil {
+			return err
+		}
+		b.recordBucketResult(bkt, &newBucket, newOrgName, false, replace)
+	}
+
+	// Restore each shard for the bucket.
+	for _, file := range b.bucketShardEntries(bkt.ID) {
+		// Skip if shard metadata was not imported.
+		newID, ok := shardIDMap[file.ShardID]
+		if !ok {
+			if err := b.skipShard(file, "shard meta not found"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if b.metadataOnly {
+			b.metadataOnlyShards = append(b.metadataOnlyShards, file)
+			continue
+		}
+		if err := b.restoreShard(ctx, newID, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createBucket creates newBucket, the bucket a bucket of the backup is
+// restored to. With --overwrite-bucket, if a bucket of its name is already
+// in its organization, newBucket is set to that bucket instead, and replace
+// is true: its shards must be replaced.
+func (b *cmdRestoreBuilder) createBucket(ctx context.Context, newBucket *influxdb.Bucket) (replace bool, err error) {
+	err = b.bucketService.CreateBucket(ctx, newBucket)
+	if err == nil {
+		return false, nil
+	} else if influxdb.ErrorCode(err) != influxdb.EConflict {
+		return false, fmt.Errorf("cannot create bucket: %w", err)
+	} else if !b.overwriteBucket {
+		return false, fmt.Errorf("cannot create bucket: %w; use --overwrite-bucket to restore into the existing bucket %q, replacing its data", err, newBucket.Name)
+	}
+
+	existing, err := b.bucketService.FindBucket(ctx, influxdb.BucketFilter{Name: &newBucket.Name, OrganizationID: &newBucket.OrgID})
+	if err != nil {
+		return false, fmt.Errorf("cannot find existing bucket %q: %w", newBucket.Name, err)
+	}
+	if existing.Type == influxdb.BucketTypeSystem {
+		return false, fmt.Errorf("cannot overwrite system bucket %q", existing.Name)
+	}
+	b.logger.Warn("Bucket exists, replacing its data", zap.String("name", existing.Name), zap.String("id", existing.ID.String()))
+	*newBucket = *existing
+	return true, nil
+}
+
+// restorePlanRow is a bucket a dry run would restore.
+type restorePlanRow struct {
+	org, bucket             string
+	targetOrg, targetBucket string
+	shards                  int
+	bytes                   int64
+}
+
+// planBucket records the restore of a bucket for a dry run, with the shards
+// listed in its metadata. The server restores those only, so the others are
+// skipped, as in a restore.
+func (b *cmdRestoreBuilder) planBucket(orgName, newOrgName string, bkt *influxdb.Bucket, newBucketName string, dbi *meta.DatabaseInfo) error {
+	if len(dbi.RetentionPolicies) != 1 {
+		return fmt.Errorf("cannot restore bucket %q: it must have 1 retention policy, not %d", bkt.Name, len(dbi.RetentionPolicies))
+	}
+	shardIDs := make(map[uint64]bool)
+	for _, sgi := range dbi.RetentionPolicies[0].ShardGroups {
+		for _, sh := range sgi.Shards {
+			shardIDs[sh.ID] = true
+		}
+	}
+
+	row := restorePlanRow{org: orgName, bucket: bkt.Name, targetOrg: newOrgName, targetBucket: newBucketName}
+	for _, file := range b.bucketShardEntries(bkt.ID) {
+		if !shardIDs[file.ShardID] {
+			if err := b.skipShard(file, "shard meta not found"); err != nil {
+				return err
+			}
+			continue
+		}
+		row.shards++
+		row.bytes += file.Size
+	}
+	b.plan = append(b.plan, row)
+	return nil
+}
+
+// planFull records the buckets of every shard in the backup for a dry run
+// of a full restore, which keeps their names.
+func (b *cmdRestoreBuilder) planFull() {
+	rows := make(map[string]*restorePlanRow)
+	for _, file := range b.shardEntries {
+		row := rows[file.BucketID]
+		if row == nil {
+			row = &restorePlanRow{org: file.OrganizationName, bucket: file.BucketName}
+			row.targetOrg, row.targetBucket = row.org, row.bucket
+			rows[file.BucketID] = row
+		}
+		row.shards++
+		row.bytes += file.Size
+	}
+	for _, row := range rows {
+		b.plan = append(b.plan, *row)
+	}
+}
+
+// shardFileProblem is a shard file of the backup that cannot be restored.
+type shardFileProblem struct {
+	entry   *influxdb.ManifestEntry
+	problem string
+}
+
+// checkShardFiles returns the problems of the shard files listed in the
+// manifests: files that are missing, do not match their manifest or cannot be
+// read as gzip files.
+func (b *cmdRestoreBuilder) checkShardFiles() ([]shardFileProblem, error) {
+	missing, err := missingShardFiles(b.path, b.shardEntries)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read manifest files: %w", err)
+	}
+	var problems []shardFileProblem
+	for _, file := range missing {
+		problems = append(problems, shardFileProblem{entry: file, problem: "missing"})
+	}
+
+	files := make([]*influxdb.ManifestEntry, 0, len(b.shardEntries))
+	for _, file := range b.shardEntries {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ShardID < files[j].ShardID })
+	for _, file := range files {
+		if !b.skipVerifyFiles {
+			if err := b.verifyShardFile(file); err != nil {
+				problems = append(problems, shardFileProblem{entry: file, problem: err.Error()})
+				continue
+			}
+		}
+		if err := b.checkShardFile(file); err != nil {
+			problems = append(problems, shardFileProblem{entry: file, problem: err.Error()})
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].entry.ShardID < problems[j].entry.ShardID })
+	return problems, nil
+}
+
+// checkShardFile returns an error if the shard file of the backup cannot be
+// read as a gzip file.
+func (b *cmdRestoreBuilder) checkShardFile(file *influxdb.ManifestEntry) error {
+	if b.archive != nil {
+		if f := b.archive.file(file.FileName); f == nil {
+			return fmt.Errorf("cannot open: %s: %w", file.FileName, os.ErrNotExist)
+		} else if f.gzipErr != nil {
+			return fmt.Errorf("cannot read: %w", f.gzipErr)
+		}
+		return nil
+	}
+	return checkShardFile(filepath.Join(b.path, file.FileName))
+}
+
+// checkShardFile returns an error if the shard file at path cannot be read
+// as a gzip file.
+func checkShardFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open: %w", err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("cannot read: %w", err)
+	}
+	return gr.Close()
+}
+
+// finishDryRun prints the buckets a dry run would restore and the problems
+// of the shard files of the backup, failing if there are any.
+func (b *cmdRestoreBuilder) finishDryRun() error {
+	problems, err := b.checkShardFiles()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(b.plan, func(i, j int) bool {
+		if b.plan[i].org != b.plan[j].org {
+			return b.plan[i].org < b.plan[j].org
+		}
+		return b.plan[i].bucket < b.plan[j].bucket
+	})
+	w := b.newTabWriter()
+	w.WriteHeaders("Organization", "Bucket", "Shards", "Bytes", "Target Organization", "Target Bucket")
+	for _, row := range b.plan {
+		w.Write(map[string]interface{}{
+			"Organization":        row.org,
+			"Bucket":              row.bucket,
+			"Shards":              row.shards,
+			"Bytes":               row.bytes,
+			"Target Organization": row.targetOrg,
+			"Target Bucket":       row.targetBucket,
+		})
+	}
+	w.Flush()
+
+	if len(problems) > 0 {
+		fmt.Fprintf(b.w, "\nThe following shard files of the backup cannot be restored:\n\n")
+		w := b.newTabWriter()
+		w.WriteHeaders("Shard", "Bucket", "File", "Problem")
+		for _, p := range problems {
+			w.Write(map[string]interface{}{
+				"Shard":   p.entry.ShardID,
+				"Bucket":  p.entry.BucketName,
+				"File":    p.entry.FileName,
+				"Problem": p.problem,
+			})
+		}
+		w.Flush()
+		return fmt.Errorf("dry run found %d shard files that cannot be restored; the backup is incomplete", len(problems))
+	}
+
+	b.logger.Info("Dry run complete; the server was not contacted",
+		zap.Int("buckets", len(b.plan)),
+		zap.Int("skipped_shards", len(b.skippedShards)),
+	)
+	return nil
+}
+
+// bucketShardEntries returns the shard entries belonging to a bucket in the backup,
+// ordered by shard ID.
+func (b *cmdRestoreBuilder) bucketShardEntries(bucketID influxdb.ID) []*influxdb.ManifestEntry {
+	var a []*influxdb.ManifestEntry
+	for _, file := range b.shardEntries {
+		if file.BucketID == bucketID.String() {
+			a = append(a, file)
+		}
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].ShardID < a[j].ShardID })
+	return a
+}
+
+// skippedShard is a shard from the backup that could not be restored.
+type skippedShard struct {
+	entry  *influxdb.ManifestEntry
+	reason string
+}
+
+// skipShard records a shard that cannot be restored. Returns an error instead
+// when running in strict mode.
+func (b *cmdRestoreBuilder) skipShard(file *influxdb.ManifestEntry, reason string) error {
+	if b.strict {
+		return fmt.Errorf("cannot restore shard %d from %s: %s", file.ShardID, file.FileName, reason)
+	}
+
+	b.logger.Warn("Meta info not found, skipping file", zap.Uint64("shard", file.ShardID), zap.String("bucket_id", file.BucketID), zap.String("filename", file.FileName), zap.String("reason", reason))
+	b.skippedShards = append(b.skippedShards, skippedShard{entry: file, reason: reason})
+	return nil
+}
+
+// logSkippedShards logs a detailed manifest of all shards skipped during the restore.
+func (b *cmdRestoreBuilder) logSkippedShards() {
+	for _, sh := range b.skippedShards {
+		b.logger.Warn("Skipped shard",
+			zap.Uint64("shard", sh.entry.ShardID),
+			zap.String("org_id", sh.entry.OrganizationID),
+			zap.String("org", sh.entry.OrganizationName),
+			zap.String("bucket_id", sh.entry.BucketID),
+			zap.String("bucket", sh.entry.BucketName),
+			zap.String("filename", sh.entry.FileName),
+			zap.Int64("size", sh.entry.Size),
+			zap.String("reason", sh.reason),
+		)
+	}
+	b.logger.Warn("Restore complete with skipped shards", zap.Int("skipped", len(b.skippedShards)))
+}
+
+// logMetadataOnly states how many shards --metadata-only did not restore the
+// data of, so that it is not mistaken for data gone missing.
+func (b *cmdRestoreBuilder) logMetadataOnly() {
+	if !b.metadataOnly {
+		return
+	}
+	var size int64
+	for _, file := range b.metadataOnlyShards {
+		size += file.Size
+	}
+	b.logger.Warn("Restored metadata only; the data of the shards of the backup was intentionally not restored",
+		zap.Int("shards_skipped", len(b.metadataOnlyShards)),
+		zap.Int64("bytes_skipped", size))
+}
+
+func (b *cmdRestoreBuilder) restoreShard(ctx context.Context, newShardID uint64, file *influxdb.ManifestEntry) error {
+	if b.shardRestored(newShardID, file) {
+		b.logger.Info("Skipping shard restored by a previous run", zap.Uint64("shard", newShardID), zap.String("filename", file.FileName))
+		b.recordShardResult(newShardID, file, true)
+		return nil
+	}
+
+	b.logger.Info("Restoring shard live from backup", zap.Uint64("shard", newShardID), zap.String("filename", file.FileName))
+	if file.Partial() {
+		b.logger.Info("Shard backup contains only some measurements", zap.Uint64("shard", newShardID), zap.Strings("measurements", file.Measurements))
+	}
+
+	// Never stream a truncated or corrupted file, which the server would
+	// restore as far as it can be read.
+	if !b.skipVerifyFiles {
+		if err := b.verifyShardFile(file); err != nil {
+			return fmt.Errorf("cannot restore shard %d: %w; use --skip-verify-files to restore it anyway", file.ShardID, err)
+		}
+	}
+
+	// The files of an archive are restored once all are verified, as it is
+	// read again.
+	if b.archive != nil {
+		b.archive.queue(newShardID, file)
+		return nil
+	}
+
+	err := b.transfer(ctx, func(ctx context.Context) error {
+		sf, err := openShardFile(filepath.Join(b.path, file.FileName))
+		if err != nil {
+			return err
+		}
+		defer sf.Close()
+		return b.restoreService.RestoreShard(ctx, newShardID, sf)
+	}, zap.Uint64("shard", newShardID))
+	if err != nil {
+		return err
+	}
+	b.recordShardResult(newShardID, file, false)
+	return b.recordShard(newShardID, file)
+}
+
+// progressContext returns the context of a restore request, asking the
+// server for progress frames if they are enabled.
+func (b *cmdRestoreBuilder) progressContext(ctx context.Context, fields ...zap.Field) context.Context {
+	return withTransferProgress(ctx, b.logger, b.transferProgressInterval(), fields...)
+}
+
+// transferProgressInterval returns the interval of the progress frames to
+// ask the server for, or 0 if they are disabled.
+func (b *cmdRestoreBuilder) transferProgressInterval() time.Duration {
+	b.progressMu.Lock()
+	defer b.progressMu.Unlock()
+	return b.progressInterval
+}
+
+// transfer runs fn, an upload to the server, with a context asking for
+// progress frames if they are enabled. If they are not, and the upload fails
+// as it does when a proxy closes a connection idle for too long, they are
+// enabled for the rest of the restore and fn is run again.
+func (b *cmdRestoreBuilder) transfer(ctx context.Context, fn func(ctx context.Context) error, fields ...zap.Field) error {
+	interval := b.transferProgressInterval()
+	err := fn(withTransferProgress(ctx, b.logger, interval, fields...))
+	if err == nil || interval > 0 || !http.IsProxyTimeout(err) {
+		return err
+	}
+
+	b.progressMu.Lock()
+	if b.progressInterval == 0 {
+		b.progressInterval = defaultProgressInterval
+	}
+	interval = b.progressInterval
+	b.progressMu.Unlock()
+
+	b.logger.Warn("Connection closed as if by a proxy timing out, retrying with progress frames",
+		append(fields[:len(fields):len(fields)], zap.Duration("progress_interval", interval), zap.Error(err))...)
+	return fn(withTransferProgress(ctx, b.logger, interval, fields...))
+}
+
+// retryPolicy returns how uploads are retried, as --retries and
+// --retry-max-duration ask, logging each retry.
+func (b *cmdRestoreBuilder) retryPolicy() http.RetryPolicy {
+	return http.RetryPolicy{
+		Retries:     b.retries,
+		MaxDuration: b.retryMaxDuration,
+		OnRetry: func(req *nethttp.Request, attempt int, wait time.Duration, err error) {
+			b.logger.Warn("Upload failed, retrying",
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path),
+				zap.Int("attempt", attempt),
+				zap.Int("retries", b.retries),
+				zap.Duration("wait", wait),
+				zap.Error(err))
+		},
+	}
+}
+
+// shardFile is the decompressed content of a shard file, which is opened
+// again when its upload is retried.
+type shardFile struct {
+	path string
+	f    *os.File
+	gr   *gzip.Reader
+}
+
+func openShardFile(path string) (*shardFile, error) {
+	sf := &shardFile{path: path}
+	if _, err := sf.Reopen(); err != nil {
+		return nil, err
+	}
+	return sf, nil
+}
+
+func (sf *shardFile) Read(p []byte) (int, error) {
+	return sf.gr.Read(p)
+}
+
+// Reopen closes the file and opens it again, to read it from its start.
+func (sf *shardFile) Reopen() (io.Reader, error) {
+	if err := sf.Close(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(sf.path)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	sf.f, sf.gr = f, gr
+	return sf, nil
+}
+
+func (sf *shardFile) Close() error {
+	if sf.f == nil {
+		return nil
+	}
+	sf.gr.Close()
+	err := sf.f.Close()
+	sf.f, sf.gr = nil, nil
+	return err
+}
+
+// streamShard restores a shard from the gzipped content of its file.
+func (b *cmdRestoreBuilder) streamShard(ctx context.Context, newShardID uint64, r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	return b.restoreService.RestoreShard(ctx, newShardID, gr)
+}
+
+// restoreArchiveShards restores the shards queued by restoreShard from the
+// archive, one at a time in the order of the archive. It returns the number
+// of shards restored.
+func (b *cmdRestoreBuilder) restoreArchiveShards(ctx context.Context) (int, error) {
+	return b.archive.restoreQueued(func(newShardID uint64, file *influxdb.ManifestEntry, r io.Reader) error {
+		b.logger.Info("Restoring shard from archive", zap.Uint64("shard", newShardID), zap.String("filename", file.FileName))
+		if err := b.streamShard(b.progressContext(ctx, zap.Uint64("shard", newShardID)), newShardID, r); err != nil {
+			if http.IsProxyTimeout(err) && b.transferProgressInterval() == 0 {
+				return fmt.Errorf("cannot restore shard %d: %w; if a proxy closes idle connections, retry with --progress-interval", file.ShardID, err)
+			}
+			return fmt.Errorf("cannot restore shard %d: %w", file.ShardID, err)
+		}
+		b.recordShardResult(newShardID, file, false)
+		return b.recordShard(newShardID, file)
+	})
+}
+
+// warnUnverifiedFiles warns about the shard files whose manifests, written
+// by older versions, have no checksum to verify them against.
+func (b *cmdRestoreBuilder) warnUnverifiedFiles() {
+	if b.skipVerifyFiles {
+		return
+	}
+	var unverified []uint64
+	for _, file := range b.shardEntries {
+		if file.Checksum == "" {
+			unverified = append(unverified, file.ShardID)
+		}
+	}
+	if len(unverified) > 0 {
+		sort.Slice(unverified, func(i, j int) bool { return unverified[i] < unverified[j] })
+		b.logger.Warn("Manifests have no checksums for some shard files, which are restored without being verified", zap.Uint64s("shards", unverified))
+	}
+}
+
+// verifyShardFile returns an error if the shard file of the backup does not
+// have the size and checksum recorded in its manifest. The files of an
+// archive are verified against what was read when it was indexed.
+func (b *cmdRestoreBuilder) verifyShardFile(file *influxdb.ManifestEntry) error {
+	if b.archive == nil {
+		return verifyShardFile(b.path, file)
+	}
+
+	f := b.archive.file(file.FileName)
+	if f == nil {
+		return fmt.Errorf("%s: %w", file.FileName, os.ErrNotExist)
+	}
+	if file.Size > 0 && f.size != file.Size {
+		return fmt.Errorf("%s is %d bytes, its manifest lists %d", file.FileName, f.size, file.Size)
+	}
+	if file.Checksum != "" && f.checksum != file.Checksum {
+		return fmt.Errorf("checksum of %s does not match its manifest", file.FileName)
+	}
+	return nil
+}
+
+// verifyShardFile returns an error if the shard file of a backup in path does
+// not have the size and checksum recorded in its manifest. Each is only
+// verified if the manifest has one.
+func verifyShardFile(path string, file *influxdb.ManifestEntry) error {
+	f, err := os.Open(filepath.Join(path, file.FileName))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if file.Size > 0 && fi.Size() != file.Size {
+		return fmt.Errorf("%s is %d bytes, its manifest lists %d", file.FileName, fi.Size(), file.Size)
+	}
+	if file.Checksum == "" {
+		return nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != file.Checksum {
+		return fmt.Errorf("checksum of %s does not match its manifest", file.FileName)
+	}
+	return nil
+}
+
+// loadIncremental loads multiple manifest files from a given directory. It
+// returns the latest KV entry, or nil if there are no manifests, and the most
+// recent backup of each shard.
+func loadIncremental(path string) (*influxdb.ManifestKVEntry, map[uint64]*influxdb.ManifestEntry, error) {
+	return loadIncrementalWith(path, func(fileName string) bool {
+		_, err := os.Stat(filepath.Join(path, fileName))
+		return err == nil
+	})
+}
+
+// loadIncrementalWith is loadIncremental with the shard files of the backup
+// that exist reported by exists.
+func loadIncrementalWith(path string, exists func(fileName string) bool) (*influxdb.ManifestKVEntry, map[uint64]*influxdb.ManifestEntry, error) {
+	shardEntries := make(map[uint64]*influxdb.ManifestEntry)
+
+	// Read all manifest files from path, sort in descending time.
+	manifests, err := filepath.Glob(filepath.Join(path, "*.manifest"))
+	if err != nil {
+		return nil, nil, err
+	} else if len(manifests) == 0 {
+		return nil, shardEntries, nil
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(manifests)))
+
+	var kvEntry *influxdb.ManifestKVEntry
+	for _, filename := range manifests {
+		// Skip file if it is a directory.
+		if fi, err := os.Stat(filename); err != nil {
+			return nil, nil, err
+		} else if fi.IsDir() {
+			continue
+		}
+
+		// Read manifest file for backup.
+		manifest, err := readManifest(filename)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		// Save latest KV entry.
-		if b.kvEntry == nil {
-			b.kvEntry = &manifest.KV
+		if kvEntry == nil {
+			kvEntry = &manifest.KV
 		}
 
 		// Load most recent backup per shard.
 		for i := range manifest.Files {
 			sh := manifest.Files[i]
-			if _, err := os.Stat(filepath.Join(b.path, sh.FileName)); err != nil {
+			if !exists(sh.FileName) {
 				continue
 			}
 
-			entry := b.shardEntries[sh.ShardID]
+			entry := shardEntries[sh.ShardID]
 			if entry == nil || sh.LastModified.After(entry.LastModified) {
-				b.shardEntries[sh.ShardID] = &sh
+				shardEntries[sh.ShardID] = &sh
 			}
 		}
 	}
 
-	return nil
+	return kvEntry, shardEntries, nil
+}
+
+// shardFileExists reports whether the shard file with fileName is in the
+// backup.
+func (b *cmdRestoreBuilder) shardFileExists(fileName string) bool {
+	if b.archive != nil {
+		return b.archive.file(fileName) != nil
+	}
+	_, err := os.Stat(filepath.Join(b.path, fileName))
+	return err == nil
+}
+
+// loadSecretEntries returns the secret keys recorded in the latest manifest
+// file in path, as those of earlier backups may since have been deleted.
+func loadSecretEntries(path string) ([]influxdb.ManifestSecretEntry, error) {
+	manifests, err := filepath.Glob(filepath.Join(path, "*.manifest"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(manifests)))
+
+	for _, filename := range manifests {
+		if fi, err := os.Stat(filename); err != nil {
+			return nil, err
+		} else if fi.IsDir() {
+			continue
+		}
+
+		manifest, err := readManifest(filename)
+		if err != nil {
+			return nil, err
+		}
+		return manifest.Secrets, nil
+	}
+	return nil, nil
+}
+
+// missingShardFiles returns the shards listed in the manifest files in path
+// that are not restored because none of their files exist, ordered by shard
+// ID.
+func missingShardFiles(path string, shardEntries map[uint64]*influxdb.ManifestEntry) ([]*influxdb.ManifestEntry, error) {
+	manifests, err := filepath.Glob(filepath.Join(path, "*.manifest"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(manifests)))
+
+	missing := make(map[uint64]*influxdb.ManifestEntry)
+	for _, filename := range manifests {
+		if fi, err := os.Stat(filename); err != nil {
+			return nil, err
+		} else if fi.IsDir() {
+			continue
+		}
+
+		manifest, err := readManifest(filename)
+		if err != nil {
+			return nil, err
+		}
+		for i := range manifest.Files {
+			sh := manifest.Files[i]
+			if _, ok := shardEntries[sh.ShardID]; ok {
+				continue
+			}
+			// Report the file of the latest backup of the shard.
+			if _, ok := missing[sh.ShardID]; !ok {
+				missing[sh.ShardID] = &sh
+			}
+		}
+	}
+
+	a := make([]*influxdb.ManifestEntry, 0, len(missing))
+	for _, file := range missing {
+		a = append(a, file)
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].ShardID < a[j].ShardID })
+	return a, nil
 }
 
 func (b *cmdRestoreBuilder) newCmd(use string, runE func(*cobra.Command, []string) error) *cobra.Command {
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// backupArchiveExts are the extensions of tar archives of backups, which may
+// be gzipped.
+var backupArchiveExts = []string{".tar", ".tar.gz", ".tgz"}
+
+// stdinPath is the path of a backup archive read from standard input.
+const stdinPath = "-"
+
+// gzipMagic are the first bytes of gzip compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// tarMagic is at tarMagicOffset in the first header of a tar archive.
+var tarMagic = []byte("ustar")
+
+const tarMagicOffset = 257
+
+// backupMetaExts are the extensions of the files of a backup that are
+// written to disk when it is read from an archive: its manifests, their
+// signatures and its KV files, which are opened as bolt files.
+var backupMetaExts = []string{".manifest", ".manifest" + manifestSignatureExt, ".bolt"}
+
+// errNotBackupArchive is returned when an archive of a backup cannot be read
+// as a tar archive, gzipped or not.
+var errNotBackupArchive = errors.New("not a backup archive")
+
+// errMissingManifest is returned when an archive holds no backup manifest.
+var errMissingManifest = errors.New("missing manifest")
+
+// isBackupArchive reports whether path is a tar archive of a backup, which
+// may be gzipped, or standard input, rather than a backup directory.
+// Archives are recognized by their extension, or else their content.
+func isBackupArchive(path string) (bool, error) {
+	if path == stdinPath {
+		return true, nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	} else if fi.IsDir() {
+		return false, nil
+	}
+	for _, ext := range backupArchiveExts {
+		if strings.HasSuffix(path, ext) {
+			return true, nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	head := make([]byte, tarMagicOffset+len(tarMagic))
+	n, _ := io.ReadFull(f, head)
+	if bytes.HasPrefix(head[:n], gzipMagic) || (n == len(head) && bytes.Equal(head[tarMagicOffset:], tarMagic)) {
+		return true, nil
+	}
+	return false, fmt.Errorf("%s is not a backup directory or a tar archive", path)
+}
+
+// archiveFile is a file of a backup archive that is not written to disk,
+// as indexed by the first read of the archive.
+type archiveFile struct {
+	size     int64
+	checksum string
+	// gzipErr is why the file cannot be read as a gzip file, if it cannot.
+	gzipErr error
+}
+
+// archiveShard is a shard queued to be restored from a file of an archive.
+type archiveShard struct {
+	newShardID uint64
+	file       *influxdb.ManifestEntry
+}
+
+// backupArchive is a tar archive of a backup directory, which may be
+// gzipped, read without extracting its shard files.
+//
+// The archive is read a first time to index it: the manifests, signatures
+// and KV files of the backup are written to a temporary directory, from
+// which the backup is loaded, and the size and checksum of the other files
+// are recorded, so they are verified before any is restored. The shards to
+// restore are then queued, and restored as they are read again from the
+// archive.
+//
+// Standard input cannot be read twice, and the manifests telling which shard
+// files to restore may follow them, so the files of an archive read from it
+// are all written to the temporary directory, and restored from there.
+type backupArchive struct {
+	path string
+	// dir is the temporary directory the archive is indexed to, and
+	// backupDir the directory of the backup within it.
+	dir, backupDir string
+	// prefix is the directory of the backup within the archive.
+	prefix string
+	// files holds the files of the backup that are not in backupDir, by
+	// name.
+	files map[string]*archiveFile
+
+	mu     sync.Mutex
+	queued map[string]archiveShard
+}
+
+// openBackupArchive indexes the archive at archivePath, reading it from stdin
+// if archivePath is stdinPath. The error wraps errNotBackupArchive if the
+// archive cannot be read, and errMissingManifest if it holds no manifest.
+func openBackupArchive(archivePath string, stdin io.Reader) (_ *backupArchive, err error) {
+	a := &backupArchive{
+		path:   archivePath,
+		files:  make(map[string]*archiveFile),
+		queued: make(map[string]archiveShard),
+	}
+	if a.dir, err = ioutil.TempDir("", "influx-restore-"); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			a.Close()
+		}
+	}()
+
+	r := stdin
+	if !a.stdin() {
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if err := a.index(r); err != nil {
+		return nil, err
+	}
+
+	if a.backupDir, err = findBackupDir(a.dir); err != nil {
+		return nil, fmt.Errorf("%s: %w", a.name(), err)
+	}
+	a.prefix, _ = filepath.Rel(a.dir, a.backupDir)
+	a.prefix = filepath.ToSlash(a.prefix)
+
+	// The files outside the directory of the backup are not part of it.
+	files := make(map[string]*archiveFile)
+	for name, file := range a.files {
+		if dir, base := path.Split(name); path.Clean(dir) == a.prefix {
+			files[base] = file
+		}
+	}
+	a.files = files
+	return a, nil
+}
+
+// index reads the archive from r, writing the files of the backup that must
+// be on disk to a.dir and recording the others in a.files.
+func (a *backupArchive) index(r io.Reader) error {
+	tr, err := newArchiveReader(r)
+	if err != nil {
+		return fmt.Errorf("%s is %w: %v", a.name(), errNotBackupArchive, err)
+	}
+
+	for first := true; ; first = false {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil && first {
+			return fmt.Errorf("%s is %w: %v", a.name(), errNotBackupArchive, err)
+		} else if err != nil {
+			return fmt.Errorf("cannot read %s: %w", a.name(), err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, err := archiveEntryName(hdr)
+		if err != nil {
+			return err
+		}
+		if a.stdin() || isBackupMetaFile(name) {
+			target := filepath.Join(a.dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return err
+			}
+			if err := extractTarFile(tr, target); err != nil {
+				return fmt.Errorf("cannot read %s from %s: %w", name, a.name(), err)
+			}
+			continue
+		}
+
+		file, err := indexArchiveFile(tr, hdr.Size)
+		if err != nil {
+			return fmt.Errorf("cannot read %s from %s: %w", name, a.name(), err)
+		}
+		a.files[name] = file
+	}
+}
+
+// Close removes the temporary directory of the archive.
+func (a *backupArchive) Close() {
+	os.RemoveAll(a.dir)
+}
+
+func (a *backupArchive) stdin() bool {
+	return a.path == stdinPath
+}
+
+// name returns the name of the archive in messages.
+func (a *backupArchive) name() string {
+	if a.stdin() {
+		return "standard input"
+	}
+	return a.path
+}
+
+// streamed reports whether the shard files of the backup are read from the
+// archive rather than from its temporary directory.
+func (a *backupArchive) streamed() bool {
+	return !a.stdin()
+}
+
+// file returns the indexed shard file of the backup with name, or nil if
+// the archive has none.
+func (a *backupArchive) file(name string) *archiveFile {
+	return a.files[name]
+}
+
+// queue queues a shard to be restored from its file by restoreQueued.
+func (a *backupArchive) queue(newShardID uint64, file *influxdb.ManifestEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.queued[file.FileName] = archiveShard{newShardID: newShardID, file: file}
+}
+
+// restoreQueued reads the archive again and calls restore with each queued
+// shard and the content of its file, in the order of the archive. It returns
+// the number of shards restored.
+func (a *backupArchive) restoreQueued(restore func(newShardID uint64, file *influxdb.ManifestEntry, r io.Reader) error) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	tr, err := newArchiveReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read %s: %w", a.name(), err)
+	}
+
+	var restored int
+	for len(a.queued) > 0 {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return restored, fmt.Errorf("cannot read %s: %w", a.name(), err)
+		}
+		name, err := archiveEntryName(hdr)
+		if err != nil {
+			return restored, err
+		}
+		dir, base := path.Split(name)
+		shard, ok := a.queued[base]
+		if hdr.Typeflag != tar.TypeReg || path.Clean(dir) != a.prefix || !ok {
+			continue
+		}
+		if file := a.files[base]; file == nil || hdr.Size != file.size {
+			return restored, fmt.Errorf("%s changed in %s since it was verified", base, a.name())
+		}
+
+		if err := restore(shard.newShardID, shard.file, tr); err != nil {
+			return restored, err
+		}
+		delete(a.queued, base)
+		restored++
+	}
+
+	if len(a.queued) > 0 {
+		var names []string
+		for name := range a.queued {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return restored, fmt.Errorf("%s no longer contains %s", a.name(), strings.Join(names, ", "))
+	}
+	return restored, nil
+}
+
+// newArchiveReader returns a reader of the tar archive read from r, which
+// is gunzipped if it is gzipped.
+func newArchiveReader(r io.Reader) (*tar.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return tar.NewReader(gr), nil
+	}
+	return tar.NewReader(br), nil
+}
+
+// archiveEntryName returns the name of an entry of an archive as a clean
+// slash separated path, refusing paths outside the archive.
+func archiveEntryName(hdr *tar.Header) (string, error) {
+	name := path.Clean(strings.ReplaceAll(hdr.Name, "\\", "/"))
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("invalid path in archive: %s", hdr.Name)
+	}
+	return name, nil
+}
+
+// isBackupMetaFile reports whether the file of an archive with name is a
+// manifest, a signature or a KV file.
+func isBackupMetaFile(name string) bool {
+	for _, ext := range backupMetaExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexArchiveFile reads a file of size bytes from r, returning its checksum
+// and whether it can be read as a gzip file.
+func indexArchiveFile(r io.Reader, size int64) (*archiveFile, error) {
+	h := sha256.New()
+	br := bufio.NewReader(io.TeeReader(r, h))
+
+	file := &archiveFile{size: size}
+	if gr, err := gzip.NewReader(br); err != nil {
+		file.gzipErr = err
+	} else {
+		gr.Close()
+	}
+	if _, err := io.Copy(ioutil.Discard, br); err != nil {
+		return nil, err
+	}
+	file.checksum = hex.EncodeToString(h.Sum(nil))
+	return file, nil
+}
+
+func extractTarFile(r io.Reader, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// findBackupDir returns the directory holding the manifests of the backup
+// extracted to root, which may have been archived within a directory.
+func findBackupDir(root string) (string, error) {
+	var dirs []string
+	seen := make(map[string]bool)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if dir := filepath.Dir(path); !info.IsDir() && filepath.Ext(path) == ".manifest" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	switch len(dirs) {
+	case 0:
+		return "", fmt.Errorf("%w: archive holds no .manifest file", errMissingManifest)
+	case 1:
+		return dirs[0], nil
+	default:
+		rel := make([]string, len(dirs))
+		for i, dir := range dirs {
+			rel[i], _ = filepath.Rel(root, dir)
+		}
+		return "", fmt.Errorf("archive contains more than one backup: %s", strings.Join(rel, ", "))
+	}
+}
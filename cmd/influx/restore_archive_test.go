@@ -0,0 +1,301 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// writeTestArchive writes a tar archive of files, keyed by name, to path. It
+// is gzipped unless path ends in .tar.
+func writeTestArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	var w io.WriteCloser = nopWriteCloser{f}
+	if !strings.HasSuffix(path, ".tar") {
+		w = gzip.NewWriter(f)
+	}
+	tw := tar.NewWriter(w)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// gzipped returns s gzipped.
+func gzipped(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.String()
+}
+
+func TestBackupArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influx-restore-archive-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	shard := gzipped(t, "shard")
+	files := map[string]string{
+		"backup/20200101T000000Z.manifest":  "{}",
+		"backup/20200101T000000Z.bolt":      "kv",
+		"backup/20200101T000000Z.s1.tar.gz": shard,
+		"backup/20200101T000000Z.s2.tar.gz": "not gzipped",
+		"backup/notes/README":               "notes",
+	}
+
+	for _, name := range []string{"backup.tar.gz", "backup.tar"} {
+		t.Run("indexes a backup within a directory of "+name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			writeTestArchive(t, path, files)
+
+			archive, err := isBackupArchive(path)
+			require.NoError(t, err)
+			require.True(t, archive)
+
+			a, err := openBackupArchive(path, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "backup", filepath.Base(a.backupDir))
+			assert.True(t, a.streamed())
+
+			// Only the metadata is written to disk.
+			buf, err := ioutil.ReadFile(filepath.Join(a.backupDir, "20200101T000000Z.bolt"))
+			require.NoError(t, err)
+			assert.Equal(t, "kv", string(buf))
+			_, err = os.Stat(filepath.Join(a.backupDir, "20200101T000000Z.s1.tar.gz"))
+			assert.True(t, os.IsNotExist(err))
+
+			file := a.file("20200101T000000Z.s1.tar.gz")
+			require.NotNil(t, file)
+			assert.Equal(t, int64(len(shard)), file.size)
+			assert.NoError(t, file.gzipErr)
+			assert.Error(t, a.file("20200101T000000Z.s2.tar.gz").gzipErr)
+			assert.Nil(t, a.file("README"), "files outside the backup directory are not part of it")
+
+			a.Close()
+			_, err = os.Stat(a.backupDir)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+
+	t.Run("writes the files read from stdin to disk", func(t *testing.T) {
+		path := filepath.Join(dir, "stdin.tar.gz")
+		writeTestArchive(t, path, files)
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+
+		a, err := openBackupArchive(stdinPath, f)
+		require.NoError(t, err)
+		defer a.Close()
+		assert.False(t, a.streamed())
+		buf, err := ioutil.ReadFile(filepath.Join(a.backupDir, "20200101T000000Z.s1.tar.gz"))
+		require.NoError(t, err)
+		assert.Equal(t, shard, string(buf))
+	})
+
+	t.Run("detects archives by content", func(t *testing.T) {
+		path := filepath.Join(dir, "backup")
+		writeTestArchive(t, path, map[string]string{"20200101T000000Z.manifest": "{}"})
+		archive, err := isBackupArchive(path)
+		require.NoError(t, err)
+		assert.True(t, archive)
+
+		a, err := openBackupArchive(path, nil)
+		require.NoError(t, err)
+		defer a.Close()
+		_, err = os.Stat(filepath.Join(a.backupDir, "20200101T000000Z.manifest"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("directories are not archives", func(t *testing.T) {
+		archive, err := isBackupArchive(dir)
+		require.NoError(t, err)
+		assert.False(t, archive)
+	})
+
+	t.Run("rejects files that are not archives", func(t *testing.T) {
+		path := filepath.Join(dir, "corrupt.tgz")
+		require.NoError(t, ioutil.WriteFile(path, []byte("not gzipped"), 0600))
+		archive, err := isBackupArchive(path)
+		require.NoError(t, err)
+		require.True(t, archive)
+		_, err = openBackupArchive(path, nil)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errNotBackupArchive), err.Error())
+		assert.Contains(t, err.Error(), "not a backup archive")
+
+		_, err = openBackupArchive(stdinPath, strings.NewReader("not gzipped"))
+		assert.True(t, errors.Is(err, errNotBackupArchive))
+
+		path = filepath.Join(dir, "20200101T000000Z.manifest")
+		require.NoError(t, ioutil.WriteFile(path, []byte("{}"), 0600))
+		_, err = isBackupArchive(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects archives without a manifest", func(t *testing.T) {
+		path := filepath.Join(dir, "empty.tar.gz")
+		writeTestArchive(t, path, map[string]string{"backup/20200101T000000Z.s1.tar.gz": shard})
+		_, err := openBackupArchive(path, nil)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errMissingManifest), err.Error())
+		assert.False(t, errors.Is(err, errNotBackupArchive))
+		assert.Contains(t, err.Error(), "missing manifest")
+	})
+
+	t.Run("rejects paths outside the archive", func(t *testing.T) {
+		path := filepath.Join(dir, "escape.tar.gz")
+		writeTestArchive(t, path, map[string]string{"../20200101T000000Z.manifest": "{}"})
+		_, err := openBackupArchive(path, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid path")
+	})
+
+	t.Run("rejects archives of more than one backup", func(t *testing.T) {
+		path := filepath.Join(dir, "two.tar.gz")
+		writeTestArchive(t, path, map[string]string{
+			"a/20200101T000000Z.manifest": "{}",
+			"b/20200101T000000Z.manifest": "{}",
+		})
+		_, err := openBackupArchive(path, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than one backup")
+	})
+}
+
+func TestCmdRestore_Archive(t *testing.T) {
+	ctx := context.Background()
+
+	// archiveBackup writes an archive of the backup, with a manifest listing
+	// its files, and returns its path.
+	archiveBackup := func(t *testing.T, bk *testBackup, name string) string {
+		t.Helper()
+		manifest := influxdb.Manifest{KV: influxdb.ManifestKVEntry{FileName: bk.kvFileName}}
+		for _, entry := range bk.shardEntries {
+			manifest.Files = append(manifest.Files, *entry)
+		}
+		buf, err := json.Marshal(manifest)
+		require.NoError(t, err)
+
+		files := map[string]string{"backup/20200101T000000Z.manifest": string(buf)}
+		fis, err := ioutil.ReadDir(bk.dir)
+		require.NoError(t, err)
+		for _, fi := range fis {
+			buf, err := ioutil.ReadFile(filepath.Join(bk.dir, fi.Name()))
+			require.NoError(t, err)
+			files["backup/"+fi.Name()] = string(buf)
+		}
+		path := filepath.Join(bk.dir, name)
+		writeTestArchive(t, path, files)
+		return path
+	}
+	newBuilder := func(path string, restoreSvc *fakeRestoreService) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = path
+		b.logger = zaptest.NewLogger(t)
+		b.full = true
+		b.includeInternalOrgs = true
+		b.restoreService = restoreSvc
+		return b
+	}
+
+	t.Run("restores the shard files of an archive", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem", "disk")
+		defer bk.cleanup()
+		path := archiveBackup(t, bk, "backup.tar.gz")
+
+		restoreSvc := &fakeRestoreService{}
+		b := newBuilder(path, restoreSvc)
+		cleanup, err := b.loadBackup()
+		require.NoError(t, err)
+		defer cleanup()
+		require.NotNil(t, b.archive)
+		require.Len(t, b.shardEntries, 3)
+
+		require.NoError(t, b.restoreFull(ctx))
+		assert.ElementsMatch(t, []uint64{1, 2, 3}, restoreSvc.restoredShards)
+	})
+
+	t.Run("restores an archive read from stdin", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+		buf, err := ioutil.ReadFile(archiveBackup(t, bk, "backup.tar"))
+		require.NoError(t, err)
+
+		restoreSvc := &fakeRestoreService{}
+		b := newBuilder(stdinPath, restoreSvc)
+		b.in = bytes.NewReader(buf)
+		cleanup, err := b.loadBackup()
+		require.NoError(t, err)
+		defer cleanup()
+
+		require.NoError(t, b.restoreFull(ctx))
+		assert.ElementsMatch(t, []uint64{1, 2}, restoreSvc.restoredShards)
+	})
+
+	t.Run("restores nothing if a shard file does not match its manifest", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+		bk.shardEntries[2].Checksum = strings.Repeat("0", 64)
+		path := archiveBackup(t, bk, "backup.tar.gz")
+
+		restoreSvc := &fakeRestoreService{}
+		b := newBuilder(path, restoreSvc)
+		cleanup, err := b.loadBackup()
+		require.NoError(t, err)
+		defer cleanup()
+
+		err = b.restoreFull(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum")
+		assert.Empty(t, restoreSvc.restoredShards)
+	})
+
+	t.Run("dry run checks the shard files of an archive", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+		bk.shardEntries[2].Size = 1000
+		path := archiveBackup(t, bk, "backup.tar.gz")
+
+		out := new(bytes.Buffer)
+		b := newBuilder(path, nil)
+		b.w = out
+		b.dryRun = true
+		cleanup, err := b.loadBackup()
+		require.NoError(t, err)
+		defer cleanup()
+
+		err = b.restoreFull(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 shard files")
+		assert.Contains(t, out.String(), bk.shardEntries[2].FileName)
+	})
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"github.com/influxdata/influxdb/v2"
+)
+
+// Modes of a restore, as written by --json.
+const (
+	restoreModeFull    = "full"
+	restoreModePartial = "partial"
+)
+
+// restoreResult is what a restore did, written as a JSON document once it
+// ends with --json, instead of being logged. A failed restore is written with
+// its error and what it did before failing.
+type restoreResult struct {
+	// RestoreID is the ID sent with the requests of the restore, which the
+	// server logs them with.
+	RestoreID string `json:"restoreID"`
+	Mode      string `json:"mode"`
+	// Orgs and Buckets are the organizations and buckets a partial restore
+	// restored to. A full restore replaces the KV store, keeping their IDs.
+	Orgs               []restoredOrgResult         `json:"orgs"`
+	Buckets            []restoredBucketResult      `json:"buckets"`
+	Shards             []restoredShardResult       `json:"shards"`
+	SkippedShards      []skippedShardResult        `json:"skippedShards"`
+	PlaceholderSecrets []restoredPlaceholderResult `json:"placeholderSecrets"`
+	PrunedBuckets      []prunedBucketResult        `json:"prunedBuckets"`
+	Error              string                      `json:"error,omitempty"`
+}
+
+// restoredOrgResult is an organization of the backup, and the organization
+// of the server it was restored to, which the restore created or found.
+type restoredOrgResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	NewID   string `json:"newID"`
+	NewName string `json:"newName"`
+	Created bool   `json:"created"`
+}
+
+// restoredBucketResult is a bucket of the backup, and the bucket it was
+// restored to, created by the restore or by a previous run with --resume, or
+// replaced with --overwrite-bucket.
+type restoredBucketResult struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	NewID    string `json:"newID"`
+	NewName  string `json:"newName"`
+	NewOrgID string `json:"newOrgID"`
+	NewOrg   string `json:"newOrg"`
+	Resumed  bool   `json:"resumed,omitempty"`
+	Replaced bool   `json:"replaced,omitempty"`
+}
+
+// restoredShardResult is a shard of the backup, and the shard it was
+// restored to, by the restore or by a previous run with --resume.
+type restoredShardResult struct {
+	ShardID    uint64 `json:"shardID"`
+	NewShardID uint64 `json:"newShardID"`
+	BucketID   string `json:"bucketID"`
+	FileName   string `json:"fileName"`
+	Bytes      int64  `json:"bytes"`
+	Resumed    bool   `json:"resumed,omitempty"`
+}
+
+// skippedShardResult is a shard of the backup that was not restored.
+type skippedShardResult struct {
+	ShardID  uint64 `json:"shardID"`
+	BucketID string `json:"bucketID"`
+	Bucket   string `json:"bucket"`
+	FileName string `json:"fileName"`
+	Reason   string `json:"reason"`
+}
+
+// restoredPlaceholderResult is a secret restored without a value.
+type restoredPlaceholderResult struct {
+	OrgID string `json:"orgID"`
+	Org   string `json:"org"`
+	Key   string `json:"key"`
+}
+
+// prunedBucketResult is a bucket deleted by --prune.
+type prunedBucketResult struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func newRestoreResult() *restoreResult {
+	return &restoreResult{
+		Orgs:               []restoredOrgResult{},
+		Buckets:            []restoredBucketResult{},
+		Shards:             []restoredShardResult{},
+		SkippedShards:      []skippedShardResult{},
+		PlaceholderSecrets: []restoredPlaceholderResult{},
+		PrunedBuckets:      []prunedBucketResult{},
+	}
+}
+
+// recordOrgResult records the restore of the organization org of the backup
+// to the organization newOrg.
+func (b *cmdRestoreBuilder) recordOrgResult(org, newOrg *influxdb.Organization, created bool) {
+	b.resultMu.Lock()
+	defer b.resultMu.Unlock()
+	b.result.Orgs = append(b.result.Orgs, restoredOrgResult{
+		ID:      org.ID.String(),
+		Name:    org.Name,
+		NewID:   newOrg.ID.String(),
+		NewName: newOrg.Name,
+		Created: created,
+	})
+}
+
+// recordBucketResult records the restore of the bucket bkt of the backup to
+// the bucket newBucket of the organization newOrgName.
+func (b *cmdRestoreBuilder) recordBucketResult(bkt, newBucket *influxdb.Bucket, newOrgName string, resumed, replaced bool) {
+	b.resultMu.Lock()
+	defer b.resultMu.Unlock()
+	b.result.Buckets = append(b.result.Buckets, restoredBucketResult{
+		ID:       bkt.ID.String(),
+		Name:     bkt.Name,
+		NewID:    newBucket.ID.String(),
+		NewName:  newBucket.Name,
+		NewOrgID: newBucket.OrgID.String(),
+		NewOrg:   newOrgName,
+		Resumed:  resumed,
+		Replaced: replaced,
+	})
+}
+
+// recordShardResult records the restore of file to the shard newShardID.
+func (b *cmdRestoreBuilder) recordShardResult(newShardID uint64, file *influxdb.ManifestEntry, resumed bool) {
+	b.resultMu.Lock()
+	defer b.resultMu.Unlock()
+	b.result.Shards = append(b.result.Shards, restoredShardResult{
+		ShardID:    file.ShardID,
+		NewShardID: newShardID,
+		BucketID:   file.BucketID,
+		FileName:   file.FileName,
+		Bytes:      file.Size,
+		Resumed:    resumed,
+	})
+}
+
+// writeRestoreResult writes the result of the restore, failed with err if
+// it is not nil, as a JSON document.
+func (b *cmdRestoreBuilder) writeRestoreResult(err error) error {
+	b.resultMu.Lock()
+	defer b.resultMu.Unlock()
+
+	for _, sh := range b.skippedShards {
+		b.result.SkippedShards = append(b.result.SkippedShards, skippedShardResult{
+			ShardID:  sh.entry.ShardID,
+			BucketID: sh.entry.BucketID,
+			Bucket:   sh.entry.BucketName,
+			FileName: sh.entry.FileName,
+			Reason:   sh.reason,
+		})
+	}
+	for _, file := range b.metadataOnlyShards {
+		b.result.SkippedShards = append(b.result.SkippedShards, skippedShardResult{
+			ShardID:  file.ShardID,
+			BucketID: file.BucketID,
+			Bucket:   file.BucketName,
+			FileName: file.FileName,
+			Reason:   "metadata only",
+		})
+	}
+	for _, p := range b.placeholders {
+		b.result.PlaceholderSecrets = append(b.result.PlaceholderSecrets, restoredPlaceholderResult{
+			OrgID: p.orgID.String(),
+			Org:   p.orgName,
+			Key:   p.key,
+		})
+	}
+	b.result.RestoreID = b.restoreID
+	if err != nil {
+		b.result.Error = err.Error()
+	}
+	return b.writeJSON(b.result)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCmdRestore_JSON(t *testing.T) {
+	ctx := context.Background()
+
+	newBuilder := func(t *testing.T, bk *testBackup, restoreSvc *fakeRestoreService) (*cmdRestoreBuilder, *bytes.Buffer) {
+		out := new(bytes.Buffer)
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{w: out})
+		b.json = true
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zap.NewNop()
+		b.result.Mode = restoreModePartial
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return nil, &influxdb.Error{Code: influxdb.ENotFound}
+			},
+			CreateOrganizationF: func(ctx context.Context, org *influxdb.Organization) error {
+				org.ID = influxdb.ID(9000)
+				return nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			bkt.ID = influxdb.ID(9001)
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = restoreSvc
+		return b, out
+	}
+
+	decode := func(t *testing.T, out *bytes.Buffer) restoreResult {
+		var result restoreResult
+		require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+		return result
+	}
+
+	t.Run("describes the restored and skipped shards", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "without-meta")
+		defer bk.cleanup()
+
+		b, out := newBuilder(t, bk, &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}})
+		b.skipMissingMeta = true
+		b.newOrgName = "restored"
+		b.org.name = "org"
+		b.bucketName, b.newBucketName = "cpu", "cpu-copy"
+		require.NoError(t, b.restorePartial(ctx))
+		require.NoError(t, b.writeRestoreResult(nil))
+
+		result := decode(t, out)
+		assert.Equal(t, restoreModePartial, result.Mode)
+		assert.Empty(t, result.Error)
+		assert.Equal(t, []restoredOrgResult{{
+			ID:      bk.org.ID.String(),
+			Name:    "org",
+			NewID:   influxdb.ID(9000).String(),
+			NewName: "restored",
+			Created: true,
+		}}, result.Orgs)
+		assert.Equal(t, []restoredBucketResult{{
+			ID:       bk.buckets[0].ID.String(),
+			Name:     "cpu",
+			NewID:    influxdb.ID(9001).String(),
+			NewName:  "cpu-copy",
+			NewOrgID: influxdb.ID(9000).String(),
+			NewOrg:   "restored",
+		}}, result.Buckets)
+		assert.Equal(t, []restoredShardResult{{
+			ShardID:    1,
+			NewShardID: 101,
+			BucketID:   bk.buckets[0].ID.String(),
+			FileName:   bk.shardEntries[1].FileName,
+			Bytes:      bk.shardEntries[1].Size,
+		}}, result.Shards)
+		assert.Empty(t, result.SkippedShards, "only the shards of the restored bucket are considered")
+	})
+
+	t.Run("lists the shards skipped for missing meta", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "without-meta")
+		defer bk.cleanup()
+
+		b, out := newBuilder(t, bk, &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}})
+		b.skipMissingMeta = true
+		require.NoError(t, b.restorePartial(ctx))
+		require.NoError(t, b.writeRestoreResult(nil))
+
+		result := decode(t, out)
+		require.Len(t, result.Buckets, 1)
+		assert.Len(t, result.Shards, 1)
+		assert.Equal(t, []skippedShardResult{{
+			ShardID:  2,
+			BucketID: bk.buckets[1].ID.String(),
+			Bucket:   "without-meta",
+			FileName: bk.shardEntries[2].FileName,
+			Reason:   "bucket database meta not found",
+		}}, result.SkippedShards)
+	})
+
+	t.Run("describes what a failed restore restored", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+
+		b, out := newBuilder(t, bk, &fakeRestoreService{
+			shardIDMap: map[uint64]uint64{1: 101},
+			shardErrs:  map[uint64]error{101: errors.New("connection reset")},
+		})
+		b.restoreID = "06ae8ef6f6c81000"
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		require.NoError(t, b.writeRestoreResult(err))
+
+		result := decode(t, out)
+		assert.Contains(t, result.Error, "connection reset")
+		assert.Equal(t, "06ae8ef6f6c81000", result.RestoreID)
+		assert.Len(t, result.Orgs, 1)
+		assert.Len(t, result.Buckets, 1)
+		assert.Empty(t, result.Shards)
+	})
+
+	t.Run("has the restore ID of a restore failing early", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{w: out})
+		b.json, b.dryRun = true, true
+		err := b.restore(ctx)
+		require.Error(t, err)
+		require.NoError(t, b.writeRestoreResult(err))
+
+		result := decode(t, out)
+		assert.NotEmpty(t, result.RestoreID)
+		assert.Equal(t, b.restoreID, result.RestoreID)
+		assert.Equal(t, "cannot use --json with --dry-run", result.Error)
+	})
+
+	t.Run("cannot be used with a dry run", func(t *testing.T) {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.json, b.dryRun = true, true
+		assert.EqualError(t, b.restore(ctx), "cannot use --json with --dry-run")
+
+		b = newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.json, b.prune = true, true
+		assert.EqualError(t, b.restore(ctx), "cannot use --json with --prune without --force")
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// flakyHandler answers its first failures requests with status, reading
+// their bodies, and passes the others to its handler.
+type flakyHandler struct {
+	h        nethttp.Handler
+	status   int
+	failures int
+
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (f *flakyHandler) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	f.mu.Lock()
+	n := len(f.bodies)
+	if n >= f.failures {
+		f.bodies = append(f.bodies, "")
+		f.mu.Unlock()
+		f.h.ServeHTTP(w, r)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	f.bodies = append(f.bodies, string(body))
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(f.status)
+	io.WriteString(w, nethttp.StatusText(f.status))
+}
+
+func (f *flakyHandler) requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.bodies)
+}
+
+func newRetryTestRestoreBuilder(t *testing.T, h nethttp.Handler, retries int) (*cmdRestoreBuilder, *observer.ObservedLogs) {
+	t.Helper()
+	b, logs := newProgressTestRestoreBuilder(t, h)
+	b.retries = retries
+	policy := b.retryPolicy()
+	policy.MinBackoff = 10 * time.Millisecond
+	b.restoreService.(*http.RestoreService).Retry = policy
+	return b, logs
+}
+
+func TestCmdRestore_Retries(t *testing.T) {
+	ctx := context.Background()
+	const shard = "shard data"
+
+	// writeShardFile writes a backup directory holding a shard file.
+	writeShardFile := func(t *testing.T) string {
+		dir, err := ioutil.TempDir("", "influx-restore-retry-")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shard.tar.gz"), []byte(gzipped(t, shard)), 0666))
+		return dir
+	}
+
+	t.Run("retries a shard file failing with a server error", func(t *testing.T) {
+		svc := &readingRestoreService{fakeRestoreService: &fakeRestoreService{}, read: make(map[uint64][]byte)}
+		f := &flakyHandler{h: newRestoreTestHandler(svc), status: nethttp.StatusInternalServerError, failures: 2}
+		b, logs := newRetryTestRestoreBuilder(t, f, 3)
+		b.path = writeShardFile(t)
+		b.skipVerifyFiles = true
+
+		require.NoError(t, b.restoreShard(ctx, 5, &influxdb.ManifestEntry{ShardID: 1, FileName: "shard.tar.gz"}))
+		assert.Equal(t, 3, f.requests())
+		// The file is sent whole with every attempt.
+		assert.Equal(t, []string{shard, shard, ""}, f.bodies)
+		assert.Equal(t, shard, string(svc.read[5]))
+
+		retries := logs.FilterMessage("Upload failed, retrying").All()
+		require.Len(t, retries, 2)
+		assert.Equal(t, int64(1), retries[0].ContextMap()["attempt"])
+		assert.Equal(t, int64(2), retries[1].ContextMap()["attempt"])
+		assert.Equal(t, "/api/v2/restore/shards/5", retries[0].ContextMap()["path"])
+	})
+
+	t.Run("retries the KV store", func(t *testing.T) {
+		f := &flakyHandler{h: newRestoreTestHandler(&fakeRestoreService{}), status: nethttp.StatusServiceUnavailable, failures: 1}
+		b, _ := newRetryTestRestoreBuilder(t, f, 1)
+		b.path = writeShardFile(t)
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: "kv"}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(b.path, "kv"), []byte("bolt"), 0666))
+
+		require.NoError(t, b.restoreKVStore(ctx))
+		assert.Equal(t, []string{"bolt", ""}, f.bodies)
+	})
+
+	t.Run("gives up after the retries", func(t *testing.T) {
+		f := &flakyHandler{status: nethttp.StatusInternalServerError, failures: 10}
+		b, logs := newRetryTestRestoreBuilder(t, f, 2)
+		b.path = writeShardFile(t)
+		b.skipVerifyFiles = true
+
+		err := b.restoreShard(ctx, 5, &influxdb.ManifestEntry{ShardID: 1, FileName: "shard.tar.gz"})
+		require.Error(t, err)
+		assert.Equal(t, influxdb.EInternal, influxdb.ErrorCode(err))
+		assert.Equal(t, 3, f.requests())
+		assert.Len(t, logs.FilterMessage("Upload failed, retrying").All(), 2)
+	})
+
+	t.Run("stops retrying after the max duration", func(t *testing.T) {
+		f := &flakyHandler{status: nethttp.StatusInternalServerError, failures: 10}
+		b, _ := newRetryTestRestoreBuilder(t, f, 5)
+		svc := b.restoreService.(*http.RestoreService)
+		svc.Retry.MaxDuration = 15 * time.Millisecond
+
+		require.Error(t, b.restoreService.RestoreKVStore(ctx, strings.NewReader("bolt")))
+		// The second retry would wait 20ms, past the max duration.
+		assert.Equal(t, 2, f.requests())
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		f := &flakyHandler{status: nethttp.StatusRequestEntityTooLarge, failures: 10}
+		b, logs := newRetryTestRestoreBuilder(t, f, 3)
+		b.path = writeShardFile(t)
+		b.skipVerifyFiles = true
+
+		require.Error(t, b.restoreShard(ctx, 5, &influxdb.ManifestEntry{ShardID: 1, FileName: "shard.tar.gz"}))
+		assert.Equal(t, 1, f.requests())
+		assert.Empty(t, logs.FilterMessage("Upload failed, retrying").All())
+	})
+
+	t.Run("does not retry shards read from an archive", func(t *testing.T) {
+		f := &flakyHandler{status: nethttp.StatusInternalServerError, failures: 10}
+		b, _ := newRetryTestRestoreBuilder(t, f, 3)
+
+		require.Error(t, b.streamShard(ctx, 5, strings.NewReader(gzipped(t, shard))))
+		assert.Equal(t, 1, f.requests())
+	})
+
+	t.Run("retries must not be negative", func(t *testing.T) {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.retries = -1
+		assert.EqualError(t, b.restore(ctx), "--retries must not be negative")
+	})
+}
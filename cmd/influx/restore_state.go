@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/influxdata/influxdb/v2"
+	"go.uber.org/zap"
+)
+
+// restoreStateFileName is the name of the state file of a restore of a
+// backup directory. The state file of a restore of an archive is next to
+// it, named after it.
+const restoreStateFileName = ".restore-state.json"
+
+// restoreState is the progress of a restore with --resume, written to its
+// state file as it goes, so that running it again skips what was restored.
+type restoreState struct {
+	// Host is the server restored to.
+	Host string `json:"host"`
+	// Full is set for a full restore, and KVRestored once it has replaced
+	// the KV store of the server.
+	Full       bool `json:"full"`
+	KVRestored bool `json:"kvRestored,omitempty"`
+	// Buckets holds the buckets restored by a partial restore, by the IDs of
+	// the buckets of the backup they were restored from.
+	Buckets map[string]*restoredBucketState `json:"buckets,omitempty"`
+	// Shards holds the restored shards, by the IDs of the shards of the
+	// backup they were restored from.
+	Shards map[uint64]restoredShardState `json:"shards,omitempty"`
+}
+
+// restoredBucketState is a bucket restored by a partial restore.
+type restoredBucketState struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// ShardIDs maps the IDs of the shards of the backup to the IDs of the
+	// shards created for them.
+	ShardIDs map[uint64]uint64 `json:"shardIDs"`
+}
+
+// restoredShardState is a shard restored from a file of the backup.
+type restoredShardState struct {
+	NewShardID uint64 `json:"newShardID"`
+	FileName   string `json:"fileName"`
+}
+
+// restoreStatePath returns the path of the state file of a restore of the
+// backup at path, a backup directory or an archive of one.
+func restoreStatePath(path string) (string, error) {
+	if path == stdinPath {
+		return "", fmt.Errorf("cannot resume a restore of a backup read from stdin")
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		return filepath.Join(path, restoreStateFileName), nil
+	}
+	return path + restoreStateFileName, nil
+}
+
+// loadRestoreState reads the state file of a restore with --resume, if any,
+// and checks it records a restore of the same kind to the same server.
+func (b *cmdRestoreBuilder) loadRestoreState() error {
+	b.state = &restoreState{Host: b.host, Full: b.full}
+
+	buf, err := ioutil.ReadFile(b.statePath)
+	if os.IsNotExist(err) {
+		b.logger.Info("No restore to resume, starting a new one", zap.String("state_file", b.statePath))
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot read restore state file: %w", err)
+	}
+
+	var state restoreState
+	if err := json.Unmarshal(buf, &state); err != nil {
+		return fmt.Errorf("cannot read restore state file %s: %w", b.statePath, err)
+	}
+	switch {
+	case state.Host != b.host:
+		return fmt.Errorf("cannot resume the restore of state file %s to %s on %s; remove it to restore from scratch", b.statePath, state.Host, b.host)
+	case state.Full && !b.full:
+		return fmt.Errorf("cannot resume the full restore of state file %s without --full; remove it to restore from scratch", b.statePath)
+	case !state.Full && b.full:
+		return fmt.Errorf("cannot resume the partial restore of state file %s with --full; remove it to restore from scratch", b.statePath)
+	}
+	b.state.KVRestored = state.KVRestored
+	b.state.Buckets = state.Buckets
+	b.state.Shards = state.Shards
+	b.logger.Info("Resuming restore", zap.String("state_file", b.statePath), zap.Int("buckets_restored", len(state.Buckets)), zap.Int("shards_restored", len(state.Shards)))
+	return nil
+}
+
+// invalidateRestoreState forgets the state of previous restores, once a full
+// restore has replaced the KV store of the server and, with it, the buckets
+// and shards they restored.
+func (b *cmdRestoreBuilder) invalidateRestoreState() error {
+	if b.state == nil {
+		if b.statePath == "" {
+			return nil
+		}
+		if err := os.Remove(b.statePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove restore state file: %w", err)
+		}
+		return nil
+	}
+
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	b.state = &restoreState{Host: b.host, Full: true, KVRestored: true}
+	return b.saveRestoreState()
+}
+
+// resumedBucket returns the bucket restored from the bucket bkt of the backup
+// by a previous run, or nil if none was. The bucket must be restored under
+// the same name, newName.
+func (b *cmdRestoreBuilder) resumedBucket(bkt *influxdb.Bucket, newName string) (*restoredBucketState, error) {
+	if b.state == nil {
+		return nil, nil
+	}
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	restored, ok := b.state.Buckets[bkt.ID.String()]
+	if !ok {
+		return nil, nil
+	}
+	if restored.Name != newName {
+		return nil, fmt.Errorf("cannot resume the restore of state file %s: bucket %q was restored as %q, not %q; remove it to restore from scratch", b.statePath, bkt.Name, restored.Name, newName)
+	}
+	return restored, nil
+}
+
+// recordBucket records the restore of the bucket bkt of the backup to the
+// bucket newBucket, with the shards created for its shards.
+func (b *cmdRestoreBuilder) recordBucket(bkt, newBucket *influxdb.Bucket, shardIDMap map[uint64]uint64) error {
+	if b.state == nil {
+		return nil
+	}
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	if b.state.Buckets == nil {
+		b.state.Buckets = make(map[string]*restoredBucketState)
+	}
+	b.state.Buckets[bkt.ID.String()] = &restoredBucketState{
+		ID:       newBucket.ID.String(),
+		Name:     newBucket.Name,
+		ShardIDs: shardIDMap,
+	}
+	return b.saveRestoreState()
+}
+
+// shardRestored reports whether a previous run restored file to the shard
+// newShardID.
+func (b *cmdRestoreBuilder) shardRestored(newShardID uint64, file *influxdb.ManifestEntry) bool {
+	if b.state == nil {
+		return false
+	}
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	restored, ok := b.state.Shards[file.ShardID]
+	return ok && restored.NewShardID == newShardID && restored.FileName == file.FileName
+}
+
+// recordShard records the restore of file to the shard newShardID.
+func (b *cmdRestoreBuilder) recordShard(newShardID uint64, file *influxdb.ManifestEntry) error {
+	if b.state == nil {
+		return nil
+	}
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	if b.state.Shards == nil {
+		b.state.Shards = make(map[uint64]restoredShardState)
+	}
+	b.state.Shards[file.ShardID] = restoredShardState{NewShardID: newShardID, FileName: file.FileName}
+	return b.saveRestoreState()
+}
+
+// saveRestoreState writes the state file, replacing it whole so that it is
+// never left half written. b.stateMu must be held.
+func (b *cmdRestoreBuilder) saveRestoreState() error {
+	buf, err := json.MarshalIndent(b.state, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := b.statePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0600); err != nil {
+		return fmt.Errorf("cannot write restore state file: %w", err)
+	}
+	if err := os.Rename(tmp, b.statePath); err != nil {
+		return fmt.Errorf("cannot write restore state file: %w", err)
+	}
+	return nil
+}
+
+// removeRestoreState removes the state file once the restore completes.
+func (b *cmdRestoreBuilder) removeRestoreState() error {
+	if b.state == nil {
+		return nil
+	}
+	if err := os.Remove(b.statePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove restore state file: %w", err)
+	}
+	b.logger.Info("Removed restore state file", zap.String("state_file", b.statePath))
+	return nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCmdRestore_Resume(t *testing.T) {
+	ctx := context.Background()
+	const host = "http://localhost:8086"
+
+	newBuilder := func(t *testing.T, bk *testBackup, restoreSvc *fakeRestoreService) (*cmdRestoreBuilder, *[]string) {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.host = host
+		b.resume = true
+		b.statePath = filepath.Join(bk.dir, restoreStateFileName)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(9000), Name: *filter.Name}, nil
+			},
+		}
+
+		var created []string
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			created = append(created, bkt.Name)
+			bkt.ID = influxdb.ID(9000 + len(created))
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = restoreSvc
+		return b, &created
+	}
+
+	readState := func(t *testing.T, bk *testBackup) *restoreState {
+		buf, err := ioutil.ReadFile(filepath.Join(bk.dir, restoreStateFileName))
+		require.NoError(t, err)
+		var state restoreState
+		require.NoError(t, json.Unmarshal(buf, &state))
+		return &state
+	}
+
+	t.Run("skips the buckets and shards restored by a previous run", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+
+		// The first run fails restoring the shard of one of the buckets.
+		first := &fakeRestoreService{
+			shardIDMap: map[uint64]uint64{1: 101, 2: 102},
+			shardErrs:  map[uint64]error{102: errors.New("connection reset")},
+		}
+		b, created := newBuilder(t, bk, first)
+		require.NoError(t, b.loadRestoreState())
+		require.Error(t, b.restorePartial(ctx))
+		firstCreated := *created
+
+		state := readState(t, bk)
+		assert.Equal(t, host, state.Host)
+		assert.False(t, state.Full)
+		require.Len(t, state.Buckets, len(firstCreated))
+
+		second := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101, 2: 102}}
+		b, created = newBuilder(t, bk, second)
+		require.NoError(t, b.loadRestoreState())
+		require.NoError(t, b.restorePartial(ctx))
+		require.NoError(t, b.removeRestoreState())
+
+		// Each bucket and shard is restored once across both runs.
+		assert.ElementsMatch(t, []string{"cpu", "mem"}, append(firstCreated, *created...))
+		restored := append(first.restoredShards, second.restoredShards...)
+		sort.Slice(restored, func(i, j int) bool { return restored[i] < restored[j] })
+		assert.Equal(t, []uint64{101, 102}, restored)
+		assert.Contains(t, second.restoredShards, uint64(102))
+
+		_, err := os.Stat(filepath.Join(bk.dir, restoreStateFileName))
+		assert.True(t, os.IsNotExist(err), "the state file is removed once the restore completes")
+	})
+
+	t.Run("refuses the state file of a restore to another server", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		state := `{"host": "http://other:8086", "full": false}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(bk.dir, restoreStateFileName), []byte(state), 0600))
+
+		b, _ := newBuilder(t, bk, &fakeRestoreService{})
+		err := b.loadRestoreState()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "http://other:8086")
+		assert.Contains(t, err.Error(), "remove it to restore from scratch")
+	})
+
+	t.Run("refuses the state file of a restore of another kind", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		state := `{"host": "` + host + `", "full": true, "kvRestored": true}`
+		require.NoError(t, ioutil.WriteFile(filepath.Join(bk.dir, restoreStateFileName), []byte(state), 0600))
+
+		b, _ := newBuilder(t, bk, &fakeRestoreService{})
+		err := b.loadRestoreState()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "without --full")
+	})
+
+	t.Run("refuses to resume a bucket restored under another name", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		state := restoreState{
+			Host: host,
+			Buckets: map[string]*restoredBucketState{
+				bk.buckets[0].ID.String(): {ID: influxdb.ID(9001).String(), Name: "cpu", ShardIDs: map[uint64]uint64{1: 101}},
+			},
+		}
+		buf, err := json.Marshal(state)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(bk.dir, restoreStateFileName), buf, 0600))
+
+		b, created := newBuilder(t, bk, &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}})
+		b.bucketName, b.newBucketName = "cpu", "cpu-copy"
+		require.NoError(t, b.loadRestoreState())
+		err = b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `bucket "cpu" was restored as "cpu", not "cpu-copy"`)
+		assert.Empty(t, *created)
+	})
+
+	t.Run("resumes a full restore without restoring the KV store again", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+		state := restoreState{
+			Host:       host,
+			Full:       true,
+			KVRestored: true,
+			Shards:     map[uint64]restoredShardState{1: {NewShardID: 1, FileName: bk.shardEntries[1].FileName}},
+		}
+		buf, err := json.Marshal(state)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(bk.dir, restoreStateFileName), buf, 0600))
+
+		restoreSvc := &fakeRestoreService{}
+		b, _ := newBuilder(t, bk, restoreSvc)
+		b.full = true
+		// The organizations on the server are not checked again.
+		b.orgService = nil
+		require.NoError(t, b.loadRestoreState())
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Equal(t, []uint64{2}, restoreSvc.restoredShards)
+		assert.Len(t, readState(t, bk).Shards, 2)
+	})
+
+	t.Run("full restores invalidate previous state files", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+		statePath := filepath.Join(bk.dir, restoreStateFileName)
+		state := `{"host": "` + host + `", "shards": {"1": {"newShardID": 101, "fileName": "` + bk.shardEntries[1].FileName + `"}}}`
+		require.NoError(t, ioutil.WriteFile(statePath, []byte(state), 0600))
+
+		restoreSvc := &fakeRestoreService{}
+		b, _ := newBuilder(t, bk, restoreSvc)
+		b.full, b.resume, b.includeInternalOrgs = true, false, true
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Equal(t, []uint64{1, 2}, restoreSvc.restoredShards)
+
+		_, err := os.Stat(statePath)
+		assert.True(t, os.IsNotExist(err), "the state file is removed")
+	})
+
+	t.Run("full restores with --resume start a new state file", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+
+		restoreSvc := &fakeRestoreService{shardErrs: map[uint64]error{2: errors.New("connection reset")}}
+		b, _ := newBuilder(t, bk, restoreSvc)
+		b.full, b.includeInternalOrgs = true, true
+		require.NoError(t, b.loadRestoreState())
+		require.Error(t, b.restoreFull(ctx))
+
+		state := readState(t, bk)
+		assert.True(t, state.Full)
+		assert.True(t, state.KVRestored)
+		assert.Equal(t, map[uint64]restoredShardState{1: {NewShardID: 1, FileName: bk.shardEntries[1].FileName}}, state.Shards)
+	})
+}
+
+func TestRestoreStatePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influx-restore-state-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, "backup.tar.gz")
+	require.NoError(t, ioutil.WriteFile(archive, nil, 0600))
+
+	path, err := restoreStatePath(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".restore-state.json"), path)
+
+	path, err = restoreStatePath(archive)
+	require.NoError(t, err)
+	assert.Equal(t, archive+".restore-state.json", path)
+
+	_, err = restoreStatePath(stdinPath)
+	require.Error(t, err)
+}
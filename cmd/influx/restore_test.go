@@ -0,0 +1,1209 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCmdRestore_MissingBucketMeta(t *testing.T) {
+	ctx := context.Background()
+
+	// Build a backup containing two buckets where only the first has database meta.
+	bk := newTestBackup(t, "with-meta", "without-meta")
+	defer bk.cleanup()
+	dir, kvFileName, shardEntries, withMeta := bk.dir, bk.kvFileName, bk.shardEntries, bk.buckets[0]
+
+	newBuilder := func(restoreSvc *fakeRestoreService) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: kvFileName}
+		b.shardEntries = shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(9000), Name: *filter.Name}, nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			bkt.ID = influxdb.ID(9001)
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = restoreSvc
+		return b
+	}
+
+	t.Run("fails by default", func(t *testing.T) {
+		b := newBuilder(&fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}})
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bucket database not found")
+	})
+
+	t.Run("skips shards with missing meta", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b := newBuilder(restoreSvc)
+		b.skipMissingMeta = true
+		require.NoError(t, b.restorePartial(ctx))
+
+		assert.Equal(t, []uint64{101}, restoreSvc.restoredShards)
+		require.Len(t, b.skippedShards, 1)
+		assert.Equal(t, uint64(2), b.skippedShards[0].entry.ShardID)
+		assert.Equal(t, "bucket database meta not found", b.skippedShards[0].reason)
+	})
+
+	t.Run("strict fails on missing shard meta", func(t *testing.T) {
+		b := newBuilder(&fakeRestoreService{shardIDMap: map[uint64]uint64{}})
+		b.strict = true
+		b.bucketName = withMeta.Name
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "shard meta not found")
+	})
+}
+
+func TestCmdRestore_Prune(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "cpu", "mem")
+	defer bk.cleanup()
+
+	const targetOrgID = influxdb.ID(9000)
+	serverBuckets := []*influxdb.Bucket{
+		{ID: 1, OrgID: targetOrgID, Name: "cpu"},
+		{ID: 2, OrgID: targetOrgID, Name: "stale"},
+		{ID: 3, OrgID: targetOrgID, Name: "_monitoring", Type: influxdb.BucketTypeSystem},
+		{ID: 4, OrgID: targetOrgID, Name: "audit", DeleteProtected: true},
+	}
+
+	type calls struct {
+		created []string
+		deleted []influxdb.ID
+	}
+	newBuilder := func(in string, c *calls) (*cmdRestoreBuilder, *bytes.Buffer) {
+		out := new(bytes.Buffer)
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{in: strings.NewReader(in), w: out})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.org.name = bk.org.Name
+		b.prune = true
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: targetOrgID, Name: *filter.Name}, nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.FindBucketsFn = func(ctx context.Context, filter influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+			return serverBuckets, len(serverBuckets), nil
+		}
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			c.created = append(c.created, bkt.Name)
+			return nil
+		}
+		bucketSvc.DeleteBucketFn = func(ctx context.Context, id influxdb.ID) error {
+			c.deleted = append(c.deleted, id)
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101, 2: 102}}
+		return b, out
+	}
+
+	t.Run("prunes buckets missing from the backup once confirmed", func(t *testing.T) {
+		var c calls
+		b, out := newBuilder("y\n", &c)
+		require.NoError(t, b.restorePartial(ctx))
+
+		assert.Contains(t, out.String(), "stale")
+		assert.NotContains(t, out.String(), "audit")
+		assert.Equal(t, []string{"cpu", "mem"}, c.created)
+		assert.Equal(t, []influxdb.ID{2}, c.deleted)
+	})
+
+	t.Run("does not prune if shards are skipped", func(t *testing.T) {
+		var c calls
+		b, _ := newBuilder("y\n", &c)
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b.restoreService = restoreSvc
+		core, logs := observer.New(zapcore.InfoLevel)
+		b.logger = zap.New(core)
+		err := b.restorePartial(ctx)
+		require.EqualError(t, err, `prune not performed because 1 shards were skipped; it would have deleted the buckets "stale"`)
+
+		require.Len(t, b.skippedShards, 1)
+		assert.Equal(t, []uint64{101}, restoreSvc.restoredShards)
+		assert.Empty(t, c.deleted)
+		notPruned := logs.FilterMessage("Bucket not pruned because shards were skipped").All()
+		require.Len(t, notPruned, 1)
+		assert.Equal(t, "stale", notPruned[0].ContextMap()["name"])
+	})
+
+	t.Run("aborts before restoring when not confirmed", func(t *testing.T) {
+		var c calls
+		b, _ := newBuilder("n\n", &c)
+		require.Error(t, b.restorePartial(ctx))
+		assert.Empty(t, c.created)
+		assert.Empty(t, c.deleted)
+	})
+
+	t.Run("dry run does not contact the server", func(t *testing.T) {
+		b, out := newBuilder("", nil)
+		b.dryRun = true
+		b.orgService, b.bucketService, b.restoreService = nil, nil, nil
+		require.NoError(t, b.restorePartial(ctx))
+		assert.NotContains(t, out.String(), "stale")
+	})
+
+	t.Run("invalid flag combinations", func(t *testing.T) {
+		tests := []struct {
+			name string
+			fn   func(b *cmdRestoreBuilder)
+		}{
+			{name: "full", fn: func(b *cmdRestoreBuilder) { b.full = true }},
+			{name: "no org", fn: func(b *cmdRestoreBuilder) { b.org.name = "" }},
+			{name: "single bucket", fn: func(b *cmdRestoreBuilder) { b.bucketName = "cpu" }},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				b, _ := newBuilder("", &calls{})
+				tt.fn(b)
+				require.Error(t, b.validatePrune())
+			})
+		}
+	})
+}
+
+func TestCmdRestore_FullRejectsMeasurementBackup(t *testing.T) {
+	bk := newTestBackup(t, "cpu")
+	defer bk.cleanup()
+
+	for _, file := range bk.shardEntries {
+		file.Measurements = []string{"cpu"}
+	}
+
+	restoreSvc := &fakeRestoreService{}
+	b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+	b.path = bk.dir
+	b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+	b.shardEntries = bk.shardEntries
+	b.logger = zaptest.NewLogger(t)
+	b.restoreService = restoreSvc
+
+	err := b.restoreFull(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only some measurements")
+	assert.Empty(t, restoreSvc.restoredShards)
+}
+
+func TestCmdRestore_InternalOrgs(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "cpu")
+	defer bk.cleanup()
+	bk.addOrg(t, "_ops", "health")
+
+	newBuilder := func(serverOrgs []*influxdb.Organization, created *[]string) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(9000), Name: *filter.Name}, nil
+			},
+			FindOrganizationsF: func(ctx context.Context, filter influxdb.OrganizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+				return serverOrgs, len(serverOrgs), nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			*created = append(*created, bkt.Name)
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101, 2: 102}}
+		return b
+	}
+
+	t.Run("skips internal orgs by default", func(t *testing.T) {
+		var created []string
+		b := newBuilder(nil, &created)
+		require.NoError(t, b.restorePartial(ctx))
+		assert.Equal(t, []string{"cpu"}, created)
+	})
+
+	t.Run("restores internal orgs when included", func(t *testing.T) {
+		var created []string
+		b := newBuilder(nil, &created)
+		b.includeInternalOrgs = true
+		require.NoError(t, b.restorePartial(ctx))
+		assert.ElementsMatch(t, []string{"cpu", "health"}, created)
+	})
+
+	t.Run("selecting an internal org requires including them", func(t *testing.T) {
+		var created []string
+		b := newBuilder(nil, &created)
+		b.org.name = "_ops"
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--include-internal-orgs")
+		assert.Empty(t, created)
+	})
+
+	t.Run("full restore refuses to overwrite internal orgs", func(t *testing.T) {
+		var created []string
+		serverOrgs := []*influxdb.Organization{{ID: 1, Name: "org"}, {ID: 2, Name: "_ops"}}
+		b := newBuilder(serverOrgs, &created)
+		err := b.restoreFull(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "_ops")
+		assert.Empty(t, b.restoreService.(*fakeRestoreService).restoredShards)
+
+		b = newBuilder(serverOrgs, &created)
+		b.includeInternalOrgs = true
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Len(t, b.restoreService.(*fakeRestoreService).restoredShards, 2)
+	})
+
+	t.Run("cannot rename into an internal org", func(t *testing.T) {
+		var created []string
+		b := newBuilder(nil, &created)
+		b.org.name = "org"
+		b.newOrgName = "_ops"
+		err := b.restore(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--include-internal-orgs")
+	})
+
+	t.Run("recognizes internal org names", func(t *testing.T) {
+		assert.True(t, isInternalOrg(&influxdb.Organization{Name: "_ops"}))
+		assert.False(t, isInternalOrg(&influxdb.Organization{Name: "ops"}))
+		assert.False(t, isInternalOrg(&influxdb.Organization{Name: "org_"}))
+	})
+}
+
+func TestCmdRestore_IDCollision(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "cpu")
+	defer bk.cleanup()
+
+	const newID = influxdb.ID(9001)
+	newBuilder := func(restoreSvc *fakeRestoreService, orphanSvc *fakeOrphanService) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(9000), Name: *filter.Name}, nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			bkt.ID = newID
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = restoreSvc
+		b.orphanService = orphanSvc
+		return b
+	}
+
+	t.Run("refuses to restore into orphaned storage", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		orphanSvc := &fakeOrphanService{report: &influxdb.OrphanReport{Orphans: []influxdb.OrphanedDirectory{
+			{Path: "/data/" + newID.String(), Kind: influxdb.OrphanKindData, BucketID: newID.String()},
+		}}}
+		b := newBuilder(restoreSvc, orphanSvc)
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already used by orphaned storage directory /data/"+newID.String())
+		assert.False(t, restoreSvc.restoredBucket)
+		assert.Empty(t, restoreSvc.restoredShards)
+	})
+
+	t.Run("refuses to restore into an existing bucket", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b := newBuilder(restoreSvc, &fakeOrphanService{report: &influxdb.OrphanReport{}})
+		b.bucketService.(*mock.BucketService).FindBucketsFn = func(ctx context.Context, filter influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+			return []*influxdb.Bucket{{ID: newID, Name: "existing"}}, 1, nil
+		}
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `already used by bucket "existing"`)
+		assert.False(t, restoreSvc.restoredBucket)
+	})
+
+	t.Run("restores without operator token", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		orphanSvc := &fakeOrphanService{err: &influxdb.Error{Code: influxdb.EForbidden, Msg: "forbidden"}}
+		b := newBuilder(restoreSvc, orphanSvc)
+		require.NoError(t, b.restorePartial(ctx))
+		assert.Equal(t, []uint64{101}, restoreSvc.restoredShards)
+	})
+}
+
+func TestCmdRestore_OverwriteBucket(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "cpu")
+	defer bk.cleanup()
+
+	const existingID = influxdb.ID(7000)
+	newBuilder := func(restoreSvc *fakeRestoreService, bucketType influxdb.BucketType) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(9000), Name: *filter.Name}, nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			return &influxdb.Error{Code: influxdb.EConflict, Msg: fmt.Sprintf("bucket with name %s already exists", bkt.Name)}
+		}
+		bucketSvc.FindBucketFn = func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: existingID, OrgID: *filter.OrganizationID, Name: *filter.Name, Type: bucketType}, nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = restoreSvc
+		b.orphanService = &fakeOrphanService{report: &influxdb.OrphanReport{}}
+		return b
+	}
+
+	t.Run("refuses to restore into an existing bucket without the flag", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b := newBuilder(restoreSvc, influxdb.BucketTypeUser)
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot create bucket")
+		assert.Contains(t, err.Error(), "--overwrite-bucket")
+		assert.False(t, restoreSvc.restoredBucket)
+		assert.Empty(t, restoreSvc.replacedBuckets)
+	})
+
+	t.Run("replaces the shards of the existing bucket", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b := newBuilder(restoreSvc, influxdb.BucketTypeUser)
+		b.overwriteBucket = true
+		require.NoError(t, b.restorePartial(ctx))
+		assert.False(t, restoreSvc.restoredBucket)
+		assert.Equal(t, []influxdb.ID{existingID}, restoreSvc.replacedBuckets)
+		assert.Equal(t, []uint64{101}, restoreSvc.restoredShards)
+
+		require.Len(t, b.result.Buckets, 1)
+		assert.Equal(t, existingID.String(), b.result.Buckets[0].NewID)
+		assert.True(t, b.result.Buckets[0].Replaced)
+	})
+
+	t.Run("replaces the bucket through the API", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		srv := httptest.NewServer(newRestoreTestHandler(restoreSvc))
+		defer srv.Close()
+
+		b := newBuilder(restoreSvc, influxdb.BucketTypeUser)
+		b.restoreService = &http.RestoreService{Addr: srv.URL}
+		b.overwriteBucket = true
+		require.NoError(t, b.restorePartial(ctx))
+		assert.False(t, restoreSvc.restoredBucket)
+		assert.Equal(t, []influxdb.ID{existingID}, restoreSvc.replacedBuckets)
+	})
+
+	t.Run("refuses to overwrite a system bucket", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b := newBuilder(restoreSvc, influxdb.BucketTypeSystem)
+		b.overwriteBucket = true
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `cannot overwrite system bucket "cpu"`)
+		assert.Empty(t, restoreSvc.replacedBuckets)
+	})
+
+	t.Run("cannot be used with a full restore", func(t *testing.T) {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.full, b.overwriteBucket = true, true
+		assert.EqualError(t, b.restore(ctx), "cannot use --overwrite-bucket with --full; a full restore already replaces all buckets")
+	})
+}
+
+func TestCmdRestore_VerifyFiles(t *testing.T) {
+	ctx := context.Background()
+
+	newBuilder := func(bk *testBackup, restoreSvc *fakeRestoreService) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(9000), Name: *filter.Name}, nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			bkt.ID = influxdb.ID(9001)
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = restoreSvc
+		b.orphanService = &fakeOrphanService{report: &influxdb.OrphanReport{}}
+		return b
+	}
+	// truncate removes the last byte of the shard file of shard 1.
+	truncate := func(t *testing.T, bk *testBackup) {
+		t.Helper()
+		path := filepath.Join(bk.dir, bk.shardEntries[1].FileName)
+		require.NoError(t, os.Truncate(path, bk.shardEntries[1].Size-1))
+	}
+
+	t.Run("restores matching files", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		require.NoError(t, newBuilder(bk, restoreSvc).restorePartial(ctx))
+		assert.Equal(t, []uint64{101}, restoreSvc.restoredShards)
+	})
+
+	t.Run("refuses truncated files", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		truncate(t, bk)
+
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		err := newBuilder(bk, restoreSvc).restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--skip-verify-files")
+		assert.Empty(t, restoreSvc.restoredShards)
+	})
+
+	t.Run("refuses files with another checksum", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		bk.shardEntries[1].Checksum = strings.Repeat("0", 64)
+
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		err := newBuilder(bk, restoreSvc).restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum")
+		assert.Empty(t, restoreSvc.restoredShards)
+	})
+
+	t.Run("restores files of old manifests without checksums", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		bk.shardEntries[1].Checksum = ""
+
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		require.NoError(t, newBuilder(bk, restoreSvc).restorePartial(ctx))
+		assert.Equal(t, []uint64{101}, restoreSvc.restoredShards)
+	})
+
+	t.Run("skips verification when asked", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		bk.shardEntries[1].Checksum = strings.Repeat("0", 64)
+
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b := newBuilder(bk, restoreSvc)
+		b.skipVerifyFiles = true
+		require.NoError(t, b.restorePartial(ctx))
+		assert.Equal(t, []uint64{101}, restoreSvc.restoredShards)
+	})
+}
+
+func TestCmdRestore_DryRun(t *testing.T) {
+	ctx := context.Background()
+
+	// The builders have no services, so a dry run that calls the server
+	// panics.
+	newBuilder := func(bk *testBackup) (*cmdRestoreBuilder, *bytes.Buffer) {
+		out := new(bytes.Buffer)
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{w: out})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.dryRun = true
+		return b, out
+	}
+
+	t.Run("prints the buckets to restore", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+		fi, err := os.Stat(filepath.Join(bk.dir, bk.shardEntries[2].FileName))
+		require.NoError(t, err)
+		bk.shardEntries[2].Size = fi.Size()
+
+		b, out := newBuilder(bk)
+		b.bucketName = "mem"
+		b.newBucketName = "mem-restored"
+		b.org.name = "org"
+		b.newOrgName = "restored"
+		require.NoError(t, b.restorePartial(ctx))
+
+		assert.Equal(t, []restorePlanRow{
+			{org: "org", bucket: "mem", targetOrg: "restored", targetBucket: "mem-restored", shards: 1, bytes: fi.Size()},
+		}, b.plan)
+		assert.Contains(t, out.String(), "mem-restored")
+		assert.NotContains(t, out.String(), "cpu")
+	})
+
+	t.Run("full restore prints every bucket", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem")
+		defer bk.cleanup()
+
+		b, out := newBuilder(bk)
+		b.includeInternalOrgs = true
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Len(t, b.plan, 2)
+		assert.Contains(t, out.String(), "cpu")
+		assert.Contains(t, out.String(), "mem")
+	})
+
+	t.Run("fails on missing and unreadable shard files", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu", "mem", "disk")
+		defer bk.cleanup()
+		require.NoError(t, os.Remove(filepath.Join(bk.dir, bk.shardEntries[1].FileName)))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(bk.dir, bk.shardEntries[2].FileName), []byte("not gzip"), 0666))
+
+		b, out := newBuilder(bk)
+		err := b.restorePartial(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2 shard files")
+		assert.Contains(t, out.String(), bk.shardEntries[1].FileName)
+		assert.Contains(t, out.String(), bk.shardEntries[2].FileName)
+		assert.NotContains(t, out.String(), bk.shardEntries[3].FileName)
+	})
+
+	t.Run("fails on shard files of the wrong size", func(t *testing.T) {
+		bk := newTestBackup(t, "cpu")
+		defer bk.cleanup()
+		bk.shardEntries[1].Size = 1000
+
+		b, _ := newBuilder(bk)
+		b.includeInternalOrgs = true
+		require.Error(t, b.restoreFull(ctx))
+	})
+}
+
+func TestCmdRestore_Parallelism(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "a", "b", "c", "d", "e", "f", "g", "h")
+	defer bk.cleanup()
+
+	newBuilder := func(restoreSvc *fakeRestoreService, parallelism int) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationsF: func(ctx context.Context, filter influxdb.OrganizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+				return nil, 0, nil
+			},
+		}
+		b.restoreService = restoreSvc
+		b.parallelism = parallelism
+		return b
+	}
+
+	t.Run("restores shards concurrently", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{delay: 10 * time.Millisecond}
+		b := newBuilder(restoreSvc, 4)
+		require.NoError(t, b.restoreFull(ctx))
+		assert.ElementsMatch(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8}, restoreSvc.restoredShards)
+		assert.True(t, restoreSvc.maxInflight > 1 && restoreSvc.maxInflight <= 4, "max in flight: %d", restoreSvc.maxInflight)
+	})
+
+	t.Run("restores shards in order by default", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{}
+		b := newBuilder(restoreSvc, 1)
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8}, restoreSvc.restoredShards)
+		assert.Equal(t, 1, restoreSvc.maxInflight)
+	})
+
+	t.Run("the first error stops the restore", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardErrs: map[uint64]error{2: fmt.Errorf("shard 2 failed")}}
+		b := newBuilder(restoreSvc, 2)
+		err := b.restoreFull(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "shard 2 failed")
+		assert.NotContains(t, restoreSvc.restoredShards, uint64(2))
+		assert.Less(t, len(restoreSvc.restoredShards), 7)
+	})
+
+	t.Run("parallelism must be positive", func(t *testing.T) {
+		b := newBuilder(&fakeRestoreService{}, 0)
+		err := b.restore(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--parallelism")
+	})
+}
+
+func TestCmdRestore_TimeRange(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2020, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	// Shard i holds the data of March i.
+	newBuilder := func(t *testing.T, restoreSvc *fakeRestoreService, start, end string) *cmdRestoreBuilder {
+		bk := newTestBackup(t, "a", "b", "c", "d", "e", "f")
+		t.Cleanup(bk.cleanup)
+		for id, entry := range bk.shardEntries {
+			entry.StartTime, entry.EndTime = day(int(id)), day(int(id)+1)
+		}
+
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationsF: func(ctx context.Context, filter influxdb.OrganizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+				return nil, 0, nil
+			},
+		}
+		b.restoreService = restoreSvc
+		b.start, b.end = start, end
+		return b
+	}
+	filter := func(t *testing.T, b *cmdRestoreBuilder) error {
+		start, end, err := b.timeRange()
+		require.NoError(t, err)
+		return b.filterTimeRange(start, end)
+	}
+
+	t.Run("restores the shards within the time range", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{}
+		b := newBuilder(t, restoreSvc, "2020-03-03T00:00:00Z", "2020-03-05T00:00:00Z")
+		require.NoError(t, filter(t, b))
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Equal(t, []uint64{3, 4}, restoreSvc.restoredShards)
+	})
+
+	t.Run("restores shards overlapping the time range whole", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{}
+		b := newBuilder(t, restoreSvc, "2020-03-03T12:00:00Z", "2020-03-04T12:00:00Z")
+		require.NoError(t, filter(t, b))
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Equal(t, []uint64{3, 4}, restoreSvc.restoredShards)
+	})
+
+	t.Run("either bound may be left open", func(t *testing.T) {
+		b := newBuilder(t, &fakeRestoreService{}, "2020-03-05T00:00:00Z", "")
+		require.NoError(t, filter(t, b))
+		assert.Len(t, b.shardEntries, 2)
+
+		b = newBuilder(t, &fakeRestoreService{}, "", "2020-03-02T00:00:00Z")
+		require.NoError(t, filter(t, b))
+		assert.Len(t, b.shardEntries, 1)
+	})
+
+	t.Run("manifests without time ranges are rejected", func(t *testing.T) {
+		b := newBuilder(t, &fakeRestoreService{}, "2020-03-03T00:00:00Z", "")
+		b.shardEntries[2].StartTime, b.shardEntries[2].EndTime = time.Time{}, time.Time{}
+		err := filter(t, b)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "older version")
+		assert.Len(t, b.shardEntries, 6)
+	})
+
+	t.Run("invalid time ranges are rejected", func(t *testing.T) {
+		for _, tr := range [][2]string{{"yesterday", ""}, {"", "2020-03-03"}, {"2020-03-03T00:00:00Z", "2020-03-03T00:00:00Z"}} {
+			b := newBuilder(t, &fakeRestoreService{}, tr[0], tr[1])
+			_, _, err := b.timeRange()
+			assert.Error(t, err, "start %q, end %q", tr[0], tr[1])
+		}
+	})
+}
+
+func TestCmdRestore_Secrets(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "cpu")
+	defer bk.cleanup()
+
+	const serverOrgID = influxdb.ID(9000)
+	newBuilder := func(serverKeys []string, patched map[influxdb.ID]map[string]string) (*cmdRestoreBuilder, *bytes.Buffer) {
+		out := new(bytes.Buffer)
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{w: out})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.secretEntries = []influxdb.ManifestSecretEntry{
+			{OrganizationID: bk.org.ID.String(), OrganizationName: bk.org.Name, Keys: []string{"password", "token"}},
+		}
+		b.logger = zaptest.NewLogger(t)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: serverOrgID, Name: *filter.Name}, nil
+			},
+			FindOrganizationsF: func(ctx context.Context, filter influxdb.OrganizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+				return nil, 0, nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			bkt.ID = influxdb.ID(9001)
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101}}
+		b.orphanService = &fakeOrphanService{report: &influxdb.OrphanReport{}}
+
+		secretSvc := mock.NewSecretService()
+		secretSvc.GetSecretKeysFn = func(ctx context.Context, orgID influxdb.ID) ([]string, error) {
+			return serverKeys, nil
+		}
+		secretSvc.PatchSecretsFn = func(ctx context.Context, orgID influxdb.ID, m map[string]string) error {
+			patched[orgID] = m
+			return nil
+		}
+		b.secretService = secretSvc
+		return b, out
+	}
+
+	t.Run("creates missing secrets as placeholders", func(t *testing.T) {
+		patched := make(map[influxdb.ID]map[string]string)
+		b, out := newBuilder([]string{"password"}, patched)
+		require.NoError(t, b.restorePartial(ctx))
+
+		assert.Equal(t, map[influxdb.ID]map[string]string{serverOrgID: {"token": ""}}, patched)
+		assert.Contains(t, out.String(), "influx secret update")
+		assert.Contains(t, out.String(), "token")
+		assert.NotContains(t, out.String(), "password")
+	})
+
+	t.Run("prints nothing without missing secrets", func(t *testing.T) {
+		patched := make(map[influxdb.ID]map[string]string)
+		b, out := newBuilder([]string{"password", "token"}, patched)
+		require.NoError(t, b.restorePartial(ctx))
+
+		assert.Empty(t, patched)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("dry run creates no secrets", func(t *testing.T) {
+		patched := make(map[influxdb.ID]map[string]string)
+		b, out := newBuilder(nil, patched)
+		b.dryRun = true
+		b.secretService = nil
+		require.NoError(t, b.restorePartial(ctx))
+
+		assert.Empty(t, patched)
+		assert.NotContains(t, out.String(), "influx secret update")
+	})
+
+	t.Run("full restore keeps organization IDs", func(t *testing.T) {
+		patched := make(map[influxdb.ID]map[string]string)
+		b, out := newBuilder(nil, patched)
+		b.includeInternalOrgs = true
+		require.NoError(t, b.restoreFull(ctx))
+
+		assert.Equal(t, map[influxdb.ID]map[string]string{bk.org.ID: {"password": "", "token": ""}}, patched)
+		assert.Contains(t, out.String(), "password")
+		assert.Contains(t, out.String(), "token")
+	})
+}
+
+func TestCmdRestore_MetadataOnly(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "cpu", "mem")
+	defer bk.cleanup()
+
+	newBuilder := func(restoreSvc *fakeRestoreService) (*cmdRestoreBuilder, *observer.ObservedLogs) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		b := newCmdRestoreBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.kvEntry = &influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+		b.shardEntries = bk.shardEntries
+		b.logger = zap.New(core)
+		b.orgService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(9000), Name: *filter.Name}, nil
+			},
+			FindOrganizationsF: func(ctx context.Context, filter influxdb.OrganizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+				return nil, 0, nil
+			},
+		}
+		bucketSvc := mock.NewBucketService()
+		nextID := influxdb.ID(9001)
+		bucketSvc.CreateBucketFn = func(ctx context.Context, bkt *influxdb.Bucket) error {
+			bkt.ID = nextID
+			nextID++
+			return nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = restoreSvc
+		b.metadataOnly = true
+		return b, logs
+	}
+	skipped := func(t *testing.T, logs *observer.ObservedLogs) interface{} {
+		t.Helper()
+		entries := logs.FilterMessage("Restored metadata only; the data of the shards of the backup was intentionally not restored").All()
+		require.Len(t, entries, 1)
+		return entries[0].ContextMap()["shards_skipped"]
+	}
+
+	t.Run("full restore stops after the KV store", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{}
+		b, logs := newBuilder(restoreSvc)
+		b.full = true
+		require.NoError(t, b.restoreFull(ctx))
+		assert.Empty(t, restoreSvc.restoredShards)
+		assert.Equal(t, int64(2), skipped(t, logs))
+	})
+
+	t.Run("partial restore creates buckets without shard data", func(t *testing.T) {
+		restoreSvc := &fakeRestoreService{shardIDMap: map[uint64]uint64{1: 101, 2: 102}}
+		b, logs := newBuilder(restoreSvc)
+		require.NoError(t, b.restorePartial(ctx))
+		assert.True(t, restoreSvc.restoredBucket)
+		assert.Len(t, b.result.Buckets, 2)
+		assert.Empty(t, restoreSvc.restoredShards)
+		assert.Empty(t, b.skippedShards)
+		assert.Equal(t, int64(2), skipped(t, logs))
+
+		var out bytes.Buffer
+		b.w = &out
+		require.NoError(t, b.writeRestoreResult(nil))
+		var result restoreResult
+		require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+		require.Len(t, result.SkippedShards, 2)
+		assert.Equal(t, "metadata only", result.SkippedShards[0].Reason)
+	})
+
+	t.Run("full restore requires a KV store", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "influx-restore-")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "20200101T000000Z.manifest"), []byte("{}"), 0666))
+
+		b, _ := newBuilder(&fakeRestoreService{})
+		b.full = true
+		b.path = dir
+		assert.EqualError(t, b.restore(ctx), "cannot use --metadata-only with --full: the backup has no KV store")
+	})
+
+	t.Run("cannot be used with a dry run", func(t *testing.T) {
+		b, _ := newBuilder(&fakeRestoreService{})
+		b.dryRun = true
+		assert.EqualError(t, b.restore(ctx), "cannot use --metadata-only with --dry-run")
+	})
+}
+
+func TestLoadSecretEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influx-restore-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeManifest := func(name string, m influxdb.Manifest) {
+		buf, err := json.Marshal(m)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), buf, 0666))
+	}
+
+	entries, err := loadSecretEntries(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	writeManifest("20200101T000000Z.manifest", influxdb.Manifest{Secrets: []influxdb.ManifestSecretEntry{
+		{OrganizationID: "0000000000000001", OrganizationName: "org", Keys: []string{"old"}},
+	}})
+	writeManifest("20200102T000000Z.manifest", influxdb.Manifest{Secrets: []influxdb.ManifestSecretEntry{
+		{OrganizationID: "0000000000000001", OrganizationName: "org", Keys: []string{"new"}},
+	}})
+
+	entries, err = loadSecretEntries(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []influxdb.ManifestSecretEntry{
+		{OrganizationID: "0000000000000001", OrganizationName: "org", Keys: []string{"new"}},
+	}, entries)
+}
+
+func TestMissingShardFiles(t *testing.T) {
+	bk := newTestBackup(t, "cpu", "mem", "disk")
+	defer bk.cleanup()
+
+	writeManifest := func(name string, files ...*influxdb.ManifestEntry) {
+		m := influxdb.Manifest{KV: influxdb.ManifestKVEntry{FileName: bk.kvFileName}}
+		for _, file := range files {
+			m.Files = append(m.Files, *file)
+		}
+		buf, err := json.Marshal(m)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(bk.dir, name), buf, 0600))
+	}
+	writeManifest("20200101T000000Z.manifest", bk.shardEntries[1], bk.shardEntries[2], bk.shardEntries[3])
+
+	// A later backup of shard 3 replaces its missing earlier file.
+	later := *bk.shardEntries[3]
+	later.FileName = "20200102T000000Z.s3.tar.gz"
+	later.LastModified = time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bk.dir, later.FileName), nil, 0600))
+	writeManifest("20200102T000000Z.manifest", &later)
+
+	require.NoError(t, os.Remove(filepath.Join(bk.dir, bk.shardEntries[2].FileName)))
+	require.NoError(t, os.Remove(filepath.Join(bk.dir, bk.shardEntries[3].FileName)))
+
+	_, shardEntries, err := loadIncremental(bk.dir)
+	require.NoError(t, err)
+	missing, err := missingShardFiles(bk.dir, shardEntries)
+	require.NoError(t, err)
+	require.Len(t, missing, 1)
+	assert.Equal(t, uint64(2), missing[0].ShardID)
+	assert.Equal(t, "mem", missing[0].BucketName)
+}
+
+type fakeOrphanService struct {
+	report *influxdb.OrphanReport
+	err    error
+}
+
+func (s *fakeOrphanService) OrphanReport(ctx context.Context) (*influxdb.OrphanReport, error) {
+	return s.report, s.err
+}
+
+type fakeRestoreService struct {
+	shardIDMap     map[uint64]uint64
+	restoredBucket bool
+	restoredShards []uint64
+	digests        map[influxdb.ID][]influxdb.ShardDigest
+
+	// replacedBuckets holds the IDs of the buckets replaced.
+	replacedBuckets []influxdb.ID
+
+	// shardErrs are returned when restoring the shards they are keyed by,
+	// and delay is spent restoring each shard.
+	shardErrs map[uint64]error
+	delay     time.Duration
+
+	mu                    sync.Mutex
+	inflight, maxInflight int
+}
+
+func (s *fakeRestoreService) RestoreKVStore(ctx context.Context, r io.Reader) error {
+	return nil
+}
+
+func (s *fakeRestoreService) RestoreBucket(ctx context.Context, id influxdb.ID, rpiData []byte) (map[uint64]uint64, error) {
+	s.restoredBucket = true
+	return s.shardIDMap, nil
+}
+
+func (s *fakeRestoreService) ReplaceBucket(ctx context.Context, id influxdb.ID, rpiData []byte) (map[uint64]uint64, error) {
+	s.replacedBuckets = append(s.replacedBuckets, id)
+	return s.shardIDMap, nil
+}
+
+func (s *fakeRestoreService) RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error {
+	s.mu.Lock()
+	s.inflight++
+	if s.inflight > s.maxInflight {
+		s.maxInflight = s.inflight
+	}
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight--
+	if err := s.shardErrs[shardID]; err != nil {
+		return err
+	}
+	s.restoredShards = append(s.restoredShards, shardID)
+	return nil
+}
+
+func (s *fakeRestoreService) BucketShardDigests(ctx context.Context, id influxdb.ID) ([]influxdb.ShardDigest, error) {
+	return s.digests[id], nil
+}
+
+// testBackup is a backup directory with one organization, a bucket and an
+// empty shard per bucket name.
+type testBackup struct {
+	dir          string
+	kvFileName   string
+	org          *influxdb.Organization
+	buckets      []*influxdb.Bucket
+	shardEntries map[uint64]*influxdb.ManifestEntry
+	// shardStarts holds the start times of the shard groups of the shards
+	// with meta.
+	shardStarts map[uint64]time.Time
+}
+
+func (bk *testBackup) cleanup() {
+	os.RemoveAll(bk.dir)
+}
+
+// newTestBackup builds a backup with the named buckets. Buckets prefixed with
+// "without-meta" have no database meta.
+func newTestBackup(t *testing.T, bucketNames ...string) *testBackup {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "influx-restore-")
+	require.NoError(t, err)
+	bk := &testBackup{
+		dir:          dir,
+		kvFileName:   "20200101T000000Z.bolt",
+		shardEntries: make(map[uint64]*influxdb.ManifestEntry),
+		shardStarts:  make(map[uint64]time.Time),
+	}
+
+	bk.org, bk.buckets = bk.addOrg(t, "org", bucketNames...)
+	return bk
+}
+
+// addOrg adds an organization with the named buckets to the backup.
+func (bk *testBackup) addOrg(t *testing.T, orgName string, bucketNames ...string) (*influxdb.Organization, []*influxdb.Bucket) {
+	t.Helper()
+	ctx := context.Background()
+
+	store := bolt.NewKVStore(zaptest.NewLogger(t), filepath.Join(bk.dir, bk.kvFileName), bolt.WithNoSync)
+	require.NoError(t, store.Open(ctx))
+	require.NoError(t, all.Up(ctx, zaptest.NewLogger(t), store))
+
+	tenantSvc := tenant.NewService(tenant.NewStore(store))
+	org := &influxdb.Organization{Name: orgName}
+	require.NoError(t, tenantSvc.CreateOrganization(ctx, org))
+
+	metaClient := meta.NewClient(meta.NewConfig(), store)
+	require.NoError(t, metaClient.Open())
+	var buckets []*influxdb.Bucket
+	for _, name := range bucketNames {
+		bkt := &influxdb.Bucket{OrgID: org.ID, Name: name}
+		require.NoError(t, tenantSvc.CreateBucket(ctx, bkt))
+		buckets = append(buckets, bkt)
+
+		// Write an empty shard file for each bucket.
+		shardID := uint64(len(bk.shardEntries) + 1)
+		if !strings.HasPrefix(name, "without-meta") {
+			dbi, err := metaClient.CreateDatabase(bkt.ID.String())
+			require.NoError(t, err)
+			sgi, err := metaClient.CreateShardGroupWithShards(dbi.Name, dbi.DefaultRetentionPolicy, time.Unix(0, 0), []meta.ShardInfo{{ID: shardID}})
+			require.NoError(t, err)
+			bk.shardStarts[shardID] = sgi.StartTime.UTC()
+		}
+		entry := &influxdb.ManifestEntry{
+			OrganizationID: org.ID.String(),
+			BucketID:       bkt.ID.String(),
+			BucketName:     bkt.Name,
+			ShardID:        shardID,
+			FileName:       fmt.Sprintf("20200101T000000Z.s%d.tar.gz", shardID),
+		}
+		f, err := os.Create(filepath.Join(bk.dir, entry.FileName))
+		require.NoError(t, err)
+		h := sha256.New()
+		gw := gzip.NewWriter(io.MultiWriter(f, h))
+		require.NoError(t, gw.Close())
+		fi, err := f.Stat()
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		entry.Size, entry.Checksum = fi.Size(), hex.EncodeToString(h.Sum(nil))
+		bk.shardEntries[shardID] = entry
+	}
+	require.NoError(t, metaClient.Close())
+	require.NoError(t, store.Close())
+
+	return org, buckets
+}
+
+func TestCmdRestore_CACert(t *testing.T) {
+	srv := httptest.NewTLSServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path == "/health" {
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+			return
+		}
+		w.WriteHeader(nethttp.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "restore-ca-cert-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caPath := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600))
+
+	newBuilder := func(caCert string, skipVerify bool) *cmdRestoreBuilder {
+		b := newCmdRestoreBuilder(&globalFlags{skipVerify: skipVerify}, genericCLIOpts{})
+		b.caCert = caCert
+		b.logger = zaptest.NewLogger(t)
+		return b
+	}
+
+	t.Run("verifies the server with the CA bundle", func(t *testing.T) {
+		tlsConfig, err := newBuilder(caPath, false).tlsConfig()
+		require.NoError(t, err)
+		require.NoError(t, checkHealth(context.Background(), srv.URL, tlsConfig))
+
+		restoreSvc := &http.RestoreService{Addr: srv.URL, TLSConfig: tlsConfig}
+		require.NoError(t, restoreSvc.RestoreKVStore(context.Background(), strings.NewReader("kv")))
+	})
+
+	t.Run("is preferred over --skip-verify", func(t *testing.T) {
+		tlsConfig, err := newBuilder(caPath, true).tlsConfig()
+		require.NoError(t, err)
+		assert.False(t, tlsConfig.InsecureSkipVerify)
+		require.NoError(t, checkHealth(context.Background(), srv.URL, tlsConfig))
+	})
+
+	t.Run("the server is not trusted without it", func(t *testing.T) {
+		tlsConfig, err := newBuilder("", false).tlsConfig()
+		require.NoError(t, err)
+		assert.Error(t, checkHealth(context.Background(), srv.URL, tlsConfig))
+	})
+
+	t.Run("invalid bundles", func(t *testing.T) {
+		_, err := newBuilder(filepath.Join(dir, "missing.pem"), false).tlsConfig()
+		assert.Error(t, err)
+
+		notPEM := filepath.Join(dir, "not.pem")
+		require.NoError(t, ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600))
+		_, err = newBuilder(notPEM, false).tlsConfig()
+		assert.EqualError(t, err, fmt.Sprintf("no PEM encoded certificates found in CA bundle %q", notPEM))
+	})
+}
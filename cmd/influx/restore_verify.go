@@ -0,0 +1,502 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdRestoreVerifyBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	against    string
+	bucketName string
+	org        organization
+	path       string
+	wait       time.Duration
+
+	verifyKeyFile string
+
+	// signature is the signature status of the backup and signatureErr why
+	// it did not verify.
+	signature    string
+	signatureErr error
+
+	shardEntries map[uint64]*influxdb.ManifestEntry
+	metaClient   *meta.Client
+
+	bucketService  influxdb.BucketService
+	restoreService influxdb.RestoreService
+
+	logger *zap.Logger
+}
+
+func newCmdRestoreVerifyBuilder(f *globalFlags, opts genericCLIOpts) *cmdRestoreVerifyBuilder {
+	return &cmdRestoreVerifyBuilder{
+		genericCLIOpts: opts,
+		globalFlags:    f,
+	}
+}
+
+func (b *cmdRestoreVerifyBuilder) cmd() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("verify", b.verifyRunE, true)
+	b.genericCLIOpts.registerPrintOptions(cmd)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	b.org.register(b.viper, cmd, true)
+	cmd.Flags().StringVar(&b.against, "against", "", "The host of the server to verify against; defaults to the active config host")
+	cmd.Flags().StringVarP(&b.bucketName, "bucket", "b", "", "The name of the bucket to verify")
+	cmd.Flags().StringVar(&b.verifyKeyFile, "verify-key-file", "", "Path to a PEM encoded Ed25519 public key to verify the backup signature with")
+	registerWaitForServer(cmd, &b.wait)
+	cmd.Use = "verify [flags] path"
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("must specify path to backup directory")
+		} else if len(args) > 1 {
+			return fmt.Errorf("too many args specified")
+		}
+		b.path = args[0]
+		return nil
+	}
+	cmd.Short = "Verify a server matches a backup"
+	cmd.Long = `
+Compares each bucket in a backup with the bucket of the same name on a server.
+Shard counts, per-shard series counts and checksums of a sample of TSM blocks
+are compared using only TSM index metadata, so verification is cheap for both
+the client and the server. Requires an operator token.
+
+The signature status of the backup is reported for each bucket: "unsigned",
+"signed" if it has a signature that was not checked, or, with
+--verify-key-file, "verified" or "bad signature". Verifying the signature also
+verifies the checksums of the backup files. A backup whose signature does not
+verify fails verification.
+
+Examples:
+	# verify a restore to another server
+	influx restore verify --against http://localhost:9999 /path/to/backup
+
+	# also verify the backup is signed and unmodified
+	influx restore verify --verify-key-file backup-key.pub.pem /path/to/backup
+`
+	return cmd
+}
+
+// bucketVerifyReport is the verification result for one bucket.
+type bucketVerifyReport struct {
+	Org          string   `json:"org"`
+	Bucket       string   `json:"bucket"`
+	Status       string   `json:"status"`
+	Signature    string   `json:"signature"`
+	BackupShards int      `json:"backupShards"`
+	ServerShards int      `json:"serverShards"`
+	Mismatches   []string `json:"mismatches,omitempty"`
+}
+
+const (
+	verifyStatusMatch    = "match"
+	verifyStatusMismatch = "mismatch"
+	verifyStatusMissing  = "missing"
+	verifyStatusSkipped  = "skipped"
+)
+
+const (
+	signatureStatusUnsigned = "unsigned"
+	signatureStatusSigned   = "signed"
+	signatureStatusVerified = "verified"
+	signatureStatusBad      = "bad signature"
+)
+
+func (b *cmdRestoreVerifyBuilder) verifyRunE(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+
+	// Log progress to stderr so stdout only contains the report.
+	logconf := influxlogger.NewConfig()
+	if b.logger, err = logconf.New(os.Stderr); err != nil {
+		return err
+	}
+
+	ac := b.config()
+	host := b.against
+	if host == "" {
+		host = ac.Host
+	}
+	if err := waitForServer(ctx, b.logger, host, &tls.Config{InsecureSkipVerify: b.skipVerify}, b.wait); err != nil {
+		return err
+	}
+
+	client, err := http.NewHTTPClient(host, ac.Token, b.skipVerify)
+	if err != nil {
+		return err
+	}
+	b.bucketService = &tenant.BucketClientService{Client: client}
+	b.restoreService = &http.RestoreService{
+		Addr:               host,
+		Token:              ac.Token,
+		InsecureSkipVerify: b.skipVerify,
+	}
+
+	reports, err := b.verify(ctx)
+	if err != nil {
+		return err
+	}
+	if err := b.printReports(reports); err != nil {
+		return err
+	}
+
+	if b.signatureErr != nil {
+		return b.signatureErr
+	}
+
+	for _, r := range reports {
+		if r.Status == verifyStatusMismatch || r.Status == verifyStatusMissing {
+			return fmt.Errorf("server does not match backup")
+		}
+	}
+	return nil
+}
+
+// verify compares each bucket in the backup with the server.
+func (b *cmdRestoreVerifyBuilder) verify(ctx context.Context) ([]bucketVerifyReport, error) {
+	kvEntry, shardEntries, err := loadIncremental(b.path)
+	if err != nil {
+		return nil, fmt.Errorf("verify failed while processing manifest files: %s", err.Error())
+	} else if kvEntry == nil {
+		return nil, fmt.Errorf("no manifest files found in: %s", b.path)
+	}
+	b.shardEntries = shardEntries
+
+	if err := b.checkSignature(kvEntry); err != nil {
+		return nil, err
+	}
+
+	bm, err := openBackupMeta(ctx, b.logger, filepath.Join(b.path, kvEntry.FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer bm.Close()
+	b.metaClient = bm.metaClient
+
+	var orgFilter influxdb.OrganizationFilter
+	if b.org.id != "" {
+		if orgFilter.ID, err = influxdb.IDFromString(b.org.id); err != nil {
+			return nil, err
+		}
+	} else if b.org.name != "" {
+		orgFilter.Name = &b.org.name
+	}
+
+	orgs, _, err := bm.tenantService.FindOrganizations(ctx, orgFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []bucketVerifyReport
+	for _, org := range orgs {
+		bucketFilter := influxdb.BucketFilter{OrganizationID: &org.ID}
+		if b.bucketName != "" {
+			bucketFilter.Name = &b.bucketName
+		}
+
+		buckets, _, err := bm.tenantService.FindBuckets(ctx, bucketFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, bkt := range buckets {
+			if isInternalBucket(bkt) {
+				continue
+			}
+
+			report, err := b.verifyBucket(ctx, org, bkt)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// checkSignature finds the signature status of the backup. A signature that
+// does not verify with the verify key is recorded to fail verification once
+// the report is printed.
+func (b *cmdRestoreVerifyBuilder) checkSignature(kvEntry *influxdb.ManifestKVEntry) error {
+	if b.verifyKeyFile == "" {
+		manifests, err := filepath.Glob(filepath.Join(b.path, "*.manifest"))
+		if err != nil {
+			return err
+		}
+		b.signature = signatureStatusSigned
+		for _, filename := range manifests {
+			signed, err := manifestSigned(filename)
+			if err != nil {
+				return err
+			}
+			if !signed {
+				b.signature = signatureStatusUnsigned
+			}
+		}
+		return nil
+	}
+
+	key, err := readVerifyKey(b.verifyKeyFile)
+	if err != nil {
+		return fmt.Errorf("cannot read verify key: %w", err)
+	}
+	err = verifySignedBackup(b.path, key, kvEntry, b.shardEntries)
+	switch {
+	case err == nil:
+		b.signature = signatureStatusVerified
+	case errors.Is(err, errUnsignedBackup):
+		b.signature = signatureStatusUnsigned
+		b.signatureErr = err
+	case errors.Is(err, errBadSignature):
+		b.signature = signatureStatusBad
+		b.signatureErr = err
+	default:
+		return err
+	}
+	if b.signatureErr != nil {
+		b.logger.Warn("Backup signature does not verify", zap.Error(b.signatureErr))
+	}
+	return nil
+}
+
+// verifyBucket compares the backup of a bucket with the bucket of the same
+// name on the server.
+func (b *cmdRestoreVerifyBuilder) verifyBucket(ctx context.Context, org *influxdb.Organization, bkt *influxdb.Bucket) (bucketVerifyReport, error) {
+	b.logger.Info("Verifying bucket", zap.String("org", org.Name), zap.String("bucket", bkt.Name))
+	report := bucketVerifyReport{Org: org.Name, Bucket: bkt.Name, Signature: b.signature}
+
+	backupDigests, partial, err := b.backupBucketDigests(bkt)
+	if err != nil {
+		return report, err
+	}
+	report.BackupShards = len(backupDigests)
+
+	// Measurement-scoped backups are expected to differ from the server.
+	if partial {
+		report.Status = verifyStatusSkipped
+		report.Mismatches = []string{"backup only contains some measurements"}
+		return report, nil
+	}
+
+	serverBkt, err := b.bucketService.FindBucket(ctx, influxdb.BucketFilter{Org: &org.Name, Name: &bkt.Name})
+	if influxdb.ErrorCode(err) == influxdb.ENotFound {
+		report.Status = verifyStatusMissing
+		return report, nil
+	} else if err != nil {
+		return report, err
+	}
+
+	serverDigests, err := b.restoreService.BucketShardDigests(ctx, serverBkt.ID)
+	if err != nil {
+		return report, err
+	}
+	report.ServerShards = len(serverDigests)
+
+	report.Mismatches = compareShardDigests(backupDigests, serverDigests)
+	report.Status = verifyStatusMatch
+	if len(report.Mismatches) > 0 {
+		report.Status = verifyStatusMismatch
+	}
+	return report, nil
+}
+
+// backupBucketDigests computes digests of the backed up shards of a bucket.
+// It also reports whether any shard backup only contains some measurements.
+func (b *cmdRestoreVerifyBuilder) backupBucketDigests(bkt *influxdb.Bucket) ([]influxdb.ShardDigest, bool, error) {
+	dbi := b.metaClient.Database(bkt.ID.String())
+	if dbi == nil {
+		return nil, false, fmt.Errorf("bucket database not found: %s", bkt.ID.String())
+	}
+
+	var digests []influxdb.ShardDigest
+	var partial bool
+	for _, rpi := range dbi.RetentionPolicies {
+		for _, sgi := range rpi.ShardGroups {
+			if sgi.Deleted() {
+				continue
+			}
+
+			for _, sh := range sgi.Shards {
+				file, ok := b.shardEntries[sh.ID]
+				if !ok {
+					continue
+				}
+				partial = partial || file.Partial()
+
+				digest, err := backupShardDigest(filepath.Join(b.path, file.FileName))
+				if err != nil {
+					return nil, false, fmt.Errorf("cannot read shard %d from %s: %w", sh.ID, file.FileName, err)
+				}
+				digest.ShardID = sh.ID
+				digest.StartTime = sgi.StartTime.UTC()
+				digest.EndTime = sgi.EndTime.UTC()
+				digests = append(digests, digest)
+			}
+		}
+	}
+
+	sort.Slice(digests, func(i, j int) bool { return digests[i].StartTime.Before(digests[j].StartTime) })
+	return digests, partial, nil
+}
+
+// backupShardDigest computes the digest of the TSM files in a shard backup
+// archive. The archive is unpacked to a temporary directory as TSM files must
+// be read from disk.
+func backupShardDigest(path string) (influxdb.ShardDigest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return influxdb.ShardDigest{}, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return influxdb.ShardDigest{}, err
+	}
+	defer gr.Close()
+
+	dir, err := ioutil.TempDir("", "influx-restore-verify-")
+	if err != nil {
+		return influxdb.ShardDigest{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	var files []tsm1.TSMFile
+	defer func() {
+		for _, r := range files {
+			r.Close()
+		}
+	}()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return influxdb.ShardDigest{}, err
+		}
+
+		if !strings.HasSuffix(hdr.Name, "."+tsm1.TSMFileExtension) {
+			continue
+		}
+
+		r, err := extractTSMFile(tr, filepath.Join(dir, fmt.Sprintf("%d.%s", len(files), tsm1.TSMFileExtension)))
+		if err != nil {
+			return influxdb.ShardDigest{}, err
+		}
+		files = append(files, r)
+	}
+
+	summary, err := tsm1.SummarizeTSMFiles(files)
+	if err != nil {
+		return influxdb.ShardDigest{}, err
+	}
+	return influxdb.ShardDigest{
+		SeriesN:  summary.SeriesN,
+		BlockN:   summary.BlockN,
+		Checksum: strconv.FormatUint(summary.Checksum, 16),
+	}, nil
+}
+
+// extractTSMFile copies the current archive entry to path and opens it.
+func extractTSMFile(r io.Reader, path string) (*tsm1.TSMReader, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	tsmReader, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return tsmReader, nil
+}
+
+// compareShardDigests returns a description of each difference between the
+// shards of a bucket in a backup and on a server. Shards are matched by the
+// start time of their shard group.
+func compareShardDigests(backup, server []influxdb.ShardDigest) []string {
+	var mismatches []string
+	if len(backup) != len(server) {
+		mismatches = append(mismatches, fmt.Sprintf("shard count: backup %d, server %d", len(backup), len(server)))
+	}
+
+	byStart := make(map[int64]influxdb.ShardDigest, len(server))
+	for _, d := range server {
+		byStart[d.StartTime.UnixNano()] = d
+	}
+
+	for _, want := range backup {
+		shard := fmt.Sprintf("shard %d (%s)", want.ShardID, want.StartTime.Format(time.RFC3339))
+
+		got, ok := byStart[want.StartTime.UnixNano()]
+		if !ok {
+			mismatches = append(mismatches, shard+": missing on server")
+			continue
+		}
+
+		if want.SeriesN != got.SeriesN {
+			mismatches = append(mismatches, fmt.Sprintf("%s: series count: backup %d, server %d", shard, want.SeriesN, got.SeriesN))
+		}
+		if want.Checksum != got.Checksum {
+			mismatches = append(mismatches, shard+": block checksums differ")
+		}
+	}
+	return mismatches
+}
+
+func (b *cmdRestoreVerifyBuilder) printReports(reports []bucketVerifyReport) error {
+	if b.json {
+		if reports == nil {
+			reports = []bucketVerifyReport{}
+		}
+		return b.writeJSON(reports)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.WriteHeaders("Organization", "Bucket", "Status", "Signature", "Backup Shards", "Server Shards", "Details")
+	for _, r := range reports {
+		w.Write(map[string]interface{}{
+			"Organization":  r.Org,
+			"Bucket":        r.Bucket,
+			"Status":        r.Status,
+			"Signature":     r.Signature,
+			"Backup Shards": r.BackupShards,
+			"Server Shards": r.ServerShards,
+			"Details":       strings.Join(r.Mismatches, "; "),
+		})
+	}
+	return nil
+}
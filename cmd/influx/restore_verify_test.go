@@ -0,0 +1,191 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCompareShardDigests(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(24 * time.Hour)
+	backup := []influxdb.ShardDigest{
+		{ShardID: 1, StartTime: t0, SeriesN: 10, Checksum: "a"},
+		{ShardID: 2, StartTime: t1, SeriesN: 20, Checksum: "b"},
+	}
+
+	tests := []struct {
+		name   string
+		server []influxdb.ShardDigest
+		exp    []string
+	}{
+		{
+			name: "match",
+			server: []influxdb.ShardDigest{
+				{ShardID: 101, StartTime: t0, SeriesN: 10, Checksum: "a"},
+				{ShardID: 102, StartTime: t1, SeriesN: 20, Checksum: "b"},
+			},
+		},
+		{
+			name: "missing shard",
+			server: []influxdb.ShardDigest{
+				{ShardID: 101, StartTime: t0, SeriesN: 10, Checksum: "a"},
+			},
+			exp: []string{
+				"shard count: backup 2, server 1",
+				"shard 2 (2020-01-02T00:00:00Z): missing on server",
+			},
+		},
+		{
+			name: "different data",
+			server: []influxdb.ShardDigest{
+				{ShardID: 101, StartTime: t0, SeriesN: 9, Checksum: "a"},
+				{ShardID: 102, StartTime: t1, SeriesN: 20, Checksum: "c"},
+			},
+			exp: []string{
+				"shard 1 (2020-01-01T00:00:00Z): series count: backup 10, server 9",
+				"shard 2 (2020-01-02T00:00:00Z): block checksums differ",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, compareShardDigests(backup, tt.server))
+		})
+	}
+}
+
+func TestBackupShardDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influx-restore-verify-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Write a TSM file and summarize it directly.
+	tsmPath := filepath.Join(dir, "000000001-000000001.tsm")
+	f, err := os.Create(tsmPath)
+	require.NoError(t, err)
+	w, err := tsm1.NewTSMWriter(f)
+	require.NoError(t, err)
+	require.NoError(t, w.Write([]byte("cpu,host=A#!~#value"), []tsm1.Value{tsm1.NewValue(0, 1.0)}))
+	require.NoError(t, w.Write([]byte("mem,host=A#!~#value"), []tsm1.Value{tsm1.NewValue(0, 2.0)}))
+	require.NoError(t, w.WriteIndex())
+	require.NoError(t, w.Close())
+
+	f, err = os.Open(tsmPath)
+	require.NoError(t, err)
+	r, err := tsm1.NewTSMReader(f)
+	require.NoError(t, err)
+	exp, err := tsm1.SummarizeTSMFiles([]tsm1.TSMFile{r})
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	// Archive it alongside a file that is not TSM data.
+	archivePath := filepath.Join(dir, "20200101T000000Z.s1.tar.gz")
+	af, err := os.Create(archivePath)
+	require.NoError(t, err)
+	gw := gzip.NewWriter(af)
+	tw := tar.NewWriter(gw)
+
+	buf, err := ioutil.ReadFile(tsmPath)
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "db/rp/1/000000001-000000001.tsm", Mode: 0666, Size: int64(len(buf))}))
+	_, err = tw.Write(buf)
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "db/rp/1/fields.idx", Mode: 0666, Size: 3}))
+	_, err = tw.Write([]byte("idx"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	require.NoError(t, af.Close())
+
+	digest, err := backupShardDigest(archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, digest.SeriesN)
+	assert.Equal(t, 2, digest.BlockN)
+	assert.Equal(t, strconv.FormatUint(exp.Checksum, 16), digest.Checksum)
+}
+
+func TestCmdRestoreVerify_Bucket(t *testing.T) {
+	ctx := context.Background()
+
+	bk := newTestBackup(t, "cpu")
+	defer bk.cleanup()
+
+	const serverBucketID = influxdb.ID(9001)
+	newBuilder := func(serverDigests []influxdb.ShardDigest, found bool) *cmdRestoreVerifyBuilder {
+		b := newCmdRestoreVerifyBuilder(&globalFlags{}, genericCLIOpts{})
+		b.path = bk.dir
+		b.logger = zaptest.NewLogger(t)
+
+		bucketSvc := mock.NewBucketService()
+		bucketSvc.FindBucketFn = func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			if !found {
+				return nil, &influxdb.Error{Code: influxdb.ENotFound}
+			}
+			return &influxdb.Bucket{ID: serverBucketID, Name: *filter.Name}, nil
+		}
+		b.bucketService = bucketSvc
+		b.restoreService = &fakeRestoreService{
+			digests: map[influxdb.ID][]influxdb.ShardDigest{serverBucketID: serverDigests},
+		}
+		return b
+	}
+
+	writeTestManifest(t, bk)
+	digest, err := backupShardDigest(filepath.Join(bk.dir, bk.shardEntries[1].FileName))
+	require.NoError(t, err)
+	digest.StartTime = bk.shardStarts[1]
+
+	t.Run("match", func(t *testing.T) {
+		reports, err := newBuilder([]influxdb.ShardDigest{digest}, true).verify(ctx)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, verifyStatusMatch, reports[0].Status)
+	})
+
+	t.Run("missing bucket", func(t *testing.T) {
+		reports, err := newBuilder(nil, false).verify(ctx)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, verifyStatusMissing, reports[0].Status)
+	})
+
+	t.Run("extra server shard", func(t *testing.T) {
+		extra := digest
+		extra.ShardID, extra.StartTime = 2, digest.StartTime.Add(time.Hour)
+		reports, err := newBuilder([]influxdb.ShardDigest{digest, extra}, true).verify(ctx)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, verifyStatusMismatch, reports[0].Status)
+		assert.Equal(t, 2, reports[0].ServerShards)
+	})
+}
+
+// writeTestManifest writes a manifest for the test backup.
+func writeTestManifest(t *testing.T, bk *testBackup) {
+	t.Helper()
+
+	b := newCmdBackupBuilder(&globalFlags{}, genericCLIOpts{})
+	b.path = bk.dir
+	b.baseName = "20200101T000000Z"
+	b.logger = zaptest.NewLogger(t)
+	b.manifest.KV = influxdb.ManifestKVEntry{FileName: bk.kvFileName}
+	for _, entry := range bk.shardEntries {
+		b.manifest.Files = append(b.manifest.Files, *entry)
+	}
+	require.NoError(t, b.writeManifest(context.Background()))
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/spf13/cobra"
+)
+
+type scraperSVCFn func() (scraperService, error)
+
+// scraperService is the part of the scraper target service the commands use.
+type scraperService interface {
+	GetTargetByID(ctx context.Context, id influxdb.ID) (*influxdb.ScraperTarget, error)
+	PatchTarget(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error)
+}
+
+func cmdScraper(f *globalFlags, opt genericCLIOpts) *cobra.Command {
+	return newCmdScraperBuilder(newScraperService, f, opt).cmd()
+}
+
+type cmdScraperBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	svcFn scraperSVCFn
+
+	id              string
+	name            string
+	url             string
+	bucketID        string
+	allowInsecure   bool
+	headers         []string
+	username        string
+	password        string
+	bearerToken     string
+	interval        time.Duration
+	batchSize       int
+	batchInterval   time.Duration
+	jitter          string
+	timestampSource string
+	precision       string
+
+	json        bool
+	hideHeaders bool
+}
+
+func newCmdScraperBuilder(svcFn scraperSVCFn, f *globalFlags, opt genericCLIOpts) *cmdScraperBuilder {
+	return &cmdScraperBuilder{
+		genericCLIOpts: opt,
+		globalFlags:    f,
+		svcFn:          svcFn,
+	}
+}
+
+func (b *cmdScraperBuilder) cmd() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("scraper", nil, false)
+	cmd.Aliases = []string{"scrapers"}
+	cmd.Short = "Scraper target management commands"
+	cmd.Run = seeHelp
+	cmd.AddCommand(
+		b.cmdUpdate(),
+	)
+	return cmd
+}
+
+func (b *cmdScraperBuilder) cmdUpdate() *cobra.Command {
+	cmd := b.newCmd("update", b.cmdUpdateRunEFn)
+	cmd.Short = "Update a scraper target"
+	cmd.Long = `
+	The scraper update command updates the fields of a scraper target given by
+	flags, leaving the others as they are. The target is read first, and the
+	update fails if the target is changed by someone else before it is applied.
+
+	Examples:
+		# rename a scraper target
+		influx scraper update --id $ID --name $NAME
+
+		# write the metrics of a scraper target to another bucket, in batches
+		# of at most 1000 points
+		influx scraper update --id $ID --bucket-id $BUCKET_ID --batch-size 1000
+
+		# replace the headers sent with each scrape
+		influx scraper update --id $ID --header "X-Scope-OrgID: $TENANT"
+
+		# scrape with a bearer token instead of a username and password
+		influx scraper update --id $ID --username "" --password "" --bearer-token $TOKEN
+
+		# scrape a scraper target every 30 seconds
+		influx scraper update --id $ID --interval 30s
+`
+
+	cmd.Flags().StringVarP(&b.id, "id", "i", "", "The scraper target ID (required)")
+	cmd.MarkFlagRequired("id")
+	cmd.Flags().StringVarP(&b.name, "name", "n", "", "The name of the scraper target")
+	cmd.Flags().StringVarP(&b.url, "url", "u", "", "The URL to scrape metrics from")
+	cmd.Flags().StringVar(&b.bucketID, "bucket-id", "", "The ID of the bucket to write the metrics to")
+	cmd.Flags().BoolVar(&b.allowInsecure, "allow-insecure", false, "Whether to skip TLS verification of the URL")
+	cmd.Flags().StringArrayVar(&b.headers, "header", nil, "A header to send with each scrape, as 'Name: value', replacing all those of the target; an empty value removes them")
+	cmd.Flags().StringVar(&b.username, "username", "", "The username to scrape with basic authentication")
+	cmd.Flags().StringVar(&b.password, "password", "", "The password to scrape with basic authentication")
+	cmd.Flags().StringVar(&b.bearerToken, "bearer-token", "", "The token to scrape with bearer authentication")
+	cmd.Flags().DurationVar(&b.interval, "interval", 0, "How often the target is scraped, at least 1s; 0 uses the interval of the scheduler")
+	cmd.Flags().IntVar(&b.batchSize, "batch-size", 0, "The number of points written per batch; 0 uses the default")
+	cmd.Flags().DurationVar(&b.batchInterval, "batch-interval", 0, "How long a partial batch waits before it is written; 0 uses the default")
+	cmd.Flags().StringVar(&b.jitter, "jitter", "", "When the target is scraped within each interval: none or spread; empty uses the default")
+	cmd.Flags().StringVar(&b.timestampSource, "timestamp-source", "", "Where the timestamps of scraped points come from; empty uses exporter timestamps")
+	cmd.Flags().StringVar(&b.precision, "precision", "", "The precision timestamps are truncated to; empty uses nanoseconds")
+	registerPrintOptions(b.viper, cmd, &b.hideHeaders, &b.json)
+
+	return cmd
+}
+
+func (b *cmdScraperBuilder) cmdUpdateRunEFn(cmd *cobra.Command, args []string) error {
+	svc, err := b.svcFn()
+	if err != nil {
+		return err
+	}
+
+	id, err := influxdb.IDFromString(b.id)
+	if err != nil {
+		return fmt.Errorf("failed to decode scraper target id %q: %v", b.id, err)
+	}
+
+	upd, err := b.update(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	target, err := svc.GetTargetByID(ctx, *id)
+	if err != nil {
+		return fmt.Errorf("failed to find scraper target with id %q: %v", b.id, err)
+	}
+	// The update is only applied to the target as it was read.
+	upd.Revision = &target.Revision
+
+	target, err = svc.PatchTarget(ctx, *id, upd, 0)
+	if err != nil {
+		if influxdb.ErrorCode(err) == influxdb.EConflict {
+			return fmt.Errorf("failed to update scraper target with id %q, as it was changed while updating; try again: %v", b.id, err)
+		}
+		return fmt.Errorf("failed to update scraper target with id %q: %v", b.id, err)
+	}
+	return b.printTarget(target)
+}
+
+// update returns the update of the fields given by the flags of cmd.
+func (b *cmdScraperBuilder) update(cmd *cobra.Command) (influxdb.ScraperTargetUpdate, error) {
+	var upd influxdb.ScraperTargetUpdate
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		upd.Name = &b.name
+	}
+	if flags.Changed("url") {
+		upd.URL = &b.url
+	}
+	if flags.Changed("bucket-id") {
+		bucketID, err := influxdb.IDFromString(b.bucketID)
+		if err != nil {
+			return upd, fmt.Errorf("failed to decode bucket id %q: %v", b.bucketID, err)
+		}
+		upd.BucketID = bucketID
+	}
+	if flags.Changed("allow-insecure") {
+		upd.AllowInsecure = &b.allowInsecure
+	}
+	if flags.Changed("header") {
+		upd.Headers = influxdb.ScraperHeaders{}
+		for _, h := range b.headers {
+			if h == "" {
+				continue
+			}
+			parts := strings.SplitN(h, ":", 2)
+			if len(parts) != 2 {
+				return upd, fmt.Errorf("header %q must be of the form 'Name: value'", h)
+			}
+			upd.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if flags.Changed("username") {
+		upd.Username = &b.username
+	}
+	if flags.Changed("password") {
+		upd.Password = &b.password
+	}
+	if flags.Changed("bearer-token") {
+		upd.BearerToken = &b.bearerToken
+	}
+	if flags.Changed("interval") {
+		upd.Interval = &influxdb.Duration{Duration: b.interval}
+	}
+	if flags.Changed("batch-size") {
+		upd.BatchSize = &b.batchSize
+	}
+	if flags.Changed("batch-interval") {
+		upd.BatchInterval = &influxdb.Duration{Duration: b.batchInterval}
+	}
+	if flags.Changed("jitter") {
+		jitter := influxdb.ScraperJitter(b.jitter)
+		upd.Jitter = &jitter
+	}
+	if flags.Changed("timestamp-source") {
+		source := influxdb.ScraperTimestampSource(b.timestampSource)
+		upd.TimestampSource = &source
+	}
+	if flags.Changed("precision") {
+		precision := influxdb.ScraperPrecision(b.precision)
+		upd.Precision = &precision
+	}
+	return upd, nil
+}
+
+func (b *cmdScraperBuilder) newCmd(use string, runE func(*cobra.Command, []string) error) *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd(use, runE, true)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	return cmd
+}
+
+func (b *cmdScraperBuilder) printTarget(target *influxdb.ScraperTarget) error {
+	if b.json {
+		// The values of headers may be credentials.
+		redacted := *target
+		redacted.Headers = target.Headers.Redacted()
+		return b.writeJSON(redacted)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+	w.WriteHeaders("ID", "Name", "URL", "Organization ID", "Bucket ID", "Revision")
+	w.Write(map[string]interface{}{
+		"ID":              target.ID.String(),
+		"Name":            target.Name,
+		"URL":             target.URL,
+		"Organization ID": target.OrgID.String(),
+		"Bucket ID":       target.BucketID.String(),
+		"Revision":        target.Revision,
+	})
+	return nil
+}
+
+func newScraperService() (scraperService, error) {
+	ac := flags.config()
+	return &http.ScraperService{
+		Addr:               ac.Host,
+		Token:              ac.Token,
+		InsecureSkipVerify: flags.skipVerify,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdScraper(t *testing.T) {
+	targetID := influxdb.ID(9000)
+
+	t.Run("update", func(t *testing.T) {
+		name := "new name"
+		bucketID := influxdb.ID(3)
+		batchSize := 0
+		interval := influxdb.Duration{Duration: time.Minute}
+		revision := uint64(4)
+		empty, token := "", "token"
+
+		tests := []struct {
+			name        string
+			flags       []string
+			patchErr    error
+			expected    influxdb.ScraperTargetUpdate
+			expectedErr bool
+		}{
+			{
+				name:  "only the fields given",
+				flags: []string{"--name=" + name},
+				expected: influxdb.ScraperTargetUpdate{
+					Name:     &name,
+					Revision: &revision,
+				},
+			},
+			{
+				name: "clear and set fields",
+				flags: []string{
+					"--bucket-id=" + bucketID.String(),
+					"--batch-size=0",
+					"--batch-interval=1m",
+				},
+				expected: influxdb.ScraperTargetUpdate{
+					BucketID:      &bucketID,
+					BatchSize:     &batchSize,
+					BatchInterval: &interval,
+					Revision:      &revision,
+				},
+			},
+			{
+				name:  "scrape interval",
+				flags: []string{"--interval=1m"},
+				expected: influxdb.ScraperTargetUpdate{
+					Interval: &interval,
+					Revision: &revision,
+				},
+			},
+			{
+				name:  "replace headers",
+				flags: []string{"--header=Authorization: Bearer token", "--header=X-Scope-OrgID:tenant"},
+				expected: influxdb.ScraperTargetUpdate{
+					Headers: influxdb.ScraperHeaders{
+						"Authorization": "Bearer token",
+						"X-Scope-OrgID": "tenant",
+					},
+					Revision: &revision,
+				},
+			},
+			{
+				name:  "remove headers",
+				flags: []string{"--header="},
+				expected: influxdb.ScraperTargetUpdate{
+					Headers:  influxdb.ScraperHeaders{},
+					Revision: &revision,
+				},
+			},
+			{
+				name:  "switch credentials",
+				flags: []string{"--username=", "--password=", "--bearer-token=token"},
+				expected: influxdb.ScraperTargetUpdate{
+					Username:    &empty,
+					Password:    &empty,
+					BearerToken: &token,
+					Revision:    &revision,
+				},
+			},
+			{
+				name:        "malformed header",
+				flags:       []string{"--header=Authorization"},
+				expectedErr: true,
+			},
+			{
+				name:        "changed while updating",
+				flags:       []string{"--name=" + name},
+				patchErr:    influxdb.ErrScraperTargetRevisionMismatch(4, 5),
+				expectedErr: true,
+			},
+			{
+				name:        "invalid bucket id",
+				flags:       []string{"--bucket-id=nope"},
+				expectedErr: true,
+			},
+		}
+
+		cmdFn := func(patchErr error) (func(*globalFlags, genericCLIOpts) *cobra.Command, *influxdb.ScraperTargetUpdate) {
+			var patched influxdb.ScraperTargetUpdate
+			svc := &mock.ScraperTargetStoreService{
+				GetTargetByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.ScraperTarget, error) {
+					return &influxdb.ScraperTarget{ID: id, Name: "old name", Revision: revision}, nil
+				},
+				PatchTargetF: func(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+					if id != targetID {
+						return nil, &influxdb.Error{Code: influxdb.ENotFound}
+					}
+					if patchErr != nil {
+						return nil, patchErr
+					}
+					patched = upd
+					target := &influxdb.ScraperTarget{ID: id, Name: "old name", Revision: revision}
+					upd.Apply(target)
+					target.Revision++
+					return target, nil
+				},
+			}
+
+			return func(g *globalFlags, opt genericCLIOpts) *cobra.Command {
+				return newCmdScraperBuilder(func() (scraperService, error) {
+					return svc, nil
+				}, g, opt).cmd()
+			}, &patched
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				builder := newInfluxCmdBuilder(
+					in(new(bytes.Buffer)),
+					out(ioutil.Discard),
+				)
+				nestedCmdFn, patched := cmdFn(tt.patchErr)
+				cmd := builder.cmd(nestedCmdFn)
+				cmd.SetArgs(append([]string{"scraper", "update", "--id=" + targetID.String()}, tt.flags...))
+
+				err := cmd.Execute()
+				if tt.expectedErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, *patched)
+			}
+
+			t.Run(tt.name, fn)
+		}
+	})
+}
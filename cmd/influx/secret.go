@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/influxdata/influxdb/v2"
@@ -14,6 +15,12 @@ import (
 
 type secretSVCsFn func() (influxdb.SecretService, influxdb.OrganizationService, func(*input.UI) string, error)
 
+// secretPlaceholderService is implemented by secret services that report the
+// placeholder secrets of an organization, which have no value yet.
+type secretPlaceholderService interface {
+	GetSecretPlaceholders(ctx context.Context, orgID influxdb.ID) ([]string, error)
+}
+
 func cmdSecret(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 	builder := newCmdSecretBuilder(newSecretSVCs, f, opt)
 	return builder.cmd()
@@ -166,16 +173,29 @@ func (b *cmdSecretBuilder) cmdFindRunEFn(cmd *cobra.Command, args []string) erro
 		return fmt.Errorf("failed to retrieve secret keys: %s", err)
 	}
 
+	placeholders := make(map[string]bool)
+	if svc, ok := scrSVC.(secretPlaceholderService); ok {
+		keys, err := svc.GetSecretPlaceholders(context.Background(), orgID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve placeholder secrets: %s", err)
+		}
+		for _, key := range keys {
+			placeholders[key] = true
+		}
+	}
+
 	secrets := make([]secret, 0, len(platformSecrets))
 	for _, key := range platformSecrets {
 		secrets = append(secrets, secret{
-			key:   key,
-			orgID: orgID,
+			key:         key,
+			orgID:       orgID,
+			placeholder: placeholders[key],
 		})
 	}
 
 	return b.printSecrets(secretPrintOpt{
-		secrets: secrets,
+		placeholders: true,
+		secrets:      secrets,
 	})
 }
 
@@ -204,6 +224,9 @@ func (b *cmdSecretBuilder) printSecrets(opt secretPrintOpt) error {
 	w.HideHeaders(b.hideHeaders)
 
 	headers := []string{"Key", "Organization ID"}
+	if opt.placeholders {
+		headers = append(headers, "Placeholder")
+	}
 	if opt.deleted {
 		headers = append(headers, "Deleted")
 	}
@@ -218,6 +241,9 @@ func (b *cmdSecretBuilder) printSecrets(opt secretPrintOpt) error {
 			"Key":             s.key,
 			"Organization ID": s.orgID.String(),
 		}
+		if opt.placeholders {
+			m["Placeholder"] = s.placeholder
+		}
 		if opt.deleted {
 			m["Deleted"] = true
 		}
@@ -229,17 +255,31 @@ func (b *cmdSecretBuilder) printSecrets(opt secretPrintOpt) error {
 
 type (
 	secretPrintOpt struct {
-		deleted bool
-		secret  secret
-		secrets []secret
+		deleted      bool
+		placeholders bool
+		secret       secret
+		secrets      []secret
 	}
 
 	secret struct {
-		key   string
-		orgID influxdb.ID
+		key         string
+		orgID       influxdb.ID
+		placeholder bool
 	}
 )
 
+func (s secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Key         string      `json:"key"`
+		OrgID       influxdb.ID `json:"orgID"`
+		Placeholder bool        `json:"placeholder,omitempty"`
+	}{
+		Key:         s.key,
+		OrgID:       s.orgID,
+		Placeholder: s.placeholder,
+	})
+}
+
 func newSecretSVCs() (influxdb.SecretService, influxdb.OrganizationService, func(*input.UI) string, error) {
 	httpClient, err := newHTTPClient()
 	if err != nil {
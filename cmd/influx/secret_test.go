@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/influxdata/influxdb/v2"
@@ -115,6 +116,62 @@ func TestCmdSecret(t *testing.T) {
 		}
 	})
 
+	t.Run("list placeholders", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			flags        []string
+			expectedRows [][]string
+			expectedJSON string
+		}{
+			{
+				name: "table",
+				expectedRows: [][]string{
+					{"Key", "Organization", "ID", "Placeholder"},
+					{"k1", orgID.String(), "false"},
+					{"k2", orgID.String(), "true"},
+				},
+			},
+			{
+				name:         "json",
+				flags:        []string{"--json"},
+				expectedJSON: `[{"key":"k1","orgID":"0000000000002328"},{"key":"k2","orgID":"0000000000002328","placeholder":true}]`,
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				svc := &fakePlaceholderSecretService{SecretService: mock.NewSecretService()}
+				svc.GetSecretKeysFn = func(ctx context.Context, organizationID influxdb.ID) ([]string, error) {
+					return []string{"k1", "k2"}, nil
+				}
+				svc.placeholders = []string{"k2"}
+
+				w := new(bytes.Buffer)
+				builder := newInfluxCmdBuilder(
+					in(new(bytes.Buffer)),
+					out(w),
+				)
+				cmd := builder.cmd(func(g *globalFlags, opt genericCLIOpts) *cobra.Command {
+					return newCmdSecretBuilder(fakeSVCFn(svc, nil), g, opt).cmd()
+				})
+				cmd.SetArgs(append([]string{"secret", "list", "--org=rg"}, tt.flags...))
+
+				require.NoError(t, cmd.Execute())
+				if tt.expectedJSON != "" {
+					assert.JSONEq(t, tt.expectedJSON, w.String())
+					return
+				}
+				var rows [][]string
+				for _, line := range strings.Split(strings.TrimSpace(w.String()), "\n") {
+					rows = append(rows, strings.Fields(line))
+				}
+				assert.Equal(t, tt.expectedRows, rows)
+			}
+
+			t.Run(tt.name, fn)
+		}
+	})
+
 	t.Run("delete", func(t *testing.T) {
 		tests := []struct {
 			name        string
@@ -238,3 +295,13 @@ func TestCmdSecret(t *testing.T) {
 		}
 	})
 }
+
+type fakePlaceholderSecretService struct {
+	*mock.SecretService
+
+	placeholders []string
+}
+
+func (s *fakePlaceholderSecretService) GetSecretPlaceholders(ctx context.Context, orgID influxdb.ID) ([]string, error) {
+	return s.placeholders, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/spf13/cobra"
+)
+
+func cmdServer(f *globalFlags, opts genericCLIOpts) *cobra.Command {
+	return newCmdServerBuilder(f, opts).cmd()
+}
+
+type cmdServerBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	maintenanceService influxdb.MaintenanceService
+	summaryService     influxdb.SummaryService
+}
+
+func newCmdServerBuilder(f *globalFlags, opts genericCLIOpts) *cmdServerBuilder {
+	return &cmdServerBuilder{
+		genericCLIOpts: opts,
+		globalFlags:    f,
+	}
+}
+
+func (b *cmdServerBuilder) cmd() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("server", nil, false)
+	cmd.Short = "Inspect the state of the server"
+	cmd.Run = seeHelp
+	cmd.AddCommand(b.cmdMaintenance(), b.cmdSummary())
+	return cmd
+}
+
+func (b *cmdServerBuilder) cmdMaintenance() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("maintenance", b.maintenanceRunE, true)
+	b.genericCLIOpts.registerPrintOptions(cmd)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	cmd.Short = "List the background maintenance services of the server"
+	cmd.Long = `
+Lists the background maintenance services of the server, such as retention
+enforcement and shard precreation, with how often they run, the outcome of
+their last run and when they run next. Requires an operator token.
+
+Examples:
+	influx server maintenance
+`
+	return cmd
+}
+
+func (b *cmdServerBuilder) maintenanceRunE(cmd *cobra.Command, args []string) error {
+	if b.maintenanceService == nil {
+		client, err := newHTTPClient()
+		if err != nil {
+			return err
+		}
+		b.maintenanceService = &http.MaintenanceService{Client: client}
+	}
+
+	services, err := b.maintenanceService.MaintenanceServices(context.Background())
+	if err != nil {
+		return err
+	}
+	return b.printMaintenanceServices(services)
+}
+
+func (b *cmdServerBuilder) printMaintenanceServices(services []influxdb.MaintenanceServiceStatus) error {
+	if b.json {
+		return b.writeJSON(services)
+	}
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+	w.WriteHeaders("Service", "Enabled", "Interval", "Last Run Start", "Last Run End", "Outcome", "Summary", "Next Run")
+	for _, s := range services {
+		row := map[string]interface{}{
+			"Service":  s.Name,
+			"Enabled":  s.Enabled,
+			"Interval": s.Interval.String(),
+			"Next Run": formatTime(s.NextRun),
+		}
+		if run := s.LastRun; run != nil {
+			row["Last Run Start"] = formatTime(&run.Start)
+			row["Last Run End"] = formatTime(run.End)
+			row["Outcome"] = run.Outcome
+			row["Summary"] = run.Summary
+		}
+		w.Write(row)
+	}
+	return nil
+}
+
+func (b *cmdServerBuilder) cmdSummary() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("summary", b.summaryRunE, true)
+	b.genericCLIOpts.registerPrintOptions(cmd)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	cmd.Short = "Show an overview of the server"
+	cmd.Long = `
+Shows an overview of the server: its version and uptime, the number of
+organizations, buckets, users, tasks and scrapers, its disk usage, series
+count, write and query rates over the last 5 minutes, and the state of its
+background maintenance services. The counts, disk usage and series count are
+refreshed by the server in the background, at the time shown. Requires an
+operator token.
+
+Examples:
+	influx server summary
+	influx server summary --json
+`
+	return cmd
+}
+
+func (b *cmdServerBuilder) summaryRunE(cmd *cobra.Command, args []string) error {
+	if b.summaryService == nil {
+		client, err := newHTTPClient()
+		if err != nil {
+			return err
+		}
+		b.summaryService = &http.SummaryService{Client: client}
+	}
+
+	summary, err := b.summaryService.ServerSummary(context.Background())
+	if err != nil {
+		return err
+	}
+	return b.printSummary(summary)
+}
+
+func (b *cmdServerBuilder) printSummary(s *influxdb.ServerSummary) error {
+	if b.json {
+		return b.writeJSON(s)
+	}
+
+	line := func(name, format string, args ...interface{}) {
+		fmt.Fprintf(b.w, "%-15s"+format+"\n", append([]interface{}{name + ":"}, args...)...)
+	}
+	line("Version", "%s (%s, built %s)", s.Version, s.Commit, s.BuildDate)
+	line("Started", "%s (up %s)", s.StartedAt.Format(time.RFC3339), s.Uptime.String())
+	line("Stats updated", "%s", formatTime(s.StatsUpdatedAt))
+	c := s.Counts
+	line("Resources", "%d orgs, %d buckets, %d users, %d tasks, %d scrapers", c.Orgs, c.Buckets, c.Users, c.Tasks, c.Scrapers)
+	line("Series", "%d", s.SeriesN)
+	d := s.Disk
+	line("Disk usage", "%s (TSM %s, WAL %s, index %s, KV %s)",
+		humanize.Bytes(uint64(d.Total)), humanize.Bytes(uint64(d.TSM)), humanize.Bytes(uint64(d.WAL)),
+		humanize.Bytes(uint64(d.Index)), humanize.Bytes(uint64(d.KV)))
+	r := s.Rates
+	line("Writes", "%.2f requests/s, %.2f points/s", r.WriteRequestsPerSecond, r.PointsWrittenPerSecond)
+	line("Queries", "%.2f queries/s", r.QueriesPerSecond)
+	line("Rates over", "%s to %s", r.Range.Start.Format(time.RFC3339), r.Range.Stop.Format(time.RFC3339))
+	fmt.Fprintln(b.w)
+
+	return b.printMaintenanceServices(s.Services)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -28,6 +28,7 @@ var setupFlags struct {
 	password    string
 	retention   string
 	token       string
+	tokenDesc   string
 	username    string
 }
 
@@ -40,6 +41,7 @@ func cmdSetup(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 	cmd.Flags().StringVarP(&setupFlags.username, "username", "u", "", "primary username")
 	cmd.Flags().StringVarP(&setupFlags.password, "password", "p", "", "password for username")
 	cmd.Flags().StringVarP(&setupFlags.token, "token", "t", "", "token for username, else auto-generated")
+	cmd.Flags().StringVar(&setupFlags.tokenDesc, "token-description", "", "description of the token for username, else \"<username>'s Token\"")
 	cmd.Flags().StringVarP(&setupFlags.org, "org", "o", "", "primary organization name")
 	cmd.Flags().StringVarP(&setupFlags.bucket, "bucket", "b", "", "primary bucket name")
 	cmd.Flags().StringVarP(&setupFlags.name, "name", "n", "", "config name, only required if you already have existing configs")
@@ -62,6 +64,7 @@ func cmdSetupUser(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 	cmd.Flags().StringVarP(&setupFlags.username, "username", "u", "", "primary username")
 	cmd.Flags().StringVarP(&setupFlags.password, "password", "p", "", "password for username")
 	cmd.Flags().StringVarP(&setupFlags.token, "token", "t", "", "token for username, else auto-generated")
+	cmd.Flags().StringVar(&setupFlags.tokenDesc, "token-description", "", "description of the token for username, else \"<username>'s Token\"")
 	cmd.Flags().StringVarP(&setupFlags.org, "org", "o", "", "primary organization name")
 	cmd.Flags().StringVarP(&setupFlags.bucket, "bucket", "b", "", "primary bucket name")
 	cmd.Flags().StringVarP(&setupFlags.name, "name", "n", "", "config name, only required if you already have existing configs")
@@ -227,12 +230,13 @@ func nonInteractive() (*influxdb.OnboardingRequest, error) {
 	}
 
 	req := &influxdb.OnboardingRequest{
-		User:            setupFlags.username,
-		Password:        setupFlags.password,
-		Token:           setupFlags.token,
-		Org:             setupFlags.org,
-		Bucket:          setupFlags.bucket,
-		RetentionPeriod: influxdb.InfiniteRetention,
+		User:             setupFlags.username,
+		Password:         setupFlags.password,
+		Token:            setupFlags.token,
+		TokenDescription: setupFlags.tokenDesc,
+		Org:              setupFlags.org,
+		Bucket:           setupFlags.bucket,
+		RetentionPeriod:  influxdb.InfiniteRetention,
 	}
 
 	dur, err := internal2.RawDurationToTimeDuration(setupFlags.retention)
@@ -266,6 +270,7 @@ func interactive() (req *influxdb.OnboardingRequest, err error) {
 		req.Token = setupFlags.token
 		// else auto-generated by service
 	}
+	req.TokenDescription = setupFlags.tokenDesc
 	if setupFlags.org != "" {
 		req.Org = setupFlags.org
 	} else {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/cmd/influx/internal"
+	internal2 "github.com/influxdata/influxdb/v2/cmd/internal"
 	"github.com/influxdata/influxdb/v2/http"
 	"github.com/spf13/cobra"
 )
@@ -24,6 +26,7 @@ func cmdTask(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 
 	cmd.AddCommand(
 		taskLogCmd(f, opt),
+		taskLogsCmd(f, opt),
 		taskRunCmd(f, opt),
 		taskCreateCmd(f, opt),
 		taskDeleteCmd(f, opt),
@@ -453,6 +456,228 @@ func taskLogFindF(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var taskLogsFlags struct {
+	runID  string
+	since  string
+	follow bool
+}
+
+func taskLogsCmd(f *globalFlags, opt genericCLIOpts) *cobra.Command {
+	cmd := opt.newCmd("logs <task-id>", taskLogsF, true)
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Short = "Print or follow the logs of a task"
+	cmd.Long = `
+Prints the logs of a task, or of one of its runs with --run. With --follow the
+logs of the run, or else of the active or most recent run of the task, are
+printed as they are written until the run finishes; the command fails if the
+run fails or is canceled. With --json every log is printed as a JSON object on
+its own line.
+
+Examples:
+	# print the logs of the last hour of a task
+	influx task logs $TASK_ID --since 1h
+
+	# follow the logs of the current run of a task
+	influx task logs $TASK_ID --follow
+`
+
+	f.registerFlags(opt.viper, cmd)
+	registerPrintOptions(opt.viper, cmd, &taskPrintFlags.hideHeaders, &taskPrintFlags.json)
+	cmd.Flags().StringVar(&taskLogsFlags.runID, "run", "", "The ID of the run to print the logs of")
+	cmd.Flags().StringVar(&taskLogsFlags.since, "since", "", "Only print logs written since a duration ago (e.g. 1h) or an RFC3339 time")
+	cmd.Flags().BoolVar(&taskLogsFlags.follow, "follow", false, "Print new logs of the run as they are written until it finishes")
+
+	return cmd
+}
+
+func taskLogsF(cmd *cobra.Command, args []string) error {
+	taskID, err := influxdb.IDFromString(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to decode task id %q: %v", args[0], err)
+	}
+	filter := influxdb.LogFilter{Task: *taskID}
+
+	if taskLogsFlags.runID != "" {
+		if filter.Run, err = influxdb.IDFromString(taskLogsFlags.runID); err != nil {
+			return fmt.Errorf("failed to decode run id %q: %v", taskLogsFlags.runID, err)
+		}
+	}
+	if taskLogsFlags.since != "" {
+		if filter.Since, err = parseLogsSince(taskLogsFlags.since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	client, err := newHTTPClient()
+	if err != nil {
+		return err
+	}
+	s := &http.TaskService{Client: client}
+
+	p := newTaskLogsPrinter(cmd.OutOrStdout(), taskPrintFlags.json, taskPrintFlags.hideHeaders)
+	ctx := context.Background()
+	if taskLogsFlags.follow {
+		return followTaskLogs(ctx, s, filter, p)
+	}
+
+	logs, _, err := s.FindLogs(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return p.print(logs)
+}
+
+// parseLogsSince parses a time either as a duration before now or as an
+// RFC3339 time.
+func parseLogsSince(since string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, since); err == nil {
+		return t, nil
+	}
+	d, err := internal2.RawDurationToTimeDuration(since)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("since must be a positive duration or an RFC3339 time: %q", since)
+	}
+	return now.Add(-d), nil
+}
+
+type taskLogsService interface {
+	FindLogs(ctx context.Context, filter influxdb.LogFilter) ([]*influxdb.Log, int, error)
+	WaitLogs(ctx context.Context, filter influxdb.LogFilter, wait time.Duration) ([]*influxdb.Log, int, error)
+	FindRuns(ctx context.Context, filter influxdb.RunFilter) ([]*influxdb.Run, int, error)
+	FindRunByID(ctx context.Context, taskID, runID influxdb.ID) (*influxdb.Run, error)
+}
+
+// taskLogsWait is how long a single request of a followed run waits for new
+// logs.
+const taskLogsWait = 30 * time.Second
+
+// followTaskLogs prints the logs of a run as they are written until it
+// finishes. Without a run in the filter, the active or else the most recent
+// run of the task is followed. An error is returned if the run does not
+// succeed.
+func followTaskLogs(ctx context.Context, s taskLogsService, filter influxdb.LogFilter, p *taskLogsPrinter) error {
+	if filter.Run == nil {
+		run, err := followedRun(ctx, s, filter.Task)
+		if err != nil {
+			return err
+		}
+		filter.Run = &run.ID
+	}
+
+	for {
+		logs, _, err := s.WaitLogs(ctx, filter, taskLogsWait)
+		if err != nil {
+			return err
+		}
+		if err := p.print(logs); err != nil {
+			return err
+		}
+		filter.Since = nextLogsSince(logs, filter.Since)
+
+		run, err := s.FindRunByID(ctx, filter.Task, *filter.Run)
+		if err != nil {
+			return err
+		}
+		if !influxdb.RunFinished(run.Status) {
+			continue
+		}
+
+		// Print the logs written between the last wait and the end of the run.
+		logs, _, err = s.FindLogs(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if err := p.print(logs); err != nil {
+			return err
+		}
+		if run.Status != influxdb.RunSuccess.String() {
+			return fmt.Errorf("run %s of task %s %s", run.ID, run.TaskID, run.Status)
+		}
+		return nil
+	}
+}
+
+// followedRun returns the active run of a task, or else its most recently
+// scheduled run.
+func followedRun(ctx context.Context, s taskLogsService, taskID influxdb.ID) (*influxdb.Run, error) {
+	runs, _, err := s.FindRuns(ctx, influxdb.RunFilter{Task: taskID})
+	if err != nil {
+		return nil, err
+	}
+
+	var followed *influxdb.Run
+	for _, run := range runs {
+		switch {
+		case followed == nil:
+			followed = run
+		case influxdb.RunFinished(followed.Status) != influxdb.RunFinished(run.Status):
+			if !influxdb.RunFinished(run.Status) {
+				followed = run
+			}
+		case run.ScheduledFor.After(followed.ScheduledFor):
+			followed = run
+		}
+	}
+	if followed == nil {
+		return nil, fmt.Errorf("task %s has no runs to follow", taskID)
+	}
+	return followed, nil
+}
+
+// nextLogsSince returns the time after the last of the logs, so that they are
+// not returned again.
+func nextLogsSince(logs []*influxdb.Log, since time.Time) time.Time {
+	if len(logs) == 0 {
+		return since
+	}
+	t, err := time.Parse(time.RFC3339Nano, logs[len(logs)-1].Time)
+	if err != nil {
+		return since
+	}
+	return t.Add(time.Nanosecond)
+}
+
+// taskLogsPrinter prints batches of logs, writing the table headers only once.
+type taskLogsPrinter struct {
+	w           io.Writer
+	json        bool
+	hideHeaders bool
+}
+
+func newTaskLogsPrinter(w io.Writer, json, hideHeaders bool) *taskLogsPrinter {
+	return &taskLogsPrinter{w: w, json: json, hideHeaders: hideHeaders}
+}
+
+func (p *taskLogsPrinter) print(logs []*influxdb.Log) error {
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		for _, log := range logs {
+			if err := enc.Encode(log); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	tabW := internal.NewTabWriter(p.w)
+	defer tabW.Flush()
+
+	tabW.HideHeaders(p.hideHeaders)
+	tabW.WriteHeaders("RunID", "Time", "Message")
+	p.hideHeaders = true
+	for _, log := range logs {
+		tabW.Write(map[string]interface{}{
+			"RunID":   log.RunID,
+			"Time":    log.Time,
+			"Message": log.Message,
+		})
+	}
+	return nil
+}
+
 func taskRunCmd(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 	cmd := opt.newCmd("run", nil, false)
 	cmd.Run = seeHelp
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTaskLogsService serves the logs of a run that writes one batch of logs
+// per wait and then finishes with status.
+type fakeTaskLogsService struct {
+	runs    []*influxdb.Run
+	batches [][]influxdb.Log
+	status  string
+
+	waits []influxdb.LogFilter
+	logs  []influxdb.Log
+}
+
+func (f *fakeTaskLogsService) FindLogs(ctx context.Context, filter influxdb.LogFilter) ([]*influxdb.Log, int, error) {
+	var logs []*influxdb.Log
+	for i := range f.logs {
+		if t, _ := time.Parse(time.RFC3339Nano, f.logs[i].Time); !t.Before(filter.Since) {
+			logs = append(logs, &f.logs[i])
+		}
+	}
+	return logs, len(logs), nil
+}
+
+func (f *fakeTaskLogsService) WaitLogs(ctx context.Context, filter influxdb.LogFilter, wait time.Duration) ([]*influxdb.Log, int, error) {
+	f.waits = append(f.waits, filter)
+	if len(f.batches) > 0 {
+		f.logs = append(f.logs, f.batches[0]...)
+		f.batches = f.batches[1:]
+	}
+	return f.FindLogs(ctx, filter)
+}
+
+func (f *fakeTaskLogsService) FindRuns(ctx context.Context, filter influxdb.RunFilter) ([]*influxdb.Run, int, error) {
+	return f.runs, len(f.runs), nil
+}
+
+func (f *fakeTaskLogsService) FindRunByID(ctx context.Context, taskID, runID influxdb.ID) (*influxdb.Run, error) {
+	status := influxdb.RunStarted.String()
+	if len(f.batches) == 0 {
+		status = f.status
+	}
+	return &influxdb.Run{ID: runID, TaskID: taskID, Status: status}, nil
+}
+
+func TestFollowTaskLogs(t *testing.T) {
+	start := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)
+	logAt := func(d time.Duration, msg string) influxdb.Log {
+		return influxdb.Log{RunID: 2, Time: start.Add(d).Format(time.RFC3339Nano), Message: msg}
+	}
+	newService := func(status string) *fakeTaskLogsService {
+		return &fakeTaskLogsService{
+			runs: []*influxdb.Run{
+				{ID: 1, Status: influxdb.RunSuccess.String(), ScheduledFor: start.Add(-time.Hour)},
+				{ID: 2, Status: influxdb.RunStarted.String(), ScheduledFor: start.Add(-2 * time.Hour)},
+				{ID: 3, Status: influxdb.RunFail.String(), ScheduledFor: start},
+			},
+			batches: [][]influxdb.Log{
+				{logAt(0, "Started task from script"), logAt(0, "Executing")},
+				nil,
+				{logAt(time.Second, "Completed")},
+			},
+			status: status,
+		}
+	}
+
+	t.Run("prints the logs of the active run once", func(t *testing.T) {
+		s := newService(influxdb.RunSuccess.String())
+		out := new(bytes.Buffer)
+		err := followTaskLogs(context.Background(), s, influxdb.LogFilter{Task: 1}, newTaskLogsPrinter(out, true, false))
+		require.NoError(t, err)
+
+		var msgs []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var l influxdb.Log
+			require.NoError(t, json.Unmarshal([]byte(line), &l))
+			assert.Equal(t, influxdb.ID(2), l.RunID)
+			msgs = append(msgs, l.Message)
+		}
+		assert.Equal(t, []string{"Started task from script", "Executing", "Completed"}, msgs)
+
+		require.Len(t, s.waits, 3)
+		for _, f := range s.waits {
+			assert.Equal(t, influxdb.ID(2), *f.Run)
+		}
+		assert.True(t, s.waits[1].Since.After(start))
+	})
+
+	t.Run("fails when the run fails", func(t *testing.T) {
+		id := influxdb.ID(2)
+		out := new(bytes.Buffer)
+		err := followTaskLogs(context.Background(), newService(influxdb.RunFail.String()), influxdb.LogFilter{Task: 1, Run: &id}, newTaskLogsPrinter(out, false, false))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed")
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 4)
+		assert.Contains(t, lines[0], "Message")
+		assert.Contains(t, lines[3], "Completed")
+	})
+
+	t.Run("fails without runs", func(t *testing.T) {
+		err := followTaskLogs(context.Background(), &fakeTaskLogsService{}, influxdb.LogFilter{Task: 1}, newTaskLogsPrinter(new(bytes.Buffer), false, false))
+		require.Error(t, err)
+	})
+}
+
+func TestParseLogsSince(t *testing.T) {
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	got, err := parseLogsSince("1h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour), got)
+
+	got, err = parseLogsSince("1d", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-24*time.Hour), got)
+
+	got, err = parseLogsSince("2020-01-01T12:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-12*time.Hour), got)
+
+	_, err = parseLogsSince("yesterday", now)
+	assert.Error(t, err)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	input "github.com/tcnksm/go-input"
+	"gopkg.in/yaml.v3"
 )
 
 type templateSVCsFn func() (pkger.SVC, influxdb.OrganizationService, error)
@@ -73,9 +74,11 @@ type cmdTemplateBuilder struct {
 	urls                []string
 
 	applyOpts struct {
-		envRefs []string
-		force   string
-		secrets []string
+		envRefs   []string
+		force     string
+		params    []string
+		paramFile string
+		secrets   []string
 	}
 
 	exportOpts struct {
@@ -98,6 +101,7 @@ type cmdTemplateBuilder struct {
 		taskNames      string
 		telegrafNames  string
 		variableNames  string
+		parameterize   []string
 	}
 
 	updateStackOpts struct {
@@ -165,6 +169,36 @@ func (b *cmdTemplateBuilder) cmdApply() *cobra.Command {
 			--filter kind=Bucket \
 			--filter resource=Dashboard:$DASHBOARD_TMPL_NAME
 
+		# Apply a template with values for its parameters, read from a YAML
+		# file of parameter names to values and from the command line, which
+		# takes precedence.
+		influx apply \
+			-f $PATH_TO_TEMPLATE/template.yml \
+			--param-file $PATH_TO_PARAMS/staging.yml \
+			--param bucket=telegraf-staging
+
+	Templates may declare parameters in an object of kind Parameters, with a
+	name, a type (string, int or duration) and an optional default each:
+
+		apiVersion: influxdata.com/v2alpha1
+		kind: Parameters
+		metadata:
+		  name: params
+		spec:
+		  parameters:
+		    - name: bucket
+		      type: string
+		      default: telegraf
+		    - name: every
+		      type: duration
+		      default: 1m
+
+	Every ${param:NAME} in a string value of the template is substituted with
+	the value of the parameter NAME, or its default, before the template is
+	applied. A value that is only a reference to an int parameter becomes a
+	number. Nothing is applied if a referenced parameter has no value or a
+	value is not of the type of its parameter.
+
 	For information about finding and using InfluxDB templates, see
 	https://v2.docs.influxdata.com/v2.0/reference/cli/influx/apply/.
 
@@ -182,6 +216,8 @@ func (b *cmdTemplateBuilder) cmdApply() *cobra.Command {
 	b.applyOpts.secrets = []string{}
 	cmd.Flags().StringSliceVar(&b.applyOpts.secrets, "secret", nil, "Secrets to provide alongside the template; format should --secret=SECRET_KEY=SECRET_VALUE --secret=SECRET_KEY_2=SECRET_VALUE_2")
 	cmd.Flags().StringSliceVar(&b.applyOpts.envRefs, "env-ref", nil, "Environment references to provide alongside the template; format should --env-ref=REF_KEY=REF_VALUE --env-ref=REF_KEY_2=REF_VALUE_2")
+	cmd.Flags().StringArrayVar(&b.applyOpts.params, "param", nil, "Values of the parameters of the template; format should --param=NAME=VALUE --param=NAME_2=VALUE_2")
+	cmd.Flags().StringVar(&b.applyOpts.paramFile, "param-file", "", "Path to a YAML or JSON file of parameter names to values; --param takes precedence")
 	cmd.Flags().StringSliceVar(&b.filters, "filter", nil, "Resources to skip when applying the template. Filter out by ‘kind’ or by ‘resource’")
 
 	return cmd
@@ -339,6 +375,10 @@ func (b *cmdTemplateBuilder) cmdExport() *cobra.Command {
 		# export a stack with resources not associated with the stack
 		influx export --stack-id $STACK_ID --buckets $BUCKET_ID
 
+		# export buckets and dashboards with the bucket names as parameters,
+		# so they can be applied with other bucket names
+		influx export --buckets=$BID1 --dashboards=$DID1 --parameterize bucket-names
+
 	All of the resources are supported via the examples provided above. Provide the
 	resource flag and then provide the IDs.
 
@@ -371,6 +411,7 @@ func (b *cmdTemplateBuilder) cmdExport() *cobra.Command {
 	cmd.Flags().StringVar(&b.exportOpts.taskNames, "task-names", "", "List of task names comma separated")
 	cmd.Flags().StringVar(&b.exportOpts.telegrafNames, "telegraf-config-names", "", "List of telegraf config names comma separated")
 	cmd.Flags().StringVar(&b.exportOpts.variableNames, "variable-names", "", "List of variable names comma separated")
+	b.registerExportParameterizeFlag(cmd)
 
 	return cmd
 }
@@ -479,6 +520,7 @@ func (b *cmdTemplateBuilder) cmdExportAll() *cobra.Command {
 
 	cmd.Flags().StringVarP(&b.file, "file", "f", "", "output file for created template; defaults to std out if no file provided; the extension of provided file (.yml/.json) will dictate encoding")
 	cmd.Flags().StringArrayVar(&b.filters, "filter", nil, "Filter exported resources by labelName or resourceKind (format: --filter=labelName=example)")
+	b.registerExportParameterizeFlag(cmd)
 
 	b.org.register(b.viper, cmd, false)
 
@@ -547,6 +589,7 @@ func (b *cmdTemplateBuilder) cmdExportStack() *cobra.Command {
 	cmd.Args = cobra.ExactValidArgs(1)
 
 	cmd.Flags().StringVarP(&b.file, "file", "f", "", "output file for created template; defaults to std out if no file provided; the extension of provided file (.yml/.json) will dictate encoding")
+	b.registerExportParameterizeFlag(cmd)
 	b.org.register(b.viper, cmd, false)
 
 	return cmd
@@ -990,11 +1033,24 @@ func (b *cmdTemplateBuilder) registerTemplateFileFlags(cmd *cobra.Command) {
 	cmd.MarkFlagFilename("encoding", "yaml", "yml", "json", "jsonnet")
 }
 
+func (b *cmdTemplateBuilder) registerExportParameterizeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&b.exportOpts.parameterize, "parameterize", nil, "References to lift into parameters of the exported template; supports: bucket-names")
+}
+
 func (b *cmdTemplateBuilder) exportTemplate(w io.Writer, templateSVC pkger.SVC, outPath string, opts ...pkger.ExportOptFn) error {
+	for _, p := range b.exportOpts.parameterize {
+		if p != "bucket-names" {
+			return fmt.Errorf("invalid --parameterize value %q; must be 1 in [bucket-names]", p)
+		}
+	}
+
 	template, err := templateSVC.Export(context.Background(), opts...)
 	if err != nil {
 		return err
 	}
+	if len(b.exportOpts.parameterize) > 0 {
+		template.ParameterizeBucketNames()
+	}
 
 	return b.writeTemplate(w, outPath, template)
 }
@@ -1027,7 +1083,7 @@ func (b *cmdTemplateBuilder) readRawTemplatesFromFiles(filePaths []string, recur
 
 	var rawTemplates []*pkger.Template
 	for f := range mFiles {
-		template, err := pkger.Parse(b.convertFileEncoding(f), pkger.FromFile(f), pkger.ValidSkipParseError())
+		template, err := pkger.Parse(b.convertFileEncoding(f), pkger.FromFile(f), pkger.ValidSkipParseError(), pkger.ValidDeferParams())
 		if err != nil {
 			return nil, err
 		}
@@ -1045,7 +1101,7 @@ func (b *cmdTemplateBuilder) readRawTemplatesFromURLs(urls []string) ([]*pkger.T
 
 	var rawTemplates []*pkger.Template
 	for u := range mURLs {
-		template, err := pkger.Parse(b.convertURLEncoding(u), pkger.FromHTTPRequest(u), pkger.ValidSkipParseError())
+		template, err := pkger.Parse(b.convertURLEncoding(u), pkger.FromHTTPRequest(u), pkger.ValidSkipParseError(), pkger.ValidDeferParams())
 		if err != nil {
 			return nil, err
 		}
@@ -1055,6 +1111,11 @@ func (b *cmdTemplateBuilder) readRawTemplatesFromURLs(urls []string) ([]*pkger.T
 }
 
 func (b *cmdTemplateBuilder) readTemplate() (*pkger.Template, bool, error) {
+	params, err := b.templateParams()
+	if err != nil {
+		return nil, false, err
+	}
+
 	var remotes, files []string
 	for _, rawURL := range append(b.files, b.urls...) {
 		u, err := url.Parse(rawURL)
@@ -1082,22 +1143,56 @@ func (b *cmdTemplateBuilder) readTemplate() (*pkger.Template, bool, error) {
 	// the pkger.ValidSkipParseError option allows our server to be the one to validate the
 	// the template is accurate. If a user has an older version of the CLI and cloud gets updated
 	// with new validation rules,they'll get immediate access to that change without having to
-	// rol their CLI build.
+	// rol their CLI build. The parameters are resolved once the templates are combined,
+	// as one template may declare the parameters of another.
 
 	if _, err := inStdIn(b.in); err != nil {
-		template, err := pkger.Combine(templates, pkger.ValidSkipParseError())
+		template, err := pkger.Combine(templates, pkger.ValidSkipParseError(), pkger.ValidWithParams(params))
 		return template, false, err
 	}
 
-	stdinTemplate, err := pkger.Parse(b.convertEncoding(), pkger.FromReader(b.in), pkger.ValidSkipParseError())
+	stdinTemplate, err := pkger.Parse(b.convertEncoding(), pkger.FromReader(b.in), pkger.ValidSkipParseError(), pkger.ValidDeferParams())
 	if err != nil {
 		return nil, true, err
 	}
 
-	template, err := pkger.Combine(append(templates, stdinTemplate), pkger.ValidSkipParseError())
+	template, err := pkger.Combine(append(templates, stdinTemplate), pkger.ValidSkipParseError(), pkger.ValidWithParams(params))
 	return template, true, err
 }
 
+// templateParams returns the values of the parameters of the template, read
+// from --param-file and --param, which takes precedence.
+func (b *cmdTemplateBuilder) templateParams() (map[string]string, error) {
+	params := make(map[string]string)
+	if b.applyOpts.paramFile != "" {
+		buf, err := ioutil.ReadFile(b.applyOpts.paramFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parameter file: %w", err)
+		}
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(buf, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse parameter file %q: %w", b.applyOpts.paramFile, err)
+		}
+		for name, v := range values {
+			switch v.(type) {
+			case string, int, float64, bool:
+				params[name] = fmt.Sprint(v)
+			default:
+				return nil, fmt.Errorf("invalid value of parameter %q in parameter file %q; must be a string or a number", name, b.applyOpts.paramFile)
+			}
+		}
+	}
+
+	for _, p := range b.applyOpts.params {
+		pair := strings.SplitN(p, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return nil, fmt.Errorf("invalid parameter %q; format should be --param=NAME=VALUE", p)
+		}
+		params[pair[0]] = pair[1]
+	}
+	return params, nil
+}
+
 func (b *cmdTemplateBuilder) readLines(r io.Reader) ([]string, error) {
 	bb, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -639,6 +639,44 @@ func Test_Template_Commands(t *testing.T) {
 	})
 }
 
+func Test_templateParams(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	paramFile := filepath.Join(dir, "params.yml")
+	require.NoError(t, ioutil.WriteFile(paramFile, []byte("bucket: telegraf\nretention: 3600\nevery: 1m\n"), os.ModePerm))
+
+	b := newCmdPkgerBuilder(nil, &globalFlags{}, genericCLIOpts{})
+	b.applyOpts.paramFile = paramFile
+	b.applyOpts.params = []string{"bucket=telegraf=staging", "owner="}
+
+	params, err := b.templateParams()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"bucket":    "telegraf=staging",
+		"retention": "3600",
+		"every":     "1m",
+		"owner":     "",
+	}, params)
+
+	t.Run("invalid param", func(t *testing.T) {
+		b := newCmdPkgerBuilder(nil, &globalFlags{}, genericCLIOpts{})
+		b.applyOpts.params = []string{"bucket"}
+		_, err := b.templateParams()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--param=NAME=VALUE")
+	})
+
+	t.Run("invalid param file", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(paramFile, []byte("bucket: [a, b]\n"), os.ModePerm))
+		b := newCmdPkgerBuilder(nil, &globalFlags{}, genericCLIOpts{})
+		b.applyOpts.paramFile = paramFile
+		_, err := b.templateParams()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `parameter "bucket"`)
+	})
+}
+
 func Test_readFilesFromPath(t *testing.T) {
 	t.Run("single file", func(t *testing.T) {
 		dir := newTempDir(t)
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/spf13/cobra"
+)
+
+func cmdUsage(f *globalFlags, opts genericCLIOpts) *cobra.Command {
+	return newCmdUsageBuilder(f, opts).cmd()
+}
+
+type cmdUsageBuilder struct {
+	genericCLIOpts
+	*globalFlags
+
+	bucketID string
+	org      organization
+	window   time.Duration
+
+	usageService influxdb.UsageReportService
+	orgService   influxdb.OrganizationService
+	now          func() time.Time
+}
+
+func newCmdUsageBuilder(f *globalFlags, opts genericCLIOpts) *cmdUsageBuilder {
+	return &cmdUsageBuilder{
+		genericCLIOpts: opts,
+		globalFlags:    f,
+		now:            time.Now,
+	}
+}
+
+func (b *cmdUsageBuilder) cmd() *cobra.Command {
+	cmd := b.genericCLIOpts.newCmd("usage", b.usageRunE, true)
+	b.genericCLIOpts.registerPrintOptions(cmd)
+	b.globalFlags.registerFlags(b.viper, cmd)
+	b.org.register(b.viper, cmd, false)
+	cmd.Flags().StringVar(&b.bucketID, "bucket-id", "", "The ID of the bucket to report")
+	cmd.Flags().DurationVar(&b.window, "window", time.Hour, "How far back to count writes for the write throughput")
+	cmd.Short = "Report storage and write usage of organizations and buckets"
+	cmd.Long = `
+Reports the series count and disk size of buckets, and the writes they received
+over a recent window, grouped by organization. Only buckets readable with the
+token are reported. The server keeps write counts for the last 24 hours; the
+window actually covered is printed with the report.
+
+Examples:
+	# report all readable buckets
+	influx usage
+
+	# report the buckets of an organization over the last 15 minutes
+	influx usage --org my-org --window 15m
+`
+	return cmd
+}
+
+func (b *cmdUsageBuilder) usageRunE(cmd *cobra.Command, args []string) error {
+	ac := b.config()
+	if b.usageService == nil {
+		b.usageService = &http.UsageService{
+			Addr:               ac.Host,
+			Token:              ac.Token,
+			InsecureSkipVerify: b.skipVerify,
+		}
+	}
+	if b.orgService == nil {
+		client, err := newHTTPClient()
+		if err != nil {
+			return err
+		}
+		b.orgService = &tenant.OrgClientService{Client: client}
+	}
+
+	report, err := b.usage(context.Background())
+	if err != nil {
+		return err
+	}
+	return b.printReport(report)
+}
+
+// usage requests the usage report for the flags.
+func (b *cmdUsageBuilder) usage(ctx context.Context) (*influxdb.UsageReport, error) {
+	if b.window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+
+	var filter influxdb.UsageFilter
+	if b.org.id != "" || b.org.name != "" {
+		orgID, err := b.org.getID(b.orgService)
+		if err != nil {
+			return nil, err
+		}
+		filter.OrgID = &orgID
+	}
+	if b.bucketID != "" {
+		id, err := influxdb.IDFromString(b.bucketID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode bucket id %q: %v", b.bucketID, err)
+		}
+		filter.BucketID = id
+	}
+	now := b.now().UTC()
+	filter.Range = &influxdb.Timespan{Start: now.Add(-b.window), Stop: now}
+
+	report, err := b.usageService.UsageReport(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve usage: %v", err)
+	}
+	return report, nil
+}
+
+func (b *cmdUsageBuilder) printReport(report *influxdb.UsageReport) error {
+	if b.json {
+		return b.writeJSON(report)
+	}
+
+	fmt.Fprintf(b.w, "Writes counted from %s to %s\n",
+		report.Range.Start.Format(time.RFC3339), report.Range.Stop.Format(time.RFC3339))
+
+	w := b.newTabWriter()
+	defer w.Flush()
+
+	w.HideHeaders(b.hideHeaders)
+	w.WriteHeaders("Organization", "Bucket", "Bucket ID", "Series", "Disk Bytes", "Write Requests", "Points Written", "Points/s")
+	row := func(org, bucket, bucketID string, seriesN, diskBytes, requests, points int64) {
+		w.Write(map[string]interface{}{
+			"Organization":   org,
+			"Bucket":         bucket,
+			"Bucket ID":      bucketID,
+			"Series":         seriesN,
+			"Disk Bytes":     diskBytes,
+			"Write Requests": requests,
+			"Points Written": points,
+			"Points/s":       fmt.Sprintf("%.2f", report.PerSecond(points)),
+		})
+	}
+	for _, o := range report.Orgs {
+		for _, u := range o.Buckets {
+			row(o.Org, u.Bucket, u.BucketID.String(), u.SeriesN, u.DiskBytes, u.WriteRequests, u.PointsWritten)
+		}
+		row(o.Org, "total", "", o.SeriesN, o.DiskBytes, o.WriteRequests, o.PointsWritten)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsageService struct {
+	filter influxdb.UsageFilter
+	report *influxdb.UsageReport
+}
+
+func (s *fakeUsageService) UsageReport(ctx context.Context, filter influxdb.UsageFilter) (*influxdb.UsageReport, error) {
+	s.filter = filter
+	return s.report, nil
+}
+
+func TestCmdUsage(t *testing.T) {
+	now := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)
+	org := influxdb.OrgUsage{OrgID: 1, Org: "my-org", Buckets: []influxdb.BucketUsage{}}
+	org.Add(influxdb.BucketUsage{OrgID: 1, BucketID: 2, Bucket: "cpu", SeriesN: 10, DiskBytes: 100, WriteRequests: 6, PointsWritten: 360})
+	org.Add(influxdb.BucketUsage{OrgID: 1, BucketID: 3, Bucket: "mem", SeriesN: 5, DiskBytes: 50})
+
+	usageSvc := &fakeUsageService{report: &influxdb.UsageReport{
+		Range: influxdb.Timespan{Start: now.Add(-time.Hour), Stop: now},
+		Orgs:  []influxdb.OrgUsage{org},
+	}}
+	orgSvc := mock.NewOrganizationService()
+	orgSvc.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return &influxdb.Organization{ID: 1, Name: *filter.Name}, nil
+	}
+
+	out := new(bytes.Buffer)
+	b := newCmdUsageBuilder(&globalFlags{}, genericCLIOpts{w: out})
+	b.usageService = usageSvc
+	b.orgService = orgSvc
+	b.now = func() time.Time { return now }
+	b.org.name = "my-org"
+	b.bucketID = influxdb.ID(2).String()
+	b.window = 15 * time.Minute
+
+	report, err := b.usage(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, b.printReport(report))
+
+	require.NotNil(t, usageSvc.filter.OrgID)
+	assert.Equal(t, influxdb.ID(1), *usageSvc.filter.OrgID)
+	require.NotNil(t, usageSvc.filter.BucketID)
+	assert.Equal(t, influxdb.ID(2), *usageSvc.filter.BucketID)
+	assert.Equal(t, &influxdb.Timespan{Start: now.Add(-15 * time.Minute), Stop: now}, usageSvc.filter.Range)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "Writes counted from 2020-01-01T00:00:00Z to 2020-01-01T01:00:00Z", lines[0])
+	assert.Equal(t, []string{"my-org", "cpu", "0000000000000002", "10", "100", "6", "360", "0.10"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"my-org", "total", "15", "150", "6", "360", "0.10"}, strings.Fields(lines[4]))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -45,6 +46,7 @@ type writeFlagsType struct {
 	Encoding                   string
 	ErrorsFile                 string
 	RateLimit                  string
+	Duplicates                 string
 }
 
 var writeFlags writeFlagsType
@@ -96,11 +98,16 @@ func cmdWrite(f *globalFlags, opt genericCLIOpts) *cobra.Command {
 	cmd.PersistentFlags().StringVar(&writeFlags.Encoding, "encoding", "UTF-8", "Character encoding of input files or stdin")
 	cmd.PersistentFlags().StringVar(&writeFlags.ErrorsFile, "errors-file", "", "The path to the file to write rejected rows to")
 	cmd.PersistentFlags().StringVar(&writeFlags.RateLimit, "rate-limit", "", "Throttles write, examples: \"5 MB / 5 min\" , \"17kBs\". \"\" (default) disables throttling.")
+	cmd.PersistentFlags().StringVar(&writeFlags.Duplicates, "duplicates", "", "What to do with lines holding a tag or a field key more than once: reject, first or last. By default, lines with duplicate tags fail and the last value of duplicate fields is kept")
 
 	cmdDryRun := opt.newCmd("dryrun", fluxWriteDryrunF, false)
 	cmdDryRun.Args = cobra.MaximumNArgs(1)
 	cmdDryRun.Short = "Write to stdout instead of InfluxDB"
-	cmdDryRun.Long = `Write protocol lines to stdout instead of InfluxDB. Troubleshoot conversion from CSV to line protocol.`
+	cmdDryRun.Long = `Write protocol lines to stdout instead of InfluxDB. Troubleshoot conversion from CSV to line protocol.
+
+With --duplicates, the lines are written as the server would write them: the
+duplicate tags and fields are resolved, and the lines --duplicates=reject
+rejects are left empty and reported in the error of the command.`
 	f.registerFlags(opt.viper, cmdDryRun)
 	cmd.AddCommand(cmdDryRun)
 	return cmd
@@ -279,6 +286,10 @@ func fluxWriteF(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid precision")
 	}
 
+	if _, err := models.ParseDuplicateAction(writeFlags.Duplicates); err != nil {
+		return err
+	}
+
 	var (
 		filter platform.BucketFilter
 		err    error
@@ -323,6 +334,7 @@ func fluxWriteF(cmd *cobra.Command, args []string) error {
 			Token:              ac.Token,
 			Precision:          writeFlags.Precision,
 			InsecureSkipVerify: flags.skipVerify,
+			Duplicates:         writeFlags.Duplicates,
 		},
 		MaxLineLength: writeFlags.MaxLineLength,
 	}
@@ -335,6 +347,10 @@ func fluxWriteF(cmd *cobra.Command, args []string) error {
 
 func fluxWriteDryrunF(cmd *cobra.Command, args []string) error {
 	writeFlags.dump(args) // print flags when in Debug mode
+	duplicates, err := models.ParseDuplicateAction(writeFlags.Duplicates)
+	if err != nil {
+		return err
+	}
 	// create line reader
 	ctx := signals.WithStandardSignals(context.Background())
 	r, closer, err := writeFlags.createLineReader(ctx, cmd, args)
@@ -345,6 +361,9 @@ func fluxWriteDryrunF(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	// dry run
+	if duplicates != models.DuplicatesDefault {
+		return writeResolvedDuplicates(cmd.OutOrStdout(), r, duplicates)
+	}
 	_, err = io.Copy(cmd.OutOrStdout(), r)
 	if err != nil {
 		return fmt.Errorf("failed: %v", err)
@@ -352,6 +371,28 @@ func fluxWriteDryrunF(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// writeResolvedDuplicates writes the lines of r to w with their duplicate
+// tags and fields resolved as the server would, and fails with the lines
+// rejected, if any.
+func writeResolvedDuplicates(w io.Writer, r io.Reader, action models.DuplicateAction) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed: %v", err)
+	}
+	resolved, rejected := models.ResolveDuplicates(buf, action)
+	if _, err := w.Write(resolved); err != nil {
+		return fmt.Errorf("failed: %v", err)
+	}
+	if len(rejected) > 0 {
+		reasons := make([]string, len(rejected))
+		for i, d := range rejected {
+			reasons[i] = d.String()
+		}
+		return fmt.Errorf("lines with duplicate tags or fields would be rejected: %s", strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
 // IsCharacterDevice returns true if the supplied reader is a character device (a terminal)
 func isCharacterDevice(reader io.Reader) bool {
 	file, isFile := reader.(*os.File)
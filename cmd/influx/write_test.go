@@ -369,6 +369,36 @@ func Test_fluxWriteDryrunF(t *testing.T) {
 		require.NotNil(t, err)
 		require.Contains(t, fmt.Sprintf("%s", err), "measurement") // no measurement column
 	})
+
+	t.Run("dryrun resolves duplicates as the server would", func(t *testing.T) {
+		stdInContents := "m,host=a,host=b v=1,v=2 1\nm,host=c v=3 2"
+		out := bytes.Buffer{}
+		command := cmdWrite(&globalFlags{}, genericCLIOpts{in: strings.NewReader(stdInContents), w: &out, viper: viper.New()})
+		command.SetArgs([]string{"dryrun", "--format", "lp", "--duplicates", "first"})
+		err := command.Execute()
+		require.Nil(t, err)
+		require.Equal(t, "m,host=a v=1 1\nm,host=c v=3 2", strings.Trim(out.String(), "\n"))
+	})
+
+	t.Run("dryrun fails on lines rejected for duplicates", func(t *testing.T) {
+		stdInContents := "m,host=a,host=b v=1 1\nm,host=c v=3,v=4 2\nm,host=d v=5 3"
+		out := bytes.Buffer{}
+		command := cmdWrite(&globalFlags{}, genericCLIOpts{in: strings.NewReader(stdInContents), w: &out, viper: viper.New()})
+		command.SetArgs([]string{"dryrun", "--format", "lp", "--duplicates", "reject"})
+		err := command.Execute()
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "line 1: duplicate tag host; line 2: duplicate field v")
+		// The rejected lines are left empty, followed by the usage and error.
+		require.True(t, strings.HasPrefix(out.String(), "\n\nm,host=d v=5 3"), out.String())
+	})
+
+	t.Run("dryrun fails on invalid --duplicates", func(t *testing.T) {
+		command := cmdWrite(&globalFlags{}, genericCLIOpts{in: strings.NewReader("m v=1"), w: ioutil.Discard, viper: viper.New()})
+		command.SetArgs([]string{"dryrun", "--format", "lp", "--duplicates", "newest"})
+		err := command.Execute()
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), `invalid duplicates "newest"`)
+	})
 }
 
 // Test_fluxWriteF tests validation and processing of input flags in fluxWriteF
@@ -361,8 +361,14 @@ func (v Verify) VerifyIndex(indexPath string, segments []*tsdb.SeriesSegment,
 			return false, nil
 		}
 
-		// do not perform any other checks if the id is deleted.
+		// do not perform any other checks if the id is deleted, but make sure
+		// the tombstone is for a series that was inserted.
 		if IDData.Deleted {
+			if IDData.Key == nil {
+				v.Logger.Error("Tombstone for unknown series",
+					zap.Uint64("id", id))
+				return false, nil
+			}
 			continue
 		}
 
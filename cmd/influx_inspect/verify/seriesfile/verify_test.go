@@ -59,6 +59,36 @@ func TestVerifies_Invalid(t *testing.T) {
 	}))
 }
 
+func TestVerifies_UnknownTombstone(t *testing.T) {
+	test := NewTest(t)
+	defer test.Close()
+
+	// Append a tombstone for a series that was never inserted after the last
+	// entry of the first segment of a partition.
+	path := filepath.Join(test.Path, "00", "0000")
+	data, err := ioutil.ReadFile(path)
+	test.AssertNoError(err)
+	offset := int64(tsdb.SeriesSegmentHeaderSize)
+	for offset < int64(len(data)) {
+		flag, _, _, sz := tsdb.ReadSeriesEntry(data[offset:])
+		if flag == 0 {
+			break
+		}
+		offset += sz
+	}
+
+	fh, err := os.OpenFile(path, os.O_RDWR, 0)
+	test.AssertNoError(err)
+	defer fh.Close()
+	_, err = fh.WriteAt(tsdb.AppendSeriesEntry(nil, tsdb.SeriesEntryTombstoneFlag, 1<<40, nil), offset)
+	test.AssertNoError(err)
+	test.AssertNoError(fh.Close())
+
+	passed, err := seriesfile.NewVerify().VerifySeriesFile(test.Path)
+	test.AssertNoError(err)
+	test.Assert(!passed)
+}
+
 //
 // helpers
 //
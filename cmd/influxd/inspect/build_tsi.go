@@ -0,0 +1,248 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/influx_inspect/buildtsi"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/pkg/file"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+type buildTSIOptions struct {
+	dataPath     string
+	walPath      string
+	bucketID     string
+	shardIDs     []uint
+	batchSize    int
+	maxCacheSize uint64
+	concurrency  int
+	verbose      bool
+}
+
+func NewBuildTSICommand() *cobra.Command {
+	var opts buildTSIOptions
+
+	cmd := &cobra.Command{
+		Use:   `build-tsi`,
+		Short: "Rebuilds the TSI index of shards from their TSM files and WAL",
+		Long: `
+This command deletes the TSI index of each shard under the data path, or of
+the shards given with --shard-id, and rebuilds it from the TSM files of the
+shard and its WAL.
+
+Shards are expected in the layout of the storage engine, at
+<bucket-id>/<retention-policy>/<shard-id> below the data and WAL paths.
+
+The series file of a bucket is shared by its shards, so the command refuses to
+run while influxd has any shard of a bucket to rebuild open. It should be run
+as the user influxd runs as, so that the index files are accessible to it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBuildTSI(cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine", "data"), "Path to the TSM data directory of the storage engine")
+	cmd.Flags().StringVar(&opts.walPath, "wal-path", filepath.Join(dir, "engine", "wal"), "Path to the WAL directory of the storage engine")
+	cmd.Flags().StringVar(&opts.bucketID, "bucket-id", "", "Only rebuild the shards of this bucket")
+	cmd.Flags().UintSliceVar(&opts.shardIDs, "shard-id", nil, "Only rebuild these shards; may be repeated")
+	cmd.Flags().IntVar(&opts.batchSize, "batch-size", 10000, "The number of series written to the index at a time")
+	cmd.Flags().Uint64Var(&opts.maxCacheSize, "max-cache-size", tsdb.DefaultCacheMaxMemorySize, "The maximum size, in bytes, of the cache the WAL of a shard is loaded into")
+	cmd.Flags().IntVar(&opts.concurrency, "concurrency", runtime.GOMAXPROCS(0), "The number of shards to rebuild concurrently")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Log each series indexed")
+
+	return cmd
+}
+
+// tsiShard is a shard whose index is to be rebuilt or read.
+type tsiShard struct {
+	id       uint64
+	rp       string
+	dataPath string
+	walPath  string
+}
+
+// tsiBucket is a bucket with shards whose index is to be rebuilt or read.
+type tsiBucket struct {
+	id   string
+	path string
+	// shards holds the selected shards and all are all of the shards of the
+	// bucket, which share its series file.
+	shards []tsiShard
+	all    []tsiShard
+}
+
+func runBuildTSI(w io.Writer, opts buildTSIOptions) error {
+	if opts.batchSize <= 0 {
+		return errors.New("--batch-size must be positive")
+	} else if opts.concurrency <= 0 {
+		return errors.New("--concurrency must be positive")
+	}
+	if opts.bucketID != "" {
+		if _, err := influxdb.IDFromString(opts.bucketID); err != nil {
+			return fmt.Errorf("invalid bucket ID %q: %w", opts.bucketID, err)
+		}
+	}
+
+	buckets, err := findTSIBuckets(opts.dataPath, opts.walPath, opts.bucketID, opts.shardIDs)
+	if err != nil {
+		return err
+	}
+	if len(buckets) == 0 {
+		return errors.New("no shards found")
+	}
+
+	// Every shard of the buckets is locked before any index is deleted, so
+	// that nothing is modified while influxd is running.
+	var locks []*os.File
+	defer func() {
+		for _, lock := range locks {
+			lock.Close()
+		}
+	}()
+	for _, bkt := range buckets {
+		for _, sh := range bkt.all {
+			lock, err := file.TryLock(filepath.Join(sh.dataPath, tsdb.ShardLockFile))
+			if err == file.ErrLocked {
+				return fmt.Errorf("shard %d of bucket %s is open in a running influxd; stop it before rebuilding the index", sh.id, bkt.id)
+			} else if err != nil {
+				return err
+			}
+			locks = append(locks, lock)
+		}
+	}
+
+	log := logger.New(w)
+	for _, bkt := range buckets {
+		if err := buildBucketTSI(log, opts, bkt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findTSIBuckets returns the buckets under the data path with the given
+// shards, or with any shards if none are given. A bucket ID limits them to
+// that bucket.
+func findTSIBuckets(dataPath, walPath, bucketID string, shardIDs []uint) ([]*tsiBucket, error) {
+	ids := make(map[uint64]bool, len(shardIDs))
+	for _, id := range shardIDs {
+		ids[uint64(id)] = true
+	}
+
+	bucketDirs, err := ioutil.ReadDir(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []*tsiBucket
+	found := make(map[uint64]bool)
+	for _, bucketDir := range bucketDirs {
+		if !bucketDir.IsDir() {
+			continue
+		} else if _, err := influxdb.IDFromString(bucketDir.Name()); err != nil {
+			continue
+		} else if bucketID != "" && bucketDir.Name() != bucketID {
+			continue
+		}
+
+		bkt := &tsiBucket{id: bucketDir.Name(), path: filepath.Join(dataPath, bucketDir.Name())}
+		rpDirs, err := ioutil.ReadDir(bkt.path)
+		if err != nil {
+			return nil, err
+		}
+		for _, rpDir := range rpDirs {
+			if !rpDir.IsDir() || rpDir.Name() == tsdb.SeriesFileDirectory {
+				continue
+			}
+			shardDirs, err := ioutil.ReadDir(filepath.Join(bkt.path, rpDir.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, shardDir := range shardDirs {
+				id, err := strconv.ParseUint(shardDir.Name(), 10, 64)
+				if err != nil || !shardDir.IsDir() {
+					continue
+				}
+				sh := tsiShard{
+					id:       id,
+					rp:       rpDir.Name(),
+					dataPath: filepath.Join(bkt.path, rpDir.Name(), shardDir.Name()),
+					walPath:  filepath.Join(walPath, bkt.id, rpDir.Name(), shardDir.Name()),
+				}
+				bkt.all = append(bkt.all, sh)
+				if len(ids) == 0 || ids[id] {
+					bkt.shards = append(bkt.shards, sh)
+					found[id] = true
+				}
+			}
+		}
+		if len(bkt.shards) > 0 {
+			sort.Slice(bkt.shards, func(i, j int) bool { return bkt.shards[i].id < bkt.shards[j].id })
+			buckets = append(buckets, bkt)
+		}
+	}
+
+	for id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("shard %d not found", id)
+		}
+	}
+	return buckets, nil
+}
+
+// buildBucketTSI rebuilds the index of the shards of a bucket, up to
+// opts.concurrency at a time.
+func buildBucketTSI(log *zap.Logger, opts buildTSIOptions, bkt *tsiBucket) error {
+	log = log.With(zap.String("bucket_id", bkt.id))
+	log.Info("Rebuilding bucket", zap.Int("shards", len(bkt.shards)))
+
+	sfile := tsdb.NewSeriesFile(filepath.Join(bkt.path, tsdb.SeriesFileDirectory))
+	sfile.Logger = log
+	if err := sfile.Open(); err != nil {
+		return err
+	}
+	defer sfile.Close()
+
+	work := make(chan tsiShard, len(bkt.shards))
+	for _, sh := range bkt.shards {
+		work <- sh
+	}
+	close(work)
+
+	var g errgroup.Group
+	for i := 0; i < opts.concurrency; i++ {
+		g.Go(func() error {
+			for sh := range work {
+				shardLog := log.With(logger.RetentionPolicy(sh.rp), logger.Shard(sh.id))
+				if err := os.RemoveAll(filepath.Join(sh.dataPath, "index")); err != nil {
+					return err
+				}
+				if err := buildtsi.IndexShard(sfile, sh.dataPath, sh.walPath, tsdb.DefaultMaxIndexLogFileSize, opts.maxCacheSize, opts.batchSize, shardLog, opts.verbose); err != nil {
+					return fmt.Errorf("cannot rebuild index of shard %d: %w", sh.id, err)
+				}
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
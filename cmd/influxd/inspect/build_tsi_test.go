@@ -0,0 +1,80 @@
+package inspect
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/pkg/file"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTSI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "build-tsi-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const bucketID = "0000000000000001"
+	dataPath, walPath := filepath.Join(dir, "data"), filepath.Join(dir, "wal")
+	bucketPath := filepath.Join(dataPath, bucketID)
+	shardPath := filepath.Join(bucketPath, "autogen", "1")
+	writeTSMFile(t, filepath.Join(shardPath, "000000001-000000001.tsm"), "cpu,host=a#!~#idle", "mem,host=a#!~#free")
+	writeTSMFile(t, filepath.Join(bucketPath, "autogen", "2", "000000001-000000001.tsm"), "disk,host=a#!~#used")
+
+	// A corrupt index is replaced.
+	require.NoError(t, os.MkdirAll(filepath.Join(shardPath, "index"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(shardPath, "index", "corrupt"), []byte("corrupt"), 0666))
+
+	opts := buildTSIOptions{
+		dataPath:     dataPath,
+		walPath:      walPath,
+		shardIDs:     []uint{1},
+		batchSize:    10,
+		maxCacheSize: tsdb.DefaultCacheMaxMemorySize,
+		concurrency:  2,
+	}
+
+	t.Run("rebuilds the index", func(t *testing.T) {
+		require.NoError(t, runBuildTSI(ioutil.Discard, opts))
+
+		_, err := os.Stat(filepath.Join(shardPath, "index", "corrupt"))
+		assert.True(t, os.IsNotExist(err))
+		// Only the given shard is rebuilt.
+		_, err = os.Stat(filepath.Join(bucketPath, "autogen", "2", "index"))
+		assert.True(t, os.IsNotExist(err))
+
+		sfile := tsdb.NewSeriesFile(filepath.Join(bucketPath, tsdb.SeriesFileDirectory))
+		require.NoError(t, sfile.Open())
+		defer sfile.Close()
+		idx := tsi1.NewIndex(sfile, bucketID, tsi1.WithPath(filepath.Join(shardPath, "index")))
+		require.NoError(t, idx.Open())
+		defer idx.Close()
+		for name, want := range map[string]bool{"cpu": true, "mem": true, "disk": false} {
+			exists, err := idx.MeasurementExists([]byte(name))
+			require.NoError(t, err)
+			assert.Equal(t, want, exists, name)
+		}
+	})
+
+	t.Run("refuses to run while a shard of the bucket is open", func(t *testing.T) {
+		lock, err := file.TryLock(filepath.Join(bucketPath, "autogen", "2", tsdb.ShardLockFile))
+		require.NoError(t, err)
+		defer lock.Close()
+
+		err = runBuildTSI(ioutil.Discard, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "shard 2 of bucket "+bucketID+" is open in a running influxd")
+	})
+
+	t.Run("unknown shard", func(t *testing.T) {
+		o := opts
+		o.shardIDs = []uint{3}
+		err := runBuildTSI(ioutil.Discard, o)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "shard 3 not found")
+	})
+}
@@ -0,0 +1,257 @@
+package inspect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+type checkSchemaOptions struct {
+	dataPath      string
+	conflictsFile string
+}
+
+func NewCheckSchemaCommand() *cobra.Command {
+	var opts checkSchemaOptions
+
+	cmd := &cobra.Command{
+		Use:   `check-schema`,
+		Short: "Reports fields written with more than one type",
+		Long: `
+This command reads the index of each TSM file found under the data path and
+records the block types of each field of each measurement. Fields stored with
+more than one type, which make writes fail with a field type conflict, are
+reported with the bucket and shards holding each type.
+
+Only the index of each file is read, one file at a time, so engines of any
+size can be checked.
+
+With --conflicts-file, the conflicts are also written to a file as JSON.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCheckSchema(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine"), "Path to the storage engine directory, or a data, bucket or shard directory within it")
+	cmd.Flags().StringVar(&opts.conflictsFile, "conflicts-file", "", "Path to the file to write the conflicts to as JSON")
+
+	return cmd
+}
+
+// schemaConflicts is the JSON document of the conflicts written to the
+// conflicts file.
+type schemaConflicts struct {
+	Conflicts []fieldTypeConflict `json:"conflicts"`
+}
+
+// fieldTypeConflict is a field of a measurement stored with more than one
+// type.
+type fieldTypeConflict struct {
+	Measurement string           `json:"measurement"`
+	Field       string           `json:"field"`
+	Types       []fieldTypeShard `json:"types"`
+}
+
+// fieldTypeShard lists the shards a field is stored in with a type.
+type fieldTypeShard struct {
+	Type   string        `json:"type"`
+	Shards []schemaShard `json:"shards"`
+}
+
+// schemaShard is the location of a shard.
+type schemaShard struct {
+	BucketID string `json:"bucketID"`
+	ShardID  string `json:"shardID"`
+	Path     string `json:"path"`
+}
+
+// schemaField records the shard directories a field is stored in, by block
+// type.
+type schemaField struct {
+	measurement string
+	field       string
+	types       map[byte]map[string]struct{}
+}
+
+// schemaCheck accumulates the block types of the fields of TSM files.
+type schemaCheck struct {
+	// fields is keyed by measurement and field, separated by a zero byte.
+	fields map[string]*schemaField
+	files  int
+	buf    []byte
+}
+
+func newSchemaCheck() *schemaCheck {
+	return &schemaCheck{fields: make(map[string]*schemaField)}
+}
+
+// addFile reads the index of the TSM file at path into the check.
+func (c *schemaCheck) addFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	reader, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	defer reader.Close()
+
+	shardDir := filepath.Dir(path)
+	var entries []tsm1.IndexEntry
+	for i := 0; i < reader.KeyCount(); i++ {
+		var key []byte
+		var typ byte
+		key, typ, entries = reader.Key(i, &entries)
+		seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey(key)
+		name := models.ParseName(seriesKey)
+
+		c.buf = append(append(append(c.buf[:0], name...), 0), field...)
+		sf := c.fields[string(c.buf)]
+		if sf == nil {
+			sf = &schemaField{
+				measurement: string(name),
+				field:       string(field),
+				types:       make(map[byte]map[string]struct{}),
+			}
+			c.fields[string(c.buf)] = sf
+		}
+		shards := sf.types[typ]
+		if shards == nil {
+			shards = make(map[string]struct{})
+			sf.types[typ] = shards
+		}
+		shards[shardDir] = struct{}{}
+	}
+	c.files++
+	return nil
+}
+
+// conflicts returns the fields stored with more than one type, sorted by
+// measurement and field.
+func (c *schemaCheck) conflicts() []fieldTypeConflict {
+	conflicts := []fieldTypeConflict{}
+	for _, sf := range c.fields {
+		if len(sf.types) < 2 {
+			continue
+		}
+
+		conflict := fieldTypeConflict{Measurement: sf.measurement, Field: sf.field}
+		types := make([]int, 0, len(sf.types))
+		for typ := range sf.types {
+			types = append(types, int(typ))
+		}
+		sort.Ints(types)
+		for _, typ := range types {
+			dirs := make([]string, 0, len(sf.types[byte(typ)]))
+			for dir := range sf.types[byte(typ)] {
+				dirs = append(dirs, dir)
+			}
+			sort.Strings(dirs)
+
+			ts := fieldTypeShard{Type: blockTypeName(byte(typ))}
+			for _, dir := range dirs {
+				bucket, _, shard := shardDirLocation(dir)
+				ts.Shards = append(ts.Shards, schemaShard{BucketID: bucket, ShardID: shard, Path: dir})
+			}
+			conflict.Types = append(conflict.Types, ts)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Measurement != conflicts[j].Measurement {
+			return conflicts[i].Measurement < conflicts[j].Measurement
+		}
+		return conflicts[i].Field < conflicts[j].Field
+	})
+	return conflicts
+}
+
+func runCheckSchema(w io.Writer, opts checkSchemaOptions) error {
+	paths, err := tsmFilePaths(opts.dataPath, nil)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("no TSM files found")
+	}
+
+	check := newSchemaCheck()
+	for _, path := range paths {
+		if err := check.addFile(path); err != nil {
+			return err
+		}
+	}
+	conflicts := check.conflicts()
+
+	if opts.conflictsFile != "" {
+		if err := writeSchemaConflicts(opts.conflictsFile, conflicts); err != nil {
+			return err
+		}
+	}
+
+	printSchemaConflicts(w, conflicts)
+	fmt.Fprintf(w, "\nChecked %d fields in %d files, %d with type conflicts\n", len(check.fields), check.files, len(conflicts))
+	return nil
+}
+
+func writeSchemaConflicts(path string, conflicts []fieldTypeConflict) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(schemaConflicts{Conflicts: conflicts}); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func printSchemaConflicts(w io.Writer, conflicts []fieldTypeConflict) {
+	if len(conflicts) == 0 {
+		fmt.Fprintln(w, "No field type conflicts found")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 8, 2, 1, ' ', 0)
+	fmt.Fprintln(tw, "Measurement\tField\tType\tBucket\tShards")
+	for _, c := range conflicts {
+		for _, ts := range c.Types {
+			// The shards of a type are listed by bucket.
+			var buckets []string
+			shards := make(map[string][]string)
+			for _, sh := range ts.Shards {
+				if shards[sh.BucketID] == nil {
+					buckets = append(buckets, sh.BucketID)
+				}
+				shards[sh.BucketID] = append(shards[sh.BucketID], sh.ShardID)
+			}
+			for _, bucket := range buckets {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Measurement, c.Field, ts.Type, bucket, strings.Join(shards[bucket], ","))
+			}
+		}
+	}
+	_ = tw.Flush()
+}
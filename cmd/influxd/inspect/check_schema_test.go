@@ -0,0 +1,107 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTypedTSMFile writes a TSM file holding the value of each key at path.
+func writeTypedTSMFile(t *testing.T, path string, values map[string]interface{}) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := tsm1.NewTSMWriter(f)
+	require.NoError(t, err)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		require.NoError(t, w.Write([]byte(key), tsm1.Values{tsm1.NewValue(1e9, values[key])}))
+	}
+	require.NoError(t, w.WriteIndex())
+	require.NoError(t, w.Close())
+}
+
+func TestCheckSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "check-schema-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const bucketA, bucketB = "0000000000000001", "0000000000000002"
+	writeTypedTSMFile(t, filepath.Join(dir, "data", bucketA, "autogen", "1", "000000001-000000001.tsm"), map[string]interface{}{
+		"cpu,host=a#!~#usage": 1.5,
+		"cpu,host=b#!~#usage": 2.5,
+		"mem,host=a#!~#free":  int64(1),
+	})
+	writeTypedTSMFile(t, filepath.Join(dir, "data", bucketA, "autogen", "2", "000000001-000000001.tsm"), map[string]interface{}{
+		"cpu,host=a#!~#usage": int64(3),
+		"mem,host=a#!~#free":  int64(2),
+	})
+	writeTypedTSMFile(t, filepath.Join(dir, "data", bucketB, "autogen", "3", "000000001-000000001.tsm"), map[string]interface{}{
+		"cpu,host=c#!~#usage": "high",
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		path := filepath.Join(dir, "conflicts.json")
+		var out bytes.Buffer
+		require.NoError(t, runCheckSchema(&out, checkSchemaOptions{dataPath: dir, conflictsFile: path}))
+
+		var lines []string
+		for _, line := range strings.Split(out.String(), "\n") {
+			lines = append(lines, strings.Join(strings.Fields(line), " "))
+		}
+		assert.Contains(t, lines, "cpu usage float "+bucketA+" 1")
+		assert.Contains(t, lines, "cpu usage integer "+bucketA+" 2")
+		assert.Contains(t, lines, "cpu usage string "+bucketB+" 3")
+		assert.NotContains(t, out.String(), "mem")
+		assert.Contains(t, out.String(), "Checked 2 fields in 3 files, 1 with type conflicts\n")
+
+		buf, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		var doc schemaConflicts
+		require.NoError(t, json.Unmarshal(buf, &doc))
+		require.Len(t, doc.Conflicts, 1)
+		conflict := doc.Conflicts[0]
+		assert.Equal(t, "cpu", conflict.Measurement)
+		assert.Equal(t, "usage", conflict.Field)
+		require.Len(t, conflict.Types, 3)
+		assert.Equal(t, fieldTypeShard{
+			Type: "float",
+			Shards: []schemaShard{{
+				BucketID: bucketA,
+				ShardID:  "1",
+				Path:     filepath.Join(dir, "data", bucketA, "autogen", "1"),
+			}},
+		}, conflict.Types[0])
+	})
+
+	t.Run("no conflicts", func(t *testing.T) {
+		path := filepath.Join(dir, "none.json")
+		var out bytes.Buffer
+		require.NoError(t, runCheckSchema(&out, checkSchemaOptions{dataPath: filepath.Join(dir, "data", bucketA, "autogen", "2"), conflictsFile: path}))
+		assert.Contains(t, out.String(), "No field type conflicts found\n")
+
+		buf, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"conflicts": []}`, string(buf))
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		err := runCheckSchema(ioutil.Discard, checkSchemaOptions{dataPath: filepath.Join(dir, "data", bucketA, "missing")})
+		require.Error(t, err)
+	})
+}
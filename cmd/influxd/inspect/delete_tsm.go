@@ -0,0 +1,236 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/models"
+	pkgfs "github.com/influxdata/influxdb/v2/pkg/fs"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+type deleteTSMOptions struct {
+	dataPath    string
+	measurement string
+	key         string
+	sanitize    bool
+	dryRun      bool
+}
+
+func NewDeleteTSMCommand() *cobra.Command {
+	var opts deleteTSMOptions
+
+	cmd := &cobra.Command{
+		Use:   `delete-tsm [<path>...]`,
+		Short: "Deletes a measurement or series from TSM files",
+		Long: `
+This command rewrites the given TSM files, or the TSM files found under the
+data path when none are given, without the blocks of a measurement, of an
+exact series key, or, with --sanitize, of the series keys that are not valid
+UTF-8. Files left without any blocks are removed.
+
+It rewrites the files in place, so influxd must not be running. Each file is
+written to a temporary file that is synced and then renamed over the original,
+so a file is either rewritten or left as it was if the command is interrupted.
+The index of the shards is not changed, so rebuild it with build-tsi after
+deleting whole series.
+
+With --dry-run, the number of blocks and bytes that would be removed from
+each file is reported, and no file is changed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteTSM(cmd.OutOrStdout(), opts, args)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine"), "Path to the storage engine directory, or a data, bucket or shard directory within it, to delete from when no files are given")
+	cmd.Flags().StringVar(&opts.measurement, "measurement", "", "The name of the measurement to delete")
+	cmd.Flags().StringVar(&opts.key, "key", "", "The exact series key to delete, such as cpu,host=a")
+	cmd.Flags().BoolVar(&opts.sanitize, "sanitize", false, "Delete the series keys that are not valid UTF-8")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only report what would be deleted")
+
+	return cmd
+}
+
+// deleteTSMResult counts the blocks and bytes removed, or that would be.
+type deleteTSMResult struct {
+	files  int
+	blocks int
+	bytes  int64
+}
+
+func runDeleteTSM(w io.Writer, opts deleteTSMOptions, args []string) error {
+	if opts.measurement == "" && opts.key == "" && !opts.sanitize {
+		return errors.New("one of --measurement, --key or --sanitize is required")
+	} else if opts.measurement != "" && opts.key != "" {
+		return errors.New("--measurement and --key are mutually exclusive")
+	}
+
+	paths, err := tsmFilePaths(opts.dataPath, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("no TSM files found")
+	}
+
+	verb := "Removed"
+	if opts.dryRun {
+		verb = "Would remove"
+	}
+
+	var total deleteTSMResult
+	for _, path := range paths {
+		res, err := deleteTSMFile(opts, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if res.blocks == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s %d blocks (%d bytes)\n", path, verb, res.blocks, res.bytes)
+		total.files++
+		total.blocks += res.blocks
+		total.bytes += res.bytes
+	}
+	fmt.Fprintf(w, "%s %d blocks (%d bytes) from %d of %d files\n", verb, total.blocks, total.bytes, total.files, len(paths))
+	return nil
+}
+
+// deleteKey returns whether the blocks of the TSM key are deleted.
+func (o deleteTSMOptions) deleteKey(key []byte) bool {
+	if o.sanitize && !utf8.Valid(key) {
+		return true
+	}
+	seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+	if o.key != "" {
+		return string(seriesKey) == o.key
+	}
+	if o.measurement != "" {
+		return string(models.ParseName(seriesKey)) == o.measurement
+	}
+	return false
+}
+
+// deleteTSMFile rewrites the TSM file at path without the deleted blocks,
+// unless it has none of them or this is a dry run.
+func deleteTSMFile(opts deleteTSMOptions, path string) (deleteTSMResult, error) {
+	tmpPath := path + "." + tsm1.TmpTSMFileExtension
+	res, kept, err := writeTSMWithout(opts, path, tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return res, err
+	}
+	if res.blocks == 0 || opts.dryRun {
+		return res, nil
+	}
+
+	if kept == 0 {
+		// Remove the file, and its tombstones, rather than leave an empty
+		// file.
+		f, err := os.Open(path)
+		if err != nil {
+			return res, err
+		}
+		r, err := tsm1.NewTSMReader(f)
+		if err != nil {
+			f.Close()
+			return res, err
+		}
+		if err := r.Close(); err != nil {
+			return res, err
+		}
+		if err := r.Remove(); err != nil {
+			return res, err
+		}
+	} else if err := pkgfs.RenameFileWithReplacement(tmpPath, path); err != nil {
+		return res, err
+	}
+	return res, pkgfs.SyncDir(filepath.Dir(path))
+}
+
+// writeTSMWithout counts the blocks of the TSM file at path that are deleted
+// and kept, and, if any are deleted and some are kept, writes those kept to a
+// new TSM file at tmpPath and syncs it. It writes nothing on a dry run.
+func writeTSMWithout(opts deleteTSMOptions, path, tmpPath string) (res deleteTSMResult, kept int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return res, 0, err
+	}
+	r, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return res, 0, err
+	}
+	defer r.Close()
+
+	// Count the blocks first, so files without any to delete are not
+	// rewritten.
+	iter := r.BlockIterator()
+	for iter.Next() {
+		key, _, _, _, _, buf, err := iter.Read()
+		if err != nil {
+			return res, 0, err
+		}
+		if opts.deleteKey(key) {
+			res.blocks++
+			res.bytes += int64(len(buf))
+		} else {
+			kept++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return res, 0, err
+	}
+	if res.blocks == 0 || kept == 0 || opts.dryRun {
+		return res, kept, nil
+	}
+
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0666)
+	if err != nil {
+		return res, kept, err
+	}
+	w, err := tsm1.NewTSMWriter(out)
+	if err != nil {
+		out.Close()
+		return res, kept, err
+	}
+	if err := copyTSMBlocks(opts, r, w); err != nil {
+		w.Close()
+		return res, kept, err
+	}
+	// Closing the writer flushes and syncs the file.
+	return res, kept, w.Close()
+}
+
+// copyTSMBlocks writes the blocks of r that are not deleted to w, and the
+// index of w.
+func copyTSMBlocks(opts deleteTSMOptions, r *tsm1.TSMReader, w tsm1.TSMWriter) error {
+	iter := r.BlockIterator()
+	for iter.Next() {
+		key, minTime, maxTime, _, _, buf, err := iter.Read()
+		if err != nil {
+			return err
+		}
+		if opts.deleteKey(key) {
+			continue
+		}
+		if err := w.WriteBlock(key, minTime, maxTime, buf); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return w.WriteIndex()
+}
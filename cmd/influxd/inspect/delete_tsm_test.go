@@ -0,0 +1,119 @@
+package inspect
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/require"
+)
+
+// readTSMKeys returns the keys of the TSM file at path.
+func readTSMKeys(t *testing.T, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	r, err := tsm1.NewTSMReader(f)
+	require.NoError(t, err)
+	defer r.Close()
+
+	var keys []string
+	for i := 0; i < r.KeyCount(); i++ {
+		key, _ := r.KeyAt(i)
+		keys = append(keys, string(key))
+	}
+	return keys
+}
+
+func TestDeleteTSM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delete-tsm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	shardPath := filepath.Join(dir, "data", "0000000000000001", "autogen", "1")
+	file1 := filepath.Join(shardPath, "000000001-000000001.tsm")
+	file2 := filepath.Join(shardPath, "000000002-000000001.tsm")
+	file3 := filepath.Join(shardPath, "000000003-000000001.tsm")
+	writeTSMFile(t, file1,
+		"cpu,host=a#!~#idle",
+		"cpu,host=b#!~#idle",
+		"junk,id=1#!~#value",
+		"junk,id=2#!~#value",
+		"mem,host=a#!~#free",
+	)
+	writeTSMFile(t, file2,
+		"cpu,host=a#!~#user",
+		"cpu,host=\xff#!~#user",
+	)
+	writeTSMFile(t, file3,
+		"junk,id=3#!~#value",
+	)
+
+	run := func(opts deleteTSMOptions) string {
+		t.Helper()
+		var out bytes.Buffer
+		opts.dataPath = dir
+		require.NoError(t, runDeleteTSM(&out, opts, nil))
+		return out.String()
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		out := run(deleteTSMOptions{measurement: "junk", dryRun: true})
+		require.Contains(t, out, file1+": Would remove 2 blocks (")
+		require.Contains(t, out, file3+": Would remove 1 blocks (")
+		require.NotContains(t, out, file2)
+		require.Contains(t, out, "Would remove 3 blocks (")
+		require.Contains(t, out, "from 2 of 3 files\n")
+
+		require.Len(t, readTSMKeys(t, file1), 5)
+		require.FileExists(t, file3)
+	})
+
+	t.Run("measurement", func(t *testing.T) {
+		out := run(deleteTSMOptions{measurement: "junk"})
+		require.Contains(t, out, "Removed 3 blocks (")
+
+		require.Equal(t, []string{
+			"cpu,host=a#!~#idle",
+			"cpu,host=b#!~#idle",
+			"mem,host=a#!~#free",
+		}, readTSMKeys(t, file1))
+		require.NoFileExists(t, file3)
+		require.NoFileExists(t, file1+"."+tsm1.TmpTSMFileExtension)
+
+		// The values of the blocks kept are unchanged.
+		f, err := os.Open(file1)
+		require.NoError(t, err)
+		r, err := tsm1.NewTSMReader(f)
+		require.NoError(t, err)
+		defer r.Close()
+		values, err := r.ReadAll([]byte("mem,host=a#!~#free"))
+		require.NoError(t, err)
+		require.Equal(t, []tsm1.Value{tsm1.NewValue(5e9, float64(4))}, values)
+	})
+
+	t.Run("key", func(t *testing.T) {
+		run(deleteTSMOptions{key: "cpu,host=b"})
+		require.Equal(t, []string{
+			"cpu,host=a#!~#idle",
+			"mem,host=a#!~#free",
+		}, readTSMKeys(t, file1))
+	})
+
+	t.Run("sanitize", func(t *testing.T) {
+		out := run(deleteTSMOptions{sanitize: true})
+		require.Contains(t, out, file2+": Removed 1 blocks (")
+		require.Equal(t, []string{"cpu,host=a#!~#user"}, readTSMKeys(t, file2))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		require.EqualError(t, runDeleteTSM(ioutil.Discard, deleteTSMOptions{dataPath: dir}, nil),
+			"one of --measurement, --key or --sanitize is required")
+		require.EqualError(t, runDeleteTSM(ioutil.Discard, deleteTSMOptions{dataPath: dir, measurement: "cpu", key: "cpu,host=a"}, nil),
+			"--measurement and --key are mutually exclusive")
+	})
+}
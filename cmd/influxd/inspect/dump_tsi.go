@@ -0,0 +1,386 @@
+package inspect
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/spf13/cobra"
+)
+
+type dumpTSIOptions struct {
+	seriesFile string
+	indexPath  string
+
+	measurementFilter string
+	tagKeyFilter      string
+	tagValueFilter    string
+
+	series         bool
+	measurements   bool
+	tagKeys        bool
+	tagValues      bool
+	tagValueSeries bool
+}
+
+func NewDumpTSICommand() *cobra.Command {
+	var opts dumpTSIOptions
+
+	cmd := &cobra.Command{
+		Use:   "dump-tsi",
+		Short: "Dumps the contents of TSI index files",
+		Long: `
+This command prints the series, measurements, tag keys and tag values held by
+each log and index file of a TSI index, under the level and path of the file
+they were read from: L0 for log files, and the compaction level of index
+files. Series tombstoned by a file are marked as tombstoned, and measurements,
+tag keys and tag values as deleted, so it shows whether a delete is still in a
+log file or has been compacted into an index file.
+
+The index path may be the index directory of a shard, one of its partitions,
+or a single log or index file. The series file is found in a parent directory
+of the index path unless given with --series-file.
+
+Tag keys are printed within their measurements, and tag values within their
+tag keys, so dumping them also prints the measurements and tag keys they
+belong to. All sections are dumped if none is selected.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDumpTSI(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.seriesFile, "series-file", "", "Path to the series file of the bucket of the index")
+	cmd.Flags().StringVar(&opts.indexPath, "index-path", "", "Path to an index directory, index partition, or log or index file")
+	cmd.Flags().StringVar(&opts.measurementFilter, "measurement-filter", "", "Only dump the measurements and series whose measurement matches this regular expression")
+	cmd.Flags().StringVar(&opts.tagKeyFilter, "tag-key-filter", "", "Only dump the tag keys, and series with a tag key, matching this regular expression")
+	cmd.Flags().StringVar(&opts.tagValueFilter, "tag-value-filter", "", "Only dump the tag values, and series with a tag value, matching this regular expression")
+	cmd.Flags().BoolVar(&opts.series, "series", false, "Dump the series of each file")
+	cmd.Flags().BoolVar(&opts.measurements, "measurements", false, "Dump the measurements of each file")
+	cmd.Flags().BoolVar(&opts.tagKeys, "tag-keys", false, "Dump the tag keys of the measurements of each file")
+	cmd.Flags().BoolVar(&opts.tagValues, "tag-values", false, "Dump the tag values of the tag keys of each file")
+	cmd.Flags().BoolVar(&opts.tagValueSeries, "tag-value-series", false, "Dump the series of the tag values of each file")
+	_ = cmd.MarkFlagRequired("index-path")
+
+	return cmd
+}
+
+// tsiDumper writes the contents of TSI files.
+type tsiDumper struct {
+	opts  dumpTSIOptions
+	w     *bufio.Writer
+	sfile *tsdb.SeriesFile
+
+	measurementFilter *regexp.Regexp
+	tagKeyFilter      *regexp.Regexp
+	tagValueFilter    *regexp.Regexp
+}
+
+func runDumpTSI(w io.Writer, opts dumpTSIOptions) error {
+	if !opts.series && !opts.measurements && !opts.tagKeys && !opts.tagValues && !opts.tagValueSeries {
+		opts.series, opts.measurements, opts.tagKeys, opts.tagValues, opts.tagValueSeries = true, true, true, true, true
+	}
+
+	d := &tsiDumper{opts: opts}
+	for _, f := range []struct {
+		flag  string
+		expr  string
+		regex **regexp.Regexp
+	}{
+		{"--measurement-filter", opts.measurementFilter, &d.measurementFilter},
+		{"--tag-key-filter", opts.tagKeyFilter, &d.tagKeyFilter},
+		{"--tag-value-filter", opts.tagValueFilter, &d.tagValueFilter},
+	} {
+		if f.expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(f.expr)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", f.flag, err)
+		}
+		*f.regex = re
+	}
+
+	paths, err := tsiFilePaths(opts.indexPath)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no index files found under %s", opts.indexPath)
+	}
+
+	seriesFile := opts.seriesFile
+	if seriesFile == "" {
+		if seriesFile, err = findSeriesFile(opts.indexPath); err != nil {
+			return err
+		}
+	}
+	d.sfile = tsdb.NewSeriesFile(seriesFile)
+	if err := d.sfile.Open(); err != nil {
+		return err
+	}
+	defer d.sfile.Close()
+
+	d.w = bufio.NewWriter(w)
+	for _, path := range paths {
+		if err := d.dumpFile(path); err != nil {
+			return fmt.Errorf("cannot dump %s: %w", path, err)
+		}
+	}
+	return d.w.Flush()
+}
+
+// tsiFilePaths returns the log and index files at path, which may be an
+// index directory, a partition or a single file. The files of a partition
+// are in the order of its manifest, newest first.
+func tsiFilePaths(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	if ok, err := tsi1.IsPartitionDir(path); err != nil {
+		return nil, err
+	} else if ok {
+		m, _, err := tsi1.ReadManifestFile(filepath.Join(path, tsi1.ManifestFileName))
+		if err != nil {
+			return nil, err
+		}
+		paths := make([]string, 0, len(m.Files))
+		for _, name := range m.Files {
+			paths = append(paths, filepath.Join(path, name))
+		}
+		return paths, nil
+	}
+
+	partitions, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range partitions {
+		if !p.IsDir() {
+			continue
+		}
+		ps, err := tsiFilePaths(filepath.Join(path, p.Name()))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, ps...)
+	}
+	return paths, nil
+}
+
+// findSeriesFile returns the series file of the bucket holding the index at
+// path, found in the nearest parent directory with one.
+func findSeriesFile(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for dir := abs; ; {
+		sfile := filepath.Join(dir, tsdb.SeriesFileDirectory)
+		if fi, err := os.Stat(sfile); err == nil && fi.IsDir() {
+			return sfile, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("unable to find the series file of the index, use --series-file")
+		}
+		dir = parent
+	}
+}
+
+// openTSIFile opens the log or index file at path.
+func openTSIFile(sfile *tsdb.SeriesFile, path string) (tsi1.File, error) {
+	// Log files are opened for appending, which would create a missing one.
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	switch filepath.Ext(path) {
+	case tsi1.LogFileExt:
+		f := tsi1.NewLogFile(sfile, path)
+		if err := f.Open(); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case tsi1.IndexFileExt:
+		f := tsi1.NewIndexFile(sfile)
+		f.SetPath(path)
+		if err := f.Open(); err != nil {
+			return nil, err
+		}
+		return f, nil
+	default:
+		return nil, errors.New("not a log or index file")
+	}
+}
+
+func (d *tsiDumper) dumpFile(path string) error {
+	f, err := openTSIFile(d.sfile, path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// ParseFilename returns the ID of the file before its level.
+	_, level := tsi1.ParseFilename(path)
+	kind := "index file"
+	if filepath.Ext(path) == tsi1.LogFileExt {
+		kind = "log file"
+	}
+	fmt.Fprintf(d.w, "L%d %s %s\n", level, kind, path)
+
+	if d.opts.series {
+		if err := d.dumpSeries(f); err != nil {
+			return err
+		}
+	}
+	if d.opts.measurements || d.opts.tagKeys || d.opts.tagValues || d.opts.tagValueSeries {
+		if err := d.dumpMeasurements(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumpSeries writes the series of f, and those it tombstones.
+func (d *tsiDumper) dumpSeries(f tsi1.File) error {
+	fmt.Fprintln(d.w, "  Series:")
+	for _, set := range []struct {
+		fn     func() (*tsdb.SeriesIDSet, error)
+		marker string
+	}{
+		{f.SeriesIDSet, ""},
+		{f.TombstoneSeriesIDSet, " (tombstoned)"},
+	} {
+		ss, err := set.fn()
+		if err != nil {
+			return err
+		}
+		ss.ForEach(func(id uint64) {
+			key := d.sfile.SeriesKey(id)
+			if len(key) == 0 {
+				// The series file no longer has the series.
+				if d.measurementFilter == nil && d.tagKeyFilter == nil && d.tagValueFilter == nil {
+					fmt.Fprintf(d.w, "    <series ID %d>%s\n", id, set.marker)
+				}
+				return
+			}
+			name, tags := tsdb.ParseSeriesKey(key)
+			if d.matchSeries(name, tags) {
+				fmt.Fprintf(d.w, "    %s%s\n", models.MakeKey(name, tags), set.marker)
+			}
+		})
+	}
+	return nil
+}
+
+// matchSeries returns whether a series matches the filters: its measurement
+// matches the measurement filter, and one of its tags matches both the tag
+// key and tag value filters.
+func (d *tsiDumper) matchSeries(name []byte, tags models.Tags) bool {
+	if d.measurementFilter != nil && !d.measurementFilter.Match(name) {
+		return false
+	}
+	if d.tagKeyFilter == nil && d.tagValueFilter == nil {
+		return true
+	}
+	for _, tag := range tags {
+		if (d.tagKeyFilter == nil || d.tagKeyFilter.Match(tag.Key)) &&
+			(d.tagValueFilter == nil || d.tagValueFilter.Match(tag.Value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpMeasurements writes the measurements of f, with their tag keys, tag
+// values and series as selected.
+func (d *tsiDumper) dumpMeasurements(f tsi1.File) error {
+	fmt.Fprintln(d.w, "  Measurements:")
+	mitr := f.MeasurementIterator()
+	if mitr == nil {
+		return nil
+	}
+	for me := mitr.Next(); me != nil; me = mitr.Next() {
+		name := me.Name()
+		if d.measurementFilter != nil && !d.measurementFilter.Match(name) {
+			continue
+		}
+		fmt.Fprintf(d.w, "    %s%s\n", name, deletedMarker(me.Deleted()))
+		if !d.opts.tagKeys && !d.opts.tagValues && !d.opts.tagValueSeries {
+			continue
+		}
+
+		kitr := f.TagKeyIterator(name)
+		if kitr == nil {
+			continue
+		}
+		for ke := kitr.Next(); ke != nil; ke = kitr.Next() {
+			key := ke.Key()
+			if d.tagKeyFilter != nil && !d.tagKeyFilter.Match(key) {
+				continue
+			}
+			fmt.Fprintf(d.w, "      %s%s\n", key, deletedMarker(ke.Deleted()))
+			if !d.opts.tagValues && !d.opts.tagValueSeries {
+				continue
+			}
+
+			if err := d.dumpTagValues(f, name, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *tsiDumper) dumpTagValues(f tsi1.File, name, key []byte) error {
+	vitr := f.TagValueIterator(name, key)
+	if vitr == nil {
+		return nil
+	}
+	for ve := vitr.Next(); ve != nil; ve = vitr.Next() {
+		value := ve.Value()
+		if d.tagValueFilter != nil && !d.tagValueFilter.Match(value) {
+			continue
+		}
+		fmt.Fprintf(d.w, "        %s%s\n", value, deletedMarker(ve.Deleted()))
+		if !d.opts.tagValueSeries {
+			continue
+		}
+
+		ss, err := f.TagValueSeriesIDSet(name, key, value)
+		if err != nil {
+			return err
+		} else if ss == nil {
+			continue
+		}
+		ss.ForEach(func(id uint64) {
+			if key := d.sfile.SeriesKey(id); len(key) > 0 {
+				fmt.Fprintf(d.w, "          %s\n", models.MakeKey(tsdb.ParseSeriesKey(key)))
+			} else {
+				fmt.Fprintf(d.w, "          <series ID %d>\n", id)
+			}
+		})
+	}
+	return nil
+}
+
+func deletedMarker(deleted bool) string {
+	if deleted {
+		return " (deleted)"
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package inspect
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpTSI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dump-tsi-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bucketPath := filepath.Join(dir, "data", "0000000000000001")
+	seriesPath := filepath.Join(bucketPath, tsdb.SeriesFileDirectory)
+	sfile := tsdb.NewSeriesFile(seriesPath)
+	require.NoError(t, sfile.Open())
+	defer sfile.Close()
+
+	// The first shard keeps its series, and the tombstone of a dropped one,
+	// in log files.
+	logShard := filepath.Join(bucketPath, "autogen", "1")
+	idx := openTestTSIIndex(t, sfile, logShard, "cpu,host=a", "cpu,host=b", "mem,host=a")
+	key := []byte("cpu,host=b")
+	id := sfile.SeriesID(models.ParseName(key), models.ParseTags(key), nil)
+	require.NoError(t, idx.DropSeries(id, key, false))
+	require.NoError(t, idx.Close())
+
+	// The second shard compacts its log files into index files.
+	indexShard := filepath.Join(bucketPath, "autogen", "2")
+	idx = tsi1.NewIndex(sfile, "", tsi1.WithPath(filepath.Join(indexShard, "index")), tsi1.WithMaximumLogFileSize(1))
+	require.NoError(t, idx.Open())
+	require.NoError(t, idx.CreateSeriesListIfNotExists(
+		[][]byte{[]byte("disk,path=/")},
+		[][]byte{[]byte("disk")},
+		[]models.Tags{models.NewTags(map[string]string{"path": "/"})},
+	))
+	idx.Wait()
+	require.NoError(t, idx.Close())
+
+	dump := func(t *testing.T, opts dumpTSIOptions) string {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, runDumpTSI(&buf, opts))
+		return buf.String()
+	}
+
+	t.Run("log files", func(t *testing.T) {
+		out := dump(t, dumpTSIOptions{indexPath: filepath.Join(logShard, "index")})
+		require.Contains(t, out, "L0 log file ")
+		require.NotContains(t, out, "index file")
+		require.Contains(t, out, "    cpu,host=a\n")
+		require.Contains(t, out, "    mem,host=a\n")
+		require.Contains(t, out, "    cpu,host=b (tombstoned)\n")
+		require.Contains(t, out, "  Measurements:\n    cpu\n      host\n        a\n          cpu,host=a\n")
+	})
+
+	t.Run("index files", func(t *testing.T) {
+		out := dump(t, dumpTSIOptions{indexPath: filepath.Join(indexShard, "index")})
+		require.Contains(t, out, "L1 index file ")
+		require.Contains(t, out, "    disk,path=/\n")
+		require.Contains(t, out, "    disk\n      path\n        /\n          disk,path=/\n")
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		out := dump(t, dumpTSIOptions{
+			indexPath:         filepath.Join(logShard, "index"),
+			measurementFilter: "^cpu$",
+			tagValueFilter:    "^b$",
+		})
+		require.Contains(t, out, "    cpu,host=b (tombstoned)\n")
+		require.NotContains(t, out, "cpu,host=a")
+		require.NotContains(t, out, "mem")
+		require.NotContains(t, out, "        a\n")
+	})
+
+	t.Run("sections", func(t *testing.T) {
+		out := dump(t, dumpTSIOptions{
+			indexPath:    filepath.Join(logShard, "index"),
+			seriesFile:   seriesPath,
+			measurements: true,
+		})
+		require.NotContains(t, out, "  Series:")
+		require.Contains(t, out, "  Measurements:\n    cpu\n")
+		require.NotContains(t, out, "host")
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		err := runDumpTSI(ioutil.Discard, dumpTSIOptions{indexPath: logShard, tagKeyFilter: "("})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid --tag-key-filter")
+	})
+
+	t.Run("missing series file", func(t *testing.T) {
+		other, err := ioutil.TempDir("", "dump-tsi-")
+		require.NoError(t, err)
+		defer os.RemoveAll(other)
+
+		idx := openTestTSIIndex(t, sfile, other, "cpu,host=a")
+		require.NoError(t, idx.Close())
+
+		err = runDumpTSI(ioutil.Discard, dumpTSIOptions{indexPath: filepath.Join(other, "index")})
+		require.EqualError(t, err, "unable to find the series file of the index, use --series-file")
+	})
+}
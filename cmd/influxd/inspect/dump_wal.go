@@ -0,0 +1,193 @@
+package inspect
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+type dumpWALOptions struct {
+	findDuplicates bool
+}
+
+func NewDumpWALCommand() *cobra.Command {
+	var opts dumpWALOptions
+
+	cmd := &cobra.Command{
+		Use:   `dump-wal <path>...`,
+		Short: "Dumps the entries of WAL segments",
+		Long: `
+This command prints each entry of the given WAL segment files, or of the
+segments found in the given directories, such as the WAL directory of a shard:
+the series key, field, timestamp and value of each value written, and the
+series keys and time ranges of deletes.
+
+With --find-duplicates, only the keys written with a timestamp that is out of
+order or duplicated within a segment are printed.
+
+A segment that cannot be read to its end is reported with the offset of the
+first entry that cannot be read, and the dump continues with the next segment.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDumpWAL(cmd.OutOrStdout(), opts, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.findDuplicates, "find-duplicates", false, "Only report keys with out of order or duplicate timestamps within a segment")
+
+	return cmd
+}
+
+// walSegmentPaths returns the WAL segment files at paths, in the order they
+// were written within each directory.
+func walSegmentPaths(paths []string) ([]string, error) {
+	var segments []string
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			segments = append(segments, path)
+			continue
+		}
+
+		var found []string
+		err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && filepath.Ext(path) == "."+tsm1.WALFileExtension {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Segment names are zero padded sequence numbers.
+		sort.Strings(found)
+		segments = append(segments, found...)
+	}
+	return segments, nil
+}
+
+func runDumpWAL(w io.Writer, opts dumpWALOptions, paths []string) error {
+	segments, err := walSegmentPaths(paths)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("no WAL segments found")
+	}
+
+	var corrupt int
+	for _, path := range segments {
+		ok, err := dumpWALSegment(w, opts, path)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			corrupt++
+		}
+	}
+	if corrupt > 0 {
+		return fmt.Errorf("%d of %d WAL segments are corrupt", corrupt, len(segments))
+	}
+	return nil
+}
+
+// dumpWALSegment prints the entries of the segment at path. It returns false
+// if the segment is corrupt.
+func dumpWALSegment(w io.Writer, opts dumpWALOptions, path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	r := tsm1.NewWALSegmentReader(f)
+	defer r.Close()
+
+	fmt.Fprintf(w, "File: %s\n", path)
+
+	// The last timestamp written to each key, and the keys written out of
+	// order, when finding duplicates.
+	last := make(map[string]int64)
+	duplicates := make(map[string]struct{})
+
+	var entries int
+	for r.Next() {
+		offset := r.Count()
+		entry, err := r.Read()
+		if err != nil {
+			fmt.Fprintf(w, "  corrupt entry at offset %d: %v\n", offset, err)
+			return false, nil
+		}
+		entries++
+
+		switch e := entry.(type) {
+		case *tsm1.WriteWALEntry:
+			keys := make([]string, 0, len(e.Values))
+			for key := range e.Values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if opts.findDuplicates {
+					for _, v := range e.Values[key] {
+						if t, ok := last[key]; ok && v.UnixNano() <= t {
+							duplicates[key] = struct{}{}
+						}
+						last[key] = v.UnixNano()
+					}
+					continue
+				}
+
+				seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey([]byte(key))
+				for _, v := range e.Values[key] {
+					fmt.Fprintf(w, "  [write] %s %s %d %s\n", seriesKey, field, v.UnixNano(), formatWALValue(v))
+				}
+			}
+		case *tsm1.DeleteWALEntry:
+			if opts.findDuplicates {
+				continue
+			}
+			for _, key := range e.Keys {
+				fmt.Fprintf(w, "  [delete] %s\n", key)
+			}
+		case *tsm1.DeleteRangeWALEntry:
+			if opts.findDuplicates {
+				continue
+			}
+			for _, key := range e.Keys {
+				fmt.Fprintf(w, "  [delete-range] %s %d %d\n", key, e.Min, e.Max)
+			}
+		}
+	}
+
+	if opts.findDuplicates {
+		keys := make([]string, 0, len(duplicates))
+		for key := range duplicates {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey([]byte(key))
+			fmt.Fprintf(w, "  [duplicate] %s %s\n", seriesKey, field)
+		}
+	}
+	fmt.Fprintf(w, "  %d entries\n", entries)
+	return true, nil
+}
+
+func formatWALValue(v tsm1.Value) string {
+	if s, ok := v.Value().(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v.Value())
+}
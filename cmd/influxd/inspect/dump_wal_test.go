@@ -0,0 +1,120 @@
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeWALSegment writes the entries to a WAL segment at path.
+func writeWALSegment(t *testing.T, path string, entries ...tsm1.WALEntry) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := tsm1.NewWALSegmentWriter(f)
+	for _, entry := range entries {
+		b, err := entry.Encode(make([]byte, 4096))
+		require.NoError(t, err)
+		require.NoError(t, w.Write(entry.Type(), snappy.Encode(nil, b)))
+	}
+	require.NoError(t, w.Flush())
+	require.NoError(t, f.Close())
+}
+
+func TestDumpWAL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dump-wal-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	shardDir := filepath.Join(dir, "wal", "0000000000000001", "autogen", "1")
+	writeWALSegment(t, filepath.Join(shardDir, "_00001.wal"),
+		&tsm1.WriteWALEntry{Values: map[string][]tsm1.Value{
+			"cpu,host=a#!~#value": {tsm1.NewValue(10, 1.5), tsm1.NewValue(20, 2.5)},
+			"cpu,host=a#!~#name":  {tsm1.NewValue(10, "idle")},
+		}},
+		&tsm1.WriteWALEntry{Values: map[string][]tsm1.Value{
+			// Out of order with the first entry.
+			"cpu,host=a#!~#value": {tsm1.NewValue(15, 3.5)},
+			// Duplicated within the entry.
+			"mem,host=a#!~#free": {tsm1.NewValue(10, int64(1)), tsm1.NewValue(10, int64(2))},
+			"mem,host=b#!~#free": {tsm1.NewValue(10, int64(1)), tsm1.NewValue(20, int64(2))},
+		}},
+		&tsm1.DeleteWALEntry{Keys: [][]byte{[]byte("disk,host=a")}},
+		&tsm1.DeleteRangeWALEntry{Keys: [][]byte{[]byte("disk,host=b")}, Min: 5, Max: 25},
+	)
+	writeWALSegment(t, filepath.Join(shardDir, "_00002.wal"),
+		&tsm1.WriteWALEntry{Values: map[string][]tsm1.Value{
+			// Not a duplicate, as it is in another segment.
+			"cpu,host=a#!~#value": {tsm1.NewValue(5, 0.5)},
+		}},
+	)
+
+	t.Run("dump", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runDumpWAL(&out, dumpWALOptions{}, []string{shardDir}))
+
+		assert.Equal(t, `File: `+filepath.Join(shardDir, "_00001.wal")+`
+  [write] cpu,host=a name 10 "idle"
+  [write] cpu,host=a value 10 1.5
+  [write] cpu,host=a value 20 2.5
+  [write] cpu,host=a value 15 3.5
+  [write] mem,host=a free 10 1
+  [write] mem,host=a free 10 2
+  [write] mem,host=b free 10 1
+  [write] mem,host=b free 20 2
+  [delete] disk,host=a
+  [delete-range] disk,host=b 5 25
+  4 entries
+File: `+filepath.Join(shardDir, "_00002.wal")+`
+  [write] cpu,host=a value 5 0.5
+  1 entries
+`, out.String())
+	})
+
+	t.Run("find duplicates", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runDumpWAL(&out, dumpWALOptions{findDuplicates: true}, []string{shardDir}))
+
+		assert.Equal(t, `File: `+filepath.Join(shardDir, "_00001.wal")+`
+  [duplicate] cpu,host=a value
+  [duplicate] mem,host=a free
+  4 entries
+File: `+filepath.Join(shardDir, "_00002.wal")+`
+  1 entries
+`, out.String())
+	})
+
+	t.Run("corrupt segment", func(t *testing.T) {
+		corrupt := filepath.Join(dir, "_00003.wal")
+		writeWALSegment(t, corrupt, &tsm1.DeleteWALEntry{Keys: [][]byte{[]byte("disk,host=a")}})
+		fi, err := os.Stat(corrupt)
+		require.NoError(t, err)
+		f, err := os.OpenFile(corrupt, os.O_APPEND|os.O_WRONLY, 0666)
+		require.NoError(t, err)
+		_, err = f.Write([]byte{byte(tsm1.WriteWALEntryType), 0, 0, 0, 10, 1, 2, 3})
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		var out bytes.Buffer
+		err = runDumpWAL(&out, dumpWALOptions{}, []string{corrupt, filepath.Join(shardDir, "_00002.wal")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 of 2 WAL segments are corrupt")
+
+		lines := strings.Split(out.String(), "\n")
+		assert.Equal(t, "  [delete] disk,host=a", lines[1])
+		assert.True(t, strings.HasPrefix(lines[2], fmt.Sprintf("  corrupt entry at offset %d: ", fi.Size())), lines[2])
+		// The next segment is still dumped.
+		assert.Contains(t, out.String(), "[write] cpu,host=a value 5 0.5")
+	})
+}
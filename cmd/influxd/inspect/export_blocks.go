@@ -0,0 +1,250 @@
+package inspect
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+type exportBlocksOptions struct {
+	format         string
+	seriesFilter   string
+	timeRange      string
+	includePayload bool
+	redactValues   bool
+}
+
+func NewExportBlocksCommand() *cobra.Command {
+	var opts exportBlocksOptions
+
+	cmd := &cobra.Command{
+		Use:   `export-blocks <tsm-file>...`,
+		Short: "Exports the raw blocks of TSM files",
+		Long: `
+This command writes a record for each block of the given TSM files: the file,
+series key, field, block type, time range, offset, size and checksum of the
+block, and with --include-payload its compressed payload, base64 encoded.
+Records are written as line delimited JSON, or with --format sql as SQL
+statements creating and filling a "blocks" table.
+
+--series-filter only exports the blocks of series keys matching a regular
+expression, and --time-range only the blocks overlapping a time range, given
+as <start>,<end> in RFC3339 format where either bound may be left empty.
+
+With --redact-values, payloads are included but replaced by zeroes of the same
+length, so the structure of the files can be shared without the data they
+hold.
+
+Blocks are read one at a time, so files of any size can be exported.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExportBlocks(cmd.OutOrStdout(), opts, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.format, "format", "json", "The output format: json or sql")
+	cmd.Flags().StringVar(&opts.seriesFilter, "series-filter", "", "Only export the blocks of series keys matching this regular expression")
+	cmd.Flags().StringVar(&opts.timeRange, "time-range", "", "Only export the blocks overlapping <start>,<end>, in RFC3339 format")
+	cmd.Flags().BoolVar(&opts.includePayload, "include-payload", false, "Include the base64 encoded compressed payload of each block")
+	cmd.Flags().BoolVar(&opts.redactValues, "redact-values", false, "Include payloads replaced by zeroes of the same length")
+
+	return cmd
+}
+
+// exportedBlock is the record of a block.
+type exportedBlock struct {
+	File      string `json:"file"`
+	SeriesKey string `json:"seriesKey"`
+	Field     string `json:"field"`
+	Type      string `json:"type"`
+	MinTime   int64  `json:"minTime"`
+	MaxTime   int64  `json:"maxTime"`
+	Offset    int64  `json:"offset"`
+	Size      uint32 `json:"size"`
+	CRC       uint32 `json:"crc"`
+	Payload   string `json:"payload,omitempty"`
+}
+
+// blockWriter writes the records of blocks in an output format.
+type blockWriter interface {
+	writeBlock(b *exportedBlock) error
+}
+
+type jsonBlockWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonBlockWriter) writeBlock(b *exportedBlock) error {
+	return w.enc.Encode(b)
+}
+
+type sqlBlockWriter struct {
+	w io.Writer
+}
+
+const createBlocksTable = `CREATE TABLE IF NOT EXISTS blocks (file TEXT, series_key TEXT, field TEXT, type TEXT, min_time INTEGER, max_time INTEGER, block_offset INTEGER, size INTEGER, crc INTEGER, payload TEXT);`
+
+func (w *sqlBlockWriter) writeBlock(b *exportedBlock) error {
+	_, err := fmt.Fprintf(w.w, "INSERT INTO blocks VALUES (%s, %s, %s, %s, %d, %d, %d, %d, %d, %s);\n",
+		sqlString(b.File), sqlString(b.SeriesKey), sqlString(b.Field), sqlString(b.Type),
+		b.MinTime, b.MaxTime, b.Offset, b.Size, b.CRC, sqlString(b.Payload))
+	return err
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// parseTimeRange parses a time range of <start>,<end> in RFC3339 format,
+// either of which may be empty to leave it unbounded.
+func parseTimeRange(s string) (min, max int64, err error) {
+	min, max = math.MinInt64, math.MaxInt64
+	if s == "" {
+		return min, max, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid time range %q: must be <start>,<end>", s)
+	}
+	if parts[0] != "" {
+		t, err := time.Parse(time.RFC3339Nano, parts[0])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid start of time range: %w", err)
+		}
+		min = t.UnixNano()
+	}
+	if parts[1] != "" {
+		t, err := time.Parse(time.RFC3339Nano, parts[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid end of time range: %w", err)
+		}
+		max = t.UnixNano()
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("invalid time range %q: start is after end", s)
+	}
+	return min, max, nil
+}
+
+func blockTypeName(typ byte) string {
+	switch typ {
+	case tsm1.BlockFloat64:
+		return "float"
+	case tsm1.BlockInteger:
+		return "integer"
+	case tsm1.BlockBoolean:
+		return "boolean"
+	case tsm1.BlockString:
+		return "string"
+	case tsm1.BlockUnsigned:
+		return "unsigned"
+	default:
+		return fmt.Sprintf("unknown(%d)", typ)
+	}
+}
+
+func runExportBlocks(w io.Writer, opts exportBlocksOptions, paths []string) error {
+	var filter *regexp.Regexp
+	if opts.seriesFilter != "" {
+		var err error
+		if filter, err = regexp.Compile(opts.seriesFilter); err != nil {
+			return fmt.Errorf("invalid series filter: %w", err)
+		}
+	}
+	min, max, err := parseTimeRange(opts.timeRange)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	var out blockWriter
+	switch opts.format {
+	case "json":
+		out = &jsonBlockWriter{enc: json.NewEncoder(bw)}
+	case "sql":
+		fmt.Fprintln(bw, createBlocksTable)
+		out = &sqlBlockWriter{w: bw}
+	default:
+		return fmt.Errorf("invalid format %q: must be json or sql", opts.format)
+	}
+
+	for _, path := range paths {
+		if err := exportTSMBlocks(out, opts, path, filter, min, max); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// exportTSMBlocks writes the records of the blocks of the TSM file at path
+// that match the filter and overlap min and max.
+func exportTSMBlocks(out blockWriter, opts exportBlocksOptions, path string, filter *regexp.Regexp, min, max int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	reader, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	defer reader.Close()
+
+	var entries []tsm1.IndexEntry
+	for i := 0; i < reader.KeyCount(); i++ {
+		var key []byte
+		var typ byte
+		key, typ, entries = reader.Key(i, &entries)
+		seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey(key)
+		if filter != nil && !filter.Match(seriesKey) {
+			continue
+		}
+
+		for j := range entries {
+			entry := &entries[j]
+			if !entry.OverlapsTimeRange(min, max) {
+				continue
+			}
+
+			b := exportedBlock{
+				File:      path,
+				SeriesKey: string(seriesKey),
+				Field:     string(field),
+				Type:      blockTypeName(typ),
+				MinTime:   entry.MinTime,
+				MaxTime:   entry.MaxTime,
+				Offset:    entry.Offset,
+				Size:      entry.Size,
+			}
+			if entry.Size < crc32.Size {
+				return fmt.Errorf("invalid size %d of block at offset %d of %s", entry.Size, entry.Offset, path)
+			}
+			crc, payload, err := reader.ReadBytes(entry, nil)
+			if err != nil {
+				return fmt.Errorf("unable to read block at offset %d of %s: %w", entry.Offset, path, err)
+			}
+			b.CRC = crc
+			if opts.includePayload || opts.redactValues {
+				if opts.redactValues {
+					payload = make([]byte, len(payload))
+				}
+				b.Payload = base64.StdEncoding.EncodeToString(payload)
+			}
+			if err := out.writeBlock(&b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
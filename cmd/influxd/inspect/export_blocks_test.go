@@ -0,0 +1,107 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportBlocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export-blocks-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Values are written at 1s, 2s and 3s.
+	path := filepath.Join(dir, "000000001-000000001.tsm")
+	writeTSMFile(t, path, "cpu,host=a#!~#idle", "cpu,host=b#!~#idle", "mem,host=a#!~#free")
+
+	export := func(t *testing.T, opts exportBlocksOptions) []exportedBlock {
+		t.Helper()
+		var out bytes.Buffer
+		require.NoError(t, runExportBlocks(&out, opts, []string{path}))
+
+		var blocks []exportedBlock
+		dec := json.NewDecoder(&out)
+		for dec.More() {
+			var b exportedBlock
+			require.NoError(t, dec.Decode(&b))
+			blocks = append(blocks, b)
+		}
+		return blocks
+	}
+
+	t.Run("json", func(t *testing.T) {
+		blocks := export(t, exportBlocksOptions{format: "json"})
+		require.Len(t, blocks, 3)
+		b := blocks[0]
+		assert.Equal(t, path, b.File)
+		assert.Equal(t, "cpu,host=a", b.SeriesKey)
+		assert.Equal(t, "idle", b.Field)
+		assert.Equal(t, "float", b.Type)
+		assert.Equal(t, int64(1e9), b.MinTime)
+		assert.Equal(t, int64(1e9), b.MaxTime)
+		assert.Equal(t, int64(5), b.Offset)
+		assert.NotZero(t, b.CRC)
+		assert.Empty(t, b.Payload)
+	})
+
+	t.Run("payloads", func(t *testing.T) {
+		blocks := export(t, exportBlocksOptions{format: "json", includePayload: true})
+		require.Len(t, blocks, 3)
+		payload, err := base64.StdEncoding.DecodeString(blocks[0].Payload)
+		require.NoError(t, err)
+		assert.Len(t, payload, int(blocks[0].Size)-4)
+		assert.NotEqual(t, make([]byte, len(payload)), payload)
+
+		blocks = export(t, exportBlocksOptions{format: "json", redactValues: true})
+		redacted, err := base64.StdEncoding.DecodeString(blocks[0].Payload)
+		require.NoError(t, err)
+		assert.Equal(t, make([]byte, len(payload)), redacted)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		blocks := export(t, exportBlocksOptions{format: "json", seriesFilter: "^cpu,"})
+		require.Len(t, blocks, 2)
+		assert.Equal(t, "cpu,host=b", blocks[1].SeriesKey)
+
+		blocks = export(t, exportBlocksOptions{format: "json", timeRange: "1970-01-01T00:00:02Z,"})
+		require.Len(t, blocks, 2)
+		assert.Equal(t, "cpu,host=b", blocks[0].SeriesKey)
+
+		blocks = export(t, exportBlocksOptions{format: "json", seriesFilter: "host=a", timeRange: ",1970-01-01T00:00:02Z"})
+		require.Len(t, blocks, 1)
+		assert.Equal(t, "cpu,host=a", blocks[0].SeriesKey)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runExportBlocks(&out, exportBlocksOptions{format: "sql", seriesFilter: "mem"}, []string{path}))
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Equal(t, createBlocksTable, lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], "INSERT INTO blocks VALUES ('"+path+"', 'mem,host=a', 'free', 'float', 3000000000, 3000000000, "), lines[1])
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []exportBlocksOptions{
+			{format: "csv"},
+			{format: "json", seriesFilter: "("},
+			{format: "json", timeRange: "yesterday"},
+			{format: "json", timeRange: "1970-01-01T00:00:02Z,1970-01-01T00:00:01Z"},
+		} {
+			assert.Error(t, runExportBlocks(ioutil.Discard, opts, []string{path}), "%+v", opts)
+		}
+	})
+}
+
+func TestSQLString(t *testing.T) {
+	assert.Equal(t, `'it''s'`, sqlString("it's"))
+}
@@ -2,56 +2,264 @@ package inspect
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/tenant"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
+type exportIndexOptions struct {
+	seriesFilePath string
+	indexPath      string
+	dataPath       string
+	boltPath       string
+	bucketID       string
+	orgID          string
+	format         string
+	outputPath     string
+}
+
 func NewExportIndexCommand() *cobra.Command {
+	var opts exportIndexOptions
+
 	cmd := &cobra.Command{
 		Use:   `export-index`,
 		Short: "Exports TSI index data",
 		Long: `
 This command will export all series in a TSI index to
-SQL format for easier inspection and debugging.`,
+SQL format for easier inspection and debugging.
+
+Given --series-path and --index-path, the index of a single shard is exported.
+Otherwise the index of each shard under --data-path is exported, and each row
+includes the bucket and shard it belongs to. --bucket-id and --org-id only
+export the shards of a bucket, or of the buckets of an organization, which
+are read from the bolt file.
+
+With --format json, each row is written as a JSON object on its own line.
+Rows are written as they are read, one shard at a time, so indexes of any size
+can be exported.`,
 		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExportIndex(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
 	}
 
-	var seriesFilePath, dataPath string
-	cmd.Flags().StringVar(&seriesFilePath, "series-path", "", "Path to series file")
-	cmd.Flags().StringVar(&dataPath, "index-path", "", "Path to the index directory of the data engine")
-	_ = cmd.MarkFlagRequired("series-path")
-	_ = cmd.MarkFlagRequired("index-path")
+	cmd.Flags().StringVar(&opts.seriesFilePath, "series-path", "", "Path to series file")
+	cmd.Flags().StringVar(&opts.indexPath, "index-path", "", "Path to the index directory of the data engine")
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine", "data"), "Path to the TSM data directory of the storage engine, used without --index-path")
+	cmd.Flags().StringVar(&opts.boltPath, "bolt-path", filepath.Join(dir, bolt.DefaultFilename), "Path to the bolt file, used with --org-id")
+	cmd.Flags().StringVar(&opts.bucketID, "bucket-id", "", "Only export the index of the shards of this bucket")
+	cmd.Flags().StringVar(&opts.orgID, "org-id", "", "Only export the index of the shards of the buckets of this organization")
+	cmd.Flags().StringVar(&opts.format, "format", "sql", "The output format: sql or json")
+	cmd.Flags().StringVar(&opts.outputPath, "output", "", "Path to the file to write the export to, stdout if not set")
 
-	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		// Initialize series file.
-		sfile := tsdb.NewSeriesFile(seriesFilePath)
-		if err := sfile.Open(); err != nil {
-			return err
+	return cmd
+}
+
+// indexRowWriter writes the rows of the index of a shard in an output format.
+type indexRowWriter interface {
+	writeIndex(idx *tsi1.Index, bucketID string, shardID uint64) error
+}
+
+type sqlIndexRowWriter struct {
+	w          io.Writer
+	showSchema bool
+}
+
+func (w *sqlIndexRowWriter) writeIndex(idx *tsi1.Index, bucketID string, shardID uint64) error {
+	e := tsi1.NewSQLIndexExporter(w.w)
+	e.BucketID, e.ShardID = bucketID, shardID
+	// The schema is only written once.
+	e.ShowSchema = w.showSchema
+	w.showSchema = false
+	if err := e.ExportIndex(idx); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// exportedIndexRow is the JSON record of a row of an index.
+type exportedIndexRow struct {
+	Table    string `json:"table"`
+	BucketID string `json:"bucketID,omitempty"`
+	ShardID  uint64 `json:"shardID,omitempty"`
+	Name     string `json:"name"`
+	Key      string `json:"key,omitempty"`
+	Value    string `json:"value,omitempty"`
+	SeriesID uint64 `json:"seriesID"`
+}
+
+type jsonIndexRowWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonIndexRowWriter) writeIndex(idx *tsi1.Index, bucketID string, shardID uint64) error {
+	return tsi1.WalkIndex(idx, func(row tsi1.IndexRow) error {
+		rec := exportedIndexRow{
+			Table:    "measurement_series",
+			BucketID: bucketID,
+			ShardID:  shardID,
+			Name:     string(row.Name),
+			SeriesID: row.SeriesID,
+		}
+		if row.Key != nil {
+			rec.Table = "tag_value_series"
+			rec.Key, rec.Value = string(row.Key), string(row.Value)
+		}
+		return w.enc.Encode(&rec)
+	})
+}
+
+func runExportIndex(w io.Writer, opts exportIndexOptions) error {
+	single := opts.seriesFilePath != "" || opts.indexPath != ""
+	if single && (opts.seriesFilePath == "" || opts.indexPath == "") {
+		return errors.New("--series-path and --index-path must be given together")
+	} else if single && (opts.bucketID != "" || opts.orgID != "") {
+		return errors.New("--bucket-id and --org-id cannot be used with --index-path")
+	}
+	if opts.bucketID != "" {
+		if _, err := influxdb.IDFromString(opts.bucketID); err != nil {
+			return fmt.Errorf("invalid bucket ID %q: %w", opts.bucketID, err)
 		}
-		defer sfile.Close()
+	}
 
-		// Open index.
-		idx := tsi1.NewIndex(sfile, "", tsi1.WithPath(dataPath), tsi1.DisableCompactions())
-		if err := idx.Open(); err != nil {
+	var buckets []*tsiBucket
+	if !single {
+		var err error
+		if buckets, err = findExportIndexBuckets(opts); err != nil {
 			return err
 		}
-		defer idx.Close()
+		if len(buckets) == 0 {
+			return errors.New("no shards found")
+		}
+	}
 
-		// Dump out index data.
-		w := bufio.NewWriter(os.Stdout)
-		e := tsi1.NewSQLIndexExporter(w)
-		if err := e.ExportIndex(idx); err != nil {
+	if opts.outputPath != "" {
+		f, err := os.Create(opts.outputPath)
+		if err != nil {
 			return err
-		} else if err := e.Close(); err != nil {
-			return err
-		} else if err := w.Flush(); err != nil {
+		}
+		defer f.Close()
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	var out indexRowWriter
+	switch opts.format {
+	case "sql":
+		out = &sqlIndexRowWriter{w: bw, showSchema: true}
+	case "json":
+		out = &jsonIndexRowWriter{enc: json.NewEncoder(bw)}
+	default:
+		return fmt.Errorf("invalid format %q: must be sql or json", opts.format)
+	}
+
+	if single {
+		if err := exportShardIndex(out, opts.seriesFilePath, opts.indexPath, "", 0); err != nil {
 			return err
 		}
-		return nil
+		return bw.Flush()
 	}
 
-	return cmd
+	for _, bkt := range buckets {
+		seriesFilePath := filepath.Join(bkt.path, tsdb.SeriesFileDirectory)
+		for _, sh := range bkt.shards {
+			if err := exportShardIndex(out, seriesFilePath, filepath.Join(sh.dataPath, "index"), bkt.id, sh.id); err != nil {
+				return fmt.Errorf("cannot export index of shard %d of bucket %s: %w", sh.id, bkt.id, err)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// findExportIndexBuckets returns the buckets under the data path matching the
+// bucket and organization filters.
+func findExportIndexBuckets(opts exportIndexOptions) ([]*tsiBucket, error) {
+	buckets, err := findTSIBuckets(opts.dataPath, "", opts.bucketID, nil)
+	if err != nil || opts.orgID == "" {
+		return buckets, err
+	}
+
+	orgID, err := influxdb.IDFromString(opts.orgID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid org ID %q: %w", opts.orgID, err)
+	}
+	ids, err := orgBucketIDs(opts.boltPath, *orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*tsiBucket
+	for _, bkt := range buckets {
+		if ids[bkt.id] {
+			matched = append(matched, bkt)
+		}
+	}
+	return matched, nil
+}
+
+// orgBucketIDs returns the IDs of the buckets of an organization in the bolt
+// file.
+func orgBucketIDs(boltPath string, orgID influxdb.ID) (map[string]bool, error) {
+	if _, err := os.Stat(boltPath); err != nil {
+		return nil, fmt.Errorf("unable to find bolt file: %w", err)
+	}
+
+	ctx := context.Background()
+	store := bolt.NewKVStore(zap.NewNop(), boltPath)
+	if err := store.Open(ctx); err != nil {
+		return nil, fmt.Errorf("%w; is the server still running?", err)
+	}
+	defer store.Close()
+
+	svc := tenant.NewService(tenant.NewStore(store))
+	ids := make(map[string]bool)
+	for offset := 0; ; offset += influxdb.MaxPageSize {
+		buckets, _, err := svc.FindBuckets(ctx, influxdb.BucketFilter{OrganizationID: &orgID}, influxdb.FindOptions{Offset: offset, Limit: influxdb.MaxPageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, bkt := range buckets {
+			ids[bkt.ID.String()] = true
+		}
+		if len(buckets) < influxdb.MaxPageSize {
+			return ids, nil
+		}
+	}
+}
+
+// exportShardIndex opens the index of a shard, writes its rows and closes it
+// again, so that only one index is held in memory at a time.
+func exportShardIndex(out indexRowWriter, seriesFilePath, indexPath, bucketID string, shardID uint64) error {
+	sfile := tsdb.NewSeriesFile(seriesFilePath)
+	if err := sfile.Open(); err != nil {
+		return err
+	}
+	defer sfile.Close()
+
+	// Series ID sets of tag values are not cached, as each is only read once.
+	idx := tsi1.NewIndex(sfile, "", tsi1.WithPath(indexPath), tsi1.DisableCompactions(), tsi1.WithSeriesIDCacheSize(0))
+	if err := idx.Open(); err != nil {
+		return err
+	}
+	defer idx.Close()
+
+	return out.writeIndex(idx, bucketID, shardID)
 }
@@ -0,0 +1,133 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestExportIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export-index-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The bolt file holds a bucket of each of two organizations.
+	ctx := context.Background()
+	boltPath := filepath.Join(dir, bolt.DefaultFilename)
+	store := bolt.NewKVStore(zaptest.NewLogger(t), boltPath, bolt.WithNoSync)
+	require.NoError(t, store.Open(ctx))
+	require.NoError(t, all.Up(ctx, zaptest.NewLogger(t), store))
+	svc := tenant.NewService(tenant.NewStore(store))
+	var orgs []*influxdb.Organization
+	var buckets []*influxdb.Bucket
+	for _, name := range []string{"a", "b"} {
+		org := &influxdb.Organization{Name: name}
+		require.NoError(t, svc.CreateOrganization(ctx, org))
+		bkt := &influxdb.Bucket{OrgID: org.ID, Name: name}
+		require.NoError(t, svc.CreateBucket(ctx, bkt))
+		orgs, buckets = append(orgs, org), append(buckets, bkt)
+	}
+	require.NoError(t, store.Close())
+
+	dataPath := filepath.Join(dir, "data")
+	for i, bkt := range buckets {
+		bucketPath := filepath.Join(dataPath, bkt.ID.String())
+		sfile := tsdb.NewSeriesFile(filepath.Join(bucketPath, tsdb.SeriesFileDirectory))
+		require.NoError(t, sfile.Open())
+		idx := openTestTSIIndex(t, sfile, filepath.Join(bucketPath, "autogen", "1"), "cpu,host=a")
+		require.NoError(t, idx.Close())
+		if i == 0 {
+			idx = openTestTSIIndex(t, sfile, filepath.Join(bucketPath, "autogen", "2"), "mem,host=b")
+			require.NoError(t, idx.Close())
+		}
+		require.NoError(t, sfile.Close())
+	}
+
+	export := func(t *testing.T, opts exportIndexOptions) string {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, runExportIndex(&buf, opts))
+		return buf.String()
+	}
+	exportJSON := func(t *testing.T, opts exportIndexOptions) []exportedIndexRow {
+		t.Helper()
+		opts.format = "json"
+		var rows []exportedIndexRow
+		for _, line := range strings.Split(strings.TrimSpace(export(t, opts)), "\n") {
+			var row exportedIndexRow
+			require.NoError(t, json.Unmarshal([]byte(line), &row))
+			rows = append(rows, row)
+		}
+		return rows
+	}
+
+	t.Run("single index", func(t *testing.T) {
+		bucketPath := filepath.Join(dataPath, buckets[0].ID.String())
+		out := export(t, exportIndexOptions{
+			seriesFilePath: filepath.Join(bucketPath, tsdb.SeriesFileDirectory),
+			indexPath:      filepath.Join(bucketPath, "autogen", "1", "index"),
+			format:         "sql",
+		})
+		assert.Contains(t, out, "CREATE TABLE IF NOT EXISTS measurement_series (\n\tname      TEXT NOT NULL,\n\tseries_id INTEGER NOT NULL\n);")
+		assert.Contains(t, out, "INSERT INTO measurement_series (name, series_id) VALUES ('cpu', ")
+		assert.NotContains(t, out, "mem")
+	})
+
+	t.Run("all shards", func(t *testing.T) {
+		out := export(t, exportIndexOptions{dataPath: dataPath, format: "sql"})
+		assert.Equal(t, 1, strings.Count(out, "CREATE TABLE IF NOT EXISTS measurement_series"))
+		assert.Equal(t, 3, strings.Count(out, "BEGIN TRANSACTION;"))
+		assert.Contains(t, out, "INSERT INTO tag_value_series (name, key, value, series_id, bucket_id, shard_id) VALUES ('mem', 'host', 'b', ")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		rows := exportJSON(t, exportIndexOptions{dataPath: dataPath, bucketID: buckets[0].ID.String()})
+		require.Len(t, rows, 4)
+		assert.Equal(t, exportedIndexRow{Table: "measurement_series", BucketID: buckets[0].ID.String(), ShardID: 1, Name: "cpu", SeriesID: rows[0].SeriesID}, rows[0])
+		assert.Equal(t, exportedIndexRow{Table: "tag_value_series", BucketID: buckets[0].ID.String(), ShardID: 1, Name: "cpu", Key: "host", Value: "a", SeriesID: rows[0].SeriesID}, rows[1])
+		assert.Equal(t, uint64(2), rows[2].ShardID)
+		assert.Equal(t, "mem", rows[2].Name)
+	})
+
+	t.Run("org filter", func(t *testing.T) {
+		rows := exportJSON(t, exportIndexOptions{dataPath: dataPath, boltPath: boltPath, orgID: orgs[1].ID.String()})
+		require.Len(t, rows, 2)
+		for _, row := range rows {
+			assert.Equal(t, buckets[1].ID.String(), row.BucketID)
+		}
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		path := filepath.Join(dir, "index.sql")
+		assert.Empty(t, export(t, exportIndexOptions{dataPath: dataPath, format: "sql", outputPath: path}))
+		buf, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(buf), "COMMIT;")
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []exportIndexOptions{
+			{indexPath: "index", format: "sql"},
+			{seriesFilePath: "series", indexPath: "index", bucketID: buckets[0].ID.String(), format: "sql"},
+			{dataPath: dataPath, bucketID: "nope", format: "sql"},
+			{dataPath: dataPath, format: "csv"},
+			{dataPath: dataPath, bucketID: "000000000000ffff", format: "sql"},
+		} {
+			assert.Error(t, runExportIndex(ioutil.Discard, opts))
+		}
+	})
+}
@@ -0,0 +1,417 @@
+package inspect
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/escape"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type exportLPOptions struct {
+	dataPath    string
+	walPath     string
+	bucketID    string
+	outputPath  string
+	measurement string
+	start       string
+	end         string
+	compress    bool
+}
+
+func NewExportLPCommand() *cobra.Command {
+	var opts exportLPOptions
+
+	cmd := &cobra.Command{
+		Use:   "export-lp",
+		Short: "Exports the TSM data of a bucket as line protocol",
+		Long: `
+This command reads the TSM files and WAL segments of each shard of a bucket,
+and writes the points they hold as line protocol, to a file or stdout. It only
+reads the data directory, so it can export data that influxd cannot start
+with, and should not be run against the data of a running influxd.
+
+The WAL of each shard is merged with its TSM files as influxd would: values
+written to the WAL replace those with the same timestamp in TSM files, and
+deletes in the WAL remove the values written before them.
+
+A field whose type differs from its type in an earlier shard is logged and
+skipped in that shard, so the export can be written to a bucket without being
+partially rejected.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExportLP(cmd.OutOrStdout(), cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine", "data"), "Path to the TSM data directory of the storage engine")
+	cmd.Flags().StringVar(&opts.walPath, "wal-path", filepath.Join(dir, "engine", "wal"), "Path to the WAL directory of the storage engine")
+	cmd.Flags().StringVar(&opts.bucketID, "bucket-id", "", "The ID of the bucket to export")
+	cmd.Flags().StringVar(&opts.outputPath, "output-path", "", "Path to the file to write line protocol to, stdout if not set")
+	cmd.Flags().StringVar(&opts.measurement, "measurement", "", "Only export the points of this measurement")
+	cmd.Flags().StringVar(&opts.start, "start", "", "Only export points at or after this time, in RFC3339 format")
+	cmd.Flags().StringVar(&opts.end, "end", "", "Only export points at or before this time, in RFC3339 format")
+	cmd.Flags().BoolVar(&opts.compress, "compress", false, "Compress the line protocol with gzip")
+	_ = cmd.MarkFlagRequired("bucket-id")
+
+	return cmd
+}
+
+// exportShard is a shard of the bucket being exported.
+type exportShard struct {
+	rp string
+	id uint64
+}
+
+// lpExporter writes the values of the shards of a bucket as line protocol.
+type lpExporter struct {
+	opts     exportLPOptions
+	w        *bufio.Writer
+	log      *zap.Logger
+	min, max int64
+
+	// The type of each field, keyed by measurement and field, as first
+	// exported.
+	fieldTypes map[string]string
+	points     int
+}
+
+func runExportLP(w, logW io.Writer, opts exportLPOptions) error {
+	if _, err := influxdb.IDFromString(opts.bucketID); err != nil {
+		return fmt.Errorf("invalid bucket ID %q: %w", opts.bucketID, err)
+	}
+
+	min, max := int64(math.MinInt64), int64(math.MaxInt64)
+	if opts.start != "" {
+		t, err := time.Parse(time.RFC3339Nano, opts.start)
+		if err != nil {
+			return fmt.Errorf("invalid start: %w", err)
+		}
+		min = t.UnixNano()
+	}
+	if opts.end != "" {
+		t, err := time.Parse(time.RFC3339Nano, opts.end)
+		if err != nil {
+			return fmt.Errorf("invalid end: %w", err)
+		}
+		max = t.UnixNano()
+	}
+	if min > max {
+		return errors.New("start is after end")
+	}
+
+	shards, err := findExportShards(opts)
+	if err != nil {
+		return err
+	}
+	if len(shards) == 0 {
+		return fmt.Errorf("no shards found for bucket %s", opts.bucketID)
+	}
+
+	log := logger.New(logW)
+
+	if opts.outputPath != "" {
+		f, err := os.Create(opts.outputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if opts.compress {
+		gw := gzip.NewWriter(w)
+		defer gw.Close()
+		w = gw
+	}
+
+	e := &lpExporter{
+		opts:       opts,
+		w:          bufio.NewWriter(w),
+		log:        log,
+		min:        min,
+		max:        max,
+		fieldTypes: make(map[string]string),
+	}
+	for _, sh := range shards {
+		if err := e.exportShard(sh); err != nil {
+			return err
+		}
+	}
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+
+	log.Info("Exported bucket", zap.String("bucket_id", opts.bucketID), zap.Int("shards", len(shards)), zap.Int("points", e.points))
+	return nil
+}
+
+// findExportShards returns the shards of the bucket found in the data or WAL
+// directories, as a shard whose data is not yet flushed has no TSM files.
+func findExportShards(opts exportLPOptions) ([]exportShard, error) {
+	seen := make(map[exportShard]struct{})
+	var shards []exportShard
+	for _, root := range []string{opts.dataPath, opts.walPath} {
+		rps, err := ioutil.ReadDir(filepath.Join(root, opts.bucketID))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, rp := range rps {
+			if !rp.IsDir() {
+				continue
+			}
+			dirs, err := ioutil.ReadDir(filepath.Join(root, opts.bucketID, rp.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, dir := range dirs {
+				id, err := strconv.ParseUint(dir.Name(), 10, 64)
+				if err != nil || !dir.IsDir() {
+					continue
+				}
+				sh := exportShard{rp: rp.Name(), id: id}
+				if _, ok := seen[sh]; !ok {
+					seen[sh] = struct{}{}
+					shards = append(shards, sh)
+				}
+			}
+		}
+	}
+
+	sort.Slice(shards, func(i, j int) bool {
+		if shards[i].rp != shards[j].rp {
+			return shards[i].rp < shards[j].rp
+		}
+		return shards[i].id < shards[j].id
+	})
+	return shards, nil
+}
+
+// readWAL returns the values of the WAL segments in dir, applying the
+// entries in the order they were written, as the cache does when influxd
+// loads the WAL. Deletes in the WAL only remove values written to the WAL
+// before them, as those in TSM files are removed by their tombstones.
+func readWAL(dir string) (map[string]tsm1.Values, error) {
+	values := make(map[string]tsm1.Values)
+	segments, err := filepath.Glob(filepath.Join(dir, "*."+tsm1.WALFileExtension))
+	if err != nil {
+		return nil, err
+	}
+	// Segment names are zero padded sequence numbers.
+	sort.Strings(segments)
+
+	deleteRange := func(keys [][]byte, min, max int64) {
+		for _, key := range keys {
+			k := string(key)
+			if vals, ok := values[k]; ok {
+				values[k] = vals.Deduplicate().Exclude(min, max)
+			}
+		}
+	}
+
+	for _, path := range segments {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		r := tsm1.NewWALSegmentReader(f)
+		for r.Next() {
+			entry, err := r.Read()
+			if err != nil {
+				// As when influxd loads the WAL, the rest of a segment
+				// that cannot be read is skipped.
+				break
+			}
+			switch e := entry.(type) {
+			case *tsm1.WriteWALEntry:
+				for key, vals := range e.Values {
+					values[key] = append(values[key], vals...)
+				}
+			case *tsm1.DeleteWALEntry:
+				deleteRange(e.Keys, math.MinInt64, math.MaxInt64)
+			case *tsm1.DeleteRangeWALEntry:
+				deleteRange(e.Keys, e.Min, e.Max)
+			}
+		}
+		r.Close()
+	}
+	return values, nil
+}
+
+// exportShard writes the values of the TSM files and WAL of the shard.
+func (e *lpExporter) exportShard(sh exportShard) error {
+	shardID := strconv.FormatUint(sh.id, 10)
+	log := e.log.With(zap.String("rp", sh.rp), zap.Uint64("shard_id", sh.id))
+
+	paths, err := filepath.Glob(filepath.Join(e.opts.dataPath, e.opts.bucketID, sh.rp, shardID, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		return err
+	}
+	// Files are named by generation, so later files replace earlier ones.
+	sort.Strings(paths)
+
+	var readers []*tsm1.TSMReader
+	defer func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}()
+	keys := make(map[string]struct{})
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		r, err := tsm1.NewTSMReader(f)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("unable to read %s: %w", path, err)
+		}
+		readers = append(readers, r)
+		for i := 0; i < r.KeyCount(); i++ {
+			key, _ := r.KeyAt(i)
+			keys[string(key)] = struct{}{}
+		}
+	}
+
+	wal, err := readWAL(filepath.Join(e.opts.walPath, e.opts.bucketID, sh.rp, shardID))
+	if err != nil {
+		return err
+	}
+	for key := range wal {
+		keys[key] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	log.Info("Exporting shard", zap.Int("tsm_files", len(readers)), zap.Int("keys", len(sorted)))
+	for _, key := range sorted {
+		seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey([]byte(key))
+		name := models.ParseName(seriesKey)
+		if e.opts.measurement != "" && string(name) != e.opts.measurement {
+			continue
+		}
+
+		var vals tsm1.Values
+		for _, r := range readers {
+			if !r.Contains([]byte(key)) {
+				continue
+			}
+			v, err := r.ReadAll([]byte(key))
+			if err != nil {
+				return fmt.Errorf("unable to read %s from %s: %w", key, r.Path(), err)
+			}
+			vals = vals.Merge(v)
+		}
+		vals = vals.Merge(wal[key].Deduplicate())
+		if vals = vals.Include(e.min, e.max); len(vals) == 0 {
+			continue
+		}
+
+		if err := e.writeValues(log, seriesKey, name, field, vals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeValues writes the values of a field of a series as lines of line
+// protocol, skipping those whose type differs from the type the field was
+// first exported with.
+func (e *lpExporter) writeValues(log *zap.Logger, seriesKey, name, field []byte, vals tsm1.Values) error {
+	fieldKey := string(name) + "\x00" + string(field)
+	escapedField := escape.Bytes(field)
+
+	var skipped int
+	for _, v := range vals {
+		typ := lpValueType(v)
+		if t, ok := e.fieldTypes[fieldKey]; !ok {
+			e.fieldTypes[fieldKey] = typ
+		} else if t != typ {
+			skipped++
+			continue
+		}
+
+		e.w.Write(seriesKey)
+		e.w.WriteByte(' ')
+		e.w.Write(escapedField)
+		e.w.WriteByte('=')
+		e.w.WriteString(lpValue(v))
+		e.w.WriteByte(' ')
+		e.w.WriteString(strconv.FormatInt(v.UnixNano(), 10))
+		if err := e.w.WriteByte('\n'); err != nil {
+			return err
+		}
+		e.points++
+	}
+
+	if skipped > 0 {
+		log.Warn("Skipping values of conflicting field type",
+			zap.ByteString("series", seriesKey),
+			zap.ByteString("field", field),
+			zap.String("type", e.fieldTypes[fieldKey]),
+			zap.Int("skipped", skipped))
+	}
+	return nil
+}
+
+func lpValueType(v tsm1.Value) string {
+	switch v.(type) {
+	case tsm1.FloatValue:
+		return "float"
+	case tsm1.IntegerValue:
+		return "integer"
+	case tsm1.UnsignedValue:
+		return "unsigned"
+	case tsm1.BooleanValue:
+		return "boolean"
+	case tsm1.StringValue:
+		return "string"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// lpValue formats the value of v as a line protocol field value.
+func lpValue(v tsm1.Value) string {
+	switch v := v.Value().(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10) + "i"
+	case uint64:
+		return strconv.FormatUint(v, 10) + "u"
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return `"` + models.EscapeStringField(v) + `"`
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,134 @@
+package inspect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTSMValues writes a TSM file holding the values of each key at path.
+func writeTSMValues(t *testing.T, path string, values map[string]tsm1.Values) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := tsm1.NewTSMWriter(f)
+	require.NoError(t, err)
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	// Keys must be written in order.
+	sort.Strings(keys)
+	for _, key := range keys {
+		require.NoError(t, w.Write([]byte(key), values[key]))
+	}
+	require.NoError(t, w.WriteIndex())
+	require.NoError(t, w.Close())
+}
+
+func TestExportLP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export-lp-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const bucket = "0000000000000001"
+	dataPath, walPath := filepath.Join(dir, "data"), filepath.Join(dir, "wal")
+
+	// Shard 1 has two TSM files, the later replacing a value of the earlier,
+	// and a WAL replacing and deleting values of both.
+	writeTSMValues(t, filepath.Join(dataPath, bucket, "autogen", "1", "000000001-000000001.tsm"), map[string]tsm1.Values{
+		"cpu,host=a#!~#usage":  {tsm1.NewValue(1e9, 1.5), tsm1.NewValue(2e9, 2.5)},
+		"cpu,host=a#!~#status": {tsm1.NewValue(1e9, `a "quoted" string`)},
+		"mem,host=a#!~#free":   {tsm1.NewValue(1e9, int64(10))},
+	})
+	writeTSMValues(t, filepath.Join(dataPath, bucket, "autogen", "1", "000000002-000000001.tsm"), map[string]tsm1.Values{
+		"cpu,host=a#!~#usage": {tsm1.NewValue(2e9, 3.5)},
+		"mem,host=a#!~#used":  {tsm1.NewValue(1e9, uint64(20)), tsm1.NewValue(2e9, uint64(30))},
+	})
+	writeWALSegment(t, filepath.Join(walPath, bucket, "autogen", "1", "_00001.wal"),
+		&tsm1.WriteWALEntry{Values: map[string][]tsm1.Value{
+			"cpu,host=a#!~#usage": {tsm1.NewValue(3e9, 4.5), tsm1.NewValue(1e9, 0.5)},
+			"cpu,host=b#!~#up":    {tsm1.NewValue(1e9, true), tsm1.NewValue(2e9, false)},
+			"cpu,host=b#!~#a b=c": {tsm1.NewValue(1e9, 1.0)},
+		}},
+		&tsm1.DeleteRangeWALEntry{Keys: [][]byte{[]byte("cpu,host=b#!~#up")}, Min: 2e9, Max: 2e9},
+	)
+	// Shard 2 has only a WAL, and conflicts with the type of a field of shard 1.
+	writeWALSegment(t, filepath.Join(walPath, bucket, "autogen", "2", "_00001.wal"),
+		&tsm1.WriteWALEntry{Values: map[string][]tsm1.Value{
+			"mem,host=b#!~#free": {tsm1.NewValue(5e9, 1.5)},
+			"mem,host=b#!~#used": {tsm1.NewValue(5e9, uint64(40))},
+		}},
+	)
+
+	const all = `cpu,host=a status="a \"quoted\" string" 1000000000
+cpu,host=a usage=0.5 1000000000
+cpu,host=a usage=3.5 2000000000
+cpu,host=a usage=4.5 3000000000
+cpu,host=b a\ b\=c=1 1000000000
+cpu,host=b up=true 1000000000
+mem,host=a free=10i 1000000000
+mem,host=a used=20u 1000000000
+mem,host=a used=30u 2000000000
+mem,host=b used=40u 5000000000
+`
+
+	t.Run("all points", func(t *testing.T) {
+		var buf, logs bytes.Buffer
+		opts := exportLPOptions{dataPath: dataPath, walPath: walPath, bucketID: bucket}
+		require.NoError(t, runExportLP(&buf, &logs, opts))
+		assert.Equal(t, all, buf.String())
+		assert.Contains(t, logs.String(), "Skipping values of conflicting field type")
+		assert.Contains(t, logs.String(), "mem,host=b")
+	})
+
+	t.Run("measurement and time range", func(t *testing.T) {
+		var buf bytes.Buffer
+		opts := exportLPOptions{
+			dataPath:    dataPath,
+			walPath:     walPath,
+			bucketID:    bucket,
+			measurement: "cpu",
+			start:       "1970-01-01T00:00:02Z",
+			end:         "1970-01-01T00:00:03Z",
+		}
+		require.NoError(t, runExportLP(&buf, ioutil.Discard, opts))
+		assert.Equal(t, "cpu,host=a usage=3.5 2000000000\ncpu,host=a usage=4.5 3000000000\n", buf.String())
+	})
+
+	t.Run("compressed to a file", func(t *testing.T) {
+		path := filepath.Join(dir, "export.lp.gz")
+		opts := exportLPOptions{dataPath: dataPath, walPath: walPath, bucketID: bucket, outputPath: path, compress: true}
+		require.NoError(t, runExportLP(ioutil.Discard, ioutil.Discard, opts))
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		r, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, all, string(b))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		err := runExportLP(ioutil.Discard, ioutil.Discard, exportLPOptions{dataPath: dataPath, walPath: walPath, bucketID: "bogus"})
+		assert.Error(t, err)
+		err = runExportLP(ioutil.Discard, ioutil.Discard, exportLPOptions{dataPath: dataPath, walPath: walPath, bucketID: bucket, start: "yesterday"})
+		assert.Error(t, err)
+		err = runExportLP(ioutil.Discard, ioutil.Discard, exportLPOptions{dataPath: dataPath, walPath: walPath, bucketID: bucket, start: "1970-01-01T00:00:02Z", end: "1970-01-01T00:00:01Z"})
+		assert.EqualError(t, err, "start is after end")
+		err = runExportLP(ioutil.Discard, ioutil.Discard, exportLPOptions{dataPath: dataPath, walPath: walPath, bucketID: "0000000000000002"})
+		assert.EqualError(t, err, "no shards found for bucket 0000000000000002")
+	})
+}
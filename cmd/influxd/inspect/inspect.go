@@ -18,17 +18,22 @@ func NewCommand() *cobra.Command {
 	// List of available sub-commands
 	// If a new sub-command is created, it must be added here
 	subCommands := []*cobra.Command{
-		//NewBuildTSICommand(),
+		NewBuildTSICommand(),
+		NewCheckSchemaCommand(),
 		//NewCompactSeriesFileCommand(),
-		//NewExportBlocksCommand(),
+		NewExportBlocksCommand(),
 		NewExportIndexCommand(),
-		//NewReportTSMCommand(),
-		//NewVerifyTSMCommand(),
+		NewExportLPCommand(),
+		NewReportOrphansCommand(),
+		NewReportTSMCommand(),
+		NewVerifyTSMCommand(),
+		NewVerifyRetentionCommand(),
 		//NewVerifyWALCommand(),
-		//NewReportTSICommand(),
-		//NewVerifySeriesFileCommand(),
-		//NewDumpWALCommand(),
-		//NewDumpTSICommand(),
+		NewReportTSICommand(),
+		NewVerifySeriesFileCommand(),
+		NewDumpWALCommand(),
+		NewDumpTSICommand(),
+		NewDeleteTSMCommand(),
 	}
 
 	base.AddCommand(subCommands...)
@@ -0,0 +1,147 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type reportOrphansOptions struct {
+	dataDir   string
+	walDir    string
+	boltPath  string
+	trashDir  string
+	remove    bool
+	keepTrash bool
+	olderThan time.Duration
+	json      bool
+}
+
+func NewReportOrphansCommand() *cobra.Command {
+	var opts reportOrphansOptions
+
+	cmd := &cobra.Command{
+		Use:   `report-orphans`,
+		Short: "Reports shard directories whose bucket or shard no longer exists",
+		Long: `
+This command cross-references the bucket and shard directories of the
+storage engine with the buckets and the meta store in the bolt file, and
+lists the directories that no longer belong to either along with their
+size and last modification time.
+
+With --remove, orphaned directories not modified within --older-than are
+moved to the trash directory and then deleted. The server must be stopped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReportOrphans(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataDir, "data-dir", filepath.Join(dir, "engine", "data"), "Path to the data directory of the storage engine")
+	cmd.Flags().StringVar(&opts.walDir, "wal-dir", "", "Path to the WAL directory of the storage engine (defaults to wal next to the data directory)")
+	cmd.Flags().StringVar(&opts.boltPath, "bolt-path", filepath.Join(dir, bolt.DefaultFilename), "Path to the bolt file")
+	cmd.Flags().StringVar(&opts.trashDir, "trash-dir", "", "Directory orphaned directories are moved to before deletion (defaults to orphans-trash next to the data directory)")
+	cmd.Flags().BoolVar(&opts.remove, "remove", false, "Remove the orphaned directories")
+	cmd.Flags().BoolVar(&opts.keepTrash, "keep-trash", false, "Keep removed directories in the trash directory instead of deleting them")
+	cmd.Flags().DurationVar(&opts.olderThan, "older-than", 24*time.Hour, "Only remove directories not modified within this duration")
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Output the report as JSON")
+
+	return cmd
+}
+
+func runReportOrphans(w io.Writer, opts reportOrphansOptions) error {
+	if opts.walDir == "" {
+		opts.walDir = filepath.Join(filepath.Dir(opts.dataDir), "wal")
+	}
+	if opts.trashDir == "" {
+		opts.trashDir = filepath.Join(filepath.Dir(opts.dataDir), "orphans-trash")
+	}
+	if opts.olderThan < 0 {
+		return errors.New("--older-than must not be negative")
+	}
+	if _, err := os.Stat(opts.boltPath); err != nil {
+		return fmt.Errorf("unable to find bolt file: %w", err)
+	}
+
+	ctx := context.Background()
+	store := bolt.NewKVStore(zap.NewNop(), opts.boltPath)
+	if err := store.Open(ctx); err != nil {
+		return fmt.Errorf("%w; is the server still running?", err)
+	}
+	defer store.Close()
+
+	metaClient := meta.NewClient(meta.NewConfig(), store)
+	if err := metaClient.Open(); err != nil {
+		return err
+	}
+	defer metaClient.Close()
+
+	buckets := tenant.NewService(tenant.NewStore(store))
+	report, err := storage.FindOrphans(ctx, opts.dataDir, opts.walDir, metaClient, buckets)
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printOrphanReport(w, report)
+	}
+
+	if !opts.remove || len(report.Orphans) == 0 {
+		return nil
+	}
+
+	moved, trash, err := storage.RemoveOrphans(report.Orphans, opts.trashDir, time.Now().Add(-opts.olderThan))
+	var size int64
+	for _, o := range moved {
+		size += o.Size
+	}
+	fmt.Fprintf(w, "Moved %d of %d orphaned directories (%d bytes) to %s\n", len(moved), len(report.Orphans), size, trash)
+	if err != nil {
+		return fmt.Errorf("failed to move orphaned directories to trash: %w", err)
+	}
+
+	if opts.keepTrash || len(moved) == 0 {
+		return nil
+	}
+	if err := os.RemoveAll(trash); err != nil {
+		return fmt.Errorf("failed to delete trash directory %s: %w", trash, err)
+	}
+	fmt.Fprintf(w, "Deleted %s\n", trash)
+	return nil
+}
+
+func printOrphanReport(w io.Writer, report *influxdb.OrphanReport) {
+	tw := tabwriter.NewWriter(w, 15, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "Path\tReason\tSize\tLast Modified")
+	for _, o := range report.Orphans {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", o.Path, o.Reason, o.Size, o.ModTime.Format(time.RFC3339))
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\n%d orphaned directories, %d bytes\n", len(report.Orphans), report.TotalSize)
+}
@@ -0,0 +1,445 @@
+package inspect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/spf13/cobra"
+)
+
+type reportTSIOptions struct {
+	dataPath string
+	bucketID string
+	shardID  uint64
+	top      int
+	format   string
+}
+
+func NewReportTSICommand() *cobra.Command {
+	var opts reportTSIOptions
+
+	cmd := &cobra.Command{
+		Use:   `report-tsi`,
+		Short: "Reports the series cardinality of TSI indexes",
+		Long: `
+This command reads the TSI index of each shard under the data path, or of the
+shard given with --shard-id, and reports the series cardinality of each bucket
+along with its top measurements by series, tag keys by distinct values and tag
+values by series. Series are counted once per bucket, however many of its
+shards index them.
+
+The index and series files are only read, and the shards are not locked, so
+the command can be run against the data path of a running influxd. Files that
+influxd compacts away while they are read make the command fail, in which case
+it should be run again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReportTSI(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine", "data"), "Path to the TSM data directory of the storage engine")
+	cmd.Flags().StringVar(&opts.bucketID, "bucket-id", "", "Only report the shards of this bucket")
+	cmd.Flags().Uint64Var(&opts.shardID, "shard-id", 0, "Only report this shard")
+	cmd.Flags().IntVar(&opts.top, "top", 10, "The number of measurements, tag keys and tag values to report per bucket")
+	cmd.Flags().StringVar(&opts.format, "format", "text", "The output format: text or json")
+
+	return cmd
+}
+
+// tsiReport is the cardinality report of the buckets.
+type tsiReport struct {
+	Series  uint64             `json:"series"`
+	Buckets []*tsiBucketReport `json:"buckets"`
+}
+
+// tsiBucketReport is the cardinality report of a bucket.
+type tsiBucketReport struct {
+	BucketID     string                  `json:"bucketID"`
+	Shards       int                     `json:"shards"`
+	Series       uint64                  `json:"series"`
+	Measurements []*tsiMeasurementReport `json:"measurements"`
+	TagKeys      []*tsiTagKeyReport      `json:"tagKeys"`
+	TagValues    []*tsiTagValueReport    `json:"tagValues"`
+}
+
+type tsiMeasurementReport struct {
+	Name   string `json:"name"`
+	Series uint64 `json:"series"`
+}
+
+type tsiTagKeyReport struct {
+	Measurement string `json:"measurement"`
+	Key         string `json:"key"`
+	Values      int    `json:"values"`
+	Series      uint64 `json:"series"`
+}
+
+type tsiTagValueReport struct {
+	Measurement string `json:"measurement"`
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Series      uint64 `json:"series"`
+}
+
+func runReportTSI(w io.Writer, opts reportTSIOptions) error {
+	if opts.top <= 0 {
+		return errors.New("--top must be positive")
+	}
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("invalid format %q: must be text or json", opts.format)
+	}
+	if opts.bucketID != "" {
+		if _, err := influxdb.IDFromString(opts.bucketID); err != nil {
+			return fmt.Errorf("invalid bucket ID %q: %w", opts.bucketID, err)
+		}
+	}
+
+	var shardIDs []uint
+	if opts.shardID != 0 {
+		shardIDs = []uint{uint(opts.shardID)}
+	}
+	buckets, err := findTSIBuckets(opts.dataPath, "", opts.bucketID, shardIDs)
+	if err != nil {
+		return err
+	}
+	if len(buckets) == 0 {
+		return errors.New("no shards found")
+	}
+
+	report := &tsiReport{Buckets: []*tsiBucketReport{}}
+	for _, bkt := range buckets {
+		card, err := readBucketTSICardinality(bkt)
+		if err != nil {
+			return fmt.Errorf("cannot read index of bucket %s: %w", bkt.id, err)
+		}
+		r := card.report(opts.top)
+		r.BucketID = bkt.id
+		r.Shards = len(bkt.shards)
+		report.Buckets = append(report.Buckets, r)
+		report.Series += r.Series
+	}
+
+	if opts.format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return writeTSIReport(w, report, opts.top)
+}
+
+// tsiCardinality holds the series of a bucket by measurement and tag.
+type tsiCardinality struct {
+	series       *tsdb.SeriesIDSet
+	measurements map[string]*tsiMeasurementCardinality
+}
+
+type tsiMeasurementCardinality struct {
+	series *tsdb.SeriesIDSet
+	// tags holds the series of each value of each tag key.
+	tags map[string]map[string]*tsdb.SeriesIDSet
+}
+
+// readBucketTSICardinality reads the index of each selected shard of a
+// bucket. The series file and index files are opened without going through
+// the index, which would compact and remove files.
+func readBucketTSICardinality(bkt *tsiBucket) (*tsiCardinality, error) {
+	sfile := tsdb.NewSeriesFile(filepath.Join(bkt.path, tsdb.SeriesFileDirectory))
+	if err := sfile.Open(); err != nil {
+		return nil, err
+	}
+	defer sfile.Close()
+
+	card := &tsiCardinality{series: tsdb.NewSeriesIDSet(), measurements: make(map[string]*tsiMeasurementCardinality)}
+	for _, sh := range bkt.shards {
+		indexPath := filepath.Join(sh.dataPath, "index")
+		if ok, err := tsi1.IsIndexDir(indexPath); os.IsNotExist(err) || (err == nil && !ok) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		partitions, err := ioutil.ReadDir(indexPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range partitions {
+			path := filepath.Join(indexPath, p.Name())
+			if ok, err := tsi1.IsPartitionDir(path); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+			if err := card.readPartition(sfile, path); err != nil {
+				return nil, fmt.Errorf("shard %d: %w", sh.id, err)
+			}
+		}
+	}
+	return card, nil
+}
+
+// readPartition adds the series of the index partition at path.
+func (c *tsiCardinality) readPartition(sfile *tsdb.SeriesFile, path string) error {
+	m, _, err := tsi1.ReadManifestFile(filepath.Join(path, tsi1.ManifestFileName))
+	if err != nil {
+		return err
+	}
+
+	var files []tsi1.File
+	for _, name := range m.Files {
+		// Log files are opened for appending, which would recreate one
+		// compacted away since the manifest was read.
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			closeTSIFiles(files)
+			return err
+		}
+		switch filepath.Ext(name) {
+		case tsi1.LogFileExt:
+			f := tsi1.NewLogFile(sfile, filepath.Join(path, name))
+			if err := f.Open(); err != nil {
+				closeTSIFiles(files)
+				return err
+			}
+			files = append(files, f)
+		case tsi1.IndexFileExt:
+			f := tsi1.NewIndexFile(sfile)
+			f.SetPath(filepath.Join(path, name))
+			if err := f.Open(); err != nil {
+				closeTSIFiles(files)
+				return err
+			}
+			files = append(files, f)
+		}
+	}
+	defer closeTSIFiles(files)
+
+	fset, err := tsi1.NewFileSet(m.Levels, sfile, files)
+	if err != nil {
+		return err
+	}
+
+	mitr := fset.MeasurementIterator()
+	if mitr == nil {
+		return nil
+	}
+	for me := mitr.Next(); me != nil; me = mitr.Next() {
+		name := me.Name()
+		series, err := tsiSeriesIDSet(files, func(f tsi1.File) (*tsdb.SeriesIDSet, error) {
+			return seriesIDSetOf(f.MeasurementSeriesIDIterator(name))
+		})
+		if err != nil {
+			return err
+		} else if series.Cardinality() == 0 {
+			continue
+		}
+
+		mc := c.measurements[string(name)]
+		if mc == nil {
+			mc = &tsiMeasurementCardinality{series: tsdb.NewSeriesIDSet(), tags: make(map[string]map[string]*tsdb.SeriesIDSet)}
+			c.measurements[string(name)] = mc
+		}
+		mc.series.Merge(series)
+		c.series.Merge(series)
+
+		if err := mc.readTags(fset, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTags adds the series of each tag value of a measurement.
+func (mc *tsiMeasurementCardinality) readTags(fset *tsi1.FileSet, name []byte) error {
+	kitr := fset.TagKeyIterator(name)
+	if kitr == nil {
+		return nil
+	}
+	for ke := kitr.Next(); ke != nil; ke = kitr.Next() {
+		if ke.Deleted() {
+			continue
+		}
+		key := ke.Key()
+		vitr := fset.TagValueIterator(name, key)
+		if vitr == nil {
+			continue
+		}
+		for ve := vitr.Next(); ve != nil; ve = vitr.Next() {
+			if ve.Deleted() {
+				continue
+			}
+			itr, err := fset.TagValueSeriesIDIterator(name, key, ve.Value())
+			if err != nil {
+				return err
+			}
+			series, err := seriesIDSetOf(itr)
+			if err != nil {
+				return err
+			} else if series.Cardinality() == 0 {
+				continue
+			}
+
+			values := mc.tags[string(key)]
+			if values == nil {
+				values = make(map[string]*tsdb.SeriesIDSet)
+				mc.tags[string(key)] = values
+			}
+			if ss := values[string(ve.Value())]; ss != nil {
+				ss.Merge(series)
+			} else {
+				values[string(ve.Value())] = series
+			}
+		}
+	}
+	return nil
+}
+
+// tsiSeriesIDSet returns the series of the files of an index partition
+// returned by fn, without those tombstoned by newer files. Files are ordered
+// newest first.
+func tsiSeriesIDSet(files []tsi1.File, fn func(f tsi1.File) (*tsdb.SeriesIDSet, error)) (*tsdb.SeriesIDSet, error) {
+	ss := tsdb.NewSeriesIDSet()
+	var tss *tsdb.SeriesIDSet
+	for i := len(files) - 1; i >= 0; i-- {
+		if tss != nil && tss.Cardinality() > 0 {
+			ss = ss.AndNot(tss)
+		}
+		fss, err := fn(files[i])
+		if err != nil {
+			return nil, err
+		} else if fss != nil {
+			ss.Merge(fss)
+		}
+		if tss, err = files[i].TombstoneSeriesIDSet(); err != nil {
+			return nil, err
+		}
+	}
+	return ss, nil
+}
+
+// seriesIDSetOf returns the series of an iterator, which may be nil.
+func seriesIDSetOf(itr tsdb.SeriesIDIterator) (*tsdb.SeriesIDSet, error) {
+	ss := tsdb.NewSeriesIDSet()
+	if itr == nil {
+		return ss, nil
+	}
+	defer itr.Close()
+	if sitr, ok := itr.(tsdb.SeriesIDSetIterator); ok {
+		ss.Merge(sitr.SeriesIDSet())
+		return ss, nil
+	}
+	for {
+		e, err := itr.Next()
+		if err != nil {
+			return nil, err
+		} else if e.SeriesID == 0 {
+			return ss, nil
+		}
+		ss.AddNoLock(e.SeriesID)
+	}
+}
+
+func closeTSIFiles(files []tsi1.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// report returns the cardinality report of c, with the top n measurements,
+// tag keys and tag values.
+func (c *tsiCardinality) report(n int) *tsiBucketReport {
+	r := &tsiBucketReport{
+		Series:       c.series.Cardinality(),
+		Measurements: []*tsiMeasurementReport{},
+		TagKeys:      []*tsiTagKeyReport{},
+		TagValues:    []*tsiTagValueReport{},
+	}
+	for name, mc := range c.measurements {
+		r.Measurements = append(r.Measurements, &tsiMeasurementReport{Name: name, Series: mc.series.Cardinality()})
+		for key, values := range mc.tags {
+			keySeries := tsdb.NewSeriesIDSet()
+			for value, series := range values {
+				keySeries.Merge(series)
+				r.TagValues = append(r.TagValues, &tsiTagValueReport{Measurement: name, Key: key, Value: value, Series: series.Cardinality()})
+			}
+			r.TagKeys = append(r.TagKeys, &tsiTagKeyReport{Measurement: name, Key: key, Values: len(values), Series: keySeries.Cardinality()})
+		}
+	}
+
+	sort.Slice(r.Measurements, func(i, j int) bool {
+		a, b := r.Measurements[i], r.Measurements[j]
+		if a.Series != b.Series {
+			return a.Series > b.Series
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(r.TagKeys, func(i, j int) bool {
+		a, b := r.TagKeys[i], r.TagKeys[j]
+		if a.Values != b.Values {
+			return a.Values > b.Values
+		} else if a.Measurement != b.Measurement {
+			return a.Measurement < b.Measurement
+		}
+		return a.Key < b.Key
+	})
+	sort.Slice(r.TagValues, func(i, j int) bool {
+		a, b := r.TagValues[i], r.TagValues[j]
+		if a.Series != b.Series {
+			return a.Series > b.Series
+		} else if a.Measurement != b.Measurement {
+			return a.Measurement < b.Measurement
+		} else if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Value < b.Value
+	})
+
+	if len(r.Measurements) > n {
+		r.Measurements = r.Measurements[:n]
+	}
+	if len(r.TagKeys) > n {
+		r.TagKeys = r.TagKeys[:n]
+	}
+	if len(r.TagValues) > n {
+		r.TagValues = r.TagValues[:n]
+	}
+	return r
+}
+
+func writeTSIReport(w io.Writer, report *tsiReport, top int) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, r := range report.Buckets {
+		fmt.Fprintf(tw, "Bucket %s: %d series in %d shards\n", r.BucketID, r.Series, r.Shards)
+
+		fmt.Fprintf(tw, "\nTop %d measurements by series:\n", top)
+		for _, m := range r.Measurements {
+			fmt.Fprintf(tw, "  %s\t%d\n", m.Name, m.Series)
+		}
+		fmt.Fprintf(tw, "\nTop %d tag keys by values:\n", top)
+		for _, k := range r.TagKeys {
+			fmt.Fprintf(tw, "  %s\t%s\t%d values\t%d series\n", k.Measurement, k.Key, k.Values, k.Series)
+		}
+		fmt.Fprintf(tw, "\nTop %d tag values by series:\n", top)
+		for _, v := range r.TagValues {
+			fmt.Fprintf(tw, "  %s\t%s=%s\t%d\n", v.Measurement, v.Key, v.Value, v.Series)
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintf(tw, "Total: %d series in %d buckets\n", report.Series, len(report.Buckets))
+	return tw.Flush()
+}
@@ -0,0 +1,132 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/file"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestTSIIndex opens the index of a shard of a bucket with the given
+// series, as influxd would.
+func openTestTSIIndex(t *testing.T, sfile *tsdb.SeriesFile, shardPath string, series ...string) *tsi1.Index {
+	t.Helper()
+
+	idx := tsi1.NewIndex(sfile, "", tsi1.WithPath(filepath.Join(shardPath, "index")))
+	require.NoError(t, idx.Open())
+
+	var keys, names [][]byte
+	var tags []models.Tags
+	for _, s := range series {
+		name, tagSet := models.ParseKeyBytes([]byte(s))
+		keys = append(keys, []byte(s))
+		names = append(names, name)
+		tags = append(tags, tagSet)
+	}
+	require.NoError(t, idx.CreateSeriesListIfNotExists(keys, names, tags))
+	return idx
+}
+
+func TestReportTSI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report-tsi-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const bucketID, otherBucketID = "0000000000000001", "0000000000000002"
+	dataPath := filepath.Join(dir, "data")
+	bucketPath := filepath.Join(dataPath, bucketID)
+
+	sfile := tsdb.NewSeriesFile(filepath.Join(bucketPath, tsdb.SeriesFileDirectory))
+	require.NoError(t, sfile.Open())
+	defer sfile.Close()
+	idx1 := openTestTSIIndex(t, sfile, filepath.Join(bucketPath, "autogen", "1"),
+		"cpu,host=a", "cpu,host=b", "cpu,host=c", "mem,host=a")
+	defer idx1.Close()
+	// The series of cpu are spread across shards, and counted once.
+	idx2 := openTestTSIIndex(t, sfile, filepath.Join(bucketPath, "autogen", "2"),
+		"cpu,host=a", "cpu,host=d,region=west")
+	defer idx2.Close()
+
+	otherPath := filepath.Join(dataPath, otherBucketID)
+	otherSfile := tsdb.NewSeriesFile(filepath.Join(otherPath, tsdb.SeriesFileDirectory))
+	require.NoError(t, otherSfile.Open())
+	defer otherSfile.Close()
+	idx3 := openTestTSIIndex(t, otherSfile, filepath.Join(otherPath, "autogen", "3"), "disk,path=/")
+	defer idx3.Close()
+
+	// The shards are read while their indexes are open, and locked.
+	lock, err := file.TryLock(filepath.Join(bucketPath, "autogen", "1", tsdb.ShardLockFile))
+	require.NoError(t, err)
+	defer lock.Close()
+
+	report := func(t *testing.T, opts reportTSIOptions) *tsiReport {
+		t.Helper()
+		opts.dataPath = dataPath
+		opts.format = "json"
+		if opts.top == 0 {
+			opts.top = 10
+		}
+		var buf bytes.Buffer
+		require.NoError(t, runReportTSI(&buf, opts))
+		var r tsiReport
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &r))
+		return &r
+	}
+
+	t.Run("reports the cardinality of each bucket", func(t *testing.T) {
+		r := report(t, reportTSIOptions{})
+		assert.Equal(t, uint64(6), r.Series)
+		require.Len(t, r.Buckets, 2)
+
+		b := r.Buckets[0]
+		assert.Equal(t, bucketID, b.BucketID)
+		assert.Equal(t, 2, b.Shards)
+		assert.Equal(t, uint64(5), b.Series)
+		assert.Equal(t, []*tsiMeasurementReport{{Name: "cpu", Series: 4}, {Name: "mem", Series: 1}}, b.Measurements)
+		assert.Equal(t, []*tsiTagKeyReport{
+			{Measurement: "cpu", Key: "host", Values: 4, Series: 4},
+			{Measurement: "cpu", Key: "region", Values: 1, Series: 1},
+			{Measurement: "mem", Key: "host", Values: 1, Series: 1},
+		}, b.TagKeys)
+		require.Len(t, b.TagValues, 6)
+		assert.Equal(t, &tsiTagValueReport{Measurement: "cpu", Key: "host", Value: "a", Series: 1}, b.TagValues[0])
+
+		assert.Equal(t, otherBucketID, r.Buckets[1].BucketID)
+		assert.Equal(t, uint64(1), r.Buckets[1].Series)
+	})
+
+	t.Run("filters and limits", func(t *testing.T) {
+		r := report(t, reportTSIOptions{bucketID: bucketID, shardID: 2, top: 1})
+		require.Len(t, r.Buckets, 1)
+		b := r.Buckets[0]
+		assert.Equal(t, 1, b.Shards)
+		assert.Equal(t, uint64(2), b.Series)
+		assert.Equal(t, []*tsiMeasurementReport{{Name: "cpu", Series: 2}}, b.Measurements)
+		assert.Len(t, b.TagKeys, 1)
+		assert.Len(t, b.TagValues, 1)
+	})
+
+	t.Run("text output", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, runReportTSI(&buf, reportTSIOptions{dataPath: dataPath, bucketID: otherBucketID, top: 5, format: "text"}))
+		assert.Contains(t, buf.String(), "Bucket "+otherBucketID+": 1 series in 1 shards")
+		assert.Contains(t, buf.String(), "disk  path=/  1")
+		assert.Contains(t, buf.String(), "Total: 1 series in 1 buckets")
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		err := runReportTSI(ioutil.Discard, reportTSIOptions{dataPath: dataPath, top: 10, format: "csv"})
+		assert.EqualError(t, err, `invalid format "csv": must be text or json`)
+		err = runReportTSI(ioutil.Discard, reportTSIOptions{dataPath: dataPath, top: 10, format: "json", shardID: 9})
+		assert.EqualError(t, err, "shard 9 not found")
+	})
+}
@@ -0,0 +1,347 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/estimator/hll"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+type reportTSMOptions struct {
+	dataPath string
+	pattern  string
+	detailed bool
+	exact    bool
+}
+
+func NewReportTSMCommand() *cobra.Command {
+	var opts reportTSMOptions
+
+	cmd := &cobra.Command{
+		Use:   `report-tsm`,
+		Short: "Reports the series and blocks of the TSM files of the storage engine",
+		Long: `
+This command reads the index of each TSM file found under the data path and
+reports the number of series and blocks it holds, followed by the totals of
+all files and the series cardinality of each bucket.
+
+Shards are expected in the layout of the storage engine, at
+data/<bucket-id>/<retention-policy>/<shard-id>, below the data path.
+
+Cardinalities are estimated unless --exact is set, which counts them exactly
+at the cost of memory proportional to the number of series. With --detailed,
+the cardinality of each measurement, and the fields, tag keys and tag values
+of each measurement, are reported too.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReportTSM(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine"), "Path to the storage engine directory, or a data, bucket or shard directory within it")
+	cmd.Flags().StringVar(&opts.pattern, "pattern", "", "Only report TSM files whose path contains this string")
+	cmd.Flags().BoolVar(&opts.detailed, "detailed", false, "Report the cardinality of each measurement, and of its fields and tags")
+	cmd.Flags().BoolVar(&opts.exact, "exact", false, "Count cardinalities exactly instead of estimating them")
+
+	return cmd
+}
+
+// cardinality counts distinct values.
+type cardinality interface {
+	Add(v []byte)
+	Count() uint64
+}
+
+// exactCardinality counts distinct values exactly.
+type exactCardinality map[string]struct{}
+
+func (c exactCardinality) Add(v []byte)  { c[string(v)] = struct{}{} }
+func (c exactCardinality) Count() uint64 { return uint64(len(c)) }
+
+// tsmFileReport is the report of a single TSM file.
+type tsmFileReport struct {
+	bucket   string
+	rp       string
+	shard    string
+	path     string
+	series   uint64
+	blocks   int
+	minTime  int64
+	maxTime  int64
+	loadTime time.Duration
+}
+
+// tsmReport accumulates the report of TSM files.
+type tsmReport struct {
+	opts reportTSMOptions
+
+	files   []tsmFileReport
+	blocks  int
+	minTime int64
+	maxTime int64
+
+	series       cardinality
+	bucketSeries map[string]cardinality
+
+	// Detailed cardinalities, keyed by measurement and then by tag key.
+	measurements map[string]cardinality
+	fields       map[string]cardinality
+	tagKeys      map[string]cardinality
+	tagValues    map[string]map[string]cardinality
+}
+
+func newTSMReport(opts reportTSMOptions) *tsmReport {
+	r := &tsmReport{
+		opts:         opts,
+		minTime:      models.MaxNanoTime,
+		maxTime:      models.MinNanoTime,
+		bucketSeries: make(map[string]cardinality),
+		measurements: make(map[string]cardinality),
+		fields:       make(map[string]cardinality),
+		tagKeys:      make(map[string]cardinality),
+		tagValues:    make(map[string]map[string]cardinality),
+	}
+	r.series = r.newCardinality()
+	return r
+}
+
+func (r *tsmReport) newCardinality() cardinality {
+	if r.opts.exact {
+		return make(exactCardinality)
+	}
+	return hll.NewDefaultPlus()
+}
+
+// shardLocation returns the bucket ID, retention policy and shard ID of the
+// shard holding the TSM file at path, or "-" for those the path does not
+// follow the layout of.
+func shardLocation(path string) (bucket, rp, shard string) {
+	return shardDirLocation(filepath.Dir(path))
+}
+
+// shardDirLocation returns the bucket ID, retention policy and shard ID of
+// the shard directory shardDir, or "-" for those the path does not follow
+// the layout of.
+func shardDirLocation(shardDir string) (bucket, rp, shard string) {
+	bucket, rp, shard = "-", "-", "-"
+	rpDir := filepath.Dir(shardDir)
+	bucketDir := filepath.Dir(rpDir)
+	if _, err := strconv.ParseUint(filepath.Base(shardDir), 10, 64); err != nil {
+		return bucket, rp, shard
+	}
+	shard = filepath.Base(shardDir)
+	if _, err := influxdb.IDFromString(filepath.Base(bucketDir)); err != nil {
+		return bucket, rp, shard
+	}
+	return filepath.Base(bucketDir), filepath.Base(rpDir), shard
+}
+
+// addFile reads the index of the TSM file at path into the report.
+func (r *tsmReport) addFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	reader, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	defer reader.Close()
+	loadTime := time.Since(start)
+
+	file := tsmFileReport{path: path, loadTime: loadTime}
+	file.bucket, file.rp, file.shard = shardLocation(path)
+	file.minTime, file.maxTime = reader.TimeRange()
+
+	bucketSeries := r.bucketSeries[file.bucket]
+	if bucketSeries == nil {
+		bucketSeries = r.newCardinality()
+		r.bucketSeries[file.bucket] = bucketSeries
+	}
+
+	// Keys are sorted, so the fields of a series are adjacent.
+	var prevSeries []byte
+	var entries []tsm1.IndexEntry
+	for i := 0; i < reader.KeyCount(); i++ {
+		var key []byte
+		key, _, entries = reader.Key(i, &entries)
+		file.blocks += len(entries)
+
+		seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey(key)
+		if !sameKey(seriesKey, prevSeries) {
+			file.series++
+			prevSeries = append(prevSeries[:0], seriesKey...)
+		}
+		r.series.Add(seriesKey)
+		bucketSeries.Add(seriesKey)
+
+		if r.opts.detailed {
+			r.addDetailed(seriesKey, field)
+		}
+	}
+
+	r.blocks += file.blocks
+	if file.minTime < r.minTime {
+		r.minTime = file.minTime
+	}
+	if file.maxTime > r.maxTime {
+		r.maxTime = file.maxTime
+	}
+	r.files = append(r.files, file)
+	return nil
+}
+
+func sameKey(a, b []byte) bool {
+	return b != nil && string(a) == string(b)
+}
+
+// addDetailed counts the series, field and tags of a key by measurement.
+func (r *tsmReport) addDetailed(seriesKey, field []byte) {
+	measurement, tags := models.ParseKeyBytes(seriesKey)
+	name := string(measurement)
+
+	if r.measurements[name] == nil {
+		r.measurements[name] = r.newCardinality()
+		r.fields[name] = r.newCardinality()
+		r.tagKeys[name] = r.newCardinality()
+		r.tagValues[name] = make(map[string]cardinality)
+	}
+	r.measurements[name].Add(seriesKey)
+	r.fields[name].Add(field)
+	for _, tag := range tags {
+		r.tagKeys[name].Add(tag.Key)
+		values := r.tagValues[name][string(tag.Key)]
+		if values == nil {
+			values = r.newCardinality()
+			r.tagValues[name][string(tag.Key)] = values
+		}
+		values.Add(tag.Value)
+	}
+}
+
+func runReportTSM(w io.Writer, opts reportTSMOptions) error {
+	if _, err := os.Stat(opts.dataPath); err != nil {
+		return fmt.Errorf("unable to find data path: %w", err)
+	}
+
+	var paths []string
+	err := filepath.Walk(opts.dataPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != "."+tsm1.TSMFileExtension {
+			return nil
+		}
+		if opts.pattern != "" && !strings.Contains(path, opts.pattern) {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("no TSM files found")
+	}
+
+	start := time.Now()
+	report := newTSMReport(opts)
+	for _, path := range paths {
+		if err := report.addFile(path); err != nil {
+			return err
+		}
+	}
+	report.print(w, time.Since(start))
+	return nil
+}
+
+func (r *tsmReport) print(w io.Writer, took time.Duration) {
+	est := " (est)"
+	if r.opts.exact {
+		est = ""
+	}
+
+	tw := tabwriter.NewWriter(w, 8, 2, 1, ' ', 0)
+	fmt.Fprintln(tw, "Bucket\tRP\tShard\tFile\tSeries\tBlocks\tMin Time\tMax Time\tLoad Time")
+	for _, f := range r.files {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			f.bucket, f.rp, f.shard, filepath.Base(f.path), f.series, f.blocks,
+			formatNanoTime(f.minTime), formatNanoTime(f.maxTime), f.loadTime)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  Files: %d\n", len(r.files))
+	fmt.Fprintf(w, "  Blocks: %d\n", r.blocks)
+	fmt.Fprintf(w, "  Time Range: %s - %s\n", formatNanoTime(r.minTime), formatNanoTime(r.maxTime))
+	fmt.Fprintf(w, "  Duration: %s\n", time.Duration(r.maxTime-r.minTime))
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Statistics:")
+	tw = tabwriter.NewWriter(w, 8, 2, 1, ' ', 0)
+	fmt.Fprintf(tw, "Bucket\tSeries%s\n", est)
+	for _, bucket := range sortedKeys(r.bucketSeries) {
+		fmt.Fprintf(tw, "%s\t%d\n", bucket, r.bucketSeries[bucket].Count())
+	}
+	fmt.Fprintf(tw, "Total\t%d\n", r.series.Count())
+	_ = tw.Flush()
+
+	if r.opts.detailed {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 8, 2, 1, ' ', 0)
+		fmt.Fprintf(tw, "Measurement\tSeries%s\tFields%s\tTag Keys%s\n", est, est, est)
+		for _, name := range sortedKeys(r.measurements) {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", name, r.measurements[name].Count(), r.fields[name].Count(), r.tagKeys[name].Count())
+		}
+		_ = tw.Flush()
+
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 8, 2, 1, ' ', 0)
+		fmt.Fprintf(tw, "Measurement\tTag Key\tTag Values%s\n", est)
+		for _, name := range sortedKeys(r.measurements) {
+			values := r.tagValues[name]
+			for _, key := range sortedKeys(values) {
+				fmt.Fprintf(tw, "%s\t%s\t%d\n", name, key, values[key].Count())
+			}
+		}
+		_ = tw.Flush()
+	}
+
+	fmt.Fprintf(w, "\nCompleted in %s\n", took)
+}
+
+func formatNanoTime(ns int64) string {
+	return time.Unix(0, ns).UTC().Format(time.RFC3339Nano)
+}
+
+func sortedKeys(m map[string]cardinality) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
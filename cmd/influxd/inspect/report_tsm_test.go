@@ -0,0 +1,117 @@
+package inspect
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTSMFile writes a TSM file holding one value per key at path.
+func writeTSMFile(t *testing.T, path string, keys ...string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := tsm1.NewTSMWriter(f)
+	require.NoError(t, err)
+	for i, key := range keys {
+		require.NoError(t, w.Write([]byte(key), tsm1.Values{tsm1.NewValue(int64(i+1)*1e9, float64(i))}))
+	}
+	require.NoError(t, w.WriteIndex())
+	require.NoError(t, w.Close())
+}
+
+func TestReportTSM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report-tsm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	const bucketA, bucketB = "0000000000000001", "0000000000000002"
+	writeTSMFile(t, filepath.Join(dir, "data", bucketA, "autogen", "1", "000000001-000000001.tsm"),
+		"cpu,host=a#!~#idle",
+		"cpu,host=a#!~#user",
+		"cpu,host=b#!~#idle",
+		"mem,host=a#!~#free",
+	)
+	writeTSMFile(t, filepath.Join(dir, "data", bucketA, "autogen", "2", "000000001-000000001.tsm"),
+		"cpu,host=a#!~#idle",
+		"cpu,host=c#!~#idle",
+	)
+	writeTSMFile(t, filepath.Join(dir, "data", bucketB, "autogen", "3", "000000001-000000001.tsm"),
+		"disk,host=a,path=/#!~#used",
+	)
+
+	t.Run("exact", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runReportTSM(&out, reportTSMOptions{dataPath: dir, exact: true, detailed: true}))
+		lines := strings.Split(out.String(), "\n")
+
+		assertLine := func(fields ...string) {
+			t.Helper()
+			for _, line := range lines {
+				if strings.Join(strings.Fields(line), " ") == strings.Join(fields, " ") {
+					return
+				}
+			}
+			t.Errorf("missing line %q in:\n%s", strings.Join(fields, " "), out.String())
+		}
+
+		// Files, with their series and blocks.
+		for _, line := range lines {
+			if strings.HasPrefix(line, bucketA) && strings.Contains(line, " 1 ") && strings.Contains(line, ".tsm") {
+				assert.Equal(t, []string{bucketA, "autogen", "1", "000000001-000000001.tsm", "3", "4"}, strings.Fields(line)[:6])
+			}
+		}
+		assertLine("Files:", "3")
+		assertLine("Blocks:", "7")
+
+		// Series by bucket.
+		assertLine(bucketA, "4")
+		assertLine(bucketB, "1")
+		assertLine("Total", "5")
+
+		// Measurements, with their series, fields and tag keys.
+		assertLine("cpu", "3", "2", "1")
+		assertLine("mem", "1", "1", "1")
+		assertLine("disk", "1", "1", "2")
+		// Tag values.
+		assertLine("cpu", "host", "3")
+		assertLine("disk", "path", "1")
+	})
+
+	t.Run("estimated", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runReportTSM(&out, reportTSMOptions{dataPath: filepath.Join(dir, "data", bucketB)}))
+		assert.Contains(t, out.String(), "Series (est)")
+		assert.Contains(t, out.String(), "Files: 1\n")
+		assert.NotContains(t, out.String(), "Measurement")
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runReportTSM(&out, reportTSMOptions{dataPath: dir, pattern: bucketB, exact: true}))
+		assert.Contains(t, out.String(), "Files: 1\n")
+		assert.NotContains(t, out.String(), bucketA)
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		err := runReportTSM(ioutil.Discard, reportTSMOptions{dataPath: filepath.Join(dir, "data", bucketA, "missing")})
+		require.Error(t, err)
+	})
+}
+
+func TestShardLocation(t *testing.T) {
+	bucket, rp, shard := shardLocation(filepath.Join("engine", "data", "0000000000000001", "autogen", "12", "000000001-000000001.tsm"))
+	assert.Equal(t, []string{"0000000000000001", "autogen", "12"}, []string{bucket, rp, shard})
+
+	bucket, rp, shard = shardLocation(filepath.Join("backup", "000000001-000000001.tsm"))
+	assert.Equal(t, []string{"-", "-", "-"}, []string{bucket, rp, shard})
+}
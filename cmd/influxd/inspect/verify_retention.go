@@ -0,0 +1,166 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type verifyRetentionOptions struct {
+	dataDir       string
+	walDir        string
+	boltPath      string
+	json          bool
+	printCommands bool
+}
+
+func NewVerifyRetentionCommand() *cobra.Command {
+	var opts verifyRetentionOptions
+
+	cmd := &cobra.Command{
+		Use:   `verify-retention`,
+		Short: "Reports shards holding data past the retention period of their bucket",
+		Long: `
+This command compares the time ranges of the shards of every bucket with a
+retention period to that period, and lists the shards still on disk that
+should have been deleted, along with their size. Shards whose oldest data
+exceeds the retention period by more than the shard group duration, which
+the retention service deletes only once all their data is expired, are
+listed as partially expired.
+
+With --print-commands, the "influx delete" command deleting the data past
+the retention period of each bucket listed is printed too. Deletes must be
+supported by the server they are run against.
+
+Shards of buckets that no longer exist are not listed; use report-orphans
+to find them. The command fails if any shard is listed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerifyRetention(cmd.OutOrStdout(), opts, time.Now())
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataDir, "data-dir", filepath.Join(dir, "engine", "data"), "Path to the data directory of the storage engine")
+	cmd.Flags().StringVar(&opts.walDir, "wal-dir", "", "Path to the WAL directory of the storage engine (defaults to wal next to the data directory)")
+	cmd.Flags().StringVar(&opts.boltPath, "bolt-path", filepath.Join(dir, bolt.DefaultFilename), "Path to the bolt file")
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Output the report as JSON")
+	cmd.Flags().BoolVar(&opts.printCommands, "print-commands", false, "Print the influx delete commands deleting the data past the retention period of each bucket")
+
+	return cmd
+}
+
+// verifyRetentionReport is the JSON output of verify-retention.
+type verifyRetentionReport struct {
+	*storage.RetentionReport
+	Commands []string `json:"commands,omitempty"`
+}
+
+func runVerifyRetention(w io.Writer, opts verifyRetentionOptions, now time.Time) error {
+	if opts.walDir == "" {
+		opts.walDir = filepath.Join(filepath.Dir(opts.dataDir), "wal")
+	}
+	if _, err := os.Stat(opts.boltPath); err != nil {
+		return fmt.Errorf("unable to find bolt file: %w", err)
+	}
+
+	ctx := context.Background()
+	store := bolt.NewKVStore(zap.NewNop(), opts.boltPath)
+	if err := store.Open(ctx); err != nil {
+		return fmt.Errorf("%w; is the server still running?", err)
+	}
+	defer store.Close()
+
+	metaClient := meta.NewClient(meta.NewConfig(), store)
+	if err := metaClient.Open(); err != nil {
+		return err
+	}
+	defer metaClient.Close()
+
+	buckets := tenant.NewService(tenant.NewStore(store))
+	report, err := storage.VerifyRetention(ctx, opts.dataDir, opts.walDir, metaClient, buckets, now)
+	if err != nil {
+		return err
+	}
+
+	var commands []string
+	if opts.printCommands {
+		commands = retentionDeleteCommands(report)
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(verifyRetentionReport{RetentionReport: report, Commands: commands}); err != nil {
+			return err
+		}
+	} else {
+		printRetentionReport(w, report, commands)
+	}
+
+	if len(report.Shards) > 0 {
+		return fmt.Errorf("found %d shards holding data past the retention period of their bucket", len(report.Shards))
+	}
+	return nil
+}
+
+// retentionDeleteCommands returns, for each bucket with shards in report,
+// the influx delete command deleting its data past its retention period.
+func retentionDeleteCommands(report *storage.RetentionReport) []string {
+	// The shards are sorted by bucket; the delete of a bucket starts at the
+	// oldest of its shards.
+	var buckets []storage.ExpiredShard
+	for _, s := range report.Shards {
+		if n := len(buckets); n == 0 || buckets[n-1].BucketID != s.BucketID {
+			buckets = append(buckets, s)
+		} else if s.Start.Before(buckets[n-1].Start) {
+			buckets[n-1].Start = s.Start
+		}
+	}
+
+	commands := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		commands = append(commands, fmt.Sprintf("influx delete --org-id %s --bucket-id %s --start %s --stop %s",
+			b.OrgID, b.BucketID, b.Start.Format(time.RFC3339Nano), b.Cutoff.Format(time.RFC3339Nano)))
+	}
+	return commands
+}
+
+func printRetentionReport(w io.Writer, report *storage.RetentionReport, commands []string) {
+	tw := tabwriter.NewWriter(w, 15, 4, 1, ' ', 0)
+	fmt.Fprintln(tw, "Bucket\tBucket ID\tShard\tStart\tEnd\tCutoff\tStatus\tSize")
+	for _, s := range report.Shards {
+		status := "partially expired"
+		if s.Expired {
+			status = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%d\n", s.BucketName, s.BucketID, s.ShardID,
+			s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.Cutoff.Format(time.RFC3339), status, s.Size)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\n%d shards past retention, %d bytes, of which %d bytes fully expired\n", len(report.Shards), report.TotalSize, report.ExpiredSize)
+
+	if len(commands) > 0 {
+		fmt.Fprintln(w, "\nTo delete the data past retention:")
+		for _, c := range commands {
+			fmt.Fprintln(w, c)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package inspect
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyRetention_Commands(t *testing.T) {
+	date := func(d int) time.Time { return time.Date(2020, 9, d, 0, 0, 0, 0, time.UTC) }
+	report := &storage.RetentionReport{
+		Shards: []storage.ExpiredShard{
+			{OrgID: 0x10, BucketID: 0x1000, BucketName: "a", ShardID: 1, Start: date(5), End: date(6), Expired: true, Cutoff: date(20), Size: 10},
+			{OrgID: 0x10, BucketID: 0x1000, BucketName: "a", ShardID: 2, Start: date(1), End: date(2), Expired: true, Cutoff: date(20), Size: 20},
+			{OrgID: 0x10, BucketID: 0x2000, BucketName: "b", ShardID: 3, Start: date(10), End: date(25), Cutoff: date(22), Size: 30},
+		},
+		ExpiredSize: 30,
+		TotalSize:   60,
+	}
+
+	commands := retentionDeleteCommands(report)
+	assert.Equal(t, []string{
+		"influx delete --org-id 0000000000000010 --bucket-id 0000000000001000 --start 2020-09-01T00:00:00Z --stop 2020-09-20T00:00:00Z",
+		"influx delete --org-id 0000000000000010 --bucket-id 0000000000002000 --start 2020-09-10T00:00:00Z --stop 2020-09-22T00:00:00Z",
+	}, commands)
+
+	var out bytes.Buffer
+	printRetentionReport(&out, report, commands)
+	assert.Contains(t, out.String(), "3 shards past retention, 60 bytes, of which 30 bytes fully expired")
+	assert.Contains(t, out.String(), "partially expired")
+	assert.Contains(t, out.String(), commands[1])
+
+	assert.Empty(t, retentionDeleteCommands(&storage.RetentionReport{}))
+}
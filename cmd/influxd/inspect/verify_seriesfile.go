@@ -0,0 +1,170 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/cmd/influx_inspect/verify/seriesfile"
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+)
+
+type verifySeriesFileOptions struct {
+	dataPath   string
+	seriesFile string
+	concurrent int
+	verbose    bool
+}
+
+func NewVerifySeriesFileCommand() *cobra.Command {
+	var opts verifySeriesFileOptions
+
+	cmd := &cobra.Command{
+		Use:   "verify-seriesfile",
+		Short: "Verifies the integrity of series files",
+		Long: `
+This command verifies each partition of the series files of the buckets found
+under the data path, or of the single series file given with --series-file.
+
+The header of each segment is checked for its magic and version, and each
+entry for a valid flag, increasing series IDs and a key that parses. Series
+entries carry no checksum of their own. The index of the partition is then
+checked to map every series ID to the offset of its entry and its key back to
+it, and every tombstone is checked to be for a series that was inserted.
+
+The reasons partitions are corrupt are logged to stderr. The command prints a
+summary of the healthy and corrupt partitions, and exits with an error if any
+is corrupt.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerifySeriesFile(cmd.OutOrStdout(), cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine"), "Path to the storage engine directory, or a data or bucket directory within it, to find series files under")
+	cmd.Flags().StringVar(&opts.seriesFile, "series-file", "", "Path to the series file of a single bucket to verify, instead of those under the data path")
+	cmd.Flags().IntVarP(&opts.concurrent, "c", "c", runtime.GOMAXPROCS(0), "The number of partitions to verify concurrently")
+	cmd.Flags().BoolVarP(&opts.verbose, "v", "v", false, "Log the progress of the verification")
+
+	return cmd
+}
+
+// seriesFilePaths returns the series file given by opts, or those found under
+// the data path.
+func seriesFilePaths(opts verifySeriesFileOptions) ([]string, error) {
+	if opts.seriesFile != "" {
+		if _, err := os.Stat(opts.seriesFile); err != nil {
+			return nil, err
+		}
+		return []string{opts.seriesFile}, nil
+	}
+
+	if _, err := os.Stat(opts.dataPath); err != nil {
+		return nil, fmt.Errorf("unable to find data path: %w", err)
+	}
+	var found []string
+	err := filepath.Walk(opts.dataPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == tsdb.SeriesFileDirectory {
+			found = append(found, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return found, err
+}
+
+func runVerifySeriesFile(w, logW io.Writer, opts verifySeriesFileOptions) error {
+	if opts.concurrent <= 0 {
+		return errors.New("--c must be positive")
+	}
+
+	paths, err := seriesFilePaths(opts)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no series files found under %s", opts.dataPath)
+	}
+
+	var partitions []string
+	for _, path := range paths {
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if info.IsDir() {
+				partitions = append(partitions, filepath.Join(path, info.Name()))
+			}
+		}
+	}
+
+	config := logger.NewConfig()
+	if !opts.verbose {
+		config.Level = zapcore.ErrorLevel
+	}
+	log, err := config.New(logW)
+	if err != nil {
+		return err
+	}
+	verify := seriesfile.NewVerify()
+	verify.Logger = log
+
+	work := make(chan int, len(partitions))
+	for i := range partitions {
+		work <- i
+	}
+	close(work)
+
+	// A partition that cannot be verified at all is as corrupt as one that
+	// fails verification.
+	healthy := make([]bool, len(partitions))
+	errs := make([]error, len(partitions))
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				healthy[i], errs[i] = verify.VerifyPartition(partitions[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	var corrupt int
+	for i, path := range partitions {
+		switch {
+		case errs[i] != nil:
+			fmt.Fprintf(w, "%s: unable to verify partition: %v\n", path, errs[i])
+		case !healthy[i]:
+			fmt.Fprintf(w, "%s: corrupt\n", path)
+		default:
+			continue
+		}
+		corrupt++
+	}
+
+	fmt.Fprintf(w, "Partitions: %d healthy, %d corrupt, in %d series files\n", len(partitions)-corrupt, corrupt, len(paths))
+	if corrupt > 0 {
+		return fmt.Errorf("found corruption in %d of %d series file partitions", corrupt, len(partitions))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestSeriesFile creates the series file of a bucket with n series.
+func createTestSeriesFile(t *testing.T, path string, n int) {
+	t.Helper()
+
+	sfile := tsdb.NewSeriesFile(path)
+	require.NoError(t, sfile.Open())
+	defer sfile.Close()
+
+	var names [][]byte
+	var tags []models.Tags
+	for i := 0; i < n; i++ {
+		names = append(names, []byte("cpu"))
+		tags = append(tags, models.NewTags(map[string]string{"host": fmt.Sprintf("server-%d", i)}))
+	}
+	ids, err := sfile.CreateSeriesListIfNotExists(names, tags)
+	require.NoError(t, err)
+	require.NoError(t, sfile.DeleteSeriesID(ids[0]))
+}
+
+func TestVerifySeriesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify-seriesfile-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dataPath := filepath.Join(dir, "data")
+	corruptPath := filepath.Join(dataPath, "0000000000000001", tsdb.SeriesFileDirectory)
+	healthyPath := filepath.Join(dataPath, "0000000000000002", tsdb.SeriesFileDirectory)
+	createTestSeriesFile(t, corruptPath, 100)
+	createTestSeriesFile(t, healthyPath, 100)
+
+	t.Run("healthy series files", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, runVerifySeriesFile(&buf, ioutil.Discard, verifySeriesFileOptions{dataPath: dataPath, concurrent: 2}))
+		assert.Equal(t, fmt.Sprintf("Partitions: %d healthy, 0 corrupt, in 2 series files\n", 2*tsdb.SeriesFilePartitionN), buf.String())
+	})
+
+	segment := filepath.Join(corruptPath, "00", "0000")
+	fh, err := os.OpenFile(segment, os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = fh.WriteAt([]byte("BOGUS"), 0)
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+
+	t.Run("corrupt series files", func(t *testing.T) {
+		var buf, logs bytes.Buffer
+		err := runVerifySeriesFile(&buf, &logs, verifySeriesFileOptions{dataPath: dataPath, concurrent: 2})
+		assert.EqualError(t, err, fmt.Sprintf("found corruption in 1 of %d series file partitions", 2*tsdb.SeriesFilePartitionN))
+		assert.Contains(t, buf.String(), filepath.Join(corruptPath, "00")+": corrupt\n")
+		assert.Contains(t, buf.String(), fmt.Sprintf("Partitions: %d healthy, 1 corrupt, in 2 series files\n", 2*tsdb.SeriesFilePartitionN-1))
+		assert.Contains(t, logs.String(), "Error opening segment")
+	})
+
+	t.Run("a single series file", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, runVerifySeriesFile(&buf, ioutil.Discard, verifySeriesFileOptions{seriesFile: healthyPath, concurrent: 1}))
+		assert.Equal(t, fmt.Sprintf("Partitions: %d healthy, 0 corrupt, in 1 series files\n", tsdb.SeriesFilePartitionN), buf.String())
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		err := runVerifySeriesFile(ioutil.Discard, ioutil.Discard, verifySeriesFileOptions{dataPath: dataPath})
+		assert.EqualError(t, err, "--c must be positive")
+		err = runVerifySeriesFile(ioutil.Discard, ioutil.Discard, verifySeriesFileOptions{dataPath: filepath.Join(dir, "empty"), concurrent: 1})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,221 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/influxdb/v2/internal/fs"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+)
+
+// verifyTSMProgressInterval is how often progress is reported.
+const verifyTSMProgressInterval = 10 * time.Second
+
+type verifyTSMOptions struct {
+	dataPath  string
+	checkUTF8 bool
+
+	progressInterval time.Duration
+}
+
+func NewVerifyTSMCommand() *cobra.Command {
+	opts := verifyTSMOptions{progressInterval: verifyTSMProgressInterval}
+
+	cmd := &cobra.Command{
+		Use:   `verify-tsm [<path>...]`,
+		Short: "Verifies the checksums of the blocks of TSM files",
+		Long: `
+This command reads every block of the given TSM files, or of the TSM files
+found under the data path when none are given, and recomputes its checksum.
+Each block whose checksum does not match, or which cannot be read, is reported
+with its file, offset and series key.
+
+With --checkUTF8, the series keys of the files are instead checked to be valid
+UTF-8.
+
+Progress is reported to stderr. The command exits with an error if any
+corruption is found.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerifyTSM(cmd.OutOrStdout(), cmd.ErrOrStderr(), opts, args)
+		},
+	}
+
+	dir, err := fs.InfluxDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to determine influx directory: %v", err))
+	}
+
+	cmd.Flags().StringVar(&opts.dataPath, "data-path", filepath.Join(dir, "engine"), "Path to the storage engine directory, or a data, bucket or shard directory within it, to verify when no files are given")
+	cmd.Flags().BoolVar(&opts.checkUTF8, "checkUTF8", false, "Verify that the series keys are valid UTF-8 instead of verifying block checksums")
+
+	return cmd
+}
+
+// tsmFilePaths returns the TSM files at paths, or found under dataPath if
+// there are none.
+func tsmFilePaths(dataPath string, paths []string) ([]string, error) {
+	if len(paths) > 0 {
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				return nil, err
+			}
+		}
+		return paths, nil
+	}
+
+	if _, err := os.Stat(dataPath); err != nil {
+		return nil, fmt.Errorf("unable to find data path: %w", err)
+	}
+	var found []string
+	err := filepath.Walk(dataPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == "."+tsm1.TSMFileExtension {
+			found = append(found, path)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// verifyProgress periodically reports the progress of a verification.
+type verifyProgress struct {
+	w        io.Writer
+	interval time.Duration
+	last     time.Time
+
+	files, filesDone int
+	size, sizeDone   int64
+}
+
+// add records n more bytes as verified, and reports progress if it has not
+// been reported for an interval.
+func (p *verifyProgress) add(n int64) {
+	p.sizeDone += n
+	if p.interval <= 0 || time.Since(p.last) < p.interval {
+		return
+	}
+	p.last = time.Now()
+
+	var pct float64
+	if p.size > 0 {
+		pct = float64(p.sizeDone) / float64(p.size) * 100
+	}
+	fmt.Fprintf(p.w, "Verified %d of %d files, %d of %d bytes (%.1f%%)\n", p.filesDone, p.files, p.sizeDone, p.size, pct)
+}
+
+// verifyTSMResult counts the blocks, or keys, verified and found corrupt.
+type verifyTSMResult struct {
+	total   int
+	corrupt int
+	files   int
+}
+
+func runVerifyTSM(w, progressW io.Writer, opts verifyTSMOptions, args []string) error {
+	paths, err := tsmFilePaths(opts.dataPath, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("no TSM files found")
+	}
+
+	progress := &verifyProgress{w: progressW, interval: opts.progressInterval, last: time.Now(), files: len(paths)}
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil {
+			progress.size += fi.Size()
+		}
+	}
+
+	start := time.Now()
+	var result verifyTSMResult
+	for _, path := range paths {
+		corrupt := result.corrupt
+		if err := verifyTSMFile(w, opts, path, progress, &result); err != nil {
+			fmt.Fprintf(w, "%s: unable to read file: %v\n", path, err)
+			result.corrupt++
+		}
+		if result.corrupt > corrupt {
+			result.files++
+		}
+		progress.filesDone++
+	}
+
+	unit := "blocks"
+	if opts.checkUTF8 {
+		unit = "series keys"
+	}
+	fmt.Fprintf(w, "Broken %s: %d / %d, in %d of %d files, in %s\n", unit, result.corrupt, result.total, result.files, len(paths), time.Since(start))
+	if result.corrupt > 0 {
+		return fmt.Errorf("found corruption in %d of %d TSM files", result.files, len(paths))
+	}
+	return nil
+}
+
+// verifyTSMFile verifies the TSM file at path, reporting each corrupt block
+// or invalid key to w. It returns an error if the file cannot be read at all.
+func verifyTSMFile(w io.Writer, opts verifyTSMOptions, path string, progress *verifyProgress, result *verifyTSMResult) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	reader, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	defer reader.Close()
+
+	if opts.checkUTF8 {
+		for i := 0; i < reader.KeyCount(); i++ {
+			key, _ := reader.KeyAt(i)
+			result.total++
+			if !utf8.Valid(key) {
+				fmt.Fprintf(w, "%s: invalid UTF-8 series key %q\n", path, key)
+				result.corrupt++
+			}
+			progress.add(int64(len(key)))
+		}
+		return nil
+	}
+
+	var entries []tsm1.IndexEntry
+	for i := 0; i < reader.KeyCount(); i++ {
+		var key []byte
+		key, _, entries = reader.Key(i, &entries)
+		seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey(key)
+		for j := range entries {
+			entry := &entries[j]
+			result.total++
+			if err := verifyTSMBlock(reader, entry); err != nil {
+				fmt.Fprintf(w, "%s: block at offset %d of series key %q: %v\n", path, entry.Offset, seriesKey, err)
+				result.corrupt++
+			}
+			progress.add(int64(entry.Size))
+		}
+	}
+	return nil
+}
+
+// verifyTSMBlock recomputes the checksum of a block.
+func verifyTSMBlock(reader *tsm1.TSMReader, entry *tsm1.IndexEntry) error {
+	if entry.Size < crc32.Size {
+		return fmt.Errorf("invalid block size %d", entry.Size)
+	}
+	checksum, buf, err := reader.ReadBytes(entry, nil)
+	if err != nil {
+		return fmt.Errorf("unable to read block: %w", err)
+	}
+	if expected := crc32.ChecksumIEEE(buf); checksum != expected {
+		return fmt.Errorf("checksum mismatch: stored %08x, computed %08x", checksum, expected)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package inspect
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyTSM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify-tsm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	shardDir := filepath.Join(dir, "data", "0000000000000001", "autogen", "1")
+	good := filepath.Join(shardDir, "000000001-000000001.tsm")
+	writeTSMFile(t, good, "cpu,host=a#!~#idle", "cpu,host=b#!~#idle")
+
+	t.Run("valid files", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runVerifyTSM(&out, ioutil.Discard, verifyTSMOptions{dataPath: dir}, nil))
+		assert.Contains(t, out.String(), "Broken blocks: 0 / 2, in 0 of 1 files")
+	})
+
+	t.Run("corrupt block", func(t *testing.T) {
+		corrupt := filepath.Join(dir, "corrupt.tsm")
+		writeTSMFile(t, corrupt, "cpu,host=a#!~#idle", "cpu,host=b#!~#idle")
+		buf, err := ioutil.ReadFile(corrupt)
+		require.NoError(t, err)
+		// The first block follows the 5 byte header and its 4 byte checksum.
+		buf[10] ^= 0xff
+		require.NoError(t, ioutil.WriteFile(corrupt, buf, 0666))
+
+		var out, progress bytes.Buffer
+		err = runVerifyTSM(&out, &progress, verifyTSMOptions{progressInterval: time.Nanosecond}, []string{good, corrupt})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found corruption in 1 of 2 TSM files")
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.True(t, strings.HasPrefix(lines[0], corrupt+`: block at offset 5 of series key "cpu,host=a": checksum mismatch`), lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], "Broken blocks: 1 / 4, in 1 of 2 files"), lines[1])
+		assert.Contains(t, progress.String(), "Verified 1 of 2 files")
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		truncated := filepath.Join(dir, "truncated.tsm")
+		require.NoError(t, ioutil.WriteFile(truncated, []byte("not a tsm file"), 0666))
+
+		var out bytes.Buffer
+		err := runVerifyTSM(&out, ioutil.Discard, verifyTSMOptions{}, []string{truncated})
+		require.Error(t, err)
+		assert.Contains(t, out.String(), truncated+": unable to read file")
+	})
+
+	t.Run("check UTF-8", func(t *testing.T) {
+		invalid := filepath.Join(dir, "utf8", "000000001-000000001.tsm")
+		writeTSMFile(t, invalid, "cpu,host=a#!~#idle", "cpu,host=\xff#!~#idle")
+
+		var out bytes.Buffer
+		err := runVerifyTSM(&out, ioutil.Discard, verifyTSMOptions{dataPath: filepath.Join(dir, "utf8"), checkUTF8: true}, nil)
+		require.Error(t, err)
+		assert.Contains(t, out.String(), invalid+`: invalid UTF-8 series key "cpu,host=\xff#!~#idle"`)
+		assert.Contains(t, out.String(), "Broken series keys: 1 / 2, in 1 of 1 files")
+	})
+}
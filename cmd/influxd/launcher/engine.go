@@ -13,6 +13,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/summary"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
@@ -28,6 +29,10 @@ type Engine interface {
 	prom.PrometheusCollector
 	influxdb.BackupService
 	influxdb.RestoreService
+	storage.UsageEngine
+	storage.OrphanEngine
+	summary.Engine
+	influxdb.CacheService
 
 	SeriesCardinality(orgID, bucketID influxdb.ID) int64
 
@@ -127,6 +132,11 @@ func (t *TemporaryEngine) CreateBucket(ctx context.Context, b *influxdb.Bucket)
 	return t.engine.CreateBucket(ctx, b)
 }
 
+// CompactBucket snapshots the caches of a bucket and fully compacts it.
+func (t *TemporaryEngine) CompactBucket(ctx context.Context, bucketID influxdb.ID) error {
+	return t.engine.CompactBucket(ctx, bucketID)
+}
+
 func (t *TemporaryEngine) UpdateBucketRetentionPeriod(ctx context.Context, bucketID influxdb.ID, d time.Duration) error {
 	return t.engine.UpdateBucketRetentionPeriod(ctx, bucketID, d)
 }
@@ -170,14 +180,58 @@ func (t *TemporaryEngine) RestoreBucket(ctx context.Context, id influxdb.ID, dbi
 	return t.engine.RestoreBucket(ctx, id, dbi)
 }
 
+func (t *TemporaryEngine) ReplaceBucket(ctx context.Context, id influxdb.ID, dbi []byte) (map[uint64]uint64, error) {
+	return t.engine.ReplaceBucket(ctx, id, dbi)
+}
+
 func (t *TemporaryEngine) BackupShard(ctx context.Context, w io.Writer, shardID uint64, since time.Time) error {
 	return t.engine.BackupShard(ctx, w, shardID, since)
 }
 
+func (t *TemporaryEngine) BackupShardMeasurements(ctx context.Context, w io.Writer, shardID uint64, since time.Time, measurements []string) error {
+	return t.engine.BackupShardMeasurements(ctx, w, shardID, since, measurements)
+}
+
+func (t *TemporaryEngine) ShardSizes(ctx context.Context, shardIDs []uint64) ([]influxdb.ShardSize, error) {
+	return t.engine.ShardSizes(ctx, shardIDs)
+}
+
 func (t *TemporaryEngine) RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error {
 	return t.engine.RestoreShard(ctx, shardID, r)
 }
 
+func (t *TemporaryEngine) BucketShardDigests(ctx context.Context, id influxdb.ID) ([]influxdb.ShardDigest, error) {
+	return t.engine.BucketShardDigests(ctx, id)
+}
+
+func (t *TemporaryEngine) Usage(ctx context.Context, rng influxdb.Timespan) (map[influxdb.ID]influxdb.BucketUsage, influxdb.Timespan, error) {
+	return t.engine.Usage(ctx, rng)
+}
+
+func (t *TemporaryEngine) DiskUsage(ctx context.Context) (influxdb.DiskUsage, error) {
+	return t.engine.DiskUsage(ctx)
+}
+
+func (t *TemporaryEngine) SeriesN(ctx context.Context) (int64, error) {
+	return t.engine.SeriesN(ctx)
+}
+
+func (t *TemporaryEngine) Writes(ctx context.Context, rng influxdb.Timespan) (int64, int64, influxdb.Timespan, error) {
+	return t.engine.Writes(ctx, rng)
+}
+
+func (t *TemporaryEngine) Orphans(ctx context.Context, buckets storage.OrphanBucketFinder) (*influxdb.OrphanReport, error) {
+	return t.engine.Orphans(ctx, buckets)
+}
+
+func (t *TemporaryEngine) ShardCaches(ctx context.Context) ([]influxdb.ShardCache, error) {
+	return t.engine.ShardCaches(ctx)
+}
+
+func (t *TemporaryEngine) SnapshotBucketCaches(ctx context.Context, bucketID influxdb.ID) ([]influxdb.ShardCache, error) {
+	return t.engine.SnapshotBucketCaches(ctx, bucketID)
+}
+
 func (t *TemporaryEngine) TSDBStore() storage.TSDBStore {
 	return &t.tsdbStore
 }
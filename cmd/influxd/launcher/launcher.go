@@ -28,11 +28,13 @@ import (
 	"github.com/influxdata/influxdb/v2/fluxinit"
 	"github.com/influxdata/influxdb/v2/gather"
 	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/http/points"
 	iqlcontrol "github.com/influxdata/influxdb/v2/influxql/control"
 	iqlquery "github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/inmem"
 	"github.com/influxdata/influxdb/v2/internal/fs"
 	"github.com/influxdata/influxdb/v2/internal/resource"
+	"github.com/influxdata/influxdb/v2/job"
 	"github.com/influxdata/influxdb/v2/kit/cli"
 	"github.com/influxdata/influxdb/v2/kit/feature"
 	overrideflagger "github.com/influxdata/influxdb/v2/kit/feature/override"
@@ -46,15 +48,20 @@ import (
 	"github.com/influxdata/influxdb/v2/kv/migration/all"
 	"github.com/influxdata/influxdb/v2/label"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/maintenance"
 	"github.com/influxdata/influxdb/v2/nats"
+	"github.com/influxdata/influxdb/v2/notification/endpoint/delivery"
 	endpointservice "github.com/influxdata/influxdb/v2/notification/endpoint/service"
+	"github.com/influxdata/influxdb/v2/notification/health"
 	ruleservice "github.com/influxdata/influxdb/v2/notification/rule/service"
 	"github.com/influxdata/influxdb/v2/pkger"
 	infprom "github.com/influxdata/influxdb/v2/prometheus"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/query/control"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	"github.com/influxdata/influxdb/v2/query/slowquery"
 	"github.com/influxdata/influxdb/v2/query/stdlib/influxdata/influxdb"
+	"github.com/influxdata/influxdb/v2/quota"
 	"github.com/influxdata/influxdb/v2/secret"
 	"github.com/influxdata/influxdb/v2/session"
 	"github.com/influxdata/influxdb/v2/snowflake"
@@ -62,6 +69,7 @@ import (
 	"github.com/influxdata/influxdb/v2/storage"
 	storageflux "github.com/influxdata/influxdb/v2/storage/flux"
 	"github.com/influxdata/influxdb/v2/storage/readservice"
+	"github.com/influxdata/influxdb/v2/summary"
 	taskbackend "github.com/influxdata/influxdb/v2/task/backend"
 	"github.com/influxdata/influxdb/v2/task/backend/coordinator"
 	"github.com/influxdata/influxdb/v2/task/backend/executor"
@@ -121,7 +129,15 @@ func NewInfluxdCommand(ctx context.Context, v *viper.Viper) *cobra.Command {
 
 	A config file can be provided via the INFLUXD_CONFIG_PATH env var. If a file is
 	not provided via an env var, influxd will look in the current directory for a
-	config.{json|toml|yaml|yml} file. If one does not exist, then it will continue unchanged.`
+	config.{json|toml|yaml|yml} file. If one does not exist, then it will continue unchanged.
+
+	Options sharing a prefix may be nested in a table of the config file, so
+	storage-wal-fsync-delay may be set as wal-fsync-delay in [storage]. String
+	values may reference environment variables as ${VAR}, or ${VAR:-default}
+	to fall back to a default when VAR is unset or empty.
+
+	Run 'influxd list-env' to list the environment variable of every option, and
+	'influxd print-config' to print the resolved config as a config file.`
 	}
 
 	cmd := cli.NewCommand(l.Viper, &prog)
@@ -135,6 +151,11 @@ func NewInfluxdCommand(ctx context.Context, v *viper.Viper) *cobra.Command {
 		setLauncherCMDOpts(l, c)
 	}
 	cmd.AddCommand(runCmd)
+	cmd.AddCommand(cli.NewListEnvCommand(prog.Name, launcherOpts(l)))
+
+	printConfigCmd := cli.NewPrintConfigCommand(launcherOpts(l))
+	setLauncherCMDOpts(l, printConfigCmd)
+	cmd.AddCommand(printConfigCmd)
 
 	return cmd
 }
@@ -212,6 +233,18 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: filepath.Join(dir, bolt.DefaultFilename),
 			Desc:    "path to boltdb database",
 		},
+		{
+			DestP:   &l.boltMetrics,
+			Flag:    "bolt-metrics",
+			Default: false,
+			Desc:    "expose transaction and bucket operation metrics of the boltdb store",
+		},
+		{
+			DestP:   &l.boltSlowTxThreshold,
+			Flag:    "bolt-slow-tx-threshold",
+			Default: time.Duration(0),
+			Desc:    "log boltdb store transactions taking at least this long; 0 disables logging",
+		},
 		{
 			DestP: &l.assetsPath,
 			Flag:  "assets-path",
@@ -259,12 +292,100 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: 60, // 60 minutes
 			Desc:    "ttl in minutes for newly created sessions",
 		},
+		{
+			DestP: &l.sessionKeysPath,
+			Flag:  "session-keys-path",
+			Desc:  "path to a JSON file of the keys signing sessions; when set, sessions are stateless signed cookies accepted by every instance sharing the keys, instead of being stored by each instance",
+		},
+		{
+			DestP:   &l.writeIdempotencyMaxKeys,
+			Flag:    "write-idempotency-max-keys",
+			Default: http.DefaultIdempotencyMaxKeys,
+			Desc:    "maximum number of write Idempotency-Key headers remembered for deduplicating retried writes; 0 disables deduplication",
+		},
+		{
+			DestP:   &l.writeIdempotencyTTL,
+			Flag:    "write-idempotency-ttl",
+			Default: http.DefaultIdempotencyTTL,
+			Desc:    "how long write Idempotency-Key headers are remembered",
+		},
+		{
+			DestP:   &l.notificationDeliveryHistory,
+			Flag:    "notification-delivery-history",
+			Default: delivery.DefaultHistorySize,
+			Desc:    "number of recent delivery attempts recorded for each notification endpoint",
+		},
+		{
+			DestP:   &l.slowQueryLogSize,
+			Flag:    "slow-query-log-size",
+			Default: slowquery.DefaultSize,
+			Desc:    "number of the slowest recent queries reported at /api/v2/debug/slow-queries; 0 disables recording them",
+		},
+		{
+			DestP:   &l.slowQueryLogWindow,
+			Flag:    "slow-query-log-window",
+			Default: slowquery.DefaultWindow,
+			Desc:    "how long queries are kept among the slowest recent queries",
+		},
+		{
+			DestP:   &l.lastValueCacheDisabled,
+			Flag:    "last-value-cache-disabled",
+			Default: false,
+			Desc:    "disables caching the last values of the last value measurements of buckets, and reading them at /api/v2/buckets/{id}/last",
+		},
+		{
+			DestP:   &l.lastValueCacheMaxBucketSize,
+			Flag:    "last-value-cache-max-bucket-size",
+			Default: storage.DefaultLastValueCacheMaxBucketSize,
+			Desc:    "maximum number of bytes of last values cached for each bucket; the least recently written values are evicted beyond it",
+		},
+		{
+			DestP:   &l.ingestQuotaResetOffset,
+			Flag:    "ingest-quota-reset-offset",
+			Default: time.Duration(0),
+			Desc:    "time of day after midnight UTC at which the daily ingest usage of organizations is reset, e.g. 6h resets at 06:00 UTC",
+		},
+		{
+			DestP:   &l.ingestQuotaFlushInterval,
+			Flag:    "ingest-quota-flush-interval",
+			Default: quota.DefaultFlushInterval,
+			Desc:    "how often the ingest usage of organizations is persisted so that it survives a restart",
+		},
+		{
+			DestP:   &l.queryResultMaxRows,
+			Flag:    "query-result-max-rows",
+			Default: int64(0),
+			Desc:    "default maximum number of rows returned by a flux query, past which its results are truncated; organizations may be given their own limit. 0 is unlimited",
+		},
+		{
+			DestP:   &l.queryResultMaxBytes,
+			Flag:    "query-result-max-bytes",
+			Default: int64(0),
+			Desc:    "default maximum number of bytes returned by a flux query, past which its results are truncated; organizations may be given their own limit. 0 is unlimited",
+		},
 		{
 			DestP:   &l.sessionRenewDisabled,
 			Flag:    "session-renew-disabled",
 			Default: false,
 			Desc:    "disables automatically extending session ttl on request",
 		},
+		{
+			DestP:   &l.httpResponseStats,
+			Flag:    "http-response-stats",
+			Default: false,
+			Desc:    "report the points written by write requests and the bytes read, rows returned and duration of query requests in response headers",
+		},
+		{
+			DestP: &l.httpDebugCaptureRoutes,
+			Flag:  "http-debug-capture-routes",
+			Desc:  "for debugging, log the start of the body of requests to these path prefixes that fail with a client error, for example /api/v2/write; bodies of sign in, authorization and other credential endpoints are never logged",
+		},
+		{
+			DestP:   &l.httpDebugCaptureSize,
+			Flag:    "http-debug-capture-size",
+			Default: http.DefaultBodyCaptureSize / 1024,
+			Desc:    "the number of KiB of each request body kept by --http-debug-capture-routes",
+		},
 		{
 			DestP: &vaultConfig.Address,
 			Flag:  "vault-addr",
@@ -345,6 +466,30 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: false,
 			Desc:    "disables the task scheduler",
 		},
+		{
+			DestP:   &l.scraperBatchSize,
+			Flag:    "scraper-batch-size",
+			Default: gather.DefaultBatchSize,
+			Desc:    "the number of scraped points written to storage per batch; scraper targets may override it",
+		},
+		{
+			DestP:   &l.scraperBatchInterval,
+			Flag:    "scraper-batch-interval",
+			Default: gather.DefaultBatchInterval,
+			Desc:    "the longest a partial batch of scraped points is held before it is written; scraper targets may override it",
+		},
+		{
+			DestP:   &l.scraperJitter,
+			Flag:    "scraper-jitter",
+			Default: string(platform.ScraperJitterSpread),
+			Desc:    "when scraper targets are scraped within each interval: none scrapes all of them at its start, spread at an offset derived from their ID; scraper targets may override it",
+		},
+		{
+			DestP:   &l.healthMetricsMaxSeries,
+			Flag:    "health-metrics-max-series",
+			Default: health.DefaultMaxSeries,
+			Desc:    "the number of checks, notification rules and scraper targets whose health is exposed as their own series at /metrics; the health of the others is aggregated into a series labelled other",
+		},
 		{
 			DestP:   &l.concurrencyQuota,
 			Flag:    "query-concurrency",
@@ -448,6 +593,11 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Flag:  "storage-retention-check-interval",
 			Desc:  "The interval of time when retention policy enforcement checks run.",
 		},
+		{
+			DestP: &l.StorageConfig.RetentionService.MinRetention,
+			Flag:  "storage-retention-min-retention",
+			Desc:  "The shortest retention period of a bucket. Shorter retention periods are raised to it. Zero sets no minimum.",
+		},
 		{
 			DestP: &l.StorageConfig.PrecreatorConfig.CheckInterval,
 			Flag:  "storage-shard-precreator-check-interval",
@@ -480,16 +630,39 @@ func launcherOpts(l *Launcher) []cli.Opt {
 
 // Launcher represents the main program execution.
 type Launcher struct {
-	wg      sync.WaitGroup
-	cancel  func()
-	running bool
+	wg        sync.WaitGroup
+	cancel    func()
+	running   bool
+	startedAt time.Time
 
 	storeType               string
 	assetsPath              string
 	testing                 bool
 	testingAlwaysAllowSetup bool
 	sessionLength           int // in minutes
+	sessionKeysPath         string
 	sessionRenewDisabled    bool
+	httpResponseStats       bool
+
+	httpDebugCaptureRoutes []string
+	httpDebugCaptureSize   int // in KiB
+
+	writeIdempotencyMaxKeys int
+	writeIdempotencyTTL     time.Duration
+
+	notificationDeliveryHistory int
+
+	slowQueryLogSize   int
+	slowQueryLogWindow time.Duration
+
+	lastValueCacheDisabled      bool
+	lastValueCacheMaxBucketSize int
+
+	ingestQuotaResetOffset   time.Duration
+	ingestQuotaFlushInterval time.Duration
+
+	queryResultMaxRows  int64
+	queryResultMaxBytes int64
 
 	logLevel          string
 	tracingType       string
@@ -497,8 +670,12 @@ type Launcher struct {
 
 	httpBindAddress string
 	boltPath        string
-	enginePath      string
-	secretStore     string
+
+	boltMetrics         bool
+	boltSlowTxThreshold time.Duration
+
+	enginePath  string
+	secretStore string
 
 	featureFlags map[string]string
 	flagger      feature.Flagger
@@ -533,6 +710,13 @@ type Launcher struct {
 	natsServer *nats.Server
 	natsPort   int
 
+	scraperBatchSize     int
+	scraperBatchInterval time.Duration
+	scraperJitter        string
+	scraperRecorder      *gather.BatchingPointWriter
+
+	healthMetricsMaxSeries int
+
 	noTasks            bool
 	scheduler          stoppingScheduler
 	executor           *executor.Executor
@@ -625,6 +809,11 @@ func (m *Launcher) Shutdown(ctx context.Context) {
 	m.log.Info("Stopping", zap.String("service", "nats"))
 	m.natsServer.Close()
 
+	m.log.Info("Stopping", zap.String("service", "scraper"))
+	if err := m.scraperRecorder.Close(); err != nil {
+		m.log.Error("Failed to flush scraped points", zap.Error(err))
+	}
+
 	m.log.Info("Stopping", zap.String("service", "bolt"))
 	if err := m.boltClient.Close(); err != nil {
 		m.log.Info("Failed closing bolt", zap.Error(err))
@@ -675,6 +864,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 	defer span.Finish()
 
 	m.running = true
+	m.startedAt = time.Now()
 	ctx, m.cancel = context.WithCancel(ctx)
 
 	for _, opt := range m.opts {
@@ -735,12 +925,20 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		return err
 	}
 
-	var flushers flushers
+	var (
+		flushers     flushers
+		kvCollectors []prometheus.Collector
+	)
 	switch m.storeType {
 	case BoltStore:
-		store := bolt.NewKVStore(m.log.With(zap.String("service", "kvstore-bolt")), m.boltPath)
+		opts := []bolt.KVOption{bolt.WithSlowTxThreshold(m.boltSlowTxThreshold)}
+		if m.boltMetrics {
+			opts = append(opts, bolt.WithMetrics)
+		}
+		store := bolt.NewKVStore(m.log.With(zap.String("service", "kvstore-bolt")), m.boltPath, opts...)
 		store.WithDB(m.boltClient.DB())
 		m.kvStore = store
+		kvCollectors = store.PrometheusCollectors()
 		if m.testing {
 			flushers = append(flushers, store)
 		}
@@ -780,8 +978,15 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		infprom.NewInfluxCollector(m.boltClient, info),
 	)
 	m.reg.MustRegister(m.boltClient)
-
-	tenantStore := tenant.NewStore(m.kvStore)
+	m.reg.MustRegister(kvCollectors...)
+
+	tenantStore := tenant.NewStore(m.kvStore,
+		tenant.WithOrgReferenceRewriters(
+			kv.RewriteTaskOrgName,
+			telegrafservice.RewriteOrgName,
+		),
+		tenant.WithMinRetention(m.StorageConfig.RetentionService.MinRetentionPeriod()),
+	)
 	ts := tenant.NewSystem(tenantStore, m.log.With(zap.String("store", "new")), m.reg, metric.WithSuffix("new"))
 
 	serviceConfig := kv.ServiceConfig{
@@ -796,6 +1001,14 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		bucketLogSvc platform.BucketOperationLogService       = opLogSvc
 		orgLogSvc    platform.OrganizationOperationLogService = opLogSvc
 	)
+	// Record changes to orgs & buckets in their operation logs.
+	ts.OrganizationService = tenant.NewOrgOpLogger(m.log.With(zap.String("service", "org_oplog")), opLogSvc, ts.OrganizationService)
+	ts.BucketService = tenant.NewBucketOpLogger(m.log.With(zap.String("service", "bucket_oplog")), opLogSvc, ts.BucketService)
+
+	var inviteSvc platform.InviteService = tenant.NewInviteService(ts)
+	inviteSvc = tenant.NewInviteMetrics(m.reg, inviteSvc, metric.WithSuffix("new"))
+	inviteSvc = tenant.NewInviteLogger(m.log.With(zap.String("service", "invite")), inviteSvc)
+	inviteSvc = tenant.NewInviteOpLogger(m.log.With(zap.String("service", "invite_oplog")), opLogSvc, inviteSvc)
 	var (
 		variableSvc      platform.VariableService           = m.kvService
 		sourceSvc        platform.SourceService             = m.kvService
@@ -850,11 +1063,14 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		return err
 	}
 
+	maintenanceRegistry := maintenance.NewRegistry()
 	if m.testing {
 		// the testing engine will write/read into a temporary directory
 		engine := NewTemporaryEngine(
 			m.StorageConfig,
 			storage.WithMetaClient(metaClient),
+			storage.WithBucketFinder(ts.BucketService),
+			storage.WithMaintenanceRegistry(maintenanceRegistry),
 		)
 		flushers = append(flushers, engine)
 		m.engine = engine
@@ -868,6 +1084,8 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 			m.enginePath,
 			m.StorageConfig,
 			storage.WithMetaClient(metaClient),
+			storage.WithBucketFinder(ts.BucketService),
+			storage.WithMaintenanceRegistry(maintenanceRegistry),
 		)
 	}
 	m.engine.WithLogger(m.log)
@@ -883,11 +1101,24 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		pointsWriter   storage.PointsWriter    = m.engine
 		backupService  platform.BackupService  = m.engine
 		restoreService platform.RestoreService = m.engine
+		engineSchema   storage.EngineSchema    = m.engine
+		lastValues     *storage.LastValueCache
 	)
+	if !m.lastValueCacheDisabled {
+		lastValues = storage.NewLastValueCache(ts.BucketService, m.lastValueCacheMaxBucketSize)
+		m.reg.MustRegister(lastValues.PrometheusCollectors()...)
+		pointsWriter = lastValues.PointsWriter(pointsWriter)
+		deleteService = lastValues.DeleteService(deleteService)
+		engineSchema = lastValues.EngineSchema(engineSchema)
+	}
+	checkLevels := health.NewLevelRecorder()
+	pointsWriter = checkLevels.PointsWriter(pointsWriter)
 
 	deps, err := influxdb.NewDependencies(
 		storageflux.NewReader(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())),
-		m.engine,
+		// Flux writes, such as the statuses of checks, go through the same
+		// wrappers as other writes.
+		pointsWriter,
 		authorizer.NewBucketService(ts.BucketService),
 		authorizer.NewOrgService(ts.OrganizationService),
 		authorizer.NewSecretService(secretSvc),
@@ -898,6 +1129,13 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		return err
 	}
 
+	// Record the requests notification rules make to their endpoints.
+	deliverySvc := delivery.NewStore(m.kvStore, m.notificationDeliveryHistory)
+	fluxDeps := deps.FluxDeps.(flux.Deps)
+	deliveryClient := delivery.NewClient(m.log.With(zap.String("service", "notification_delivery")), fluxDeps.Deps.HTTPClient, deliverySvc)
+	fluxDeps.Deps.HTTPClient = deliveryClient
+	deps.FluxDeps = fluxDeps
+
 	m.queryController, err = control.New(control.Config{
 		ConcurrencyQuota:                m.concurrencyQuota,
 		InitialMemoryBytesQuotaPerQuery: m.initialMemoryBytesQuotaPerQuery,
@@ -915,6 +1153,10 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 	m.reg.MustRegister(m.queryController.PrometheusCollectors()...)
 
 	var storageQueryService = readservice.NewProxyQueryService(m.queryController)
+	slowQueryLog := slowquery.New(m.slowQueryLogSize, m.slowQueryLogWindow)
+	if m.slowQueryLogSize > 0 {
+		storageQueryService = query.NewLoggingProxyQueryService(m.log.With(zap.String("service", "slow-queries")), slowQueryLog, storageQueryService)
+	}
 	var taskSvc platform.TaskService
 	{
 		// create the task stack
@@ -1040,6 +1282,16 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		notificationRuleSvc = middleware.NewNotificationRuleStore(notificationRuleSvc, m.kvService, coordinator)
 	}
 
+	deliveryClient.SetResolver(&delivery.Resolver{
+		Rules:     notificationRuleSvc,
+		Endpoints: notificationEndpointSvc,
+		Secrets:   secretSvc,
+	})
+
+	healthCollector := health.NewCollector(m.log.With(zap.String("service", "health_metrics")), checkSvc, notificationRuleSvc, taskSvc, deliverySvc, checkLevels)
+	healthCollector.MaxSeries = m.healthMetricsMaxSeries
+	m.reg.MustRegister(healthCollector)
+
 	var telegrafSvc platform.TelegrafConfigStore
 	{
 		telegrafSvc = telegrafservice.New(m.kvStore)
@@ -1067,12 +1319,22 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		return err
 	}
 
-	subscriber.Subscribe(gather.MetricsSubject, "metrics", gather.NewRecorderHandler(m.log, gather.PointWriter{Writer: pointsWriter}))
-	scraperScheduler, err := gather.NewScheduler(m.log, 10, scraperTargetSvc, publisher, subscriber, 10*time.Second, 30*time.Second)
+	m.scraperRecorder = gather.NewBatchingPointWriter(m.log.With(zap.String("service", "scraper")), pointsWriter, gather.BatchConfig{
+		MaxPoints:     m.scraperBatchSize,
+		FlushInterval: m.scraperBatchInterval,
+	})
+	subscriber.Subscribe(gather.MetricsSubject, "metrics", gather.NewRecorderHandler(m.log, m.scraperRecorder))
+	scraperScheduler, err := gather.NewScheduler(m.log, 10, scraperTargetSvc, ts.UserService, publisher, subscriber, 10*time.Second, 30*time.Second)
 	if err != nil {
 		m.log.Error("Failed to create scraper subscriber", zap.Error(err))
 		return err
 	}
+	scraperScheduler.Jitter = platform.ScraperJitter(m.scraperJitter)
+	if !scraperScheduler.Jitter.Valid() {
+		return fmt.Errorf("invalid scraper jitter %q: must be one of none or spread", m.scraperJitter)
+	}
+	scraperScheduler.MaxHealthSeries = m.healthMetricsMaxSeries
+	m.reg.MustRegister(scraperScheduler.PrometheusCollectors()...)
 
 	m.wg.Add(1)
 	go func(log *zap.Logger) {
@@ -1104,13 +1366,33 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 
 	var sessionSvc platform.SessionService
 	{
-		sessionSvc = session.NewService(
-			session.NewStorage(inmem.NewSessionStore()),
-			ts.UserService,
-			ts.UserResourceMappingService,
-			authSvc,
-			session.WithSessionLength(time.Duration(m.sessionLength)*time.Minute),
-		)
+		sessionOpts := []session.ServiceOption{
+			session.WithSessionLength(time.Duration(m.sessionLength) * time.Minute),
+		}
+		if m.sessionKeysPath != "" {
+			keys, err := session.LoadSigningKeys(m.sessionKeysPath)
+			if err != nil {
+				m.log.Error("Failed to load session signing keys", zap.Error(err))
+				return err
+			}
+			m.log.Info("Using stateless sessions", zap.String("keys_path", m.sessionKeysPath))
+			sessionSvc = session.NewStatelessService(
+				keys,
+				session.NewKVStore(m.kvStore),
+				ts.UserService,
+				ts.UserResourceMappingService,
+				authSvc,
+				sessionOpts...,
+			)
+		} else {
+			sessionSvc = session.NewService(
+				session.NewStorage(inmem.NewSessionStore()),
+				ts.UserService,
+				ts.UserResourceMappingService,
+				authSvc,
+				sessionOpts...,
+			)
+		}
 		sessionSvc = session.NewSessionMetrics(m.reg, sessionSvc)
 		sessionSvc = session.NewSessionLogger(m.log.With(zap.String("service", "session")), sessionSvc)
 	}
@@ -1125,7 +1407,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		labelSvc = label.NewService(labelsStore)
 	}
 
-	ts.BucketService = storage.NewBucketService(m.log, ts.BucketService, m.engine)
+	ts.BucketService = storage.NewBucketService(m.log, ts.BucketService, engineSchema)
 	ts.BucketService = dbrp.NewBucketService(m.log, ts.BucketService, dbrpSvc)
 
 	var onboardOpts []tenant.OnboardServiceOptionFn
@@ -1190,21 +1472,108 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		NotificationRuleFinder:     notificationRuleSvc,
 	}
 
+	var writeIdempotency *http.IdempotencyCache
+	if m.writeIdempotencyMaxKeys > 0 {
+		writeIdempotency = http.NewIdempotencyCache(m.log.With(zap.String("service", "write_idempotency")), m.kvStore, m.writeIdempotencyMaxKeys, m.writeIdempotencyTTL)
+		if err := writeIdempotency.Open(ctx); err != nil {
+			m.log.Error("Failed to open write idempotency cache", zap.Error(err))
+			return err
+		}
+	}
+
+	if m.ingestQuotaResetOffset < 0 || m.ingestQuotaResetOffset >= 24*time.Hour {
+		return fmt.Errorf("ingest-quota-reset-offset must be between 0 and 24h, got %s", m.ingestQuotaResetOffset)
+	}
+	ingestQuotaSvc := quota.NewService(m.log.With(zap.String("service", "ingest_quota")), m.kvStore,
+		quota.WithResetOffset(m.ingestQuotaResetOffset))
+	if err := ingestQuotaSvc.Open(ctx); err != nil {
+		m.log.Error("Failed to open ingest quota service", zap.Error(err))
+		return err
+	}
+	m.reg.MustRegister(ingestQuotaSvc.PrometheusCollectors()...)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ingestQuotaSvc.Run(ctx, m.ingestQuotaFlushInterval)
+	}()
+
+	if m.queryResultMaxRows < 0 || m.queryResultMaxBytes < 0 {
+		return fmt.Errorf("query-result-max-rows and query-result-max-bytes must not be negative")
+	}
+	queryLimitSvc := quota.NewQueryLimitService(m.log.With(zap.String("service", "query_limits")), m.kvStore,
+		platform.QueryResultLimits{MaxRows: m.queryResultMaxRows, MaxBytes: m.queryResultMaxBytes})
+	if err := queryLimitSvc.Open(ctx); err != nil {
+		m.log.Error("Failed to open query result limit service", zap.Error(err))
+		return err
+	}
+	m.reg.MustRegister(queryLimitSvc.PrometheusCollectors()...)
+
+	jobSvc := job.NewService(m.log.With(zap.String("service", "jobs")), m.kvStore)
+	jobSvc.Register(platform.JobKindDelete, 1, job.NewDeleteHandler(deleteService))
+	if err := jobSvc.Open(ctx); err != nil {
+		m.log.Error("Failed to open job service", zap.Error(err))
+		return err
+	}
+	m.reg.MustRegister(jobSvc.PrometheusCollectors()...)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		jobSvc.Run(ctx)
+	}()
+
+	var summaryKVPath string
+	if m.storeType == BoltStore {
+		summaryKVPath = m.boltPath
+	}
+	summarySvc := summary.NewService(m.log.With(zap.String("service", "summary")), summary.Config{
+		Engine:                    m.engine,
+		OrganizationService:       ts.OrganizationService,
+		BucketService:             ts.BucketService,
+		UserService:               ts.UserService,
+		TaskService:               taskSvc,
+		ScraperTargetStoreService: scraperTargetSvc,
+		MaintenanceService:        maintenanceRegistry,
+		Gatherer:                  m.reg,
+		KVPath:                    summaryKVPath,
+		StartedAt:                 m.startedAt,
+	})
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		summarySvc.Run(ctx, summary.DefaultRefreshInterval)
+	}()
+
 	m.apibackend = &http.APIBackend{
-		AssetsPath:           m.assetsPath,
-		HTTPErrorHandler:     kithttp.ErrorHandler(0),
-		Logger:               m.log,
-		SessionRenewDisabled: m.sessionRenewDisabled,
-		NewBucketService:     source.NewBucketService,
-		NewQueryService:      source.NewQueryService,
+		AssetsPath:              m.assetsPath,
+		HTTPErrorHandler:        kithttp.ErrorHandler(0),
+		Logger:                  m.log,
+		SessionRenewDisabled:    m.sessionRenewDisabled,
+		ResponseStats:           m.httpResponseStats,
+		NewBucketService:        source.NewBucketService,
+		WriteIdempotencyCache:   writeIdempotency,
+		IngestQuotaService:      ingestQuotaSvc,
+		IngestQuotaEnforcer:     ingestQuotaSvc,
+		QueryResultLimitService: queryLimitSvc,
+		QueryResultLimiter:      queryLimitSvc,
+		NewQueryService:         source.NewQueryService,
 		PointsWriter: &storage.LoggingPointsWriter{
 			Underlying:    pointsWriter,
 			BucketFinder:  ts.BucketService,
 			LogBucketName: platform.MonitoringSystemBucketName,
 		},
 		DeleteService:        deleteService,
+		JobService:           jobSvc,
+		JobSubmitter:         jobSvc,
 		BackupService:        backupService,
 		RestoreService:       restoreService,
+		UsageReportService:   storage.NewUsageReportService(m.engine, ts.BucketService, ts.OrganizationService),
+		OrphanReportService:  storage.NewOrphanReportService(m.engine, ts.BucketService),
+		MaintenanceService:   maintenanceRegistry,
+		SummaryService:       summarySvc,
+		CacheService:         m.engine,
+		SlowQueryService:     slowQueryLog,
 		AuthorizationService: authSvc,
 		AuthorizerV1:         authorizerV1,
 		AlgoWProxy:           &http.NoopProxyHandler{},
@@ -1233,6 +1602,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		TelegrafService:                 telegrafSvc,
 		NotificationRuleStore:           notificationRuleSvc,
 		NotificationEndpointService:     notificationEndpointSvc,
+		NotificationDeliveryService:     deliverySvc,
 		CheckService:                    checkSvc,
 		ScraperTargetStoreService:       scraperTargetSvc,
 		ChronografService:               chronografSvc,
@@ -1241,6 +1611,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		DocumentService:                 m.kvService,
 		OrgLookupService:                resourceResolver,
 		WriteEventRecorder:              infprom.NewEventRecorder("write"),
+		NonFiniteFields:                 points.NewNonFiniteFieldsCounter(),
 		QueryEventRecorder:              infprom.NewEventRecorder("query"),
 		Flagger:                         m.flagger,
 		FlagsHandler:                    feature.NewFlagsHandler(kithttp.ErrorHandler(0), feature.ByKey),
@@ -1289,7 +1660,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		templatesHTTPServer = pkger.NewHTTPServerTemplates(tLogger, pkgSVC)
 	}
 
-	userHTTPServer := ts.NewUserHTTPHandler(m.log)
+	userHTTPServer := ts.NewUserHTTPHandler(m.log, authSvc, resourceResolver)
 	onboardHTTPServer := tenant.NewHTTPOnboardHandler(m.log, onboardSvc)
 
 	// feature flagging for new labels service
@@ -1333,9 +1704,14 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		sessionHTTPServer = session.NewSessionHandler(m.log.With(zap.String("handler", "session")), sessionSvc, ts.UserService, ts.PasswordsService)
 	}
 
-	orgHTTPServer := ts.NewOrgHTTPHandler(m.log, secret.NewAuthedService(secretSvc))
+	orgHTTPServer := ts.NewOrgHTTPHandler(m.log, secret.NewAuthedService(secretSvc), orgLogSvc, inviteSvc)
 
-	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc)
+	inviteHTTPServer := ts.NewInviteHTTPHandler(m.log, inviteSvc)
+
+	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc, bucketLogSvc)
+	if lastValues != nil {
+		bucketHTTPServer.WithLastValueService(lastValues)
+	}
 
 	var dashboardServer *dashboardTransport.DashboardHandler
 	{
@@ -1376,6 +1752,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 			http.WithResourceHandler(userHTTPServer.MeResourceHandler()),
 			http.WithResourceHandler(userHTTPServer.UserResourceHandler()),
 			http.WithResourceHandler(orgHTTPServer),
+			http.WithResourceHandler(inviteHTTPServer),
 			http.WithResourceHandler(bucketHTTPServer),
 			http.WithResourceHandler(v1AuthHTTPServer),
 			http.WithResourceHandler(dashboardServer),
@@ -1392,6 +1769,11 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		if logLevel == zap.DebugLevel {
 			m.httpServer.Handler = http.LoggingMW(httpLogger)(m.httpServer.Handler)
 		}
+		if len(m.httpDebugCaptureRoutes) > 0 {
+			bodyCapture := http.NewBodyCapture(httpLogger, m.httpDebugCaptureRoutes, m.httpDebugCaptureSize*1024)
+			m.reg.MustRegister(bodyCapture.PrometheusCollectors()...)
+			m.httpServer.Handler = bodyCapture.Middleware(m.httpServer.Handler)
+		}
 		// If we are in testing mode we allow all data to be flushed and removed.
 		if m.testing {
 			m.httpServer.Handler = http.DebugFlush(ctx, m.httpServer.Handler, flushers)
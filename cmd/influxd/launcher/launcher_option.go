@@ -1,6 +1,8 @@
 package launcher
 
 import (
+	platform "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/gather"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
@@ -44,6 +46,16 @@ func WithInfluxQLMaxSelectBucketsN(n int) Option {
 	}
 }
 
+// WithGatherer registers g as the gatherer of the scraper targets of type typ.
+// Launchers constructed again with the same gatherer share its registration.
+func WithGatherer(typ platform.ScraperType, g gather.Gatherer) Option {
+	return &launcherOption{
+		applyInitFn: func(l *Launcher) {
+			gather.RegisterGatherer(typ, g)
+		},
+	}
+}
+
 type launcherOption struct {
 	applyInitFn   func(*Launcher)
 	applyConfigFn func(*Launcher)
@@ -0,0 +1,21 @@
+package context
+
+import (
+	"context"
+)
+
+const (
+	dryRunCtxKey contextKey = "influx/dryrun/v1"
+)
+
+// SetDryRun marks the context as a dry run. Services seeing a dry run
+// validate and authorize an operation as usual, but change nothing.
+func SetDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunCtxKey, true)
+}
+
+// IsDryRun reports whether the context is marked as a dry run.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunCtxKey).(bool)
+	return dryRun
+}
@@ -0,0 +1,29 @@
+package context
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const (
+	taskRunCtxKey contextKey = "influx/taskrun/v1"
+)
+
+// TaskRun identifies the task run a query is executed for.
+type TaskRun struct {
+	OrgID  influxdb.ID
+	TaskID influxdb.ID
+	RunID  influxdb.ID
+}
+
+// SetTaskRun marks the context as executing the run of a task.
+func SetTaskRun(ctx context.Context, run TaskRun) context.Context {
+	return context.WithValue(ctx, taskRunCtxKey, run)
+}
+
+// GetTaskRun returns the task run the context is executing, if any.
+func GetTaskRun(ctx context.Context) (TaskRun, bool) {
+	run, ok := ctx.Value(taskRunCtxKey).(TaskRun)
+	return run, ok
+}
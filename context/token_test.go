@@ -52,3 +52,13 @@ func TestGetUserID(t *testing.T) {
 		t.Errorf("GetUserID() want %s, got %s", want, got)
 	}
 }
+
+func TestIsDryRun(t *testing.T) {
+	ctx := context.Background()
+	if icontext.IsDryRun(ctx) {
+		t.Error("expected context without dry run flag not to be a dry run")
+	}
+	if !icontext.IsDryRun(icontext.SetDryRun(ctx)) {
+		t.Error("expected context with dry run flag to be a dry run")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
 	"go.uber.org/zap"
 )
 
@@ -29,6 +30,9 @@ func (s *BucketService) DeleteBucket(ctx context.Context, id influxdb.ID) error
 	if err := s.BucketService.DeleteBucket(ctx, id); err != nil {
 		return err
 	}
+	if icontext.IsDryRun(ctx) {
+		return nil
+	}
 
 	logger := s.Logger.With(zap.String("bucket_id", id.String()))
 	mappings, _, err := s.DBRPMappingService.FindMany(ctx, influxdb.DBRPMappingFilterV2{
@@ -0,0 +1,56 @@
+package influxdb
+
+// Stable identifiers of common errors, set as the ErrID of their Error. Clients
+// map them to their own, translated, messages, so an identifier must never
+// change or be reused, even if its message does. Every identifier must be in
+// ErrorIDs, and in testdata/error_ids.golden, which the tests check.
+const (
+	ErrIDBucketNotFound           = "bucket.not_found"
+	ErrIDBucketCreateNameConflict = "bucket.create.name_conflict"
+	ErrIDBucketUpdateNameConflict = "bucket.update.name_conflict"
+	ErrIDBucketUpdateSystemName   = "bucket.update.system_name"
+	ErrIDBucketDeleteSystem       = "bucket.delete.system"
+
+	ErrIDOrgNotFound           = "org.not_found"
+	ErrIDOrgCreateNameConflict = "org.create.name_conflict"
+
+	ErrIDUserNotFound           = "user.not_found"
+	ErrIDUserCreateNameConflict = "user.create.name_conflict"
+	ErrIDUserPasswordIncorrect  = "user.password.incorrect"
+	ErrIDUserPasswordTooShort   = "user.password.too_short"
+
+	ErrIDOnboardingCompleted = "onboarding.completed"
+
+	ErrIDInviteNotFound     = "invite.not_found"
+	ErrIDInviteNotPending   = "invite.not_pending"
+	ErrIDInviteTokenInvalid = "invite.token_invalid"
+
+	ErrIDAuthorizationNotFound            = "authorization.not_found"
+	ErrIDAuthorizationCreateTokenConflict = "authorization.create.token_conflict"
+)
+
+// ErrorIDs is the registry of the stable identifiers of errors.
+var ErrorIDs = []string{
+	ErrIDBucketNotFound,
+	ErrIDBucketCreateNameConflict,
+	ErrIDBucketUpdateNameConflict,
+	ErrIDBucketUpdateSystemName,
+	ErrIDBucketDeleteSystem,
+
+	ErrIDOrgNotFound,
+	ErrIDOrgCreateNameConflict,
+
+	ErrIDUserNotFound,
+	ErrIDUserCreateNameConflict,
+	ErrIDUserPasswordIncorrect,
+	ErrIDUserPasswordTooShort,
+
+	ErrIDOnboardingCompleted,
+
+	ErrIDInviteNotFound,
+	ErrIDInviteNotPending,
+	ErrIDInviteTokenInvalid,
+
+	ErrIDAuthorizationNotFound,
+	ErrIDAuthorizationCreateTokenConflict,
+}
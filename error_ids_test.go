@@ -0,0 +1,51 @@
+package influxdb_test
+
+import (
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	platform "github.com/influxdata/influxdb/v2"
+)
+
+var errIDPattern = regexp.MustCompile(`^[a-z][a-z_]*(\.[a-z][a-z_]*)+$`)
+
+func TestErrorIDs(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, id := range platform.ErrorIDs {
+		if !errIDPattern.MatchString(id) {
+			t.Errorf("error ID %q is not a dotted lower case identifier", id)
+		}
+		if seen[id] {
+			t.Errorf("error ID %q is not unique", id)
+		}
+		seen[id] = true
+	}
+
+	// The golden file holds every identifier ever released, so that
+	// changing or removing one fails.
+	b, err := ioutil.ReadFile("testdata/error_ids.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := make(map[string]bool)
+	for _, id := range strings.Fields(string(b)) {
+		golden[id] = true
+		if !seen[id] {
+			t.Errorf("error ID %q was changed or removed; clients rely on error IDs, restore it", id)
+		}
+	}
+
+	var added []string
+	for id := range seen {
+		if !golden[id] {
+			added = append(added, id)
+		}
+	}
+	sort.Strings(added)
+	for _, id := range added {
+		t.Errorf("error ID %q is not in testdata/error_ids.golden; add it once it is final", id)
+	}
+}
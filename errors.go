@@ -37,31 +37,50 @@ const (
 // Msg is used by the system operator to help diagnose and fix the problem.
 // Op and Err chain errors together in a logical stack trace to
 // further help operators.
+// ErrID is a stable identifier of common errors, from ErrorIDs, that
+// clients can show their own, translated, message for. Msg remains the
+// default English text.
 //
 // To create a simple error,
-//     &Error{
-//         Code:ENotFound,
-//     }
+//
+//	&Error{
+//	    Code:ENotFound,
+//	}
+//
 // To show where the error happens, add Op.
-//     &Error{
-//         Code: ENotFound,
-//         Op: "bolt.FindUserByID"
-//     }
+//
+//	&Error{
+//	    Code: ENotFound,
+//	    Op: "bolt.FindUserByID"
+//	}
+//
 // To show an error with a unpredictable value, add the value in Msg.
-//     &Error{
-//        Code: EConflict,
-//        Message: fmt.Sprintf("organization with name %s already exist", aName),
-//     }
+//
+//	&Error{
+//	   Code: EConflict,
+//	   Message: fmt.Sprintf("organization with name %s already exist", aName),
+//	}
+//
 // To show an error wrapped with another error.
-//     &Error{
-//         Code:EInternal,
-//         Err: err,
-//     }.
+//
+//	&Error{
+//	    Code:EInternal,
+//	    Err: err,
+//	}.
+//
+// To let clients recognize a common error, add ErrID.
+//
+//	&Error{
+//	    Code: ENotFound,
+//	    Msg: "bucket not found",
+//	    ErrID: ErrIDBucketNotFound,
+//	}
 type Error struct {
-	Code string
-	Msg  string
-	Op   string
-	Err  error
+	Code  string
+	Msg   string
+	Op    string
+	Err   error
+	ErrID string
 }
 
 // NewError returns an instance of an error.
@@ -102,6 +121,13 @@ func WithErrorOp(op string) func(*Error) {
 	}
 }
 
+// WithErrorID sets the stable identifier on the error.
+func WithErrorID(id string) func(*Error) {
+	return func(e *Error) {
+		e.ErrID = id
+	}
+}
+
 // Error implements the error interface by writing out the recursive messages.
 func (e *Error) Error() string {
 	if e.Msg != "" && e.Err != nil {
@@ -170,6 +196,33 @@ func ErrorOp(err error) string {
 	return ""
 }
 
+// ErrorID returns the stable identifier of the error, if available; otherwise
+// returns an empty string.
+func ErrorID(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	e, ok := err.(*Error)
+	if !ok {
+		return ""
+	}
+
+	if e == nil {
+		return ""
+	}
+
+	if e.ErrID != "" {
+		return e.ErrID
+	}
+
+	if e.Err != nil {
+		return ErrorID(e.Err)
+	}
+
+	return ""
+}
+
 // ErrorMessage returns the human-readable message of the error, if available.
 // Otherwise returns a generic error message.
 func ErrorMessage(err error) string {
@@ -199,18 +252,20 @@ func ErrorMessage(err error) string {
 
 // errEncode an JSON encoding helper that is needed to handle the recursive stack of errors.
 type errEncode struct {
-	Code string      `json:"code"`              // Code is the machine-readable error code.
-	Msg  string      `json:"message,omitempty"` // Msg is a human-readable message.
-	Op   string      `json:"op,omitempty"`      // Op describes the logical code operation during error.
-	Err  interface{} `json:"error,omitempty"`   // Err is a stack of additional errors.
+	Code  string      `json:"code"`              // Code is the machine-readable error code.
+	Msg   string      `json:"message,omitempty"` // Msg is a human-readable message.
+	Op    string      `json:"op,omitempty"`      // Op describes the logical code operation during error.
+	Err   interface{} `json:"error,omitempty"`   // Err is a stack of additional errors.
+	ErrID string      `json:"errID,omitempty"`   // ErrID is the stable identifier of the error.
 }
 
 // MarshalJSON recursively marshals the stack of Err.
 func (e *Error) MarshalJSON() (result []byte, err error) {
 	ee := errEncode{
-		Code: e.Code,
-		Msg:  e.Msg,
-		Op:   e.Op,
+		Code:  e.Code,
+		Msg:   e.Msg,
+		Op:    e.Op,
+		ErrID: e.ErrID,
 	}
 	if e.Err != nil {
 		if _, ok := e.Err.(*Error); ok {
@@ -233,6 +288,7 @@ func (e *Error) UnmarshalJSON(b []byte) (err error) {
 	e.Code = ee.Code
 	e.Msg = ee.Msg
 	e.Op = ee.Op
+	e.ErrID = ee.ErrID
 	e.Err = decodeInternalError(ee.Err)
 	return err
 }
@@ -252,6 +308,9 @@ func decodeInternalError(target interface{}) error {
 		if op, ok := internalErrMap["op"].(string); ok {
 			internalErr.Op = op
 		}
+		if id, ok := internalErrMap["errID"].(string); ok {
+			internalErr.ErrID = id
+		}
 		internalErr.Err = decodeInternalError(internalErrMap["error"])
 		return internalErr
 	}
@@ -154,6 +154,38 @@ func TestErrorOp(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorID(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "nil error",
+		},
+		{
+			name: "simple error",
+			err:  &platform.Error{ErrID: platform.ErrIDBucketNotFound},
+			want: platform.ErrIDBucketNotFound,
+		},
+		{
+			name: "embedded error without id in root level",
+			err:  &platform.Error{Code: platform.EInternal, Err: &platform.Error{ErrID: platform.ErrIDOrgNotFound}},
+			want: platform.ErrIDOrgNotFound,
+		},
+		{
+			name: "default error",
+			err:  errors.New("s"),
+			want: "",
+		},
+	}
+	for _, c := range cases {
+		if result := platform.ErrorID(c.err); c.want != result {
+			t.Errorf("%s failed, want %s, got %s", c.name, c.want, result)
+		}
+	}
+}
 func TestErrorCode(t *testing.T) {
 	cases := []struct {
 		name string
@@ -223,6 +255,18 @@ func TestJSON(t *testing.T) {
 			},
 			encoded: `{"code":"not found","message":"with ID 323","op":"bolt/FindAuthorizationByID"}`,
 		},
+		{
+			name: "with id",
+			err: &platform.Error{
+				Code: platform.EInternal,
+				Err: &platform.Error{
+					Code:  platform.ENotFound,
+					Msg:   "bucket not found",
+					ErrID: platform.ErrIDBucketNotFound,
+				},
+			},
+			encoded: `{"code":"internal error","error":{"code":"not found","message":"bucket not found","errID":"bucket.not_found"}}`,
+		},
 		{
 			name: "with a third party error",
 			err: &platform.Error{
@@ -288,6 +332,9 @@ func decodeEqual(t *testing.T, want, result *platform.Error, caseName string) {
 	if want.Msg != result.Msg {
 		t.Errorf("%s msg failed, want %s, got %s", caseName, want.Msg, result.Msg)
 	}
+	if want.ErrID != result.ErrID {
+		t.Errorf("%s errID failed, want %s, got %s", caseName, want.ErrID, result.ErrID)
+	}
 	if want.Err != nil {
 		if _, ok := want.Err.(*platform.Error); ok {
 			decodeEqual(t, want.Err.(*platform.Error), result.Err.(*platform.Error), caseName)
@@ -0,0 +1,207 @@
+package gather
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"go.uber.org/zap"
+)
+
+// Default batching settings used when neither the writer nor the target
+// configures them.
+const (
+	DefaultBatchSize     = 1000
+	DefaultBatchInterval = time.Second
+)
+
+// BatchConfig configures how scraped points are grouped before being
+// written to storage.
+type BatchConfig struct {
+	// MaxPoints is the number of points that triggers a write.
+	MaxPoints int
+	// FlushInterval is the longest a partial batch is held before it is
+	// written.
+	FlushInterval time.Duration
+}
+
+// withDefaults fills unset values of c from the package defaults.
+func (c BatchConfig) withDefaults() BatchConfig {
+	if c.MaxPoints <= 0 {
+		c.MaxPoints = DefaultBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = DefaultBatchInterval
+	}
+	return c
+}
+
+// batchKey identifies the points of one target going to one bucket.
+type batchKey struct {
+	targetID influxdb.ID
+	orgID    influxdb.ID
+	bucketID influxdb.ID
+}
+
+// batch is a set of points waiting to be written.
+type batch struct {
+	points models.Points
+	max    int
+	timer  *time.Timer
+}
+
+// BatchingPointWriter is a Recorder that buffers scraped points per target
+// and writes them to storage in batches. A batch is written as soon as it
+// holds the maximum number of points, and a partial batch is written once
+// its flush interval has passed.
+type BatchingPointWriter struct {
+	Writer storage.PointsWriter
+	Config BatchConfig
+
+	log *zap.Logger
+
+	mu      sync.Mutex
+	batches map[batchKey]*batch
+	closed  bool
+}
+
+// NewBatchingPointWriter creates a BatchingPointWriter writing to w.
+func NewBatchingPointWriter(log *zap.Logger, w storage.PointsWriter, config BatchConfig) *BatchingPointWriter {
+	return &BatchingPointWriter{
+		Writer:  w,
+		Config:  config.withDefaults(),
+		log:     log,
+		batches: make(map[batchKey]*batch),
+	}
+}
+
+// Record adds the collected metrics to the batch of their target, writing
+// every batch that becomes full.
+func (w *BatchingPointWriter) Record(collected MetricsCollection) error {
+	ps, err := collected.MetricsSlice.Points()
+	if err != nil {
+		return err
+	}
+
+	config := w.configFor(collected)
+	key := batchKey{
+		targetID: collected.TargetID,
+		orgID:    collected.OrgID,
+		bucketID: collected.BucketID,
+	}
+
+	for len(ps) > 0 {
+		full, rest := w.add(key, config, ps)
+		ps = rest
+		if full == nil {
+			continue
+		}
+		if err := w.write(key, full); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configFor returns the batch settings for collected, applying the
+// overrides of its target.
+func (w *BatchingPointWriter) configFor(collected MetricsCollection) BatchConfig {
+	config := w.Config
+	if collected.BatchSize > 0 {
+		config.MaxPoints = collected.BatchSize
+	}
+	if collected.BatchInterval > 0 {
+		config.FlushInterval = collected.BatchInterval
+	}
+	return config.withDefaults()
+}
+
+// add appends as many points to the batch of key as fit. If the batch is
+// full, or the writer is closed, it is removed and returned along with the
+// points that did not fit.
+func (w *BatchingPointWriter) add(key batchKey, config BatchConfig, ps models.Points) (models.Points, models.Points) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b, ok := w.batches[key]
+	if !ok {
+		b = &batch{
+			points: make(models.Points, 0, config.MaxPoints),
+			max:    config.MaxPoints,
+		}
+		if !w.closed {
+			b.timer = time.AfterFunc(config.FlushInterval, func() {
+				w.flushInterval(key, b)
+			})
+		}
+		w.batches[key] = b
+	}
+
+	n := b.max - len(b.points)
+	if n > len(ps) {
+		n = len(ps)
+	}
+	b.points = append(b.points, ps[:n]...)
+	if len(b.points) < b.max && !w.closed {
+		return nil, ps[n:]
+	}
+
+	delete(w.batches, key)
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b.points, ps[n:]
+}
+
+// flushInterval writes the partial batch b once its interval has passed,
+// unless it has already been written because it became full.
+func (w *BatchingPointWriter) flushInterval(key batchKey, b *batch) {
+	w.mu.Lock()
+	if w.batches[key] != b {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.batches, key)
+	w.mu.Unlock()
+
+	if err := w.write(key, b.points); err != nil {
+		w.log.Error("Failed to write scraper batch", zap.Stringer("target", key.targetID), zap.Error(err))
+	}
+}
+
+// Flush writes every pending batch regardless of its size.
+func (w *BatchingPointWriter) Flush() error {
+	w.mu.Lock()
+	batches := w.batches
+	w.batches = make(map[batchKey]*batch)
+	w.mu.Unlock()
+
+	var firstErr error
+	for key, b := range batches {
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		if err := w.write(key, b.points); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close stops the flush timers and writes every pending batch.
+func (w *BatchingPointWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	return w.Flush()
+}
+
+func (w *BatchingPointWriter) write(key batchKey, ps models.Points) error {
+	if len(ps) == 0 {
+		return nil
+	}
+	return w.Writer.WritePoints(context.Background(), key.orgID, key.bucketID, ps)
+}
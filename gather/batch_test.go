@@ -0,0 +1,152 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+// batchRecorder is a storage.PointsWriter recording the size of every write.
+type batchRecorder struct {
+	mu     sync.Mutex
+	sizes  []int
+	writes chan int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{writes: make(chan int, 100)}
+}
+
+func (r *batchRecorder) WritePoints(ctx context.Context, orgID, bucketID influxdb.ID, ps []models.Point) error {
+	r.mu.Lock()
+	r.sizes = append(r.sizes, len(ps))
+	r.mu.Unlock()
+	r.writes <- len(ps)
+	return nil
+}
+
+func (r *batchRecorder) Sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.sizes...)
+}
+
+func newCollection(targetID influxdb.ID, n int) MetricsCollection {
+	ms := make(MetricsSlice, n)
+	for i := range ms {
+		ms[i] = Metrics{
+			Name:      "m",
+			Tags:      map[string]string{"i": fmt.Sprint(i)},
+			Fields:    map[string]interface{}{"gauge": float64(i)},
+			Timestamp: time.Unix(int64(i), 0),
+			Type:      MetricTypeGauge,
+		}
+	}
+	return MetricsCollection{
+		OrgID:        *orgID,
+		BucketID:     *bucketID,
+		TargetID:     targetID,
+		MetricsSlice: ms,
+	}
+}
+
+func TestBatchingPointWriter_BatchBoundaries(t *testing.T) {
+	targetID := influxdbtesting.MustIDBase16("3a0d0a6365646120")
+	cases := []struct {
+		name      string
+		config    BatchConfig
+		batchSize int
+		records   []int
+		want      []int
+	}{
+		{
+			name:    "full batches then partial on close",
+			config:  BatchConfig{MaxPoints: 3, FlushInterval: time.Hour},
+			records: []int{7},
+			want:    []int{3, 3, 1},
+		},
+		{
+			name:    "batch spans records",
+			config:  BatchConfig{MaxPoints: 4, FlushInterval: time.Hour},
+			records: []int{3, 3, 2},
+			want:    []int{4, 4},
+		},
+		{
+			name:      "target overrides batch size",
+			config:    BatchConfig{MaxPoints: 100, FlushInterval: time.Hour},
+			batchSize: 2,
+			records:   []int{5},
+			want:      []int{2, 2, 1},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newBatchRecorder()
+			w := NewBatchingPointWriter(zaptest.NewLogger(t), rec, tt.config)
+			for _, n := range tt.records {
+				c := newCollection(targetID, n)
+				c.BatchSize = tt.batchSize
+				if err := w.Record(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, rec.Sizes()); diff != "" {
+				t.Errorf("unexpected batch sizes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBatchingPointWriter_FlushInterval(t *testing.T) {
+	targetID := influxdbtesting.MustIDBase16("3a0d0a6365646120")
+	rec := newBatchRecorder()
+	w := NewBatchingPointWriter(zaptest.NewLogger(t), rec, BatchConfig{MaxPoints: 10, FlushInterval: time.Hour})
+	defer w.Close()
+
+	c := newCollection(targetID, 4)
+	c.BatchInterval = 10 * time.Millisecond
+	if err := w.Record(c); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case n := <-rec.writes:
+		if n != 4 {
+			t.Fatalf("expected partial batch of 4 points, got %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("partial batch was not flushed on interval")
+	}
+}
+
+func TestBatchingPointWriter_SeparatesTargets(t *testing.T) {
+	rec := newBatchRecorder()
+	w := NewBatchingPointWriter(zaptest.NewLogger(t), rec, BatchConfig{MaxPoints: 3, FlushInterval: time.Hour})
+
+	for _, id := range []string{"3a0d0a6365646120", "3a0d0a6365646121"} {
+		if err := w.Record(newCollection(influxdbtesting.MustIDBase16(id), 2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.Sizes(); len(got) != 0 {
+		t.Fatalf("expected no writes before the batches are full, got %v", got)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{2, 2}, rec.Sizes()); diff != "" {
+		t.Errorf("unexpected batch sizes (-want +got):\n%s", diff)
+	}
+}
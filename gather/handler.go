@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/nats"
@@ -12,13 +13,14 @@ import (
 
 // handler implements nats Handler interface.
 type handler struct {
-	Scraper   Scraper
 	Publisher nats.Publisher
 	log       *zap.Logger
+	// health records the outcome of each scrape, if set.
+	health *targetHealth
 }
 
 // Process consumes scraper target from scraper target queue,
-// call the scraper to gather, and publish to metrics queue.
+// call the gatherer of its type, and publish to metrics queue.
 func (h *handler) Process(s nats.Subscription, m nats.Message) {
 	defer m.Ack()
 
@@ -29,15 +31,29 @@ func (h *handler) Process(s nats.Subscription, m nats.Message) {
 		return
 	}
 
-	ms, err := h.Scraper.Gather(context.TODO(), *req)
+	gatherer, ok := gathererOf(req.Type)
+	if !ok {
+		h.log.Error("Unsupported scraper type", zap.Stringer("target", req.ID), zap.String("type", string(req.Type)))
+		return
+	}
+
+	start := time.Now()
+	ms, err := gatherer.Gather(context.TODO(), *req)
+	if h.health != nil {
+		h.health.recordScrape(req.ID, err == nil, time.Since(start))
+	}
 	if err != nil {
-		h.log.Error("Unable to gather", zap.Error(err))
+		h.log.Error("Unable to gather",
+			zap.Stringer("target", req.ID),
+			zap.String("url", req.URL),
+			zap.Any("headers", req.Headers.Redacted()),
+			zap.Error(err))
 		return
 	}
 
 	// send metrics to recorder queue
 	buf := new(bytes.Buffer)
-	if err := json.NewEncoder(buf).Encode(ms); err != nil {
+	if err := json.NewEncoder(buf).Encode(newMetricsCollection(*req, ms)); err != nil {
 		h.log.Error("Unable to marshal json", zap.Error(err))
 		return
 	}
@@ -0,0 +1,170 @@
+package gather
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMaxHealthSeries is the number of scraper targets whose health is
+// reported as their own series when the scheduler sets no other.
+const DefaultMaxHealthSeries = 1000
+
+// overflowTarget is the target label of the series aggregating the health of
+// the targets past the cap on the number of series.
+const overflowTarget = "other"
+
+// targetState is the health of a scraper target.
+type targetState struct {
+	// active is false while the target is stopped, as its status records.
+	active bool
+	// scraped is true once a scrape of the target completed, and up and
+	// duration are the outcome of the last one.
+	scraped  bool
+	up       bool
+	duration time.Duration
+}
+
+// targetHealth records the health of the scraper targets: whether they are
+// active, as recorded in their status, and the outcome of their last scrape.
+type targetHealth struct {
+	mu      sync.Mutex
+	targets map[influxdb.ID]*targetState
+}
+
+func newTargetHealth() *targetHealth {
+	return &targetHealth{targets: make(map[influxdb.ID]*targetState)}
+}
+
+// setTargets records the status of the targets as listed by the scheduler,
+// forgetting the targets that are no longer listed.
+func (h *targetHealth) setTargets(targets []influxdb.ScraperTarget) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	listed := make(map[influxdb.ID]*targetState, len(targets))
+	for _, target := range targets {
+		st := h.targets[target.ID]
+		if st == nil {
+			st = &targetState{}
+		}
+		st.active = target.Status == nil || target.Status.Active
+		listed[target.ID] = st
+	}
+	h.targets = listed
+}
+
+// recordScrape records the outcome of a scrape of a target.
+func (h *targetHealth) recordScrape(id influxdb.ID, up bool, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := h.targets[id]
+	if st == nil {
+		// The target is scraped before its status is first listed.
+		st = &targetState{active: true}
+		h.targets[id] = st
+	}
+	st.scraped, st.up, st.duration = true, up, d
+}
+
+// healthCollector exposes the health of scraper targets. The targets with the
+// lowest IDs have their own series, up to a cap, and the others are
+// aggregated into a series of the "other" target, as down if any of them is.
+type healthCollector struct {
+	health    *targetHealth
+	maxSeries func() int
+
+	activeDesc   *prometheus.Desc
+	upDesc       *prometheus.Desc
+	durationDesc *prometheus.Desc
+}
+
+func newHealthCollector(health *targetHealth, maxSeries func() int) *healthCollector {
+	const namespace = "scraper"
+	const subsystem = "target"
+
+	return &healthCollector{
+		health:    health,
+		maxSeries: maxSeries,
+		activeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "active"),
+			"Whether the target is being scraped, 0 while it is stopped because its owner cannot write to its bucket.",
+			[]string{"target"}, nil),
+		upDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "up"),
+			"Whether the last scrape of the target succeeded.",
+			[]string{"target"}, nil),
+		durationDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "scrape_duration_seconds"),
+			"The duration of the last scrape of the target in seconds.",
+			[]string{"target"}, nil),
+	}
+}
+
+// Describe returns all descriptions associated with the health collector.
+func (c *healthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeDesc
+	ch <- c.upDesc
+	ch <- c.durationDesc
+}
+
+// Collect returns the health of the targets.
+func (c *healthCollector) Collect(ch chan<- prometheus.Metric) {
+	c.health.mu.Lock()
+	ids := make([]influxdb.ID, 0, len(c.health.targets))
+	states := make(map[influxdb.ID]targetState, len(c.health.targets))
+	for id, st := range c.health.targets {
+		ids = append(ids, id)
+		states[id] = *st
+	}
+	c.health.mu.Unlock()
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	max := c.maxSeries()
+	if max <= 0 {
+		max = DefaultMaxHealthSeries
+	}
+
+	var overflow *targetState
+	for i, id := range ids {
+		st := states[id]
+		if i < max {
+			c.collectTarget(ch, id.String(), st)
+			continue
+		}
+		if overflow == nil {
+			overflow = &targetState{active: true, up: true}
+		}
+		overflow.active = overflow.active && st.active
+		if st.scraped {
+			overflow.scraped = true
+			overflow.up = overflow.up && st.up
+			if st.duration > overflow.duration {
+				overflow.duration = st.duration
+			}
+		}
+	}
+	if overflow != nil {
+		c.collectTarget(ch, overflowTarget, *overflow)
+	}
+}
+
+func (c *healthCollector) collectTarget(ch chan<- prometheus.Metric, target string, st targetState) {
+	ch <- prometheus.MustNewConstMetric(c.activeDesc, prometheus.GaugeValue, boolValue(st.active), target)
+	if !st.scraped {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, boolValue(st.up), target)
+	ch <- prometheus.MustNewConstMetric(c.durationDesc, prometheus.GaugeValue, st.duration.Seconds(), target)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package gather
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"go.uber.org/zap"
+)
+
+func TestScheduler_health(t *testing.T) {
+	s := &Scheduler{health: newTargetHealth(), metrics: newSchedulerMetrics(time.Second)}
+	reg := prom.NewRegistry(zap.NewNop())
+	reg.MustRegister(s.PrometheusCollectors()...)
+
+	s.health.setTargets([]influxdb.ScraperTarget{
+		{ID: 1},
+		{ID: 2, Status: &influxdb.ScraperTargetStatus{Active: false, Reason: "revoked"}},
+		{ID: 3},
+		{ID: 4},
+	})
+	s.health.recordScrape(1, true, 2*time.Second)
+	s.health.recordScrape(3, true, time.Second)
+	s.health.recordScrape(4, false, 3*time.Second)
+
+	value := func(name, target string) float64 {
+		t.Helper()
+		mfs := promtest.MustGather(t, reg)
+		return promtest.MustFindMetric(t, mfs, name, map[string]string{"target": target}).GetGauge().GetValue()
+	}
+	absent := func(name, target string) bool {
+		mfs := promtest.MustGather(t, reg)
+		return promtest.FindMetric(mfs, name, map[string]string{"target": target}) == nil
+	}
+
+	target1 := influxdb.ID(1).String()
+	target2 := influxdb.ID(2).String()
+	if got := value("scraper_target_up", target1); got != 1 {
+		t.Fatalf("expected target 1 up, got %v", got)
+	}
+	if got := value("scraper_target_scrape_duration_seconds", target1); got != 2 {
+		t.Fatalf("expected a scrape of 2s, got %v", got)
+	}
+	if got := value("scraper_target_active", target2); got != 0 {
+		t.Fatalf("expected stopped target 2 not active, got %v", got)
+	}
+	if !absent("scraper_target_up", target2) {
+		t.Fatal("expected no scrape outcome of a target never scraped")
+	}
+
+	// Past the cap, the targets with the highest IDs are aggregated.
+	s.MaxHealthSeries = 2
+	if !absent("scraper_target_active", influxdb.ID(3).String()) {
+		t.Fatal("expected target 3 to be aggregated")
+	}
+	if got := value("scraper_target_up", overflowTarget); got != 0 {
+		t.Fatalf("expected aggregated targets down as target 4 is, got %v", got)
+	}
+	if got := value("scraper_target_scrape_duration_seconds", overflowTarget); got != 3 {
+		t.Fatalf("expected the longest scrape of aggregated targets, got %v", got)
+	}
+	if got := value("scraper_target_active", overflowTarget); got != 1 {
+		t.Fatalf("expected aggregated targets active, got %v", got)
+	}
+
+	// Targets no longer listed are forgotten.
+	s.health.setTargets([]influxdb.ScraperTarget{{ID: 1}})
+	if !absent("scraper_target_active", overflowTarget) {
+		t.Fatal("expected no aggregated series under the cap")
+	}
+}
@@ -0,0 +1,52 @@
+package gather
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeOffset returns how long after the start of each interval the target
+// with id is scraped. Spread offsets are derived from a hash of the ID, so
+// they are the same on every tick and the target is still scraped exactly
+// once per interval.
+func scrapeOffset(id influxdb.ID, interval time.Duration, jitter influxdb.ScraperJitter) time.Duration {
+	if jitter != influxdb.ScraperJitterSpread || interval <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	b, _ := id.Encode()
+	h.Write(b)
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+// schedulerMetrics records when targets are scraped within their interval.
+type schedulerMetrics struct {
+	startOffset *prometheus.HistogramVec
+}
+
+func newSchedulerMetrics(interval time.Duration) *schedulerMetrics {
+	const namespace = "scraper"
+	const subsystem = "scheduler"
+
+	return &schedulerMetrics{
+		startOffset: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "scrape_start_offset_seconds",
+			Help:      "The time in seconds between the start of a scrape interval and a target being scraped, split out by jitter mode.",
+			Buckets:   prometheus.LinearBuckets(interval.Seconds()/10, interval.Seconds()/10, 10),
+		}, []string{"jitter"}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (m *schedulerMetrics) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{m.startOffset}
+}
+
+func (m *schedulerMetrics) observeStart(jitter influxdb.ScraperJitter, offset time.Duration) {
+	m.startOffset.WithLabelValues(string(jitter)).Observe(offset.Seconds())
+}
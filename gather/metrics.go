@@ -15,6 +15,28 @@ type MetricsCollection struct {
 	OrgID        influxdb.ID  `json:"orgID"`
 	BucketID     influxdb.ID  `json:"bucketID"`
 	MetricsSlice MetricsSlice `json:"metrics"`
+
+	// TargetID, BatchSize and BatchInterval carry the batching settings of
+	// the target the metrics were scraped from.
+	TargetID      influxdb.ID   `json:"targetID,omitempty"`
+	BatchSize     int           `json:"batchSize,omitempty"`
+	BatchInterval time.Duration `json:"batchInterval,omitempty"`
+}
+
+// newMetricsCollection returns the collection of the metrics ms scraped from
+// target, with its organization, bucket and batching settings.
+func newMetricsCollection(target influxdb.ScraperTarget, ms []Metrics) MetricsCollection {
+	collected := MetricsCollection{
+		MetricsSlice: ms,
+		OrgID:        target.OrgID,
+		BucketID:     target.BucketID,
+		TargetID:     target.ID,
+		BatchSize:    target.BatchSize,
+	}
+	if target.BatchInterval != nil {
+		collected.BatchInterval = target.BatchInterval.Duration
+	}
+	return collected
 }
 
 // Metrics is the default influx based metrics.
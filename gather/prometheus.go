@@ -2,7 +2,6 @@ package gather
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"math"
@@ -17,47 +16,37 @@ import (
 )
 
 // prometheusScraper handles parsing prometheus metrics.
-// implements Scraper interfaces.
-type prometheusScraper struct {
-	insecureHttp *http.Client
-}
+// implements Gatherer interfaces.
+type prometheusScraper struct{}
 
 // newPrometheusScraper create a new prometheusScraper.
 func newPrometheusScraper() *prometheusScraper {
-	customTransport := http.DefaultTransport.(*http.Transport).Clone()
-	customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	client := &http.Client{Transport: customTransport}
-
-	return &prometheusScraper{insecureHttp: client}
+	return &prometheusScraper{}
 }
 
 // Gather parse metrics from a scraper target url.
-func (p *prometheusScraper) Gather(ctx context.Context, target influxdb.ScraperTarget) (collected MetricsCollection, err error) {
-	var (
-		resp *http.Response
-	)
-
-	if target.AllowInsecure {
-		resp, err = p.insecureHttp.Get(target.URL)
-	} else {
-		resp, err = http.Get(target.URL)
+func (p *prometheusScraper) Gather(ctx context.Context, target influxdb.ScraperTarget) ([]Metrics, error) {
+	req, err := NewRequest(ctx, target)
+	if err != nil {
+		return nil, err
 	}
 
+	start := time.Now()
+	resp, err := Client(target).Do(req)
 	if err != nil {
-		return collected, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	return p.parse(resp.Body, resp.Header, target)
+	return p.parse(resp.Body, resp.Header, target, start)
 }
 
-func (p *prometheusScraper) parse(r io.Reader, header http.Header, target influxdb.ScraperTarget) (collected MetricsCollection, err error) {
+func (p *prometheusScraper) parse(r io.Reader, header http.Header, target influxdb.ScraperTarget, start time.Time) ([]Metrics, error) {
 	var parser expfmt.TextParser
-	now := time.Now()
 
 	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
 	if err != nil {
-		return collected, err
+		return nil, err
 	}
 	// Prepare output
 	metricFamilies := make(map[string]*dto.MetricFamily)
@@ -70,16 +59,17 @@ func (p *prometheusScraper) parse(r io.Reader, header http.Header, target influx
 				if err == io.EOF {
 					break
 				}
-				return collected, fmt.Errorf("reading metric family protocol buffer failed: %s", err)
+				return nil, fmt.Errorf("reading metric family protocol buffer failed: %s", err)
 			}
 			metricFamilies[mf.GetName()] = mf
 		}
 	} else {
 		metricFamilies, err = parser.TextToMetricFamilies(r)
 		if err != nil {
-			return collected, fmt.Errorf("reading text format failed: %s", err)
+			return nil, fmt.Errorf("reading text format failed: %s", err)
 		}
 	}
+	stamp := newTimestamper(target, start, time.Now())
 	ms := make([]Metrics, 0)
 
 	// read metrics
@@ -107,12 +97,8 @@ func (p *prometheusScraper) parse(r io.Reader, header http.Header, target influx
 			if len(fields) == 0 {
 				continue
 			}
-			tm := now
-			if m.TimestampMs != nil && *m.TimestampMs > 0 {
-				tm = time.Unix(0, *m.TimestampMs*1000000)
-			}
 			me := Metrics{
-				Timestamp: tm,
+				Timestamp: stamp(m),
 				Tags:      tags,
 				Fields:    fields,
 				Name:      name,
@@ -123,13 +109,29 @@ func (p *prometheusScraper) parse(r io.Reader, header http.Header, target influx
 
 	}
 
-	collected = MetricsCollection{
-		MetricsSlice: ms,
-		OrgID:        target.OrgID,
-		BucketID:     target.BucketID,
-	}
+	return ms, nil
+}
 
-	return collected, nil
+// newTimestamper returns a func returning the timestamp of a metric scraped
+// from target between start and end.
+func newTimestamper(target influxdb.ScraperTarget, start, end time.Time) func(m *dto.Metric) time.Time {
+	precision := target.Precision.Duration()
+	switch target.TimestampSource {
+	case influxdb.ScraperTimestampScrapeStart:
+		tm := start.Truncate(precision)
+		return func(*dto.Metric) time.Time { return tm }
+	case influxdb.ScraperTimestampScrapeEnd:
+		tm := end.Truncate(precision)
+		return func(*dto.Metric) time.Time { return tm }
+	default:
+		return func(m *dto.Metric) time.Time {
+			tm := end
+			if m.TimestampMs != nil && *m.TimestampMs > 0 {
+				tm = time.Unix(0, *m.TimestampMs*int64(time.Millisecond))
+			}
+			return tm.Truncate(precision)
+		}
+	}
 }
 
 // Get labels from metric
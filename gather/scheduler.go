@@ -5,34 +5,66 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/nats"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
 // nats subjects
 const (
-	MetricsSubject    = "metrics"
-	promTargetSubject = "promTarget"
+	MetricsSubject = "metrics"
+	// targetSubject is the subject of the targets to scrape, of any type.
+	// It is named after the first type for compatibility.
+	targetSubject = "promTarget"
 )
 
+// PermissionService finds the permissions of the user owning a target.
+type PermissionService interface {
+	FindPermissionForUser(ctx context.Context, UserID influxdb.ID) (influxdb.PermissionSet, error)
+}
+
 // Scheduler is struct to run scrape jobs.
 type Scheduler struct {
 	Targets influxdb.ScraperTargetStoreService
-	// Interval is between each metrics gathering event.
+	// Permissions is used to check that the owner of a target may still
+	// write to its bucket before each scrape.
+	Permissions PermissionService
+	// Interval is between each metrics gathering event of the targets that
+	// do not set their own interval.
 	Interval time.Duration
 	// Timeout is the maximum time duration allowed by each TCP request
 	Timeout time.Duration
+	// Jitter is when targets that do not set their own jitter mode are
+	// scraped within each interval. The empty value scrapes them at the
+	// start of the interval.
+	Jitter influxdb.ScraperJitter
+	// MaxHealthSeries is the number of targets whose health is reported as
+	// their own series; the others are aggregated. Zero uses
+	// DefaultMaxHealthSeries.
+	MaxHealthSeries int
 
 	// Publisher will send the gather requests and gathered metrics to the queue.
 	Publisher nats.Publisher
 
-	log *zap.Logger
+	log     *zap.Logger
+	metrics *schedulerMetrics
+	health  *targetHealth
+
+	// gather receives the time of each tick, when the interval of a target
+	// may have started.
+	gather chan time.Time
+	// last is the time of the previous tick, or zero before the first.
+	last time.Time
 
-	gather chan struct{}
+	mu sync.Mutex
+	// intervals holds the distinct intervals of the targets as last listed,
+	// which the ticks are scheduled by.
+	intervals map[time.Duration]struct{}
 }
 
 // NewScheduler creates a new Scheduler and subscriptions for scraper jobs.
@@ -40,6 +72,7 @@ func NewScheduler(
 	log *zap.Logger,
 	numScrapers int,
 	targets influxdb.ScraperTargetStoreService,
+	ps PermissionService,
 	p nats.Publisher,
 	s nats.Subscriber,
 	interval time.Duration,
@@ -52,19 +85,22 @@ func NewScheduler(
 		timeout = 30 * time.Second
 	}
 	scheduler := &Scheduler{
-		Targets:   targets,
-		Interval:  interval,
-		Timeout:   timeout,
-		Publisher: p,
-		log:       log,
-		gather:    make(chan struct{}, 100),
+		Targets:     targets,
+		Permissions: ps,
+		Interval:    interval,
+		Timeout:     timeout,
+		Publisher:   p,
+		log:         log,
+		metrics:     newSchedulerMetrics(interval),
+		health:      newTargetHealth(),
+		gather:      make(chan time.Time, 100),
 	}
 
 	for i := 0; i < numScrapers; i++ {
-		err := s.Subscribe(promTargetSubject, "metrics", &handler{
-			Scraper:   newPrometheusScraper(),
+		err := s.Subscribe(targetSubject, "metrics", &handler{
 			Publisher: p,
 			log:       log,
+			health:    scheduler.health,
 		})
 		if err != nil {
 			return nil, err
@@ -74,51 +110,195 @@ func NewScheduler(
 	return scheduler, nil
 }
 
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (s *Scheduler) PrometheusCollectors() []prometheus.Collector {
+	health := newHealthCollector(s.health, func() int { return s.MaxHealthSeries })
+	return append(s.metrics.PrometheusCollectors(), health)
+}
+
 // Run will retrieve scraper targets from the target storage,
 // and publish them to nats job queue for gather.
 func (s *Scheduler) Run(ctx context.Context) error {
 	go func(s *Scheduler, ctx context.Context) {
+		// Ticks are scheduled at the next start of an interval of any
+		// target. Intervals start at multiples of their duration, so they
+		// do not drift, and targets scraped at an offset into them are
+		// still scraped once per interval.
+		timer := time.NewTimer(time.Until(s.nextTick(time.Now())))
+		defer timer.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.After(s.Interval): // TODO: change to ticker because of garbage collection
-				s.gather <- struct{}{}
+			case tick := <-timer.C:
+				select {
+				case s.gather <- tick:
+				case <-ctx.Done():
+					return
+				}
+				timer.Reset(time.Until(s.nextTick(time.Now())))
 			}
 		}
 	}(s, ctx)
 	return s.run(ctx)
 }
 
+// nextTick returns the next start of an interval after now, of the default
+// interval or that of a target.
+func (s *Scheduler) nextTick(now time.Time) time.Time {
+	next := now.Truncate(s.Interval).Add(s.Interval)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for interval := range s.intervals {
+		if t := now.Truncate(interval).Add(interval); t.Before(next) {
+			next = t
+		}
+	}
+	return next
+}
+
+// interval returns how often target is scraped.
+func (s *Scheduler) interval(target influxdb.ScraperTarget) time.Duration {
+	if target.Interval != nil && target.Interval.Duration > 0 {
+		return target.Interval.Duration
+	}
+	return s.Interval
+}
+
 func (s *Scheduler) run(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-s.gather:
-			s.doGather(ctx)
+		case tick := <-s.gather:
+			s.doGather(ctx, tick)
 		}
 	}
 }
 
-func (s *Scheduler) doGather(ctx context.Context) {
-	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+func (s *Scheduler) doGather(runCtx context.Context, tick time.Time) {
+	ctx, cancel := context.WithTimeout(runCtx, s.Timeout)
 	defer cancel()
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	last := s.last
+	s.last = tick
+
 	targets, err := s.Targets.ListTargets(ctx, influxdb.ScraperTargetFilter{})
 	if err != nil {
 		s.log.Error("Cannot list targets", zap.Error(err))
 		tracing.LogError(span, err)
 		return
 	}
-	for _, target := range targets {
+
+	intervals := make(map[time.Duration]struct{})
+	defer s.health.setTargets(targets)
+	for i, target := range targets {
+		interval := s.interval(target)
+		intervals[interval] = struct{}{}
+
+		// A target is scraped once for each of its intervals, in the tick
+		// following the start of the interval.
+		start := tick.Truncate(interval)
+		if !last.IsZero() && !start.After(last) {
+			continue
+		}
+		if !s.authorizeTarget(ctx, &targets[i]) {
+			continue
+		}
+		s.scrapeAt(runCtx, start, interval, target)
+	}
+
+	s.mu.Lock()
+	s.intervals = intervals
+	s.mu.Unlock()
+}
+
+// jitter returns the jitter mode of target.
+func (s *Scheduler) jitter(target influxdb.ScraperTarget) influxdb.ScraperJitter {
+	switch {
+	case target.Jitter != "":
+		return target.Jitter
+	case s.Jitter != "":
+		return s.Jitter
+	default:
+		return influxdb.ScraperJitterNone
+	}
+}
+
+// scrapeAt requests a scrape of target at its offset into the interval
+// starting at start, or right away if that time has passed.
+func (s *Scheduler) scrapeAt(ctx context.Context, start time.Time, interval time.Duration, target influxdb.ScraperTarget) {
+	jitter := s.jitter(target)
+	scrape := func() {
+		s.metrics.observeStart(jitter, time.Since(start))
 		if err := requestScrape(target, s.Publisher); err != nil {
 			s.log.Error("JSON encoding error", zap.Error(err))
-			tracing.LogError(span, err)
 		}
 	}
+
+	delay := time.Until(start.Add(scrapeOffset(target.ID, interval, jitter)))
+	if delay <= 0 {
+		scrape()
+		return
+	}
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+			scrape()
+		}
+	}()
+}
+
+// authorizeTarget reports whether the owner of target may still write to its
+// bucket. The status of the target is updated whenever the outcome changes.
+func (s *Scheduler) authorizeTarget(ctx context.Context, target *influxdb.ScraperTarget) bool {
+	reason := s.unauthorizedReason(ctx, *target)
+	active := reason == ""
+
+	if st := target.Status; (st == nil && active) || (st != nil && st.Active == active && st.Reason == reason) {
+		return active
+	}
+
+	if active {
+		s.log.Info("Resuming scraper target", zap.Stringer("target", target.ID))
+	} else {
+		s.log.Warn("Stopping scraper target", zap.Stringer("target", target.ID), zap.String("reason", reason))
+	}
+	target.Status = &influxdb.ScraperTargetStatus{
+		Active:    active,
+		Reason:    reason,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if _, err := s.Targets.UpdateTarget(ctx, target, target.OwnerID); err != nil {
+		s.log.Error("Cannot update scraper target status", zap.Stringer("target", target.ID), zap.Error(err))
+	}
+	return active
+}
+
+// unauthorizedReason returns why target may not be scraped, or an empty
+// string if its owner has write permission on its bucket.
+func (s *Scheduler) unauthorizedReason(ctx context.Context, target influxdb.ScraperTarget) string {
+	if !target.OwnerID.Valid() {
+		return "target has no owner"
+	}
+	p, err := target.WritePermission()
+	if err != nil {
+		return err.Error()
+	}
+	ps, err := s.Permissions.FindPermissionForUser(ctx, target.OwnerID)
+	if err != nil {
+		return fmt.Sprintf("cannot find permissions of owner %s: %v", target.OwnerID, err)
+	}
+	if !ps.Allowed(*p) {
+		return fmt.Sprintf("owner %s is not authorized to %s", target.OwnerID, p)
+	}
+	return ""
 }
 
 func requestScrape(t influxdb.ScraperTarget, publisher nats.Publisher) error {
@@ -127,9 +307,8 @@ func requestScrape(t influxdb.ScraperTarget, publisher nats.Publisher) error {
 	if err != nil {
 		return err
 	}
-	switch t.Type {
-	case influxdb.PrometheusScraperType:
-		return publisher.Publish(promTargetSubject, buf)
+	if _, ok := gathererOf(t.Type); !ok {
+		return fmt.Errorf("unsupported target scrape type: %s", t.Type)
 	}
-	return fmt.Errorf("unsupported target scrape type: %s", t.Type)
+	return publisher.Publish(targetSubject, buf)
 }
@@ -2,6 +2,8 @@ package gather
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
@@ -12,6 +14,7 @@ import (
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/mock"
 	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"go.uber.org/zap/zaptest"
 )
 
 func TestScheduler(t *testing.T) {
@@ -37,6 +40,7 @@ func TestScheduler(t *testing.T) {
 				URL:      ts.URL + "/metrics",
 				OrgID:    *orgID,
 				BucketID: *bucketID,
+				OwnerID:  ownerID,
 			},
 		},
 		TotalGatherJobs: make(chan struct{}, totalGatherJobs),
@@ -47,7 +51,7 @@ func TestScheduler(t *testing.T) {
 		Recorder: storage,
 	})
 
-	scheduler, err := NewScheduler(logger, 10, storage, publisher, subscriber, time.Millisecond, time.Microsecond)
+	scheduler, err := NewScheduler(logger, 10, storage, newOwnerPermissions(true), publisher, subscriber, time.Millisecond, time.Microsecond)
 
 	go func() {
 		err = scheduler.run(ctx)
@@ -61,7 +65,7 @@ func TestScheduler(t *testing.T) {
 		for i := 0; i < totalGatherJobs; i++ {
 			// make sure timestamp don't overwrite each other
 			time.Sleep(time.Millisecond * 10)
-			scheduler.gather <- struct{}{}
+			scheduler.gather <- time.Now()
 		}
 	}(scheduler)
 
@@ -91,8 +95,252 @@ func TestScheduler(t *testing.T) {
 	ts.Close()
 }
 
+func TestScheduler_authorizeTarget(t *testing.T) {
+	ctx := context.Background()
+	target := influxdb.ScraperTarget{
+		ID:       influxdbtesting.MustIDBase16("3a0d0a6365646120"),
+		Type:     influxdb.PrometheusScraperType,
+		OrgID:    *orgID,
+		BucketID: *bucketID,
+		OwnerID:  ownerID,
+	}
+	storage := &mockStorage{Targets: []influxdb.ScraperTarget{target}}
+	scheduler := &Scheduler{Targets: storage, log: zaptest.NewLogger(t)}
+
+	// An authorized target without a status is scraped without being updated.
+	scheduler.Permissions = newOwnerPermissions(true)
+	if !scheduler.authorizeTarget(ctx, &storage.Targets[0]) {
+		t.Fatal("expected authorized target to be scraped")
+	}
+	if storage.Targets[0].Status != nil {
+		t.Fatalf("unexpected status: %+v", storage.Targets[0].Status)
+	}
+
+	// Revoking the owner's permission stops the target and records why.
+	scheduler.Permissions = newOwnerPermissions(false)
+	if scheduler.authorizeTarget(ctx, &storage.Targets[0]) {
+		t.Fatal("expected unauthorized target not to be scraped")
+	}
+	status := storage.Targets[0].Status
+	if status == nil || status.Active || status.Reason == "" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	// Granting it again resumes the target.
+	scheduler.Permissions = newOwnerPermissions(true)
+	if !scheduler.authorizeTarget(ctx, &storage.Targets[0]) {
+		t.Fatal("expected authorized target to be scraped")
+	}
+	if status := storage.Targets[0].Status; status == nil || !status.Active {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	// A target without an owner is not scraped.
+	target.OwnerID = 0
+	if scheduler.authorizeTarget(ctx, &target) {
+		t.Fatal("expected target without owner not to be scraped")
+	}
+}
+
+func TestScrapeOffset(t *testing.T) {
+	interval := 10 * time.Second
+
+	offsets := make(map[time.Duration]bool)
+	for id := influxdb.ID(1); id <= 100; id++ {
+		if got := scrapeOffset(id, interval, influxdb.ScraperJitterNone); got != 0 {
+			t.Fatalf("expected no offset without jitter, got %s", got)
+		}
+		got := scrapeOffset(id, interval, influxdb.ScraperJitterSpread)
+		if got < 0 || got >= interval {
+			t.Fatalf("offset %s of target %s is outside of the interval", got, id)
+		}
+		if again := scrapeOffset(id, interval, influxdb.ScraperJitterSpread); again != got {
+			t.Fatalf("offset of target %s changed from %s to %s", id, got, again)
+		}
+		offsets[got/time.Second] = true
+	}
+	if len(offsets) < 8 {
+		t.Fatalf("expected offsets to be spread across the interval, got %d distinct seconds", len(offsets))
+	}
+}
+
+func TestScheduler_jitter(t *testing.T) {
+	scheduler := &Scheduler{}
+	target := influxdb.ScraperTarget{}
+	if got := scheduler.jitter(target); got != influxdb.ScraperJitterNone {
+		t.Fatalf("expected targets to be scraped without jitter by default, got %q", got)
+	}
+	scheduler.Jitter = influxdb.ScraperJitterSpread
+	if got := scheduler.jitter(target); got != influxdb.ScraperJitterSpread {
+		t.Fatalf("expected the scheduler default, got %q", got)
+	}
+	target.Jitter = influxdb.ScraperJitterNone
+	if got := scheduler.jitter(target); got != influxdb.ScraperJitterNone {
+		t.Fatalf("expected the target override, got %q", got)
+	}
+}
+
+func TestScheduler_interval(t *testing.T) {
+	scheduler := &Scheduler{Interval: 10 * time.Second}
+	target := influxdb.ScraperTarget{}
+	if got := scheduler.interval(target); got != 10*time.Second {
+		t.Fatalf("expected the scheduler default, got %s", got)
+	}
+	target.Interval = &influxdb.Duration{Duration: time.Minute}
+	if got := scheduler.interval(target); got != time.Minute {
+		t.Fatalf("expected the target override, got %s", got)
+	}
+}
+
+func TestScheduler_nextTick(t *testing.T) {
+	scheduler := &Scheduler{Interval: 10 * time.Second}
+	now := time.Date(2020, 1, 1, 0, 0, 12, 0, time.UTC)
+	if got, want := scheduler.nextTick(now), now.Add(8*time.Second); !got.Equal(want) {
+		t.Fatalf("expected the next start of the default interval %s, got %s", want, got)
+	}
+
+	// The ticks follow the shortest interval of any target.
+	scheduler.intervals = map[time.Duration]struct{}{
+		time.Minute:     {},
+		3 * time.Second: {},
+	}
+	if got, want := scheduler.nextTick(now), now.Add(3*time.Second); !got.Equal(want) {
+		t.Fatalf("expected the next start of the target interval %s, got %s", want, got)
+	}
+}
+
+var ownerID = influxdbtesting.MustIDBase16("020f755c3c082000")
+
+// newOwnerPermissions returns a permission service that grants the owner
+// write access to the test bucket if writable is true.
+func newOwnerPermissions(writable bool) PermissionService {
+	users := mock.NewUserService()
+	users.FindPermissionForUserFn = func(ctx context.Context, id influxdb.ID) (influxdb.PermissionSet, error) {
+		if id != ownerID || !writable {
+			return nil, nil
+		}
+		p, err := influxdb.NewPermissionAtID(*bucketID, influxdb.WriteAction, influxdb.BucketsResourceType, *orgID)
+		if err != nil {
+			return nil, err
+		}
+		return influxdb.PermissionSet{*p}, nil
+	}
+	return users
+}
+
 const sampleRespSmall = `
 # HELP go_goroutines Number of goroutines that currently exist.
 # TYPE go_goroutines gauge
 go_goroutines 36
 `
+
+// staticScraperType is the type of the targets gathered by staticGatherer.
+const staticScraperType influxdb.ScraperType = "static"
+
+// staticGatherer gathers an "up" gauge from any target answering a GET of
+// its URL, with the target's credentials and TLS settings.
+type staticGatherer struct{}
+
+func (staticGatherer) Gather(ctx context.Context, target influxdb.ScraperTarget) ([]Metrics, error) {
+	req, err := NewRequest(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := Client(target).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return []Metrics{{
+		Name:      "up",
+		Type:      MetricTypeGauge,
+		Tags:      map[string]string{},
+		Fields:    map[string]interface{}{"gauge": float64(1)},
+		Timestamp: time.Now().Truncate(target.Precision.Duration()),
+	}}, nil
+}
+
+// collectionRecorder records the last collection recorded.
+type collectionRecorder struct {
+	collected chan MetricsCollection
+}
+
+func (r collectionRecorder) Record(collected MetricsCollection) error {
+	r.collected <- collected
+	return nil
+}
+
+func TestScheduler_RegisteredGatherer(t *testing.T) {
+	RegisterGatherer(staticScraperType, staticGatherer{})
+	// Registering the same gatherer again does nothing.
+	RegisterGatherer(staticScraperType, staticGatherer{})
+
+	if !influxdb.ValidScraperType(string(staticScraperType)) {
+		t.Fatal("expected a registered scraper type to be valid")
+	}
+	if err := (influxdb.ScraperTarget{Type: staticScraperType, BearerToken: "token"}).ValidCredentials(); err != nil {
+		t.Fatalf("expected a registered scraper type to support credentials: %v", err)
+	}
+	for _, typ := range []influxdb.ScraperType{staticScraperType, influxdb.PrometheusScraperType} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected registering another gatherer for the %s scraper type to panic", typ)
+				}
+			}()
+			RegisterGatherer(typ, &staticGatherer{})
+		}()
+	}
+
+	// The target is only gathered with its credentials and TLS settings.
+	ts := httptest.NewTLSServer(&mockHTTPHandler{
+		responseMap: map[string]string{"/status": "ok"},
+		headers:     map[string]string{"Authorization": "Bearer token"},
+	})
+	defer ts.Close()
+
+	publisher, subscriber := mock.NewNats()
+	storage := &mockStorage{
+		Targets: []influxdb.ScraperTarget{
+			{
+				ID:            influxdbtesting.MustIDBase16("3a0d0a6365646121"),
+				Type:          staticScraperType,
+				URL:           ts.URL + "/status",
+				OrgID:         *orgID,
+				BucketID:      *bucketID,
+				OwnerID:       ownerID,
+				BearerToken:   "token",
+				AllowInsecure: true,
+				BatchSize:     5,
+			},
+		},
+	}
+	recorder := collectionRecorder{collected: make(chan MetricsCollection, 1)}
+	subscriber.Subscribe(MetricsSubject, "", &RecorderHandler{
+		log:      zaptest.NewLogger(t),
+		Recorder: recorder,
+	})
+
+	scheduler, err := NewScheduler(zaptest.NewLogger(t), 1, storage, newOwnerPermissions(true), publisher, subscriber, time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.doGather(context.Background(), time.Now())
+
+	var collected MetricsCollection
+	select {
+	case collected = <-recorder.collected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the target was not gathered")
+	}
+	if len(collected.MetricsSlice) != 1 || collected.MetricsSlice[0].Name != "up" {
+		t.Fatalf("expected the up metric, got %v", collected.MetricsSlice)
+	}
+	target := storage.Targets[0]
+	if collected.OrgID != target.OrgID || collected.BucketID != target.BucketID || collected.TargetID != target.ID || collected.BatchSize != target.BatchSize {
+		t.Fatalf("expected the collection to carry the settings of the target, got %+v", collected)
+	}
+}
@@ -2,11 +2,101 @@ package gather
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
 
 	"github.com/influxdata/influxdb/v2"
 )
 
-// Scraper gathers metrics from a scraper target.
-type Scraper interface {
-	Gather(ctx context.Context, target influxdb.ScraperTarget) (collected MetricsCollection, err error)
+// Gatherer gathers the metrics of the scraper targets of a type.
+//
+// A gatherer is given the whole target, and must honor its URL, headers,
+// credentials, AllowInsecure, Precision and TimestampSource; NewRequest and
+// Client do so for HTTP targets. The organization, bucket and batching
+// settings of the target are applied to the metrics it returns. Gatherers
+// are shared by all the scrapes of their type, so must be safe for
+// concurrent use.
+type Gatherer interface {
+	Gather(ctx context.Context, target influxdb.ScraperTarget) ([]Metrics, error)
+}
+
+var gatherers = struct {
+	sync.RWMutex
+	m map[influxdb.ScraperType]Gatherer
+}{
+	m: map[influxdb.ScraperType]Gatherer{
+		influxdb.PrometheusScraperType: newPrometheusScraper(),
+	},
+}
+
+// RegisterGatherer makes g gather the targets of type typ, which becomes a
+// valid scraper type. Gatherers must be registered before the scheduler is
+// created, typically when the launcher is constructed. Registering the same
+// gatherer again for a type does nothing, so launchers may be constructed
+// repeatedly with it. It panics if typ is empty or already registered with
+// another gatherer.
+func RegisterGatherer(typ influxdb.ScraperType, g Gatherer) {
+	gatherers.Lock()
+	defer gatherers.Unlock()
+	if g == nil {
+		panic(fmt.Sprintf("gatherer of scraper type %q is nil", typ))
+	}
+	if registered, ok := gatherers.m[typ]; ok {
+		// Gatherers of uncomparable types are never the same.
+		if reflect.TypeOf(g).Comparable() && registered == g {
+			return
+		}
+		panic(fmt.Sprintf("scraper type %q already has another gatherer", typ))
+	}
+	influxdb.RegisterScraperType(typ)
+	gatherers.m[typ] = g
+}
+
+// gathererOf returns the gatherer registered for typ.
+func gathererOf(typ influxdb.ScraperType) (Gatherer, bool) {
+	gatherers.RLock()
+	defer gatherers.RUnlock()
+	g, ok := gatherers.m[typ]
+	return g, ok
+}
+
+// NewRequest returns a GET request of the URL of target, with its headers
+// and credentials.
+func NewRequest(ctx context.Context, target influxdb.ScraperTarget) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range target.Headers {
+		// The Host header is only sent as the host of the request.
+		if http.CanonicalHeaderKey(name) == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	if target.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+target.BearerToken)
+	} else if target.Username != "" {
+		req.SetBasicAuth(target.Username, target.Password)
+	}
+	return req, nil
+}
+
+var insecureClient = func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Transport: transport}
+}()
+
+// Client returns the HTTP client scraping target, which skips the
+// verification of TLS certificates if the target allows it.
+func Client(target influxdb.ScraperTarget) *http.Client {
+	if target.AllowInsecure {
+		return insecureClient
+	}
+	return http.DefaultClient
 }
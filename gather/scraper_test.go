@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -138,11 +139,11 @@ func TestPrometheusScraper(t *testing.T) {
 		if err != nil && !c.hasErr {
 			t.Fatalf("scraper parse err in testing %s: %v", c.name, err)
 		}
-		if len(c.ms) != len(results.MetricsSlice) {
+		if len(c.ms) != len(results) {
 			t.Fatalf("scraper parse metrics incorrect length, want %d, got %d",
-				len(c.ms), len(results.MetricsSlice))
+				len(c.ms), len(results))
 		}
-		for _, m := range results.MetricsSlice {
+		for _, m := range results {
 			for _, cm := range c.ms {
 				if m.Name == cm.Name {
 					if diff := cmp.Diff(m, cm, metricsCmpOption); diff != "" {
@@ -275,10 +276,27 @@ func (s *mockStorage) UpdateTarget(ctx context.Context, update *influxdb.Scraper
 	return update, err
 }
 
+func (s *mockStorage) PatchTarget(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for k, v := range s.Targets {
+		if v.ID == id {
+			upd.Apply(&s.Targets[k])
+			target := s.Targets[k]
+			return &target, nil
+		}
+	}
+	return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: influxdb.ErrScraperTargetNotFound}
+}
+
 type mockHTTPHandler struct {
 	unauthorized bool
 	noContent    bool
 	responseMap  map[string]string
+	// headers are required of requests, which are unauthorized without
+	// them.
+	headers map[string]string
 }
 
 func (h mockHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -286,6 +304,12 @@ func (h mockHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	for name, value := range h.headers {
+		if r.Header.Get(name) != value {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
 	if h.noContent {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -307,3 +331,184 @@ var metricsCmpOption = cmp.Options{
 			reflect.DeepEqual(x.Fields, y.Fields)
 	}),
 }
+
+func TestPrometheusScraperHeaders(t *testing.T) {
+	handler := &mockHTTPHandler{
+		responseMap: map[string]string{
+			"/metrics": sampleResp,
+		},
+		headers: map[string]string{
+			"Authorization": "Bearer secret",
+			"X-Scope-OrgID": "tenant",
+		},
+	}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	scraper := newPrometheusScraper()
+	target := influxdb.ScraperTarget{
+		URL: ts.URL + "/metrics",
+	}
+	if _, err := scraper.Gather(context.Background(), target); err == nil {
+		t.Fatal("expected scrape without headers to fail")
+	}
+
+	target.Headers = influxdb.ScraperHeaders(handler.headers)
+	collected, err := scraper.Gather(context.Background(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collected) == 0 {
+		t.Fatal("expected metrics to be scraped with headers")
+	}
+}
+
+func TestPrometheusScraperCredentials(t *testing.T) {
+	cases := []struct {
+		name          string
+		target        influxdb.ScraperTarget
+		authorization string
+	}{
+		{
+			name: "basic auth",
+			target: influxdb.ScraperTarget{
+				Username: "user",
+				Password: "pass",
+			},
+			authorization: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name: "bearer token",
+			target: influxdb.ScraperTarget{
+				BearerToken: "token",
+			},
+			authorization: "Bearer token",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ts := httptest.NewServer(&mockHTTPHandler{
+				responseMap: map[string]string{
+					"/metrics": sampleResp,
+				},
+				headers: map[string]string{
+					"Authorization": c.authorization,
+				},
+			})
+			defer ts.Close()
+
+			target := c.target
+			target.URL = ts.URL + "/metrics"
+			collected, err := newPrometheusScraper().Gather(context.Background(), target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(collected) == 0 {
+				t.Fatal("expected metrics to be scraped with credentials")
+			}
+		})
+	}
+}
+
+func TestPrometheusScraperTimestamps(t *testing.T) {
+	// Only some samples of the exposition carry a timestamp.
+	const resp = `
+# TYPE exported gauge
+exported{host="a"} 1 1600000000123
+exported{host="b"} 2
+# TYPE unstamped counter
+unstamped 3
+`
+	start := time.Date(2020, 9, 13, 12, 0, 0, 111222333, time.UTC)
+	exported := time.Unix(0, 1600000000123*int64(time.Millisecond))
+
+	cases := []struct {
+		name      string
+		source    influxdb.ScraperTimestampSource
+		precision influxdb.ScraperPrecision
+		// want returns the timestamp expected for a sample given the end of
+		// the scrape.
+		want map[string]func(end time.Time) time.Time
+	}{
+		{
+			name: "default",
+			want: map[string]func(time.Time) time.Time{
+				"a":         func(time.Time) time.Time { return exported },
+				"b":         func(end time.Time) time.Time { return end },
+				"unstamped": func(end time.Time) time.Time { return end },
+			},
+		},
+		{
+			name:      "exporter in seconds",
+			source:    influxdb.ScraperTimestampExporter,
+			precision: influxdb.ScraperPrecisionSecond,
+			want: map[string]func(time.Time) time.Time{
+				"a":         func(time.Time) time.Time { return time.Unix(1600000000, 0) },
+				"b":         func(end time.Time) time.Time { return end.Truncate(time.Second) },
+				"unstamped": func(end time.Time) time.Time { return end.Truncate(time.Second) },
+			},
+		},
+		{
+			name:      "scrape start in milliseconds",
+			source:    influxdb.ScraperTimestampScrapeStart,
+			precision: influxdb.ScraperPrecisionMillisecond,
+			want: map[string]func(time.Time) time.Time{
+				"a":         func(time.Time) time.Time { return start.Truncate(time.Millisecond) },
+				"b":         func(time.Time) time.Time { return start.Truncate(time.Millisecond) },
+				"unstamped": func(time.Time) time.Time { return start.Truncate(time.Millisecond) },
+			},
+		},
+		{
+			name:      "scrape end",
+			source:    influxdb.ScraperTimestampScrapeEnd,
+			precision: influxdb.ScraperPrecisionNanosecond,
+			want: map[string]func(time.Time) time.Time{
+				"a":         func(end time.Time) time.Time { return end },
+				"b":         func(end time.Time) time.Time { return end },
+				"unstamped": func(end time.Time) time.Time { return end },
+			},
+		},
+	}
+
+	header := http.Header{"Content-Type": []string{"text/plain; version=0.0.4"}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			before := time.Now()
+			collected, err := newPrometheusScraper().parse(strings.NewReader(resp), header, influxdb.ScraperTarget{
+				TimestampSource: c.source,
+				Precision:       c.precision,
+			}, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after := time.Now()
+			if len(collected) != 3 {
+				t.Fatalf("expected 3 metrics, got %d", len(collected))
+			}
+
+			// Samples stamped with the end of the scrape share a timestamp.
+			var end time.Time
+			for _, m := range collected {
+				if m.Name == "unstamped" {
+					end = m.Timestamp
+				}
+			}
+			if c.source != influxdb.ScraperTimestampScrapeStart {
+				if end.Before(before.Truncate(c.precision.Duration())) || end.After(after) {
+					t.Fatalf("expected scrape end between %v and %v, got %v", before, after, end)
+				}
+			}
+
+			for _, m := range collected {
+				key := m.Name
+				if host, ok := m.Tags["host"]; ok {
+					key = host
+				}
+				if want := c.want[key](end); !m.Timestamp.Equal(want) {
+					t.Errorf("%s: expected timestamp %v, got %v", key, want, m.Timestamp)
+				}
+			}
+		})
+	}
+}
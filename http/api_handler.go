@@ -12,10 +12,12 @@ import (
 	"github.com/influxdata/influxdb/v2/dbrp"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	"github.com/influxdata/influxdb/v2/influxql"
+	"github.com/influxdata/influxdb/v2/job"
 	"github.com/influxdata/influxdb/v2/kit/feature"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/quota"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -49,6 +51,30 @@ type APIBackend struct {
 	// write request. A value of zero specifies there is no limit.
 	WriteParserMaxValues int
 
+	// WriteIdempotencyCache deduplicates writes carrying an Idempotency-Key header.
+	// When nil, the header is ignored.
+	WriteIdempotencyCache *IdempotencyCache
+
+	// IngestQuotaService manages the daily ingest quotas of organizations
+	// and IngestQuotaEnforcer applies them to writes. When nil, writes are
+	// not limited.
+	IngestQuotaService  influxdb.IngestQuotaService
+	IngestQuotaEnforcer influxdb.IngestQuotaEnforcer
+
+	// QueryResultLimitService manages the limits on the results of queries
+	// of organizations and QueryResultLimiter applies them to flux queries.
+	// When nil, query results are not limited.
+	QueryResultLimitService influxdb.QueryResultLimitService
+	QueryResultLimiter      influxdb.QueryResultLimiter
+
+	// ResponseStats reports the points written by writes and the bytes
+	// read, rows returned and duration of queries in response headers.
+	ResponseStats bool
+
+	// NonFiniteFields counts the NaN and infinite float field values skipped
+	// by writes. When nil, they are not counted.
+	NonFiniteFields *prometheus.CounterVec
+
 	NewBucketService func(*influxdb.Source) (influxdb.BucketService, error)
 	NewQueryService  func(*influxdb.Source) (query.ProxyQueryService, error)
 
@@ -59,8 +85,16 @@ type APIBackend struct {
 
 	PointsWriter                    storage.PointsWriter
 	DeleteService                   influxdb.DeleteService
+	JobService                      influxdb.JobService
+	JobSubmitter                    influxdb.JobSubmitter
 	BackupService                   influxdb.BackupService
 	RestoreService                  influxdb.RestoreService
+	UsageReportService              influxdb.UsageReportService
+	OrphanReportService             influxdb.OrphanReportService
+	MaintenanceService              influxdb.MaintenanceService
+	SummaryService                  influxdb.SummaryService
+	CacheService                    influxdb.CacheService
+	SlowQueryService                influxdb.SlowQueryService
 	AuthorizationService            influxdb.AuthorizationService
 	AuthorizerV1                    influxdb.AuthorizerV1
 	OnboardingService               influxdb.OnboardingService
@@ -94,6 +128,7 @@ type APIBackend struct {
 	DocumentService                 influxdb.DocumentService
 	NotificationRuleStore           influxdb.NotificationRuleStore
 	NotificationEndpointService     influxdb.NotificationEndpointService
+	NotificationDeliveryService     influxdb.NotificationDeliveryService
 	Flagger                         feature.Flagger
 	FlagsHandler                    http.Handler
 }
@@ -110,6 +145,10 @@ func (b *APIBackend) PrometheusCollectors() []prometheus.Collector {
 		cs = append(cs, pc.PrometheusCollectors()...)
 	}
 
+	if b.NonFiniteFields != nil {
+		cs = append(cs, b.NonFiniteFields)
+	}
+
 	return cs
 }
 
@@ -164,7 +203,8 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	scraperBackend := NewScraperBackend(b.Logger.With(zap.String("handler", "scraper")), b)
 	scraperBackend.ScraperStorageService = authorizer.NewScraperTargetStoreService(b.ScraperTargetStoreService,
 		b.UserResourceMappingService,
-		b.OrganizationService)
+		b.OrganizationService,
+		b.UserService)
 	h.Mount(prefixTargets, NewScraperHandler(b.Logger, scraperBackend))
 
 	sourceBackend := NewSourceBackend(b.Logger.With(zap.String("handler", "source")), b)
@@ -199,11 +239,49 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	restoreBackend.RestoreService = authorizer.NewRestoreService(restoreBackend.RestoreService)
 	h.Mount(prefixRestore, NewRestoreHandler(restoreBackend))
 
+	usageBackend := NewUsageBackend(b)
+	usageBackend.UsageReportService = authorizer.NewUsageReportService(usageBackend.UsageReportService)
+	h.Mount(prefixUsage, NewUsageHandler(usageBackend))
+
+	orphanBackend := NewOrphanBackend(b)
+	orphanBackend.OrphanReportService = authorizer.NewOrphanReportService(orphanBackend.OrphanReportService)
+	h.Mount(prefixOrphans, NewOrphanHandler(orphanBackend))
+
+	maintenanceBackend := NewMaintenanceBackend(b)
+	maintenanceBackend.MaintenanceService = authorizer.NewMaintenanceService(maintenanceBackend.MaintenanceService)
+	h.Mount(prefixMaintenance, NewMaintenanceHandler(maintenanceBackend))
+
+	summaryBackend := NewSummaryBackend(b)
+	summaryBackend.SummaryService = authorizer.NewSummaryService(summaryBackend.SummaryService)
+	h.Mount(prefixSummary, NewSummaryHandler(summaryBackend))
+
+	cacheBackend := NewCacheBackend(b)
+	cacheBackend.CacheService = authorizer.NewCacheService(cacheBackend.CacheService)
+	h.Mount(prefixDebugCache, NewCacheHandler(cacheBackend))
+
+	slowQueryBackend := NewSlowQueryBackend(b)
+	slowQueryBackend.SlowQueryService = authorizer.NewSlowQueryService(slowQueryBackend.SlowQueryService)
+	h.Mount(prefixDebugSlowQueries, NewSlowQueryHandler(slowQueryBackend))
+
 	h.Mount(dbrp.PrefixDBRP, dbrp.NewHTTPHandler(b.Logger, b.DBRPService, b.OrganizationService))
 
+	if b.IngestQuotaService != nil {
+		h.Mount(quota.PrefixIngestQuota, quota.NewHTTPHandler(b.Logger.With(zap.String("handler", "ingest_quota")), quota.NewAuthorizedService(b.IngestQuotaService)))
+	}
+	if b.QueryResultLimitService != nil {
+		h.Mount(quota.PrefixQueryLimits, quota.NewQueryLimitHTTPHandler(b.Logger.With(zap.String("handler", "query_limits")), quota.NewAuthorizedQueryLimitService(b.QueryResultLimitService)))
+	}
+	if b.JobService != nil {
+		h.Mount(job.PrefixJobs, job.NewHTTPHandler(b.Logger.With(zap.String("handler", "jobs")), job.NewAuthorizedService(b.JobService)))
+	}
+
 	writeBackend := NewWriteBackend(b.Logger.With(zap.String("handler", "write")), b)
 	h.Mount(prefixWrite, NewWriteHandler(b.Logger, writeBackend,
 		WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
+		WithIdempotencyCache(b.WriteIdempotencyCache),
+		WithIngestQuota(b.IngestQuotaEnforcer),
+		WithResponseStats(b.ResponseStats),
+		WithNonFiniteFieldsCounter(b.NonFiniteFields),
 		//WithParserOptions(
 		//	models.WithParserMaxBytes(b.WriteParserMaxBytes),
 		//	models.WithParserMaxLines(b.WriteParserMaxLines),
@@ -256,6 +334,7 @@ var apiLinks = map[string]interface{}{
 	"checks":    "/api/v2/checks",
 	"telegrafs": "/api/v2/telegrafs",
 	"plugins":   "/api/v2/telegraf/plugins",
+	"usage":     "/api/v2/usage",
 	"users":     "/api/v2/users",
 	"write":     "/api/v2/write",
 	"delete":    "/api/v2/delete",
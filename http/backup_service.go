@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -47,6 +48,7 @@ const (
 	prefixBackup      = "/api/v2/backup"
 	backupKVStorePath = prefixBackup + "/kv"
 	backupShardPath   = prefixBackup + "/shards/:shardID"
+	backupSizesPath   = prefixBackup + "/sizes"
 
 	httpClientTimeout = time.Hour
 )
@@ -62,6 +64,7 @@ func NewBackupHandler(b *BackupBackend) *BackupHandler {
 
 	h.HandlerFunc(http.MethodGet, backupKVStorePath, h.handleBackupKVStore)
 	h.HandlerFunc(http.MethodGet, backupShardPath, h.handleBackupShard)
+	h.HandlerFunc(http.MethodGet, backupSizesPath, h.handleGetShardSizes)
 
 	return h
 }
@@ -72,7 +75,17 @@ func (h *BackupHandler) handleBackupKVStore(w http.ResponseWriter, r *http.Reque
 
 	ctx := r.Context()
 
-	if err := h.BackupService.BackupKVStore(ctx, w); err != nil {
+	var dst io.Writer = w
+	progress := newProgressStream(w, r)
+	if progress != nil {
+		dst = progress.writer()
+	}
+
+	err := h.BackupService.BackupKVStore(ctx, dst)
+	if progress.finish(nil, err) {
+		return
+	}
+	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
@@ -99,12 +112,61 @@ func (h *BackupHandler) handleBackupShard(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	if err := h.BackupService.BackupShard(ctx, w, shardID, since); err != nil {
+	var dst io.Writer = w
+	progress := newProgressStream(w, r)
+	if progress != nil {
+		dst = progress.writer()
+	}
+
+	if measurements := r.URL.Query()["measurement"]; len(measurements) > 0 {
+		err = h.BackupService.BackupShardMeasurements(ctx, dst, shardID, since, measurements)
+	} else {
+		err = h.BackupService.BackupShard(ctx, dst, shardID, since)
+	}
+	if progress.finish(nil, err) {
+		return
+	}
+	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
 }
 
+func (h *BackupHandler) handleGetShardSizes(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "BackupHandler.handleGetShardSizes")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	var shardIDs []uint64
+	for _, s := range r.URL.Query()["shardID"] {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			h.HandleHTTPError(ctx, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("invalid shard ID %q", s),
+				Err:  err,
+			}, w)
+			return
+		}
+		shardIDs = append(shardIDs, id)
+	}
+
+	sizes, err := h.BackupService.ShardSizes(ctx, shardIDs)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	if sizes == nil {
+		sizes = []influxdb.ShardSize{}
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, sizes); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
 // BackupService is the client implementation of influxdb.BackupService.
 type BackupService struct {
 	Addr               string
@@ -130,33 +192,36 @@ func (s *BackupService) BackupKVStore(ctx context.Context, w io.Writer) error {
 
 	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
 	hc.Timeout = httpClientTimeout
-	resp, err := hc.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	return doTransfer(hc, req, w, nil)
+}
 
-	if err := CheckError(resp); err != nil {
-		return err
-	}
+func (s *BackupService) BackupShard(ctx context.Context, w io.Writer, shardID uint64, since time.Time) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
 
-	if _, err := io.Copy(w, resp.Body); err != nil {
-		return err
-	}
-	return resp.Body.Close()
+	return s.backupShard(ctx, w, shardID, since, nil)
 }
 
-func (s *BackupService) BackupShard(ctx context.Context, w io.Writer, shardID uint64, since time.Time) error {
+func (s *BackupService) BackupShardMeasurements(ctx context.Context, w io.Writer, shardID uint64, since time.Time, measurements []string) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	return s.backupShard(ctx, w, shardID, since, measurements)
+}
+
+func (s *BackupService) backupShard(ctx context.Context, w io.Writer, shardID uint64, since time.Time, measurements []string) error {
 	u, err := NewURL(s.Addr, fmt.Sprintf(prefixBackup+"/shards/%d", shardID))
 	if err != nil {
 		return err
 	}
+	params := url.Values{}
 	if !since.IsZero() {
-		u.RawQuery = (url.Values{"since": {since.UTC().Format(time.RFC3339)}}).Encode()
+		params.Set("since", since.UTC().Format(time.RFC3339))
 	}
+	for _, name := range measurements {
+		params.Add("measurement", name)
+	}
+	u.RawQuery = params.Encode()
 
 	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
 	if err != nil {
@@ -167,18 +232,44 @@ func (s *BackupService) BackupShard(ctx context.Context, w io.Writer, shardID ui
 
 	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
 	hc.Timeout = httpClientTimeout
+	return doTransfer(hc, req, w, nil)
+}
+
+func (s *BackupService) ShardSizes(ctx context.Context, shardIDs []uint64) ([]influxdb.ShardSize, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	u, err := NewURL(s.Addr, backupSizesPath)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	for _, id := range shardIDs {
+		params.Add("shardID", strconv.FormatUint(id, 10))
+	}
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	SetToken(s.Token, req)
+	req = req.WithContext(ctx)
+
+	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
 	resp, err := hc.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if err := CheckError(resp); err != nil {
-		return err
+		return nil, err
 	}
 
-	if _, err := io.Copy(w, resp.Body); err != nil {
-		return err
+	var sizes []influxdb.ShardSize
+	if err := json.NewDecoder(resp.Body).Decode(&sizes); err != nil {
+		return nil, err
 	}
-	return resp.Body.Close()
+	return sizes, nil
 }
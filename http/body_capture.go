@@ -0,0 +1,179 @@
+package http
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/middleware"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// DefaultBodyCaptureSize is how much of a request body BodyCapture keeps by
+// default.
+const DefaultBodyCaptureSize = 4 * 1024
+
+// requestIDs generates the IDs of captured requests sent without one.
+var requestIDs = snowflake.NewDefaultIDGenerator()
+
+// authEndpoints are the endpoints whose request bodies hold credentials or
+// secrets. Their bodies are never captured.
+var authEndpoints = map[string]isValidMethodFn{
+	prefixSignIn:                         ignoreMethod(),
+	prefixSignOut:                        ignoreMethod(),
+	prefixMe:                             ignoreMethod(),
+	mePasswordPath:                       ignoreMethod(),
+	usersPasswordPath:                    ignoreMethod(),
+	prefixSetup:                          ignoreMethod(),
+	prefixSetup + "/user":                ignoreMethod(),
+	prefixAuthorization:                  ignoreMethod(),
+	prefixAuthorization + "/:id":         ignoreMethod(),
+	"/private/legacy/authorizations":     ignoreMethod(),
+	"/private/legacy/authorizations/:id": ignoreMethod(),
+	"/private/legacy/authorizations/:id/password": ignoreMethod(),
+	"/api/v2/invites/accept":                      ignoreMethod(),
+	organizationsIDSecretsPath:                    ignoreMethod(),
+	organizationsIDSecretsDeletePath:              ignoreMethod(),
+}
+
+// BodyCapture is a debugging middleware logging the start of the body of
+// requests that fail with a client error, so that malformed payloads can be
+// reproduced. Only requests to an allow-list of routes are captured, and
+// never those to endpoints taking credentials.
+type BodyCapture struct {
+	log    *zap.Logger
+	routes []string
+	size   int
+
+	truncated prometheus.Counter
+}
+
+// NewBodyCapture returns a BodyCapture keeping up to size bytes of the body
+// of requests to paths starting with one of routes.
+func NewBodyCapture(log *zap.Logger, routes []string, size int) *BodyCapture {
+	if size <= 0 {
+		size = DefaultBodyCaptureSize
+	}
+	return &BodyCapture{
+		log:    log,
+		routes: routes,
+		size:   size,
+		truncated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "http",
+			Subsystem: "api",
+			Name:      "request_body_capture_truncated_total",
+			Help:      "Number of captured request bodies logged truncated to the capture size",
+		}),
+	}
+}
+
+// PrometheusCollectors satisfies prom.PrometheusCollector.
+func (c *BodyCapture) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{c.truncated}
+}
+
+// captures reports whether the body of a request to path is captured.
+func (c *BodyCapture) captures(path string) bool {
+	if _, ok := matchURLPath(authEndpoints, path); ok {
+		return false
+	}
+	for _, route := range c.routes {
+		if strings.HasPrefix(path, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware captures the bodies of requests handled by next.
+func (c *BodyCapture) Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !c.captures(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Routers further down reuse the request ID of the header, so that
+		// it identifies the request in their logs too.
+		requestID := r.Header.Get(middleware.RequestIDHeader)
+		if requestID == "" {
+			requestID = requestIDs.ID().String()
+			r.Header.Set(middleware.RequestIDHeader, requestID)
+		}
+		w.Header().Set(middleware.RequestIDHeader, requestID)
+
+		buf := &boundedBuffer{size: c.size}
+		if r.Body != nil {
+			r.Body = &bodyEchoer{
+				rc:    r.Body,
+				teedR: io.TeeReader(r.Body, buf),
+			}
+		}
+		srw := &flushingStatusResponseWriter{StatusResponseWriter: kithttp.NewStatusResponseWriter(w)}
+
+		next.ServeHTTP(srw, r)
+
+		if code := srw.Code(); code >= 400 && code < 500 {
+			c.logCapture(r, requestID, code, w.Header().Get(kithttp.PlatformErrorCodeHeader), buf)
+		}
+	}
+	return http.HandlerFunc(fn)
+}
+
+func (c *BodyCapture) logCapture(r *http.Request, requestID string, code int, errCode string, buf *boundedBuffer) {
+	fields := []zap.Field{
+		zap.String("request_id", requestID),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.Int("status_code", code),
+		zap.String("error_code", errCode),
+		zap.String("content_type", r.Header.Get("Content-Type")),
+		zap.String("content_encoding", r.Header.Get("Content-Encoding")),
+		zap.Int64("content_length", r.ContentLength),
+		zap.Bool("truncated", buf.truncated),
+	}
+	if utf8.Valid(buf.buf) {
+		fields = append(fields, zap.ByteString("body", buf.buf))
+	} else {
+		fields = append(fields, zap.String("body_base64", base64.StdEncoding.EncodeToString(buf.buf)))
+	}
+	if buf.truncated {
+		c.truncated.Inc()
+	}
+	c.log.Info("Captured body of failed request", fields...)
+}
+
+// boundedBuffer keeps the first size bytes written to it and discards the
+// rest.
+type boundedBuffer struct {
+	size      int
+	buf       []byte
+	truncated bool
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	if n := b.size - len(b.buf); n < len(p) {
+		b.truncated = true
+		b.buf = append(b.buf, p[:n]...)
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+// flushingStatusResponseWriter keeps streamed responses flushing through a
+// StatusResponseWriter.
+type flushingStatusResponseWriter struct {
+	*kithttp.StatusResponseWriter
+}
+
+func (w *flushingStatusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestBodyCapture(t *testing.T) {
+	// handler reads the whole body and fails with code, or responds with
+	// what it read.
+	handler := func(code int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			if code >= 400 {
+				w.Header().Set(kithttp.PlatformErrorCodeHeader, "invalid")
+				w.WriteHeader(code)
+				return
+			}
+			w.Write(body)
+		})
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		code          int
+		wantBody      string
+		wantBase64    bool
+		wantTruncated bool
+		noCapture     bool
+	}{
+		{
+			name:     "client error",
+			path:     "/api/v2/write",
+			body:     "cpu value=",
+			code:     http.StatusBadRequest,
+			wantBody: "cpu value=",
+		},
+		{
+			name:          "truncated",
+			path:          "/api/v2/write",
+			body:          strings.Repeat("x", 20),
+			code:          http.StatusBadRequest,
+			wantBody:      strings.Repeat("x", 16),
+			wantTruncated: true,
+		},
+		{
+			name:       "binary body",
+			path:       "/api/v2/write",
+			body:       "\x1f\x8b\x08\x00\xff",
+			code:       http.StatusUnprocessableEntity,
+			wantBody:   base64.StdEncoding.EncodeToString([]byte("\x1f\x8b\x08\x00\xff")),
+			wantBase64: true,
+		},
+		{
+			name:      "success",
+			path:      "/api/v2/write",
+			body:      "cpu value=1",
+			code:      http.StatusOK,
+			noCapture: true,
+		},
+		{
+			name:      "server error",
+			path:      "/api/v2/write",
+			body:      "cpu value=1",
+			code:      http.StatusInternalServerError,
+			noCapture: true,
+		},
+		{
+			name:      "route not allowed",
+			path:      "/api/v2/query",
+			body:      "from(",
+			code:      http.StatusBadRequest,
+			noCapture: true,
+		},
+		{
+			name:      "sign in",
+			path:      "/api/v2/signin",
+			body:      "password",
+			code:      http.StatusUnauthorized,
+			noCapture: true,
+		},
+		{
+			name:      "user password",
+			path:      "/api/v2/users/0000000000000001/password",
+			body:      `{"password":"secret"}`,
+			code:      http.StatusBadRequest,
+			noCapture: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			// The users route is allowed to check that credential endpoints
+			// below it are still excluded.
+			capture := NewBodyCapture(zap.New(core), []string{"/api/v2/write", "/api/v2/signin", "/api/v2/users"}, 16)
+
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			capture.Middleware(handler(tt.code)).ServeHTTP(w, r)
+			assert.Equal(t, tt.code, w.Code)
+
+			if tt.noCapture {
+				assert.Equal(t, 0, logs.Len())
+				return
+			}
+			entries := logs.All()
+			require.Len(t, entries, 1)
+			fields := entries[0].ContextMap()
+			assert.Equal(t, w.Header().Get(middleware.RequestIDHeader), fields["request_id"])
+			assert.NotEmpty(t, fields["request_id"])
+			assert.Equal(t, "invalid", fields["error_code"])
+			assert.Equal(t, tt.wantTruncated, fields["truncated"])
+			if tt.wantBase64 {
+				assert.Equal(t, tt.wantBody, fields["body_base64"])
+			} else {
+				assert.Equal(t, tt.wantBody, fields["body"])
+			}
+
+			truncated := 0.0
+			if tt.wantTruncated {
+				truncated = 1
+			}
+			assert.Equal(t, truncated, testutil.ToFloat64(capture.truncated))
+		})
+	}
+
+	t.Run("keeps request ID and streamed body", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		capture := NewBodyCapture(zap.New(core), []string{"/api/v2/write"}, 4)
+
+		body := strings.Repeat("cpu value=1\n", 100)
+		r := httptest.NewRequest(http.MethodPost, "/api/v2/write", strings.NewReader(body))
+		r.Header.Set(middleware.RequestIDHeader, "client-id")
+		w := httptest.NewRecorder()
+		capture.Middleware(handler(http.StatusOK)).ServeHTTP(w, r)
+
+		assert.Equal(t, body, w.Body.String())
+		assert.Equal(t, "client-id", w.Header().Get(middleware.RequestIDHeader))
+		assert.Equal(t, 0, logs.Len())
+	})
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"go.uber.org/zap"
+)
+
+const prefixDebugCache = "/api/v2/debug/cache"
+
+// CacheBackend is all services and associated parameters required to construct the CacheHandler.
+type CacheBackend struct {
+	Logger *zap.Logger
+	influxdb.HTTPErrorHandler
+
+	CacheService influxdb.CacheService
+}
+
+// NewCacheBackend returns a new instance of CacheBackend.
+func NewCacheBackend(b *APIBackend) *CacheBackend {
+	return &CacheBackend{
+		Logger: b.Logger.With(zap.String("handler", "cache")),
+
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		CacheService:     b.CacheService,
+	}
+}
+
+// CacheHandler is http handler for the in-memory write caches of the storage engine.
+type CacheHandler struct {
+	chi.Router
+	influxdb.HTTPErrorHandler
+	Logger *zap.Logger
+
+	CacheService influxdb.CacheService
+}
+
+// NewCacheHandler creates a new handler at /api/v2/debug/cache to report the
+// state of the shard caches and to snapshot the caches of a bucket.
+func NewCacheHandler(b *CacheBackend) *CacheHandler {
+	h := &CacheHandler{
+		Router:           NewBaseChiRouter(kithttp.NewAPI(kithttp.WithLog(b.Logger))),
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		Logger:           b.Logger,
+		CacheService:     b.CacheService,
+	}
+
+	h.Get("/", h.handleGetCaches)
+	h.Post("/snapshot", h.handlePostSnapshot)
+
+	return h
+}
+
+type shardCachesResponse struct {
+	Shards []influxdb.ShardCache `json:"shards"`
+}
+
+func (h *CacheHandler) handleGetCaches(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "CacheHandler.handleGetCaches")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	caches, err := h.CacheService.ShardCaches(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, shardCachesResponse{Shards: caches}); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *CacheHandler) handlePostSnapshot(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "CacheHandler.handlePostSnapshot")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := influxdb.IDFromString(r.URL.Query().Get("bucketID"))
+	if err != nil {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "bucketID is required and must be a valid ID",
+			Err:  err,
+		}, w)
+		return
+	}
+
+	caches, err := h.CacheService.SnapshotBucketCaches(ctx, *bucketID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	h.Logger.Info("Bucket caches snapshotted on request", zap.String("bucket_id", bucketID.String()))
+
+	if err := encodeResponse(ctx, w, http.StatusOK, shardCachesResponse{Shards: caches}); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// CacheService connects to Influx via HTTP using tokens to report on and
+// snapshot the write caches of the storage engine.
+type CacheService struct {
+	Client *httpc.Client
+}
+
+var _ influxdb.CacheService = (*CacheService)(nil)
+
+// ShardCaches returns the state of the cache of every shard.
+func (s *CacheService) ShardCaches(ctx context.Context) ([]influxdb.ShardCache, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp shardCachesResponse
+	err := s.Client.
+		Get(prefixDebugCache).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Shards, nil
+}
+
+// SnapshotBucketCaches writes the caches of the shards of a bucket to disk.
+func (s *CacheService) SnapshotBucketCaches(ctx context.Context, bucketID influxdb.ID) ([]influxdb.ShardCache, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp shardCachesResponse
+	err := s.Client.
+		Post(httpc.BodyEmpty, prefixDebugCache, "snapshot").
+		QueryParams([2]string{"bucketID", bucketID.String()}).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Shards, nil
+}
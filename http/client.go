@@ -145,6 +145,14 @@ var DefaultTransportInsecure http.RoundTripper = &SpanTransport{
 	},
 }
 
+// NewTLSTransport returns a transport that pools connections, injects a span
+// and secures connections with tlsConfig.
+func NewTLSTransport(tlsConfig *tls.Config) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	return &SpanTransport{base: t}
+}
+
 func httpClient(scheme string, insecure bool) *http.Client {
 	if scheme == "https" && insecure {
 		return &http.Client{Transport: DefaultTransportInsecure}
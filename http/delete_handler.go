@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	http "net/http"
+	"strconv"
 	"time"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/job"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/predicate"
 	"go.uber.org/zap"
 )
@@ -25,6 +28,7 @@ type DeleteBackend struct {
 	DeleteService       influxdb.DeleteService
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
+	JobSubmitter        influxdb.JobSubmitter
 }
 
 // NewDeleteBackend returns a new instance of DeleteBackend
@@ -36,6 +40,7 @@ func NewDeleteBackend(log *zap.Logger, b *APIBackend) *DeleteBackend {
 		DeleteService:       b.DeleteService,
 		BucketService:       b.BucketService,
 		OrganizationService: b.OrganizationService,
+		JobSubmitter:        b.JobSubmitter,
 	}
 }
 
@@ -45,10 +50,12 @@ type DeleteHandler struct {
 	*httprouter.Router
 
 	log *zap.Logger
+	api *kithttp.API
 
 	DeleteService       influxdb.DeleteService
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
+	JobSubmitter        influxdb.JobSubmitter
 }
 
 const (
@@ -61,10 +68,12 @@ func NewDeleteHandler(log *zap.Logger, b *DeleteBackend) *DeleteHandler {
 		HTTPErrorHandler: b.HTTPErrorHandler,
 		Router:           NewRouter(b.HTTPErrorHandler),
 		log:              log,
+		api:              kithttp.NewAPI(kithttp.WithLog(log)),
 
 		BucketService:       b.BucketService,
 		DeleteService:       b.DeleteService,
 		OrganizationService: b.OrganizationService,
+		JobSubmitter:        b.JobSubmitter,
 	}
 
 	h.HandlerFunc("POST", prefixDelete, h.handleDelete)
@@ -75,9 +84,21 @@ func (h *DeleteHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	span, r := tracing.ExtractFromHTTPRequest(r, "DeleteHandler")
 	defer span.Finish()
 
-	ctx := r.Context()
 	defer r.Body.Close()
 
+	r, dryRun, err := h.api.DecodeDryRun(r)
+	if err != nil {
+		h.HandleHTTPError(r.Context(), err, w)
+		return
+	}
+	ctx := r.Context()
+
+	async, err := decodeAsync(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
 	a, err := pcontext.GetAuthorizer(ctx)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
@@ -114,6 +135,25 @@ func (h *DeleteHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if dr.Bucket.DeleteProtected {
+		h.HandleHTTPError(ctx, influxdb.ErrBucketDeleteProtected(dr.Bucket), w)
+		return
+	}
+	if dr.Bucket.Archived {
+		h.HandleHTTPError(ctx, influxdb.ErrBucketArchived(dr.Bucket), w)
+		return
+	}
+
+	if dryRun {
+		h.api.RespondDryRun(w, r, newDeletePlan(dr))
+		return
+	}
+
+	if async {
+		h.handleAsyncDelete(w, r, dr)
+		return
+	}
+
 	h.HandleHTTPError(r.Context(), &influxdb.Error{
 		Code: influxdb.ENotImplemented,
 		Op:   "http/handleDelete",
@@ -126,6 +166,59 @@ func (h *DeleteHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// handleAsyncDelete queues a job deleting the points of dr, and responds
+// with the job, whose status is then read from /api/v2/jobs.
+func (h *DeleteHandler) handleAsyncDelete(w http.ResponseWriter, r *http.Request, dr *deleteRequest) {
+	ctx := r.Context()
+	if h.JobSubmitter == nil {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.ENotImplemented,
+			Op:   "http/handleDelete",
+			Msg:  "asynchronous deletes are not supported",
+		}, w)
+		return
+	}
+
+	j, err := h.JobSubmitter.SubmitJob(ctx, influxdb.JobCreate{
+		Kind:  influxdb.JobKindDelete,
+		OrgID: dr.Org.ID,
+		Params: job.DeleteParams{
+			BucketID:  dr.Bucket.ID,
+			Start:     time.Unix(0, dr.Start).UTC(),
+			Stop:      time.Unix(0, dr.Stop).UTC(),
+			Predicate: dr.PredicateExpr,
+		},
+	})
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	h.log.Debug("Queued delete",
+		zap.String("orgID", dr.Org.ID.String()),
+		zap.String("bucketID", dr.Bucket.ID.String()),
+		zap.String("jobID", j.ID.String()),
+	)
+	h.api.Respond(w, r, http.StatusAccepted, j)
+}
+
+// decodeAsync returns whether the async query parameter asks for the delete
+// to run in the background.
+func decodeAsync(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("async")
+	if v == "" {
+		return false, nil
+	}
+	async, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("invalid async value %q; expected true or false", v),
+		}
+	}
+	return async, nil
+}
+
 func decodeDeleteRequest(ctx context.Context, r *http.Request, orgSvc influxdb.OrganizationService, bucketSvc influxdb.BucketService) (*deleteRequest, error) {
 	dr := new(deleteRequest)
 	err := json.NewDecoder(r.Body).Decode(dr)
@@ -147,11 +240,35 @@ func decodeDeleteRequest(ctx context.Context, r *http.Request, orgSvc influxdb.O
 }
 
 type deleteRequest struct {
-	Org       *influxdb.Organization
-	Bucket    *influxdb.Bucket
-	Start     int64
-	Stop      int64
-	Predicate influxdb.Predicate
+	Org           *influxdb.Organization
+	Bucket        *influxdb.Bucket
+	Start         int64
+	Stop          int64
+	Predicate     influxdb.Predicate
+	PredicateExpr string
+}
+
+// deletePlan is the plan of a delete dry run.
+type deletePlan struct {
+	OrgID     influxdb.ID `json:"orgID"`
+	Org       string      `json:"org"`
+	BucketID  influxdb.ID `json:"bucketID"`
+	Bucket    string      `json:"bucket"`
+	Start     time.Time   `json:"start"`
+	Stop      time.Time   `json:"stop"`
+	Predicate string      `json:"predicate,omitempty"`
+}
+
+func newDeletePlan(dr *deleteRequest) deletePlan {
+	return deletePlan{
+		OrgID:     dr.Org.ID,
+		Org:       dr.Org.Name,
+		BucketID:  dr.Bucket.ID,
+		Bucket:    dr.Bucket.Name,
+		Start:     time.Unix(0, dr.Start).UTC(),
+		Stop:      time.Unix(0, dr.Stop).UTC(),
+		Predicate: dr.PredicateExpr,
+	}
 }
 
 type deleteRequestDecode struct {
@@ -200,6 +317,7 @@ func (dr *deleteRequest) UnmarshalJSON(b []byte) error {
 		}
 	}
 	dr.Stop = stop.UnixNano()
+	dr.PredicateExpr = drd.Predicate
 	node, err := predicate.Parse(drd.Predicate)
 	if err != nil {
 		return err
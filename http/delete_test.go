@@ -6,10 +6,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/job"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
@@ -337,6 +340,121 @@ func TestDelete(t *testing.T) {
 				body:       ``,
 			},
 		},
+		{
+			name: "dry run delete",
+			args: args{
+				queryParams: map[string][]string{
+					"org":    []string{"org1"},
+					"bucket": []string{"buck1"},
+					"dryRun": []string{"true"},
+				},
+				body: []byte(`{
+					"start":"2009-01-01T23:00:00Z",
+					"stop":"2019-11-10T01:00:00Z",
+					"predicate": "tag1=\"v1\""
+				}`),
+				authorizer: &influxdb.Authorization{
+					UserID: user1ID,
+					Status: influxdb.Active,
+					Permissions: []influxdb.Permission{
+						{
+							Action: influxdb.WriteAction,
+							Resource: influxdb.Resource{
+								Type:  influxdb.BucketsResourceType,
+								ID:    influxtesting.IDPtr(influxdb.ID(2)),
+								OrgID: influxtesting.IDPtr(influxdb.ID(1)),
+							},
+						},
+					},
+				},
+			},
+			fields: fields{
+				DeleteService: mock.NewDeleteService(),
+				BucketService: &mock.BucketService{
+					FindBucketFn: func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+						return &influxdb.Bucket{
+							ID:   influxdb.ID(2),
+							Name: "bucket1",
+						}, nil
+					},
+				},
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+						return &influxdb.Organization{
+							ID:   influxdb.ID(1),
+							Name: "org1",
+						}, nil
+					},
+				},
+			},
+			wants: wants{
+				statusCode:  http.StatusOK,
+				contentType: "application/json; charset=utf-8",
+				body: `{
+					"dryRun": true,
+					"plan": {
+						"orgID": "0000000000000001",
+						"org": "org1",
+						"bucketID": "0000000000000002",
+						"bucket": "bucket1",
+						"start": "2009-01-01T23:00:00Z",
+						"stop": "2019-11-10T01:00:00Z",
+						"predicate": "tag1=\"v1\""
+					}
+				}`,
+			},
+		},
+		{
+			name: "invalid dry run",
+			args: args{
+				queryParams: map[string][]string{
+					"org":    []string{"org1"},
+					"bucket": []string{"buck1"},
+					"dryRun": []string{"maybe"},
+				},
+				body: []byte(`{"start":"2009-01-01T23:00:00Z","stop":"2019-11-10T01:00:00Z"}`),
+				authorizer: &influxdb.Authorization{
+					UserID: user1ID,
+					Status: influxdb.Active,
+					Permissions: []influxdb.Permission{
+						{
+							Action: influxdb.WriteAction,
+							Resource: influxdb.Resource{
+								Type:  influxdb.BucketsResourceType,
+								ID:    influxtesting.IDPtr(influxdb.ID(2)),
+								OrgID: influxtesting.IDPtr(influxdb.ID(1)),
+							},
+						},
+					},
+				},
+			},
+			fields: fields{
+				DeleteService: mock.NewDeleteService(),
+				BucketService: &mock.BucketService{
+					FindBucketFn: func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+						return &influxdb.Bucket{
+							ID:   influxdb.ID(2),
+							Name: "bucket1",
+						}, nil
+					},
+				},
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+						return &influxdb.Organization{
+							ID:   influxdb.ID(1),
+							Name: "org1",
+						}, nil
+					},
+				},
+			},
+			wants: wants{
+				statusCode: http.StatusBadRequest,
+				body: `{
+					"code": "invalid",
+					"message": "invalid dryRun value \"maybe\"; expected true or false"
+				}`,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -382,3 +500,105 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+type fakeJobSubmitter struct {
+	created []influxdb.JobCreate
+}
+
+func (s *fakeJobSubmitter) SubmitJob(ctx context.Context, c influxdb.JobCreate) (*influxdb.Job, error) {
+	s.created = append(s.created, c)
+	return &influxdb.Job{
+		ID:        influxdb.ID(3),
+		Kind:      c.Kind,
+		OrgID:     c.OrgID,
+		State:     influxdb.JobQueued,
+		CreatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func TestDelete_Async(t *testing.T) {
+	newRequest := func(async string) *http.Request {
+		r := httptest.NewRequest("POST", "http://any.tld/api/v2/delete?org=org1&bucket=bucket1&async="+async,
+			bytes.NewReader([]byte(`{"start":"2009-01-01T23:00:00Z","stop":"2019-11-10T01:00:00Z","predicate":"tag1=\"v1\""}`)))
+		return r.WithContext(pcontext.SetAuthorizer(r.Context(), &influxdb.Authorization{
+			UserID: user1ID,
+			Status: influxdb.Active,
+			Permissions: []influxdb.Permission{
+				{
+					Action: influxdb.WriteAction,
+					Resource: influxdb.Resource{
+						Type:  influxdb.BucketsResourceType,
+						ID:    influxtesting.IDPtr(influxdb.ID(2)),
+						OrgID: influxtesting.IDPtr(influxdb.ID(1)),
+					},
+				},
+			},
+		}))
+	}
+	newHandler := func(t *testing.T, jobs influxdb.JobSubmitter) *DeleteHandler {
+		deleteBackend := NewMockDeleteBackend(t)
+		deleteBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+		deleteBackend.BucketService = &mock.BucketService{
+			FindBucketFn: func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return &influxdb.Bucket{ID: influxdb.ID(2), OrgID: influxdb.ID(1), Name: "bucket1"}, nil
+			},
+		}
+		deleteBackend.OrganizationService = &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: influxdb.ID(1), Name: "org1"}, nil
+			},
+		}
+		deleteBackend.JobSubmitter = jobs
+		return NewDeleteHandler(zaptest.NewLogger(t), deleteBackend)
+	}
+
+	t.Run("queues a delete job", func(t *testing.T) {
+		jobs := &fakeJobSubmitter{}
+		w := httptest.NewRecorder()
+		newHandler(t, jobs).ServeHTTP(w, newRequest("true"))
+
+		if got, want := w.Code, http.StatusAccepted; got != want {
+			t.Fatalf("unexpected status code: got %d want %d: %s", got, want, w.Body.String())
+		}
+		want := `{"id":"0000000000000003","kind":"delete","orgID":"0000000000000001","state":"queued","progress":0,"attempts":0,"createdAt":"2020-01-01T00:00:00Z"}`
+		if eq, diff, _ := jsonEqual(w.Body.String(), want); !eq {
+			t.Errorf("unexpected body: %s", diff)
+		}
+		if len(jobs.created) != 1 {
+			t.Fatalf("expected one job, got %d", len(jobs.created))
+		}
+		wantCreate := influxdb.JobCreate{
+			Kind:  influxdb.JobKindDelete,
+			OrgID: influxdb.ID(1),
+			Params: job.DeleteParams{
+				BucketID:  influxdb.ID(2),
+				Start:     time.Date(2009, 1, 1, 23, 0, 0, 0, time.UTC),
+				Stop:      time.Date(2019, 11, 10, 1, 0, 0, 0, time.UTC),
+				Predicate: `tag1="v1"`,
+			},
+		}
+		if !reflect.DeepEqual(jobs.created[0], wantCreate) {
+			t.Errorf("unexpected job: got %+v want %+v", jobs.created[0], wantCreate)
+		}
+	})
+
+	t.Run("not supported without jobs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newHandler(t, nil).ServeHTTP(w, newRequest("true"))
+		if got, want := w.Code, http.StatusNotImplemented; got != want {
+			t.Errorf("unexpected status code: got %d want %d", got, want)
+		}
+	})
+
+	t.Run("invalid async", func(t *testing.T) {
+		jobs := &fakeJobSubmitter{}
+		w := httptest.NewRecorder()
+		newHandler(t, jobs).ServeHTTP(w, newRequest("maybe"))
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected status code: got %d want %d", got, want)
+		}
+		if len(jobs.created) != 0 {
+			t.Errorf("unexpected jobs: %v", jobs.created)
+		}
+	})
+}
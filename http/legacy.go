@@ -19,6 +19,8 @@ func newLegacyBackend(b *APIBackend) *legacy.Backend {
 		ProxyQueryService:     b.InfluxQLService,
 		InfluxqldQueryService: b.InfluxqldService,
 		WriteEventRecorder:    b.WriteEventRecorder,
+		ResponseStats:         b.ResponseStats,
+		NonFiniteFields:       b.NonFiniteFields,
 	}
 }
 
@@ -29,7 +31,11 @@ func newLegacyHandler(b *legacy.Backend, config legacy.HandlerConfig) *legacy.Ha
 	}
 
 	pointsWriterBackend := legacy.NewPointsWriterBackend(b)
-	h.PointsWriterHandler = legacy.NewWriterHandler(pointsWriterBackend, legacy.WithMaxBatchSizeBytes(b.MaxBatchSizeBytes))
+	h.PointsWriterHandler = legacy.NewWriterHandler(pointsWriterBackend,
+		legacy.WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
+		legacy.WithResponseStats(b.ResponseStats),
+		legacy.WithNonFiniteFieldsCounter(b.NonFiniteFields),
+	)
 
 	influxqlBackend := legacy.NewInfluxQLBackend(b)
 	h.InfluxQLHandler = legacy.NewInfluxQLHandler(influxqlBackend, config)
@@ -25,6 +25,12 @@ type Backend struct {
 	influxdb.HTTPErrorHandler
 	Logger            *zap.Logger
 	MaxBatchSizeBytes int64
+	// ResponseStats reports the points written by writes and the bytes
+	// read, rows returned and duration of queries in response headers.
+	ResponseStats bool
+	// NonFiniteFields counts the NaN and infinite float field values skipped
+	// by writes. When nil, they are not counted.
+	NonFiniteFields *prometheus.CounterVec
 
 	WriteEventRecorder    metric.EventRecorder
 	AuthorizationService  influxdb.AuthorizationService
@@ -25,6 +25,9 @@ type InfluxQLBackend struct {
 	OrganizationService   platform.OrganizationService
 	ProxyQueryService     query.ProxyQueryService
 	InfluxqldQueryService influxqld.ProxyQueryService
+
+	// ResponseStats reports the statistics of queries in response headers.
+	ResponseStats bool
 }
 
 // NewInfluxQLBackend constructs an InfluxQLBackend from a LegacyBackend.
@@ -35,6 +38,7 @@ func NewInfluxQLBackend(b *Backend) *InfluxQLBackend {
 		AuthorizationService:  b.AuthorizationService,
 		OrganizationService:   b.OrganizationService,
 		InfluxqldQueryService: b.InfluxqldQueryService,
+		ResponseStats:         b.ResponseStats,
 	}
 }
 
@@ -12,6 +12,7 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
@@ -156,10 +157,36 @@ func (h *InfluxqlHandler) handleInfluxqldQuery(w http.ResponseWriter, r *http.Re
 		ChunkSize:      chunkSize,
 	}
 
+	// Chunked responses are streamed right away, with the statistics sent
+	// as trailers.
+	out := w
+	var statsW *kithttp.StatsResponseWriter
+	if h.ResponseStats {
+		size := kithttp.DefaultStatsBufferSize
+		if chunked {
+			size = 0
+		}
+		statsW = kithttp.NewStatsResponseWriter(w, size)
+		out = statsW
+	}
+
 	var respSize int64
-	cw := iocounter.Writer{Writer: w}
-	_, err = h.InfluxqldQueryService.Query(ctx, &cw, req)
+	cw := iocounter.Writer{Writer: out}
+	stats, err := h.InfluxqldQueryService.Query(ctx, &cw, req)
 	respSize = cw.Count()
+	if statsW != nil {
+		if err := statsW.Finish(kithttp.QueryStats{
+			BytesRead:    int64(stats.ScannedBytes),
+			RowsReturned: int64(stats.RowsReturned),
+			Duration:     stats.TotalDuration(),
+		}); err != nil {
+			h.Logger.Info("error writing response to client",
+				zap.String("org", o.Name),
+				zap.String("handler", "influxql"),
+				zap.Error(err),
+			)
+		}
+	}
 
 	if err != nil {
 		if respSize == 0 {
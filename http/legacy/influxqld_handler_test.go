@@ -3,10 +3,14 @@ package legacy
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	platform "github.com/influxdata/influxdb/v2"
@@ -15,6 +19,9 @@ import (
 	imock "github.com/influxdata/influxdb/v2/influxql/mock"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
 )
 
 var cmpOpts = []cmp.Option{
@@ -260,6 +267,58 @@ func TestInfluxQLdHandler_HandleQuery(t *testing.T) {
 	}
 }
 
+func TestInfluxQLdHandler_ResponseStats(t *testing.T) {
+	b := &InfluxQLBackend{
+		HTTPErrorHandler: kithttp.ErrorHandler(0),
+		Logger:           zaptest.NewLogger(t),
+		OrganizationService: &mock.OrganizationService{
+			FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+				return &platform.Organization{}, nil
+			},
+		},
+		InfluxqldQueryService: &imock.ProxyQueryService{
+			QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+				_, err := io.WriteString(w, `{"results":[]}`)
+				return influxql.Statistics{
+					PlanDuration:    time.Millisecond,
+					ExecuteDuration: 41 * time.Millisecond,
+					ScannedBytes:    2048,
+					RowsReturned:    7,
+				}, err
+			},
+		},
+		ResponseStats: true,
+	}
+	h := NewInfluxQLHandler(b, HandlerConfig{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := pcontext.SetAuthorizer(r.Context(), &platform.Authorization{Status: platform.Active})
+		h.handleInfluxqldQuery(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	for _, chunked := range []bool{false, true} {
+		t.Run(fmt.Sprintf("chunked=%t", chunked), func(t *testing.T) {
+			req, err := http.NewRequest("POST", srv.URL+"/query?q=SELECT+*+FROM+m&chunked="+strconv.FormatBool(chunked), nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, `{"results":[]}`, string(body))
+
+			// Chunked responses are streamed, so their statistics come last.
+			got := resp.Header
+			if chunked {
+				got = resp.Trailer
+			}
+			assert.Equal(t, "2048", got.Get(kithttp.BytesReadHeader))
+			assert.Equal(t, "7", got.Get(kithttp.RowsReturnedHeader))
+			assert.Equal(t, "42", got.Get(kithttp.QueryDurationHeader))
+		})
+	}
+}
+
 func WithHeader(r *http.Request, key, value string) *http.Request {
 	r.Header.Set(key, value)
 	return r
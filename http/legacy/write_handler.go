@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
@@ -13,6 +14,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
@@ -56,6 +58,8 @@ type WriteHandler struct {
 	router            *httprouter.Router
 	logger            *zap.Logger
 	maxBatchSizeBytes int64
+	responseStats     bool
+	nonFiniteFields   *prometheus.CounterVec
 }
 
 // NewWriterHandler returns a new instance of PointsWriterHandler.
@@ -91,6 +95,22 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithResponseStats reports the number of points written in a response
+// header.
+func WithResponseStats(enabled bool) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.responseStats = enabled
+	}
+}
+
+// WithNonFiniteFieldsCounter counts the NaN and infinite float field values
+// skipped by writes with c, if not nil.
+func WithNonFiniteFieldsCounter(c *prometheus.CounterVec) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.nonFiniteFields = c
+	}
+}
+
 // ServeHTTP implements http.Handler
 func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.router.ServeHTTP(w, r)
@@ -133,8 +153,13 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
+	if bucket.Archived {
+		h.HandleHTTPError(ctx, influxdb.ErrBucketArchived(bucket), sw)
+		return
+	}
 
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, auth.OrgID, bucket.ID, req.Body)
+	parser := &points.Parser{Precision: req.Precision, NaNHandling: bucket.NaNHandling}
+	parsed, err := parser.Parse(ctx, auth.OrgID, bucket.ID, req.Body)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, sw)
 		return
@@ -150,6 +175,19 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parsed.NonFinite) > 0 {
+		if bucket.NaNHandling == influxdb.NaNHandlingReject {
+			h.HandleHTTPError(ctx, points.ErrNonFiniteRejected(parsed.NonFinite), sw)
+			return
+		}
+		if h.nonFiniteFields != nil {
+			h.nonFiniteFields.WithLabelValues(bucket.ID.String(), string(bucket.NaNHandling)).Add(float64(len(parsed.NonFinite)))
+		}
+	}
+
+	if h.responseStats {
+		w.Header().Set(kithttp.PointsWrittenHeader, strconv.Itoa(len(parsed.Points)))
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"go.uber.org/zap"
+)
+
+const prefixMaintenance = "/api/v2/maintenance/services"
+
+// MaintenanceBackend is all services and associated parameters required to construct the MaintenanceHandler.
+type MaintenanceBackend struct {
+	Logger *zap.Logger
+	influxdb.HTTPErrorHandler
+
+	MaintenanceService influxdb.MaintenanceService
+}
+
+// NewMaintenanceBackend returns a new instance of MaintenanceBackend.
+func NewMaintenanceBackend(b *APIBackend) *MaintenanceBackend {
+	return &MaintenanceBackend{
+		Logger: b.Logger.With(zap.String("handler", "maintenance")),
+
+		HTTPErrorHandler:   b.HTTPErrorHandler,
+		MaintenanceService: b.MaintenanceService,
+	}
+}
+
+// MaintenanceHandler is http handler for the status of background maintenance services.
+type MaintenanceHandler struct {
+	chi.Router
+	influxdb.HTTPErrorHandler
+	Logger *zap.Logger
+
+	MaintenanceService influxdb.MaintenanceService
+}
+
+// NewMaintenanceHandler creates a new handler at /api/v2/maintenance/services
+// to report when the background maintenance services last ran and run next.
+func NewMaintenanceHandler(b *MaintenanceBackend) *MaintenanceHandler {
+	h := &MaintenanceHandler{
+		Router:             NewBaseChiRouter(kithttp.NewAPI(kithttp.WithLog(b.Logger))),
+		HTTPErrorHandler:   b.HTTPErrorHandler,
+		Logger:             b.Logger,
+		MaintenanceService: b.MaintenanceService,
+	}
+
+	h.Get("/", h.handleGetServices)
+
+	return h
+}
+
+type maintenanceServicesResponse struct {
+	Services []influxdb.MaintenanceServiceStatus `json:"services"`
+}
+
+func (h *MaintenanceHandler) handleGetServices(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "MaintenanceHandler.handleGetServices")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	services, err := h.MaintenanceService.MaintenanceServices(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, maintenanceServicesResponse{Services: services}); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// MaintenanceService connects to Influx via HTTP using tokens to report the
+// status of background maintenance services.
+type MaintenanceService struct {
+	Client *httpc.Client
+}
+
+var _ influxdb.MaintenanceService = (*MaintenanceService)(nil)
+
+// MaintenanceServices returns the status of the background maintenance services.
+func (s *MaintenanceService) MaintenanceServices(ctx context.Context) ([]influxdb.MaintenanceServiceStatus, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp maintenanceServicesResponse
+	err := s.Client.
+		Get(prefixMaintenance).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Services, nil
+}
@@ -70,7 +70,13 @@ func LoggingMW(log *zap.Logger) kithttp.Middleware {
 type isValidMethodFn func(method string) bool
 
 func mapURLPath(rawPath string) (isValidMethodFn, bool) {
-	if fn, ok := blacklistEndpoints[rawPath]; ok {
+	return matchURLPath(blacklistEndpoints, rawPath)
+}
+
+// matchURLPath returns the method filter of the endpoint matching rawPath.
+// Endpoint path segments starting with ":" match any segment.
+func matchURLPath(endpoints map[string]isValidMethodFn, rawPath string) (isValidMethodFn, bool) {
+	if fn, ok := endpoints[rawPath]; ok {
 		return fn, true
 	}
 
@@ -100,7 +106,7 @@ func mapURLPath(rawPath string) (isValidMethodFn, bool) {
 		return sourceHead == ""
 	}
 
-	for sourcePath, fn := range blacklistEndpoints {
+	for sourcePath, fn := range endpoints {
 		match := compareRawSourceURLs(rawPath, sourcePath)
 		if match {
 			return fn, true
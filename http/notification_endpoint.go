@@ -23,6 +23,7 @@ type NotificationEndpointBackend struct {
 	log *zap.Logger
 
 	NotificationEndpointService influxdb.NotificationEndpointService
+	NotificationDeliveryService influxdb.NotificationDeliveryService
 	UserResourceMappingService  influxdb.UserResourceMappingService
 	LabelService                influxdb.LabelService
 	UserService                 influxdb.UserService
@@ -34,6 +35,7 @@ func NewNotificationEndpointBackend(log *zap.Logger, b *APIBackend) *Notificatio
 		HTTPErrorHandler:            b.HTTPErrorHandler,
 		log:                         log,
 		NotificationEndpointService: b.NotificationEndpointService,
+		NotificationDeliveryService: b.NotificationDeliveryService,
 		UserResourceMappingService:  b.UserResourceMappingService,
 		LabelService:                b.LabelService,
 		UserService:                 b.UserService,
@@ -51,6 +53,7 @@ type NotificationEndpointHandler struct {
 	log *zap.Logger
 
 	NotificationEndpointService influxdb.NotificationEndpointService
+	NotificationDeliveryService influxdb.NotificationDeliveryService
 	UserResourceMappingService  influxdb.UserResourceMappingService
 	LabelService                influxdb.LabelService
 	UserService                 influxdb.UserService
@@ -65,6 +68,7 @@ const (
 	notificationEndpointsIDOwnersIDPath  = "/api/v2/notificationEndpoints/:id/owners/:userID"
 	notificationEndpointsIDLabelsPath    = "/api/v2/notificationEndpoints/:id/labels"
 	notificationEndpointsIDLabelsIDPath  = "/api/v2/notificationEndpoints/:id/labels/:lid"
+	notificationEndpointsDeliveriesPath  = "/api/v2/notificationEndpoints/:id/deliveries"
 )
 
 // NewNotificationEndpointHandler returns a new instance of NotificationEndpointHandler.
@@ -75,6 +79,7 @@ func NewNotificationEndpointHandler(log *zap.Logger, b *NotificationEndpointBack
 		log:              log,
 
 		NotificationEndpointService: b.NotificationEndpointService,
+		NotificationDeliveryService: b.NotificationDeliveryService,
 		UserResourceMappingService:  b.UserResourceMappingService,
 		LabelService:                b.LabelService,
 		UserService:                 b.UserService,
@@ -85,6 +90,9 @@ func NewNotificationEndpointHandler(log *zap.Logger, b *NotificationEndpointBack
 	h.HandlerFunc("DELETE", notificationEndpointsIDPath, h.handleDeleteNotificationEndpoint)
 	h.HandlerFunc("PUT", notificationEndpointsIDPath, h.handlePutNotificationEndpoint)
 	h.HandlerFunc("PATCH", notificationEndpointsIDPath, h.handlePatchNotificationEndpoint)
+	if h.NotificationDeliveryService != nil {
+		h.HandlerFunc("GET", notificationEndpointsDeliveriesPath, h.handleGetNotificationEndpointDeliveries)
+	}
 
 	memberBackend := MemberBackend{
 		HTTPErrorHandler:           b.HTTPErrorHandler,
@@ -260,6 +268,40 @@ func (h *NotificationEndpointHandler) handleGetNotificationEndpoint(w http.Respo
 	}
 }
 
+type notificationDeliveriesResponse struct {
+	Deliveries []*influxdb.NotificationDelivery `json:"deliveries"`
+}
+
+// handleGetNotificationEndpointDeliveries returns the recent delivery attempts
+// of an endpoint. The endpoint is looked up first so that reading its
+// deliveries requires reading the endpoint.
+func (h *NotificationEndpointHandler) handleGetNotificationEndpointDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeGetNotificationEndpointRequest(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	edp, err := h.NotificationEndpointService.FindNotificationEndpointByID(ctx, id)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	deliveries, err := h.NotificationDeliveryService.FindNotificationDeliveries(ctx, edp.GetID())
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*influxdb.NotificationDelivery{}
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, notificationDeliveriesResponse{Deliveries: deliveries}); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
 func decodeNotificationEndpointFilter(ctx context.Context, r *http.Request) (influxdb.NotificationEndpointFilter, influxdb.FindOptions, error) {
 	f := influxdb.NotificationEndpointFilter{
 		UserResourceMappingFilter: influxdb.UserResourceMappingFilter{
@@ -574,6 +616,22 @@ func (s *NotificationEndpointService) FindNotificationEndpointByID(ctx context.C
 	return resp.endpoint, nil
 }
 
+// FindNotificationDeliveries returns the recent delivery attempts of a notification endpoint, newest first.
+func (s *NotificationEndpointService) FindNotificationDeliveries(ctx context.Context, id influxdb.ID) ([]*influxdb.NotificationDelivery, error) {
+	if !id.Valid() {
+		return nil, fmt.Errorf("invalid ID: please provide a valid ID")
+	}
+	var resp notificationDeliveriesResponse
+	err := s.Client.
+		Get(prefixNotificationEndpoints, id.String(), "deliveries").
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Deliveries, nil
+}
+
 // FindNotificationEndpoints returns a list of notification endpoints that match filter and the total count of matching notification endpoints.
 // Additional options provide pagination & sorting.
 func (s *NotificationEndpointService) FindNotificationEndpoints(ctx context.Context, filter influxdb.NotificationEndpointFilter, opt ...influxdb.FindOptions) ([]influxdb.NotificationEndpoint, int, error) {
@@ -609,7 +667,8 @@ func (s *NotificationEndpointService) FindNotificationEndpoints(ctx context.Cont
 
 // CreateNotificationEndpoint creates a new notification endpoint and sets b.ID with the new identifier.
 // TODO(@jsteenb2): this is unsatisfactory, we have no way of grabbing the new notification endpoint without
-//  serious hacky hackertoning. Put it on the list...
+//
+//	serious hacky hackertoning. Put it on the list...
 func (s *NotificationEndpointService) CreateNotificationEndpoint(ctx context.Context, ne influxdb.NotificationEndpoint, userID influxdb.ID) error {
 	var resp notificationEndpointDecoder
 	err := s.Client.
@@ -665,9 +724,10 @@ func (s *NotificationEndpointService) PatchNotificationEndpoint(ctx context.Cont
 
 // DeleteNotificationEndpoint removes a notification endpoint by ID, returns secret fields, orgID for further deletion.
 // TODO: axe this delete design, makes little sense in how its currently being done. Right now, as an http client,
-//  I am forced to know how the store handles this and then figure out what the server does in between me and that store,
-//  then see what falls out :flushed... for now returning nothing for secrets, orgID, and only returning an error. This makes
-//  the code/design smell super obvious imo
+//
+//	I am forced to know how the store handles this and then figure out what the server does in between me and that store,
+//	then see what falls out :flushed... for now returning nothing for secrets, orgID, and only returning an error. This makes
+//	the code/design smell super obvious imo
 func (s *NotificationEndpointService) DeleteNotificationEndpoint(ctx context.Context, id influxdb.ID) ([]influxdb.SecretField, influxdb.ID, error) {
 	if !id.Valid() {
 		return nil, 0, fmt.Errorf("invalid ID: please provide a valid ID")
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"go.uber.org/zap"
+)
+
+const prefixOrphans = "/api/v2/storage/orphans"
+
+// OrphanBackend is all services and associated parameters required to construct the OrphanHandler.
+type OrphanBackend struct {
+	Logger *zap.Logger
+	influxdb.HTTPErrorHandler
+
+	OrphanReportService influxdb.OrphanReportService
+}
+
+// NewOrphanBackend returns a new instance of OrphanBackend.
+func NewOrphanBackend(b *APIBackend) *OrphanBackend {
+	return &OrphanBackend{
+		Logger: b.Logger.With(zap.String("handler", "orphans")),
+
+		HTTPErrorHandler:    b.HTTPErrorHandler,
+		OrphanReportService: b.OrphanReportService,
+	}
+}
+
+// OrphanHandler is http handler for reports of orphaned storage directories.
+type OrphanHandler struct {
+	chi.Router
+	influxdb.HTTPErrorHandler
+	Logger *zap.Logger
+
+	OrphanReportService influxdb.OrphanReportService
+}
+
+// NewOrphanHandler creates a new handler at /api/v2/storage/orphans to report
+// the storage directories whose buckets or shards no longer exist.
+func NewOrphanHandler(b *OrphanBackend) *OrphanHandler {
+	h := &OrphanHandler{
+		Router:              NewBaseChiRouter(kithttp.NewAPI(kithttp.WithLog(b.Logger))),
+		HTTPErrorHandler:    b.HTTPErrorHandler,
+		Logger:              b.Logger,
+		OrphanReportService: b.OrphanReportService,
+	}
+
+	h.Get("/", h.handleGetOrphans)
+
+	return h
+}
+
+func (h *OrphanHandler) handleGetOrphans(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "OrphanHandler.handleGetOrphans")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	report, err := h.OrphanReportService.OrphanReport(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, report); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// OrphanService connects to Influx via HTTP using tokens to report orphaned
+// storage directories.
+type OrphanService struct {
+	Client *httpc.Client
+}
+
+var _ influxdb.OrphanReportService = (*OrphanService)(nil)
+
+// OrphanReport returns the orphaned directories of the storage engine.
+func (s *OrphanService) OrphanReport(ctx context.Context) (*influxdb.OrphanReport, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var report influxdb.OrphanReport
+	err := s.Client.
+		Get(prefixOrphans).
+		DecodeJSON(&report).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
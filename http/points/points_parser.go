@@ -4,8 +4,10 @@ import (
 	"compress/gzip"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
@@ -13,6 +15,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"istio.io/pkg/log"
 )
@@ -35,11 +38,23 @@ const (
 type ParsedPoints struct {
 	Points  models.Points
 	RawSize int
+	// NonFinite holds the NaN and infinite float field values skipped under
+	// the NaN handling of the parser.
+	NonFinite []models.NonFiniteField
+	// Duplicates holds the duplicate tag and field keys of the lines
+	// rejected under the duplicate handling of the parser.
+	Duplicates []models.DuplicateKey
 }
 
 // Parser parses batches of Points.
 type Parser struct {
 	Precision string
+	// NaNHandling is the policy of the bucket written to for NaN and infinite
+	// float field values.
+	NaNHandling influxdb.NaNHandling
+	// Duplicates is what is done with lines holding a tag or a field key
+	// more than once.
+	Duplicates models.DuplicateAction
 	//ParserOptions []models.ParserOption
 }
 
@@ -78,7 +93,22 @@ func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID influxdb.ID,
 
 	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "encoding and parsing")
 
-	points, err := models.ParsePointsWithPrecision(data, time.Now().UTC(), pw.Precision)
+	// Duplicates are resolved first, so that every parsing path sees the
+	// same lines.
+	data, duplicates := models.ResolveDuplicates(data, pw.Duplicates)
+
+	var (
+		points    []models.Point
+		nonFinite []models.NonFiniteField
+	)
+	switch pw.NaNHandling {
+	case influxdb.NaNHandlingDefault:
+		points, err = models.ParsePointsWithPrecision(data, time.Now().UTC(), pw.Precision)
+	case influxdb.NaNHandlingReject:
+		points, nonFinite, err = models.ParsePointsWithNonFinite(data, time.Now().UTC(), pw.Precision, models.SkipNonFiniteLines)
+	default:
+		points, nonFinite, err = models.ParsePointsWithNonFinite(data, time.Now().UTC(), pw.Precision, models.SkipNonFiniteFields)
+	}
 	span.LogKV("values_total", len(points))
 	span.Finish()
 	if err != nil {
@@ -101,11 +131,56 @@ func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID influxdb.ID,
 	}
 
 	return &ParsedPoints{
-		Points:  points,
-		RawSize: requestBytes,
+		Points:     points,
+		RawSize:    requestBytes,
+		NonFinite:  nonFinite,
+		Duplicates: duplicates,
 	}, nil
 }
 
+// ErrNonFiniteRejected is returned for a write to a bucket rejecting NaN and
+// infinite float field values, after writing the lines without them.
+func ErrNonFiniteRejected(nonFinite []models.NonFiniteField) *influxdb.Error {
+	reasons := make([]string, len(nonFinite))
+	lines := make(map[int]bool)
+	for i, f := range nonFinite {
+		reasons[i] = f.String()
+		lines[f.Line] = true
+	}
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Op:   opPointsWriter,
+		Msg:  fmt.Sprintf("partial write: lines with NaN or Inf float field values were rejected: %s dropped=%d", strings.Join(reasons, "; "), len(lines)),
+	}
+}
+
+// ErrDuplicatesRejected is returned for a write rejecting lines with
+// duplicate tags or fields, after writing the other lines.
+func ErrDuplicatesRejected(duplicates []models.DuplicateKey) *influxdb.Error {
+	reasons := make([]string, len(duplicates))
+	lines := make(map[int]bool)
+	for i, d := range duplicates {
+		reasons[i] = d.String()
+		lines[d.Line] = true
+	}
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Op:   opPointsWriter,
+		Msg:  fmt.Sprintf("partial write: lines with duplicate tags or fields were rejected: %s dropped=%d", strings.Join(reasons, "; "), len(lines)),
+	}
+}
+
+// NewNonFiniteFieldsCounter returns a counter of the NaN and infinite float
+// field values skipped by writes to buckets dropping or nulling them.
+func NewNonFiniteFieldsCounter() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "http",
+		Subsystem: "write",
+		Name:      "non_finite_fields_skipped_total",
+		Help:      "Number of NaN and infinite float field values skipped by writes, by bucket and NaN handling",
+	}, []string{"bucket", "policy"})
+}
+
 func readAll(ctx context.Context, rc io.ReadCloser) (data []byte, err error) {
 	defer func() {
 		if cerr := rc.Close(); cerr != nil && err == nil {
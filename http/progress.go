@@ -0,0 +1,409 @@
+package http
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const (
+	// ProgressIntervalHeader is the header a client sets, to a duration, to
+	// ask the server for progress frames at that interval during a backup or
+	// restore, so proxies closing idle connections do not close its
+	// connection while the server is busy.
+	ProgressIntervalHeader = "X-Influxdb-Progress-Interval"
+
+	// progressStreamContentType is the content type of the responses made of
+	// progress frames.
+	progressStreamContentType = "application/vnd.influxdata.progress-stream"
+
+	// minProgressInterval is the shortest interval the server sends progress
+	// frames at, whatever the client asks for.
+	minProgressInterval = 100 * time.Millisecond
+
+	// maxProgressFrameSize is the largest frame, other than a data frame, a
+	// client reads.
+	maxProgressFrameSize = 1 << 20
+)
+
+// The kinds of the frames of a progress stream. Each frame is its kind, the
+// length of its payload as a big-endian uint32, and its payload. A stream
+// ends with a result or an error frame; one ending otherwise was cut short.
+const (
+	progressFrameData     byte = 'd'
+	progressFrameProgress byte = 'p'
+	progressFrameResult   byte = 'r'
+	progressFrameError    byte = 'e'
+)
+
+// Progress is the payload of a progress frame.
+type Progress struct {
+	// Bytes is the number of bytes the server has read from the request, or
+	// written to the response, so far.
+	Bytes int64 `json:"bytes"`
+}
+
+type progressKey struct{}
+
+type progressOpts struct {
+	interval time.Duration
+	fn       func(Progress)
+}
+
+// WithProgress returns a context asking the server for progress frames at
+// the interval during the backup and restore requests made with it. fn, if
+// not nil, is called with every progress frame the server sends.
+func WithProgress(ctx context.Context, interval time.Duration, fn func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, progressOpts{interval: interval, fn: fn})
+}
+
+// setProgressHeader asks the server for progress frames, if the context of
+// the request does.
+func setProgressHeader(req *http.Request) {
+	opts, ok := req.Context().Value(progressKey{}).(progressOpts)
+	if ok && opts.interval > 0 {
+		req.Header.Set(ProgressIntervalHeader, opts.interval.String())
+	}
+}
+
+// IsProxyTimeout reports whether err is the error of a backup or restore
+// request whose connection was closed, or answered with a gateway error, as
+// proxies do with connections idle for too long.
+func IsProxyTimeout(err error) bool {
+	var perr *proxyTimeoutError
+	if errors.As(err, &perr) {
+		return true
+	}
+	var uerr *url.Error
+	return errors.As(err, &uerr) &&
+		(errors.Is(uerr.Err, io.EOF) ||
+			errors.Is(uerr.Err, io.ErrUnexpectedEOF) ||
+			errors.Is(uerr.Err, syscall.ECONNRESET) ||
+			errors.Is(uerr.Err, syscall.EPIPE))
+}
+
+// proxyTimeoutError is the error of a response with a gateway error, which
+// influxd never sends, but proxies do, or of a progress stream cut short.
+type proxyTimeoutError struct {
+	err error
+}
+
+func (e *proxyTimeoutError) Error() string { return e.err.Error() }
+func (e *proxyTimeoutError) Unwrap() error { return e.err }
+
+// doTransfer sends a backup or restore request, copying the body of the
+// response to w and decoding it into result, if they are not nil. The
+// response may be a progress stream, if the context of the request asked
+// for one.
+func doTransfer(hc *http.Client, req *http.Request, w io.Writer, result interface{}) error {
+	_, err := transfer(hc, req, w, result)
+	return err
+}
+
+// transfer is doTransfer, also returning the status code of the response,
+// or 0 if there was none.
+func transfer(hc *http.Client, req *http.Request, w io.Writer, result interface{}) (int, error) {
+	setProgressHeader(req)
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckError(resp); err != nil {
+		if resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout {
+			return resp.StatusCode, &proxyTimeoutError{err: err}
+		}
+		return resp.StatusCode, err
+	}
+
+	if mediatype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediatype == progressStreamContentType {
+		var fn func(Progress)
+		if opts, ok := req.Context().Value(progressKey{}).(progressOpts); ok {
+			fn = opts.fn
+		}
+		if err := readProgressStream(resp.Body, w, fn, result); err != nil {
+			return resp.StatusCode, err
+		}
+	} else if w != nil {
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			return resp.StatusCode, err
+		}
+	} else if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, resp.Body.Close()
+}
+
+// readProgressStream reads the frames of a progress stream from r, copying
+// data frames to w and calling fn with progress frames, until its result
+// frame, which is decoded into result if it is not nil, or its error frame.
+func readProgressStream(r io.Reader, w io.Writer, fn func(Progress), result interface{}) error {
+	var header [5]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return &proxyTimeoutError{err: fmt.Errorf("progress stream ended before its result: %w", err)}
+		}
+		kind, n := header[0], binary.BigEndian.Uint32(header[1:])
+
+		if kind == progressFrameData {
+			if w == nil {
+				w = ioutil.Discard
+			}
+			if _, err := io.CopyN(w, r, int64(n)); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			continue
+		}
+
+		if n > maxProgressFrameSize {
+			return fmt.Errorf("progress stream frame of %d bytes exceeds the limit of %d", n, maxProgressFrameSize)
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		switch kind {
+		case progressFrameProgress:
+			var p Progress
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return err
+			}
+			if fn != nil {
+				fn(p)
+			}
+		case progressFrameResult:
+			if result == nil || len(payload) == 0 {
+				return nil
+			}
+			return json.Unmarshal(payload, result)
+		case progressFrameError:
+			perr := &influxdb.Error{}
+			if err := json.Unmarshal(payload, perr); err != nil {
+				return fmt.Errorf("attempted to unmarshal error as JSON but failed: %q", err)
+			}
+			return perr
+		default:
+			return fmt.Errorf("invalid progress stream frame kind %q", kind)
+		}
+	}
+}
+
+// progressStream writes a response as a progress stream. Once started, it
+// sends a progress frame at every interval, until it is finished.
+type progressStream struct {
+	// bytes is the number of bytes read or written so far. It is first, to
+	// be aligned for atomic operations.
+	bytes int64
+
+	w        http.ResponseWriter
+	interval time.Duration
+	done     chan struct{}
+
+	mu       sync.Mutex
+	started  bool
+	finished bool
+	err      error
+}
+
+// newProgressStream returns the progress stream of the response to r, or
+// nil if the client did not ask for one, in which case the response is
+// written as it always was.
+func newProgressStream(w http.ResponseWriter, r *http.Request) *progressStream {
+	s := r.Header.Get(ProgressIntervalHeader)
+	if s == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(s)
+	if err != nil || interval <= 0 {
+		return nil
+	}
+	if interval < minProgressInterval {
+		interval = minProgressInterval
+	}
+	return &progressStream{
+		w:        w,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// body returns the body of the request to read the upload of a restore
+// from. Once it is read to its end, the response may no longer be held
+// back: if the restore then runs for longer than the interval, the response
+// becomes a progress stream. Until then, nothing is written to it, as a
+// response written before the request is read would cut its body short.
+func (s *progressStream) body(r io.Reader) io.Reader {
+	if s == nil {
+		return r
+	}
+	body := &progressBody{r: r, bytes: &s.bytes, eof: make(chan struct{})}
+	go s.run(body.eof)
+	return body
+}
+
+// writer returns the writer of a backup to the response, which becomes a
+// progress stream at once.
+func (s *progressStream) writer() io.Writer {
+	s.mu.Lock()
+	s.start()
+	s.mu.Unlock()
+
+	go s.run(nil)
+	return s
+}
+
+// run sends progress frames at every interval once begin is closed, or at
+// once if it is nil, until the stream is finished.
+func (s *progressStream) run(begin <-chan struct{}) {
+	if begin != nil {
+		select {
+		case <-begin:
+		case <-s.done:
+			return
+		}
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			if !s.finished {
+				s.start()
+				b, _ := json.Marshal(Progress{Bytes: atomic.LoadInt64(&s.bytes)})
+				s.writeFrame(progressFrameProgress, b)
+			}
+			s.mu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Write writes p to the stream in a data frame.
+func (s *progressStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writeFrame(progressFrameData, p); err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&s.bytes, int64(len(p)))
+	return len(p), nil
+}
+
+// finish ends the stream with the error, or the result if it is nil. It
+// returns false, having written nothing, if the stream never started, in
+// which case the response is to be written as usual.
+func (s *progressStream) finish(result interface{}, err error) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.finished {
+		s.finished = true
+		close(s.done)
+	}
+	if !s.started {
+		return false
+	}
+
+	if err != nil {
+		e := struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}{Code: influxdb.ErrorCode(err)}
+		if err, ok := err.(*influxdb.Error); ok {
+			e.Message = err.Error()
+		} else {
+			e.Message = "An internal error has occurred"
+		}
+		b, _ := json.Marshal(e)
+		s.writeFrame(progressFrameError, b)
+		return true
+	}
+
+	var b []byte
+	if result != nil {
+		b, _ = json.Marshal(result)
+	}
+	s.writeFrame(progressFrameResult, b)
+	return true
+}
+
+// start writes the headers of the stream, if not done yet. s.mu must be
+// held.
+func (s *progressStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", progressStreamContentType)
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// writeFrame writes a frame to the stream and flushes it to the client.
+// Once a write fails, the following ones fail alike. s.mu must be held.
+func (s *progressStream) writeFrame(kind byte, payload []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	var header [5]byte
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, s.err = s.w.Write(header[:]); s.err != nil {
+		return s.err
+	}
+	if _, s.err = s.w.Write(payload); s.err != nil {
+		return s.err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// progressBody counts the bytes read from the body of a request, and closes
+// eof once it is read to its end.
+type progressBody struct {
+	r       io.Reader
+	bytes   *int64
+	eof     chan struct{}
+	eofOnce sync.Once
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	atomic.AddInt64(b.bytes, int64(n))
+	if err == io.EOF {
+		b.eofOnce.Do(func() { close(b.eof) })
+	}
+	return n, err
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/iocounter"
+	"github.com/influxdata/flux/metadata"
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
@@ -50,6 +51,13 @@ type FluxBackend struct {
 	ProxyQueryService   query.ProxyQueryService
 	FluxLanguageService influxdb.FluxLanguageService
 	Flagger             feature.Flagger
+
+	// QueryResultLimiter truncates the results of queries at the limits of
+	// their organization. When nil, results are not limited.
+	QueryResultLimiter influxdb.QueryResultLimiter
+
+	// ResponseStats reports the statistics of queries in response headers.
+	ResponseStats bool
 }
 
 // NewFluxBackend returns a new instance of FluxBackend.
@@ -66,6 +74,8 @@ func NewFluxBackend(log *zap.Logger, b *APIBackend) *FluxBackend {
 		OrganizationService: b.OrganizationService,
 		FluxLanguageService: b.FluxLanguageService,
 		Flagger:             b.Flagger,
+		QueryResultLimiter:  b.QueryResultLimiter,
+		ResponseStats:       b.ResponseStats,
 	}
 }
 
@@ -88,6 +98,13 @@ type FluxHandler struct {
 	EventRecorder metric.EventRecorder
 
 	Flagger feature.Flagger
+
+	// QueryResultLimiter truncates the results of queries at the limits of
+	// their organization.
+	QueryResultLimiter influxdb.QueryResultLimiter
+
+	// ResponseStats reports the statistics of queries in response headers.
+	ResponseStats bool
 }
 
 // Prefix provides the route prefix.
@@ -108,6 +125,8 @@ func NewFluxHandler(log *zap.Logger, b *FluxBackend) *FluxHandler {
 		EventRecorder:       b.QueryEventRecorder,
 		FluxLanguageService: b.FluxLanguageService,
 		Flagger:             b.Flagger,
+		QueryResultLimiter:  b.QueryResultLimiter,
+		ResponseStats:       b.ResponseStats,
 	}
 
 	// query reponses can optionally be gzip encoded
@@ -192,8 +211,34 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	}
 	hd.SetHeaders(w)
 
+	if h.QueryResultLimiter != nil {
+		if l := h.QueryResultLimiter.QueryResultLimits(ctx, orgID); !l.Unlimited() {
+			req.Limits = &l
+			// The limit may only be reached after the response is streamed.
+			w.Header().Add("Trailer", kithttp.PartialResultHeader)
+		}
+	}
+
+	var statsW *kithttp.StatsResponseWriter
+	if h.ResponseStats {
+		statsW = kithttp.NewStatsResponseWriter(w, kithttp.DefaultStatsBufferSize)
+		w = statsW
+	}
+
 	cw := iocounter.Writer{Writer: w}
-	if _, err := h.ProxyQueryService.Query(ctx, &cw, req); err != nil {
+	stats, err := h.ProxyQueryService.Query(ctx, &cw, req)
+	if limit := truncatedLimit(stats.Metadata); limit != "" && req.Limits != nil {
+		w.Header().Set(kithttp.PartialResultHeader, partialResultMessage(limit, req.Limits))
+		h.QueryResultLimiter.RecordTruncatedQuery(ctx, orgID, limit)
+	}
+	if statsW != nil {
+		if err := statsW.Finish(fluxQueryStats(stats)); err != nil {
+			log.Info("Error writing response to client", zap.String("handler", "flux"), zap.Error(err))
+		}
+		// An error is written past the finished writer.
+		w = sw
+	}
+	if err != nil {
 		if cw.Count() == 0 {
 			// Only record the error headers IFF nothing has been written to w.
 			h.HandleHTTPError(ctx, err, w)
@@ -207,6 +252,54 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// fluxQueryStats returns the response statistics of a flux query.
+func fluxQueryStats(stats flux.Statistics) kithttp.QueryStats {
+	return kithttp.QueryStats{
+		BytesRead:    sumMetadata(stats.Metadata, "influxdb/scanned-bytes"),
+		RowsReturned: sumMetadata(stats.Metadata, query.RowsReturnedMetadataKey),
+		Duration:     stats.TotalDuration,
+	}
+}
+
+// sumMetadata returns the sum of the integer values of key in md, or -1 if
+// it has none.
+func sumMetadata(md metadata.Metadata, key string) int64 {
+	values, ok := md[key]
+	if !ok {
+		return -1
+	}
+	var sum int64
+	for _, v := range values {
+		switch v := v.(type) {
+		case int:
+			sum += int64(v)
+		case int64:
+			sum += v
+		}
+	}
+	return sum
+}
+
+// truncatedLimit returns the limit at which the results of a query were
+// truncated, if any.
+func truncatedLimit(md metadata.Metadata) string {
+	for _, v := range md[query.ResultTruncatedMetadataKey] {
+		if limit, ok := v.(string); ok {
+			return limit
+		}
+	}
+	return ""
+}
+
+// partialResultMessage describes the limit at which the results of a query
+// were truncated.
+func partialResultMessage(limit string, l *influxdb.QueryResultLimits) string {
+	if limit == influxdb.QueryByteLimit {
+		return fmt.Sprintf("partial results, byte limit of %d reached", l.MaxBytes)
+	}
+	return fmt.Sprintf("partial results, row limit of %d reached", l.MaxRows)
+}
+
 type langRequest struct {
 	Query string `json:"query"`
 }
@@ -3,12 +3,14 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
@@ -48,6 +50,10 @@ const (
 	restoreKVPath     = prefixRestore + "/kv"
 	restoreBucketPath = prefixRestore + "/buckets/:bucketID"
 	restoreShardPath  = prefixRestore + "/shards/:shardID"
+	restoreDigestPath = prefixRestore + "/buckets/:bucketID/digests"
+
+	// RestoreIDHeader is the header used to correlate all requests belonging to one restore.
+	RestoreIDHeader = "X-Influxdb-Restore-Id"
 )
 
 // NewRestoreHandler creates a new handler at /api/v2/restore to receive restore requests.
@@ -61,7 +67,9 @@ func NewRestoreHandler(b *RestoreBackend) *RestoreHandler {
 
 	h.HandlerFunc(http.MethodPost, restoreKVPath, h.handleRestoreKVStore)
 	h.HandlerFunc(http.MethodPost, restoreBucketPath, h.handleRestoreBucket)
+	h.HandlerFunc(http.MethodPut, restoreBucketPath, h.handleRestoreBucket)
 	h.HandlerFunc(http.MethodPost, restoreShardPath, h.handleRestoreShard)
+	h.HandlerFunc(http.MethodGet, restoreDigestPath, h.handleGetBucketShardDigests)
 
 	return h
 }
@@ -71,18 +79,33 @@ func (h *RestoreHandler) handleRestoreKVStore(w http.ResponseWriter, r *http.Req
 	defer span.Finish()
 
 	ctx := r.Context()
+	log := h.restoreLogger(r)
 
-	if err := h.RestoreService.RestoreKVStore(ctx, r.Body); err != nil {
-		h.HandleHTTPError(ctx, err, w)
+	progress := newProgressStream(w, r)
+
+	log.Info("Restoring KV store")
+	err := h.RestoreService.RestoreKVStore(ctx, progress.body(r.Body))
+	if err != nil {
+		log.Error("Failed to restore KV store", zap.Error(err))
+	} else {
+		log.Info("KV store restored")
+	}
+	if progress.finish(nil, err) {
 		return
 	}
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+	}
 }
 
+// handleRestoreBucket restores a bucket created for the restore, or replaces
+// the shards of an existing bucket with PUT.
 func (h *RestoreHandler) handleRestoreBucket(w http.ResponseWriter, r *http.Request) {
 	span, r := tracing.ExtractFromHTTPRequest(r, "RestoreHandler.handleRestoreBucket")
 	defer span.Finish()
 
 	ctx := r.Context()
+	log := h.restoreLogger(r)
 
 	// Read bucket ID.
 	bucketID, err := decodeIDFromCtx(r.Context(), "bucketID")
@@ -90,15 +113,34 @@ func (h *RestoreHandler) handleRestoreBucket(w http.ResponseWriter, r *http.Requ
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
+	log = log.With(zap.Stringer("bucket_id", bucketID))
+	progress := newProgressStream(w, r)
 
 	// Read serialized DBI data.
-	buf, err := ioutil.ReadAll(r.Body)
+	buf, err := ioutil.ReadAll(progress.body(r.Body))
 	if err != nil {
+		// The progress stream cannot have started before the body was read.
+		progress.finish(nil, nil)
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
 
-	shardIDMap, err := h.RestoreService.RestoreBucket(ctx, bucketID, buf)
+	restore := h.RestoreService.RestoreBucket
+	if r.Method == http.MethodPut {
+		restore = h.RestoreService.ReplaceBucket
+		log = log.With(zap.Bool("replace", true))
+	}
+
+	log.Info("Restoring bucket")
+	shardIDMap, err := restore(ctx, bucketID, buf)
+	if err != nil {
+		log.Error("Failed to restore bucket", zap.Error(err))
+	} else {
+		log.Info("Bucket restored", zap.Int("shards", len(shardIDMap)))
+	}
+	if progress.finish(shardIDMap, err) {
+		return
+	}
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
@@ -123,10 +165,57 @@ func (h *RestoreHandler) handleRestoreShard(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := h.RestoreService.RestoreShard(ctx, shardID, r.Body); err != nil {
+	log := h.restoreLogger(r).With(zap.Uint64("shard_id", shardID))
+	progress := newProgressStream(w, r)
+
+	log.Info("Restoring shard")
+	err = h.RestoreService.RestoreShard(ctx, shardID, progress.body(r.Body))
+	if err != nil {
+		log.Error("Failed to restore shard", zap.Error(err))
+	} else {
+		log.Info("Shard restored")
+	}
+	if progress.finish(nil, err) {
+		return
+	}
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+	}
+}
+
+func (h *RestoreHandler) handleGetBucketShardDigests(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "RestoreHandler.handleGetBucketShardDigests")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, err := decodeIDFromCtx(ctx, "bucketID")
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	digests, err := h.RestoreService.BucketShardDigests(ctx, bucketID)
+	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
+	if digests == nil {
+		digests = []influxdb.ShardDigest{}
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, digests); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// restoreLogger returns the handler logger annotated with the restore ID sent by the client, if any.
+func (h *RestoreHandler) restoreLogger(r *http.Request) *zap.Logger {
+	if id := r.Header.Get(RestoreIDHeader); id != "" {
+		return h.Logger.With(zap.String("restore_id", id))
+	}
+	return h.Logger
 }
 
 // RestoreService is the client implementation of influxdb.RestoreService.
@@ -134,69 +223,108 @@ type RestoreService struct {
 	Addr               string
 	Token              string
 	InsecureSkipVerify bool
-}
+	// TLSConfig secures connections to the server in place of
+	// InsecureSkipVerify, if set.
+	TLSConfig *tls.Config
 
-func (s *RestoreService) RestoreKVStore(ctx context.Context, r io.Reader) error {
-	span, ctx := tracing.StartSpanFromContext(ctx)
-	defer span.Finish()
+	// RestoreID is sent with every request so the server can correlate them in its logs.
+	RestoreID string
 
-	u, err := NewURL(s.Addr, restoreKVPath)
-	if err != nil {
-		return err
-	}
+	// Retry is how the uploads of the KV store, buckets and shards are
+	// retried. An upload is only retried if its body can be read again,
+	// being an io.Seeker or a ReopenableReader.
+	Retry RetryPolicy
 
-	req, err := http.NewRequest(http.MethodPost, u.String(), r)
-	if err != nil {
-		return err
+	transportOnce sync.Once
+	transport     http.RoundTripper
+}
+
+// client returns a client for requests to a URL with the given scheme.
+func (s *RestoreService) client(scheme string) *http.Client {
+	if s.TLSConfig == nil {
+		return NewClient(scheme, s.InsecureSkipVerify)
 	}
+	s.transportOnce.Do(func() {
+		s.transport = NewTLSTransport(s.TLSConfig)
+	})
+	return &http.Client{Transport: s.transport}
+}
+
+// setHeaders sets the token & restore ID headers on a restore request.
+func (s *RestoreService) setHeaders(req *http.Request) {
 	SetToken(s.Token, req)
-	req = req.WithContext(ctx)
+	if s.RestoreID != "" {
+		req.Header.Set(RestoreIDHeader, s.RestoreID)
+	}
+}
 
-	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
-	hc.Timeout = httpClientTimeout
-	resp, err := hc.Do(req)
+// upload sends the body r to the path, decoding the response into result if
+// it is not nil, and retries it as s.Retry allows if r can be read again.
+func (s *RestoreService) upload(ctx context.Context, method, path string, r io.Reader, result interface{}) error {
+	u, err := NewURL(s.Addr, path)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if err := CheckError(resp); err != nil {
-		return err
+	policy := s.Retry
+	reopen := rewinder(r)
+	if reopen == nil {
+		policy.Retries = 0
 	}
 
-	return nil
+	hc := s.client(u.Scheme)
+	hc.Timeout = httpClientTimeout
+	return policy.do(ctx, func(n int) (*http.Request, int, error) {
+		body := r
+		if n > 0 {
+			var err error
+			if body, err = reopen(); err != nil {
+				return nil, 0, err
+			}
+		}
+		// The client closes the bodies it sends, which must stay open to be
+		// sent again.
+		if _, ok := body.(io.Closer); ok {
+			body = ioutil.NopCloser(body)
+		}
+
+		req, err := http.NewRequest(method, u.String(), body)
+		if err != nil {
+			return nil, 0, err
+		}
+		s.setHeaders(req)
+		req = req.WithContext(ctx)
+
+		status, err := transfer(hc, req, nil, result)
+		return req, status, err
+	})
 }
 
-func (s *RestoreService) RestoreBucket(ctx context.Context, id influxdb.ID, dbi []byte) (map[uint64]uint64, error) {
+func (s *RestoreService) RestoreKVStore(ctx context.Context, r io.Reader) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
-	u, err := NewURL(s.Addr, prefixRestore+fmt.Sprintf("/buckets/%s", id.String()))
-	if err != nil {
-		return nil, err
-	}
+	return s.upload(ctx, http.MethodPost, restoreKVPath, r, nil)
+}
 
-	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(dbi))
-	if err != nil {
-		return nil, err
-	}
-	SetToken(s.Token, req)
-	req = req.WithContext(ctx)
+func (s *RestoreService) RestoreBucket(ctx context.Context, id influxdb.ID, dbi []byte) (map[uint64]uint64, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
 
-	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
-	hc.Timeout = httpClientTimeout
-	resp, err := hc.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return s.restoreBucket(ctx, http.MethodPost, id, dbi)
+}
 
-	if err := CheckError(resp); err != nil {
-		return nil, err
-	}
+func (s *RestoreService) ReplaceBucket(ctx context.Context, id influxdb.ID, dbi []byte) (map[uint64]uint64, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	return s.restoreBucket(ctx, http.MethodPut, id, dbi)
+}
 
+func (s *RestoreService) restoreBucket(ctx context.Context, method string, id influxdb.ID, dbi []byte) (map[uint64]uint64, error) {
 	shardIDMap := make(map[uint64]uint64)
-	if err := json.NewDecoder(resp.Body).Decode(&shardIDMap); err != nil {
+	path := prefixRestore + fmt.Sprintf("/buckets/%s", id.String())
+	if err := s.upload(ctx, method, path, bytes.NewReader(dbi), &shardIDMap); err != nil {
 		return nil, err
 	}
 	return shardIDMap, nil
@@ -206,29 +334,39 @@ func (s *RestoreService) RestoreShard(ctx context.Context, shardID uint64, r io.
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
-	u, err := NewURL(s.Addr, fmt.Sprintf(prefixRestore+"/shards/%d", shardID))
+	return s.upload(ctx, http.MethodPost, fmt.Sprintf(prefixRestore+"/shards/%d", shardID), r, nil)
+}
+
+func (s *RestoreService) BucketShardDigests(ctx context.Context, id influxdb.ID) ([]influxdb.ShardDigest, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	u, err := NewURL(s.Addr, prefixRestore+fmt.Sprintf("/buckets/%s/digests", id.String()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, u.String(), r)
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	SetToken(s.Token, req)
+	s.setHeaders(req)
 	req = req.WithContext(ctx)
 
-	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
-	hc.Timeout = httpClientTimeout
+	hc := s.client(u.Scheme)
 	resp, err := hc.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if err := CheckError(resp); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	var digests []influxdb.ShardDigest
+	if err := json.NewDecoder(resp.Body).Decode(&digests); err != nil {
+		return nil, err
+	}
+	return digests, nil
 }
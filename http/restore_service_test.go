@@ -0,0 +1,114 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	ihttp "github.com/influxdata/influxdb/v2/http"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// stubRestoreService restores nothing, reading the bodies it is sent.
+type stubRestoreService struct{}
+
+func (stubRestoreService) RestoreKVStore(ctx context.Context, r io.Reader) error {
+	_, err := io.Copy(ioutil.Discard, r)
+	return err
+}
+
+func (stubRestoreService) RestoreBucket(ctx context.Context, id influxdb.ID, dbi []byte) (map[uint64]uint64, error) {
+	return map[uint64]uint64{1: 101}, nil
+}
+
+func (stubRestoreService) ReplaceBucket(ctx context.Context, id influxdb.ID, dbi []byte) (map[uint64]uint64, error) {
+	return map[uint64]uint64{1: 101}, nil
+}
+
+func (stubRestoreService) RestoreShard(ctx context.Context, shardID uint64, r io.Reader) error {
+	_, err := io.Copy(ioutil.Discard, r)
+	return err
+}
+
+func (stubRestoreService) BucketShardDigests(ctx context.Context, id influxdb.ID) ([]influxdb.ShardDigest, error) {
+	return nil, nil
+}
+
+// restoreIDRecorder records the restore ID header of the requests it passes
+// to its handler.
+type restoreIDRecorder struct {
+	h http.Handler
+
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func (rec *restoreIDRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	rec.ids[r.Method+" "+r.URL.Path] = r.Header.Get(ihttp.RestoreIDHeader)
+	rec.mu.Unlock()
+	rec.h.ServeHTTP(w, r)
+}
+
+func TestRestoreService_RestoreID(t *testing.T) {
+	ctx := context.Background()
+	const restoreID = "06ae8ef6f6c81000"
+	bucketID := influxdb.ID(0x1000)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	rec := &restoreIDRecorder{
+		h: ihttp.NewRestoreHandler(&ihttp.RestoreBackend{
+			Logger:           zap.New(core),
+			HTTPErrorHandler: kithttp.ErrorHandler(0),
+			RestoreService:   stubRestoreService{},
+		}),
+		ids: make(map[string]string),
+	}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	svc := &ihttp.RestoreService{Addr: srv.URL, RestoreID: restoreID}
+	require.NoError(t, svc.RestoreKVStore(ctx, strings.NewReader("bolt")))
+	_, err := svc.RestoreBucket(ctx, bucketID, []byte("dbi"))
+	require.NoError(t, err)
+	_, err = svc.ReplaceBucket(ctx, bucketID, []byte("dbi"))
+	require.NoError(t, err)
+	require.NoError(t, svc.RestoreShard(ctx, 101, strings.NewReader("shard")))
+	_, err = svc.BucketShardDigests(ctx, bucketID)
+	require.NoError(t, err)
+
+	// The client sends the restore ID with every request.
+	assert.Equal(t, map[string]string{
+		"POST /api/v2/restore/kv":                              restoreID,
+		"POST /api/v2/restore/buckets/0000000000001000":        restoreID,
+		"PUT /api/v2/restore/buckets/0000000000001000":         restoreID,
+		"POST /api/v2/restore/shards/101":                      restoreID,
+		"GET /api/v2/restore/buckets/0000000000001000/digests": restoreID,
+	}, rec.ids)
+
+	// The server logs the restore with it.
+	for _, msg := range []string{"KV store restored", "Bucket restored", "Shard restored"} {
+		entries := logs.FilterMessage(msg).All()
+		require.NotEmpty(t, entries, msg)
+		for _, e := range entries {
+			assert.Equal(t, restoreID, e.ContextMap()["restore_id"], msg)
+		}
+	}
+
+	// Requests without the header are logged without it.
+	svc.RestoreID = ""
+	require.NoError(t, svc.RestoreShard(ctx, 102, strings.NewReader("shard")))
+	entries := logs.FilterMessage("Shard restored").All()
+	assert.NotContains(t, entries[len(entries)-1].ContextMap(), "restore_id")
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// The backoffs of a RetryPolicy leaving them unset.
+const (
+	DefaultRetryMinBackoff = time.Second
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy is how requests failing with a transient error, a 5xx
+// response, a connection reset or a timeout, are retried. Requests answered
+// with a 4xx are never retried. The zero value does not retry.
+type RetryPolicy struct {
+	// Retries is the number of times a request is retried.
+	Retries int
+	// MaxDuration, if not 0, bounds the time spent retrying a request, from
+	// its first failure; no retry is made that would start after it.
+	MaxDuration time.Duration
+	// MinBackoff is the wait before the first retry, doubled for each retry
+	// after it, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnRetry, if not nil, is called before each retry with the request that
+	// failed, the number of the retry, from 1, the wait before it and the
+	// error of the request.
+	OnRetry func(req *http.Request, attempt int, wait time.Duration, err error)
+}
+
+// do runs attempt, which sends a request and returns it with the status code
+// of its response, or 0 if there was none, until it succeeds, fails with an
+// error that is not transient, or the policy gives up. attempt is called
+// with the number of the attempt, from 0.
+func (p RetryPolicy) do(ctx context.Context, attempt func(n int) (*http.Request, int, error)) error {
+	backoff, maxBackoff := p.MinBackoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+
+	var firstFailure time.Time
+	for n := 0; ; n++ {
+		req, status, err := attempt(n)
+		if err == nil || n >= p.Retries || !isTransient(ctx, status, err) {
+			return err
+		}
+		if firstFailure.IsZero() {
+			firstFailure = time.Now()
+		}
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		if p.MaxDuration > 0 && time.Since(firstFailure)+backoff > p.MaxDuration {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(req, n+1, backoff, err)
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// isTransient reports whether a request failing with err, and a response
+// with the status code, or 0 if there was none, may succeed if sent again.
+func isTransient(ctx context.Context, status int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if status >= 500 || IsProxyTimeout(err) {
+		return true
+	}
+	var nerr net.Error
+	return status == 0 && errors.As(err, &nerr) && nerr.Timeout()
+}
+
+// ReopenableReader is a request body that can be read again from its start,
+// so that requests sending it can be retried. Bodies that are io.Seekers are
+// read again by seeking back to where they were first read from.
+type ReopenableReader interface {
+	io.Reader
+	// Reopen returns a reader of the body from its start.
+	Reopen() (io.Reader, error)
+}
+
+// rewinder returns a function returning a reader of r from its current
+// position, to send it again, or nil if r cannot be read again.
+func rewinder(r io.Reader) func() (io.Reader, error) {
+	if rr, ok := r.(ReopenableReader); ok {
+		return rr.Reopen
+	}
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() (io.Reader, error) {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+}
@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strconv"
+	"strings"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	pctx "github.com/influxdata/influxdb/v2/context"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"go.uber.org/zap"
 )
 
@@ -53,6 +56,7 @@ type ScraperHandler struct {
 	*httprouter.Router
 	influxdb.HTTPErrorHandler
 	log                        *zap.Logger
+	api                        *kithttp.API
 	UserService                influxdb.UserService
 	UserResourceMappingService influxdb.UserResourceMappingService
 	LabelService               influxdb.LabelService
@@ -77,6 +81,7 @@ func NewScraperHandler(log *zap.Logger, b *ScraperBackend) *ScraperHandler {
 		Router:                     NewRouter(b.HTTPErrorHandler),
 		HTTPErrorHandler:           b.HTTPErrorHandler,
 		log:                        log,
+		api:                        kithttp.NewAPI(kithttp.WithLog(log)),
 		UserService:                b.UserService,
 		UserResourceMappingService: b.UserResourceMappingService,
 		LabelService:               b.LabelService,
@@ -130,7 +135,7 @@ func NewScraperHandler(log *zap.Logger, b *ScraperBackend) *ScraperHandler {
 // handlePostScraperTarget is HTTP handler for the POST /api/v2/scrapers route.
 func (h *ScraperHandler) handlePostScraperTarget(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	req, err := decodeScraperTargetAddRequest(ctx, r)
+	req, err := decodeScraperTargetAddRequest(ctx, h.api, r)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
@@ -178,9 +183,12 @@ func (h *ScraperHandler) handleDeleteScraperTarget(w http.ResponseWriter, r *htt
 }
 
 // handlePatchScraperTarget is the HTTP handler for the PATCH /api/v2/scrapers/:id route.
+// Only the fields in the body are updated. With an If-Match header holding
+// the revision of the target, as returned in the ETag header, the update
+// fails if the target has changed since.
 func (h *ScraperHandler) handlePatchScraperTarget(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	update, err := decodeScraperTargetUpdateRequest(ctx, r)
+	id, update, err := decodeScraperTargetUpdateRequest(ctx, h.api, r)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
@@ -192,7 +200,7 @@ func (h *ScraperHandler) handlePatchScraperTarget(w http.ResponseWriter, r *http
 		return
 	}
 
-	target, err := h.ScraperStorageService.UpdateTarget(ctx, update, auth.GetUserID())
+	target, err := h.ScraperStorageService.PatchTarget(ctx, id, update, auth.GetUserID())
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
@@ -204,6 +212,7 @@ func (h *ScraperHandler) handlePatchScraperTarget(w http.ResponseWriter, r *http
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
+	w.Header().Set("ETag", targetETag(target))
 
 	if err := encodeResponse(ctx, w, http.StatusOK, resp); err != nil {
 		logEncodingError(h.log, r, err)
@@ -230,6 +239,7 @@ func (h *ScraperHandler) handleGetScraperTarget(w http.ResponseWriter, r *http.R
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
+	w.Header().Set("ETag", targetETag(target))
 
 	if err := encodeResponse(ctx, w, http.StatusOK, resp); err != nil {
 		logEncodingError(h.log, r, err)
@@ -268,6 +278,10 @@ func decodeScraperTargetsRequest(ctx context.Context, r *http.Request) (*getScra
 	} else if org := qp.Get("org"); org != "" {
 		req.filter.Org = &org
 	}
+	if typ := qp.Get("type"); typ != "" {
+		scraperType := influxdb.ScraperType(typ)
+		req.filter.Type = &scraperType
+	}
 
 	return req, nil
 }
@@ -299,22 +313,49 @@ func (h *ScraperHandler) handleGetScraperTargets(w http.ResponseWriter, r *http.
 	}
 }
 
-func decodeScraperTargetUpdateRequest(ctx context.Context, r *http.Request) (*influxdb.ScraperTarget, error) {
-	update := &influxdb.ScraperTarget{}
-	if err := json.NewDecoder(r.Body).Decode(update); err != nil {
-		return nil, err
+func decodeScraperTargetUpdateRequest(ctx context.Context, api *kithttp.API, r *http.Request) (influxdb.ID, influxdb.ScraperTargetUpdate, error) {
+	var update influxdb.ScraperTargetUpdate
+	if err := api.DecodeAndValidate(r, &update); err != nil {
+		return 0, update, err
 	}
 	id, err := decodeScraperTargetIDRequest(ctx, r)
 	if err != nil {
-		return nil, err
+		return 0, update, err
+	}
+	if rev, ok, err := decodeTargetRevision(r.Header.Get("If-Match")); err != nil {
+		return 0, update, err
+	} else if ok {
+		update.Revision = &rev
+	}
+	return *id, update, nil
+}
+
+// targetETag returns the entity tag of the revision of target.
+func targetETag(target *influxdb.ScraperTarget) string {
+	return strconv.Quote(strconv.FormatUint(target.Revision, 10))
+}
+
+// decodeTargetRevision decodes the revision of a scraper target in an
+// If-Match header, returning false if the header is empty or matches any
+// revision.
+func decodeTargetRevision(ifMatch string) (uint64, bool, error) {
+	tag := strings.TrimPrefix(strings.TrimSpace(ifMatch), "W/")
+	if tag == "" || tag == "*" {
+		return 0, false, nil
 	}
-	update.ID = *id
-	return update, nil
+	rev, err := strconv.ParseUint(strings.Trim(tag, `"`), 10, 64)
+	if err != nil {
+		return 0, false, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("If-Match header %q is not the ETag of a scraper target revision", ifMatch),
+		}
+	}
+	return rev, true, nil
 }
 
-func decodeScraperTargetAddRequest(ctx context.Context, r *http.Request) (*influxdb.ScraperTarget, error) {
+func decodeScraperTargetAddRequest(ctx context.Context, api *kithttp.API, r *http.Request) (*influxdb.ScraperTarget, error) {
 	req := &influxdb.ScraperTarget{}
-	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+	if err := api.DecodeAndValidate(r, req); err != nil {
 		return nil, err
 	}
 	return req, nil
@@ -369,6 +410,9 @@ func (s *ScraperService) ListTargets(ctx context.Context, filter influxdb.Scrape
 	if filter.Org != nil {
 		query.Set("org", *filter.Org)
 	}
+	if filter.Type != nil {
+		query.Set("type", string(*filter.Type))
+	}
 
 	req, err := http.NewRequest("GET", url.String(), nil)
 	if err != nil {
@@ -411,12 +455,65 @@ func (s *ScraperService) UpdateTarget(ctx context.Context, update *influxdb.Scra
 			Msg:  "provided scraper target ID has invalid format",
 		}
 	}
-	url, err := NewURL(s.Addr, targetIDPath(update.ID))
+	// Set every field, so that those with empty values are cleared.
+	interval := influxdb.Duration{}
+	if update.Interval != nil {
+		interval = *update.Interval
+	}
+	batchInterval := influxdb.Duration{}
+	if update.BatchInterval != nil {
+		batchInterval = *update.BatchInterval
+	}
+	headers := update.Headers
+	if headers == nil {
+		headers = influxdb.ScraperHeaders{}
+	}
+	upd := influxdb.ScraperTargetUpdate{
+		Name:            &update.Name,
+		Type:            &update.Type,
+		URL:             &update.URL,
+		AllowInsecure:   &update.AllowInsecure,
+		Headers:         headers,
+		Interval:        &interval,
+		BatchSize:       &update.BatchSize,
+		BatchInterval:   &batchInterval,
+		Jitter:          &update.Jitter,
+		TimestampSource: &update.TimestampSource,
+		Precision:       &update.Precision,
+	}
+	if update.BucketID.Valid() {
+		upd.BucketID = &update.BucketID
+	}
+	// Credentials left out are kept, as they are not returned to be sent
+	// back.
+	if update.Username != "" {
+		upd.Username = &update.Username
+	}
+	if update.Password != "" {
+		upd.Password = &update.Password
+	}
+	if update.BearerToken != "" {
+		upd.BearerToken = &update.BearerToken
+	}
+	return s.PatchTarget(ctx, update.ID, upd, userID)
+}
+
+// PatchTarget applies a partial update to a scraper target. If the update
+// has a revision, it is sent as the If-Match header.
+func (s *ScraperService) PatchTarget(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+	if !id.Valid() {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   s.OpPrefix + influxdb.OpPatchTarget,
+			Msg:  "provided scraper target ID has invalid format",
+		}
+	}
+	url, err := NewURL(s.Addr, targetIDPath(id))
 	if err != nil {
 		return nil, err
 	}
 
-	octets, err := json.Marshal(update)
+	octets, err := json.Marshal(upd)
 	if err != nil {
 		return nil, err
 	}
@@ -426,6 +523,9 @@ func (s *ScraperService) UpdateTarget(ctx context.Context, update *influxdb.Scra
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if upd.Revision != nil {
+		req.Header.Set("If-Match", strconv.Quote(strconv.FormatUint(*upd.Revision, 10)))
+	}
 	SetToken(s.Token, req)
 	hc := NewClient(url.Scheme, s.InsecureSkipVerify)
 
@@ -582,6 +682,7 @@ type targetResponse struct {
 	influxdb.ScraperTarget
 	Org    string      `json:"org,omitempty"`
 	Bucket string      `json:"bucket,omitempty"`
+	Owner  string      `json:"owner,omitempty"`
 	Links  targetLinks `json:"links"`
 }
 
@@ -613,6 +714,10 @@ func (h *ScraperHandler) newTargetResponse(ctx context.Context, target influxdb.
 		},
 		ScraperTarget: target,
 	}
+	// Secrets are write only.
+	res.Password = ""
+	res.BearerToken = ""
+
 	bucket, err := h.BucketService.FindBucketByID(ctx, target.BucketID)
 	if err == nil {
 		res.Bucket = bucket.Name
@@ -631,6 +736,12 @@ func (h *ScraperHandler) newTargetResponse(ctx context.Context, target influxdb.
 		res.OrgID = influxdb.InvalidID()
 	}
 
+	if target.OwnerID.Valid() {
+		if owner, err := h.UserService.FindUserByID(ctx, target.OwnerID); err == nil {
+			res.Owner = owner.Name
+		}
+	}
+
 	return res, nil
 }
 
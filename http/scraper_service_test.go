@@ -273,7 +273,7 @@ func TestService_handleGetScraperTarget(t *testing.T) {
 		wants  wants
 	}{
 		{
-			name: "get a scraper target by id",
+			name: "get a scraper target by id without its secrets",
 			fields: fields{
 				OrganizationService: &mock.OrganizationService{
 					FindOrganizationByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
@@ -301,6 +301,8 @@ func TestService_handleGetScraperTarget(t *testing.T) {
 								URL:      "www.some.url",
 								OrgID:    platformtesting.MustIDBase16("0000000000000211"),
 								BucketID: platformtesting.MustIDBase16("0000000000000212"),
+								Username: "user",
+								Password: "secret",
 							}, nil
 						}
 						return nil, &influxdb.Error{
@@ -327,6 +329,7 @@ func TestService_handleGetScraperTarget(t *testing.T) {
                       "bucketID": "0000000000000212",
 					  "orgID": "0000000000000211",
 					  "org": "org1",
+					  "username": "user",
                       "links": {
                         "bucket": "/api/v2/buckets/0000000000000212",
                         "organization": "/api/v2/orgs/0000000000000211",
@@ -630,13 +633,15 @@ func TestService_handlePatchScraperTarget(t *testing.T) {
 	}
 
 	type args struct {
-		id     string
-		update *influxdb.ScraperTarget
+		id      string
+		ifMatch string
+		update  *influxdb.ScraperTarget
 	}
 
 	type wants struct {
 		statusCode  int
 		contentType string
+		etag        string
 		body        string
 	}
 
@@ -666,32 +671,42 @@ func TestService_handlePatchScraperTarget(t *testing.T) {
 					},
 				},
 				ScraperTargetStoreService: &mock.ScraperTargetStoreService{
-					UpdateTargetF: func(ctx context.Context, t *influxdb.ScraperTarget, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
-						if t.ID == targetOneID {
-							return t, nil
+					PatchTargetF: func(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+						if id != targetOneID {
+							return nil, &influxdb.Error{
+								Code: influxdb.ENotFound,
+								Msg:  "scraper target is not found",
+							}
+						}
+						if upd.Revision != nil {
+							return nil, influxdb.ErrScraperTargetRevisionMismatch(*upd.Revision, 3)
 						}
 
-						return nil, &influxdb.Error{
-							Code: influxdb.ENotFound,
-							Msg:  "scraper target is not found",
+						t := &influxdb.ScraperTarget{
+							ID:       targetOneID,
+							Name:     "old name",
+							OrgID:    platformtesting.MustIDBase16("0000000000000211"),
+							URL:      "www.example.url",
+							Revision: 2,
 						}
+						upd.Apply(t)
+						t.Revision++
+						return t, nil
 					},
 				},
 			},
 			args: args{
 				id: targetOneIDString,
 				update: &influxdb.ScraperTarget{
-					ID:       targetOneID,
 					Name:     "name",
 					BucketID: platformtesting.MustIDBase16("0000000000000212"),
 					Type:     influxdb.PrometheusScraperType,
-					URL:      "www.example.url",
-					OrgID:    platformtesting.MustIDBase16("0000000000000211"),
 				},
 			},
 			wants: wants{
 				statusCode:  http.StatusOK,
 				contentType: "application/json; charset=utf-8",
+				etag:        `"3"`,
 				body: fmt.Sprintf(
 					`{
 		              "id":"%s",
@@ -702,6 +717,7 @@ func TestService_handlePatchScraperTarget(t *testing.T) {
 					  "orgID":"0000000000000211",
 					  "bucket": "bucket1",
 					  "bucketID":"0000000000000212",
+					  "revision":3,
 		              "links":{
 		                "bucket": "/api/v2/buckets/0000000000000212",
 		                "organization": "/api/v2/orgs/0000000000000211",
@@ -734,7 +750,7 @@ func TestService_handlePatchScraperTarget(t *testing.T) {
 					},
 				},
 				ScraperTargetStoreService: &mock.ScraperTargetStoreService{
-					UpdateTargetF: func(ctx context.Context, upd *influxdb.ScraperTarget, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+					PatchTargetF: func(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
 						return nil, &influxdb.Error{
 							Code: influxdb.ENotFound,
 							Msg:  influxdb.ErrScraperTargetNotFound,
@@ -757,6 +773,42 @@ func TestService_handlePatchScraperTarget(t *testing.T) {
 				statusCode: http.StatusNotFound,
 			},
 		},
+		{
+			name: "scraper target changed since read",
+			fields: fields{
+				ScraperTargetStoreService: &mock.ScraperTargetStoreService{
+					PatchTargetF: func(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+						if upd.Revision == nil || *upd.Revision != 2 {
+							t.Errorf("PatchTarget() revision = %v, want 2", upd.Revision)
+						}
+						return nil, influxdb.ErrScraperTargetRevisionMismatch(2, 3)
+					},
+				},
+			},
+			args: args{
+				id:      targetOneIDString,
+				ifMatch: `"2"`,
+				update: &influxdb.ScraperTarget{
+					Name: "name",
+				},
+			},
+			wants: wants{
+				statusCode: http.StatusUnprocessableEntity,
+			},
+		},
+		{
+			name: "invalid If-Match header",
+			args: args{
+				id:      targetOneIDString,
+				ifMatch: "abc",
+				update: &influxdb.ScraperTarget{
+					Name: "name",
+				},
+			},
+			wants: wants{
+				statusCode: http.StatusBadRequest,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -778,6 +830,9 @@ func TestService_handlePatchScraperTarget(t *testing.T) {
 			}
 
 			r := httptest.NewRequest("GET", "http://any.tld", bytes.NewReader(st))
+			if tt.args.ifMatch != "" {
+				r.Header.Set("If-Match", tt.args.ifMatch)
+			}
 
 			r = r.WithContext(context.WithValue(
 				context.Background(),
@@ -803,6 +858,9 @@ func TestService_handlePatchScraperTarget(t *testing.T) {
 			if tt.wants.contentType != "" && content != tt.wants.contentType {
 				t.Errorf("%q. handlePatchScraperTarget() = %v, want %v", tt.name, content, tt.wants.contentType)
 			}
+			if etag := res.Header.Get("ETag"); etag != tt.wants.etag {
+				t.Errorf("%q. handlePatchScraperTarget() ETag = %v, want %v", tt.name, etag, tt.wants.etag)
+			}
 			if tt.wants.body != "" {
 				if eq, diff, err := jsonEqual(string(body), tt.wants.body); err != nil {
 					t.Errorf("%q, handlePatchScraperTarget(). error unmarshalling json %v", tt.name, err)
@@ -843,6 +901,7 @@ func initScraperService(f platformtesting.TargetFields, t *testing.T) (influxdb.
 	scraperBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
 	scraperBackend.ScraperStorageService = svc
 	scraperBackend.OrganizationService = tenantService
+	scraperBackend.UserService = tenantService
 	scraperBackend.BucketService = &mock.BucketService{
 		FindBucketByIDFn: func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
 			return &influxdb.Bucket{
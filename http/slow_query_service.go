@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"go.uber.org/zap"
+)
+
+const prefixDebugSlowQueries = "/api/v2/debug/slow-queries"
+
+// SlowQueryBackend is all services and associated parameters required to construct the SlowQueryHandler.
+type SlowQueryBackend struct {
+	Logger *zap.Logger
+	influxdb.HTTPErrorHandler
+
+	SlowQueryService influxdb.SlowQueryService
+}
+
+// NewSlowQueryBackend returns a new instance of SlowQueryBackend.
+func NewSlowQueryBackend(b *APIBackend) *SlowQueryBackend {
+	return &SlowQueryBackend{
+		Logger: b.Logger.With(zap.String("handler", "slow_queries")),
+
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		SlowQueryService: b.SlowQueryService,
+	}
+}
+
+// SlowQueryHandler is http handler for the slowest queries run recently.
+type SlowQueryHandler struct {
+	chi.Router
+	influxdb.HTTPErrorHandler
+	Logger *zap.Logger
+
+	SlowQueryService influxdb.SlowQueryService
+}
+
+// NewSlowQueryHandler creates a new handler at /api/v2/debug/slow-queries to
+// report and clear the slowest queries run recently.
+func NewSlowQueryHandler(b *SlowQueryBackend) *SlowQueryHandler {
+	h := &SlowQueryHandler{
+		Router:           NewBaseChiRouter(kithttp.NewAPI(kithttp.WithLog(b.Logger))),
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		Logger:           b.Logger,
+		SlowQueryService: b.SlowQueryService,
+	}
+
+	h.Get("/", h.handleGetSlowQueries)
+	h.Delete("/", h.handleDeleteSlowQueries)
+
+	return h
+}
+
+type slowQueriesResponse struct {
+	Queries []influxdb.SlowQuery `json:"queries"`
+}
+
+func (h *SlowQueryHandler) handleGetSlowQueries(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "SlowQueryHandler.handleGetSlowQueries")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	queries, err := h.SlowQueryService.SlowQueries(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, slowQueriesResponse{Queries: queries}); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *SlowQueryHandler) handleDeleteSlowQueries(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "SlowQueryHandler.handleDeleteSlowQueries")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	if err := h.SlowQueryService.ClearSlowQueries(ctx); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	h.Logger.Info("Slow queries cleared on request")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SlowQueryService connects to Influx via HTTP using tokens to report and
+// clear the slowest queries run recently.
+type SlowQueryService struct {
+	Client *httpc.Client
+}
+
+var _ influxdb.SlowQueryService = (*SlowQueryService)(nil)
+
+// SlowQueries returns the slowest queries run recently, slowest first.
+func (s *SlowQueryService) SlowQueries(ctx context.Context) ([]influxdb.SlowQuery, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp slowQueriesResponse
+	err := s.Client.
+		Get(prefixDebugSlowQueries).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Queries, nil
+}
+
+// ClearSlowQueries forgets the queries recorded so far.
+func (s *SlowQueryService) ClearSlowQueries(ctx context.Context) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	return s.Client.
+		Delete(prefixDebugSlowQueries).
+		Do(ctx)
+}
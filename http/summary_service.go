@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"go.uber.org/zap"
+)
+
+const prefixSummary = "/api/v2/summary"
+
+// SummaryBackend is all services and associated parameters required to construct the SummaryHandler.
+type SummaryBackend struct {
+	Logger *zap.Logger
+	influxdb.HTTPErrorHandler
+
+	SummaryService influxdb.SummaryService
+}
+
+// NewSummaryBackend returns a new instance of SummaryBackend.
+func NewSummaryBackend(b *APIBackend) *SummaryBackend {
+	return &SummaryBackend{
+		Logger: b.Logger.With(zap.String("handler", "summary")),
+
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		SummaryService:   b.SummaryService,
+	}
+}
+
+// SummaryHandler is http handler for the summary of the server.
+type SummaryHandler struct {
+	chi.Router
+	influxdb.HTTPErrorHandler
+	Logger *zap.Logger
+
+	SummaryService influxdb.SummaryService
+}
+
+// NewSummaryHandler creates a new handler at /api/v2/summary to report an
+// overview of the server.
+func NewSummaryHandler(b *SummaryBackend) *SummaryHandler {
+	h := &SummaryHandler{
+		Router:           NewBaseChiRouter(kithttp.NewAPI(kithttp.WithLog(b.Logger))),
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		Logger:           b.Logger,
+		SummaryService:   b.SummaryService,
+	}
+
+	h.Get("/", h.handleGetSummary)
+
+	return h
+}
+
+func (h *SummaryHandler) handleGetSummary(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "SummaryHandler.handleGetSummary")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	summary, err := h.SummaryService.ServerSummary(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, summary); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// SummaryService connects to Influx via HTTP using tokens to report the
+// summary of the server.
+type SummaryService struct {
+	Client *httpc.Client
+}
+
+var _ influxdb.SummaryService = (*SummaryService)(nil)
+
+// ServerSummary returns the summary of the server.
+func (s *SummaryService) ServerSummary(ctx context.Context) (*influxdb.ServerSummary, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var summary influxdb.ServerSummary
+	err := s.Client.
+		Get(prefixSummary).
+		DecodeJSON(&summary).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
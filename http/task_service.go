@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/pkg/httpc"
+	"github.com/influxdata/influxdb/v2/task/backend/scheduler"
 	"github.com/influxdata/influxdb/v2/task/options"
 	"go.uber.org/zap"
 )
@@ -73,6 +74,7 @@ const (
 	tasksIDPath            = "/api/v2/tasks/:id"
 	tasksIDLogsPath        = "/api/v2/tasks/:id/logs"
 	tasksIDMembersPath     = "/api/v2/tasks/:id/members"
+	tasksIDNextRunsPath    = "/api/v2/tasks/:id/next-runs"
 	tasksIDMembersIDPath   = "/api/v2/tasks/:id/members/:userID"
 	tasksIDOwnersPath      = "/api/v2/tasks/:id/owners"
 	tasksIDOwnersIDPath    = "/api/v2/tasks/:id/owners/:userID"
@@ -108,6 +110,7 @@ func NewTaskHandler(log *zap.Logger, b *TaskBackend) *TaskHandler {
 	h.HandlerFunc("DELETE", tasksIDPath, h.handleDeleteTask)
 
 	h.HandlerFunc("GET", tasksIDLogsPath, h.handleGetLogs)
+	h.HandlerFunc("GET", tasksIDNextRunsPath, h.handleGetNextRuns)
 	h.HandlerFunc("GET", tasksIDRunsIDLogsPath, h.handleGetLogs)
 
 	memberBackend := MemberBackend{
@@ -733,6 +736,121 @@ func decodeGetTaskRequest(ctx context.Context, r *http.Request) (*getTaskRequest
 	return req, nil
 }
 
+// maxNextRuns is the most runs the next-runs endpoint previews.
+const maxNextRuns = 100
+
+type nextRun struct {
+	ScheduledFor time.Time `json:"scheduledFor"`
+	RunAt        time.Time `json:"runAt"`
+}
+
+type nextRunsResponse struct {
+	Links map[string]string `json:"links"`
+	Runs  []nextRun         `json:"runs"`
+}
+
+// handleGetNextRuns previews the runs a task will next be scheduled for.
+func (h *TaskHandler) handleGetNextRuns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, err := decodeGetNextRunsRequest(ctx, r)
+	if err != nil {
+		err = &influxdb.Error{
+			Err:  err,
+			Code: influxdb.EInvalid,
+			Msg:  "failed to decode request",
+		}
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	task, err := h.TaskService.FindTaskByID(ctx, req.TaskID)
+	if err != nil {
+		err = &influxdb.Error{
+			Err:  err,
+			Code: influxdb.ENotFound,
+			Msg:  "failed to find task",
+		}
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	runs, err := nextRuns(task, req.Count)
+	if err != nil {
+		err = &influxdb.Error{
+			Err:  err,
+			Code: influxdb.EInvalid,
+			Msg:  "failed to compute the schedule of the task",
+		}
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	resp := nextRunsResponse{
+		Links: map[string]string{
+			"self": path.Join(taskIDPath(task.ID), "next-runs"),
+			"task": taskIDPath(task.ID),
+		},
+		Runs: runs,
+	}
+	if err := encodeResponse(ctx, w, http.StatusOK, resp); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
+// nextRuns returns the next count runs of the task, following on from its
+// latest scheduled or completed run as the task scheduler does.
+func nextRuns(task *influxdb.Task, count int) ([]nextRun, error) {
+	ts := task.LatestScheduled
+	if ts.IsZero() || ts.Before(task.LatestCompleted) {
+		ts = task.LatestCompleted
+	}
+
+	sch, ts, err := scheduler.NewScheduleIn(task.EffectiveCron(), task.Timezone, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]nextRun, 0, count)
+	for len(runs) < count {
+		ts, err = sch.Next(ts)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, nextRun{ScheduledFor: ts, RunAt: ts.Add(task.Offset)})
+	}
+	return runs, nil
+}
+
+type getNextRunsRequest struct {
+	TaskID influxdb.ID
+	Count  int
+}
+
+func decodeGetNextRunsRequest(ctx context.Context, r *http.Request) (*getNextRunsRequest, error) {
+	taskReq, err := decodeGetTaskRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &getNextRunsRequest{
+		TaskID: taskReq.TaskID,
+		Count:  5,
+	}
+	if count := r.URL.Query().Get("count"); count != "" {
+		i, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, err
+		}
+		if i < 1 || i > maxNextRuns {
+			return nil, fmt.Errorf("count must be between 1 and %d", maxNextRuns)
+		}
+		req.Count = i
+	}
+
+	return req, nil
+}
+
 func (h *TaskHandler) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	req, err := decodeUpdateTaskRequest(ctx, r)
@@ -898,7 +1016,7 @@ func (h *TaskHandler) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 		ctx = pcontext.SetAuthorizer(ctx, authz)
 	}
 
-	logs, _, err := h.TaskService.FindLogs(ctx, req.filter)
+	logs, err := h.waitLogs(ctx, req)
 	if err != nil {
 		err := &influxdb.Error{
 			Err: err,
@@ -917,8 +1035,45 @@ func (h *TaskHandler) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// waitLogs finds the logs matching the request. When the request waits for
+// the logs of a run, it polls the run until it writes logs, finishes or the
+// wait expires, whichever comes first.
+func (h *TaskHandler) waitLogs(ctx context.Context, req *getLogsRequest) ([]*influxdb.Log, error) {
+	deadline := time.Now().Add(req.wait)
+	for {
+		logs, _, err := h.TaskService.FindLogs(ctx, req.filter)
+		if err != nil || len(logs) > 0 || !time.Now().Before(deadline) {
+			return logs, err
+		}
+
+		run, err := h.TaskService.FindRunByID(ctx, req.filter.Task, *req.filter.Run)
+		if err != nil {
+			return nil, err
+		}
+		if influxdb.RunFinished(run.Status) {
+			// The run may have written its last logs after they were found.
+			logs, _, err := h.TaskService.FindLogs(ctx, req.filter)
+			return logs, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return logs, nil
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+const (
+	// maxLogsWait is the longest a request waits for new logs of a run.
+	maxLogsWait = time.Minute
+	// logsPollInterval is how often a waiting request looks for new logs.
+	logsPollInterval = 500 * time.Millisecond
+)
+
 type getLogsRequest struct {
 	filter influxdb.LogFilter
+	wait   time.Duration
 }
 
 type getLogsResponse struct {
@@ -950,6 +1105,40 @@ func decodeGetLogsRequest(ctx context.Context, r *http.Request) (*getLogsRequest
 		req.filter.Run = id
 	}
 
+	qp := r.URL.Query()
+	if since := qp.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "since must be an RFC3339 time",
+				Err:  err,
+			}
+		}
+		req.filter.Since = t
+	}
+
+	if wait := qp.Get("wait"); wait != "" {
+		if req.filter.Run == nil {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "wait is only supported for the logs of a run",
+			}
+		}
+		d, err := time.ParseDuration(wait)
+		if err != nil || d < 0 {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "wait must be a non-negative duration",
+				Err:  err,
+			}
+		}
+		if d > maxLogsWait {
+			d = maxLogsWait
+		}
+		req.wait = d
+	}
+
 	return req, nil
 }
 
@@ -1574,6 +1763,23 @@ func (t TaskService) FindLogs(ctx context.Context, filter influxdb.LogFilter) ([
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	return t.findLogs(ctx, filter, 0)
+}
+
+// WaitLogs returns the logs of a run like FindLogs, but if there are none yet
+// and the run has not finished, waits up to wait for the run to write some.
+// The server caps the wait at a minute.
+func (t TaskService) WaitLogs(ctx context.Context, filter influxdb.LogFilter, wait time.Duration) ([]*influxdb.Log, int, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if filter.Run == nil {
+		return nil, 0, errors.New("run ID required")
+	}
+	return t.findLogs(ctx, filter, wait)
+}
+
+func (t TaskService) findLogs(ctx context.Context, filter influxdb.LogFilter, wait time.Duration) ([]*influxdb.Log, int, error) {
 	if !filter.Task.Valid() {
 		return nil, 0, errors.New("task ID required")
 	}
@@ -1585,9 +1791,18 @@ func (t TaskService) FindLogs(ctx context.Context, filter influxdb.LogFilter) ([
 		urlPath = path.Join(taskIDRunIDPath(filter.Task, *filter.Run), "logs")
 	}
 
+	var params [][2]string
+	if !filter.Since.IsZero() {
+		params = append(params, [2]string{"since", filter.Since.UTC().Format(time.RFC3339Nano)})
+	}
+	if wait > 0 {
+		params = append(params, [2]string{"wait", wait.String()})
+	}
+
 	var logs getLogsResponse
 	err := t.Client.
 		Get(urlPath).
+		QueryParams(params...).
 		DecodeJSON(&logs).
 		Do(ctx)
 
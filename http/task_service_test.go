@@ -700,6 +700,81 @@ func TestTaskHandler_handleGetRun(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_handleGetNextRuns(t *testing.T) {
+	latestScheduled, _ := time.Parse(time.RFC3339, "2021-03-26T12:00:00Z")
+	taskService := &mock.TaskService{
+		FindTaskByIDFn: func(ctx context.Context, id influxdb.ID) (*influxdb.Task, error) {
+			return &influxdb.Task{
+				ID:              id,
+				Cron:            "30 2 * * *",
+				Timezone:        "Europe/Berlin",
+				Offset:          time.Minute,
+				LatestScheduled: latestScheduled,
+				LatestCompleted: latestScheduled,
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		statusCode int
+		body       string
+	}{
+		{
+			name:       "previews runs skipping times skipped by daylight saving",
+			query:      "?count=2",
+			statusCode: http.StatusOK,
+			body: `
+{
+  "links": {
+    "self": "/api/v2/tasks/0000000000000001/next-runs",
+    "task": "/api/v2/tasks/0000000000000001"
+  },
+  "runs": [
+    {"scheduledFor": "2021-03-27T01:30:00Z", "runAt": "2021-03-27T01:31:00Z"},
+    {"scheduledFor": "2021-03-29T00:30:00Z", "runAt": "2021-03-29T00:31:00Z"}
+  ]
+}`,
+		},
+		{
+			name:       "count out of range",
+			query:      "?count=0",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://any.url"+tt.query, nil)
+			r = r.WithContext(context.WithValue(
+				context.Background(),
+				httprouter.ParamsKey,
+				httprouter.Params{{Key: "id", Value: influxdb.ID(1).String()}}))
+			w := httptest.NewRecorder()
+			taskBackend := NewMockTaskBackend(t)
+			taskBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+			taskBackend.TaskService = taskService
+			h := NewTaskHandler(zaptest.NewLogger(t), taskBackend)
+			h.handleGetNextRuns(w, r)
+
+			res := w.Result()
+			body, _ := ioutil.ReadAll(res.Body)
+
+			if res.StatusCode != tt.statusCode {
+				t.Errorf("%q. handleGetNextRuns() = %v, want %v", tt.name, res.StatusCode, tt.statusCode)
+			}
+			if tt.body != "" {
+				if eq, diff, err := jsonEqual(string(body), tt.body); err != nil {
+					t.Errorf("%q, handleGetNextRuns(). error unmarshalling json %v", tt.name, err)
+				} else if !eq {
+					t.Errorf("%q. handleGetNextRuns() = ***%s***", tt.name, diff)
+				}
+			}
+		})
+	}
+}
+
 func TestTaskHandler_handleGetRuns(t *testing.T) {
 	type fields struct {
 		taskService influxdb.TaskService
@@ -818,6 +893,77 @@ func TestTaskHandler_handleGetRuns(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_WaitLogs(t *testing.T) {
+	const (
+		taskID = influxdb.ID(1)
+		runID  = influxdb.ID(2)
+	)
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	url := fmt.Sprintf("http://localhost:8086/api/v2/tasks/%s/runs/%s/logs?since=%s&wait=2m", taskID, runID, since.Format(time.RFC3339))
+	ctx := context.WithValue(context.Background(), httprouter.ParamsKey, httprouter.Params{
+		{Key: "id", Value: taskID.String()},
+		{Key: "rid", Value: runID.String()},
+	})
+	req, err := decodeGetLogsRequest(ctx, httptest.NewRequest("GET", url, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !req.filter.Since.Equal(since) {
+		t.Fatalf("expected since %v, got %v", since, req.filter.Since)
+	}
+	if req.wait != maxLogsWait {
+		t.Fatalf("expected wait to be capped at %v, got %v", maxLogsWait, req.wait)
+	}
+
+	// Waiting is only supported for a single run.
+	ctx = context.WithValue(context.Background(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: taskID.String()}})
+	if _, err := decodeGetLogsRequest(ctx, httptest.NewRequest("GET", "http://localhost:8086/api/v2/tasks/x/logs?wait=1s", nil)); err == nil {
+		t.Fatal("expected error waiting for the logs of all runs")
+	}
+
+	var finds, polls int
+	h := &TaskHandler{TaskService: &mock.TaskService{
+		FindLogsFn: func(_ context.Context, f influxdb.LogFilter) ([]*influxdb.Log, int, error) {
+			finds++
+			if !f.Since.Equal(since) {
+				t.Fatalf("expected since %v, got %v", since, f.Since)
+			}
+			if polls < 2 {
+				return nil, 0, nil
+			}
+			return []*influxdb.Log{{RunID: runID, Message: "done"}}, 1, nil
+		},
+		FindRunByIDFn: func(_ context.Context, tid, rid influxdb.ID) (*influxdb.Run, error) {
+			polls++
+			status := influxdb.RunStarted
+			if polls == 2 {
+				status = influxdb.RunSuccess
+			}
+			return &influxdb.Run{ID: rid, TaskID: tid, Status: status.String()}, nil
+		},
+	}}
+
+	req.wait = 10 * time.Second
+	logs, err := h.waitLogs(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || finds != 3 || polls != 2 {
+		t.Fatalf("expected the logs found after the run finished, got %d logs after %d finds and %d polls", len(logs), finds, polls)
+	}
+
+	// Without a wait the logs are returned right away.
+	finds, polls = 0, 0
+	req.wait = 0
+	if logs, err = h.waitLogs(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 0 || finds != 1 || polls != 0 {
+		t.Fatalf("expected no logs without polling, got %d logs after %d finds and %d polls", len(logs), finds, polls)
+	}
+}
+
 func TestTaskHandler_NotFoundStatus(t *testing.T) {
 	// Ensure that the HTTP handlers return 404s for missing resources, and OKs for matching.
 
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixUsage = "/api/v2/usage"
+
+// UsageBackend is all services and associated parameters required to construct the UsageHandler.
+type UsageBackend struct {
+	Logger *zap.Logger
+	influxdb.HTTPErrorHandler
+
+	UsageReportService  influxdb.UsageReportService
+	OrganizationService influxdb.OrganizationService
+}
+
+// NewUsageBackend returns a new instance of UsageBackend.
+func NewUsageBackend(b *APIBackend) *UsageBackend {
+	return &UsageBackend{
+		Logger: b.Logger.With(zap.String("handler", "usage")),
+
+		HTTPErrorHandler:    b.HTTPErrorHandler,
+		UsageReportService:  b.UsageReportService,
+		OrganizationService: b.OrganizationService,
+	}
+}
+
+// UsageHandler is http handler for usage reports.
+type UsageHandler struct {
+	chi.Router
+	influxdb.HTTPErrorHandler
+	Logger *zap.Logger
+
+	UsageReportService  influxdb.UsageReportService
+	OrganizationService influxdb.OrganizationService
+}
+
+// NewUsageHandler creates a new handler at /api/v2/usage to report the usage of organizations and buckets.
+func NewUsageHandler(b *UsageBackend) *UsageHandler {
+	h := &UsageHandler{
+		Router:              NewBaseChiRouter(kithttp.NewAPI(kithttp.WithLog(b.Logger))),
+		HTTPErrorHandler:    b.HTTPErrorHandler,
+		Logger:              b.Logger,
+		UsageReportService:  b.UsageReportService,
+		OrganizationService: b.OrganizationService,
+	}
+
+	h.Get("/", h.handleGetUsage)
+
+	return h
+}
+
+func (h *UsageHandler) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "UsageHandler.handleGetUsage")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	filter, err := h.decodeUsageFilter(ctx, r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	report, err := h.UsageReportService.UsageReport(ctx, filter)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, report); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// decodeUsageFilter decodes the orgID, org, bucketID, start and stop query
+// parameters. A start without a stop counts writes up to now.
+func (h *UsageHandler) decodeUsageFilter(ctx context.Context, r *http.Request) (influxdb.UsageFilter, error) {
+	var filter influxdb.UsageFilter
+	q := r.URL.Query()
+
+	if orgID := q.Get("orgID"); orgID != "" {
+		id, err := influxdb.IDFromString(orgID)
+		if err != nil {
+			return filter, &influxdb.Error{Code: influxdb.EInvalid, Msg: "invalid orgID", Err: err}
+		}
+		filter.OrgID = id
+	} else if org := q.Get("org"); org != "" {
+		o, err := h.OrganizationService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &org})
+		if err != nil {
+			return filter, err
+		}
+		filter.OrgID = &o.ID
+	}
+
+	if bucketID := q.Get("bucketID"); bucketID != "" {
+		id, err := influxdb.IDFromString(bucketID)
+		if err != nil {
+			return filter, &influxdb.Error{Code: influxdb.EInvalid, Msg: "invalid bucketID", Err: err}
+		}
+		filter.BucketID = id
+	}
+
+	if start := q.Get("start"); start != "" {
+		var rng influxdb.Timespan
+		var err error
+		if rng.Start, err = time.Parse(time.RFC3339, start); err != nil {
+			return filter, &influxdb.Error{Code: influxdb.EInvalid, Msg: "invalid start", Err: err}
+		}
+		if stop := q.Get("stop"); stop != "" {
+			if rng.Stop, err = time.Parse(time.RFC3339, stop); err != nil {
+				return filter, &influxdb.Error{Code: influxdb.EInvalid, Msg: "invalid stop", Err: err}
+			}
+		}
+		filter.Range = &rng
+	}
+
+	return filter, nil
+}
+
+// UsageService is the client implementation of influxdb.UsageReportService.
+type UsageService struct {
+	Addr               string
+	Token              string
+	InsecureSkipVerify bool
+}
+
+var _ influxdb.UsageReportService = (*UsageService)(nil)
+
+// UsageReport returns the usage of the organizations and buckets matching the filter.
+func (s *UsageService) UsageReport(ctx context.Context, filter influxdb.UsageFilter) (*influxdb.UsageReport, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	u, err := NewURL(s.Addr, prefixUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	if filter.OrgID != nil {
+		q.Set("orgID", filter.OrgID.String())
+	}
+	if filter.BucketID != nil {
+		q.Set("bucketID", filter.BucketID.String())
+	}
+	if filter.Range != nil {
+		q.Set("start", filter.Range.Start.UTC().Format(time.RFC3339))
+		if !filter.Range.Stop.IsZero() {
+			q.Set("stop", filter.Range.Stop.UTC().Format(time.RFC3339))
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	SetToken(s.Token, req)
+	req = req.WithContext(ctx)
+
+	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckError(resp); err != nil {
+		return nil, err
+	}
+
+	var report influxdb.UsageReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
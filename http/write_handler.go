@@ -1,11 +1,19 @@
 package http
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
@@ -16,6 +24,7 @@ import (
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
@@ -55,6 +64,10 @@ type WriteHandler struct {
 	router            *httprouter.Router
 	log               *zap.Logger
 	maxBatchSizeBytes int64
+	idempotency       *IdempotencyCache
+	ingestQuota       influxdb.IngestQuotaEnforcer
+	responseStats     bool
+	nonFiniteFields   *prometheus.CounterVec
 	// parserOptions     []models.ParserOption
 }
 
@@ -69,6 +82,38 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithIdempotencyCache enables deduplication of writes carrying an
+// Idempotency-Key header using the provided cache.
+func WithIdempotencyCache(c *IdempotencyCache) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.idempotency = c
+	}
+}
+
+// WithIngestQuota enforces the ingest quotas of organizations and records the
+// bytes they write.
+func WithIngestQuota(q influxdb.IngestQuotaEnforcer) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.ingestQuota = q
+	}
+}
+
+// WithResponseStats reports the number of points written in a response
+// header.
+func WithResponseStats(enabled bool) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.responseStats = enabled
+	}
+}
+
+// WithNonFiniteFieldsCounter counts the NaN and infinite float field values
+// skipped by writes with c, if not nil.
+func WithNonFiniteFieldsCounter(c *prometheus.CounterVec) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.nonFiniteFields = c
+	}
+}
+
 //func WithParserOptions(opts ...models.ParserOption) WriteHandlerOption {
 //	return func(w *WriteHandler) {
 //		w.parserOptions = opts
@@ -175,28 +220,149 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
+	if bucket.Archived {
+		h.HandleHTTPError(ctx, influxdb.ErrBucketArchived(bucket), sw)
+		return
+	}
+
+	if h.ingestQuota != nil {
+		if err := h.ingestQuota.CheckIngest(ctx, org.ID); err != nil {
+			h.handleIngestQuotaError(ctx, err, sw)
+			return
+		}
+	}
+
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" || h.idempotency == nil {
+		n, err := h.writePoints(ctx, org.ID, bucket, req, &requestBytes)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
+		h.setPointsWritten(sw, n)
+		sw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := validateIdempotencyKey(key); err != nil {
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+
+	// Buffer the body so it can be hashed before anything is written.
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+	sum := sha256.Sum256(body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	key = idempotencyScopedKey(org.ID, bucket.ID, key)
+	status, replay, err := h.idempotency.Begin(ctx, key, hex.EncodeToString(sum[:]))
+	if err != nil {
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+	if replay {
+		sw.Header().Set(IdempotentReplayHeader, "true")
+		sw.WriteHeader(status)
+		return
+	}
+
+	n, err := h.writePoints(ctx, org.ID, bucket, req, &requestBytes)
+	if err != nil {
+		h.idempotency.Abort(key)
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+	h.idempotency.Finish(ctx, key, http.StatusNoContent)
+
+	h.setPointsWritten(sw, n)
+	sw.WriteHeader(http.StatusNoContent)
+}
+
+// setPointsWritten reports the number of points written in a response
+// header if response statistics are enabled.
+func (h *WriteHandler) setPointsWritten(w http.ResponseWriter, n int) {
+	if h.responseStats {
+		w.Header().Set(kithttp.PointsWrittenHeader, strconv.Itoa(n))
+	}
+}
 
+// writePoints parses the request body and writes the points to the bucket,
+// returning the number of points written. NaN and infinite float field values
+// are handled as the bucket says, and duplicate tags and fields as the request
+// says.
+func (h *WriteHandler) writePoints(ctx context.Context, orgID influxdb.ID, bucket *influxdb.Bucket, req *writeRequest, requestBytes *int) (int, error) {
 	// TODO: Backport?
 	//opts := append([]models.ParserOption{}, h.parserOptions...)
 	//opts = append(opts, models.WithParserPrecision(req.Precision))
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, org.ID, bucket.ID, req.Body)
+	parser := &points.Parser{Precision: req.Precision, NaNHandling: bucket.NaNHandling, Duplicates: req.Duplicates}
+	parsed, err := parser.Parse(ctx, orgID, bucket.ID, req.Body)
 	if err != nil {
-		h.HandleHTTPError(ctx, err, sw)
-		return
+		return 0, err
 	}
-	requestBytes = parsed.RawSize
+	*requestBytes = parsed.RawSize
 
-	if err := h.PointsWriter.WritePoints(ctx, org.ID, bucket.ID, parsed.Points); err != nil {
-		h.HandleHTTPError(ctx, &influxdb.Error{
+	if err := h.PointsWriter.WritePoints(ctx, orgID, bucket.ID, parsed.Points); err != nil {
+		return 0, &influxdb.Error{
 			Code: influxdb.EInternal,
 			Op:   opWriteHandler,
 			Msg:  "unexpected error writing points to database",
 			Err:  err,
-		}, sw)
+		}
+	}
+
+	if h.ingestQuota != nil {
+		h.ingestQuota.RecordIngest(ctx, orgID, int64(parsed.RawSize))
+	}
+
+	if len(parsed.NonFinite) > 0 {
+		if bucket.NaNHandling == influxdb.NaNHandlingReject {
+			return len(parsed.Points), points.ErrNonFiniteRejected(parsed.NonFinite)
+		}
+		if h.nonFiniteFields != nil {
+			h.nonFiniteFields.WithLabelValues(bucket.ID.String(), string(bucket.NaNHandling)).Add(float64(len(parsed.NonFinite)))
+		}
+	}
+	if len(parsed.Duplicates) > 0 {
+		return len(parsed.Points), points.ErrDuplicatesRejected(parsed.Duplicates)
+	}
+	return len(parsed.Points), nil
+}
+
+// handleIngestQuotaError responds to a write rejected by an ingest quota,
+// adding the quota details to the error and a Retry-After header set to when
+// the quota resets.
+func (h *WriteHandler) handleIngestQuotaError(ctx context.Context, err error, w http.ResponseWriter) {
+	ierr, ok := err.(*influxdb.Error)
+	if !ok {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	exceeded, ok := ierr.Err.(*influxdb.IngestQuotaExceeded)
+	if !ok {
+		h.HandleHTTPError(ctx, err, w)
 		return
 	}
 
-	sw.WriteHeader(http.StatusNoContent)
+	if retry := time.Until(exceeded.ResetAt); retry > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	}
+	w.Header().Set(kithttp.PlatformErrorCodeHeader, ierr.Code)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(kithttp.ErrorCodeToStatusCode(ctx, ierr.Code))
+	b, _ := json.Marshal(struct {
+		Code          string                        `json:"code"`
+		Message       string                        `json:"message"`
+		QuotaExceeded *influxdb.IngestQuotaExceeded `json:"quotaExceeded"`
+	}{
+		Code:          ierr.Code,
+		Message:       ierr.Error(),
+		QuotaExceeded: exceeded,
+	})
+	_, _ = w.Write(b)
 }
 
 // checkBucketWritePermissions checks an Authorizer for write permissions to a
@@ -225,10 +391,11 @@ func checkBucketWritePermissions(auth influxdb.Authorizer, orgID, bucketID influ
 // writeRequest is a request object holding information about a batch of points
 // to be written to a Bucket.
 type writeRequest struct {
-	Org       string
-	Bucket    string
-	Precision string
-	Body      io.ReadCloser
+	Org        string
+	Bucket     string
+	Precision  string
+	Duplicates models.DuplicateAction
+	Body       io.ReadCloser
 }
 
 // decodeWriteRequest extracts information from an http.Request object to
@@ -248,6 +415,15 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 		}
 	}
 
+	duplicates, err := models.ParseDuplicateAction(qp.Get("duplicates"))
+	if err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   "http/newWriteRequest",
+			Msg:  err.Error(),
+		}
+	}
+
 	bucket := qp.Get("bucket")
 	if bucket == "" {
 		return nil, &influxdb.Error{
@@ -264,10 +440,11 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 	}
 
 	return &writeRequest{
-		Bucket:    qp.Get("bucket"),
-		Org:       qp.Get("org"),
-		Precision: precision,
-		Body:      body,
+		Bucket:     qp.Get("bucket"),
+		Org:        qp.Get("org"),
+		Precision:  precision,
+		Duplicates: duplicates,
+		Body:       body,
 	}, nil
 }
 
@@ -277,6 +454,10 @@ type WriteService struct {
 	Token              string
 	Precision          string
 	InsecureSkipVerify bool
+	// Duplicates is what the server does with lines holding a tag or a field
+	// key more than once: reject, first or last. The server default applies
+	// if it is empty.
+	Duplicates string
 }
 
 var _ influxdb.WriteService = (*WriteService)(nil)
@@ -328,6 +509,9 @@ func (s *WriteService) Write(ctx context.Context, orgID, bucketID influxdb.ID, r
 	params.Set("org", string(org))
 	params.Set("bucket", string(bucket))
 	params.Set("precision", string(precision))
+	if s.Duplicates != "" {
+		params.Set("duplicates", s.Duplicates)
+	}
 	req.URL.RawQuery = params.Encode()
 
 	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
@@ -394,6 +578,9 @@ func (s *WriteService) WriteTo(ctx context.Context, filter influxdb.BucketFilter
 		params[key] = param
 	}
 	params.Set("precision", string(precision))
+	if s.Duplicates != "" {
+		params.Set("duplicates", s.Duplicates)
+	}
 	req.URL.RawQuery = params.Encode()
 
 	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
@@ -8,15 +8,23 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/http/points"
+	"github.com/influxdata/influxdb/v2/inmem"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -142,6 +150,28 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 				body: `{"code":"internal error","message":"unexpected error writing points to database: error"}`,
 			},
 		},
+		{
+			name: "archived bucket returns 403 error",
+			request: request{
+				org:    "043e0780ee2b1000",
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1 f1=1",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org: testOrg("043e0780ee2b1000"),
+				bucket: &influxdb.Bucket{
+					ID:       influxtesting.MustIDBase16("04504b356e23b000"),
+					OrgID:    influxtesting.MustIDBase16("043e0780ee2b1000"),
+					Name:     "history",
+					Archived: true,
+				},
+			},
+			wants: wants{
+				code: 403,
+				body: `{"code":"forbidden","message":"bucket \"history\" is archived; it must be unarchived before writing or deleting data"}`,
+			},
+		},
 		{
 			name: "empty request body returns 400 error",
 			request: request{
@@ -339,6 +369,326 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 	}
 }
 
+func TestWriteHandler_NaNHandling(t *testing.T) {
+	const org, bucket = "043e0780ee2b1000", "04504b356e23b000"
+	const body = "m1 f1=1,f2=NaN 1\nm1 f1=2 2\nm1 f2=1e400 3"
+
+	write := func(t *testing.T, handling influxdb.NaNHandling, nonFinite *prometheus.CounterVec) (*httptest.ResponseRecorder, []string) {
+		t.Helper()
+		orgs := mock.NewOrganizationService()
+		orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+			return testOrg(org), nil
+		}
+		buckets := mock.NewBucketService()
+		buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			b := testBucket(org, bucket)
+			b.NaNHandling = handling
+			return b, nil
+		}
+		var written []string
+		pointsWriter := &mock.PointsWriter{
+			WritePointsFn: func(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point) error {
+				for _, p := range points {
+					written = append(written, p.String())
+				}
+				return nil
+			},
+		}
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			Logger:              zaptest.NewLogger(t),
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        pointsWriter,
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithNonFiniteFieldsCounter(nonFinite))
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(org, bucket))
+
+		r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org="+org+"&bucket="+bucket, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w, written
+	}
+
+	t.Run("default fails the write", func(t *testing.T) {
+		w, written := write(t, influxdb.NaNHandlingDefault, nil)
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected status code: got %d want %d", got, want)
+		}
+		if len(written) != 0 {
+			t.Errorf("unexpected points written: %v", written)
+		}
+	})
+
+	t.Run("reject writes the other lines", func(t *testing.T) {
+		w, written := write(t, influxdb.NaNHandlingReject, nil)
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected status code: got %d want %d", got, want)
+		}
+		want := `{"code":"invalid","message":"partial write: lines with NaN or Inf float field values were rejected: line 1: NaN is an unsupported value for field f2; line 3: 1e400 is an unsupported value for field f2 dropped=2"}`
+		if got := w.Body.String(); got != want {
+			t.Errorf("unexpected body: got %s want %s", got, want)
+		}
+		if want := []string{"m1 f1=2 2"}; !reflect.DeepEqual(written, want) {
+			t.Errorf("unexpected points written: got %v want %v", written, want)
+		}
+	})
+
+	for _, handling := range []influxdb.NaNHandling{influxdb.NaNHandlingDrop, influxdb.NaNHandlingNull} {
+		t.Run(string(handling)+" skips the fields", func(t *testing.T) {
+			nonFinite := points.NewNonFiniteFieldsCounter()
+			w, written := write(t, handling, nonFinite)
+			if got, want := w.Code, http.StatusNoContent; got != want {
+				t.Errorf("unexpected status code: got %d want %d", got, want)
+			}
+			if want := []string{"m1 f1=1 1", "m1 f1=2 2"}; !reflect.DeepEqual(written, want) {
+				t.Errorf("unexpected points written: got %v want %v", written, want)
+			}
+			if got := testutil.ToFloat64(nonFinite.WithLabelValues(bucket, string(handling))); got != 2 {
+				t.Errorf("unexpected count of skipped fields: got %v want 2", got)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_Duplicates(t *testing.T) {
+	const org, bucket = "043e0780ee2b1000", "04504b356e23b000"
+	const body = "m1,t=a,t=b f1=1 1\nm1,t=a f1=2,f1=3 2\nm1,t=c f1=4 3"
+
+	write := func(t *testing.T, duplicates string) (*httptest.ResponseRecorder, []string) {
+		t.Helper()
+		orgs := mock.NewOrganizationService()
+		orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+			return testOrg(org), nil
+		}
+		buckets := mock.NewBucketService()
+		buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			return testBucket(org, bucket), nil
+		}
+		var written []string
+		pointsWriter := &mock.PointsWriter{
+			WritePointsFn: func(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point) error {
+				for _, p := range points {
+					written = append(written, p.String())
+				}
+				return nil
+			},
+		}
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			Logger:              zaptest.NewLogger(t),
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        pointsWriter,
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(org, bucket))
+
+		u := "http://localhost:8086/api/v2/write?org=" + org + "&bucket=" + bucket
+		if duplicates != "" {
+			u += "&duplicates=" + duplicates
+		}
+		r := httptest.NewRequest("POST", u, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w, written
+	}
+
+	t.Run("default fails the write", func(t *testing.T) {
+		w, written := write(t, "")
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected status code: got %d want %d", got, want)
+		}
+		if len(written) != 0 {
+			t.Errorf("unexpected points written: %v", written)
+		}
+	})
+
+	t.Run("reject writes the other lines", func(t *testing.T) {
+		w, written := write(t, "reject")
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected status code: got %d want %d", got, want)
+		}
+		want := `{"code":"invalid","message":"partial write: lines with duplicate tags or fields were rejected: line 1: duplicate tag t; line 2: duplicate field f1 dropped=2"}`
+		if got := w.Body.String(); got != want {
+			t.Errorf("unexpected body: got %s want %s", got, want)
+		}
+		if want := []string{"m1,t=c f1=4 3"}; !reflect.DeepEqual(written, want) {
+			t.Errorf("unexpected points written: got %v want %v", written, want)
+		}
+	})
+
+	for duplicates, want := range map[string][]string{
+		"first": {"m1,t=a f1=1 1", "m1,t=a f1=2 2", "m1,t=c f1=4 3"},
+		"last":  {"m1,t=b f1=1 1", "m1,t=a f1=3 2", "m1,t=c f1=4 3"},
+	} {
+		duplicates, want := duplicates, want
+		t.Run(duplicates+" keeps a value", func(t *testing.T) {
+			w, written := write(t, duplicates)
+			if got, want := w.Code, http.StatusNoContent; got != want {
+				t.Errorf("unexpected status code: got %d want %d", got, want)
+			}
+			if !reflect.DeepEqual(written, want) {
+				t.Errorf("unexpected points written: got %v want %v", written, want)
+			}
+		})
+	}
+
+	t.Run("rejects an invalid value", func(t *testing.T) {
+		w, written := write(t, "newest")
+		if got, want := w.Code, http.StatusBadRequest; got != want {
+			t.Errorf("unexpected status code: got %d want %d", got, want)
+		}
+		if len(written) != 0 {
+			t.Errorf("unexpected points written: %v", written)
+		}
+	})
+}
+
+func TestWriteHandler_Idempotency(t *testing.T) {
+	const org, bucket = "043e0780ee2b1000", "04504b356e23b000"
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(org), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(org, bucket), nil
+	}
+
+	var writes int
+	pointsWriter := &mock.PointsWriter{
+		WritePointsFn: func(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point) error {
+			writes++
+			return nil
+		},
+	}
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		Logger:              zaptest.NewLogger(t),
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        pointsWriter,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	cache := NewIdempotencyCache(zaptest.NewLogger(t), nil, 2, time.Hour)
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithIdempotencyCache(cache))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(org, bucket))
+
+	write := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org="+org+"&bucket="+bucket, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := write("batch-1", "m1,t1=v1 f1=1")
+	if got, want := w.Code, http.StatusNoContent; got != want {
+		t.Fatalf("unexpected status code: got %d want %d", got, want)
+	}
+	if w.Header().Get(IdempotentReplayHeader) != "" {
+		t.Errorf("first write must not be marked as a replay")
+	}
+
+	// A retried batch is replayed without writing again.
+	w = write("batch-1", "m1,t1=v1 f1=1")
+	if got, want := w.Code, http.StatusNoContent; got != want {
+		t.Errorf("unexpected status code: got %d want %d", got, want)
+	}
+	if got, want := w.Header().Get(IdempotentReplayHeader), "true"; got != want {
+		t.Errorf("unexpected %s header: got %q want %q", IdempotentReplayHeader, got, want)
+	}
+	if writes != 1 {
+		t.Errorf("expected 1 write, got %d", writes)
+	}
+
+	// Reusing the key with a different body is a conflict.
+	w = write("batch-1", "m1,t1=v1 f1=2")
+	if got, want := w.Code, http.StatusConflict; got != want {
+		t.Errorf("unexpected status code: got %d want %d", got, want)
+	}
+
+	// Writes without the header are never deduplicated.
+	write("", "m1,t1=v1 f1=1")
+	write("", "m1,t1=v1 f1=1")
+	if writes != 3 {
+		t.Errorf("expected 3 writes, got %d", writes)
+	}
+
+	// The oldest key is evicted once the limit is reached.
+	write("batch-2", "m1,t1=v1 f1=1")
+	write("batch-3", "m1,t1=v1 f1=1")
+	write("batch-1", "m1,t1=v1 f1=1")
+	if writes != 6 {
+		t.Errorf("expected 6 writes, got %d", writes)
+	}
+}
+
+func TestIdempotencyCache_EvictedKeyReused(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.NewKVStore()
+	if err := store.CreateBucket(ctx, idempotencyBucket); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewIdempotencyCache(zaptest.NewLogger(t), store, 1, time.Hour)
+
+	stored := func(key string) bool {
+		t.Helper()
+		var found bool
+		if err := store.View(ctx, func(tx kv.Tx) error {
+			b, err := tx.Bucket(idempotencyBucket)
+			if err != nil {
+				return err
+			}
+			_, err = b.Get([]byte(key))
+			found = err == nil
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+	write := func(key string) {
+		t.Helper()
+		if _, _, err := cache.Begin(ctx, key, "hash"); err != nil {
+			t.Fatal(err)
+		}
+		cache.Finish(ctx, key, http.StatusNoContent)
+	}
+
+	write("a")
+	if !stored("a") {
+		t.Fatal("expected key a to be stored")
+	}
+
+	// Beginning b evicts a, which is removed from the store.
+	write("b")
+	if stored("a") {
+		t.Error("expected evicted key a to be removed from the store")
+	}
+
+	// Reusing a evicts b. The eviction of a must not remove it once
+	// persisted again, whatever the order the store is updated in.
+	if _, _, err := cache.Begin(ctx, "a", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	cache.deleteEvicted(ctx, [][]byte{[]byte("a")})
+	cache.Finish(ctx, "a", http.StatusNoContent)
+	cache.deleteEvicted(ctx, [][]byte{[]byte("a")})
+	if !stored("a") {
+		t.Error("expected reused key a to stay stored")
+	}
+	if stored("b") {
+		t.Error("expected evicted key b to be removed from the store")
+	}
+}
+
 var DefaultErrorHandler = kithttp.ErrorHandler(0)
 
 func bucketWritePermission(org, bucket string) *influxdb.Authorization {
@@ -0,0 +1,298 @@
+package http
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+const (
+	// IdempotencyKeyHeader is the header clients set to deduplicate retried writes.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set on responses replayed for a duplicate key.
+	IdempotentReplayHeader = "Idempotent-Replay"
+
+	// DefaultIdempotencyMaxKeys is the default number of idempotency keys remembered.
+	DefaultIdempotencyMaxKeys = 10000
+	// DefaultIdempotencyTTL is the default time an idempotency key is remembered.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	maxIdempotencyKeyLen = 256
+)
+
+var idempotencyBucket = []byte("writeidempotencyv1")
+
+// IdempotencyCache remembers the outcome of recent writes by idempotency key
+// so that retried batches are not written twice. Keys are scoped to an org
+// and bucket, bounded in number and expire after a TTL. Entries are persisted
+// to an optional kv store on a best-effort basis.
+type IdempotencyCache struct {
+	log     *zap.Logger
+	store   kv.Store
+	maxKeys int
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// idempotencyEntry is the remembered outcome of a write.
+type idempotencyEntry struct {
+	Key       string    `json:"key"`
+	BodyHash  string    `json:"bodyHash"`
+	Status    int       `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	pending bool
+}
+
+// NewIdempotencyCache returns a cache remembering up to maxKeys keys for ttl.
+// The store may be nil, in which case keys are only kept in memory.
+func NewIdempotencyCache(log *zap.Logger, store kv.Store, maxKeys int, ttl time.Duration) *IdempotencyCache {
+	if maxKeys <= 0 {
+		maxKeys = DefaultIdempotencyMaxKeys
+	}
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &IdempotencyCache{
+		log:     log,
+		store:   store,
+		maxKeys: maxKeys,
+		ttl:     ttl,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Open loads unexpired keys from the store. Failures are logged and the cache
+// starts empty.
+func (c *IdempotencyCache) Open(ctx context.Context) error {
+	if c.store == nil {
+		return nil
+	}
+
+	var expired [][]byte
+	err := c.store.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(idempotencyBucket)
+		if err != nil {
+			return err
+		}
+		cur, err := b.ForwardCursor(nil)
+		if err != nil {
+			return err
+		}
+		defer cur.Close()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		now := c.now()
+		for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+			var e idempotencyEntry
+			if err := json.Unmarshal(v, &e); err != nil || !now.Before(e.ExpiresAt) {
+				expired = append(expired, append([]byte(nil), k...))
+				continue
+			}
+			expired = append(expired, c.insert(&e)...)
+		}
+		return cur.Err()
+	})
+	if err != nil {
+		c.log.Warn("Failed to load write idempotency keys", zap.Error(err))
+		return nil
+	}
+
+	c.deleteStored(ctx, expired...)
+	return nil
+}
+
+// idempotencyScopedKey scopes a client supplied key to an org and bucket.
+func idempotencyScopedKey(orgID, bucketID influxdb.ID, key string) string {
+	return orgID.String() + "/" + bucketID.String() + "/" + key
+}
+
+// Begin reserves the key for a write with the given body hash. If the key was
+// already used for an identical body, the original status is returned with
+// replay set. A key reused with a different body, or still in progress, is
+// rejected with EConflict.
+func (c *IdempotencyCache) Begin(ctx context.Context, key, bodyHash string) (status int, replay bool, err error) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*idempotencyEntry)
+		if c.now().Before(e.ExpiresAt) {
+			defer c.mu.Unlock()
+			switch {
+			case e.BodyHash != bodyHash:
+				return 0, false, &influxdb.Error{
+					Code: influxdb.EConflict,
+					Op:   opWriteHandler,
+					Msg:  "idempotency key was already used for a different request body",
+				}
+			case e.pending:
+				return 0, false, &influxdb.Error{
+					Code: influxdb.EConflict,
+					Op:   opWriteHandler,
+					Msg:  "a request with this idempotency key is already in progress",
+				}
+			}
+			c.lru.MoveToFront(el)
+			return e.Status, true, nil
+		}
+		c.remove(el)
+	}
+
+	evicted := c.insert(&idempotencyEntry{
+		Key:       key,
+		BodyHash:  bodyHash,
+		ExpiresAt: c.now().Add(c.ttl),
+		pending:   true,
+	})
+	c.mu.Unlock()
+
+	c.deleteEvicted(ctx, evicted)
+	return 0, false, nil
+}
+
+// Finish records the status of a successful write for the key.
+func (c *IdempotencyCache) Finish(ctx context.Context, key string, status int) {
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	e := el.Value.(*idempotencyEntry)
+	e.Status = status
+	e.pending = false
+	stored := *e
+	c.mu.Unlock()
+
+	c.put(ctx, &stored)
+}
+
+// Abort releases the key after a failed write so that it may be retried.
+func (c *IdempotencyCache) Abort(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// insert adds an entry, evicting the least recently used entries beyond
+// maxKeys, and returns the keys of the evicted entries that may be stored.
+// The caller must hold c.mu, and delete them with deleteEvicted once it is
+// released.
+func (c *IdempotencyCache) insert(e *idempotencyEntry) (evicted [][]byte) {
+	c.entries[e.Key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.maxKeys {
+		el := c.lru.Back()
+		c.remove(el)
+		if old := el.Value.(*idempotencyEntry); !old.pending {
+			evicted = append(evicted, []byte(old.Key))
+		}
+	}
+	return evicted
+}
+
+// remove drops an entry from memory. The caller must hold c.mu.
+func (c *IdempotencyCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*idempotencyEntry).Key)
+}
+
+func (c *IdempotencyCache) put(ctx context.Context, e *idempotencyEntry) {
+	if c.store == nil {
+		return
+	}
+
+	v, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	err = c.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(idempotencyBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(e.Key), v)
+	})
+	if err != nil {
+		c.log.Warn("Failed to persist write idempotency key", zap.Error(err))
+	}
+}
+
+func (c *IdempotencyCache) deleteStored(ctx context.Context, keys ...[]byte) {
+	if c.store == nil || len(keys) == 0 {
+		return
+	}
+
+	err := c.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(idempotencyBucket)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.log.Warn("Failed to remove write idempotency keys", zap.Error(err))
+	}
+}
+
+// deleteEvicted removes evicted keys from the store, except those reused
+// since their eviction. Whether a key is in use is checked within the
+// transaction deleting it, so a reused key persisted by a later transaction
+// is never removed.
+func (c *IdempotencyCache) deleteEvicted(ctx context.Context, keys [][]byte) {
+	if c.store == nil || len(keys) == 0 {
+		return
+	}
+
+	err := c.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(idempotencyBucket)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, k := range keys {
+			if _, ok := c.entries[string(k)]; ok {
+				continue
+			}
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.log.Warn("Failed to remove write idempotency keys", zap.Error(err))
+	}
+}
+
+// validateIdempotencyKey ensures a client supplied key is reasonably sized.
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLen {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteHandler,
+			Msg:  fmt.Sprintf("%s header must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLen),
+		}
+	}
+	return nil
+}
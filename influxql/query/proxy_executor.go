@@ -61,6 +61,9 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 	epoch := req.Epoch
 	rw := NewResponseWriter(req.EncodingFormat)
 
+	// The rows are counted apart from stats, which the executor updates until
+	// the results are drained.
+	var rows int
 	results, stats := s.executor.ExecuteQuery(ctx, q, opts)
 	if req.Chunked {
 		for r := range results {
@@ -74,6 +77,7 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 				convertToEpoch(r, epoch)
 			}
 
+			rows += countRows(r)
 			err = rw.WriteResponse(ctx, w, Response{Results: []*Result{r}})
 			if err != nil {
 				break
@@ -81,12 +85,25 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 		}
 	} else {
 		resp := Response{Results: GatherResults(results, epoch)}
+		for _, r := range resp.Results {
+			rows += countRows(r)
+		}
 		err = rw.WriteResponse(ctx, w, resp)
 	}
 
+	stats.RowsReturned += rows
 	return *stats, err
 }
 
+// countRows returns the number of rows of the series of r.
+func countRows(r *Result) int {
+	var n int
+	for _, s := range r.Series {
+		n += len(s.Values)
+	}
+	return n
+}
+
 // GatherResults consumes the results from the given channel and organizes them correctly.
 // Results for various statements need to be combined together.
 func GatherResults(ch <-chan *Result, epoch string) []*Result {
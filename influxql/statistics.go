@@ -15,6 +15,7 @@ type Statistics struct {
 	StatementCount  int           `json:"statement_count"`  // StatementCount is the number of InfluxQL statements executed
 	ScannedValues   int           `json:"scanned_values"`   // ScannedValues is the number of values scanned from storage
 	ScannedBytes    int           `json:"scanned_bytes"`    // ScannedBytes is the number of bytes scanned from storage
+	RowsReturned    int           `json:"rows_returned"`    // RowsReturned is the number of rows of the series returned
 }
 
 // Adding returns the sum of s and other.
@@ -25,6 +26,7 @@ func (s Statistics) Adding(other Statistics) Statistics {
 		StatementCount:  s.StatementCount + other.StatementCount,
 		ScannedValues:   s.ScannedValues + other.ScannedValues,
 		ScannedBytes:    s.ScannedBytes + other.ScannedBytes,
+		RowsReturned:    s.RowsReturned + other.RowsReturned,
 	}
 }
 
@@ -35,6 +37,7 @@ func (s *Statistics) Add(other Statistics) {
 	s.StatementCount += other.StatementCount
 	s.ScannedValues += other.ScannedValues
 	s.ScannedBytes += other.ScannedBytes
+	s.RowsReturned += other.RowsReturned
 }
 
 func (s *Statistics) LogToSpan(span opentracing.Span) {
@@ -47,6 +50,7 @@ func (s *Statistics) LogToSpan(span opentracing.Span) {
 		log.Int("stats_statement_count", s.StatementCount),
 		log.Int("stats_scanned_values", s.ScannedValues),
 		log.Int("stats_scanned_bytes", s.ScannedBytes),
+		log.Int("stats_rows_returned", s.RowsReturned),
 	)
 }
 
@@ -0,0 +1,69 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IngestQuota limits the number of line protocol bytes an organization may
+// write per day. A zero DailyBytes means the organization is unlimited. In
+// grace mode writes over the quota are logged but not rejected.
+type IngestQuota struct {
+	OrgID      ID    `json:"orgID"`
+	DailyBytes int64 `json:"dailyBytes"`
+	Grace      bool  `json:"grace"`
+}
+
+// IngestUsage is the number of bytes an organization has written during the
+// current quota period.
+type IngestUsage struct {
+	OrgID       ID           `json:"orgID"`
+	Bytes       int64        `json:"bytes"`
+	PeriodStart time.Time    `json:"periodStart"`
+	PeriodEnd   time.Time    `json:"periodEnd"`
+	Quota       *IngestQuota `json:"quota,omitempty"`
+	Exceeded    bool         `json:"exceeded"`
+}
+
+// IngestQuotaService manages the ingest quotas of organizations and reports
+// their usage.
+type IngestQuotaService interface {
+	// IngestUsage returns the usage of an organization in the current period.
+	IngestUsage(ctx context.Context, orgID ID) (*IngestUsage, error)
+	// FindIngestQuota returns the quota of an organization.
+	FindIngestQuota(ctx context.Context, orgID ID) (*IngestQuota, error)
+	// SetIngestQuota creates or replaces the quota of an organization.
+	SetIngestQuota(ctx context.Context, q *IngestQuota) error
+	// DeleteIngestQuota removes the quota of an organization.
+	DeleteIngestQuota(ctx context.Context, orgID ID) error
+}
+
+// IngestQuotaEnforcer tracks and limits the bytes written by organizations.
+type IngestQuotaEnforcer interface {
+	// CheckIngest returns an ETooManyRequests error wrapping an
+	// *IngestQuotaExceeded if the organization may not write.
+	CheckIngest(ctx context.Context, orgID ID) error
+	// RecordIngest adds n written bytes to the usage of the organization.
+	RecordIngest(ctx context.Context, orgID ID, n int64)
+}
+
+// IngestQuotaExceeded details why a write was rejected by an ingest quota.
+type IngestQuotaExceeded struct {
+	OrgID      ID        `json:"orgID"`
+	QuotaBytes int64     `json:"quotaBytes"`
+	UsedBytes  int64     `json:"usedBytes"`
+	ResetAt    time.Time `json:"resetAt"`
+}
+
+// Error implements the error interface.
+func (e *IngestQuotaExceeded) Error() string {
+	return fmt.Sprintf("organization %s has written %d of its %d byte daily ingest quota; the quota resets at %s",
+		e.OrgID, e.UsedBytes, e.QuotaBytes, e.ResetAt.Format(time.RFC3339))
+}
+
+// ErrIngestQuotaNotFound is returned when an organization has no ingest quota.
+var ErrIngestQuotaNotFound = &Error{
+	Code: ENotFound,
+	Msg:  "ingest quota not found",
+}
@@ -14,6 +14,7 @@ import (
 // TSDBStoreMock is a mockable implementation of tsdb.Store.
 type TSDBStoreMock struct {
 	BackupShardFn             func(id uint64, since time.Time, w io.Writer) error
+	BackupShardMeasurementsFn func(id uint64, since time.Time, measurements []string, w io.Writer) error
 	BackupSeriesFileFn        func(database string, w io.Writer) error
 	ExportShardFn             func(id uint64, ExportStart time.Time, ExportEnd time.Time, w io.Writer) error
 	CloseFn                   func() error
@@ -52,6 +53,9 @@ type TSDBStoreMock struct {
 func (s *TSDBStoreMock) BackupShard(id uint64, since time.Time, w io.Writer) error {
 	return s.BackupShardFn(id, since, w)
 }
+func (s *TSDBStoreMock) BackupShardMeasurements(id uint64, since time.Time, measurements []string, w io.Writer) error {
+	return s.BackupShardMeasurementsFn(id, since, measurements, w)
+}
 func (s *TSDBStoreMock) BackupSeriesFile(database string, w io.Writer) error {
 	return s.BackupSeriesFileFn(database, w)
 }
@@ -0,0 +1,91 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultInviteTTL is how long the setup token of an invitation is valid for
+// when no other duration is given.
+const DefaultInviteTTL = 7 * 24 * time.Hour
+
+// Statuses of an invitation. An invitation whose setup token has expired
+// keeps the pending status; Expired reports whether it has.
+const (
+	InvitePending  = "pending"
+	InviteAccepted = "accepted"
+	InviteRevoked  = "revoked"
+)
+
+// Invite is an invitation of a new user to an organization. The user is
+// created inactive and without a password when they are invited, and is
+// activated and added to the organization with Role once they choose their
+// password using the one-time setup token of the invitation.
+type Invite struct {
+	ID     ID       `json:"id"`
+	OrgID  ID       `json:"orgID"`
+	UserID ID       `json:"userID"`
+	Name   string   `json:"name"`
+	Role   UserType `json:"role"`
+	Status string   `json:"status"`
+
+	CreatedBy  ID         `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+}
+
+// Expired reports whether the setup token of a pending invitation has
+// expired at now.
+func (i *Invite) Expired(now time.Time) bool {
+	return i.Status == InvitePending && !now.Before(i.ExpiresAt)
+}
+
+// InviteToken is an invitation with its one-time setup token. Only a hash of
+// the token is stored, so it is known only when the invitation is created or
+// resent.
+type InviteToken struct {
+	*Invite
+	Token string `json:"token"`
+}
+
+// InviteCreate is the request to invite a user to an organization.
+type InviteCreate struct {
+	OrgID ID       `json:"orgID"`
+	Name  string   `json:"name"`
+	Role  UserType `json:"role"`
+	// TTL is how long the setup token is valid for. DefaultInviteTTL is used
+	// when it is zero.
+	TTL time.Duration `json:"-"`
+}
+
+// InviteAccept is the request to accept an invitation by choosing a password.
+type InviteAccept struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// InviteService manages the invitations of new users to organizations.
+type InviteService interface {
+	// CreateInvite creates an inactive user and an invitation for it to join
+	// the organization, returning the invitation with its setup token.
+	CreateInvite(ctx context.Context, c InviteCreate) (*InviteToken, error)
+
+	// FindInviteByID returns a single invitation by ID.
+	FindInviteByID(ctx context.Context, id ID) (*Invite, error)
+
+	// FindInvites returns the invitations of an organization.
+	FindInvites(ctx context.Context, orgID ID) ([]*Invite, error)
+
+	// ResendInvite replaces the setup token of a pending invitation with a
+	// new one, extending its expiry.
+	ResendInvite(ctx context.Context, id ID) (*InviteToken, error)
+
+	// RevokeInvite revokes a pending invitation and removes the user it
+	// created.
+	RevokeInvite(ctx context.Context, id ID) error
+
+	// AcceptInvite sets the password of the invited user, activates it and
+	// adds it to the organization. The setup token can be used only once.
+	AcceptInvite(ctx context.Context, a InviteAccept) (*Invite, error)
+}
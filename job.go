@@ -0,0 +1,97 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JobKindDelete is the kind of the jobs deleting data from a bucket.
+const JobKindDelete = "delete"
+
+// States of a job. Queued and running jobs are pending; the other states
+// are final.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCanceled  = "canceled"
+)
+
+// Job is a long-running operation the server runs in the background, such
+// as deleting data. Jobs are kept in the KV store, so a job interrupted by a
+// restart of the server is run again once it starts.
+type Job struct {
+	ID    ID     `json:"id"`
+	Kind  string `json:"kind"`
+	OrgID ID     `json:"orgID"`
+	State string `json:"state"`
+	// Progress is the fraction of the job done, from 0 to 1.
+	Progress float64 `json:"progress"`
+	// Params are the parameters of the job, and Result what it returned once
+	// it succeeded. Both are specific to the kind of the job.
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	// Attempts is the number of times the job was started.
+	Attempts int `json:"attempts"`
+
+	CreatedBy  ID         `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Done reports whether the job is in a final state.
+func (j *Job) Done() bool {
+	return j.State != JobQueued && j.State != JobRunning
+}
+
+// JobCreate is the request to run a job. Params is encoded as JSON.
+type JobCreate struct {
+	Kind   string
+	OrgID  ID
+	Params interface{}
+}
+
+// JobFilter selects jobs. Empty fields match all jobs.
+type JobFilter struct {
+	OrgID *ID
+	Kind  string
+	State string
+}
+
+// JobService reports and cancels the background jobs of the server.
+type JobService interface {
+	// FindJobByID returns a single job by ID.
+	FindJobByID(ctx context.Context, id ID) (*Job, error)
+
+	// FindJobs returns the jobs matching filter, newest first.
+	FindJobs(ctx context.Context, filter JobFilter, opt ...FindOptions) ([]*Job, int, error)
+
+	// CancelJob cancels a pending job. A queued job is canceled at once; a
+	// running job is canceled once it stops.
+	CancelJob(ctx context.Context, id ID) (*Job, error)
+}
+
+// JobSubmitter queues background jobs. It does not authorize the operation
+// a job runs, which its submitter must do.
+type JobSubmitter interface {
+	SubmitJob(ctx context.Context, c JobCreate) (*Job, error)
+}
+
+// ErrJobNotFound is returned when a job does not exist.
+var ErrJobNotFound = &Error{
+	Code: ENotFound,
+	Msg:  "job not found",
+}
+
+// ErrJobDone is returned when canceling a job that already ended.
+func ErrJobDone(j *Job) *Error {
+	return &Error{
+		Code: EConflict,
+		Msg:  fmt.Sprintf("job %s already %s", j.ID, j.State),
+	}
+}
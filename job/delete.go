@@ -0,0 +1,41 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/predicate"
+)
+
+// DeleteParams are the parameters of a delete job, deleting the points of a
+// bucket of the organization of the job between Start and Stop that match
+// Predicate.
+type DeleteParams struct {
+	BucketID  influxdb.ID `json:"bucketID"`
+	Start     time.Time   `json:"start"`
+	Stop      time.Time   `json:"stop"`
+	Predicate string      `json:"predicate,omitempty"`
+}
+
+// NewDeleteHandler returns the handler of delete jobs, deleting with s.
+// Deleting points already deleted does nothing, so an interrupted delete is
+// safe to run again.
+func NewDeleteHandler(s influxdb.DeleteService) Handler {
+	return func(ctx context.Context, j *influxdb.Job, progress func(float64)) (interface{}, error) {
+		var p DeleteParams
+		if err := json.Unmarshal(j.Params, &p); err != nil {
+			return nil, err
+		}
+		node, err := predicate.Parse(p.Predicate)
+		if err != nil {
+			return nil, err
+		}
+		pred, err := predicate.New(node)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.DeleteBucketRangePredicate(ctx, j.OrgID, p.BucketID, p.Start.UnixNano(), p.Stop.UnixNano(), pred)
+	}
+}
@@ -0,0 +1,148 @@
+package job
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const (
+	PrefixJobs = "/api/v2/jobs"
+)
+
+// HTTPHandler serves the background jobs of the server.
+type HTTPHandler struct {
+	chi.Router
+	api    *kithttp.API
+	log    *zap.Logger
+	jobSvc influxdb.JobService
+}
+
+// NewHTTPHandler constructs a new http server.
+func NewHTTPHandler(log *zap.Logger, jobSvc influxdb.JobService) *HTTPHandler {
+	h := &HTTPHandler{
+		api:    kithttp.NewAPI(kithttp.WithLog(log)),
+		log:    log,
+		jobSvc: jobSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetJobs)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetJob)
+			r.Post("/cancel", h.handleCancelJob)
+		})
+	})
+
+	h.Router = r
+	return h
+}
+
+type jobsResponse struct {
+	Jobs []*influxdb.Job `json:"jobs"`
+}
+
+func (h *HTTPHandler) handleGetJobs(w http.ResponseWriter, r *http.Request) {
+	filter, err := decodeJobFilter(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	jobs, _, err := h.jobSvc.FindJobs(r.Context(), filter, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if jobs == nil {
+		jobs = []*influxdb.Job{}
+	}
+	h.api.Respond(w, r, http.StatusOK, jobsResponse{Jobs: jobs})
+}
+
+func (h *HTTPHandler) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := decodeJobID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	j, err := h.jobSvc.FindJobByID(r.Context(), id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, j)
+}
+
+func (h *HTTPHandler) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := decodeJobID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	j, err := h.jobSvc.CancelJob(r.Context(), id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, j)
+}
+
+// decodeJobID returns the ID of the job in the URL.
+func decodeJobID(r *http.Request) (influxdb.ID, error) {
+	var id influxdb.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "invalid job ID",
+			Err:  err,
+		}
+	}
+	return id, nil
+}
+
+// decodeJobFilter returns the filter of the orgID, kind and state query
+// parameters.
+func decodeJobFilter(r *http.Request) (influxdb.JobFilter, error) {
+	q := r.URL.Query()
+	filter := influxdb.JobFilter{
+		Kind:  q.Get("kind"),
+		State: q.Get("state"),
+	}
+	if s := q.Get("orgID"); s != "" {
+		orgID, err := influxdb.IDFromString(s)
+		if err != nil {
+			return filter, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "invalid organization ID",
+				Err:  err,
+			}
+		}
+		filter.OrgID = orgID
+	}
+	switch filter.State {
+	case "", influxdb.JobQueued, influxdb.JobRunning, influxdb.JobSucceeded, influxdb.JobFailed, influxdb.JobCanceled:
+	default:
+		return filter, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "invalid job state " + filter.State,
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,90 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(zaptest.NewLogger(t), newTestStore(t))
+	h := newBlockingHandler()
+	s.Register("slow", 1, h.run)
+	require.NoError(t, s.Open(ctx))
+	defer runService(s)()
+
+	own, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "slow", OrgID: orgID})
+	require.NoError(t, err)
+	other, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "slow", OrgID: otherOrgID})
+	require.NoError(t, err)
+	waitForState(t, s, own.ID, influxdb.JobRunning)
+
+	orgPermission := func(action influxdb.Action, id influxdb.ID) influxdb.Permission {
+		return influxdb.Permission{
+			Action:   action,
+			Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &id},
+		}
+	}
+	do := func(t *testing.T, method, path string, permissions ...influxdb.Permission) *httptest.ResponseRecorder {
+		t.Helper()
+		r := chi.NewRouter()
+		r.Mount(PrefixJobs, NewHTTPHandler(zaptest.NewLogger(t), NewAuthorizedService(s)))
+
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(icontext.SetAuthorizer(req.Context(), mock.NewMockAuthorizer(false, permissions)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	readOwn := orgPermission(influxdb.ReadAction, orgID)
+
+	t.Run("lists the jobs of readable organizations", func(t *testing.T) {
+		w := do(t, "GET", PrefixJobs, readOwn)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp jobsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Jobs, 1)
+		assert.Equal(t, own.ID, resp.Jobs[0].ID)
+
+		w = do(t, "GET", PrefixJobs+"?orgID="+otherOrgID.String(), readOwn)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = do(t, "GET", PrefixJobs+"?state=done", readOwn)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("gets a job", func(t *testing.T) {
+		w := do(t, "GET", PrefixJobs+"/"+own.ID.String(), readOwn)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var j influxdb.Job
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &j))
+		assert.Equal(t, influxdb.JobRunning, j.State)
+		assert.Equal(t, 0.5, j.Progress)
+
+		w = do(t, "GET", PrefixJobs+"/"+other.ID.String(), readOwn)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = do(t, "GET", PrefixJobs+"/"+influxdb.ID(1).String(), readOwn)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("cancels a job with write access", func(t *testing.T) {
+		w := do(t, "POST", PrefixJobs+"/"+own.ID.String()+"/cancel", readOwn)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = do(t, "POST", PrefixJobs+"/"+own.ID.String()+"/cancel", orgPermission(influxdb.WriteAction, orgID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		waitForState(t, s, own.ID, influxdb.JobCanceled)
+	})
+}
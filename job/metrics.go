@@ -0,0 +1,36 @@
+package job
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const namespace = "jobs"
+
+type metrics struct {
+	queued  *prometheus.GaugeVec
+	running *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		queued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queued",
+			Help:      "Number of background jobs waiting to run, by kind.",
+		}, []string{"kind"}),
+		running: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "running",
+			Help:      "Number of background jobs running, by kind.",
+		}, []string{"kind"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the service.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{s.metrics.queued, s.metrics.running}
+}
+
+// updateMetrics sets the gauges of the jobs of kind k. s.mu must be held.
+func (s *Service) updateMetrics(name string, k *kind) {
+	s.metrics.queued.WithLabelValues(name).Set(float64(len(k.queue)))
+	s.metrics.running.WithLabelValues(name).Set(float64(k.running))
+}
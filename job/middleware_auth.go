@@ -0,0 +1,75 @@
+package job
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+)
+
+var _ influxdb.JobService = (*AuthorizedService)(nil)
+
+// AuthorizedService authorizes access to jobs. Jobs may be read by anyone
+// able to read their organization, and canceled by anyone able to write it.
+type AuthorizedService struct {
+	s influxdb.JobService
+}
+
+// NewAuthorizedService wraps s with authorization checks.
+func NewAuthorizedService(s influxdb.JobService) *AuthorizedService {
+	return &AuthorizedService{s: s}
+}
+
+func (svc *AuthorizedService) FindJobByID(ctx context.Context, id influxdb.ID) (*influxdb.Job, error) {
+	j, err := svc.s.FindJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, j.OrgID); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// FindJobs returns the jobs of the organizations the authorizer on context
+// can read. Without an organization in filter, the jobs of the others are
+// left out before the page of opt is taken.
+func (svc *AuthorizedService) FindJobs(ctx context.Context, filter influxdb.JobFilter, opt ...influxdb.FindOptions) ([]*influxdb.Job, int, error) {
+	if filter.OrgID != nil {
+		if _, _, err := authorizer.AuthorizeReadOrg(ctx, *filter.OrgID); err != nil {
+			return nil, 0, err
+		}
+		return svc.s.FindJobs(ctx, filter, opt...)
+	}
+
+	jobs, _, err := svc.s.FindJobs(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	// This filters without allocating
+	// https://github.com/golang/go/wiki/SliceTricks#filtering-without-allocating
+	allowed := jobs[:0]
+	for _, j := range jobs {
+		_, _, err := authorizer.AuthorizeReadOrg(ctx, j.OrgID)
+		if err != nil && influxdb.ErrorCode(err) != influxdb.EUnauthorized {
+			return nil, 0, err
+		}
+		if influxdb.ErrorCode(err) == influxdb.EUnauthorized {
+			continue
+		}
+		allowed = append(allowed, j)
+	}
+	allowed = page(allowed, opt...)
+	return allowed, len(allowed), nil
+}
+
+func (svc *AuthorizedService) CancelJob(ctx context.Context, id influxdb.ID) (*influxdb.Job, error) {
+	j, err := svc.s.FindJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, j.OrgID); err != nil {
+		return nil, err
+	}
+	return svc.s.CancelJob(ctx, id)
+}
@@ -0,0 +1,513 @@
+// Package job runs the long-running operations of the server, such as
+// deleting data, as background jobs. Jobs are kept in the KV store with their
+// state and progress, and are run by a pool of workers limiting how many jobs
+// of each kind run at once.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRetention is how long jobs are kept once they end.
+	DefaultRetention = 7 * 24 * time.Hour
+
+	pruneInterval = time.Hour
+)
+
+var jobBucket = []byte("jobsv1")
+
+var (
+	_ influxdb.JobService   = (*Service)(nil)
+	_ influxdb.JobSubmitter = (*Service)(nil)
+)
+
+// Handler runs the jobs of a kind, returning their result, encoded as JSON,
+// or an error. It may report the progress of a job, from 0 to 1, with
+// progress. ctx is canceled when the job is canceled or the service stops. A
+// job interrupted by a stop is run again once the service is opened, so
+// handlers must be safe to run again.
+type Handler func(ctx context.Context, j *influxdb.Job, progress func(float64)) (interface{}, error)
+
+// kind is the handler and the queue of the jobs of a kind.
+type kind struct {
+	handler     Handler
+	concurrency int
+	queue       []influxdb.ID
+	running     int
+}
+
+// run is a running job.
+type run struct {
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// Service runs background jobs, keeping them in the kv store. Handlers are
+// registered for each kind of job before the service is opened; jobs are
+// then submitted with SubmitJob and run while Run is running.
+type Service struct {
+	log       *zap.Logger
+	store     kv.Store
+	idGen     influxdb.IDGenerator
+	retention time.Duration
+	metrics   *metrics
+	now       func() time.Time
+
+	mu      sync.Mutex
+	kinds   map[string]*kind
+	running map[influxdb.ID]*run
+	wake    chan struct{}
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithRetention sets how long jobs are kept once they end. The default is
+// DefaultRetention.
+func WithRetention(d time.Duration) Option {
+	return func(s *Service) {
+		s.retention = d
+	}
+}
+
+// NewService returns a service keeping its jobs in store.
+func NewService(log *zap.Logger, store kv.Store, opts ...Option) *Service {
+	s := &Service{
+		log:       log,
+		store:     store,
+		idGen:     snowflake.NewDefaultIDGenerator(),
+		retention: DefaultRetention,
+		metrics:   newMetrics(),
+		now:       time.Now,
+		kinds:     make(map[string]*kind),
+		running:   make(map[influxdb.ID]*run),
+		wake:      make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register sets the handler of the jobs of kind, running at most
+// concurrency of them at once. A concurrency of zero or less runs one at a
+// time. Kinds are registered before the service is opened.
+func (s *Service) Register(name string, concurrency int, h Handler) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := &kind{handler: h, concurrency: concurrency}
+	s.kinds[name] = k
+	s.updateMetrics(name, k)
+}
+
+// Open queues the pending jobs, oldest first, including the jobs that were
+// running when the service stopped. Pending jobs of a kind with no handler
+// fail, and the jobs that ended longer ago than the retention are deleted.
+func (s *Service) Open(ctx context.Context) error {
+	now := s.now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []*influxdb.Job
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		jobs, err := findJobs(tx)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			switch {
+			case j.Done():
+				if s.expired(j, now) {
+					if err := deleteJob(tx, j.ID); err != nil {
+						return err
+					}
+				}
+				continue
+			case s.kinds[j.Kind] == nil:
+				j.State = influxdb.JobFailed
+				j.Error = fmt.Sprintf("no handler for jobs of kind %q", j.Kind)
+				j.FinishedAt = &now
+			case j.State == influxdb.JobRunning:
+				s.log.Info("Resuming job interrupted by a stop",
+					zap.String("job_id", j.ID.String()),
+					zap.String("kind", j.Kind))
+				j.State = influxdb.JobQueued
+				pending = append(pending, j)
+			default:
+				pending = append(pending, j)
+				continue
+			}
+			if err := putJob(tx, j); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	for _, j := range pending {
+		k := s.kinds[j.Kind]
+		k.queue = append(k.queue, j.ID)
+	}
+	for name, k := range s.kinds {
+		s.updateMetrics(name, k)
+	}
+	return nil
+}
+
+// Run runs the queued jobs until ctx is done, then waits for the running
+// jobs to stop. The jobs stopped this way are run again once the service is
+// opened. The jobs that ended longer ago than the retention are deleted
+// every hour.
+func (s *Service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		s.startJobs(ctx, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+			if err := s.prune(ctx); err != nil {
+				s.log.Error("Failed to delete expired jobs", zap.Error(err))
+			}
+		}
+	}
+}
+
+// SubmitJob queues a job, run once a worker of its kind is free.
+func (s *Service) SubmitJob(ctx context.Context, c influxdb.JobCreate) (*influxdb.Job, error) {
+	s.mu.Lock()
+	_, ok := s.kinds[c.Kind]
+	s.mu.Unlock()
+	if !ok {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("unknown job kind %q", c.Kind),
+		}
+	}
+
+	j := &influxdb.Job{
+		ID:        s.idGen.ID(),
+		Kind:      c.Kind,
+		OrgID:     c.OrgID,
+		State:     influxdb.JobQueued,
+		CreatedAt: s.now().UTC(),
+	}
+	if c.Params != nil {
+		params, err := json.Marshal(c.Params)
+		if err != nil {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "invalid job parameters",
+				Err:  err,
+			}
+		}
+		j.Params = params
+	}
+	if userID, err := icontext.GetUserID(ctx); err == nil {
+		j.CreatedBy = userID
+	}
+
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		return putJob(tx, j)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	k := s.kinds[c.Kind]
+	k.queue = append(k.queue, j.ID)
+	s.updateMetrics(c.Kind, k)
+	s.mu.Unlock()
+	s.notify()
+
+	return j, nil
+}
+
+// FindJobByID returns a single job by ID.
+func (s *Service) FindJobByID(ctx context.Context, id influxdb.ID) (*influxdb.Job, error) {
+	var j *influxdb.Job
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		var err error
+		j, err = getJob(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// FindJobs returns the jobs matching filter, newest first.
+func (s *Service) FindJobs(ctx context.Context, filter influxdb.JobFilter, opt ...influxdb.FindOptions) ([]*influxdb.Job, int, error) {
+	var jobs []*influxdb.Job
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		all, err := findJobs(tx)
+		if err != nil {
+			return err
+		}
+		for _, j := range all {
+			if matches(j, filter) {
+				jobs = append(jobs, j)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	jobs = page(jobs, opt...)
+	return jobs, len(jobs), nil
+}
+
+// CancelJob cancels a pending job. A queued job is canceled at once; a
+// running job is canceled once its handler returns.
+func (s *Service) CancelJob(ctx context.Context, id influxdb.ID) (*influxdb.Job, error) {
+	s.mu.Lock()
+	if r, ok := s.running[id]; ok {
+		r.canceled = true
+		r.cancel()
+		s.mu.Unlock()
+		return s.FindJobByID(ctx, id)
+	}
+	s.dequeue(id)
+	s.mu.Unlock()
+
+	return s.update(ctx, id, func(j *influxdb.Job) error {
+		if j.Done() {
+			return influxdb.ErrJobDone(j)
+		}
+		now := s.now().UTC()
+		j.State = influxdb.JobCanceled
+		j.FinishedAt = &now
+		return nil
+	})
+}
+
+// startJobs starts the queued jobs of each kind its concurrency allows.
+func (s *Service) startJobs(ctx context.Context, wg *sync.WaitGroup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, k := range s.kinds {
+		for k.running < k.concurrency && len(k.queue) > 0 {
+			id := k.queue[0]
+			k.queue = k.queue[1:]
+
+			jobCtx, cancel := context.WithCancel(ctx)
+			s.running[id] = &run{cancel: cancel}
+			k.running++
+
+			wg.Add(1)
+			go func(name string, k *kind, id influxdb.ID) {
+				defer wg.Done()
+				s.runJob(ctx, jobCtx, name, k, id)
+			}(name, k, id)
+		}
+		s.updateMetrics(name, k)
+	}
+}
+
+// runJob runs the job id with the handler of its kind. ctx is the context of
+// Run, and jobCtx the context of the job, canceled with it.
+func (s *Service) runJob(ctx, jobCtx context.Context, name string, k *kind, id influxdb.ID) {
+	log := s.log.With(zap.String("job_id", id.String()), zap.String("kind", name))
+
+	defer func() {
+		s.mu.Lock()
+		s.running[id].cancel()
+		delete(s.running, id)
+		k.running--
+		s.updateMetrics(name, k)
+		s.mu.Unlock()
+		s.notify()
+	}()
+
+	j, err := s.update(jobCtx, id, func(j *influxdb.Job) error {
+		now := s.now().UTC()
+		j.State = influxdb.JobRunning
+		j.StartedAt = &now
+		j.Attempts++
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("Failed to start job", zap.Error(err))
+		}
+		return
+	}
+
+	progress := func(p float64) {
+		if _, err := s.update(jobCtx, id, func(j *influxdb.Job) error {
+			j.Progress = p
+			return nil
+		}); err != nil && jobCtx.Err() == nil {
+			log.Error("Failed to record job progress", zap.Error(err))
+		}
+	}
+	result, runErr := k.handler(jobCtx, j, progress)
+
+	s.mu.Lock()
+	canceled := s.running[id].canceled
+	s.mu.Unlock()
+	if !canceled && ctx.Err() != nil {
+		// The service stopped; the job is run again once it is opened.
+		log.Info("Job interrupted by a stop")
+		return
+	}
+
+	// The job ended, even if the service is stopping while it is recorded.
+	if _, err := s.update(context.Background(), id, func(j *influxdb.Job) error {
+		now := s.now().UTC()
+		j.FinishedAt = &now
+		switch {
+		case canceled:
+			j.State = influxdb.JobCanceled
+		case runErr != nil:
+			j.State = influxdb.JobFailed
+			j.Error = runErr.Error()
+		default:
+			j.State = influxdb.JobSucceeded
+			j.Progress = 1
+			if result != nil {
+				b, err := json.Marshal(result)
+				if err != nil {
+					j.State = influxdb.JobFailed
+					j.Error = fmt.Sprintf("invalid job result: %v", err)
+					break
+				}
+				j.Result = b
+			}
+		}
+		log.Info("Job ended", zap.String("state", j.State), zap.String("error", j.Error))
+		return nil
+	}); err != nil {
+		log.Error("Failed to record job end", zap.Error(err))
+	}
+}
+
+// prune deletes the jobs that ended longer ago than the retention.
+func (s *Service) prune(ctx context.Context) error {
+	now := s.now().UTC()
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		jobs, err := findJobs(tx)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobs {
+			if j.Done() && s.expired(j, now) {
+				if err := deleteJob(tx, j.ID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) expired(j *influxdb.Job, now time.Time) bool {
+	return j.FinishedAt != nil && now.Sub(*j.FinishedAt) > s.retention
+}
+
+// update applies fn to the stored job id, and stores it unless fn fails.
+func (s *Service) update(ctx context.Context, id influxdb.ID, fn func(j *influxdb.Job) error) (*influxdb.Job, error) {
+	var j *influxdb.Job
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		var err error
+		if j, err = getJob(tx, id); err != nil {
+			return err
+		}
+		if err := fn(j); err != nil {
+			return err
+		}
+		return putJob(tx, j)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// dequeue removes the queued job id from the queue of its kind. s.mu must be
+// held.
+func (s *Service) dequeue(id influxdb.ID) {
+	for name, k := range s.kinds {
+		for i, qid := range k.queue {
+			if qid == id {
+				k.queue = append(k.queue[:i], k.queue[i+1:]...)
+				s.updateMetrics(name, k)
+				return
+			}
+		}
+	}
+}
+
+// notify wakes Run up to start the jobs that can run.
+func (s *Service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func matches(j *influxdb.Job, filter influxdb.JobFilter) bool {
+	if filter.OrgID != nil && j.OrgID != *filter.OrgID {
+		return false
+	}
+	if filter.Kind != "" && j.Kind != filter.Kind {
+		return false
+	}
+	return filter.State == "" || j.State == filter.State
+}
+
+// page returns the page of jobs selected by the offset and limit of opt.
+func page(jobs []*influxdb.Job, opt ...influxdb.FindOptions) []*influxdb.Job {
+	if len(opt) == 0 {
+		return jobs
+	}
+	if o := opt[0].Offset; o > 0 {
+		if o >= len(jobs) {
+			return []*influxdb.Job{}
+		}
+		jobs = jobs[o:]
+	}
+	if l := opt[0].Limit; l > 0 && l < len(jobs) {
+		jobs = jobs[:l]
+	}
+	return jobs
+}
@@ -0,0 +1,309 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	orgID      = influxdb.ID(0x1000)
+	otherOrgID = influxdb.ID(0x2000)
+)
+
+func newTestStore(t *testing.T) kv.Store {
+	t.Helper()
+	store := inmem.NewKVStore()
+	require.NoError(t, all.Up(context.Background(), zaptest.NewLogger(t), store))
+	return store
+}
+
+// runService runs s until the returned function is called, which waits for
+// s to stop.
+func runService(s *Service) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// waitForState waits for the job id to be in state.
+func waitForState(t *testing.T, s *Service, id influxdb.ID, state string) *influxdb.Job {
+	t.Helper()
+	var j *influxdb.Job
+	require.Eventually(t, func() bool {
+		var err error
+		j, err = s.FindJobByID(context.Background(), id)
+		require.NoError(t, err)
+		return j.State == state
+	}, 5*time.Second, 5*time.Millisecond, "job %s never %s", id, state)
+	return j
+}
+
+// blockingHandler runs its jobs until they are canceled or released.
+type blockingHandler struct {
+	mu      sync.Mutex
+	started map[influxdb.ID]bool
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{started: make(map[influxdb.ID]bool), release: make(chan struct{})}
+}
+
+func (h *blockingHandler) run(ctx context.Context, j *influxdb.Job, progress func(float64)) (interface{}, error) {
+	h.mu.Lock()
+	h.started[j.ID] = true
+	h.mu.Unlock()
+	progress(0.5)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-h.release:
+		return map[string]int{"n": 1}, nil
+	}
+}
+
+func (h *blockingHandler) startedN() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.started)
+}
+
+func TestService_RunsJobs(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(zaptest.NewLogger(t), newTestStore(t))
+	s.Register("ok", 1, func(ctx context.Context, j *influxdb.Job, progress func(float64)) (interface{}, error) {
+		var params map[string]string
+		if err := json.Unmarshal(j.Params, &params); err != nil {
+			return nil, err
+		}
+		return map[string]string{"echo": params["msg"]}, nil
+	})
+	s.Register("fail", 1, func(ctx context.Context, j *influxdb.Job, progress func(float64)) (interface{}, error) {
+		return nil, errors.New("out of luck")
+	})
+	require.NoError(t, s.Open(ctx))
+	defer runService(s)()
+
+	ok, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "ok", OrgID: orgID, Params: map[string]string{"msg": "hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.JobQueued, ok.State)
+
+	failed, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "fail", OrgID: otherOrgID})
+	require.NoError(t, err)
+
+	j := waitForState(t, s, ok.ID, influxdb.JobSucceeded)
+	assert.JSONEq(t, `{"echo":"hi"}`, string(j.Result))
+	assert.Equal(t, 1.0, j.Progress)
+	assert.Equal(t, 1, j.Attempts)
+	assert.NotNil(t, j.StartedAt)
+	assert.NotNil(t, j.FinishedAt)
+
+	j = waitForState(t, s, failed.ID, influxdb.JobFailed)
+	assert.Equal(t, "out of luck", j.Error)
+
+	t.Run("finds jobs newest first", func(t *testing.T) {
+		jobs, n, err := s.FindJobs(ctx, influxdb.JobFilter{})
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		assert.Equal(t, failed.ID, jobs[0].ID)
+		assert.Equal(t, ok.ID, jobs[1].ID)
+
+		jobs, _, err = s.FindJobs(ctx, influxdb.JobFilter{}, influxdb.FindOptions{Offset: 1, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		assert.Equal(t, ok.ID, jobs[0].ID)
+
+		id := orgID
+		jobs, _, err = s.FindJobs(ctx, influxdb.JobFilter{OrgID: &id})
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		assert.Equal(t, ok.ID, jobs[0].ID)
+
+		jobs, _, err = s.FindJobs(ctx, influxdb.JobFilter{State: influxdb.JobFailed})
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		assert.Equal(t, failed.ID, jobs[0].ID)
+	})
+
+	t.Run("cannot cancel an ended job", func(t *testing.T) {
+		_, err := s.CancelJob(ctx, ok.ID)
+		assert.Equal(t, influxdb.EConflict, influxdb.ErrorCode(err))
+	})
+
+	t.Run("rejects unknown kinds", func(t *testing.T) {
+		_, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "nope", OrgID: orgID})
+		assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+	})
+
+	t.Run("job not found", func(t *testing.T) {
+		_, err := s.FindJobByID(ctx, influxdb.ID(1))
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+	})
+}
+
+func TestService_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(zaptest.NewLogger(t), newTestStore(t))
+	h := newBlockingHandler()
+	s.Register("slow", 2, h.run)
+	require.NoError(t, s.Open(ctx))
+	defer runService(s)()
+
+	var ids []influxdb.ID
+	for i := 0; i < 3; i++ {
+		j, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "slow", OrgID: orgID})
+		require.NoError(t, err)
+		ids = append(ids, j.ID)
+	}
+
+	waitForState(t, s, ids[0], influxdb.JobRunning)
+	waitForState(t, s, ids[1], influxdb.JobRunning)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(s.metrics.running.WithLabelValues("slow")) == 2
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 2, h.startedN())
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.queued.WithLabelValues("slow")))
+
+	j, err := s.FindJobByID(ctx, ids[2])
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.JobQueued, j.State)
+
+	close(h.release)
+	for _, id := range ids {
+		waitForState(t, s, id, influxdb.JobSucceeded)
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(s.metrics.running.WithLabelValues("slow")) == 0
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0.0, testutil.ToFloat64(s.metrics.queued.WithLabelValues("slow")))
+}
+
+func TestService_CancelJob(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(zaptest.NewLogger(t), newTestStore(t))
+	h := newBlockingHandler()
+	s.Register("slow", 1, h.run)
+	require.NoError(t, s.Open(ctx))
+	defer runService(s)()
+
+	running, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "slow", OrgID: orgID})
+	require.NoError(t, err)
+	queued, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "slow", OrgID: orgID})
+	require.NoError(t, err)
+	waitForState(t, s, running.ID, influxdb.JobRunning)
+
+	j, err := s.CancelJob(ctx, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.JobCanceled, j.State)
+
+	_, err = s.CancelJob(ctx, running.ID)
+	require.NoError(t, err)
+	j = waitForState(t, s, running.ID, influxdb.JobCanceled)
+	assert.Equal(t, 0.5, j.Progress)
+	assert.Empty(t, j.Error)
+
+	// The canceled queued job never started.
+	assert.Equal(t, 1, h.startedN())
+}
+
+func TestService_ResumesJobsAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	s := NewService(zaptest.NewLogger(t), store)
+	h := newBlockingHandler()
+	s.Register("slow", 1, h.run)
+	s.Register("gone", 1, h.run)
+	require.NoError(t, s.Open(ctx))
+	stop := runService(s)
+
+	interrupted, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "slow", OrgID: orgID})
+	require.NoError(t, err)
+	queued, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "slow", OrgID: orgID})
+	require.NoError(t, err)
+	waitForState(t, s, interrupted.ID, influxdb.JobRunning)
+	orphan, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "gone", OrgID: orgID})
+	require.NoError(t, err)
+	waitForState(t, s, orphan.ID, influxdb.JobRunning)
+	stop()
+
+	// Jobs interrupted by the stop stay running until the service is opened.
+	j, err := s.FindJobByID(ctx, interrupted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.JobRunning, j.State)
+
+	s = NewService(zaptest.NewLogger(t), store)
+	var order []influxdb.ID
+	var mu sync.Mutex
+	s.Register("slow", 1, func(ctx context.Context, j *influxdb.Job, progress func(float64)) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, j.ID)
+		return nil, nil
+	})
+	require.NoError(t, s.Open(ctx))
+
+	j, err = s.FindJobByID(ctx, orphan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.JobFailed, j.State)
+	assert.Equal(t, `no handler for jobs of kind "gone"`, j.Error)
+
+	defer runService(s)()
+	j = waitForState(t, s, interrupted.ID, influxdb.JobSucceeded)
+	assert.Equal(t, 2, j.Attempts)
+	j = waitForState(t, s, queued.ID, influxdb.JobSucceeded)
+	assert.Equal(t, 1, j.Attempts)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []influxdb.ID{interrupted.ID, queued.ID}, order)
+}
+
+func TestService_Retention(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	s := NewService(zaptest.NewLogger(t), store, WithRetention(time.Hour))
+	s.now = func() time.Time { return now }
+	s.Register("ok", 1, func(ctx context.Context, j *influxdb.Job, progress func(float64)) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, s.Open(ctx))
+	stop := runService(s)
+	j, err := s.SubmitJob(ctx, influxdb.JobCreate{Kind: "ok", OrgID: orgID})
+	require.NoError(t, err)
+	waitForState(t, s, j.ID, influxdb.JobSucceeded)
+	stop()
+
+	now = now.Add(time.Hour)
+	require.NoError(t, s.prune(ctx))
+	_, err = s.FindJobByID(ctx, j.ID)
+	require.NoError(t, err, "jobs are kept for the retention")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, s.prune(ctx))
+	_, err = s.FindJobByID(ctx, j.ID)
+	assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+}
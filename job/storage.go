@@ -0,0 +1,95 @@
+package job
+
+import (
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// Jobs are stored as JSON, keyed by their encoded ID.
+
+func getJob(tx kv.Tx, id influxdb.ID) (*influxdb.Job, error) {
+	key, err := id.Encode()
+	if err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Err:  err,
+		}
+	}
+	b, err := tx.Bucket(jobBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get(key)
+	if kv.IsNotFound(err) {
+		return nil, influxdb.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(v)
+}
+
+func putJob(tx kv.Tx, j *influxdb.Job) error {
+	key, err := j.ID.Encode()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(jobBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, v)
+}
+
+func deleteJob(tx kv.Tx, id influxdb.ID) error {
+	key, err := id.Encode()
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(jobBucket)
+	if err != nil {
+		return err
+	}
+	return b.Delete(key)
+}
+
+// findJobs returns all the stored jobs.
+func findJobs(tx kv.Tx) ([]*influxdb.Job, error) {
+	b, err := tx.Bucket(jobBucket)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := b.ForwardCursor(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var jobs []*influxdb.Job
+	for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+		j, err := decodeJob(v)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, cur.Err()
+}
+
+func decodeJob(v []byte) (*influxdb.Job, error) {
+	j := &influxdb.Job{}
+	if err := json.Unmarshal(v, j); err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInternal,
+			Msg:  "corrupt job",
+			Err:  err,
+		}
+	}
+	return j, nil
+}
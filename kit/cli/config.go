@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// configKeySeparator joins the names of nested config tables and their keys
+// into the flat keys of options, so that
+//
+//	[storage]
+//	cache-max-memory-size = 1073741824
+//
+// sets the storage-cache-max-memory-size option.
+const configKeySeparator = "-"
+
+// loadConfigFile flattens the nested tables of the config file read by v
+// into the flat keys of opts, and expands the environment variables
+// referenced by its string values.
+func loadConfigFile(v *viper.Viper, opts []Opt) error {
+	path := v.ConfigFileUsed()
+	if path == "" {
+		return nil
+	}
+
+	// The file is read again by itself, as the settings of v are already
+	// overridden by environment variables.
+	raw := viper.New()
+	raw.SetConfigFile(path)
+	if err := raw.ReadInConfig(); err != nil {
+		return err
+	}
+
+	keys := make(map[string]bool, len(opts))
+	for _, o := range opts {
+		keys[strings.ToLower(o.Flag)] = true
+		if o.EnvVar != "" {
+			keys[strings.ToLower(o.EnvVar)] = true
+		}
+	}
+
+	flat := make(map[string]interface{})
+	if err := flattenConfig("", raw.AllSettings(), keys, flat); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	for key, val := range flat {
+		expanded, err := expandConfigValue(key, val)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		flat[key] = expanded
+	}
+	return v.MergeConfigMap(flat)
+}
+
+// flattenConfig adds the settings of a config table named prefix to flat,
+// keyed by their flat keys. Tables are flattened unless they are the value
+// of an option in keys, like a map of strings.
+func flattenConfig(prefix string, settings map[string]interface{}, keys map[string]bool, flat map[string]interface{}) error {
+	for name, val := range settings {
+		key := name
+		if prefix != "" {
+			key = prefix + configKeySeparator + name
+		}
+		if table, ok := val.(map[string]interface{}); ok && !keys[key] {
+			if err := flattenConfig(key, table, keys, flat); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := flat[key]; ok {
+			return fmt.Errorf("%q is set both as a key and within a table", key)
+		}
+		flat[key] = val
+	}
+	return nil
+}
+
+// expandConfigValue expands the environment variables referenced by the
+// strings of the value of the config key.
+func expandConfigValue(key string, val interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case string:
+		return expandEnv(key, val)
+	case []interface{}:
+		expanded := make([]interface{}, len(val))
+		for i, v := range val {
+			var err error
+			if expanded[i], err = expandConfigValue(key, v); err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	case []string:
+		expanded := make([]string, len(val))
+		for i, v := range val {
+			var err error
+			if expanded[i], err = expandEnv(key, v); err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			var err error
+			if expanded[k], err = expandConfigValue(key, v); err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	default:
+		return val, nil
+	}
+}
+
+// expandEnv replaces the references to environment variables in s, the
+// value of the config key. ${VAR} is replaced by the value of VAR, which
+// must be set, and ${VAR:-default} by default if VAR is unset or empty.
+// $${ is replaced by a literal ${.
+func expandEnv(key, s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		b.WriteString(s[:i])
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%q: unterminated reference to an environment variable in %q", key, s[i:])
+		}
+		ref := s[i+2 : i+end]
+		s = s[i+end+1:]
+
+		name, def, hasDefault := ref, "", false
+		if j := strings.Index(ref, ":-"); j >= 0 {
+			name, def, hasDefault = ref[:j], ref[j+2:], true
+		}
+		if name == "" {
+			return "", fmt.Errorf("%q: empty reference to an environment variable", key)
+		}
+
+		val, ok := os.LookupEnv(name)
+		switch {
+		case hasDefault && val == "":
+			val = def
+		case !ok:
+			return "", fmt.Errorf("%q: environment variable %s is not set and has no default; use ${%s:-<default>} to give one", key, name, name)
+		}
+		b.WriteString(val)
+	}
+}
+
+// WriteConfigTOML writes the values of opts as a TOML config file. Options
+// whose flags share their first word with another option are written in a
+// table named after it, e.g. storage-wal-fsync-delay as wal-fsync-delay in
+// [storage].
+func WriteConfigTOML(w io.Writer, opts []Opt) error {
+	values := make(map[string]interface{}, len(opts))
+	prefixes := make(map[string]int)
+	for _, o := range opts {
+		if o.Hidden {
+			continue
+		}
+		key := o.Flag
+		if o.EnvVar != "" {
+			key = o.EnvVar
+		}
+		val := configValue(o.DestP)
+		if val == nil {
+			continue
+		}
+		values[key] = val
+		if i := strings.Index(key, configKeySeparator); i > 0 {
+			prefixes[key[:i]]++
+		}
+	}
+
+	config := make(map[string]interface{})
+	for key, val := range values {
+		i := strings.Index(key, configKeySeparator)
+		if i <= 0 || prefixes[key[:i]] < 2 {
+			config[key] = val
+			continue
+		}
+		table, _ := config[key[:i]].(map[string]interface{})
+		if table == nil {
+			table = make(map[string]interface{})
+			config[key[:i]] = table
+		}
+		table[key[i+1:]] = val
+	}
+
+	return toml.NewEncoder(w).Encode(config)
+}
+
+// configValue returns the value of the option destination destP as it is
+// written to a config file, or nil if it has none.
+func configValue(destP interface{}) interface{} {
+	switch destP := destP.(type) {
+	case *string:
+		return *destP
+	case *int:
+		return *destP
+	case *int32:
+		return *destP
+	case *int64:
+		return *destP
+	case *float64:
+		return *destP
+	case *bool:
+		return *destP
+	case *time.Duration:
+		return destP.String()
+	case *[]string:
+		if *destP == nil {
+			return []string{}
+		}
+		return *destP
+	case *[]int:
+		if *destP == nil {
+			return []int{}
+		}
+		return *destP
+	case *[]int64:
+		if *destP == nil {
+			return []int64{}
+		}
+		return *destP
+	case *map[string]string:
+		if *destP == nil {
+			return map[string]string{}
+		}
+		return *destP
+	case pflag.Value:
+		return destP.String()
+	case *influxdb.ID:
+		if !destP.Valid() {
+			return nil
+		}
+		return destP.String()
+	default:
+		return nil
+	}
+}
+
+// NewPrintConfigCommand returns a command printing the values of opts as
+// a TOML config file, nested in tables. The options are expected to be
+// bound to the command.
+func NewPrintConfigCommand(opts []Opt) *cobra.Command {
+	return &cobra.Command{
+		Use:   "print-config",
+		Short: "Print the resolved config as a TOML config file",
+		Long: `
+	Print the value of every option, as resolved from flags, env vars, the
+	config file and defaults, as a TOML config file. Options sharing a prefix
+	are nested in a table, e.g. --storage-wal-fsync-delay as wal-fsync-delay
+	in [storage].`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return WriteConfigTOML(cmd.OutOrStdout(), opts)
+		},
+	}
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTOMLConfigFile writes a TOML config file holding config.
+func newTOMLConfigFile(t *testing.T, config string) (string, func()) {
+	t.Helper()
+
+	testDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+
+	testFile := path.Join(testDir, "config.toml")
+	require.NoError(t, ioutil.WriteFile(testFile, []byte(config), os.ModePerm))
+
+	return testFile, func() {
+		os.RemoveAll(testDir)
+	}
+}
+
+// testConfig holds the options of the config file tests.
+type testConfig struct {
+	bindAddress string
+	debug       bool
+	fsyncDelay  time.Duration
+	cacheSize   int64
+	token       string
+	hosts       []string
+	flags       map[string]string
+}
+
+func (c *testConfig) opts() []Opt {
+	return []Opt{
+		{DestP: &c.bindAddress, Flag: "http-bind-address", Default: ":8086"},
+		{DestP: &c.debug, Flag: "http-debug"},
+		{DestP: &c.fsyncDelay, Flag: "storage-wal-fsync-delay"},
+		{DestP: &c.cacheSize, Flag: "storage-cache-max-memory-size"},
+		{DestP: &c.token, Flag: "vault-token"},
+		{DestP: &c.hosts, Flag: "hosts"},
+		{DestP: &c.flags, Flag: "feature-flags"},
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		env      map[string]string
+		args     []string
+		expected testConfig
+		wantErr  string
+	}{
+		{
+			name: "flat keys",
+			config: `
+http-bind-address = ":9999"
+storage-wal-fsync-delay = "1s"
+`,
+			expected: testConfig{bindAddress: ":9999", fsyncDelay: time.Second},
+		},
+		{
+			name: "nested tables",
+			config: `
+hosts = ["a", "b"]
+
+[http]
+bind-address = ":9999"
+
+[storage]
+wal-fsync-delay = "1s"
+
+[storage.cache]
+max-memory-size = 1024
+
+[feature-flags]
+someFlag = "true"
+`,
+			expected: testConfig{
+				bindAddress: ":9999",
+				fsyncDelay:  time.Second,
+				cacheSize:   1024,
+				hosts:       []string{"a", "b"},
+				flags:       map[string]string{"someflag": "true"},
+			},
+		},
+		{
+			name: "env vars",
+			config: `
+hosts = ["${TEST_CONFIG_HOST}", "$${literal}"]
+
+[http]
+bind-address = "${TEST_CONFIG_UNSET:-:8087}"
+
+[vault]
+token = "pre-${TEST_CONFIG_TOKEN}-post"
+`,
+			env: map[string]string{"TEST_CONFIG_TOKEN": "secret", "TEST_CONFIG_HOST": "host"},
+			expected: testConfig{
+				bindAddress: ":8087",
+				token:       "pre-secret-post",
+				hosts:       []string{"host", "${literal}"},
+			},
+		},
+		{
+			name: "flags and env vars take precedence",
+			config: `
+[http]
+bind-address = ":9999"
+
+[vault]
+token = "${TEST_CONFIG_TOKEN}"
+`,
+			env:  map[string]string{"TEST_CONFIG_TOKEN": "secret", "TEST_VAULT_TOKEN": "other"},
+			args: []string{"--http-bind-address=:1234"},
+			expected: testConfig{
+				bindAddress: ":1234",
+				token:       "other",
+			},
+		},
+		{
+			name: "unset env var",
+			config: `
+[vault]
+token = "${TEST_CONFIG_UNSET}"
+`,
+			wantErr: `"vault-token": environment variable TEST_CONFIG_UNSET is not set and has no default`,
+		},
+		{
+			name: "set twice",
+			config: `
+http-bind-address = ":9999"
+
+[http]
+bind-address = ":9999"
+`,
+			wantErr: `"http-bind-address" is set both as a key and within a table`,
+		},
+	}
+
+	for _, tt := range tests {
+		fn := func(t *testing.T) {
+			configPath, cleanup := newTOMLConfigFile(t, tt.config)
+			defer cleanup()
+			defer setEnvVar("TEST_CONFIG_PATH", configPath)()
+			for k, v := range tt.env {
+				defer setEnvVar(k, v)()
+			}
+
+			var got testConfig
+			cmd := NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: got.opts(),
+			})
+			cmd.SetArgs(tt.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expected.bindAddress == "" {
+				tt.expected.bindAddress = ":8086"
+			}
+			assert.Equal(t, tt.expected.bindAddress, got.bindAddress)
+			assert.Equal(t, tt.expected.fsyncDelay, got.fsyncDelay)
+			assert.Equal(t, tt.expected.cacheSize, got.cacheSize)
+			assert.Equal(t, tt.expected.token, got.token)
+			assert.ElementsMatch(t, tt.expected.hosts, got.hosts)
+			if tt.expected.flags != nil {
+				assert.Equal(t, tt.expected.flags, got.flags)
+			}
+		}
+
+		t.Run(tt.name, fn)
+	}
+}
+
+func TestWriteConfigTOML(t *testing.T) {
+	want := testConfig{
+		bindAddress: ":9999",
+		fsyncDelay:  time.Second,
+		cacheSize:   1024,
+		token:       "secret",
+		hosts:       []string{"a"},
+		flags:       map[string]string{"someFlag": "true"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteConfigTOML(&buf, want.opts()))
+
+	out := buf.String()
+	assert.Contains(t, out, "[http]\n  bind-address = \":9999\"\n  debug = false\n")
+	assert.Contains(t, out, "vault-token = \"secret\"\n")
+	assert.Contains(t, out, "[storage]\n  cache-max-memory-size = 1024\n  wal-fsync-delay = \"1s\"\n")
+	assert.Contains(t, out, "hosts = [\"a\"]\n")
+
+	// The config is read back as it was written.
+	configPath, cleanup := newTOMLConfigFile(t, out)
+	defer cleanup()
+	defer setEnvVar("TEST_CONFIG_PATH", configPath)()
+
+	var got testConfig
+	cmd := NewCommand(viper.New(), &Program{
+		Run:  func() error { return nil },
+		Name: "test",
+		Opts: got.opts(),
+	})
+	cmd.SetArgs(nil)
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, want.bindAddress, got.bindAddress)
+	assert.Equal(t, want.fsyncDelay, got.fsyncDelay)
+	assert.Equal(t, want.cacheSize, got.cacheSize)
+	assert.Equal(t, want.token, got.token)
+	assert.Equal(t, want.hosts, got.hosts)
+	assert.Equal(t, map[string]string{"someflag": "true"}, got.flags)
+}
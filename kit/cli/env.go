@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// envKeyReplacer normalizes "-" to an underscore in env names, matching the
+// replacer NewCommand installs on viper.
+var envKeyReplacer = strings.NewReplacer("-", "_")
+
+// EnvVarName returns the environment variable that sets the option for a
+// program named prefix.
+func EnvVarName(prefix string, o Opt) string {
+	key := o.Flag
+	if o.EnvVar != "" {
+		key = o.EnvVar
+	}
+	if prefix != "" {
+		key = prefix + "_" + key
+	}
+	return strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// EnvVar describes the environment variable of an option.
+type EnvVar struct {
+	Name    string
+	Flag    string
+	Default string
+	Desc    string
+	// Hidden is true if the flag of the option is hidden. The environment
+	// variable still sets it.
+	Hidden bool
+}
+
+// ListEnvVars returns the environment variables of the opts of a program
+// named prefix, including those of hidden options.
+func ListEnvVars(prefix string, opts []Opt) []EnvVar {
+	vars := make([]EnvVar, 0, len(opts))
+	for _, o := range opts {
+		vars = append(vars, EnvVar{
+			Name:    EnvVarName(prefix, o),
+			Flag:    o.Flag,
+			Default: formatDefault(o.Default),
+			Desc:    o.Desc,
+			Hidden:  o.Hidden,
+		})
+	}
+	return vars
+}
+
+// description returns the description of v, marked if its option is hidden.
+func (v EnvVar) description() string {
+	if !v.Hidden {
+		return v.Desc
+	}
+	return strings.TrimSpace("(hidden) " + v.Desc)
+}
+
+func formatDefault(d interface{}) string {
+	if fn, ok := d.(func() interface{}); ok {
+		d = fn()
+	}
+	switch d := d.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(d, ",")
+	case map[string]string:
+		pairs := make([]string, 0, len(d))
+		for k, v := range d {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(d)
+	}
+}
+
+// WriteEnvTable writes vars as a table.
+func WriteEnvTable(w io.Writer, vars []EnvVar) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENV VAR\tFLAG\tDEFAULT\tDESCRIPTION")
+	for _, v := range vars {
+		fmt.Fprintf(tw, "%s\t--%s\t%s\t%s\n", v.Name, v.Flag, v.Default, v.description())
+	}
+	return tw.Flush()
+}
+
+// WriteEnvExports writes vars as shell export statements set to their
+// defaults, each preceded by its description.
+func WriteEnvExports(w io.Writer, vars []EnvVar) error {
+	for _, v := range vars {
+		if desc := v.description(); desc != "" {
+			if _, err := fmt.Fprintf(w, "# %s\n", desc); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", v.Name, shellQuote(v.Default)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// NewListEnvCommand returns a command printing the environment variables of
+// the opts of a program named name.
+func NewListEnvCommand(name string, opts []Opt) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "list-env",
+		Short: "List the environment variables of every option",
+		Long: fmt.Sprintf(`
+	List the environment variable that sets each option of %s, along with
+	its flag, default and description. Options whose flag is hidden are
+	listed too, marked as hidden.
+
+	With --format=export the list is printed as shell export statements set
+	to the defaults, ready to be edited and sourced.`, name),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			vars := ListEnvVars(strings.ToUpper(name), opts)
+			switch format {
+			case "table":
+				return WriteEnvTable(cmd.OutOrStdout(), vars)
+			case "export":
+				return WriteEnvExports(cmd.OutOrStdout(), vars)
+			default:
+				return fmt.Errorf("unknown format %q, expected table or export", format)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table or export")
+	return cmd
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvVarName(t *testing.T) {
+	var (
+		httpBindAddress string
+		storeTimeout    time.Duration
+		legacy          bool
+	)
+	opts := []Opt{
+		{DestP: &httpBindAddress, Flag: "http-bind-address", Default: ":8086"},
+		{DestP: &storeTimeout, Flag: "store-timeout", Default: time.Second},
+		{DestP: &legacy, Flag: "legacy", EnvVar: "old-legacy-name"},
+	}
+
+	assert.Equal(t, "ENVTEST_HTTP_BIND_ADDRESS", EnvVarName("envtest", opts[0]))
+	assert.Equal(t, "ENVTEST_STORE_TIMEOUT", EnvVarName("envtest", opts[1]))
+	assert.Equal(t, "ENVTEST_OLD_LEGACY_NAME", EnvVarName("envtest", opts[2]))
+
+	// The listed names must be the ones the command reads.
+	for k, v := range map[string]string{
+		EnvVarName("envtest", opts[0]): ":9999",
+		EnvVarName("envtest", opts[1]): "5s",
+		EnvVarName("envtest", opts[2]): "true",
+	} {
+		require.NoError(t, os.Setenv(k, v))
+		defer os.Unsetenv(k)
+	}
+	cmd := NewCommand(viper.New(), &Program{
+		Run:  func() error { return nil },
+		Name: "envtest",
+		Opts: opts,
+	})
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, ":9999", httpBindAddress)
+	assert.Equal(t, 5*time.Second, storeTimeout)
+	assert.True(t, legacy)
+}
+
+func Test_NewListEnvCommand(t *testing.T) {
+	var (
+		dataDir string
+		tags    []string
+		secret  string
+	)
+	opts := []Opt{
+		{DestP: &dataDir, Flag: "data-dir", Default: func() interface{} { return "/var/lib/it's" }, Desc: "data directory"},
+		{DestP: &tags, Flag: "tags", Default: []string{"a", "b"}, Desc: "tags"},
+		{DestP: &secret, Flag: "secret", Hidden: true},
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		cmd := NewListEnvCommand("prog", opts)
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{})
+		require.NoError(t, cmd.Execute())
+
+		assert.Equal(t, `ENV VAR        FLAG        DEFAULT        DESCRIPTION
+PROG_DATA_DIR  --data-dir  /var/lib/it's  data directory
+PROG_TAGS      --tags      a,b            tags
+PROG_SECRET    --secret                   (hidden)
+`, buf.String())
+	})
+
+	t.Run("export", func(t *testing.T) {
+		var buf bytes.Buffer
+		cmd := NewListEnvCommand("prog", opts)
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"--format", "export"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Equal(t, `# data directory
+export PROG_DATA_DIR='/var/lib/it'\''s'
+# tags
+export PROG_TAGS='a,b'
+# (hidden)
+export PROG_SECRET=''
+`, buf.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		cmd := NewListEnvCommand("prog", opts)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"--format", "yaml"})
+		assert.Error(t, cmd.Execute())
+	})
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/pflag"
+)
+
+// Wrapper for url.URL
+type urlValue url.URL
+
+func newURLValue(val *url.URL, p *url.URL) *urlValue {
+	if val != nil {
+		*p = *val
+	}
+	return (*urlValue)(p)
+}
+
+func (u *urlValue) String() string { return (*url.URL)(u).String() }
+func (u *urlValue) Set(s string) error {
+	parsed, err := parseURL(s)
+	if err != nil {
+		return err
+	}
+	*u = urlValue(*parsed)
+	return nil
+}
+
+func (u *urlValue) Type() string {
+	return "URL"
+}
+
+// parseURL parses s as an absolute URL, with a scheme and a host.
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: must be absolute, like http://localhost:8086", s)
+	}
+	return u, nil
+}
+
+// URLVar defines a url.URL flag with specified name, default value, and usage string.
+// The argument p points to a url.URL variable in which to store the value of the flag.
+// A nil value leaves the URL empty.
+func URLVar(fs *pflag.FlagSet, p *url.URL, name string, value *url.URL, usage string) {
+	URLVarP(fs, p, name, "", value, usage)
+}
+
+// URLVarP is like URLVar, but accepts a shorthand letter that can be used after a single dash.
+func URLVarP(fs *pflag.FlagSet, p *url.URL, name, shorthand string, value *url.URL, usage string) {
+	fs.VarP(newURLValue(value, p), name, shorthand, usage)
+}
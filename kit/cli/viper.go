@@ -2,8 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +26,14 @@ type Opt struct {
 	Required   bool
 	Short      rune // using rune b/c it guarantees correctness. a short must always be a string of length 1
 
+	// Deprecated is the message printed when a deprecated option is used,
+	// which is also hidden from the help. A deprecated option cannot be
+	// required.
+	Deprecated string
+
+	// Default is the default value of the option. It may also be a
+	// func() interface{}, which is evaluated when the option is bound
+	// to allow for defaults that depend on the environment.
 	Default interface{}
 	Desc    string
 }
@@ -66,7 +76,7 @@ func NewCommand(v *viper.Viper, p *Program) *cobra.Command {
 	v.SetEnvPrefix(strings.ToUpper(p.Name))
 	v.AutomaticEnv()
 	// This normalizes "-" to an underscore in env names.
-	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.SetEnvKeyReplacer(envKeyReplacer)
 
 	if configPath := v.GetString("CONFIG_PATH"); configPath != "" {
 		switch path.Ext(configPath) {
@@ -87,33 +97,88 @@ func NewCommand(v *viper.Viper, p *Program) *cobra.Command {
 	//	1. flags
 	//  2. env vars
 	//	3. config file
-	if err := initializeConfig(v); err != nil {
-		panic("invalid config file caused panic: " + err.Error())
+	if err := initializeConfig(v, p.Opts); err != nil {
+		// The command and its subcommands fail with the error when run.
+		cmd.PersistentPreRunE = func(*cobra.Command, []string) error {
+			return fmt.Errorf("invalid config file: %w", err)
+		}
 	}
 	BindOptions(v, cmd, p.Opts)
 
 	return cmd
 }
 
-func initializeConfig(v *viper.Viper) error {
+func initializeConfig(v *viper.Viper, opts []Opt) error {
 	err := v.ReadInConfig()
 	if err != nil && !os.IsNotExist(err) {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return err
 		}
 	}
+	if err != nil {
+		return nil
+	}
+	return loadConfigFile(v, opts)
+}
+
+// validateShorts checks that the short flags of opts are ASCII letters, each
+// used by a single option.
+func validateShorts(opts []Opt) error {
+	byShort := make(map[rune]string, len(opts))
+	for _, o := range opts {
+		if o.Short == 0 {
+			continue
+		}
+		if !(o.Short >= 'a' && o.Short <= 'z' || o.Short >= 'A' && o.Short <= 'Z') {
+			return fmt.Errorf("option %q has short flag %q; it must be a single ASCII letter", o.Flag, o.Short)
+		}
+		if flag, ok := byShort[o.Short]; ok {
+			return fmt.Errorf("options %q and %q both use the short flag -%c", flag, o.Flag, o.Short)
+		}
+		byShort[o.Short] = o.Flag
+	}
 	return nil
 }
 
+// negationPrefix prefixes the hidden flags setting boolean options to false.
+const negationPrefix = "no-"
+
+// negationNote is added to the help of commands with negatable boolean flags.
+const negationNote = "Boolean flags can be set to false with --no-<flag>, which takes precedence over env vars and config files."
+
 // BindOptions adds opts to the specified command and automatically
 // registers those options with viper.
+//
+// Every boolean option also gets a hidden --no-<flag> companion setting it
+// to false. Passing both forms is an error. The companion of a deprecated
+// option is deprecated too.
+//
+// A *url.URL option must be an absolute URL; its default is a string or a
+// *url.URL. An invalid env var or config file value makes the command fail
+// when run, unless the flag is set.
+//
+// It panics, before registering any option, if the short flag of an option
+// is not an ASCII letter or is shared by two options.
 func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) {
+	if err := validateShorts(opts); err != nil {
+		panic(err)
+	}
+
+	flags := make(map[string]bool, len(opts))
+	for _, o := range opts {
+		flags[o.Flag] = true
+	}
+
+	var negatable bool
 	for _, o := range opts {
 		flagset := cmd.Flags()
 		if o.Persistent {
 			flagset = cmd.PersistentFlags()
 		}
 
+		if o.Required && o.Deprecated != "" {
+			panic(fmt.Errorf("option %q cannot be both required and deprecated", o.Flag))
+		}
 		if o.Required {
 			cmd.MarkFlagRequired(o.Flag)
 		}
@@ -124,6 +189,11 @@ func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) {
 		}
 
 		hasShort := o.Short != 0
+		var negated bool
+
+		if fn, ok := o.Default.(func() interface{}); ok {
+			o.Default = fn()
+		}
 
 		switch destP := o.DestP.(type) {
 		case *string:
@@ -202,6 +272,24 @@ func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) {
 			}
 			mustBindPFlag(v, o.Flag, flagset)
 			*destP = v.GetInt64(envVar)
+		case *float64:
+			var d float64
+			if o.Default != nil {
+				// As for int64 fields, an int default is easy to give a float64
+				// field by mistake, so both float64 and int defaults are supported.
+				var ok bool
+				d, ok = o.Default.(float64)
+				if !ok {
+					d = float64(o.Default.(int))
+				}
+			}
+			if hasShort {
+				flagset.Float64VarP(destP, o.Flag, string(o.Short), d, o.Desc)
+			} else {
+				flagset.Float64Var(destP, o.Flag, d, o.Desc)
+			}
+			mustBindPFlag(v, o.Flag, flagset)
+			*destP = v.GetFloat64(envVar)
 		case *bool:
 			var d bool
 			if o.Default != nil {
@@ -214,6 +302,9 @@ func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) {
 			}
 			mustBindPFlag(v, o.Flag, flagset)
 			*destP = v.GetBool(envVar)
+			if !flags[negationPrefix+o.Flag] && bindNegation(flagset, o.Flag, destP) {
+				negatable, negated = true, true
+			}
 		case *time.Duration:
 			var d time.Duration
 			if o.Default != nil {
@@ -238,6 +329,46 @@ func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) {
 			}
 			mustBindPFlag(v, o.Flag, flagset)
 			*destP = v.GetStringSlice(envVar)
+		case *[]int:
+			var d []int
+			if o.Default != nil {
+				d = o.Default.([]int)
+			}
+			if hasShort {
+				flagset.IntSliceVarP(destP, o.Flag, string(o.Short), d, o.Desc)
+			} else {
+				flagset.IntSliceVar(destP, o.Flag, d, o.Desc)
+			}
+			mustBindPFlag(v, o.Flag, flagset)
+			ints64 := getIntSlice(v, envVar)
+			ints := make([]int, len(ints64))
+			for i, n := range ints64 {
+				ints[i] = int(n)
+			}
+			*destP = ints
+		case *[]int64:
+			var d []int64
+			if o.Default != nil {
+				// N.B. as with int64 fields, literal numbers get typed as int by
+				// default, so we support both []int64 and []int defaults for
+				// []int64 fields.
+				var ok bool
+				d, ok = o.Default.([]int64)
+				if !ok {
+					ints := o.Default.([]int)
+					d = make([]int64, len(ints))
+					for i, n := range ints {
+						d[i] = int64(n)
+					}
+				}
+			}
+			if hasShort {
+				flagset.Int64SliceVarP(destP, o.Flag, string(o.Short), d, o.Desc)
+			} else {
+				flagset.Int64SliceVar(destP, o.Flag, d, o.Desc)
+			}
+			mustBindPFlag(v, o.Flag, flagset)
+			*destP = getIntSlice(v, envVar)
 		case *map[string]string:
 			var d map[string]string
 			if o.Default != nil {
@@ -274,6 +405,32 @@ func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) {
 			if s := v.GetString(envVar); s != "" {
 				_ = (*destP).DecodeFromString(v.GetString(envVar))
 			}
+		case *url.URL:
+			var d *url.URL
+			switch dflt := o.Default.(type) {
+			case nil:
+			case string:
+				u, err := parseURL(dflt)
+				if err != nil {
+					panic(fmt.Errorf("option %q has an invalid default: %w", o.Flag, err))
+				}
+				d = u
+			case *url.URL:
+				d = dflt
+			default:
+				panic(fmt.Errorf("option %q has a default of type %T; it must be a string or a *url.URL", o.Flag, o.Default))
+			}
+			if hasShort {
+				URLVarP(flagset, destP, o.Flag, string(o.Short), d, o.Desc)
+			} else {
+				URLVar(flagset, destP, o.Flag, d, o.Desc)
+			}
+			mustBindPFlag(v, o.Flag, flagset)
+			if s := v.GetString(envVar); s != "" {
+				if err := (*urlValue)(destP).Set(s); err != nil {
+					failUnlessChanged(cmd, flagset, o.Flag, fmt.Errorf("invalid value for option %q: %w", o.Flag, err))
+				}
+			}
 		default:
 			// if you get a panic here, sorry about that!
 			// anyway, go ahead and make a PR and add another type.
@@ -285,7 +442,128 @@ func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) {
 		if o.Hidden {
 			flagset.MarkHidden(o.Flag)
 		}
+		if o.Deprecated != "" {
+			flagset.MarkDeprecated(o.Flag, o.Deprecated)
+			if negated {
+				flagset.MarkDeprecated(negationPrefix+o.Flag, o.Deprecated)
+			}
+		}
+	}
+
+	if negatable && !strings.Contains(cmd.Long, negationNote) {
+		long := strings.TrimRight(cmd.Long, "\n")
+		if long == "" {
+			long = cmd.Short
+		}
+		if long != "" {
+			long += "\n\n"
+		}
+		cmd.Long = long + negationNote + "\n"
+	}
+}
+
+// failUnlessChanged makes cmd fail with err when run, after any error it
+// already fails with, unless flag is set on the command line. A flag takes
+// precedence over the env var or config file value err is about.
+func failUnlessChanged(cmd *cobra.Command, flagset *pflag.FlagSet, flag string, err error) {
+	prev := cmd.PersistentPreRunE
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		if prev != nil {
+			if err := prev(c, args); err != nil {
+				return err
+			}
+		}
+		if flagset.Changed(flag) {
+			return nil
+		}
+		return err
+	}
+}
+
+// bindNegation registers the hidden --no-<flag> companion of a boolean flag
+// and returns false if the flag cannot be negated. Flags that already read
+// as a negation, like --no-tasks, are not negated again.
+func bindNegation(flagset *pflag.FlagSet, flag string, destP *bool) bool {
+	name := negationPrefix + flag
+	if strings.HasPrefix(flag, negationPrefix) || flagset.Lookup(name) != nil {
+		return false
+	}
+
+	pos := flagset.Lookup(flag)
+	neg := flagset.VarPF(&negatedBool{flag: flag, destP: destP, other: pos}, name, "", fmt.Sprintf("Set --%s to false", flag))
+	neg.NoOptDefVal = "true"
+	neg.Hidden = true
+	pos.Value = &exclusiveValue{Value: pos.Value, flag: flag, other: neg}
+	return true
+}
+
+// negatedBool is the value of a --no-<flag> flag. It sets the boolean of
+// the negated flag to the opposite of its own value.
+type negatedBool struct {
+	flag  string
+	destP *bool
+	other *pflag.Flag
+	value bool
+}
+
+func (b *negatedBool) String() string { return strconv.FormatBool(b.value) }
+
+func (b *negatedBool) Type() string { return "bool" }
+
+func (b *negatedBool) Set(s string) error {
+	if b.other.Changed {
+		return fmt.Errorf("cannot use --%s%s with --%s", negationPrefix, b.flag, b.flag)
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value = v
+	*b.destP = !v
+	return nil
+}
+
+// exclusiveValue wraps the value of a negatable flag to refuse being set
+// once its --no-<flag> companion was passed.
+type exclusiveValue struct {
+	pflag.Value
+	flag  string
+	other *pflag.Flag
+}
+
+func (v *exclusiveValue) Set(s string) error {
+	if v.other.Changed {
+		return fmt.Errorf("cannot use --%s with --%s%s", v.flag, negationPrefix, v.flag)
+	}
+	return v.Value.Set(s)
+}
+
+// getIntSlice returns the integers of the key. Viper casts the values of
+// int slice flags and config files with GetIntSlice, but returns env vars
+// and int64 slice flags as strings, which are parsed here as comma separated
+// lists. Like viper, it returns an empty slice for values it cannot parse.
+func getIntSlice(v *viper.Viper, key string) []int64 {
+	s, ok := v.Get(key).(string)
+	if !ok {
+		ints := v.GetIntSlice(key)
+		ints64 := make([]int64, len(ints))
+		for i, n := range ints {
+			ints64[i] = int64(n)
+		}
+		return ints64
+	}
+
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "["), "]")
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	ints := make([]int64, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.ParseInt(f, 0, 64)
+		if err != nil {
+			return []int64{}
+		}
+		ints = append(ints, n)
 	}
+	return ints
 }
 
 func mustBindPFlag(v *viper.Viper, key string, flagset *pflag.FlagSet) {
@@ -1,15 +1,18 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math"
+	"net/url"
 	"os"
 	"path"
 	"testing"
 	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -214,6 +217,167 @@ func Test_NewProgram(t *testing.T) {
 	}
 }
 
+func Test_BindOptions_DefaultFunc(t *testing.T) {
+	t.Run("function default is evaluated at bind time", func(t *testing.T) {
+		var called int
+		var dataDir string
+		var port int
+		cmd := NewCommand(viper.New(), &Program{
+			Run:  func() error { return nil },
+			Name: "test",
+			Opts: []Opt{
+				{
+					DestP: &dataDir,
+					Flag:  "data-dir",
+					Default: func() interface{} {
+						called++
+						return "/home/influx/.influxdbv2"
+					},
+				},
+				{
+					DestP:   &port,
+					Flag:    "port",
+					Default: func() interface{} { return 8086 },
+				},
+			},
+		})
+		require.Equal(t, 1, called)
+
+		cmd.SetArgs([]string{})
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "/home/influx/.influxdbv2", dataDir)
+		assert.Equal(t, 8086, port)
+		assert.Equal(t, "/home/influx/.influxdbv2", cmd.Flag("data-dir").DefValue)
+	})
+
+	t.Run("function default is type checked", func(t *testing.T) {
+		var port int
+		assert.Panics(t, func() {
+			NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: []Opt{
+					{
+						DestP:   &port,
+						Flag:    "port",
+						Default: func() interface{} { return "8086" },
+					},
+				},
+			})
+		})
+	})
+}
+
+func Test_BindOptions_Negation(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		envVal   string
+		args     []string
+		expected bool
+		wantErr  string
+	}{
+		{
+			name:     "default",
+			expected: true,
+		},
+		{
+			name:     "negation overrides default",
+			args:     []string{"--no-sleep"},
+			expected: false,
+		},
+		{
+			name:     "negation overrides config",
+			config:   map[string]interface{}{"sleep": true},
+			args:     []string{"--no-sleep"},
+			expected: false,
+		},
+		{
+			name:     "negation overrides env var",
+			config:   map[string]interface{}{"sleep": true},
+			envVal:   "true",
+			args:     []string{"--no-sleep"},
+			expected: false,
+		},
+		{
+			name:     "env var overrides config",
+			config:   map[string]interface{}{"sleep": false},
+			envVal:   "true",
+			expected: true,
+		},
+		{
+			name:     "explicit negation value",
+			envVal:   "false",
+			args:     []string{"--no-sleep=false"},
+			expected: true,
+		},
+		{
+			name:    "both forms",
+			args:    []string{"--sleep", "--no-sleep"},
+			wantErr: "cannot use --no-sleep with --sleep",
+		},
+		{
+			name:    "both forms reversed",
+			args:    []string{"--no-sleep", "--sleep=true"},
+			wantErr: "cannot use --sleep with --no-sleep",
+		},
+	}
+
+	for _, tt := range tests {
+		fn := func(t *testing.T) {
+			if tt.config != nil {
+				configPath, cleanup := newConfigFile(t, tt.config)
+				defer cleanup()
+				defer setEnvVar("TEST_CONFIG_PATH", configPath)()
+			}
+			if tt.envVal != "" {
+				defer setEnvVar("TEST_SLEEP", tt.envVal)()
+			}
+
+			var sleep bool
+			cmd := NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: []Opt{
+					{
+						DestP:   &sleep,
+						Flag:    "sleep",
+						Default: true,
+					},
+				},
+			})
+			cmd.SetArgs(tt.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sleep)
+		}
+
+		t.Run(tt.name, fn)
+	}
+
+	t.Run("help", func(t *testing.T) {
+		var sleep, noTasks bool
+		cmd := NewCommand(viper.New(), &Program{
+			Run:  func() error { return nil },
+			Name: "test",
+			Opts: []Opt{
+				{DestP: &sleep, Flag: "sleep"},
+				{DestP: &noTasks, Flag: "no-tasks"},
+			},
+		})
+		assert.True(t, cmd.Flags().Lookup("no-sleep").Hidden)
+		assert.Nil(t, cmd.Flags().Lookup("no-no-tasks"))
+		assert.Equal(t, negationNote+"\n", cmd.Long)
+	})
+}
+
 func setEnvVar(key, val string) func() {
 	old := os.Getenv(key)
 	os.Setenv(key, val)
@@ -238,3 +402,378 @@ func newConfigFile(t *testing.T, config interface{}) (string, func()) {
 		os.RemoveAll(testDir)
 	}
 }
+
+func Test_BindOptions_IntSlices(t *testing.T) {
+	tests := []struct {
+		name       string
+		envVarVal  string
+		args       []string
+		expected   []int
+		expected64 []int64
+	}{
+		{
+			name:       "defaults",
+			expected:   []int{1, 2},
+			expected64: []int64{3, 4},
+		},
+		{
+			name:       "reads from env var",
+			envVarVal:  "5,6",
+			expected:   []int{1, 2},
+			expected64: []int64{5, 6},
+		},
+		{
+			name:       "reads from flags",
+			args:       []string{"--ints=7", "--ints=8", "-s", "9,10"},
+			expected:   []int{7, 8},
+			expected64: []int64{9, 10},
+		},
+		{
+			name:       "flag has highest precedence",
+			envVarVal:  "5,6",
+			args:       []string{"--shard-ids=11"},
+			expected:   []int{1, 2},
+			expected64: []int64{11},
+		},
+	}
+
+	for _, tt := range tests {
+		fn := func(t *testing.T) {
+			if tt.envVarVal != "" {
+				defer setEnvVar("TEST_SHARD_IDS", tt.envVarVal)()
+			}
+
+			var ints []int
+			var ints64 []int64
+			cmd := NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: []Opt{
+					{
+						DestP:   &ints,
+						Flag:    "ints",
+						Default: []int{1, 2},
+					},
+					{
+						DestP: &ints64,
+						Flag:  "shard-ids",
+						Short: 's',
+						// An []int default is accepted for an []int64 option.
+						Default: []int{3, 4},
+					},
+				},
+			})
+			cmd.SetArgs(append([]string{}, tt.args...))
+			require.NoError(t, cmd.Execute())
+
+			assert.Equal(t, tt.expected, ints)
+			assert.Equal(t, tt.expected64, ints64)
+		}
+
+		t.Run(tt.name, fn)
+	}
+}
+
+func Test_BindOptions_Float64(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    map[string]interface{}
+		envVarVal string
+		args      []string
+		expected  float64
+		// expectedInt is the value of the option with an int default.
+		expectedInt float64
+	}{
+		{
+			name:        "defaults",
+			expected:    0.5,
+			expectedInt: 1,
+		},
+		{
+			name:        "reads from config",
+			config:      map[string]interface{}{"ratio": 0.25, "threshold": 2.5},
+			expected:    0.25,
+			expectedInt: 2.5,
+		},
+		{
+			name:        "env var has precedence over config",
+			config:      map[string]interface{}{"ratio": 0.25},
+			envVarVal:   "0.75",
+			expected:    0.75,
+			expectedInt: 1,
+		},
+		{
+			name:        "flag has highest precedence",
+			config:      map[string]interface{}{"ratio": 0.25},
+			envVarVal:   "0.75",
+			args:        []string{"--ratio=0.125", "-t", "3.5"},
+			expected:    0.125,
+			expectedInt: 3.5,
+		},
+	}
+
+	for _, tt := range tests {
+		fn := func(t *testing.T) {
+			if tt.config != nil {
+				testFilePath, cleanup := newConfigFile(t, tt.config)
+				defer cleanup()
+				defer setEnvVar("TEST_CONFIG_PATH", testFilePath)()
+			}
+			if tt.envVarVal != "" {
+				defer setEnvVar("TEST_RATIO", tt.envVarVal)()
+			}
+
+			var ratio, threshold float64
+			cmd := NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: []Opt{
+					{
+						DestP:   &ratio,
+						Flag:    "ratio",
+						Default: 0.5,
+					},
+					{
+						DestP: &threshold,
+						Flag:  "threshold",
+						Short: 't',
+						// An int default is accepted for a float64 option.
+						Default: 1,
+					},
+				},
+			})
+			cmd.SetArgs(append([]string{}, tt.args...))
+			require.NoError(t, cmd.Execute())
+
+			assert.Equal(t, tt.expected, ratio)
+			assert.Equal(t, tt.expectedInt, threshold)
+		}
+
+		t.Run(tt.name, fn)
+	}
+}
+
+func Test_BindOptions_Deprecated(t *testing.T) {
+	newCmd := func(name *string, verbose *bool) *cobra.Command {
+		return NewCommand(viper.New(), &Program{
+			Run:  func() error { return nil },
+			Name: "test",
+			Opts: []Opt{
+				{
+					DestP:      name,
+					Flag:       "name",
+					Deprecated: "use the --bucket flag",
+				},
+				{
+					DestP:      verbose,
+					Flag:       "verbose",
+					Deprecated: "the output is always verbose",
+				},
+			},
+		})
+	}
+
+	t.Run("still sets the option with a warning", func(t *testing.T) {
+		var name string
+		var verbose bool
+		cmd := newCmd(&name, &verbose)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs([]string{"--name", "b1", "--verbose"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Equal(t, "b1", name)
+		assert.True(t, verbose)
+		assert.Contains(t, out.String(), "Flag --name has been deprecated, use the --bucket flag")
+		assert.Contains(t, out.String(), "Flag --verbose has been deprecated, the output is always verbose")
+	})
+
+	t.Run("hides the option and its negation", func(t *testing.T) {
+		var name string
+		var verbose bool
+		cmd := newCmd(&name, &verbose)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs([]string{"--no-verbose"})
+		require.NoError(t, cmd.Execute())
+
+		assert.False(t, verbose)
+		assert.Contains(t, out.String(), "Flag --no-verbose has been deprecated, the output is always verbose")
+		assert.NotContains(t, cmd.UsageString(), "--name")
+		assert.NotContains(t, cmd.UsageString(), "--verbose")
+	})
+
+	t.Run("cannot be required", func(t *testing.T) {
+		var name string
+		assert.Panics(t, func() {
+			NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: []Opt{
+					{
+						DestP:      &name,
+						Flag:       "name",
+						Required:   true,
+						Deprecated: "use the --bucket flag",
+					},
+				},
+			})
+		})
+	})
+}
+
+func Test_BindOptions_Shorts(t *testing.T) {
+	newCmd := func(opts ...Opt) *cobra.Command {
+		return NewCommand(viper.New(), &Program{
+			Run:  func() error { return nil },
+			Name: "test",
+			Opts: opts,
+		})
+	}
+	var org, output, bucket string
+
+	t.Run("registers distinct shorts", func(t *testing.T) {
+		cmd := newCmd(
+			Opt{DestP: &org, Flag: "org", Short: 'o'},
+			Opt{DestP: &output, Flag: "output", Short: 'O'},
+			Opt{DestP: &bucket, Flag: "bucket"},
+		)
+		cmd.SetArgs([]string{"-o", "o1", "-O", "json"})
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "o1", org)
+		assert.Equal(t, "json", output)
+	})
+
+	t.Run("rejects a duplicated short", func(t *testing.T) {
+		cmd := &cobra.Command{Use: "test"}
+		assert.PanicsWithError(t, `options "org" and "output" both use the short flag -o`, func() {
+			BindOptions(viper.New(), cmd, []Opt{
+				{DestP: &org, Flag: "org", Short: 'o'},
+				{DestP: &output, Flag: "output", Short: 'o'},
+			})
+		})
+		// Nothing was registered.
+		assert.Nil(t, cmd.Flags().Lookup("org"))
+	})
+
+	for _, short := range []rune{'1', '-', 'é', ' '} {
+		t.Run(fmt.Sprintf("rejects short %q", short), func(t *testing.T) {
+			assert.PanicsWithError(t, fmt.Sprintf("option %q has short flag %q; it must be a single ASCII letter", "org", short), func() {
+				newCmd(Opt{DestP: &org, Flag: "org", Short: short})
+			})
+		})
+	}
+}
+
+func Test_BindOptions_URL(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    map[string]interface{}
+		envVarVal string
+		args      []string
+		expected  string
+		// expectedErr is the error running the command fails with.
+		expectedErr string
+	}{
+		{
+			name:     "defaults",
+			expected: "http://localhost:8086",
+		},
+		{
+			name:     "reads from config",
+			config:   map[string]interface{}{"host": "https://config.example.com"},
+			expected: "https://config.example.com",
+		},
+		{
+			name:      "env var has precedence over config",
+			config:    map[string]interface{}{"host": "https://config.example.com"},
+			envVarVal: "https://env.example.com:9999/path",
+			expected:  "https://env.example.com:9999/path",
+		},
+		{
+			name:      "flag has highest precedence",
+			envVarVal: "https://env.example.com",
+			args:      []string{"-u", "http://flag.example.com"},
+			expected:  "http://flag.example.com",
+		},
+		{
+			name:        "malformed env var",
+			envVarVal:   "http://[::1",
+			expectedErr: `invalid value for option "host": invalid URL: parse "http://[::1": missing ']' in host`,
+		},
+		{
+			name:        "relative config value",
+			config:      map[string]interface{}{"host": "localhost"},
+			expectedErr: `invalid value for option "host": invalid URL "localhost": must be absolute, like http://localhost:8086`,
+		},
+		{
+			name:      "flag overrides a malformed env var",
+			envVarVal: "http://[::1",
+			args:      []string{"--host", "http://flag.example.com"},
+			expected:  "http://flag.example.com",
+		},
+		{
+			name:        "malformed flag",
+			args:        []string{"--host", "example.com"},
+			expectedErr: `invalid argument "example.com" for "-u, --host" flag: invalid URL "example.com": must be absolute, like http://localhost:8086`,
+		},
+	}
+
+	for _, tt := range tests {
+		fn := func(t *testing.T) {
+			if tt.config != nil {
+				testFilePath, cleanup := newConfigFile(t, tt.config)
+				defer cleanup()
+				defer setEnvVar("TEST_CONFIG_PATH", testFilePath)()
+			}
+			if tt.envVarVal != "" {
+				defer setEnvVar("TEST_HOST", tt.envVarVal)()
+			}
+
+			var host, proxy url.URL
+			cmd := NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: []Opt{
+					{
+						DestP:   &host,
+						Flag:    "host",
+						Short:   'u',
+						Default: "http://localhost:8086",
+					},
+					{
+						DestP: &proxy,
+						Flag:  "proxy",
+					},
+				},
+			})
+			cmd.SetOut(ioutil.Discard)
+			cmd.SetErr(ioutil.Discard)
+			cmd.SetArgs(append([]string{}, tt.args...))
+			err := cmd.Execute()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, host.String())
+			assert.Equal(t, url.URL{}, proxy)
+		}
+
+		t.Run(tt.name, fn)
+	}
+
+	t.Run("invalid default", func(t *testing.T) {
+		var host url.URL
+		assert.Panics(t, func() {
+			NewCommand(viper.New(), &Program{
+				Run:  func() error { return nil },
+				Name: "test",
+				Opts: []Opt{{DestP: &host, Flag: "host", Default: "localhost"}},
+			})
+		})
+	})
+}
@@ -94,8 +94,10 @@ func NewAPI(opts ...APIOptFn) *API {
 			}
 			code := influxdb.ErrorCode(err)
 			return ErrBody{
-				Code: code,
-				Msg:  msg,
+				Code:    code,
+				Msg:     msg,
+				ErrID:   influxdb.ErrorID(err),
+				Details: errDetails(err),
 			}, ErrorCodeToStatusCode(ctx, code), nil
 		},
 	}
@@ -259,8 +261,11 @@ func (n noopCloser) Close() error {
 	return nil
 }
 
-// ErrBody is an err response body.
+// ErrBody is an err response body. ErrID is set for errors with a stable
+// identifier, and Details for errors validating request bodies.
 type ErrBody struct {
-	Code string `json:"code"`
-	Msg  string `json:"message"`
+	Code    string      `json:"code"`
+	Msg     string      `json:"message"`
+	ErrID   string      `json:"errID,omitempty"`
+	Details *ErrDetails `json:"details,omitempty"`
 }
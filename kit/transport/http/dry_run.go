@@ -0,0 +1,79 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+)
+
+const (
+	// DryRunParam is the query parameter asking a mutating endpoint for a dry run.
+	DryRunParam = "dryRun"
+
+	// DryRunHeader is the header asking a mutating endpoint for a dry run, for
+	// clients unable to set query parameters.
+	DryRunHeader = "Influx-Dry-Run"
+)
+
+// DryRunResponse is the response body of every mutating endpoint asked for a
+// dry run. Plan describes what the request would have changed.
+type DryRunResponse struct {
+	DryRun bool        `json:"dryRun"`
+	Plan   interface{} `json:"plan"`
+}
+
+// DecodeDryRun reports whether the request asks for a dry run with the
+// DryRunParam query parameter or the DryRunHeader header. When it does, the
+// returned request's context is marked with icontext.SetDryRun, so that the
+// services it is passed to change nothing.
+//
+// Mutating endpoints supporting dry runs call the same services with the
+// returned request's context whether or not it is a dry run, so the request is
+// validated and authorized the same way, and respond to dry runs with
+// RespondDryRun:
+//
+//	r, dryRun, err := h.api.DecodeDryRun(r)
+//	if err != nil {
+//		h.api.Err(w, r, err)
+//		return
+//	}
+//	if err := h.svc.DeleteThing(r.Context(), id); err != nil {
+//		h.api.Err(w, r, err)
+//		return
+//	}
+//	if dryRun {
+//		h.api.RespondDryRun(w, r, thingDeletePlan{ID: id})
+//		return
+//	}
+func (a *API) DecodeDryRun(r *http.Request) (*http.Request, bool, error) {
+	v := r.URL.Query().Get(DryRunParam)
+	if v == "" {
+		v = r.Header.Get(DryRunHeader)
+	}
+	if v == "" {
+		return r, false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return r, false, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("invalid %s value %q; expected true or false", DryRunParam, v),
+		}
+	}
+	if !dryRun {
+		return r, false, nil
+	}
+	return r.WithContext(icontext.SetDryRun(r.Context())), true, nil
+}
+
+// RespondDryRun writes the DryRunResponse with the plan of a dry run.
+func (a *API) RespondDryRun(w http.ResponseWriter, r *http.Request, plan interface{}) {
+	a.Respond(w, r, http.StatusOK, DryRunResponse{
+		DryRun: true,
+		Plan:   plan,
+	})
+}
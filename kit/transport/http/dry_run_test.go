@@ -0,0 +1,57 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_DecodeDryRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		header  string
+		want    bool
+		wantErr bool
+	}{
+		{name: "none", url: "/things/1"},
+		{name: "query param", url: "/things/1?dryRun=true", want: true},
+		{name: "query param false", url: "/things/1?dryRun=false"},
+		{name: "header", url: "/things/1", header: "true", want: true},
+		{name: "query param takes precedence", url: "/things/1?dryRun=false", header: "true"},
+		{name: "invalid", url: "/things/1?dryRun=maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, tt.url, nil)
+			if tt.header != "" {
+				r.Header.Set(kithttp.DryRunHeader, tt.header)
+			}
+
+			r, dryRun, err := kithttp.NewAPI().DecodeDryRun(r)
+			if tt.wantErr {
+				assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, dryRun)
+			assert.Equal(t, tt.want, icontext.IsDryRun(r.Context()))
+		})
+	}
+}
+
+func TestAPI_RespondDryRun(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/things/1?dryRun=true", nil)
+
+	kithttp.NewAPI(kithttp.WithPrettyJSON(false)).RespondDryRun(w, r, map[string]string{"id": "1"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"dryRun":true,"plan":{"id":"1"}}`, w.Body.String())
+}
@@ -31,10 +31,14 @@ func (h ErrorHandler) HandleHTTPError(ctx context.Context, err error, w http.Res
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(ErrorCodeToStatusCode(ctx, code))
 	var e struct {
-		Code    string `json:"code"`
-		Message string `json:"message"`
+		Code    string      `json:"code"`
+		Message string      `json:"message"`
+		ErrID   string      `json:"errID,omitempty"`
+		Details *ErrDetails `json:"details,omitempty"`
 	}
 	e.Code = influxdb.ErrorCode(err)
+	e.ErrID = influxdb.ErrorID(err)
+	e.Details = errDetails(err)
 	if err, ok := err.(*influxdb.Error); ok {
 		e.Message = err.Error()
 	} else {
@@ -55,3 +55,28 @@ func TestEncodeErrorWithError(t *testing.T) {
 		t.Errorf("unexpected message -want/+got:\n\t- %q\n\t+ %q", want, got)
 	}
 }
+
+func TestEncodeErrorWithErrorID(t *testing.T) {
+	ctx := context.TODO()
+	err := &influxdb.Error{
+		Code: influxdb.EInternal,
+		Msg:  "cannot create bucket",
+		Err: &influxdb.Error{
+			Code:  influxdb.EConflict,
+			Msg:   "bucket with name b already exists",
+			ErrID: influxdb.ErrIDBucketCreateNameConflict,
+		},
+	}
+
+	w := httptest.NewRecorder()
+
+	kithttp.ErrorHandler(0).HandleHTTPError(ctx, err, w)
+
+	pe := http.CheckError(w.Result()).(*influxdb.Error)
+	if want, got := influxdb.ErrIDBucketCreateNameConflict, pe.ErrID; want != got {
+		t.Errorf("unexpected error ID -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+	if want, got := "cannot create bucket: bucket with name b already exists", pe.Msg; want != got {
+		t.Errorf("unexpected message -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+}
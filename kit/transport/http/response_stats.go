@@ -0,0 +1,135 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers reporting what a write or query request cost the server. They are
+// only set when the server is configured to report response statistics.
+const (
+	PointsWrittenHeader = "X-Influxdb-Points-Written"
+	BytesReadHeader     = "X-Influxdb-Bytes-Read"
+	RowsReturnedHeader  = "X-Influxdb-Rows-Returned"
+	QueryDurationHeader = "X-Influxdb-Query-Duration-Ms"
+)
+
+// PartialResultHeader is set on the response to a query whose results were
+// truncated by a limit on the results of its organization. It is sent as a
+// trailer if the response was streamed before the limit was reached.
+const PartialResultHeader = "X-Influxdb-Partial-Result"
+
+// DefaultStatsBufferSize is how much of a query response a
+// StatsResponseWriter holds back so its statistics can be sent as headers.
+const DefaultStatsBufferSize = 64 * 1024
+
+// QueryStats are the statistics of a query reported in response headers.
+// Negative values are unknown and not reported.
+type QueryStats struct {
+	BytesRead    int64
+	RowsReturned int64
+	Duration     time.Duration
+}
+
+// SetHeaders sets the known statistics on h.
+func (s QueryStats) SetHeaders(h http.Header) {
+	if s.BytesRead >= 0 {
+		h.Set(BytesReadHeader, strconv.FormatInt(s.BytesRead, 10))
+	}
+	if s.RowsReturned >= 0 {
+		h.Set(RowsReturnedHeader, strconv.FormatInt(s.RowsReturned, 10))
+	}
+	if s.Duration >= 0 {
+		h.Set(QueryDurationHeader, strconv.FormatInt(s.Duration.Milliseconds(), 10))
+	}
+}
+
+// StatsResponseWriter holds back a query response until its statistics are
+// known, so they can be sent as headers. A response outgrowing the buffer,
+// or flushed, is streamed instead and its statistics are sent as trailers.
+type StatsResponseWriter struct {
+	http.ResponseWriter
+
+	size      int
+	buf       bytes.Buffer
+	code      int
+	streaming bool
+}
+
+// NewStatsResponseWriter returns a StatsResponseWriter holding back up to
+// size bytes of the response written to w. A size of zero streams the
+// response right away.
+func NewStatsResponseWriter(w http.ResponseWriter, size int) *StatsResponseWriter {
+	return &StatsResponseWriter{
+		ResponseWriter: w,
+		size:           size,
+	}
+}
+
+// WriteHeader holds back the status code with the response.
+func (w *StatsResponseWriter) WriteHeader(statusCode int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	w.code = statusCode
+}
+
+func (w *StatsResponseWriter) Write(b []byte) (int, error) {
+	if !w.streaming && w.buf.Len()+len(b) <= w.size {
+		return w.buf.Write(b)
+	}
+	if err := w.stream(); err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush streams the response.
+func (w *StatsResponseWriter) Flush() {
+	if err := w.stream(); err != nil {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// stream declares the statistics as trailers and writes the response held
+// back so far.
+func (w *StatsResponseWriter) stream() error {
+	if w.streaming {
+		return nil
+	}
+	w.streaming = true
+
+	w.Header().Add("Trailer", strings.Join([]string{BytesReadHeader, RowsReturnedHeader, QueryDurationHeader}, ", "))
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// Finish sets the statistics of the query and writes the response held
+// back. Nothing is written if the response is empty, so an error may still
+// be written to the underlying writer.
+func (w *StatsResponseWriter) Finish(stats QueryStats) error {
+	stats.SetHeaders(w.Header())
+	if w.streaming {
+		return nil
+	}
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsResponseWriter(t *testing.T) {
+	stats := QueryStats{BytesRead: 1024, RowsReturned: 3, Duration: 1500 * time.Millisecond}
+	body := strings.Repeat("a,b,c\n", 10)
+
+	tests := []struct {
+		name        string
+		size        int
+		flush       bool
+		wantChunked bool
+	}{
+		{name: "buffered response", size: DefaultStatsBufferSize},
+		{name: "response larger than the buffer", size: 10, wantChunked: true},
+		{name: "streamed response", size: 0, wantChunked: true},
+		{name: "flushed response", size: DefaultStatsBufferSize, flush: true, wantChunked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sw := NewStatsResponseWriter(w, tt.size)
+				sw.Header().Set("Content-Type", "text/csv")
+				sw.WriteHeader(http.StatusAccepted)
+				_, _ = io.WriteString(sw, body[:6])
+				if tt.flush {
+					sw.Flush()
+				}
+				_, _ = io.WriteString(sw, body[6:])
+				require.NoError(t, sw.Finish(stats))
+			}))
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			b, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+			assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+			assert.Equal(t, body, string(b))
+
+			got := resp.Header
+			if tt.wantChunked {
+				assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+				assert.Empty(t, resp.Header.Get(RowsReturnedHeader))
+				got = resp.Trailer
+			}
+			assert.Equal(t, "1024", got.Get(BytesReadHeader))
+			assert.Equal(t, "3", got.Get(RowsReturnedHeader))
+			assert.Equal(t, "1500", got.Get(QueryDurationHeader))
+		})
+	}
+}
+
+func TestStatsResponseWriter_Empty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sw := NewStatsResponseWriter(rec, DefaultStatsBufferSize)
+	require.NoError(t, sw.Finish(QueryStats{BytesRead: -1, RowsReturned: 0, Duration: -1}))
+
+	// Nothing is written, so an error may still be.
+	assert.False(t, rec.Flushed)
+	assert.Zero(t, rec.Body.Len())
+	rec.WriteHeader(http.StatusBadRequest)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	assert.Equal(t, "0", rec.Header().Get(RowsReturnedHeader))
+	assert.NotContains(t, rec.Header(), BytesReadHeader)
+	assert.NotContains(t, rec.Header(), QueryDurationHeader)
+}
@@ -0,0 +1,336 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// FieldError is a field of a request body that failed to decode or validate.
+// Path is the path of the field by its JSON names, such as
+// "retentionRules[0].everySeconds", and is empty for the body as a whole.
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + " " + e.Message
+}
+
+// ErrDetails are the details of an error response.
+type ErrDetails struct {
+	Fields []FieldError `json:"fields"`
+}
+
+// ValidationError is the error of the fields of a request body that failed to
+// decode or validate. DecodeAndValidate wraps it in an influxdb.Error with the
+// EInvalid code and the message "invalid request body", and the API writes its
+// fields as the details of the error.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// errDetails returns the details of err, if it is or wraps a ValidationError.
+func errDetails(err error) *ErrDetails {
+	for err != nil {
+		switch e := err.(type) {
+		case *ValidationError:
+			return &ErrDetails{Fields: e.Fields}
+		case *influxdb.Error:
+			err = e.Err
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+	return nil
+}
+
+func newValidationError(fields ...FieldError) error {
+	verr := &ValidationError{Fields: fields}
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "invalid request body",
+		Err:  verr,
+	}
+}
+
+// DecodeAndValidate decodes the JSON body of r into v, and validates it by the
+// validate tags of its fields and its OK method, if it has one. Errors
+// decoding or validating fields are returned as an influxdb.Error with the
+// EInvalid code, naming the fields by their JSON paths.
+//
+// The validate tag holds comma separated rules:
+//
+//	required  the field must not be empty, nor an empty string, slice or map
+//	min=N     numbers must be at least N, and strings, slices and maps must
+//	          have at least N characters or items
+//	max=N     as min, but at most N
+//
+// Rules other than required are not checked against nil pointers, so
+// optional fields are only validated when present. Fields of nested structs,
+// and of structs in slices, are validated as well.
+func (a *API) DecodeAndValidate(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return newValidationError(decodeFieldError(err))
+	}
+
+	if fields := validateFields(reflect.ValueOf(v), "", nil); len(fields) > 0 {
+		return newValidationError(fields...)
+	}
+
+	if vv, ok := v.(oker); ok {
+		err := vv.OK()
+		if a != nil && a.okErrFn != nil {
+			return a.okErrFn(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// decodeFieldError converts an error decoding JSON into a FieldError that
+// does not refer to Go types.
+func decodeFieldError(err error) FieldError {
+	var (
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return FieldError{Path: fieldPath(typeErr.Field), Message: "must be " + jsonTypeName(typeErr.Type)}
+	case errors.As(err, &typeErr):
+		return FieldError{Message: "request body must be " + jsonTypeName(typeErr.Type)}
+	case errors.As(err, &syntaxErr):
+		return FieldError{Message: fmt.Sprintf("request body is malformed JSON at offset %d", syntaxErr.Offset)}
+	case err == io.EOF:
+		return FieldError{Message: "request body is empty"}
+	case err == io.ErrUnexpectedEOF:
+		return FieldError{Message: "request body is truncated JSON"}
+	default:
+		// Errors of the UnmarshalJSON methods of fields are already in
+		// terms of their values.
+		return FieldError{Message: err.Error()}
+	}
+}
+
+// fieldPath converts the dotted path of a field in a json error, such as
+// "retentionRules.0.everySeconds", into the form used by validation.
+func fieldPath(dotted string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(dotted, ".") {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a valid value"
+	}
+}
+
+// validateFields appends the fields of the struct v, or that v points to,
+// that fail the rules of their validate tags to fields.
+func validateFields(v reflect.Value, path string, fields []FieldError) []FieldError {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return fields
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fields = validateFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+		return fields
+	default:
+		return fields
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if (f.PkgPath != "" && !f.Anonymous) || f.Tag.Get("json") == "-" {
+			continue
+		}
+		name, embedded := jsonFieldName(f)
+		if name == "" && !embedded {
+			continue
+		}
+		fv := v.Field(i)
+		if embedded {
+			fields = validateFields(fv, path, fields)
+			continue
+		}
+
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		if tag, ok := f.Tag.Lookup("validate"); ok {
+			if msg := checkRules(fv, tag); msg != "" {
+				fields = append(fields, FieldError{Path: fieldPath, Message: msg})
+				continue
+			}
+		}
+		fields = validateFields(fv, fieldPath, fields)
+	}
+	return fields
+}
+
+// jsonFieldName returns the name of f in JSON, and whether its fields are
+// promoted to the struct holding it. It returns an empty name for unexported
+// fields that are not encoded.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	name := f.Tag.Get("json")
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+	if name != "" {
+		return name, false
+	}
+	if f.Anonymous {
+		t := f.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+	if f.PkgPath != "" {
+		return "", false
+	}
+	return f.Name, false
+}
+
+// checkRules returns the message of the first rule of tag that v fails, or
+// an empty string if it passes them all.
+func checkRules(v reflect.Value, tag string) string {
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if rule == "required" {
+			if isEmpty(v) {
+				return "is required"
+			}
+			continue
+		}
+
+		kv := strings.SplitN(rule, "=", 2)
+		if len(kv) != 2 || (kv[0] != "min" && kv[0] != "max") {
+			panic(fmt.Sprintf("unknown validate rule %q", rule))
+		}
+		limit, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			panic(fmt.Sprintf("invalid validate rule %q: %v", rule, err))
+		}
+
+		rv := v
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				break
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Ptr {
+			continue
+		}
+		n, unit, ok := measure(rv)
+		if !ok {
+			panic(fmt.Sprintf("validate rule %q on unsupported type %s", rule, v.Type()))
+		}
+		bound := "least"
+		if kv[0] == "max" {
+			bound = "most"
+		}
+		if (kv[0] == "min" && n < limit) || (kv[0] == "max" && n > limit) {
+			return fmt.Sprintf("must %s at %s %s%s", verb(unit), bound, kv[1], unit)
+		}
+	}
+	return ""
+}
+
+// measure returns the value of the number v, or the length of the string,
+// slice or map v, with the unit of the length.
+func measure(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " items", true
+	default:
+		return 0, "", false
+	}
+}
+
+func verb(unit string) string {
+	if unit == "" {
+		return "be"
+	}
+	return "have"
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
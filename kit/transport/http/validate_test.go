@@ -0,0 +1,176 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/pkg/testttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateRule struct {
+	Type         string `json:"type" validate:"required"`
+	EverySeconds int64  `json:"everySeconds" validate:"min=1"`
+}
+
+type validateEmbedded struct {
+	Labels []string `json:"labels,omitempty" validate:"max=2"`
+}
+
+type validateBody struct {
+	validateEmbedded
+	OrgName     string         `json:"orgName" validate:"required,max=8"`
+	Description *string        `json:"description,omitempty" validate:"min=1"`
+	Rules       []validateRule `json:"retentionRules"`
+	Ignored     string         `json:"-" validate:"required"`
+}
+
+func TestAPI_DecodeAndValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []kithttp.FieldError
+	}{
+		{
+			name: "valid",
+			body: `{"orgName":"org","labels":["a"],"retentionRules":[{"type":"expire","everySeconds":60}]}`,
+		},
+		{
+			name: "type mismatch of a nested field",
+			body: `{"orgName":"org","retentionRules":[{"type":"expire","everySeconds":"1h"}]}`,
+			fields: []kithttp.FieldError{
+				{Path: "retentionRules[0].everySeconds", Message: "must be an integer"},
+			},
+		},
+		{
+			name: "type mismatch of the body",
+			body: `["org"]`,
+			fields: []kithttp.FieldError{
+				{Message: "request body must be an object"},
+			},
+		},
+		{
+			name: "malformed",
+			body: `{"orgName":}`,
+			fields: []kithttp.FieldError{
+				{Message: "request body is malformed JSON at offset 12"},
+			},
+		},
+		{
+			name: "empty",
+			body: ``,
+			fields: []kithttp.FieldError{
+				{Message: "request body is empty"},
+			},
+		},
+		{
+			name: "rules of tags",
+			body: `{"orgName":"organization","description":"","labels":["a","b","c"],"retentionRules":[{"type":"expire","everySeconds":60},{"everySeconds":0}]}`,
+			fields: []kithttp.FieldError{
+				{Path: "labels", Message: "must have at most 2 items"},
+				{Path: "orgName", Message: "must have at most 8 characters"},
+				{Path: "description", Message: "must have at least 1 characters"},
+				{Path: "retentionRules[1].type", Message: "is required"},
+				{Path: "retentionRules[1].everySeconds", Message: "must be at least 1"},
+			},
+		},
+		{
+			name: "required",
+			body: `{}`,
+			fields: []kithttp.FieldError{
+				{Path: "orgName", Message: "is required"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(tt.body))
+
+			var body validateBody
+			err := kithttp.NewAPI().DecodeAndValidate(req, &body)
+			if tt.fields == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+			var verr *kithttp.ValidationError
+			require.IsType(t, verr, err.(*influxdb.Error).Err)
+			verr = err.(*influxdb.Error).Err.(*kithttp.ValidationError)
+			assert.Equal(t, tt.fields, verr.Fields)
+
+			// Messages refer to JSON names, never to Go names or types.
+			for _, goName := range []string{"validateBody", "OrgName", "EverySeconds", "int64", "Go "} {
+				assert.NotContains(t, err.Error(), goName)
+			}
+		})
+	}
+
+	t.Run("calls OK", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(`{"Foo":"","Bar":-1}`))
+		var out validatFoo
+		err := kithttp.NewAPI().DecodeAndValidate(req, &out)
+		assert.EqualError(t, err, "foo must be at least 1 char; bar must be a positive real number")
+	})
+}
+
+func TestAPI_ErrDetails(t *testing.T) {
+	api := kithttp.NewAPI()
+	svr := func(w http.ResponseWriter, r *http.Request) {
+		var body validateBody
+		if err := api.DecodeAndValidate(r, &body); err != nil {
+			api.Err(w, r, err)
+			return
+		}
+		api.Respond(w, r, http.StatusNoContent, nil)
+	}
+
+	testttp.
+		Post(t, "/foo", strings.NewReader(`{"orgName":"org","retentionRules":[{"everySeconds":60}]}`)).
+		Do(http.HandlerFunc(svr)).
+		ExpectStatus(http.StatusBadRequest).
+		ExpectHeader(kithttp.PlatformErrorCodeHeader, influxdb.EInvalid).
+		ExpectBody(func(body *bytes.Buffer) {
+			var errBody kithttp.ErrBody
+			require.NoError(t, json.NewDecoder(body).Decode(&errBody))
+			assert.Equal(t, kithttp.ErrBody{
+				Code: influxdb.EInvalid,
+				Msg:  "invalid request body: retentionRules[0].type is required",
+				Details: &kithttp.ErrDetails{
+					Fields: []kithttp.FieldError{{Path: "retentionRules[0].type", Message: "is required"}},
+				},
+			}, errBody)
+		})
+
+	t.Run("error handler", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(`{"orgName":true}`))
+		var body validateBody
+		err := api.DecodeAndValidate(req, &body)
+		require.Error(t, err)
+
+		w := httptest.NewRecorder()
+		kithttp.ErrorHandler(0).HandleHTTPError(context.Background(), err, w)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{
+			"code": "invalid",
+			"message": "invalid request body: orgName must be a string",
+			"details": {"fields": [{"path": "orgName", "message": "must be a string"}]}
+		}`, w.Body.String())
+	})
+
+	t.Run("other errors have no details", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		kithttp.ErrorHandler(0).HandleHTTPError(context.Background(), &influxdb.Error{Code: influxdb.EInvalid, Msg: "bad"}, w)
+		assert.JSONEq(t, `{"code": "invalid", "message": "bad"}`, w.Body.String())
+	})
+}
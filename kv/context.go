@@ -0,0 +1,25 @@
+package kv
+
+import (
+	"context"
+)
+
+type key int
+
+const (
+	callerKey key = iota
+)
+
+// WithCaller returns a new context tagged with the name of the operation
+// opening transactions with it. Stores may use it to attribute transactions,
+// for example when logging slow ones.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey, caller)
+}
+
+// CallerFromContext returns the caller ctx is tagged with, or an empty string
+// if it has none.
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey).(string)
+	return caller
+}
@@ -367,3 +367,75 @@ func (s *Service) lastLogEntry(ctx context.Context, tx Tx, k []byte) ([]byte, ti
 
 	return s.getLogEntry(ctx, tx, k, bounds.StopTime())
 }
+
+// TrimLogEntriesTx removes the oldest entries of the log at key k so that at most max entries remain.
+// A max of zero or less keeps all entries.
+func (s *Service) TrimLogEntriesTx(ctx context.Context, tx Tx, k []byte, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	bounds, err := s.getKeyValueLogBounds(ctx, tx, k)
+	if err != nil {
+		return err
+	}
+
+	bkt, err := tx.Bucket(kvlogBucket)
+	if err != nil {
+		return err
+	}
+
+	startKey, stopKey, err := bounds.Bounds(k)
+	if err != nil {
+		return err
+	}
+
+	cur, err := bkt.ForwardCursor(stopKey, WithCursorDirection(CursorDescending))
+	if err != nil {
+		return err
+	}
+
+	// Walk back from the newest entry, keeping the first max entries and
+	// collecting the keys of all older ones.
+	var (
+		count   int
+		oldest  time.Time
+		expired [][]byte
+	)
+	for key, _ := cur.Next(); key != nil; key, _ = cur.Next() {
+		_, ts, err := decodeLogEntryKey(key)
+		if err != nil {
+			return err
+		}
+
+		if count < max {
+			oldest = ts
+		} else {
+			expired = append(expired, key)
+		}
+		count++
+
+		if bytes.Equal(key, startKey) {
+			break
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	if err := cur.Close(); err != nil {
+		return err
+	}
+
+	if len(expired) == 0 {
+		return nil
+	}
+
+	for _, key := range expired {
+		if err := bkt.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	bounds.Start = oldest.UTC().UnixNano()
+	return s.putKeyValueLogBounds(ctx, tx, k, bounds)
+}
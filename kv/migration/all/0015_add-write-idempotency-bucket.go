@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0015_AddWriteIdempotencyBucket creates the bucket used to persist
+// write idempotency keys.
+var Migration0015_AddWriteIdempotencyBucket = migration.CreateBuckets(
+	"Create write idempotency bucket",
+	[]byte("writeidempotencyv1"))
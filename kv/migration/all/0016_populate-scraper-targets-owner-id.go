@@ -0,0 +1,112 @@
+package all
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// Migration0016_PopulateScraperTargetsOwnerID assigns the owner of the organization
+// to scraper targets created before targets had an owner.
+var Migration0016_PopulateScraperTargetsOwnerID = UpOnlyMigration("populate scraper targets owner id", func(ctx context.Context, store kv.SchemaStore) error {
+	var (
+		urmBucket      = []byte("userresourcemappingsv1")
+		scrapersBucket = []byte("scraperv2")
+	)
+	type userResourceMapping struct {
+		UserID       influxdb.ID           `json:"userID"`
+		UserType     influxdb.UserType     `json:"userType"`
+		ResourceType influxdb.ResourceType `json:"resourceType"`
+		ResourceID   influxdb.ID           `json:"resourceID"`
+	}
+
+	// collect the first owner of every organization
+	orgOwners := map[influxdb.ID]influxdb.ID{}
+	if err := store.View(ctx, func(tx kv.Tx) error {
+		bkt, err := tx.Bucket(urmBucket)
+		if err != nil {
+			return err
+		}
+
+		cursor, err := bkt.ForwardCursor(nil)
+		if err != nil {
+			return err
+		}
+
+		return kv.WalkCursor(ctx, cursor, func(_, v []byte) (bool, error) {
+			var mapping userResourceMapping
+			if err := json.Unmarshal(v, &mapping); err != nil {
+				return false, err
+			}
+
+			if mapping.ResourceType == influxdb.OrgsResourceType && mapping.UserType == influxdb.Owner {
+				if _, ok := orgOwners[mapping.ResourceID]; !ok {
+					orgOwners[mapping.ResourceID] = mapping.UserID
+				}
+			}
+
+			return true, nil
+		})
+	}); err != nil {
+		return err
+	}
+
+	// targets are kept as raw fields so that nothing else is rewritten
+	type target map[string]json.RawMessage
+
+	return store.Update(ctx, func(tx kv.Tx) error {
+		bkt, err := tx.Bucket(scrapersBucket)
+		if err != nil {
+			return err
+		}
+
+		cursor, err := bkt.ForwardCursor(nil)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string][]byte{}
+		if err := kv.WalkCursor(ctx, cursor, func(k, v []byte) (bool, error) {
+			var t target
+			if err := json.Unmarshal(v, &t); err != nil {
+				return false, err
+			}
+			if _, ok := t["ownerID"]; ok {
+				return true, nil
+			}
+
+			var orgID influxdb.ID
+			if err := json.Unmarshal(t["orgID"], &orgID); err != nil {
+				return false, err
+			}
+			ownerID, ok := orgOwners[orgID]
+			if !ok {
+				// the target stays without an owner, so it is not scraped
+				return true, nil
+			}
+
+			if t["ownerID"], err = json.Marshal(ownerID); err != nil {
+				return false, err
+			}
+			updated, err := json.Marshal(t)
+			if err != nil {
+				return false, err
+			}
+			updates[string(k)] = updated
+
+			return true, nil
+		}); err != nil {
+			return err
+		}
+
+		for k, v := range updates {
+			if err := bkt.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+})
@@ -0,0 +1,62 @@
+package all
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+func TestMigration0016_PopulateScraperTargetsOwnerID(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	ts := newService(t, ctx)
+
+	var (
+		unowned = influxdb.ID(1)
+		owned   = influxdb.ID(2)
+		orphan  = influxdb.ID(3)
+		other   = influxdb.ID(4)
+	)
+	err := ts.Store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket([]byte("scraperv2"))
+		if err != nil {
+			return err
+		}
+		targets := map[influxdb.ID]string{
+			unowned: fmt.Sprintf(`{"id":"%s","name":"unowned","type":"prometheus","url":"url","orgID":"%s","bucketID":"0000000000000001"}`, unowned, ts.Org.ID),
+			owned:   fmt.Sprintf(`{"id":"%s","name":"owned","type":"prometheus","url":"url","orgID":"%s","bucketID":"0000000000000001","ownerID":"%s"}`, owned, ts.Org.ID, other),
+			orphan:  fmt.Sprintf(`{"id":"%s","name":"orphan","type":"prometheus","url":"url","orgID":"%s","bucketID":"0000000000000001"}`, orphan, other),
+		}
+		for id, body := range targets {
+			encID, _ := id.Encode()
+			if err := b.Put(encID, []byte(body)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migration0016_PopulateScraperTargetsOwnerID.Up(ctx, ts.Store); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, exp := range map[influxdb.ID]influxdb.ID{unowned: ts.User.ID, owned: other, orphan: 0} {
+		target, err := ts.Service.GetTargetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target.OwnerID != exp {
+			t.Errorf("unexpected owner of target %q: got %s, exp %s", target.Name, target.OwnerID, exp)
+		}
+		if target.URL != "url" {
+			t.Errorf("unexpected url of target %q: got %q", target.Name, target.URL)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0017_AddIngestQuotaBuckets creates the buckets used to persist
+// the ingest quotas of organizations and their usage.
+var Migration0017_AddIngestQuotaBuckets = migration.CreateBuckets(
+	"Create ingest quota buckets",
+	[]byte("ingestquotasv1"),
+	[]byte("ingestusagev1"))
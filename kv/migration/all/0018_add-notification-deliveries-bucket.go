@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0018_AddNotificationDeliveriesBucket creates the bucket holding the
+// recent delivery attempts of notification endpoints.
+var Migration0018_AddNotificationDeliveriesBucket = migration.CreateBuckets(
+	"Create notification deliveries bucket",
+	[]byte("notificationdeliveriesv1"))
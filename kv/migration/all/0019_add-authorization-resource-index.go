@@ -0,0 +1,83 @@
+package all
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// Migration0019_AddAuthorizationResourceIndex indexes the existing
+// authorizations by the resources of their permissions.
+var Migration0019_AddAuthorizationResourceIndex = &Migration{
+	name: "add authorization resource index",
+	up: func(ctx context.Context, store kv.SchemaStore) error {
+		var (
+			authBucket  = []byte("authorizationsv1")
+			indexBucket = []byte("authorizationresourceindexv1")
+		)
+		if err := store.CreateBucket(ctx, indexBucket); err != nil {
+			return err
+		}
+
+		type authorization struct {
+			Permissions []influxdb.Permission `json:"permissions"`
+		}
+
+		// index keys are the resource type followed by "i" and the resource ID,
+		// "o" and the organization ID, or nothing, then the authorization ID.
+		index := map[string][]byte{}
+		if err := store.View(ctx, func(tx kv.Tx) error {
+			bkt, err := tx.Bucket(authBucket)
+			if err != nil {
+				return err
+			}
+
+			cursor, err := bkt.ForwardCursor(nil)
+			if err != nil {
+				return err
+			}
+
+			return kv.WalkCursor(ctx, cursor, func(k, v []byte) (bool, error) {
+				var a authorization
+				if err := json.Unmarshal(v, &a); err != nil {
+					return false, err
+				}
+
+				for _, p := range a.Permissions {
+					key := string(p.Resource.Type) + "/"
+					switch {
+					case p.Resource.ID != nil:
+						key += "i" + p.Resource.ID.String()
+					case p.Resource.OrgID != nil:
+						key += "o" + p.Resource.OrgID.String()
+					}
+					index[key+"/"+string(k)] = append([]byte(nil), k...)
+				}
+
+				return true, nil
+			})
+		}); err != nil {
+			return err
+		}
+
+		return store.Update(ctx, func(tx kv.Tx) error {
+			bkt, err := tx.Bucket(indexBucket)
+			if err != nil {
+				return err
+			}
+
+			for k, v := range index {
+				if err := bkt.Put([]byte(k), v); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	},
+	down: func(ctx context.Context, store kv.SchemaStore) error {
+		return store.DeleteBucket(ctx, []byte("authorizationresourceindexv1"))
+	},
+}
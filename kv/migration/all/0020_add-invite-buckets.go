@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0020_AddInviteBuckets creates the buckets holding the invitations
+// of users to organizations and the index of their setup tokens.
+var Migration0020_AddInviteBuckets = migration.CreateBuckets(
+	"Create invite buckets",
+	[]byte("invitesv1"),
+	[]byte("invitetokenindexv1"))
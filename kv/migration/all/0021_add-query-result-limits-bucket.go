@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0021_AddQueryResultLimitsBucket creates the bucket holding the
+// query result limits of organizations.
+var Migration0021_AddQueryResultLimitsBucket = migration.CreateBuckets(
+	"Create query result limits bucket",
+	[]byte("queryresultlimitsv1"))
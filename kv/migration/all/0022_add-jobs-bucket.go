@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0022_AddJobsBucket creates the bucket holding the background jobs
+// of the server.
+var Migration0022_AddJobsBucket = migration.CreateBuckets(
+	"Create jobs bucket",
+	[]byte("jobsv1"))
@@ -0,0 +1,9 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0023_AddSessionsStoreBucket creates the bucket holding the
+// denylist of stateless sessions, shared by the instances of a kv store.
+var Migration0023_AddSessionsStoreBucket = migration.CreateBuckets(
+	"Create sessions store bucket",
+	[]byte("sessionsstorev1"))
@@ -35,5 +35,23 @@ var Migrations = [...]migration.Spec{
 	Migration0013_RepairDBRPOwnerAndBucketIDs,
 	// reindex DBRPs
 	Migration0014_ReindexDBRPs,
+	// add write idempotency bucket
+	Migration0015_AddWriteIdempotencyBucket,
+	// populate scraper targets owner id
+	Migration0016_PopulateScraperTargetsOwnerID,
+	// add ingest quota buckets
+	Migration0017_AddIngestQuotaBuckets,
+	// add notification deliveries bucket
+	Migration0018_AddNotificationDeliveriesBucket,
+	// add authorization resource index
+	Migration0019_AddAuthorizationResourceIndex,
+	// add invite buckets
+	Migration0020_AddInviteBuckets,
+	// add query result limits bucket
+	Migration0021_AddQueryResultLimitsBucket,
+	// add jobs bucket
+	Migration0022_AddJobsBucket,
+	// add sessions store bucket
+	Migration0023_AddSessionsStoreBucket,
 	// {{ do_not_edit . }}
 }
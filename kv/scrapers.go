@@ -35,6 +35,55 @@ var (
 		Code: influxdb.EInvalid,
 		Msg:  "provided organization ID has invalid format",
 	}
+
+	// ErrInvalidScraperType is used when the service was provided an
+	// unknown scraper type.
+	ErrInvalidScraperType = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "provided scraper target type is not supported",
+	}
+
+	// ErrInvalidScraperBatch is used when the batch size or interval of a
+	// scraper target is negative.
+	ErrInvalidScraperBatch = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "scraper target batch size and interval must not be negative",
+	}
+
+	// ErrInvalidScraperInterval is used when the interval of a scraper
+	// target is shorter than the minimum.
+	ErrInvalidScraperInterval = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  fmt.Sprintf("scraper target interval must be 0 to use the default, or at least %s", influxdb.MinScraperTargetInterval),
+	}
+
+	// ErrInvalidScraperHeaders is used when a header of a scraper target has
+	// a malformed name or value.
+	ErrInvalidScraperHeaders = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "scraper target headers must have valid HTTP header names and values",
+	}
+
+	// ErrInvalidScraperJitter is used when the jitter mode of a scraper
+	// target is unknown.
+	ErrInvalidScraperJitter = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "scraper target jitter must be one of none or spread",
+	}
+
+	// ErrInvalidScraperTimestampSource is used when the timestamp source of
+	// a scraper target is unknown.
+	ErrInvalidScraperTimestampSource = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "scraper target timestamp source must be one of scrapeStart, scrapeEnd or exporter",
+	}
+
+	// ErrInvalidScraperPrecision is used when the precision of a scraper
+	// target is unknown.
+	ErrInvalidScraperPrecision = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "scraper target precision must be one of s, ms or ns",
+	}
 )
 
 // UnexpectedScrapersBucketError is used when the error comes from an internal system.
@@ -92,6 +141,10 @@ func (s *Service) scrapersBucket(tx Tx) (Bucket, error) {
 
 // ListTargets will list all scrape targets.
 func (s *Service) ListTargets(ctx context.Context, filter influxdb.ScraperTargetFilter) ([]influxdb.ScraperTarget, error) {
+	if filter.Type != nil && !influxdb.ValidScraperType(string(*filter.Type)) {
+		return nil, ErrInvalidScraperType
+	}
+
 	if filter.Org != nil {
 		org, err := s.orgs.FindOrganization(ctx, influxdb.OrganizationFilter{
 			Name: filter.Org,
@@ -142,6 +195,10 @@ func (s *Service) listTargets(ctx context.Context, tx Tx, filter influxdb.Scrape
 			continue
 		}
 
+		if filter.Type != nil && target.Type != *filter.Type {
+			continue
+		}
+
 		targets = append(targets, *target)
 	}
 	return targets, nil
@@ -163,7 +220,17 @@ func (s *Service) addTarget(ctx context.Context, tx Tx, target *influxdb.Scraper
 		return ErrInvalidScrapersBucketID
 	}
 
+	if err := validTargetSettings(target); err != nil {
+		return err
+	}
+
+	if err := target.ValidCredentials(); err != nil {
+		return err
+	}
+
 	target.ID = s.IDGenerator.ID()
+	target.OwnerID = userID
+	target.Revision = 1
 	if err := s.putTarget(ctx, tx, target); err != nil {
 		return err
 	}
@@ -171,6 +238,46 @@ func (s *Service) addTarget(ctx context.Context, tx Tx, target *influxdb.Scraper
 	return nil
 }
 
+func validBatch(target *influxdb.ScraperTarget) bool {
+	if target.BatchSize < 0 {
+		return false
+	}
+	return target.BatchInterval == nil || target.BatchInterval.Duration >= 0
+}
+
+// validTargetSettings returns an error if the batching, header, jitter or
+// timestamp settings of target are invalid.
+func validInterval(target *influxdb.ScraperTarget) bool {
+	return target.Interval == nil || target.Interval.Duration == 0 || target.Interval.Duration >= influxdb.MinScraperTargetInterval
+}
+
+func validTargetSettings(target *influxdb.ScraperTarget) error {
+	if !validBatch(target) {
+		return ErrInvalidScraperBatch
+	}
+
+	if !validInterval(target) {
+		return ErrInvalidScraperInterval
+	}
+
+	if !target.Headers.Valid() {
+		return ErrInvalidScraperHeaders
+	}
+
+	if !target.Jitter.Valid() {
+		return ErrInvalidScraperJitter
+	}
+
+	if !target.TimestampSource.Valid() {
+		return ErrInvalidScraperTimestampSource
+	}
+
+	if !target.Precision.Valid() {
+		return ErrInvalidScraperPrecision
+	}
+	return nil
+}
+
 // RemoveTarget removes a scraper target from the bucket.
 func (s *Service) RemoveTarget(ctx context.Context, id influxdb.ID) error {
 	return s.kv.Update(ctx, func(tx Tx) error {
@@ -225,6 +332,10 @@ func (s *Service) updateTarget(ctx context.Context, tx Tx, update *influxdb.Scra
 		return nil, ErrInvalidScraperID
 	}
 
+	if err := validTargetSettings(update); err != nil {
+		return nil, err
+	}
+
 	target, err := s.findTargetByID(ctx, tx, update.ID)
 	if err != nil {
 		return nil, err
@@ -237,10 +348,73 @@ func (s *Service) updateTarget(ctx context.Context, tx Tx, update *influxdb.Scra
 	if !update.OrgID.Valid() {
 		update.OrgID = target.OrgID
 	}
+	// Credentials are not returned to clients, so those left out of the
+	// update are kept rather than cleared.
+	if update.Username == "" {
+		update.Username = target.Username
+	}
+	if update.Password == "" {
+		update.Password = target.Password
+	}
+	if update.BearerToken == "" {
+		update.BearerToken = target.BearerToken
+	}
+	if err := update.ValidCredentials(); err != nil {
+		return nil, err
+	}
+	// The updating user becomes the owner, as their permission on the
+	// bucket is the one that was checked.
+	if userID.Valid() {
+		update.OwnerID = userID
+	} else {
+		update.OwnerID = target.OwnerID
+	}
+	update.Revision = target.Revision + 1
 	target = update
 	return target, s.putTarget(ctx, tx, target)
 }
 
+// PatchTarget applies a partial update to a scraper target.
+func (s *Service) PatchTarget(ctx context.Context, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+	var target *influxdb.ScraperTarget
+	err := s.kv.Update(ctx, func(tx Tx) error {
+		var err error
+		target, err = s.patchTarget(ctx, tx, id, upd, userID)
+		return err
+	})
+
+	return target, err
+}
+
+func (s *Service) patchTarget(ctx context.Context, tx Tx, id influxdb.ID, upd influxdb.ScraperTargetUpdate, userID influxdb.ID) (*influxdb.ScraperTarget, error) {
+	target, err := s.findTargetByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upd.Revision != nil && *upd.Revision != target.Revision {
+		return nil, influxdb.ErrScraperTargetRevisionMismatch(*upd.Revision, target.Revision)
+	}
+
+	upd.Apply(target)
+	if !target.BucketID.Valid() {
+		return nil, ErrInvalidScrapersBucketID
+	}
+	if err := validTargetSettings(target); err != nil {
+		return nil, err
+	}
+	if err := target.ValidCredentials(); err != nil {
+		return nil, err
+	}
+
+	// The updating user becomes the owner, as for UpdateTarget.
+	if userID.Valid() {
+		target.OwnerID = userID
+	}
+	target.Revision++
+	return target, s.putTarget(ctx, tx, target)
+}
+
 // GetTargetByID retrieves a scraper target by id.
 func (s *Service) GetTargetByID(ctx context.Context, id influxdb.ID) (*influxdb.ScraperTarget, error) {
 	var target *influxdb.ScraperTarget
@@ -67,3 +67,96 @@ func initScraperTargetStoreService(s kv.SchemaStore, f influxdbtesting.TargetFie
 		}
 	}
 }
+
+func TestScraperTargetCredentials(t *testing.T) {
+	orgID, bucketID := influxdb.ID(1), influxdb.ID(2)
+	svc, _, done := initBoltTargetService(influxdbtesting.TargetFields{
+		Organizations: []*influxdb.Organization{{ID: orgID, Name: "org"}},
+	}, t)
+	defer done()
+	ctx := context.Background()
+
+	target := &influxdb.ScraperTarget{
+		Name:     "name",
+		Type:     influxdb.PrometheusScraperType,
+		URL:      "http://localhost:9100/metrics",
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Username: "user",
+		Password: "secret",
+	}
+	if err := svc.AddTarget(ctx, target, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer svc.RemoveTarget(ctx, target.ID)
+
+	// Credentials left out of an update are kept.
+	updated, err := svc.UpdateTarget(ctx, &influxdb.ScraperTarget{
+		ID:   target.ID,
+		Name: "renamed",
+		Type: influxdb.PrometheusScraperType,
+		URL:  target.URL,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Username != "user" || updated.Password != "secret" {
+		t.Fatalf("expected credentials to be kept, got %q and %q", updated.Username, updated.Password)
+	}
+
+	// A bearer token conflicts with the kept username and password.
+	if _, err := svc.UpdateTarget(ctx, &influxdb.ScraperTarget{
+		ID:          target.ID,
+		Name:        "renamed",
+		Type:        influxdb.PrometheusScraperType,
+		URL:         target.URL,
+		BearerToken: "token",
+	}, 0); influxdb.ErrorCode(err) != influxdb.EInvalid {
+		t.Fatalf("expected invalid error, got %v", err)
+	}
+
+	// Patching can clear them.
+	empty, token := "", "token"
+	patched, err := svc.PatchTarget(ctx, target.ID, influxdb.ScraperTargetUpdate{
+		Username:    &empty,
+		Password:    &empty,
+		BearerToken: &token,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Username != "" || patched.Password != "" || patched.BearerToken != "token" {
+		t.Fatalf("unexpected credentials %q, %q and %q", patched.Username, patched.Password, patched.BearerToken)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		target influxdb.ScraperTarget
+	}{
+		{
+			name:   "password without username",
+			target: influxdb.ScraperTarget{Type: influxdb.PrometheusScraperType, Password: "secret"},
+		},
+		{
+			name: "credentials and authorization header",
+			target: influxdb.ScraperTarget{
+				Type:        influxdb.PrometheusScraperType,
+				BearerToken: "token",
+				Headers:     influxdb.ScraperHeaders{"authorization": "Basic abc"},
+			},
+		},
+		{
+			name:   "unsupported type",
+			target: influxdb.ScraperTarget{Type: "other", BearerToken: "token"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			target.Name, target.URL = "name", "http://localhost:9100/metrics"
+			target.OrgID, target.BucketID = orgID, bucketID
+			if err := svc.AddTarget(ctx, &target, 0); influxdb.ErrorCode(err) != influxdb.EInvalid {
+				t.Fatalf("expected invalid error, got %v", err)
+			}
+		})
+	}
+}
@@ -47,6 +47,7 @@ type kvTask struct {
 	Flux            string                 `json:"flux"`
 	Every           string                 `json:"every,omitempty"`
 	Cron            string                 `json:"cron,omitempty"`
+	Timezone        string                 `json:"tz,omitempty"`
 	LastRunStatus   string                 `json:"lastRunStatus,omitempty"`
 	LastRunError    string                 `json:"lastRunError,omitempty"`
 	Offset          influxdb.Duration      `json:"offset,omitempty"`
@@ -72,6 +73,7 @@ func kvToInfluxTask(k *kvTask) *influxdb.Task {
 		Flux:            k.Flux,
 		Every:           k.Every,
 		Cron:            k.Cron,
+		Timezone:        k.Timezone,
 		LastRunStatus:   k.LastRunStatus,
 		LastRunError:    k.LastRunError,
 		Offset:          k.Offset.Duration,
@@ -507,6 +509,7 @@ func (s *Service) createTask(ctx context.Context, tx Tx, org *influxdb.Organizat
 		Flux:            tc.Flux,
 		Every:           opts.Every.String(),
 		Cron:            opts.Cron,
+		Timezone:        opts.Timezone,
 		CreatedAt:       createdAt,
 		LatestCompleted: createdAt,
 		LatestScheduled: createdAt,
@@ -615,6 +618,7 @@ func (s *Service) updateTask(ctx context.Context, tx Tx, id influxdb.ID, upd inf
 		task.Name = opts.Name
 		task.Every = opts.Every.String()
 		task.Cron = opts.Cron
+		task.Timezone = opts.Timezone
 
 		var off time.Duration
 		if opts.Offset != nil {
@@ -826,6 +830,98 @@ func (s *Service) deleteTask(ctx context.Context, tx Tx, id influxdb.ID) error {
 	})
 }
 
+// RewriteTaskOrgName sets the organization name held by each task of org to
+// its current name. It is run within the transaction renaming org, and
+// returns the tasks it rewrote.
+func RewriteTaskOrgName(ctx context.Context, tx Tx, org *influxdb.Organization, oldName string) ([]influxdb.OrganizationReference, error) {
+	indexBucket, err := tx.Bucket(taskIndexBucket)
+	if err != nil {
+		return nil, influxdb.ErrUnexpectedTaskBucketErr(err)
+	}
+
+	prefix, err := org.ID.Encode()
+	if err != nil {
+		return nil, influxdb.ErrInvalidTaskID
+	}
+
+	c, err := indexBucket.ForwardCursor(prefix, WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, influxdb.ErrUnexpectedTaskBucketErr(err)
+	}
+
+	// collect the task IDs before writing, as writes invalidate the cursor
+	var ids []influxdb.ID
+	for k, v := c.Next(); k != nil; k, v = c.Next() {
+		id, err := influxdb.IDFromString(string(v))
+		if err != nil {
+			c.Close()
+			return nil, influxdb.ErrInvalidTaskID
+		}
+		ids = append(ids, *id)
+	}
+	if err := c.Close(); err != nil {
+		return nil, err
+	}
+
+	bucket, err := tx.Bucket(taskBucket)
+	if err != nil {
+		return nil, influxdb.ErrUnexpectedTaskBucketErr(err)
+	}
+
+	name, err := json.Marshal(org.Name)
+	if err != nil {
+		return nil, influxdb.ErrInternalTaskServiceError(err)
+	}
+
+	var refs []influxdb.OrganizationReference
+	for _, id := range ids {
+		key, err := taskKey(id)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := bucket.Get(key)
+		if IsNotFound(err) {
+			// we might have some crufty index's
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// rewrite only the org field to leave the rest of the task as stored
+		var task map[string]json.RawMessage
+		if err := json.Unmarshal(v, &task); err != nil {
+			return nil, influxdb.ErrInternalTaskServiceError(err)
+		}
+		var current string
+		if err := json.Unmarshal(task["org"], &current); err != nil {
+			return nil, influxdb.ErrInternalTaskServiceError(err)
+		}
+		if current == org.Name {
+			continue
+		}
+		task["org"] = name
+
+		taskBytes, err := json.Marshal(task)
+		if err != nil {
+			return nil, influxdb.ErrInternalTaskServiceError(err)
+		}
+		if err := bucket.Put(key, taskBytes); err != nil {
+			return nil, influxdb.ErrUnexpectedTaskBucketErr(err)
+		}
+
+		var taskName string
+		_ = json.Unmarshal(task["name"], &taskName)
+		refs = append(refs, influxdb.OrganizationReference{
+			ResourceType: influxdb.TasksResourceType,
+			ID:           id,
+			Name:         taskName,
+		})
+	}
+	return refs, nil
+}
+
 // FindLogs returns logs for a run.
 func (s *Service) FindLogs(ctx context.Context, filter influxdb.LogFilter) ([]*influxdb.Log, int, error) {
 	var logs []*influxdb.Log
@@ -850,9 +946,10 @@ func (s *Service) findLogs(ctx context.Context, tx Tx, filter influxdb.LogFilter
 		if err != nil {
 			return nil, 0, err
 		}
-		rtn := make([]*influxdb.Log, len(r.Log))
-		for i := 0; i < len(r.Log); i++ {
-			rtn[i] = &r.Log[i]
+		ls := influxdb.LogsSince(r.Log, filter.Since)
+		rtn := make([]*influxdb.Log, len(ls))
+		for i := 0; i < len(ls); i++ {
+			rtn[i] = &ls[i]
 		}
 		return rtn, len(rtn), nil
 	}
@@ -863,9 +960,9 @@ func (s *Service) findLogs(ctx context.Context, tx Tx, filter influxdb.LogFilter
 	}
 	var logs []*influxdb.Log
 	for _, run := range runs {
-		for i := 0; i < len(run.Log); i++ {
-			logs = append(logs, &run.Log[i])
-
+		ls := influxdb.LogsSince(run.Log, filter.Since)
+		for i := 0; i < len(ls); i++ {
+			logs = append(logs, &ls[i])
 		}
 	}
 	return logs, len(logs), nil
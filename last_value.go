@@ -0,0 +1,32 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// LastValue is the most recent value written to a field of a series.
+type LastValue struct {
+	Measurement string            `json:"measurement"`
+	Tags        map[string]string `json:"tags"`
+	Field       string            `json:"field"`
+	Time        time.Time         `json:"time"`
+	Value       interface{}       `json:"value"`
+}
+
+// LastValueFilter selects the series of a measurement to read the last
+// values of.
+type LastValueFilter struct {
+	Measurement string
+	// Tags the series must have, with the same values.
+	Tags map[string]string
+}
+
+// LastValueService reads the last values of series cached as they are
+// written, without querying storage.
+type LastValueService interface {
+	// FindLastValues returns the last values cached for the series of a
+	// measurement of bucket. The measurement must be one of the last value
+	// measurements of the bucket.
+	FindLastValues(ctx context.Context, bucket *Bucket, filter LastValueFilter) ([]*LastValue, error)
+}
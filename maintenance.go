@@ -0,0 +1,37 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// Outcomes of a run of a background maintenance service.
+const (
+	MaintenanceRunSucceeded = "success"
+	MaintenanceRunFailed    = "failure"
+)
+
+// MaintenanceRun is a run of a background maintenance service.
+type MaintenanceRun struct {
+	Start time.Time `json:"start"`
+	// End is unset while the run is in progress.
+	End     *time.Time `json:"end,omitempty"`
+	Outcome string     `json:"outcome,omitempty"`
+	Summary string     `json:"summary,omitempty"`
+}
+
+// MaintenanceServiceStatus is the schedule and last run of a background
+// maintenance service, such as retention enforcement.
+type MaintenanceServiceStatus struct {
+	Name     string          `json:"name"`
+	Enabled  bool            `json:"enabled"`
+	Interval Duration        `json:"interval"`
+	LastRun  *MaintenanceRun `json:"lastRun,omitempty"`
+	NextRun  *time.Time      `json:"nextRun,omitempty"`
+}
+
+// MaintenanceService reports the status of the background maintenance
+// services of the server.
+type MaintenanceService interface {
+	MaintenanceServices(ctx context.Context) ([]MaintenanceServiceStatus, error)
+}
@@ -0,0 +1,146 @@
+// Package maintenance keeps track of the runs of the background maintenance
+// services of the server, such as retention enforcement, so operators can
+// see when they last ran and when they run next.
+package maintenance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+var _ influxdb.MaintenanceService = (*Registry)(nil)
+
+// Registry holds the status of the background services reporting into it.
+type Registry struct {
+	mu        sync.RWMutex
+	reporters []*Reporter
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds the service name to the registry, returning the reporter it
+// records its schedule and runs with. Registering a name again returns the
+// same reporter. A nil registry returns a nil reporter, which discards what
+// it is given.
+func (r *Registry) Register(name string) *Reporter {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rep := range r.reporters {
+		if rep.status.Name == name {
+			return rep
+		}
+	}
+	rep := &Reporter{status: influxdb.MaintenanceServiceStatus{Name: name}}
+	r.reporters = append(r.reporters, rep)
+	return rep
+}
+
+// MaintenanceServices returns the status of the registered services in the
+// order they were registered.
+func (r *Registry) MaintenanceServices(ctx context.Context) ([]influxdb.MaintenanceServiceStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]influxdb.MaintenanceServiceStatus, 0, len(r.reporters))
+	for _, rep := range r.reporters {
+		statuses = append(statuses, rep.Status())
+	}
+	return statuses, nil
+}
+
+// Reporter records the schedule and runs of a background service. The
+// methods of a nil Reporter do nothing, so services need not check whether
+// they report at all.
+type Reporter struct {
+	mu     sync.Mutex
+	status influxdb.MaintenanceServiceStatus
+}
+
+// Schedule records whether the service is enabled, how often it runs and
+// when it runs next. A zero next time means no run is scheduled.
+func (r *Reporter) Schedule(enabled bool, interval time.Duration, next time.Time) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status.Enabled = enabled
+	r.status.Interval = influxdb.Duration{Duration: interval}
+	r.status.NextRun = timePtr(next)
+}
+
+// StartRun records that a run started at start.
+func (r *Reporter) StartRun(start time.Time) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status.LastRun = &influxdb.MaintenanceRun{Start: start.UTC()}
+	r.status.NextRun = nil
+}
+
+// FinishRun records that the run in progress ended at end with summary,
+// failing if err is not nil, and that the next run is at next.
+func (r *Reporter) FinishRun(end time.Time, summary string, err error, next time.Time) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run := r.status.LastRun
+	if run == nil {
+		run = &influxdb.MaintenanceRun{Start: end.UTC()}
+		r.status.LastRun = run
+	}
+	run.End = timePtr(end)
+	run.Outcome = influxdb.MaintenanceRunSucceeded
+	run.Summary = summary
+	if err != nil {
+		run.Outcome = influxdb.MaintenanceRunFailed
+		if summary == "" {
+			run.Summary = err.Error()
+		} else {
+			run.Summary = summary + ": " + err.Error()
+		}
+	}
+	r.status.NextRun = timePtr(next)
+}
+
+// Status returns the status of the service.
+func (r *Reporter) Status() influxdb.MaintenanceServiceStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	if status.LastRun != nil {
+		run := *status.LastRun
+		status.LastRun = &run
+	}
+	return status
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
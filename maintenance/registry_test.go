@@ -0,0 +1,83 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Second)
+	next := start.Add(time.Hour)
+
+	r := NewRegistry()
+	retention := r.Register("retention")
+	precreation := r.Register("shard-precreation")
+	assert.Same(t, retention, r.Register("retention"))
+
+	retention.Schedule(true, time.Hour, start)
+	retention.StartRun(start)
+	retention.FinishRun(end, "deleted 2 shards", nil, next)
+
+	precreation.Schedule(false, 10*time.Minute, time.Time{})
+	precreation.StartRun(start)
+	precreation.FinishRun(end, "", errors.New("meta store closed"), time.Time{})
+
+	statuses, err := r.MaintenanceServices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []influxdb.MaintenanceServiceStatus{
+		{
+			Name:     "retention",
+			Enabled:  true,
+			Interval: influxdb.Duration{Duration: time.Hour},
+			LastRun: &influxdb.MaintenanceRun{
+				Start:   start,
+				End:     &end,
+				Outcome: influxdb.MaintenanceRunSucceeded,
+				Summary: "deleted 2 shards",
+			},
+			NextRun: &next,
+		},
+		{
+			Name:     "shard-precreation",
+			Interval: influxdb.Duration{Duration: 10 * time.Minute},
+			LastRun: &influxdb.MaintenanceRun{
+				Start:   start,
+				End:     &end,
+				Outcome: influxdb.MaintenanceRunFailed,
+				Summary: "meta store closed",
+			},
+		},
+	}, statuses)
+}
+
+func TestReporter_Running(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rep := NewRegistry().Register("retention")
+	rep.Schedule(true, time.Hour, start)
+	rep.StartRun(start)
+
+	status := rep.Status()
+	require.NotNil(t, status.LastRun)
+	assert.Nil(t, status.LastRun.End)
+	assert.Empty(t, status.LastRun.Outcome)
+	assert.Nil(t, status.NextRun)
+}
+
+func TestReporter_Nil(t *testing.T) {
+	var r *Registry
+	rep := r.Register("retention")
+	assert.Nil(t, rep)
+
+	// Reporting into a nil reporter is a no-op.
+	rep.Schedule(true, time.Hour, time.Now())
+	rep.StartRun(time.Now())
+	rep.FinishRun(time.Now(), "", nil, time.Now())
+}
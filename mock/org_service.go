@@ -11,11 +11,13 @@ var _ platform.OrganizationService = &OrganizationService{}
 // OrganizationService is a mock organization server.
 type OrganizationService struct {
 	FindOrganizationByIDF       func(ctx context.Context, id platform.ID) (*platform.Organization, error)
+	FindOrganizationsByIDsF     func(ctx context.Context, ids []platform.ID) ([]*platform.Organization, error)
 	FindOrganizationF           func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error)
 	FindOrganizationsF          func(ctx context.Context, filter platform.OrganizationFilter, opt ...platform.FindOptions) ([]*platform.Organization, int, error)
 	CreateOrganizationF         func(ctx context.Context, b *platform.Organization) error
 	UpdateOrganizationF         func(ctx context.Context, id platform.ID, upd platform.OrganizationUpdate) (*platform.Organization, error)
 	DeleteOrganizationF         func(ctx context.Context, id platform.ID) error
+	RenameOrganizationSafelyF   func(ctx context.Context, id platform.ID, name string) (*platform.Organization, []platform.OrganizationReference, error)
 	FindResourceOrganizationIDF func(ctx context.Context, rt platform.ResourceType, id platform.ID) (platform.ID, error)
 }
 
@@ -24,6 +26,9 @@ type OrganizationService struct {
 func NewOrganizationService() *OrganizationService {
 	return &OrganizationService{
 		FindOrganizationByIDF: func(ctx context.Context, id platform.ID) (*platform.Organization, error) { return nil, nil },
+		FindOrganizationsByIDsF: func(ctx context.Context, ids []platform.ID) ([]*platform.Organization, error) {
+			return nil, nil
+		},
 		FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
 			return nil, nil
 		},
@@ -35,6 +40,9 @@ func NewOrganizationService() *OrganizationService {
 			return nil, nil
 		},
 		DeleteOrganizationF: func(ctx context.Context, id platform.ID) error { return nil },
+		RenameOrganizationSafelyF: func(ctx context.Context, id platform.ID, name string) (*platform.Organization, []platform.OrganizationReference, error) {
+			return nil, nil, nil
+		},
 	}
 }
 
@@ -43,6 +51,11 @@ func (s *OrganizationService) FindOrganizationByID(ctx context.Context, id platf
 	return s.FindOrganizationByIDF(ctx, id)
 }
 
+// FindOrganizationsByIDs calls FindOrganizationsByIDsF.
+func (s *OrganizationService) FindOrganizationsByIDs(ctx context.Context, ids []platform.ID) ([]*platform.Organization, error) {
+	return s.FindOrganizationsByIDsF(ctx, ids)
+}
+
 //FindOrganization calls FindOrganizationF.
 func (s *OrganizationService) FindOrganization(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
 	return s.FindOrganizationF(ctx, filter)
@@ -68,6 +81,11 @@ func (s *OrganizationService) DeleteOrganization(ctx context.Context, id platfor
 	return s.DeleteOrganizationF(ctx, id)
 }
 
+// RenameOrganizationSafely calls RenameOrganizationSafelyF.
+func (s *OrganizationService) RenameOrganizationSafely(ctx context.Context, id platform.ID, name string) (*platform.Organization, []platform.OrganizationReference, error) {
+	return s.RenameOrganizationSafelyF(ctx, id, name)
+}
+
 // FindResourceOrganizationID calls FindResourceOrganizationIDF.
 func (s *OrganizationService) FindResourceOrganizationID(ctx context.Context, rt platform.ResourceType, id platform.ID) (platform.ID, error) {
 	return s.FindResourceOrganizationIDF(ctx, rt, id)
@@ -17,6 +17,7 @@ type ScraperTargetStoreService struct {
 	GetTargetByIDF func(ctx context.Context, id platform.ID) (*platform.ScraperTarget, error)
 	RemoveTargetF  func(ctx context.Context, id platform.ID) error
 	UpdateTargetF  func(ctx context.Context, t *platform.ScraperTarget, userID platform.ID) (*platform.ScraperTarget, error)
+	PatchTargetF   func(ctx context.Context, id platform.ID, upd platform.ScraperTargetUpdate, userID platform.ID) (*platform.ScraperTarget, error)
 }
 
 // ListTargets lists all the scraper targets.
@@ -43,3 +44,8 @@ func (s *ScraperTargetStoreService) RemoveTarget(ctx context.Context, id platfor
 func (s *ScraperTargetStoreService) UpdateTarget(ctx context.Context, t *platform.ScraperTarget, userID platform.ID) (*platform.ScraperTarget, error) {
 	return s.UpdateTargetF(ctx, t, userID)
 }
+
+// PatchTarget applies a partial update to a scraper target.
+func (s *ScraperTargetStoreService) PatchTarget(ctx context.Context, id platform.ID, upd platform.ScraperTargetUpdate, userID platform.ID) (*platform.ScraperTarget, error) {
+	return s.PatchTargetF(ctx, id, upd, userID)
+}
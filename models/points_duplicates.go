@@ -0,0 +1,279 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/pkg/escape"
+)
+
+// DuplicateKey is a tag or field key given more than once in a line of line
+// protocol.
+type DuplicateKey struct {
+	// Line is the number of the line holding the key, counting from 1.
+	Line int
+	// Kind is "tag" or "field".
+	Kind string
+	Key  string
+}
+
+func (d DuplicateKey) String() string {
+	return fmt.Sprintf("line %d: duplicate %s %s", d.Line, d.Kind, d.Key)
+}
+
+// DuplicateAction is what ResolveDuplicates does with a line holding a tag or
+// a field key more than once.
+type DuplicateAction int
+
+const (
+	// DuplicatesDefault leaves lines as they are, for the parser to fail
+	// those with duplicate tags, and keep the last value of duplicate fields.
+	DuplicatesDefault DuplicateAction = iota
+	// DuplicatesReject skips lines with duplicate tags or fields.
+	DuplicatesReject
+	// DuplicatesFirst keeps the first value of duplicate tags and fields.
+	DuplicatesFirst
+	// DuplicatesLast keeps the last value of duplicate tags and fields.
+	DuplicatesLast
+)
+
+// ParseDuplicateAction returns the DuplicateAction named s, one of reject,
+// first and last, or DuplicatesDefault if s is empty.
+func ParseDuplicateAction(s string) (DuplicateAction, error) {
+	switch s {
+	case "":
+		return DuplicatesDefault, nil
+	case "reject":
+		return DuplicatesReject, nil
+	case "first":
+		return DuplicatesFirst, nil
+	case "last":
+		return DuplicatesLast, nil
+	default:
+		return DuplicatesDefault, fmt.Errorf("invalid duplicates %q; valid values are reject, first and last", s)
+	}
+}
+
+// String returns the name of a, as parsed by ParseDuplicateAction.
+func (a DuplicateAction) String() string {
+	switch a {
+	case DuplicatesReject:
+		return "reject"
+	case DuplicatesFirst:
+		return "first"
+	case DuplicatesLast:
+		return "last"
+	default:
+		return ""
+	}
+}
+
+// ResolveDuplicates returns the lines of line protocol buf with their
+// duplicate tags and fields handled as action says, and the duplicate keys
+// of the lines it skipped. Skipped lines are left empty, so that the lines
+// after them keep their numbers. Lines that cannot be scanned are left
+// unchanged, for the parser to report. It returns buf itself if no line
+// holds duplicates.
+func ResolveDuplicates(buf []byte, action DuplicateAction) ([]byte, []DuplicateKey) {
+	if action == DuplicatesDefault {
+		return buf, nil
+	}
+
+	var (
+		// out is only allocated once a line is changed.
+		out      []byte
+		pos      int
+		line     = 1
+		block    []byte
+		rejected []DuplicateKey
+	)
+	for pos < len(buf) {
+		lineStart := pos
+		pos, block = scanLine(buf, pos)
+
+		// Quoted string field values may hold newlines.
+		lineNum := line
+		line += bytes.Count(block, []byte{'\n'}) + 1
+
+		resolved, dups := resolveBlockDuplicates(block, action)
+		for i := range dups {
+			dups[i].Line = lineNum
+		}
+		if action == DuplicatesReject {
+			rejected = append(rejected, dups...)
+		}
+
+		if out == nil && len(dups) > 0 {
+			out = append(make([]byte, 0, len(buf)), buf[:lineStart]...)
+		}
+		if out != nil {
+			out = append(out, resolved...)
+			if pos < len(buf) {
+				out = append(out, '\n')
+			}
+		}
+		pos++
+	}
+	if out == nil {
+		return buf, nil
+	}
+	return out, rejected
+}
+
+// resolveBlockDuplicates resolves the duplicates of the line block, keeping
+// its leading whitespace. A skipped line is returned with its newlines only.
+func resolveBlockDuplicates(block []byte, action DuplicateAction) ([]byte, []DuplicateKey) {
+	start := skipWhitespace(block, 0)
+	if start >= len(block) || block[start] == '#' {
+		return block, nil
+	}
+
+	resolved, dups := resolveLineDuplicates(block[start:], action)
+	if len(dups) == 0 {
+		return block, nil
+	}
+	if resolved == nil {
+		return bytes.Repeat([]byte{'\n'}, bytes.Count(block, []byte{'\n'})), dups
+	}
+	return append(block[:start:start], resolved...), dups
+}
+
+// resolveLineDuplicates returns the line buf with its duplicate tags and
+// fields resolved as action says, or nil if it is skipped, and its duplicate
+// keys. It returns buf unchanged if it has none or cannot be scanned.
+func resolveLineDuplicates(buf []byte, action DuplicateAction) ([]byte, []DuplicateKey) {
+	keyEnd, key := scanTo(buf, 0, ' ')
+	if keyEnd >= len(buf) {
+		return buf, nil
+	}
+	// The first part of the key is the measurement.
+	parts := splitUnescaped(key, ',')
+	fieldsStart := skipWhitespace(buf, keyEnd)
+	fields, fieldsEnd, ok := splitFields(buf, fieldsStart)
+	if !ok {
+		return buf, nil
+	}
+
+	tags, tagDups := resolveKeys(parts[1:], action)
+	fields, fieldDups := resolveKeys(fields, action)
+
+	var dups []DuplicateKey
+	for _, k := range tagDups {
+		dups = append(dups, DuplicateKey{Kind: "tag", Key: string(unescapeTag(k))})
+	}
+	for _, k := range fieldDups {
+		dups = append(dups, DuplicateKey{Kind: "field", Key: string(escape.Unescape(k))})
+	}
+	if len(dups) == 0 {
+		return buf, nil
+	} else if action == DuplicatesReject {
+		return nil, dups
+	}
+
+	out := make([]byte, 0, len(buf))
+	out = append(out, parts[0]...)
+	for _, tag := range tags {
+		out = append(out, ',')
+		out = append(out, tag...)
+	}
+	out = append(out, buf[keyEnd:fieldsStart]...)
+	out = append(out, bytes.Join(fields, []byte{','})...)
+	out = append(out, buf[fieldsEnd:]...)
+	return out, dups
+}
+
+// resolveKeys returns the tags or fields kvs, as key=value pairs, with those
+// of duplicate keys resolved as action says, and the duplicate keys. It
+// returns kvs unchanged if there are none.
+func resolveKeys(kvs [][]byte, action DuplicateAction) ([][]byte, [][]byte) {
+	var (
+		dups [][]byte
+		last = make(map[string]int, len(kvs))
+	)
+	for i, kv := range kvs {
+		_, k := scanTo(kv, 0, '=')
+		if _, ok := last[string(k)]; ok && !containsKey(dups, k) {
+			dups = append(dups, k)
+		}
+		last[string(k)] = i
+	}
+	if len(dups) == 0 {
+		return kvs, nil
+	}
+
+	kept := make([][]byte, 0, len(last))
+	seen := make(map[string]bool, len(last))
+	for i, kv := range kvs {
+		_, k := scanTo(kv, 0, '=')
+		if action == DuplicatesLast {
+			if last[string(k)] == i {
+				kept = append(kept, kv)
+			}
+		} else if !seen[string(k)] {
+			seen[string(k)] = true
+			kept = append(kept, kv)
+		}
+	}
+	return kept, dups
+}
+
+func containsKey(keys [][]byte, k []byte) bool {
+	for _, key := range keys {
+		if bytes.Equal(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitUnescaped splits buf at the occurrences of sep that are not escaped.
+func splitUnescaped(buf []byte, sep byte) [][]byte {
+	var parts [][]byte
+	pos := 0
+	for {
+		i, part := scanTo(buf, pos, sep)
+		parts = append(parts, part)
+		if i >= len(buf) {
+			return parts
+		}
+		pos = i + 1
+	}
+}
+
+// splitFields returns the key=value fields of the line buf, from its fields
+// section at start, and the end of the section. It returns false if a field
+// has no '='.
+func splitFields(buf []byte, start int) ([][]byte, int, bool) {
+	var fields [][]byte
+	end := start
+	for end < len(buf) {
+		i, _ := scanTo(buf, end, '=')
+		if i >= len(buf) {
+			return nil, end, false
+		}
+
+		// Scan the value up to the next unquoted comma or space.
+		j := i + 1
+		quoted := false
+		for j < len(buf) {
+			if buf[j] == '\\' && j+1 < len(buf) {
+				j += 2
+				continue
+			}
+			if buf[j] == '"' {
+				quoted = !quoted
+			} else if !quoted && (buf[j] == ',' || buf[j] == ' ') {
+				break
+			}
+			j++
+		}
+		fields = append(fields, buf[end:j])
+
+		end = j
+		if end >= len(buf) || buf[end] == ' ' {
+			break
+		}
+		end++ // skip the comma
+	}
+	return fields, end, true
+}
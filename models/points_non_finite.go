@@ -0,0 +1,162 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/pkg/escape"
+)
+
+// NonFiniteField is a float field of a line of line protocol whose value is
+// NaN or infinite, which a point cannot hold.
+type NonFiniteField struct {
+	// Line is the number of the line holding the field, counting from 1.
+	Line  int
+	Field string
+	Value string
+}
+
+func (f NonFiniteField) String() string {
+	return fmt.Sprintf("line %d: %s is an unsupported value for field %s", f.Line, f.Value, f.Field)
+}
+
+// NonFiniteAction is what ParsePointsWithNonFinite does with a line holding
+// NaN or infinite float field values.
+type NonFiniteAction int
+
+const (
+	// SkipNonFiniteLines skips the whole line.
+	SkipNonFiniteLines NonFiniteAction = iota
+	// SkipNonFiniteFields skips the fields with such values, and parses the
+	// rest of the line. A line whose fields are all skipped yields no point.
+	SkipNonFiniteFields
+)
+
+// ParsePointsWithNonFinite is similar to ParsePointsWithPrecision, but
+// returns the float field values that are NaN or infinite rather than failing
+// their lines, and skips them as action says. Such values are the literals
+// NaN and Inf, in any case and optionally signed, and floats too large to be
+// held.
+func ParsePointsWithNonFinite(buf []byte, defaultTime time.Time, precision string, action NonFiniteAction) ([]Point, []NonFiniteField, error) {
+	points := make([]Point, 0, bytes.Count(buf, []byte{'\n'})+1)
+	var (
+		pos       int
+		line      = 1
+		block     []byte
+		failed    []string
+		nonFinite []NonFiniteField
+	)
+	for pos < len(buf) {
+		pos, block = scanLine(buf, pos)
+		pos++
+
+		// Quoted string field values may hold newlines.
+		lineNum := line
+		line += bytes.Count(block, []byte{'\n'}) + 1
+
+		if len(block) == 0 {
+			continue
+		}
+
+		start := skipWhitespace(block, 0)
+
+		// If line is all whitespace, just skip it
+		if start >= len(block) {
+			continue
+		}
+
+		// lines which start with '#' are comments
+		if block[start] == '#' {
+			continue
+		}
+
+		// strip the newline if one is present
+		if block[len(block)-1] == '\n' {
+			block = block[:len(block)-1]
+		}
+
+		stripped, fields := stripNonFiniteFields(block[start:])
+		for i := range fields {
+			fields[i].Line = lineNum
+		}
+		nonFinite = append(nonFinite, fields...)
+		if len(fields) > 0 && (action == SkipNonFiniteLines || stripped == nil) {
+			continue
+		}
+
+		pt, err := parsePoint(stripped, defaultTime, precision)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("unable to parse '%s': %v", string(block[start:]), err))
+		} else {
+			points = append(points, pt)
+		}
+	}
+	if len(failed) > 0 {
+		return points, nonFinite, fmt.Errorf("%s", strings.Join(failed, "\n"))
+	}
+	return points, nonFinite, nil
+}
+
+// stripNonFiniteFields returns the line buf without the fields whose values
+// are NaN or infinite floats, and those fields. It returns nil if all fields
+// are such, and buf unchanged if none are or its key cannot be scanned,
+// leaving parsePoint to report any error.
+func stripNonFiniteFields(buf []byte) ([]byte, []NonFiniteField) {
+	pos, _, err := scanKey(buf, 0)
+	if err != nil {
+		return buf, nil
+	}
+
+	start := skipWhitespace(buf, pos)
+	fields, end, ok := splitFields(buf, start)
+	if !ok {
+		return buf, nil
+	}
+
+	var (
+		kept    [][]byte
+		skipped []NonFiniteField
+	)
+	for _, field := range fields {
+		i, key := scanTo(field, 0, '=')
+		if value := field[i+1:]; isNonFiniteFloat(value) {
+			skipped = append(skipped, NonFiniteField{Field: string(escape.Unescape(key)), Value: string(value)})
+		} else {
+			kept = append(kept, field)
+		}
+	}
+
+	if len(skipped) == 0 {
+		return buf, nil
+	}
+	if len(kept) == 0 {
+		return nil, skipped
+	}
+	out := make([]byte, 0, len(buf))
+	out = append(out, buf[:start]...)
+	out = append(out, bytes.Join(kept, []byte{','})...)
+	out = append(out, buf[end:]...)
+	return out, skipped
+}
+
+// isNonFiniteFloat reports whether the field value v is a float that is NaN
+// or infinite.
+func isNonFiniteFloat(v []byte) bool {
+	if len(v) == 0 || v[0] == '"' || bytes.ContainsAny(v, "xX_") {
+		return false
+	}
+	// Integers may be too large, but are never floats.
+	if c := v[len(v)-1]; c == 'i' || c == 'u' {
+		return false
+	}
+	f, err := strconv.ParseFloat(string(v), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return false
+	}
+	return math.IsNaN(f) || math.IsInf(f, 0)
+}
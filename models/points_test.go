@@ -2585,3 +2585,297 @@ func BenchmarkNewTagsKeyValues(b *testing.B) {
 		})
 	})
 }
+
+func TestParsePointsWithNonFinite(t *testing.T) {
+	buf := strings.Join([]string{
+		`cpu value=NaN,count=1i 1`,
+		`cpu value=1.5 2`,
+		`cpu value=+Inf,max=-inf,count=2i 3`,
+		`cpu value=nan,max=1e400`,
+		`cpu desc="NaN and Inf are strings",value=2 4`,
+		`cpu desc="multi`,
+		`line",value=-Infinity 5`,
+		`cpu value=1e-400 6`,
+	}, "\n")
+
+	// Literal and computed non-finite values fail the default parse.
+	for _, line := range []string{`cpu value=NaN`, `cpu value=Inf`, `cpu value=1e400`} {
+		if _, err := models.ParsePointsString(line); err == nil {
+			t.Errorf("ParsePoints(%q) succeeded, expected an error", line)
+		}
+	}
+
+	expNonFinite := []models.NonFiniteField{
+		{Line: 1, Field: "value", Value: "NaN"},
+		{Line: 3, Field: "value", Value: "+Inf"},
+		{Line: 3, Field: "max", Value: "-inf"},
+		{Line: 4, Field: "value", Value: "nan"},
+		{Line: 4, Field: "max", Value: "1e400"},
+		{Line: 6, Field: "value", Value: "-Infinity"},
+	}
+
+	t.Run("skip fields", func(t *testing.T) {
+		pts, nonFinite, err := models.ParsePointsWithNonFinite([]byte(buf), time.Unix(0, 0), "n", models.SkipNonFiniteFields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(nonFinite, expNonFinite) {
+			t.Fatalf("unexpected non-finite fields:\n%v", nonFinite)
+		}
+
+		got := make([]string, len(pts))
+		for i, pt := range pts {
+			got[i] = pt.String()
+		}
+		exp := []string{
+			`cpu count=1i 1`,
+			`cpu value=1.5 2`,
+			`cpu count=2i 3`,
+			`cpu desc="NaN and Inf are strings",value=2 4`,
+			"cpu desc=\"multi\nline\" 5",
+			// A float underflowing to zero is finite.
+			`cpu value=1e-400 6`,
+		}
+		if !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected points:\n%q", got)
+		}
+	})
+
+	t.Run("skip lines", func(t *testing.T) {
+		pts, nonFinite, _ := models.ParsePointsWithNonFinite([]byte(buf), time.Unix(0, 0), "n", models.SkipNonFiniteLines)
+		if !reflect.DeepEqual(nonFinite, expNonFinite) {
+			t.Fatalf("unexpected non-finite fields:\n%v", nonFinite)
+		}
+		if len(pts) != 3 || pts[0].String() != `cpu value=1.5 2` || pts[1].String() != `cpu desc="NaN and Inf are strings",value=2 4` {
+			t.Fatalf("unexpected points: %v", pts)
+		}
+	})
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	buf := strings.Join([]string{
+		`cpu,host=a,host=b value=1 1`,
+		`cpu,host=a value=1,value=2,count=1i,value=3 2`,
+		`# a comment,host=a,host=b`,
+		`cpu,host=a desc="multi`,
+		`line",value=NaN 3`,
+		`  cpu,host=a,region=x,host=b,region=y value=4 4`,
+		`cpu,host=a value=5 5`,
+	}, "\n")
+
+	t.Run("default", func(t *testing.T) {
+		resolved, rejected := models.ResolveDuplicates([]byte(buf), models.DuplicatesDefault)
+		if string(resolved) != buf || rejected != nil {
+			t.Fatalf("unexpected resolved lines:\n%s\nrejected: %v", resolved, rejected)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		resolved, rejected := models.ResolveDuplicates([]byte(buf), models.DuplicatesReject)
+		exp := []models.DuplicateKey{
+			{Line: 1, Kind: "tag", Key: "host"},
+			{Line: 2, Kind: "field", Key: "value"},
+			{Line: 6, Kind: "tag", Key: "host"},
+			{Line: 6, Kind: "tag", Key: "region"},
+		}
+		if !reflect.DeepEqual(rejected, exp) {
+			t.Fatalf("unexpected rejected keys:\n%v", rejected)
+		}
+
+		// The lines after the rejected lines keep their numbers.
+		pts, nonFinite, err := models.ParsePointsWithNonFinite(resolved, time.Unix(0, 0), "n", models.SkipNonFiniteFields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nonFinite) != 1 || nonFinite[0].Line != 4 {
+			t.Fatalf("unexpected non-finite fields: %v", nonFinite)
+		}
+		if len(pts) != 2 || pts[0].String() != "cpu,host=a desc=\"multi\nline\" 3" || pts[1].String() != `cpu,host=a value=5 5` {
+			t.Fatalf("unexpected points: %v", pts)
+		}
+	})
+
+	for _, tt := range []struct {
+		action models.DuplicateAction
+		exp    []string
+	}{
+		{
+			action: models.DuplicatesFirst,
+			exp: []string{
+				`cpu,host=a value=1 1`,
+				`cpu,host=a value=1,count=1i 2`,
+				`cpu,host=a,region=x value=4 4`,
+				`cpu,host=a value=5 5`,
+			},
+		},
+		{
+			action: models.DuplicatesLast,
+			exp: []string{
+				`cpu,host=b value=1 1`,
+				`cpu,host=a count=1i,value=3 2`,
+				`cpu,host=b,region=y value=4 4`,
+				`cpu,host=a value=5 5`,
+			},
+		},
+	} {
+		t.Run(tt.action.String(), func(t *testing.T) {
+			resolved, rejected := models.ResolveDuplicates([]byte(buf), tt.action)
+			if rejected != nil {
+				t.Fatalf("unexpected rejected keys: %v", rejected)
+			}
+			pts, _, err := models.ParsePointsWithNonFinite(resolved, time.Unix(0, 0), "n", models.SkipNonFiniteLines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(pts))
+			for i, pt := range pts {
+				got[i] = pt.String()
+			}
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Fatalf("unexpected points:\n%q", got)
+			}
+		})
+	}
+}
+
+func TestParseDuplicateAction(t *testing.T) {
+	for _, action := range []models.DuplicateAction{models.DuplicatesDefault, models.DuplicatesReject, models.DuplicatesFirst, models.DuplicatesLast} {
+		got, err := models.ParseDuplicateAction(action.String())
+		if err != nil || got != action {
+			t.Errorf("ParseDuplicateAction(%q) = %v, %v", action.String(), got, err)
+		}
+	}
+	if _, err := models.ParseDuplicateAction("newest"); err == nil {
+		t.Error("ParseDuplicateAction(newest) succeeded, expected an error")
+	}
+}
+
+// TestResolveDuplicates_Random checks the lines resolved under each duplicate
+// action against a model of the tags and fields of random lines.
+func TestResolveDuplicates_Random(t *testing.T) {
+	// The keys and values are given as written, and as parsed.
+	type kv struct{ raw, key, value, rawValue string }
+	tagKeys := [][2]string{{"a", "a"}, {"b", "b"}, {`t\ k`, "t k"}}
+	tagValues := [][2]string{{"v0", "v0"}, {"v1", "v1"}, {`v\,2`, "v,2"}}
+	fieldKeys := [][2]string{{"x", "x"}, {"y", "y"}, {`f\=k`, "f=k"}}
+	fieldValues := []string{"1i", "2i", `"s, t=u"`}
+
+	rng := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 200; iter++ {
+		type line struct {
+			tags, fields       []kv
+			dupTags, dupFields bool
+		}
+		var (
+			lines []line
+			buf   bytes.Buffer
+		)
+		for i := 0; i < 1+rng.Intn(5); i++ {
+			var l line
+			seen := make(map[string]bool)
+			for j := 0; j < rng.Intn(4); j++ {
+				k, v := tagKeys[rng.Intn(len(tagKeys))], tagValues[rng.Intn(len(tagValues))]
+				l.dupTags = l.dupTags || seen[k[1]]
+				seen[k[1]] = true
+				l.tags = append(l.tags, kv{raw: k[0] + "=" + v[0], key: k[1], value: v[1]})
+			}
+			seen = make(map[string]bool)
+			for j := 0; j < 1+rng.Intn(4); j++ {
+				k, v := fieldKeys[rng.Intn(len(fieldKeys))], fieldValues[rng.Intn(len(fieldValues))]
+				l.dupFields = l.dupFields || seen[k[1]]
+				seen[k[1]] = true
+				l.fields = append(l.fields, kv{raw: k[0] + "=" + v, key: k[1], rawValue: v})
+			}
+			lines = append(lines, l)
+
+			buf.WriteString("m")
+			for _, tag := range l.tags {
+				buf.WriteString("," + tag.raw)
+			}
+			buf.WriteString(" ")
+			for j, field := range l.fields {
+				if j > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString(field.raw)
+			}
+			fmt.Fprintf(&buf, " %d\n", i+1)
+		}
+
+		// resolve returns the tags or fields of a line as the action keeps
+		// them.
+		resolve := func(kvs []kv, action models.DuplicateAction, field bool) map[string]interface{} {
+			m := make(map[string]interface{})
+			for _, kv := range kvs {
+				if _, ok := m[kv.key]; ok && action == models.DuplicatesFirst {
+					continue
+				}
+				m[kv.key] = kv.value
+				if field {
+					switch kv.rawValue {
+					case "1i":
+						m[kv.key] = int64(1)
+					case "2i":
+						m[kv.key] = int64(2)
+					default:
+						m[kv.key] = "s, t=u"
+					}
+				}
+			}
+			return m
+		}
+
+		for _, action := range []models.DuplicateAction{models.DuplicatesDefault, models.DuplicatesReject, models.DuplicatesFirst, models.DuplicatesLast} {
+			resolved, rejected := models.ResolveDuplicates(buf.Bytes(), action)
+			pts, _ := models.ParsePointsWithPrecision(resolved, time.Unix(0, 0), "n")
+			byLine := make(map[int]models.Point)
+			for _, pt := range pts {
+				byLine[int(pt.UnixNano())] = pt
+			}
+			rejectedLines := make(map[int]bool)
+			for _, d := range rejected {
+				rejectedLines[d.Line] = true
+			}
+
+			for i, l := range lines {
+				n := i + 1
+				pt, parsed := byLine[n]
+				switch {
+				case action == models.DuplicatesReject && (l.dupTags || l.dupFields):
+					if parsed || !rejectedLines[n] {
+						t.Fatalf("%v: line %d with duplicates was not rejected:\n%s", action, n, buf.Bytes())
+					}
+					continue
+				case action == models.DuplicatesDefault && l.dupTags:
+					if parsed {
+						t.Fatalf("%v: line %d with duplicate tags was parsed:\n%s", action, n, buf.Bytes())
+					}
+					continue
+				}
+				if !parsed || rejectedLines[n] {
+					t.Fatalf("%v: line %d was not parsed:\n%s", action, n, buf.Bytes())
+				}
+
+				// The parser keeps the last value of duplicate fields by default.
+				fieldAction := action
+				if action == models.DuplicatesDefault {
+					fieldAction = models.DuplicatesLast
+				}
+				tags := make(map[string]interface{})
+				for k, v := range pt.Tags().Map() {
+					tags[k] = v
+				}
+				fields, err := pt.Fields()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if exp := resolve(l.tags, action, false); !reflect.DeepEqual(tags, exp) {
+					t.Fatalf("%v: line %d: got tags %v, expected %v:\n%s", action, n, tags, exp, buf.Bytes())
+				}
+				if exp := resolve(l.fields, fieldAction, true); !reflect.DeepEqual(map[string]interface{}(fields), exp) {
+					t.Fatalf("%v: line %d: got fields %v, expected %v:\n%s", action, n, fields, exp, buf.Bytes())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	fluxhttp "github.com/influxdata/flux/dependencies/http"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxSnippet is the number of bytes of request and response bodies kept.
+	maxSnippet = 512
+	// maxPeek is the number of bytes of bodies read to redact secrets from
+	// before they are truncated, so that a secret crossing the end of the
+	// snippet is still redacted.
+	maxPeek = 64 << 10
+
+	redacted = "******"
+)
+
+// Resolver finds the notification rule a task sends notifications for, its
+// endpoint and the secrets to redact from recorded deliveries.
+type Resolver struct {
+	Rules     influxdb.NotificationRuleStore
+	Endpoints influxdb.NotificationEndpointService
+	Secrets   influxdb.SecretService
+}
+
+// Client is the http client of flux that records the requests made by the
+// tasks of notification rules as deliveries of their endpoint. Requests made
+// outside of a task run, or by tasks that are not notification rules, are
+// passed through unrecorded.
+type Client struct {
+	log        *zap.Logger
+	next       fluxhttp.Client
+	deliveries influxdb.NotificationDeliveryService
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	resolver *Resolver
+	// ruleIDs caches the notification rule of a task; a zero ID means
+	// the task is not a notification rule.
+	ruleIDs map[influxdb.ID]influxdb.ID
+}
+
+var _ fluxhttp.Client = (*Client)(nil)
+
+// NewClient returns a client recording the deliveries of requests made with
+// next. Nothing is recorded until a resolver is set.
+func NewClient(log *zap.Logger, next fluxhttp.Client, deliveries influxdb.NotificationDeliveryService) *Client {
+	return &Client{
+		log:        log,
+		next:       next,
+		deliveries: deliveries,
+		now:        time.Now,
+		ruleIDs:    make(map[influxdb.ID]influxdb.ID),
+	}
+}
+
+// SetResolver sets the resolver used to map task runs to endpoints.
+func (c *Client) SetResolver(r *Resolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolver = r
+}
+
+// Do sends the request and records it as a delivery if it is made by the task
+// of a notification rule. Failing to record a delivery does not fail the
+// request.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	run, ok := icontext.GetTaskRun(req.Context())
+	c.mu.RLock()
+	resolver := c.resolver
+	c.mu.RUnlock()
+	if !ok || resolver == nil {
+		return c.next.Do(req)
+	}
+
+	reqBody, err := peekRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := c.now()
+	resp, err := c.next.Do(req)
+	d := &influxdb.NotificationDelivery{
+		TaskID:  run.TaskID,
+		RunID:   run.RunID,
+		Time:    start.UTC(),
+		Host:    req.URL.Scheme + "://" + req.URL.Host,
+		Latency: influxdb.Duration{Duration: c.now().Sub(start)},
+	}
+	var respBody []byte
+	if err != nil {
+		d.Error = err.Error()
+	} else {
+		d.StatusCode = resp.StatusCode
+		respBody = peekResponseBody(resp)
+	}
+
+	c.record(req.Context(), resolver, run, req, d, reqBody, respBody)
+	return resp, err
+}
+
+func (c *Client) record(ctx context.Context, resolver *Resolver, run icontext.TaskRun, req *http.Request, d *influxdb.NotificationDelivery, reqBody, respBody []byte) {
+	log := c.log.With(zap.Stringer("task_id", run.TaskID))
+
+	rule, err := c.findRule(ctx, resolver, run)
+	if err != nil {
+		log.Warn("Failed to find notification rule of task", zap.Error(err))
+		return
+	}
+	if rule == nil {
+		return
+	}
+	edp, err := resolver.Endpoints.FindNotificationEndpointByID(ctx, rule.GetEndpointID())
+	if err != nil {
+		log.Warn("Failed to find notification endpoint of rule", zap.Stringer("rule_id", rule.GetID()), zap.Error(err))
+		return
+	}
+
+	secrets := requestSecrets(req)
+	for _, sf := range edp.SecretFields() {
+		if sf.Value != nil {
+			secrets = append(secrets, *sf.Value)
+			continue
+		}
+		v, err := resolver.Secrets.LoadSecret(ctx, edp.GetOrgID(), sf.Key)
+		if err != nil {
+			// Without the secret the bodies cannot be safely redacted.
+			log.Warn("Failed to load notification endpoint secret", zap.Stringer("endpoint_id", edp.GetID()), zap.Error(err))
+			reqBody, respBody = nil, nil
+			continue
+		}
+		secrets = append(secrets, v)
+	}
+
+	d.EndpointID = edp.GetID()
+	d.RuleID = rule.GetID()
+	d.RequestBody = snippet(reqBody, secrets)
+	d.ResponseBody = snippet(respBody, secrets)
+	if d.Error != "" {
+		d.Error = redact(d.Error, secrets)
+	}
+	if err := c.deliveries.RecordNotificationDelivery(ctx, d); err != nil {
+		log.Warn("Failed to record notification delivery", zap.Stringer("endpoint_id", edp.GetID()), zap.Error(err))
+	}
+}
+
+// findRule returns the notification rule of the task, or nil if the task is
+// not a notification rule.
+func (c *Client) findRule(ctx context.Context, resolver *Resolver, run icontext.TaskRun) (influxdb.NotificationRule, error) {
+	c.mu.RLock()
+	ruleID, ok := c.ruleIDs[run.TaskID]
+	c.mu.RUnlock()
+	if ok {
+		if !ruleID.Valid() {
+			return nil, nil
+		}
+		rule, err := resolver.Rules.FindNotificationRuleByID(ctx, ruleID)
+		if err == nil || influxdb.ErrorCode(err) != influxdb.ENotFound {
+			return rule, err
+		}
+	}
+
+	const limit = 100
+	for offset := 0; ; offset += limit {
+		rules, _, err := resolver.Rules.FindNotificationRules(ctx,
+			influxdb.NotificationRuleFilter{OrgID: &run.OrgID},
+			influxdb.FindOptions{Limit: limit, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			if rule.GetTaskID() == run.TaskID {
+				c.cacheRuleID(run.TaskID, rule.GetID())
+				return rule, nil
+			}
+		}
+		if len(rules) < limit {
+			break
+		}
+	}
+	c.cacheRuleID(run.TaskID, 0)
+	return nil, nil
+}
+
+func (c *Client) cacheRuleID(taskID, ruleID influxdb.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ruleIDs[taskID] = ruleID
+}
+
+// peekRequestBody returns the body of the request, leaving it unread.
+func peekRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(io.LimitReader(body, maxPeek))
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if len(b) > maxPeek {
+		b = b[:maxPeek]
+	}
+	return b, nil
+}
+
+// peekResponseBody returns the start of the body of the response, leaving
+// the body unread.
+func peekResponseBody(resp *http.Response) []byte {
+	if resp.Body == nil {
+		return nil
+	}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(b), resp.Body),
+		Closer: resp.Body,
+	}
+	return b
+}
+
+// requestSecrets returns the parts of the request that may carry
+// credentials: the URL path, query and password, and the authorization
+// header.
+func requestSecrets(req *http.Request) []string {
+	secrets := []string{req.URL.Path, req.URL.RawQuery, req.Header.Get("Authorization")}
+	if req.URL.User != nil {
+		if p, ok := req.URL.User.Password(); ok {
+			secrets = append(secrets, p)
+		}
+	}
+	return secrets
+}
+
+func snippet(b []byte, secrets []string) string {
+	s := redact(string(b), secrets)
+	if len(s) > maxSnippet {
+		s = s[:maxSnippet]
+	}
+	return s
+}
+
+// redact replaces the secrets found in s. Secrets shorter than four bytes are
+// ignored as they would redact unrelated text.
+func redact(s string, secrets []string) string {
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if len(secret) < 4 {
+			continue
+		}
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
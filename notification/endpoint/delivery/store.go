@@ -0,0 +1,134 @@
+package delivery
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// DefaultHistorySize is the number of delivery attempts kept per endpoint.
+const DefaultHistorySize = 20
+
+var deliveryBucket = []byte("notificationdeliveriesv1")
+
+var _ influxdb.NotificationDeliveryService = (*Store)(nil)
+
+// Store keeps the last delivery attempts of each notification endpoint in
+// the kv store. Attempts are keyed by endpoint ID and a sequence number, and
+// the oldest attempt of an endpoint is deleted once it has more than the
+// history size.
+type Store struct {
+	kv   kv.Store
+	size int
+}
+
+// NewStore returns a store keeping size attempts per endpoint. A size of
+// zero or less uses DefaultHistorySize.
+func NewStore(store kv.Store, size int) *Store {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &Store{kv: store, size: size}
+}
+
+// RecordNotificationDelivery records a delivery attempt of an endpoint.
+func (s *Store) RecordNotificationDelivery(ctx context.Context, d *influxdb.NotificationDelivery) error {
+	prefix, err := d.EndpointID.Encode()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(deliveryBucket)
+		if err != nil {
+			return err
+		}
+		keys, err := endpointKeys(b, prefix)
+		if err != nil {
+			return err
+		}
+
+		var seq uint64
+		if len(keys) > 0 {
+			seq = binary.BigEndian.Uint64(keys[len(keys)-1][len(prefix):]) + 1
+		}
+		if err := b.Put(deliveryKey(prefix, seq), v); err != nil {
+			return err
+		}
+
+		for i := 0; i < len(keys)+1-s.size; i++ {
+			if err := b.Delete(keys[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindNotificationDeliveries returns the recorded attempts of an endpoint,
+// newest first.
+func (s *Store) FindNotificationDeliveries(ctx context.Context, endpointID influxdb.ID) ([]*influxdb.NotificationDelivery, error) {
+	prefix, err := endpointID.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*influxdb.NotificationDelivery
+	err = s.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(deliveryBucket)
+		if err != nil {
+			return err
+		}
+		cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+		if err != nil {
+			return err
+		}
+		defer cur.Close()
+
+		for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+			d := &influxdb.NotificationDelivery{}
+			if err := json.Unmarshal(v, d); err != nil {
+				return err
+			}
+			deliveries = append(deliveries, d)
+		}
+		return cur.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(deliveries)-1; i < j; i, j = i+1, j-1 {
+		deliveries[i], deliveries[j] = deliveries[j], deliveries[i]
+	}
+	return deliveries, nil
+}
+
+// endpointKeys returns the keys of the attempts of an endpoint, oldest first.
+func endpointKeys(b kv.Bucket, prefix []byte) ([][]byte, error) {
+	cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var keys [][]byte
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	return keys, cur.Err()
+}
+
+func deliveryKey(prefix []byte, seq uint64) []byte {
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], seq)
+	return key
+}
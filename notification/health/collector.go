@@ -0,0 +1,246 @@
+// Package health exposes the health of checks and notification rules as
+// Prometheus metrics, read from the records the server already keeps of
+// their runs, statuses and notifications rather than by querying data.
+package health
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// DefaultMaxSeries is the number of checks, and of notification rules, whose
+// health is reported as their own series when the collector sets no other.
+const DefaultMaxSeries = 1000
+
+// overflowLabel is the label value of the series aggregating the health of
+// the checks, or rules, past the cap on the number of series.
+const overflowLabel = "other"
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Collector collects the health of checks and notification rules: the last
+// evaluation and level of each check, from the run of its task and its
+// newest status record, and the last notification of each rule, from the
+// deliveries recorded for its endpoint.
+//
+// The checks and rules with the lowest IDs have their own series, up to
+// MaxSeries of each, and the others are aggregated into a series labelled
+// "other" with the worst health among them: the highest level, the oldest
+// evaluation or notification, and a failure if any of them failed.
+type Collector struct {
+	// MaxSeries is the number of checks, and of rules, whose health is
+	// reported as their own series. Zero uses DefaultMaxSeries.
+	MaxSeries int
+
+	log        *zap.Logger
+	checks     influxdb.CheckService
+	rules      influxdb.NotificationRuleStore
+	tasks      influxdb.TaskService
+	deliveries influxdb.NotificationDeliveryService
+	levels     *LevelRecorder
+
+	checkEvaluatedDesc *prometheus.Desc
+	checkSuccessDesc   *prometheus.Desc
+	checkLevelDesc     *prometheus.Desc
+	ruleNotifiedDesc   *prometheus.Desc
+	ruleSuccessDesc    *prometheus.Desc
+}
+
+// NewCollector returns a Collector of the health of the checks and rules.
+func NewCollector(log *zap.Logger, checks influxdb.CheckService, rules influxdb.NotificationRuleStore, tasks influxdb.TaskService, deliveries influxdb.NotificationDeliveryService, levels *LevelRecorder) *Collector {
+	return &Collector{
+		log:        log,
+		checks:     checks,
+		rules:      rules,
+		tasks:      tasks,
+		deliveries: deliveries,
+		levels:     levels,
+		checkEvaluatedDesc: prometheus.NewDesc(
+			"check_last_evaluation_timestamp_seconds",
+			"Unix time of the last completed evaluation of the check.",
+			[]string{"check"}, nil),
+		checkSuccessDesc: prometheus.NewDesc(
+			"check_last_evaluation_success",
+			"Whether the last evaluation of the check succeeded.",
+			[]string{"check"}, nil),
+		checkLevelDesc: prometheus.NewDesc(
+			"check_level",
+			"Level of the newest status of the check: 0 unknown, 1 ok, 2 info, 3 warn, 4 crit.",
+			[]string{"check"}, nil),
+		ruleNotifiedDesc: prometheus.NewDesc(
+			"notification_rule_last_notification_timestamp_seconds",
+			"Unix time of the last notification the rule sent to its endpoint.",
+			[]string{"rule"}, nil),
+		ruleSuccessDesc: prometheus.NewDesc(
+			"notification_rule_last_notification_success",
+			"Whether the endpoint accepted the last notification the rule sent.",
+			[]string{"rule"}, nil),
+	}
+}
+
+// Describe returns all descriptions of the collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.checkEvaluatedDesc
+	ch <- c.checkSuccessDesc
+	ch <- c.checkLevelDesc
+	ch <- c.ruleNotifiedDesc
+	ch <- c.ruleSuccessDesc
+}
+
+// Collect returns the health of the checks and rules.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	c.collectChecks(ctx, ch)
+	c.collectRules(ctx, ch)
+}
+
+// health is the health of a check or a rule. A zero time is unknown.
+type health struct {
+	time       time.Time
+	hasSuccess bool
+	success    bool
+	hasLevel   bool
+	level      notification.CheckLevel
+}
+
+// worst merges the health h and o into the worst of both.
+func (h *health) worst(o health) {
+	if !o.time.IsZero() && (h.time.IsZero() || o.time.Before(h.time)) {
+		h.time = o.time
+	}
+	if o.hasSuccess {
+		h.success = (h.success || !h.hasSuccess) && o.success
+		h.hasSuccess = true
+	}
+	if o.hasLevel && (!h.hasLevel || o.level > h.level) {
+		h.level, h.hasLevel = o.level, true
+	}
+}
+
+func (c *Collector) collectChecks(ctx context.Context, ch chan<- prometheus.Metric) {
+	checks, _, err := c.checks.FindChecks(ctx, influxdb.CheckFilter{})
+	if err != nil {
+		c.log.Error("Cannot find checks to collect their health", zap.Error(err))
+		return
+	}
+
+	ids := make([]influxdb.ID, 0, len(checks))
+	healths := make(map[influxdb.ID]health, len(checks))
+	for _, chk := range checks {
+		var h health
+		if task, err := c.tasks.FindTaskByID(ctx, chk.GetTaskID()); err == nil {
+			h.time = task.LatestCompleted
+			h.hasSuccess = task.LastRunStatus != ""
+			h.success = task.LastRunStatus == influxdb.RunSuccess.String()
+		}
+		if l, ok := c.levels.level(chk.GetID()); ok {
+			h.hasLevel, h.level = true, l.level
+		}
+		ids = append(ids, chk.GetID())
+		healths[chk.GetID()] = h
+	}
+
+	retained := make(map[influxdb.ID]struct{}, len(ids))
+	for _, id := range ids {
+		retained[id] = struct{}{}
+	}
+	c.levels.retain(retained)
+
+	c.collect(ids, healths, func(label string, h health) {
+		if !h.time.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.checkEvaluatedDesc, prometheus.GaugeValue, unixSeconds(h.time), label)
+		}
+		if h.hasSuccess {
+			ch <- prometheus.MustNewConstMetric(c.checkSuccessDesc, prometheus.GaugeValue, boolValue(h.success), label)
+		}
+		if h.hasLevel {
+			ch <- prometheus.MustNewConstMetric(c.checkLevelDesc, prometheus.GaugeValue, float64(h.level), label)
+		}
+	})
+}
+
+func (c *Collector) collectRules(ctx context.Context, ch chan<- prometheus.Metric) {
+	rules, _, err := c.rules.FindNotificationRules(ctx, influxdb.NotificationRuleFilter{})
+	if err != nil {
+		c.log.Error("Cannot find notification rules to collect their health", zap.Error(err))
+		return
+	}
+
+	// The deliveries of an endpoint are read once for all of its rules.
+	deliveries := make(map[influxdb.ID][]*influxdb.NotificationDelivery)
+	ids := make([]influxdb.ID, 0, len(rules))
+	healths := make(map[influxdb.ID]health, len(rules))
+	for _, rule := range rules {
+		endpointID := rule.GetEndpointID()
+		ds, ok := deliveries[endpointID]
+		if !ok {
+			ds, err = c.deliveries.FindNotificationDeliveries(ctx, endpointID)
+			if err != nil {
+				c.log.Error("Cannot find notification deliveries", zap.Stringer("endpoint", endpointID), zap.Error(err))
+			}
+			deliveries[endpointID] = ds
+		}
+
+		var h health
+		for _, d := range ds {
+			// Deliveries are newest first.
+			if d.RuleID == rule.GetID() {
+				h.time, h.hasSuccess, h.success = d.Time, true, d.Succeeded()
+				break
+			}
+		}
+		ids = append(ids, rule.GetID())
+		healths[rule.GetID()] = h
+	}
+
+	c.collect(ids, healths, func(label string, h health) {
+		if !h.time.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.ruleNotifiedDesc, prometheus.GaugeValue, unixSeconds(h.time), label)
+		}
+		if h.hasSuccess {
+			ch <- prometheus.MustNewConstMetric(c.ruleSuccessDesc, prometheus.GaugeValue, boolValue(h.success), label)
+		}
+	})
+}
+
+// collect calls fn with the health of each of the ids with the lowest IDs, up
+// to the cap, and then with the worst health of the others, if any.
+func (c *Collector) collect(ids []influxdb.ID, healths map[influxdb.ID]health, fn func(label string, h health)) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	max := c.MaxSeries
+	if max <= 0 {
+		max = DefaultMaxSeries
+	}
+
+	var overflow *health
+	for i, id := range ids {
+		if i < max {
+			fn(id.String(), healths[id])
+			continue
+		}
+		if overflow == nil {
+			overflow = &health{}
+		}
+		overflow.worst(healths[id])
+	}
+	if overflow != nil {
+		fn(overflowLabel, *overflow)
+	}
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
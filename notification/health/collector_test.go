@@ -0,0 +1,124 @@
+package health_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/influxdata/influxdb/v2/notification/health"
+	"github.com/influxdata/influxdb/v2/notification/rule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeDeliveries map[influxdb.ID][]*influxdb.NotificationDelivery
+
+func (f fakeDeliveries) RecordNotificationDelivery(ctx context.Context, d *influxdb.NotificationDelivery) error {
+	f[d.EndpointID] = append([]*influxdb.NotificationDelivery{d}, f[d.EndpointID]...)
+	return nil
+}
+
+func (f fakeDeliveries) FindNotificationDeliveries(ctx context.Context, endpointID influxdb.ID) ([]*influxdb.NotificationDelivery, error) {
+	return f[endpointID], nil
+}
+
+type fakePointsWriter struct{}
+
+func (fakePointsWriter) WritePoints(ctx context.Context, orgID influxdb.ID, bucketID influxdb.ID, points []models.Point) error {
+	return nil
+}
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	checks := mock.NewCheckService()
+	checks.FindChecksFn = func(context.Context, influxdb.CheckFilter, ...influxdb.FindOptions) ([]influxdb.Check, int, error) {
+		return []influxdb.Check{
+			&check.Deadman{Base: check.Base{ID: 1, TaskID: 11}},
+			&check.Deadman{Base: check.Base{ID: 2, TaskID: 12}},
+			&check.Threshold{Base: check.Base{ID: 3, TaskID: 13}},
+		}, 3, nil
+	}
+	tasks := mock.NewTaskService()
+	tasks.FindTaskByIDFn = func(ctx context.Context, id influxdb.ID) (*influxdb.Task, error) {
+		switch id {
+		case 11:
+			return &influxdb.Task{ID: id, LatestCompleted: now, LastRunStatus: "success"}, nil
+		case 12:
+			return &influxdb.Task{ID: id, LatestCompleted: now.Add(-time.Minute), LastRunStatus: "failed"}, nil
+		case 13:
+			return &influxdb.Task{ID: id, LatestCompleted: now.Add(-time.Hour), LastRunStatus: "success"}, nil
+		}
+		return nil, &influxdb.Error{Code: influxdb.ENotFound}
+	}
+	rules := mock.NewNotificationRuleStore()
+	rules.FindNotificationRulesF = func(context.Context, influxdb.NotificationRuleFilter, ...influxdb.FindOptions) ([]influxdb.NotificationRule, int, error) {
+		return []influxdb.NotificationRule{
+			&rule.Slack{Base: rule.Base{ID: 21, EndpointID: 31}},
+			&rule.Slack{Base: rule.Base{ID: 22, EndpointID: 31}},
+		}, 2, nil
+	}
+	deliveries := fakeDeliveries{}
+	require.NoError(t, deliveries.RecordNotificationDelivery(ctx, &influxdb.NotificationDelivery{EndpointID: 31, RuleID: 21, Time: now.Add(-time.Minute), StatusCode: 500}))
+	require.NoError(t, deliveries.RecordNotificationDelivery(ctx, &influxdb.NotificationDelivery{EndpointID: 31, RuleID: 21, Time: now, StatusCode: 204}))
+
+	levels := health.NewLevelRecorder()
+	points, err := models.ParsePointsString(
+		`statuses,_check_id=0000000000000001,_level=ok _message="ok" ` + itoa(now.Add(-time.Minute)) + "\n" +
+			`statuses,_check_id=0000000000000001,_level=crit _message="crit" ` + itoa(now) + "\n" +
+			// An older status does not replace a newer one.
+			`statuses,_check_id=0000000000000001,_level=info _message="info" ` + itoa(now.Add(-time.Hour)) + "\n" +
+			`statuses,_check_id=0000000000000002,_level=warn _message="warn" ` + itoa(now) + "\n" +
+			`cpu,_check_id=0000000000000003,_level=crit usage=1 ` + itoa(now) + "\n")
+	require.NoError(t, err)
+	require.NoError(t, levels.PointsWriter(fakePointsWriter{}).WritePoints(ctx, 1, 2, points))
+
+	c := health.NewCollector(zaptest.NewLogger(t), checks, rules, tasks, deliveries, levels)
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+	reg.MustRegister(c)
+
+	value := func(name, label, id string) float64 {
+		t.Helper()
+		mfs := promtest.MustGather(t, reg)
+		return promtest.MustFindMetric(t, mfs, name, map[string]string{label: id}).GetGauge().GetValue()
+	}
+	absent := func(name, label, id string) bool {
+		mfs := promtest.MustGather(t, reg)
+		return promtest.FindMetric(mfs, name, map[string]string{label: id}) == nil
+	}
+	id := func(i influxdb.ID) string { return i.String() }
+
+	assert.Equal(t, float64(now.Unix()), value("check_last_evaluation_timestamp_seconds", "check", id(1)))
+	assert.Equal(t, 1.0, value("check_last_evaluation_success", "check", id(1)))
+	assert.Equal(t, 4.0, value("check_level", "check", id(1)))
+	assert.Equal(t, 0.0, value("check_last_evaluation_success", "check", id(2)))
+	assert.Equal(t, 3.0, value("check_level", "check", id(2)))
+	assert.True(t, absent("check_level", "check", id(3)), "expected no level of a check without status")
+
+	assert.Equal(t, float64(now.Unix()), value("notification_rule_last_notification_timestamp_seconds", "rule", id(21)))
+	assert.Equal(t, 1.0, value("notification_rule_last_notification_success", "rule", id(21)))
+	assert.True(t, absent("notification_rule_last_notification_success", "rule", id(22)), "expected no result of a rule that never notified")
+
+	// Past the cap, the checks and rules with the highest IDs are aggregated
+	// with their worst health.
+	c.MaxSeries = 1
+	assert.True(t, absent("check_level", "check", id(2)))
+	assert.Equal(t, float64(now.Add(-time.Hour).Unix()), value("check_last_evaluation_timestamp_seconds", "check", "other"))
+	assert.Equal(t, 0.0, value("check_last_evaluation_success", "check", "other"))
+	assert.Equal(t, 3.0, value("check_level", "check", "other"))
+	assert.True(t, absent("notification_rule_last_notification_success", "rule", "other"))
+	assert.Equal(t, 1.0, value("notification_rule_last_notification_success", "rule", id(21)))
+}
+
+func itoa(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
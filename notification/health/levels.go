@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+const (
+	// statusMeasurement is the measurement of the status records checks
+	// write on each evaluation.
+	statusMeasurement = "statuses"
+	checkIDTag        = "_check_id"
+	levelTag          = "_level"
+)
+
+// checkLevel is the level of the newest status record of a check.
+type checkLevel struct {
+	level notification.CheckLevel
+	time  time.Time
+}
+
+// LevelRecorder records the level of the newest status record written by
+// each check, as the records are written, so the levels are known without
+// querying them.
+//
+// Only the records written since the recorder started are known: the level
+// of a check is missing until it is next evaluated.
+type LevelRecorder struct {
+	mu     sync.Mutex
+	levels map[influxdb.ID]checkLevel
+}
+
+// NewLevelRecorder returns a LevelRecorder without any level.
+func NewLevelRecorder() *LevelRecorder {
+	return &LevelRecorder{levels: make(map[influxdb.ID]checkLevel)}
+}
+
+// PointsWriter returns a PointsWriter recording the levels of the status
+// records written to w.
+func (r *LevelRecorder) PointsWriter(w storage.PointsWriter) storage.PointsWriter {
+	return &levelPointsWriter{recorder: r, underlying: w}
+}
+
+// level returns the level of the newest status record of a check, and false
+// if none was recorded.
+func (r *LevelRecorder) level(id influxdb.ID) (checkLevel, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.levels[id]
+	return l, ok
+}
+
+// retain forgets the levels of the checks that are not in ids.
+func (r *LevelRecorder) retain(ids map[influxdb.ID]struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id := range r.levels {
+		if _, ok := ids[id]; !ok {
+			delete(r.levels, id)
+		}
+	}
+}
+
+func (r *LevelRecorder) record(points []models.Point) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range points {
+		if string(p.Name()) != statusMeasurement {
+			continue
+		}
+		var id influxdb.ID
+		if err := id.DecodeFromString(string(p.Tags().Get([]byte(checkIDTag)))); err != nil {
+			continue
+		}
+		level := notification.ParseCheckLevel(strings.ToUpper(string(p.Tags().Get([]byte(levelTag)))))
+		if l, ok := r.levels[id]; ok && l.time.After(p.Time()) {
+			continue
+		}
+		r.levels[id] = checkLevel{level: level, time: p.Time()}
+	}
+}
+
+type levelPointsWriter struct {
+	recorder   *LevelRecorder
+	underlying storage.PointsWriter
+}
+
+// WritePoints writes points to the underlying PointsWriter, and records the
+// levels of the status records among them once they are written.
+func (w *levelPointsWriter) WritePoints(ctx context.Context, orgID influxdb.ID, bucketID influxdb.ID, points []models.Point) error {
+	if err := w.underlying.WritePoints(ctx, orgID, bucketID, points); err != nil {
+		return err
+	}
+	w.recorder.record(points)
+	return nil
+}
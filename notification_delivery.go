@@ -0,0 +1,40 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// NotificationDelivery is an attempt to send a notification to the 3rd party
+// of a notification endpoint. Request and response bodies are truncated and
+// have the secrets of the endpoint redacted.
+type NotificationDelivery struct {
+	EndpointID   ID        `json:"endpointID"`
+	RuleID       ID        `json:"ruleID,omitempty"`
+	TaskID       ID        `json:"taskID,omitempty"`
+	RunID        ID        `json:"runID,omitempty"`
+	Time         time.Time `json:"time"`
+	Host         string    `json:"host"`
+	StatusCode   int       `json:"statusCode,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Latency      Duration  `json:"latency"`
+	RequestBody  string    `json:"requestBody,omitempty"`
+	ResponseBody string    `json:"responseBody,omitempty"`
+}
+
+// Succeeded reports whether the 3rd party accepted the notification.
+func (d *NotificationDelivery) Succeeded() bool {
+	return d.Error == "" && d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+// NotificationDeliveryService records the recent delivery attempts of
+// notification endpoints.
+type NotificationDeliveryService interface {
+	// RecordNotificationDelivery records a delivery attempt, discarding the
+	// oldest attempt of the endpoint once its history is full.
+	RecordNotificationDelivery(ctx context.Context, d *NotificationDelivery) error
+
+	// FindNotificationDeliveries returns the recorded delivery attempts of an
+	// endpoint, newest first.
+	FindNotificationDeliveries(ctx context.Context, endpointID ID) ([]*NotificationDelivery, error)
+}
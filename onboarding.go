@@ -2,6 +2,9 @@ package influxdb
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 )
 
@@ -27,13 +30,23 @@ type OnboardingResults struct {
 
 // OnboardingRequest is the request
 // to setup defaults.
+//
+// In JSON, the retention period is given either as a number of hours, as
+// retentionPeriodHrs, or as a duration string such as "72h", as
+// retentionPeriod.
 type OnboardingRequest struct {
 	User            string        `json:"username"`
 	Password        string        `json:"password"`
 	Org             string        `json:"org"`
 	Bucket          string        `json:"bucket"`
-	RetentionPeriod time.Duration `json:"retentionPeriodHrs,omitempty"`
+	RetentionPeriod time.Duration `json:"-"`
 	Token           string        `json:"token,omitempty"`
+	// TokenDescription is the description of the authorization of the
+	// token, "<username>'s Token" if empty.
+	TokenDescription string `json:"tokenDescription,omitempty"`
+	// BucketDefaults are the bucket defaults of the new organization. They
+	// apply to the initial bucket too.
+	BucketDefaults *BucketDefaults `json:"bucketDefaults,omitempty"`
 }
 
 func (r *OnboardingRequest) Valid() error {
@@ -57,5 +70,87 @@ func (r *OnboardingRequest) Valid() error {
 			Msg:  "bucket name is empty",
 		}
 	}
+
+	if r.RetentionPeriod < 0 {
+		return &Error{
+			Code: EInvalid,
+			Msg:  "retention period must not be negative",
+		}
+	}
+
+	if r.BucketDefaults != nil {
+		return r.BucketDefaults.Valid()
+	}
+	return nil
+}
+
+// onboardingRequestJSON is an OnboardingRequest without its JSON methods.
+type onboardingRequestJSON OnboardingRequest
+
+// MarshalJSON encodes the retention period as a duration string, which
+// keeps periods that are not whole hours.
+func (r OnboardingRequest) MarshalJSON() ([]byte, error) {
+	v := struct {
+		onboardingRequestJSON
+		RetentionPeriod string `json:"retentionPeriod,omitempty"`
+	}{onboardingRequestJSON: onboardingRequestJSON(r)}
+	if r.RetentionPeriod != 0 {
+		v.RetentionPeriod = r.RetentionPeriod.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the retention period from either retentionPeriodHrs,
+// in hours, or retentionPeriod, as a duration string.
+func (r *OnboardingRequest) UnmarshalJSON(b []byte) error {
+	var v struct {
+		onboardingRequestJSON
+		RetentionPeriodHrs *float64 `json:"retentionPeriodHrs"`
+		RetentionPeriod    *string  `json:"retentionPeriod"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*r = OnboardingRequest(v.onboardingRequestJSON)
+
+	switch {
+	case v.RetentionPeriodHrs != nil && v.RetentionPeriod != nil:
+		return &Error{
+			Code: EInvalid,
+			Msg:  "only one of retentionPeriodHrs and retentionPeriod may be given",
+		}
+	case v.RetentionPeriodHrs != nil:
+		hrs := *v.RetentionPeriodHrs
+		if hrs < 0 {
+			return &Error{
+				Code: EInvalid,
+				Msg:  "retention period must not be negative",
+			}
+		}
+		if hrs*float64(time.Hour) > math.MaxInt64 {
+			return &Error{
+				Code: EInvalid,
+				Msg:  fmt.Sprintf("retentionPeriodHrs %v is too large", hrs),
+			}
+		}
+		r.RetentionPeriod = time.Duration(hrs * float64(time.Hour))
+	case v.RetentionPeriod != nil:
+		d, err := time.ParseDuration(*v.RetentionPeriod)
+		if err != nil {
+			return &Error{
+				Code: EInvalid,
+				Msg:  fmt.Sprintf("invalid retentionPeriod %q", *v.RetentionPeriod),
+				Err:  err,
+			}
+		}
+		r.RetentionPeriod = d
+	}
+
+	if r.RetentionPeriod < 0 {
+		return &Error{
+			Code: EInvalid,
+			Msg:  "retention period must not be negative",
+		}
+	}
 	return nil
 }
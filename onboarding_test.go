@@ -0,0 +1,102 @@
+package influxdb_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	platform "github.com/influxdata/influxdb/v2"
+)
+
+func TestOnboardingRequest_JSON(t *testing.T) {
+	cases := []struct {
+		name      string
+		json      string
+		retention time.Duration
+		err       bool
+	}{
+		{
+			name: "no retention",
+			json: `{"username":"u","org":"o","bucket":"b"}`,
+		},
+		{
+			name:      "hours",
+			json:      `{"username":"u","org":"o","bucket":"b","retentionPeriodHrs":72}`,
+			retention: 72 * time.Hour,
+		},
+		{
+			name:      "fractional hours",
+			json:      `{"retentionPeriodHrs":1.5}`,
+			retention: 90 * time.Minute,
+		},
+		{
+			name:      "duration string",
+			json:      `{"retentionPeriod":"72h30m"}`,
+			retention: 72*time.Hour + 30*time.Minute,
+		},
+		{
+			name: "negative hours",
+			json: `{"retentionPeriodHrs":-1}`,
+			err:  true,
+		},
+		{
+			name: "negative duration string",
+			json: `{"retentionPeriod":"-1h"}`,
+			err:  true,
+		},
+		{
+			name: "invalid duration string",
+			json: `{"retentionPeriod":"three days"}`,
+			err:  true,
+		},
+		{
+			name: "too many hours",
+			json: `{"retentionPeriodHrs":1e300}`,
+			err:  true,
+		},
+		{
+			name: "both forms",
+			json: `{"retentionPeriodHrs":1,"retentionPeriod":"1h"}`,
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var req platform.OnboardingRequest
+			err := json.Unmarshal([]byte(c.json), &req)
+			if c.err {
+				if platform.ErrorCode(err) != platform.EInvalid {
+					t.Fatalf("want an invalid error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.RetentionPeriod != c.retention {
+				t.Errorf("want retention period %v, got %v", c.retention, req.RetentionPeriod)
+			}
+		})
+	}
+}
+
+func TestOnboardingRequest_JSONRoundTrip(t *testing.T) {
+	want := platform.OnboardingRequest{
+		User:            "u",
+		Org:             "o",
+		Bucket:          "b",
+		RetentionPeriod: 90 * time.Minute,
+		Token:           "t",
+	}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got platform.OnboardingRequest
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
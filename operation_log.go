@@ -5,11 +5,22 @@ import (
 	"time"
 )
 
+// Operations recorded in an operation log.
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
 // OperationLogEntry is a record in an operation log.
 type OperationLogEntry struct {
 	Description string    `json:"description"`
 	UserID      ID        `json:"userID,omitempty"`
 	Time        time.Time `json:"time,omitempty"`
+	// Operation is the kind of operation performed on the resource.
+	Operation string `json:"operation,omitempty"`
+	// Fields lists the names of the fields changed by an update.
+	Fields []string `json:"fields,omitempty"`
 }
 
 // DashboardOperationLogService is an interface for retrieving the operation log for a dashboard.
@@ -36,6 +47,9 @@ type OrganizationOperationLogService interface {
 	GetOrganizationOperationLog(ctx context.Context, id ID, opts FindOptions) ([]*OperationLogEntry, int, error)
 }
 
+// DefaultMaxOperationLogEntries is the default number of entries retained per resource in an operation log.
+const DefaultMaxOperationLogEntries = 1000
+
 // DefaultOperationLogFindOptions are the default options for the operation log.
 var DefaultOperationLogFindOptions = FindOptions{
 	Descending: true,
@@ -3,6 +3,9 @@ package influxdb
 import (
 	"context"
 	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Organization is an organization. 🎉
@@ -10,9 +13,14 @@ type Organization struct {
 	ID          ID     `json:"id,omitempty"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// BucketDefaults are applied to the buckets created in the organization.
+	BucketDefaults BucketDefaults `json:"bucketDefaults"`
 	CRUDLog
 }
 
+// MaxOrgNameLength is the maximum number of characters in an org name.
+const MaxOrgNameLength = 256
+
 // errors of org
 var (
 	// ErrOrgNameisEmpty is error when org name is empty
@@ -20,17 +28,50 @@ var (
 		Code: EInvalid,
 		Msg:  "org name is empty",
 	}
+
+	// ErrOrgNameTooLong is error when org name is longer than MaxOrgNameLength
+	ErrOrgNameTooLong = &Error{
+		Code: EInvalid,
+		Msg:  fmt.Sprintf("org name must be at most %d characters", MaxOrgNameLength),
+	}
+
+	// ErrOrgNameHasControlChars is error when org name contains control characters
+	ErrOrgNameHasControlChars = &Error{
+		Code: EInvalid,
+		Msg:  "org name must not contain control characters",
+	}
 )
 
+// Valid trims the leading and trailing whitespace of the name of the
+// organization, and returns an error if the name is then empty, longer than
+// MaxOrgNameLength or contains control characters, such as newlines.
+func (o *Organization) Valid() error {
+	o.Name = strings.TrimSpace(o.Name)
+	return validOrgName(o.Name)
+}
+
+func validOrgName(name string) error {
+	switch {
+	case name == "":
+		return ErrOrgNameisEmpty
+	case utf8.RuneCountInString(name) > MaxOrgNameLength:
+		return ErrOrgNameTooLong
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return ErrOrgNameHasControlChars
+	}
+	return nil
+}
+
 // ops for orgs error and orgs op logs.
 const (
-	OpFindOrganizationByID = "FindOrganizationByID"
-	OpFindOrganization     = "FindOrganization"
-	OpFindOrganizations    = "FindOrganizations"
-	OpCreateOrganization   = "CreateOrganization"
-	OpPutOrganization      = "PutOrganization"
-	OpUpdateOrganization   = "UpdateOrganization"
-	OpDeleteOrganization   = "DeleteOrganization"
+	OpFindOrganizationByID   = "FindOrganizationByID"
+	OpFindOrganizationsByIDs = "FindOrganizationsByIDs"
+	OpFindOrganization       = "FindOrganization"
+	OpFindOrganizations      = "FindOrganizations"
+	OpCreateOrganization     = "CreateOrganization"
+	OpPutOrganization        = "PutOrganization"
+	OpUpdateOrganization     = "UpdateOrganization"
+	OpDeleteOrganization     = "DeleteOrganization"
 )
 
 // OrganizationService represents a service for managing organization data.
@@ -38,6 +79,10 @@ type OrganizationService interface {
 	// Returns a single organization by ID.
 	FindOrganizationByID(ctx context.Context, id ID) (*Organization, error)
 
+	// Returns the organizations with the given IDs, in the order of ids.
+	// Returns a not found error listing the IDs of any that do not exist.
+	FindOrganizationsByIDs(ctx context.Context, ids []ID) ([]*Organization, error)
+
 	// Returns the first organization that matches filter.
 	FindOrganization(ctx context.Context, filter OrganizationFilter) (*Organization, error)
 
@@ -54,6 +99,19 @@ type OrganizationService interface {
 
 	// Removes a organization by ID.
 	DeleteOrganization(ctx context.Context, id ID) error
+
+	// Renames an organization and rewrites the references to it by name
+	// within the same transaction, so either all of them change or none do.
+	// Returns the renamed organization and the rewritten references.
+	RenameOrganizationSafely(ctx context.Context, id ID, name string) (*Organization, []OrganizationReference, error)
+}
+
+// OrganizationReference is a resource that referred to an organization by
+// its name.
+type OrganizationReference struct {
+	ResourceType ResourceType `json:"resourceType"`
+	ID           ID           `json:"id"`
+	Name         string       `json:"name,omitempty"`
 }
 
 // OrganizationUpdate represents updates to a organization.
@@ -61,6 +119,25 @@ type OrganizationService interface {
 type OrganizationUpdate struct {
 	Name        *string
 	Description *string `json:"description,omitempty"`
+	// BucketDefaults replaces the bucket defaults of the organization.
+	BucketDefaults *BucketDefaults `json:"bucketDefaults,omitempty"`
+}
+
+// Valid trims the name of the update, if it sets one, and returns an error if
+// it is not a valid org name, as Organization.Valid, or the bucket defaults
+// of the update are not valid.
+func (u *OrganizationUpdate) Valid() error {
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		if err := validOrgName(name); err != nil {
+			return err
+		}
+		u.Name = &name
+	}
+	if u.BucketDefaults != nil {
+		return u.BucketDefaults.Valid()
+	}
+	return nil
 }
 
 // ErrInvalidOrgFilter is the error indicate org filter is empty
@@ -69,11 +146,49 @@ var ErrInvalidOrgFilter = &Error{
 	Msg:  "Please provide either orgID or org",
 }
 
+// ErrOrgFilterNameAndNamePrefix is the error indicating an org filter has both
+// a name and a name prefix.
+var ErrOrgFilterNameAndNamePrefix = &Error{
+	Code: EInvalid,
+	Msg:  "org name and name prefix are mutually exclusive",
+}
+
 // OrganizationFilter represents a set of filter that restrict the returned results.
+// Name and NamePrefix are mutually exclusive.
 type OrganizationFilter struct {
-	Name   *string
-	ID     *ID
-	UserID *ID
+	Name *string
+	// NamePrefix matches the organizations whose names start with it.
+	NamePrefix *string
+	ID         *ID
+	UserID     *ID
+}
+
+// Valid returns an error if the filter sets both Name and NamePrefix.
+func (f OrganizationFilter) Valid() error {
+	if f.Name != nil && f.NamePrefix != nil {
+		return ErrOrgFilterNameAndNamePrefix
+	}
+	return nil
+}
+
+// MatchesNamePrefix reports whether name starts with the NamePrefix of the
+// filter, if it has one.
+func (f OrganizationFilter) MatchesNamePrefix(name string) bool {
+	return f.NamePrefix == nil || strings.HasPrefix(name, *f.NamePrefix)
+}
+
+// ErrOrgsNotFound is the error indicating the organizations with ids do not
+// exist.
+func ErrOrgsNotFound(ids []ID) *Error {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return &Error{
+		Code: ENotFound,
+		Msg:  fmt.Sprintf("organizations not found: %s", strings.Join(strs, ", ")),
+		Op:   OpFindOrganizationsByIDs,
+	}
 }
 
 func ErrInternalOrgServiceError(op string, err error) *Error {
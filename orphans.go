@@ -0,0 +1,43 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// Kinds of storage engine directories holding shards.
+const (
+	OrphanKindData = "data"
+	OrphanKindWAL  = "wal"
+)
+
+// OrphanedDirectory is a directory of the storage engine that belongs to no
+// bucket, or to no shard of the meta store.
+type OrphanedDirectory struct {
+	Path            string    `json:"path"`
+	Kind            string    `json:"kind"`
+	BucketID        string    `json:"bucketID"`
+	RetentionPolicy string    `json:"retentionPolicy,omitempty"`
+	ShardID         uint64    `json:"shardID,omitempty"`
+	Reason          string    `json:"reason"`
+	Size            int64     `json:"size"`
+	ModTime         time.Time `json:"modTime"`
+}
+
+// OrphanReport lists the orphaned directories of the storage engine.
+type OrphanReport struct {
+	Orphans   []OrphanedDirectory `json:"orphans"`
+	TotalSize int64               `json:"totalSize"`
+}
+
+// Add adds an orphaned directory to the report.
+func (r *OrphanReport) Add(o OrphanedDirectory) {
+	r.Orphans = append(r.Orphans, o)
+	r.TotalSize += o.Size
+}
+
+// OrphanReportService reports the directories of the storage engine whose
+// buckets or shards no longer exist.
+type OrphanReportService interface {
+	OrphanReport(ctx context.Context) (*OrphanReport, error)
+}
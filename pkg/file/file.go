@@ -0,0 +1,6 @@
+package file
+
+import "errors"
+
+// ErrLocked is returned by TryLock when the lock is held by another process.
+var ErrLocked = errors.New("file is locked by another process")
@@ -33,3 +33,21 @@ func SyncDir(dirName string) error {
 func RenameFile(oldpath, newpath string) error {
 	return os.Rename(oldpath, newpath)
 }
+
+// TryLock takes an exclusive advisory lock on the file at path, creating it
+// if it does not exist, and returns ErrLocked if another process holds the
+// lock. The lock is released by closing the returned file.
+func TryLock(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
@@ -1,6 +1,10 @@
 package file
 
-import "os"
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
 
 func SyncDir(dirName string) error {
 	return nil
@@ -16,3 +20,22 @@ func RenameFile(oldpath, newpath string) error {
 
 	return os.Rename(oldpath, newpath)
 }
+
+// TryLock takes an exclusive lock on the file at path, creating it if it does
+// not exist, and returns ErrLocked if another process holds the lock. The
+// lock is released by closing the returned file.
+func TryLock(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	flags := uint32(windows.LOCKFILE_EXCLUSIVE_LOCK | windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, new(windows.Overlapped)); err != nil {
+		f.Close()
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
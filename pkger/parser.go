@@ -538,6 +538,8 @@ type (
 	validateOpt struct {
 		minResources bool
 		skipValidate bool
+		deferParams  bool
+		params       map[string]string
 	}
 
 	// ValidateOptFn provides a means to disable desired validation checks.
@@ -562,6 +564,23 @@ func ValidSkipParseError() ValidateOptFn {
 	}
 }
 
+// ValidWithParams provides the values of the parameters of the template,
+// which are substituted for their references in place of their defaults.
+func ValidWithParams(params map[string]string) ValidateOptFn {
+	return func(opt *validateOpt) {
+		opt.params = params
+	}
+}
+
+// ValidDeferParams leaves the references to parameters unresolved. This is
+// useful for reading templates that are combined before their parameters
+// are resolved, as one may declare the parameters of another.
+func ValidDeferParams() ValidateOptFn {
+	return func(opt *validateOpt) {
+		opt.deferParams = true
+	}
+}
+
 // Validate will graph all resources and validate every thing is in a useful form.
 func (p *Template) Validate(opts ...ValidateOptFn) error {
 	opt := &validateOpt{minResources: true}
@@ -569,6 +588,12 @@ func (p *Template) Validate(opts ...ValidateOptFn) error {
 		o(opt)
 	}
 
+	if !opt.deferParams {
+		if err := p.resolveParams(opt.params); err != nil {
+			return err
+		}
+	}
+
 	var setupFns []func() error
 	if opt.minResources {
 		setupFns = append(setupFns, p.validResources)
@@ -1246,6 +1271,10 @@ func (p *Template) graphVariables() *parseErr {
 func (p *Template) eachResource(resourceKind Kind, fn func(o Object) []validationErr) *parseErr {
 	var pErr parseErr
 	for i, k := range p.Objects {
+		if k.Kind == KindParameters {
+			// parameters are resolved before the resources are graphed
+			continue
+		}
 		if err := k.Kind.OK(); err != nil {
 			pErr.append(resourceErr{
 				Kind: k.Kind.String(),
@@ -0,0 +1,308 @@
+package pkger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/task/options"
+)
+
+// KindParameters is the kind of the object declaring the parameters of a
+// template. It is not a resource: its parameters are substituted into the
+// other objects as the template is parsed, and it is then removed.
+const KindParameters Kind = "Parameters"
+
+const fieldParameters = "parameters"
+
+// parameter types
+const (
+	paramTypeDuration = "duration"
+	paramTypeInt      = "int"
+	paramTypeString   = "string"
+)
+
+var (
+	// paramRefPattern matches the references to parameters in string values,
+	// as ${param:name}.
+	paramRefPattern  = regexp.MustCompile(`\$\{param:([^}]*)\}`)
+	paramNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// ParamRef returns the reference to the parameter name, to be substituted
+// with its value in a string value of a template.
+func ParamRef(name string) string {
+	return "${param:" + name + "}"
+}
+
+// templateParam is a parameter declared by a template.
+type templateParam struct {
+	name       string
+	typ        string
+	hasDefault bool
+	defaultVal string
+}
+
+// value returns the value of the parameter from its string form s, which
+// must be of its type. Ints are substituted as numbers, others as strings.
+func (p templateParam) value(s string) (interface{}, error) {
+	switch p.typ {
+	case paramTypeInt:
+		i, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q must be an int; got %q", p.name, s)
+		}
+		return i, nil
+	case paramTypeDuration:
+		if _, err := options.ParseSignedDuration(s); err != nil {
+			return nil, fmt.Errorf("parameter %q must be a duration; got %q", p.name, s)
+		}
+	}
+	return s, nil
+}
+
+// resolveParams substitutes the references to parameters in the objects of
+// the template with their values, or their defaults, and removes the objects
+// declaring them. Nothing is substituted if a value is not of the type of its
+// parameter, or if a referenced parameter is not declared or has no value.
+func (p *Template) resolveParams(values map[string]string) error {
+	params, err := p.declaredParams()
+	if err != nil {
+		return err
+	}
+
+	for name := range values {
+		if _, ok := params[name]; !ok {
+			return influxErr(influxdb.EInvalid, fmt.Sprintf("template declares no parameter %q", name))
+		}
+	}
+
+	resolved := make(map[string]interface{})
+	for name, param := range params {
+		s, ok := values[name]
+		if !ok {
+			if !param.hasDefault {
+				continue
+			}
+			s = param.defaultVal
+		}
+		v, err := param.value(s)
+		if err != nil {
+			return influxErr(influxdb.EInvalid, err.Error())
+		}
+		resolved[name] = v
+	}
+
+	unresolved := make(map[string]bool)
+	findUnresolved := func(s string) interface{} {
+		for _, m := range paramRefPattern.FindAllStringSubmatch(s, -1) {
+			if _, ok := resolved[m[1]]; !ok {
+				unresolved[m[1]] = true
+			}
+		}
+		return s
+	}
+	objects := make([]Object, 0, len(p.Objects))
+	for _, o := range p.Objects {
+		if o.Kind == KindParameters {
+			continue
+		}
+		objects = append(objects, o)
+		walkStrings(o.Metadata, findUnresolved)
+		walkStrings(o.Spec, findUnresolved)
+	}
+	if len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return influxErr(influxdb.EInvalid, "unresolved template parameters: "+strings.Join(names, ", "))
+	}
+
+	substitute := func(s string) interface{} {
+		// A value that is only a reference takes the type of the parameter.
+		if m := paramRefPattern.FindStringSubmatch(s); m != nil && m[0] == s {
+			return resolved[m[1]]
+		}
+		return paramRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			v := resolved[paramRefPattern.FindStringSubmatch(ref)[1]]
+			str, _ := ifaceToStr(v)
+			return str
+		})
+	}
+	for _, o := range objects {
+		walkStrings(o.Metadata, substitute)
+		walkStrings(o.Spec, substitute)
+	}
+	p.Objects = objects
+	return nil
+}
+
+// declaredParams returns the parameters declared by the template, by name.
+func (p *Template) declaredParams() (map[string]templateParam, error) {
+	params := make(map[string]templateParam)
+	for _, o := range p.Objects {
+		if o.Kind != KindParameters {
+			continue
+		}
+		for i, r := range o.Spec.slcResource(fieldParameters) {
+			param := templateParam{
+				name: r.stringShort(fieldName),
+				typ:  r.stringShort(fieldType),
+			}
+			if param.typ == "" {
+				param.typ = paramTypeString
+			}
+			field := fmt.Sprintf("%s %q spec.%s[%d]", KindParameters, o.Name(), fieldParameters, i)
+			if !paramNamePattern.MatchString(param.name) {
+				return nil, influxErr(influxdb.EInvalid, fmt.Sprintf("%s: invalid parameter name %q; must only contain letters, digits, _, . and -", field, param.name))
+			}
+			if _, ok := params[param.name]; ok {
+				return nil, influxErr(influxdb.EInvalid, fmt.Sprintf("%s: parameter %q is declared more than once", field, param.name))
+			}
+			switch param.typ {
+			case paramTypeDuration, paramTypeInt, paramTypeString:
+			default:
+				return nil, influxErr(influxdb.EInvalid, fmt.Sprintf("%s: invalid type %q of parameter %q; must be 1 in [%s, %s, %s]", field, param.typ, param.name, paramTypeDuration, paramTypeInt, paramTypeString))
+			}
+			if v, ok := r[fieldDefault]; ok && v != nil {
+				s, ok := ifaceToStr(v)
+				if !ok {
+					return nil, influxErr(influxdb.EInvalid, fmt.Sprintf("%s: invalid default of parameter %q", field, param.name))
+				}
+				if _, err := param.value(s); err != nil {
+					return nil, influxErr(influxdb.EInvalid, fmt.Sprintf("%s: invalid default: %s", field, err))
+				}
+				param.hasDefault, param.defaultVal = true, s
+			}
+			params[param.name] = param
+		}
+	}
+	return params, nil
+}
+
+// walkStrings replaces every string within v, a decoded value, with the
+// result of fn, in place. It returns the value replacing v.
+func walkStrings(v interface{}, fn func(s string) interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return fn(t)
+	case Resource:
+		for k, vv := range t {
+			t[k] = walkStrings(vv, fn)
+		}
+	case map[string]interface{}:
+		for k, vv := range t {
+			t[k] = walkStrings(vv, fn)
+		}
+	case map[interface{}]interface{}:
+		for k, vv := range t {
+			t[k] = walkStrings(vv, fn)
+		}
+	case []interface{}:
+		for i, vv := range t {
+			t[i] = walkStrings(vv, fn)
+		}
+	case []Resource:
+		for _, vv := range t {
+			walkStrings(vv, fn)
+		}
+	}
+	return v
+}
+
+// ParameterizeBucketNames lifts the names of the buckets of the template
+// into parameters, declared with the names as their defaults, so the
+// template can be applied with other bucket names. The names are replaced
+// in the buckets, and wherever another object quotes them, as in the
+// from(bucket: "name") calls of queries.
+func (p *Template) ParameterizeBucketNames() {
+	// params holds the parameter of each bucket name.
+	params := make(map[string]string)
+	used := make(map[string]bool)
+	for _, o := range p.Objects {
+		if o.Kind == KindParameters {
+			for _, r := range o.Spec.slcResource(fieldParameters) {
+				used[r.stringShort(fieldName)] = true
+			}
+		}
+	}
+	for i, o := range p.Objects {
+		if o.Kind != KindBucket {
+			continue
+		}
+		name, ok := o.Spec[fieldName].(string)
+		if !ok {
+			if _, isSet := o.Spec[fieldName]; isSet {
+				// The name is an environment reference.
+				continue
+			}
+			name = o.Name()
+		}
+		if name == "" || paramRefPattern.MatchString(name) {
+			continue
+		}
+		param, ok := params[name]
+		if !ok {
+			param = paramName("bucket_" + name)
+			for n := 2; used[param]; n++ {
+				param = fmt.Sprintf("%s_%d", paramName("bucket_"+name), n)
+			}
+			used[param] = true
+			params[name] = param
+		}
+		if o.Spec == nil {
+			p.Objects[i].Spec = make(Resource)
+		}
+		p.Objects[i].Spec[fieldName] = ParamRef(param)
+	}
+	if len(params) == 0 {
+		return
+	}
+
+	replacer := func(s string) interface{} {
+		for name, param := range params {
+			s = strings.ReplaceAll(s, strconv.Quote(name), strconv.Quote(ParamRef(param)))
+		}
+		return s
+	}
+	for _, o := range p.Objects {
+		if o.Kind == KindBucket || o.Kind == KindParameters {
+			continue
+		}
+		walkStrings(o.Spec, replacer)
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return params[names[i]] < params[names[j]] })
+	declared := make([]Resource, 0, len(names))
+	for _, name := range names {
+		declared = append(declared, Resource{
+			fieldName:    params[name],
+			fieldType:    paramTypeString,
+			fieldDefault: name,
+		})
+	}
+	o := newObject(KindParameters, "")
+	o.Spec = Resource{fieldParameters: declared}
+	p.Objects = append(p.Objects, o)
+}
+
+// paramName returns s with the characters not allowed in the names of
+// parameters replaced with underscores.
+func paramName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
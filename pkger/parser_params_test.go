@@ -0,0 +1,221 @@
+package pkger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateParams(t *testing.T) {
+	const paramsYml = `apiVersion: influxdata.com/v2alpha1
+kind: Parameters
+metadata:
+  name: params
+spec:
+  parameters:
+    - name: bucket
+      default: telegraf
+    - name: retention
+      type: int
+      default: 3600
+    - name: every
+      type: duration
+      default: 1m
+`
+	const resourcesYml = `apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: bucket
+spec:
+  name: ${param:bucket}
+  retentionRules:
+    - type: expire
+      everySeconds: ${param:retention}
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Telegraf
+metadata:
+  name: telegraf
+spec:
+  name: collector
+  config: |
+    [agent]
+      interval = "${param:every}"
+    [[outputs.influxdb_v2]]
+      bucket = "${param:bucket}"
+`
+
+	t.Run("substitutes defaults", func(t *testing.T) {
+		template := newParsedTemplate(t, FromString(paramsYml+"---\n"+resourcesYml), EncodingYAML)
+		sum := template.Summary()
+		require.Len(t, sum.Buckets, 1)
+		assert.Equal(t, "telegraf", sum.Buckets[0].Name)
+		assert.Equal(t, time.Hour, sum.Buckets[0].RetentionPeriod)
+		require.Len(t, sum.TelegrafConfigs, 1)
+		assert.Contains(t, sum.TelegrafConfigs[0].TelegrafConfig.Config, `interval = "1m"`)
+		assert.Contains(t, sum.TelegrafConfigs[0].TelegrafConfig.Config, `bucket = "telegraf"`)
+		for _, o := range template.Objects {
+			assert.NotEqual(t, KindParameters, o.Kind)
+		}
+	})
+
+	t.Run("substitutes values", func(t *testing.T) {
+		template := newParsedTemplate(t, FromString(paramsYml+"---\n"+resourcesYml), EncodingYAML, ValidWithParams(map[string]string{
+			"bucket":    "staging",
+			"retention": "7200",
+		}))
+		sum := template.Summary()
+		assert.Equal(t, "staging", sum.Buckets[0].Name)
+		assert.Equal(t, 2*time.Hour, sum.Buckets[0].RetentionPeriod)
+		assert.Contains(t, sum.TelegrafConfigs[0].TelegrafConfig.Config, `bucket = "staging"`)
+	})
+
+	t.Run("resolves parameters declared by a combined template", func(t *testing.T) {
+		resources := newParsedTemplate(t, FromString(resourcesYml), EncodingYAML, ValidSkipParseError(), ValidDeferParams())
+		params := newParsedTemplate(t, FromString(paramsYml), EncodingYAML, ValidWithoutResources(), ValidDeferParams())
+
+		template, err := Combine([]*Template{resources, params}, ValidWithParams(map[string]string{"bucket": "prod"}))
+		require.NoError(t, err)
+		assert.Equal(t, "prod", template.Summary().Buckets[0].Name)
+	})
+
+	t.Run("lists unresolved parameters", func(t *testing.T) {
+		const yml = `apiVersion: influxdata.com/v2alpha1
+kind: Parameters
+metadata:
+  name: params
+spec:
+  parameters:
+    - name: bucket
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: bucket
+spec:
+  name: ${param:bucket}
+  description: ${param:owner}'s bucket
+`
+		_, err := Parse(EncodingYAML, FromString(yml), ValidSkipParseError())
+		require.Error(t, err)
+		assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+		assert.Contains(t, err.Error(), "unresolved template parameters: bucket, owner")
+
+		template, err := Parse(EncodingYAML, FromString(yml), ValidWithParams(map[string]string{"bucket": "b", "owner": "ops"}))
+		require.Error(t, err, "expected an error for the undeclared owner parameter")
+		assert.Nil(t, template)
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			yml    string
+			params map[string]string
+			errMsg string
+		}{
+			{
+				name:   "value of wrong type",
+				yml:    paramsYml + "---\n" + resourcesYml,
+				params: map[string]string{"retention": "1h"},
+				errMsg: `parameter "retention" must be an int`,
+			},
+			{
+				name:   "invalid duration",
+				yml:    paramsYml + "---\n" + resourcesYml,
+				params: map[string]string{"every": "often"},
+				errMsg: `parameter "every" must be a duration`,
+			},
+			{
+				name:   "undeclared parameter",
+				yml:    paramsYml + "---\n" + resourcesYml,
+				params: map[string]string{"org": "ops"},
+				errMsg: `template declares no parameter "org"`,
+			},
+			{
+				name:   "invalid type",
+				yml:    strings.Replace(paramsYml, "type: int", "type: float", 1) + "---\n" + resourcesYml,
+				errMsg: `invalid type "float"`,
+			},
+			{
+				name:   "invalid default",
+				yml:    strings.Replace(paramsYml, "default: 3600", "default: hourly", 1) + "---\n" + resourcesYml,
+				errMsg: "invalid default",
+			},
+			{
+				name:   "duplicate name",
+				yml:    strings.Replace(paramsYml, "name: retention", "name: bucket", 1) + "---\n" + resourcesYml,
+				errMsg: "declared more than once",
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				_, err := Parse(EncodingYAML, FromString(tt.yml), ValidSkipParseError(), ValidWithParams(tt.params))
+				require.Error(t, err)
+				assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+				assert.Contains(t, err.Error(), tt.errMsg)
+			}
+			t.Run(tt.name, fn)
+		}
+	})
+}
+
+func TestTemplate_ParameterizeBucketNames(t *testing.T) {
+	const yml = `apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: bucket-1
+spec:
+  name: telegraf
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: bucket-2
+spec:
+  name: system metrics
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Telegraf
+metadata:
+  name: telegraf
+spec:
+  name: collector
+  config: |
+    [[outputs.influxdb_v2]]
+      bucket = "telegraf"
+    [[outputs.influxdb_v2]]
+      bucket = "system metrics"
+`
+	template := newParsedTemplate(t, FromString(yml), EncodingYAML)
+	template.ParameterizeBucketNames()
+
+	buf, err := template.Encode(EncodingYAML)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), `${param:bucket_telegraf}`)
+	assert.Contains(t, string(buf), `${param:bucket_system_metrics}`)
+
+	t.Run("applies with the bucket names by default", func(t *testing.T) {
+		exported := newParsedTemplate(t, FromString(string(buf)), EncodingYAML)
+		sum := exported.Summary()
+		require.Len(t, sum.Buckets, 2)
+		assert.Equal(t, "telegraf", sum.Buckets[0].Name)
+		assert.Equal(t, "system metrics", sum.Buckets[1].Name)
+		assert.Contains(t, sum.TelegrafConfigs[0].TelegrafConfig.Config, `bucket = "telegraf"`)
+	})
+
+	t.Run("applies with other bucket names", func(t *testing.T) {
+		exported := newParsedTemplate(t, FromString(string(buf)), EncodingYAML, ValidWithParams(map[string]string{
+			"bucket_telegraf": "telegraf-staging",
+		}))
+		sum := exported.Summary()
+		assert.Equal(t, "telegraf-staging", sum.Buckets[0].Name)
+		config := sum.TelegrafConfigs[0].TelegrafConfig.Config
+		assert.Contains(t, config, `bucket = "telegraf-staging"`)
+		assert.Contains(t, config, `bucket = "system metrics"`)
+	})
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/iocounter"
+	"github.com/influxdata/flux/metadata"
 	platform "github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/check"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -138,7 +140,15 @@ func (b ProxyQueryServiceAsyncBridge) Query(ctx context.Context, w io.Writer, re
 		return flux.Statistics{}, tracing.LogError(span, err)
 	}
 
-	results := flux.NewResultIteratorFromQuery(q)
+	var it flux.ResultIterator = flux.NewResultIteratorFromQuery(q)
+	var limited *limitingResultIterator
+	if req.Limits != nil && !req.Limits.Unlimited() {
+		cw := &iocounter.Writer{Writer: w}
+		w = cw
+		limited = &limitingResultIterator{ResultIterator: it, limits: *req.Limits, bytes: cw}
+		it = limited
+	}
+	results := &rowCountingResultIterator{ResultIterator: it}
 	defer results.Release()
 
 	encoder := req.Dialect.Encoder()
@@ -146,6 +156,16 @@ func (b ProxyQueryServiceAsyncBridge) Query(ctx context.Context, w io.Writer, re
 	// Release the results and collect the statistics regardless of the error.
 	results.Release()
 	stats := results.Statistics()
+	if stats.Metadata == nil {
+		stats.Metadata = make(metadata.Metadata)
+	}
+	stats.Metadata.Add(RowsReturnedMetadataKey, results.rows)
+	if limited != nil && limited.truncated != "" && err == errResultLimitReached {
+		// The results written so far are complete; the query is only
+		// missing the results past the limit.
+		stats.Metadata.Add(ResultTruncatedMetadataKey, limited.truncated)
+		return stats, nil
+	}
 	if err != nil {
 		return stats, tracing.LogError(span, err)
 	}
@@ -167,6 +187,53 @@ func (ProxyQueryServiceAsyncBridge) Check(context.Context) check.Response {
 	return check.Response{Name: "Query Service", Status: check.StatusPass}
 }
 
+// RowsReturnedMetadataKey is the statistics metadata key of the number of
+// rows of the results encoded by ProxyQueryServiceAsyncBridge.
+const RowsReturnedMetadataKey = "influxdb/rows-returned"
+
+// rowCountingResultIterator counts the rows of the tables read from the
+// results it iterates over.
+type rowCountingResultIterator struct {
+	flux.ResultIterator
+	rows int64
+}
+
+func (it *rowCountingResultIterator) Next() flux.Result {
+	return rowCountingResult{Result: it.ResultIterator.Next(), rows: &it.rows}
+}
+
+type rowCountingResult struct {
+	flux.Result
+	rows *int64
+}
+
+func (r rowCountingResult) Tables() flux.TableIterator {
+	return rowCountingTableIterator{TableIterator: r.Result.Tables(), rows: r.rows}
+}
+
+type rowCountingTableIterator struct {
+	flux.TableIterator
+	rows *int64
+}
+
+func (it rowCountingTableIterator) Do(f func(flux.Table) error) error {
+	return it.TableIterator.Do(func(tbl flux.Table) error {
+		return f(rowCountingTable{Table: tbl, rows: it.rows})
+	})
+}
+
+type rowCountingTable struct {
+	flux.Table
+	rows *int64
+}
+
+func (t rowCountingTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		*t.rows += int64(cr.Len())
+		return f(cr)
+	})
+}
+
 // REPLQuerier implements the repl.Querier interface while consuming a QueryService
 type REPLQuerier struct {
 	// Authorization is the authorization to provide for all requests
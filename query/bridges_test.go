@@ -1,9 +1,11 @@
 package query_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"testing"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/query/mock"
 )
@@ -66,3 +69,96 @@ func TestProxyQueryServiceAsyncBridge_StatsOnClientDisconnect(t *testing.T) {
 		t.Fatalf("stats were missing or had wrong metadata: exp metadata[foo]=[bar], got %v", md)
 	}
 }
+
+func TestProxyQueryServiceAsyncBridge_ResultLimits(t *testing.T) {
+	newQuery := func() *mock.Query {
+		var tables []*executetest.Table
+		for _, host := range []string{"a", "b", "c"} {
+			tbl := &executetest.Table{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "host", Type: flux.TString},
+					{Label: "_value", Type: flux.TInt},
+				},
+			}
+			for i := 0; i < 5; i++ {
+				tbl.Data = append(tbl.Data, []interface{}{host, int64(i)})
+			}
+			tables = append(tables, tbl)
+		}
+		r := executetest.NewResult(tables)
+		r.Nm = "_result"
+		q := mock.NewQuery()
+		q.SetResults(r)
+		return q
+	}
+
+	for _, tt := range []struct {
+		name      string
+		limits    *influxdb.QueryResultLimits
+		rows      int64
+		truncated string
+	}{
+		{name: "unlimited", rows: 15},
+		{name: "under limits", limits: &influxdb.QueryResultLimits{MaxRows: 15, MaxBytes: 1 << 20}, rows: 15},
+		{name: "row limit mid table", limits: &influxdb.QueryResultLimits{MaxRows: 7}, rows: 7, truncated: influxdb.QueryRowLimit},
+		{name: "row limit between tables", limits: &influxdb.QueryResultLimits{MaxRows: 5}, rows: 5, truncated: influxdb.QueryRowLimit},
+		{name: "byte limit", limits: &influxdb.QueryResultLimits{MaxBytes: 1}, rows: 5, truncated: influxdb.QueryByteLimit},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newQuery()
+			bridge := query.ProxyQueryServiceAsyncBridge{
+				AsyncQueryService: &mock.AsyncQueryService{
+					QueryF: func(ctx context.Context, req *query.Request) (flux.Query, error) {
+						return q, nil
+					},
+				},
+			}
+
+			var buf bytes.Buffer
+			stats, err := bridge.Query(context.Background(), &buf, &query.ProxyRequest{
+				Request: query.Request{OrganizationID: 0x1234},
+				Dialect: csv.DefaultDialect(),
+				Limits:  tt.limits,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := stats.Metadata[query.RowsReturnedMetadataKey]; len(got) != 1 || got[0] != tt.rows {
+				t.Errorf("unexpected rows returned: exp %d, got %v", tt.rows, got)
+			}
+			var truncated string
+			if v := stats.Metadata[query.ResultTruncatedMetadataKey]; len(v) == 1 {
+				truncated = v[0].(string)
+			}
+			if truncated != tt.truncated {
+				t.Errorf("unexpected truncation: exp %q, got %q", tt.truncated, truncated)
+			}
+
+			// The truncated results must still be decodable.
+			results, err := csv.NewMultiResultDecoder(csv.ResultDecoderConfig{}).Decode(ioutil.NopCloser(&buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer results.Release()
+			var rows int64
+			for results.More() {
+				if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+					return tbl.Do(func(cr flux.ColReader) error {
+						rows += int64(cr.Len())
+						return nil
+					})
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := results.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if rows != tt.rows {
+				t.Errorf("unexpected decoded rows: exp %d, got %d", tt.rows, rows)
+			}
+		})
+	}
+}
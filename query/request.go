@@ -147,6 +147,9 @@ type ProxyRequest struct {
 	// Dialect is the result encoder
 	Dialect flux.Dialect `json:"dialect"`
 
+	// Limits truncates the encoded results. Nil is unlimited.
+	Limits *platform.QueryResultLimits `json:"limits,omitempty"`
+
 	// dialectMappings maps dialect types to creation methods
 	dialectMappings flux.DialectMappings
 }
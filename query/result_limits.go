@@ -0,0 +1,139 @@
+package query
+
+import (
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/iocounter"
+	platform "github.com/influxdata/influxdb/v2"
+)
+
+// ResultTruncatedMetadataKey is the statistics metadata key of the limit,
+// platform.QueryRowLimit or platform.QueryByteLimit, at which
+// ProxyQueryServiceAsyncBridge truncated the results of a query.
+const ResultTruncatedMetadataKey = "influxdb/result-truncated"
+
+// resultLimitError stops the encoding of results once a limit is reached.
+// It is an encoder error, so that encoders return it instead of encoding it
+// as the error of the query.
+type resultLimitError struct{}
+
+func (resultLimitError) Error() string        { return "query result limit reached" }
+func (resultLimitError) IsEncoderError() bool { return true }
+
+var errResultLimitReached error = resultLimitError{}
+
+// limitingResultIterator truncates the results it iterates over once they
+// reach a number of rows, or once the bytes written by their encoder reach a
+// size. The byte limit is checked before each buffer of rows is encoded, so
+// it may be exceeded by up to one buffer.
+type limitingResultIterator struct {
+	flux.ResultIterator
+	limits platform.QueryResultLimits
+	bytes  *iocounter.Writer
+
+	rows int64
+	// truncated is the limit at which the results were truncated, if any.
+	truncated string
+}
+
+func (it *limitingResultIterator) Next() flux.Result {
+	return limitingResult{Result: it.ResultIterator.Next(), it: it}
+}
+
+// reached returns the limit reached by the results written so far, if any.
+func (it *limitingResultIterator) reached() string {
+	switch {
+	case it.limits.MaxRows > 0 && it.rows >= it.limits.MaxRows:
+		return platform.QueryRowLimit
+	case it.limits.MaxBytes > 0 && it.bytes.Count() >= it.limits.MaxBytes:
+		return platform.QueryByteLimit
+	}
+	return ""
+}
+
+type limitingResult struct {
+	flux.Result
+	it *limitingResultIterator
+}
+
+func (r limitingResult) Tables() flux.TableIterator {
+	return limitingTableIterator{TableIterator: r.Result.Tables(), it: r.it}
+}
+
+type limitingTableIterator struct {
+	flux.TableIterator
+	it *limitingResultIterator
+}
+
+func (ti limitingTableIterator) Do(f func(flux.Table) error) error {
+	err := ti.TableIterator.Do(func(tbl flux.Table) error {
+		if ti.it.truncated = ti.it.reached(); ti.it.truncated != "" {
+			tbl.Done()
+			return errResultLimitReached
+		}
+		return f(limitingTable{Table: tbl, it: ti.it})
+	})
+	// The table iterator may wrap the error of f.
+	if ti.it.truncated != "" {
+		return errResultLimitReached
+	}
+	return err
+}
+
+type limitingTable struct {
+	flux.Table
+	it *limitingResultIterator
+}
+
+func (t limitingTable) Do(f func(flux.ColReader) error) error {
+	it := t.it
+	return t.Table.Do(func(cr flux.ColReader) error {
+		if it.truncated = it.reached(); it.truncated != "" {
+			return errResultLimitReached
+		}
+
+		n := int64(cr.Len())
+		if max := it.limits.MaxRows; max > 0 && it.rows+n > max {
+			sliced := sliceColReader(cr, max-it.rows)
+			defer sliced.Release()
+			it.rows = max
+			if err := f(sliced); err != nil {
+				return err
+			}
+			it.truncated = platform.QueryRowLimit
+			return errResultLimitReached
+		}
+		it.rows += n
+		return f(cr)
+	})
+}
+
+// sliceColReader returns the first n rows of cr.
+func sliceColReader(cr flux.ColReader, n int64) flux.ColReader {
+	cols := cr.Cols()
+	vs := make([]array.Interface, len(cols))
+	for j, c := range cols {
+		var arr array.Interface
+		switch c.Type {
+		case flux.TBool:
+			arr = cr.Bools(j)
+		case flux.TInt:
+			arr = cr.Ints(j)
+		case flux.TUInt:
+			arr = cr.UInts(j)
+		case flux.TFloat:
+			arr = cr.Floats(j)
+		case flux.TString:
+			arr = cr.Strings(j)
+		case flux.TTime:
+			arr = cr.Times(j)
+		}
+		vs[j] = arrow.Slice(arr, 0, n)
+	}
+	return &arrow.TableBuffer{
+		GroupKey: cr.Key(),
+		Columns:  cols,
+		Values:   vs,
+	}
+}
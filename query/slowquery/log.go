@@ -0,0 +1,217 @@
+// Package slowquery records the slowest queries run recently, so that the
+// cause of a slow period can be found after the fact.
+package slowquery
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/influxql"
+)
+
+const (
+	// DefaultSize is the default number of queries kept.
+	DefaultSize = 10
+
+	// DefaultWindow is the default time queries are kept for.
+	DefaultWindow = 24 * time.Hour
+
+	// MaxQueryLength is the length in bytes past which the text of a query
+	// is truncated.
+	MaxQueryLength = 1024
+)
+
+var (
+	_ query.Logger              = (*Log)(nil)
+	_ influxdb.SlowQueryService = (*Log)(nil)
+)
+
+// Log keeps the slowest queries completed within a window of time. It is a
+// query.Logger, to be given the queries of a query.LoggingProxyQueryService.
+type Log struct {
+	size   int
+	window time.Duration
+	now    func() time.Time
+
+	// threshold is the duration in nanoseconds a query must exceed to be
+	// kept, or -1 while the log is not full, and expires is when in unix
+	// nanoseconds the oldest query kept expires. They let queries that are
+	// not kept skip the lock.
+	threshold int64
+	expires   int64
+
+	mu      sync.Mutex
+	queries []influxdb.SlowQuery
+}
+
+// New returns a Log keeping the size slowest queries completed within the
+// last window. A Log of size 0 keeps no queries.
+func New(size int, window time.Duration) *Log {
+	l := &Log{
+		size:   size,
+		window: window,
+		now:    time.Now,
+	}
+	l.update()
+	return l
+}
+
+// Log records q if it is among the slowest queries within the window.
+func (l *Log) Log(q query.Log) error {
+	d := q.Statistics.TotalDuration
+	if l.size <= 0 ||
+		(int64(d) <= atomic.LoadInt64(&l.threshold) && q.Time.UnixNano() < atomic.LoadInt64(&l.expires)) {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.expire(q.Time)
+	if len(l.queries) < l.size {
+		l.queries = append(l.queries, newSlowQuery(q))
+		l.update()
+		return nil
+	}
+
+	fastest := 0
+	for i := range l.queries {
+		if l.queries[i].Duration.Duration < l.queries[fastest].Duration.Duration {
+			fastest = i
+		}
+	}
+	if d > l.queries[fastest].Duration.Duration {
+		l.queries[fastest] = newSlowQuery(q)
+	}
+	l.update()
+	return nil
+}
+
+// SlowQueries returns the slowest queries completed within the window,
+// slowest first.
+func (l *Log) SlowQueries(ctx context.Context) ([]influxdb.SlowQuery, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.expire(l.now())
+	l.update()
+
+	queries := make([]influxdb.SlowQuery, len(l.queries))
+	copy(queries, l.queries)
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].Duration.Duration > queries[j].Duration.Duration
+	})
+	return queries, nil
+}
+
+// ClearSlowQueries forgets the queries recorded so far.
+func (l *Log) ClearSlowQueries(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.queries = l.queries[:0]
+	l.update()
+	return nil
+}
+
+// expire removes the queries completed before the window ending at now.
+func (l *Log) expire(now time.Time) {
+	start := now.Add(-l.window)
+	kept := l.queries[:0]
+	for _, q := range l.queries {
+		if !q.Time.Before(start) {
+			kept = append(kept, q)
+		}
+	}
+	l.queries = kept
+}
+
+// update sets the threshold and expiry of the log from its queries.
+func (l *Log) update() {
+	threshold, expires := int64(-1), int64(math.MaxInt64)
+	if len(l.queries) >= l.size && len(l.queries) > 0 {
+		threshold = math.MaxInt64
+		for _, q := range l.queries {
+			if d := int64(q.Duration.Duration); d < threshold {
+				threshold = d
+			}
+		}
+	}
+	for _, q := range l.queries {
+		if t := q.Time.Add(l.window).UnixNano(); t < expires {
+			expires = t
+		}
+	}
+	atomic.StoreInt64(&l.threshold, threshold)
+	atomic.StoreInt64(&l.expires, expires)
+}
+
+func newSlowQuery(q query.Log) influxdb.SlowQuery {
+	sq := influxdb.SlowQuery{
+		Time:           q.Time,
+		OrganizationID: q.OrganizationID,
+		Duration:       influxdb.Duration{Duration: q.Statistics.TotalDuration},
+		ScannedValues:  sumMetadata(q.Statistics.Metadata, "influxdb/scanned-values"),
+		ScannedBytes:   sumMetadata(q.Statistics.Metadata, "influxdb/scanned-bytes"),
+		PeakMemory:     q.Statistics.MaxAllocated,
+	}
+	if q.ProxyRequest != nil {
+		sq.Query, sq.QueryTruncated = truncate(queryText(q.ProxyRequest.Request.Compiler))
+	}
+	if q.Error != nil {
+		sq.Error = q.Error.Error()
+	}
+	return sq
+}
+
+// queryText returns the text of the query compiled by c, if it has one.
+func queryText(c interface{}) string {
+	switch c := c.(type) {
+	case lang.FluxCompiler:
+		return c.Query
+	case *lang.FluxCompiler:
+		return c.Query
+	case influxql.Compiler:
+		return c.Query
+	case *influxql.Compiler:
+		return c.Query
+	default:
+		return ""
+	}
+}
+
+// truncate returns s cut to at most MaxQueryLength bytes without splitting
+// a character, and whether it was cut.
+func truncate(s string) (string, bool) {
+	if len(s) <= MaxQueryLength {
+		return s, false
+	}
+	n := MaxQueryLength
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n], true
+}
+
+// sumMetadata returns the sum of the integer values of key in md.
+func sumMetadata(md metadata.Metadata, key string) int64 {
+	var sum int64
+	for _, v := range md[key] {
+		switch v := v.(type) {
+		case int:
+			sum += int64(v)
+		case int64:
+			sum += v
+		}
+	}
+	return sum
+}
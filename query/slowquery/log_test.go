@@ -0,0 +1,115 @@
+package slowquery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/stretchr/testify/require"
+)
+
+func newLog(t time.Time, text string, d time.Duration) query.Log {
+	return query.Log{
+		Time:           t,
+		OrganizationID: 1,
+		ProxyRequest: &query.ProxyRequest{
+			Request: query.Request{Compiler: lang.FluxCompiler{Query: text}},
+		},
+		Statistics: flux.Statistics{
+			TotalDuration: d,
+			MaxAllocated:  1024,
+			Metadata: metadata.Metadata{
+				"influxdb/scanned-values": []interface{}{10, 5},
+				"influxdb/scanned-bytes":  []interface{}{80, 40},
+			},
+		},
+	}
+}
+
+func queryTexts(t *testing.T, l *Log) []string {
+	t.Helper()
+	queries, err := l.SlowQueries(context.Background())
+	require.NoError(t, err)
+	texts := make([]string, len(queries))
+	for i, q := range queries {
+		texts[i] = q.Query
+	}
+	return texts
+}
+
+func TestLog(t *testing.T) {
+	now := time.Date(2020, 10, 1, 9, 0, 0, 0, time.UTC)
+	l := New(3, time.Hour)
+	l.now = func() time.Time { return now }
+
+	for _, q := range []struct {
+		text string
+		d    time.Duration
+	}{
+		{"a", 2 * time.Second},
+		{"b", time.Second},
+		{"c", 5 * time.Second},
+		{"d", 3 * time.Second},
+		{"e", time.Millisecond},
+	} {
+		require.NoError(t, l.Log(newLog(now, q.text, q.d)))
+	}
+	require.Equal(t, []string{"c", "d", "a"}, queryTexts(t, l))
+
+	queries, err := l.SlowQueries(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, influxdb.SlowQuery{
+		Time:           now,
+		OrganizationID: 1,
+		Duration:       influxdb.Duration{Duration: 5 * time.Second},
+		ScannedValues:  15,
+		ScannedBytes:   120,
+		PeakMemory:     1024,
+		Query:          "c",
+	}, queries[0])
+
+	// Queries expire after the window, making room for faster ones.
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, l.Log(newLog(now, "f", 4*time.Second)))
+	require.Equal(t, []string{"c", "f", "d"}, queryTexts(t, l))
+
+	now = now.Add(45 * time.Minute)
+	require.Equal(t, []string{"f"}, queryTexts(t, l))
+	require.NoError(t, l.Log(newLog(now, "g", time.Millisecond)))
+	require.Equal(t, []string{"f", "g"}, queryTexts(t, l))
+
+	require.NoError(t, l.ClearSlowQueries(context.Background()))
+	require.Empty(t, queryTexts(t, l))
+	require.NoError(t, l.Log(newLog(now, "h", time.Millisecond)))
+	require.Equal(t, []string{"h"}, queryTexts(t, l))
+}
+
+func TestLog_QueryText(t *testing.T) {
+	now := time.Now()
+	l := New(2, time.Hour)
+
+	long := strings.Repeat("x", MaxQueryLength-1) + "é"
+	q := newLog(now, long, time.Second)
+	q.Error = errors.New("query failed")
+	require.NoError(t, l.Log(q))
+
+	queries, err := l.SlowQueries(context.Background())
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	require.Equal(t, long[:MaxQueryLength-1], queries[0].Query)
+	require.True(t, queries[0].QueryTruncated)
+	require.Equal(t, "query failed", queries[0].Error)
+}
+
+func TestLog_Disabled(t *testing.T) {
+	l := New(0, time.Hour)
+	require.NoError(t, l.Log(newLog(time.Now(), "a", time.Second)))
+	require.Empty(t, queryTexts(t, l))
+}
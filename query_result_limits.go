@@ -0,0 +1,55 @@
+package influxdb
+
+import "context"
+
+// Limits a query may reach, reported when its results are truncated.
+const (
+	QueryRowLimit  = "rows"
+	QueryByteLimit = "bytes"
+)
+
+// QueryResultLimits caps the rows and bytes returned by a single query of an
+// organization. A zero limit is unlimited. Results over a limit are truncated
+// rather than failing the query.
+type QueryResultLimits struct {
+	OrgID    ID    `json:"orgID"`
+	MaxRows  int64 `json:"maxRows"`
+	MaxBytes int64 `json:"maxBytes"`
+	// Default is true if the organization has no limits of its own and the
+	// limits set by the operator apply.
+	Default bool `json:"default"`
+}
+
+// Unlimited reports whether no limit applies.
+func (l QueryResultLimits) Unlimited() bool {
+	return l.MaxRows <= 0 && l.MaxBytes <= 0
+}
+
+// QueryResultLimitService manages the query result limits of organizations.
+type QueryResultLimitService interface {
+	// FindQueryResultLimits returns the limits applying to an organization:
+	// its own if set, and the operator defaults otherwise.
+	FindQueryResultLimits(ctx context.Context, orgID ID) (*QueryResultLimits, error)
+	// SetQueryResultLimits creates or replaces the limits of an organization.
+	SetQueryResultLimits(ctx context.Context, l *QueryResultLimits) error
+	// DeleteQueryResultLimits removes the limits of an organization, so
+	// that the operator defaults apply again.
+	DeleteQueryResultLimits(ctx context.Context, orgID ID) error
+}
+
+// QueryResultLimiter provides the limits enforced on query results and
+// tracks the queries they truncate.
+type QueryResultLimiter interface {
+	// QueryResultLimits returns the limits applying to an organization.
+	QueryResultLimits(ctx context.Context, orgID ID) QueryResultLimits
+	// RecordTruncatedQuery records that a query of the organization was
+	// truncated by reaching the limit, QueryRowLimit or QueryByteLimit.
+	RecordTruncatedQuery(ctx context.Context, orgID ID, limit string)
+}
+
+// ErrQueryResultLimitsNotFound is returned when an organization has no query
+// result limits of its own.
+var ErrQueryResultLimitsNotFound = &Error{
+	Code: ENotFound,
+	Msg:  "query result limits not found",
+}
@@ -0,0 +1,104 @@
+package quota
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const (
+	PrefixQueryLimits = "/api/v2/orgs/{id}/limits/query"
+)
+
+// QueryLimitHandler serves the query result limits of an organization.
+type QueryLimitHandler struct {
+	chi.Router
+	api      *kithttp.API
+	log      *zap.Logger
+	limitSvc influxdb.QueryResultLimitService
+}
+
+// NewQueryLimitHTTPHandler constructs a new http server.
+func NewQueryLimitHTTPHandler(log *zap.Logger, limitSvc influxdb.QueryResultLimitService) *QueryLimitHandler {
+	h := &QueryLimitHandler{
+		api:      kithttp.NewAPI(kithttp.WithLog(log)),
+		log:      log,
+		limitSvc: limitSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetLimits)
+		r.Put("/", h.handlePutLimits)
+		r.Delete("/", h.handleDeleteLimits)
+	})
+
+	h.Router = r
+	return h
+}
+
+func (h *QueryLimitHandler) handleGetLimits(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	l, err := h.limitSvc.FindQueryResultLimits(r.Context(), orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, l)
+}
+
+type putQueryLimitsRequest struct {
+	MaxRows  int64 `json:"maxRows"`
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+func (h *QueryLimitHandler) handlePutLimits(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	var req putQueryLimitsRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	l := &influxdb.QueryResultLimits{
+		OrgID:    orgID,
+		MaxRows:  req.MaxRows,
+		MaxBytes: req.MaxBytes,
+	}
+	if err := h.limitSvc.SetQueryResultLimits(r.Context(), l); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, l)
+}
+
+func (h *QueryLimitHandler) handleDeleteLimits(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := h.limitSvc.DeleteQueryResultLimits(r.Context(), orgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
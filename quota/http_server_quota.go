@@ -0,0 +1,135 @@
+package quota
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const (
+	PrefixIngestQuota = "/api/v2/orgs/{id}/usage/ingest"
+)
+
+// Handler serves the ingest usage and quota of an organization.
+type Handler struct {
+	chi.Router
+	api      *kithttp.API
+	log      *zap.Logger
+	quotaSvc influxdb.IngestQuotaService
+}
+
+// NewHTTPHandler constructs a new http server.
+func NewHTTPHandler(log *zap.Logger, quotaSvc influxdb.IngestQuotaService) *Handler {
+	h := &Handler{
+		api:      kithttp.NewAPI(kithttp.WithLog(log)),
+		log:      log,
+		quotaSvc: quotaSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetUsage)
+
+		r.Route("/quota", func(r chi.Router) {
+			r.Get("/", h.handleGetQuota)
+			r.Put("/", h.handlePutQuota)
+			r.Delete("/", h.handleDeleteQuota)
+		})
+	})
+
+	h.Router = r
+	return h
+}
+
+// decodeOrgID returns the ID of the organization in the URL.
+func decodeOrgID(r *http.Request) (influxdb.ID, error) {
+	var id influxdb.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "invalid organization ID",
+			Err:  err,
+		}
+	}
+	return id, nil
+}
+
+func (h *Handler) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	usage, err := h.quotaSvc.IngestUsage(r.Context(), orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, usage)
+}
+
+func (h *Handler) handleGetQuota(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	q, err := h.quotaSvc.FindIngestQuota(r.Context(), orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, q)
+}
+
+type putQuotaRequest struct {
+	DailyBytes int64 `json:"dailyBytes"`
+	Grace      bool  `json:"grace"`
+}
+
+func (h *Handler) handlePutQuota(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	var req putQuotaRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	q := &influxdb.IngestQuota{
+		OrgID:      orgID,
+		DailyBytes: req.DailyBytes,
+		Grace:      req.Grace,
+	}
+	if err := h.quotaSvc.SetIngestQuota(r.Context(), q); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, q)
+}
+
+func (h *Handler) handleDeleteQuota(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := h.quotaSvc.DeleteIngestQuota(r.Context(), orgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
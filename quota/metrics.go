@@ -0,0 +1,48 @@
+package quota
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	namespace = "quota"
+	subsystem = "ingest"
+)
+
+type metrics struct {
+	exceeded *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		exceeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "exceeded_total",
+			Help:      "Number of writes made by organizations over their ingest quota, by whether they were rejected or allowed in grace mode.",
+		}, []string{"org_id", "mode"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the service.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{s.metrics.exceeded}
+}
+
+type queryMetrics struct {
+	truncated *prometheus.CounterVec
+}
+
+func newQueryMetrics() *queryMetrics {
+	return &queryMetrics{
+		truncated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "query",
+			Name:      "truncated_total",
+			Help:      "Number of queries of organizations whose results were truncated, by the limit reached.",
+		}, []string{"org_id", "limit"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the service.
+func (s *QueryLimitService) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{s.metrics.truncated}
+}
@@ -0,0 +1,85 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+)
+
+var _ influxdb.IngestQuotaService = (*AuthorizedService)(nil)
+
+// AuthorizedService authorizes access to ingest quotas. Usage and quotas may
+// be read by anyone able to read the organization; only operators may change
+// quotas.
+type AuthorizedService struct {
+	s influxdb.IngestQuotaService
+}
+
+// NewAuthorizedService wraps s with authorization checks.
+func NewAuthorizedService(s influxdb.IngestQuotaService) *AuthorizedService {
+	return &AuthorizedService{s: s}
+}
+
+func (svc *AuthorizedService) IngestUsage(ctx context.Context, orgID influxdb.ID) (*influxdb.IngestUsage, error) {
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return svc.s.IngestUsage(ctx, orgID)
+}
+
+func (svc *AuthorizedService) FindIngestQuota(ctx context.Context, orgID influxdb.ID) (*influxdb.IngestQuota, error) {
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return svc.s.FindIngestQuota(ctx, orgID)
+}
+
+func (svc *AuthorizedService) SetIngestQuota(ctx context.Context, q *influxdb.IngestQuota) error {
+	if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return svc.s.SetIngestQuota(ctx, q)
+}
+
+func (svc *AuthorizedService) DeleteIngestQuota(ctx context.Context, orgID influxdb.ID) error {
+	if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return svc.s.DeleteIngestQuota(ctx, orgID)
+}
+
+var _ influxdb.QueryResultLimitService = (*AuthorizedQueryLimitService)(nil)
+
+// AuthorizedQueryLimitService authorizes access to query result limits.
+// Limits may be read by anyone able to read the organization; only
+// operators may change them.
+type AuthorizedQueryLimitService struct {
+	s influxdb.QueryResultLimitService
+}
+
+// NewAuthorizedQueryLimitService wraps s with authorization checks.
+func NewAuthorizedQueryLimitService(s influxdb.QueryResultLimitService) *AuthorizedQueryLimitService {
+	return &AuthorizedQueryLimitService{s: s}
+}
+
+func (svc *AuthorizedQueryLimitService) FindQueryResultLimits(ctx context.Context, orgID influxdb.ID) (*influxdb.QueryResultLimits, error) {
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return svc.s.FindQueryResultLimits(ctx, orgID)
+}
+
+func (svc *AuthorizedQueryLimitService) SetQueryResultLimits(ctx context.Context, l *influxdb.QueryResultLimits) error {
+	if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return svc.s.SetQueryResultLimits(ctx, l)
+}
+
+func (svc *AuthorizedQueryLimitService) DeleteQueryResultLimits(ctx context.Context, orgID influxdb.ID) error {
+	if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return svc.s.DeleteQueryResultLimits(ctx, orgID)
+}
@@ -0,0 +1,152 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+var queryLimitsBucket = []byte("queryresultlimitsv1")
+
+var (
+	_ influxdb.QueryResultLimitService = (*QueryLimitService)(nil)
+	_ influxdb.QueryResultLimiter      = (*QueryLimitService)(nil)
+)
+
+// QueryLimitService provides the limits on the rows and bytes returned by a
+// query of each organization. Organizations without limits of their own get
+// the defaults set by the operator. Limits are stored in the kv store and
+// cached in memory, as they are read by every query.
+type QueryLimitService struct {
+	log      *zap.Logger
+	store    kv.Store
+	defaults influxdb.QueryResultLimits
+	metrics  *queryMetrics
+
+	mu     sync.RWMutex
+	limits map[influxdb.ID]influxdb.QueryResultLimits
+}
+
+// NewQueryLimitService returns a service persisting limits to store. The
+// defaults apply to organizations without limits of their own; zero limits
+// are unlimited.
+func NewQueryLimitService(log *zap.Logger, store kv.Store, defaults influxdb.QueryResultLimits) *QueryLimitService {
+	defaults.OrgID = 0
+	defaults.Default = true
+	return &QueryLimitService{
+		log:      log,
+		store:    store,
+		defaults: defaults,
+		metrics:  newQueryMetrics(),
+		limits:   make(map[influxdb.ID]influxdb.QueryResultLimits),
+	}
+}
+
+// Open loads the limits of organizations from the store.
+func (s *QueryLimitService) Open(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.store.View(ctx, func(tx kv.Tx) error {
+		return forEach(tx, queryLimitsBucket, func(id influxdb.ID, v []byte) error {
+			var l influxdb.QueryResultLimits
+			if err := json.Unmarshal(v, &l); err != nil {
+				return err
+			}
+			s.limits[id] = l
+			return nil
+		})
+	})
+}
+
+// QueryResultLimits returns the limits applying to the organization.
+func (s *QueryLimitService) QueryResultLimits(ctx context.Context, orgID influxdb.ID) influxdb.QueryResultLimits {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if l, ok := s.limits[orgID]; ok {
+		return l
+	}
+	l := s.defaults
+	l.OrgID = orgID
+	return l
+}
+
+// RecordTruncatedQuery counts a query of the organization truncated by the
+// limit.
+func (s *QueryLimitService) RecordTruncatedQuery(ctx context.Context, orgID influxdb.ID, limit string) {
+	s.metrics.truncated.WithLabelValues(orgID.String(), limit).Inc()
+	s.log.Debug("Query results truncated",
+		zap.Stringer("org_id", orgID),
+		zap.String("limit", limit))
+}
+
+// FindQueryResultLimits returns the limits applying to the organization.
+func (s *QueryLimitService) FindQueryResultLimits(ctx context.Context, orgID influxdb.ID) (*influxdb.QueryResultLimits, error) {
+	l := s.QueryResultLimits(ctx, orgID)
+	return &l, nil
+}
+
+// SetQueryResultLimits creates or replaces the limits of the organization.
+func (s *QueryLimitService) SetQueryResultLimits(ctx context.Context, l *influxdb.QueryResultLimits) error {
+	if !l.OrgID.Valid() {
+		return influxdb.ErrInvalidID
+	}
+	if l.MaxRows < 0 || l.MaxBytes < 0 {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "query result limits must not be negative",
+		}
+	}
+	l.Default = false
+
+	v, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(queryLimitsBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(encodeID(l.OrgID), v)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.limits[l.OrgID] = *l
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteQueryResultLimits removes the limits of the organization.
+func (s *QueryLimitService) DeleteQueryResultLimits(ctx context.Context, orgID influxdb.ID) error {
+	s.mu.RLock()
+	_, ok := s.limits[orgID]
+	s.mu.RUnlock()
+	if !ok {
+		return influxdb.ErrQueryResultLimitsNotFound
+	}
+
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(queryLimitsBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete(encodeID(orgID))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.limits, orgID)
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,43 @@
+package quota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestQueryLimitService(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	defaults := influxdb.QueryResultLimits{MaxRows: 1000}
+
+	s := NewQueryLimitService(zaptest.NewLogger(t), store, defaults)
+	require.NoError(t, s.Open(ctx))
+
+	// Organizations without limits of their own get the defaults.
+	l := s.QueryResultLimits(ctx, orgID)
+	assert.Equal(t, influxdb.QueryResultLimits{OrgID: orgID, MaxRows: 1000, Default: true}, l)
+
+	require.NoError(t, s.SetQueryResultLimits(ctx, &influxdb.QueryResultLimits{OrgID: orgID, MaxBytes: 1 << 20}))
+	found, err := s.FindQueryResultLimits(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, &influxdb.QueryResultLimits{OrgID: orgID, MaxBytes: 1 << 20}, found)
+	assert.False(t, found.Unlimited())
+
+	// Limits survive a restart.
+	s = NewQueryLimitService(zaptest.NewLogger(t), store, defaults)
+	require.NoError(t, s.Open(ctx))
+	assert.Equal(t, int64(1<<20), s.QueryResultLimits(ctx, orgID).MaxBytes)
+
+	require.NoError(t, s.DeleteQueryResultLimits(ctx, orgID))
+	assert.True(t, s.QueryResultLimits(ctx, orgID).Default)
+	err = s.DeleteQueryResultLimits(ctx, orgID)
+	assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+
+	err = s.SetQueryResultLimits(ctx, &influxdb.QueryResultLimits{OrgID: orgID, MaxRows: -1})
+	assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+}
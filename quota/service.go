@@ -0,0 +1,376 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultFlushInterval is how often usage counters are persisted.
+	DefaultFlushInterval = 10 * time.Second
+
+	period = 24 * time.Hour
+)
+
+var (
+	quotaBucket = []byte("ingestquotasv1")
+	usageBucket = []byte("ingestusagev1")
+)
+
+var (
+	_ influxdb.IngestQuotaService  = (*Service)(nil)
+	_ influxdb.IngestQuotaEnforcer = (*Service)(nil)
+)
+
+// orgUsage is the usage of an organization in a period.
+type orgUsage struct {
+	PeriodStart time.Time `json:"periodStart"`
+	Bytes       int64     `json:"bytes"`
+
+	dirty  bool
+	warned bool
+}
+
+// Service tracks the bytes written by each organization per daily period and
+// enforces their ingest quotas. Quotas are stored in the kv store, usage is
+// counted in memory and flushed to the kv store periodically so that it
+// survives a restart.
+type Service struct {
+	log         *zap.Logger
+	store       kv.Store
+	resetOffset time.Duration
+	metrics     *metrics
+	now         func() time.Time
+
+	mu     sync.Mutex
+	quotas map[influxdb.ID]influxdb.IngestQuota
+	usage  map[influxdb.ID]*orgUsage
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithResetOffset sets the time of day, in UTC, at which usage is reset.
+// The default resets usage at midnight UTC.
+func WithResetOffset(d time.Duration) Option {
+	return func(s *Service) {
+		s.resetOffset = d
+	}
+}
+
+// NewService returns a service persisting quotas and usage to store.
+func NewService(log *zap.Logger, store kv.Store, opts ...Option) *Service {
+	s := &Service{
+		log:     log,
+		store:   store,
+		metrics: newMetrics(),
+		now:     time.Now,
+		quotas:  make(map[influxdb.ID]influxdb.IngestQuota),
+		usage:   make(map[influxdb.ID]*orgUsage),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Open loads the quotas and the usage of the current period from the store.
+func (s *Service) Open(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.store.View(ctx, func(tx kv.Tx) error {
+		if err := forEach(tx, quotaBucket, func(id influxdb.ID, v []byte) error {
+			var q influxdb.IngestQuota
+			if err := json.Unmarshal(v, &q); err != nil {
+				return err
+			}
+			s.quotas[id] = q
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		start := s.periodStart(s.now())
+		return forEach(tx, usageBucket, func(id influxdb.ID, v []byte) error {
+			var u orgUsage
+			if err := json.Unmarshal(v, &u); err != nil {
+				return err
+			}
+			if u.PeriodStart.Equal(start) {
+				s.usage[id] = &u
+			}
+			return nil
+		})
+	})
+}
+
+func forEach(tx kv.Tx, bucket []byte, fn func(id influxdb.ID, v []byte) error) error {
+	b, err := tx.Bucket(bucket)
+	if err != nil {
+		return err
+	}
+	cur, err := b.ForwardCursor(nil)
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+
+	for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+		var id influxdb.ID
+		if err := id.Decode(k); err != nil {
+			continue
+		}
+		if err := fn(id, v); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// Run flushes usage to the store every interval until ctx is done, and once
+// more before returning.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(context.Background()); err != nil {
+				s.log.Error("Failed to flush ingest usage", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.log.Error("Failed to flush ingest usage", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Flush persists the usage counters changed since the last flush.
+func (s *Service) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	dirty := make(map[influxdb.ID][]byte)
+	for id, u := range s.usage {
+		if !u.dirty {
+			continue
+		}
+		v, err := json.Marshal(u)
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		dirty[id] = v
+		u.dirty = false
+	}
+	s.mu.Unlock()
+
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(usageBucket)
+		if err != nil {
+			return err
+		}
+		for id, v := range dirty {
+			if err := b.Put(encodeID(id), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Retry on the next flush.
+		s.mu.Lock()
+		for id := range dirty {
+			if u, ok := s.usage[id]; ok {
+				u.dirty = true
+			}
+		}
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// periodStart returns the start of the period t falls in.
+func (s *Service) periodStart(t time.Time) time.Time {
+	return t.UTC().Add(-s.resetOffset).Truncate(period).Add(s.resetOffset)
+}
+
+// current returns the usage of an organization in the current period,
+// resetting it if a new period has started. The caller must hold s.mu.
+func (s *Service) current(orgID influxdb.ID) *orgUsage {
+	start := s.periodStart(s.now())
+	u, ok := s.usage[orgID]
+	if !ok || !u.PeriodStart.Equal(start) {
+		u = &orgUsage{PeriodStart: start, dirty: ok}
+		s.usage[orgID] = u
+	}
+	return u
+}
+
+// CheckIngest returns an error if the organization has exhausted its quota
+// and is not in grace mode. Organizations in grace mode are logged once per
+// period instead.
+func (s *Service) CheckIngest(ctx context.Context, orgID influxdb.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.quotas[orgID]
+	if !ok || q.DailyBytes <= 0 {
+		return nil
+	}
+	u := s.current(orgID)
+	if u.Bytes < q.DailyBytes {
+		return nil
+	}
+
+	exceeded := &influxdb.IngestQuotaExceeded{
+		OrgID:      orgID,
+		QuotaBytes: q.DailyBytes,
+		UsedBytes:  u.Bytes,
+		ResetAt:    u.PeriodStart.Add(period),
+	}
+	if q.Grace {
+		s.metrics.exceeded.WithLabelValues(orgID.String(), "grace").Inc()
+		if !u.warned {
+			u.warned = true
+			s.log.Warn("Organization exceeded its ingest quota; writes are allowed in grace mode",
+				zap.Stringer("org_id", orgID),
+				zap.Int64("quota_bytes", q.DailyBytes),
+				zap.Int64("used_bytes", u.Bytes))
+		}
+		return nil
+	}
+
+	s.metrics.exceeded.WithLabelValues(orgID.String(), "enforce").Inc()
+	return &influxdb.Error{
+		Code: influxdb.ETooManyRequests,
+		Msg:  "ingest quota exceeded",
+		Err:  exceeded,
+	}
+}
+
+// RecordIngest adds n bytes to the usage of the organization.
+func (s *Service) RecordIngest(ctx context.Context, orgID influxdb.ID, n int64) {
+	if n <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.current(orgID)
+	u.Bytes += n
+	u.dirty = true
+}
+
+// IngestUsage returns the usage of the organization in the current period.
+func (s *Service) IngestUsage(ctx context.Context, orgID influxdb.ID) (*influxdb.IngestUsage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := s.periodStart(s.now())
+	usage := &influxdb.IngestUsage{
+		OrgID:       orgID,
+		PeriodStart: start,
+		PeriodEnd:   start.Add(period),
+	}
+	if u, ok := s.usage[orgID]; ok && u.PeriodStart.Equal(start) {
+		usage.Bytes = u.Bytes
+	}
+	if q, ok := s.quotas[orgID]; ok {
+		usage.Quota = &q
+		usage.Exceeded = q.DailyBytes > 0 && usage.Bytes >= q.DailyBytes
+	}
+	return usage, nil
+}
+
+// FindIngestQuota returns the quota of the organization.
+func (s *Service) FindIngestQuota(ctx context.Context, orgID influxdb.ID) (*influxdb.IngestQuota, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.quotas[orgID]
+	if !ok {
+		return nil, influxdb.ErrIngestQuotaNotFound
+	}
+	return &q, nil
+}
+
+// SetIngestQuota creates or replaces the quota of the organization.
+func (s *Service) SetIngestQuota(ctx context.Context, q *influxdb.IngestQuota) error {
+	if !q.OrgID.Valid() {
+		return influxdb.ErrInvalidID
+	}
+	if q.DailyBytes < 0 {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "ingest quota must not be negative",
+		}
+	}
+
+	v, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(quotaBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(encodeID(q.OrgID), v)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.quotas[q.OrgID] = *q
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteIngestQuota removes the quota of the organization.
+func (s *Service) DeleteIngestQuota(ctx context.Context, orgID influxdb.ID) error {
+	s.mu.Lock()
+	_, ok := s.quotas[orgID]
+	s.mu.Unlock()
+	if !ok {
+		return influxdb.ErrIngestQuotaNotFound
+	}
+
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(quotaBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete(encodeID(orgID))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.quotas, orgID)
+	s.mu.Unlock()
+	return nil
+}
+
+func encodeID(id influxdb.ID) []byte {
+	b, _ := id.Encode()
+	return b
+}
@@ -0,0 +1,127 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const orgID = influxdb.ID(0x1000)
+
+func newTestStore(t *testing.T) kv.Store {
+	t.Helper()
+	store := inmem.NewKVStore()
+	require.NoError(t, all.Up(context.Background(), zaptest.NewLogger(t), store))
+	return store
+}
+
+func newTestService(t *testing.T, store kv.Store, now time.Time, opts ...Option) *Service {
+	t.Helper()
+	s := NewService(zaptest.NewLogger(t), store, opts...)
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Open(context.Background()))
+	return s
+}
+
+func TestService_CheckIngest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestService(t, newTestStore(t), now)
+
+	// No quota means unlimited.
+	s.RecordIngest(ctx, orgID, 1<<30)
+	require.NoError(t, s.CheckIngest(ctx, orgID))
+
+	require.NoError(t, s.SetIngestQuota(ctx, &influxdb.IngestQuota{OrgID: orgID, DailyBytes: 2 << 30}))
+	require.NoError(t, s.CheckIngest(ctx, orgID))
+
+	s.RecordIngest(ctx, orgID, 1<<30)
+	err := s.CheckIngest(ctx, orgID)
+	require.Error(t, err)
+	assert.Equal(t, influxdb.ETooManyRequests, influxdb.ErrorCode(err))
+
+	exceeded, ok := err.(*influxdb.Error).Err.(*influxdb.IngestQuotaExceeded)
+	require.True(t, ok)
+	assert.Equal(t, int64(2<<30), exceeded.QuotaBytes)
+	assert.Equal(t, int64(2<<30), exceeded.UsedBytes)
+	assert.Equal(t, time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC), exceeded.ResetAt)
+
+	usage, err := s.IngestUsage(ctx, orgID)
+	require.NoError(t, err)
+	assert.True(t, usage.Exceeded)
+	assert.Equal(t, int64(2<<30), usage.Bytes)
+
+	// Grace mode allows writes over the quota.
+	require.NoError(t, s.SetIngestQuota(ctx, &influxdb.IngestQuota{OrgID: orgID, DailyBytes: 2 << 30, Grace: true}))
+	require.NoError(t, s.CheckIngest(ctx, orgID))
+
+	require.NoError(t, s.DeleteIngestQuota(ctx, orgID))
+	require.NoError(t, s.CheckIngest(ctx, orgID))
+	_, err = s.FindIngestQuota(ctx, orgID)
+	assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+}
+
+func TestService_ResetOffset(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 5, 0, 0, 0, time.UTC)
+	s := newTestService(t, newTestStore(t), now, WithResetOffset(6*time.Hour))
+
+	require.NoError(t, s.SetIngestQuota(ctx, &influxdb.IngestQuota{OrgID: orgID, DailyBytes: 10}))
+	s.RecordIngest(ctx, orgID, 10)
+	require.Error(t, s.CheckIngest(ctx, orgID))
+
+	usage, err := s.IngestUsage(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 9, 30, 6, 0, 0, 0, time.UTC), usage.PeriodStart)
+	assert.Equal(t, time.Date(2020, 10, 1, 6, 0, 0, 0, time.UTC), usage.PeriodEnd)
+
+	// Usage is reset once the next period starts.
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	require.NoError(t, s.CheckIngest(ctx, orgID))
+	usage, err = s.IngestUsage(ctx, orgID)
+	require.NoError(t, err)
+	assert.Zero(t, usage.Bytes)
+}
+
+func TestService_Persistence(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	s := newTestService(t, store, now)
+	require.NoError(t, s.SetIngestQuota(ctx, &influxdb.IngestQuota{OrgID: orgID, DailyBytes: 100}))
+	s.RecordIngest(ctx, orgID, 60)
+	require.NoError(t, s.Flush(ctx))
+
+	// Usage and quotas survive a restart within the same period.
+	s = newTestService(t, store, now.Add(time.Hour))
+	q, err := s.FindIngestQuota(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), q.DailyBytes)
+	usage, err := s.IngestUsage(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), usage.Bytes)
+
+	// Usage from a previous period is discarded.
+	s = newTestService(t, store, now.Add(24*time.Hour))
+	usage, err = s.IngestUsage(ctx, orgID)
+	require.NoError(t, err)
+	assert.Zero(t, usage.Bytes)
+}
+
+func TestService_SetIngestQuotaInvalid(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, newTestStore(t), time.Now())
+
+	err := s.SetIngestQuota(ctx, &influxdb.IngestQuota{OrgID: orgID, DailyBytes: -1})
+	assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+	assert.Error(t, s.SetIngestQuota(ctx, &influxdb.IngestQuota{DailyBytes: 1}))
+}
@@ -2,6 +2,12 @@ package influxdb
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
 )
 
 // ErrScraperTargetNotFound is the error msg for a missing scraper target.
@@ -14,17 +20,197 @@ const (
 	OpGetTargetByID = "GetTargetByID"
 	OpRemoveTarget  = "RemoveTarget"
 	OpUpdateTarget  = "UpdateTarget"
+	OpPatchTarget   = "PatchTarget"
 )
 
-// ScraperTarget is a target to scrape
+// MinScraperTargetInterval is the shortest interval a scraper target can be
+// scraped at.
+const MinScraperTargetInterval = time.Second
+
+// ScraperTarget is a target to scrape. Scraped metrics are written to the
+// bucket only while the owner holds write permission on it.
 type ScraperTarget struct {
-	ID            ID          `json:"id,omitempty"`
-	Name          string      `json:"name"`
-	Type          ScraperType `json:"type"`
-	URL           string      `json:"url"`
-	OrgID         ID          `json:"orgID,omitempty"`
-	BucketID      ID          `json:"bucketID,omitempty"`
-	AllowInsecure bool        `json:"allowInsecure,omitempty"`
+	ID            ID                   `json:"id,omitempty"`
+	Name          string               `json:"name" validate:"required"`
+	Type          ScraperType          `json:"type"`
+	URL           string               `json:"url" validate:"required"`
+	OrgID         ID                   `json:"orgID,omitempty"`
+	BucketID      ID                   `json:"bucketID,omitempty"`
+	AllowInsecure bool                 `json:"allowInsecure,omitempty"`
+	Headers       ScraperHeaders       `json:"headers,omitempty"`
+	OwnerID       ID                   `json:"ownerID,omitempty"`
+	Status        *ScraperTargetStatus `json:"status,omitempty"`
+	// Interval overrides the scheduler default for how often the target is
+	// scraped. A nil or zero interval uses the default, and any other must
+	// be at least MinScraperTargetInterval.
+	Interval *Duration `json:"interval,omitempty"`
+	// BatchSize and BatchInterval override the scraper defaults for the
+	// number of points written per batch and how long a partial batch waits
+	// before it is flushed. Zero values use the defaults.
+	BatchSize     int       `json:"batchSize,omitempty"`
+	BatchInterval *Duration `json:"batchInterval,omitempty"`
+	// Jitter overrides the scraper default for when the target is scraped
+	// within each scrape interval. The empty value uses the default.
+	Jitter ScraperJitter `json:"jitter,omitempty"`
+	// TimestampSource is where the timestamps of scraped points come from,
+	// and Precision what they are truncated to before they are written.
+	// The empty values use exporter timestamps at nanosecond precision.
+	TimestampSource ScraperTimestampSource `json:"timestampSource,omitempty"`
+	Precision       ScraperPrecision       `json:"precision,omitempty"`
+	// Username and Password, or BearerToken, authenticate the scrapes of
+	// the target. Password and BearerToken are never returned by the API.
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	BearerToken string `json:"bearerToken,omitempty"`
+	// Revision is incremented each time the target is updated, so that an
+	// update can be made to fail if the target changed since it was read.
+	Revision uint64 `json:"revision,omitempty"`
+}
+
+// WritePermission returns the permission the owner of the target needs to
+// write the scraped metrics into its bucket.
+func (t ScraperTarget) WritePermission() (*Permission, error) {
+	return NewPermissionAtID(t.BucketID, WriteAction, BucketsResourceType, t.OrgID)
+}
+
+// ScraperTargetUpdate is a partial update of a scraper target. Only the
+// fields that are set are updated, and setting a field to its empty value
+// clears it. Headers that are not nil replace all the headers of the target.
+type ScraperTargetUpdate struct {
+	Name            *string                 `json:"name,omitempty" validate:"min=1"`
+	Type            *ScraperType            `json:"type,omitempty"`
+	URL             *string                 `json:"url,omitempty" validate:"min=1"`
+	BucketID        *ID                     `json:"bucketID,omitempty"`
+	AllowInsecure   *bool                   `json:"allowInsecure,omitempty"`
+	Headers         ScraperHeaders          `json:"headers"`
+	Interval        *Duration               `json:"interval,omitempty"`
+	BatchSize       *int                    `json:"batchSize,omitempty"`
+	BatchInterval   *Duration               `json:"batchInterval,omitempty"`
+	Jitter          *ScraperJitter          `json:"jitter,omitempty"`
+	TimestampSource *ScraperTimestampSource `json:"timestampSource,omitempty"`
+	Precision       *ScraperPrecision       `json:"precision,omitempty"`
+	Username        *string                 `json:"username,omitempty"`
+	Password        *string                 `json:"password,omitempty"`
+	BearerToken     *string                 `json:"bearerToken,omitempty"`
+
+	// Revision, if set, is the revision the target must still be at for the
+	// update to be applied. The update fails with an EConflict error
+	// otherwise. Over HTTP it is sent as the If-Match header.
+	Revision *uint64 `json:"-"`
+}
+
+// Apply applies the fields of the update that are set to t.
+func (u ScraperTargetUpdate) Apply(t *ScraperTarget) {
+	if u.Name != nil {
+		t.Name = *u.Name
+	}
+	if u.Type != nil {
+		t.Type = *u.Type
+	}
+	if u.URL != nil {
+		t.URL = *u.URL
+	}
+	if u.BucketID != nil {
+		t.BucketID = *u.BucketID
+	}
+	if u.AllowInsecure != nil {
+		t.AllowInsecure = *u.AllowInsecure
+	}
+	if u.Headers != nil {
+		t.Headers = u.Headers
+		if len(u.Headers) == 0 {
+			t.Headers = nil
+		}
+	}
+	if u.Interval != nil {
+		t.Interval = u.Interval
+		if u.Interval.Duration == 0 {
+			t.Interval = nil
+		}
+	}
+	if u.BatchSize != nil {
+		t.BatchSize = *u.BatchSize
+	}
+	if u.BatchInterval != nil {
+		t.BatchInterval = u.BatchInterval
+		if u.BatchInterval.Duration == 0 {
+			t.BatchInterval = nil
+		}
+	}
+	if u.Jitter != nil {
+		t.Jitter = *u.Jitter
+	}
+	if u.TimestampSource != nil {
+		t.TimestampSource = *u.TimestampSource
+	}
+	if u.Precision != nil {
+		t.Precision = *u.Precision
+	}
+	if u.Username != nil {
+		t.Username = *u.Username
+	}
+	if u.Password != nil {
+		t.Password = *u.Password
+	}
+	if u.BearerToken != nil {
+		t.BearerToken = *u.BearerToken
+	}
+}
+
+// HasCredentials returns true if the scrapes of t are authenticated with a
+// username and password or a bearer token.
+func (t ScraperTarget) HasCredentials() bool {
+	return t.Username != "" || t.Password != "" || t.BearerToken != ""
+}
+
+// ValidCredentials returns an EInvalid error if the credentials of t are not
+// supported by its type, or are incomplete or conflicting.
+func (t ScraperTarget) ValidCredentials() error {
+	if !t.HasCredentials() {
+		return nil
+	}
+
+	var msg string
+	switch {
+	case ValidScraperType(string(t.Type)):
+		switch {
+		case t.BearerToken != "" && (t.Username != "" || t.Password != ""):
+			msg = "scraper target must not have both a username and password and a bearer token"
+		case t.Password != "" && t.Username == "":
+			msg = "scraper target with a password must have a username"
+		}
+		for name := range t.Headers {
+			if http.CanonicalHeaderKey(name) == "Authorization" {
+				msg = "scraper target with credentials must not have an Authorization header"
+			}
+		}
+	default:
+		msg = fmt.Sprintf("scraper targets of type %q do not support credentials", t.Type)
+	}
+	if msg == "" {
+		return nil
+	}
+	return &Error{
+		Code: EInvalid,
+		Msg:  msg,
+	}
+}
+
+// ErrScraperTargetRevisionMismatch is the error of an update of a scraper
+// target that changed since the revision the update was made from.
+func ErrScraperTargetRevisionMismatch(want, got uint64) *Error {
+	return &Error{
+		Code: EConflict,
+		Op:   OpPatchTarget,
+		Msg:  fmt.Sprintf("scraper target is at revision %d, not %d; it was changed since it was read", got, want),
+	}
+}
+
+// ScraperTargetStatus records whether a target is being scraped, and if not, why.
+type ScraperTargetStatus struct {
+	Active    bool      `json:"active"`
+	Reason    string    `json:"reason,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // ScraperTargetStoreService defines the crud service for ScraperTarget.
@@ -34,6 +220,10 @@ type ScraperTargetStoreService interface {
 	GetTargetByID(ctx context.Context, id ID) (*ScraperTarget, error)
 	RemoveTarget(ctx context.Context, id ID) error
 	UpdateTarget(ctx context.Context, t *ScraperTarget, userID ID) (*ScraperTarget, error)
+	// PatchTarget applies a partial update to the target with id, failing
+	// with an EConflict error if the update has a revision the target is no
+	// longer at.
+	PatchTarget(ctx context.Context, id ID, upd ScraperTargetUpdate, userID ID) (*ScraperTarget, error)
 }
 
 // ScraperTargetFilter represents a set of filter that restrict the returned results.
@@ -42,6 +232,8 @@ type ScraperTargetFilter struct {
 	Name  *string     `json:"name"`
 	OrgID *ID         `json:"orgID"`
 	Org   *string     `json:"org"`
+	// Type only matches targets of a type, and must be a valid type.
+	Type *ScraperType `json:"type"`
 }
 
 // ScraperType defines the scraper methods.
@@ -53,12 +245,144 @@ const (
 	PrometheusScraperType = "prometheus"
 )
 
-// ValidScraperType returns true is the type string is valid
+var scraperTypes = struct {
+	sync.RWMutex
+	m map[ScraperType]struct{}
+}{
+	m: map[ScraperType]struct{}{PrometheusScraperType: {}},
+}
+
+// RegisterScraperType makes typ a valid scraper type. It is called when the
+// gatherer of the type is registered. It panics if typ is empty or already
+// registered.
+func RegisterScraperType(typ ScraperType) {
+	scraperTypes.Lock()
+	defer scraperTypes.Unlock()
+	if typ == "" {
+		panic("scraper type must not be empty")
+	}
+	if _, ok := scraperTypes.m[typ]; ok {
+		panic(fmt.Sprintf("scraper type %q registered twice", typ))
+	}
+	scraperTypes.m[typ] = struct{}{}
+}
+
+// ValidScraperType returns true is the type string is a registered type.
 func ValidScraperType(s string) bool {
+	scraperTypes.RLock()
+	defer scraperTypes.RUnlock()
+	_, ok := scraperTypes.m[ScraperType(s)]
+	return ok
+}
+
+// ScraperHeaders are the HTTP headers sent with each scrape of a target, such
+// as the Authorization header required by an auth proxy in front of it. The
+// values may be credentials, so only their redacted form is logged.
+type ScraperHeaders map[string]string
+
+// Valid returns true if the names and values of h are valid HTTP header
+// field names and values.
+func (h ScraperHeaders) Valid() bool {
+	for name, value := range h {
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Redacted returns a copy of h with its values replaced, for use in logs
+// and other diagnostic output.
+func (h ScraperHeaders) Redacted() ScraperHeaders {
+	if h == nil {
+		return nil
+	}
+	redacted := make(ScraperHeaders, len(h))
+	for name := range h {
+		redacted[name] = "redacted"
+	}
+	return redacted
+}
+
+// ScraperJitter is how the scrapes of a target are offset from the start of
+// each scrape interval.
+type ScraperJitter string
+
+// Scraper jitter modes
+const (
+	// ScraperJitterNone scrapes the target at the start of each interval.
+	ScraperJitterNone ScraperJitter = "none"
+	// ScraperJitterSpread scrapes the target at a fixed offset into each
+	// interval derived from its ID, spreading the scrapes of all targets
+	// across the interval.
+	ScraperJitterSpread ScraperJitter = "spread"
+)
+
+// Valid returns true if j is a known jitter mode or empty.
+func (j ScraperJitter) Valid() bool {
+	switch j {
+	case "", ScraperJitterNone, ScraperJitterSpread:
+		return true
+	default:
+		return false
+	}
+}
+
+// ScraperTimestampSource is where the timestamps of the points scraped from
+// a target come from.
+type ScraperTimestampSource string
+
+// Scraper timestamp sources
+const (
+	// ScraperTimestampScrapeStart stamps points with the time the scrape
+	// started.
+	ScraperTimestampScrapeStart ScraperTimestampSource = "scrapeStart"
+	// ScraperTimestampScrapeEnd stamps points with the time the scrape
+	// completed.
+	ScraperTimestampScrapeEnd ScraperTimestampSource = "scrapeEnd"
+	// ScraperTimestampExporter stamps points with the timestamps of the
+	// exposition, and those without one with the time the scrape completed.
+	ScraperTimestampExporter ScraperTimestampSource = "exporter"
+)
+
+// Valid returns true if s is a known timestamp source or empty.
+func (s ScraperTimestampSource) Valid() bool {
 	switch s {
-	case PrometheusScraperType:
+	case "", ScraperTimestampScrapeStart, ScraperTimestampScrapeEnd, ScraperTimestampExporter:
+		return true
+	default:
+		return false
+	}
+}
+
+// ScraperPrecision is the precision scraped timestamps are truncated to.
+type ScraperPrecision string
+
+// Scraper timestamp precisions
+const (
+	ScraperPrecisionSecond      ScraperPrecision = "s"
+	ScraperPrecisionMillisecond ScraperPrecision = "ms"
+	ScraperPrecisionNanosecond  ScraperPrecision = "ns"
+)
+
+// Valid returns true if p is a known precision or empty.
+func (p ScraperPrecision) Valid() bool {
+	switch p {
+	case "", ScraperPrecisionSecond, ScraperPrecisionMillisecond, ScraperPrecisionNanosecond:
 		return true
 	default:
 		return false
 	}
 }
+
+// Duration returns the unit timestamps are truncated to.
+func (p ScraperPrecision) Duration() time.Duration {
+	switch p {
+	case ScraperPrecisionSecond:
+		return time.Second
+	case ScraperPrecisionMillisecond:
+		return time.Millisecond
+	default:
+		return time.Nanosecond
+	}
+}
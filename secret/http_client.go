@@ -50,6 +50,28 @@ func (s *Client) GetSecretKeys(ctx context.Context, orgID influxdb.ID) ([]string
 	return ss.Secrets, nil
 }
 
+// GetSecretPlaceholders gets the keys of the placeholder secrets of an org ID
+// via HTTP. Placeholders have an empty value until one is set.
+func (s *Client) GetSecretPlaceholders(ctx context.Context, orgID influxdb.ID) ([]string, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	span.LogKV("org-id", orgID)
+
+	path := fmt.Sprintf("/api/v2/orgs/%s/secrets", orgID.String())
+
+	var ss secretsResponse
+	err := s.Client.
+		Get(path).
+		DecodeJSON(&ss).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.Placeholders, nil
+}
+
 // PutSecrets is not implemented for http.
 func (s *Client) PutSecrets(ctx context.Context, orgID influxdb.ID, m map[string]string) error {
 	return &influxdb.Error{
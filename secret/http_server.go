@@ -50,12 +50,33 @@ func (h *handler) handleGetSecrets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.api.Respond(w, r, http.StatusOK, newSecretsResponse(orgID, ks))
+	resp := newSecretsResponse(orgID, ks)
+	resp.Placeholders = h.placeholderKeys(r, orgID, ks)
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// placeholderKeys returns the keys of ks whose secrets are placeholders, which
+// have an empty value until one is set, such as those created by a restore.
+// Keys whose value cannot be loaded are not reported.
+func (h *handler) placeholderKeys(r *http.Request, orgID influxdb.ID, ks []string) []string {
+	var placeholders []string
+	for _, k := range ks {
+		v, err := h.svc.LoadSecret(r.Context(), orgID, k)
+		if err != nil {
+			h.log.Debug("Failed to load secret", zap.String("key", k), zap.Error(err))
+			continue
+		}
+		if v == "" {
+			placeholders = append(placeholders, k)
+		}
+	}
+	return placeholders
 }
 
 type secretsResponse struct {
-	Links   map[string]string `json:"links"`
-	Secrets []string          `json:"secrets"`
+	Links        map[string]string `json:"links"`
+	Secrets      []string          `json:"secrets"`
+	Placeholders []string          `json:"placeholders,omitempty"`
 }
 
 func newSecretsResponse(orgID influxdb.ID, ks []string) *secretsResponse {
@@ -94,6 +94,9 @@ func TestSecretService_handleGetSecrets(t *testing.T) {
 					GetSecretKeysFn: func(ctx context.Context, orgID influxdb.ID) ([]string, error) {
 						return []string{"hello", "world"}, nil
 					},
+					LoadSecretFn: func(ctx context.Context, orgID influxdb.ID, k string) (string, error) {
+						return "value", nil
+					},
 				},
 			},
 			args: args{
@@ -105,6 +108,30 @@ func TestSecretService_handleGetSecrets(t *testing.T) {
 				body:        "{\n\t\"links\": {\n\t\t\"org\": \"/api/v2/orgs/0000000000000001\",\n\t\t\"self\": \"/api/v2/orgs/0000000000000001/secrets\"\n\t},\n\t\"secrets\": [\n\t\t\"hello\",\n\t\t\"world\"\n\t]\n}",
 			},
 		},
+		{
+			name: "get secrets with placeholders",
+			fields: fields{
+				&mock.SecretService{
+					GetSecretKeysFn: func(ctx context.Context, orgID influxdb.ID) ([]string, error) {
+						return []string{"hello", "world"}, nil
+					},
+					LoadSecretFn: func(ctx context.Context, orgID influxdb.ID, k string) (string, error) {
+						if k == "world" {
+							return "", nil
+						}
+						return "value", nil
+					},
+				},
+			},
+			args: args{
+				orgID: 1,
+			},
+			wants: wants{
+				statusCode:  http.StatusOK,
+				contentType: "application/json; charset=utf-8",
+				body:        "{\n\t\"links\": {\n\t\t\"org\": \"/api/v2/orgs/0000000000000001\",\n\t\t\"self\": \"/api/v2/orgs/0000000000000001/secrets\"\n\t},\n\t\"secrets\": [\n\t\t\"hello\",\n\t\t\"world\"\n\t],\n\t\"placeholders\": [\n\t\t\"world\"\n\t]\n}",
+			},
+		},
 		{
 			name: "get secrets when there are none",
 			fields: fields{
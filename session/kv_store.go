@@ -0,0 +1,152 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var kvStoreBucket = []byte("sessionsstorev1")
+
+// KVStore is a Store persisted in a kv store, so that it is shared by the
+// instances using the same kv store and survives their restarts. It holds
+// the denylist of stateless sessions.
+//
+// Expired entries are never returned, and are removed whenever a value is
+// set.
+type KVStore struct {
+	store kv.Store
+	now   func() time.Time
+}
+
+// kvStoreEntry is a value of a KVStore and its expiration.
+type kvStoreEntry struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewKVStore creates a Store persisted in store.
+func NewKVStore(store kv.Store) *KVStore {
+	return &KVStore{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Set sets the value of key until expireAt, and removes the expired entries.
+func (s *KVStore) Set(key, val string, expireAt time.Time) error {
+	v, err := json.Marshal(kvStoreEntry{Value: val, ExpiresAt: expireAt})
+	if err != nil {
+		return err
+	}
+	return s.store.Update(context.Background(), func(tx kv.Tx) error {
+		b, err := tx.Bucket(kvStoreBucket)
+		if err != nil {
+			return err
+		}
+		if err := s.prune(b); err != nil {
+			return err
+		}
+		return b.Put([]byte(key), v)
+	})
+}
+
+// prune removes the expired entries of b.
+func (s *KVStore) prune(b kv.Bucket) error {
+	cur, err := b.ForwardCursor(nil)
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+
+	var expired [][]byte
+	now := s.now()
+	for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+		var e kvStoreEntry
+		if err := json.Unmarshal(v, &e); err != nil || !now.Before(e.ExpiresAt) {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+
+	for _, k := range expired {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the value of key, or an empty string if it is not set or has
+// expired.
+func (s *KVStore) Get(key string) (string, error) {
+	var val string
+	err := s.store.View(context.Background(), func(tx kv.Tx) error {
+		e, err := s.entry(tx, key)
+		if err != nil || e == nil {
+			return err
+		}
+		val = e.Value
+		return nil
+	})
+	return val, err
+}
+
+// Delete removes key.
+func (s *KVStore) Delete(key string) error {
+	return s.store.Update(context.Background(), func(tx kv.Tx) error {
+		b, err := tx.Bucket(kvStoreBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// ExpireAt changes the expiration of key, if it is set and has not expired.
+func (s *KVStore) ExpireAt(key string, expireAt time.Time) error {
+	return s.store.Update(context.Background(), func(tx kv.Tx) error {
+		e, err := s.entry(tx, key)
+		if err != nil || e == nil {
+			return err
+		}
+		e.ExpiresAt = expireAt
+		v, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(kvStoreBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), v)
+	})
+}
+
+// entry returns the entry of key, or nil if it is not set or has expired.
+func (s *KVStore) entry(tx kv.Tx, key string) (*kvStoreEntry, error) {
+	b, err := tx.Bucket(kvStoreBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get([]byte(key))
+	if kv.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var e kvStoreEntry
+	if err := json.Unmarshal(v, &e); err != nil {
+		return nil, err
+	}
+	if !s.now().Before(e.ExpiresAt) {
+		return nil, nil
+	}
+	return &e, nil
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestKVStore(t *testing.T) {
+	ctx := context.Background()
+	kvStore := inmem.NewKVStore()
+	require.NoError(t, all.Up(ctx, zaptest.NewLogger(t), kvStore))
+
+	now := time.Now()
+	s := NewKVStore(kvStore)
+	s.now = func() time.Time { return now }
+
+	get := func(key string) string {
+		t.Helper()
+		val, err := s.Get(key)
+		require.NoError(t, err)
+		return val
+	}
+	stored := func() []string {
+		t.Helper()
+		var keys []string
+		require.NoError(t, kvStore.View(ctx, func(tx kv.Tx) error {
+			b, err := tx.Bucket(kvStoreBucket)
+			if err != nil {
+				return err
+			}
+			cur, err := b.ForwardCursor(nil)
+			if err != nil {
+				return err
+			}
+			defer cur.Close()
+			for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+				keys = append(keys, string(k))
+			}
+			return cur.Err()
+		}))
+		return keys
+	}
+
+	require.NoError(t, s.Set("a", "1", now.Add(time.Minute)))
+	require.NoError(t, s.Set("b", "2", now.Add(time.Hour)))
+	assert.Equal(t, "1", get("a"))
+	assert.Equal(t, "2", get("b"))
+	assert.Equal(t, "", get("c"))
+
+	// Another store over the same kv store shares the values.
+	other := NewKVStore(kvStore)
+	val, err := other.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", val)
+
+	// Expired values are not returned, and are removed by the next Set.
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, "", get("a"))
+	assert.Equal(t, []string{"a", "b"}, stored())
+	require.NoError(t, s.Set("c", "3", now.Add(time.Hour)))
+	assert.Equal(t, []string{"b", "c"}, stored())
+
+	require.NoError(t, s.ExpireAt("b", now))
+	assert.Equal(t, "", get("b"))
+	require.NoError(t, s.ExpireAt("a", now.Add(time.Hour)))
+	assert.Equal(t, "", get("a"))
+
+	require.NoError(t, s.Delete("c"))
+	assert.Equal(t, "", get("c"))
+}
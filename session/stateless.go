@@ -0,0 +1,362 @@
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// MinSigningKeySize is the minimum size of the secret of a signing key.
+const MinSigningKeySize = 32
+
+const (
+	signedSessionMode    = "s"
+	encryptedSessionMode = "e"
+)
+
+var denylistPrefix = "sessionsdenylistv2/"
+
+var validKeyID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// SigningKey is a key signing stateless sessions.
+type SigningKey struct {
+	// ID is written to the sessions signed with the key, to find the key
+	// verifying them.
+	ID string `json:"id"`
+	// Secret is the base64 encoded secret of the key.
+	Secret string `json:"secret"`
+}
+
+// signingKey holds the keys derived from the secret of a SigningKey.
+type signingKey struct {
+	id      string
+	macKey  []byte
+	aead    cipher.AEAD
+	encrypt bool
+}
+
+// SigningKeys are the keys signing and verifying stateless sessions. The
+// first key signs new sessions, and all of them verify sessions, so keys
+// can be rotated by adding a new key first and removing the old one once
+// the sessions it signed have expired.
+type SigningKeys struct {
+	keys []*signingKey
+	byID map[string]*signingKey
+}
+
+// signingKeysFile is the JSON document of a signing keys file.
+type signingKeysFile struct {
+	// Encrypt encrypts the contents of sessions, besides signing them.
+	Encrypt bool         `json:"encrypt"`
+	Keys    []SigningKey `json:"keys"`
+}
+
+// LoadSigningKeys reads the signing keys of stateless sessions from the
+// JSON file at path, of the form
+//
+//	{
+//		"encrypt": true,
+//		"keys": [
+//			{"id": "2020-12", "secret": "<base64 encoded secret>"},
+//			{"id": "2020-11", "secret": "<base64 encoded secret>"}
+//		]
+//	}
+func LoadSigningKeys(path string) (*SigningKeys, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f signingKeysFile
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return nil, fmt.Errorf("invalid signing keys file %s: %w", path, err)
+	}
+	keys, err := NewSigningKeys(f.Encrypt, f.Keys...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing keys file %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// NewSigningKeys returns the signing keys of stateless sessions, which are
+// encrypted if encrypt is true.
+func NewSigningKeys(encrypt bool, keys ...SigningKey) (*SigningKeys, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+
+	sk := &SigningKeys{byID: make(map[string]*signingKey, len(keys))}
+	for _, k := range keys {
+		if !validKeyID.MatchString(k.ID) {
+			return nil, fmt.Errorf("invalid signing key ID %q: must be letters, digits, '-' and '_'", k.ID)
+		}
+		if _, ok := sk.byID[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key ID %q", k.ID)
+		}
+		secret, err := base64.StdEncoding.DecodeString(k.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret of signing key %q: %w", k.ID, err)
+		}
+		if len(secret) < MinSigningKeySize {
+			return nil, fmt.Errorf("secret of signing key %q must be at least %d bytes", k.ID, MinSigningKeySize)
+		}
+
+		// Signing and encryption use distinct keys derived from the secret.
+		block, err := aes.NewCipher(deriveKey(secret, "influxdb session encryption"))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		key := &signingKey{
+			id:      k.ID,
+			macKey:  deriveKey(secret, "influxdb session signing"),
+			aead:    aead,
+			encrypt: encrypt,
+		}
+		sk.keys = append(sk.keys, key)
+		sk.byID[k.ID] = key
+	}
+	return sk, nil
+}
+
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// sessionClaims are the contents of a stateless session.
+type sessionClaims struct {
+	SessionID influxdb.ID `json:"sid"`
+	UserID    influxdb.ID `json:"uid"`
+	IssuedAt  int64       `json:"iat"`
+	ExpiresAt int64       `json:"exp"`
+}
+
+var sessionEncoding = base64.RawURLEncoding
+
+// sign encodes claims as a session key of the form
+// <mode>.<key id>.<payload>.<signature>, signed with the first key.
+func (sk *SigningKeys) sign(claims sessionClaims) (string, error) {
+	key := sk.keys[0]
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	mode := signedSessionMode
+	if key.encrypt {
+		mode = encryptedSessionMode
+		nonce := make([]byte, key.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", err
+		}
+		payload = key.aead.Seal(nonce, nonce, payload, nil)
+	}
+
+	signed := mode + "." + key.id + "." + sessionEncoding.EncodeToString(payload)
+	return signed + "." + sessionEncoding.EncodeToString(key.mac(signed)), nil
+}
+
+func (k *signingKey) mac(signed string) []byte {
+	mac := hmac.New(sha256.New, k.macKey)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+// verify returns the claims of a session key signed with any of the keys.
+func (sk *SigningKeys) verify(s string) (*sessionClaims, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return nil, errInvalidSession("malformed session")
+	}
+	mode, kid := parts[0], parts[1]
+	key, ok := sk.byID[kid]
+	if !ok {
+		return nil, errInvalidSession("unknown signing key")
+	}
+	sig, err := sessionEncoding.DecodeString(parts[3])
+	if err != nil || !hmac.Equal(sig, key.mac(strings.Join(parts[:3], "."))) {
+		return nil, errInvalidSession("invalid signature")
+	}
+
+	payload, err := sessionEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidSession("malformed payload")
+	}
+	switch mode {
+	case signedSessionMode:
+	case encryptedSessionMode:
+		n := key.aead.NonceSize()
+		if len(payload) < n {
+			return nil, errInvalidSession("malformed payload")
+		}
+		if payload, err = key.aead.Open(nil, payload[:n], payload[n:], nil); err != nil {
+			return nil, errInvalidSession("cannot decrypt payload")
+		}
+	default:
+		return nil, errInvalidSession("unknown mode")
+	}
+
+	var claims sessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errInvalidSession("malformed payload")
+	}
+	return &claims, nil
+}
+
+// errInvalidSession is returned for session keys that cannot be verified.
+// They are not found like unknown keys of stored sessions, with the reason
+// kept as the underlying error.
+func errInvalidSession(reason string) error {
+	return &influxdb.Error{
+		Code: influxdb.ENotFound,
+		Msg:  influxdb.ErrSessionNotFound,
+		Err:  fmt.Errorf("invalid stateless session: %s", reason),
+	}
+}
+
+// StatelessService implements the influxdb.SessionService interface with
+// sessions that are not stored, but encoded in their keys and signed, so
+// that they are accepted by every instance sharing the signing keys.
+//
+// Sessions expired with ExpireSession are denied until they would have
+// expired, in the denylist store. Instances must share it, like a KVStore
+// over the same kv store, for a session expired by one to be denied by all.
+// Sessions are not renewed, and expire the session length after they were
+// created.
+type StatelessService struct {
+	svc      *Service
+	keys     *SigningKeys
+	denylist Store
+}
+
+// NewStatelessService creates a new stateless session service. The options
+// of a Service configure the session length and the generation of IDs.
+func NewStatelessService(keys *SigningKeys, denylist Store, userService influxdb.UserService, urmService influxdb.UserResourceMappingService, authSvc influxdb.AuthorizationService, opts ...ServiceOption) *StatelessService {
+	return &StatelessService{
+		svc:      NewService(nil, userService, urmService, authSvc, opts...),
+		keys:     keys,
+		denylist: denylist,
+	}
+}
+
+// WithMaxPermissionFunc sets the useAuthorizationsForMaxPermissions function
+// which can trigger whether or not max permissions uses the users authorizations
+// to derive maximum permissions.
+func (s *StatelessService) WithMaxPermissionFunc(fn func(context.Context) bool) {
+	s.svc.WithMaxPermissionFunc(fn)
+}
+
+// FindSession verifies the session encoded in key.
+func (s *StatelessService) FindSession(ctx context.Context, key string) (*influxdb.Session, error) {
+	claims, err := s.claims(key)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &influxdb.Session{
+		ID:        claims.SessionID,
+		Key:       key,
+		CreatedAt: time.Unix(claims.IssuedAt, 0),
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		UserID:    claims.UserID,
+	}
+	if session.Permissions, err = s.svc.getPermissionSet(ctx, session.UserID); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// claims returns the claims of the session encoded in key, if it has
+// neither expired nor been denied.
+func (s *StatelessService) claims(key string) (*sessionClaims, error) {
+	claims, err := s.keys.verify(key)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  influxdb.ErrSessionNotFound,
+		}
+	}
+
+	denied, err := s.denylist.Get(denylistPrefix + claims.SessionID.String())
+	if err != nil {
+		return nil, err
+	}
+	if denied != "" {
+		return nil, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  influxdb.ErrSessionNotFound,
+		}
+	}
+	return claims, nil
+}
+
+// ExpireSession denies the session encoded in key until it expires.
+func (s *StatelessService) ExpireSession(ctx context.Context, key string) error {
+	claims, err := s.claims(key)
+	if err != nil {
+		return err
+	}
+	return s.denylist.Set(denylistPrefix+claims.SessionID.String(), claims.UserID.String(), time.Unix(claims.ExpiresAt, 0))
+}
+
+// CreateSession creates a session for user, signed with the first signing
+// key.
+func (s *StatelessService) CreateSession(ctx context.Context, user string) (*influxdb.Session, error) {
+	u, err := s.svc.userService.FindUser(ctx, influxdb.UserFilter{
+		Name: &user,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Times are kept in seconds, as they are encoded.
+	now := time.Now().Truncate(time.Second)
+	session := &influxdb.Session{
+		ID:        s.svc.idGen.ID(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.svc.sessionLength),
+		UserID:    u.ID,
+	}
+	session.Key, err = s.keys.sign(sessionClaims{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		IssuedAt:  session.CreatedAt.Unix(),
+		ExpiresAt: session.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RenewSession does nothing, as the expiration of a stateless session is
+// part of its key, which cannot be changed.
+func (s *StatelessService) RenewSession(ctx context.Context, session *influxdb.Session, newExpiration time.Time) error {
+	if session == nil {
+		return &influxdb.Error{
+			Msg: "session is nil",
+		}
+	}
+	return nil
+}
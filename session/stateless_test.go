@@ -0,0 +1,178 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func testSigningKey(id string, b byte) SigningKey {
+	return SigningKey{ID: id, Secret: base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), MinSigningKeySize)))}
+}
+
+func TestStatelessService(t *testing.T) {
+	ctx := context.Background()
+	kvStore := inmem.NewKVStore()
+	require.NoError(t, all.Up(ctx, zaptest.NewLogger(t), kvStore))
+	ten := tenant.NewService(tenant.NewStore(kvStore))
+	user := &influxdb.User{Name: "user"}
+	require.NoError(t, ten.CreateUser(ctx, user))
+	auths := &mock.AuthorizationService{
+		FindAuthorizationsFn: func(context.Context, influxdb.AuthorizationFilter, ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+			return []*influxdb.Authorization{}, 0, nil
+		},
+	}
+
+	newService := func(t *testing.T, encrypt bool, keys ...SigningKey) *StatelessService {
+		sk, err := NewSigningKeys(encrypt, keys...)
+		require.NoError(t, err)
+		return NewStatelessService(sk, inmem.NewSessionStore(), ten, ten, auths, WithSessionLength(time.Hour))
+	}
+	oldKey, newKey := testSigningKey("old", 'a'), testSigningKey("new", 'b')
+
+	t.Run("shared between instances", func(t *testing.T) {
+		a, b := newService(t, false, oldKey), newService(t, false, oldKey)
+		s, err := a.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, s.UserID)
+		assert.Equal(t, time.Hour, s.ExpiresAt.Sub(s.CreatedAt))
+
+		found, err := b.FindSession(ctx, s.Key)
+		require.NoError(t, err)
+		assert.Equal(t, s.ID, found.ID)
+		assert.Equal(t, user.ID, found.UserID)
+		assert.True(t, s.ExpiresAt.Equal(found.ExpiresAt))
+		assert.Equal(t, influxdb.PermissionSet(influxdb.MePermissions(user.ID)), influxdb.PermissionSet(found.Permissions))
+	})
+
+	t.Run("rotated keys", func(t *testing.T) {
+		old := newService(t, false, oldKey)
+		s, err := old.CreateSession(ctx, "user")
+		require.NoError(t, err)
+
+		rotated := newService(t, false, newKey, oldKey)
+		_, err = rotated.FindSession(ctx, s.Key)
+		require.NoError(t, err)
+		s2, err := rotated.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(s2.Key, "s.new."))
+
+		// Sessions signed with removed keys are not found.
+		_, err = newService(t, false, newKey).FindSession(ctx, s.Key)
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		svc := newService(t, true, oldKey)
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(s.Key, "e.old."))
+		payload, err := sessionEncoding.DecodeString(strings.Split(s.Key, ".")[2])
+		require.NoError(t, err)
+		assert.NotContains(t, string(payload), user.ID.String())
+
+		found, err := svc.FindSession(ctx, s.Key)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.UserID)
+	})
+
+	t.Run("invalid sessions", func(t *testing.T) {
+		svc := newService(t, false, oldKey)
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		parts := strings.Split(s.Key, ".")
+
+		expired, err := svc.keys.sign(sessionClaims{SessionID: 1, UserID: user.ID, IssuedAt: time.Now().Add(-2 * time.Hour).Unix(), ExpiresAt: time.Now().Add(-time.Hour).Unix()})
+		require.NoError(t, err)
+		for _, key := range []string{
+			"",
+			"nope",
+			strings.Join([]string{parts[0], parts[1], parts[3], parts[3]}, "."),
+			strings.Join([]string{"e", parts[1], parts[2], parts[3]}, "."),
+			strings.Join([]string{parts[0], "new", parts[2], parts[3]}, "."),
+			expired,
+		} {
+			_, err := svc.FindSession(ctx, key)
+			assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err), key)
+		}
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		svc := newService(t, false, oldKey)
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		require.NoError(t, svc.ExpireSession(ctx, s.Key))
+
+		_, err = svc.FindSession(ctx, s.Key)
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(svc.ExpireSession(ctx, s.Key)))
+	})
+
+	t.Run("expire shared between instances", func(t *testing.T) {
+		sk, err := NewSigningKeys(false, oldKey)
+		require.NoError(t, err)
+		a := NewStatelessService(sk, NewKVStore(kvStore), ten, ten, auths, WithSessionLength(time.Hour))
+		b := NewStatelessService(sk, NewKVStore(kvStore), ten, ten, auths, WithSessionLength(time.Hour))
+		s, err := a.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		_, err = b.FindSession(ctx, s.Key)
+		require.NoError(t, err)
+
+		require.NoError(t, a.ExpireSession(ctx, s.Key))
+		_, err = b.FindSession(ctx, s.Key)
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := newService(t, false, oldKey).CreateSession(ctx, "nope")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadSigningKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signing-keys-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	load := func(t *testing.T, contents string) (*SigningKeys, error) {
+		path := filepath.Join(dir, "keys.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+		return LoadSigningKeys(path)
+	}
+	secret := testSigningKey("", 'a').Secret
+
+	keys, err := load(t, `{"encrypt": true, "keys": [{"id": "b", "secret": "`+secret+`"}, {"id": "a", "secret": "`+secret+`"}]}`)
+	require.NoError(t, err)
+	require.Len(t, keys.keys, 2)
+	assert.Equal(t, "b", keys.keys[0].id)
+	assert.True(t, keys.keys[0].encrypt)
+
+	for _, contents := range []string{
+		`{`,
+		`{"keys": []}`,
+		`{"keys": [{"id": "a.b", "secret": "` + secret + `"}]}`,
+		`{"keys": [{"id": "a", "secret": "` + secret + `"}, {"id": "a", "secret": "` + secret + `"}]}`,
+		`{"keys": [{"id": "a", "secret": "c2hvcnQ="}]}`,
+		`{"keys": [{"id": "a", "secret": "!"}]}`,
+	} {
+		_, err := load(t, contents)
+		assert.Error(t, err, contents)
+	}
+
+	_, err = LoadSigningKeys(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// SlowQuery is a query that was among the slowest run recently.
+type SlowQuery struct {
+	// Time is when the query completed.
+	Time           time.Time `json:"time"`
+	OrganizationID ID        `json:"orgID"`
+	Duration       Duration  `json:"duration"`
+	// ScannedValues and ScannedBytes are the number of values and
+	// uncompressed bytes the query read from storage.
+	ScannedValues int64 `json:"scannedValues"`
+	ScannedBytes  int64 `json:"scannedBytes"`
+	// PeakMemory is the most memory in bytes allocated by the query at once.
+	PeakMemory int64 `json:"peakMemoryBytes"`
+	// Query is the text of the query, truncated if it was long.
+	Query          string `json:"query"`
+	QueryTruncated bool   `json:"queryTruncated,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// SlowQueryService reports the slowest queries run recently.
+type SlowQueryService interface {
+	// SlowQueries returns the slowest queries run recently, slowest first.
+	SlowQueries(ctx context.Context) ([]SlowQuery, error)
+
+	// ClearSlowQueries forgets the queries recorded so far.
+	ClearSlowQueries(ctx context.Context) error
+}
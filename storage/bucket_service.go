@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"go.uber.org/zap"
 )
@@ -13,6 +14,7 @@ type EngineSchema interface {
 	CreateBucket(context.Context, *influxdb.Bucket) error
 	UpdateBucketRetentionPeriod(context.Context, influxdb.ID, time.Duration) error
 	DeleteBucket(context.Context, influxdb.ID, influxdb.ID) error
+	CompactBucket(context.Context, influxdb.ID) error
 }
 
 // BucketService wraps an existing influxdb.BucketService implementation.
@@ -67,13 +69,36 @@ func (s *BucketService) UpdateBucket(ctx context.Context, id influxdb.ID, upd in
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
+	bucket, err := s.FindBucketByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := upd.ValidFor(bucket); err != nil {
+		return nil, err
+	}
+
 	if upd.RetentionPeriod != nil {
 		if err = s.engine.UpdateBucketRetentionPeriod(ctx, id, *upd.RetentionPeriod); err != nil {
 			return nil, err
 		}
 	}
 
-	return s.BucketService.UpdateBucket(ctx, id, upd)
+	if b, err = s.BucketService.UpdateBucket(ctx, id, upd); err != nil {
+		return nil, err
+	}
+
+	// An archived bucket no longer receives writes, so its data is
+	// compacted to its minimal size on disk.
+	if b.Archived && !bucket.Archived {
+		if err := s.engine.CompactBucket(ctx, id); err != nil {
+			s.log.Error("Unable to compact archived bucket", zap.Stringer("bucket_id", id), zap.Error(err))
+		}
+	}
+	return b, nil
 }
 
 // DeleteBucket removes a bucket by ID.
@@ -85,12 +110,17 @@ func (s *BucketService) DeleteBucket(ctx context.Context, bucketID influxdb.ID)
 	if err != nil {
 		return err
 	}
+	if bucket.DeleteProtected {
+		return influxdb.ErrBucketDeleteProtected(bucket)
+	}
 
 	// The data is dropped first from the storage engine. If this fails for any
 	// reason, then the bucket will still be available in the future to retrieve
-	// the orgID, which is needed for the engine.
-	if err := s.engine.DeleteBucket(ctx, bucket.OrgID, bucketID); err != nil {
-		return err
+	// the orgID, which is needed for the engine. A dry run keeps the data.
+	if !icontext.IsDryRun(ctx) {
+		if err := s.engine.DeleteBucket(ctx, bucket.OrgID, bucketID); err != nil {
+			return err
+		}
 	}
 	return s.BucketService.DeleteBucket(ctx, bucketID)
 }
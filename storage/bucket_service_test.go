@@ -3,9 +3,11 @@ package storage_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/inmem"
 	"github.com/influxdata/influxdb/v2/kv/migration/all"
 	"github.com/influxdata/influxdb/v2/storage"
@@ -43,6 +45,16 @@ func TestBucketService(t *testing.T) {
 		panic(err)
 	}
 
+	// Test a dry run does not call into the deleter or delete the bucket.
+	service = storage.NewBucketService(logger, inmemService, engine)
+
+	if err := service.DeleteBucket(icontext.SetDryRun(context.TODO()), bucket.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inmemService.FindBucketByID(context.TODO(), bucket.ID); err != nil {
+		t.Fatalf("expected dry run to keep the bucket: %v", err)
+	}
+
 	engine.EXPECT().DeleteBucket(gomock.Any(), org.ID, bucket.ID)
 
 	// Test deleting a bucket calls into the deleter.
@@ -53,6 +65,56 @@ func TestBucketService(t *testing.T) {
 	}
 }
 
+func TestBucketService_Archive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	engine := mocks.NewMockEngineSchema(ctrl)
+	inmemService := newTenantService(t)
+	service := storage.NewBucketService(zaptest.NewLogger(t), inmemService, engine)
+
+	org := &influxdb.Organization{Name: "org1"}
+	if err := inmemService.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	bucket := &influxdb.Bucket{OrgID: org.ID, Name: "history"}
+	if err := inmemService.CreateBucket(ctx, bucket); err != nil {
+		t.Fatal(err)
+	}
+
+	// Archiving compacts the bucket once.
+	engine.EXPECT().CompactBucket(gomock.Any(), bucket.ID).Times(1)
+	archived := true
+	for i := 0; i < 2; i++ {
+		b, err := service.UpdateBucket(ctx, bucket.ID, influxdb.BucketUpdate{Archived: &archived})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !b.Archived {
+			t.Fatal("expected the bucket to be archived")
+		}
+	}
+
+	// The retention period cannot change while archived.
+	retention := time.Hour
+	_, err := service.UpdateBucket(ctx, bucket.ID, influxdb.BucketUpdate{RetentionPeriod: &retention})
+	if code := influxdb.ErrorCode(err); code != influxdb.EConflict {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+
+	// Unarchiving along with the retention change is allowed.
+	engine.EXPECT().UpdateBucketRetentionPeriod(gomock.Any(), bucket.ID, retention)
+	archived = false
+	b, err := service.UpdateBucket(ctx, bucket.ID, influxdb.BucketUpdate{Archived: &archived, RetentionPeriod: &retention})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Archived || b.RetentionPeriod != retention {
+		t.Fatalf("unexpected bucket after unarchiving: %+v", b)
+	}
+}
+
 func newTenantService(t *testing.T) *tenant.Service {
 	t.Helper()
 
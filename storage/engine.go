@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/maintenance"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	_ "github.com/influxdata/influxdb/v2/tsdb/engine"
@@ -25,6 +28,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 var (
@@ -42,6 +46,8 @@ type Engine struct {
 
 	mu           sync.RWMutex
 	closing      chan struct{} // closing returns the zero value when the engine is shutting down.
+	wg           sync.WaitGroup
+	usage        usageSamples
 	tsdbStore    *tsdb.Store
 	metaClient   MetaClient
 	pointsWriter interface {
@@ -49,9 +55,17 @@ type Engine struct {
 		Close() error
 	}
 
+	bucketFinder      retentionBucketFinder
+	maintenance       *maintenance.Registry
 	retentionService  *retention.Service
 	precreatorService *precreator.Service
 
+	// deletes journals the measurement deletes in progress.
+	deletes *measurementDeleteJournal
+
+	// cacheSnapshots limits how often caches are snapshotted on request.
+	cacheSnapshots *rate.Limiter
+
 	defaultMetricLabels prometheus.Labels
 
 	writePointsValidationEnabled bool
@@ -68,6 +82,26 @@ func WithMetaClient(c MetaClient) Option {
 	}
 }
 
+// WithBucketFinder sets the bucket finder used by retention enforcement to
+// honor the delete protection of buckets.
+func WithBucketFinder(f retentionBucketFinder) Option {
+	return func(e *Engine) {
+		e.bucketFinder = f
+	}
+}
+
+// WithMaintenanceRegistry sets the registry the retention and shard
+// precreation services report their schedule and checks into.
+func WithMaintenanceRegistry(r *maintenance.Registry) Option {
+	return func(e *Engine) {
+		e.maintenance = r
+	}
+}
+
+type retentionBucketFinder interface {
+	FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error)
+}
+
 type MetaClient interface {
 	CreateDatabaseWithRetentionPolicy(name string, spec *meta.RetentionPolicySpec) (*meta.DatabaseInfo, error)
 	CreateShardGroup(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error)
@@ -106,6 +140,8 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 		path:                path,
 		defaultMetricLabels: prometheus.Labels{},
 		tsdbStore:           tsdb.NewStore(c.Data.Dir),
+		deletes:             &measurementDeleteJournal{path: filepath.Join(path, "pending_deletes.json")},
+		cacheSnapshots:      rate.NewLimiter(rate.Every(cacheSnapshotInterval), cacheSnapshotBurst),
 		logger:              zap.NewNop(),
 
 		writePointsValidationEnabled: true,
@@ -129,9 +165,14 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 	e.retentionService = retention.NewService(c.RetentionService)
 	e.retentionService.TSDBStore = e.tsdbStore
 	e.retentionService.MetaClient = e.metaClient
+	if e.bucketFinder != nil {
+		e.retentionService.BucketFinder = e.bucketFinder
+	}
+	e.retentionService.Maintenance = e.maintenance.Register("retention")
 
 	e.precreatorService = precreator.NewService(c.PrecreatorConfig)
 	e.precreatorService.MetaClient = e.metaClient
+	e.precreatorService.Maintenance = e.maintenance.Register("shard-precreation")
 
 	return e
 }
@@ -179,6 +220,10 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 		return err
 	}
 
+	if err := e.resumeMeasurementDeletes(); err != nil {
+		return err
+	}
+
 	if err := e.retentionService.Open(ctx); err != nil {
 		return err
 	}
@@ -189,6 +234,10 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 
 	e.closing = make(chan struct{})
 
+	e.usage.add(sampleWrites(e.tsdbStore.Shards(e.tsdbStore.ShardIDs()), time.Now().UTC()))
+	e.wg.Add(1)
+	go e.sampleUsage(e.closing)
+
 	return nil
 }
 
@@ -213,6 +262,7 @@ func (e *Engine) Close() error {
 
 	close(e.closing)
 	e.mu.RUnlock()
+	e.wg.Wait()
 
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -266,8 +316,9 @@ func (e *Engine) CreateBucket(ctx context.Context, b *influxdb.Bucket) (err erro
 	defer span.Finish()
 
 	spec := meta.RetentionPolicySpec{
-		Name:     meta.DefaultRetentionPolicyName,
-		Duration: &b.RetentionPeriod,
+		Name:               meta.DefaultRetentionPolicyName,
+		Duration:           &b.RetentionPeriod,
+		ShardGroupDuration: b.ShardGroupDuration,
 	}
 
 	if _, err = e.metaClient.CreateDatabaseWithRetentionPolicy(b.ID.String(), &spec); err != nil {
@@ -304,12 +355,6 @@ func (e *Engine) DeleteBucketRange(ctx context.Context, orgID, bucketID influxdb
 	return ErrNotImplemented
 }
 
-// DeleteBucketRangePredicate deletes data within a bucket from the storage engine. Any data
-// deleted must be in [min, max], and the key must match the predicate if provided.
-func (e *Engine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64, pred influxdb.Predicate) error {
-	return ErrNotImplemented
-}
-
 func (e *Engine) BackupKVStore(ctx context.Context, w io.Writer) error {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
@@ -338,6 +383,48 @@ func (e *Engine) BackupShard(ctx context.Context, w io.Writer, shardID uint64, s
 	return e.tsdbStore.BackupShard(shardID, since, w)
 }
 
+func (e *Engine) BackupShardMeasurements(ctx context.Context, w io.Writer, shardID uint64, since time.Time, measurements []string) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+
+	return e.tsdbStore.BackupShardMeasurements(shardID, since, measurements, w)
+}
+
+// ShardSizes returns the size on disk of the given shards held by this
+// engine. Shards not held locally are omitted.
+func (e *Engine) ShardSizes(ctx context.Context, shardIDs []uint64) ([]influxdb.ShardSize, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	sizes := make([]influxdb.ShardSize, 0, len(shardIDs))
+	for _, id := range shardIDs {
+		sh := e.tsdbStore.Shard(id)
+		if sh == nil {
+			continue
+		}
+		size, err := sh.DiskSize()
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, influxdb.ShardSize{ShardID: id, Size: size})
+	}
+	return sizes, nil
+}
+
 func (e *Engine) RestoreKVStore(ctx context.Context, r io.Reader) error {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
@@ -381,6 +468,23 @@ func (e *Engine) RestoreBucket(ctx context.Context, id influxdb.ID, buf []byte)
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	return e.restoreBucket(id, buf, false)
+}
+
+// ReplaceBucket replaces the shards of the existing bucket id, and their
+// data, with the empty shards of the serialized database info buf, keeping
+// the bucket ID. It returns the new IDs of the shards of buf.
+func (e *Engine) ReplaceBucket(ctx context.Context, id influxdb.ID, buf []byte) (map[uint64]uint64, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	return e.restoreBucket(id, buf, true)
+}
+
+// restoreBucket sets the retention policy of the bucket id to the one of the
+// serialized database info buf, with new IDs for its shards, and creates
+// them. With replace, the shards the bucket held are deleted.
+func (e *Engine) restoreBucket(id influxdb.ID, buf []byte, replace bool) (map[uint64]uint64, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
@@ -401,6 +505,17 @@ func (e *Engine) RestoreBucket(ctx context.Context, id influxdb.ID, buf []byte)
 		return nil, fmt.Errorf("bucket must have 1 retention policy; attempting to restore %d retention policies", len(newDBI.RetentionPolicies))
 	}
 
+	var oldShardIDs []uint64
+	if replace {
+		for _, rpi := range dbi.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				for _, sh := range sgi.Shards {
+					oldShardIDs = append(oldShardIDs, sh.ID)
+				}
+			}
+		}
+	}
+
 	dbi.RetentionPolicies = newDBI.RetentionPolicies
 	dbi.ContinuousQueries = newDBI.ContinuousQueries
 
@@ -424,6 +539,13 @@ func (e *Engine) RestoreBucket(ctx context.Context, id influxdb.ID, buf []byte)
 		return nil, err
 	}
 
+	// Delete the replaced shards, no longer in the meta data.
+	for _, shardID := range oldShardIDs {
+		if err := e.tsdbStore.DeleteShard(shardID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create shards.
 	for _, sgi := range rpi.ShardGroups {
 		if sgi.Deleted() {
@@ -454,6 +576,59 @@ func (e *Engine) RestoreShard(ctx context.Context, shardID uint64, r io.Reader)
 	return e.tsdbStore.RestoreShard(shardID, r)
 }
 
+// BucketShardDigests returns digests of the bucket's shards held by this
+// engine, ordered by shard group start time. Shards not held locally are
+// omitted.
+func (e *Engine) BucketShardDigests(ctx context.Context, id influxdb.ID) ([]influxdb.ShardDigest, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	dbi := e.metaClient.Database(id.String())
+	if dbi == nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  fmt.Sprintf("bucket dbi for %q not found", id.String()),
+		}
+	}
+
+	var digests []influxdb.ShardDigest
+	for _, rpi := range dbi.RetentionPolicies {
+		for _, sgi := range rpi.ShardGroups {
+			if sgi.Deleted() {
+				continue
+			}
+
+			for _, sh := range sgi.Shards {
+				summary, err := e.tsdbStore.ShardSummary(sh.ID)
+				if err == tsdb.ErrShardNotFound {
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+
+				digests = append(digests, influxdb.ShardDigest{
+					ShardID:   sh.ID,
+					StartTime: sgi.StartTime.UTC(),
+					EndTime:   sgi.EndTime.UTC(),
+					SeriesN:   summary.SeriesN,
+					BlockN:    summary.BlockN,
+					Checksum:  strconv.FormatUint(summary.Checksum, 16),
+				})
+			}
+		}
+	}
+
+	sort.Slice(digests, func(i, j int) bool { return digests[i].StartTime.Before(digests[j].StartTime) })
+	return digests, nil
+}
+
 // SeriesCardinality returns the number of series in the engine.
 func (e *Engine) SeriesCardinality(orgID, bucketID influxdb.ID) int64 {
 	e.mu.RLock()
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"go.uber.org/zap"
+)
+
+const (
+	// cacheSnapshotInterval and cacheSnapshotBurst limit the snapshots of
+	// bucket caches requested by operators, as each one writes a TSM file per
+	// shard of the bucket.
+	cacheSnapshotInterval = 10 * time.Second
+	cacheSnapshotBurst    = 3
+)
+
+var _ influxdb.CacheService = (*Engine)(nil)
+
+// ShardCaches returns the state of the cache of every shard.
+func (e *Engine) ShardCaches(ctx context.Context) ([]influxdb.ShardCache, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+	return shardCaches(e.tsdbStore.Shards(e.tsdbStore.ShardIDs())), nil
+}
+
+// SnapshotBucketCaches writes the caches of the shards of a bucket to TSM
+// files. Snapshots are rate limited, and fail with ETooManyRequests when
+// requested too often.
+func (e *Engine) SnapshotBucketCaches(ctx context.Context, bucketID influxdb.ID) ([]influxdb.ShardCache, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	db := e.metaClient.Database(bucketID.String())
+	if db == nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+
+	if !e.cacheSnapshots.Allow() {
+		return nil, &influxdb.Error{
+			Code: influxdb.ETooManyRequests,
+			Msg:  "cache snapshots are limited to " + cacheSnapshotInterval.String() + " apart; try again later",
+		}
+	}
+
+	var shards []*tsdb.Shard
+	for _, sh := range e.tsdbStore.Shards(e.tsdbStore.ShardIDs()) {
+		if sh.Database() == db.Name {
+			shards = append(shards, sh)
+		}
+	}
+
+	log := e.logger.With(zap.String("bucket_id", bucketID.String()))
+	log.Info("Snapshotting bucket caches on request", zap.Int("shards", len(shards)))
+	start := time.Now()
+	for _, sh := range shards {
+		engine, err := sh.Engine()
+		if err != nil {
+			return nil, err
+		}
+		tsmEngine, ok := engine.(*tsm1.Engine)
+		if !ok {
+			continue
+		}
+		if tsmEngine.Cache.Size() == 0 {
+			continue
+		}
+		if err := tsmEngine.WriteSnapshot(); err != nil {
+			log.Error("Failed to snapshot shard cache", zap.Uint64("shard_id", sh.ID()), zap.Error(err))
+			return nil, err
+		}
+	}
+	log.Info("Snapshotted bucket caches", zap.Duration("duration", time.Since(start)))
+
+	return shardCaches(shards), nil
+}
+
+// CompactBucket writes the caches of the shards of a bucket to TSM files and
+// schedules a full compaction of each shard, bringing the bucket to its
+// minimal size on disk once it no longer receives writes. Unlike
+// SnapshotBucketCaches it is not rate limited.
+func (e *Engine) CompactBucket(ctx context.Context, bucketID influxdb.ID) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+
+	db := e.metaClient.Database(bucketID.String())
+	if db == nil {
+		// The bucket has no data.
+		return nil
+	}
+
+	log := e.logger.With(zap.String("bucket_id", bucketID.String()))
+	for _, sh := range e.tsdbStore.Shards(e.tsdbStore.ShardIDs()) {
+		if sh.Database() != db.Name {
+			continue
+		}
+		engine, err := sh.Engine()
+		if err != nil {
+			return err
+		}
+		tsmEngine, ok := engine.(*tsm1.Engine)
+		if !ok {
+			continue
+		}
+		if tsmEngine.Cache.Size() > 0 {
+			if err := tsmEngine.WriteSnapshot(); err != nil {
+				log.Error("Failed to snapshot shard cache", zap.Uint64("shard_id", sh.ID()), zap.Error(err))
+				return err
+			}
+		}
+		if err := tsmEngine.ScheduleFullCompaction(); err != nil {
+			log.Error("Failed to schedule full compaction of shard", zap.Uint64("shard_id", sh.ID()), zap.Error(err))
+			return err
+		}
+	}
+	log.Info("Scheduled full compaction of bucket")
+	return nil
+}
+
+// shardCaches returns the state of the caches of shards backed by the TSM
+// engine.
+func shardCaches(shards []*tsdb.Shard) []influxdb.ShardCache {
+	now := time.Now()
+	caches := make([]influxdb.ShardCache, 0, len(shards))
+	for _, sh := range shards {
+		engine, err := sh.Engine()
+		if err != nil {
+			// The shard is closed.
+			continue
+		}
+		tsmEngine, ok := engine.(*tsm1.Engine)
+		if !ok {
+			continue
+		}
+		bucketID, err := influxdb.IDFromString(sh.Database())
+		if err != nil {
+			continue
+		}
+
+		cache := tsmEngine.Cache
+		lastSnapshot := cache.LastSnapshotTime()
+		c := influxdb.ShardCache{
+			BucketID:          *bucketID,
+			ShardID:           sh.ID(),
+			Size:              cache.Size(),
+			MaxSize:           cache.MaxSize(),
+			LastSnapshot:      lastSnapshot.UTC(),
+			SinceLastSnapshot: influxdb.Duration{Duration: now.Sub(lastSnapshot)},
+		}
+		if oldest := cache.OldestWriteTime(); !oldest.IsZero() {
+			c.OldestEntryAge = influxdb.Duration{Duration: now.Sub(oldest)}
+		}
+		caches = append(caches, c)
+	}
+	return caches
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestEngine_ShardCaches(t *testing.T) {
+	ctx := context.Background()
+	e := newDeleteTestEngine(t)
+
+	p, err := models.NewPoint("cpu", nil, models.Fields{"value": 1.0}, deleteTestStart)
+	require.NoError(t, err)
+	require.NoError(t, e.WritePoints(ctx, deleteTestOrgID, deleteTestBucketID, []models.Point{p}))
+
+	caches, err := e.ShardCaches(ctx)
+	require.NoError(t, err)
+	require.Len(t, caches, 1)
+	assert.Equal(t, deleteTestBucketID, caches[0].BucketID)
+	assert.NotZero(t, caches[0].Size)
+	assert.NotZero(t, caches[0].OldestEntryAge.Duration)
+
+	caches, err = e.SnapshotBucketCaches(ctx, deleteTestBucketID)
+	require.NoError(t, err)
+	require.Len(t, caches, 1)
+	assert.Zero(t, caches[0].Size)
+	assert.Zero(t, caches[0].OldestEntryAge.Duration)
+}
+
+func TestEngine_SnapshotBucketCaches(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown bucket", func(t *testing.T) {
+		e := newDeleteTestEngine(t)
+		_, err := e.SnapshotBucketCaches(ctx, influxdb.ID(100))
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		e := newDeleteTestEngine(t)
+		e.cacheSnapshots = rate.NewLimiter(rate.Every(cacheSnapshotInterval), 1)
+
+		_, err := e.SnapshotBucketCaches(ctx, deleteTestBucketID)
+		require.NoError(t, err)
+		_, err = e.SnapshotBucketCaches(ctx, deleteTestBucketID)
+		assert.Equal(t, influxdb.ETooManyRequests, influxdb.ErrorCode(err))
+	})
+}
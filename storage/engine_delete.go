@@ -0,0 +1,350 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/file"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxql"
+	"go.uber.org/zap"
+)
+
+// deleteRemap renames the special tag keys of a delete predicate to the
+// names the index matches them by.
+var deleteRemap = map[string]string{
+	"_measurement":           "_name",
+	models.MeasurementTagKey: "_name",
+	"_field":                 "_field",
+	models.FieldKeyTagKey:    "_field",
+}
+
+// DeleteBucketRangePredicate deletes data within a bucket from the storage engine. Any data
+// deleted must be in [min, max], and the key must match the predicate if provided.
+//
+// Deleting a whole measurement over the entire history of the bucket drops
+// the measurement from the index and the TSM index entries of its series,
+// instead of tombstoning the range of each of its series.
+func (e *Engine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64, pred influxdb.Predicate) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+
+	cond, err := deleteCondition(pred)
+	if err != nil {
+		return err
+	}
+
+	database := bucketID.String()
+	name, single := deleteMeasurement(cond)
+	if single && isMeasurementOnly(cond) && e.coversBucketHistory(database, min, max) {
+		span.LogKV("delete_path", "measurement")
+		return e.deleteMeasurement(database, name)
+	}
+
+	span.LogKV("delete_path", "series")
+	var sources []influxql.Source
+	if single {
+		sources = []influxql.Source{&influxql.Measurement{Name: name}}
+	}
+	return e.tsdbStore.DeleteSeries(database, sources, deleteTimeCondition(cond, min, max))
+}
+
+// deleteCondition returns the influxql condition of a delete predicate, or
+// nil if it has none.
+func deleteCondition(pred influxdb.Predicate) (influxql.Expr, error) {
+	if pred == nil {
+		return nil, nil
+	}
+	buf, err := pred.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) == 0 {
+		return nil, nil
+	}
+
+	// Marshaled predicates are a version byte followed by the protobuf.
+	var p datatypes.Predicate
+	if err := p.Unmarshal(buf[1:]); err != nil {
+		return nil, &influxdb.Error{Code: influxdb.EInvalid, Msg: "invalid delete predicate", Err: err}
+	}
+	if p.Root == nil {
+		return nil, nil
+	}
+
+	cond, err := reads.NodeToExpr(p.Root, deleteRemap)
+	if err != nil {
+		return nil, &influxdb.Error{Code: influxdb.EInvalid, Msg: "invalid delete predicate", Err: err}
+	}
+
+	var byField bool
+	influxql.WalkFunc(cond, func(n influxql.Node) {
+		if ref, ok := n.(*influxql.VarRef); ok && ref.Val == "_field" {
+			byField = true
+		}
+	})
+	if byField {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "deleting by _field is not supported; whole series are deleted",
+		}
+	}
+	return cond, nil
+}
+
+// deleteMeasurement returns the measurement a delete condition is limited
+// to, if it is joined to the rest of the condition by AND only.
+func deleteMeasurement(cond influxql.Expr) (string, bool) {
+	var (
+		name  string
+		found int
+		or    bool
+	)
+	influxql.WalkFunc(cond, func(n influxql.Node) {
+		be, ok := n.(*influxql.BinaryExpr)
+		if !ok {
+			return
+		}
+		if be.Op == influxql.OR {
+			or = true
+		}
+		if ref, ok := be.LHS.(*influxql.VarRef); ok && ref.Val == "_name" {
+			found++
+			if lit, ok := be.RHS.(*influxql.StringLiteral); ok && be.Op == influxql.EQ {
+				name = lit.Val
+			}
+		}
+	})
+	return name, found == 1 && name != "" && !or
+}
+
+// isMeasurementOnly reports whether a delete condition is exactly a
+// comparison of the measurement.
+func isMeasurementOnly(cond influxql.Expr) bool {
+	for {
+		paren, ok := cond.(*influxql.ParenExpr)
+		if !ok {
+			break
+		}
+		cond = paren.Expr
+	}
+	be, ok := cond.(*influxql.BinaryExpr)
+	if !ok || be.Op != influxql.EQ {
+		return false
+	}
+	ref, ok := be.LHS.(*influxql.VarRef)
+	return ok && ref.Val == "_name"
+}
+
+// deleteTimeCondition limits a delete condition to [min, max].
+func deleteTimeCondition(cond influxql.Expr, min, max int64) influxql.Expr {
+	if min < influxql.MinTime {
+		min = influxql.MinTime
+	}
+	if max > influxql.MaxTime {
+		max = influxql.MaxTime
+	}
+	timeCond := &influxql.BinaryExpr{
+		Op: influxql.AND,
+		LHS: &influxql.BinaryExpr{
+			Op:  influxql.GTE,
+			LHS: &influxql.VarRef{Val: "time"},
+			RHS: &influxql.IntegerLiteral{Val: min},
+		},
+		RHS: &influxql.BinaryExpr{
+			Op:  influxql.LTE,
+			LHS: &influxql.VarRef{Val: "time"},
+			RHS: &influxql.IntegerLiteral{Val: max},
+		},
+	}
+	if cond == nil {
+		return timeCond
+	}
+	return &influxql.BinaryExpr{Op: influxql.AND, LHS: &influxql.ParenExpr{Expr: cond}, RHS: timeCond}
+}
+
+// coversBucketHistory reports whether [min, max] covers every shard group of
+// the database, so that no data of the database lies outside of it.
+func (e *Engine) coversBucketHistory(database string, min, max int64) bool {
+	if min == math.MinInt64 && max == math.MaxInt64 {
+		return true
+	}
+	db := e.metaClient.Database(database)
+	if db == nil {
+		return true
+	}
+	for _, rp := range db.RetentionPolicies {
+		for _, sg := range rp.ShardGroups {
+			if sg.Deleted() {
+				continue
+			}
+			// The end time of a shard group is exclusive.
+			if sg.StartTime.UnixNano() < min || sg.EndTime.Add(-time.Nanosecond).UnixNano() > max {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// deleteMeasurement drops the measurement from every shard of the database.
+// The delete is journaled until it completes, so that a delete interrupted
+// by a crash is finished when the engine reopens rather than leaving the
+// measurement in some of the shards only.
+func (e *Engine) deleteMeasurement(database, name string) error {
+	d := pendingMeasurementDelete{Database: database, Measurement: name}
+	if err := e.deletes.add(d); err != nil {
+		return fmt.Errorf("failed to journal measurement delete: %w", err)
+	}
+	if err := e.tsdbStore.DeleteMeasurement(database, name); err != nil {
+		// The delete is left in the journal and retried on the next open.
+		return err
+	}
+	return e.deletes.remove(d)
+}
+
+// resumeMeasurementDeletes finishes the measurement deletes interrupted by a
+// crash or a failure.
+func (e *Engine) resumeMeasurementDeletes() error {
+	pending, err := e.deletes.load()
+	if err != nil {
+		return fmt.Errorf("failed to read measurement delete journal: %w", err)
+	}
+	for _, d := range pending {
+		e.logger.Info("Resuming interrupted measurement delete",
+			zap.String("bucket", d.Database),
+			zap.String("measurement", d.Measurement))
+		if err := e.tsdbStore.DeleteMeasurement(d.Database, d.Measurement); err != nil {
+			return fmt.Errorf("failed to resume delete of measurement %q of bucket %s: %w", d.Measurement, d.Database, err)
+		}
+		if err := e.deletes.remove(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingMeasurementDelete is a measurement delete that has not completed.
+type pendingMeasurementDelete struct {
+	Database    string `json:"database"`
+	Measurement string `json:"measurement"`
+}
+
+// measurementDeleteJournal is the file recording the measurement deletes in
+// progress.
+type measurementDeleteJournal struct {
+	mu      sync.Mutex
+	path    string
+	pending []pendingMeasurementDelete
+}
+
+// load reads the deletes left in the journal.
+func (j *measurementDeleteJournal) load() ([]pendingMeasurementDelete, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	buf, err := ioutil.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		j.pending = nil
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var pending []pendingMeasurementDelete
+	if err := json.Unmarshal(buf, &pending); err != nil {
+		return nil, err
+	}
+	j.pending = pending
+	return append([]pendingMeasurementDelete(nil), pending...), nil
+}
+
+func (j *measurementDeleteJournal) add(d pendingMeasurementDelete) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, p := range j.pending {
+		if p == d {
+			return nil
+		}
+	}
+	if err := j.write(append(j.pending, d)); err != nil {
+		return err
+	}
+	j.pending = append(j.pending, d)
+	return nil
+}
+
+func (j *measurementDeleteJournal) remove(d pendingMeasurementDelete) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var pending []pendingMeasurementDelete
+	for _, p := range j.pending {
+		if p != d {
+			pending = append(pending, p)
+		}
+	}
+	if err := j.write(pending); err != nil {
+		return err
+	}
+	j.pending = pending
+	return nil
+}
+
+// write replaces the journal with pending, removing it when empty.
+func (j *measurementDeleteJournal) write(pending []pendingMeasurementDelete) error {
+	if len(pending) == 0 {
+		if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	buf, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0777); err != nil {
+		return err
+	}
+
+	tmp := j.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := file.RenameFile(tmp, j.path); err != nil {
+		return err
+	}
+	return file.SyncDir(filepath.Dir(j.path))
+}
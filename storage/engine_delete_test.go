@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/predicate"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	deleteTestOrgID    = influxdb.ID(1)
+	deleteTestBucketID = influxdb.ID(2)
+)
+
+var deleteTestStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type deleteTestEngine struct {
+	*Engine
+	path       string
+	metaClient *meta.Client
+}
+
+func newDeleteTestEngine(tb testing.TB) *deleteTestEngine {
+	tb.Helper()
+
+	path, err := ioutil.TempDir("", "storage-delete")
+	require.NoError(tb, err)
+	tb.Cleanup(func() { os.RemoveAll(path) })
+
+	store := inmem.NewKVStore()
+	require.NoError(tb, store.CreateBucket(context.Background(), meta.BucketName))
+	metaClient := meta.NewClient(meta.NewConfig(), store)
+	require.NoError(tb, metaClient.Open())
+
+	e := &deleteTestEngine{path: path, metaClient: metaClient}
+	e.reopen(tb)
+	tb.Cleanup(func() { e.Close() })
+
+	require.NoError(tb, e.CreateBucket(context.Background(), &influxdb.Bucket{ID: deleteTestBucketID}))
+	return e
+}
+
+func (e *deleteTestEngine) reopen(tb testing.TB) {
+	tb.Helper()
+	if e.Engine != nil {
+		require.NoError(tb, e.Close())
+	}
+	e.Engine = NewEngine(e.path, NewConfig(), WithMetaClient(e.metaClient))
+	require.NoError(tb, e.Open(context.Background()))
+}
+
+// writePoints writes a point per minute for an hour to n series of each
+// measurement, and snapshots them to TSM files.
+func (e *deleteTestEngine) writePoints(tb testing.TB, n int, measurements ...string) {
+	tb.Helper()
+
+	var points []models.Point
+	for _, m := range measurements {
+		for i := 0; i < n; i++ {
+			for t := 0; t < 60; t++ {
+				p, err := models.NewPoint(m,
+					models.NewTags(map[string]string{"host": fmt.Sprintf("h%d", i)}),
+					models.Fields{"value": float64(t)},
+					deleteTestStart.Add(time.Duration(t)*time.Minute))
+				require.NoError(tb, err)
+				points = append(points, p)
+			}
+		}
+	}
+	require.NoError(tb, e.WritePoints(context.Background(), deleteTestOrgID, deleteTestBucketID, points))
+
+	for _, sh := range e.tsdbStore.Shards(e.tsdbStore.ShardIDs()) {
+		engine, err := sh.Engine()
+		require.NoError(tb, err)
+		require.NoError(tb, engine.(interface{ WriteSnapshot() error }).WriteSnapshot())
+	}
+}
+
+func (e *deleteTestEngine) measurements(tb testing.TB) []string {
+	tb.Helper()
+
+	names, err := e.tsdbStore.MeasurementNames(query.OpenAuthorizer, deleteTestBucketID.String(), nil)
+	require.NoError(tb, err)
+	var out []string
+	for _, name := range names {
+		out = append(out, string(name))
+	}
+	return out
+}
+
+func tagPredicate(tb testing.TB, key, value string) influxdb.Predicate {
+	tb.Helper()
+
+	pred, err := predicate.New(&predicate.TagRuleNode{
+		Tag:      influxdb.Tag{Key: key, Value: value},
+		Operator: influxdb.Equal,
+	})
+	require.NoError(tb, err)
+	return pred
+}
+
+func TestEngine_DeleteBucketRangePredicate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("whole measurement", func(t *testing.T) {
+		e := newDeleteTestEngine(t)
+		e.writePoints(t, 2, "cpu", "mem")
+
+		err := e.DeleteBucketRangePredicate(ctx, deleteTestOrgID, deleteTestBucketID, math.MinInt64, math.MaxInt64, tagPredicate(t, "_measurement", "cpu"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mem"}, e.measurements(t))
+
+		_, err = os.Stat(filepath.Join(e.path, "pending_deletes.json"))
+		assert.True(t, os.IsNotExist(err), "journal should be removed once the delete completes")
+	})
+
+	t.Run("partial range", func(t *testing.T) {
+		e := newDeleteTestEngine(t)
+		e.writePoints(t, 2, "cpu", "mem")
+
+		min := deleteTestStart.UnixNano()
+		max := deleteTestStart.Add(30 * time.Minute).UnixNano()
+		err := e.DeleteBucketRangePredicate(ctx, deleteTestOrgID, deleteTestBucketID, min, max, tagPredicate(t, "_measurement", "cpu"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cpu", "mem"}, e.measurements(t))
+	})
+
+	t.Run("by tag", func(t *testing.T) {
+		e := newDeleteTestEngine(t)
+		e.writePoints(t, 1, "cpu", "mem")
+
+		err := e.DeleteBucketRangePredicate(ctx, deleteTestOrgID, deleteTestBucketID, math.MinInt64, math.MaxInt64, tagPredicate(t, "host", "h0"))
+		require.NoError(t, err)
+		assert.Empty(t, e.measurements(t))
+	})
+
+	t.Run("by field", func(t *testing.T) {
+		e := newDeleteTestEngine(t)
+
+		err := e.DeleteBucketRangePredicate(ctx, deleteTestOrgID, deleteTestBucketID, math.MinInt64, math.MaxInt64, tagPredicate(t, "_field", "value"))
+		assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+	})
+}
+
+func TestEngine_ResumeMeasurementDeletes(t *testing.T) {
+	e := newDeleteTestEngine(t)
+	e.writePoints(t, 2, "cpu", "mem")
+
+	// A crash after journaling the delete leaves it pending.
+	require.NoError(t, e.deletes.add(pendingMeasurementDelete{Database: deleteTestBucketID.String(), Measurement: "cpu"}))
+	e.reopen(t)
+
+	assert.Equal(t, []string{"mem"}, e.measurements(t))
+	pending, err := e.deletes.load()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestCoversBucketHistory(t *testing.T) {
+	e := newDeleteTestEngine(t)
+	e.writePoints(t, 1, "cpu")
+
+	db := e.metaClient.Database(deleteTestBucketID.String())
+	require.NotNil(t, db)
+	sg := db.RetentionPolicies[0].ShardGroups[0]
+
+	assert.True(t, e.coversBucketHistory(db.Name, math.MinInt64, math.MaxInt64))
+	assert.True(t, e.coversBucketHistory(db.Name, sg.StartTime.UnixNano(), sg.EndTime.UnixNano()-1))
+	assert.False(t, e.coversBucketHistory(db.Name, sg.StartTime.UnixNano()+1, sg.EndTime.UnixNano()))
+	assert.False(t, e.coversBucketHistory(db.Name, sg.StartTime.UnixNano(), sg.EndTime.UnixNano()-2))
+}
+
+// BenchmarkEngine_DeleteMeasurement compares deleting all the data of a
+// measurement by dropping it with deleting the range of each of its series.
+func BenchmarkEngine_DeleteMeasurement(b *testing.B) {
+	ctx := context.Background()
+	benchmarks := []struct {
+		name     string
+		min, max int64
+	}{
+		{name: "measurement", min: math.MinInt64, max: math.MaxInt64},
+		// The range of the points leaves the rest of their shard group out,
+		// so the series are deleted one by one.
+		{name: "series", min: deleteTestStart.UnixNano(), max: deleteTestStart.Add(time.Hour).UnixNano()},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			e := newDeleteTestEngine(b)
+			pred := tagPredicate(b, "_measurement", "cpu")
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				e.writePoints(b, 1000, "cpu")
+				b.StartTimer()
+
+				if err := e.DeleteBucketRangePredicate(ctx, deleteTestOrgID, deleteTestBucketID, bm.min, bm.max, pred); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_ReplaceBucket(t *testing.T) {
+	ctx := context.Background()
+	e := newDeleteTestEngine(t)
+	e.writePoints(t, 2, "cpu")
+
+	oldShardIDs := e.tsdbStore.ShardIDs()
+	require.Len(t, oldShardIDs, 1)
+
+	// The backup of the bucket, taken before its data is replaced.
+	dbi := e.metaClient.Database(deleteTestBucketID.String())
+	require.NotNil(t, dbi)
+	buf, err := dbi.MarshalBinary()
+	require.NoError(t, err)
+
+	shardIDMap, err := e.ReplaceBucket(ctx, deleteTestBucketID, buf)
+	require.NoError(t, err)
+	require.Len(t, shardIDMap, 1)
+	newShardID := shardIDMap[oldShardIDs[0]]
+	assert.NotEqual(t, oldShardIDs[0], newShardID)
+
+	// The replaced shard and its data are deleted, and the new shard is
+	// created empty under the same bucket.
+	assert.Nil(t, e.tsdbStore.Shard(oldShardIDs[0]))
+	sh := e.tsdbStore.Shard(newShardID)
+	require.NotNil(t, sh)
+	assert.Equal(t, deleteTestBucketID.String(), sh.Database())
+	seriesN, err := e.SeriesN(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, seriesN)
+
+	dbi = e.metaClient.Database(deleteTestBucketID.String())
+	require.NotNil(t, dbi)
+	require.Len(t, dbi.RetentionPolicies, 1)
+	var metaShardIDs []uint64
+	for _, sgi := range dbi.RetentionPolicies[0].ShardGroups {
+		for _, si := range sgi.Shards {
+			metaShardIDs = append(metaShardIDs, si.ID)
+		}
+	}
+	assert.Equal(t, []uint64{newShardID}, metaShardIDs)
+}
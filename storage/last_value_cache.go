@@ -0,0 +1,432 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultLastValueCacheMaxBucketSize is the default number of bytes the last
+// value cache holds for each bucket.
+const DefaultLastValueCacheMaxBucketSize = 16 * 1024 * 1024
+
+// lastValueEntryOverhead approximates the bytes an entry takes besides its
+// series key, field and value.
+const lastValueEntryOverhead = 128
+
+// LastValueBucketFinder finds the buckets written to, for their last value
+// measurements.
+type LastValueBucketFinder interface {
+	FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error)
+}
+
+// LastValueCache caches the most recent value written to each field of the
+// series of the last value measurements of buckets, so that they are read
+// without querying storage. It passes writes and deletes through to storage.
+//
+// The cache only knows of the values written since it started tracking a
+// series: it is empty on startup, and a series is missing until it is
+// written again after its cached values were evicted or deleted.
+type LastValueCache struct {
+	finder        LastValueBucketFinder
+	maxBucketSize int
+
+	mu      sync.Mutex
+	buckets map[influxdb.ID]*lastValueBucket
+
+	metrics *lastValueCacheMetrics
+}
+
+// lastValueBucket holds the cached values of a bucket.
+type lastValueBucket struct {
+	// measurements are the last value measurements of the bucket when it
+	// was last written to.
+	measurements map[string]bool
+	// entries are keyed by series key and field, separated by a zero byte.
+	entries map[string]*list.Element
+	// lru lists the entries from the most to the least recently written.
+	lru  *list.List
+	size int
+}
+
+// lastValueEntry is the last value of a field of a series.
+type lastValueEntry struct {
+	key         string
+	measurement string
+	seriesKey   string
+	field       string
+	time        int64
+	value       interface{}
+	size        int
+}
+
+// NewLastValueCache returns a cache of last values holding at most
+// maxBucketSize bytes for each bucket, evicting the least recently written
+// values beyond it.
+func NewLastValueCache(finder LastValueBucketFinder, maxBucketSize int) *LastValueCache {
+	if maxBucketSize <= 0 {
+		maxBucketSize = DefaultLastValueCacheMaxBucketSize
+	}
+	return &LastValueCache{
+		finder:        finder,
+		maxBucketSize: maxBucketSize,
+		buckets:       make(map[influxdb.ID]*lastValueBucket),
+		metrics:       newLastValueCacheMetrics(),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (c *LastValueCache) PrometheusCollectors() []prometheus.Collector {
+	return c.metrics.PrometheusCollectors()
+}
+
+// PointsWriter returns a PointsWriter caching the points written to w.
+func (c *LastValueCache) PointsWriter(w PointsWriter) PointsWriter {
+	return &lastValuePointsWriter{cache: c, underlying: w}
+}
+
+// DeleteService returns a DeleteService invalidating the values deleted by
+// s.
+func (c *LastValueCache) DeleteService(s influxdb.DeleteService) influxdb.DeleteService {
+	return &lastValueDeleteService{cache: c, underlying: s}
+}
+
+// EngineSchema returns an EngineSchema dropping the values of the buckets
+// deleted by s.
+func (c *LastValueCache) EngineSchema(s EngineSchema) EngineSchema {
+	return &lastValueEngineSchema{EngineSchema: s, cache: c}
+}
+
+type lastValuePointsWriter struct {
+	cache      *LastValueCache
+	underlying PointsWriter
+}
+
+// WritePoints writes points to the underlying PointsWriter, and caches the
+// values of the points of the last value measurements of the bucket.
+func (w *lastValuePointsWriter) WritePoints(ctx context.Context, orgID influxdb.ID, bucketID influxdb.ID, points []models.Point) error {
+	err := w.underlying.WritePoints(ctx, orgID, bucketID, points)
+
+	bucket, e := w.cache.finder.FindBucketByID(ctx, bucketID)
+	if e != nil {
+		// Without the measurements of the bucket, the values it may hold
+		// can no longer be trusted.
+		w.cache.dropBucket(bucketID)
+		return err
+	}
+	w.cache.write(bucket, points, err != nil)
+	return err
+}
+
+type lastValueDeleteService struct {
+	cache      *LastValueCache
+	underlying influxdb.DeleteService
+}
+
+// DeleteBucketRangePredicate deletes with the underlying DeleteService, and
+// invalidates the cached values of the bucket within [min, max]. They are
+// invalidated whatever the predicate, as it is evaluated by storage.
+func (s *lastValueDeleteService) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64, pred influxdb.Predicate) error {
+	err := s.underlying.DeleteBucketRangePredicate(ctx, orgID, bucketID, min, max, pred)
+	// A failed delete may still have deleted some of the data.
+	s.cache.deleteRange(bucketID, min, max)
+	return err
+}
+
+type lastValueEngineSchema struct {
+	EngineSchema
+	cache *LastValueCache
+}
+
+// DeleteBucket deletes the bucket with the underlying EngineSchema, and
+// drops its cached values.
+func (s *lastValueEngineSchema) DeleteBucket(ctx context.Context, orgID, bucketID influxdb.ID) error {
+	err := s.EngineSchema.DeleteBucket(ctx, orgID, bucketID)
+	s.cache.dropBucket(bucketID)
+	return err
+}
+
+// write caches the values of points written to bucket. When the write
+// failed, some points may have been written anyway, so the values of their
+// series are invalidated instead.
+func (c *LastValueCache) write(bucket *influxdb.Bucket, points []models.Point, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.syncBucket(bucket)
+	if b == nil {
+		return
+	}
+
+	for _, p := range points {
+		name := string(p.Name())
+		if !b.measurements[name] {
+			continue
+		}
+		fields, err := p.Fields()
+		if err != nil {
+			continue
+		}
+		seriesKey := string(p.Key())
+		for field, value := range fields {
+			key := seriesKey + "\x00" + field
+			if failed {
+				c.remove(b, key)
+				continue
+			}
+			c.set(b, &lastValueEntry{
+				key:         key,
+				measurement: name,
+				seriesKey:   seriesKey,
+				field:       field,
+				time:        p.UnixNano(),
+				value:       value,
+				size:        len(key) + lastValueSize(value) + lastValueEntryOverhead,
+			})
+		}
+	}
+	c.evict(b)
+}
+
+// syncBucket returns the cached values of bucket, first dropping those of
+// measurements that are no longer last value measurements. It returns nil
+// if the bucket has none.
+func (c *LastValueCache) syncBucket(bucket *influxdb.Bucket) *lastValueBucket {
+	if len(bucket.LastValueMeasurements) == 0 {
+		c.dropBucketLocked(bucket.ID)
+		return nil
+	}
+
+	measurements := make(map[string]bool, len(bucket.LastValueMeasurements))
+	for _, name := range bucket.LastValueMeasurements {
+		measurements[name] = true
+	}
+
+	b := c.buckets[bucket.ID]
+	if b == nil {
+		b = &lastValueBucket{
+			entries: make(map[string]*list.Element),
+			lru:     list.New(),
+		}
+		c.buckets[bucket.ID] = b
+	} else {
+		for name := range b.measurements {
+			if !measurements[name] {
+				c.removeWhere(b, func(e *lastValueEntry) bool { return e.measurement == name })
+			}
+		}
+	}
+	b.measurements = measurements
+	return b
+}
+
+// set caches the value of entry unless a more recent one is cached.
+func (c *LastValueCache) set(b *lastValueBucket, entry *lastValueEntry) {
+	if elem, ok := b.entries[entry.key]; ok {
+		if elem.Value.(*lastValueEntry).time > entry.time {
+			return
+		}
+		c.remove(b, entry.key)
+	}
+	b.entries[entry.key] = b.lru.PushFront(entry)
+	b.size += entry.size
+	c.metrics.size.Add(float64(entry.size))
+	c.metrics.entries.Inc()
+}
+
+func (c *LastValueCache) remove(b *lastValueBucket, key string) {
+	elem, ok := b.entries[key]
+	if !ok {
+		return
+	}
+	entry := b.lru.Remove(elem).(*lastValueEntry)
+	delete(b.entries, key)
+	b.size -= entry.size
+	c.metrics.size.Sub(float64(entry.size))
+	c.metrics.entries.Dec()
+}
+
+func (c *LastValueCache) removeWhere(b *lastValueBucket, fn func(*lastValueEntry) bool) {
+	for elem := b.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*lastValueEntry); fn(entry) {
+			c.remove(b, entry.key)
+		}
+		elem = next
+	}
+}
+
+// evict removes the least recently written values of a bucket holding more
+// than the maximum size.
+func (c *LastValueCache) evict(b *lastValueBucket) {
+	for b.size > c.maxBucketSize {
+		c.remove(b, b.lru.Back().Value.(*lastValueEntry).key)
+		c.metrics.evictions.Inc()
+	}
+}
+
+func (c *LastValueCache) deleteRange(bucketID influxdb.ID, min, max int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if b := c.buckets[bucketID]; b != nil {
+		c.removeWhere(b, func(e *lastValueEntry) bool { return e.time >= min && e.time <= max })
+	}
+}
+
+func (c *LastValueCache) dropBucket(bucketID influxdb.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropBucketLocked(bucketID)
+}
+
+func (c *LastValueCache) dropBucketLocked(bucketID influxdb.ID) {
+	b := c.buckets[bucketID]
+	if b == nil {
+		return
+	}
+	c.metrics.size.Sub(float64(b.size))
+	c.metrics.entries.Sub(float64(len(b.entries)))
+	delete(c.buckets, bucketID)
+}
+
+// FindLastValues returns the last values cached for the series of a last
+// value measurement of bucket, sorted by series and field. Values older
+// than the retention period of the bucket are left out, as storage expires
+// them.
+func (c *LastValueCache) FindLastValues(ctx context.Context, bucket *influxdb.Bucket, filter influxdb.LastValueFilter) ([]*influxdb.LastValue, error) {
+	var enabled bool
+	for _, name := range bucket.LastValueMeasurements {
+		enabled = enabled || name == filter.Measurement
+	}
+	if !enabled {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("measurement %q is not a last value measurement of bucket %q", filter.Measurement, bucket.Name),
+		}
+	}
+
+	var oldest int64
+	if bucket.RetentionPeriod > 0 {
+		oldest = time.Now().Add(-bucket.RetentionPeriod).UnixNano()
+	}
+
+	values := c.findLastValues(bucket.ID, filter, oldest)
+	if len(values) == 0 {
+		c.metrics.misses.WithLabelValues(bucket.ID.String()).Inc()
+	} else {
+		c.metrics.hits.WithLabelValues(bucket.ID.String()).Inc()
+	}
+	return values, nil
+}
+
+func (c *LastValueCache) findLastValues(bucketID influxdb.ID, filter influxdb.LastValueFilter, oldest int64) []*influxdb.LastValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.buckets[bucketID]
+	if b == nil || !b.measurements[filter.Measurement] {
+		return []*influxdb.LastValue{}
+	}
+
+	var entries []*lastValueEntry
+	for elem := b.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lastValueEntry)
+		if entry.measurement == filter.Measurement && entry.time >= oldest {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	values := []*influxdb.LastValue{}
+	for _, entry := range entries {
+		_, tags := models.ParseKey([]byte(entry.seriesKey))
+		if !matchesTags(tags, filter.Tags) {
+			continue
+		}
+		values = append(values, &influxdb.LastValue{
+			Measurement: entry.measurement,
+			Tags:        tags.Map(),
+			Field:       entry.field,
+			Time:        time.Unix(0, entry.time).UTC(),
+			Value:       entry.value,
+		})
+	}
+	return values
+}
+
+func matchesTags(tags models.Tags, want map[string]string) bool {
+	for k, v := range want {
+		if tags.GetString(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// lastValueSize approximates the bytes held by a field value.
+func lastValueSize(v interface{}) int {
+	if s, ok := v.(string); ok {
+		return len(s)
+	}
+	return 8
+}
+
+// lastValueCacheMetrics records the use of the last value cache.
+type lastValueCacheMetrics struct {
+	size      prometheus.Gauge
+	entries   prometheus.Gauge
+	evictions prometheus.Counter
+	hits      *prometheus.CounterVec
+	misses    *prometheus.CounterVec
+}
+
+func newLastValueCacheMetrics() *lastValueCacheMetrics {
+	const namespace = "storage"
+	const subsystem = "last_value_cache"
+
+	return &lastValueCacheMetrics{
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "size_bytes",
+			Help:      "Approximate number of bytes held by the last value cache.",
+		}),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "entries",
+			Help:      "Number of field values held by the last value cache.",
+		}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "evictions_total",
+			Help:      "Number of field values evicted from the last value cache to keep buckets within their size.",
+		}),
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "hits_total",
+			Help:      "Number of reads of the last value cache finding values, split out by bucket.",
+		}, []string{"bucket"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "misses_total",
+			Help:      "Number of reads of the last value cache finding no values, split out by bucket.",
+		}, []string{"bucket"}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (m *lastValueCacheMetrics) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{m.size, m.entries, m.evictions, m.hits, m.misses}
+}
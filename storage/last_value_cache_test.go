@@ -0,0 +1,147 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLastValueStorage struct {
+	writeErr error
+	deletes  int
+}
+
+func (s *fakeLastValueStorage) WritePoints(ctx context.Context, orgID influxdb.ID, bucketID influxdb.ID, points []models.Point) error {
+	return s.writeErr
+}
+
+func (s *fakeLastValueStorage) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64, pred influxdb.Predicate) error {
+	s.deletes++
+	return nil
+}
+
+func TestLastValueCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	bucket := &influxdb.Bucket{ID: 1, OrgID: 100, Name: "b", LastValueMeasurements: []string{"cpu"}}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+		return bucket, nil
+	}
+
+	newCache := func(t *testing.T, maxBucketSize int) (*storage.LastValueCache, *fakeLastValueStorage, storage.PointsWriter) {
+		t.Helper()
+		underlying := &fakeLastValueStorage{}
+		cache := storage.NewLastValueCache(buckets, maxBucketSize)
+		return cache, underlying, cache.PointsWriter(underlying)
+	}
+	write := func(t *testing.T, w storage.PointsWriter, lp string) error {
+		t.Helper()
+		points, err := models.ParsePointsString(lp)
+		require.NoError(t, err)
+		return w.WritePoints(ctx, bucket.OrgID, bucket.ID, points)
+	}
+	find := func(t *testing.T, cache *storage.LastValueCache, tags map[string]string) []*influxdb.LastValue {
+		t.Helper()
+		values, err := cache.FindLastValues(ctx, bucket, influxdb.LastValueFilter{Measurement: "cpu", Tags: tags})
+		require.NoError(t, err)
+		return values
+	}
+	ts := func(d time.Duration) int64 { return now.Add(d).UnixNano() }
+
+	t.Run("most recent values", func(t *testing.T) {
+		cache, _, w := newCache(t, 0)
+		require.NoError(t, write(t, w, lines(
+			"cpu,host=a usage=1,idle=9i %d", ts(-2*time.Second),
+			"cpu,host=a usage=2 %d", ts(-time.Second),
+			// Older values do not replace more recent ones.
+			"cpu,host=a usage=0 %d", ts(-3*time.Second),
+			"cpu,host=b usage=5 %d", ts(-time.Second),
+			"mem,host=a free=1 %d", ts(0),
+		)))
+
+		assert.Equal(t, []*influxdb.LastValue{
+			{Measurement: "cpu", Tags: map[string]string{"host": "a"}, Field: "idle", Time: now.Add(-2 * time.Second), Value: int64(9)},
+			{Measurement: "cpu", Tags: map[string]string{"host": "a"}, Field: "usage", Time: now.Add(-time.Second), Value: 2.0},
+			{Measurement: "cpu", Tags: map[string]string{"host": "b"}, Field: "usage", Time: now.Add(-time.Second), Value: 5.0},
+		}, find(t, cache, nil))
+		assert.Len(t, find(t, cache, map[string]string{"host": "b"}), 1)
+		assert.Empty(t, find(t, cache, map[string]string{"host": "c"}))
+
+		_, err := cache.FindLastValues(ctx, bucket, influxdb.LastValueFilter{Measurement: "mem"})
+		assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+	})
+
+	t.Run("failed write invalidates", func(t *testing.T) {
+		cache, underlying, w := newCache(t, 0)
+		require.NoError(t, write(t, w, lines("cpu,host=a usage=1 %d", ts(-time.Second))))
+		underlying.writeErr = errors.New("partial write")
+		assert.Error(t, write(t, w, lines("cpu,host=a usage=2 %d", ts(0))))
+		assert.Empty(t, find(t, cache, nil))
+	})
+
+	t.Run("delete invalidates range", func(t *testing.T) {
+		cache, underlying, w := newCache(t, 0)
+		require.NoError(t, write(t, w, lines(
+			"cpu,host=a usage=1 %d", ts(-time.Hour),
+			"cpu,host=b usage=2 %d", ts(0),
+		)))
+		deleter := cache.DeleteService(underlying)
+		require.NoError(t, deleter.DeleteBucketRangePredicate(ctx, bucket.OrgID, bucket.ID, ts(-time.Minute), ts(time.Minute), nil))
+		assert.Equal(t, 1, underlying.deletes)
+
+		values := find(t, cache, nil)
+		require.Len(t, values, 1)
+		assert.Equal(t, "a", values[0].Tags["host"])
+	})
+
+	t.Run("bounded size", func(t *testing.T) {
+		cache, _, w := newCache(t, 300)
+		require.NoError(t, write(t, w, lines(
+			"cpu,host=a usage=1 %d", ts(-2*time.Second),
+			"cpu,host=b usage=1 %d", ts(-time.Second),
+		)))
+		values := find(t, cache, nil)
+		require.Len(t, values, 1)
+		assert.Equal(t, "b", values[0].Tags["host"])
+	})
+
+	t.Run("disabled measurement", func(t *testing.T) {
+		cache, _, w := newCache(t, 0)
+		require.NoError(t, write(t, w, lines("cpu,host=a usage=1 %d", ts(0))))
+
+		bucket.LastValueMeasurements = []string{"mem"}
+		require.NoError(t, write(t, w, lines("cpu,host=a usage=2 %d", ts(time.Second))))
+		bucket.LastValueMeasurements = []string{"cpu"}
+
+		// The value written while disabled is not known, so the value
+		// cached before must not be read.
+		assert.Empty(t, find(t, cache, nil))
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		cache, _, w := newCache(t, 0)
+		require.NoError(t, write(t, w, lines("cpu,host=a usage=1 %d", ts(-2*time.Hour))))
+		bucket.RetentionPeriod = time.Hour
+		defer func() { bucket.RetentionPeriod = 0 }()
+		assert.Empty(t, find(t, cache, nil))
+	})
+}
+
+// lines formats pairs of line protocol formats and timestamps as lines.
+func lines(args ...interface{}) string {
+	var lp string
+	for i := 0; i < len(args); i += 2 {
+		lp += fmt.Sprintf(args[i].(string), args[i+1]) + "\n"
+	}
+	return lp
+}
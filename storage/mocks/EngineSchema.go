@@ -36,6 +36,20 @@ func (m *MockEngineSchema) EXPECT() *MockEngineSchemaMockRecorder {
 	return m.recorder
 }
 
+// CompactBucket mocks base method
+func (m *MockEngineSchema) CompactBucket(arg0 context.Context, arg1 influxdb.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompactBucket", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompactBucket indicates an expected call of CompactBucket
+func (mr *MockEngineSchemaMockRecorder) CompactBucket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompactBucket", reflect.TypeOf((*MockEngineSchema)(nil).CompactBucket), arg0, arg1)
+}
+
 // CreateBucket mocks base method
 func (m *MockEngineSchema) CreateBucket(arg0 context.Context, arg1 *influxdb.Bucket) error {
 	m.ctrl.T.Helper()
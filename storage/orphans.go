@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+)
+
+// OrphanMetaClient is the part of the meta store needed to find orphaned
+// shard directories.
+type OrphanMetaClient interface {
+	Database(name string) *meta.DatabaseInfo
+}
+
+// OrphanBucketFinder finds the bucket owning a directory of the engine.
+type OrphanBucketFinder interface {
+	FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error)
+}
+
+// FindOrphans cross-references the bucket and shard directories under the
+// data and WAL directories of an engine with the buckets and the meta store.
+// Bucket directories of buckets that no longer exist are reported as a whole;
+// otherwise every shard directory missing from the meta store is reported.
+// Either directory may be empty to skip it.
+func FindOrphans(ctx context.Context, dataDir, walDir string, mc OrphanMetaClient, buckets OrphanBucketFinder) (*influxdb.OrphanReport, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	report := &influxdb.OrphanReport{Orphans: []influxdb.OrphanedDirectory{}}
+	for _, root := range []struct{ kind, dir string }{
+		{kind: influxdb.OrphanKindData, dir: dataDir},
+		{kind: influxdb.OrphanKindWAL, dir: walDir},
+	} {
+		if root.dir == "" {
+			continue
+		}
+		if err := findOrphans(ctx, report, root.kind, root.dir, mc, buckets); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(report.Orphans, func(i, j int) bool {
+		return report.Orphans[i].Path < report.Orphans[j].Path
+	})
+	return report, nil
+}
+
+func findOrphans(ctx context.Context, report *influxdb.OrphanReport, kind, dir string, mc OrphanMetaClient, buckets OrphanBucketFinder) error {
+	bucketDirs, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, bd := range bucketDirs {
+		if !bd.IsDir() {
+			continue
+		}
+		bucketID, err := influxdb.IDFromString(bd.Name())
+		if err != nil {
+			continue
+		}
+
+		bucketPath := filepath.Join(dir, bd.Name())
+		if _, err := buckets.FindBucketByID(ctx, *bucketID); influxdb.ErrorCode(err) == influxdb.ENotFound {
+			o := influxdb.OrphanedDirectory{
+				Path:     bucketPath,
+				Kind:     kind,
+				BucketID: bd.Name(),
+				Reason:   "bucket not found",
+			}
+			if o.Size, o.ModTime, err = dirUsage(bucketPath); err != nil {
+				return err
+			}
+			report.Add(o)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		shards := make(map[string]map[uint64]bool)
+		if db := mc.Database(bd.Name()); db != nil {
+			for _, rp := range db.RetentionPolicies {
+				ids := make(map[uint64]bool)
+				for _, sg := range rp.ShardGroups {
+					if sg.Deleted() {
+						continue
+					}
+					for _, sh := range sg.Shards {
+						ids[sh.ID] = true
+					}
+				}
+				shards[rp.Name] = ids
+			}
+		}
+
+		rpDirs, err := ioutil.ReadDir(bucketPath)
+		if err != nil {
+			return err
+		}
+		for _, rpd := range rpDirs {
+			// Directories such as _series belong to the bucket, not to a
+			// retention policy.
+			if !rpd.IsDir() || strings.HasPrefix(rpd.Name(), "_") {
+				continue
+			}
+			rpPath := filepath.Join(bucketPath, rpd.Name())
+			shardDirs, err := ioutil.ReadDir(rpPath)
+			if err != nil {
+				return err
+			}
+			for _, sd := range shardDirs {
+				if !sd.IsDir() {
+					continue
+				}
+				shardID, err := strconv.ParseUint(sd.Name(), 10, 64)
+				if err != nil || shards[rpd.Name()][shardID] {
+					continue
+				}
+
+				shardPath := filepath.Join(rpPath, sd.Name())
+				o := influxdb.OrphanedDirectory{
+					Path:            shardPath,
+					Kind:            kind,
+					BucketID:        bd.Name(),
+					RetentionPolicy: rpd.Name(),
+					ShardID:         shardID,
+					Reason:          "shard not in meta store",
+				}
+				if o.Size, o.ModTime, err = dirUsage(shardPath); err != nil {
+					return err
+				}
+				report.Add(o)
+			}
+		}
+	}
+	return nil
+}
+
+// dirUsage returns the total size of the files under dir and the most recent
+// modification time of dir or any file under it.
+func dirUsage(dir string) (int64, time.Time, error) {
+	var size int64
+	var modTime time.Time
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		return nil
+	})
+	return size, modTime.UTC(), err
+}
+
+// RemoveOrphans moves the orphaned directories last modified before cutoff
+// into a new directory under trashDir and returns the directories moved along
+// with the directory they were moved to. Moving every directory before any is
+// deleted keeps the engine consistent if a move fails; the caller deletes the
+// returned directory once it no longer needs the data.
+func RemoveOrphans(orphans []influxdb.OrphanedDirectory, trashDir string, cutoff time.Time) ([]influxdb.OrphanedDirectory, string, error) {
+	trash := filepath.Join(trashDir, time.Now().UTC().Format("20060102T150405Z"))
+
+	var moved []influxdb.OrphanedDirectory
+	for _, o := range orphans {
+		if !o.ModTime.Before(cutoff) {
+			continue
+		}
+
+		dst := filepath.Join(trash, o.Kind, o.BucketID)
+		if o.RetentionPolicy != "" {
+			dst = filepath.Join(dst, o.RetentionPolicy, strconv.FormatUint(o.ShardID, 10))
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return moved, trash, err
+		}
+		if err := os.Rename(o.Path, dst); err != nil {
+			return moved, trash, err
+		}
+		moved = append(moved, o)
+	}
+	return moved, trash, nil
+}
+
+// Orphans returns the orphaned directories of the engine.
+func (e *Engine) Orphans(ctx context.Context, buckets OrphanBucketFinder) (*influxdb.OrphanReport, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+	return FindOrphans(ctx, e.config.Data.Dir, e.config.Data.WALDir, e.metaClient, buckets)
+}
+
+// OrphanEngine is an engine able to report its orphaned directories.
+type OrphanEngine interface {
+	Orphans(ctx context.Context, buckets OrphanBucketFinder) (*influxdb.OrphanReport, error)
+}
+
+// OrphanReportService reports the orphaned directories of a running engine.
+type OrphanReportService struct {
+	engine  OrphanEngine
+	buckets OrphanBucketFinder
+}
+
+var _ influxdb.OrphanReportService = (*OrphanReportService)(nil)
+
+// NewOrphanReportService returns a service reporting the orphaned directories
+// of engine.
+func NewOrphanReportService(engine OrphanEngine, buckets OrphanBucketFinder) *OrphanReportService {
+	return &OrphanReportService{engine: engine, buckets: buckets}
+}
+
+// OrphanReport returns the orphaned directories of the engine.
+func (s *OrphanReportService) OrphanReport(ctx context.Context) (*influxdb.OrphanReport, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	return s.engine.Orphans(ctx, s.buckets)
+}
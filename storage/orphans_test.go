@@ -0,0 +1,122 @@
+package storage_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOrphanMeta map[string]*meta.DatabaseInfo
+
+func (m fakeOrphanMeta) Database(name string) *meta.DatabaseInfo { return m[name] }
+
+type fakeOrphanBuckets map[influxdb.ID]bool
+
+func (b fakeOrphanBuckets) FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+	if !b[id] {
+		return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+	}
+	return &influxdb.Bucket{ID: id}, nil
+}
+
+func writeShardFile(t *testing.T, path string, n int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, ioutil.WriteFile(path, make([]byte, n), 0600))
+}
+
+func TestFindOrphans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orphans")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		liveBucket    = influxdb.ID(0x1000)
+		deletedBucket = influxdb.ID(0x2000)
+		dataDir       = filepath.Join(dir, "data")
+		walDir        = filepath.Join(dir, "wal")
+	)
+
+	writeShardFile(t, filepath.Join(dataDir, liveBucket.String(), "_series", "00", "0000"), 1)
+	writeShardFile(t, filepath.Join(dataDir, liveBucket.String(), "autogen", "1", "000000001-000000001.tsm"), 10)
+	writeShardFile(t, filepath.Join(dataDir, liveBucket.String(), "autogen", "2", "000000001-000000001.tsm"), 20)
+	writeShardFile(t, filepath.Join(dataDir, liveBucket.String(), "autogen", "3", "000000001-000000001.tsm"), 30)
+	writeShardFile(t, filepath.Join(walDir, liveBucket.String(), "autogen", "2", "_00001.wal"), 5)
+	writeShardFile(t, filepath.Join(dataDir, deletedBucket.String(), "autogen", "4", "000000001-000000001.tsm"), 40)
+	writeShardFile(t, filepath.Join(dataDir, "not-a-bucket", "file"), 50)
+
+	mc := fakeOrphanMeta{
+		liveBucket.String(): &meta.DatabaseInfo{
+			Name: liveBucket.String(),
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name: "autogen",
+				ShardGroups: []meta.ShardGroupInfo{
+					{ID: 1, Shards: []meta.ShardInfo{{ID: 1}}},
+					{ID: 3, Shards: []meta.ShardInfo{{ID: 3}}, DeletedAt: time.Now()},
+				},
+			}},
+		},
+	}
+	buckets := fakeOrphanBuckets{liveBucket: true}
+
+	report, err := storage.FindOrphans(context.Background(), dataDir, walDir, mc, buckets)
+	require.NoError(t, err)
+
+	type orphan struct {
+		path   string
+		reason string
+		size   int64
+	}
+	var got []orphan
+	for _, o := range report.Orphans {
+		rel, err := filepath.Rel(dir, o.Path)
+		require.NoError(t, err)
+		got = append(got, orphan{path: rel, reason: o.Reason, size: o.Size})
+		assert.False(t, o.ModTime.IsZero())
+	}
+	assert.Equal(t, []orphan{
+		{path: filepath.Join("data", liveBucket.String(), "autogen", "2"), reason: "shard not in meta store", size: 20},
+		{path: filepath.Join("data", liveBucket.String(), "autogen", "3"), reason: "shard not in meta store", size: 30},
+		{path: filepath.Join("data", deletedBucket.String()), reason: "bucket not found", size: 40},
+		{path: filepath.Join("wal", liveBucket.String(), "autogen", "2"), reason: "shard not in meta store", size: 5},
+	}, got)
+	assert.Equal(t, int64(95), report.TotalSize)
+}
+
+func TestRemoveOrphans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orphans")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	oldShard := filepath.Join(dir, "data", "0000000000001000", "autogen", "2")
+	newShard := filepath.Join(dir, "data", "0000000000001000", "autogen", "3")
+	writeShardFile(t, filepath.Join(oldShard, "a.tsm"), 1)
+	writeShardFile(t, filepath.Join(newShard, "a.tsm"), 1)
+
+	now := time.Now()
+	orphans := []influxdb.OrphanedDirectory{
+		{Path: oldShard, Kind: influxdb.OrphanKindData, BucketID: "0000000000001000", RetentionPolicy: "autogen", ShardID: 2, ModTime: now.Add(-48 * time.Hour)},
+		{Path: newShard, Kind: influxdb.OrphanKindData, BucketID: "0000000000001000", RetentionPolicy: "autogen", ShardID: 3, ModTime: now},
+	}
+
+	moved, trash, err := storage.RemoveOrphans(orphans, filepath.Join(dir, "trash"), now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, moved, 1)
+	assert.Equal(t, uint64(2), moved[0].ShardID)
+
+	_, err = os.Stat(oldShard)
+	assert.True(t, os.IsNotExist(err), "old shard should have been moved")
+	_, err = os.Stat(filepath.Join(trash, "data", "0000000000001000", "autogen", "2", "a.tsm"))
+	assert.NoError(t, err)
+	_, err = os.Stat(newShard)
+	assert.NoError(t, err, "recently modified shard should have been kept")
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+)
+
+// RetentionMetaClient is the part of the meta store needed to verify the
+// retention of buckets.
+type RetentionMetaClient interface {
+	Databases() []meta.DatabaseInfo
+}
+
+// RetentionBucketFinder finds the bucket of a database of the meta store.
+type RetentionBucketFinder interface {
+	FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error)
+}
+
+// ExpiredShard is a shard still on disk holding data older than the
+// retention period of its bucket.
+type ExpiredShard struct {
+	OrgID           influxdb.ID `json:"orgID"`
+	BucketID        influxdb.ID `json:"bucketID"`
+	BucketName      string      `json:"bucketName"`
+	RetentionPolicy string      `json:"retentionPolicy"`
+	ShardID         uint64      `json:"shardID"`
+	// Start and End bound the time range of the shard group of the shard.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Expired is true if all the data of the shard is past the retention
+	// period, which the retention service should have deleted. Otherwise,
+	// its oldest data exceeds the retention period by more than the duration
+	// of a shard group.
+	Expired bool `json:"expired"`
+	// Cutoff is the time the data before which is past the retention period
+	// of the bucket.
+	Cutoff time.Time `json:"cutoff"`
+	// Size is the size of the files of the shard, in the data and WAL
+	// directories.
+	Size int64 `json:"size"`
+}
+
+// RetentionReport lists the shards holding data past the retention period
+// of their bucket.
+type RetentionReport struct {
+	Shards      []ExpiredShard `json:"shards"`
+	ExpiredSize int64          `json:"expiredSize"`
+	TotalSize   int64          `json:"totalSize"`
+}
+
+// VerifyRetention compares the time ranges of the shards of every bucket
+// with a retention period to that period, as of now, and reports the shards
+// with files under the data or WAL directory that should have been deleted,
+// or whose oldest data exceeds the retention period by more than the
+// duration of a shard group. The shards of deleted shard groups and of
+// buckets that no longer exist are not reported; they are orphans.
+func VerifyRetention(ctx context.Context, dataDir, walDir string, mc RetentionMetaClient, buckets RetentionBucketFinder, now time.Time) (*RetentionReport, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	report := &RetentionReport{Shards: []ExpiredShard{}}
+	for _, db := range mc.Databases() {
+		bucketID, err := influxdb.IDFromString(db.Name)
+		if err != nil {
+			continue
+		}
+		bucket, err := buckets.FindBucketByID(ctx, *bucketID)
+		if influxdb.ErrorCode(err) == influxdb.ENotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if bucket.RetentionPeriod == 0 {
+			continue
+		}
+		cutoff := now.Add(-bucket.RetentionPeriod)
+
+		for _, rp := range db.RetentionPolicies {
+			for _, sg := range rp.ShardGroups {
+				if sg.Deleted() {
+					continue
+				}
+				sgDuration := rp.ShardGroupDuration
+				if sgDuration == 0 {
+					sgDuration = sg.EndTime.Sub(sg.StartTime)
+				}
+				expired := sg.EndTime.Before(cutoff)
+				if !expired && !sg.StartTime.Before(cutoff.Add(-sgDuration)) {
+					continue
+				}
+
+				for _, sh := range sg.Shards {
+					size, ok, err := shardUsage(dataDir, walDir, db.Name, rp.Name, sh.ID)
+					if err != nil {
+						return nil, err
+					} else if !ok {
+						continue
+					}
+					report.Shards = append(report.Shards, ExpiredShard{
+						OrgID:           bucket.OrgID,
+						BucketID:        bucket.ID,
+						BucketName:      bucket.Name,
+						RetentionPolicy: rp.Name,
+						ShardID:         sh.ID,
+						Start:           sg.StartTime.UTC(),
+						End:             sg.EndTime.UTC(),
+						Expired:         expired,
+						Cutoff:          cutoff.UTC(),
+						Size:            size,
+					})
+					if expired {
+						report.ExpiredSize += size
+					}
+					report.TotalSize += size
+				}
+			}
+		}
+	}
+
+	sort.Slice(report.Shards, func(i, j int) bool {
+		a, b := report.Shards[i], report.Shards[j]
+		if a.BucketID != b.BucketID {
+			return a.BucketID < b.BucketID
+		}
+		return a.ShardID < b.ShardID
+	})
+	return report, nil
+}
+
+// shardUsage returns the total size of the directories of a shard under the
+// data and WAL directories, either of which may be empty to skip it, and
+// whether either exists.
+func shardUsage(dataDir, walDir, bucketID, rp string, shardID uint64) (int64, bool, error) {
+	var size int64
+	var found bool
+	for _, dir := range []string{dataDir, walDir} {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, bucketID, rp, strconv.FormatUint(shardID, 10))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, false, err
+		}
+		n, _, err := dirUsage(path)
+		if err != nil {
+			return 0, false, err
+		}
+		size += n
+		found = true
+	}
+	return size, found, nil
+}
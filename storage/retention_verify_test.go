@@ -0,0 +1,117 @@
+package storage_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRetentionMeta []meta.DatabaseInfo
+
+func (m fakeRetentionMeta) Databases() []meta.DatabaseInfo { return m }
+
+type fakeRetentionBuckets map[influxdb.ID]*influxdb.Bucket
+
+func (b fakeRetentionBuckets) FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+	if bkt, ok := b[id]; ok {
+		return bkt, nil
+	}
+	return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+}
+
+func TestVerifyRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "retention")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		bucket         = influxdb.ID(0x1000)
+		infiniteBucket = influxdb.ID(0x2000)
+		deletedBucket  = influxdb.ID(0x3000)
+		orgID          = influxdb.ID(0x10)
+		dataDir        = filepath.Join(dir, "data")
+		walDir         = filepath.Join(dir, "wal")
+		now            = time.Date(2020, 10, 10, 0, 0, 0, 0, time.UTC)
+		day            = 24 * time.Hour
+	)
+	date := func(month time.Month, d int) time.Time { return time.Date(2020, month, d, 0, 0, 0, 0, time.UTC) }
+	group := func(id uint64, start, end time.Time) meta.ShardGroupInfo {
+		return meta.ShardGroupInfo{ID: id, StartTime: start, EndTime: end, Shards: []meta.ShardInfo{{ID: id}}}
+	}
+
+	writeShardFile(t, filepath.Join(dataDir, bucket.String(), "autogen", "1", "000000001-000000001.tsm"), 10)
+	writeShardFile(t, filepath.Join(walDir, bucket.String(), "autogen", "1", "_00001.wal"), 5)
+	writeShardFile(t, filepath.Join(dataDir, bucket.String(), "autogen", "2", "000000001-000000001.tsm"), 20)
+	writeShardFile(t, filepath.Join(dataDir, bucket.String(), "autogen", "3", "000000001-000000001.tsm"), 30)
+	writeShardFile(t, filepath.Join(dataDir, bucket.String(), "autogen", "5", "000000001-000000001.tsm"), 50)
+	writeShardFile(t, filepath.Join(dataDir, infiniteBucket.String(), "autogen", "6", "000000001-000000001.tsm"), 60)
+	writeShardFile(t, filepath.Join(dataDir, deletedBucket.String(), "autogen", "7", "000000001-000000001.tsm"), 70)
+
+	deleted := group(5, date(9, 1), date(9, 2))
+	deleted.DeletedAt = now
+	mc := fakeRetentionMeta{
+		{
+			Name: bucket.String(),
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name:               "autogen",
+				ShardGroupDuration: day,
+				ShardGroups: []meta.ShardGroupInfo{
+					// Past the retention period.
+					group(1, date(9, 20), date(9, 21)),
+					// Ends at the cutoff, so within the retention period.
+					group(2, date(10, 2), date(10, 3)),
+					// Created before the shard group duration was shortened,
+					// starting more than a day before the cutoff.
+					group(3, date(9, 25), date(10, 5)),
+					// Not on disk.
+					group(4, date(9, 10), date(9, 11)),
+					deleted,
+				},
+			}},
+		},
+		{
+			Name: infiniteBucket.String(),
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name:        "autogen",
+				ShardGroups: []meta.ShardGroupInfo{group(6, date(1, 1), date(1, 2))},
+			}},
+		},
+		{
+			Name: deletedBucket.String(),
+			RetentionPolicies: []meta.RetentionPolicyInfo{{
+				Name:        "autogen",
+				ShardGroups: []meta.ShardGroupInfo{group(7, date(1, 1), date(1, 2))},
+			}},
+		},
+	}
+	buckets := fakeRetentionBuckets{
+		bucket:         {ID: bucket, OrgID: orgID, Name: "metrics", RetentionPeriod: 7 * day},
+		infiniteBucket: {ID: infiniteBucket, OrgID: orgID, Name: "forever"},
+	}
+
+	report, err := storage.VerifyRetention(context.Background(), dataDir, walDir, mc, buckets, now)
+	require.NoError(t, err)
+
+	cutoff := date(10, 3)
+	assert.Equal(t, []storage.ExpiredShard{
+		{
+			OrgID: orgID, BucketID: bucket, BucketName: "metrics", RetentionPolicy: "autogen", ShardID: 1,
+			Start: date(9, 20), End: date(9, 21), Expired: true, Cutoff: cutoff, Size: 15,
+		},
+		{
+			OrgID: orgID, BucketID: bucket, BucketName: "metrics", RetentionPolicy: "autogen", ShardID: 3,
+			Start: date(9, 25), End: date(10, 5), Expired: false, Cutoff: cutoff, Size: 30,
+		},
+	}, report.Shards)
+	assert.Equal(t, int64(15), report.ExpiredSize)
+	assert.Equal(t, int64(45), report.TotalSize)
+}
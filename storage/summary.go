@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+)
+
+// DiskUsage returns the size on disk of the TSM files, WAL segments and
+// indexes of all shards, from the sizes their files are tracked at. The KV
+// store is not included.
+func (e *Engine) DiskUsage(ctx context.Context) (influxdb.DiskUsage, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return influxdb.DiskUsage{}, ErrEngineClosed
+	}
+
+	var usage influxdb.DiskUsage
+	// Shards closed since they were listed have no size to report.
+	for _, sh := range e.tsdbStore.Shards(e.tsdbStore.ShardIDs()) {
+		if engine, err := sh.Engine(); err == nil {
+			if tsmEngine, ok := engine.(*tsm1.Engine); ok {
+				usage.TSM += tsmEngine.FileStore.DiskSizeBytes()
+				if tsmEngine.WALEnabled {
+					usage.WAL += tsmEngine.WAL.DiskSizeBytes()
+				}
+			} else {
+				usage.TSM += engine.DiskSize()
+			}
+		}
+		if index, err := sh.Index(); err == nil {
+			usage.Index += index.DiskSizeBytes()
+		}
+	}
+	usage.Total = usage.TSM + usage.WAL + usage.Index
+	return usage, nil
+}
+
+// SeriesN returns the number of series in all buckets.
+func (e *Engine) SeriesN(ctx context.Context) (int64, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return 0, ErrEngineClosed
+	}
+
+	var n int64
+	for _, db := range e.tsdbStore.Databases() {
+		dbN, err := e.tsdbStore.SeriesCardinality(db)
+		if err != nil {
+			return 0, err
+		}
+		n += dbN
+	}
+	return n, nil
+}
+
+// Writes returns the write requests and points written to all buckets over
+// the part of rng covered by the samples held by the engine, which is
+// returned. Unlike Usage, it only reads the write counters of shards.
+func (e *Engine) Writes(ctx context.Context, rng influxdb.Timespan) (requests, points int64, covered influxdb.Timespan, err error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return 0, 0, influxdb.Timespan{}, ErrEngineClosed
+	}
+
+	start, stop := e.usageSamplesFor(e.tsdbStore.Shards(e.tsdbStore.ShardIDs()), rng)
+	for _, w := range writesBetween(start, stop) {
+		requests += w.requests
+		points += w.points
+	}
+	return requests, points, influxdb.Timespan{Start: start.time, Stop: stop.time}, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Summary(t *testing.T) {
+	ctx := context.Background()
+	e := newDeleteTestEngine(t)
+
+	var points []models.Point
+	for _, host := range []string{"a", "b", "c"} {
+		p, err := models.NewPoint("cpu", models.NewTags(map[string]string{"host": host}), models.Fields{"value": 1.0}, deleteTestStart)
+		require.NoError(t, err)
+		points = append(points, p)
+	}
+	require.NoError(t, e.WritePoints(ctx, deleteTestOrgID, deleteTestBucketID, points))
+
+	seriesN, err := e.SeriesN(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), seriesN)
+
+	usage, err := e.DiskUsage(ctx)
+	require.NoError(t, err)
+	assert.NotZero(t, usage.WAL)
+	assert.Equal(t, usage.TSM+usage.WAL+usage.Index, usage.Total)
+	assert.Zero(t, usage.KV)
+
+	now := time.Now().UTC()
+	requests, pointsN, covered, err := e.Writes(ctx, influxdb.Timespan{Start: now.Add(-5 * time.Minute), Stop: now})
+	require.NoError(t, err)
+	// Without samples older than the range, writes are counted from the
+	// opening of the shards.
+	assert.Equal(t, int64(1), requests)
+	assert.Equal(t, int64(3), pointsN)
+	assert.False(t, covered.Stop.Before(now))
+}
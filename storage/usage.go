@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+const (
+	// usageSampleInterval is how often the write counters of shards are sampled.
+	usageSampleInterval = time.Minute
+
+	// usageRetention is how long samples are kept, which bounds the range
+	// writes can be counted over.
+	usageRetention = 24 * time.Hour
+
+	// DefaultUsageRange is the range writes are counted over when a usage
+	// filter has no range.
+	DefaultUsageRange = time.Hour
+)
+
+// shardWrites are the write counters of a shard.
+type shardWrites struct {
+	bucketID influxdb.ID
+	requests int64
+	points   int64
+}
+
+// usageSample is the write counters of all shards at a point in time.
+type usageSample struct {
+	time   time.Time
+	shards map[uint64]shardWrites
+}
+
+// usageSamples holds samples of shard write counters, oldest first.
+type usageSamples struct {
+	mu      sync.Mutex
+	samples []usageSample
+}
+
+// add appends s and drops the samples older than usageRetention.
+func (u *usageSamples) add(s usageSample) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.samples = append(u.samples, s)
+	cutoff := s.time.Add(-usageRetention)
+	i := 0
+	for i < len(u.samples)-1 && u.samples[i].time.Before(cutoff) {
+		i++
+	}
+	u.samples = u.samples[i:]
+}
+
+// at returns the latest sample taken at or before t, or the oldest sample if
+// none was. It returns false if there are no samples.
+func (u *usageSamples) at(t time.Time) (usageSample, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if len(u.samples) == 0 {
+		return usageSample{}, false
+	}
+	i := sort.Search(len(u.samples), func(i int) bool { return u.samples[i].time.After(t) })
+	if i == 0 {
+		return u.samples[0], true
+	}
+	return u.samples[i-1], true
+}
+
+// latest returns the most recent sample.
+func (u *usageSamples) latest() (usageSample, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if len(u.samples) == 0 {
+		return usageSample{}, false
+	}
+	return u.samples[len(u.samples)-1], true
+}
+
+// writesBetween returns the writes to each bucket between two samples.
+// Counters of shards opened or reopened since start are counted from zero.
+func writesBetween(start, stop usageSample) map[influxdb.ID]shardWrites {
+	writes := make(map[influxdb.ID]shardWrites)
+	for id, end := range stop.shards {
+		d := end
+		if begin, ok := start.shards[id]; ok && begin.points <= end.points && begin.requests <= end.requests {
+			d.requests -= begin.requests
+			d.points -= begin.points
+		}
+
+		w := writes[end.bucketID]
+		w.requests += d.requests
+		w.points += d.points
+		writes[end.bucketID] = w
+	}
+	return writes
+}
+
+// sampleWrites returns the current write counters of the shards of all buckets.
+func sampleWrites(shards []*tsdb.Shard, now time.Time) usageSample {
+	s := usageSample{time: now, shards: make(map[uint64]shardWrites, len(shards))}
+	for _, sh := range shards {
+		bucketID, err := influxdb.IDFromString(sh.Database())
+		if err != nil {
+			continue
+		}
+		stats := sh.Stats()
+		s.shards[sh.ID()] = shardWrites{
+			bucketID: *bucketID,
+			requests: stats.WriteReqOK,
+			points:   stats.WritePointsOK,
+		}
+	}
+	return s
+}
+
+// sampleUsage samples the write counters of shards until closing is closed.
+func (e *Engine) sampleUsage(closing <-chan struct{}) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(usageSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closing:
+			return
+		case now := <-ticker.C:
+			e.mu.RLock()
+			if e.closing != nil {
+				e.usage.add(sampleWrites(e.tsdbStore.Shards(e.tsdbStore.ShardIDs()), now.UTC()))
+			}
+			e.mu.RUnlock()
+		}
+	}
+}
+
+// usageSamplesFor returns the samples bounding the part of rng covered by the
+// samples held by the engine. A range reaching the latest sample ends with
+// the current counters of shards. e.mu must be held.
+func (e *Engine) usageSamplesFor(shards []*tsdb.Shard, rng influxdb.Timespan) (start, stop usageSample) {
+	stop, ok := e.usage.latest()
+	if !ok || !rng.Stop.Before(stop.time) {
+		stop = sampleWrites(shards, time.Now().UTC())
+	} else {
+		stop, _ = e.usage.at(rng.Stop)
+	}
+	start, ok = e.usage.at(rng.Start)
+	if !ok || start.time.After(stop.time) {
+		start = stop
+	}
+	return start, stop
+}
+
+// Usage returns the usage of every bucket with data in the engine. Writes are
+// counted over the part of rng covered by the samples held by the engine,
+// which is returned. Bucket and organization names are not set.
+func (e *Engine) Usage(ctx context.Context, rng influxdb.Timespan) (map[influxdb.ID]influxdb.BucketUsage, influxdb.Timespan, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closing == nil {
+		return nil, influxdb.Timespan{}, ErrEngineClosed
+	}
+
+	shards := e.tsdbStore.Shards(e.tsdbStore.ShardIDs())
+	start, stop := e.usageSamplesFor(shards, rng)
+
+	usage := make(map[influxdb.ID]influxdb.BucketUsage)
+	for _, sh := range shards {
+		bucketID, err := influxdb.IDFromString(sh.Database())
+		if err != nil {
+			continue
+		}
+		u := usage[*bucketID]
+		u.BucketID = *bucketID
+		// A shard closed since it was listed has no size to report.
+		if n, err := sh.DiskSize(); err == nil {
+			u.DiskBytes += n
+		}
+		usage[*bucketID] = u
+	}
+
+	for bucketID, u := range usage {
+		n, err := e.tsdbStore.SeriesCardinality(bucketID.String())
+		if err != nil {
+			return nil, influxdb.Timespan{}, err
+		}
+		u.SeriesN = n
+		usage[bucketID] = u
+	}
+
+	for bucketID, w := range writesBetween(start, stop) {
+		u, ok := usage[bucketID]
+		if !ok {
+			continue
+		}
+		u.WriteRequests = w.requests
+		u.PointsWritten = w.points
+		usage[bucketID] = u
+	}
+
+	return usage, influxdb.Timespan{Start: start.time, Stop: stop.time}, nil
+}
+
+// UsageEngine is the storage engine data needed by a UsageReportService.
+type UsageEngine interface {
+	Usage(ctx context.Context, rng influxdb.Timespan) (map[influxdb.ID]influxdb.BucketUsage, influxdb.Timespan, error)
+}
+
+var _ influxdb.UsageReportService = (*UsageReportService)(nil)
+
+// UsageReportService reports the usage of buckets in the storage engine,
+// grouped by organization.
+type UsageReportService struct {
+	engine  UsageEngine
+	buckets influxdb.BucketService
+	orgs    influxdb.OrganizationService
+	now     func() time.Time
+}
+
+// NewUsageReportService returns a new UsageReportService for the provided
+// UsageEngine, which typically will be an Engine.
+func NewUsageReportService(engine UsageEngine, buckets influxdb.BucketService, orgs influxdb.OrganizationService) *UsageReportService {
+	return &UsageReportService{
+		engine:  engine,
+		buckets: buckets,
+		orgs:    orgs,
+		now:     time.Now,
+	}
+}
+
+// UsageReport returns the usage of the buckets matching the filter. Writes are
+// counted over the filter's range, or the last DefaultUsageRange if it has none.
+func (s *UsageReportService) UsageReport(ctx context.Context, filter influxdb.UsageFilter) (*influxdb.UsageReport, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var rng influxdb.Timespan
+	if filter.Range != nil {
+		rng = *filter.Range
+	} else {
+		rng.Stop = s.now().UTC()
+		rng.Start = rng.Stop.Add(-DefaultUsageRange)
+	}
+	if rng.Stop.IsZero() {
+		rng.Stop = s.now().UTC()
+	}
+	if rng.Start.After(rng.Stop) {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "usage range start must not be after its stop",
+		}
+	}
+
+	usage, covered, err := s.engine.Usage(ctx, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []*influxdb.Bucket
+	if filter.BucketID != nil {
+		b, err := s.buckets.FindBucketByID(ctx, *filter.BucketID)
+		if err != nil {
+			return nil, err
+		}
+		if filter.OrgID == nil || b.OrgID == *filter.OrgID {
+			buckets = append(buckets, b)
+		}
+	} else {
+		buckets, err = s.findBuckets(ctx, influxdb.BucketFilter{OrganizationID: filter.OrgID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &influxdb.UsageReport{Range: covered, Orgs: []influxdb.OrgUsage{}}
+	orgs := make(map[influxdb.ID]int)
+	for _, b := range buckets {
+		i, ok := orgs[b.OrgID]
+		if !ok {
+			o := influxdb.OrgUsage{OrgID: b.OrgID, Buckets: []influxdb.BucketUsage{}}
+			if org, err := s.orgs.FindOrganizationByID(ctx, b.OrgID); err == nil {
+				o.Org = org.Name
+			}
+			i = len(report.Orgs)
+			orgs[b.OrgID] = i
+			report.Orgs = append(report.Orgs, o)
+		}
+
+		u := usage[b.ID]
+		u.OrgID = b.OrgID
+		u.BucketID = b.ID
+		u.Bucket = b.Name
+		report.Orgs[i].Add(u)
+	}
+
+	return report, nil
+}
+
+// findBuckets returns all buckets matching the filter, a page at a time.
+func (s *UsageReportService) findBuckets(ctx context.Context, filter influxdb.BucketFilter) ([]*influxdb.Bucket, error) {
+	var buckets []*influxdb.Bucket
+	for {
+		page, _, err := s.buckets.FindBuckets(ctx, filter, influxdb.FindOptions{Offset: len(buckets), Limit: influxdb.MaxPageSize})
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, page...)
+		if len(page) < influxdb.MaxPageSize {
+			return buckets, nil
+		}
+	}
+}
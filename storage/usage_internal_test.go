@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageSamples(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var u usageSamples
+	if _, ok := u.at(t0); ok {
+		t.Fatal("expected no sample")
+	}
+
+	for i := 0; i < 3; i++ {
+		u.add(usageSample{time: t0.Add(time.Duration(i) * time.Hour)})
+	}
+
+	s, _ := u.at(t0.Add(-time.Hour))
+	assert.Equal(t, t0, s.time, "oldest sample is used before the first sample")
+	s, _ = u.at(t0.Add(90 * time.Minute))
+	assert.Equal(t, t0.Add(time.Hour), s.time)
+
+	// Samples older than the retention are dropped.
+	u.add(usageSample{time: t0.Add(usageRetention + 90*time.Minute)})
+	s, _ = u.at(t0)
+	assert.Equal(t, t0.Add(2*time.Hour), s.time)
+	s, _ = u.latest()
+	assert.Equal(t, t0.Add(usageRetention+90*time.Minute), s.time)
+}
+
+func TestWritesBetween(t *testing.T) {
+	start := usageSample{shards: map[uint64]shardWrites{
+		1: {bucketID: 10, requests: 5, points: 50},
+		2: {bucketID: 10, requests: 5, points: 50},
+		3: {bucketID: 20, requests: 9, points: 90},
+	}}
+	stop := usageSample{shards: map[uint64]shardWrites{
+		1: {bucketID: 10, requests: 7, points: 70},
+		// shard 2 was deleted; shard 3 was reopened and its counters reset.
+		3: {bucketID: 20, requests: 1, points: 10},
+		4: {bucketID: 20, requests: 2, points: 20},
+	}}
+
+	assert.Equal(t, map[influxdb.ID]shardWrites{
+		10: {requests: 2, points: 20},
+		20: {requests: 3, points: 30},
+	}, writesBetween(start, stop))
+}
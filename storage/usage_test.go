@@ -0,0 +1,86 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsageEngine struct {
+	rng   influxdb.Timespan
+	usage map[influxdb.ID]influxdb.BucketUsage
+}
+
+func (e *fakeUsageEngine) Usage(ctx context.Context, rng influxdb.Timespan) (map[influxdb.ID]influxdb.BucketUsage, influxdb.Timespan, error) {
+	e.rng = rng
+	return e.usage, rng, nil
+}
+
+func TestUsageReportService(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	engine := &fakeUsageEngine{usage: map[influxdb.ID]influxdb.BucketUsage{
+		1: {BucketID: 1, SeriesN: 10, DiskBytes: 100, WriteRequests: 1, PointsWritten: 10},
+		2: {BucketID: 2, SeriesN: 20, DiskBytes: 200, WriteRequests: 2, PointsWritten: 20},
+		3: {BucketID: 3, SeriesN: 30, DiskBytes: 300, WriteRequests: 3, PointsWritten: 30},
+	}}
+
+	buckets := mock.NewBucketService()
+	buckets.FindBucketsFn = func(ctx context.Context, filter influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+		all := []*influxdb.Bucket{
+			{ID: 1, OrgID: 100, Name: "a"},
+			{ID: 2, OrgID: 100, Name: "b"},
+			{ID: 3, OrgID: 200, Name: "c"},
+			// A bucket without data in the engine.
+			{ID: 4, OrgID: 200, Name: "d"},
+		}
+		var bs []*influxdb.Bucket
+		for _, b := range all {
+			if filter.OrganizationID == nil || *filter.OrganizationID == b.OrgID {
+				bs = append(bs, b)
+			}
+		}
+		return bs, len(bs), nil
+	}
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationByIDF = func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+		return &influxdb.Organization{ID: id, Name: id.String()}, nil
+	}
+
+	s := storage.NewUsageReportService(engine, buckets, orgs)
+
+	report, err := s.UsageReport(context.Background(), influxdb.UsageFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, storage.DefaultUsageRange, engine.rng.Stop.Sub(engine.rng.Start))
+
+	rng := influxdb.Timespan{Start: now.Add(-time.Minute), Stop: now}
+	report, err = s.UsageReport(context.Background(), influxdb.UsageFilter{Range: &rng})
+	require.NoError(t, err)
+	assert.Equal(t, rng, engine.rng)
+	assert.Equal(t, rng, report.Range)
+	require.Len(t, report.Orgs, 2)
+	assert.Equal(t, influxdb.ID(100), report.Orgs[0].OrgID)
+	assert.Equal(t, int64(30), report.Orgs[0].SeriesN)
+	assert.Equal(t, int64(300), report.Orgs[0].DiskBytes)
+	assert.Equal(t, int64(30), report.Orgs[0].PointsWritten)
+	assert.Len(t, report.Orgs[0].Buckets, 2)
+	assert.Equal(t, int64(300), report.Orgs[1].DiskBytes)
+	require.Len(t, report.Orgs[1].Buckets, 2)
+	assert.Equal(t, influxdb.BucketUsage{OrgID: 200, BucketID: 4, Bucket: "d"}, report.Orgs[1].Buckets[1])
+
+	orgID := influxdb.ID(200)
+	report, err = s.UsageReport(context.Background(), influxdb.UsageFilter{OrgID: &orgID})
+	require.NoError(t, err)
+	require.Len(t, report.Orgs, 1)
+	assert.Equal(t, "00000000000000c8", report.Orgs[0].Org)
+
+	_, err = s.UsageReport(context.Background(), influxdb.UsageFilter{
+		Range: &influxdb.Timespan{Start: now, Stop: now.Add(-time.Hour)},
+	})
+	assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+}
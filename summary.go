@@ -0,0 +1,61 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// ServerSummary is an overview of a server: its build, the resources it
+// holds, the storage they use, the load it is under and the state of its
+// background services.
+type ServerSummary struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	BuildDate string    `json:"buildDate"`
+	StartedAt time.Time `json:"startedAt"`
+	Uptime    Duration  `json:"uptime"`
+
+	// StatsUpdatedAt is when the counts, disk usage and series count were
+	// last computed. They are computed in the background, so a summary is
+	// quick to get whatever the size of the server, but may lag behind.
+	StatsUpdatedAt *time.Time     `json:"statsUpdatedAt,omitempty"`
+	Counts         ResourceCounts `json:"counts"`
+	Disk           DiskUsage      `json:"disk"`
+	SeriesN        int64          `json:"seriesCount"`
+
+	Rates    ServerRates                `json:"rates"`
+	Services []MaintenanceServiceStatus `json:"services"`
+}
+
+// ResourceCounts is the number of resources of each kind on a server.
+type ResourceCounts struct {
+	Orgs     int `json:"orgs"`
+	Buckets  int `json:"buckets"`
+	Users    int `json:"users"`
+	Tasks    int `json:"tasks"`
+	Scrapers int `json:"scrapers"`
+}
+
+// DiskUsage is the size on disk, in bytes, of the components of the storage
+// of a server.
+type DiskUsage struct {
+	TSM   int64 `json:"tsm"`
+	WAL   int64 `json:"wal"`
+	Index int64 `json:"index"`
+	KV    int64 `json:"kv"`
+	Total int64 `json:"total"`
+}
+
+// ServerRates are the rates of the writes and queries a server received
+// over its range.
+type ServerRates struct {
+	Range                  Timespan `json:"range"`
+	WriteRequestsPerSecond float64  `json:"writeRequestsPerSecond"`
+	PointsWrittenPerSecond float64  `json:"pointsWrittenPerSecond"`
+	QueriesPerSecond       float64  `json:"queriesPerSecond"`
+}
+
+// SummaryService reports an overview of the server.
+type SummaryService interface {
+	ServerSummary(ctx context.Context) (*ServerSummary, error)
+}
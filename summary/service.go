@@ -0,0 +1,358 @@
+// Package summary reports an overview of the server, for dashboards polling
+// many servers. The statistics costly to compute are refreshed in the
+// background, so a summary is quick to get whatever the size of the server.
+package summary
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRefreshInterval is how often the statistics of the summary are
+	// refreshed by default.
+	DefaultRefreshInterval = 30 * time.Second
+
+	// RateRange is the range the rates of writes and queries are averaged
+	// over.
+	RateRange = 5 * time.Minute
+)
+
+// queryCounters are the counters of the queries run by the server.
+var queryCounters = []string{
+	"query_control_requests_total",
+	"influxql_service_requests_total",
+}
+
+// Engine is the storage engine data needed by the Service.
+type Engine interface {
+	DiskUsage(ctx context.Context) (influxdb.DiskUsage, error)
+	SeriesN(ctx context.Context) (int64, error)
+	Writes(ctx context.Context, rng influxdb.Timespan) (requests, points int64, covered influxdb.Timespan, err error)
+}
+
+// Config is the statistics sources of a Service. Sources left nil are not
+// reported.
+type Config struct {
+	Engine                    Engine
+	OrganizationService       influxdb.OrganizationService
+	BucketService             influxdb.BucketService
+	UserService               influxdb.UserService
+	TaskService               influxdb.TaskService
+	ScraperTargetStoreService influxdb.ScraperTargetStoreService
+	MaintenanceService        influxdb.MaintenanceService
+
+	// Gatherer is the registry of the metrics the queries are counted from.
+	Gatherer prometheus.Gatherer
+	// KVPath is the path of the file of the KV store.
+	KVPath string
+	// StartedAt is when the server started.
+	StartedAt time.Time
+}
+
+var _ influxdb.SummaryService = (*Service)(nil)
+
+// Service reports an overview of the server from statistics it refreshes
+// with Run.
+type Service struct {
+	log    *zap.Logger
+	config Config
+	now    func() time.Time
+
+	mu             sync.RWMutex
+	statsUpdatedAt time.Time
+	counts         influxdb.ResourceCounts
+	disk           influxdb.DiskUsage
+	seriesN        int64
+	rates          influxdb.ServerRates
+	queries        []querySample
+}
+
+// querySample is the number of queries run by the server at a point in time.
+type querySample struct {
+	time time.Time
+	n    float64
+}
+
+// NewService returns a Service reporting the statistics of the sources of
+// config. Its statistics are empty until it is refreshed.
+func NewService(log *zap.Logger, config Config) *Service {
+	return &Service{
+		log:    log,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run refreshes the statistics of the summary at once, then at every
+// interval until ctx is done.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	s.Refresh(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh computes the statistics of the summary. The statistics that fail
+// to be computed are logged, and keep their previous values.
+func (s *Service) Refresh(ctx context.Context) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	now := s.now().UTC()
+
+	s.mu.RLock()
+	counts, disk, seriesN := s.counts, s.disk, s.seriesN
+	s.mu.RUnlock()
+
+	if c, err := s.countResources(ctx); err != nil {
+		s.log.Error("Failed to count resources for the server summary", zap.Error(err))
+	} else {
+		counts = c
+	}
+
+	rates := influxdb.ServerRates{Range: influxdb.Timespan{Start: now.Add(-RateRange), Stop: now}}
+	if e := s.config.Engine; e != nil {
+		if d, err := e.DiskUsage(ctx); err != nil {
+			s.log.Error("Failed to get disk usage for the server summary", zap.Error(err))
+		} else {
+			d.KV = disk.KV
+			disk = d
+		}
+		if n, err := e.SeriesN(ctx); err != nil {
+			s.log.Error("Failed to count series for the server summary", zap.Error(err))
+		} else {
+			seriesN = n
+		}
+		if requests, points, covered, err := e.Writes(ctx, rates.Range); err != nil {
+			s.log.Error("Failed to count writes for the server summary", zap.Error(err))
+		} else {
+			rates.Range = covered
+			rates.WriteRequestsPerSecond = perSecond(float64(requests), covered.Stop.Sub(covered.Start))
+			rates.PointsWrittenPerSecond = perSecond(float64(points), covered.Stop.Sub(covered.Start))
+		}
+	}
+	if s.config.KVPath != "" {
+		if fi, err := os.Stat(s.config.KVPath); err != nil {
+			s.log.Error("Failed to get KV store size for the server summary", zap.Error(err))
+		} else {
+			disk.KV = fi.Size()
+		}
+	}
+	disk.Total = disk.TSM + disk.WAL + disk.Index + disk.KV
+
+	queries, queriesErr := s.countQueries()
+	if queriesErr != nil {
+		s.log.Error("Failed to count queries for the server summary", zap.Error(queriesErr))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statsUpdatedAt = now
+	s.counts = counts
+	s.disk = disk
+	s.seriesN = seriesN
+	if queriesErr == nil {
+		s.addQuerySample(querySample{time: now, n: queries})
+	}
+	rates.QueriesPerSecond = s.queryRate(rates.Range.Start)
+	s.rates = rates
+}
+
+// ServerSummary returns the summary of the server, with the statistics of
+// its last refresh.
+func (s *Service) ServerSummary(ctx context.Context) (*influxdb.ServerSummary, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	info := influxdb.GetBuildInfo()
+	sum := &influxdb.ServerSummary{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildDate: info.Date,
+		StartedAt: s.config.StartedAt,
+		Uptime:    influxdb.Duration{Duration: s.now().Sub(s.config.StartedAt).Round(time.Second)},
+		Services:  []influxdb.MaintenanceServiceStatus{},
+	}
+
+	s.mu.RLock()
+	if !s.statsUpdatedAt.IsZero() {
+		updatedAt := s.statsUpdatedAt
+		sum.StatsUpdatedAt = &updatedAt
+	}
+	sum.Counts = s.counts
+	sum.Disk = s.disk
+	sum.SeriesN = s.seriesN
+	sum.Rates = s.rates
+	s.mu.RUnlock()
+
+	if s.config.MaintenanceService != nil {
+		services, err := s.config.MaintenanceService.MaintenanceServices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sum.Services = services
+	}
+	return sum, nil
+}
+
+// countResources counts the resources of each kind, a page at a time.
+func (s *Service) countResources(ctx context.Context) (influxdb.ResourceCounts, error) {
+	var counts influxdb.ResourceCounts
+
+	if svc := s.config.OrganizationService; svc != nil {
+		n, err := countPages(func(offset int) (int, error) {
+			orgs, _, err := svc.FindOrganizations(ctx, influxdb.OrganizationFilter{}, influxdb.FindOptions{Offset: offset, Limit: influxdb.MaxPageSize})
+			return len(orgs), err
+		})
+		if err != nil {
+			return counts, err
+		}
+		counts.Orgs = n
+	}
+
+	if svc := s.config.BucketService; svc != nil {
+		n, err := countPages(func(offset int) (int, error) {
+			buckets, _, err := svc.FindBuckets(ctx, influxdb.BucketFilter{}, influxdb.FindOptions{Offset: offset, Limit: influxdb.MaxPageSize})
+			return len(buckets), err
+		})
+		if err != nil {
+			return counts, err
+		}
+		counts.Buckets = n
+	}
+
+	if svc := s.config.UserService; svc != nil {
+		n, err := countPages(func(offset int) (int, error) {
+			users, _, err := svc.FindUsers(ctx, influxdb.UserFilter{}, influxdb.FindOptions{Offset: offset, Limit: influxdb.MaxPageSize})
+			return len(users), err
+		})
+		if err != nil {
+			return counts, err
+		}
+		counts.Users = n
+	}
+
+	if svc := s.config.TaskService; svc != nil {
+		var after *influxdb.ID
+		for {
+			tasks, _, err := svc.FindTasks(ctx, influxdb.TaskFilter{After: after, Limit: influxdb.TaskMaxPageSize})
+			if err != nil {
+				return counts, err
+			}
+			counts.Tasks += len(tasks)
+			if len(tasks) < influxdb.TaskMaxPageSize {
+				break
+			}
+			after = &tasks[len(tasks)-1].ID
+		}
+	}
+
+	if svc := s.config.ScraperTargetStoreService; svc != nil {
+		targets, err := svc.ListTargets(ctx, influxdb.ScraperTargetFilter{})
+		if err != nil {
+			return counts, err
+		}
+		counts.Scrapers = len(targets)
+	}
+
+	return counts, nil
+}
+
+// countPages counts the resources returned by find, which returns the
+// number of resources in the page at offset, until a page is not full.
+func countPages(find func(offset int) (int, error)) (int, error) {
+	var total int
+	for {
+		n, err := find(total)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+		if n < influxdb.MaxPageSize {
+			return total, nil
+		}
+	}
+}
+
+// countQueries returns the number of queries the server ran since it started.
+func (s *Service) countQueries() (float64, error) {
+	if s.config.Gatherer == nil {
+		return 0, nil
+	}
+	families, err := s.config.Gatherer.Gather()
+	if err != nil {
+		return 0, err
+	}
+
+	var n float64
+	for _, f := range families {
+		for _, name := range queryCounters {
+			if f.GetName() != name {
+				continue
+			}
+			for _, m := range f.GetMetric() {
+				n += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return n, nil
+}
+
+// addQuerySample appends sample, and drops the samples no longer needed to
+// compute the rate of queries over RateRange. s.mu must be held.
+func (s *Service) addQuerySample(sample querySample) {
+	s.queries = append(s.queries, sample)
+	cutoff := sample.time.Add(-RateRange)
+	i := 0
+	for i < len(s.queries)-1 && !s.queries[i+1].time.After(cutoff) {
+		i++
+	}
+	s.queries = s.queries[i:]
+}
+
+// queryRate returns the rate of queries between the latest sample taken at
+// or before start, or the oldest sample, and the latest sample. s.mu must be
+// held.
+func (s *Service) queryRate(start time.Time) float64 {
+	if len(s.queries) < 2 {
+		return 0
+	}
+	i := sort.Search(len(s.queries), func(i int) bool { return s.queries[i].time.After(start) })
+	if i > 0 {
+		i--
+	}
+	first, last := s.queries[i], s.queries[len(s.queries)-1]
+	if last.n < first.n {
+		return 0
+	}
+	return perSecond(last.n-first.n, last.time.Sub(first.time))
+}
+
+func perSecond(n float64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return n / d.Seconds()
+}
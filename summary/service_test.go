@@ -0,0 +1,179 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/maintenance"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeEngine struct {
+	disk    influxdb.DiskUsage
+	seriesN int64
+	err     error
+}
+
+func (e *fakeEngine) DiskUsage(ctx context.Context) (influxdb.DiskUsage, error) {
+	return e.disk, e.err
+}
+
+func (e *fakeEngine) SeriesN(ctx context.Context) (int64, error) {
+	return e.seriesN, e.err
+}
+
+func (e *fakeEngine) Writes(ctx context.Context, rng influxdb.Timespan) (int64, int64, influxdb.Timespan, error) {
+	// Writes are held for the last minute of the range only.
+	return 60, 600, influxdb.Timespan{Start: rng.Stop.Add(-time.Minute), Stop: rng.Stop}, e.err
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := startedAt.Add(time.Hour)
+
+	dir, err := ioutil.TempDir("", "influxdb-summary-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	kvPath := filepath.Join(dir, "influxd.bolt")
+	require.NoError(t, ioutil.WriteFile(kvPath, make([]byte, 1000), 0600))
+
+	orgs := &mock.OrganizationService{
+		FindOrganizationsF: func(ctx context.Context, filter influxdb.OrganizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+			return make([]*influxdb.Organization, 2), 2, nil
+		},
+	}
+	// The buckets span two pages.
+	buckets := mock.NewBucketService()
+	buckets.FindBucketsFn = func(ctx context.Context, filter influxdb.BucketFilter, opt ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+		n := influxdb.MaxPageSize
+		if opt[0].Offset > 0 {
+			n = 3
+		}
+		return make([]*influxdb.Bucket, n), n, nil
+	}
+	users := mock.NewUserService()
+	users.FindUsersFn = func(ctx context.Context, filter influxdb.UserFilter, opt ...influxdb.FindOptions) ([]*influxdb.User, int, error) {
+		return make([]*influxdb.User, 4), 4, nil
+	}
+	tasks := mock.NewTaskService()
+	tasks.FindTasksFn = func(ctx context.Context, filter influxdb.TaskFilter) ([]*influxdb.Task, int, error) {
+		if filter.After != nil {
+			return []*influxdb.Task{{ID: 1}}, 1, nil
+		}
+		page := make([]*influxdb.Task, influxdb.TaskMaxPageSize)
+		for i := range page {
+			page[i] = &influxdb.Task{ID: influxdb.ID(i + 2)}
+		}
+		return page, len(page), nil
+	}
+	scrapers := &mock.ScraperTargetStoreService{
+		ListTargetsF: func(ctx context.Context, filter influxdb.ScraperTargetFilter) ([]influxdb.ScraperTarget, error) {
+			return make([]influxdb.ScraperTarget, 5), nil
+		},
+	}
+
+	registry := maintenance.NewRegistry()
+	registry.Register("retention").Schedule(true, time.Hour, now.Add(time.Minute))
+
+	reg := prometheus.NewRegistry()
+	queries := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "query",
+		Subsystem: "control",
+		Name:      "requests_total",
+	}, []string{"result"})
+	reg.MustRegister(queries)
+
+	engine := &fakeEngine{
+		disk:    influxdb.DiskUsage{TSM: 100, WAL: 10, Index: 1, Total: 111},
+		seriesN: 42,
+	}
+	s := NewService(zaptest.NewLogger(t), Config{
+		Engine:                    engine,
+		OrganizationService:       orgs,
+		BucketService:             buckets,
+		UserService:               users,
+		TaskService:               tasks,
+		ScraperTargetStoreService: scrapers,
+		MaintenanceService:        registry,
+		Gatherer:                  reg,
+		KVPath:                    kvPath,
+		StartedAt:                 startedAt,
+	})
+	s.now = func() time.Time { return now }
+
+	summary, err := s.ServerSummary(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, summary.StatsUpdatedAt, "the statistics are not computed until refreshed")
+	assert.Equal(t, time.Hour, summary.Uptime.Duration)
+
+	queries.WithLabelValues("success").Add(10)
+	s.Refresh(ctx)
+	now = now.Add(time.Minute)
+	queries.WithLabelValues("success").Add(50)
+	queries.WithLabelValues("runtime_error").Add(10)
+	s.Refresh(ctx)
+
+	summary, err = s.ServerSummary(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, summary.StatsUpdatedAt)
+	assert.Equal(t, now, *summary.StatsUpdatedAt)
+	assert.Equal(t, startedAt, summary.StartedAt)
+	assert.Equal(t, time.Hour+time.Minute, summary.Uptime.Duration)
+	assert.Equal(t, influxdb.ResourceCounts{
+		Orgs:     2,
+		Buckets:  influxdb.MaxPageSize + 3,
+		Users:    4,
+		Tasks:    influxdb.TaskMaxPageSize + 1,
+		Scrapers: 5,
+	}, summary.Counts)
+	assert.Equal(t, influxdb.DiskUsage{TSM: 100, WAL: 10, Index: 1, KV: 1000, Total: 1111}, summary.Disk)
+	assert.Equal(t, int64(42), summary.SeriesN)
+	assert.Equal(t, influxdb.ServerRates{
+		Range:                  influxdb.Timespan{Start: now.Add(-time.Minute), Stop: now},
+		WriteRequestsPerSecond: 1,
+		PointsWrittenPerSecond: 10,
+		QueriesPerSecond:       1,
+	}, summary.Rates)
+	require.Len(t, summary.Services, 1)
+	assert.Equal(t, "retention", summary.Services[0].Name)
+
+	t.Run("keeps the statistics failing to refresh", func(t *testing.T) {
+		engine.err = errors.New("engine is closed")
+		now = now.Add(time.Minute)
+		s.Refresh(ctx)
+
+		summary, err := s.ServerSummary(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, now, *summary.StatsUpdatedAt)
+		assert.Equal(t, int64(1111), summary.Disk.Total)
+		assert.Equal(t, int64(42), summary.SeriesN)
+	})
+}
+
+func TestService_QueryRate(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewService(zaptest.NewLogger(t), Config{})
+
+	// Samples every minute, with 60 queries a minute.
+	for i := 0; i <= 10; i++ {
+		s.addQuerySample(querySample{time: start.Add(time.Duration(i) * time.Minute), n: float64(60 * i)})
+	}
+	// Only the samples needed for the rate over RateRange are kept.
+	assert.Len(t, s.queries, 6)
+	assert.Equal(t, 1.0, s.queryRate(start.Add(5*time.Minute)))
+
+	// A counter reset, as when a collector is registered again, has no rate.
+	s.addQuerySample(querySample{time: start.Add(11 * time.Minute), n: 0})
+	assert.Zero(t, s.queryRate(start.Add(6*time.Minute)))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -42,6 +43,7 @@ type Task struct {
 	Flux            string                 `json:"flux"`
 	Every           string                 `json:"every,omitempty"`
 	Cron            string                 `json:"cron,omitempty"`
+	Timezone        string                 `json:"tz,omitempty"`
 	Offset          time.Duration          `json:"offset,omitempty"`
 	LatestCompleted time.Time              `json:"latestCompleted,omitempty"`
 	LatestScheduled time.Time              `json:"latestScheduled,omitempty"`
@@ -186,6 +188,9 @@ func (t *TaskUpdate) UnmarshalJSON(data []byte) error {
 		// Cron is a cron style time schedule that can be used in place of Every.
 		Cron string `json:"cron,omitempty"`
 
+		// Timezone is the IANA name of the time zone Cron is evaluated in.
+		Timezone string `json:"tz,omitempty"`
+
 		// Every represents a fixed period to repeat execution.
 		// It gets marshalled from a string duration, i.e.: "10s" is 10 seconds
 		Every options.Duration `json:"every,omitempty"`
@@ -205,6 +210,7 @@ func (t *TaskUpdate) UnmarshalJSON(data []byte) error {
 	t.Options.Name = jo.Name
 	t.Description = jo.Description
 	t.Options.Cron = jo.Cron
+	t.Options.Timezone = jo.Timezone
 	t.Options.Every = jo.Every
 	if jo.Offset != nil {
 		offset := *jo.Offset
@@ -227,6 +233,9 @@ func (t *TaskUpdate) MarshalJSON() ([]byte, error) {
 		// Cron is a cron style time schedule that can be used in place of Every.
 		Cron string `json:"cron,omitempty"`
 
+		// Timezone is the IANA name of the time zone Cron is evaluated in.
+		Timezone string `json:"tz,omitempty"`
+
 		// Every represents a fixed period to repeat execution.
 		Every options.Duration `json:"every,omitempty"`
 
@@ -239,6 +248,7 @@ func (t *TaskUpdate) MarshalJSON() ([]byte, error) {
 	}{}
 	jo.Name = t.Options.Name
 	jo.Cron = t.Options.Cron
+	jo.Timezone = t.Options.Timezone
 	jo.Every = t.Options.Every
 	jo.Description = t.Description
 	if t.Options.Offset != nil {
@@ -253,6 +263,11 @@ func (t *TaskUpdate) MarshalJSON() ([]byte, error) {
 }
 
 func (t *TaskUpdate) Validate() error {
+	if t.Options.Timezone != "" {
+		if err := options.ValidateTimezone(t.Options.Timezone); err != nil {
+			return fmt.Errorf("tz: %s is invalid: %s", t.Options.Timezone, err)
+		}
+	}
 	switch {
 	case !t.Options.Every.IsZero() && t.Options.Cron != "":
 		return errors.New("cannot specify both every and cron")
@@ -328,6 +343,12 @@ func (t *TaskUpdate) updateFlux(parser FluxLanguageService, oldFlux string) erro
 	if t.Options.Cron != "" {
 		op["cron"] = &ast.StringLiteral{Value: t.Options.Cron}
 	}
+	if t.Options.Timezone != "" {
+		op["tz"] = &ast.StringLiteral{Value: t.Options.Timezone}
+	} else if !t.Options.Every.IsZero() {
+		// the time zone of a cron does not apply to every
+		toDelete["tz"] = struct{}{}
+	}
 	if t.Options.Offset != nil {
 		if !t.Options.Offset.IsZero() {
 			op["offset"] = &t.Options.Offset.Node
@@ -357,6 +378,11 @@ func (t *TaskUpdate) updateFlux(parser FluxLanguageService, oldFlux string) erro
 						delete(op, "name")
 						p.Value = name
 					}
+				case "tz":
+					if tz, ok := op["tz"]; ok {
+						delete(op, "tz")
+						p.Value = tz
+					}
 				case "offset":
 					if offset, ok := op["offset"]; ok && t.Options.Offset != nil {
 						delete(op, "offset")
@@ -437,6 +463,7 @@ func (t *TaskUpdate) updateFluxAST(parser FluxLanguageService, oldFlux string) e
 	if !t.Options.Every.IsZero() {
 		edit.SetProperty(optsExpr, "every", t.Options.Every.Node.Copy().(*ast.DurationLiteral))
 		edit.DeleteProperty(optsExpr, "cron")
+		edit.DeleteProperty(optsExpr, "tz")
 	}
 	if t.Options.Cron != "" {
 		edit.SetProperty(optsExpr, "cron", &ast.StringLiteral{
@@ -444,6 +471,11 @@ func (t *TaskUpdate) updateFluxAST(parser FluxLanguageService, oldFlux string) e
 		})
 		edit.DeleteProperty(optsExpr, "every")
 	}
+	if t.Options.Timezone != "" {
+		edit.SetProperty(optsExpr, "tz", &ast.StringLiteral{
+			Value: t.Options.Timezone,
+		})
+	}
 	if t.Options.Offset != nil {
 		if !t.Options.Offset.IsZero() {
 			edit.SetProperty(optsExpr, "offset", t.Options.Offset.Node.Copy().(*ast.DurationLiteral))
@@ -515,6 +547,22 @@ type LogFilter struct {
 
 	// The optional Run ID limits logs to a single run.
 	Run *ID
+
+	// The optional Since limits logs to those written at or after it.
+	Since time.Time
+}
+
+// LogsSince returns the logs of a run written at or after since. The logs of
+// a run are appended in time order, so they are searched rather than scanned.
+func LogsSince(logs []Log, since time.Time) []Log {
+	if since.IsZero() {
+		return logs
+	}
+	i := sort.Search(len(logs), func(i int) bool {
+		t, err := time.Parse(time.RFC3339Nano, logs[i].Time)
+		return err == nil && !t.Before(since)
+	})
+	return logs[i:]
 }
 
 type TaskStatus string
@@ -552,6 +600,16 @@ func (r RunStatus) String() string {
 	panic(fmt.Sprintf("unknown RunStatus: %d", r))
 }
 
+// RunFinished reports whether a run with the given status will not change
+// anymore, and so will not write further logs.
+func RunFinished(status string) bool {
+	switch status {
+	case RunSuccess.String(), RunFail.String(), RunCanceled.String():
+		return true
+	}
+	return false
+}
+
 // RequestStillQueuedError is returned when attempting to retry a run which has not yet completed.
 type RequestStillQueuedError struct {
 	// Unix timestamps matching existing request's start and end.
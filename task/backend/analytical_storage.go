@@ -96,8 +96,9 @@ func (as *AnalyticalStorage) FindLogs(ctx context.Context, filter influxdb.LogFi
 		if err != nil {
 			return nil, 0, err
 		}
-		for i := 0; i < len(run.Log); i++ {
-			logs = append(logs, &run.Log[i])
+		ls := influxdb.LogsSince(run.Log, filter.Since)
+		for i := 0; i < len(ls); i++ {
+			logs = append(logs, &ls[i])
 		}
 		return logs, len(logs), nil
 	}
@@ -109,8 +110,9 @@ func (as *AnalyticalStorage) FindLogs(ctx context.Context, filter influxdb.LogFi
 	}
 
 	for _, run := range runs {
-		for i := 0; i < len(run.Log); i++ {
-			logs = append(logs, &run.Log[i])
+		ls := influxdb.LogsSince(run.Log, filter.Since)
+		for i := 0; i < len(ls); i++ {
+			logs = append(logs, &ls[i])
 		}
 	}
 
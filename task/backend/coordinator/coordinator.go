@@ -83,7 +83,7 @@ func NewSchedulableTask(task *influxdb.Task) (SchedulableTask, error) {
 
 	var sch scheduler.Schedule
 	var err error
-	sch, ts, err = scheduler.NewSchedule(effCron, ts)
+	sch, ts, err = scheduler.NewScheduleIn(effCron, task.Timezone, ts)
 	if err != nil {
 		return SchedulableTask{}, err
 	}
@@ -492,6 +492,11 @@ func (w *worker) executeQuery(p *promise) {
 	w.start(p)
 
 	ctx = icontext.SetAuthorizer(ctx, p.auth)
+	ctx = icontext.SetTaskRun(ctx, icontext.TaskRun{
+		OrgID:  p.task.OrganizationID,
+		TaskID: p.task.ID,
+		RunID:  p.run.ID,
+	})
 
 	buildCompiler := w.systemBuildCompiler
 	if p.task.Type != influxdb.TaskSystemType {
@@ -68,31 +68,104 @@ func NewSchedule(unparsed string, lastScheduledAt time.Time) (Schedule, time.Tim
 		err := every.Parse(everyString)
 		if err != nil {
 			// We cannot align a invalid time
-			return Schedule{c}, lastScheduledAt, nil
+			return Schedule{cron: c}, lastScheduledAt, nil
 		}
 
 		// drop nanoseconds
 		lastScheduledAt = time.Unix(lastScheduledAt.UTC().Unix(), 0).UTC()
 		everyDur, err := every.DurationFrom(lastScheduledAt)
 		if err != nil {
-			return Schedule{c}, lastScheduledAt, nil
+			return Schedule{cron: c}, lastScheduledAt, nil
 		}
 
 		// and align
 		lastScheduledAt = lastScheduledAt.Truncate(everyDur).Truncate(time.Second)
 	}
 
-	return Schedule{c}, lastScheduledAt, err
+	return Schedule{cron: c}, lastScheduledAt, err
+}
+
+// NewScheduleIn is like NewSchedule, but evaluates the cron string in the time
+// zone with the IANA name tz rather than in UTC. Wall clock times skipped by a
+// daylight saving transition are skipped by the schedule, and those repeated by
+// one trigger it once, at their first occurrence. An empty tz is UTC, and tz
+// does not affect @every schedules.
+func NewScheduleIn(unparsed, tz string, lastScheduledAt time.Time) (Schedule, time.Time, error) {
+	sch, lastScheduledAt, err := NewSchedule(unparsed, lastScheduledAt)
+	if err != nil || tz == "" || strings.HasPrefix(strings.TrimSpace(unparsed), "@every ") {
+		return sch, lastScheduledAt, err
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return Schedule{}, lastScheduledAt, err
+	}
+	sch.loc = loc
+	return sch, lastScheduledAt, nil
 }
 
 // Schedule is an object a valid schedule of runs
 type Schedule struct {
 	cron cron.Parsed
+	// loc is the time zone cron is evaluated in, UTC if nil.
+	loc *time.Location
 }
 
 // Next returns the next time after from that a schedule should trigger on.
 func (s Schedule) Next(from time.Time) (time.Time, error) {
-	return cron.Parsed(s.cron).Next(from)
+	if s.loc == nil {
+		return cron.Parsed(s.cron).Next(from)
+	}
+
+	// The cron is evaluated on wall clock times held in UTC, so that it is not
+	// shifted by daylight saving transitions, and its times are then resolved
+	// to instants in the time zone.
+	wall := wallClock(from, s.loc)
+	for {
+		next, err := cron.Parsed(s.cron).Next(wall)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, t := range instantsOf(next, s.loc) {
+			if t.After(from) {
+				return t.UTC(), nil
+			}
+		}
+		wall = next
+	}
+}
+
+// wallClock returns the wall clock time of t in loc, held in UTC.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	h, min, sec := t.Clock()
+	return time.Date(y, m, d, h, min, sec, t.Nanosecond(), time.UTC)
+}
+
+// instantsOf returns the instants, in order, at which the wall clock in loc
+// shows the wall clock time wall held in UTC. There are none for a time skipped
+// by a daylight saving transition, and two for a time repeated by one.
+func instantsOf(wall time.Time, loc *time.Location) []time.Time {
+	var ts []time.Time
+	// Transitions are months apart, so the offsets a day either side are
+	// the only ones in effect at wall.
+	for _, probe := range []time.Time{wall.Add(-24 * time.Hour), wall.Add(24 * time.Hour)} {
+		_, offset := probe.In(loc).Zone()
+		t := wall.Add(-time.Duration(offset) * time.Second)
+		if !wallClock(t, loc).Equal(wall) {
+			continue
+		}
+		switch {
+		case len(ts) == 0:
+			ts = append(ts, t)
+		case t.Before(ts[0]):
+			ts = append([]time.Time{t}, ts...)
+		case t.After(ts[0]):
+			ts = append(ts, t)
+		}
+	}
+	return ts
 }
 
 // ValidSchedule returns an error if the cron string is invalid.
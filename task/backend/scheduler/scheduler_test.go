@@ -666,3 +666,92 @@ func TestNewSchedule(t *testing.T) {
 		})
 	}
 }
+
+func TestNewScheduleIn(t *testing.T) {
+	utc := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	tests := []struct {
+		name     string
+		unparsed string
+		tz       string
+		from     time.Time
+		want     []time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "without a time zone the cron is in UTC",
+			unparsed: "30 2 * * *",
+			from:     utc("2021-03-27T12:00:00Z"),
+			want:     []time.Time{utc("2021-03-28T02:30:00Z"), utc("2021-03-29T02:30:00Z")},
+		},
+		{
+			name:     "seconds",
+			unparsed: "*/15 * * * * *",
+			tz:       "Europe/Berlin",
+			from:     utc("2021-01-01T00:00:00Z"),
+			want:     []time.Time{utc("2021-01-01T00:00:15Z"), utc("2021-01-01T00:00:30Z"), utc("2021-01-01T00:00:45Z")},
+		},
+		{
+			name:     "times skipped by daylight saving are skipped",
+			unparsed: "30 2 * * *",
+			tz:       "Europe/Berlin",
+			from:     utc("2021-03-26T12:00:00Z"),
+			want:     []time.Time{utc("2021-03-27T01:30:00Z"), utc("2021-03-29T00:30:00Z"), utc("2021-03-30T00:30:00Z")},
+		},
+		{
+			name:     "times repeated by daylight saving run once",
+			unparsed: "30 2 * * *",
+			tz:       "Europe/Berlin",
+			from:     utc("2021-10-30T12:00:00Z"),
+			want:     []time.Time{utc("2021-10-31T00:30:00Z"), utc("2021-11-01T01:30:00Z")},
+		},
+		{
+			name:     "hours repeated by daylight saving run once",
+			unparsed: "0 * * * *",
+			tz:       "Europe/Berlin",
+			from:     utc("2021-10-30T23:30:00Z"),
+			want:     []time.Time{utc("2021-10-31T00:00:00Z"), utc("2021-10-31T02:00:00Z"), utc("2021-10-31T03:00:00Z")},
+		},
+		{
+			name:     "every is unaffected by the time zone",
+			unparsed: "@every 1h",
+			tz:       "Europe/Berlin",
+			from:     utc("2021-10-31T00:00:00Z"),
+			want:     []time.Time{utc("2021-10-31T01:00:00Z"), utc("2021-10-31T02:00:00Z")},
+		},
+		{
+			name:     "unknown time zone",
+			unparsed: "30 2 * * *",
+			tz:       "Mars/Olympus_Mons",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sch, ts, err := NewScheduleIn(tt.unparsed, tt.tz, tt.from)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewScheduleIn() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var got []time.Time
+			for range tt.want {
+				if ts, err = sch.Next(ts); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, ts)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Next() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
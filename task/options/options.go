@@ -27,8 +27,13 @@ type Options struct {
 	Name string `json:"name,omitempty"`
 
 	// Cron is a cron style time schedule that can be used in place of Every.
+	// It may have a leading seconds field.
 	Cron string `json:"cron,omitempty"`
 
+	// Timezone is the IANA name of the time zone Cron is evaluated in.
+	// Cron is evaluated in UTC if it is empty.
+	Timezone string `json:"tz,omitempty"`
+
 	// Every represents a fixed period to repeat execution.
 	// this can be unmarshaled from json as a string i.e.: "1d" will unmarshal as 1 day
 	Every Duration `json:"every,omitempty"`
@@ -114,6 +119,7 @@ func (a *Duration) Add(t time.Time) (time.Time, error) {
 func (o *Options) Clear() {
 	o.Name = ""
 	o.Cron = ""
+	o.Timezone = ""
 	o.Every = Duration{}
 	o.Offset = nil
 	o.Concurrency = nil
@@ -124,6 +130,7 @@ func (o *Options) Clear() {
 func (o *Options) IsZero() bool {
 	return o.Name == "" &&
 		o.Cron == "" &&
+		o.Timezone == "" &&
 		o.Every.IsZero() &&
 		(o.Offset == nil || o.Offset.IsZero()) &&
 		o.Concurrency == nil &&
@@ -134,6 +141,7 @@ func (o *Options) IsZero() bool {
 const (
 	optName        = "name"
 	optCron        = "cron"
+	optTimezone    = "tz"
 	optEvery       = "every"
 	optOffset      = "offset"
 	optConcurrency = "concurrency"
@@ -278,6 +286,7 @@ type extractFn func(*Options, *ast.ObjectExpression) error
 var taskOptionExtractors = []extractFn{
 	extractNameOption,
 	extractScheduleOptions,
+	extractTimezoneOption,
 	extractOffsetOption,
 	extractConcurrencyOption,
 	extractRetryOption,
@@ -326,6 +335,21 @@ func extractScheduleOptions(opts *Options, objExpr *ast.ObjectExpression) error
 	return nil
 }
 
+func extractTimezoneOption(opts *Options, objExpr *ast.ObjectExpression) error {
+	tzExpr, err := edit.GetProperty(objExpr, optTimezone)
+	if err != nil {
+		return nil
+	}
+
+	tzStr, ok := tzExpr.(*ast.StringLiteral)
+	if !ok {
+		return errParseTaskOptionField(optTimezone)
+	}
+	opts.Timezone = ast.StringFromLiteral(tzStr)
+
+	return nil
+}
+
 func extractOffsetOption(opts *Options, objExpr *ast.ObjectExpression) error {
 	offsetExpr, offsetErr := edit.GetProperty(objExpr, optOffset)
 	if offsetErr != nil {
@@ -436,6 +460,13 @@ func FromScript(lang FluxLanguageService, script string) (Options, error) {
 		opt.Cron = crVal.Str()
 	}
 
+	if tzVal, ok := optObject.Get(optTimezone); ok {
+		if err := checkNature(tzVal.Type().Nature(), semantic.String); err != nil {
+			return opt, err
+		}
+		opt.Timezone = tzVal.Str()
+	}
+
 	if everyOK {
 		if err := checkNature(everyVal.Type().Nature(), semantic.Duration); err != nil {
 			return opt, err
@@ -516,7 +547,15 @@ func (o *Options) Validate() error {
 		if err != nil {
 			errs = append(errs, "cron invalid: "+err.Error())
 		}
+		if o.Timezone != "" {
+			if err := ValidateTimezone(o.Timezone); err != nil {
+				errs = append(errs, "tz invalid: "+err.Error())
+			}
+		}
 	} else if everyPresent {
+		if o.Timezone != "" {
+			errs = append(errs, "tz option requires cron")
+		}
 		every, err := o.Every.DurationFrom(now)
 		if err != nil {
 			return err
@@ -559,6 +598,16 @@ func (o *Options) Validate() error {
 	return fmt.Errorf("invalid options: %s", strings.Join(errs, ", "))
 }
 
+// ValidateTimezone returns an error if tz is not the IANA name of a time zone
+// known to the host.
+func ValidateTimezone(tz string) error {
+	if tz == "Local" {
+		return errors.New(`"Local" is not an IANA time zone name`)
+	}
+	_, err := time.LoadLocation(tz)
+	return err
+}
+
 // EffectiveCronString returns the effective cron string of the options.
 // If the cron option was specified, it is returned.
 // If the every option was specified, it is converted into a cron string using "@every".
@@ -592,7 +641,7 @@ func validateOptionNames(o values.Object) error {
 	var unexpected []string
 	o.Range(func(name string, _ values.Value) {
 		switch name {
-		case optName, optCron, optEvery, optOffset, optConcurrency, optRetry:
+		case optName, optCron, optTimezone, optEvery, optOffset, optConcurrency, optRetry:
 			// Known option. Nothing to do.
 		default:
 			unexpected = append(unexpected, name)
@@ -601,7 +650,7 @@ func validateOptionNames(o values.Object) error {
 
 	if len(unexpected) > 0 {
 		u := strings.Join(unexpected, ", ")
-		v := strings.Join([]string{optName, optCron, optEvery, optOffset, optConcurrency, optRetry}, ", ")
+		v := strings.Join([]string{optName, optCron, optTimezone, optEvery, optOffset, optConcurrency, optRetry}, ", ")
 		return fmt.Errorf("unknown task option(s): %s. valid options are %s", u, v)
 	}
 
@@ -24,6 +24,9 @@ func scriptGenerator(opt options.Options, body string) string {
 	if opt.Cron != "" {
 		taskData = fmt.Sprintf("%s  cron: %q,\n", taskData, opt.Cron)
 	}
+	if opt.Timezone != "" {
+		taskData = fmt.Sprintf("%s  tz: %q,\n", taskData, opt.Timezone)
+	}
 	if !opt.Every.IsZero() {
 		taskData = fmt.Sprintf("%s  every: %s,\n", taskData, opt.Every.String())
 	}
@@ -74,6 +77,9 @@ func TestFromScriptAST(t *testing.T) {
 		{script: scriptGenerator(options.Options{Name: "name1", Every: *(options.MustParseDuration("5s"))}, ""), exp: options.Options{Name: "name1", Every: *(options.MustParseDuration("5s")), Concurrency: pointer.Int64(1), Retry: pointer.Int64(1)}},
 		{script: scriptGenerator(options.Options{Name: "name2", Cron: "* * * * *"}, ""), exp: options.Options{Name: "name2", Cron: "* * * * *", Concurrency: pointer.Int64(1), Retry: pointer.Int64(1)}},
 		{script: scriptGenerator(options.Options{Name: "name3", Every: *(options.MustParseDuration("1h")), Cron: "* * * * *"}, ""), shouldErr: true},
+		{script: scriptGenerator(options.Options{Name: "name2a", Cron: "*/15 30 2 * * *", Timezone: "Europe/Berlin"}, ""), exp: options.Options{Name: "name2a", Cron: "*/15 30 2 * * *", Timezone: "Europe/Berlin", Concurrency: pointer.Int64(1), Retry: pointer.Int64(1)}},
+		{script: scriptGenerator(options.Options{Name: "name2b", Cron: "* * * * *", Timezone: "Europe/Nowhere"}, ""), shouldErr: true},
+		{script: scriptGenerator(options.Options{Name: "name2c", Every: *(options.MustParseDuration("1h")), Timezone: "Europe/Berlin"}, ""), shouldErr: true},
 		{script: scriptGenerator(options.Options{Name: "name4", Concurrency: pointer.Int64(1000), Every: *(options.MustParseDuration("1h"))}, ""), shouldErr: true},
 		{script: "option task = {\n  name: \"name5\",\n  concurrency: 0,\n  every: 1m0s,\n\n}\n\nfrom(bucket: \"test\")\n    |> range(start:-1h)", shouldErr: true},
 		{script: "option task = {\n  name: \"name6\",\n  concurrency: 1,\n  every: 1,\n\n}\n\nfrom(bucket: \"test\")\n    |> range(start:-1h)", shouldErr: true},
@@ -140,6 +146,9 @@ func TestFromScript(t *testing.T) {
 		{script: scriptGenerator(options.Options{Name: "name1", Every: *(options.MustParseDuration("5s"))}, ""), exp: options.Options{Name: "name1", Every: *(options.MustParseDuration("5s")), Concurrency: pointer.Int64(1), Retry: pointer.Int64(1)}},
 		{script: scriptGenerator(options.Options{Name: "name2", Cron: "* * * * *"}, ""), exp: options.Options{Name: "name2", Cron: "* * * * *", Concurrency: pointer.Int64(1), Retry: pointer.Int64(1)}},
 		{script: scriptGenerator(options.Options{Name: "name3", Every: *(options.MustParseDuration("1h")), Cron: "* * * * *"}, ""), shouldErr: true},
+		{script: scriptGenerator(options.Options{Name: "name2a", Cron: "*/15 30 2 * * *", Timezone: "Europe/Berlin"}, ""), exp: options.Options{Name: "name2a", Cron: "*/15 30 2 * * *", Timezone: "Europe/Berlin", Concurrency: pointer.Int64(1), Retry: pointer.Int64(1)}},
+		{script: scriptGenerator(options.Options{Name: "name2b", Cron: "* * * * *", Timezone: "Europe/Nowhere"}, ""), shouldErr: true},
+		{script: scriptGenerator(options.Options{Name: "name2c", Every: *(options.MustParseDuration("1h")), Timezone: "Europe/Berlin"}, ""), shouldErr: true},
 		{script: scriptGenerator(options.Options{Name: "name4", Concurrency: pointer.Int64(1000), Every: *(options.MustParseDuration("1h"))}, ""), shouldErr: true},
 		{script: "option task = {\n  name: \"name5\",\n  concurrency: 0,\n  every: 1m0s,\n\n}\n\nfrom(bucket: \"test\")\n    |> range(start:-1h)", shouldErr: true},
 		{script: "option task = {\n  name: \"name6\",\n  concurrency: 1,\n  every: 1,\n\n}\n\nfrom(bucket: \"test\")\n    |> range(start:-1h)", shouldErr: true},
@@ -215,7 +224,7 @@ func TestFromScriptWithUnknownOptions(t *testing.T) {
 		t.Errorf("expected error to mention unrecognized options, but it said: %v", err)
 	}
 
-	validOpts := []string{"name", "cron", "every", "offset", "concurrency", "retry"}
+	validOpts := []string{"name", "cron", "tz", "every", "offset", "concurrency", "retry"}
 	for _, o := range validOpts {
 		if !strings.Contains(msg, o) {
 			t.Errorf("expected error to mention valid option %q but it said: %v", o, err)
@@ -254,6 +263,20 @@ func TestValidate(t *testing.T) {
 		t.Error("expected error for options with invalid cron")
 	}
 
+	*bad = good
+	bad.Timezone = "Local"
+	if err := bad.Validate(); err == nil {
+		t.Error("expected error for options with the Local time zone")
+	}
+
+	*bad = good
+	bad.Cron = ""
+	bad.Every = *options.MustParseDuration("1m")
+	bad.Timezone = "Europe/Berlin"
+	if err := bad.Validate(); err == nil {
+		t.Error("expected error for options with every and a time zone")
+	}
+
 	*bad = good
 	bad.Cron = ""
 	bad.Every = *options.MustParseDuration("-1m")
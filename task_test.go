@@ -32,6 +32,24 @@ func TestUpdateValidate(t *testing.T) {
 
 }
 
+func TestUpdateValidateTimezone(t *testing.T) {
+	tu := &platform.TaskUpdate{}
+	if err := json.Unmarshal([]byte(`{"cron":"0 30 2 * * *", "tz":"Europe/Berlin"}`), tu); err != nil {
+		t.Fatal(err)
+	}
+	if tu.Options.Timezone != "Europe/Berlin" {
+		t.Fatalf("option.tz not properly unmarshaled, expected Europe/Berlin got %s", tu.Options.Timezone)
+	}
+	if err := tu.Validate(); err != nil {
+		t.Fatalf("expected task update to be valid but it was not: %s", err)
+	}
+
+	tu.Options.Timezone = "Europe/Nowhere"
+	if err := tu.Validate(); err == nil {
+		t.Fatal("expected task update with an unknown time zone to be invalid")
+	}
+}
+
 func TestOptionsMarshal(t *testing.T) {
 	tu := &platform.TaskUpdate{}
 	// this is to make sure that string durations are properly marshaled into durations
@@ -150,6 +168,35 @@ from(bucket: "x")
 			t.Fatalf("expected Cron to be \"\" but was %s", op.Cron)
 		}
 	})
+	t.Run("setting the time zone", func(t *testing.T) {
+		tu := &platform.TaskUpdate{}
+		tu.Options.Cron = "0 30 2 * * *"
+		tu.Options.Timezone = "Europe/Berlin"
+		if err := tu.UpdateFlux(ctx, fluxlang.DefaultService, `option task = {every: 20s, name: "foo"} from(bucket:"x") |> range(start:-1h)`); err != nil {
+			t.Fatal(err)
+		}
+		op, err := options.FromScript(fluxlang.DefaultService, *tu.Flux)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op.Cron != "0 30 2 * * *" || op.Timezone != "Europe/Berlin" {
+			t.Fatalf("expected Cron \"0 30 2 * * *\" in Europe/Berlin but was %q in %q", op.Cron, op.Timezone)
+		}
+	})
+	t.Run("switching from cron with a time zone to every", func(t *testing.T) {
+		tu := &platform.TaskUpdate{}
+		tu.Options.Every = *(options.MustParseDuration("10s"))
+		if err := tu.UpdateFlux(ctx, fluxlang.DefaultService, `option task = {cron: "* * * * *", name: "foo", tz: "Europe/Berlin"} from(bucket:"x") |> range(start:-1h)`); err != nil {
+			t.Fatal(err)
+		}
+		op, err := options.FromScript(fluxlang.DefaultService, *tu.Flux)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op.Timezone != "" {
+			t.Fatalf("expected Timezone to be \"\" but was %s", op.Timezone)
+		}
+	})
 	t.Run("delete deletable option", func(t *testing.T) {
 		tu := &platform.TaskUpdate{}
 		tu.Options.Offset = &options.Duration{}
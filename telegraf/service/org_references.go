@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/telegraf"
+)
+
+// RewriteOrgName replaces the organization name set in the influxdb_v2 outputs
+// of each telegraf config of org, from oldName to its current name. It is run
+// within the transaction renaming org, and returns the configs it rewrote.
+func RewriteOrgName(ctx context.Context, tx kv.Tx, org *influxdb.Organization, oldName string) ([]influxdb.OrganizationReference, error) {
+	orgID, err := org.ID.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	// only rewrite settings naming the organization, such as
+	// organization = "old", keeping their indentation and comments
+	setting := regexp.MustCompile(`(?m)^(\s*organization\s*=\s*)` + regexp.QuoteMeta(strconv.Quote(oldName)))
+	replacement := "${1}" + strings.ReplaceAll(strconv.Quote(org.Name), "$", "$$")
+
+	// collect the configs before writing, as writes invalidate the cursor
+	var tcs []*influxdb.TelegrafConfig
+	index := kv.NewIndex(telegraf.ByOrganizationIndexMapping, kv.WithIndexReadPathEnabled)
+	err = index.Walk(ctx, tx, orgID, func(k, v []byte) (bool, error) {
+		tc, err := unmarshalTelegraf(v)
+		if err != nil {
+			return false, err
+		}
+		if setting.MatchString(tc.Config) {
+			tcs = append(tcs, tc)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []influxdb.OrganizationReference
+	for _, tc := range tcs {
+		tc.Config = setting.ReplaceAllString(tc.Config, replacement)
+
+		encodedID, err := tc.ID.Encode()
+		if err != nil {
+			return nil, ErrInvalidTelegrafID
+		}
+		v, err := marshalTelegraf(tc)
+		if err != nil {
+			return nil, err
+		}
+		bucket, err := tx.Bucket(telegrafBucket)
+		if err != nil {
+			return nil, UnavailableTelegrafServiceError(err)
+		}
+		if err := bucket.Put(encodedID, v); err != nil {
+			return nil, UnavailableTelegrafServiceError(err)
+		}
+
+		refs = append(refs, influxdb.OrganizationReference{
+			ResourceType: influxdb.TelegrafsResourceType,
+			ID:           tc.ID,
+			Name:         tc.Name,
+		})
+	}
+	return refs, nil
+}
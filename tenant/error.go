@@ -30,8 +30,9 @@ var (
 
 	// ErrOnboardingNotAllowed occurs when request to onboard comes in and we are not allowing this request
 	ErrOnboardingNotAllowed = &influxdb.Error{
-		Code: influxdb.EConflict,
-		Msg:  "onboarding has already been completed",
+		Code:  influxdb.EConflict,
+		Msg:   "onboarding has already been completed",
+		ErrID: influxdb.ErrIDOnboardingCompleted,
 	}
 
 	ErrOnboardInvalid = &influxdb.Error{
@@ -14,31 +14,36 @@ var (
 	}
 
 	errRenameSystemBucket = &influxdb.Error{
-		Code: influxdb.EInvalid,
-		Msg:  "system buckets cannot be renamed",
+		Code:  influxdb.EInvalid,
+		Msg:   "system buckets cannot be renamed",
+		ErrID: influxdb.ErrIDBucketUpdateSystemName,
 	}
 
 	errDeleteSystemBucket = &influxdb.Error{
-		Code: influxdb.EInvalid,
-		Msg:  "system buckets cannot be deleted",
+		Code:  influxdb.EInvalid,
+		Msg:   "system buckets cannot be deleted",
+		ErrID: influxdb.ErrIDBucketDeleteSystem,
 	}
 
 	ErrBucketNotFound = &influxdb.Error{
-		Code: influxdb.ENotFound,
-		Msg:  "bucket not found",
+		Code:  influxdb.ENotFound,
+		Msg:   "bucket not found",
+		ErrID: influxdb.ErrIDBucketNotFound,
 	}
 
 	ErrBucketNameNotUnique = &influxdb.Error{
-		Code: influxdb.EConflict,
-		Msg:  "bucket name is not unique",
+		Code:  influxdb.EConflict,
+		Msg:   "bucket name is not unique",
+		ErrID: influxdb.ErrIDBucketUpdateNameConflict,
 	}
 )
 
 // ErrBucketNotFoundByName is used when the user is not found.
 func ErrBucketNotFoundByName(n string) *influxdb.Error {
 	return &influxdb.Error{
-		Msg:  fmt.Sprintf("bucket %q not found", n),
-		Code: influxdb.ENotFound,
+		Msg:   fmt.Sprintf("bucket %q not found", n),
+		Code:  influxdb.ENotFound,
+		ErrID: influxdb.ErrIDBucketNotFound,
 	}
 }
 
@@ -57,8 +62,9 @@ func ErrCorruptBucket(err error) *influxdb.Error {
 // that already exists.
 func BucketAlreadyExistsError(n string) *influxdb.Error {
 	return &influxdb.Error{
-		Code: influxdb.EConflict,
-		Msg:  fmt.Sprintf("bucket with name %s already exists", n),
+		Code:  influxdb.EConflict,
+		Msg:   fmt.Sprintf("bucket with name %s already exists", n),
+		ErrID: influxdb.ErrIDBucketCreateNameConflict,
 	}
 }
 
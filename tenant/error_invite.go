@@ -0,0 +1,68 @@
+package tenant
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+var (
+	// ErrInviteNotFound is used when the invitation is not found.
+	ErrInviteNotFound = &influxdb.Error{
+		Msg:   "invite not found",
+		Code:  influxdb.ENotFound,
+		ErrID: influxdb.ErrIDInviteNotFound,
+	}
+
+	// ErrInviteNotPending is used when resending or revoking an invitation
+	// that has already been accepted or revoked.
+	ErrInviteNotPending = &influxdb.Error{
+		Msg:   "invite is no longer pending",
+		Code:  influxdb.EConflict,
+		ErrID: influxdb.ErrIDInviteNotPending,
+	}
+
+	// ErrInviteTokenInvalid is used when accepting an invitation with a setup
+	// token that is unknown, expired or already used. The cause is not
+	// revealed to the caller.
+	ErrInviteTokenInvalid = &influxdb.Error{
+		Msg:   "invite token is invalid or has expired",
+		Code:  influxdb.EForbidden,
+		ErrID: influxdb.ErrIDInviteTokenInvalid,
+	}
+
+	// ErrInviteNameRequired is used when inviting a user without a name.
+	ErrInviteNameRequired = &influxdb.Error{
+		Msg:  "invite requires a user name",
+		Code: influxdb.EInvalid,
+	}
+)
+
+// ErrInviteRoleInvalid is used when inviting a user with a role other than
+// owner or member.
+func ErrInviteRoleInvalid(role influxdb.UserType) *influxdb.Error {
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  fmt.Sprintf("invalid invite role %q; must be %q or %q", role, influxdb.Owner, influxdb.Member),
+	}
+}
+
+// ErrCorruptInvite is used when the invite cannot be unmarshalled from the
+// bytes stored in the kv.
+func ErrCorruptInvite(err error) *influxdb.Error {
+	return &influxdb.Error{
+		Code: influxdb.EInternal,
+		Msg:  "unknown internal invite data error",
+		Op:   "kv/invite",
+		Err:  err,
+	}
+}
+
+// ErrUnprocessableInvite is used when an invite is not able to be converted
+// to JSON.
+func ErrUnprocessableInvite(err error) *influxdb.Error {
+	return &influxdb.Error{
+		Code: influxdb.EUnprocessableEntity,
+		Msg:  fmt.Sprintf("unable to convert invite into JSON; Err %v", err),
+	}
+}
@@ -9,8 +9,9 @@ import (
 var (
 	// ErrOrgNotFound is used when the user is not found.
 	ErrOrgNotFound = &influxdb.Error{
-		Msg:  "organization not found",
-		Code: influxdb.ENotFound,
+		Msg:   "organization not found",
+		Code:  influxdb.ENotFound,
+		ErrID: influxdb.ErrIDOrgNotFound,
 	}
 )
 
@@ -18,16 +19,18 @@ var (
 // a name that has already been used. Organization names must be unique.
 func OrgAlreadyExistsError(name string) error {
 	return &influxdb.Error{
-		Code: influxdb.EConflict,
-		Msg:  fmt.Sprintf("organization with name %s already exists", name),
+		Code:  influxdb.EConflict,
+		Msg:   fmt.Sprintf("organization with name %s already exists", name),
+		ErrID: influxdb.ErrIDOrgCreateNameConflict,
 	}
 }
 
 func OrgNotFoundByName(name string) error {
 	return &influxdb.Error{
-		Code: influxdb.ENotFound,
-		Op:   influxdb.OpFindOrganizations,
-		Msg:  fmt.Sprintf("organization name \"%s\" not found", name),
+		Code:  influxdb.ENotFound,
+		Op:    influxdb.OpFindOrganizations,
+		Msg:   fmt.Sprintf("organization name \"%s\" not found", name),
+		ErrID: influxdb.ErrIDOrgNotFound,
 	}
 }
 
@@ -9,15 +9,17 @@ import (
 var (
 	// ErrUserNotFound is used when the user is not found.
 	ErrUserNotFound = &influxdb.Error{
-		Msg:  "user not found",
-		Code: influxdb.ENotFound,
+		Msg:   "user not found",
+		Code:  influxdb.ENotFound,
+		ErrID: influxdb.ErrIDUserNotFound,
 	}
 
 	// EIncorrectPassword is returned when any password operation fails in which
 	// we do not want to leak information.
 	EIncorrectPassword = &influxdb.Error{
-		Code: influxdb.EForbidden,
-		Msg:  "your username or password is incorrect",
+		Code:  influxdb.EForbidden,
+		Msg:   "your username or password is incorrect",
+		ErrID: influxdb.ErrIDUserPasswordIncorrect,
 	}
 
 	// EIncorrectUser is returned when any user is failed to be found which indicates
@@ -30,8 +32,9 @@ var (
 	// EShortPassword is used when a password is less than the minimum
 	// acceptable password length.
 	EShortPassword = &influxdb.Error{
-		Code: influxdb.EInvalid,
-		Msg:  "passwords must be at least 8 characters long",
+		Code:  influxdb.EInvalid,
+		Msg:   "passwords must be at least 8 characters long",
+		ErrID: influxdb.ErrIDUserPasswordTooShort,
 	}
 )
 
@@ -39,8 +42,9 @@ var (
 // that already exists.
 func UserAlreadyExistsError(n string) *influxdb.Error {
 	return &influxdb.Error{
-		Code: influxdb.EConflict,
-		Msg:  fmt.Sprintf("user with name %s already exists", n),
+		Code:  influxdb.EConflict,
+		Msg:   fmt.Sprintf("user with name %s already exists", n),
+		ErrID: influxdb.ErrIDUserCreateNameConflict,
 	}
 }
 
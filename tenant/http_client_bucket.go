@@ -177,3 +177,20 @@ func (s *BucketClientService) DeleteBucket(ctx context.Context, id influxdb.ID)
 		Delete(path.Join(prefixBuckets, id.String())).
 		Do(ctx)
 }
+
+// GetBucketOperationLog retrieves the operation log for the bucket with the provided id.
+func (s *BucketClientService) GetBucketOperationLog(ctx context.Context, id influxdb.ID, opts influxdb.FindOptions) ([]*influxdb.OperationLogEntry, int, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp operationLogResponse
+	err := s.Client.
+		Get(path.Join(prefixBuckets, id.String(), "logs")).
+		QueryParams(influxdb.FindOptionParams(opts)...).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.toInfluxDB(), len(resp.Logs), nil
+}
@@ -0,0 +1,121 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+)
+
+var _ influxdb.InviteService = (*InviteClientService)(nil)
+
+// InviteClientService connects to Influx via HTTP using tokens to manage the
+// invites of organizations.
+type InviteClientService struct {
+	Client *httpc.Client
+}
+
+func (r inviteTokenResponse) toInfluxdb() *influxdb.InviteToken {
+	return &influxdb.InviteToken{Invite: &r.Invite, Token: r.Token}
+}
+
+// CreateInvite invites a user to an organization over HTTP.
+func (s *InviteClientService) CreateInvite(ctx context.Context, c influxdb.InviteCreate) (*influxdb.InviteToken, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	req := postInviteRequest{Name: c.Name, Role: c.Role}
+	if c.TTL > 0 {
+		req.ExpiresIn = c.TTL.String()
+	}
+
+	var resp inviteTokenResponse
+	err := s.Client.
+		PostJSON(req, prefixOrganizations, c.OrgID.String(), "invites").
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+	return resp.toInfluxdb(), nil
+}
+
+// FindInviteByID returns a single invite by ID over HTTP.
+func (s *InviteClientService) FindInviteByID(ctx context.Context, id influxdb.ID) (*influxdb.Invite, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp inviteResponse
+	err := s.Client.
+		Get(prefixInvites, id.String()).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+	return &resp.Invite, nil
+}
+
+// FindInvites returns the invites of an organization over HTTP.
+func (s *InviteClientService) FindInvites(ctx context.Context, orgID influxdb.ID) ([]*influxdb.Invite, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp invitesResponse
+	err := s.Client.
+		Get(prefixOrganizations, orgID.String(), "invites").
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	invites := make([]*influxdb.Invite, len(resp.Invites))
+	for i := range resp.Invites {
+		invites[i] = &resp.Invites[i].Invite
+	}
+	return invites, nil
+}
+
+// ResendInvite replaces the setup token of an invite over HTTP.
+func (s *InviteClientService) ResendInvite(ctx context.Context, id influxdb.ID) (*influxdb.InviteToken, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp inviteTokenResponse
+	err := s.Client.
+		Post(nil, prefixInvites, id.String(), "resend").
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+	return resp.toInfluxdb(), nil
+}
+
+// RevokeInvite revokes an invite over HTTP.
+func (s *InviteClientService) RevokeInvite(ctx context.Context, id influxdb.ID) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	return s.Client.
+		Delete(prefixInvites, id.String()).
+		Do(ctx)
+}
+
+// AcceptInvite accepts an invite with its setup token over HTTP.
+func (s *InviteClientService) AcceptInvite(ctx context.Context, a influxdb.InviteAccept) (*influxdb.Invite, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp inviteResponse
+	err := s.Client.
+		PostJSON(a, prefixInvites, "accept").
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+	return &resp.Invite, nil
+}
@@ -2,6 +2,7 @@ package tenant
 
 import (
 	"context"
+	"path"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -36,6 +37,30 @@ func (s *OrgClientService) FindOrganizationByID(ctx context.Context, id influxdb
 	return o, nil
 }
 
+// FindOrganizationsByIDs gets the organizations with the given ids using HTTP,
+// one request per organization.
+func (s *OrgClientService) FindOrganizationsByIDs(ctx context.Context, ids []influxdb.ID) ([]*influxdb.Organization, error) {
+	orgs := make([]*influxdb.Organization, 0, len(ids))
+	var missing []influxdb.ID
+	for _, id := range ids {
+		o, err := s.FindOrganizationByID(ctx, id)
+		if influxdb.ErrorCode(err) == influxdb.ENotFound {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	if len(missing) > 0 {
+		err := influxdb.ErrOrgsNotFound(missing)
+		err.Op = s.OpPrefix + err.Op
+		return nil, err
+	}
+	return orgs, nil
+}
+
 // FindOrganization gets a single organization matching the filter using HTTP.
 func (s *OrgClientService) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
 	if filter.ID == nil && filter.Name == nil {
@@ -70,6 +95,10 @@ func (s *OrgClientService) FindOrganizations(ctx context.Context, filter influxd
 		span.LogKV("org", *filter.Name)
 		params = append(params, [2]string{"org", *filter.Name})
 	}
+	if filter.NamePrefix != nil {
+		span.LogKV("name-prefix", *filter.NamePrefix)
+		params = append(params, [2]string{"namePrefix", *filter.NamePrefix})
+	}
 	if filter.ID != nil {
 		span.LogKV("org-id", *filter.ID)
 		params = append(params, [2]string{"orgID", filter.ID.String()})
@@ -139,6 +168,27 @@ func (s *OrgClientService) UpdateOrganization(ctx context.Context, id influxdb.I
 	return &o, nil
 }
 
+// RenameOrganizationSafely renames the organization over HTTP, rewriting the
+// references to it by name on the server.
+func (s *OrgClientService) RenameOrganizationSafely(ctx context.Context, id influxdb.ID, name string) (*influxdb.Organization, []influxdb.OrganizationReference, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	span.LogKV("org-id", id)
+	span.LogKV("name", name)
+
+	var resp orgRenameResponse
+	err := s.Client.
+		PostJSON(orgRenameRequest{Name: name}, prefixOrganizations, id.String(), "rename").
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, nil, tracing.LogError(span, err)
+	}
+
+	return &resp.Organization.Organization, resp.References, nil
+}
+
 // DeleteOrganization removes organization id over HTTP.
 func (s *OrgClientService) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
 	span, _ := tracing.StartSpanFromContext(ctx)
@@ -148,3 +198,20 @@ func (s *OrgClientService) DeleteOrganization(ctx context.Context, id influxdb.I
 		Delete(prefixOrganizations, id.String()).
 		Do(ctx)
 }
+
+// GetOrganizationOperationLog retrieves the operation log for the org with the provided id.
+func (s *OrgClientService) GetOrganizationOperationLog(ctx context.Context, id influxdb.ID, opts influxdb.FindOptions) ([]*influxdb.OperationLogEntry, int, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp operationLogResponse
+	err := s.Client.
+		Get(path.Join(prefixOrganizations, id.String(), "logs")).
+		QueryParams(influxdb.FindOptionParams(opts)...).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.toInfluxDB(), len(resp.Logs), nil
+}
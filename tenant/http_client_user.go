@@ -126,17 +126,36 @@ func (s *UserClientService) DeleteUser(ctx context.Context, id influxdb.ID) erro
 		Do(ctx)
 }
 
-// FindUserByID returns a single user by ID.
+// FindPermissionForUser returns the effective permissions of a user, reading
+// all pages of them.
 func (s *UserClientService) FindPermissionForUser(ctx context.Context, id influxdb.ID) (influxdb.PermissionSet, error) {
 	var ps influxdb.PermissionSet
+	opt := influxdb.FindOptions{Limit: influxdb.MaxPageSize}
+	for {
+		perms, err := s.FindUserPermissions(ctx, id, opt)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, perms.PermissionSet()...)
+		if perms.ResourceCount() < opt.Limit {
+			return ps, nil
+		}
+		opt.Offset += opt.Limit
+	}
+}
+
+// FindUserPermissions returns a page of the effective permissions of a user.
+func (s *UserClientService) FindUserPermissions(ctx context.Context, id influxdb.ID, opt influxdb.FindOptions) (*influxdb.UserPermissions, error) {
+	var resp userPermissionsResponse
 	err := s.Client.
 		Get(prefixUsers, id.String(), "permissions").
-		DecodeJSON(&ps).
+		QueryParams(influxdb.FindOptionParams(opt)...).
+		DecodeJSON(&resp).
 		Do(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return ps, nil
+	return resp.UserPermissions, nil
 }
 
 // PasswordClientService is an http client to speak to the password service.
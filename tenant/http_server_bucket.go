@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -16,10 +17,12 @@ import (
 // BucketHandler represents an HTTP API handler for users.
 type BucketHandler struct {
 	chi.Router
-	api       *kithttp.API
-	log       *zap.Logger
-	bucketSvc influxdb.BucketService
-	labelSvc  influxdb.LabelService // we may need this for now but we dont want it permanently
+	api          *kithttp.API
+	log          *zap.Logger
+	bucketSvc    influxdb.BucketService
+	bucketLogSvc influxdb.BucketOperationLogService
+	labelSvc     influxdb.LabelService // we may need this for now but we dont want it permanently
+	lastValueSvc influxdb.LastValueService
 }
 
 const (
@@ -27,12 +30,13 @@ const (
 )
 
 // NewHTTPBucketHandler constructs a new http server.
-func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, labelSvc influxdb.LabelService, urmHandler, labelHandler http.Handler) *BucketHandler {
+func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, bucketLogSvc influxdb.BucketOperationLogService, labelSvc influxdb.LabelService, urmHandler, labelHandler http.Handler) *BucketHandler {
 	svr := &BucketHandler{
-		api:       kithttp.NewAPI(kithttp.WithLog(log)),
-		log:       log,
-		bucketSvc: bucketSvc,
-		labelSvc:  labelSvc,
+		api:          kithttp.NewAPI(kithttp.WithLog(log)),
+		log:          log,
+		bucketSvc:    bucketSvc,
+		bucketLogSvc: bucketLogSvc,
+		labelSvc:     labelSvc,
 	}
 
 	r := chi.NewRouter()
@@ -51,6 +55,8 @@ func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, lab
 			r.Get("/", svr.handleGetBucket)
 			r.Patch("/", svr.handlePatchBucket)
 			r.Delete("/", svr.handleDeleteBucket)
+			r.Get("/logs", svr.handleGetBucketLog)
+			r.Get("/last", svr.handleGetBucketLastValues)
 
 			// mount embedded resources
 			mountableRouter := r.With(kithttp.ValidResource(svr.api, svr.lookupOrgByBucketID))
@@ -68,6 +74,12 @@ func (h *BucketHandler) Prefix() string {
 	return prefixBuckets
 }
 
+// WithLastValueService serves the last values cached for buckets from svc.
+// Without it, reading last values fails as the cache is disabled.
+func (h *BucketHandler) WithLastValueService(svc influxdb.LastValueService) {
+	h.lastValueSvc = svc
+}
+
 // bucket is used for serialization/deserialization with duration string syntax.
 type bucket struct {
 	ID                  influxdb.ID     `json:"id,omitempty"`
@@ -77,6 +89,15 @@ type bucket struct {
 	Name                string          `json:"name"`
 	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
 	RetentionRules      []retentionRule `json:"retentionRules"`
+	// ShardGroupDurationSeconds is zero when derived from the retention period.
+	ShardGroupDurationSeconds int64 `json:"shardGroupDurationSeconds,omitempty"`
+	DeleteProtected           bool  `json:"deleteProtected"`
+	// RetentionBypassesDeleteProtection allows retention to expire data from a delete protected bucket.
+	RetentionBypassesDeleteProtection bool `json:"retentionBypassesDeleteProtection"`
+	Archived                          bool `json:"archived"`
+	// NaNHandling is empty for the default policy.
+	NaNHandling           influxdb.NaNHandling `json:"nanHandling,omitempty"`
+	LastValueMeasurements []string             `json:"lastValueMeasurements,omitempty"`
 	influxdb.CRUDLog
 }
 
@@ -124,7 +145,14 @@ func (b *bucket) toInfluxDB() (*influxdb.Bucket, error) {
 		Name:                b.Name,
 		RetentionPolicyName: b.RetentionPolicyName,
 		RetentionPeriod:     d,
+		ShardGroupDuration:  time.Duration(b.ShardGroupDurationSeconds) * time.Second,
+		DeleteProtected:     b.DeleteProtected,
 		CRUDLog:             b.CRUDLog,
+
+		RetentionBypassesDeleteProtection: b.RetentionBypassesDeleteProtection,
+		Archived:                          b.Archived,
+		NaNHandling:                       b.NaNHandling,
+		LastValueMeasurements:             b.LastValueMeasurements,
 	}, nil
 }
 
@@ -133,7 +161,12 @@ func newBucket(pb *influxdb.Bucket) *bucket {
 		return nil
 	}
 
-	rules := []retentionRule{}
+	// Leaving out the rules of a bucket to create gives it the default
+	// retention period of its organization.
+	var rules []retentionRule
+	if pb.RetentionPeriod != influxdb.DefaultRetention {
+		rules = []retentionRule{}
+	}
 	rp := int64(pb.RetentionPeriod.Round(time.Second) / time.Second)
 	if rp > 0 {
 		rules = append(rules, retentionRule{
@@ -143,22 +176,38 @@ func newBucket(pb *influxdb.Bucket) *bucket {
 	}
 
 	return &bucket{
-		ID:                  pb.ID,
-		OrgID:               pb.OrgID,
-		Type:                pb.Type.String(),
-		Name:                pb.Name,
-		Description:         pb.Description,
-		RetentionPolicyName: pb.RetentionPolicyName,
-		RetentionRules:      rules,
-		CRUDLog:             pb.CRUDLog,
+		ID:                        pb.ID,
+		OrgID:                     pb.OrgID,
+		Type:                      pb.Type.String(),
+		Name:                      pb.Name,
+		Description:               pb.Description,
+		RetentionPolicyName:       pb.RetentionPolicyName,
+		RetentionRules:            rules,
+		ShardGroupDurationSeconds: int64(pb.ShardGroupDuration.Round(time.Second) / time.Second),
+		DeleteProtected:           pb.DeleteProtected,
+		CRUDLog:                   pb.CRUDLog,
+
+		RetentionBypassesDeleteProtection: pb.RetentionBypassesDeleteProtection,
+		Archived:                          pb.Archived,
+		NaNHandling:                       pb.NaNHandling,
+		LastValueMeasurements:             pb.LastValueMeasurements,
 	}
 }
 
 // bucketUpdate is used for serialization/deserialization with retention rules.
 type bucketUpdate struct {
-	Name           *string         `json:"name,omitempty"`
+	Name           *string         `json:"name,omitempty" validate:"min=1"`
 	Description    *string         `json:"description,omitempty"`
 	RetentionRules []retentionRule `json:"retentionRules,omitempty"`
+
+	DeleteProtected                   *bool `json:"deleteProtected,omitempty"`
+	RetentionBypassesDeleteProtection *bool `json:"retentionBypassesDeleteProtection,omitempty"`
+
+	Archived *bool `json:"archived,omitempty"`
+
+	NaNHandling *influxdb.NaNHandling `json:"nanHandling,omitempty"`
+
+	LastValueMeasurements *[]string `json:"lastValueMeasurements,omitempty"`
 }
 
 func (b *bucketUpdate) OK() error {
@@ -168,6 +217,14 @@ func (b *bucketUpdate) OK() error {
 			return err
 		}
 	}
+	if b.NaNHandling != nil {
+		if err := b.NaNHandling.Valid(); err != nil {
+			return err
+		}
+	}
+	if b.LastValueMeasurements != nil {
+		return influxdb.ValidLastValueMeasurements(*b.LastValueMeasurements)
+	}
 	return nil
 }
 
@@ -176,17 +233,28 @@ func (b *bucketUpdate) toInfluxDB() *influxdb.BucketUpdate {
 		return nil
 	}
 
-	// For now, only use a single retention rule.
-	var d time.Duration
-	if len(b.RetentionRules) > 0 {
-		d, _ = b.RetentionRules[0].RetentionPeriod()
-	}
-
-	return &influxdb.BucketUpdate{
-		Name:            b.Name,
-		Description:     b.Description,
-		RetentionPeriod: &d,
+	upd := &influxdb.BucketUpdate{
+		Name:                              b.Name,
+		Description:                       b.Description,
+		DeleteProtected:                   b.DeleteProtected,
+		RetentionBypassesDeleteProtection: b.RetentionBypassesDeleteProtection,
+		Archived:                          b.Archived,
+		NaNHandling:                       b.NaNHandling,
+		LastValueMeasurements:             b.LastValueMeasurements,
+	}
+
+	// For now, only use a single retention rule. The retention period is
+	// left untouched when the rules are absent so that updates to other
+	// fields do not reset it, and an empty list of rules resets it to
+	// infinite retention.
+	if b.RetentionRules != nil {
+		d := time.Duration(influxdb.InfiniteRetention)
+		if len(b.RetentionRules) > 0 {
+			d, _ = b.RetentionRules[0].RetentionPeriod()
+		}
+		upd.RetentionPeriod = &d
 	}
+	return upd
 }
 
 func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
@@ -198,6 +266,12 @@ func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
 		Name:           pb.Name,
 		Description:    pb.Description,
 		RetentionRules: []retentionRule{},
+
+		DeleteProtected:                   pb.DeleteProtected,
+		RetentionBypassesDeleteProtection: pb.RetentionBypassesDeleteProtection,
+		Archived:                          pb.Archived,
+		NaNHandling:                       pb.NaNHandling,
+		LastValueMeasurements:             pb.LastValueMeasurements,
 	}
 
 	if pb.RetentionPeriod != nil {
@@ -224,6 +298,7 @@ func NewBucketResponse(b *influxdb.Bucket, labels ...*influxdb.Label) *bucketRes
 			"members": fmt.Sprintf("/api/v2/buckets/%s/members", b.ID),
 			"owners":  fmt.Sprintf("/api/v2/buckets/%s/owners", b.ID),
 			"labels":  fmt.Sprintf("/api/v2/buckets/%s/labels", b.ID),
+			"logs":    fmt.Sprintf("/api/v2/buckets/%s/logs", b.ID),
 			"write":   fmt.Sprintf("/api/v2/write?org=%s&bucket=%s", b.OrgID, b.ID),
 		},
 		bucket: *newBucket(b),
@@ -236,6 +311,11 @@ func NewBucketResponse(b *influxdb.Bucket, labels ...*influxdb.Label) *bucketRes
 	return res
 }
 
+// bucketDeletePlan is the plan of a bucket delete dry run.
+type bucketDeletePlan struct {
+	Bucket *bucketResponse `json:"bucket"`
+}
+
 type bucketsResponse struct {
 	Links   *influxdb.PagingLinks `json:"links"`
 	Buckets []*bucketResponse     `json:"buckets"`
@@ -259,7 +339,7 @@ func newBucketsResponse(ctx context.Context, opts influxdb.FindOptions, f influx
 // handlePostBucket is the HTTP handler for the POST /api/v2/buckets route.
 func (h *BucketHandler) handlePostBucket(w http.ResponseWriter, r *http.Request) {
 	var b postBucketRequest
-	if err := h.api.DecodeJSON(r.Body, &b); err != nil {
+	if err := h.api.DecodeAndValidate(r, &b); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
@@ -279,12 +359,18 @@ func (h *BucketHandler) handlePostBucket(w http.ResponseWriter, r *http.Request)
 	h.api.Respond(w, r, http.StatusCreated, NewBucketResponse(bucket))
 }
 
+// postBucketRequest is the body of a bucket creation. Leaving out the retention
+// rules gives the bucket the default retention period of its organization; an
+// empty list of rules is infinite retention.
 type postBucketRequest struct {
-	OrgID               influxdb.ID     `json:"orgID,omitempty"`
-	Name                string          `json:"name"`
-	Description         string          `json:"description"`
-	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
-	RetentionRules      []retentionRule `json:"retentionRules"`
+	OrgID                     influxdb.ID          `json:"orgID,omitempty"`
+	Name                      string               `json:"name" validate:"required"`
+	Description               string               `json:"description"`
+	RetentionPolicyName       string               `json:"rp,omitempty"` // This to support v1 sources
+	RetentionRules            []retentionRule      `json:"retentionRules"`
+	ShardGroupDurationSeconds int64                `json:"shardGroupDurationSeconds,omitempty"`
+	NaNHandling               influxdb.NaNHandling `json:"nanHandling,omitempty"`
+	LastValueMeasurements     []string             `json:"lastValueMeasurements,omitempty"`
 }
 
 func (b *postBucketRequest) OK() error {
@@ -305,12 +391,25 @@ func (b *postBucketRequest) OK() error {
 		}
 	}
 
-	return nil
+	if b.ShardGroupDurationSeconds < 0 {
+		return &influxdb.Error{
+			Code: influxdb.EUnprocessableEntity,
+			Msg:  "shard group duration seconds must not be negative",
+		}
+	}
+
+	if err := b.NaNHandling.Valid(); err != nil {
+		return err
+	}
+	return influxdb.ValidLastValueMeasurements(b.LastValueMeasurements)
 }
 
 func (b postBucketRequest) toInfluxDB() *influxdb.Bucket {
 	// Only support a single retention period for the moment
-	var dur time.Duration
+	dur := influxdb.DefaultRetention
+	if b.RetentionRules != nil {
+		dur = influxdb.InfiniteRetention
+	}
 	if len(b.RetentionRules) > 0 {
 		dur, _ = b.RetentionRules[0].RetentionPeriod()
 	}
@@ -322,6 +421,10 @@ func (b postBucketRequest) toInfluxDB() *influxdb.Bucket {
 		Type:                influxdb.BucketTypeUser,
 		RetentionPolicyName: b.RetentionPolicyName,
 		RetentionPeriod:     dur,
+		ShardGroupDuration:  time.Duration(b.ShardGroupDurationSeconds) * time.Second,
+		NaNHandling:         b.NaNHandling,
+
+		LastValueMeasurements: b.LastValueMeasurements,
 	}
 }
 
@@ -350,6 +453,115 @@ func (h *BucketHandler) handleGetBucket(w http.ResponseWriter, r *http.Request)
 	h.api.Respond(w, r, http.StatusOK, NewBucketResponse(b, labels...))
 }
 
+// handleGetBucketLog is the HTTP handler for the GET /api/v2/buckets/:id/logs route.
+func (h *BucketHandler) handleGetBucketLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := influxdb.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	opts, err := decodeOperationLogFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	// Ensure the bucket exists and is readable before returning its log.
+	if _, err := h.bucketSvc.FindBucketByID(ctx, *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	log, _, err := h.bucketLogSvc.GetBucketOperationLog(ctx, *id, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket log retrieved", zap.String("bucketID", fmt.Sprint(id)))
+
+	h.api.Respond(w, r, http.StatusOK, newOperationLogResponse(fmt.Sprintf("/api/v2/buckets/%s/logs", id), *opts, log))
+}
+
+// lastValuesResponse is the body of the last values of a measurement.
+type lastValuesResponse struct {
+	Values []*influxdb.LastValue `json:"values"`
+}
+
+// handleGetBucketLastValues is the HTTP handler for the GET /api/v2/buckets/:id/last route.
+func (h *BucketHandler) handleGetBucketLastValues(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := influxdb.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	filter, err := decodeLastValueFilter(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if h.lastValueSvc == nil {
+		h.api.Err(w, r, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  "the last value cache is disabled",
+		})
+		return
+	}
+
+	b, err := h.bucketSvc.FindBucketByID(ctx, *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	values, err := h.lastValueSvc.FindLastValues(ctx, b, filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, lastValuesResponse{Values: values})
+}
+
+// decodeLastValueFilter decodes the measurement and tags query parameters
+// of a read of last values. Tags are comma separated key=value pairs, and
+// may be given more than once.
+func decodeLastValueFilter(r *http.Request) (influxdb.LastValueFilter, error) {
+	q := r.URL.Query()
+	filter := influxdb.LastValueFilter{
+		Measurement: q.Get("measurement"),
+		Tags:        make(map[string]string),
+	}
+	if filter.Measurement == "" {
+		return filter, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "measurement is required",
+		}
+	}
+
+	for _, param := range q["tags"] {
+		if param == "" {
+			continue
+		}
+		for _, pair := range strings.Split(param, ",") {
+			i := strings.IndexByte(pair, '=')
+			if i <= 0 {
+				return filter, &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  fmt.Sprintf("invalid tag %q: must be key=value", pair),
+				}
+			}
+			filter.Tags[pair[:i]] = pair[i+1:]
+		}
+	}
+	return filter, nil
+}
+
 // handleDeleteBucket is the HTTP handler for the DELETE /api/v2/buckets/:id route.
 func (h *BucketHandler) handleDeleteBucket(w http.ResponseWriter, r *http.Request) {
 	id, err := influxdb.IDFromString(chi.URLParam(r, "id"))
@@ -358,11 +570,27 @@ func (h *BucketHandler) handleDeleteBucket(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	r, dryRun, err := h.api.DecodeDryRun(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
 	if err := h.bucketSvc.DeleteBucket(r.Context(), *id); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
 
+	if dryRun {
+		b, err := h.bucketSvc.FindBucketByID(r.Context(), *id)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		h.api.RespondDryRun(w, r, bucketDeletePlan{Bucket: NewBucketResponse(b)})
+		return
+	}
+
 	h.log.Debug("Bucket deleted", zap.String("bucketID", id.String()))
 
 	h.api.Respond(w, r, http.StatusNoContent, nil)
@@ -438,7 +666,7 @@ func (h *BucketHandler) handlePatchBucket(w http.ResponseWriter, r *http.Request
 	}
 
 	var reqBody bucketUpdate
-	if err := h.api.DecodeJSON(r.Body, &reqBody); err != nil {
+	if err := h.api.DecodeAndValidate(r, &reqBody); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
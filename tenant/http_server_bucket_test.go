@@ -2,8 +2,13 @@ package tenant_test
 
 import (
 	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/influxdata/influxdb/v2"
@@ -11,6 +16,8 @@ import (
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/tenant"
 	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -56,7 +63,7 @@ func initBucketHttpService(f itesting.BucketFields, t *testing.T) (influxdb.Buck
 		t.Fatalf("failed to seed data: %s", err)
 	}
 
-	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), tenant.NewService(store), nil, nil, nil)
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), tenant.NewService(store), nil, nil, nil, nil)
 	r := chi.NewRouter()
 	r.Mount(handler.Prefix(), handler)
 	server := httptest.NewServer(r)
@@ -78,3 +85,380 @@ func initBucketHttpService(f itesting.BucketFields, t *testing.T) (influxdb.Buck
 func TestHTTPBucketService(t *testing.T) {
 	itesting.BucketService(initBucketHttpService, t)
 }
+
+func TestBucketHandler_DeleteDryRun(t *testing.T) {
+	s, stCloser, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stCloser()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+	bucket := &influxdb.Bucket{OrgID: org.ID, Name: "bucket"}
+	require.NoError(t, svc.CreateBucket(ctx, bucket))
+	protected := &influxdb.Bucket{OrgID: org.ID, Name: "protected", DeleteProtected: true}
+	require.NoError(t, svc.CreateBucket(ctx, protected))
+
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), svc, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Mount(handler.Prefix(), handler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	deleteBucket := func(id influxdb.ID, query string) (*http.Response, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodDelete, server.URL+"/api/v2/buckets/"+id.String()+query, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	resp, body := deleteBucket(bucket.ID, "?dryRun=true")
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var dryRun struct {
+		DryRun bool `json:"dryRun"`
+		Plan   struct {
+			Bucket struct {
+				ID   influxdb.ID `json:"id"`
+				Name string      `json:"name"`
+			} `json:"bucket"`
+		} `json:"plan"`
+	}
+	require.NoError(t, json.Unmarshal(body, &dryRun))
+	assert.True(t, dryRun.DryRun)
+	assert.Equal(t, bucket.ID, dryRun.Plan.Bucket.ID)
+	assert.Equal(t, "bucket", dryRun.Plan.Bucket.Name)
+
+	_, err = svc.FindBucketByID(ctx, bucket.ID)
+	assert.NoError(t, err, "dry run must not delete the bucket")
+
+	resp, body = deleteBucket(protected.ID, "?dryRun=true")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, string(body))
+
+	resp, body = deleteBucket(bucket.ID, "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode, string(body))
+	_, err = svc.FindBucketByID(ctx, bucket.ID)
+	assert.Error(t, err)
+}
+
+func TestBucketHandler_CreateWithOrgDefaults(t *testing.T) {
+	s, stCloser, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stCloser()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{
+		Name:           "org",
+		BucketDefaults: influxdb.BucketDefaults{RetentionPeriod: influxdb.Duration{Duration: 30 * 24 * time.Hour}},
+	}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), svc, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Mount(handler.Prefix(), handler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	postBucket := func(body string) *influxdb.Bucket {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/api/v2/buckets", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(b))
+
+		var bkt struct {
+			ID influxdb.ID `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal(b, &bkt))
+		created, err := svc.FindBucketByID(ctx, bkt.ID)
+		require.NoError(t, err)
+		return created
+	}
+
+	bkt := postBucket(`{"orgID":"` + org.ID.String() + `","name":"unset"}`)
+	assert.Equal(t, 30*24*time.Hour, bkt.RetentionPeriod)
+
+	bkt = postBucket(`{"orgID":"` + org.ID.String() + `","name":"infinite","retentionRules":[]}`)
+	assert.Equal(t, time.Duration(influxdb.InfiniteRetention), bkt.RetentionPeriod)
+
+	bkt = postBucket(`{"orgID":"` + org.ID.String() + `","name":"explicit","retentionRules":[{"type":"expire","everySeconds":7200}],"shardGroupDurationSeconds":3600}`)
+	assert.Equal(t, 2*time.Hour, bkt.RetentionPeriod)
+	assert.Equal(t, time.Hour, bkt.ShardGroupDuration)
+
+	httpClient, err := ihttp.NewHTTPClient(server.URL, "", false)
+	require.NoError(t, err)
+	client := tenant.BucketClientService{Client: httpClient}
+	bkt = &influxdb.Bucket{OrgID: org.ID, Name: "client", RetentionPeriod: influxdb.DefaultRetention}
+	require.NoError(t, client.CreateBucket(ctx, bkt))
+	assert.Equal(t, 30*24*time.Hour, bkt.RetentionPeriod)
+}
+
+func TestBucketHandler_UpdateRetentionRules(t *testing.T) {
+	s, stCloser, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stCloser()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "metrics", RetentionPeriod: 2 * time.Hour}
+	require.NoError(t, svc.CreateBucket(ctx, bkt))
+
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), svc, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Mount(handler.Prefix(), handler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	patchBucket := func(body string) *influxdb.Bucket {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPatch, server.URL+"/api/v2/buckets/"+bkt.ID.String(), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+
+		updated, err := svc.FindBucketByID(ctx, bkt.ID)
+		require.NoError(t, err)
+		return updated
+	}
+
+	// Absent rules leave the retention period untouched.
+	updated := patchBucket(`{"description":"kept"}`)
+	assert.Equal(t, 2*time.Hour, updated.RetentionPeriod)
+	assert.Equal(t, "kept", updated.Description)
+
+	updated = patchBucket(`{"retentionRules":[{"type":"expire","everySeconds":3600}]}`)
+	assert.Equal(t, time.Hour, updated.RetentionPeriod)
+
+	// An empty list of rules resets the bucket to infinite retention.
+	updated = patchBucket(`{"retentionRules":[]}`)
+	assert.Equal(t, time.Duration(influxdb.InfiniteRetention), updated.RetentionPeriod)
+}
+
+func TestBucketHandler_NaNHandling(t *testing.T) {
+	s, stCloser, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stCloser()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), svc, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Mount(handler.Prefix(), handler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	httpClient, err := ihttp.NewHTTPClient(server.URL, "", false)
+	require.NoError(t, err)
+	client := tenant.BucketClientService{Client: httpClient}
+
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "sensors", NaNHandling: influxdb.NaNHandlingDrop}
+	require.NoError(t, client.CreateBucket(ctx, bkt))
+	found, err := client.FindBucketByID(ctx, bkt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.NaNHandlingDrop, found.NaNHandling)
+
+	reject := influxdb.NaNHandlingReject
+	updated, err := client.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{NaNHandling: &reject})
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.NaNHandlingReject, updated.NaNHandling)
+
+	invalid := influxdb.NaNHandling("zero")
+	_, err = client.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{NaNHandling: &invalid})
+	require.Error(t, err)
+	assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+
+	err = client.CreateBucket(ctx, &influxdb.Bucket{OrgID: org.ID, Name: "invalid", NaNHandling: invalid})
+	require.Error(t, err)
+	assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+}
+
+type fakeLastValueService struct {
+	filter influxdb.LastValueFilter
+}
+
+func (s *fakeLastValueService) FindLastValues(ctx context.Context, bucket *influxdb.Bucket, filter influxdb.LastValueFilter) ([]*influxdb.LastValue, error) {
+	s.filter = filter
+	return []*influxdb.LastValue{{
+		Measurement: filter.Measurement,
+		Tags:        map[string]string{"host": "a"},
+		Field:       "usage",
+		Time:        time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Value:       1.5,
+	}}, nil
+}
+
+func TestBucketHandler_LastValues(t *testing.T) {
+	s, stCloser, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stCloser()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), svc, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Mount(handler.Prefix(), handler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	httpClient, err := ihttp.NewHTTPClient(server.URL, "", false)
+	require.NoError(t, err)
+	client := tenant.BucketClientService{Client: httpClient}
+
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "sensors", LastValueMeasurements: []string{"cpu"}}
+	require.NoError(t, client.CreateBucket(ctx, bkt))
+	found, err := client.FindBucketByID(ctx, bkt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cpu"}, found.LastValueMeasurements)
+
+	measurements := []string{"cpu", "mem"}
+	updated, err := client.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{LastValueMeasurements: &measurements})
+	require.NoError(t, err)
+	assert.Equal(t, measurements, updated.LastValueMeasurements)
+
+	duplicate := []string{"cpu", "cpu"}
+	_, err = client.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{LastValueMeasurements: &duplicate})
+	require.Error(t, err)
+	assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+
+	get := func(t *testing.T, query string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/api/v2/buckets/" + bkt.ID.String() + "/last" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	// Without the service, the cache is disabled.
+	code, _ := get(t, "?measurement=cpu")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	lastValues := &fakeLastValueService{}
+	handler.WithLastValueService(lastValues)
+
+	code, body := get(t, "?measurement=cpu&tags=host=a,region=west&tags=rack=1")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, influxdb.LastValueFilter{
+		Measurement: "cpu",
+		Tags:        map[string]string{"host": "a", "region": "west", "rack": "1"},
+	}, lastValues.filter)
+	assert.JSONEq(t, `{"values":[{"measurement":"cpu","tags":{"host":"a"},"field":"usage","time":"2020-01-01T00:00:00Z","value":1.5}]}`, body)
+
+	code, _ = get(t, "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get(t, "?measurement=cpu&tags=host")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestBucketHandler_ValidationErrors(t *testing.T) {
+	s, stCloser, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stCloser()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "bucket"}
+	require.NoError(t, svc.CreateBucket(ctx, bkt))
+
+	handler := tenant.NewHTTPBucketHandler(zaptest.NewLogger(t), svc, nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Mount(handler.Prefix(), handler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		fields string
+	}{
+		{
+			name:   "create without a name",
+			method: http.MethodPost,
+			path:   "/api/v2/buckets",
+			body:   `{"orgID":"` + org.ID.String() + `"}`,
+			fields: `[{"path":"name","message":"is required"}]`,
+		},
+		{
+			name:   "create with a string shard group duration",
+			method: http.MethodPost,
+			path:   "/api/v2/buckets",
+			body:   `{"orgID":"` + org.ID.String() + `","name":"b","shardGroupDurationSeconds":"1h"}`,
+			fields: `[{"path":"shardGroupDurationSeconds","message":"must be an integer"}]`,
+		},
+		{
+			name:   "create with a string retention period",
+			method: http.MethodPost,
+			path:   "/api/v2/buckets",
+			body:   `{"orgID":"` + org.ID.String() + `","name":"b","retentionRules":[{"type":"expire","everySeconds":"1h"}]}`,
+			fields: `[{"path":"retentionRules[0].everySeconds","message":"must be an integer"}]`,
+		},
+		{
+			name:   "update to an empty name",
+			method: http.MethodPatch,
+			path:   "/api/v2/buckets/" + bkt.ID.String(),
+			body:   `{"name":""}`,
+			fields: `[{"path":"name","message":"must have at least 1 characters"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			b, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, string(b))
+
+			var body struct {
+				Code    string `json:"code"`
+				Details struct {
+					Fields json.RawMessage `json:"fields"`
+				} `json:"details"`
+			}
+			require.NoError(t, json.Unmarshal(b, &body))
+			assert.Equal(t, influxdb.EInvalid, body.Code)
+			assert.JSONEq(t, tt.fields, string(body.Details.Fields))
+		})
+	}
+}
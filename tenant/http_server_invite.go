@@ -0,0 +1,269 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const (
+	prefixInvites = "/api/v2/invites"
+)
+
+// InviteHandler represents an HTTP API handler for invites. Invites are
+// created and listed under their organization, and resent, revoked and
+// accepted by ID or setup token under /api/v2/invites.
+type InviteHandler struct {
+	chi.Router
+	api       *kithttp.API
+	log       *zap.Logger
+	inviteSvc influxdb.InviteService
+}
+
+func (h *InviteHandler) Prefix() string {
+	return prefixInvites
+}
+
+// NewHTTPInviteHandler constructs a new http server for the invites.
+func NewHTTPInviteHandler(log *zap.Logger, inviteSvc influxdb.InviteService) *InviteHandler {
+	svr := &InviteHandler{
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		inviteSvc: inviteSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Route("/", func(r chi.Router) {
+		r.Post("/accept", svr.handlePostInviteAccept)
+
+		r.Route("/{inviteID}", func(r chi.Router) {
+			r.Get("/", svr.handleGetInvite)
+			r.Delete("/", svr.handleDeleteInvite)
+			r.Post("/resend", svr.handlePostInviteResend)
+		})
+	})
+	svr.Router = r
+	return svr
+}
+
+// NewHTTPOrgInviteHandler constructs the http server for the invites of an
+// organization, embedded under the organization identified by the orgIDParam
+// URL parameter.
+func NewHTTPOrgInviteHandler(log *zap.Logger, orgIDParam string, inviteSvc influxdb.InviteService) http.Handler {
+	svr := &InviteHandler{
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		inviteSvc: inviteSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Post("/", svr.handlePostOrgInvite(orgIDParam))
+	r.Get("/", svr.handleGetOrgInvites(orgIDParam))
+	return r
+}
+
+type inviteResponse struct {
+	Links map[string]string `json:"links"`
+	influxdb.Invite
+}
+
+func newInviteResponse(i *influxdb.Invite) inviteResponse {
+	return inviteResponse{
+		Links: map[string]string{
+			"self":   fmt.Sprintf("/api/v2/invites/%s", i.ID),
+			"resend": fmt.Sprintf("/api/v2/invites/%s/resend", i.ID),
+			"org":    fmt.Sprintf("/api/v2/orgs/%s", i.OrgID),
+			"user":   fmt.Sprintf("/api/v2/users/%s", i.UserID),
+		},
+		Invite: *i,
+	}
+}
+
+type inviteTokenResponse struct {
+	inviteResponse
+	Token string `json:"token"`
+}
+
+// newInviteTokenResponse adds the one-time setup link to the links of the
+// invite.
+func newInviteTokenResponse(i *influxdb.InviteToken) inviteTokenResponse {
+	res := inviteTokenResponse{
+		inviteResponse: newInviteResponse(i.Invite),
+		Token:          i.Token,
+	}
+	res.Links["setup"] = prefixInvites + "/accept?token=" + url.QueryEscape(i.Token)
+	return res
+}
+
+type invitesResponse struct {
+	Links   map[string]string `json:"links"`
+	Invites []inviteResponse  `json:"invites"`
+}
+
+func newInvitesResponse(orgID influxdb.ID, is []*influxdb.Invite) *invitesResponse {
+	res := &invitesResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v2/orgs/%s/invites", orgID),
+			"org":  fmt.Sprintf("/api/v2/orgs/%s", orgID),
+		},
+		Invites: []inviteResponse{},
+	}
+	for _, i := range is {
+		res.Invites = append(res.Invites, newInviteResponse(i))
+	}
+	return res
+}
+
+type postInviteRequest struct {
+	Name string            `json:"name"`
+	Role influxdb.UserType `json:"role"`
+	// ExpiresIn is how long the setup token is valid for, as a duration
+	// such as "72h".
+	ExpiresIn string `json:"expiresIn,omitempty"`
+}
+
+// handlePostOrgInvite is the HTTP handler for the POST /api/v2/orgs/:id/invites route.
+func (h *InviteHandler) handlePostOrgInvite(orgIDParam string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orgID, err := influxdb.IDFromString(chi.URLParam(r, orgIDParam))
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+
+		var req postInviteRequest
+		if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+
+		c := influxdb.InviteCreate{OrgID: *orgID, Name: req.Name, Role: req.Role}
+		if req.ExpiresIn != "" {
+			ttl, err := time.ParseDuration(req.ExpiresIn)
+			if err != nil || ttl <= 0 {
+				h.api.Err(w, r, &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  fmt.Sprintf("invalid expiresIn %q; must be a positive duration", req.ExpiresIn),
+				})
+				return
+			}
+			c.TTL = ttl
+		}
+
+		i, err := h.inviteSvc.CreateInvite(r.Context(), c)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		h.log.Debug("Invite created", zap.Stringer("invite_id", i.ID), zap.Stringer("org_id", i.OrgID))
+
+		h.api.Respond(w, r, http.StatusCreated, newInviteTokenResponse(i))
+	}
+}
+
+// handleGetOrgInvites is the HTTP handler for the GET /api/v2/orgs/:id/invites route.
+func (h *InviteHandler) handleGetOrgInvites(orgIDParam string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orgID, err := influxdb.IDFromString(chi.URLParam(r, orgIDParam))
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+
+		is, err := h.inviteSvc.FindInvites(r.Context(), *orgID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+
+		h.api.Respond(w, r, http.StatusOK, newInvitesResponse(*orgID, is))
+	}
+}
+
+// handleGetInvite is the HTTP handler for the GET /api/v2/invites/:inviteID route.
+func (h *InviteHandler) handleGetInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := influxdb.IDFromString(chi.URLParam(r, "inviteID"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	i, err := h.inviteSvc.FindInviteByID(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, newInviteResponse(i))
+}
+
+// handlePostInviteResend is the HTTP handler for the POST /api/v2/invites/:inviteID/resend route.
+func (h *InviteHandler) handlePostInviteResend(w http.ResponseWriter, r *http.Request) {
+	id, err := influxdb.IDFromString(chi.URLParam(r, "inviteID"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	i, err := h.inviteSvc.ResendInvite(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invite resent", zap.Stringer("invite_id", i.ID))
+
+	h.api.Respond(w, r, http.StatusOK, newInviteTokenResponse(i))
+}
+
+// handleDeleteInvite is the HTTP handler for the DELETE /api/v2/invites/:inviteID route.
+func (h *InviteHandler) handleDeleteInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := influxdb.IDFromString(chi.URLParam(r, "inviteID"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.inviteSvc.RevokeInvite(r.Context(), *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invite revoked", zap.Stringer("invite_id", id))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePostInviteAccept is the HTTP handler for the POST /api/v2/invites/accept
+// route. It requires no authorization: the setup token, given in the body or
+// in the token query parameter of the setup link, authorizes the request.
+func (h *InviteHandler) handlePostInviteAccept(w http.ResponseWriter, r *http.Request) {
+	var req influxdb.InviteAccept
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if req.Token == "" {
+		req.Token = r.URL.Query().Get("token")
+	}
+
+	i, err := h.inviteSvc.AcceptInvite(r.Context(), req)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Invite accepted", zap.Stringer("invite_id", i.ID), zap.Stringer("user_id", i.UserID))
+
+	h.api.Respond(w, r, http.StatusOK, newInviteResponse(i))
+}
@@ -0,0 +1,64 @@
+package tenant_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHTTPInviteService(t *testing.T) {
+	ctx := context.Background()
+	s, closeS, err := NewTestInmemStore(t)
+	require.NoError(t, err)
+	defer closeS()
+
+	ts := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, ts.CreateOrganization(ctx, org))
+
+	log := zaptest.NewLogger(t)
+	inviteSvc := tenant.NewInviteService(ts)
+	orgHandler := tenant.NewHTTPOrgHandler(log, ts, nil, nil, nil, tenant.NewHTTPOrgInviteHandler(log, "id", inviteSvc))
+	inviteHandler := tenant.NewHTTPInviteHandler(log, inviteSvc)
+	r := chi.NewRouter()
+	r.Mount(orgHandler.Prefix(), orgHandler)
+	r.Mount(inviteHandler.Prefix(), inviteHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	httpClient, err := http.NewHTTPClient(server.URL, "", false)
+	require.NoError(t, err)
+	client := &tenant.InviteClientService{Client: httpClient}
+
+	created, err := client.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID, Name: "jane", TTL: time.Hour})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Token)
+	assert.Equal(t, influxdb.Member, created.Role)
+	assert.Equal(t, time.Hour, created.ExpiresAt.Sub(created.CreatedAt))
+
+	resent, err := client.ResendInvite(ctx, created.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Token, resent.Token)
+
+	_, err = client.AcceptInvite(ctx, influxdb.InviteAccept{Token: created.Token, Password: "password"})
+	assert.Equal(t, influxdb.EForbidden, influxdb.ErrorCode(err))
+	accepted, err := client.AcceptInvite(ctx, influxdb.InviteAccept{Token: resent.Token, Password: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.InviteAccepted, accepted.Status)
+
+	invites, err := client.FindInvites(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, invites, 1)
+	assert.Equal(t, influxdb.InviteAccepted, invites[0].Status)
+
+	assert.Equal(t, influxdb.EConflict, influxdb.ErrorCode(client.RevokeInvite(ctx, created.ID)))
+}
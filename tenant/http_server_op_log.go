@@ -0,0 +1,69 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+type operationLogResponse struct {
+	Links *influxdb.PagingLinks        `json:"links"`
+	Logs  []*operationLogEntryResponse `json:"logs"`
+}
+
+func newOperationLogResponse(basePath string, opts influxdb.FindOptions, es []*influxdb.OperationLogEntry) *operationLogResponse {
+	logs := make([]*operationLogEntryResponse, 0, len(es))
+	for _, e := range es {
+		logs = append(logs, newOperationLogEntryResponse(e))
+	}
+	return &operationLogResponse{
+		Links: influxdb.NewPagingLinks(basePath, opts, operationLogFilter{}, len(es)),
+		Logs:  logs,
+	}
+}
+
+func (r operationLogResponse) toInfluxDB() []*influxdb.OperationLogEntry {
+	es := make([]*influxdb.OperationLogEntry, 0, len(r.Logs))
+	for _, l := range r.Logs {
+		es = append(es, l.OperationLogEntry)
+	}
+	return es
+}
+
+type operationLogEntryResponse struct {
+	Links map[string]string `json:"links"`
+	*influxdb.OperationLogEntry
+}
+
+func newOperationLogEntryResponse(e *influxdb.OperationLogEntry) *operationLogEntryResponse {
+	links := map[string]string{}
+	if e.UserID.Valid() {
+		links["user"] = fmt.Sprintf("/api/v2/users/%s", e.UserID)
+	}
+	return &operationLogEntryResponse{
+		Links:             links,
+		OperationLogEntry: e,
+	}
+}
+
+// operationLogFilter is the filter used for operation log paging links. Logs
+// are always scoped to the resource in the path, so it has no parameters.
+type operationLogFilter struct{}
+
+func (operationLogFilter) QueryParams() map[string][]string {
+	return map[string][]string{}
+}
+
+// decodeOperationLogFindOptions decodes the paging options for an operation log
+// request. Entries are returned newest first unless requested otherwise.
+func decodeOperationLogFindOptions(r *http.Request) (*influxdb.FindOptions, error) {
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.URL.Query().Get("descending") == "" {
+		opts.Descending = influxdb.DefaultOperationLogFindOptions.Descending
+	}
+	return opts, nil
+}
@@ -15,9 +15,10 @@ import (
 // OrgHandler represents an HTTP API handler for organizations.
 type OrgHandler struct {
 	chi.Router
-	api    *kithttp.API
-	log    *zap.Logger
-	orgSvc influxdb.OrganizationService
+	api       *kithttp.API
+	log       *zap.Logger
+	orgSvc    influxdb.OrganizationService
+	orgLogSvc influxdb.OrganizationOperationLogService
 }
 
 const (
@@ -29,11 +30,12 @@ func (h *OrgHandler) Prefix() string {
 }
 
 // NewHTTPOrgHandler constructs a new http server.
-func NewHTTPOrgHandler(log *zap.Logger, orgService influxdb.OrganizationService, urm http.Handler, secretHandler http.Handler) *OrgHandler {
+func NewHTTPOrgHandler(log *zap.Logger, orgService influxdb.OrganizationService, orgLogService influxdb.OrganizationOperationLogService, urm http.Handler, secretHandler http.Handler, inviteHandler http.Handler) *OrgHandler {
 	svr := &OrgHandler{
-		api:    kithttp.NewAPI(kithttp.WithLog(log)),
-		log:    log,
-		orgSvc: orgService,
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		orgSvc:    orgService,
+		orgLogSvc: orgLogService,
 	}
 
 	r := chi.NewRouter()
@@ -51,12 +53,15 @@ func NewHTTPOrgHandler(log *zap.Logger, orgService influxdb.OrganizationService,
 			r.Get("/", svr.handleGetOrg)
 			r.Patch("/", svr.handlePatchOrg)
 			r.Delete("/", svr.handleDeleteOrg)
+			r.Get("/logs", svr.handleGetOrgLog)
+			r.Post("/rename", svr.handlePostOrgRename)
 
 			// mount embedded resources
 			mountableRouter := r.With(kithttp.ValidResource(svr.api, svr.lookupOrgByID))
 			mountableRouter.Mount("/members", urm)
 			mountableRouter.Mount("/owners", urm)
 			mountableRouter.Mount("/secrets", secretHandler)
+			mountableRouter.Mount("/invites", inviteHandler)
 		})
 	})
 	svr.Router = r
@@ -76,6 +81,7 @@ func newOrgResponse(o influxdb.Organization) orgResponse {
 			"members":    fmt.Sprintf("/api/v2/orgs/%s/members", o.ID),
 			"owners":     fmt.Sprintf("/api/v2/orgs/%s/owners", o.ID),
 			"secrets":    fmt.Sprintf("/api/v2/orgs/%s/secrets", o.ID),
+			"invites":    fmt.Sprintf("/api/v2/orgs/%s/invites", o.ID),
 			"labels":     fmt.Sprintf("/api/v2/orgs/%s/labels", o.ID),
 			"buckets":    fmt.Sprintf("/api/v2/buckets?org=%s", o.Name),
 			"tasks":      fmt.Sprintf("/api/v2/tasks?org=%s", o.Name),
@@ -85,6 +91,15 @@ func newOrgResponse(o influxdb.Organization) orgResponse {
 	}
 }
 
+type orgRenameRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type orgRenameResponse struct {
+	Organization orgResponse                      `json:"org"`
+	References   []influxdb.OrganizationReference `json:"references"`
+}
+
 type orgsResponse struct {
 	Links         map[string]string `json:"links"`
 	Organizations []orgResponse     `json:"orgs"`
@@ -106,7 +121,7 @@ func newOrgsResponse(orgs []*influxdb.Organization) *orgsResponse {
 // handlePostOrg is the HTTP handler for the POST /api/v2/orgs route.
 func (h *OrgHandler) handlePostOrg(w http.ResponseWriter, r *http.Request) {
 	var org influxdb.Organization
-	if err := h.api.DecodeJSON(r.Body, &org); err != nil {
+	if err := h.api.DecodeAndValidate(r, &org); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
@@ -154,6 +169,10 @@ func (h *OrgHandler) handleGetOrgs(w http.ResponseWriter, r *http.Request) {
 		filter.Name = &name
 	}
 
+	if prefix := qp.Get("namePrefix"); prefix != "" {
+		filter.NamePrefix = &prefix
+	}
+
 	if id := qp.Get("orgID"); id != "" {
 		i, err := influxdb.IDFromString(id)
 		if err == nil {
@@ -187,7 +206,7 @@ func (h *OrgHandler) handlePatchOrg(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var upd influxdb.OrganizationUpdate
-	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+	if err := h.api.DecodeAndValidate(r, &upd); err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
@@ -202,6 +221,33 @@ func (h *OrgHandler) handlePatchOrg(w http.ResponseWriter, r *http.Request) {
 	h.api.Respond(w, r, http.StatusOK, newOrgResponse(*org))
 }
 
+// handlePostOrgRename is the HTTP handler for the POST /api/v2/orgs/:id/rename route.
+func (h *OrgHandler) handlePostOrgRename(w http.ResponseWriter, r *http.Request) {
+	id, err := influxdb.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req orgRenameRequest
+	if err := h.api.DecodeAndValidate(r, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	org, refs, err := h.orgSvc.RenameOrganizationSafely(r.Context(), *id, req.Name)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Org renamed", zap.String("org", fmt.Sprint(org)), zap.Int("references", len(refs)))
+
+	h.api.Respond(w, r, http.StatusOK, orgRenameResponse{
+		Organization: newOrgResponse(*org),
+		References:   refs,
+	})
+}
+
 // handleDeleteOrganization is the HTTP handler for the DELETE /api/v2/orgs/:id route.
 func (h *OrgHandler) handleDeleteOrg(w http.ResponseWriter, r *http.Request) {
 	id, err := influxdb.IDFromString(chi.URLParam(r, "id"))
@@ -220,6 +266,38 @@ func (h *OrgHandler) handleDeleteOrg(w http.ResponseWriter, r *http.Request) {
 	h.api.Respond(w, r, http.StatusNoContent, nil)
 }
 
+// handleGetOrgLog is the HTTP handler for the GET /api/v2/orgs/:id/logs route.
+func (h *OrgHandler) handleGetOrgLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := influxdb.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	opts, err := decodeOperationLogFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	// Ensure the org exists and is readable before returning its log.
+	if _, err := h.orgSvc.FindOrganizationByID(ctx, *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	log, _, err := h.orgLogSvc.GetOrganizationOperationLog(ctx, *id, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Org log retrieved", zap.String("orgID", fmt.Sprint(id)))
+
+	h.api.Respond(w, r, http.StatusOK, newOperationLogResponse(fmt.Sprintf("/api/v2/orgs/%s/logs", id), *opts, log))
+}
+
 func (h *OrgHandler) lookupOrgByID(ctx context.Context, id influxdb.ID) (influxdb.ID, error) {
 	_, err := h.orgSvc.FindOrganizationByID(ctx, id)
 	if err != nil {
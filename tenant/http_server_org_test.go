@@ -9,8 +9,11 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/http"
 	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/tenant"
 	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -42,7 +45,7 @@ func initHttpOrgService(f itesting.OrganizationFields, t *testing.T) (influxdb.O
 		t.Fatalf("failed to populate organizations: %s", err)
 	}
 
-	handler := tenant.NewHTTPOrgHandler(zaptest.NewLogger(t), tenant.NewService(storage), nil, nil)
+	handler := tenant.NewHTTPOrgHandler(zaptest.NewLogger(t), tenant.NewService(storage), nil, nil, nil, nil)
 	r := chi.NewRouter()
 	r.Mount(handler.Prefix(), handler)
 	server := httptest.NewServer(r)
@@ -64,3 +67,23 @@ func initHttpOrgService(f itesting.OrganizationFields, t *testing.T) (influxdb.O
 func TestHTTPOrgService(t *testing.T) {
 	itesting.OrganizationService(initHttpOrgService, t)
 }
+
+func TestHTTPOrgService_RenameOrganizationSafely(t *testing.T) {
+	ctx := context.Background()
+	orgID := influxdb.ID(1)
+	svc, _, done := initHttpOrgService(itesting.OrganizationFields{
+		OrgBucketIDs: mock.NewStaticIDGenerator(orgID),
+		Organizations: []*influxdb.Organization{
+			{Name: "old"},
+		},
+	}, t)
+	defer done()
+
+	o, refs, err := svc.RenameOrganizationSafely(ctx, orgID, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "new", o.Name)
+	assert.Empty(t, refs)
+
+	_, _, err = svc.RenameOrganizationSafely(ctx, orgID, "")
+	assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+}
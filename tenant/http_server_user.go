@@ -22,6 +22,7 @@ type UserHandler struct {
 	log         *zap.Logger
 	userSvc     influxdb.UserService
 	passwordSvc influxdb.PasswordsService
+	permSvc     influxdb.UserPermissionService
 }
 
 const (
@@ -30,12 +31,13 @@ const (
 )
 
 // NewHTTPUserHandler constructs a new http server.
-func NewHTTPUserHandler(log *zap.Logger, userService influxdb.UserService, passwordService influxdb.PasswordsService) *UserHandler {
+func NewHTTPUserHandler(log *zap.Logger, userService influxdb.UserService, passwordService influxdb.PasswordsService, permissionService influxdb.UserPermissionService) *UserHandler {
 	svr := &UserHandler{
 		api:         kithttp.NewAPI(kithttp.WithLog(log)),
 		log:         log,
 		userSvc:     userService,
 		passwordSvc: passwordService,
+		permSvc:     permissionService,
 	}
 
 	r := chi.NewRouter()
@@ -258,29 +260,45 @@ func (h *UserHandler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 	h.api.Respond(w, r, http.StatusOK, newUserResponse(b))
 }
 
+type userPermissionsResponse struct {
+	*influxdb.UserPermissions
+	Links *influxdb.PagingLinks `json:"links"`
+}
+
+// userPermissionsFilter is the filter used for user permission paging links.
+// Permissions are always scoped to the user in the path, so it has no
+// parameters.
+type userPermissionsFilter struct{}
+
+func (userPermissionsFilter) QueryParams() map[string][]string {
+	return map[string][]string{}
+}
+
+// handleGetPermissions is the HTTP handler for the GET /api/v2/users/:id/permissions route.
 func (h *UserHandler) handleGetPermissions(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	if id == "" {
-		err := &influxdb.Error{
-			Code: influxdb.EInvalid,
-			Msg:  "url missing id",
-		}
+	ctx := r.Context()
+	req, err := decodeGetUserRequest(ctx, r)
+	if err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
-	var i influxdb.ID
-	if err := i.DecodeFromString(id); err != nil {
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
 
-	ps, err := h.userSvc.FindPermissionForUser(r.Context(), i)
+	ps, err := h.permSvc.FindUserPermissions(ctx, req.UserID, *opts)
 	if err != nil {
 		h.api.Err(w, r, err)
 		return
 	}
+	h.log.Debug("User permissions retrieved", zap.String("user", req.UserID.String()), zap.Int("resources", ps.ResourceCount()))
 
-	h.api.Respond(w, r, http.StatusOK, ps)
+	h.api.Respond(w, r, http.StatusOK, userPermissionsResponse{
+		UserPermissions: ps,
+		Links:           influxdb.NewPagingLinks(r.URL.Path, *opts, userPermissionsFilter{}, ps.ResourceCount()),
+	})
 }
 
 type getUserRequest struct {
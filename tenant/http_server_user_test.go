@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi"
+	"github.com/google/go-cmp/cmp"
 	platform "github.com/influxdata/influxdb/v2"
 	ihttp "github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/tenant"
 	platformtesting "github.com/influxdata/influxdb/v2/testing"
 	"go.uber.org/zap/zaptest"
@@ -31,7 +33,7 @@ func initHttpUserService(f platformtesting.UserFields, t *testing.T) (platform.U
 		}
 	}
 
-	handler := tenant.NewHTTPUserHandler(zaptest.NewLogger(t), svc, svc)
+	handler := tenant.NewHTTPUserHandler(zaptest.NewLogger(t), svc, svc, tenant.NewUserPermissionService(svc, mock.NewAuthorizationService(), nil))
 	r := chi.NewRouter()
 	r.Mount("/api/v2/users", handler)
 	r.Mount("/api/v2/me", handler)
@@ -56,3 +58,64 @@ func TestUserService(t *testing.T) {
 	t.Parallel()
 	platformtesting.UserService(initHttpUserService, t)
 }
+
+func TestUserPermissionsHTTP(t *testing.T) {
+	s, stCloser, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stCloser()
+	svc := tenant.NewService(tenant.NewStore(s))
+
+	ctx := context.Background()
+	u := &platform.User{Name: "member", Status: platform.Active}
+	if err := svc.CreateUser(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	org := &platform.Organization{Name: "org"}
+	if err := svc.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.CreateUserResourceMapping(ctx, &platform.UserResourceMapping{
+		UserID:       u.ID,
+		UserType:     platform.Member,
+		ResourceType: platform.OrgsResourceType,
+		ResourceID:   org.ID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	permSvc := tenant.NewUserPermissionService(svc, mock.NewAuthorizationService(), nil)
+	handler := tenant.NewHTTPUserHandler(zaptest.NewLogger(t), svc, svc, permSvc)
+	r := chi.NewRouter()
+	r.Mount("/api/v2/users", handler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	httpClient, err := ihttp.NewHTTPClient(server.URL, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tenant.UserClientService{Client: httpClient}
+
+	page, err := client.FindUserPermissions(ctx, u.ID, platform.FindOptions{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := page.ResourceCount(); n != 2 {
+		t.Fatalf("got %d resources on page, exp 2", n)
+	}
+
+	// The client reads every page of the permissions.
+	all, err := permSvc.FindUserPermissions(ctx, u.ID, platform.FindOptions{Limit: platform.MaxPageSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := client.FindPermissionForUser(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(all.PermissionSet(), ps); diff != "" {
+		t.Fatalf("unexpected permissions: %s", diff)
+	}
+}
@@ -104,6 +104,12 @@ func (s *AuthedBucketService) UpdateBucket(ctx context.Context, id influxdb.ID,
 	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, id, b.OrgID); err != nil {
 		return nil, err
 	}
+	// Only org owners may change the delete protection of a bucket.
+	if upd.ChangesDeleteProtection() {
+		if _, _, err := authorizer.AuthorizeWriteOrg(ctx, b.OrgID); err != nil {
+			return nil, err
+		}
+	}
 	return s.s.UpdateBucket(ctx, id, upd)
 }
 
@@ -440,6 +440,61 @@ func TestBucketService_UpdateBucket(t *testing.T) {
 	}
 }
 
+func TestBucketService_UpdateBucket_DeleteProtection(t *testing.T) {
+	svc := &mock.BucketService{
+		FindBucketByIDFn: func(ctc context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: 1, OrgID: 10}, nil
+		},
+		UpdateBucketFn: func(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: 1, OrgID: 10}, nil
+		},
+	}
+	bucketWrite := influxdb.Permission{
+		Action: "write",
+		Resource: influxdb.Resource{
+			Type: influxdb.BucketsResourceType,
+			ID:   influxdbtesting.IDPtr(1),
+		},
+	}
+	orgWrite := influxdb.Permission{
+		Action: "write",
+		Resource: influxdb.Resource{
+			Type: influxdb.OrgsResourceType,
+			ID:   influxdbtesting.IDPtr(10),
+		},
+	}
+	protect := true
+
+	tests := []struct {
+		name        string
+		permissions []influxdb.Permission
+		err         error
+	}{
+		{
+			name:        "bucket writer cannot change delete protection",
+			permissions: []influxdb.Permission{bucketWrite},
+			err: &influxdb.Error{
+				Msg:  "write:orgs/000000000000000a is unauthorized",
+				Code: influxdb.EUnauthorized,
+			},
+		},
+		{
+			name:        "org owner can change delete protection",
+			permissions: []influxdb.Permission{bucketWrite, orgWrite},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tenant.NewAuthedBucketService(svc)
+			ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, tt.permissions))
+
+			_, err := s.UpdateBucket(ctx, 1, influxdb.BucketUpdate{DeleteProtected: &protect})
+			influxdbtesting.ErrorsEqual(t, err, tt.err)
+		})
+	}
+}
+
 func TestBucketService_DeleteBucket(t *testing.T) {
 	type fields struct {
 		BucketService influxdb.BucketService
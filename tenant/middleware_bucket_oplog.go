@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"go.uber.org/zap"
+)
+
+// BucketOperationLogWriter records entries in bucket operation logs.
+type BucketOperationLogWriter interface {
+	AddBucketLogEntry(ctx context.Context, id influxdb.ID, e *influxdb.OperationLogEntry) error
+}
+
+// BucketOpLogger is a service middleware which records successful changes to
+// buckets in their operation logs. Recording is best-effort: failures are
+// logged and never returned to the caller.
+type BucketOpLogger struct {
+	logger        *zap.Logger
+	opLog         BucketOperationLogWriter
+	bucketService influxdb.BucketService
+}
+
+var _ influxdb.BucketService = (*BucketOpLogger)(nil)
+
+// NewBucketOpLogger returns an operation log middleware for the Bucket Service.
+func NewBucketOpLogger(log *zap.Logger, opLog BucketOperationLogWriter, s influxdb.BucketService) *BucketOpLogger {
+	return &BucketOpLogger{
+		logger:        log,
+		opLog:         opLog,
+		bucketService: s,
+	}
+}
+
+func (l *BucketOpLogger) FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+	return l.bucketService.FindBucketByID(ctx, id)
+}
+
+func (l *BucketOpLogger) FindBucketByName(ctx context.Context, orgID influxdb.ID, name string) (*influxdb.Bucket, error) {
+	return l.bucketService.FindBucketByName(ctx, orgID, name)
+}
+
+func (l *BucketOpLogger) FindBucket(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+	return l.bucketService.FindBucket(ctx, filter)
+}
+
+func (l *BucketOpLogger) FindBuckets(ctx context.Context, filter influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+	return l.bucketService.FindBuckets(ctx, filter, opts...)
+}
+
+func (l *BucketOpLogger) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	if err := l.bucketService.CreateBucket(ctx, b); err != nil {
+		return err
+	}
+	l.record(ctx, b.ID, newOperationLogEntry(ctx, influxdb.OperationCreate, "Bucket Created", nil))
+	return nil
+}
+
+func (l *BucketOpLogger) UpdateBucket(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+	b, err := l.bucketService.UpdateBucket(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+	l.record(ctx, id, newOperationLogEntry(ctx, influxdb.OperationUpdate, "Bucket Updated", bucketUpdateFields(upd)))
+	return b, nil
+}
+
+func (l *BucketOpLogger) DeleteBucket(ctx context.Context, id influxdb.ID) error {
+	if err := l.bucketService.DeleteBucket(ctx, id); err != nil {
+		return err
+	}
+	if icontext.IsDryRun(ctx) {
+		return nil
+	}
+	l.record(ctx, id, newOperationLogEntry(ctx, influxdb.OperationDelete, "Bucket Deleted", nil))
+	return nil
+}
+
+func (l *BucketOpLogger) record(ctx context.Context, id influxdb.ID, e *influxdb.OperationLogEntry) {
+	if err := l.opLog.AddBucketLogEntry(ctx, id, e); err != nil {
+		l.logger.Warn("Failed to record bucket operation log entry", zap.Stringer("bucket_id", id), zap.String("operation", e.Operation), zap.Error(err))
+	}
+}
+
+// bucketUpdateFields returns the names of the fields set in a bucket update.
+func bucketUpdateFields(upd influxdb.BucketUpdate) []string {
+	var fields []string
+	if upd.Name != nil {
+		fields = append(fields, "name")
+	}
+	if upd.Description != nil {
+		fields = append(fields, "description")
+	}
+	if upd.RetentionPeriod != nil {
+		fields = append(fields, "retentionPeriod")
+	}
+	if upd.DeleteProtected != nil {
+		fields = append(fields, "deleteProtected")
+	}
+	if upd.RetentionBypassesDeleteProtection != nil {
+		fields = append(fields, "retentionBypassesDeleteProtection")
+	}
+	if upd.Archived != nil {
+		fields = append(fields, "archived")
+	}
+	if upd.NaNHandling != nil {
+		fields = append(fields, "nanHandling")
+	}
+	if upd.LastValueMeasurements != nil {
+		fields = append(fields, "lastValueMeasurements")
+	}
+	return fields
+}
@@ -0,0 +1,73 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+)
+
+var _ influxdb.InviteService = (*AuthedInviteService)(nil)
+
+// AuthedInviteService wraps a influxdb.InviteService and authorizes actions
+// against it appropriately. Managing the invites of an organization requires
+// write access to it; accepting an invite only requires its setup token.
+type AuthedInviteService struct {
+	s influxdb.InviteService
+}
+
+// NewAuthedInviteService constructs an instance of an authorizing invite service.
+func NewAuthedInviteService(s influxdb.InviteService) *AuthedInviteService {
+	return &AuthedInviteService{
+		s: s,
+	}
+}
+
+// CreateInvite checks to see if the authorizer on context has write access to the org of the invite.
+func (s *AuthedInviteService) CreateInvite(ctx context.Context, c influxdb.InviteCreate) (*influxdb.InviteToken, error) {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, c.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.CreateInvite(ctx, c)
+}
+
+// FindInviteByID checks to see if the authorizer on context has write access to the org of the invite.
+func (s *AuthedInviteService) FindInviteByID(ctx context.Context, id influxdb.ID) (*influxdb.Invite, error) {
+	i, err := s.s.FindInviteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, i.OrgID); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// FindInvites checks to see if the authorizer on context has write access to the org.
+func (s *AuthedInviteService) FindInvites(ctx context.Context, orgID influxdb.ID) ([]*influxdb.Invite, error) {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return s.s.FindInvites(ctx, orgID)
+}
+
+// ResendInvite checks to see if the authorizer on context has write access to the org of the invite.
+func (s *AuthedInviteService) ResendInvite(ctx context.Context, id influxdb.ID) (*influxdb.InviteToken, error) {
+	if _, err := s.FindInviteByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.s.ResendInvite(ctx, id)
+}
+
+// RevokeInvite checks to see if the authorizer on context has write access to the org of the invite.
+func (s *AuthedInviteService) RevokeInvite(ctx context.Context, id influxdb.ID) error {
+	if _, err := s.FindInviteByID(ctx, id); err != nil {
+		return err
+	}
+	return s.s.RevokeInvite(ctx, id)
+}
+
+// AcceptInvite pass through. The setup token is checked by the underlying service layer.
+func (s *AuthedInviteService) AcceptInvite(ctx context.Context, a influxdb.InviteAccept) (*influxdb.Invite, error) {
+	return s.s.AcceptInvite(ctx, a)
+}
@@ -0,0 +1,102 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"go.uber.org/zap"
+)
+
+type InviteLogger struct {
+	logger    *zap.Logger
+	inviteSvc influxdb.InviteService
+}
+
+// NewInviteLogger returns a logging service middleware for the Invite Service.
+func NewInviteLogger(log *zap.Logger, s influxdb.InviteService) *InviteLogger {
+	return &InviteLogger{
+		logger:    log,
+		inviteSvc: s,
+	}
+}
+
+var _ influxdb.InviteService = (*InviteLogger)(nil)
+
+func (l *InviteLogger) CreateInvite(ctx context.Context, c influxdb.InviteCreate) (i *influxdb.InviteToken, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to invite user %s", c.Name)
+			l.logger.Debug(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite create", dur)
+	}(time.Now())
+	return l.inviteSvc.CreateInvite(ctx, c)
+}
+
+func (l *InviteLogger) FindInviteByID(ctx context.Context, id influxdb.ID) (i *influxdb.Invite, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to find invite with ID %v", id)
+			l.logger.Debug(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite find by ID", dur)
+	}(time.Now())
+	return l.inviteSvc.FindInviteByID(ctx, id)
+}
+
+func (l *InviteLogger) FindInvites(ctx context.Context, orgID influxdb.ID) (is []*influxdb.Invite, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to find invites of org %v", orgID)
+			l.logger.Debug(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invites find", dur)
+	}(time.Now())
+	return l.inviteSvc.FindInvites(ctx, orgID)
+}
+
+func (l *InviteLogger) ResendInvite(ctx context.Context, id influxdb.ID) (i *influxdb.InviteToken, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to resend invite with ID %v", id)
+			l.logger.Debug(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite resend", dur)
+	}(time.Now())
+	return l.inviteSvc.ResendInvite(ctx, id)
+}
+
+func (l *InviteLogger) RevokeInvite(ctx context.Context, id influxdb.ID) (err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			msg := fmt.Sprintf("failed to revoke invite with ID %v", id)
+			l.logger.Debug(msg, zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite revoke", dur)
+	}(time.Now())
+	return l.inviteSvc.RevokeInvite(ctx, id)
+}
+
+func (l *InviteLogger) AcceptInvite(ctx context.Context, a influxdb.InviteAccept) (i *influxdb.Invite, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to accept invite", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("invite accept", dur)
+	}(time.Now())
+	return l.inviteSvc.AcceptInvite(ctx, a)
+}
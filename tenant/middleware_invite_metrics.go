@@ -0,0 +1,62 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/metric"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ influxdb.InviteService = (*InviteMetrics)(nil)
+
+type InviteMetrics struct {
+	// RED metrics
+	rec *metric.REDClient
+
+	inviteSvc influxdb.InviteService
+}
+
+// NewInviteMetrics returns a metrics service middleware for the Invite Service.
+func NewInviteMetrics(reg prometheus.Registerer, s influxdb.InviteService, opts ...metric.ClientOptFn) *InviteMetrics {
+	o := metric.ApplyMetricOpts(opts...)
+	return &InviteMetrics{
+		rec:       metric.New(reg, o.ApplySuffix("invite")),
+		inviteSvc: s,
+	}
+}
+
+func (m *InviteMetrics) CreateInvite(ctx context.Context, c influxdb.InviteCreate) (*influxdb.InviteToken, error) {
+	rec := m.rec.Record("create_invite")
+	i, err := m.inviteSvc.CreateInvite(ctx, c)
+	return i, rec(err)
+}
+
+func (m *InviteMetrics) FindInviteByID(ctx context.Context, id influxdb.ID) (*influxdb.Invite, error) {
+	rec := m.rec.Record("find_invite_by_id")
+	i, err := m.inviteSvc.FindInviteByID(ctx, id)
+	return i, rec(err)
+}
+
+func (m *InviteMetrics) FindInvites(ctx context.Context, orgID influxdb.ID) ([]*influxdb.Invite, error) {
+	rec := m.rec.Record("find_invites")
+	is, err := m.inviteSvc.FindInvites(ctx, orgID)
+	return is, rec(err)
+}
+
+func (m *InviteMetrics) ResendInvite(ctx context.Context, id influxdb.ID) (*influxdb.InviteToken, error) {
+	rec := m.rec.Record("resend_invite")
+	i, err := m.inviteSvc.ResendInvite(ctx, id)
+	return i, rec(err)
+}
+
+func (m *InviteMetrics) RevokeInvite(ctx context.Context, id influxdb.ID) error {
+	rec := m.rec.Record("revoke_invite")
+	return rec(m.inviteSvc.RevokeInvite(ctx, id))
+}
+
+func (m *InviteMetrics) AcceptInvite(ctx context.Context, a influxdb.InviteAccept) (*influxdb.Invite, error) {
+	rec := m.rec.Record("accept_invite")
+	i, err := m.inviteSvc.AcceptInvite(ctx, a)
+	return i, rec(err)
+}
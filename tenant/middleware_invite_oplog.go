@@ -0,0 +1,86 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"go.uber.org/zap"
+)
+
+// InviteOpLogger is a service middleware which records invites in the
+// operation logs of their organizations. Recording is best-effort: failures
+// are logged and never returned to the caller.
+type InviteOpLogger struct {
+	logger    *zap.Logger
+	opLog     OrgOperationLogWriter
+	inviteSvc influxdb.InviteService
+}
+
+var _ influxdb.InviteService = (*InviteOpLogger)(nil)
+
+// NewInviteOpLogger returns an operation log middleware for the Invite Service.
+func NewInviteOpLogger(log *zap.Logger, opLog OrgOperationLogWriter, s influxdb.InviteService) *InviteOpLogger {
+	return &InviteOpLogger{
+		logger:    log,
+		opLog:     opLog,
+		inviteSvc: s,
+	}
+}
+
+func (l *InviteOpLogger) CreateInvite(ctx context.Context, c influxdb.InviteCreate) (*influxdb.InviteToken, error) {
+	i, err := l.inviteSvc.CreateInvite(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	l.record(ctx, i.Invite, newOperationLogEntry(ctx, influxdb.OperationCreate, "Invite Created", []string{"name", "role"}))
+	return i, nil
+}
+
+func (l *InviteOpLogger) FindInviteByID(ctx context.Context, id influxdb.ID) (*influxdb.Invite, error) {
+	return l.inviteSvc.FindInviteByID(ctx, id)
+}
+
+func (l *InviteOpLogger) FindInvites(ctx context.Context, orgID influxdb.ID) ([]*influxdb.Invite, error) {
+	return l.inviteSvc.FindInvites(ctx, orgID)
+}
+
+func (l *InviteOpLogger) ResendInvite(ctx context.Context, id influxdb.ID) (*influxdb.InviteToken, error) {
+	i, err := l.inviteSvc.ResendInvite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	l.record(ctx, i.Invite, newOperationLogEntry(ctx, influxdb.OperationUpdate, "Invite Resent", []string{"expiresAt"}))
+	return i, nil
+}
+
+func (l *InviteOpLogger) RevokeInvite(ctx context.Context, id influxdb.ID) error {
+	if err := l.inviteSvc.RevokeInvite(ctx, id); err != nil {
+		return err
+	}
+	i, err := l.inviteSvc.FindInviteByID(ctx, id)
+	if err != nil {
+		l.logger.Warn("Failed to find revoked invite", zap.Stringer("invite_id", id), zap.Error(err))
+		return nil
+	}
+	l.record(ctx, i, newOperationLogEntry(ctx, influxdb.OperationDelete, "Invite Revoked", nil))
+	return nil
+}
+
+func (l *InviteOpLogger) AcceptInvite(ctx context.Context, a influxdb.InviteAccept) (*influxdb.Invite, error) {
+	i, err := l.inviteSvc.AcceptInvite(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	// The invite is accepted without an authorization, by the invited user.
+	e := newOperationLogEntry(ctx, influxdb.OperationUpdate, "Invite Accepted", []string{"status"})
+	e.UserID = i.UserID
+	l.record(ctx, i, e)
+	return i, nil
+}
+
+func (l *InviteOpLogger) record(ctx context.Context, i *influxdb.Invite, e *influxdb.OperationLogEntry) {
+	e.Description += ": " + i.Name
+	if err := l.opLog.AddOrganizationLogEntry(ctx, i.OrgID, e); err != nil {
+		l.logger.Warn("Failed to record invite operation log entry", zap.Stringer("org_id", i.OrgID), zap.Stringer("invite_id", i.ID), zap.String("operation", e.Operation), zap.Error(err))
+	}
+}
@@ -33,6 +33,16 @@ func (s *AuthedOrgService) FindOrganizationByID(ctx context.Context, id influxdb
 	return s.s.FindOrganizationByID(ctx, id)
 }
 
+// FindOrganizationsByIDs checks to see if the authorizer on context has read access to each of the ids provided.
+func (s *AuthedOrgService) FindOrganizationsByIDs(ctx context.Context, ids []influxdb.ID) ([]*influxdb.Organization, error) {
+	for _, id := range ids {
+		if _, _, err := authorizer.AuthorizeReadOrg(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.s.FindOrganizationsByIDs(ctx, ids)
+}
+
 // FindOrganization retrieves the organization and checks to see if the authorizer on context has read access to the org.
 func (s *AuthedOrgService) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
 	o, err := s.s.FindOrganization(ctx, filter)
@@ -82,6 +92,14 @@ func (s *AuthedOrgService) UpdateOrganization(ctx context.Context, id influxdb.I
 	return s.s.UpdateOrganization(ctx, id, upd)
 }
 
+// RenameOrganizationSafely checks to see if the authorizer on context has write access to the organization provided.
+func (s *AuthedOrgService) RenameOrganizationSafely(ctx context.Context, id influxdb.ID, name string) (*influxdb.Organization, []influxdb.OrganizationReference, error) {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, id); err != nil {
+		return nil, nil, err
+	}
+	return s.s.RenameOrganizationSafely(ctx, id, name)
+}
+
 // DeleteOrganization checks to see if the authorizer on context has write access to the organization provided.
 func (s *AuthedOrgService) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
 	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, id); err != nil {
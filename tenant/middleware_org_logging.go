@@ -49,6 +49,18 @@ func (l *OrgLogger) FindOrganizationByID(ctx context.Context, id influxdb.ID) (u
 	return l.orgService.FindOrganizationByID(ctx, id)
 }
 
+func (l *OrgLogger) FindOrganizationsByIDs(ctx context.Context, ids []influxdb.ID) (orgs []*influxdb.Organization, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to find orgs by IDs", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("orgs find by IDs", dur)
+	}(time.Now())
+	return l.orgService.FindOrganizationsByIDs(ctx, ids)
+}
+
 func (l *OrgLogger) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (u *influxdb.Organization, err error) {
 	defer func(start time.Time) {
 		dur := zap.Duration("took", time.Since(start))
@@ -86,6 +98,18 @@ func (l *OrgLogger) UpdateOrganization(ctx context.Context, id influxdb.ID, upd
 	return l.orgService.UpdateOrganization(ctx, id, upd)
 }
 
+func (l *OrgLogger) RenameOrganizationSafely(ctx context.Context, id influxdb.ID, name string) (o *influxdb.Organization, refs []influxdb.OrganizationReference, err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to rename org", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("org rename", zap.Int("references", len(refs)), dur)
+	}(time.Now())
+	return l.orgService.RenameOrganizationSafely(ctx, id, name)
+}
+
 func (l *OrgLogger) DeleteOrganization(ctx context.Context, id influxdb.ID) (err error) {
 	defer func(start time.Time) {
 		dur := zap.Duration("took", time.Since(start))
@@ -32,6 +32,12 @@ func (m *OrgMetrics) FindOrganizationByID(ctx context.Context, id influxdb.ID) (
 	return org, rec(err)
 }
 
+func (m *OrgMetrics) FindOrganizationsByIDs(ctx context.Context, ids []influxdb.ID) ([]*influxdb.Organization, error) {
+	rec := m.rec.Record("find_orgs_by_ids")
+	orgs, err := m.orgService.FindOrganizationsByIDs(ctx, ids)
+	return orgs, rec(err)
+}
+
 func (m *OrgMetrics) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
 	rec := m.rec.Record("find_org")
 	org, err := m.orgService.FindOrganization(ctx, filter)
@@ -56,6 +62,12 @@ func (m *OrgMetrics) UpdateOrganization(ctx context.Context, id influxdb.ID, upd
 	return updatedOrg, rec(err)
 }
 
+func (m *OrgMetrics) RenameOrganizationSafely(ctx context.Context, id influxdb.ID, name string) (*influxdb.Organization, []influxdb.OrganizationReference, error) {
+	rec := m.rec.Record("rename_org")
+	o, refs, err := m.orgService.RenameOrganizationSafely(ctx, id, name)
+	return o, refs, rec(err)
+}
+
 func (m *OrgMetrics) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
 	rec := m.rec.Record("delete_org")
 	err := m.orgService.DeleteOrganization(ctx, id)
@@ -0,0 +1,116 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"go.uber.org/zap"
+)
+
+// OrgOperationLogWriter records entries in organization operation logs.
+type OrgOperationLogWriter interface {
+	AddOrganizationLogEntry(ctx context.Context, id influxdb.ID, e *influxdb.OperationLogEntry) error
+}
+
+// OrgOpLogger is a service middleware which records successful changes to
+// organizations in their operation logs. Recording is best-effort: failures
+// are logged and never returned to the caller.
+type OrgOpLogger struct {
+	logger     *zap.Logger
+	opLog      OrgOperationLogWriter
+	orgService influxdb.OrganizationService
+}
+
+var _ influxdb.OrganizationService = (*OrgOpLogger)(nil)
+
+// NewOrgOpLogger returns an operation log middleware for the Organization Service.
+func NewOrgOpLogger(log *zap.Logger, opLog OrgOperationLogWriter, s influxdb.OrganizationService) *OrgOpLogger {
+	return &OrgOpLogger{
+		logger:     log,
+		opLog:      opLog,
+		orgService: s,
+	}
+}
+
+func (l *OrgOpLogger) FindOrganizationByID(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+	return l.orgService.FindOrganizationByID(ctx, id)
+}
+
+func (l *OrgOpLogger) FindOrganizationsByIDs(ctx context.Context, ids []influxdb.ID) ([]*influxdb.Organization, error) {
+	return l.orgService.FindOrganizationsByIDs(ctx, ids)
+}
+
+func (l *OrgOpLogger) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+	return l.orgService.FindOrganization(ctx, filter)
+}
+
+func (l *OrgOpLogger) FindOrganizations(ctx context.Context, filter influxdb.OrganizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+	return l.orgService.FindOrganizations(ctx, filter, opt...)
+}
+
+func (l *OrgOpLogger) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
+	if err := l.orgService.CreateOrganization(ctx, o); err != nil {
+		return err
+	}
+	l.record(ctx, o.ID, newOperationLogEntry(ctx, influxdb.OperationCreate, "Organization Created", nil))
+	return nil
+}
+
+func (l *OrgOpLogger) UpdateOrganization(ctx context.Context, id influxdb.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+	o, err := l.orgService.UpdateOrganization(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+	l.record(ctx, id, newOperationLogEntry(ctx, influxdb.OperationUpdate, "Organization Updated", orgUpdateFields(upd)))
+	return o, nil
+}
+
+func (l *OrgOpLogger) RenameOrganizationSafely(ctx context.Context, id influxdb.ID, name string) (*influxdb.Organization, []influxdb.OrganizationReference, error) {
+	o, refs, err := l.orgService.RenameOrganizationSafely(ctx, id, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	l.record(ctx, id, newOperationLogEntry(ctx, influxdb.OperationUpdate, "Organization Renamed", []string{"name"}))
+	return o, refs, nil
+}
+
+func (l *OrgOpLogger) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
+	if err := l.orgService.DeleteOrganization(ctx, id); err != nil {
+		return err
+	}
+	l.record(ctx, id, newOperationLogEntry(ctx, influxdb.OperationDelete, "Organization Deleted", nil))
+	return nil
+}
+
+func (l *OrgOpLogger) record(ctx context.Context, id influxdb.ID, e *influxdb.OperationLogEntry) {
+	if err := l.opLog.AddOrganizationLogEntry(ctx, id, e); err != nil {
+		l.logger.Warn("Failed to record organization operation log entry", zap.Stringer("org_id", id), zap.String("operation", e.Operation), zap.Error(err))
+	}
+}
+
+// orgUpdateFields returns the names of the fields set in an organization update.
+func orgUpdateFields(upd influxdb.OrganizationUpdate) []string {
+	var fields []string
+	if upd.Name != nil {
+		fields = append(fields, "name")
+	}
+	if upd.Description != nil {
+		fields = append(fields, "description")
+	}
+	return fields
+}
+
+// newOperationLogEntry returns an operation log entry for the user authorized on ctx, if any.
+func newOperationLogEntry(ctx context.Context, operation, description string, fields []string) *influxdb.OperationLogEntry {
+	e := &influxdb.OperationLogEntry{
+		Description: description,
+		Operation:   operation,
+		Fields:      fields,
+	}
+	if a, err := icontext.GetAuthorizer(ctx); err == nil {
+		// Add the user to the log if you can, but don't error if its not there.
+		e.UserID = a.GetUserID()
+	}
+	return e
+}
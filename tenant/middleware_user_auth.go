@@ -5,6 +5,7 @@ import (
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/authorizer"
+	icontext "github.com/influxdata/influxdb/v2/context"
 )
 
 var _ influxdb.UserService = (*AuthedUserService)(nil)
@@ -115,3 +116,30 @@ func (s *AuthedPasswordService) ComparePassword(ctx context.Context, userID infl
 func (s *AuthedPasswordService) CompareAndSetPassword(ctx context.Context, userID influxdb.ID, old string, new string) error {
 	panic("not implemented")
 }
+
+// AuthedUserPermissionService is a new authorization middleware for a user
+// permission service.
+type AuthedUserPermissionService struct {
+	s influxdb.UserPermissionService
+}
+
+// NewAuthedUserPermissionService wraps an existing user permission service
+// with auth middleware.
+func NewAuthedUserPermissionService(svc influxdb.UserPermissionService) *AuthedUserPermissionService {
+	return &AuthedUserPermissionService{s: svc}
+}
+
+// FindUserPermissions checks that the authorizer on context is the user or an
+// operator, as the permissions of a user span organizations.
+func (s *AuthedUserPermissionService) FindUserPermissions(ctx context.Context, userID influxdb.ID, opt influxdb.FindOptions) (*influxdb.UserPermissions, error) {
+	a, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.GetUserID() != userID {
+		if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+			return nil, err
+		}
+	}
+	return s.s.FindUserPermissions(ctx, userID, opt)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
 	"github.com/influxdata/influxdb/v2/kit/metric"
 	"github.com/influxdata/influxdb/v2/label"
 	"github.com/influxdata/influxdb/v2/secret"
@@ -60,18 +61,24 @@ func NewSystem(store *Store, log *zap.Logger, reg prometheus.Registerer, metricO
 	return ts
 }
 
-func (ts *Service) NewOrgHTTPHandler(log *zap.Logger, secretSvc influxdb.SecretService) *OrgHandler {
+func (ts *Service) NewOrgHTTPHandler(log *zap.Logger, secretSvc influxdb.SecretService, orgLogSvc influxdb.OrganizationOperationLogService, inviteSvc influxdb.InviteService) *OrgHandler {
 	secretHandler := secret.NewHandler(log, "id", secret.NewAuthedService(secretSvc))
 	urmHandler := NewURMHandler(log.With(zap.String("handler", "urm")), influxdb.OrgsResourceType, "id", ts.UserService, NewAuthedURMService(ts.OrganizationService, ts.UserResourceMappingService))
-	return NewHTTPOrgHandler(log.With(zap.String("handler", "org")), NewAuthedOrgService(ts.OrganizationService), urmHandler, secretHandler)
+	inviteHandler := NewHTTPOrgInviteHandler(log.With(zap.String("handler", "invite")), "id", NewAuthedInviteService(inviteSvc))
+	return NewHTTPOrgHandler(log.With(zap.String("handler", "org")), NewAuthedOrgService(ts.OrganizationService), orgLogSvc, urmHandler, secretHandler, inviteHandler)
 }
 
-func (ts *Service) NewBucketHTTPHandler(log *zap.Logger, labelSvc influxdb.LabelService) *BucketHandler {
+func (ts *Service) NewBucketHTTPHandler(log *zap.Logger, labelSvc influxdb.LabelService, bucketLogSvc influxdb.BucketOperationLogService) *BucketHandler {
 	urmHandler := NewURMHandler(log.With(zap.String("handler", "urm")), influxdb.BucketsResourceType, "id", ts.UserService, NewAuthedURMService(ts.OrganizationService, ts.UserResourceMappingService))
 	labelHandler := label.NewHTTPEmbeddedHandler(log.With(zap.String("handler", "label")), influxdb.BucketsResourceType, labelSvc)
-	return NewHTTPBucketHandler(log.With(zap.String("handler", "bucket")), NewAuthedBucketService(ts.BucketService), labelSvc, urmHandler, labelHandler)
+	return NewHTTPBucketHandler(log.With(zap.String("handler", "bucket")), NewAuthedBucketService(ts.BucketService), bucketLogSvc, labelSvc, urmHandler, labelHandler)
 }
 
-func (ts *Service) NewUserHTTPHandler(log *zap.Logger) *UserHandler {
-	return NewHTTPUserHandler(log.With(zap.String("handler", "user")), NewAuthedUserService(ts.UserService), NewAuthedPasswordService(ts.PasswordsService))
+func (ts *Service) NewInviteHTTPHandler(log *zap.Logger, inviteSvc influxdb.InviteService) *InviteHandler {
+	return NewHTTPInviteHandler(log.With(zap.String("handler", "invite")), NewAuthedInviteService(inviteSvc))
+}
+
+func (ts *Service) NewUserHTTPHandler(log *zap.Logger, authSvc influxdb.AuthorizationService, orgIDResolver authorizer.OrgIDResolver) *UserHandler {
+	permSvc := NewAuthedUserPermissionService(NewUserPermissionService(ts, authSvc, orgIDResolver))
+	return NewHTTPUserHandler(log.With(zap.String("handler", "user")), NewAuthedUserService(ts.UserService), NewAuthedPasswordService(ts.PasswordsService), permSvc)
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/kv"
 )
 
@@ -23,6 +24,7 @@ func NewBucketSvc(st *Store, svc *Service) *BucketSvc {
 
 // FindBucketByID returns a single bucket by ID.
 func (s *BucketSvc) FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+	ctx = kv.WithCaller(ctx, "tenant.FindBucketByID")
 	var bucket *influxdb.Bucket
 	err := s.store.View(ctx, func(tx kv.Tx) error {
 		b, err := s.store.GetBucket(ctx, tx, id)
@@ -41,6 +43,7 @@ func (s *BucketSvc) FindBucketByID(ctx context.Context, id influxdb.ID) (*influx
 }
 
 func (s *BucketSvc) FindBucketByName(ctx context.Context, orgID influxdb.ID, name string) (*influxdb.Bucket, error) {
+	ctx = kv.WithCaller(ctx, "tenant.FindBucketByName")
 	var bucket *influxdb.Bucket
 	err := s.store.View(ctx, func(tx kv.Tx) error {
 		b, err := s.store.GetBucketByName(ctx, tx, orgID, name)
@@ -143,10 +146,26 @@ func (s *BucketSvc) CreateBucket(ctx context.Context, b *influxdb.Bucket) error
 	}
 
 	// make sure the org exists
-	if _, err := s.svc.FindOrganizationByID(ctx, b.OrgID); err != nil {
+	org, err := s.svc.FindOrganizationByID(ctx, b.OrgID)
+	if err != nil {
 		return err
 	}
 
+	if b.Type == influxdb.BucketTypeUser {
+		if err := org.BucketDefaults.Apply(b, org.Name); err != nil {
+			return err
+		}
+	} else if b.RetentionPeriod == influxdb.DefaultRetention {
+		b.RetentionPeriod = influxdb.InfiniteRetention
+	}
+	if b.RetentionPeriod < 0 || b.ShardGroupDuration < 0 {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "bucket retention period and shard group duration must not be negative",
+		}
+	}
+	b.RetentionPeriod = s.store.retentionPeriod(b.RetentionPeriod)
+
 	return s.store.Update(ctx, func(tx kv.Tx) error {
 		return s.store.CreateBucket(ctx, tx, b)
 	})
@@ -155,6 +174,11 @@ func (s *BucketSvc) CreateBucket(ctx context.Context, b *influxdb.Bucket) error
 // UpdateBucket updates a single bucket with changeset.
 // Returns the new bucket state after update.
 func (s *BucketSvc) UpdateBucket(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+	if upd.RetentionPeriod != nil {
+		d := s.store.retentionPeriod(*upd.RetentionPeriod)
+		upd.RetentionPeriod = &d
+	}
+
 	var bucket *influxdb.Bucket
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		b, err := s.store.UpdateBucket(ctx, tx, id, upd)
@@ -174,6 +198,7 @@ func (s *BucketSvc) UpdateBucket(ctx context.Context, id influxdb.ID, upd influx
 
 // DeleteBucket removes a bucket by ID.
 func (s *BucketSvc) DeleteBucket(ctx context.Context, id influxdb.ID) error {
+	dryRun := icontext.IsDryRun(ctx)
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		bucket, err := s.store.GetBucket(ctx, tx, id)
 		if err != nil {
@@ -183,13 +208,19 @@ func (s *BucketSvc) DeleteBucket(ctx context.Context, id influxdb.ID) error {
 			// TODO: I think we should allow bucket deletes but maybe im wrong.
 			return errDeleteSystemBucket
 		}
+		if bucket.DeleteProtected {
+			return influxdb.ErrBucketDeleteProtected(bucket)
+		}
+		if dryRun {
+			return nil
+		}
 
 		if err := s.store.DeleteBucket(ctx, tx, id); err != nil {
 			return err
 		}
 		return nil
 	})
-	if err != nil {
+	if err != nil || dryRun {
 		return err
 	}
 	return s.removeResourceRelations(ctx, id)
@@ -3,11 +3,14 @@ package tenant_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/tenant"
 	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInmemBucketService(t *testing.T) {
@@ -139,3 +142,127 @@ func TestSystemBucketsInNameFind(t *testing.T) {
 		t.Fatal("failed to return a single bucket when doing a bucket lookup by name")
 	}
 }
+
+func TestBucketService_DeleteProtected(t *testing.T) {
+	s, closeS, err := NewTestInmemStore(t)
+	require.NoError(t, err)
+	defer closeS()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "audit", RetentionPeriod: time.Hour}
+	require.NoError(t, svc.CreateBucket(ctx, bkt))
+
+	protect, unprotect := true, false
+	_, err = svc.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{DeleteProtected: &protect})
+	require.NoError(t, err)
+
+	err = svc.DeleteBucket(ctx, bkt.ID)
+	require.Equal(t, influxdb.EForbidden, influxdb.ErrorCode(err))
+	assert.Contains(t, err.Error(), "deleteProtected")
+
+	// Clearing the flag must be its own update.
+	desc := "audit log"
+	_, err = svc.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{DeleteProtected: &unprotect, Description: &desc})
+	require.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+
+	updated, err := svc.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{DeleteProtected: &unprotect})
+	require.NoError(t, err)
+	assert.False(t, updated.DeleteProtected)
+	assert.Equal(t, time.Hour, updated.RetentionPeriod)
+
+	require.NoError(t, svc.DeleteBucket(ctx, bkt.ID))
+}
+
+func TestBucketService_OrgBucketDefaults(t *testing.T) {
+	s, closeS, err := NewTestInmemStore(t)
+	require.NoError(t, err)
+	defer closeS()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s))
+	org := &influxdb.Organization{Name: "team"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+
+	// Buckets keep infinite retention until the org sets defaults.
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "before", RetentionPeriod: influxdb.DefaultRetention}
+	require.NoError(t, svc.CreateBucket(ctx, bkt))
+	assert.Equal(t, time.Duration(influxdb.InfiniteRetention), bkt.RetentionPeriod)
+
+	_, err = svc.UpdateOrganization(ctx, org.ID, influxdb.OrganizationUpdate{
+		BucketDefaults: &influxdb.BucketDefaults{RetentionPeriod: influxdb.Duration{Duration: time.Minute}},
+	})
+	require.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+
+	defaults := influxdb.BucketDefaults{
+		RetentionPeriod:     influxdb.Duration{Duration: 30 * 24 * time.Hour},
+		ShardGroupDuration:  influxdb.Duration{Duration: 24 * time.Hour},
+		DescriptionTemplate: "{{.Name}} of {{.Org}}",
+	}
+	updated, err := svc.UpdateOrganization(ctx, org.ID, influxdb.OrganizationUpdate{BucketDefaults: &defaults})
+	require.NoError(t, err)
+	assert.Equal(t, defaults, updated.BucketDefaults)
+
+	found, err := svc.FindOrganizationByID(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, defaults, found.BucketDefaults)
+
+	t.Run("unset fields use defaults", func(t *testing.T) {
+		bkt := &influxdb.Bucket{OrgID: org.ID, Name: "metrics", RetentionPeriod: influxdb.DefaultRetention}
+		require.NoError(t, svc.CreateBucket(ctx, bkt))
+		assert.Equal(t, 30*24*time.Hour, bkt.RetentionPeriod)
+		assert.Equal(t, 24*time.Hour, bkt.ShardGroupDuration)
+		assert.Equal(t, "metrics of team", bkt.Description)
+	})
+
+	t.Run("explicit values win", func(t *testing.T) {
+		bkt := &influxdb.Bucket{OrgID: org.ID, Name: "archive", RetentionPeriod: influxdb.InfiniteRetention, ShardGroupDuration: 7 * 24 * time.Hour, Description: "kept forever"}
+		require.NoError(t, svc.CreateBucket(ctx, bkt))
+		assert.Equal(t, time.Duration(influxdb.InfiniteRetention), bkt.RetentionPeriod)
+		assert.Equal(t, 7*24*time.Hour, bkt.ShardGroupDuration)
+		assert.Equal(t, "kept forever", bkt.Description)
+	})
+
+	t.Run("default shard group duration longer than retention", func(t *testing.T) {
+		bkt := &influxdb.Bucket{OrgID: org.ID, Name: "short", RetentionPeriod: 12 * time.Hour}
+		require.NoError(t, svc.CreateBucket(ctx, bkt))
+		assert.Zero(t, bkt.ShardGroupDuration)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		bad := influxdb.BucketDefaults{DescriptionTemplate: "{{.Owner}}"}
+		_, err := svc.UpdateOrganization(ctx, org.ID, influxdb.OrganizationUpdate{BucketDefaults: &bad})
+		require.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+	})
+}
+
+func TestBucketService_MinRetention(t *testing.T) {
+	s, closeS, err := NewTestInmemStore(t)
+	require.NoError(t, err)
+	defer closeS()
+
+	ctx := context.Background()
+	svc := tenant.NewService(tenant.NewStore(s, tenant.WithMinRetention(time.Hour)))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+
+	short := &influxdb.Bucket{OrgID: org.ID, Name: "short", RetentionPeriod: time.Minute}
+	require.NoError(t, svc.CreateBucket(ctx, short))
+	assert.Equal(t, time.Hour, short.RetentionPeriod)
+
+	infinite := &influxdb.Bucket{OrgID: org.ID, Name: "infinite", RetentionPeriod: influxdb.InfiniteRetention}
+	require.NoError(t, svc.CreateBucket(ctx, infinite))
+	assert.Equal(t, time.Duration(influxdb.InfiniteRetention), infinite.RetentionPeriod)
+
+	rp := 10 * time.Minute
+	updated, err := svc.UpdateBucket(ctx, infinite.ID, influxdb.BucketUpdate{RetentionPeriod: &rp})
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, updated.RetentionPeriod)
+
+	rp = 2 * time.Hour
+	updated, err = svc.UpdateBucket(ctx, infinite.ID, influxdb.BucketUpdate{RetentionPeriod: &rp})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, updated.RetentionPeriod)
+}
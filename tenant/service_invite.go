@@ -0,0 +1,252 @@
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/rand"
+)
+
+// inviteTokenSize is the number of random bytes in a setup token.
+const inviteTokenSize = 32
+
+var _ influxdb.InviteService = (*InviteSvc)(nil)
+
+// InviteSvc invites users to organizations. Invited users are created
+// inactive and without a password, and only become members of the
+// organization once they accept the invitation.
+type InviteSvc struct {
+	store    *Store
+	tokenGen influxdb.TokenGenerator
+}
+
+// NewInviteService returns a service inviting users to the organizations of
+// svc.
+func NewInviteService(svc *Service) *InviteSvc {
+	return &InviteSvc{
+		store:    svc.store,
+		tokenGen: rand.NewTokenGenerator(inviteTokenSize),
+	}
+}
+
+// hashInviteToken returns the hash of a setup token, by which its invite is
+// stored.
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateInvite creates an inactive user and a pending invite for it to join
+// the organization.
+func (s *InviteSvc) CreateInvite(ctx context.Context, c influxdb.InviteCreate) (*influxdb.InviteToken, error) {
+	if c.Name == "" {
+		return nil, ErrInviteNameRequired
+	}
+	if c.Role == "" {
+		c.Role = influxdb.Member
+	}
+	if c.Role != influxdb.Owner && c.Role != influxdb.Member {
+		return nil, ErrInviteRoleInvalid(c.Role)
+	}
+	if c.TTL <= 0 {
+		c.TTL = influxdb.DefaultInviteTTL
+	}
+
+	token, err := s.tokenGen.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.store.now()
+	invite := &influxdb.Invite{
+		OrgID:     c.OrgID,
+		Name:      c.Name,
+		Role:      c.Role,
+		Status:    influxdb.InvitePending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(c.TTL),
+	}
+	if a, err := icontext.GetAuthorizer(ctx); err == nil {
+		invite.CreatedBy = a.GetUserID()
+	}
+
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		if _, err := s.store.GetOrg(ctx, tx, c.OrgID); err != nil {
+			return err
+		}
+
+		u := &influxdb.User{Name: c.Name, Status: influxdb.Inactive}
+		if err := s.store.CreateUser(ctx, tx, u); err != nil {
+			return err
+		}
+		invite.UserID = u.ID
+
+		id, err := s.store.generateSafeID(ctx, tx, inviteBucket, s.store.IDGen)
+		if err != nil {
+			return err
+		}
+		invite.ID = id
+
+		return s.store.PutInvite(ctx, tx, &storedInvite{Invite: invite, TokenHash: hashInviteToken(token)})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &influxdb.InviteToken{Invite: invite, Token: token}, nil
+}
+
+// FindInviteByID returns a single invite by ID.
+func (s *InviteSvc) FindInviteByID(ctx context.Context, id influxdb.ID) (*influxdb.Invite, error) {
+	var invite *influxdb.Invite
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		i, err := s.store.GetInvite(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		invite = i.Invite
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// FindInvites returns the invites of an organization.
+func (s *InviteSvc) FindInvites(ctx context.Context, orgID influxdb.ID) ([]*influxdb.Invite, error) {
+	var invites []*influxdb.Invite
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		is, err := s.store.ListInvites(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		for _, i := range is {
+			invites = append(invites, i.Invite)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// ResendInvite replaces the setup token of a pending invite, so that the
+// token sent before can no longer be used, and restarts its expiry.
+func (s *InviteSvc) ResendInvite(ctx context.Context, id influxdb.ID) (*influxdb.InviteToken, error) {
+	token, err := s.tokenGen.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	var invite *influxdb.Invite
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		i, err := s.store.GetInvite(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if i.Status != influxdb.InvitePending {
+			return ErrInviteNotPending
+		}
+
+		ttl := i.ExpiresAt.Sub(i.CreatedAt)
+		if ttl <= 0 {
+			ttl = influxdb.DefaultInviteTTL
+		}
+		i.ExpiresAt = s.store.now().Add(ttl)
+		i.TokenHash = hashInviteToken(token)
+		invite = i.Invite
+		return s.store.PutInvite(ctx, tx, i)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &influxdb.InviteToken{Invite: invite, Token: token}, nil
+}
+
+// RevokeInvite revokes a pending invite and deletes the user created for it,
+// which has never been active.
+func (s *InviteSvc) RevokeInvite(ctx context.Context, id influxdb.ID) error {
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		i, err := s.store.GetInvite(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if i.Status != influxdb.InvitePending {
+			return ErrInviteNotPending
+		}
+
+		if err := s.store.DeleteUser(ctx, tx, i.UserID); err != nil && err != ErrUserNotFound {
+			return err
+		}
+
+		i.Status = influxdb.InviteRevoked
+		i.TokenHash = ""
+		return s.store.PutInvite(ctx, tx, i)
+	})
+}
+
+// AcceptInvite sets the password of the invited user, activates it and makes
+// it a member or owner of the organization. The setup token is discarded, so
+// it cannot be used again.
+func (s *InviteSvc) AcceptInvite(ctx context.Context, a influxdb.InviteAccept) (*influxdb.Invite, error) {
+	if a.Token == "" {
+		return nil, ErrInviteTokenInvalid
+	}
+	if len(a.Password) < 8 {
+		return nil, EShortPassword
+	}
+	passHash, err := encryptPassword(a.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var invite *influxdb.Invite
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		i, err := s.store.GetInviteByTokenHash(ctx, tx, hashInviteToken(a.Token))
+		if err == ErrInviteNotFound {
+			return ErrInviteTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		now := s.store.now()
+		if i.Status != influxdb.InvitePending || i.Expired(now) {
+			return ErrInviteTokenInvalid
+		}
+
+		if err := s.store.SetPassword(ctx, tx, i.UserID, passHash); err != nil {
+			return err
+		}
+		active := influxdb.Active
+		if _, err := s.store.UpdateUser(ctx, tx, i.UserID, influxdb.UserUpdate{Status: &active}); err != nil {
+			return err
+		}
+		err = s.store.CreateURM(ctx, tx, &influxdb.UserResourceMapping{
+			UserID:       i.UserID,
+			UserType:     i.Role,
+			ResourceType: influxdb.OrgsResourceType,
+			ResourceID:   i.OrgID,
+		})
+		if err != nil {
+			return err
+		}
+
+		i.Status = influxdb.InviteAccepted
+		i.AcceptedAt = &now
+		i.TokenHash = ""
+		invite = i.Invite
+		return s.store.PutInvite(ctx, tx, i)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
@@ -0,0 +1,160 @@
+package tenant_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestInviteService(t *testing.T, now *time.Time) (*tenant.Service, *tenant.InviteSvc, *influxdb.Organization) {
+	t.Helper()
+
+	s, closeS, err := NewTestInmemStore(t)
+	require.NoError(t, err)
+	t.Cleanup(closeS)
+
+	ts := tenant.NewService(tenant.NewStore(s, tenant.WithNow(func() time.Time { return *now })))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, ts.CreateOrganization(context.Background(), org))
+	return ts, tenant.NewInviteService(ts), org
+}
+
+func TestInviteService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("accept", func(t *testing.T) {
+		ts, svc, org := newTestInviteService(t, &now)
+
+		invite, err := svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID, Name: "jane", Role: influxdb.Owner})
+		require.NoError(t, err)
+		assert.NotEmpty(t, invite.Token)
+		assert.Equal(t, influxdb.InvitePending, invite.Status)
+		assert.Equal(t, now.Add(influxdb.DefaultInviteTTL), invite.ExpiresAt)
+
+		u, err := ts.FindUserByID(ctx, invite.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, influxdb.Inactive, u.Status)
+
+		_, err = svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: "wrong", Password: "password"})
+		assert.Equal(t, influxdb.EForbidden, influxdb.ErrorCode(err))
+		_, err = svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: invite.Token, Password: "short"})
+		assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+
+		accepted, err := svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: invite.Token, Password: "password"})
+		require.NoError(t, err)
+		assert.Equal(t, influxdb.InviteAccepted, accepted.Status)
+		require.NotNil(t, accepted.AcceptedAt)
+
+		u, err = ts.FindUserByID(ctx, invite.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, influxdb.Active, u.Status)
+		require.NoError(t, ts.ComparePassword(ctx, u.ID, "password"))
+
+		urms, _, err := ts.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{UserID: u.ID})
+		require.NoError(t, err)
+		require.Len(t, urms, 1)
+		assert.Equal(t, org.ID, urms[0].ResourceID)
+		assert.Equal(t, influxdb.Owner, urms[0].UserType)
+
+		// The setup token can only be used once.
+		_, err = svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: invite.Token, Password: "password2"})
+		assert.Equal(t, influxdb.EForbidden, influxdb.ErrorCode(err))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now := now
+		_, svc, org := newTestInviteService(t, &now)
+
+		invite, err := svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID, Name: "jane", TTL: time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, influxdb.Member, invite.Role)
+
+		now = now.Add(time.Hour)
+		_, err = svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: invite.Token, Password: "password"})
+		assert.Equal(t, influxdb.EForbidden, influxdb.ErrorCode(err))
+
+		// Resending replaces the token and restarts the expiry.
+		resent, err := svc.ResendInvite(ctx, invite.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, invite.Token, resent.Token)
+		assert.Equal(t, now.Add(time.Hour), resent.ExpiresAt)
+
+		_, err = svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: invite.Token, Password: "password"})
+		assert.Equal(t, influxdb.EForbidden, influxdb.ErrorCode(err))
+		_, err = svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: resent.Token, Password: "password"})
+		require.NoError(t, err)
+
+		_, err = svc.ResendInvite(ctx, invite.ID)
+		assert.Equal(t, influxdb.EConflict, influxdb.ErrorCode(err))
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		ts, svc, org := newTestInviteService(t, &now)
+
+		invite, err := svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID, Name: "jane"})
+		require.NoError(t, err)
+		require.NoError(t, svc.RevokeInvite(ctx, invite.ID))
+
+		_, err = ts.FindUserByID(ctx, invite.UserID)
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+		_, err = svc.AcceptInvite(ctx, influxdb.InviteAccept{Token: invite.Token, Password: "password"})
+		assert.Equal(t, influxdb.EForbidden, influxdb.ErrorCode(err))
+		assert.Equal(t, influxdb.EConflict, influxdb.ErrorCode(svc.RevokeInvite(ctx, invite.ID)))
+
+		invites, err := svc.FindInvites(ctx, org.ID)
+		require.NoError(t, err)
+		require.Len(t, invites, 1)
+		assert.Equal(t, influxdb.InviteRevoked, invites[0].Status)
+
+		// The name of the revoked invite's user is free again.
+		_, err = svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID, Name: "jane"})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, svc, org := newTestInviteService(t, &now)
+
+		_, err := svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID})
+		assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+		_, err = svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID, Name: "jane", Role: "admin"})
+		assert.Equal(t, influxdb.EInvalid, influxdb.ErrorCode(err))
+		_, err = svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID + 1, Name: "jane"})
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+	})
+}
+
+func TestInviteOpLogger(t *testing.T) {
+	ts, opLogSvc := newTestOpLogService(t)
+	svc := tenant.NewInviteOpLogger(zaptest.NewLogger(t), opLogSvc, tenant.NewInviteService(ts))
+
+	admin := &influxdb.User{Name: "admin"}
+	require.NoError(t, ts.CreateUser(context.Background(), admin))
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{UserID: admin.ID})
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, ts.CreateOrganization(ctx, org))
+
+	invite, err := svc.CreateInvite(ctx, influxdb.InviteCreate{OrgID: org.ID, Name: "jane"})
+	require.NoError(t, err)
+	invite, err = svc.ResendInvite(ctx, invite.ID)
+	require.NoError(t, err)
+	_, err = svc.AcceptInvite(context.Background(), influxdb.InviteAccept{Token: invite.Token, Password: "password"})
+	require.NoError(t, err)
+
+	log, n, err := opLogSvc.GetOrganizationOperationLog(ctx, org.ID, influxdb.DefaultOperationLogFindOptions)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	// The log is returned newest first.
+	assert.Equal(t, "Invite Accepted: jane", log[0].Description)
+	assert.Equal(t, invite.UserID, log[0].UserID)
+	assert.Equal(t, "Invite Resent: jane", log[1].Description)
+	assert.Equal(t, "Invite Created: jane", log[2].Description)
+	assert.Equal(t, admin.ID, log[2].UserID)
+}
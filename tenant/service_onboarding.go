@@ -110,18 +110,24 @@ func (s *OnboardService) onboardUser(ctx context.Context, req *influxdb.Onboardi
 	org := &influxdb.Organization{
 		Name: req.Org,
 	}
+	if req.BucketDefaults != nil {
+		org.BucketDefaults = *req.BucketDefaults
+	}
 
 	if err := s.service.CreateOrganization(ctx, org); err != nil {
 		return nil, err
 	}
 
-	// create orgs buckets
+	// create orgs buckets, with the org's default retention unless one is given
 	ub := &influxdb.Bucket{
 		OrgID:           org.ID,
 		Name:            req.Bucket,
 		Type:            influxdb.BucketTypeUser,
 		RetentionPeriod: req.RetentionPeriod,
 	}
+	if ub.RetentionPeriod == 0 {
+		ub.RetentionPeriod = influxdb.DefaultRetention
+	}
 
 	if err := s.service.CreateBucket(ctx, ub); err != nil {
 		return nil, err
@@ -133,8 +139,12 @@ func (s *OnboardService) onboardUser(ctx context.Context, req *influxdb.Onboardi
 
 	// bolt doesn't lock per collection or record so we have to close our transaction
 	// before we can reach out to the auth service.
+	description := req.TokenDescription
+	if description == "" {
+		description = fmt.Sprintf("%s's Token", req.User)
+	}
 	result.Auth = &influxdb.Authorization{
-		Description: fmt.Sprintf("%s's Token", req.User),
+		Description: description,
 		Permissions: permFn(result.Org.ID, result.User.ID),
 		Token:       req.Token,
 		UserID:      result.User.ID,
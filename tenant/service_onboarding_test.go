@@ -200,3 +200,28 @@ func TestOnboardService_RetentionPolicy(t *testing.T) {
 
 	assert.Equal(t, onboard.Bucket.RetentionPeriod, retention, "Retention policy should pass through")
 }
+
+func TestOnboardService_BucketDefaults(t *testing.T) {
+	s, _, _ := NewTestInmemStore(t)
+	ten := tenant.NewService(tenant.NewStore(s))
+
+	authStore, err := authorization.NewStore(s)
+	require.NoError(t, err)
+	svc := tenant.NewOnboardService(ten, authorization.NewService(authStore, ten))
+
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{
+		UserID: 123,
+	})
+
+	defaults := &influxdb.BucketDefaults{RetentionPeriod: influxdb.Duration{Duration: 30 * 24 * time.Hour}}
+	onboard, err := svc.OnboardInitialUser(ctx, &influxdb.OnboardingRequest{
+		User:           "name",
+		Org:            "name",
+		Bucket:         "name",
+		BucketDefaults: defaults,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, *defaults, onboard.Org.BucketDefaults)
+	assert.Equal(t, 30*24*time.Hour, onboard.Bucket.RetentionPeriod, "initial bucket should use the default retention")
+}
@@ -20,6 +20,7 @@ const (
 type OpLogStore interface {
 	AddLogEntryTx(ctx context.Context, tx kv.Tx, k, v []byte, t time.Time) error
 	ForEachLogEntryTx(ctx context.Context, tx kv.Tx, k []byte, opts influxdb.FindOptions, fn func([]byte, time.Time) error) error
+	TrimLogEntriesTx(ctx context.Context, tx kv.Tx, k []byte, max int) error
 }
 
 // OpLogService is a type which stores operation logs for buckets, users and orgs.
@@ -29,6 +30,10 @@ type OpLogService struct {
 	opLogStore OpLogStore
 
 	TimeGenerator influxdb.TimeGenerator
+
+	// MaxEntries is the number of entries retained per resource. Older entries are
+	// removed as new ones are added. Zero or less retains all entries.
+	MaxEntries int
 }
 
 // NewOpLogService constructs and configures a new op log service.
@@ -37,7 +42,40 @@ func NewOpLogService(store kv.Store, opLogStore OpLogStore) *OpLogService {
 		kv:            store,
 		opLogStore:    opLogStore,
 		TimeGenerator: influxdb.RealTimeGenerator{},
+		MaxEntries:    influxdb.DefaultMaxOperationLogEntries,
+	}
+}
+
+// AddOrganizationLogEntry records an entry in an organization operation log.
+func (s *OpLogService) AddOrganizationLogEntry(ctx context.Context, id influxdb.ID, e *influxdb.OperationLogEntry) error {
+	key, err := encodeOrganizationOperationLogKey(id)
+	if err != nil {
+		return err
+	}
+	return s.addLogEntry(ctx, key, e)
+}
+
+// AddBucketLogEntry records an entry in a bucket operation log.
+func (s *OpLogService) AddBucketLogEntry(ctx context.Context, id influxdb.ID, e *influxdb.OperationLogEntry) error {
+	key, err := encodeBucketOperationLogKey(id)
+	if err != nil {
+		return err
 	}
+	return s.addLogEntry(ctx, key, e)
+}
+
+func (s *OpLogService) addLogEntry(ctx context.Context, key []byte, e *influxdb.OperationLogEntry) error {
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		if err := s.opLogStore.AddLogEntryTx(ctx, tx, key, v, s.TimeGenerator.Now()); err != nil {
+			return err
+		}
+		return s.opLogStore.TrimLogEntriesTx(ctx, tx, key, s.MaxEntries)
+	})
 }
 
 // GetOrganizationOperationLog retrieves a organization operation log.
@@ -0,0 +1,114 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// incTimeGenerator returns a time one second later on every call.
+type incTimeGenerator struct {
+	now time.Time
+}
+
+func (g *incTimeGenerator) Now() time.Time {
+	g.now = g.now.Add(time.Second)
+	return g.now
+}
+
+func newTestOpLogService(t *testing.T) (*tenant.Service, *tenant.OpLogService) {
+	t.Helper()
+
+	s, closeS, err := NewTestInmemStore(t)
+	require.NoError(t, err)
+	t.Cleanup(closeS)
+
+	ts := tenant.NewService(tenant.NewStore(s))
+	opLogSvc := tenant.NewOpLogService(s, kv.NewService(zaptest.NewLogger(t), s, ts))
+	opLogSvc.TimeGenerator = &incTimeGenerator{now: time.Unix(0, 0)}
+	return ts, opLogSvc
+}
+
+func TestOrgOpLogger(t *testing.T) {
+	ts, opLogSvc := newTestOpLogService(t)
+	orgSvc := tenant.NewOrgOpLogger(zaptest.NewLogger(t), opLogSvc, ts.OrganizationService)
+
+	user := &influxdb.User{Name: "user"}
+	require.NoError(t, ts.CreateUser(context.Background(), user))
+	userID := user.ID
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{UserID: userID})
+
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, orgSvc.CreateOrganization(ctx, org))
+
+	name := "renamed"
+	_, err := orgSvc.UpdateOrganization(ctx, org.ID, influxdb.OrganizationUpdate{Name: &name})
+	require.NoError(t, err)
+
+	log, n, err := opLogSvc.GetOrganizationOperationLog(ctx, org.ID, influxdb.DefaultOperationLogFindOptions)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	assert.Equal(t, influxdb.OperationUpdate, log[0].Operation)
+	assert.Equal(t, []string{"name"}, log[0].Fields)
+	assert.Equal(t, userID, log[0].UserID)
+	assert.Equal(t, influxdb.OperationCreate, log[1].Operation)
+	assert.True(t, log[0].Time.After(log[1].Time))
+}
+
+func TestBucketOpLogger_BestEffort(t *testing.T) {
+	ts, _ := newTestOpLogService(t)
+
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, ts.CreateOrganization(context.Background(), org))
+
+	// A failing operation log must never fail the primary operation.
+	bucketSvc := tenant.NewBucketOpLogger(zaptest.NewLogger(t), failingOpLog{}, ts.BucketService)
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "bucket"}
+	require.NoError(t, bucketSvc.CreateBucket(context.Background(), bkt))
+
+	_, err := ts.FindBucketByID(context.Background(), bkt.ID)
+	require.NoError(t, err)
+}
+
+func TestOpLogService_MaxEntries(t *testing.T) {
+	ts, opLogSvc := newTestOpLogService(t)
+	opLogSvc.MaxEntries = 3
+	bucketSvc := tenant.NewBucketOpLogger(zaptest.NewLogger(t), opLogSvc, ts.BucketService)
+
+	ctx := context.Background()
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, ts.CreateOrganization(ctx, org))
+
+	bkt := &influxdb.Bucket{OrgID: org.ID, Name: "bucket"}
+	require.NoError(t, bucketSvc.CreateBucket(ctx, bkt))
+	for _, desc := range []string{"a", "b", "c", "d"} {
+		desc := desc
+		_, err := bucketSvc.UpdateBucket(ctx, bkt.ID, influxdb.BucketUpdate{Description: &desc})
+		require.NoError(t, err)
+	}
+
+	log, n, err := opLogSvc.GetBucketOperationLog(ctx, bkt.ID, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	for _, e := range log {
+		assert.Equal(t, influxdb.OperationUpdate, e.Operation)
+		assert.Equal(t, []string{"description"}, e.Fields)
+	}
+	assert.Equal(t, time.Unix(3, 0).UTC(), log[0].Time.UTC())
+}
+
+type failingOpLog struct{}
+
+func (failingOpLog) AddBucketLogEntry(ctx context.Context, id influxdb.ID, e *influxdb.OperationLogEntry) error {
+	return errors.New("oplog unavailable")
+}
@@ -39,6 +39,25 @@ func (s *OrgSvc) FindOrganizationByID(ctx context.Context, id influxdb.ID) (*inf
 	return org, nil
 }
 
+// Returns the organizations with the given IDs, in the order of ids.
+func (s *OrgSvc) FindOrganizationsByIDs(ctx context.Context, ids []influxdb.ID) ([]*influxdb.Organization, error) {
+	var orgs []*influxdb.Organization
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		os, err := s.store.GetOrgs(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		orgs = os
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
 // Returns the first organization that matches filter.
 func (s *OrgSvc) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
 	if filter.ID != nil {
@@ -69,12 +88,19 @@ func (s *OrgSvc) FindOrganization(ctx context.Context, filter influxdb.Organizat
 // Returns a list of organizations that match filter and the total count of matching organizations.
 // Additional options provide pagination & sorting.
 func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.OrganizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+	if err := filter.Valid(); err != nil {
+		return nil, 0, err
+	}
+
 	// if im given a id or a name I know I can only return 1
 	if filter.ID != nil || filter.Name != nil {
 		org, err := s.FindOrganization(ctx, filter)
 		if err != nil {
 			return nil, 0, err
 		}
+		if !filter.MatchesNamePrefix(org.Name) {
+			return []*influxdb.Organization{}, 0, nil
+		}
 		return []*influxdb.Organization{org}, 1, nil
 	}
 
@@ -92,7 +118,7 @@ func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.Organiza
 		// find orgs by the urm's resource ids.
 		for _, urm := range urms {
 			o, err := s.FindOrganizationByID(ctx, urm.ResourceID)
-			if err == nil {
+			if err == nil && filter.MatchesNamePrefix(o.Name) {
 				// if there is an error then this is a crufty urm and we should just move on
 				orgs = append(orgs, o)
 			}
@@ -102,7 +128,15 @@ func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.Organiza
 	}
 
 	err := s.store.View(ctx, func(tx kv.Tx) error {
-		os, err := s.store.ListOrgs(ctx, tx, opt...)
+		var (
+			os  []*influxdb.Organization
+			err error
+		)
+		if filter.NamePrefix != nil {
+			os, err = s.store.ListOrgsByNamePrefix(ctx, tx, *filter.NamePrefix, opt...)
+		} else {
+			os, err = s.store.ListOrgs(ctx, tx, opt...)
+		}
 		if err != nil {
 			return err
 		}
@@ -119,6 +153,13 @@ func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.Organiza
 
 // Creates a new organization and sets b.ID with the new identifier.
 func (s *OrgSvc) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
+	if err := o.Valid(); err != nil {
+		return err
+	}
+	if err := o.BucketDefaults.Valid(); err != nil {
+		return err
+	}
+
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		return s.store.CreateOrg(ctx, tx, o)
 	})
@@ -171,6 +212,10 @@ func (s *OrgSvc) CreateOrganization(ctx context.Context, o *influxdb.Organizatio
 // Updates a single organization with changeset.
 // Returns the new organization state after update.
 func (s *OrgSvc) UpdateOrganization(ctx context.Context, id influxdb.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
 	var org *influxdb.Organization
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		o, err := s.store.UpdateOrg(ctx, tx, id, upd)
@@ -186,6 +231,32 @@ func (s *OrgSvc) UpdateOrganization(ctx context.Context, id influxdb.ID, upd inf
 	return org, nil
 }
 
+// RenameOrganizationSafely renames an organization and rewrites the references
+// to it by name in the same transaction. Nothing is changed if any rewrite fails.
+func (s *OrgSvc) RenameOrganizationSafely(ctx context.Context, id influxdb.ID, name string) (*influxdb.Organization, []influxdb.OrganizationReference, error) {
+	upd := influxdb.OrganizationUpdate{Name: &name}
+	if err := upd.Valid(); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		org  *influxdb.Organization
+		refs []influxdb.OrganizationReference
+	)
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		o, r, err := s.store.RenameOrg(ctx, tx, id, *upd.Name)
+		if err != nil {
+			return err
+		}
+		org, refs = o, r
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return org, refs, nil
+}
+
 // DeleteOrganization removes a organization by ID and its dependent resources.
 func (s *OrgSvc) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
 	// clean up the buckets for this organization
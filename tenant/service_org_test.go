@@ -2,12 +2,18 @@ package tenant_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kv"
+	telegrafservice "github.com/influxdata/influxdb/v2/telegraf/service"
 	"github.com/influxdata/influxdb/v2/tenant"
 	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBoltOrganizationService(t *testing.T) {
@@ -63,3 +69,133 @@ func initOrganizationService(s kv.Store, f influxdbtesting.OrganizationFields, t
 		}
 	}
 }
+
+func TestOrgService_RenameOrganizationSafely(t *testing.T) {
+	ctx := context.Background()
+
+	s, closeBolt, err := NewTestBoltStore(t)
+	if err != nil {
+		t.Fatalf("failed to create new kv store: %v", err)
+	}
+	defer closeBolt()
+
+	var failRewrite bool
+	failing := func(ctx context.Context, tx kv.Tx, org *influxdb.Organization, oldName string) ([]influxdb.OrganizationReference, error) {
+		if failRewrite {
+			return nil, errors.New("rewrite failed")
+		}
+		return nil, nil
+	}
+	storage := tenant.NewStore(s, tenant.WithOrgReferenceRewriters(kv.RewriteTaskOrgName, telegrafservice.RewriteOrgName, failing))
+	svc := tenant.NewService(storage)
+
+	org := &influxdb.Organization{Name: "old"}
+	if err := svc.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+
+	// store a task directly, as the task service is not part of tenant
+	taskID := influxdb.ID(10)
+	if err := s.Update(ctx, func(tx kv.Tx) error {
+		encodedID, _ := taskID.Encode()
+		encodedOrgID, _ := org.ID.Encode()
+		tasks, err := tx.Bucket([]byte("tasksv1"))
+		if err != nil {
+			return err
+		}
+		task := fmt.Sprintf(`{"id":%q,"orgID":%q,"org":"old","name":"downsample","flux":"option task = {name: \"downsample\", every: 1h}"}`, taskID, org.ID)
+		if err := tasks.Put(encodedID, []byte(task)); err != nil {
+			return err
+		}
+		index, err := tx.Bucket([]byte("taskIndexsv1"))
+		if err != nil {
+			return err
+		}
+		return index.Put([]byte(string(encodedOrgID)+"/"+string(encodedID)), []byte(taskID.String()))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	telegrafs := telegrafservice.New(s)
+	tc := &influxdb.TelegrafConfig{
+		OrgID:  org.ID,
+		Name:   "cpu",
+		Config: "[[outputs.influxdb_v2]]\n  organization = \"old\" # the org\n  bucket = \"old\"\n",
+	}
+	if err := telegrafs.CreateTelegrafConfig(ctx, tc, influxdb.ID(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	taskOrg := func() string {
+		var name string
+		if err := s.View(ctx, func(tx kv.Tx) error {
+			encodedID, _ := taskID.Encode()
+			tasks, err := tx.Bucket([]byte("tasksv1"))
+			if err != nil {
+				return err
+			}
+			v, err := tasks.Get(encodedID)
+			if err != nil {
+				return err
+			}
+			var task struct {
+				Org string `json:"org"`
+			}
+			if err := json.Unmarshal(v, &task); err != nil {
+				return err
+			}
+			name = task.Org
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		failRewrite = true
+		defer func() { failRewrite = false }()
+
+		if _, _, err := svc.RenameOrganizationSafely(ctx, org.ID, "new"); err == nil {
+			t.Fatal("expected rename to fail")
+		}
+
+		o, err := svc.FindOrganizationByID(ctx, org.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "old", o.Name)
+		assert.Equal(t, "old", taskOrg())
+
+		got, err := telegrafs.FindTelegrafConfigByID(ctx, tc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tc.Config, got.Config)
+	})
+
+	t.Run("rewrites references", func(t *testing.T) {
+		o, refs, err := svc.RenameOrganizationSafely(ctx, org.ID, "new")
+		require.NoError(t, err)
+		assert.Equal(t, "new", o.Name)
+		assert.Equal(t, []influxdb.OrganizationReference{
+			{ResourceType: influxdb.TasksResourceType, ID: taskID, Name: "downsample"},
+			{ResourceType: influxdb.TelegrafsResourceType, ID: tc.ID, Name: "cpu"},
+		}, refs)
+
+		assert.Equal(t, "new", taskOrg())
+
+		got, err := telegrafs.FindTelegrafConfigByID(ctx, tc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "[[outputs.influxdb_v2]]\n  organization = \"new\" # the org\n  bucket = \"old\"\n", got.Config)
+
+		oldName := "old"
+		_, err = svc.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &oldName})
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+	})
+
+	t.Run("rejects a taken name", func(t *testing.T) {
+		other := &influxdb.Organization{Name: "other"}
+		require.NoError(t, svc.CreateOrganization(ctx, other))
+
+		_, _, err := svc.RenameOrganizationSafely(ctx, org.ID, "other")
+		assert.Equal(t, influxdb.EConflict, influxdb.ErrorCode(err))
+		assert.Equal(t, "new", taskOrg())
+	})
+}
@@ -0,0 +1,208 @@
+package tenant
+
+import (
+	"context"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+)
+
+// UserPermissionSvc computes the effective permissions of users from their
+// user resource mappings and tokens.
+type UserPermissionSvc struct {
+	service       *Service
+	authSvc       influxdb.AuthorizationService
+	orgIDResolver authorizer.OrgIDResolver
+}
+
+// NewUserPermissionService constructs a service computing the effective
+// permissions of users. The org ID resolver finds the organizations of the
+// resources users own or are members of, and of their tokens' permissions
+// that are not scoped to one.
+func NewUserPermissionService(svc *Service, as influxdb.AuthorizationService, orgIDResolver authorizer.OrgIDResolver) *UserPermissionSvc {
+	return &UserPermissionSvc{
+		service:       svc,
+		authSvc:       as,
+		orgIDResolver: orgIDResolver,
+	}
+}
+
+// permissionKey identifies the resources of an effective permission. A zero
+// org ID is no organization, and a zero ID all resources of the type.
+type permissionKey struct {
+	orgID influxdb.ID
+	rt    influxdb.ResourceType
+	id    influxdb.ID
+}
+
+type permissionGrants struct {
+	actions map[influxdb.Action]bool
+	sources map[string]bool
+}
+
+// FindUserPermissions returns the effective permissions of a user. The full
+// set is computed and sorted by organization, resource type and resource ID
+// before a page of it is taken, so pages are stable.
+func (s *UserPermissionSvc) FindUserPermissions(ctx context.Context, userID influxdb.ID, opt influxdb.FindOptions) (*influxdb.UserPermissions, error) {
+	if _, err := s.service.FindUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	grants := make(map[permissionKey]*permissionGrants)
+	roles := make(map[influxdb.ID]influxdb.UserType)
+	grant := func(key permissionKey, source string, actions ...influxdb.Action) {
+		g, ok := grants[key]
+		if !ok {
+			g = &permissionGrants{actions: make(map[influxdb.Action]bool), sources: make(map[string]bool)}
+			grants[key] = g
+		}
+		g.sources[source] = true
+		for _, a := range actions {
+			g.actions[a] = true
+		}
+	}
+	grantAll := func(ps []influxdb.Permission, source string) {
+		for _, p := range ps {
+			key := permissionKey{orgID: s.resourceOrgID(ctx, p.Resource), rt: p.Resource.Type}
+			if p.Resource.ID != nil {
+				key.id = *p.Resource.ID
+			}
+			grant(key, source, p.Action)
+		}
+	}
+
+	mappings, _, err := s.service.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		ps, err := m.ToPermissions()
+		if err != nil {
+			return nil, &influxdb.Error{Err: err}
+		}
+		source := string(m.UserType)
+		if m.ResourceType == influxdb.OrgsResourceType {
+			roles[m.ResourceID] = m.UserType
+		} else {
+			// Record the resource even if the mapping grants nothing beyond
+			// the organization, so that it is listed as owned.
+			r := influxdb.Resource{Type: m.ResourceType, ID: &m.ResourceID}
+			grant(permissionKey{orgID: s.resourceOrgID(ctx, r), rt: m.ResourceType, id: m.ResourceID}, source)
+		}
+		grantAll(ps, source)
+	}
+
+	grantAll(influxdb.MePermissions(userID), influxdb.PermissionSourceUser)
+
+	auths, _, err := s.authSvc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range auths {
+		if !a.IsActive() {
+			continue
+		}
+		grantAll(a.Permissions, influxdb.PermissionSourceToken)
+	}
+
+	keys := make([]permissionKey, 0, len(grants))
+	for key := range grants {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].orgID != keys[j].orgID {
+			return keys[i].orgID < keys[j].orgID
+		}
+		if keys[i].rt != keys[j].rt {
+			return keys[i].rt < keys[j].rt
+		}
+		return keys[i].id < keys[j].id
+	})
+
+	if opt.Offset >= len(keys) {
+		keys = nil
+	} else {
+		keys = keys[opt.Offset:]
+	}
+	if limit := opt.GetLimit(); len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	perms := &influxdb.UserPermissions{UserID: userID, Orgs: []*influxdb.OrgPermissions{}}
+	var (
+		org *influxdb.OrgPermissions
+		rt  *influxdb.ResourceTypePermissions
+	)
+	for _, key := range keys {
+		if org == nil || orgIDOf(org) != key.orgID {
+			org = s.orgPermissions(ctx, key.orgID, roles[key.orgID])
+			perms.Orgs = append(perms.Orgs, org)
+			rt = nil
+		}
+		if rt == nil || rt.Type != key.rt {
+			rt = &influxdb.ResourceTypePermissions{Type: key.rt}
+			org.ResourceTypes = append(org.ResourceTypes, rt)
+		}
+		rt.Resources = append(rt.Resources, newResourcePermissions(key, grants[key]))
+	}
+	return perms, nil
+}
+
+// resourceOrgID returns the ID of the organization of r, or zero if it does
+// not belong to one or its organization cannot be found.
+func (s *UserPermissionSvc) resourceOrgID(ctx context.Context, r influxdb.Resource) influxdb.ID {
+	switch {
+	case r.OrgID != nil:
+		return *r.OrgID
+	case r.Type == influxdb.OrgsResourceType && r.ID != nil:
+		return *r.ID
+	case r.Type == influxdb.UsersResourceType || r.ID == nil || s.orgIDResolver == nil:
+		return 0
+	}
+	orgID, err := s.orgIDResolver.FindResourceOrganizationID(ctx, r.Type, *r.ID)
+	if err != nil {
+		return 0
+	}
+	return orgID
+}
+
+func (s *UserPermissionSvc) orgPermissions(ctx context.Context, orgID influxdb.ID, role influxdb.UserType) *influxdb.OrgPermissions {
+	org := &influxdb.OrgPermissions{Role: role}
+	if !orgID.Valid() {
+		return org
+	}
+	id := orgID
+	org.OrgID = &id
+	if o, err := s.service.FindOrganizationByID(ctx, orgID); err == nil {
+		org.Name = o.Name
+	}
+	return org
+}
+
+func orgIDOf(org *influxdb.OrgPermissions) influxdb.ID {
+	if org.OrgID == nil {
+		return 0
+	}
+	return *org.OrgID
+}
+
+func newResourcePermissions(key permissionKey, g *permissionGrants) *influxdb.ResourcePermissions {
+	r := &influxdb.ResourcePermissions{
+		Actions: make([]influxdb.Action, 0, len(g.actions)),
+		Sources: make([]string, 0, len(g.sources)),
+	}
+	if key.id.Valid() {
+		id := key.id
+		r.ID = &id
+	}
+	for a := range g.actions {
+		r.Actions = append(r.Actions, a)
+	}
+	sort.Slice(r.Actions, func(i, j int) bool { return r.Actions[i] < r.Actions[j] })
+	for source := range g.sources {
+		r.Sources = append(r.Sources, source)
+	}
+	sort.Strings(r.Sources)
+	return r
+}
@@ -0,0 +1,170 @@
+package tenant_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserPermissionService(t *testing.T) {
+	s, _, err := NewTestInmemStore(t)
+	require.NoError(t, err)
+	svc := tenant.NewService(tenant.NewStore(s))
+	ctx := context.Background()
+
+	owner := &influxdb.User{Name: "owner", Status: influxdb.Active}
+	require.NoError(t, svc.CreateUser(ctx, owner))
+	member := &influxdb.User{Name: "member", Status: influxdb.Active}
+	require.NoError(t, svc.CreateUser(ctx, member))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, svc.CreateOrganization(ctx, org))
+
+	dashboardID := influxdb.ID(10)
+	for _, m := range []*influxdb.UserResourceMapping{
+		{UserID: owner.ID, UserType: influxdb.Owner, ResourceType: influxdb.OrgsResourceType, ResourceID: org.ID},
+		{UserID: owner.ID, UserType: influxdb.Owner, ResourceType: influxdb.DashboardsResourceType, ResourceID: dashboardID},
+		{UserID: member.ID, UserType: influxdb.Member, ResourceType: influxdb.OrgsResourceType, ResourceID: org.ID},
+	} {
+		require.NoError(t, svc.CreateUserResourceMapping(ctx, m))
+	}
+
+	writeBuckets, err := influxdb.NewPermission(influxdb.WriteAction, influxdb.BucketsResourceType, org.ID)
+	require.NoError(t, err)
+	readTasks, err := influxdb.NewPermission(influxdb.ReadAction, influxdb.TasksResourceType, org.ID)
+	require.NoError(t, err)
+	authSvc := mock.NewAuthorizationService()
+	authSvc.FindAuthorizationsFn = func(ctx context.Context, filter influxdb.AuthorizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+		if *filter.UserID != member.ID {
+			return nil, 0, nil
+		}
+		auths := []*influxdb.Authorization{
+			{ID: 1, UserID: member.ID, OrgID: org.ID, Status: influxdb.Active, Permissions: []influxdb.Permission{*writeBuckets}},
+			{ID: 2, UserID: member.ID, OrgID: org.ID, Status: influxdb.Inactive, Permissions: []influxdb.Permission{*readTasks}},
+		}
+		return auths, len(auths), nil
+	}
+	orgIDResolver := &mock.OrganizationService{
+		FindResourceOrganizationIDF: func(ctx context.Context, rt influxdb.ResourceType, id influxdb.ID) (influxdb.ID, error) {
+			if rt == influxdb.DashboardsResourceType && id == dashboardID {
+				return org.ID, nil
+			}
+			return 0, &influxdb.Error{Code: influxdb.ENotFound}
+		},
+	}
+	permSvc := tenant.NewUserPermissionService(svc, authSvc, orgIDResolver)
+
+	findOrg := func(t *testing.T, perms *influxdb.UserPermissions, orgID *influxdb.ID) *influxdb.OrgPermissions {
+		t.Helper()
+		for _, o := range perms.Orgs {
+			if (o.OrgID == nil && orgID == nil) || (o.OrgID != nil && orgID != nil && *o.OrgID == *orgID) {
+				return o
+			}
+		}
+		t.Fatalf("no permissions for org %v", orgID)
+		return nil
+	}
+	findResource := func(t *testing.T, o *influxdb.OrgPermissions, rt influxdb.ResourceType, id *influxdb.ID) *influxdb.ResourcePermissions {
+		t.Helper()
+		for _, typ := range o.ResourceTypes {
+			if typ.Type != rt {
+				continue
+			}
+			for _, r := range typ.Resources {
+				if (r.ID == nil && id == nil) || (r.ID != nil && id != nil && *r.ID == *id) {
+					return r
+				}
+			}
+		}
+		t.Fatalf("no permissions for %s %v", rt, id)
+		return nil
+	}
+
+	t.Run("owners can write within the org", func(t *testing.T) {
+		perms, err := permSvc.FindUserPermissions(ctx, owner.ID, influxdb.FindOptions{Limit: influxdb.MaxPageSize})
+		require.NoError(t, err)
+
+		o := findOrg(t, perms, &org.ID)
+		assert.Equal(t, "org", o.Name)
+		assert.Equal(t, influxdb.Owner, o.Role)
+		buckets := findResource(t, o, influxdb.BucketsResourceType, nil)
+		assert.Equal(t, []influxdb.Action{influxdb.ReadAction, influxdb.WriteAction}, buckets.Actions)
+		assert.Equal(t, []string{influxdb.PermissionSourceOwner}, buckets.Sources)
+
+		dashboard := findResource(t, o, influxdb.DashboardsResourceType, &dashboardID)
+		assert.Empty(t, dashboard.Actions)
+		assert.Equal(t, []string{influxdb.PermissionSourceOwner}, dashboard.Sources)
+
+		self := findResource(t, findOrg(t, perms, nil), influxdb.UsersResourceType, &owner.ID)
+		assert.Equal(t, []string{influxdb.PermissionSourceUser}, self.Sources)
+	})
+
+	t.Run("members can read within the org and use their tokens", func(t *testing.T) {
+		perms, err := permSvc.FindUserPermissions(ctx, member.ID, influxdb.FindOptions{Limit: influxdb.MaxPageSize})
+		require.NoError(t, err)
+
+		o := findOrg(t, perms, &org.ID)
+		assert.Equal(t, influxdb.Member, o.Role)
+		buckets := findResource(t, o, influxdb.BucketsResourceType, nil)
+		assert.Equal(t, []influxdb.Action{influxdb.ReadAction, influxdb.WriteAction}, buckets.Actions)
+		assert.Equal(t, []string{influxdb.PermissionSourceMember, influxdb.PermissionSourceToken}, buckets.Sources)
+		dashboards := findResource(t, o, influxdb.DashboardsResourceType, nil)
+		assert.Equal(t, []influxdb.Action{influxdb.ReadAction}, dashboards.Actions)
+
+		// The inactive token grants nothing.
+		tasks := findResource(t, o, influxdb.TasksResourceType, nil)
+		assert.Equal(t, []string{influxdb.PermissionSourceMember}, tasks.Sources)
+
+		ps := perms.PermissionSet()
+		assert.True(t, ps.Allowed(*writeBuckets))
+		assert.False(t, ps.Allowed(influxdb.Permission{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.DashboardsResourceType, OrgID: &org.ID}}))
+	})
+
+	t.Run("pages through resources", func(t *testing.T) {
+		all, err := permSvc.FindUserPermissions(ctx, owner.ID, influxdb.FindOptions{Limit: influxdb.MaxPageSize})
+		require.NoError(t, err)
+
+		var paged influxdb.PermissionSet
+		var n int
+		for opt := (influxdb.FindOptions{Limit: 7}); ; opt.Offset += opt.Limit {
+			page, err := permSvc.FindUserPermissions(ctx, owner.ID, opt)
+			require.NoError(t, err)
+			require.LessOrEqual(t, page.ResourceCount(), opt.Limit)
+			paged = append(paged, page.PermissionSet()...)
+			n += page.ResourceCount()
+			if page.ResourceCount() < opt.Limit {
+				break
+			}
+		}
+		assert.Equal(t, all.ResourceCount(), n)
+		assert.Equal(t, all.PermissionSet(), paged)
+	})
+
+	t.Run("unknown users are not found", func(t *testing.T) {
+		_, err := permSvc.FindUserPermissions(ctx, influxdb.ID(1000), influxdb.FindOptions{})
+		require.Error(t, err)
+		assert.Equal(t, influxdb.ENotFound, influxdb.ErrorCode(err))
+	})
+
+	t.Run("only the user or an operator may find them", func(t *testing.T) {
+		authed := tenant.NewAuthedUserPermissionService(permSvc)
+
+		self := icontext.SetAuthorizer(ctx, &influxdb.Authorization{UserID: member.ID, Status: influxdb.Active})
+		_, err := authed.FindUserPermissions(self, member.ID, influxdb.FindOptions{})
+		assert.NoError(t, err)
+
+		ownerCtx := icontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, influxdb.OwnerPermissions(org.ID)))
+		_, err = authed.FindUserPermissions(ownerCtx, member.ID, influxdb.FindOptions{})
+		require.Error(t, err)
+		assert.Equal(t, influxdb.EUnauthorized, influxdb.ErrorCode(err))
+
+		operator := icontext.SetAuthorizer(ctx, mock.NewMockAuthorizer(false, influxdb.OperPermissions()))
+		_, err = authed.FindUserPermissions(operator, member.ID, influxdb.FindOptions{})
+		assert.NoError(t, err)
+	})
+}
@@ -23,10 +23,43 @@ type Store struct {
 	now func() time.Time
 
 	urmByUserIndex *kv.Index
+
+	orgReferences []OrgReferenceRewriter
+	minRetention  time.Duration
 }
 
 type StoreOption func(*Store)
 
+// OrgReferenceRewriter rewrites the references to org by name held by other
+// resources, within the transaction renaming org from oldName. It returns the
+// references it rewrote.
+type OrgReferenceRewriter func(ctx context.Context, tx kv.Tx, org *influxdb.Organization, oldName string) ([]influxdb.OrganizationReference, error)
+
+// WithOrgReferenceRewriters sets the rewriters run when an organization is
+// renamed safely.
+func WithOrgReferenceRewriters(rewriters ...OrgReferenceRewriter) StoreOption {
+	return func(s *Store) {
+		s.orgReferences = append(s.orgReferences, rewriters...)
+	}
+}
+
+// WithMinRetention sets the shortest retention period of a bucket. Shorter,
+// finite periods are raised to it as buckets are created or updated.
+func WithMinRetention(d time.Duration) StoreOption {
+	return func(s *Store) {
+		s.minRetention = d
+	}
+}
+
+// retentionPeriod returns the retention period d, raised to the minimum
+// retention period if it is finite and shorter.
+func (s *Store) retentionPeriod(d time.Duration) time.Duration {
+	if d > 0 && d < s.minRetention {
+		return s.minRetention
+	}
+	return d
+}
+
 func NewStore(kvStore kv.Store, opts ...StoreOption) *Store {
 	store := &Store{
 		kvStore:     kvStore,
@@ -314,6 +314,10 @@ func (s *Store) CreateBucket(ctx context.Context, tx kv.Tx, bucket *influxdb.Buc
 }
 
 func (s *Store) UpdateBucket(ctx context.Context, tx kv.Tx, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
 	encodedID, err := id.Encode()
 	if err != nil {
 		return nil, err
@@ -323,6 +327,9 @@ func (s *Store) UpdateBucket(ctx context.Context, tx kv.Tx, id influxdb.ID, upd
 	if err != nil {
 		return nil, err
 	}
+	if err := upd.ValidFor(bucket); err != nil {
+		return nil, err
+	}
 
 	bucket.SetUpdatedAt(s.now())
 	if upd.Name != nil && bucket.Name != *upd.Name {
@@ -372,6 +379,26 @@ func (s *Store) UpdateBucket(ctx context.Context, tx kv.Tx, id influxdb.ID, upd
 		bucket.RetentionPeriod = *upd.RetentionPeriod
 	}
 
+	if upd.DeleteProtected != nil {
+		bucket.DeleteProtected = *upd.DeleteProtected
+	}
+
+	if upd.RetentionBypassesDeleteProtection != nil {
+		bucket.RetentionBypassesDeleteProtection = *upd.RetentionBypassesDeleteProtection
+	}
+
+	if upd.Archived != nil {
+		bucket.Archived = *upd.Archived
+	}
+
+	if upd.NaNHandling != nil {
+		bucket.NaNHandling = *upd.NaNHandling
+	}
+
+	if upd.LastValueMeasurements != nil {
+		bucket.LastValueMeasurements = *upd.LastValueMeasurements
+	}
+
 	v, err := marshalBucket(bucket)
 	if err != nil {
 		return nil, err
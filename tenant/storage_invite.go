@@ -0,0 +1,155 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	inviteBucket     = []byte("invitesv1")
+	inviteTokenIndex = []byte("invitetokenindexv1")
+)
+
+// storedInvite is an invite as it is stored, with the hash of its setup
+// token. The token itself is never stored.
+type storedInvite struct {
+	*influxdb.Invite
+	TokenHash string `json:"tokenHash,omitempty"`
+}
+
+func unmarshalInvite(v []byte) (*storedInvite, error) {
+	i := &storedInvite{}
+	if err := json.Unmarshal(v, i); err != nil {
+		return nil, ErrCorruptInvite(err)
+	}
+	return i, nil
+}
+
+func marshalInvite(i *storedInvite) ([]byte, error) {
+	v, err := json.Marshal(i)
+	if err != nil {
+		return nil, ErrUnprocessableInvite(err)
+	}
+	return v, nil
+}
+
+func (s *Store) GetInvite(ctx context.Context, tx kv.Tx, id influxdb.ID) (*storedInvite, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &influxdb.Error{Code: influxdb.EInvalid, Err: err}
+	}
+
+	b, err := tx.Bucket(inviteBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	return unmarshalInvite(v)
+}
+
+// GetInviteByTokenHash returns the invite whose setup token has the hash.
+func (s *Store) GetInviteByTokenHash(ctx context.Context, tx kv.Tx, hash string) (*storedInvite, error) {
+	idx, err := tx.Bucket(inviteTokenIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedID, err := idx.Get([]byte(hash))
+	if kv.IsNotFound(err) {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	var id influxdb.ID
+	if err := id.Decode(encodedID); err != nil {
+		return nil, ErrCorruptInvite(err)
+	}
+	return s.GetInvite(ctx, tx, id)
+}
+
+// ListInvites returns the invites of an organization.
+func (s *Store) ListInvites(ctx context.Context, tx kv.Tx, orgID influxdb.ID) ([]*storedInvite, error) {
+	b, err := tx.Bucket(inviteBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := b.ForwardCursor(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	is := []*storedInvite{}
+	for k, v := cursor.Next(); k != nil; k, v = cursor.Next() {
+		i, err := unmarshalInvite(v)
+		if err != nil {
+			continue
+		}
+		if i.OrgID == orgID {
+			is = append(is, i)
+		}
+	}
+
+	return is, cursor.Err()
+}
+
+// PutInvite creates or replaces an invite, indexing it by the hash of its
+// setup token. The index entry of the token it replaces is removed.
+func (s *Store) PutInvite(ctx context.Context, tx kv.Tx, i *storedInvite) error {
+	encodedID, err := i.ID.Encode()
+	if err != nil {
+		return &influxdb.Error{Code: influxdb.EInvalid, Err: err}
+	}
+
+	idx, err := tx.Bucket(inviteTokenIndex)
+	if err != nil {
+		return err
+	}
+
+	prev, err := s.GetInvite(ctx, tx, i.ID)
+	switch {
+	case err == nil:
+		if prev.TokenHash != "" && prev.TokenHash != i.TokenHash {
+			if err := idx.Delete([]byte(prev.TokenHash)); err != nil {
+				return ErrInternalServiceError(err)
+			}
+		}
+	case err != ErrInviteNotFound:
+		return err
+	}
+
+	if i.TokenHash != "" {
+		if err := idx.Put([]byte(i.TokenHash), encodedID); err != nil {
+			return ErrInternalServiceError(err)
+		}
+	}
+
+	v, err := marshalInvite(i)
+	if err != nil {
+		return err
+	}
+
+	b, err := tx.Bucket(inviteBucket)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(encodedID, v); err != nil {
+		return ErrInternalServiceError(err)
+	}
+
+	return nil
+}
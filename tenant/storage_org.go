@@ -1,8 +1,10 @@
 package tenant
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"sort"
 	"strings"
 
 	"github.com/influxdata/influxdb/v2"
@@ -153,6 +155,124 @@ func (s *Store) ListOrgs(ctx context.Context, tx kv.Tx, opt ...influxdb.FindOpti
 	return us, cursor.Err()
 }
 
+// GetOrgs returns the organizations with the given IDs, in the order of ids,
+// reading them in a single pass of a cursor over the organization bucket.
+func (s *Store) GetOrgs(ctx context.Context, tx kv.Tx, ids []influxdb.ID) ([]*influxdb.Organization, error) {
+	if len(ids) == 0 {
+		return []*influxdb.Organization{}, nil
+	}
+
+	keys := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		encodedID, err := id.Encode()
+		if err != nil {
+			return nil, InvalidOrgIDError(err)
+		}
+		keys = append(keys, encodedID)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	b, err := tx.Bucket(organizationBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := b.ForwardCursor(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	found := make(map[string]*influxdb.Organization, len(keys))
+	i := 0
+	for k, v := cursor.Next(); k != nil && i < len(keys); k, v = cursor.Next() {
+		for i < len(keys) && bytes.Compare(keys[i], k) < 0 {
+			i++
+		}
+		if i == len(keys) || !bytes.Equal(keys[i], k) {
+			continue
+		}
+
+		o, err := unmarshalOrg(v)
+		if err != nil {
+			return nil, err
+		}
+		found[string(k)] = o
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, ErrInternalServiceError(err)
+	}
+
+	orgs := make([]*influxdb.Organization, 0, len(ids))
+	var missing []influxdb.ID
+	for _, id := range ids {
+		encodedID, _ := id.Encode()
+		if o, ok := found[string(encodedID)]; ok {
+			orgs = append(orgs, o)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, influxdb.ErrOrgsNotFound(missing)
+	}
+	return orgs, nil
+}
+
+// ListOrgsByNamePrefix lists the organizations whose names start with prefix,
+// in the order of their names.
+func (s *Store) ListOrgsByNamePrefix(ctx context.Context, tx kv.Tx, prefix string, opt ...influxdb.FindOptions) ([]*influxdb.Organization, error) {
+	if len(opt) == 0 {
+		opt = append(opt, influxdb.FindOptions{
+			Limit: influxdb.DefaultPageSize,
+		})
+	}
+	o := opt[0]
+	if o.Limit > influxdb.MaxPageSize || o.Limit == 0 {
+		o.Limit = influxdb.MaxPageSize
+	}
+
+	idx, err := tx.Bucket(organizationIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	key := []byte(prefix)
+	cursor, err := idx.ForwardCursor(key, kv.WithCursorPrefix(key))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	count := 0
+	us := []*influxdb.Organization{}
+	for k, v := cursor.Next(); k != nil; k, v = cursor.Next() {
+		if o.Offset != 0 && count < o.Offset {
+			count++
+			continue
+		}
+
+		var id influxdb.ID
+		if err := id.Decode(v); err != nil {
+			return nil, influxdb.ErrCorruptID(err)
+		}
+		u, err := s.GetOrg(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		us = append(us, u)
+
+		if len(us) >= o.Limit {
+			break
+		}
+	}
+
+	return us, cursor.Err()
+}
+
 func (s *Store) CreateOrg(ctx context.Context, tx kv.Tx, o *influxdb.Organization) (err error) {
 	// if ID is provided then ensure it is unique
 	// generate new bucket ID
@@ -235,6 +355,10 @@ func (s *Store) UpdateOrg(ctx context.Context, tx kv.Tx, id influxdb.ID, upd inf
 		u.Description = *upd.Description
 	}
 
+	if upd.BucketDefaults != nil {
+		u.BucketDefaults = *upd.BucketDefaults
+	}
+
 	v, err := marshalOrg(u)
 	if err != nil {
 		return nil, err
@@ -251,6 +375,31 @@ func (s *Store) UpdateOrg(ctx context.Context, tx kv.Tx, id influxdb.ID, upd inf
 	return u, nil
 }
 
+// RenameOrg renames the organization and rewrites the references to it by name
+// with the store's OrgReferenceRewriters.
+func (s *Store) RenameOrg(ctx context.Context, tx kv.Tx, id influxdb.ID, name string) (*influxdb.Organization, []influxdb.OrganizationReference, error) {
+	o, err := s.GetOrg(ctx, tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldName := o.Name
+
+	o, err = s.UpdateOrg(ctx, tx, id, influxdb.OrganizationUpdate{Name: &name})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refs := []influxdb.OrganizationReference{}
+	for _, rewrite := range s.orgReferences {
+		r, err := rewrite(ctx, tx, o, oldName)
+		if err != nil {
+			return nil, nil, err
+		}
+		refs = append(refs, r...)
+	}
+	return o, refs, nil
+}
+
 func (s *Store) DeleteOrg(ctx context.Context, tx kv.Tx, id influxdb.ID) error {
 	u, err := s.GetOrg(ctx, tx, id)
 	if err != nil {
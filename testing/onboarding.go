@@ -22,7 +22,7 @@ var onboardCmpOptions = cmp.Options{
 		return x.User.Name == y.User.Name && x.User.OAuthID == y.User.OAuthID && x.User.Status == y.User.Status &&
 			x.Org.Name == y.Org.Name && x.Org.Description == y.Org.Description &&
 			x.Bucket.Type == y.Bucket.Type && x.Bucket.Description == y.Bucket.Description && x.Bucket.RetentionPolicyName == y.Bucket.RetentionPolicyName && x.Bucket.RetentionPeriod == y.Bucket.RetentionPeriod && x.Bucket.Name == y.Bucket.Name &&
-			(x.Auth != nil && y.Auth != nil && cmp.Equal(x.Auth.Permissions, y.Auth.Permissions)) // its possible auth wont exist on the basic service level
+			(x.Auth != nil && y.Auth != nil && cmp.Equal(x.Auth.Permissions, y.Auth.Permissions) && x.Auth.Description == y.Auth.Description) // its possible auth wont exist on the basic service level
 	}),
 }
 
@@ -202,6 +202,67 @@ func OnboardInitialUser(
 				},
 			},
 		},
+		{
+			name: "onboarding with a token description",
+			fields: OnboardingFields{
+				IDGenerator: &loopIDGenerator{
+					s: []string{oneID, twoID, threeID, fourID},
+				},
+				TimeGenerator:  mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+				TokenGenerator: mock.NewTokenGenerator(oneToken, nil),
+				IsOnboarding:   true,
+			},
+			args: args{
+				request: &platform.OnboardingRequest{
+					User:             "admin",
+					Org:              "org1",
+					Bucket:           "bucket1",
+					Password:         "password1",
+					TokenDescription: "automation",
+					RetentionPeriod:  time.Hour * 24 * 7, // 1 week
+				},
+			},
+			wants: wants{
+				results: &platform.OnboardingResults{
+					User: &platform.User{
+						ID:     MustIDBase16(oneID),
+						Name:   "admin",
+						Status: platform.Active,
+					},
+					Org: &platform.Organization{
+						ID:   MustIDBase16(twoID),
+						Name: "org1",
+						CRUDLog: platform.CRUDLog{
+							CreatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+							UpdatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+						},
+					},
+					Bucket: &platform.Bucket{
+						ID:              MustIDBase16(threeID),
+						Name:            "bucket1",
+						OrgID:           MustIDBase16(twoID),
+						RetentionPeriod: time.Hour * 24 * 7,
+						CRUDLog: platform.CRUDLog{
+							CreatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+							UpdatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+						},
+					},
+					Auth: &platform.Authorization{
+						ID:          MustIDBase16(fourID),
+						Token:       oneToken,
+						Status:      platform.Active,
+						UserID:      MustIDBase16(oneID),
+						Description: "automation",
+						OrgID:       MustIDBase16(twoID),
+						Permissions: platform.OperPermissions(),
+						CRUDLog: platform.CRUDLog{
+							CreatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+							UpdatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -61,6 +62,10 @@ func OrganizationService(
 			name: "FindOrganizationByID",
 			fn:   FindOrganizationByID,
 		},
+		{
+			name: "FindOrganizationsByIDs",
+			fn:   FindOrganizationsByIDs,
+		},
 		{
 			name: "FindOrganizations",
 			fn:   FindOrganizations,
@@ -228,6 +233,64 @@ func CreateOrganization(
 				err: influxdb.ErrOrgNameisEmpty,
 			},
 		},
+		{
+			name: "name has control characters",
+			fields: OrganizationFields{
+				IDGenerator:   mock.NewMockIDGenerator(),
+				OrgBucketIDs:  orgBucketsIDGenerator,
+				TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+				Organizations: []*influxdb.Organization{
+					{
+						ID:   idOne,
+						Name: "organization1",
+					},
+				},
+			},
+			args: args{
+				organization: &influxdb.Organization{
+					ID:   idTwo,
+					Name: "organization\n2",
+				},
+			},
+			wants: wants{
+				organizations: []*influxdb.Organization{
+					{
+						ID:   idOne,
+						Name: "organization1",
+					},
+				},
+				err: influxdb.ErrOrgNameHasControlChars,
+			},
+		},
+		{
+			name: "name too long",
+			fields: OrganizationFields{
+				IDGenerator:   mock.NewMockIDGenerator(),
+				OrgBucketIDs:  orgBucketsIDGenerator,
+				TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+				Organizations: []*influxdb.Organization{
+					{
+						ID:   idOne,
+						Name: "organization1",
+					},
+				},
+			},
+			args: args{
+				organization: &influxdb.Organization{
+					ID:   idTwo,
+					Name: strings.Repeat("o", influxdb.MaxOrgNameLength+1),
+				},
+			},
+			wants: wants{
+				organizations: []*influxdb.Organization{
+					{
+						ID:   idOne,
+						Name: "organization1",
+					},
+				},
+				err: influxdb.ErrOrgNameTooLong,
+			},
+		},
 		{
 			name: "names should be unique",
 			fields: OrganizationFields{
@@ -261,6 +324,41 @@ func CreateOrganization(
 				},
 			},
 		},
+		{
+			name: "name is trimmed",
+			fields: OrganizationFields{
+				IDGenerator:   mock.NewMockIDGenerator(),
+				OrgBucketIDs:  mock.NewMockIDGenerator(),
+				TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+				Organizations: []*influxdb.Organization{
+					{
+						ID:   idOne,
+						Name: "organization1",
+					},
+				},
+			},
+			args: args{
+				organization: &influxdb.Organization{
+					Name: "  organization2\n",
+				},
+			},
+			wants: wants{
+				organizations: []*influxdb.Organization{
+					{
+						ID:   idOne,
+						Name: "organization1",
+					},
+					{
+						ID:   influxdb.ID(mock.FirstMockID),
+						Name: "organization2",
+						CRUDLog: influxdb.CRUDLog{
+							CreatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+							UpdatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "create organization with no id",
 			fields: OrganizationFields{
@@ -408,6 +506,106 @@ func FindOrganizationByID(
 	}
 }
 
+// FindOrganizationsByIDs testing
+func FindOrganizationsByIDs(
+	init func(OrganizationFields, *testing.T) (influxdb.OrganizationService, string, func()),
+	t *testing.T,
+) {
+	type args struct {
+		ids []influxdb.ID
+	}
+	type wants struct {
+		err           error
+		organizations []*influxdb.Organization
+	}
+
+	fields := func() OrganizationFields {
+		return OrganizationFields{
+			OrgBucketIDs: mock.NewIncrementingIDGenerator(idOne),
+			Organizations: []*influxdb.Organization{
+				{
+					// ID(1)
+					Name: "organization1",
+				},
+				{
+					// ID(2)
+					Name: "organization2",
+				},
+				{
+					// ID(3)
+					Name: "organization3",
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		fields OrganizationFields
+		args   args
+		wants  wants
+	}{
+		{
+			name:   "find organizations in the order of their ids",
+			fields: fields(),
+			args: args{
+				ids: []influxdb.ID{idThree, idOne, idThree},
+			},
+			wants: wants{
+				organizations: []*influxdb.Organization{
+					{
+						ID:   idThree,
+						Name: "organization3",
+					},
+					{
+						ID:   idOne,
+						Name: "organization1",
+					},
+					{
+						ID:   idThree,
+						Name: "organization3",
+					},
+				},
+			},
+		},
+		{
+			name:   "find no organizations",
+			fields: fields(),
+			args: args{
+				ids: []influxdb.ID{},
+			},
+			wants: wants{
+				organizations: []*influxdb.Organization{},
+			},
+		},
+		{
+			name:   "didn't find organizations by ids",
+			fields: fields(),
+			args: args{
+				ids: []influxdb.ID{idTwo, idFour, idFive},
+			},
+			wants: wants{
+				err: influxdb.ErrOrgsNotFound([]influxdb.ID{idFour, idFive}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, opPrefix, done := init(tt.fields, t)
+			defer done()
+			ctx := context.Background()
+
+			organizations, err := s.FindOrganizationsByIDs(ctx, tt.args.ids)
+			diffPlatformErrors(tt.name, err, tt.wants.err, opPrefix, t)
+
+			if diff := cmp.Diff(organizations, tt.wants.organizations, organizationCmpOptions...); diff != "" {
+				t.Errorf("organizations are different -got/+want\ndiff %s", diff)
+			}
+		})
+	}
+}
+
 // FindOrganizations testing
 func FindOrganizations(
 	init func(OrganizationFields, *testing.T) (influxdb.OrganizationService, string, func()),
@@ -416,6 +614,7 @@ func FindOrganizations(
 	type args struct {
 		ID          influxdb.ID
 		name        string
+		namePrefix  string
 		findOptions influxdb.FindOptions
 	}
 
@@ -604,6 +803,86 @@ func FindOrganizations(
 				},
 			},
 		},
+		{
+			name: "find organizations by name prefix",
+			fields: OrganizationFields{
+				OrgBucketIDs: mock.NewIncrementingIDGenerator(idOne),
+				Organizations: []*influxdb.Organization{
+					{
+						// ID(1)
+						Name: "team-b",
+					},
+					{
+						// ID(2)
+						Name: "abc",
+					},
+					{
+						// ID(3)
+						Name: "team-a",
+					},
+				},
+			},
+			args: args{
+				namePrefix: "team-",
+			},
+			wants: wants{
+				organizations: []*influxdb.Organization{
+					{
+						ID:   idThree,
+						Name: "team-a",
+					},
+					{
+						ID:   idOne,
+						Name: "team-b",
+					},
+				},
+			},
+		},
+		{
+			name: "find organizations by id and name prefix",
+			fields: OrganizationFields{
+				OrgBucketIDs: mock.NewIncrementingIDGenerator(idOne),
+				Organizations: []*influxdb.Organization{
+					{
+						// ID(1)
+						Name: "team-a",
+					},
+					{
+						// ID(2)
+						Name: "abc",
+					},
+				},
+			},
+			args: args{
+				ID:         idTwo,
+				namePrefix: "team-",
+			},
+			wants: wants{
+				organizations: []*influxdb.Organization{},
+			},
+		},
+		{
+			name: "find organizations by name and name prefix",
+			fields: OrganizationFields{
+				OrgBucketIDs: mock.NewIncrementingIDGenerator(idOne),
+				Organizations: []*influxdb.Organization{
+					{
+						// ID(1)
+						Name: "team-a",
+					},
+				},
+			},
+			args: args{
+				name:       "team-a",
+				namePrefix: "team-",
+			},
+			wants: wants{
+				err: &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  "org name and name prefix are mutually exclusive",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -619,6 +898,9 @@ func FindOrganizations(
 			if tt.args.name != "" {
 				filter.Name = &tt.args.name
 			}
+			if tt.args.namePrefix != "" {
+				filter.NamePrefix = &tt.args.namePrefix
+			}
 
 			organizations, _, err := s.FindOrganizations(ctx, filter, tt.args.findOptions)
 			diffPlatformErrors(tt.name, err, tt.wants.err, opPrefix, t)
@@ -1046,6 +1328,84 @@ func UpdateOrganization(
 				err: influxdb.ErrOrgNameisEmpty,
 			},
 		},
+		{
+			name: "update name has control characters",
+			fields: OrganizationFields{
+				OrgBucketIDs:  mock.NewIncrementingIDGenerator(idOne),
+				TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+				Organizations: []*influxdb.Organization{
+					{
+						// ID(1)
+						Name: "organization1",
+					},
+					{
+						// ID(2)
+						Name: "organization2",
+					},
+				},
+			},
+			args: args{
+				id:   idOne,
+				name: strPtr("organ\tization1"),
+			},
+			wants: wants{
+				err: influxdb.ErrOrgNameHasControlChars,
+			},
+		},
+		{
+			name: "update name too long",
+			fields: OrganizationFields{
+				OrgBucketIDs:  mock.NewIncrementingIDGenerator(idOne),
+				TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+				Organizations: []*influxdb.Organization{
+					{
+						// ID(1)
+						Name: "organization1",
+					},
+					{
+						// ID(2)
+						Name: "organization2",
+					},
+				},
+			},
+			args: args{
+				id:   idOne,
+				name: strPtr(strings.Repeat("o", influxdb.MaxOrgNameLength+1)),
+			},
+			wants: wants{
+				err: influxdb.ErrOrgNameTooLong,
+			},
+		},
+		{
+			name: "update name is trimmed",
+			fields: OrganizationFields{
+				OrgBucketIDs:  mock.NewIncrementingIDGenerator(idOne),
+				TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+				Organizations: []*influxdb.Organization{
+					{
+						// ID(1)
+						Name: "organization1",
+					},
+					{
+						// ID(2)
+						Name: "organization2",
+					},
+				},
+			},
+			args: args{
+				id:   idOne,
+				name: strPtr(" changed "),
+			},
+			wants: wants{
+				organization: &influxdb.Organization{
+					ID:   idOne,
+					Name: "changed",
+					CRUDLog: influxdb.CRUDLog{
+						UpdatedAt: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC),
+					},
+				},
+			},
+		},
 		{
 			name: "update description",
 			fields: OrganizationFields{
@@ -101,6 +101,10 @@ func ScraperService(
 			name: "UpdateTarget",
 			fn:   UpdateTarget,
 		},
+		{
+			name: "PatchTarget",
+			fn:   PatchTarget,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -157,6 +161,8 @@ func AddTarget(
 						BucketID: idOne,
 						URL:      "url1",
 						ID:       MustIDBase16(targetOneID),
+						OwnerID:  MustIDBase16(threeID),
+						Revision: 1,
 					},
 				},
 			},
@@ -272,6 +278,7 @@ func AddTarget(
 					OrgID:    idTwo,
 					BucketID: idTwo,
 					URL:      "url2",
+					Headers:  influxdb.ScraperHeaders{"Authorization": "Bearer token"},
 				},
 			},
 			wants: wants{
@@ -290,7 +297,10 @@ func AddTarget(
 						OrgID:    idTwo,
 						BucketID: idTwo,
 						URL:      "url2",
+						Headers:  influxdb.ScraperHeaders{"Authorization": "Bearer token"},
 						ID:       MustIDBase16(targetTwoID),
+						OwnerID:  MustIDBase16(threeID),
+						Revision: 1,
 					},
 				},
 			},
@@ -458,6 +468,54 @@ func ListTargets(
 				},
 			},
 		},
+		{
+			name: "filter targets by type and orgID",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{
+					newOrg(influxdb.ID(1)),
+					newOrg(influxdb.ID(2)),
+				},
+				Targets: []*influxdb.ScraperTarget{
+					&target1,
+					&target2,
+					&target3,
+				},
+			},
+			args: args{
+				filter: influxdb.ScraperTargetFilter{
+					OrgID: idPtr(idOne),
+					Type:  scraperTypePtr(influxdb.PrometheusScraperType),
+				},
+			},
+			wants: wants{
+				targets: []influxdb.ScraperTarget{
+					target1,
+					target3,
+				},
+			},
+		},
+		{
+			name: "filter targets by unknown type",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{
+					newOrg(influxdb.ID(1)),
+				},
+				Targets: []*influxdb.ScraperTarget{
+					&target1,
+				},
+			},
+			args: args{
+				filter: influxdb.ScraperTargetFilter{
+					Type: scraperTypePtr("nope"),
+				},
+			},
+			wants: wants{
+				err: &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  "provided scraper target type is not supported",
+				},
+			},
+		},
 		{
 			name: "filter targets by org name not exist",
 			fields: TargetFields{
@@ -712,9 +770,10 @@ func UpdateTarget(
 	t *testing.T,
 ) {
 	type args struct {
-		url    string
-		userID influxdb.ID
-		id     influxdb.ID
+		url     string
+		headers influxdb.ScraperHeaders
+		userID  influxdb.ID
+		id      influxdb.ID
 	}
 	type wants struct {
 		err    error
@@ -808,8 +867,95 @@ func UpdateTarget(
 				},
 			},
 			args: args{
-				id:  MustIDBase16(targetOneID),
-				url: "changed",
+				id:     MustIDBase16(targetOneID),
+				url:    "changed",
+				userID: MustIDBase16(threeID),
+			},
+			wants: wants{
+				target: &influxdb.ScraperTarget{
+					ID:       MustIDBase16(targetOneID),
+					URL:      "changed",
+					OrgID:    idOne,
+					BucketID: idOne,
+					OwnerID:  MustIDBase16(threeID),
+					Revision: 1,
+				},
+			},
+		},
+		{
+			name: "update headers",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{newOrg(influxdb.ID(1))},
+				Targets: []*influxdb.ScraperTarget{
+					{
+						ID:       MustIDBase16(targetOneID),
+						URL:      "url1",
+						OrgID:    idOne,
+						BucketID: idOne,
+						Headers:  influxdb.ScraperHeaders{"X-Old": "old"},
+					},
+				},
+			},
+			args: args{
+				id:      MustIDBase16(targetOneID),
+				url:     "url1",
+				headers: influxdb.ScraperHeaders{"Authorization": "Bearer token"},
+			},
+			wants: wants{
+				target: &influxdb.ScraperTarget{
+					ID:       MustIDBase16(targetOneID),
+					URL:      "url1",
+					OrgID:    idOne,
+					BucketID: idOne,
+					Headers:  influxdb.ScraperHeaders{"Authorization": "Bearer token"},
+					Revision: 1,
+				},
+			},
+		},
+		{
+			name: "update with malformed header name",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{newOrg(influxdb.ID(1))},
+				Targets: []*influxdb.ScraperTarget{
+					{
+						ID:       MustIDBase16(targetOneID),
+						URL:      "url1",
+						OrgID:    idOne,
+						BucketID: idOne,
+					},
+				},
+			},
+			args: args{
+				id:      MustIDBase16(targetOneID),
+				url:     "url1",
+				headers: influxdb.ScraperHeaders{"Bad Header:": "value"},
+			},
+			wants: wants{
+				err: &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Op:   influxdb.OpUpdateTarget,
+					Msg:  "scraper target headers must have valid HTTP header names and values",
+				},
+			},
+		},
+		{
+			name: "update sets owner",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{newOrg(influxdb.ID(1))},
+				Targets: []*influxdb.ScraperTarget{
+					{
+						ID:       MustIDBase16(targetOneID),
+						URL:      "url1",
+						OrgID:    idOne,
+						BucketID: idOne,
+						OwnerID:  idOne,
+					},
+				},
+			},
+			args: args{
+				id:     MustIDBase16(targetOneID),
+				url:    "changed",
+				userID: MustIDBase16(threeID),
 			},
 			wants: wants{
 				target: &influxdb.ScraperTarget{
@@ -817,6 +963,8 @@ func UpdateTarget(
 					URL:      "changed",
 					OrgID:    idOne,
 					BucketID: idOne,
+					OwnerID:  MustIDBase16(threeID),
+					Revision: 1,
 				},
 			},
 		},
@@ -829,8 +977,9 @@ func UpdateTarget(
 			ctx := context.Background()
 
 			upd := &influxdb.ScraperTarget{
-				ID:  tt.args.id,
-				URL: tt.args.url,
+				ID:      tt.args.id,
+				URL:     tt.args.url,
+				Headers: tt.args.headers,
 			}
 
 			target, err := s.UpdateTarget(ctx, upd, tt.args.userID)
@@ -842,3 +991,163 @@ func UpdateTarget(
 		})
 	}
 }
+
+// PatchTarget testing
+func PatchTarget(
+	init func(TargetFields, *testing.T) (influxdb.ScraperTargetStoreService, string, func()),
+	t *testing.T,
+) {
+	name := "changed"
+	revision := uint64(3)
+	staleRevision := uint64(2)
+
+	type args struct {
+		userID influxdb.ID
+		id     influxdb.ID
+		upd    influxdb.ScraperTargetUpdate
+	}
+	type wants struct {
+		err    error
+		target *influxdb.ScraperTarget
+	}
+
+	tests := []struct {
+		name   string
+		fields TargetFields
+		args   args
+		wants  wants
+	}{
+		{
+			name: "patch name with non exist id",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{newOrg(influxdb.ID(1))},
+				Targets: []*influxdb.ScraperTarget{
+					{
+						ID:       MustIDBase16(targetOneID),
+						Name:     "name1",
+						URL:      "url1",
+						OrgID:    idOne,
+						BucketID: idOne,
+					},
+				},
+			},
+			args: args{
+				id:  MustIDBase16(targetThreeID),
+				upd: influxdb.ScraperTargetUpdate{Name: &name},
+			},
+			wants: wants{
+				err: &influxdb.Error{
+					Code: influxdb.ENotFound,
+					Op:   influxdb.OpPatchTarget,
+					Msg:  "scraper target is not found",
+				},
+			},
+		},
+		{
+			name: "patch name keeps other fields",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{newOrg(influxdb.ID(1))},
+				Targets: []*influxdb.ScraperTarget{
+					{
+						ID:        MustIDBase16(targetOneID),
+						Name:      "name1",
+						URL:       "url1",
+						OrgID:     idOne,
+						BucketID:  idOne,
+						BatchSize: 100,
+						Revision:  3,
+					},
+				},
+			},
+			args: args{
+				id:     MustIDBase16(targetOneID),
+				userID: MustIDBase16(threeID),
+				upd:    influxdb.ScraperTargetUpdate{Name: &name},
+			},
+			wants: wants{
+				target: &influxdb.ScraperTarget{
+					ID:        MustIDBase16(targetOneID),
+					Name:      "changed",
+					URL:       "url1",
+					OrgID:     idOne,
+					BucketID:  idOne,
+					OwnerID:   MustIDBase16(threeID),
+					BatchSize: 100,
+					Revision:  4,
+				},
+			},
+		},
+		{
+			name: "patch with current revision",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{newOrg(influxdb.ID(1))},
+				Targets: []*influxdb.ScraperTarget{
+					{
+						ID:       MustIDBase16(targetOneID),
+						Name:     "name1",
+						URL:      "url1",
+						OrgID:    idOne,
+						BucketID: idOne,
+						Revision: 3,
+					},
+				},
+			},
+			args: args{
+				id:  MustIDBase16(targetOneID),
+				upd: influxdb.ScraperTargetUpdate{Name: &name, Revision: &revision},
+			},
+			wants: wants{
+				target: &influxdb.ScraperTarget{
+					ID:       MustIDBase16(targetOneID),
+					Name:     "changed",
+					URL:      "url1",
+					OrgID:    idOne,
+					BucketID: idOne,
+					Revision: 4,
+				},
+			},
+		},
+		{
+			name: "patch with stale revision",
+			fields: TargetFields{
+				Organizations: []*influxdb.Organization{newOrg(influxdb.ID(1))},
+				Targets: []*influxdb.ScraperTarget{
+					{
+						ID:       MustIDBase16(targetOneID),
+						Name:     "name1",
+						URL:      "url1",
+						OrgID:    idOne,
+						BucketID: idOne,
+						Revision: 3,
+					},
+				},
+			},
+			args: args{
+				id:  MustIDBase16(targetOneID),
+				upd: influxdb.ScraperTargetUpdate{Name: &name, Revision: &staleRevision},
+			},
+			wants: wants{
+				err: influxdb.ErrScraperTargetRevisionMismatch(2, 3),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, opPrefix, done := init(tt.fields, t)
+			defer done()
+			ctx := context.Background()
+
+			target, err := s.PatchTarget(ctx, tt.args.id, tt.args.upd, tt.args.userID)
+			diffPlatformErrors(tt.name, err, tt.wants.err, opPrefix, t)
+
+			if diff := cmp.Diff(target, tt.wants.target, targetCmpOptions...); diff != "" {
+				t.Errorf("scraper target is different -got/+want\ndiff %s", diff)
+			}
+		})
+	}
+}
+
+func scraperTypePtr(typ influxdb.ScraperType) *influxdb.ScraperType {
+	return &typ
+}
@@ -43,10 +43,12 @@ type Engine interface {
 
 	CreateSnapshot() (string, error)
 	Backup(w io.Writer, basePath string, since time.Time) error
+	BackupMeasurements(w io.Writer, basePath string, since time.Time, measurements [][]byte) error
 	Export(w io.Writer, basePath string, start time.Time, end time.Time) error
 	Restore(r io.Reader, basePath string) error
 	Import(r io.Reader, basePath string) error
 	Digest() (io.ReadCloser, int64, error)
+	Summary() (ShardSummary, error)
 
 	CreateIterator(ctx context.Context, measurement string, opt query.IteratorOptions) (query.Iterator, error)
 	CreateCursorIterator(ctx context.Context) (CursorIterator, error)
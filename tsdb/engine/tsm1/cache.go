@@ -197,9 +197,10 @@ type Cache struct {
 	// This number is the number of pending or failed WriteSnaphot attempts since the last successful one.
 	snapshotAttempts int
 
-	stats         *CacheStatistics
-	lastSnapshot  time.Time
-	lastWriteTime time.Time
+	stats          *CacheStatistics
+	lastSnapshot   time.Time
+	lastWriteTime  time.Time
+	firstWriteTime time.Time // first write since the last snapshot
 
 	// A one time synchronization used to initial the cache with a store.  Since the store can allocate a
 	// a large amount memory across shards, we lazily create it.
@@ -366,6 +367,9 @@ func (c *Cache) WriteMulti(values map[string][]Value) error {
 
 	c.mu.Lock()
 	c.lastWriteTime = time.Now()
+	if c.firstWriteTime.IsZero() {
+		c.firstWriteTime = c.lastWriteTime
+	}
 	c.mu.Unlock()
 
 	return werr
@@ -416,6 +420,7 @@ func (c *Cache) Snapshot() (*Cache, error) {
 	c.store.reset()
 	atomic.StoreUint64(&c.size, 0)
 	c.lastSnapshot = time.Now()
+	c.snapshot.firstWriteTime, c.firstWriteTime = c.firstWriteTime, time.Time{}
 
 	c.updateCachedBytes(snapshotSize) // increment the number of bytes added to the snapshot
 	c.updateSnapshots()
@@ -773,6 +778,26 @@ func (c *Cache) LastWriteTime() time.Time {
 	return c.lastWriteTime
 }
 
+// LastSnapshotTime returns when the cache was last snapshotted.
+func (c *Cache) LastSnapshotTime() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastSnapshot
+}
+
+// OldestWriteTime returns when the oldest data held by the cache, including
+// a snapshot still being written, was written. It returns the zero time if
+// the cache is empty.
+func (c *Cache) OldestWriteTime() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.snapshot != nil && c.snapshot.Size() > 0 && !c.snapshot.firstWriteTime.IsZero() {
+		return c.snapshot.firstWriteTime
+	}
+	return c.firstWriteTime
+}
+
 // UpdateAge updates the age statistic based on the current time.
 func (c *Cache) UpdateAge() {
 	c.mu.RLock()
@@ -470,6 +470,38 @@ func TestCache_CacheSnapshot(t *testing.T) {
 }
 
 // Tests that Snapshot updates statistics correctly.
+// Tests that the oldest write is tracked across snapshots.
+func TestCache_OldestWriteTime(t *testing.T) {
+	c := NewCache(0)
+	if ts := c.OldestWriteTime(); !ts.IsZero() {
+		t.Fatalf("expected no oldest write for an empty cache, got %v", ts)
+	}
+
+	if err := c.WriteMulti(map[string][]Value{"foo": {NewValue(1, 1.0)}}); err != nil {
+		t.Fatal(err)
+	}
+	first := c.OldestWriteTime()
+	if first.IsZero() {
+		t.Fatal("expected an oldest write")
+	}
+
+	// The oldest write is kept while the snapshot is written.
+	if _, err := c.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if ts := c.OldestWriteTime(); !ts.Equal(first) {
+		t.Fatalf("expected oldest write %v while snapshotting, got %v", first, ts)
+	}
+
+	c.ClearSnapshot(true)
+	if ts := c.OldestWriteTime(); !ts.IsZero() {
+		t.Fatalf("expected no oldest write after the snapshot, got %v", ts)
+	}
+	if ts := c.LastSnapshotTime(); ts.Before(first) {
+		t.Fatalf("expected the last snapshot after %v, got %v", first, ts)
+	}
+}
+
 func TestCache_Snapshot_Stats(t *testing.T) {
 	limit := uint64(16)
 	c := NewCache(limit)
@@ -941,6 +941,105 @@ func (e *Engine) Backup(w io.Writer, basePath string, since time.Time) error {
 	return intar.Stream(w, path, basePath, intar.SinceFilterTarFile(since))
 }
 
+// BackupMeasurements writes a tar archive of the TSM data for the given
+// measurements in any TSM files modified since the passed in time. TSM files
+// are rewritten to contain only blocks belonging to the measurements; all
+// other files are omitted as the index is rebuilt from the series keys on
+// restore. Files are named as in the shard, so archives should be imported
+// rather than restored over a shard containing other measurements.
+func (e *Engine) BackupMeasurements(w io.Writer, basePath string, since time.Time, measurements [][]byte) error {
+	path, err := e.CreateSnapshot()
+	if err != nil {
+		return err
+	}
+	// Remove the temporary snapshot dir
+	defer os.RemoveAll(path)
+
+	return intar.Stream(w, path, basePath, e.measurementFilterTarFile(since, measurements))
+}
+
+func (e *Engine) measurementFilterTarFile(since time.Time, measurements [][]byte) func(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+	names := make(map[string]struct{}, len(measurements))
+	for _, name := range measurements {
+		names[string(name)] = struct{}{}
+	}
+
+	return func(fi os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+		if !strings.HasSuffix(fi.Name(), "."+TSMFileExtension) || !fi.ModTime().After(since) {
+			return nil
+		}
+
+		f, err := os.Open(fullPath)
+		if err != nil {
+			return err
+		}
+		r, err := NewTSMReader(f)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		return e.filterMeasurementsToBackup(r, fi, shardRelativePath, fullPath, names, tw)
+	}
+}
+
+// filterMeasurementsToBackup rewrites the TSM file read by r keeping only the
+// blocks of the named measurements. Nothing is written if no blocks match.
+func (e *Engine) filterMeasurementsToBackup(r *TSMReader, fi os.FileInfo, shardRelativePath, fullPath string, names map[string]struct{}, tw *tar.Writer) error {
+	path := fullPath + ".tmp"
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	w, err := NewTSMWriter(out)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	var n int
+	bi := r.BlockIterator()
+	for bi.Next() {
+		key, minTime, maxTime, _, _, buf, err := bi.Read()
+		if err != nil {
+			return err
+		}
+
+		seriesKey, _ := SeriesAndFieldFromCompositeKey(key)
+		if _, ok := names[string(models.ParseName(seriesKey))]; !ok {
+			continue
+		}
+		if err := w.WriteBlock(key, minTime, maxTime, buf); err != nil {
+			return err
+		}
+		n++
+	}
+
+	if err := bi.Err(); err != nil {
+		return err
+	} else if n == 0 {
+		return nil
+	}
+
+	if err := w.WriteIndex(); err != nil {
+		return err
+	}
+
+	// make sure the whole file is out to disk
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	tmpFi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return intar.StreamRenameFile(tmpFi, fi.Name(), shardRelativePath, path, tw)
+}
+
 func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
 	return func(fi os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
 		if !strings.HasSuffix(fi.Name(), ".tsm") {
@@ -1811,7 +1910,25 @@ func (e *Engine) cleanupMeasurement(name []byte) error {
 }
 
 // DeleteMeasurement deletes a measurement and all related series.
+//
+// All the data of the series is deleted, so the TSM index entries of the series
+// are removed outright and the series are dropped from the index without
+// checking whether any of their data remains on disk.
 func (e *Engine) DeleteMeasurement(name []byte) error {
+	// Ensure that the index does not compact away the measurement or series we're
+	// going to delete before we're done with them.
+	if tsiIndex, ok := e.index.(*tsi1.Index); ok {
+		tsiIndex.DisableCompactions()
+		defer tsiIndex.EnableCompactions()
+		tsiIndex.Wait()
+
+		fs, err := tsiIndex.RetainFileSet()
+		if err != nil {
+			return err
+		}
+		defer fs.Release()
+	}
+
 	// Attempt to find the series keys.
 	indexSet := tsdb.IndexSet{Indexes: []tsdb.Index{e.index}, SeriesFile: e.sfile}
 	itr, err := indexSet.MeasurementSeriesByExprIterator(name, nil)
@@ -1821,7 +1938,150 @@ func (e *Engine) DeleteMeasurement(name []byte) error {
 		return nil
 	}
 	defer itr.Close()
-	return e.DeleteSeriesRange(tsdb.NewSeriesIteratorAdapter(e.sfile, itr), math.MinInt64, math.MaxInt64)
+
+	var seriesKeys [][]byte
+	ids := tsdb.NewSeriesIDSet()
+	for {
+		elem, err := itr.Next()
+		if err != nil {
+			return err
+		} else if elem.SeriesID == 0 {
+			break
+		}
+		seriesName, tags := e.sfile.Series(elem.SeriesID)
+		if len(seriesName) == 0 {
+			continue
+		}
+		seriesKeys = append(seriesKeys, models.MakeKey(seriesName, tags))
+		ids.Add(elem.SeriesID)
+	}
+	if len(seriesKeys) == 0 {
+		return nil
+	}
+
+	// Disable and abort running level compactions so that the tombstones added
+	// to existing TSM files are not compacted away while we are deleting.
+	e.disableLevelCompactions(true)
+	defer e.enableLevelCompactions(true)
+
+	e.sfile.DisableCompactions()
+	defer e.sfile.EnableCompactions()
+	e.sfile.Wait()
+
+	bytesutil.Sort(seriesKeys)
+
+	// Remove the index entries of every field of the series from each TSM file.
+	if err := e.FileStore.Apply(func(r TSMFile) error {
+		var deleteKeys [][]byte
+		n := r.KeyCount()
+		var j int
+		for i := r.Seek(seriesKeys[0]); i < n && j < len(seriesKeys); i++ {
+			indexKey, _ := r.KeyAt(i)
+			seriesKey, _ := SeriesAndFieldFromCompositeKey(indexKey)
+
+			for j < len(seriesKeys) && bytes.Compare(seriesKeys[j], seriesKey) < 0 {
+				j++
+			}
+			if j < len(seriesKeys) && bytes.Equal(seriesKeys[j], seriesKey) {
+				deleteKeys = append(deleteKeys, indexKey)
+			}
+		}
+		if len(deleteKeys) == 0 {
+			return nil
+		}
+		return r.Delete(deleteKeys)
+	}); err != nil {
+		return err
+	}
+
+	// Remove the series from the cache and the WAL.
+	var deleteKeys [][]byte
+	_ = e.Cache.ApplyEntryFn(func(k []byte, _ *entry) error {
+		seriesKey, _ := SeriesAndFieldFromCompositeKey(k)
+		if i := bytesutil.SearchBytes(seriesKeys, seriesKey); i < len(seriesKeys) && bytes.Equal(seriesKey, seriesKeys[i]) {
+			deleteKeys = append(deleteKeys, k)
+		}
+		return nil
+	})
+	bytesutil.Sort(deleteKeys)
+	e.Cache.Delete(deleteKeys)
+	if e.WALEnabled {
+		if _, err := e.WAL.Delete(deleteKeys); err != nil {
+			return err
+		}
+	}
+
+	// Series written while we were deleting are kept in the index.
+	cacheKeys := e.Cache.Keys()
+	buf := make([]byte, 1024) // For use when accessing series file.
+	var dropIDs []uint64
+	var dropKeys [][]byte
+	for _, k := range seriesKeys {
+		if i := bytesutil.SearchBytes(cacheKeys, k); i < len(cacheKeys) {
+			if cacheSeriesKey, _ := SeriesAndFieldFromCompositeKey(cacheKeys[i]); bytes.Equal(k, cacheSeriesKey) {
+				continue
+			}
+		}
+
+		seriesName, tags := models.ParseKeyBytes(k)
+		if sid := e.sfile.SeriesID(seriesName, tags, buf); sid != 0 {
+			dropIDs = append(dropIDs, sid)
+			dropKeys = append(dropKeys, k)
+		}
+	}
+
+	// The tsi1 index syncs its log once for the whole batch of series.
+	if tsiIndex, ok := e.index.(*tsi1.Index); ok {
+		if err := tsiIndex.DropSeriesList(dropIDs, dropKeys); err != nil {
+			return err
+		}
+	} else {
+		for i, sid := range dropIDs {
+			if err := e.index.DropSeries(sid, dropKeys[i], false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if dropped, err := e.index.DropMeasurementIfSeriesNotExist(name); err != nil {
+		return err
+	} else if dropped {
+		if err := e.cleanupMeasurement(name); err != nil {
+			return err
+		}
+		if err := e.fieldset.Save(); err != nil {
+			return err
+		}
+	}
+
+	// Remove any series IDs for our set that still exist in other shards, or in
+	// this one if they were written while we were deleting. We cannot remove
+	// these from the series file yet.
+	if err := e.seriesIDSets.ForEach(func(s *tsdb.SeriesIDSet) {
+		ids = ids.AndNot(s)
+	}); err != nil {
+		return err
+	}
+
+	// Remove the remaining ids from the series file as they no longer exist
+	// in any shard.
+	var dropErr error
+	ids.ForEach(func(id uint64) {
+		seriesName, tags := e.sfile.Series(id)
+		if err := e.sfile.DeleteSeriesID(id); err != nil {
+			dropErr = err
+			return
+		}
+
+		// In the case of the inmem index the series can be removed across
+		// the global index (all shards).
+		if index, ok := e.index.(*inmem.ShardIndex); ok {
+			if err := index.Index.DropSeriesGlobal(models.MakeKey(seriesName, tags)); err != nil {
+				dropErr = err
+			}
+		}
+	})
+	return dropErr
 }
 
 // ForEachMeasurementName iterates over each measurement name in the engine.
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -717,6 +718,120 @@ func equalBuffers(bufA, bufB *bytes.Buffer) bool {
 	return true
 }
 
+// Ensure a measurement-scoped backup only contains the requested measurements
+// and that importing it leaves other measurements untouched.
+func TestEngine_BackupMeasurements(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			src := MustOpenEngine(index)
+			defer src.Close()
+			src.CompactionPlan = &mockPlanner{}
+
+			if err := src.WritePointsString(
+				"cpu,host=A value=1.1 1000000000",
+				"mem,host=A value=2.1 1000000000",
+				"disk,host=A value=3.1 1000000000",
+			); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+			if err := src.WriteSnapshot(); err != nil {
+				t.Fatalf("failed to snapshot: %s", err.Error())
+			}
+
+			var buf bytes.Buffer
+			if err := src.BackupMeasurements(&buf, "", time.Unix(0, 0), [][]byte{[]byte("cpu"), []byte("disk")}); err != nil {
+				t.Fatalf("failed to backup: %s", err.Error())
+			}
+
+			// Only the requested measurements are in the archive.
+			data, err := getExportData(bytes.NewBuffer(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for name, fbuf := range data {
+				if !strings.HasSuffix(name, ".tsm") {
+					t.Fatalf("unexpected file in backup: %s", name)
+				}
+				f := MustTempFile("")
+				defer os.Remove(f.Name())
+				defer f.Close()
+				if _, err := f.Write(fbuf.Bytes()); err != nil {
+					t.Fatal(err)
+				}
+				r, err := tsm1.NewTSMReader(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 0; i < r.KeyCount(); i++ {
+					key, _ := r.KeyAt(i)
+					keys = append(keys, string(key))
+				}
+				r.Close()
+			}
+			sort.Strings(keys)
+			if exp := []string{"cpu,host=A#!~#value", "disk,host=A#!~#value"}; !reflect.DeepEqual(keys, exp) {
+				t.Fatalf("unexpected keys: got %v, exp %v", keys, exp)
+			}
+
+			// Importing into a shard with other data leaves it untouched.
+			dst := MustOpenEngine(index)
+			defer dst.Close()
+			dst.CompactionPlan = &mockPlanner{}
+
+			if err := dst.WritePointsString(
+				"cpu,host=A value=9.9 2000000000",
+				"mem,host=B value=4.2 1000000000",
+			); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+			if err := dst.WriteSnapshot(); err != nil {
+				t.Fatalf("failed to snapshot: %s", err.Error())
+			}
+
+			if err := dst.Import(&buf, ""); err != nil {
+				t.Fatalf("failed to import: %s", err.Error())
+			}
+
+			for _, tt := range []struct {
+				key   string
+				ts    int64
+				value float64
+				found bool
+			}{
+				{key: "cpu,host=A#!~#value", ts: 1000000000, value: 1.1, found: true},
+				{key: "cpu,host=A#!~#value", ts: 2000000000, value: 9.9, found: true},
+				{key: "disk,host=A#!~#value", ts: 1000000000, value: 3.1, found: true},
+				{key: "mem,host=B#!~#value", ts: 1000000000, value: 4.2, found: true},
+				{key: "mem,host=A#!~#value", ts: 1000000000, found: false},
+			} {
+				values, err := dst.FileStore.Read([]byte(tt.key), tt.ts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var found bool
+				for _, v := range values {
+					if v.UnixNano() == tt.ts && v.Value() == tt.value {
+						found = true
+					}
+				}
+				if found != tt.found {
+					t.Fatalf("%s at %d: got %v, exp found=%v", tt.key, tt.ts, values, tt.found)
+				}
+			}
+
+			for _, name := range []string{"cpu", "mem", "disk"} {
+				if ok, err := dst.MeasurementExists([]byte(name)); err != nil {
+					t.Fatal(err)
+				} else if !ok {
+					t.Fatalf("measurement %q missing from index", name)
+				}
+			}
+		})
+	}
+}
+
 func getExportData(exBuf *bytes.Buffer) (map[string]*bytes.Buffer, error) {
 
 	tr := tar.NewReader(exBuf)
@@ -0,0 +1,136 @@
+package tsm1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// SummarySampleRate is the approximate fraction (1/n) of keys whose block
+// checksums are included in a summary checksum.
+const SummarySampleRate = 16
+
+// blockChecksumReader is implemented by TSM files that can return the stored
+// checksum of a block without decoding it.
+type blockChecksumReader interface {
+	ReadBytes(e *IndexEntry, b []byte) (uint32, []byte, error)
+}
+
+// Summary returns a summary of the TSM data in the engine. Data still in the
+// cache is not included.
+func (e *Engine) Summary() (tsdb.ShardSummary, error) {
+	return e.FileStore.Summary()
+}
+
+// Summary returns a summary of the TSM files in the store.
+func (f *FileStore) Summary() (tsdb.ShardSummary, error) {
+	f.mu.RLock()
+	files := make([]TSMFile, len(f.files))
+	copy(files, f.files)
+	for _, file := range files {
+		file.Ref()
+	}
+	f.mu.RUnlock()
+
+	defer func() {
+		for _, file := range files {
+			file.Unref()
+		}
+	}()
+
+	return SummarizeTSMFiles(files)
+}
+
+// SummarizeTSMFiles computes a summary of files using only the TSM index and
+// the stored checksums of a sample of blocks, so no block is decoded. Two sets
+// of TSM files holding identically written blocks produce the same summary
+// regardless of how the blocks are split across files.
+func SummarizeTSMFiles(files []TSMFile) (tsdb.ShardSummary, error) {
+	var (
+		s          tsdb.ShardSummary
+		lastKey    []byte
+		lastSeries []byte
+		entries    []IndexEntry
+		sampled    []sampledBlock
+	)
+
+	h := fnv.New64a()
+	ki := newMergeKeyIterator(files, nil)
+	for ki.Next() {
+		// The merge iterator may repeat a key once only one file remains.
+		key, _ := ki.Read()
+		if bytes.Equal(key, lastKey) {
+			continue
+		}
+		lastKey = append(lastKey[:0], key...)
+
+		if seriesKey, _ := SeriesAndFieldFromCompositeKey(key); !bytes.Equal(seriesKey, lastSeries) {
+			s.SeriesN++
+			lastSeries = append(lastSeries[:0], seriesKey...)
+		}
+
+		sample := sampleKey(key)
+		sampled = sampled[:0]
+		for _, file := range files {
+			entries = file.ReadEntries(key, &entries)
+			s.BlockN += len(entries)
+			if !sample {
+				continue
+			}
+
+			r, ok := file.(blockChecksumReader)
+			if !ok {
+				continue
+			}
+			for i := range entries {
+				crc, _, err := r.ReadBytes(&entries[i], nil)
+				if err != nil {
+					return tsdb.ShardSummary{}, err
+				}
+				sampled = append(sampled, sampledBlock{minTime: entries[i].MinTime, maxTime: entries[i].MaxTime, checksum: crc})
+			}
+		}
+
+		if !sample {
+			continue
+		}
+
+		// Order blocks by time so the checksum does not depend on file order.
+		sort.Slice(sampled, func(i, j int) bool {
+			if sampled[i].minTime != sampled[j].minTime {
+				return sampled[i].minTime < sampled[j].minTime
+			} else if sampled[i].maxTime != sampled[j].maxTime {
+				return sampled[i].maxTime < sampled[j].maxTime
+			}
+			return sampled[i].checksum < sampled[j].checksum
+		})
+
+		h.Write(key)
+		var buf [20]byte
+		for _, b := range sampled {
+			binary.BigEndian.PutUint64(buf[0:8], uint64(b.minTime))
+			binary.BigEndian.PutUint64(buf[8:16], uint64(b.maxTime))
+			binary.BigEndian.PutUint32(buf[16:20], b.checksum)
+			h.Write(buf[:])
+		}
+	}
+
+	s.Checksum = h.Sum64()
+	return s, nil
+}
+
+// sampledBlock identifies a block included in a summary checksum.
+type sampledBlock struct {
+	minTime, maxTime int64
+	checksum         uint32
+}
+
+// sampleKey returns true if the blocks of key are included in the checksum.
+func sampleKey(key []byte) bool {
+	h := fnv.New32a()
+	h.Write(key)
+	return h.Sum32()%SummarySampleRate == 0
+}
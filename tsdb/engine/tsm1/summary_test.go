@@ -0,0 +1,77 @@
+package tsm1_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+)
+
+func TestSummarizeTSMFiles(t *testing.T) {
+	// summarize writes one TSM file per key, with value v for every key, and
+	// summarizes them.
+	summarize := func(t *testing.T, v float64, split bool) (n, blocks int, checksum uint64) {
+		dir := MustTempDir()
+		defer os.RemoveAll(dir)
+
+		var data []keyValues
+		for i := 0; i < 64; i++ {
+			key := fmt.Sprintf("cpu,host=%d#!~#value", i)
+			if split {
+				data = append(data,
+					keyValues{key, []tsm1.Value{tsm1.NewValue(0, v)}},
+					keyValues{key, []tsm1.Value{tsm1.NewValue(1, v)}},
+				)
+			} else {
+				data = append(data, keyValues{key, []tsm1.Value{tsm1.NewValue(0, v)}})
+			}
+			data = append(data, keyValues{fmt.Sprintf("cpu,host=%d#!~#idle", i), []tsm1.Value{tsm1.NewValue(0, v)}})
+		}
+
+		paths, err := newFiles(dir, data...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var files []tsm1.TSMFile
+		for _, path := range paths {
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := tsm1.NewTSMReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			files = append(files, r)
+		}
+
+		s, err := tsm1.SummarizeTSMFiles(files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.SeriesN, s.BlockN, s.Checksum
+	}
+
+	n, blocks, checksum := summarize(t, 1.0, false)
+	if n != 64 {
+		t.Fatalf("unexpected series count: got %d, exp %d", n, 64)
+	} else if blocks != 128 {
+		t.Fatalf("unexpected block count: got %d, exp %d", blocks, 128)
+	}
+
+	if _, _, got := summarize(t, 1.0, false); got != checksum {
+		t.Fatalf("checksum differs for identical data: got %x, exp %x", got, checksum)
+	}
+	if _, _, got := summarize(t, 2.0, false); got == checksum {
+		t.Fatal("checksum unchanged for different data")
+	}
+
+	if n, blocks, _ := summarize(t, 1.0, true); n != 64 {
+		t.Fatalf("unexpected series count: got %d, exp %d", n, 64)
+	} else if blocks != 192 {
+		t.Fatalf("unexpected block count: got %d, exp %d", blocks, 192)
+	}
+}
@@ -207,6 +207,52 @@ func TestIndex_DropMeasurement(t *testing.T) {
 	})
 }
 
+func TestIndex_DropSeriesList(t *testing.T) {
+	idx := MustOpenDefaultIndex()
+	defer idx.Close()
+
+	series := []Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "east"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "west"})},
+		{Name: []byte("mem"), Tags: models.NewTags(map[string]string{"region": "west"})},
+	}
+	if err := idx.CreateSeriesSliceIfNotExists(series); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop both cpu series at once.
+	var ids []uint64
+	var keys [][]byte
+	for _, s := range series[:2] {
+		sid := idx.Index.SeriesFile().SeriesID(s.Name, s.Tags, nil)
+		if sid == 0 {
+			t.Fatalf("got 0 series id for %s/%v", s.Name, s.Tags)
+		}
+		ids = append(ids, sid)
+		keys = append(keys, models.MakeKey(s.Name, s.Tags))
+	}
+	if err := idx.DropSeriesList(ids, keys); err != nil {
+		t.Fatal(err)
+	}
+
+	idx.Run(t, func(t *testing.T) {
+		// The measurement is not dropped with its series.
+		if v, err := idx.MeasurementHasSeries([]byte("cpu")); err != nil {
+			t.Fatal(err)
+		} else if v {
+			t.Fatal("expected no series for measurement")
+		}
+		if v, err := idx.MeasurementHasSeries([]byte("mem")); err != nil {
+			t.Fatal(err)
+		} else if !v {
+			t.Fatal("expected series for measurement")
+		}
+		if ss := idx.SeriesIDSet(); ss.Contains(ids[0]) || ss.Contains(ids[1]) {
+			t.Fatal("expected series ids to be removed")
+		}
+	})
+}
+
 func TestIndex_Open(t *testing.T) {
 	// Opening a fresh index should set the MANIFEST version to current version.
 	idx := NewDefaultIndex()
@@ -581,6 +581,36 @@ func (f *LogFile) DeleteSeriesID(id uint64) error {
 	return f.FlushAndSync()
 }
 
+// DeleteSeriesIDList adds a tombstone for each series id in bulk.
+func (f *LogFile) DeleteSeriesIDList(ids []uint64) error {
+	entries := make([]LogEntry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, LogEntry{Flag: LogEntrySeriesTombstoneFlag, SeriesID: id})
+	}
+	return f.appendTombstones(entries)
+}
+
+// appendTombstones adds a batch of tombstone entries to the log file, syncing
+// it once all of them are written.
+func (f *LogFile) appendTombstones(entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range entries {
+		if err := f.appendEntry(&entries[i]); err != nil {
+			return err
+		}
+		f.execEntry(&entries[i])
+	}
+
+	// Flush buffer and sync to disk.
+	return f.FlushAndSync()
+}
+
 // SeriesN returns the total number of series in the file.
 func (f *LogFile) SeriesN() (n uint64) {
 	f.mu.RLock()
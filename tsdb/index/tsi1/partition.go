@@ -586,38 +586,27 @@ func (p *Partition) DropMeasurement(name []byte) error {
 	}
 	defer fs.Release()
 
-	// Delete all keys and values.
+	// Tombstone all keys, values and series, and the measurement itself, in a
+	// single batch so the log file is synced once.
+	var entries []LogEntry
 	if kitr := fs.TagKeyIterator(name); kitr != nil {
 		for k := kitr.Next(); k != nil; k = kitr.Next() {
 			// Delete key if not already deleted.
 			if !k.Deleted() {
-				if err := func() error {
-					p.mu.RLock()
-					defer p.mu.RUnlock()
-					return p.activeLogFile.DeleteTagKey(name, k.Key())
-				}(); err != nil {
-					return err
-				}
+				entries = append(entries, LogEntry{Flag: LogEntryTagKeyTombstoneFlag, Name: name, Key: k.Key()})
 			}
 
 			// Delete each value in key.
 			if vitr := k.TagValueIterator(); vitr != nil {
 				for v := vitr.Next(); v != nil; v = vitr.Next() {
 					if !v.Deleted() {
-						if err := func() error {
-							p.mu.RLock()
-							defer p.mu.RUnlock()
-							return p.activeLogFile.DeleteTagValue(name, k.Key(), v.Value())
-						}(); err != nil {
-							return err
-						}
+						entries = append(entries, LogEntry{Flag: LogEntryTagValueTombstoneFlag, Name: name, Key: k.Key(), Value: v.Value()})
 					}
 				}
 			}
 		}
 	}
 
-	// Delete all series.
 	if itr := fs.MeasurementSeriesIDIterator(name); itr != nil {
 		defer itr.Close()
 		for {
@@ -627,24 +616,18 @@ func (p *Partition) DropMeasurement(name []byte) error {
 			} else if elem.SeriesID == 0 {
 				break
 			}
-			if err := func() error {
-				p.mu.RLock()
-				defer p.mu.RUnlock()
-				return p.activeLogFile.DeleteSeriesID(elem.SeriesID)
-			}(); err != nil {
-				return err
-			}
+			entries = append(entries, LogEntry{Flag: LogEntrySeriesTombstoneFlag, SeriesID: elem.SeriesID})
 		}
 		if err = itr.Close(); err != nil {
 			return err
 		}
 	}
 
-	// Mark measurement as deleted.
+	entries = append(entries, LogEntry{Flag: LogEntryMeasurementTombstoneFlag, Name: name})
 	if err := func() error {
 		p.mu.RLock()
 		defer p.mu.RUnlock()
-		return p.activeLogFile.DeleteMeasurement(name)
+		return p.activeLogFile.appendTombstones(entries)
 	}(); err != nil {
 		return err
 	}
@@ -706,6 +689,29 @@ func (p *Partition) DropSeries(seriesID uint64) error {
 	return p.CheckLogFile()
 }
 
+// DropSeriesList drops the provided series from the partition in bulk.
+func (p *Partition) DropSeriesList(seriesIDs []uint64) error {
+	if len(seriesIDs) == 0 {
+		return nil
+	}
+
+	// Delete series from index.
+	if err := func() error {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return p.activeLogFile.DeleteSeriesIDList(seriesIDs)
+	}(); err != nil {
+		return err
+	}
+
+	for _, id := range seriesIDs {
+		p.seriesIDSet.Remove(id)
+	}
+
+	// Swap log file, if necessary.
+	return p.CheckLogFile()
+}
+
 // MeasurementsSketches returns the two sketches for the partition by merging all
 // instances of the type sketch types in all the index files.
 func (p *Partition) MeasurementsSketches() (estimator.Sketch, estimator.Sketch, error) {
@@ -21,6 +21,11 @@ type SQLIndexExporter struct {
 
 	// Write schema, if true.
 	ShowSchema bool
+
+	// If BucketID is set, rows include the bucket and shard the index
+	// belongs to, so the indexes of several shards can be exported together.
+	BucketID string
+	ShardID  uint64
 }
 
 // NewSQLIndexExporter returns a new instance of SQLIndexExporter.
@@ -46,6 +51,56 @@ func (e *SQLIndexExporter) ExportIndex(idx *Index) error {
 
 	fmt.Fprintln(e.w, `BEGIN TRANSACTION;`)
 
+	if err := WalkIndex(idx, e.exportRow); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.w, "COMMIT;")
+	return nil
+}
+
+func (e *SQLIndexExporter) exportRow(row IndexRow) error {
+	var shardColumns, shardValues string
+	if e.BucketID != "" {
+		shardColumns = ", bucket_id, shard_id"
+		shardValues = fmt.Sprintf(", %s, %d", quoteSQL(e.BucketID), e.ShardID)
+	}
+
+	if row.Key == nil {
+		_, err := fmt.Fprintf(e.w, "INSERT INTO measurement_series (name, series_id%s) VALUES (%s, %d%s);\n",
+			shardColumns,
+			quoteSQL(string(row.Name)),
+			row.SeriesID,
+			shardValues)
+		return err
+	}
+
+	_, err := fmt.Fprintf(e.w,
+		"INSERT INTO tag_value_series (name, key, value, series_id%s) VALUES (%s, %s, %s, %d%s);\n",
+		shardColumns,
+		quoteSQL(string(row.Name)),
+		quoteSQL(string(row.Key)),
+		quoteSQL(string(row.Value)),
+		row.SeriesID,
+		shardValues,
+	)
+	return err
+}
+
+// IndexRow is a series of a measurement in an index, or, if Key is set, a
+// series of a tag value of the measurement.
+type IndexRow struct {
+	Name     []byte
+	Key      []byte
+	Value    []byte
+	SeriesID uint64
+}
+
+// WalkIndex calls fn with each row of the index: the series of each
+// measurement, followed by the series of each of its tag values. The keys of
+// measurements and fields are named _measurement and _field. Rows are read
+// from the index as they are walked, and are only valid during the call.
+func WalkIndex(idx *Index, fn func(IndexRow) error) error {
 	// Iterate over each measurement across all partitions.
 	itr, err := idx.MeasurementIterator()
 	if err != nil {
@@ -63,17 +118,15 @@ func (e *SQLIndexExporter) ExportIndex(idx *Index) error {
 			break
 		}
 
-		if err := e.exportMeasurement(idx, name); err != nil {
+		if err := walkMeasurement(idx, name, fn); err != nil {
 			return err
 		}
 	}
-
-	fmt.Fprintln(e.w, "COMMIT;")
 	return nil
 }
 
-func (e *SQLIndexExporter) exportMeasurement(idx *Index, name []byte) error {
-	if err := e.exportMeasurementSeries(idx, name); err != nil {
+func walkMeasurement(idx *Index, name []byte, fn func(IndexRow) error) error {
+	if err := walkMeasurementSeries(idx, name, fn); err != nil {
 		return err
 	}
 
@@ -93,14 +146,14 @@ func (e *SQLIndexExporter) exportMeasurement(idx *Index, name []byte) error {
 			break
 		}
 
-		if err := e.exportTagKey(idx, name, key); err != nil {
+		if err := walkTagKey(idx, name, key, fn); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (e *SQLIndexExporter) exportMeasurementSeries(idx *Index, name []byte) error {
+func walkMeasurementSeries(idx *Index, name []byte, fn func(IndexRow) error) error {
 	itr, err := idx.MeasurementSeriesIDIterator(name)
 	if err != nil {
 		return err
@@ -117,16 +170,14 @@ func (e *SQLIndexExporter) exportMeasurementSeries(idx *Index, name []byte) erro
 			break
 		}
 
-		if _, err := fmt.Fprintf(e.w, "INSERT INTO measurement_series (name, series_id) VALUES (%s, %d);\n",
-			quoteSQL(string(name)),
-			elem.SeriesID); err != nil {
+		if err := fn(IndexRow{Name: name, SeriesID: elem.SeriesID}); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (e *SQLIndexExporter) exportTagKey(idx *Index, name, key []byte) error {
+func walkTagKey(idx *Index, name, key []byte, fn func(IndexRow) error) error {
 	itr, err := idx.TagValueIterator(name, key)
 	if err != nil {
 		return err
@@ -143,14 +194,14 @@ func (e *SQLIndexExporter) exportTagKey(idx *Index, name, key []byte) error {
 			break
 		}
 
-		if err := e.exportTagValue(idx, name, key, value); err != nil {
+		if err := walkTagValue(idx, name, key, value, fn); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (e *SQLIndexExporter) exportTagValue(idx *Index, name, key, value []byte) error {
+func walkTagValue(idx *Index, name, key, value []byte, fn func(IndexRow) error) error {
 	itr, err := idx.TagValueSeriesIDIterator(name, key, value)
 	if err != nil {
 		return err
@@ -159,6 +210,13 @@ func (e *SQLIndexExporter) exportTagValue(idx *Index, name, key, value []byte) e
 	}
 	defer itr.Close()
 
+	// Replace special case keys for measurement & field.
+	if bytes.Equal(key, []byte{0}) {
+		key = []byte("_measurement")
+	} else if bytes.Equal(key, []byte{0xff}) {
+		key = []byte("_field")
+	}
+
 	for {
 		elem, err := itr.Next()
 		if err != nil {
@@ -167,20 +225,7 @@ func (e *SQLIndexExporter) exportTagValue(idx *Index, name, key, value []byte) e
 			break
 		}
 
-		// Replace special case keys for measurement & field.
-		if bytes.Equal(key, []byte{0}) {
-			key = []byte("_measurement")
-		} else if bytes.Equal(key, []byte{0xff}) {
-			key = []byte("_field")
-		}
-
-		if _, err := fmt.Fprintf(e.w,
-			"INSERT INTO tag_value_series (name, key, value, series_id) VALUES (%s, %s, %s, %d);\n",
-			quoteSQL(string(name)),
-			quoteSQL(string(key)),
-			quoteSQL(string(value)),
-			elem.SeriesID,
-		); err != nil {
+		if err := fn(IndexRow{Name: name, Key: key, Value: value, SeriesID: elem.SeriesID}); err != nil {
 			return err
 		}
 	}
@@ -196,23 +241,36 @@ func (e *SQLIndexExporter) initialize() error {
 	if !e.ShowSchema {
 		return nil
 	}
-	fmt.Fprintln(e.w, `
+	shard := e.shardSchema()
+	fmt.Fprintf(e.w, `
 CREATE TABLE IF NOT EXISTS measurement_series (
 	name      TEXT NOT NULL,
-	series_id INTEGER NOT NULL
+	series_id INTEGER NOT NULL%s
 );
 
 CREATE TABLE IF NOT EXISTS tag_value_series (
 	name      TEXT NOT NULL,
 	key       TEXT NOT NULL,
 	value     TEXT NOT NULL,
-	series_id INTEGER NOT NULL
+	series_id INTEGER NOT NULL%s
 );
-`[1:])
+
+`[1:], shard, shard)
 
 	return nil
 }
 
+// shardSchema returns the definitions of the bucket and shard columns of the
+// tables, if rows include them.
+func (e *SQLIndexExporter) shardSchema() string {
+	if e.BucketID == "" {
+		return ""
+	}
+	return `,
+	bucket_id TEXT NOT NULL,
+	shard_id  INTEGER NOT NULL`
+}
+
 func quoteSQL(s string) string {
 	return `'` + sqlReplacer.Replace(toValidUTF8(s)) + `'`
 }
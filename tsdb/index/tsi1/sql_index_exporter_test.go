@@ -49,3 +49,47 @@ COMMIT;
 		t.Fatalf("unexpected output:\ngot=%s\n--\nwant=%s", got, want)
 	}
 }
+
+func TestSQLIndexExporter_ExportIndex_Shard(t *testing.T) {
+	idx := MustOpenIndex(1)
+	defer idx.Close()
+
+	if err := idx.CreateSeriesSliceIfNotExists([]Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "east"})},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `
+CREATE TABLE IF NOT EXISTS measurement_series (
+	name      TEXT NOT NULL,
+	series_id INTEGER NOT NULL,
+	bucket_id TEXT NOT NULL,
+	shard_id  INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tag_value_series (
+	name      TEXT NOT NULL,
+	key       TEXT NOT NULL,
+	value     TEXT NOT NULL,
+	series_id INTEGER NOT NULL,
+	bucket_id TEXT NOT NULL,
+	shard_id  INTEGER NOT NULL
+);
+
+BEGIN TRANSACTION;
+INSERT INTO measurement_series (name, series_id, bucket_id, shard_id) VALUES ('cpu', 5, '000000000000000a', 3);
+INSERT INTO tag_value_series (name, key, value, series_id, bucket_id, shard_id) VALUES ('cpu', 'region', 'east', 5, '000000000000000a', 3);
+COMMIT;
+`[1:]
+
+	var buf bytes.Buffer
+	e := tsi1.NewSQLIndexExporter(&buf)
+	e.BucketID = "000000000000000a"
+	e.ShardID = 3
+	if err := e.ExportIndex(idx.Index); err != nil {
+		t.Fatal(err)
+	} else if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\ngot=%s\n--\nwant=%s", got, want)
+	}
+}
@@ -120,6 +120,10 @@ func (e PartialWriteError) Error() string {
 	return fmt.Sprintf("partial write: %s dropped=%d", e.Reason, e.Dropped)
 }
 
+// ShardLockFile is the name of the file in the directory of a shard that is
+// locked while the shard is open.
+const ShardLockFile = ".lock"
+
 // Shard represents a self-contained time series database. An inverted index of
 // the measurement and tag data is kept along with the raw time series data.
 // Data can be split across many shards. The query engine in TSDB is responsible
@@ -139,6 +143,9 @@ type Shard struct {
 	_engine Engine
 	index   Index
 	enabled bool
+	// lock is held while the shard is open, so that offline tools do not
+	// modify it.
+	lock *os.File
 
 	// expvar-based stats.
 	stats       *ShardStatistics
@@ -250,6 +257,21 @@ type ShardStatistics struct {
 	DiskBytes          int64
 }
 
+// Stats returns a copy of the shard's statistics.
+func (s *Shard) Stats() ShardStatistics {
+	return ShardStatistics{
+		WriteReq:           atomic.LoadInt64(&s.stats.WriteReq),
+		WriteReqOK:         atomic.LoadInt64(&s.stats.WriteReqOK),
+		WriteReqErr:        atomic.LoadInt64(&s.stats.WriteReqErr),
+		FieldsCreated:      atomic.LoadInt64(&s.stats.FieldsCreated),
+		WritePointsErr:     atomic.LoadInt64(&s.stats.WritePointsErr),
+		WritePointsDropped: atomic.LoadInt64(&s.stats.WritePointsDropped),
+		WritePointsOK:      atomic.LoadInt64(&s.stats.WritePointsOK),
+		BytesWritten:       atomic.LoadInt64(&s.stats.BytesWritten),
+		DiskBytes:          atomic.LoadInt64(&s.stats.DiskBytes),
+	}
+}
+
 // Statistics returns statistics for periodic monitoring.
 func (s *Shard) Statistics(tags map[string]string) []models.Statistic {
 	engine, err := s.Engine()
@@ -307,6 +329,17 @@ func (s *Shard) Open() error {
 			return nil
 		}
 
+		if err := os.MkdirAll(s.path, 0777); err != nil {
+			return err
+		}
+		lock, err := file.TryLock(filepath.Join(s.path, ShardLockFile))
+		if err == file.ErrLocked {
+			return fmt.Errorf("shard is locked by another process")
+		} else if err != nil {
+			return err
+		}
+		s.lock = lock
+
 		seriesIDSet := NewSeriesIDSet()
 
 		// Initialize underlying index.
@@ -372,12 +405,14 @@ func (s *Shard) Close() error {
 // indexes, unless clean is false.
 func (s *Shard) close() error {
 	if s._engine == nil {
+		s.unlock()
 		return nil
 	}
 
 	err := s._engine.Close()
 	if err == nil {
 		s._engine = nil
+		s.unlock()
 	}
 
 	if e := s.index.Close(); e == nil {
@@ -386,6 +421,14 @@ func (s *Shard) close() error {
 	return err
 }
 
+// unlock releases the lock held while the shard is open.
+func (s *Shard) unlock() {
+	if s.lock != nil {
+		s.lock.Close()
+		s.lock = nil
+	}
+}
+
 // IndexType returns the index version being used for this shard.
 //
 // IndexType returns the empty string if it is called before the shard is opened,
@@ -1070,6 +1113,16 @@ func (s *Shard) Backup(w io.Writer, basePath string, since time.Time) error {
 	return engine.Backup(w, basePath, since)
 }
 
+// BackupMeasurements backs up only the given measurements of the shard.
+// See Engine.BackupMeasurements for more details.
+func (s *Shard) BackupMeasurements(w io.Writer, basePath string, since time.Time, measurements [][]byte) error {
+	engine, err := s.Engine()
+	if err != nil {
+		return err
+	}
+	return engine.BackupMeasurements(w, basePath, since, measurements)
+}
+
 func (s *Shard) Export(w io.Writer, basePath string, start time.Time, end time.Time) error {
 	engine, err := s.Engine()
 	if err != nil {
@@ -1166,6 +1219,23 @@ func (s *Shard) Digest() (io.ReadCloser, int64, error) {
 	return engine.Digest()
 }
 
+// ShardSummary is a lightweight summary of the data in a shard, computed from
+// index metadata, used to compare copies of a shard.
+type ShardSummary struct {
+	SeriesN  int
+	BlockN   int
+	Checksum uint64
+}
+
+// Summary returns a summary of the shard's persisted data.
+func (s *Shard) Summary() (ShardSummary, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return ShardSummary{}, err
+	}
+	return engine.Summary()
+}
+
 // engine safely (under an RLock) returns a reference to the shard's Engine, or
 // an error if the Engine is closed, or the shard is currently disabled.
 //
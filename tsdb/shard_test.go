@@ -25,6 +25,7 @@ import (
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/deep"
+	"github.com/influxdata/influxdb/v2/pkg/file"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	_ "github.com/influxdata/influxdb/v2/tsdb/engine"
 	_ "github.com/influxdata/influxdb/v2/tsdb/index"
@@ -690,6 +691,40 @@ func TestShard_Close_RemoveIndex(t *testing.T) {
 	}
 }
 
+// Ensure a shard holds a lock on its directory while open.
+func TestShard_Open_Lock(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+	tmpShard := filepath.Join(tmpDir, "shard")
+	tmpWal := filepath.Join(tmpDir, "wal")
+
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+	opts.InmemIndex = inmem.NewIndex(filepath.Base(tmpDir), sfile.SeriesFile)
+
+	sh := tsdb.NewShard(1, tmpShard, tmpWal, sfile.SeriesFile, opts)
+	if err := sh.Open(); err != nil {
+		t.Fatalf("error opening shard: %s", err.Error())
+	}
+
+	lockPath := filepath.Join(tmpShard, tsdb.ShardLockFile)
+	if _, err := file.TryLock(lockPath); err != file.ErrLocked {
+		t.Fatalf("got error %v locking open shard, exp %v", err, file.ErrLocked)
+	}
+
+	if err := sh.Close(); err != nil {
+		t.Fatalf("error closing shard: %s", err.Error())
+	}
+	lock, err := file.TryLock(lockPath)
+	if err != nil {
+		t.Fatalf("error locking closed shard: %s", err.Error())
+	}
+	lock.Close()
+}
+
 // Ensure a shard can create iterators for its underlying data.
 func TestShard_CreateIterator_Ascending(t *testing.T) {
 	for _, index := range tsdb.RegisteredIndexes() {
@@ -599,6 +599,16 @@ func (s *Store) ShardDigest(id uint64) (io.ReadCloser, int64, error) {
 	return sh.Digest()
 }
 
+// ShardSummary returns a summary of the shard with the specified ID.
+func (s *Store) ShardSummary(id uint64) (ShardSummary, error) {
+	sh := s.Shard(id)
+	if sh == nil {
+		return ShardSummary{}, ErrShardNotFound
+	}
+
+	return sh.Summary()
+}
+
 // CreateShard creates a shard with the given id and retention policy on a database.
 func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error {
 	s.mu.Lock()
@@ -1217,6 +1227,29 @@ func (s *Store) BackupShard(id uint64, since time.Time, w io.Writer) error {
 	return shard.Backup(w, path, since)
 }
 
+// BackupShardMeasurements will get the shard and have the engine backup the
+// given measurements since the passed in time to the writer.
+func (s *Store) BackupShardMeasurements(id uint64, since time.Time, measurements []string, w io.Writer) error {
+	shard := s.Shard(id)
+	if shard == nil {
+		return &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  fmt.Sprintf("shard %d not found", id),
+		}
+	}
+
+	path, err := relativePath(s.path, shard.path)
+	if err != nil {
+		return err
+	}
+
+	names := make([][]byte, 0, len(measurements))
+	for _, name := range measurements {
+		names = append(names, []byte(name))
+	}
+	return shard.BackupMeasurements(w, path, since, names)
+}
+
 func (s *Store) ExportShard(id uint64, start time.Time, end time.Time, w io.Writer) error {
 	shard := s.Shard(id)
 	if shard == nil {
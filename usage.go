@@ -50,3 +50,59 @@ type Timespan struct {
 	Start time.Time `json:"start"`
 	Stop  time.Time `json:"stop"`
 }
+
+// BucketUsage is the storage used by a bucket, and the writes it received
+// during the range of a UsageReport.
+type BucketUsage struct {
+	OrgID         ID     `json:"orgID"`
+	BucketID      ID     `json:"bucketID"`
+	Bucket        string `json:"bucket"`
+	SeriesN       int64  `json:"seriesCount"`
+	DiskBytes     int64  `json:"diskBytes"`
+	WriteRequests int64  `json:"writeRequests"`
+	PointsWritten int64  `json:"pointsWritten"`
+}
+
+// OrgUsage is the usage of an organization summed over its buckets.
+type OrgUsage struct {
+	OrgID         ID            `json:"orgID"`
+	Org           string        `json:"org"`
+	SeriesN       int64         `json:"seriesCount"`
+	DiskBytes     int64         `json:"diskBytes"`
+	WriteRequests int64         `json:"writeRequests"`
+	PointsWritten int64         `json:"pointsWritten"`
+	Buckets       []BucketUsage `json:"buckets"`
+}
+
+// Add adds the usage of a bucket to the organization.
+func (o *OrgUsage) Add(b BucketUsage) {
+	o.SeriesN += b.SeriesN
+	o.DiskBytes += b.DiskBytes
+	o.WriteRequests += b.WriteRequests
+	o.PointsWritten += b.PointsWritten
+	o.Buckets = append(o.Buckets, b)
+}
+
+// UsageReport is the usage of organizations and their buckets.
+type UsageReport struct {
+	// Range is the range writes were counted over. It may be shorter than
+	// the requested range if write counts are not held for all of it.
+	Range Timespan   `json:"range"`
+	Orgs  []OrgUsage `json:"orgs"`
+}
+
+// PerSecond returns the rate of n over the range of the report.
+func (r *UsageReport) PerSecond(n int64) float64 {
+	d := r.Range.Stop.Sub(r.Range.Start).Seconds()
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d
+}
+
+// UsageReportService reports the storage used and the writes received by
+// organizations and buckets. Only the OrgID, BucketID and Range of the
+// filter are used.
+type UsageReportService interface {
+	UsageReport(ctx context.Context, filter UsageFilter) (*UsageReport, error)
+}
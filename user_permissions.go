@@ -0,0 +1,91 @@
+package influxdb
+
+import (
+	"context"
+)
+
+// Sources of the permissions of a user.
+const (
+	// PermissionSourceOwner grants permissions to the owners of a resource.
+	PermissionSourceOwner = "owner"
+	// PermissionSourceMember grants permissions to the members of a resource.
+	PermissionSourceMember = "member"
+	// PermissionSourceToken grants the permissions of an active token of the user.
+	PermissionSourceToken = "token"
+	// PermissionSourceUser grants every user the permissions over themselves.
+	PermissionSourceUser = "user"
+)
+
+// OpFindUserPermissions is the op for finding the effective permissions of a user.
+const OpFindUserPermissions = "FindUserPermissions"
+
+// UserPermissions is the effective permission set of a user, grouped by
+// organization and resource type.
+type UserPermissions struct {
+	UserID ID                `json:"userID"`
+	Orgs   []*OrgPermissions `json:"orgs"`
+}
+
+// OrgPermissions are the permissions of a user within an organization.
+// Permissions not scoped to an organization are grouped under a nil OrgID.
+type OrgPermissions struct {
+	OrgID *ID    `json:"orgID,omitempty"`
+	Name  string `json:"name,omitempty"`
+	// Role is the role of the user in the organization, if they are a member of it.
+	Role          UserType                   `json:"role,omitempty"`
+	ResourceTypes []*ResourceTypePermissions `json:"resourceTypes"`
+}
+
+// ResourceTypePermissions are the permissions of a user on the resources of a type.
+type ResourceTypePermissions struct {
+	Type      ResourceType           `json:"type"`
+	Resources []*ResourcePermissions `json:"resources"`
+}
+
+// ResourcePermissions are the actions a user may take on a resource, or on
+// all resources of the type in the organization if ID is nil, and the sources
+// that grant them. The actions of a resource the user owns or is a member of
+// may be empty when the organization already grants them.
+type ResourcePermissions struct {
+	ID      *ID      `json:"id,omitempty"`
+	Actions []Action `json:"actions"`
+	Sources []string `json:"sources"`
+}
+
+// PermissionSet returns the permissions of p.
+func (p *UserPermissions) PermissionSet() PermissionSet {
+	var ps PermissionSet
+	for _, org := range p.Orgs {
+		for _, rt := range org.ResourceTypes {
+			for _, r := range rt.Resources {
+				res := Resource{Type: rt.Type, ID: r.ID}
+				if r.ID == nil {
+					res.OrgID = org.OrgID
+				}
+				for _, a := range r.Actions {
+					ps = append(ps, Permission{Action: a, Resource: res})
+				}
+			}
+		}
+	}
+	return ps
+}
+
+// ResourceCount returns the number of resources p holds permissions on.
+func (p *UserPermissions) ResourceCount() int {
+	var n int
+	for _, org := range p.Orgs {
+		for _, rt := range org.ResourceTypes {
+			n += len(rt.Resources)
+		}
+	}
+	return n
+}
+
+// UserPermissionService computes the effective permissions of users.
+type UserPermissionService interface {
+	// FindUserPermissions returns the effective permissions of a user, from
+	// their organization memberships, the resources they own or are members
+	// of and their active tokens. Pagination is over resources.
+	FindUserPermissions(ctx context.Context, userID ID, opt FindOptions) (*UserPermissions, error)
+}
@@ -3,10 +3,12 @@ package precreator // import "github.com/influxdata/influxdb/v2/v1/services/prec
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/maintenance"
 	"go.uber.org/zap"
 )
 
@@ -23,6 +25,9 @@ type Service struct {
 	MetaClient interface {
 		PrecreateShardGroups(now, cutoff time.Time) error
 	}
+
+	// Maintenance records the schedule and checks of the service, if set.
+	Maintenance *maintenance.Reporter
 }
 
 // NewService returns an instance of the precreation service.
@@ -51,6 +56,7 @@ func (s *Service) Open(ctx context.Context) error {
 
 	ctx, s.cancel = context.WithCancel(ctx)
 
+	s.Maintenance.Schedule(true, s.checkInterval, time.Now().Add(s.checkInterval))
 	s.wg.Add(1)
 	go s.runPrecreation(ctx)
 	return nil
@@ -76,9 +82,15 @@ func (s *Service) runPrecreation(ctx context.Context) {
 	for {
 		select {
 		case <-time.After(s.checkInterval):
-			if err := s.precreate(time.Now().UTC()); err != nil {
+			now := time.Now().UTC()
+			s.Maintenance.StartRun(now)
+			err := s.precreate(now)
+			if err != nil {
 				s.Logger.Info("Failed to precreate shards", zap.Error(err))
 			}
+			end := time.Now()
+			summary := fmt.Sprintf("precreated the successors of shard groups ending before %s", now.Add(s.advancePeriod).Format(time.RFC3339))
+			s.Maintenance.FinishRun(end, summary, err, end.Add(s.checkInterval))
 		case <-ctx.Done():
 			s.Logger.Info("Terminating precreation service")
 			return
@@ -12,6 +12,10 @@ import (
 type Config struct {
 	Enabled       bool          `toml:"enabled"`
 	CheckInterval toml.Duration `toml:"check-interval"`
+	// MinRetention is the shortest retention period of a bucket. Shorter
+	// periods are raised to it, as data outlives them until the next check
+	// anyway. Zero sets no minimum.
+	MinRetention toml.Duration `toml:"min-retention"`
 }
 
 // NewConfig returns an instance of Config with defaults.
@@ -29,6 +33,10 @@ func (c Config) Validate() error {
 		return errors.New("check-interval must be positive")
 	}
 
+	if c.MinRetention < 0 {
+		return errors.New("min-retention must not be negative")
+	}
+
 	return nil
 }
 
@@ -43,5 +51,15 @@ func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 	return diagnostics.RowFromMap(map[string]interface{}{
 		"enabled":        true,
 		"check-interval": c.CheckInterval,
+		"min-retention":  c.MinRetention,
 	}), nil
 }
+
+// MinRetentionPeriod returns the shortest retention period of a bucket, or
+// zero if there is no minimum, as when the service is disabled.
+func (c Config) MinRetentionPeriod() time.Duration {
+	if !c.Enabled {
+		return 0
+	}
+	return time.Duration(c.MinRetention)
+}
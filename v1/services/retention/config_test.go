@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	itoml "github.com/influxdata/influxdb/v2/toml"
 	"github.com/influxdata/influxdb/v2/v1/services/retention"
 )
 
@@ -14,6 +15,7 @@ func TestConfig_Parse(t *testing.T) {
 	if _, err := toml.Decode(`
 enabled = true
 check-interval = "1s"
+min-retention = "1h"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -23,6 +25,13 @@ check-interval = "1s"
 		t.Fatalf("unexpected enabled state: %v", c.Enabled)
 	} else if time.Duration(c.CheckInterval) != time.Second {
 		t.Fatalf("unexpected check interval: %v", c.CheckInterval)
+	} else if c.MinRetentionPeriod() != time.Hour {
+		t.Fatalf("unexpected min retention: %v", c.MinRetention)
+	}
+
+	c.Enabled = false
+	if c.MinRetentionPeriod() != 0 {
+		t.Fatalf("unexpected min retention of disabled service: %v", c.MinRetentionPeriod())
 	}
 }
 
@@ -38,6 +47,12 @@ func TestConfig_Validate(t *testing.T) {
 		t.Fatal("expected error for check-interval = 0, got nil")
 	}
 
+	c = retention.NewConfig()
+	c.MinRetention = itoml.Duration(-time.Hour)
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for negative min-retention, got nil")
+	}
+
 	c = retention.NewConfig()
 	c.CheckInterval *= -1
 	if err := c.Validate(); err == nil {
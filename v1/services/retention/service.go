@@ -3,10 +3,13 @@ package retention // import "github.com/influxdata/influxdb/services/retention"
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/maintenance"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"go.uber.org/zap"
 )
@@ -22,6 +25,13 @@ type Service struct {
 		ShardIDs() []uint64
 		DeleteShard(shardID uint64) error
 	}
+	// BucketFinder is used to look up the delete protection of the bucket
+	// backing each database. If nil, all expired shard groups are deleted.
+	BucketFinder interface {
+		FindBucketByID(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error)
+	}
+	// Maintenance records the schedule and checks of the service, if set.
+	Maintenance *maintenance.Reporter
 
 	config Config
 	wg     sync.WaitGroup
@@ -40,10 +50,17 @@ func NewService(c Config) *Service {
 
 // Open starts retention policy enforcement.
 func (s *Service) Open(ctx context.Context) error {
-	if !s.config.Enabled || s.cancel != nil {
+	if s.cancel != nil {
 		return nil
 	}
 
+	interval := time.Duration(s.config.CheckInterval)
+	if !s.config.Enabled {
+		s.Maintenance.Schedule(false, interval, time.Time{})
+		return nil
+	}
+	s.Maintenance.Schedule(true, interval, time.Now().Add(interval))
+
 	s.logger.Info("Starting retention policy enforcement service",
 		logger.DurationLiteral("check_interval", time.Duration(s.config.CheckInterval)))
 
@@ -86,7 +103,8 @@ func (s *Service) run(ctx context.Context) {
 		case <-ctx.Done():
 			return
 
-		case <-ticker.C:
+		case tick := <-ticker.C:
+			s.Maintenance.StartRun(time.Now())
 			log, logEnd := logger.NewOperation(context.Background(), s.logger, "Retention policy deletion check", "retention_delete_check")
 
 			type deletionInfo struct {
@@ -100,8 +118,10 @@ func (s *Service) run(ctx context.Context) {
 			// Without the message, they may see the error message and assume they
 			// have to do it manually.
 			var retryNeeded bool
+			var deletedGroups, deletedShards, failures int
 			dbs := s.MetaClient.Databases()
 			for _, d := range dbs {
+				protected := s.isDeleteProtected(ctx, d.Name)
 				for _, r := range d.RetentionPolicies {
 					// Build list of already deleted shards.
 					for _, g := range r.DeletedShardGroups() {
@@ -110,6 +130,10 @@ func (s *Service) run(ctx context.Context) {
 						}
 					}
 
+					if protected {
+						continue
+					}
+
 					// Determine all shards that have expired and need to be deleted.
 					for _, g := range r.ExpiredShardGroups(time.Now().UTC()) {
 						if err := s.MetaClient.DeleteShardGroup(d.Name, r.Name, g.ID); err != nil {
@@ -119,8 +143,10 @@ func (s *Service) run(ctx context.Context) {
 								logger.RetentionPolicy(r.Name),
 								zap.Error(err))
 							retryNeeded = true
+							failures++
 							continue
 						}
+						deletedGroups++
 
 						log.Info("Deleted shard group",
 							logger.Database(d.Name),
@@ -145,8 +171,10 @@ func (s *Service) run(ctx context.Context) {
 							logger.RetentionPolicy(info.rp),
 							zap.Error(err))
 						retryNeeded = true
+						failures++
 						continue
 					}
+					deletedShards++
 					log.Info("Deleted shard",
 						logger.Database(info.db),
 						logger.Shard(id),
@@ -157,6 +185,7 @@ func (s *Service) run(ctx context.Context) {
 			if err := s.MetaClient.PruneShardGroups(); err != nil {
 				log.Info("Problem pruning shard groups", zap.Error(err))
 				retryNeeded = true
+				failures++
 			}
 
 			if retryNeeded {
@@ -164,6 +193,38 @@ func (s *Service) run(ctx context.Context) {
 			}
 
 			logEnd()
+
+			var err error
+			if failures > 0 {
+				err = fmt.Errorf("%d operations failed and will be retried on the next check", failures)
+			}
+			summary := fmt.Sprintf("deleted %d shard groups and %d shards", deletedGroups, deletedShards)
+			s.Maintenance.FinishRun(time.Now(), summary, err, tick.Add(time.Duration(s.config.CheckInterval)))
 		}
 	}
 }
+
+// isDeleteProtected reports whether the bucket backing the database is delete
+// protected and does not allow retention to bypass the protection.
+func (s *Service) isDeleteProtected(ctx context.Context, database string) bool {
+	if s.BucketFinder == nil {
+		return false
+	}
+
+	id, err := influxdb.IDFromString(database)
+	if err != nil {
+		return false
+	}
+
+	b, err := s.BucketFinder.FindBucketByID(ctx, *id)
+	if err != nil {
+		// An unknown bucket has no protection to honor.
+		return false
+	}
+
+	if b.DeleteProtected && !b.RetentionBypassesDeleteProtection {
+		s.logger.Debug("Skipping retention for delete protected bucket", logger.Database(database))
+		return true
+	}
+	return false
+}
@@ -3,14 +3,18 @@ package retention_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/internal"
 	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/maintenance"
+	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/toml"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"github.com/influxdata/influxdb/v2/v1/services/retention"
@@ -208,6 +212,166 @@ func TestService_CheckShards(t *testing.T) {
 	}
 }
 
+func TestService_CheckShards_DeleteProtected(t *testing.T) {
+	now := time.Now()
+	expired := func(db string) meta.DatabaseInfo {
+		return meta.DatabaseInfo{
+			Name:                   db,
+			DefaultRetentionPolicy: "autogen",
+			RetentionPolicies: []meta.RetentionPolicyInfo{
+				{
+					Name:               "autogen",
+					Duration:           time.Hour,
+					ShardGroupDuration: time.Hour,
+					ShardGroups: []meta.ShardGroupInfo{
+						{
+							ID:        1,
+							StartTime: now.Add(-3 * time.Hour),
+							EndTime:   now.Add(-2 * time.Hour),
+						},
+					},
+				},
+			},
+		}
+	}
+
+	protectedID, bypassID, unknownID := influxdb.ID(1), influxdb.ID(2), influxdb.ID(3)
+	data := []meta.DatabaseInfo{expired(protectedID.String()), expired(bypassID.String()), expired(unknownID.String())}
+
+	config := retention.NewConfig()
+	config.CheckInterval = toml.Duration(10 * time.Millisecond)
+	s := NewService(config)
+	s.MetaClient.DatabasesFn = func() []meta.DatabaseInfo {
+		return data
+	}
+
+	bucketSvc := mock.NewBucketService()
+	bucketSvc.FindBucketByIDFn = func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+		switch id {
+		case protectedID:
+			return &influxdb.Bucket{ID: id, DeleteProtected: true}, nil
+		case bypassID:
+			return &influxdb.Bucket{ID: id, DeleteProtected: true, RetentionBypassesDeleteProtection: true}, nil
+		}
+		return nil, &influxdb.Error{Code: influxdb.ENotFound}
+	}
+	s.Service.BucketFinder = bucketSvc
+
+	var mu sync.Mutex
+	deleted := make(map[string]struct{})
+	s.MetaClient.DeleteShardGroupFn = func(database, policy string, id uint64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deleted[database] = struct{}{}
+		return nil
+	}
+
+	checked := make(chan struct{})
+	var once sync.Once
+	s.MetaClient.PruneShardGroupsFn = func() error {
+		once.Do(func() { close(checked) })
+		return nil
+	}
+	s.TSDBStore.ShardIDsFn = func() []uint64 { return nil }
+
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("unexpected open error: %s", err)
+	}
+
+	select {
+	case <-checked:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for retention check")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected close error: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := deleted, map[string]struct{}{
+		bypassID.String():  {},
+		unknownID.String(): {},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected deleted databases: got=%#v want=%#v", got, want)
+	}
+}
+
+func TestService_Maintenance(t *testing.T) {
+	now := time.Now()
+	data := []meta.DatabaseInfo{{
+		Name:                   "db0",
+		DefaultRetentionPolicy: "autogen",
+		RetentionPolicies: []meta.RetentionPolicyInfo{
+			{
+				Name:               "autogen",
+				Duration:           time.Hour,
+				ShardGroupDuration: time.Hour,
+				ShardGroups: []meta.ShardGroupInfo{
+					{
+						ID:        1,
+						StartTime: now.Add(-3 * time.Hour),
+						EndTime:   now.Add(-2 * time.Hour),
+						Shards:    []meta.ShardInfo{{ID: 2}},
+					},
+				},
+			},
+		},
+	}}
+
+	config := retention.NewConfig()
+	config.CheckInterval = toml.Duration(10 * time.Millisecond)
+	s := NewService(config)
+	s.MetaClient.DatabasesFn = func() []meta.DatabaseInfo { return data }
+	s.MetaClient.DeleteShardGroupFn = func(database, policy string, id uint64) error { return nil }
+	s.TSDBStore.ShardIDsFn = func() []uint64 { return []uint64{2} }
+	s.TSDBStore.DeleteShardFn = func(id uint64) error { return nil }
+
+	checked := make(chan struct{})
+	var once sync.Once
+	s.MetaClient.PruneShardGroupsFn = func() error {
+		once.Do(func() { close(checked) })
+		return errors.New("prune failed")
+	}
+
+	registry := maintenance.NewRegistry()
+	s.Service.Maintenance = registry.Register("retention")
+
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("unexpected open error: %s", err)
+	}
+	select {
+	case <-checked:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for retention check")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected close error: %s", err)
+	}
+
+	statuses, err := registry.MaintenanceServices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("unexpected statuses: %#v", statuses)
+	}
+	status := statuses[0]
+	if !status.Enabled || status.Interval.Duration != 10*time.Millisecond || status.NextRun == nil {
+		t.Errorf("unexpected schedule: %#v", status)
+	}
+	run := status.LastRun
+	if run == nil || run.End == nil {
+		t.Fatalf("expected a finished run, got %#v", run)
+	}
+	if got, want := run.Outcome, influxdb.MaintenanceRunFailed; got != want {
+		t.Errorf("unexpected outcome: got=%q want=%q", got, want)
+	}
+	if got, want := run.Summary, "deleted 1 shard groups and 1 shards: 1 operations failed and will be retried on the next check"; got != want {
+		t.Errorf("unexpected summary: got=%q want=%q", got, want)
+	}
+}
+
 // This reproduces https://github.com/influxdata/influxdb/issues/8819
 func TestService_8819_repro(t *testing.T) {
 	for i := 0; i < 1000; i++ {